@@ -0,0 +1,58 @@
+// Package openapi embeds the generated OpenAPI document and validates requests against it.
+package openapi
+
+//go:generate go run ../../cmd/openapi-gen -root ../.. -out openapi.json
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var specJSON []byte
+
+// document is the decoded spec, used by the request validator
+var document map[string]interface{}
+
+// operations indexes spec operations by "METHOD /gin/:style/path"
+var operations = map[string]map[string]interface{}{}
+
+func init() {
+	if err := json.Unmarshal(specJSON, &document); err != nil {
+		log.Printf("⚠️ Failed to decode embedded OpenAPI spec: %v", err)
+		return
+	}
+
+	paths, _ := document["paths"].(map[string]interface{})
+	for path, item := range paths {
+		methods, _ := item.(map[string]interface{})
+		for method, operation := range methods {
+			op, ok := operation.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			operations[strings.ToUpper(method)+" "+ginPath(path)] = op
+		}
+	}
+}
+
+// ginPath converts /services/{id} into gin's /services/:id
+func ginPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ServeSpec serves the OpenAPI document
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", specJSON)
+}
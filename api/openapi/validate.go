@@ -0,0 +1,251 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest rejects requests whose query parameters or JSON body do not match
+// the operation's schema. Set OPENAPI_VALIDATION=false to disable it.
+func ValidateRequest() gin.HandlerFunc {
+	enabled := !strings.EqualFold(strings.TrimSpace(os.Getenv("OPENAPI_VALIDATION")), "false")
+
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		operation, ok := operations[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if err := validateParameters(c, operation); err != nil {
+			abortInvalid(c, err)
+			return
+		}
+		if err := validateBody(c, operation); err != nil {
+			abortInvalid(c, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func abortInvalid(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error": "request validation failed: " + err.Error(),
+	})
+}
+
+// validateParameters checks required and typed query parameters
+func validateParameters(c *gin.Context, operation map[string]interface{}) error {
+	parameters, _ := operation["parameters"].([]interface{})
+	for _, p := range parameters {
+		parameter, _ := p.(map[string]interface{})
+		if parameter["in"] != "query" {
+			continue
+		}
+
+		name, _ := parameter["name"].(string)
+		value, present := c.GetQuery(name)
+		if !present || value == "" {
+			if required, _ := parameter["required"].(bool); required {
+				return fmt.Errorf("query parameter %q is required", name)
+			}
+			continue
+		}
+
+		schema, _ := parameter["schema"].(map[string]interface{})
+		switch schema["type"] {
+		case "integer":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return fmt.Errorf("query parameter %q must be an integer", name)
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("query parameter %q must be a number", name)
+			}
+		case "boolean":
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("query parameter %q must be a boolean", name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBody checks a JSON body against the request body schema. Bodies that are
+// not valid JSON are left to the handler's own binding.
+func validateBody(c *gin.Context, operation map[string]interface{}) error {
+	requestBody, ok := operation["requestBody"].(map[string]interface{})
+	if !ok || c.Request.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required, _ := requestBody["required"].(bool); required {
+			return fmt.Errorf("request body is required")
+		}
+		return nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+
+	content, _ := requestBody["content"].(map[string]interface{})
+	media, _ := content["application/json"].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+	return validateValue(value, schema, "body")
+}
+
+// resolve follows a local $ref
+func resolve(schema map[string]interface{}) map[string]interface{} {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	components, _ := document["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	resolved, _ := schemas[name].(map[string]interface{})
+	return resolved
+}
+
+// validateValue checks value against the subset of JSON Schema the generator emits
+func validateValue(value interface{}, schema map[string]interface{}, path string) error {
+	schema = resolve(schema)
+	if len(schema) == 0 || value == nil {
+		return nil
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			subSchema, _ := sub.(map[string]interface{})
+			if err := validateValue(value, subSchema, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		matched := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s must be one of %v", path, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+		return validateObject(object, schema, path)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", path)
+		}
+		if min, ok := number(schema["minItems"]); ok && float64(len(array)) < min {
+			return fmt.Errorf("%s must contain at least %v items", path, min)
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(array)) > max {
+			return fmt.Errorf("%s must contain at most %v items", path, max)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			if err := validateValue(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		length := float64(len([]rune(str)))
+		if min, ok := number(schema["minLength"]); ok && length < min {
+			return fmt.Errorf("%s must be at least %v characters", path, min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && length > max {
+			return fmt.Errorf("%s must be at most %v characters", path, max)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be a %s", path, schema["type"])
+		}
+		f, err := n.Float64()
+		if err != nil || (schema["type"] == "integer" && f != math.Trunc(f)) {
+			return fmt.Errorf("%s must be a %s", path, schema["type"])
+		}
+		if min, ok := number(schema["minimum"]); ok && f < min {
+			return fmt.Errorf("%s must be at least %v", path, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && f > max {
+			return fmt.Errorf("%s must be at most %v", path, max)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	}
+	return nil
+}
+
+func validateObject(object map[string]interface{}, schema map[string]interface{}, path string) error {
+	required, _ := schema["required"].([]interface{})
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s.%s is required", path, name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, _ := schema["additionalProperties"].(map[string]interface{})
+	for name, fieldValue := range object {
+		propertySchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			propertySchema = additional
+		}
+		if err := validateValue(fieldValue, propertySchema, path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// ActivityController handles the project activity feed
+type ActivityController struct {
+	activityService *services.ActivityService
+}
+
+// NewActivityController creates a new activity controller
+func NewActivityController() *ActivityController {
+	return &ActivityController{
+		activityService: services.NewActivityService(),
+	}
+}
+
+// RegisterRoutes registers the activity feed routes
+func (c *ActivityController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/activity", c.GetProjectActivity)
+	}
+}
+
+// GetProjectActivity returns what recently changed in a project
+// @Summary Get the activity feed of a project
+// @Description Deployments, config changes, scaling changes and incidents of the project's services, newest first. Pass nextCursor from a page as cursor to read the next one.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Items per page (default 50, max 100)"
+// @Param kinds query string false "Comma-separated kinds: deployment, config_change, scaling, incident"
+// @Success 200 {object} object{data=dto.ActivityFeed}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/activity [get]
+func (c *ActivityController) GetProjectActivity(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	var kinds []string
+	if value := ctx.Query("kinds"); value != "" {
+		kinds = strings.Split(value, ",")
+	}
+
+	feed, err := c.activityService.GetProjectActivity(ctx.Param("id"), ctx.Query("cursor"), limit, kinds, userID, isAdmin)
+	if errors.Is(err, services.ErrInvalidActivityCursor) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": feed,
+	})
+}
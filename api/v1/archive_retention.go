@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetArchiveRetention returns the retention policy of archived build logs and artifacts
+// @Summary Get the archive retention policy (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=models.ArchiveRetentionSettings}
+// @Router /admin/archive-retention [get]
+func GetArchiveRetention(c *gin.Context) {
+	settings, err := services.NewArchiveRetentionService().GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateArchiveRetention changes the retention policy of archived build logs and artifacts
+// @Summary Set the archive retention policy (admin only)
+// @Description Build logs and artifacts archived in the artifact store are deleted once older than their retention period in days. Projects whose archives exceed their quota (their own, or defaultProjectQuotaMb) lose their oldest archives first. The archives of each service's latest deployment are always kept. 0 disables a limit. The purger applies the policy every ARCHIVE_PURGE_INTERVAL_MINUTES (default 60).
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ArchiveRetentionSettingsRequest true "Fields to change"
+// @Success 200 {object} object{data=models.ArchiveRetentionSettings}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /admin/archive-retention [put]
+func UpdateArchiveRetention(c *gin.Context) {
+	var req dto.ArchiveRetentionSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	settings, err := services.NewArchiveRetentionService().UpdateSettings(req, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// PurgeArchives applies the retention policy now
+// @Summary Run the archive purger now (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.ArchivePurgeReport}
+// @Failure 500 {object} object{error=string}
+// @Router /admin/archive-retention/purge [post]
+func PurgeArchives(c *gin.Context) {
+	report, err := services.NewArchiveRetentionService().RunOnce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GetArchiveUsageOverview lists the archive usage of every project
+// @Summary Get the archive usage of all projects (admin only)
+// @Description Size of the archived build logs and artifacts of each project against its quota, largest first.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.ArchiveUsageOverview}
+// @Router /admin/archive-usage [get]
+func GetArchiveUsageOverview(c *gin.Context) {
+	overview, err := services.NewArchiveRetentionService().GetUsageOverview()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": overview})
+}
+
+// GetProjectArchiveUsage returns the archive usage of a project
+// @Summary Get the archive usage of a project
+// @Description Size of the project's archived build logs and artifacts against its quota. Over the quota, the oldest archives are deleted on the next purger run.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=dto.ProjectArchiveUsage}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/archive-usage [get]
+func GetProjectArchiveUsage(c *gin.Context) {
+	userID, isAdmin := getRequestUser(c)
+
+	usage, err := services.NewArchiveRetentionService().GetProjectUsage(c.Param("id"), userID, isAdmin)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+// SaveArchiveQuota sets the archive size quota of a project
+// @Summary Set the archive quota of a project (admin only)
+// @Description Replaces the default project quota for this project; 0 keeps its archives regardless of size.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param request body dto.ArchiveQuotaRequest true "Quota in MB"
+// @Success 200 {object} object{data=models.ArchiveQuota}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/projects/{id}/archive-quota [put]
+func SaveArchiveQuota(c *gin.Context) {
+	var req dto.ArchiveQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	quota, err := services.NewArchiveRetentionService().SaveQuota(c.Param("id"), req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quota})
+}
+
+// DeleteArchiveQuota puts a project back on the default archive quota
+// @Summary Remove the archive quota of a project (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/projects/{id}/archive-quota [delete]
+func DeleteArchiveQuota(c *gin.Context) {
+	err := services.NewArchiveRetentionService().DeleteQuota(c.Param("id"))
+	if errors.Is(err, services.ErrArchiveQuotaNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Archive quota removed"}})
+}
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
@@ -9,6 +12,14 @@ import (
 )
 
 // Register handles user registration
+// @Summary Register a new user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body dto.RegisterRequest true "Registration data"
+// @Success 201 {object} object{status=string,message=string,user=models.User}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Router /auth/register [post]
 func Register(c *gin.Context) {
 	var req dto.RegisterRequest
 
@@ -41,6 +52,16 @@ func Register(c *gin.Context) {
 }
 
 // Login handles user authentication
+// @Summary Log in and obtain a JWT
+// @Description The token is returned in the body and also set as the access_token HttpOnly cookie. Accounts with two-factor authentication fail with twoFactorRequired until twoFactorCode or recoveryCode is sent. Repeated failures lock the account, and addresses with many failures are throttled, both answered with 429 and Retry-After. When twoFactorSetupRequired is set, the token only works for /auth/me and /auth/2fa until 2FA is enabled.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body dto.LoginRequest true "Login credentials"
+// @Success 200 {object} object{status=string,data=dto.AuthResponse}
+// @Failure 401 {object} object{status=string,message=string,error=string,twoFactorRequired=bool}
+// @Failure 429 {object} object{status=string,message=string,error=string}
+// @Router /auth/login [post]
 func Login(c *gin.Context) {
 	var req dto.LoginRequest
 
@@ -55,7 +76,26 @@ func Login(c *gin.Context) {
 	}
 
 	// Authenticate user
-	authResponse, err := services.Login(req)
+	authResponse, err := services.Login(req, services.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	var lockedErr *services.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		c.Header("Retry-After", fmt.Sprint(int(time.Until(lockedErr.Until).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"status":  "error",
+			"message": "Authentication failed",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrTwoFactorRequired) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":            "error",
+			"message":           "Two-factor code required",
+			"error":             err.Error(),
+			"twoFactorRequired": true,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
@@ -84,6 +124,12 @@ func Login(c *gin.Context) {
 }
 
 // GetCurrentUser returns the currently authenticated user's profile
+// @Summary Get the authenticated user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,user=models.User,impersonatedBy=string}
+// @Router /auth/me [get]
 func GetCurrentUser(c *gin.Context) {
 	// Get user ID from the context (set by the AuthMiddleware)
 	userID, exists := c.Get("userId")
@@ -106,9 +152,13 @@ func GetCurrentUser(c *gin.Context) {
 		return
 	}
 	
-	// Return user profile
-	c.JSON(http.StatusOK, gin.H{
+	// Return user profile; impersonatedBy tells the dashboard to show the impersonation banner
+	response := gin.H{
 		"status": "success",
 		"user":   user,
-	})
+	}
+	if impersonator := c.GetString("impersonatorEmail"); impersonator != "" {
+		response["impersonatedBy"] = impersonator
+	}
+	c.JSON(http.StatusOK, response)
 }
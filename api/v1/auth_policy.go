@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"gorm.io/gorm"
+)
+
+// GetAuthPolicy returns the login protection policy
+// @Summary Get the login protection policy (admin only)
+// @Description requireTwoFactor is optional, admins or all. Accounts lock for lockoutMinutes after maxFailedLogins consecutive failures; addresses with maxFailedLoginsPerIp failures within throttleWindowMinutes are throttled. 0 disables a limit.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=models.AuthPolicy}
+// @Router /admin/auth-policy [get]
+func GetAuthPolicy(c *gin.Context) {
+	policy, err := services.NewLoginProtectionService().GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdateAuthPolicy changes the login protection policy
+// @Summary Configure login protection (admin only)
+// @Description Requiring 2FA takes effect at each user's next login: users without it get a session that can only set it up.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.AuthPolicyUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=models.AuthPolicy}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/auth-policy [put]
+func UpdateAuthPolicy(c *gin.Context) {
+	var req dto.AuthPolicyUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	policy, err := services.NewLoginProtectionService().UpdatePolicy(req, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// ListLoginAttempts lists the login audit trail, newest first
+// @Summary List login attempts (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "Only attempts for this email address"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100)"
+// @Success 200 {object} object{data=dto.LoginAttemptListResponse}
+// @Router /admin/login-attempts [get]
+func ListLoginAttempts(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	attempts, err := services.NewLoginProtectionService().ListAttempts(c.Query("email"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": attempts})
+}
+
+// UnlockUser lifts the lockout of an account after failed logins
+// @Summary Unlock a user account (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	err := services.NewLoginProtectionService().UnlockUser(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// ResetUserTwoFactor turns off 2FA of a user who lost their authenticator and recovery codes
+// @Summary Reset a user's two-factor authentication (admin only)
+// @Description Deletes the TOTP secret and recovery codes. If the auth policy requires 2FA, the user sets it up again at the next login.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/two-factor [delete]
+func ResetUserTwoFactor(c *gin.Context) {
+	err := services.NewTwoFactorService().Reset(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetProjectBuildUsage returns the build minutes a project consumed per month
+// @Summary Get the build usage of a project
+// @Description Build minutes, build count and peak build concurrency per calendar month (UTC), newest first, with the running and queued builds and the project's build quota.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param months query int false "Months of history (default 6, max 24)"
+// @Success 200 {object} object{data=dto.BuildUsageReport}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/build-usage [get]
+func GetProjectBuildUsage(c *gin.Context) {
+	userID, isAdmin := getRequestUser(c)
+
+	months, err := strconv.Atoi(c.DefaultQuery("months", "6"))
+	if err != nil {
+		months = 6
+	}
+
+	report, err := services.NewBuildUsageService().GetProjectUsage(c.Param("id"), months, userID, isAdmin)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GetBuildUsageOverview lists the build usage of every project in a month
+// @Summary Get the build usage of all projects (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param month query string false "Month as YYYY-MM (default: current month)"
+// @Success 200 {object} object{data=dto.BuildUsageOverview}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/build-usage [get]
+func GetBuildUsageOverview(c *gin.Context) {
+	overview, err := services.NewBuildUsageService().GetUsageOverview(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": overview})
+}
+
+// SaveBuildQuota sets the build caps of a project
+// @Summary Set the build quota of a project (admin only)
+// @Description Builds beyond maxConcurrentBuilds wait in a queue (deployment status queued). Past monthlyMinutesSoftLimit builds run one at a time; past monthlyMinutesHardLimit new deployments are rejected with 429 until the next month.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param request body dto.BuildQuotaRequest true "Build caps"
+// @Success 200 {object} object{data=models.BuildQuota}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/projects/{id}/build-quota [put]
+func SaveBuildQuota(c *gin.Context) {
+	var req dto.BuildQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	quota, err := services.NewBuildUsageService().SaveQuota(c.Param("id"), req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quota})
+}
+
+// DeleteBuildQuota removes the build caps of a project
+// @Summary Remove the build quota of a project (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/projects/{id}/build-quota [delete]
+func DeleteBuildQuota(c *gin.Context) {
+	err := services.NewBuildUsageService().DeleteQuota(c.Param("id"))
+	if errors.Is(err, services.ErrBuildQuotaNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Build quota removed"}})
+}
@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetCapacityForecast projects cluster resource exhaustion from the recorded usage history
+// @Summary Forecast cluster capacity (admin only)
+// @Description Fits a linear trend through the usage samples recorded every few minutes and projects when CPU and memory requests, actual usage and node storage reach capacity. Also lists the services whose per-pod p95 usage is furthest from their limits.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "History window in days (1-30, default 14)"
+// @Success 200 {object} object{data=dto.CapacityForecast}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/capacity/forecast [get]
+func GetCapacityForecast(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 30 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 30"})
+			return
+		}
+		days = parsed
+	}
+
+	forecast, err := services.NewCapacityService().GetCapacityForecast(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to forecast capacity: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": forecast})
+}
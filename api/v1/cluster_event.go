@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// StreamClusterEvents streams important cluster events to admins
+// Node NotReady and pressure conditions, volume provisioning failures, cert-manager
+// failures and unschedulable pods across all namespaces, in Server-Sent Events format
+// @Summary Stream important cluster events (admin only)
+// @Description Sends the important events of the last hour first, then new ones as they happen. Each event is a JSON dto.ClusterEvent. Use severity=critical to only receive critical events.
+// @Tags admin
+// @Produce event-stream
+// @Security BearerAuth
+// @Param severity query string false "Minimum severity (warning or critical)"
+// @Success 200 {string} string "Server-Sent Events"
+// @Failure 400 {object} object{error=string}
+// @Router /admin/events/stream [get]
+func StreamClusterEvents(c *gin.Context) {
+	clusterEventService := services.NewClusterEventService()
+
+	severity := c.Query("severity")
+	if err := clusterEventService.ValidateSeverity(severity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Set headers for SSE streaming
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+
+	if err := clusterEventService.StreamClusterEvents(severity, c.Writer, c.Request.Context().Done()); err != nil {
+		// Headers are already sent, so report the error as an event
+		utils.WriteSSEMessage(c.Writer, "error: "+err.Error())
+	}
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// ConsoleController handles the in-browser database console endpoints
+type ConsoleController struct {
+	consoleService *services.ConsoleService
+}
+
+// NewConsoleController creates a new console controller
+func NewConsoleController() *ConsoleController {
+	return &ConsoleController{
+		consoleService: services.NewConsoleService(),
+	}
+}
+
+// RegisterRoutes registers console routes
+func (c *ConsoleController) RegisterRoutes(router *gin.RouterGroup) {
+	servicesGroup := router.Group("/services")
+	{
+		servicesGroup.POST("/:id/console", c.ExecuteQuery)
+		servicesGroup.GET("/:id/console/audit", c.GetAuditLog)
+	}
+}
+
+// ExecuteQuery runs a short query against a managed database
+// @Summary Run a query against a managed database
+// @Description PostgreSQL queries run in a read-only transaction; Redis runs read-only commands only, e.g. GET, HGETALL, SCAN or INFO.
+// @Tags console
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param query body dto.ConsoleQueryRequest true "Query"
+// @Success 200 {object} object{data=dto.ConsoleQueryResult}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/console [post]
+func (c *ConsoleController) ExecuteQuery(ctx *gin.Context) {
+	serviceID := ctx.Param("id")
+
+	userIDValue, _ := ctx.Get("userId")
+	userID := userIDValue.(string)
+	roleValue, _ := ctx.Get("role")
+	role, _ := roleValue.(string)
+	isAdmin := role == "admin"
+
+	var req dto.ConsoleQueryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := c.consoleService.ExecuteQuery(serviceID, req.Query, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+// GetAuditLog returns recent console queries for a managed service
+// @Summary List recent console queries
+// @Tags console
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]models.ConsoleAuditLog}
+// @Router /services/{id}/console/audit [get]
+func (c *ConsoleController) GetAuditLog(ctx *gin.Context) {
+	serviceID := ctx.Param("id")
+
+	userIDValue, _ := ctx.Get("userId")
+	userID := userIDValue.(string)
+	roleValue, _ := ctx.Get("role")
+	role, _ := roleValue.(string)
+	isAdmin := role == "admin"
+
+	entries, err := c.consoleService.GetAuditLog(serviceID, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": entries,
+	})
+}
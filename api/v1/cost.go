@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/middleware"
+	"github.com/pendeploy-simple/services"
+)
+
+// CostController handles cost estimates of projects
+type CostController struct {
+	costService *services.CostService
+}
+
+// NewCostController creates a new cost controller
+func NewCostController() *CostController {
+	return &CostController{
+		costService: services.NewCostService(),
+	}
+}
+
+// RegisterRoutes registers cost routes
+func (c *CostController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/costs", middleware.ResponseCache(), c.GetProjectCosts)
+	}
+}
+
+// GetProjectCosts returns the monthly cost estimate of a project
+// @Summary Get the cost estimate of a project
+// @Description Estimates monthly costs per service and environment from the configured pricing (COST_CPU_HOUR, COST_MEMORY_GB_HOUR, COST_STORAGE_GB_MONTH). limitsMonthly prices the billed replicas at their CPU and memory limits; usageMonthly prices current usage from the metrics API as if sustained, and is null when metrics are unavailable. Storage is always priced at its provisioned size.
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=dto.ProjectCostEstimate}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/costs [get]
+func (c *CostController) GetProjectCosts(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	costs, err := c.costService.GetProjectCosts(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Project not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": costs,
+	})
+}
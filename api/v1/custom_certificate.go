@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// CustomCertificateController handles bring-your-own TLS certificates for custom domains
+type CustomCertificateController struct {
+	certificateService *services.CustomCertificateService
+}
+
+// NewCustomCertificateController creates a new custom certificate controller
+func NewCustomCertificateController() *CustomCertificateController {
+	return &CustomCertificateController{
+		certificateService: services.NewCustomCertificateService(),
+	}
+}
+
+// RegisterRoutes registers custom certificate routes
+func (c *CustomCertificateController) RegisterRoutes(router *gin.RouterGroup) {
+	serviceGroup := router.Group("/services/:id")
+	{
+		serviceGroup.GET("/certificate", c.GetCertificate)
+		serviceGroup.PUT("/certificate", c.UploadCertificate)
+		serviceGroup.DELETE("/certificate", c.DeleteCertificate)
+	}
+}
+
+// GetCertificate returns the uploaded certificate of a service
+// @Summary Get the uploaded TLS certificate of a service's custom domain
+// @Tags certificates
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=dto.CustomCertificateResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/certificate [get]
+func (c *CustomCertificateController) GetCertificate(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	certificate, err := c.certificateService.GetCertificate(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": certificate,
+	})
+}
+
+// UploadCertificate stores a certificate and key for the service's custom domain
+// @Summary Upload a TLS certificate for a service's custom domain
+// @Tags certificates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param certificate body dto.CustomCertificateRequest true "PEM certificate chain and private key"
+// @Success 200 {object} object{data=dto.CustomCertificateResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/certificate [put]
+func (c *CustomCertificateController) UploadCertificate(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.CustomCertificateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	certificate, err := c.certificateService.UploadCertificate(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": certificate,
+	})
+}
+
+// DeleteCertificate removes the uploaded certificate of a service
+// @Summary Remove the uploaded TLS certificate and return to cert-manager issued certificates
+// @Tags certificates
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/certificate [delete]
+func (c *CustomCertificateController) DeleteCertificate(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.certificateService.DeleteCertificate(ctx.Param("id"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Certificate removed",
+		},
+	})
+}
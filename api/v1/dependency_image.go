@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ListDependencyImages lists the dependency images copied into every registry
+// @Summary List pinned dependency images (admin only)
+// @Description The images the build system copies into every registry (git client, Kaniko executor), each pinned to the digest its source had when first built, with the update waiting for review and whether each registry's copy was built from the pin.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.DependencyImageListResponse}
+// @Router /admin/dependency-images [get]
+func ListDependencyImages(c *gin.Context) {
+	images, err := services.NewDependencyImageService().ListImages()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
+
+// CheckDependencyImages checks the dependency images for upstream updates
+// @Summary Check dependency images for updates (admin only)
+// @Description Resolves the source tag of every dependency image. Images not pinned yet are pinned to the current digest; a tag that moved to another digest is offered as an update for review. Errors lists the images that could not be checked.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.DependencyImageListResponse}
+// @Router /admin/dependency-images/check [post]
+func CheckDependencyImages(c *gin.Context) {
+	images, err := services.NewDependencyImageService().CheckForUpdates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
+
+// ProposeDependencyImageUpdate offers a newer tag of a dependency image for review
+// @Summary Propose a dependency image update (admin only)
+// @Description Resolves another tag of the image's source repository to a digest and offers it for review, replacing the update waiting for review.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Dependency image name, e.g. kaniko-executor"
+// @Param request body dto.DependencyImageUpdateRequest true "Source image tag"
+// @Success 200 {object} object{data=dto.DependencyImageStatus}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /admin/dependency-images/{name}/candidate [put]
+func ProposeDependencyImageUpdate(c *gin.Context) {
+	var req dto.DependencyImageUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	status, err := services.NewDependencyImageService().ProposeUpdate(c.Param("name"), req)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(dependencyImageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// ApproveDependencyImageUpdate pins a dependency image to its reviewed update
+// @Summary Approve a dependency image update (admin only)
+// @Description Pins the image to the update waiting for review; digest must be the candidate digest that was reviewed. Registries keep their copies until the update is rolled out with POST /admin/dependency-images/rollout.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Dependency image name"
+// @Param request body dto.DependencyImageApproveRequest true "Reviewed candidate digest"
+// @Success 200 {object} object{data=dto.DependencyImageStatus}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /admin/dependency-images/{name}/approve [post]
+func ApproveDependencyImageUpdate(c *gin.Context) {
+	var req dto.DependencyImageApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	status, err := services.NewDependencyImageService().ApproveUpdate(c.Param("name"), req, userID)
+	if err != nil {
+		c.JSON(dependencyImageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// DismissDependencyImageUpdate drops the update waiting for review
+// @Summary Dismiss a dependency image update (admin only)
+// @Description A source tag that moved is offered again the next time it is checked.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Dependency image name"
+// @Success 200 {object} object{data=dto.DependencyImageStatus}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/dependency-images/{name}/candidate [delete]
+func DismissDependencyImageUpdate(c *gin.Context) {
+	status, err := services.NewDependencyImageService().DismissUpdate(c.Param("name"))
+	if err != nil {
+		c.JSON(dependencyImageErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// RollOutDependencyImages rebuilds outdated dependency images across registries
+// @Summary Roll out pinned dependency images (admin only)
+// @Description Rebuilds the dependency images not built from their pinned digest in every ready registry that stores pushed images, one registry after another in the background. Registries that are busy or already building are skipped.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.DependencyImageRolloutRequest false "Images to roll out; all when empty"
+// @Success 202 {object} object{data=dto.DependencyImageRolloutResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /admin/dependency-images/rollout [post]
+func RollOutDependencyImages(c *gin.Context) {
+	var req dto.DependencyImageRolloutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondValidationProblem(c, err)
+			return
+		}
+	}
+
+	rollout, err := services.NewDependencyImageService().RollOut(req)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": rollout})
+}
+
+// dependencyImageErrorStatus maps dependency image errors onto HTTP statuses
+func dependencyImageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDependencyImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDependencyImageNoCandidate):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// DeployLockController handles the deploy locks of projects and environments
+type DeployLockController struct {
+	lockService *services.DeployLockService
+}
+
+// NewDeployLockController creates a new deploy lock controller
+func NewDeployLockController() *DeployLockController {
+	return &DeployLockController{
+		lockService: services.NewDeployLockService(),
+	}
+}
+
+// RegisterRoutes registers deploy lock routes
+func (c *DeployLockController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/deploy-locks", c.ListLocks)
+		projects.POST("/:id/deploy-locks", c.CreateLock)
+		projects.DELETE("/:id/deploy-locks/:lockId", c.DeleteLock)
+	}
+}
+
+// ListLocks returns the unexpired deploy locks of a project
+// @Summary List the deploy locks of a project
+// @Tags deploy-locks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.DeployLock}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/deploy-locks [get]
+func (c *DeployLockController) ListLocks(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	locks, err := c.lockService.ListLocks(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": locks,
+	})
+}
+
+// CreateLock locks a project or one of its environments
+// @Summary Lock deployments of a project or environment
+// @Description While the lock is active, deployments, service updates that roll out and managed service changes in the project (or only in environmentId) are rejected with 423 unless made by an admin. The lock ends at expiresAt, after durationMinutes, or when it is deleted.
+// @Tags deploy-locks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param lock body dto.DeployLockRequest true "Lock"
+// @Success 201 {object} object{data=models.DeployLock}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/deploy-locks [post]
+func (c *DeployLockController) CreateLock(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.DeployLockRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	lock, err := c.lockService.CreateLock(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": lock,
+	})
+}
+
+// DeleteLock lifts a deploy lock
+// @Summary Lift a deploy lock
+// @Tags deploy-locks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param lockId path string true "Deploy lock ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/deploy-locks/{lockId} [delete]
+func (c *DeployLockController) DeleteLock(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.lockService.DeleteLock(ctx.Param("id"), ctx.Param("lockId"), userID, isAdmin); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrDeployLockNotFound) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Deploy lock lifted",
+		},
+	})
+}
+
+// respondDeployLocked answers 423 with the blocking lock when err is a DeployLockedError
+func respondDeployLocked(ctx *gin.Context, err error) bool {
+	var locked *services.DeployLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	ctx.JSON(http.StatusLocked, gin.H{
+		"error": locked.Error(),
+		"lock":  locked.Lock,
+	})
+	return true
+}
@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetDeploymentReport returns the platform-wide deployment statistics of a date range
+// @Summary Get the deployment statistics of a date range (admin only)
+// @Description Daily rollups (computed nightly, UTC) of deployments, builds, average build time, failures by failure class and the services using the most CPU and memory, with their totals over the range.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "First day as YYYY-MM-DD (default: 30 days before to)"
+// @Param to query string false "Last day as YYYY-MM-DD (default: yesterday)"
+// @Success 200 {object} object{data=dto.DeploymentReport}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/reports [get]
+func GetDeploymentReport(c *gin.Context) {
+	report, err := services.NewDeploymentReportService().GetReport(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// RecomputeDeploymentReport recomputes the rollup of a day
+// @Summary Recompute the deployment statistics of a day (admin only)
+// @Description Replaces the nightly rollup of a finished day, e.g. after deployments of that day finished late.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param day query string true "Day as YYYY-MM-DD"
+// @Success 200 {object} object{data=models.DeploymentStatsRollup}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/reports/rollup [post]
+func RecomputeDeploymentReport(c *gin.Context) {
+	rollup, err := services.NewDeploymentReportService().RecomputeDay(c.Query("day"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rollup})
+}
@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"gorm.io/gorm"
+)
+
+// RequestDeviceCode starts a device-code login for a CLI
+// @Summary Start a device-code login
+// @Description The CLI shows userCode and verificationUri to the user, then polls /auth/device/token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.DeviceCodeRequest true "Client name and requested scopes"
+// @Success 200 {object} object{status=string,data=dto.DeviceCodeResponse}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Router /auth/device/code [post]
+func RequestDeviceCode(c *gin.Context) {
+	var req dto.DeviceCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := services.NewDeviceAuthService().StartAuthorization(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to start device login",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   response,
+	})
+}
+
+// ExchangeDeviceToken exchanges an approved device code for a scoped API token
+// @Summary Exchange a device code for an API token
+// @Description Until the user decides, fails with error authorization_pending (keep polling) or slow_down (poll less often). access_denied, expired_token and invalid_grant are final.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.DeviceTokenRequest true "Device code"
+// @Success 200 {object} object{status=string,data=dto.DeviceTokenResponse}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Router /auth/device/token [post]
+func ExchangeDeviceToken(c *gin.Context) {
+	var req dto.DeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response, err := services.NewDeviceAuthService().ExchangeDeviceCode(req.DeviceCode)
+	var tokenErr *services.DeviceTokenError
+	if errors.As(err, &tokenErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Device login not completed",
+			"error":   tokenErr.Code,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to issue API token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   response,
+	})
+}
+
+// GetDeviceAuthorization shows the signed-in user which client is asking for access
+// @Summary Describe a pending device login
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param user_code query string true "Code shown by the CLI"
+// @Success 200 {object} object{status=string,data=dto.DeviceAuthorizationInfo}
+// @Failure 404 {object} object{status=string,message=string}
+// @Router /auth/device [get]
+func GetDeviceAuthorization(c *gin.Context) {
+	info, err := services.NewDeviceAuthService().GetAuthorization(c.Query("user_code"))
+	if err != nil {
+		respondDeviceLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   info,
+	})
+}
+
+// DecideDeviceAuthorization approves or denies a pending device login
+// @Summary Approve or deny a device login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.DeviceApprovalRequest true "User code and decision"
+// @Success 200 {object} object{status=string,data=dto.DeviceAuthorizationInfo}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Failure 404 {object} object{status=string,message=string}
+// @Router /auth/device/approve [post]
+func DecideDeviceAuthorization(c *gin.Context) {
+	var req dto.DeviceApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	info, err := services.NewDeviceAuthService().Decide(req.UserCode, *req.Approve, c.GetString("userId"))
+	if err != nil {
+		respondDeviceLookupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   info,
+	})
+}
+
+// ListAPITokens lists the caller's API tokens
+// @Summary List API tokens
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.APITokenListResponse}
+// @Router /auth/tokens [get]
+func ListAPITokens(c *gin.Context) {
+	tokens, err := services.NewAPITokenService().ListTokens(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to list API tokens",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dto.APITokenListResponse{Tokens: tokens},
+	})
+}
+
+// RevokeAPIToken revokes one of the caller's API tokens
+// @Summary Revoke an API token
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Token ID"
+// @Success 200 {object} object{status=string,message=string}
+// @Failure 404 {object} object{status=string,message=string}
+// @Router /auth/tokens/{id} [delete]
+func RevokeAPIToken(c *gin.Context) {
+	err := services.NewAPITokenService().RevokeToken(c.Param("id"), c.GetString("userId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "API token not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to revoke API token",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "API token revoked",
+	})
+}
+
+func respondDeviceLookupError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Unknown code",
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":  "error",
+		"message": "Cannot update device login",
+		"error":   err.Error(),
+	})
+}
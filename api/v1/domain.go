@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// ListDomains returns the base domains a project can use for generated hostnames
+// @Summary List selectable base domains
+// @Tags projects
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.DomainListResponse}
+// @Router /domains [get]
+func ListDomains(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.NewDomainService().ListDomains(),
+	})
+}
+
+// CheckDomains verifies wildcard DNS and TLS readiness of every configured base domain
+// @Summary Check wildcard DNS and TLS of the platform base domains (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=[]dto.DomainCheck}
+// @Router /admin/domains [get]
+func CheckDomains(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.NewDomainService().CheckDomains(),
+	})
+}
+
+// GetDNS01Status reports the DNS-01 challenge provider and ClusterIssuer readiness
+// @Summary Get DNS-01 challenge configuration status (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.DNS01Status}
+// @Router /admin/dns01 [get]
+func GetDNS01Status(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.NewDomainService().GetDNS01Status(),
+	})
+}
+
+// ApplyDNS01Issuer creates or updates the DNS-01 ClusterIssuer from the configured credentials
+// @Summary Apply the DNS-01 ClusterIssuer (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.DNS01Status}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/dns01/issuer [post]
+func ApplyDNS01Issuer(c *gin.Context) {
+	status, err := services.NewDomainService().ApplyDNS01Issuer()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !status.Enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No DNS-01 provider configured (set DNS01_PROVIDER)"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
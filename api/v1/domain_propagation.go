@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// DomainPropagationController handles the propagation status of service hostnames
+type DomainPropagationController struct {
+	domainService *services.DomainPropagationService
+}
+
+// NewDomainPropagationController creates a new domain propagation controller
+func NewDomainPropagationController() *DomainPropagationController {
+	return &DomainPropagationController{
+		domainService: services.NewDomainPropagationService(),
+	}
+}
+
+// RegisterRoutes registers domain propagation routes
+func (c *DomainPropagationController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.GET("/:id/domains", c.GetDomainStatuses)
+		svc.POST("/:id/domains/check", c.CheckDomains)
+	}
+}
+
+// GetDomainStatuses returns the propagation status of a service's hostnames
+// @Summary Get the propagation status of a service's hostnames
+// @Description Result of the last DNS and HTTPS check of each hostname: pending (does not resolve), misdirected (resolves elsewhere than the ingress), tls_pending (answers, certificate not issued yet), unreachable or reachable. Hostnames are checked every 30 seconds for 30 minutes after a deploy and every 15 minutes after that until reachable.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]models.DomainStatus}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/domains [get]
+func (c *DomainPropagationController) GetDomainStatuses(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	statuses, err := c.domainService.GetStatuses(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": statuses,
+	})
+}
+
+// CheckDomains checks a service's hostnames right away
+// @Summary Check the propagation of a service's hostnames now
+// @Description Resolves every hostname of a git service from inside the cluster, compares the addresses with the ingress's (the public addresses of the platform settings or INGRESS_PUBLIC_ADDRESSES when set) and requests it over HTTPS.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]models.DomainStatus}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/domains/check [post]
+func (c *DomainPropagationController) CheckDomains(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	statuses, err := c.domainService.CheckNow(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": statuses,
+	})
+}
@@ -1,12 +1,14 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
 )
 
 // EnvironmentController handles environment-related API endpoints
@@ -30,6 +32,8 @@ func (c *EnvironmentController) RegisterRoutes(router *gin.RouterGroup) {
 		environments.POST("", c.CreateEnvironment)
 		environments.PUT("/:id", c.UpdateEnvironment)
 		environments.DELETE("/:id", c.DeleteEnvironment)
+		environments.POST("/:id/archive", c.ArchiveEnvironment)
+		environments.POST("/:id/unarchive", c.UnarchiveEnvironment)
 	}
 
 	// Also add project-specific environment routes
@@ -40,6 +44,14 @@ func (c *EnvironmentController) RegisterRoutes(router *gin.RouterGroup) {
 }
 
 // ListEnvironments retrieves all environments (admin only)
+// @Summary List environments (admin only)
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param projectId query string false "Project ID"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentListResponse}
+// @Failure 403 {object} object{error=string}
+// @Router /environments [get]
 func (c *EnvironmentController) ListEnvironments(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -78,14 +90,7 @@ func (c *EnvironmentController) ListEnvironments(ctx *gin.Context) {
 	response.Environments = make([]dto.EnvironmentResponse, 0)
 	
 	for _, env := range environments {
-		response.Environments = append(response.Environments, dto.EnvironmentResponse{
-			ID:          env.ID,
-			Name:        env.Name,
-			Description: env.Description,
-			ProjectID:   env.ProjectID,
-			CreatedAt:   env.CreatedAt,
-			UpdatedAt:   env.UpdatedAt,
-		})
+		response.Environments = append(response.Environments, toEnvironmentResponse(env))
 	}
 	
 	ctx.JSON(http.StatusOK, gin.H{
@@ -95,6 +100,13 @@ func (c *EnvironmentController) ListEnvironments(ctx *gin.Context) {
 }
 
 // ListProjectEnvironments retrieves all environments for a specific project
+// @Summary List the environments of a project
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentListResponse}
+// @Router /projects/{id}/environments [get]
 func (c *EnvironmentController) ListProjectEnvironments(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -115,14 +127,7 @@ func (c *EnvironmentController) ListProjectEnvironments(ctx *gin.Context) {
 	response.Environments = make([]dto.EnvironmentResponse, 0)
 	
 	for _, env := range environments {
-		response.Environments = append(response.Environments, dto.EnvironmentResponse{
-			ID:          env.ID,
-			Name:        env.Name,
-			Description: env.Description,
-			ProjectID:   env.ProjectID,
-			CreatedAt:   env.CreatedAt,
-			UpdatedAt:   env.UpdatedAt,
-		})
+		response.Environments = append(response.Environments, toEnvironmentResponse(env))
 	}
 	
 	ctx.JSON(http.StatusOK, gin.H{
@@ -132,6 +137,14 @@ func (c *EnvironmentController) ListProjectEnvironments(ctx *gin.Context) {
 }
 
 // GetEnvironment retrieves a specific environment
+// @Summary Get an environment
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id} [get]
 func (c *EnvironmentController) GetEnvironment(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -147,14 +160,7 @@ func (c *EnvironmentController) GetEnvironment(ctx *gin.Context) {
 		return
 	}
 	
-	response := dto.EnvironmentResponse{
-		ID:          environment.ID,
-		Name:        environment.Name,
-		Description: environment.Description,
-		ProjectID:   environment.ProjectID,
-		CreatedAt:   environment.CreatedAt,
-		UpdatedAt:   environment.UpdatedAt,
-	}
+	response := toEnvironmentResponse(environment)
 	
 	ctx.JSON(http.StatusOK, gin.H{
 		"status": "success",
@@ -163,6 +169,17 @@ func (c *EnvironmentController) GetEnvironment(ctx *gin.Context) {
 }
 
 // CreateEnvironment creates a new environment
+// @Summary Create an environment
+// @Description domainTemplate generates the hostnames of the environment's git services from the placeholders {service}, {repo}, {branch}, {env}, {envId} and {base}, e.g. {service}.{env}.example.com; it must contain {service} or {repo}. Without one, hostnames are repo-branch.env-id.base-domain.
+// @Tags environments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param environment body dto.EnvironmentRequest true "Environment data"
+// @Success 201 {object} object{status=string,data=dto.EnvironmentResponse}
+// @Failure 400 {object} object{error=string}
+// @Failure 409 {object} object{error=string} "The project is being deleted"
+// @Router /environments [post]
 func (c *EnvironmentController) CreateEnvironment(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -176,30 +193,33 @@ func (c *EnvironmentController) CreateEnvironment(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var fieldErrors utils.FieldErrors
+	if fieldErrors.CheckDomainTemplate("domainTemplate", request.DomainTemplate); fieldErrors.Err() != nil {
+		respondValidationProblem(ctx, fieldErrors)
+		return
+	}
 	
 	// Create environment model
 	environment := models.Environment{
-		Name:        request.Name,
-		Description: request.Description,
-		ProjectID:   request.ProjectID,
+		Name:           request.Name,
+		Description:    request.Description,
+		ProjectID:      request.ProjectID,
+		DomainTemplate: request.DomainTemplate,
 	}
 	
 	// Call service to create
 	createdEnv, err := c.environmentService.CreateEnvironment(environment, userID, isAdmin)
+	if errors.Is(err, services.ErrProjectDeleting) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
 	// Return created environment
-	response := dto.EnvironmentResponse{
-		ID:          createdEnv.ID,
-		Name:        createdEnv.Name,
-		Description: createdEnv.Description,
-		ProjectID:   createdEnv.ProjectID,
-		CreatedAt:   createdEnv.CreatedAt,
-		UpdatedAt:   createdEnv.UpdatedAt,
-	}
+	response := toEnvironmentResponse(createdEnv)
 	
 	ctx.JSON(http.StatusCreated, gin.H{
 		"status": "success",
@@ -207,7 +227,18 @@ func (c *EnvironmentController) CreateEnvironment(ctx *gin.Context) {
 	})
 }
 
-// UpdateEnvironment updates an existing environment
+// UpdateEnvironment renames an environment or changes its description and service defaults
+// @Summary Update an environment
+// @Description Changing domainTemplate, or renaming an environment whose template contains {env}, moves every git service of the environment to its new hostname and re-applies the Ingresses of deployed ones. Hostnames taken by another service get a -2, -3... suffix, older services first.
+// @Tags environments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param environment body dto.EnvironmentUpdateRequest true "Fields to change"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /environments/{id} [put]
 func (c *EnvironmentController) UpdateEnvironment(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -217,44 +248,99 @@ func (c *EnvironmentController) UpdateEnvironment(ctx *gin.Context) {
 	isAdmin := role == "admin"
 	environmentID := ctx.Param("id")
 	
-	var request dto.EnvironmentRequest
+	var request dto.EnvironmentUpdateRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	// Create environment model
-	environment := models.Environment{
-		ID:          environmentID,
-		Name:        request.Name,
-		Description: request.Description,
-		// No need to set ProjectID as it cannot be changed after creation
+	if err := utils.ValidateEnvironmentUpdateRequest(request); err != nil {
+		respondValidationProblem(ctx, err)
+		return
 	}
 	
 	// Call service to update
-	updatedEnv, err := c.environmentService.UpdateEnvironment(environment, userID, isAdmin)
+	updatedEnv, err := c.environmentService.UpdateEnvironment(environmentID, request, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Return updated environment
-	response := dto.EnvironmentResponse{
-		ID:          updatedEnv.ID,
-		Name:        updatedEnv.Name,
-		Description: updatedEnv.Description,
-		ProjectID:   updatedEnv.ProjectID,
-		CreatedAt:   updatedEnv.CreatedAt,
-		UpdatedAt:   updatedEnv.UpdatedAt,
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   toEnvironmentResponse(updatedEnv),
+	})
+}
+
+// ArchiveEnvironment scales every service of an environment to zero, keeping its data
+// @Summary Archive an environment
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentArchiveResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /environments/{id}/archive [post]
+func (c *EnvironmentController) ArchiveEnvironment(ctx *gin.Context) {
+	c.setArchived(ctx, true)
+}
+
+// UnarchiveEnvironment scales the services of an archived environment back up
+// @Summary Unarchive an environment
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Success 200 {object} object{status=string,data=dto.EnvironmentArchiveResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /environments/{id}/unarchive [post]
+func (c *EnvironmentController) UnarchiveEnvironment(ctx *gin.Context) {
+	c.setArchived(ctx, false)
+}
+
+func (c *EnvironmentController) setArchived(ctx *gin.Context, archive bool) {
+	// Get userId and role from context
+	userIDValue, _ := ctx.Get("userId")
+	userID := userIDValue.(string)
+	roleValue, _ := ctx.Get("role")
+	role, _ := roleValue.(string)
+	isAdmin := role == "admin"
+	environmentID := ctx.Param("id")
+	
+	var environment models.Environment
+	var result dto.EnvironmentArchiveResponse
+	var err error
+	if archive {
+		environment, result, err = c.environmentService.ArchiveEnvironment(environmentID, userID, isAdmin)
+	} else {
+		environment, result, err = c.environmentService.UnarchiveEnvironment(environmentID, userID, isAdmin)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	
+	result.Environment = toEnvironmentResponse(environment)
 	ctx.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   response,
+		"data":   result,
 	})
 }
 
 // DeleteEnvironment deletes an environment
+// @Summary Delete an environment
+// @Tags environments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param force query bool false "Also delete the services of the environment"
+// @Success 200 {object} object{status=string,message=string}
+// @Failure 409 {object} object{error=string,services=[]string}
+// @Router /environments/{id} [delete]
 func (c *EnvironmentController) DeleteEnvironment(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -263,8 +349,17 @@ func (c *EnvironmentController) DeleteEnvironment(ctx *gin.Context) {
 	role, _ := roleValue.(string)
 	isAdmin := role == "admin"
 	environmentID := ctx.Param("id")
+	force := ctx.Query("force") == "true"
 	
-	err := c.environmentService.DeleteEnvironment(environmentID, userID, isAdmin)
+	err := c.environmentService.DeleteEnvironment(environmentID, force, userID, isAdmin)
+	var protected *services.ProtectedServicesError
+	if errors.As(err, &protected) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":    protected.Error(),
+			"services": protected.Services,
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -275,3 +370,31 @@ func (c *EnvironmentController) DeleteEnvironment(ctx *gin.Context) {
 		"message": "Environment deleted successfully",
 	})
 }
+
+// toEnvironmentResponse converts an environment model to its API representation
+func toEnvironmentResponse(env models.Environment) dto.EnvironmentResponse {
+	return dto.EnvironmentResponse{
+		ID:                 env.ID,
+		Name:               env.Name,
+		Description:        env.Description,
+		ProjectID:          env.ProjectID,
+		CreatedAt:          env.CreatedAt,
+		UpdatedAt:          env.UpdatedAt,
+		Archived:           env.IsArchived(),
+		ArchivedAt:         env.ArchivedAt,
+		DefaultCPULimit:    env.DefaultCPULimit,
+		DefaultMemoryLimit: env.DefaultMemoryLimit,
+		DefaultReplicas:    env.DefaultReplicas,
+		DefaultStorageSize: env.DefaultStorageSize,
+		MinCPULimit:        env.MinCPULimit,
+		MaxCPULimit:        env.MaxCPULimit,
+		MinMemoryLimit:     env.MinMemoryLimit,
+		MaxMemoryLimit:     env.MaxMemoryLimit,
+		MaxStorageSize:     env.MaxStorageSize,
+		PriorityTier:       env.PriorityTier,
+		DomainTemplate:     env.DomainTemplate,
+
+		PromotionApprovalRequired: env.PromotionApprovalRequired,
+		EnvVarApprovalRequired:    env.EnvVarApprovalRequired,
+	}
+}
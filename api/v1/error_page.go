@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ErrorPageController handles the branded ingress error pages of projects
+type ErrorPageController struct {
+	errorPageService *services.ErrorPageService
+}
+
+// NewErrorPageController creates a new error page controller
+func NewErrorPageController() *ErrorPageController {
+	return &ErrorPageController{
+		errorPageService: services.NewErrorPageService(),
+	}
+}
+
+// RegisterRoutes registers error page routes
+func (c *ErrorPageController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/error-page", c.GetErrorPage)
+		projects.PUT("/:id/error-page", c.SetErrorPage)
+		projects.DELETE("/:id/error-page", c.RemoveErrorPage)
+		projects.GET("/:id/error-page/preview", c.PreviewErrorPage)
+	}
+}
+
+// GetErrorPage returns the error page config of a project
+// @Summary Get the error page of a project
+// @Description data is null when the project's services answer with Traefik's own error responses.
+// @Tags error-pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=models.ErrorPageConfig}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/error-page [get]
+func (c *ErrorPageController) GetErrorPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	config, err := c.errorPageService.GetErrorPage(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": config,
+	})
+}
+
+// SetErrorPage sets the branded error page of a project
+// @Summary Set the error page of a project
+// @Description The ingress serves the page in place of 502, 503 and 504 responses of the project's git services, e.g. while a backend is down or scaled to zero. Each environment runs a small error page service behind a Traefik errors middleware; deployed services switch over at once.
+// @Tags error-pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param page body dto.ErrorPageRequest true "Branding, or a full HTML page"
+// @Success 200 {object} object{data=models.ErrorPageConfig}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/error-page [put]
+func (c *ErrorPageController) SetErrorPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ErrorPageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	config, err := c.errorPageService.SetErrorPage(ctx.Param("id"), models.ErrorPageConfig{
+		Title:   req.Title,
+		Message: req.Message,
+		LogoURL: req.LogoURL,
+		HTML:    req.HTML,
+	}, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": config,
+	})
+}
+
+// RemoveErrorPage removes the error page of a project
+// @Summary Remove the error page of a project
+// @Description The project's services go back to Traefik's own error responses.
+// @Tags error-pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/error-page [delete]
+func (c *ErrorPageController) RemoveErrorPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.errorPageService.RemoveErrorPage(ctx.Param("id"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Error page removed",
+	})
+}
+
+// PreviewErrorPage renders the error page of a project
+// @Summary Preview the error page of a project
+// @Description Returns the HTML the ingress serves for the status.
+// @Tags error-pages
+// @Produce html
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param status query int false "502, 503 (default) or 504"
+// @Success 200 {string} string "HTML page"
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/error-page/preview [get]
+func (c *ErrorPageController) PreviewErrorPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	status, err := strconv.Atoi(ctx.DefaultQuery("status", "503"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be a number",
+		})
+		return
+	}
+
+	page, err := c.errorPageService.PreviewErrorPage(ctx.Param("id"), status, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
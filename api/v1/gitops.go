@@ -0,0 +1,227 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// maxGitOpsWebhookBytes bounds the body of a push webhook
+const maxGitOpsWebhookBytes = 5 << 20
+
+// GitOpsController handles the config repository of a project
+type GitOpsController struct {
+	gitopsService *services.GitOpsService
+}
+
+// NewGitOpsController creates a new GitOps controller
+func NewGitOpsController() *GitOpsController {
+	return &GitOpsController{
+		gitopsService: services.NewGitOpsService(),
+	}
+}
+
+// RegisterRoutes registers GitOps configuration routes
+func (c *GitOpsController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/gitops", c.GetConfig)
+		projects.PUT("/:id/gitops", c.SaveConfig)
+		projects.DELETE("/:id/gitops", c.DeleteConfig)
+		projects.POST("/:id/gitops/sync", c.SyncNow)
+	}
+}
+
+// RegisterPublicRoutes registers the push webhook of config repositories, which is
+// authenticated by its secret instead of an account
+func (c *GitOpsController) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.POST("/gitops/:projectId/webhook", c.HandlePush)
+}
+
+// GetConfig returns the GitOps configuration of a project
+// @Summary Get the GitOps configuration of a project
+// @Tags gitops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=dto.GitOpsConfigResponse}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/gitops [get]
+func (c *GitOpsController) GetConfig(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	config, err := c.gitopsService.GetConfig(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(gitopsErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": config,
+	})
+}
+
+// SaveConfig points a project at a config repository
+// @Summary Configure GitOps sync from a config repository
+// @Description The spec file (default pendeploy.yaml) lists the environments of the project under environments, each with the fields of the declarative environment apply and its services under services, each with the fields of the declarative service apply, keyed by name. On each push to the branch and every pollMinutes, the spec is applied as the project owner; resources missing from it are left in place. Git credentials must not be committed: private git services created by a sync use gitUsername and gitToken. Register webhookPath as a push webhook with webhookSecret (GitHub: secret of an application/json webhook; GitLab: secret token). The secret is only returned when the configuration is created or rotateWebhookSecret is set.
+// @Tags gitops
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param config body dto.GitOpsConfigRequest true "Config repository and sync settings"
+// @Success 200 {object} object{data=dto.GitOpsConfigResponse}
+// @Success 201 {object} object{data=dto.GitOpsConfigResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/gitops [put]
+func (c *GitOpsController) SaveConfig(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.GitOpsConfigRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateGitOpsConfigRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	config, created, err := c.gitopsService.SaveConfig(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{
+		"data": config,
+	})
+}
+
+// DeleteConfig disconnects the config repository of a project
+// @Summary Remove the GitOps configuration of a project
+// @Description Stops syncing; the environments and services created by syncs are kept.
+// @Tags gitops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/gitops [delete]
+func (c *GitOpsController) DeleteConfig(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.gitopsService.DeleteConfig(ctx.Param("id"), userID, isAdmin); err != nil {
+		ctx.JSON(gitopsErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "GitOps configuration deleted",
+		},
+	})
+}
+
+// SyncNow applies the spec file at the head of the branch right away
+// @Summary Sync a project from its config repository now
+// @Description Applies the spec file at the head of the branch even if that commit was synced before, and reports what changed per environment and service. Runs even while syncing is paused.
+// @Tags gitops
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=dto.GitOpsSyncResult}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Failure 502 {object} object{error=string,data=dto.GitOpsSyncResult}
+// @Router /projects/{id}/gitops/sync [post]
+func (c *GitOpsController) SyncNow(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	result, err := c.gitopsService.SyncNow(ctx.Param("id"), userID, isAdmin)
+	if errors.Is(err, services.ErrGitOpsSyncFailed) {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+			"data":  result,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(gitopsErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+// HandlePush receives the push webhooks of a config repository
+// @Summary Push webhook of a GitOps config repository
+// @Description Authenticated by the GitHub X-Hub-Signature-256 signature or the GitLab X-Gitlab-Token of the project's webhook secret. Pushes to the configured branch start a sync in the background; other events are acknowledged and ignored.
+// @Tags gitops
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Success 202 {object} object{data=object{synced=bool}}
+// @Failure 401 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /gitops/{projectId}/webhook [post]
+func (c *GitOpsController) HandlePush(ctx *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxGitOpsWebhookBytes))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	synced, err := c.gitopsService.HandlePush(ctx.Param("projectId"), ctx.Request.Header, body)
+	if errors.Is(err, services.ErrGitOpsInvalidSignature) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(gitopsErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"data": gin.H{
+			"synced": synced,
+		},
+	})
+}
+
+func gitopsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrGitOpsNotConfigured):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGitOpsSyncInProgress):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -1,10 +1,19 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/dto"
 )
 
 // HealthCheck handles the health check endpoint
+// @Summary Health check
+// @Tags health
+// @Produce json
+// @Success 200 {object} object{status=string,service=string,version=string}
+// @Router /health [get]
 func HealthCheck(c *gin.Context) {
 	c.JSON(200, gin.H{
 		"status":  "ok",
@@ -12,3 +21,39 @@ func HealthCheck(c *gin.Context) {
 		"version": "1.0.0",
 	})
 }
+
+// Healthz reports database reachability and connection pool saturation. It answers
+// 503 when a database is down so it can back a readiness probe.
+// @Summary Detailed health check with database pool usage
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthzResponse
+// @Failure 503 {object} dto.HealthzResponse
+// @Router /healthz [get]
+func Healthz(c *gin.Context) {
+	response := dto.HealthzResponse{
+		Status:   "ok",
+		Database: database.GetDatabaseHealth(c.Request.Context()),
+	}
+
+	components := []dto.DBPoolHealth{response.Database.Primary}
+	if response.Database.Replica != nil {
+		components = append(components, *response.Database.Replica)
+	}
+	for _, component := range components {
+		switch component.Status {
+		case "down":
+			response.Status = "down"
+		case "degraded":
+			if response.Status == "ok" {
+				response.Status = "degraded"
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if response.Status == "down" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"gorm.io/gorm"
+)
+
+// StartImpersonation lets an admin act as a user to reproduce an issue
+// @Summary Impersonate a user (admin only)
+// @Description Returns a session token that acts as the user with the user's permissions, for IMPERSONATION_TTL_MINUTES (default 60). Responses to it carry the X-Impersonated-By header and /auth/me returns impersonatedBy. Credential endpoints under /auth are refused. The start, every write request and the end are recorded in the impersonation audit trail. Admins cannot be impersonated.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body dto.ImpersonationRequest true "Reason, recorded in the audit trail"
+// @Success 200 {object} object{data=dto.ImpersonationResponse}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	var req dto.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	client := services.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	response, err := services.NewImpersonationService().Start(c.GetString("userId"), c.Param("id"), req.Reason, client)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	case errors.Is(err, services.ErrImpersonateSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrImpersonateAdmin):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// EndImpersonation ends the impersonation session making the request
+// @Summary End an impersonation
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,message=string}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Router /auth/impersonation/end [post]
+func EndImpersonation(c *gin.Context) {
+	err := services.NewImpersonationService().End(c.GetString("sessionId"), c.GetString("userId"), c.GetString("impersonatorId"), c.ClientIP())
+	if errors.Is(err, services.ErrNotImpersonating) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to end impersonation",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to end impersonation",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Impersonation ended",
+	})
+}
+
+// ListImpersonationAudit lists the impersonation audit trail, newest first
+// @Summary List impersonation audit entries (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param impersonatorId query string false "Only impersonations by this admin"
+// @Param userId query string false "Only impersonations of this user"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100)"
+// @Success 200 {object} object{data=dto.ImpersonationAuditListResponse}
+// @Router /admin/impersonations [get]
+func ListImpersonationAudit(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	entries, err := services.NewImpersonationService().ListAudit(c.Query("impersonatorId"), c.Query("userId"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
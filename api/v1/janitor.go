@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// RunJanitor triggers an immediate cleanup of build leftovers and stale TLS secrets
+// @Summary Run the build namespace janitor now (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.JanitorReport}
+// @Router /admin/janitor/run [post]
+func RunJanitor(c *gin.Context) {
+	report, err := services.NewJanitorService().RunOnce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to run janitor: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize caps pageSize on list endpoints
+const maxPageSize = 100
+
+// parsePagination reads page and pageSize query parameters with defaults of 1 and 10
+func parsePagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize
+}
+
+// parseOptionalPagination is parsePagination for lists that returned every row before they
+// were paginated: without page or pageSize it returns a pageSize of 0, meaning all rows
+func parseOptionalPagination(ctx *gin.Context) (int, int) {
+	if ctx.Query("page") == "" && ctx.Query("pageSize") == "" {
+		return 1, 0
+	}
+	return parsePagination(ctx)
+}
+
+// parseDateRange reads createdFrom and createdTo query parameters (RFC3339 or YYYY-MM-DD).
+// A date-only createdTo includes the whole day.
+func parseDateRange(ctx *gin.Context) (*time.Time, *time.Time, error) {
+	from, err := parseDateParam(ctx.Query("createdFrom"), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid createdFrom: %v", err)
+	}
+
+	to, err := parseDateParam(ctx.Query("createdTo"), true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid createdTo: %v", err)
+	}
+
+	return from, to, nil
+}
+
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC3339 or YYYY-MM-DD, got %q", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
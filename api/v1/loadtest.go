@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// LoadTestController handles load tests of services
+type LoadTestController struct {
+	loadTestService *services.LoadTestService
+}
+
+// NewLoadTestController creates a new load test controller
+func NewLoadTestController() *LoadTestController {
+	return &LoadTestController{
+		loadTestService: services.NewLoadTestService(),
+	}
+}
+
+// RegisterRoutes registers load test routes
+func (c *LoadTestController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.POST("/:id/loadtest", c.StartLoadTest)
+		svc.GET("/:id/loadtests", c.ListLoadTests)
+		svc.GET("/:id/loadtests/:loadTestId", c.GetLoadTest)
+		svc.GET("/:id/loadtests/:loadTestId/stream", c.StreamLoadTest)
+	}
+}
+
+// StartLoadTest runs a load test against a service
+// @Summary Start a load test
+// @Description Runs a vegeta Job against the service's internal URL at the given rate for the given duration. Results are recorded against the running deployment and compared with the previous deployment's to catch performance regressions. Only one test per service runs at a time.
+// @Tags load-tests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param loadtest body dto.LoadTestRequest true "Rate, duration and path"
+// @Success 202 {object} object{data=models.LoadTest}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/loadtest [post]
+func (c *LoadTestController) StartLoadTest(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.LoadTestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateLoadTestRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	test, err := c.loadTestService.StartLoadTest(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"data": test,
+	})
+}
+
+// ListLoadTests returns the load tests of a service
+// @Summary List load tests of a service
+// @Description Newest first. Completed tests include the baseline test of the previous deployment at the same rate, the p95 latency change and whether it regressed.
+// @Tags load-tests
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.LoadTestListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/loadtests [get]
+func (c *LoadTestController) ListLoadTests(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	tests, err := c.loadTestService.ListLoadTests(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": tests,
+	})
+}
+
+// GetLoadTest returns a load test of a service
+// @Summary Get a load test
+// @Tags load-tests
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param loadTestId path string true "Load test ID"
+// @Success 200 {object} object{data=dto.LoadTestResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/loadtests/{loadTestId} [get]
+func (c *LoadTestController) GetLoadTest(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	test, err := c.loadTestService.GetLoadTest(ctx.Param("id"), ctx.Param("loadTestId"), userID, isAdmin)
+	if err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, services.ErrLoadTestNotFound) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": test,
+	})
+}
+
+// StreamLoadTest streams the progress of a running load test
+// Streams the vegeta report printed every 5 seconds in Server-Sent Events format
+// @Summary Stream load test progress
+// @Tags load-tests
+// @Produce event-stream
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param loadTestId path string true "Load test ID"
+// @Success 200 {string} string "Server-Sent Events"
+// @Router /services/{id}/loadtests/{loadTestId}/stream [get]
+func (c *LoadTestController) StreamLoadTest(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	// Set headers for SSE streaming
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
+
+	err := c.loadTestService.StreamLoadTest(ctx.Param("id"), ctx.Param("loadTestId"), userID, isAdmin, w)
+	if err != nil {
+		// Headers are already sent, so report the error as an event
+		utils.WriteSSEMessage(w, "error: "+err.Error())
+	}
+}
@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// LogDrainController handles the external log sinks of projects
+type LogDrainController struct {
+	drainService *services.LogDrainService
+}
+
+// NewLogDrainController creates a new log drain controller
+func NewLogDrainController() *LogDrainController {
+	return &LogDrainController{
+		drainService: services.NewLogDrainService(),
+	}
+}
+
+// RegisterRoutes registers log drain routes
+func (c *LogDrainController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/log-drains", c.ListDrains)
+		projects.POST("/:id/log-drains", c.CreateDrain)
+		projects.PUT("/:id/log-drains/:drainId", c.UpdateDrain)
+		projects.DELETE("/:id/log-drains/:drainId", c.DeleteDrain)
+	}
+}
+
+// ListDrains returns the log drains of a project
+// @Summary List the log drains of a project
+// @Description Each drain reports its delivery health: pending, healthy, failing or disabled.
+// @Tags log-drains
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]dto.LogDrainResponse}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/log-drains [get]
+func (c *LogDrainController) ListDrains(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	drains, err := c.drainService.ListDrains(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": drains,
+	})
+}
+
+// CreateDrain registers a log drain for a project
+// @Summary Register a log drain
+// @Description Pod logs of every service in the project are forwarded to the sink (Loki push API, Datadog logs intake or a generic HTTPS endpoint receiving JSON batches).
+// @Tags log-drains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param drain body dto.LogDrainRequest true "Sink configuration"
+// @Success 201 {object} object{data=dto.LogDrainResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/log-drains [post]
+func (c *LogDrainController) CreateDrain(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.LogDrainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateLogDrainRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	drain, err := c.drainService.CreateDrain(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": drain,
+	})
+}
+
+// UpdateDrain changes a log drain
+// @Summary Update a log drain
+// @Tags log-drains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param drainId path string true "Log drain ID"
+// @Param drain body dto.LogDrainUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=dto.LogDrainResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/log-drains/{drainId} [put]
+func (c *LogDrainController) UpdateDrain(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.LogDrainUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	drain, err := c.drainService.UpdateDrain(ctx.Param("id"), ctx.Param("drainId"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(logDrainErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": drain,
+	})
+}
+
+// DeleteDrain removes a log drain
+// @Summary Delete a log drain
+// @Tags log-drains
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param drainId path string true "Log drain ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/log-drains/{drainId} [delete]
+func (c *LogDrainController) DeleteDrain(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.drainService.DeleteDrain(ctx.Param("id"), ctx.Param("drainId"), userID, isAdmin); err != nil {
+		ctx.JSON(logDrainErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Log drain deleted",
+		},
+	})
+}
+
+func logDrainErrorStatus(err error) int {
+	if errors.Is(err, services.ErrLogDrainNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -2,11 +2,29 @@ package v1
 
 import (
 	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
 )
 
 // Logout handles user logout
+// @Summary Log out and clear the access_token cookie
+// @Description Also revokes the session of the token, sent as cookie or bearer token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object{status=string,message=string}
+// @Router /auth/logout [post]
 func Logout(c *gin.Context) {
+	// Revoke the session so a copy of the token stops working too
+	tokenString, _ := c.Cookie("access_token")
+	if parts := strings.Fields(c.GetHeader("Authorization")); len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+		tokenString = parts[1]
+	}
+	if tokenString != "" && !services.IsAPIToken(tokenString) {
+		services.NewSessionService().EndSession(tokenString)
+	}
+
 	// Clear the cookie by setting max-age to -1 (expired)
 	c.SetCookie(
 		"access_token", // name
@@ -0,0 +1,335 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// MaintenanceTaskController handles the scheduled maintenance tasks of environments
+type MaintenanceTaskController struct {
+	maintenanceService *services.MaintenanceTaskService
+}
+
+// NewMaintenanceTaskController creates a new maintenance task controller
+func NewMaintenanceTaskController() *MaintenanceTaskController {
+	return &MaintenanceTaskController{
+		maintenanceService: services.NewMaintenanceTaskService(),
+	}
+}
+
+// RegisterRoutes registers maintenance task routes
+func (c *MaintenanceTaskController) RegisterRoutes(router *gin.RouterGroup) {
+	environments := router.Group("/environments")
+	{
+		environments.GET("/:id/maintenance-tasks", c.ListTasks)
+		environments.POST("/:id/maintenance-tasks", c.CreateTask)
+		environments.GET("/:id/maintenance-tasks/:taskId", c.GetTask)
+		environments.PATCH("/:id/maintenance-tasks/:taskId", c.UpdateTask)
+		environments.DELETE("/:id/maintenance-tasks/:taskId", c.DeleteTask)
+		environments.POST("/:id/maintenance-tasks/:taskId/run", c.TriggerRun)
+		environments.GET("/:id/maintenance-tasks/:taskId/runs", c.ListRuns)
+		environments.GET("/:id/maintenance-tasks/:taskId/runs/:runId/logs", c.GetRunLogs)
+		environments.GET("/:id/maintenance-tasks/:taskId/runs/:runId/stream", c.StreamRunLogs)
+	}
+}
+
+// ListTasks returns the maintenance tasks of an environment
+// @Summary List maintenance tasks of an environment
+// @Description Each task includes the name of its service, its next scheduled run (none while suspended) and its last run.
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Success 200 {object} object{data=[]dto.MaintenanceTaskResponse}
+// @Failure 403 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks [get]
+func (c *MaintenanceTaskController) ListTasks(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	tasks, err := c.maintenanceService.ListTasks(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": tasks,
+	})
+}
+
+// CreateTask adds a maintenance task to an environment
+// @Summary Create a maintenance task
+// @Description Schedules a recurring Job, such as a cache warmer or a report generator, that runs a shell command in the image of the latest successful deployment of a git service of the environment. The schedule is a 5-field cron expression in UTC. A scheduled run is skipped while the previous run is still running. With inheritEnv the Job gets the service's env vars, secrets included; envVars are added on top and must not contain secret references.
+// @Tags maintenance-tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param task body dto.MaintenanceTaskRequest true "Name, schedule, service, command and resources"
+// @Success 201 {object} object{data=dto.MaintenanceTaskResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks [post]
+func (c *MaintenanceTaskController) CreateTask(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.MaintenanceTaskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateMaintenanceTaskRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	task, err := c.maintenanceService.CreateTask(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": task,
+	})
+}
+
+// GetTask returns a maintenance task of an environment
+// @Summary Get a maintenance task
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Success 200 {object} object{data=dto.MaintenanceTaskResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId} [get]
+func (c *MaintenanceTaskController) GetTask(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	task, err := c.maintenanceService.GetTask(ctx.Param("id"), ctx.Param("taskId"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": task,
+	})
+}
+
+// UpdateTask changes a maintenance task
+// @Summary Update a maintenance task
+// @Description Only the fields that are set are changed. Set suspended to pause the schedule; manual runs still start.
+// @Tags maintenance-tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Param task body dto.MaintenanceTaskUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=dto.MaintenanceTaskResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId} [patch]
+func (c *MaintenanceTaskController) UpdateTask(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.MaintenanceTaskUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateMaintenanceTaskUpdateRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	task, err := c.maintenanceService.UpdateTask(ctx.Param("id"), ctx.Param("taskId"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": task,
+	})
+}
+
+// DeleteTask removes a maintenance task
+// @Summary Delete a maintenance task
+// @Description Removes the task and its run history. A run in progress finishes on its own.
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId} [delete]
+func (c *MaintenanceTaskController) DeleteTask(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.maintenanceService.DeleteTask(ctx.Param("id"), ctx.Param("taskId"), userID, isAdmin); err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Maintenance task deleted successfully",
+	})
+}
+
+// TriggerRun runs a maintenance task now
+// @Summary Run a maintenance task now
+// @Description Starts a run outside the schedule, also while the task is suspended. The run's output can be streamed while it runs.
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Success 202 {object} object{data=models.MaintenanceTaskRun}
+// @Failure 400 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId}/run [post]
+func (c *MaintenanceTaskController) TriggerRun(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	run, err := c.maintenanceService.TriggerRun(ctx.Param("id"), ctx.Param("taskId"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"data": run,
+	})
+}
+
+// ListRuns returns the run history of a maintenance task
+// @Summary List runs of a maintenance task
+// @Description Newest first, with the trigger, image, status, exit code and error of each run.
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.MaintenanceTaskRunListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId}/runs [get]
+func (c *MaintenanceTaskController) ListRuns(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	runs, err := c.maintenanceService.ListRuns(ctx.Param("id"), ctx.Param("taskId"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": runs,
+	})
+}
+
+// GetRunLogs returns the kept output of a maintenance task run
+// @Summary Get the logs of a maintenance task run
+// @Description Returns the last 500 lines of a finished run's output, with secrets masked. Use the stream endpoint while the run is running.
+// @Tags maintenance-tasks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Param runId path string true "Run ID"
+// @Success 200 {object} object{data=dto.MaintenanceTaskRunLogsResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/maintenance-tasks/{taskId}/runs/{runId}/logs [get]
+func (c *MaintenanceTaskController) GetRunLogs(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	logs, err := c.maintenanceService.GetRunLogs(ctx.Param("id"), ctx.Param("taskId"), ctx.Param("runId"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(maintenanceTaskErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": logs,
+	})
+}
+
+// StreamRunLogs streams the output of a maintenance task run
+// Streams the output of a running run in Server-Sent Events format; a finished run's kept output is sent at once
+// @Summary Stream the logs of a maintenance task run
+// @Tags maintenance-tasks
+// @Produce event-stream
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param taskId path string true "Maintenance task ID"
+// @Param runId path string true "Run ID"
+// @Success 200 {string} string "Server-Sent Events"
+// @Router /environments/{id}/maintenance-tasks/{taskId}/runs/{runId}/stream [get]
+func (c *MaintenanceTaskController) StreamRunLogs(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	// Set headers for SSE streaming
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
+
+	err := c.maintenanceService.StreamRunLogs(ctx.Param("id"), ctx.Param("taskId"), ctx.Param("runId"), userID, isAdmin, w)
+	if err != nil {
+		// Headers are already sent, so report the error as an event
+		utils.WriteSSEMessage(w, "error: "+err.Error())
+	}
+}
+
+func maintenanceTaskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrMaintenanceTaskNotFound),
+		errors.Is(err, services.ErrMaintenanceRunNotFound),
+		errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrMaintenanceTaskExists),
+		errors.Is(err, services.ErrMaintenanceTaskRunning):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
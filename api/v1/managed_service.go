@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetManagedServiceCatalog describes the managed service types that can be created
+// @Summary List managed service types, versions and defaults
+// @Description Derived from the server's managed service catalog; other versions are accepted on create and update only when the image tag exists in the registry.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.ManagedServiceCatalog}
+// @Router /managed-services/catalog [get]
+func GetManagedServiceCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": services.NewManagedServiceService().GetCatalog(),
+	})
+}
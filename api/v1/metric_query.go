@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// MetricQueryController handles the metric queries of dashboard widgets
+type MetricQueryController struct {
+	metricService *services.MetricQueryService
+}
+
+// NewMetricQueryController creates a new metric query controller
+func NewMetricQueryController() *MetricQueryController {
+	return &MetricQueryController{
+		metricService: services.NewMetricQueryService(),
+	}
+}
+
+// RegisterRoutes registers metric query routes
+func (c *MetricQueryController) RegisterRoutes(router *gin.RouterGroup) {
+	metrics := router.Group("/metrics")
+	{
+		metrics.GET("/catalog", c.GetCatalog)
+		metrics.POST("/query", c.Query)
+	}
+}
+
+// GetCatalog lists what metric queries can aggregate
+// @Summary List the sources, metrics and dimensions of metric queries
+// @Tags metrics
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.MetricCatalog}
+// @Router /metrics/catalog [get]
+func (c *MetricQueryController) GetCatalog(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": c.metricService.GetCatalog(),
+	})
+}
+
+// Query aggregates a stored metric over time
+// @Summary Aggregate a metric for a dashboard widget
+// @Description Aggregates a metric of a source (usage samples, uptime checks, deployments or incidents) over time buckets, with one series per combination of the groupBy dimensions, e.g. the average CPU per service in 1h buckets or the failed deployments per environment per day. Rows are scoped to the caller's projects (all projects for admins) and can be narrowed to a project, environment or services. Buckets without data are left out.
+// @Tags metrics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param query body dto.MetricQueryRequest true "Source, metric, aggregation, grouping, bucket and range"
+// @Success 200 {object} object{data=dto.MetricQueryResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 500 {object} object{error=string}
+// @Router /metrics/query [post]
+func (c *MetricQueryController) Query(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.MetricQueryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	response, err := c.metricService.Query(req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": response,
+	})
+}
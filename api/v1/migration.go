@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetMigrationStatus reports applied and pending schema migrations
+// @Summary List schema migrations and their state (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.MigrationReport}
+// @Failure 500 {object} object{error=string}
+// @Router /admin/migrations [get]
+func GetMigrationStatus(c *gin.Context) {
+	report, err := services.NewMigrationService().GetStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to read migration status: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
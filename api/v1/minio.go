@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// MinIOController handles bucket provisioning for managed MinIO
+type MinIOController struct {
+	minioService *services.MinIOService
+}
+
+// NewMinIOController creates a new MinIO controller
+func NewMinIOController() *MinIOController {
+	return &MinIOController{
+		minioService: services.NewMinIOService(),
+	}
+}
+
+// RegisterRoutes registers MinIO routes
+func (c *MinIOController) RegisterRoutes(router *gin.RouterGroup) {
+	bucketGroup := router.Group("/services/:id/minio/buckets")
+	{
+		bucketGroup.GET("", c.ListBuckets)
+		bucketGroup.POST("", c.CreateBucket)
+		bucketGroup.GET("/:bucket/credentials", c.GetBucketCredentials)
+		bucketGroup.PUT("/:bucket/lifecycle", c.SetLifecycleRules)
+		bucketGroup.DELETE("/:bucket", c.DeleteBucket)
+	}
+}
+
+// ListBuckets lists buckets on the MinIO instance
+// @Summary List MinIO buckets
+// @Tags minio
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]dto.MinIOBucketResponse}
+// @Router /services/{id}/minio/buckets [get]
+func (c *MinIOController) ListBuckets(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	buckets, err := c.minioService.ListBuckets(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": buckets,
+	})
+}
+
+// CreateBucket creates a bucket with policy, lifecycle rules and optional credentials
+// @Summary Create a MinIO bucket
+// @Tags minio
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param bucket body dto.MinIOBucketRequest true "Bucket"
+// @Success 201 {object} object{data=dto.MinIOBucketResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/minio/buckets [post]
+func (c *MinIOController) CreateBucket(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.MinIOBucketRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	bucket, err := c.minioService.CreateBucket(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": bucket,
+	})
+}
+
+// GetBucketCredentials returns bucket-scoped credentials, generating them on first use
+// @Summary Get bucket-scoped credentials
+// @Tags minio
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} object{data=dto.MinIOBucketCredentials}
+// @Router /services/{id}/minio/buckets/{bucket}/credentials [get]
+func (c *MinIOController) GetBucketCredentials(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	credentials, err := c.minioService.GetBucketCredentials(ctx.Param("id"), ctx.Param("bucket"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": credentials,
+	})
+}
+
+// SetLifecycleRules replaces a bucket's lifecycle/expiry rules
+// @Summary Replace bucket lifecycle rules
+// @Tags minio
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param bucket path string true "Bucket name"
+// @Param rules body dto.MinIOLifecycleRequest true "Lifecycle rules"
+// @Success 200 {object} object{data=[]dto.MinIOLifecycleRule}
+// @Router /services/{id}/minio/buckets/{bucket}/lifecycle [put]
+func (c *MinIOController) SetLifecycleRules(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.MinIOLifecycleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := c.minioService.SetLifecycleRules(ctx.Param("id"), ctx.Param("bucket"), req, userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": req.Rules,
+	})
+}
+
+// DeleteBucket deletes a bucket and its scoped credentials
+// @Summary Delete a MinIO bucket
+// @Tags minio
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param bucket path string true "Bucket name"
+// @Success 200 {object} object{data=object{message=string}}
+// @Router /services/{id}/minio/buckets/{bucket} [delete]
+func (c *MinIOController) DeleteBucket(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.minioService.DeleteBucket(ctx.Param("id"), ctx.Param("bucket"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Bucket deleted successfully",
+		},
+	})
+}
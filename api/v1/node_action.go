@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// RunNodeAction cordons, uncordons or drains a node
+// @Summary Cordon, uncordon or drain a node (admin only)
+// @Description Cordon and uncordon complete immediately. Drain cordons the node and returns 202 while its pods are evicted in the background; evictions blocked by a PodDisruptionBudget are retried until the timeout. DaemonSet and mirror pods are left in place. Poll GET /admin/nodes/{name}/actions for progress.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Node name"
+// @Param request body dto.NodeActionRequest true "Action and drain options"
+// @Success 200 {object} object{data=dto.NodeOperation}
+// @Success 202 {object} object{data=dto.NodeOperation}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /admin/nodes/{name}/actions [post]
+func RunNodeAction(c *gin.Context) {
+	var req dto.NodeActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	operation, err := services.NewNodeStatsService().RunNodeAction(c.Param("name"), req)
+	switch {
+	case errors.Is(err, services.ErrNodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrNodeOperationRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if req.Action == utils.NodeActionDrain {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, gin.H{"data": operation})
+}
+
+// GetNodeOperation reports the progress of the latest action on a node
+// @Summary Get the latest node action and its progress (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Node name"
+// @Success 200 {object} object{data=dto.NodeOperation}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/nodes/{name}/actions [get]
+func GetNodeOperation(c *gin.Context) {
+	operation, err := services.NewNodeStatsService().GetNodeOperation(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": operation})
+}
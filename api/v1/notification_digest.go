@@ -0,0 +1,275 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// GetSMTPSettings returns the mail server notification emails are sent through
+// @Summary Get the SMTP settings (admin only)
+// @Description The password is never returned; hasPassword reports whether one is set. Digest emails are only sent while configured is true.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.SMTPSettingsResponse}
+// @Router /admin/smtp-settings [get]
+func GetSMTPSettings(c *gin.Context) {
+	settings, err := services.NewNotificationDigestService().GetSMTPSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateSMTPSettings changes the mail server notification emails are sent through
+// @Summary Configure SMTP (admin only)
+// @Description security is starttls (default, usually port 587), tls (usually port 465) or none; credentials are never sent over an unencrypted connection.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SMTPSettingsUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=dto.SMTPSettingsResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /admin/smtp-settings [put]
+func UpdateSMTPSettings(c *gin.Context) {
+	var req dto.SMTPSettingsUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	settings, err := services.NewNotificationDigestService().UpdateSMTPSettings(req, userID)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// SendTestEmail checks the SMTP settings by sending an email
+// @Summary Send a test email (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SMTPTestRequest true "Recipient"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 502 {object} object{error=string}
+// @Router /admin/smtp-settings/test [post]
+func SendTestEmail(c *gin.Context) {
+	var req dto.SMTPTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	if err := services.NewNotificationDigestService().SendTestEmail(req.To); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test email sent"})
+}
+
+// DigestController handles the digest email subscriptions of projects
+type DigestController struct {
+	digestService *services.NotificationDigestService
+}
+
+// NewDigestController creates a new digest controller
+func NewDigestController() *DigestController {
+	return &DigestController{
+		digestService: services.NewNotificationDigestService(),
+	}
+}
+
+// RegisterRoutes registers digest subscription routes
+func (c *DigestController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/digests", c.ListSubscriptions)
+		projects.POST("/:id/digests", c.CreateSubscription)
+		projects.GET("/:id/digests/preview", c.PreviewDigest)
+		projects.PATCH("/:id/digests/:digestId", c.UpdateSubscription)
+		projects.DELETE("/:id/digests/:digestId", c.DeleteSubscription)
+	}
+}
+
+// ListSubscriptions returns the digest subscriptions of a project
+// @Summary List the digest subscriptions of a project
+// @Description lastError is set when the last digest could not be sent.
+// @Tags digests
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.DigestSubscription}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/digests [get]
+func (c *DigestController) ListSubscriptions(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	subscriptions, err := c.digestService.ListSubscriptions(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": subscriptions,
+	})
+}
+
+// CreateSubscription subscribes an email address to a project's digest
+// @Summary Subscribe to a project's digest emails
+// @Description Daily digests are sent at 08:00 UTC and cover the previous 24 hours; weekly digests are sent on Mondays at 08:00 UTC and cover the previous 7 days. Each lists deployments per service, failed deployments with their error hints, service incidents and the image size and port warnings of deployments. Requires SMTP to be configured by an admin.
+// @Tags digests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param subscription body dto.DigestSubscriptionRequest true "Recipient and frequency"
+// @Success 201 {object} object{data=models.DigestSubscription}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/digests [post]
+func (c *DigestController) CreateSubscription(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.DigestSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	subscription, err := c.digestService.CreateSubscription(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": subscription,
+	})
+}
+
+// UpdateSubscription changes a digest subscription
+// @Summary Update a digest subscription
+// @Description Changing the frequency starts over with the new period; no catch-up digest is sent.
+// @Tags digests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param digestId path string true "Subscription ID"
+// @Param subscription body dto.DigestSubscriptionUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=models.DigestSubscription}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/digests/{digestId} [patch]
+func (c *DigestController) UpdateSubscription(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.DigestSubscriptionUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	subscription, err := c.digestService.UpdateSubscription(ctx.Param("id"), ctx.Param("digestId"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(digestErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": subscription,
+	})
+}
+
+// DeleteSubscription unsubscribes an email address from a project's digest
+// @Summary Delete a digest subscription
+// @Tags digests
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param digestId path string true "Subscription ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/digests/{digestId} [delete]
+func (c *DigestController) DeleteSubscription(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.digestService.DeleteSubscription(ctx.Param("id"), ctx.Param("digestId"), userID, isAdmin); err != nil {
+		ctx.JSON(digestErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Digest subscription deleted",
+		},
+	})
+}
+
+// PreviewDigest returns a project's digest for the period ending now
+// @Summary Preview a project's digest
+// @Tags digests
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param frequency query string false "daily (default) or weekly"
+// @Success 200 {object} object{data=dto.ProjectDigest}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/digests/preview [get]
+func (c *DigestController) PreviewDigest(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	frequency := ctx.DefaultQuery("frequency", models.DigestFrequencyDaily)
+	if frequency != models.DigestFrequencyDaily && frequency != models.DigestFrequencyWeekly {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "frequency must be daily or weekly",
+		})
+		return
+	}
+
+	digest, err := c.digestService.PreviewDigest(ctx.Param("id"), frequency, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": digest,
+	})
+}
+
+func digestErrorStatus(err error) int {
+	if errors.Is(err, services.ErrDigestSubscriptionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
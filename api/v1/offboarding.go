@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// GetOffboardingPlan lists what deleting the signed-in user's account involves
+// @Summary Get the account deletion plan
+// @Description Lists the owned projects, each of which must be transferred to another user or deleted before the account can be deleted.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.OffboardingPlan}
+// @Router /auth/offboarding [get]
+func GetOffboardingPlan(c *gin.Context) {
+	plan, err := services.NewOffboardingService().GetPlan(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to build the offboarding plan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   plan,
+	})
+}
+
+// DeleteAccount offboards the signed-in user
+// @Summary Delete the account
+// @Description Transfers or deletes every owned project as decided, tears down the namespaces of deleted projects, revokes all sessions and API tokens, and deletes the account. Audit records older than AUDIT_RETENTION_DAYS are deleted, newer ones anonymized. If a project or namespace fails, the account is kept and the request can be repeated.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.OffboardingRequest true "Project decisions and email confirmation"
+// @Success 200 {object} object{status=string,data=dto.OffboardingResult}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 500 {object} object{error=string,data=dto.OffboardingResult}
+// @Router /auth/offboarding [post]
+func DeleteAccount(c *gin.Context) {
+	var req dto.OffboardingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	result, err := services.NewOffboardingService().Offboard(c.GetString("userId"), req)
+	if err != nil {
+		respondOffboardingError(c, result, err)
+		return
+	}
+
+	// The session is revoked along with the account
+	c.SetCookie("access_token", "", -1, "/", "", true, true)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   result,
+	})
+}
+
+// GetUserOffboardingPlan lists what deleting a user's account involves
+// @Summary Get a user's account deletion plan (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} object{data=dto.OffboardingPlan}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/offboarding [get]
+func GetUserOffboardingPlan(c *gin.Context) {
+	plan, err := services.NewOffboardingService().GetPlan(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": plan})
+}
+
+// OffboardUser deletes a user's account on their behalf
+// @Summary Delete a user's account (admin only)
+// @Description Same workflow as /auth/offboarding; confirmEmail repeats the user's email. The last admin account cannot be deleted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body dto.OffboardingRequest true "Project decisions and email confirmation"
+// @Success 200 {object} object{data=dto.OffboardingResult}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Failure 500 {object} object{error=string,data=dto.OffboardingResult}
+// @Router /admin/users/{id}/offboarding [post]
+func OffboardUser(c *gin.Context) {
+	var req dto.OffboardingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	result, err := services.NewOffboardingService().Offboard(c.Param("id"), req)
+	if err != nil {
+		respondOffboardingError(c, result, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// respondOffboardingError maps the errors of an offboarding run; an incomplete run returns
+// its result so the caller sees what failed
+func respondOffboardingError(c *gin.Context, result dto.OffboardingResult, err error) {
+	var fieldErrors utils.FieldErrors
+	switch {
+	case errors.As(err, &fieldErrors):
+		respondValidationProblem(c, err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, services.ErrOffboardingIncomplete):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": result})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
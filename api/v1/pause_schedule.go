@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/services"
+)
+
+// PauseScheduleController handles pause schedules and manual pause/resume of managed services
+type PauseScheduleController struct {
+	pauseScheduleService *services.PauseScheduleService
+}
+
+// NewPauseScheduleController creates a new pause schedule controller
+func NewPauseScheduleController() *PauseScheduleController {
+	return &PauseScheduleController{
+		pauseScheduleService: services.NewPauseScheduleService(),
+	}
+}
+
+// RegisterRoutes registers pause schedule routes
+func (c *PauseScheduleController) RegisterRoutes(router *gin.RouterGroup) {
+	serviceGroup := router.Group("/services/:id")
+	{
+		serviceGroup.GET("/pause-schedule", c.GetSchedule)
+		serviceGroup.PUT("/pause-schedule", c.SaveSchedule)
+		serviceGroup.DELETE("/pause-schedule", c.DeleteSchedule)
+		serviceGroup.POST("/pause", c.Pause)
+		serviceGroup.POST("/resume", c.Resume)
+	}
+}
+
+// GetSchedule returns the pause schedule of a service
+// @Summary Get the pause schedule of a managed service
+// @Tags pause-schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=dto.PauseScheduleResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/pause-schedule [get]
+func (c *PauseScheduleController) GetSchedule(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	schedule, err := c.pauseScheduleService.GetSchedule(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
+
+// SaveSchedule creates or replaces the pause schedule of a service
+// @Summary Create or replace the pause schedule of a managed service
+// @Tags pause-schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param schedule body dto.PauseScheduleRequest true "Schedule"
+// @Success 200 {object} object{data=dto.PauseScheduleResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/pause-schedule [put]
+func (c *PauseScheduleController) SaveSchedule(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PauseScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	schedule, err := c.pauseScheduleService.SaveSchedule(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedule,
+	})
+}
+
+// DeleteSchedule removes the pause schedule of a service
+// @Summary Delete the pause schedule of a managed service
+// @Tags pause-schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Router /services/{id}/pause-schedule [delete]
+func (c *PauseScheduleController) DeleteSchedule(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.pauseScheduleService.DeleteSchedule(ctx.Param("id"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Pause schedule deleted successfully",
+		},
+	})
+}
+
+// Pause scales a managed service to zero until the next scheduled transition
+// @Summary Pause a managed service now
+// @Tags pause-schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=models.Service}
+// @Router /services/{id}/pause [post]
+func (c *PauseScheduleController) Pause(ctx *gin.Context) {
+	c.setState(ctx, models.PauseStatePaused)
+}
+
+// Resume scales a managed service back up until the next scheduled transition
+// @Summary Resume a paused managed service now
+// @Tags pause-schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=models.Service}
+// @Router /services/{id}/resume [post]
+func (c *PauseScheduleController) Resume(ctx *gin.Context) {
+	c.setState(ctx, models.PauseStateRunning)
+}
+
+func (c *PauseScheduleController) setState(ctx *gin.Context, state models.PauseState) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.pauseScheduleService.SetManualState(ctx.Param("id"), state, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
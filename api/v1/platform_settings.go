@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// GetPlatformSettings returns the platform settings
+// @Summary Get the platform settings (admin only)
+// @Description ingressProvider is the ingress controller Ingresses and managed services' TCP ports are rendered for: traefik (default, INGRESS_PROVIDER), nginx, or gateway, which renders Gateway API Gateways, HTTPRoutes and TCPRoutes instead of Ingresses. defaultDomain, publicAddresses, corsAllowedOrigins, clusterIssuer and buildNamespace are empty while the deployment's environment applies.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=models.PlatformSettings}
+// @Router /admin/settings [get]
+func GetPlatformSettings(c *gin.Context) {
+	settings, err := services.NewPlatformSettingsService().GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdatePlatformSettings changes the platform settings
+// @Summary Update the platform settings (admin only)
+// @Description Switching the ingress provider re-applies the Ingresses of every deployed service and registry and re-publishes the TCP ports of managed services; result lists any failures. With nginx or gateway, cache policies and error pages are unavailable, and TCP ports terminating TLS or restricted to source CIDRs stay on the platform's TCP proxy. maxBuildTimeoutMinutes caps the build timeout services may set (default 60); services set above a lowered cap build with the cap. nodePortRange (default SERVICE_NODE_PORT_RANGE, 30000-32767) must match the API server's --service-node-port-range, which is checked with dry-run allocations before saving. managedPortRanges maps managed service types to the ranges their external ports are allocated from, e.g. {"postgresql": {"start": 24000, "end": 24099}}; types without one use the TCP proxy's whole range, ranges must not overlap each other or the NodePort range, and allocated ports are kept when a range changes. buildBackend (default BUILD_BACKEND, kaniko) builds the images of services that do not select one: kaniko, buildkit (rootless BuildKit with build secrets), or external, the build API at BUILD_API_URL; a service needing a capability it lacks (multi-arch images, build secrets) is built with the first backend that has it. defaultDomain (default DEFAULT_DOMAIN), publicAddresses (comma-separated IPs or hostnames the ingress is reached at behind NAT, default INGRESS_PUBLIC_ADDRESSES), corsAllowedOrigins (comma-separated http(s) origins of the dashboard, default CORS_ALLOWED) and clusterIssuer (default letsencrypt-prod, which must exist and be ready) apply at once; an empty string restores the default. buildNamespace (default BUILD_NAMESPACE, build-and-deploy) must exist with the build secrets and applies when the API restarts, reported in result.restartRequired.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PlatformSettingsUpdateRequest true "Settings"
+// @Success 200 {object} object{data=models.PlatformSettings,result=dto.IngressProviderSwitchResult}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /admin/settings [put]
+func UpdatePlatformSettings(c *gin.Context) {
+	var req dto.PlatformSettingsUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	settings, result, err := services.NewPlatformSettingsService().UpdateSettings(req, userID)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings, "result": result})
+}
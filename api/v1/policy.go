@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// ListPolicyRules lists the built-in workload policy rules and their modes
+// @Summary List workload policy rules (admin only)
+// @Description Rules are evaluated against generated specs before they are applied. disabled rules are skipped, audit rules only record violations, enforce rules refuse to apply.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=[]dto.PolicyRuleInfo}
+// @Router /admin/policies [get]
+func ListPolicyRules(c *gin.Context) {
+	rules, err := services.NewPolicyService().ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// UpdatePolicyRule sets the mode and threshold of a workload policy rule
+// @Summary Configure a workload policy rule (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Rule ID (forbid-latest-tag, require-probes, max-external-ports)"
+// @Param request body dto.PolicyRuleUpdateRequest true "Mode and limit"
+// @Success 200 {object} object{data=dto.PolicyRuleInfo}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/policies/{id} [put]
+func UpdatePolicyRule(c *gin.Context) {
+	var req dto.PolicyRuleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	rule, err := services.NewPolicyService().UpdateRule(c.Param("id"), req, userID)
+	if errors.Is(err, services.ErrUnknownPolicyRule) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+// ListPolicyViolations lists recorded policy violations, newest first
+// @Summary List workload policy violations (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param ruleId query string false "Only violations of this rule"
+// @Param serviceId query string false "Only violations of this service"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100)"
+// @Success 200 {object} object{data=dto.PolicyViolationListResponse}
+// @Router /admin/policies/violations [get]
+func ListPolicyViolations(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	violations, err := services.NewPolicyService().ListViolations(c.Query("ruleId"), c.Query("serviceId"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": violations})
+}
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetPreflight checks the cluster prerequisites of the platform
+// @Summary Check cluster prerequisites (admin only)
+// @Description Verifies Traefik's CRDs, cert-manager and its ClusterIssuer, metrics-server, a default StorageClass and free NodePorts without changing anything. Each check that did not pass carries a remedy; ready is false when a check failed, i.e. deploys will fail until it is fixed.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.PreflightReport}
+// @Router /admin/preflight [get]
+func GetPreflight(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": services.NewPreflightService().RunChecks()})
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// ListPriorityTiers lists the scheduling priority tiers
+// @Summary List scheduling priority tiers (admin only)
+// @Description Each tier is backed by a PriorityClass. Environments are mapped to a tier via PUT /environments/{id}; the tier marked builds is used by image build jobs.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=[]models.PriorityTier}
+// @Router /admin/priority-tiers [get]
+func ListPriorityTiers(c *gin.Context) {
+	tiers, err := services.NewPriorityTierService().ListTiers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tiers})
+}
+
+// SavePriorityTier creates or updates a scheduling priority tier
+// @Summary Create or update a scheduling priority tier (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Tier name (DNS label)"
+// @Param request body dto.PriorityTierRequest true "Priority value and preemption"
+// @Success 200 {object} object{data=models.PriorityTier}
+// @Failure 400 {object} object{error=string}
+// @Router /admin/priority-tiers/{name} [put]
+func SavePriorityTier(c *gin.Context) {
+	var req dto.PriorityTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	tier, err := services.NewPriorityTierService().SaveTier(c.Param("name"), req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tier})
+}
+
+// DeletePriorityTier removes a scheduling priority tier that no environment uses
+// @Summary Delete a scheduling priority tier (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Tier name"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /admin/priority-tiers/{name} [delete]
+func DeletePriorityTier(c *gin.Context) {
+	err := services.NewPriorityTierService().DeleteTier(c.Param("name"))
+	if errors.Is(err, services.ErrPriorityTierNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"message": "Priority tier deleted"}})
+}
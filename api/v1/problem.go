@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/utils"
+)
+
+// validationProblemType identifies field validation problems (RFC 7807 "type")
+const validationProblemType = "/problems/validation-error"
+
+func init() {
+	// Report binding errors with JSON field names instead of Go struct field names
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// respondValidationProblem writes an application/problem+json response listing every
+// invalid field. It accepts binding errors and utils.FieldErrors; any other error is
+// reported without field details.
+func respondValidationProblem(ctx *gin.Context, err error) {
+	problem := dto.ProblemDetails{
+		Type:     validationProblemType,
+		Title:    "Invalid request",
+		Status:   http.StatusBadRequest,
+		Detail:   err.Error(),
+		Instance: ctx.Request.URL.Path,
+	}
+
+	var fieldErrors utils.FieldErrors
+	var bindingErrors validator.ValidationErrors
+	switch {
+	case errors.As(err, &fieldErrors):
+		problem.InvalidParams = fieldErrors
+		problem.Detail = "one or more fields are invalid"
+	case errors.As(err, &bindingErrors):
+		for _, fieldError := range bindingErrors {
+			problem.InvalidParams = append(problem.InvalidParams, dto.FieldError{
+				Field:   bindingFieldPath(fieldError),
+				Message: bindingMessage(fieldError),
+			})
+		}
+		problem.Detail = "one or more fields are invalid"
+	}
+	problem.Error = problem.Detail
+	if len(problem.InvalidParams) > 0 {
+		problem.Error = utils.FieldErrors(problem.InvalidParams).Error()
+	}
+
+	ctx.Header("Content-Type", "application/problem+json")
+	ctx.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// bindingFieldPath drops the root struct name from the validator namespace
+func bindingFieldPath(fieldError validator.FieldError) string {
+	namespace := fieldError.Namespace()
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// bindingMessage describes a failed binding rule
+func bindingMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	case "min":
+		return "must be at least " + fieldError.Param()
+	case "max":
+		return "must be at most " + fieldError.Param()
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	}
+	return "failed the '" + fieldError.Tag() + "' rule"
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/middleware"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/services"
 )
@@ -25,7 +27,8 @@ var projectService = services.NewProjectService()
 // @Param search query string false "Search term for project name/description"
 // @Param sortBy query string false "Field to sort by (created_at, updated_at, name)"
 // @Param sortOrder query string false "Sort order (asc or desc)"
-// @Success 200 {object} dto.ProjectListResponse
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.ProjectListResponse}
 // @Router /projects [get]
 func ListProjects(c *gin.Context) {
 	// Get user info from context (set by AuthMiddleware)
@@ -84,7 +87,9 @@ func ListProjects(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Project ID"
-// @Success 200 {object} models.Project
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=models.Project}
+// @Success 304 {string} string "Not Modified"
 // @Router /projects/{id} [get]
 func GetProject(c *gin.Context) {
 	// Get user info from context
@@ -115,6 +120,15 @@ func GetProject(c *gin.Context) {
 		return
 	}
 
+	// Project detail embeds its services, so their versions are part of the ETag
+	etagParts := []string{project.ID, project.UpdatedAt.String()}
+	for _, service := range project.Services {
+		etagParts = append(etagParts, service.ID, service.UpdatedAt.String(), service.Status)
+	}
+	if middleware.CheckETag(c, middleware.ComputeETag(etagParts...)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   project,
@@ -128,7 +142,8 @@ func GetProject(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Project ID"
-// @Success 200 {object} dto.ProjectStatsResponse
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.ProjectStatsResponse}
 // @Router /projects/{id}/stats [get]
 func GetProjectStats(c *gin.Context) {
 	// Get user info from context
@@ -172,7 +187,8 @@ func GetProjectStats(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param project body dto.CreateProjectRequest true "Project Data"
-// @Success 201 {object} dto.ProjectResponse
+// @Security BearerAuth
+// @Success 201 {object} object{status=string,data=dto.ProjectResponse}
 // @Router /projects [post]
 func CreateProject(c *gin.Context) {
 	// Get user info from context
@@ -197,6 +213,7 @@ func CreateProject(c *gin.Context) {
 	project := models.Project{
 		Name:        projectDTO.Name,
 		Description: projectDTO.Description,
+		BaseDomain:  projectDTO.BaseDomain,
 		UserID:      userID.(string),
 		CreatedAt:   now,
 		UpdatedAt:   now,
@@ -218,6 +235,8 @@ func CreateProject(c *gin.Context) {
 		Name:        newProject.Name,
 		Description: newProject.Description,
 		UserID:      newProject.UserID,
+		BaseDomain:  newProject.BaseDomain,
+		Status:      newProject.Status,
 		CreatedAt:   newProject.CreatedAt,
 		UpdatedAt:   newProject.UpdatedAt,
 	}
@@ -236,7 +255,9 @@ func CreateProject(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Project ID"
 // @Param project body dto.UpdateProjectRequest true "Project Data"
-// @Success 200 {object} dto.ProjectResponse
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.ProjectResponse}
+// @Failure 409 {object} object{status=string,message=string} "The project is being deleted"
 // @Router /projects/{id} [put]
 func UpdateProject(c *gin.Context) {
 	// Get user info from context
@@ -272,10 +293,15 @@ func UpdateProject(c *gin.Context) {
 		ID:          projectID, // Set ID yang akan diupdate
 		Name:        projectDTO.Name,
 		Description: projectDTO.Description,
+		BaseDomain:  projectDTO.BaseDomain,
 	}
 
 	// Find and update project dengan parameter yang benar
 	updatedProject, err := projectService.UpdateProject(projectChanges, userID.(string), isAdmin)
+	if errors.Is(err, services.ErrProjectDeleting) {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -290,6 +316,8 @@ func UpdateProject(c *gin.Context) {
 		Name:        updatedProject.Name,
 		Description: updatedProject.Description,
 		UserID:      updatedProject.UserID,
+		BaseDomain:  updatedProject.BaseDomain,
+		Status:      updatedProject.Status,
 		CreatedAt:   updatedProject.CreatedAt,
 		UpdatedAt:   updatedProject.UpdatedAt,
 	}
@@ -302,12 +330,14 @@ func UpdateProject(c *gin.Context) {
 
 // DeleteProject godoc
 // @Summary Delete a project
-// @Description Delete an existing project
+// @Description Start deleting a project. The project is marked deleting and the namespace of each environment is torn down in the background, retried until it is gone; the project is deleted once every namespace is. Deleting a project that is being deleted retries the namespaces that failed.
 // @Tags projects
 // @Accept json
 // @Produce json
 // @Param id path string true "Project ID"
-// @Success 200 {object} map[string]interface{}
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,message=string,data=dto.ProjectDeletionResponse} "The project is deleted"
+// @Success 202 {object} object{status=string,message=string,data=dto.ProjectDeletionResponse} "The namespaces are being torn down"
 // @Router /projects/{id} [delete]
 func DeleteProject(c *gin.Context) {
 	// Get user info from context
@@ -329,7 +359,7 @@ func DeleteProject(c *gin.Context) {
 	}
 
 	// Delete project
-	err := projectService.DeleteProject(projectID, userID.(string), isAdmin)
+	progress, err := projectService.DeleteProject(projectID, userID.(string), isAdmin)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -339,8 +369,61 @@ func DeleteProject(c *gin.Context) {
 		return
 	}
 
+	if progress.Status != "deleted" {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "success",
+			"message": "Project is being deleted",
+			"data":    progress,
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Project deleted successfully",
+		"data":    progress,
+	})
+}
+
+// GetProjectDeletion godoc
+// @Summary Get the deletion progress of a project
+// @Description How many namespaces of a project being deleted are torn down, with the state, attempts and last error of each. Status is deleted once the project is gone.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.ProjectDeletionResponse}
+// @Router /projects/{id}/deletion [get]
+func GetProjectDeletion(c *gin.Context) {
+	// Get user info from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "User not authenticated"})
+		return
+	}
+
+	// Check if user is admin
+	role, _ := c.Get("role")
+	isAdmin := role == "admin"
+
+	// Get project ID from URL
+	projectID := c.Param("id")
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Project ID is required"})
+		return
+	}
+
+	progress, err := projectService.GetDeletionProgress(projectID, userID.(string), isAdmin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Project not found or access denied: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   progress,
 	})
 }
@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ProjectSecretController handles the secrets vault of a project
+type ProjectSecretController struct {
+	secretService *services.ProjectSecretService
+}
+
+// NewProjectSecretController creates a new project secret controller
+func NewProjectSecretController() *ProjectSecretController {
+	return &ProjectSecretController{
+		secretService: services.NewProjectSecretService(),
+	}
+}
+
+// RegisterRoutes registers project secret routes
+func (c *ProjectSecretController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/secrets", c.ListSecrets)
+		projects.POST("/:id/secrets", c.CreateSecret)
+		projects.PUT("/:id/secrets/:secretId", c.UpdateSecret)
+		projects.DELETE("/:id/secrets/:secretId", c.DeleteSecret)
+	}
+}
+
+// ListSecrets returns the secrets vault entries of a project
+// @Summary List the secrets vault entries of a project
+// @Description Values are never returned.
+// @Tags project-secrets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.ProjectSecret}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/secrets [get]
+func (c *ProjectSecretController) ListSecrets(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	secrets, err := c.secretService.ListSecrets(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": secrets,
+	})
+}
+
+// CreateSecret adds a value to the secrets vault of a project
+// @Summary Add a secret to the project vault
+// @Description Service env var values reference it as ${secret:name}, e.g. postgres://app:${secret:db_password}@db:5432/app. References are resolved at deploy time into a Secret the container reads the variable from.
+// @Tags project-secrets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param secret body dto.ProjectSecretRequest true "Secret name and value"
+// @Success 201 {object} object{data=models.ProjectSecret}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/secrets [post]
+func (c *ProjectSecretController) CreateSecret(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ProjectSecretRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateProjectSecretRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	secret, err := c.secretService.CreateSecret(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": secret,
+	})
+}
+
+// UpdateSecret replaces the value of a project secret
+// @Summary Replace the value of a project secret
+// @Description Services referencing the secret pick up the new value on their next deploy.
+// @Tags project-secrets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param secretId path string true "Project secret ID"
+// @Param secret body dto.ProjectSecretUpdateRequest true "New value"
+// @Success 200 {object} object{data=models.ProjectSecret}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/secrets/{secretId} [put]
+func (c *ProjectSecretController) UpdateSecret(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ProjectSecretUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	secret, err := c.secretService.UpdateSecret(ctx.Param("id"), ctx.Param("secretId"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(projectSecretErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": secret,
+	})
+}
+
+// DeleteSecret removes a secret from the project vault
+// @Summary Delete a project secret
+// @Description Running services keep their values; deploys of services still referencing the secret fail.
+// @Tags project-secrets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param secretId path string true "Project secret ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/secrets/{secretId} [delete]
+func (c *ProjectSecretController) DeleteSecret(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.secretService.DeleteSecret(ctx.Param("id"), ctx.Param("secretId"), userID, isAdmin); err != nil {
+		ctx.JSON(projectSecretErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Project secret deleted",
+		},
+	})
+}
+
+func projectSecretErrorStatus(err error) int {
+	if errors.Is(err, services.ErrProjectSecretNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,212 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/services"
+)
+
+// PromotionController handles promoting deployments between the environments of a project
+type PromotionController struct {
+	promotionService *services.PromotionService
+}
+
+// NewPromotionController creates a new promotion controller
+func NewPromotionController() *PromotionController {
+	return &PromotionController{
+		promotionService: services.NewPromotionService(),
+	}
+}
+
+// RegisterRoutes registers promotion routes
+func (c *PromotionController) RegisterRoutes(router *gin.RouterGroup) {
+	promotions := router.Group("/promotions")
+	{
+		promotions.POST("/preview", c.PreviewPromotion)
+		promotions.POST("", c.Promote)
+		promotions.POST("/:id/approve", c.ApprovePromotion)
+		promotions.POST("/:id/reject", c.RejectPromotion)
+	}
+	router.GET("/projects/:id/promotions", c.ListPromotions)
+}
+
+// PreviewPromotion shows what a promotion would deploy
+// @Summary Preview a promotion
+// @Description Shows the digest-pinned image a promotion of the deployment to the target environment would roll out to the service of the same name there, how that service's runtime config and env vars differ from the source's (secret values are masked), and whether the target environment requires approval. Nothing is deployed.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param promotion body dto.PromotionRequest true "Deployment and target environment"
+// @Success 200 {object} object{data=dto.PromotionPreview}
+// @Failure 400 {object} object{error=string}
+// @Router /promotions/preview [post]
+func (c *PromotionController) PreviewPromotion(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	preview, err := c.promotionService.Preview(req.DeploymentID, req.TargetEnvironmentID, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": preview,
+	})
+}
+
+// Promote promotes a deployment to another environment
+// @Summary Promote a deployment
+// @Description Rolls the exact image of a successful deployment, pinned to its digest, out to the service of the same name in the target environment, without a build. When the target environment requires approval the promotion waits as pending_approval until someone other than the requester approves it.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param promotion body dto.PromotionRequest true "Deployment and target environment"
+// @Success 201 {object} object{data=models.Promotion}
+// @Failure 400 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /promotions [post]
+func (c *PromotionController) Promote(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	promotion, err := c.promotionService.Promote(req, userID, isAdmin)
+	if err != nil {
+		if respondDeployLocked(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": promotion,
+	})
+}
+
+// ApprovePromotion approves a promotion and deploys it
+// @Summary Approve a promotion
+// @Description Approves a promotion waiting for approval and rolls it out. The requester cannot approve their own promotion.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Param review body dto.PromotionReviewRequest false "Review comment"
+// @Success 200 {object} object{data=models.Promotion}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /promotions/{id}/approve [post]
+func (c *PromotionController) ApprovePromotion(ctx *gin.Context) {
+	c.review(ctx, c.promotionService.Approve)
+}
+
+// RejectPromotion rejects a promotion
+// @Summary Reject a promotion
+// @Description Rejects a promotion waiting for approval; nothing is deployed.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Param review body dto.PromotionReviewRequest false "Review comment"
+// @Success 200 {object} object{data=models.Promotion}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /promotions/{id}/reject [post]
+func (c *PromotionController) RejectPromotion(ctx *gin.Context) {
+	c.review(ctx, c.promotionService.Reject)
+}
+
+// ListPromotions returns the promotions of a project
+// @Summary List promotions of a project
+// @Description Newest first.
+// @Tags promotions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param status query string false "pending_approval, approved, rejected, deployed or failed"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.PromotionListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/promotions [get]
+func (c *PromotionController) ListPromotions(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	promotions, err := c.promotionService.ListPromotions(ctx.Param("id"), ctx.Query("status"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Project not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": promotions,
+	})
+}
+
+// review handles approving and rejecting, which differ only in the decision
+func (c *PromotionController) review(ctx *gin.Context, decide func(promotionID string, comment string, userID string, isAdmin bool) (models.Promotion, error)) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PromotionReviewRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondValidationProblem(ctx, err)
+			return
+		}
+	}
+
+	promotion, err := decide(ctx.Param("id"), req.Comment, userID, isAdmin)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, gin.H{
+			"data": promotion,
+		})
+	case errors.Is(err, services.ErrPromotionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrPromotionNotPending):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrPromotionSelfApprove):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case respondDeployLocked(ctx, err):
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// PullCredentialController handles logins for private external registries
+type PullCredentialController struct {
+	credentialService *services.PullCredentialService
+}
+
+// NewPullCredentialController creates a new pull credential controller
+func NewPullCredentialController() *PullCredentialController {
+	return &PullCredentialController{
+		credentialService: services.NewPullCredentialService(),
+	}
+}
+
+// RegisterRoutes registers pull credential routes
+func (c *PullCredentialController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/pull-credentials", c.ListCredentials)
+		projects.POST("/:id/pull-credentials", c.CreateCredential)
+		projects.PUT("/:id/pull-credentials/:credentialId", c.UpdateCredential)
+		projects.DELETE("/:id/pull-credentials/:credentialId", c.DeleteCredential)
+	}
+}
+
+// ListCredentials returns the pull credentials of a project
+// @Summary List the private registry pull credentials of a project
+// @Tags pull-credentials
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.PullCredential}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/pull-credentials [get]
+func (c *PullCredentialController) ListCredentials(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	credentials, err := c.credentialService.ListCredentials(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": credentials,
+	})
+}
+
+// CreateCredential registers a pull credential for a project or one of its environments
+// @Summary Register a private registry pull credential
+// @Description Stores the login as a dockerconfigjson Secret that is copied into the environment namespaces and attached to generated workloads as imagePullSecrets on their next deploy.
+// @Tags pull-credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param credential body dto.PullCredentialRequest true "Registry login; omit environmentId to apply to every environment"
+// @Success 201 {object} object{data=models.PullCredential}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/pull-credentials [post]
+func (c *PullCredentialController) CreateCredential(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PullCredentialRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidatePullCredentialRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	credential, err := c.credentialService.CreateCredential(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": credential,
+	})
+}
+
+// UpdateCredential rotates the login of a pull credential
+// @Summary Rotate the login of a pull credential
+// @Tags pull-credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param credentialId path string true "Pull credential ID"
+// @Param credential body dto.PullCredentialUpdateRequest true "New password, and optionally username"
+// @Success 200 {object} object{data=models.PullCredential}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/pull-credentials/{credentialId} [put]
+func (c *PullCredentialController) UpdateCredential(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.PullCredentialUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	credential, err := c.credentialService.UpdateCredential(ctx.Param("id"), ctx.Param("credentialId"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(pullCredentialErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": credential,
+	})
+}
+
+// DeleteCredential removes a pull credential and its Secrets
+// @Summary Delete a pull credential
+// @Tags pull-credentials
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param credentialId path string true "Pull credential ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/pull-credentials/{credentialId} [delete]
+func (c *PullCredentialController) DeleteCredential(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.credentialService.DeleteCredential(ctx.Param("id"), ctx.Param("credentialId"), userID, isAdmin); err != nil {
+		ctx.JSON(pullCredentialErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Pull credential deleted",
+		},
+	})
+}
+
+func pullCredentialErrorStatus(err error) int {
+	if errors.Is(err, services.ErrPullCredentialNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
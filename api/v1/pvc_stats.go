@@ -8,6 +8,13 @@ import (
 )
 
 // GetPVCStats returns statistics about Persistent Volume Claims in the cluster
+// @Summary Get PVC statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.PVCStatsResponse
+// @Router /admin/stats/pvc [get]
 func GetPVCStats(c *gin.Context) {
 	pvcStatsService := services.NewPVCStatsService()
 	
@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// RabbitMQController handles vhost/user provisioning for managed RabbitMQ
+type RabbitMQController struct {
+	rabbitMQService *services.RabbitMQService
+}
+
+// NewRabbitMQController creates a new RabbitMQ controller
+func NewRabbitMQController() *RabbitMQController {
+	return &RabbitMQController{
+		rabbitMQService: services.NewRabbitMQService(),
+	}
+}
+
+// RegisterRoutes registers RabbitMQ routes
+func (c *RabbitMQController) RegisterRoutes(router *gin.RouterGroup) {
+	rabbitGroup := router.Group("/services/:id/rabbitmq")
+	{
+		rabbitGroup.GET("/vhosts", c.ListVhosts)
+		rabbitGroup.POST("/vhosts", c.CreateVhost)
+		rabbitGroup.DELETE("/vhosts/:vhost", c.DeleteVhost)
+		rabbitGroup.GET("/users", c.ListUsers)
+		rabbitGroup.POST("/users", c.CreateUser)
+		rabbitGroup.DELETE("/users/:username", c.DeleteUser)
+	}
+}
+
+// getRequestUser extracts the user ID and admin flag set by AuthMiddleware
+func getRequestUser(ctx *gin.Context) (string, bool) {
+	userIDValue, _ := ctx.Get("userId")
+	userID := userIDValue.(string)
+	roleValue, _ := ctx.Get("role")
+	role, _ := roleValue.(string)
+	return userID, role == "admin"
+}
+
+// ListVhosts lists the vhosts on the broker
+// @Summary List RabbitMQ vhosts
+// @Tags rabbitmq
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]dto.RabbitMQVhostResponse}
+// @Router /services/{id}/rabbitmq/vhosts [get]
+func (c *RabbitMQController) ListVhosts(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	vhosts, err := c.rabbitMQService.ListVhosts(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": vhosts,
+	})
+}
+
+// CreateVhost creates a new vhost
+// @Summary Create a RabbitMQ vhost
+// @Tags rabbitmq
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param vhost body dto.RabbitMQVhostRequest true "Vhost"
+// @Success 201 {object} object{data=dto.RabbitMQVhostResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/rabbitmq/vhosts [post]
+func (c *RabbitMQController) CreateVhost(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.RabbitMQVhostRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	vhost, err := c.rabbitMQService.CreateVhost(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": vhost,
+	})
+}
+
+// DeleteVhost deletes a vhost and the users provisioned for it
+// @Summary Delete a RabbitMQ vhost
+// @Tags rabbitmq
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param vhost path string true "Vhost name"
+// @Success 200 {object} object{data=object{message=string}}
+// @Router /services/{id}/rabbitmq/vhosts/{vhost} [delete]
+func (c *RabbitMQController) DeleteVhost(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.rabbitMQService.DeleteVhost(ctx.Param("id"), ctx.Param("vhost"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Vhost deleted successfully",
+		},
+	})
+}
+
+// ListUsers lists users provisioned through the API with their connection strings
+// @Summary List provisioned RabbitMQ users
+// @Tags rabbitmq
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]dto.RabbitMQUserResponse}
+// @Router /services/{id}/rabbitmq/users [get]
+func (c *RabbitMQController) ListUsers(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	users, err := c.rabbitMQService.ListUsers(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": users,
+	})
+}
+
+// CreateUser creates a vhost-scoped user
+// @Summary Create a vhost-scoped RabbitMQ user
+// @Tags rabbitmq
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param user body dto.RabbitMQUserRequest true "User"
+// @Success 201 {object} object{data=dto.RabbitMQUserResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/rabbitmq/users [post]
+func (c *RabbitMQController) CreateUser(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.RabbitMQUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := c.rabbitMQService.CreateUser(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": user,
+	})
+}
+
+// DeleteUser deletes a provisioned user
+// @Summary Delete a RabbitMQ user
+// @Tags rabbitmq
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param username path string true "Username"
+// @Success 200 {object} object{data=object{message=string}}
+// @Router /services/{id}/rabbitmq/users/{username} [delete]
+func (c *RabbitMQController) DeleteUser(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.rabbitMQService.DeleteUser(ctx.Param("id"), ctx.Param("username"), userID, isAdmin); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "User deleted successfully",
+		},
+	})
+}
@@ -33,6 +33,16 @@ func (rc *RegistryController) RegisterRoutes(router *gin.RouterGroup) {
 		// Registry details with K8s information
 		registryGroup.GET("/:id/details", rc.controller.GetRegistryDetails)
 		
+		// Dependency images used by build jobs, with a re-run for failed builds
+		registryGroup.GET("/:id/dependencies", rc.controller.GetDependencies)
+		registryGroup.POST("/:id/dependencies/rerun", rc.controller.RerunDependencies)
+		
+		// Switch between volume and S3 storage, optionally migrating existing blobs
+		registryGroup.PUT("/:id/storage", rc.controller.UpdateStorage)
+		
+		// Copy an image to another registry, streaming progress as server-sent events
+		registryGroup.POST("/:id/copy", rc.controller.CopyImage)
+		
 		// Stream build logs endpoint - this uses server-sent events for real-time updates
 		registryGroup.GET("/:id/logs/stream", rc.controller.StreamBuildLogs)
 	}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/api/openapi"
 	"github.com/pendeploy-simple/middleware"
 	"github.com/pendeploy-simple/controllers"
 )
@@ -10,15 +11,47 @@ import (
 func RegisterRoutes(router *gin.RouterGroup) {
 	// Health check endpoint
 	router.GET("/health", HealthCheck)
+	router.GET("/healthz", Healthz)
+
+	// OpenAPI document generated from handler annotations (see api/openapi)
+	router.GET("/openapi.json", openapi.ServeSpec)
 
 	// Auth endpoints
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/register", Register)
-		authGroup.POST("/login", Login)
+		authGroup.POST("/login", middleware.LoginThrottleMiddleware(), Login)
 		authGroup.POST("/logout", Logout)
 		// Use auth middleware here only for the /me endpoint
 		authGroup.GET("/me", middleware.AuthMiddleware(), GetCurrentUser)
+
+		// Device-code login for CLIs: the two public endpoints are used by the CLI, the
+		// others by the signed-in user approving it in the dashboard
+		authGroup.POST("/device/code", RequestDeviceCode)
+		authGroup.POST("/device/token", ExchangeDeviceToken)
+		authGroup.GET("/device", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), GetDeviceAuthorization)
+		authGroup.POST("/device/approve", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), DecideDeviceAuthorization)
+
+		// API tokens issued to automation
+		authGroup.GET("/tokens", middleware.AuthMiddleware(), ListAPITokens)
+		authGroup.DELETE("/tokens/:id", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), RevokeAPIToken)
+
+		// Signed-in sessions, revocable before their token expires
+		authGroup.GET("/sessions", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), ListSessions)
+		authGroup.DELETE("/sessions", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), RevokeAllSessions)
+		authGroup.DELETE("/sessions/:id", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), RevokeSession)
+		authGroup.POST("/impersonation/end", middleware.AuthMiddleware(), EndImpersonation)
+
+		// TOTP two-factor authentication of the signed-in user
+		authGroup.GET("/2fa", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), GetTwoFactorStatus)
+		authGroup.POST("/2fa/setup", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), SetupTwoFactor)
+		authGroup.POST("/2fa/enable", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), EnableTwoFactor)
+		authGroup.POST("/2fa/disable", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), DisableTwoFactor)
+		authGroup.POST("/2fa/recovery-codes", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), RegenerateRecoveryCodes)
+
+		// Account deletion: owned projects are transferred or deleted first
+		authGroup.GET("/offboarding", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), GetOffboardingPlan)
+		authGroup.POST("/offboarding", middleware.AuthMiddleware(), middleware.SessionOnlyMiddleware(), DeleteAccount)
 	}
 
 	// Project endpoints - protected by AuthMiddleware
@@ -27,10 +60,13 @@ func RegisterRoutes(router *gin.RouterGroup) {
 	{
 		projectGroup.GET("", ListProjects)
 		projectGroup.POST("", CreateProject)
-		projectGroup.GET("/:id", GetProject)
+		projectGroup.GET("/:id", middleware.ResponseCache(), GetProject)
 		projectGroup.PUT("/:id", UpdateProject)
 		projectGroup.DELETE("/:id", DeleteProject)
-		projectGroup.GET("/:id/stats", GetProjectStats)
+		projectGroup.GET("/:id/deletion", GetProjectDeletion)
+		projectGroup.GET("/:id/stats", middleware.ResponseCache(), GetProjectStats)
+		projectGroup.GET("/:id/build-usage", GetProjectBuildUsage)
+		projectGroup.GET("/:id/archive-usage", GetProjectArchiveUsage)
 	}
 
 	// Environment endpoints - protected by AuthMiddleware
@@ -43,6 +79,120 @@ func RegisterRoutes(router *gin.RouterGroup) {
 	serviceController := NewServiceController()
 	serviceController.RegisterRoutes(authRouter)
 	
+	// Database console endpoints - protected by AuthMiddleware
+	consoleController := NewConsoleController()
+	consoleController.RegisterRoutes(authRouter)
+	
+	// RabbitMQ vhost/user endpoints - protected by AuthMiddleware
+	rabbitMQController := NewRabbitMQController()
+	rabbitMQController.RegisterRoutes(authRouter)
+	
+	// MinIO bucket endpoints - protected by AuthMiddleware
+	minioController := NewMinIOController()
+	minioController.RegisterRoutes(authRouter)
+	
+	// Managed service pause schedule endpoints - protected by AuthMiddleware
+	pauseScheduleController := NewPauseScheduleController()
+	pauseScheduleController.RegisterRoutes(authRouter)
+	
+	// Uploaded TLS certificate endpoints - protected by AuthMiddleware
+	customCertificateController := NewCustomCertificateController()
+	customCertificateController.RegisterRoutes(authRouter)
+	
+	// Private registry pull credential endpoints - protected by AuthMiddleware
+	pullCredentialController := NewPullCredentialController()
+	pullCredentialController.RegisterRoutes(authRouter)
+	
+	// Project secrets vault endpoints - protected by AuthMiddleware
+	projectSecretController := NewProjectSecretController()
+	projectSecretController.RegisterRoutes(authRouter)
+	
+	// Project error page endpoints - protected by AuthMiddleware
+	errorPageController := NewErrorPageController()
+	errorPageController.RegisterRoutes(authRouter)
+	
+	// External secret store endpoints - protected by AuthMiddleware
+	secretStoreController := NewSecretStoreController()
+	secretStoreController.RegisterRoutes(authRouter)
+	
+	// Project log drain endpoints - protected by AuthMiddleware
+	logDrainController := NewLogDrainController()
+	logDrainController.RegisterRoutes(authRouter)
+	
+	// Project deploy lock endpoints - protected by AuthMiddleware
+	deployLockController := NewDeployLockController()
+	deployLockController.RegisterRoutes(authRouter)
+	
+	// Service load test endpoints - protected by AuthMiddleware
+	loadTestController := NewLoadTestController()
+	loadTestController.RegisterRoutes(authRouter)
+	
+	// Scheduled deployment endpoints - protected by AuthMiddleware
+	scheduledDeploymentController := NewScheduledDeploymentController()
+	scheduledDeploymentController.RegisterRoutes(authRouter)
+	
+	// Cross-environment promotion endpoints - protected by AuthMiddleware
+	promotionController := NewPromotionController()
+	promotionController.RegisterRoutes(authRouter)
+	
+	// Service traffic analytics endpoints - protected by AuthMiddleware
+	trafficController := NewTrafficController()
+	trafficController.RegisterRoutes(authRouter)
+	
+	// Service hostname propagation endpoints - protected by AuthMiddleware
+	domainPropagationController := NewDomainPropagationController()
+	domainPropagationController.RegisterRoutes(authRouter)
+	
+	// Project cost estimate endpoints - protected by AuthMiddleware
+	costController := NewCostController()
+	costController.RegisterRoutes(authRouter)
+	
+	// Uptime monitor, status page and incident endpoints - protected by AuthMiddleware;
+	// the rendered status page itself is public
+	uptimeController := NewUptimeController()
+	uptimeController.RegisterRoutes(authRouter)
+	uptimeController.RegisterPublicRoutes(router)
+	
+	// Environment share link endpoints - protected by AuthMiddleware; the links themselves
+	// are opened without an account
+	shareLinkController := NewShareLinkController()
+	shareLinkController.RegisterRoutes(authRouter)
+	shareLinkController.RegisterPublicRoutes(router)
+	
+	// Project GitOps configuration endpoints - protected by AuthMiddleware; the push
+	// webhook is authenticated by its secret
+	gitopsController := NewGitOpsController()
+	gitopsController.RegisterRoutes(authRouter)
+	gitopsController.RegisterPublicRoutes(router)
+	
+	// Dashboard widget metric query endpoints - protected by AuthMiddleware
+	metricQueryController := NewMetricQueryController()
+	metricQueryController.RegisterRoutes(authRouter)
+	
+	// Environment maintenance task endpoints - protected by AuthMiddleware
+	maintenanceTaskController := NewMaintenanceTaskController()
+	maintenanceTaskController.RegisterRoutes(authRouter)
+	
+	// Service storage expansion endpoints - protected by AuthMiddleware
+	storageExpansionController := NewStorageExpansionController()
+	storageExpansionController.RegisterRoutes(authRouter)
+	
+	// Service move endpoints - protected by AuthMiddleware
+	serviceMoveController := NewServiceMoveController()
+	serviceMoveController.RegisterRoutes(authRouter)
+	
+	// Project digest email endpoints - protected by AuthMiddleware
+	digestController := NewDigestController()
+	digestController.RegisterRoutes(authRouter)
+	
+	// Project activity feed endpoint - protected by AuthMiddleware
+	activityController := NewActivityController()
+	activityController.RegisterRoutes(authRouter)
+	
+	// Global search endpoint - protected by AuthMiddleware
+	searchController := NewSearchController()
+	searchController.RegisterRoutes(authRouter)
+	
 	// Registry endpoints - protected by AuthMiddleware
 	registryController := NewRegistryController()
 	registryController.RegisterRoutes(authRouter)
@@ -51,6 +201,12 @@ func RegisterRoutes(router *gin.RouterGroup) {
 	gitDeployController := controllers.NewDeploymentController()
 	gitDeployController.RegisterRoutes(authRouter)
 
+	// Base domains selectable per project - protected by AuthMiddleware
+	authRouter.GET("/domains", ListDomains)
+
+	// Managed service types and versions selectable at creation - protected by AuthMiddleware
+	authRouter.GET("/managed-services/catalog", GetManagedServiceCatalog)
+
 	// Admin endpoints - protected by AdminMiddleware
 	statsGroup := router.Group("/admin")
 	// Apply admin middleware to ensure only admins can access these routes
@@ -64,5 +220,61 @@ func RegisterRoutes(router *gin.RouterGroup) {
 		statsGroup.GET("/stats/certificates", GetCertificateStats)
 		statsGroup.GET("/stats/pvc", GetPVCStats)
 		statsGroup.GET("/cluster/info", GetClusterInfo)
+		statsGroup.GET("/preflight", GetPreflight)
+		statsGroup.GET("/capacity/forecast", GetCapacityForecast)
+		statsGroup.POST("/nodes/:name/actions", RunNodeAction)
+		statsGroup.GET("/nodes/:name/actions", GetNodeOperation)
+		statsGroup.GET("/events/stream", StreamClusterEvents)
+		statsGroup.POST("/janitor/run", RunJanitor)
+		statsGroup.GET("/migrations", GetMigrationStatus)
+		statsGroup.GET("/domains", CheckDomains)
+		statsGroup.GET("/dns01", GetDNS01Status)
+		statsGroup.POST("/dns01/issuer", ApplyDNS01Issuer)
+		statsGroup.GET("/policies", ListPolicyRules)
+		statsGroup.GET("/policies/violations", ListPolicyViolations)
+		statsGroup.PUT("/policies/:id", UpdatePolicyRule)
+		statsGroup.GET("/priority-tiers", ListPriorityTiers)
+		statsGroup.PUT("/priority-tiers/:name", SavePriorityTier)
+		statsGroup.DELETE("/priority-tiers/:name", DeletePriorityTier)
+		statsGroup.GET("/auth-policy", GetAuthPolicy)
+		statsGroup.PUT("/auth-policy", UpdateAuthPolicy)
+		statsGroup.GET("/smtp-settings", GetSMTPSettings)
+		statsGroup.PUT("/smtp-settings", UpdateSMTPSettings)
+		statsGroup.POST("/smtp-settings/test", SendTestEmail)
+		statsGroup.GET("/tenant-isolation", GetTenantIsolation)
+		statsGroup.PUT("/tenant-isolation", UpdateTenantIsolation)
+		statsGroup.GET("/tenant-isolation/report", GetTenantIsolationReport)
+		statsGroup.GET("/settings", GetPlatformSettings)
+		statsGroup.PUT("/settings", UpdatePlatformSettings)
+		statsGroup.GET("/login-attempts", ListLoginAttempts)
+		statsGroup.POST("/users/:id/unlock", UnlockUser)
+		statsGroup.DELETE("/users/:id/two-factor", ResetUserTwoFactor)
+		statsGroup.GET("/users/:id/sessions", ListUserSessions)
+		statsGroup.DELETE("/users/:id/sessions", RevokeUserSessions)
+		statsGroup.POST("/users/:id/impersonate", middleware.SessionOnlyMiddleware(), StartImpersonation)
+		statsGroup.GET("/users/:id/offboarding", GetUserOffboardingPlan)
+		statsGroup.POST("/users/:id/offboarding", middleware.SessionOnlyMiddleware(), OffboardUser)
+		statsGroup.GET("/impersonations", ListImpersonationAudit)
+		statsGroup.GET("/services/:id/manifests", InspectServiceManifests)
+		statsGroup.GET("/services/:id/events", InspectServiceEvents)
+		statsGroup.GET("/services/:id/logs", InspectServiceLogs)
+		statsGroup.GET("/service-inspections", ListServiceInspectionAudit)
+		statsGroup.GET("/build-usage", GetBuildUsageOverview)
+		statsGroup.PUT("/projects/:id/build-quota", SaveBuildQuota)
+		statsGroup.GET("/dependency-images", ListDependencyImages)
+		statsGroup.POST("/dependency-images/check", CheckDependencyImages)
+		statsGroup.POST("/dependency-images/rollout", RollOutDependencyImages)
+		statsGroup.PUT("/dependency-images/:name/candidate", ProposeDependencyImageUpdate)
+		statsGroup.DELETE("/dependency-images/:name/candidate", DismissDependencyImageUpdate)
+		statsGroup.POST("/dependency-images/:name/approve", ApproveDependencyImageUpdate)
+		statsGroup.DELETE("/projects/:id/build-quota", DeleteBuildQuota)
+		statsGroup.GET("/archive-retention", GetArchiveRetention)
+		statsGroup.PUT("/archive-retention", UpdateArchiveRetention)
+		statsGroup.POST("/archive-retention/purge", PurgeArchives)
+		statsGroup.GET("/archive-usage", GetArchiveUsageOverview)
+		statsGroup.PUT("/projects/:id/archive-quota", SaveArchiveQuota)
+		statsGroup.DELETE("/projects/:id/archive-quota", DeleteArchiveQuota)
+		statsGroup.GET("/reports", GetDeploymentReport)
+		statsGroup.POST("/reports/rollup", RecomputeDeploymentReport)
 	}
 }
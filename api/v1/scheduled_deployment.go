@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// ScheduledDeploymentController handles deployments scheduled for a future time
+type ScheduledDeploymentController struct {
+	scheduledDeploymentService *services.ScheduledDeploymentService
+}
+
+// NewScheduledDeploymentController creates a new scheduled deployment controller
+func NewScheduledDeploymentController() *ScheduledDeploymentController {
+	return &ScheduledDeploymentController{
+		scheduledDeploymentService: services.NewScheduledDeploymentService(),
+	}
+}
+
+// RegisterRoutes registers scheduled deployment routes
+func (c *ScheduledDeploymentController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.POST("/:id/scheduled-deployments", c.ScheduleDeployment)
+		svc.GET("/:id/scheduled-deployments", c.ListScheduledDeployments)
+		svc.DELETE("/:id/scheduled-deployments/:scheduleId", c.CancelScheduledDeployment)
+	}
+}
+
+// ScheduleDeployment schedules a deployment of a git service
+// @Summary Schedule a deployment
+// @Description Builds commitSha (the branch head when empty), or rolls out the image of deploymentId, at scheduledAt. A local time is read in timezone; an RFC3339 time keeps its offset. Deploy locks, archived environments and build quotas are checked when the deployment starts; a deployment that cannot start is marked failed with the reason.
+// @Tags deployments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param schedule body dto.ScheduledDeploymentRequest true "Time and what to deploy"
+// @Success 201 {object} object{data=dto.ScheduledDeploymentResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/scheduled-deployments [post]
+func (c *ScheduledDeploymentController) ScheduleDeployment(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ScheduledDeploymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	schedule, err := c.scheduledDeploymentService.Schedule(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": schedule,
+	})
+}
+
+// ListScheduledDeployments returns the scheduled deployments of a service
+// @Summary List scheduled deployments of a service
+// @Description Latest scheduled time first.
+// @Tags deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param status query string false "scheduled, started, failed or cancelled"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.ScheduledDeploymentListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/scheduled-deployments [get]
+func (c *ScheduledDeploymentController) ListScheduledDeployments(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	schedules, err := c.scheduledDeploymentService.ListScheduled(ctx.Param("id"), ctx.Query("status"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": schedules,
+	})
+}
+
+// CancelScheduledDeployment cancels a pending scheduled deployment
+// @Summary Cancel a scheduled deployment
+// @Tags deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param scheduleId path string true "Scheduled deployment ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/scheduled-deployments/{scheduleId} [delete]
+func (c *ScheduledDeploymentController) CancelScheduledDeployment(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	err := c.scheduledDeploymentService.Cancel(ctx.Param("id"), ctx.Param("scheduleId"), userID, isAdmin)
+	if errors.Is(err, services.ErrScheduledDeploymentNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Scheduled deployment cancelled",
+	})
+}
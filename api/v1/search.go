@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// SearchController handles the global search endpoint
+type SearchController struct {
+	searchService *services.SearchService
+}
+
+// NewSearchController creates a new search controller
+func NewSearchController() *SearchController {
+	return &SearchController{
+		searchService: services.NewSearchService(),
+	}
+}
+
+// RegisterRoutes registers search routes
+func (c *SearchController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/search", c.Search)
+}
+
+// Search returns projects, services, deployments and domains matching ?q=
+// @Summary Search projects, services, deployments and domains
+// @Tags search
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search term (at least 2 characters)"
+// @Param limit query int false "Results per category (default 10, max 50)"
+// @Success 200 {object} object{data=dto.SearchResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /search [get]
+func (c *SearchController) Search(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+
+	results, err := c.searchService.Search(ctx.Query("q"), limit, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": results,
+	})
+}
@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// SecretStoreController handles the external secret managers of a project
+type SecretStoreController struct {
+	storeService *services.SecretStoreService
+}
+
+// NewSecretStoreController creates a new secret store controller
+func NewSecretStoreController() *SecretStoreController {
+	return &SecretStoreController{
+		storeService: services.NewSecretStoreService(),
+	}
+}
+
+// RegisterRoutes registers secret store routes
+func (c *SecretStoreController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/secret-stores", c.ListStores)
+		projects.POST("/:id/secret-stores", c.CreateStore)
+		projects.PUT("/:id/secret-stores/:storeId", c.UpdateStore)
+		projects.DELETE("/:id/secret-stores/:storeId", c.DeleteStore)
+		projects.POST("/:id/secret-stores/:storeId/refresh", c.RefreshStore)
+	}
+}
+
+// ListStores returns the secret stores of a project
+// @Summary List the external secret stores of a project
+// @Tags secret-stores
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.SecretStore}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/secret-stores [get]
+func (c *SecretStoreController) ListStores(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	stores, err := c.storeService.ListStores(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": stores,
+	})
+}
+
+// CreateStore connects an external secret manager to a project
+// @Summary Connect a Vault or AWS Secrets Manager store
+// @Description Service env var values reference its entries as ${external:store/path#key}, e.g. ${external:vault/secret/data/db#password} or ${external:aws/prod/db#password}. Values are synced into the service's env Secret on deploy and every refreshMinutes; with restartPolicy rolling, services whose values changed are restarted.
+// @Tags secret-stores
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param store body dto.SecretStoreRequest true "Store connection and refresh settings"
+// @Success 201 {object} object{data=models.SecretStore}
+// @Failure 400 {object} object{error=string}
+// @Router /projects/{id}/secret-stores [post]
+func (c *SecretStoreController) CreateStore(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.SecretStoreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateSecretStoreRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	store, err := c.storeService.CreateStore(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": store,
+	})
+}
+
+// UpdateStore changes a secret store
+// @Summary Update a secret store
+// @Tags secret-stores
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param storeId path string true "Secret store ID"
+// @Param store body dto.SecretStoreUpdateRequest true "Fields to change; empty fields are left unchanged"
+// @Success 200 {object} object{data=models.SecretStore}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/secret-stores/{storeId} [put]
+func (c *SecretStoreController) UpdateStore(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.SecretStoreUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateSecretStoreUpdateRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	store, err := c.storeService.UpdateStore(ctx.Param("id"), ctx.Param("storeId"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(secretStoreErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": store,
+	})
+}
+
+// DeleteStore disconnects a secret store
+// @Summary Delete a secret store
+// @Description Running services keep their synced values; deploys of services still referencing the store fail.
+// @Tags secret-stores
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param storeId path string true "Secret store ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/secret-stores/{storeId} [delete]
+func (c *SecretStoreController) DeleteStore(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.storeService.DeleteStore(ctx.Param("id"), ctx.Param("storeId"), userID, isAdmin); err != nil {
+		ctx.JSON(secretStoreErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Secret store deleted",
+		},
+	})
+}
+
+// RefreshStore re-syncs the services referencing a store right away
+// @Summary Refresh the values synced from a secret store
+// @Description Re-reads the referenced values, updates the env Secrets of deployed services and applies the store's restart policy.
+// @Tags secret-stores
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param storeId path string true "Secret store ID"
+// @Success 200 {object} object{data=dto.SecretStoreRefreshResponse}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 502 {object} object{error=string}
+// @Router /projects/{id}/secret-stores/{storeId}/refresh [post]
+func (c *SecretStoreController) RefreshStore(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	result, err := c.storeService.RefreshStore(ctx.Param("id"), ctx.Param("storeId"), userID, isAdmin)
+	if errors.Is(err, services.ErrSecretRefreshFailed) {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(secretStoreErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+func secretStoreErrorStatus(err error) int {
+	if errors.Is(err, services.ErrSecretStoreNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
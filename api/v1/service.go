@@ -1,12 +1,16 @@
 package v1
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/middleware"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/services"
 	"github.com/pendeploy-simple/utils"
@@ -14,28 +18,75 @@ import (
 
 // ServiceController handles service-related API endpoints
 type ServiceController struct {
-	serviceService *services.ServiceService
+	serviceService       *services.ServiceService
+	driftService         *services.DriftService
+	incidentService      *services.ServiceIncidentService
+	anomalyService       *services.UsageAnomalyService
+	manifestService      *services.ManifestService
+	versionUpdateService *services.VersionUpdateService
+	restartService       *services.RestartHistoryService
+	usageStreamService   *services.UsageStreamService
 }
 
 // NewServiceController creates a new service controller
 func NewServiceController() *ServiceController {
 	return &ServiceController{
-		serviceService: services.NewServiceService(),
+		serviceService:       services.NewServiceService(),
+		driftService:         services.NewDriftService(),
+		incidentService:      services.NewServiceIncidentService(),
+		anomalyService:       services.NewUsageAnomalyService(),
+		manifestService:      services.NewManifestService(),
+		versionUpdateService: services.NewVersionUpdateService(),
+		restartService:       services.NewRestartHistoryService(),
+		usageStreamService:   services.NewUsageStreamService(),
 	}
 }
 
 
+// serviceListDeprecatedSince is when GET /services was superseded by the v2 envelope
+var serviceListDeprecatedSince = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
 // RegisterRoutes registers service routes
 func (c *ServiceController) RegisterRoutes(router *gin.RouterGroup) {
 	servicesGroup := router.Group("/services")
 	{
-		servicesGroup.GET("", c.ListServices)
-		servicesGroup.GET("/:id", c.GetService)
+		servicesGroup.GET("", middleware.Deprecated(serviceListDeprecatedSince, time.Time{}, "/api/v2/services"), c.ListServices)
+		servicesGroup.GET("/:id", middleware.ResponseCache(), c.GetService)
 		servicesGroup.POST("", c.CreateService)
+		servicesGroup.POST("/status", c.GetBatchStatus)
 		servicesGroup.PUT("/:id", c.UpdateService)
+		servicesGroup.PATCH("/:id", c.PatchService)
 		servicesGroup.DELETE("/:id", c.DeleteService)
+		servicesGroup.PUT("/:id/deletion-protection", c.SetDeletionProtection)
+		servicesGroup.PUT("/:id/pin", c.PinDeployment)
+		servicesGroup.PUT("/:id/internal-alias", c.SetInternalAlias)
+		servicesGroup.PUT("/:id/cache-policy", c.SetCachePolicy)
+		servicesGroup.PUT("/:id/containers", c.SetContainers)
+		servicesGroup.DELETE("/:id/pin", c.UnpinDeployment)
+		servicesGroup.GET("/:id/revisions", c.ListRevisions)
+		servicesGroup.POST("/:id/revisions/:revision/revert", c.RevertToRevision)
+		servicesGroup.POST("/:id/env/import", c.ImportEnvVars)
+		servicesGroup.GET("/:id/env/export", c.ExportEnvVars)
+		servicesGroup.POST("/:id/env/reveal", c.RevealEnvVars)
+		servicesGroup.GET("/:id/env/reveals", c.GetEnvRevealLog)
+		servicesGroup.POST("/:id/env/changes", c.ProposeEnvVarChange)
+		servicesGroup.GET("/:id/env/changes", c.ListEnvVarChanges)
+		servicesGroup.POST("/:id/env/changes/:changeId/approve", c.ApproveEnvVarChange)
+		servicesGroup.POST("/:id/env/changes/:changeId/reject", c.RejectEnvVarChange)
+		servicesGroup.GET("/:id/drift", c.GetDrift)
+		servicesGroup.POST("/:id/drift/resync", c.ResyncDrift)
+		servicesGroup.GET("/:id/manifests", c.GetManifests)
+		servicesGroup.GET("/:id/rightsizing", c.GetRightSizing)
+		servicesGroup.POST("/:id/rightsizing/apply", c.ApplyRightSizing)
+		servicesGroup.GET("/:id/incidents", c.ListIncidents)
+		servicesGroup.GET("/:id/anomalies", c.ListAnomalies)
+		servicesGroup.GET("/:id/restarts", c.GetRestartHistory)
+		servicesGroup.GET("/:id/usage/stream", c.StreamUsage)
+		servicesGroup.GET("/:id/version-updates", c.ListVersionUpdates)
 		servicesGroup.GET("/:id/deployments", c.GetDeploymentList)
-		servicesGroup.GET("/:id/latest-deployment", c.GetLatestDeployment)
+		servicesGroup.GET("/:id/deployments/compare", c.CompareDeployments)
+		servicesGroup.GET("/:id/latest-deployment", middleware.ResponseCache(), c.GetLatestDeployment)
+		servicesGroup.GET("/:id/deployments/:deploymentId/artifact", c.DownloadBuildArtifact)
 	}
 
 	// Also add project-specific service routes
@@ -46,6 +97,15 @@ func (c *ServiceController) RegisterRoutes(router *gin.RouterGroup) {
 }
 
 // GetLatestDeployment returns latest deployment - UPDATED untuk handle managed services
+// @Summary Get the latest deployment of a git service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=object{deployment=dto.DeploymentResponse}}
+// @Success 304 {string} string "Not Modified"
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/latest-deployment [get]
 func (c *ServiceController) GetLatestDeployment(ctx *gin.Context) {
 	// Get service ID from URL
 	serviceID := ctx.Param("id")
@@ -81,6 +141,10 @@ func (c *ServiceController) GetLatestDeployment(ctx *gin.Context) {
 		return
 	}
 
+	if middleware.CheckETag(ctx, middleware.ComputeETag(deployment.ID, deployment.Status, deployment.Image)) {
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
 			"deployment": deployment,
@@ -90,6 +154,22 @@ func (c *ServiceController) GetLatestDeployment(ctx *gin.Context) {
 
 
 // GetDeploymentList returns deployment list - UPDATED untuk handle managed services
+// @Summary List the deployments of a git service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param status query string false "Deployment status"
+// @Param imageDigest query string false "Only the deployment(s) that pushed this image digest"
+// @Param createdFrom query string false "RFC3339 or YYYY-MM-DD lower bound"
+// @Param createdTo query string false "RFC3339 or YYYY-MM-DD upper bound"
+// @Param sortBy query string false "created_at, updated_at or status"
+// @Param sortOrder query string false "asc or desc"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100); all deployments are returned when neither page nor pageSize is given"
+// @Success 200 {object} object{data=dto.DeploymentListResponse}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/deployments [get]
 func (c *ServiceController) GetDeploymentList(ctx *gin.Context) {
 	// Get service ID from URL
 	serviceID := ctx.Param("id")
@@ -117,7 +197,27 @@ func (c *ServiceController) GetDeploymentList(ctx *gin.Context) {
 		return
 	}
 
-	deployments, err := c.serviceService.GetDeploymentList(serviceID, userID, isAdmin)
+	createdFrom, createdTo, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	page, pageSize := parseOptionalPagination(ctx)
+	filter := dto.DeploymentFilter{
+		Status:      ctx.Query("status"),
+		ImageDigest: ctx.Query("imageDigest"),
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+		SortBy:      ctx.DefaultQuery("sortBy", "created_at"),
+		SortOrder:   ctx.DefaultQuery("sortOrder", "desc"),
+		Page:        page,
+		PageSize:    pageSize,
+	}
+
+	deployments, err := c.serviceService.GetDeploymentList(serviceID, filter, userID, isAdmin)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -126,12 +226,150 @@ func (c *ServiceController) GetDeploymentList(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"deployments": deployments,
-		},
+		"data": deployments,
+	})
+}
+// CompareDeployments compares the images of two deployments of a service
+// @Summary Compare the images of two deployments
+// @Description Reports the image size delta and the layers that changed, were added or were removed from deployment a to deployment b, to catch image bloat. Layers are compared by position, so a change points at the Dockerfile instruction that caused it.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param a query string true "Deployment ID to compare from"
+// @Param b query string true "Deployment ID to compare to"
+// @Success 200 {object} object{data=dto.DeploymentComparison}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 422 {object} object{error=string}
+// @Router /services/{id}/deployments/compare [get]
+func (c *ServiceController) CompareDeployments(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	a, b := ctx.Query("a"), ctx.Query("b")
+	if a == "" || b == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "query parameters a and b are required",
+		})
+		return
+	}
+
+	comparison, err := c.serviceService.CompareDeployments(ctx.Param("id"), a, b, userID, isAdmin)
+	if errors.Is(err, services.ErrImageLayersNotRecorded) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": comparison,
+	})
+}
+
+// DownloadBuildArtifact streams the artifact directory exported by a deployment's build
+// @Summary Download the build artifact of a deployment
+// @Description Gzipped tarball of the service's artifactPath, exported after the build succeeded
+// @Tags services
+// @Produce application/gzip
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param deploymentId path string true "Deployment ID"
+// @Success 200 {file} file
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/deployments/{deploymentId}/artifact [get]
+func (c *ServiceController) DownloadBuildArtifact(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.serviceService.GetServiceDetail(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reader, size, fileName, err := services.NewBuildArtifactService().OpenArtifact(service.ID, ctx.Param("deploymentId"))
+	if errors.Is(err, services.ErrArtifactNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	ctx.DataFromReader(http.StatusOK, size, "application/gzip", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + fileName + `"`,
+	})
+}
+
+// GetBatchStatus returns ready-replica counts and health for a list of services in one call
+// @Summary Get the status of several services in one call
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.BatchStatusRequest true "Service IDs"
+// @Success 200 {object} object{data=[]dto.ServiceStatusSummary}
+// @Failure 400 {object} object{error=string}
+// @Router /services/status [post]
+func (c *ServiceController) GetBatchStatus(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.BatchStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	statuses, err := c.serviceService.GetBatchStatus(req.ServiceIDs, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": statuses,
 	})
 }
+
 // ListServices retrieves all services (admin only)
+// @Summary List all services (admin only)
+// @Description Superseded by GET /api/v2/services
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Deprecated
+// @Param search query string false "Search term"
+// @Param status query string false "Service status"
+// @Param type query string false "git or managed"
+// @Param environmentId query string false "Environment ID"
+// @Param projectId query string false "Project ID"
+// @Param createdFrom query string false "RFC3339 or YYYY-MM-DD lower bound"
+// @Param createdTo query string false "RFC3339 or YYYY-MM-DD upper bound"
+// @Param sortBy query string false "Sort column"
+// @Param sortOrder query string false "asc or desc"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100); all services are returned when neither page nor pageSize is given"
+// @Success 200 {object} object{data=dto.ServiceListResponse}
+// @Failure 403 {object} object{error=string}
+// @Router /services [get]
 func (c *ServiceController) ListServices(ctx *gin.Context) {
 	// Get userId and role from context
 	roleValue, _ := ctx.Get("role")
@@ -145,7 +383,30 @@ func (c *ServiceController) ListServices(ctx *gin.Context) {
 		return
 	}
 
-	services, err := c.serviceService.ListAllServices()
+	createdFrom, createdTo, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	page, pageSize := parseOptionalPagination(ctx)
+	filter := dto.ServiceFilter{
+		Search:        ctx.Query("search"),
+		Status:        ctx.Query("status"),
+		Type:          ctx.Query("type"),
+		EnvironmentID: ctx.Query("environmentId"),
+		ProjectID:     ctx.Query("projectId"),
+		CreatedFrom:   createdFrom,
+		CreatedTo:     createdTo,
+		SortBy:        ctx.DefaultQuery("sortBy", "created_at"),
+		SortOrder:     ctx.DefaultQuery("sortOrder", "desc"),
+		Page:          page,
+		PageSize:      pageSize,
+	}
+
+	services, err := c.serviceService.ListAllServices(filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve services",
@@ -154,13 +415,18 @@ func (c *ServiceController) ListServices(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"services": services,
-		},
+		"data": services,
 	})
 }
 
 // ListProjectServices retrieves all services for a specific project
+// @Summary List the services of a project
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=object{services=[]models.Service}}
+// @Router /projects/{id}/services [get]
 func (c *ServiceController) ListProjectServices(ctx *gin.Context) {
 	// Get project ID from URL
 	projectID := ctx.Param("id")
@@ -188,6 +454,15 @@ func (c *ServiceController) ListProjectServices(ctx *gin.Context) {
 }
 
 // GetService retrieves a specific service
+// @Summary Get a service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=models.Service}
+// @Success 304 {string} string "Not Modified"
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id} [get]
 func (c *ServiceController) GetService(ctx *gin.Context) {
 	// Get service ID from URL
 	serviceID := ctx.Param("id")
@@ -207,12 +482,33 @@ func (c *ServiceController) GetService(ctx *gin.Context) {
 		return
 	}
 
+	// Live health is part of the response, so it is part of the ETag too
+	etagParts := []string{service.ID, service.UpdatedAt.String(), service.Status}
+	if service.Health != nil {
+		etagParts = append(etagParts, string(service.Health.Status), strconv.Itoa(service.Health.ReadyReplicas), strconv.Itoa(service.Health.Replicas))
+	}
+	if middleware.CheckETag(ctx, middleware.ComputeETag(etagParts...)) {
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"data": service,
 	})
 }
 
 // CreateService creates a new service - UPDATED untuk managed services validation
+// @Summary Create a service
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param service body dto.ServiceRequest true "Service"
+// @Success 201 {object} object{data=models.Service,warnings=[]string}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string,field=string,suggestion=string}
+// @Failure 422 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services [post]
 func (c *ServiceController) CreateService(ctx *gin.Context) {
 	// Get userId and role from context
 	userIDValue, _ := ctx.Get("userId")
@@ -224,81 +520,13 @@ func (c *ServiceController) CreateService(ctx *gin.Context) {
 	// Parse request body
 	var req dto.ServiceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondValidationProblem(ctx, err)
 		return
 	}
 
-	// Validate fields based on service type
-	if req.Type == models.ServiceTypeGit {
-		// Git services require RepoURL
-		if req.RepoURL == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Repository URL is required for git services",
-			})
-			return
-		}
-
-		// Only HTTPS URLs are supported (PAT authentication is HTTPS-only).
-		if !strings.HasPrefix(req.RepoURL, "https://") {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Repository URL must be an HTTPS URL (e.g. https://github.com/owner/repo.git)",
-			})
-			return
-		}
-
-		// Private repositories require an access token.
-		if !req.IsPublic && req.GitToken == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "A personal access token is required for private repositories",
-			})
-			return
-		}
-	} else if req.Type == models.ServiceTypeManaged {
-		// Managed services require ManagedType and validation
-		if req.ManagedType == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "ManagedType is required for managed services",
-			})
-			return
-		}
-		
-		// Validate managed service type
-		if !utils.IsValidManagedServiceType(req.ManagedType) {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Unsupported managed service type: " + req.ManagedType,
-			})
-			return
-		}
-		
-		// For managed services, EnvVars should not be provided by user (auto-generated)
-		if req.EnvVars != nil && len(req.EnvVars) > 0 {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Environment variables are auto-generated for managed services and cannot be specified",
-			})
-			return
-		}
-		
-		// Managed services don't need git-specific fields
-		if req.RepoURL != "" || req.Branch != "" || req.BuildCommand != "" || req.StartCommand != "" || req.GitUsername != "" || req.GitToken != "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Git-specific fields (repoUrl, branch, buildCommand, startCommand, gitUsername, gitToken) are not allowed for managed services",
-			})
-			return
-		}
-		
-		// Port is auto-determined for managed services
-		if req.Port != 0 {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "Port is auto-determined for managed services and cannot be specified",
-			})
-			return
-		}
-	} else {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid service type",
-		})
+	// Validate every field and report all problems at once
+	if err := utils.ValidateServiceRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
 		return
 	}
 
@@ -318,25 +546,94 @@ func (c *ServiceController) CreateService(ctx *gin.Context) {
 		Port:           req.Port,
 		BuildCommand:   req.BuildCommand,
 		StartCommand:   req.StartCommand,
+		DockerfilePath: req.DockerfilePath,
+		BuildArgs:      req.BuildArgs,
+		TestCommand:    req.TestCommand,
+		TestImage:      req.TestImage,
+		ArtifactPath:   req.ArtifactPath,
+		BuildPlatforms: strings.Join(req.BuildPlatforms, ","),
+		BuildBackend:   req.BuildBackend,
+		BuildSecrets:   req.BuildSecrets,
+		CloneDepth:     req.CloneDepth,
+		BuildTimeoutMinutes: req.BuildTimeoutMinutes,
+		MaxImageSize:   req.MaxImageSize,
+		ImageSizeBudgetAction: req.ImageSizeBudgetAction,
+		SparseCheckoutPaths: strings.Join(req.SparseCheckoutPaths, ","),
 		
 		// Managed service fields
 		ManagedType:    req.ManagedType,
 		Version:        req.Version,
 		StorageSize:    req.StorageSize,
+		PoolingEnabled: req.PoolingEnabled,
+		PoolMode:       req.PoolMode,
+		PoolSize:       req.PoolSize,
+		MaxClientConn:  req.MaxClientConn,
+		VPAMode:        req.VPAMode,
+		ExternalAllowedCIDRs: strings.Join(req.ExternalAllowedCIDRs, ","),
+		DatabaseTLS:    req.DatabaseTLS,
+		Topology:       req.Topology,
+		AutoUpdate:     req.AutoUpdate,
+		MaintenanceWindow: req.MaintenanceWindow,
 		
 		// Common configuration fields
 		EnvVars:        req.EnvVars, // Will be empty for managed services
+		SecretEnvKeys:  strings.Join(req.SecretEnvKeys, ","),
+		BuildEnvKeys:   strings.Join(req.BuildEnvKeys, ","),
 		CPULimit:       req.CPULimit,
 		MemoryLimit:    req.MemoryLimit,
 		IsStaticReplica: req.IsStaticReplica,
 		Replicas:       req.Replicas,
 		MinReplicas:    req.MinReplicas,
 		MaxReplicas:    req.MaxReplicas,
+		HighAvailability: req.HighAvailability,
+		TerminationGracePeriodSeconds: req.TerminationGracePeriodSeconds,
+		PreStopCommand: req.PreStopCommand,
+		Command:        req.Command,
+		Args:           req.Args,
+		WorkingDir:     req.WorkingDir,
+		AutoCorrectPort: req.AutoCorrectPort,
 		CustomDomain:   req.CustomDomain,
+		TLSChallenge:   req.TLSChallenge,
+		DeletionProtected: req.DeletionProtected,
+		ServiceAccountAnnotations: req.ServiceAccountAnnotations,
+		PodLabels:      req.PodLabels,
+		PodAnnotations: req.PodAnnotations,
+	}
+
+	// Make sure the requested resources can actually be scheduled
+	placement, err := c.serviceService.CheckPlacement(service)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !placement.Schedulable {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Insufficient cluster capacity: " + placement.Reason,
+		})
+		return
 	}
 
 	// Call service to create
 	createdService, err := c.serviceService.CreateService(service, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if respondDeployLocked(ctx, err) {
+		return
+	}
+	var conflict *services.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      conflict.Error(),
+			"field":      conflict.Field,
+			"suggestion": conflict.Suggestion,
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -344,12 +641,30 @@ func (c *ServiceController) CreateService(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"data": createdService,
-	})
+	}
+	if len(placement.Warnings) > 0 {
+		response["warnings"] = placement.Warnings
+	}
+
+	ctx.JSON(http.StatusCreated, response)
 }
 
 // UpdateService updates an existing service - UPDATED untuk use existing DTO
+// @Summary Update a service
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param service body dto.ServiceUpdateRequest true "Fields to update"
+// @Success 200 {object} object{data=models.Service,warnings=[]string}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string,field=string,suggestion=string}
+// @Failure 422 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services/{id} [put]
 func (c *ServiceController) UpdateService(ctx *gin.Context) {
 	// Get service ID from URL
 	serviceID := ctx.Param("id")
@@ -376,9 +691,7 @@ func (c *ServiceController) UpdateService(ctx *gin.Context) {
 	var updateReq dto.ServiceUpdateRequest
 	if err := ctx.ShouldBindJSON(&updateReq); err != nil {
 		log.Println("error binding json")
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondValidationProblem(ctx, err)
 		return
 	}
 
@@ -400,18 +713,80 @@ func (c *ServiceController) UpdateService(ctx *gin.Context) {
 		})
 		return
 	}
+	if err := utils.ValidateServiceUpdateRequest(updateReq); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	// Check capacity against the service as it will look after the update
+	candidate := existingService
+	updateReq.UpdateServiceModel(&candidate)
+	placement, err := c.serviceService.CheckPlacement(candidate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !placement.Schedulable {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Insufficient cluster capacity: " + placement.Reason,
+		})
+		return
+	}
 
 	// Create a service object for update
 	service := models.Service{
 		ID: serviceID,
+		// Toggles are always copied by the update, so start from the current value
+		HighAvailability: existingService.HighAvailability,
+		PoolingEnabled:   existingService.PoolingEnabled,
+		TerminationGracePeriodSeconds: existingService.TerminationGracePeriodSeconds,
+		PreStopCommand:   existingService.PreStopCommand,
+		Command:          existingService.Command,
+		Args:             existingService.Args,
+		WorkingDir:       existingService.WorkingDir,
+		AutoCorrectPort:  existingService.AutoCorrectPort,
+		SecretEnvKeys:    existingService.SecretEnvKeys,
+		BuildEnvKeys:     existingService.BuildEnvKeys,
+		VPAMode:          existingService.VPAMode,
+		CloneDepth:       existingService.CloneDepth,
+		BuildTimeoutMinutes: existingService.BuildTimeoutMinutes,
+		MaxImageSize:     existingService.MaxImageSize,
+		ImageSizeBudgetAction: existingService.ImageSizeBudgetAction,
+		TestCommand:      existingService.TestCommand,
+		DockerfilePath:   existingService.DockerfilePath,
+		BuildBackend:     existingService.BuildBackend,
+		BuildSecrets:     existingService.BuildSecrets,
+		SparseCheckoutPaths: existingService.SparseCheckoutPaths,
+		ExternalAllowedCIDRs: existingService.ExternalAllowedCIDRs,
+		DatabaseTLS:      existingService.DatabaseTLS,
+		AutoUpdate:       existingService.AutoUpdate,
+		MaintenanceWindow: existingService.MaintenanceWindow,
 	}
 
 	// Use the DTO to update service model
 	updateReq.UpdateServiceModel(&service)
 	log.Println("update service model")
-    log.Println(service)
 	// Call service layer to update
 	updatedService, err := c.serviceService.UpdateService(service, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if respondDeployLocked(ctx, err) || respondEnvVarApprovalRequired(ctx, err) {
+		return
+	}
+	var conflict *services.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      conflict.Error(),
+			"field":      conflict.Field,
+			"suggestion": conflict.Suggestion,
+		})
+		return
+	}
 	if err != nil {
 		log.Println("error updating service")
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -420,13 +795,91 @@ func (c *ServiceController) UpdateService(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"data": updatedService,
+	}
+	if len(placement.Warnings) > 0 {
+		response["warnings"] = placement.Warnings
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// PatchService applies a JSON merge patch to a service
+// @Summary Partially update a service
+// @Description Applies a JSON merge patch (RFC 7386) to the updatable fields of the service, under the names GET returns them. Omitted fields keep their value and map keys set to null (e.g. in envVars) are removed. The plan tells which fields changed and whether the change was only stored (none), rolled out with the running image (restart) or triggered a build (rebuild).
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param patch body dto.ServicePatchDocument true "Merge patch of the fields to change"
+// @Success 200 {object} object{data=models.Service,plan=dto.ServiceUpdatePlan}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string,field=string,suggestion=string}
+// @Failure 415 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services/{id} [patch]
+func (c *ServiceController) PatchService(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	contentType := ctx.ContentType()
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be application/merge-patch+json",
+		})
+		return
+	}
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	service, plan, err := c.serviceService.PatchService(ctx.Param("id"), patch, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if respondDeployLocked(ctx, err) || respondEnvVarApprovalRequired(ctx, err) {
+		return
+	}
+	var conflict *services.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      conflict.Error(),
+			"field":      conflict.Field,
+			"suggestion": conflict.Suggestion,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+		"plan": plan,
 	})
 }
 
 
 // DeleteService deletes a service
+// @Summary Delete a service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 409 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services/{id} [delete]
 func (c *ServiceController) DeleteService(ctx *gin.Context) {
 	// Get service ID from URL
 	serviceID := ctx.Param("id")
@@ -440,6 +893,16 @@ func (c *ServiceController) DeleteService(ctx *gin.Context) {
 
 	// Call service to delete
 	err := c.serviceService.DeleteService(serviceID, userID, isAdmin)
+	if respondDeployLocked(ctx, err) {
+		return
+	}
+	var protected *services.ProtectedServicesError
+	if errors.As(err, &protected) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": protected.Error(),
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -453,3 +916,944 @@ func (c *ServiceController) DeleteService(ctx *gin.Context) {
 		},
 	})
 }
+
+// SetDeletionProtection turns deletion protection of a service on or off
+// @Summary Enable or disable deletion protection of a service
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param protection body dto.DeletionProtectionRequest true "Protection state"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/deletion-protection [put]
+func (c *ServiceController) SetDeletionProtection(ctx *gin.Context) {
+	serviceID := ctx.Param("id")
+
+	// Get userId and role from context
+	userIDValue, _ := ctx.Get("userId")
+	userID := userIDValue.(string)
+	roleValue, _ := ctx.Get("role")
+	role, _ := roleValue.(string)
+	isAdmin := role == "admin"
+
+	var req dto.DeletionProtectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	service, err := c.serviceService.SetDeletionProtection(serviceID, *req.Enabled, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// PinDeployment pins a service to one of its deployments
+// @Summary Pin a service to a deployment
+// @Description While pinned, pushes received on the deploy webhook are ignored. deploymentId defaults to the latest successful deployment; pinning an older one rolls its image out again as a new deployment, which becomes the pinned one. Git services only.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param pin body dto.ServicePinRequest true "Deployment to pin"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services/{id}/pin [put]
+func (c *ServiceController) PinDeployment(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ServicePinRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	service, err := c.serviceService.PinDeployment(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		var lockedErr *services.DeployLockedError
+		if errors.As(err, &lockedErr) {
+			ctx.JSON(http.StatusLocked, gin.H{"error": err.Error(), "lock": lockedErr.Lock})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// UnpinDeployment lets webhook pushes deploy a pinned service again
+// @Summary Unpin a service
+// @Description The running deployment is kept; the next push deploys.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/pin [delete]
+func (c *ServiceController) UnpinDeployment(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.serviceService.UnpinDeployment(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// SetInternalAlias changes the name other services in the environment reach a service at
+// @Summary Set the internal alias of a service
+// @Description The alias is an ExternalName Service in the environment's namespace, so apps in the environment can connect to e.g. "db" instead of s-<id>.<environment-id>.svc.cluster.local. New services get one derived from their name. An empty alias removes it.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param alias body dto.InternalAliasRequest true "Alias, a DNS label starting with a letter"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string,field=string,suggestion=string}
+// @Router /services/{id}/internal-alias [put]
+func (c *ServiceController) SetInternalAlias(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.InternalAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	service, err := c.serviceService.SetInternalAlias(ctx.Param("id"), req.InternalAlias, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	var conflict *services.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      conflict.Error(),
+			"field":      conflict.Field,
+			"suggestion": conflict.Suggestion,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// SetCachePolicy changes which routes of a service are cached at the ingress
+// @Summary Set the HTTP cache policy of a service
+// @Description Responses under each path prefix are cached by Traefik's cache plugin for the rule's TTL and served with a matching Cache-Control header, e.g. static assets under /assets. Replaces the previous rules; an empty list turns caching off. Git services only.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param policy body dto.CachePolicyRequest true "Cache rules, at most 10"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/cache-policy [put]
+func (c *ServiceController) SetCachePolicy(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.CachePolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	service, err := c.serviceService.SetCachePolicy(ctx.Param("id"), req.Rules, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// SetContainers changes the companion containers running beside the app container
+// @Summary Set the companion containers of a service
+// @Description Companion containers run tightly coupled processes, e.g. a queue worker, in every pod of a git service beside the app container. Each runs its command with sh -c from the service's image and env vars, with its own CPU and memory limits (the service's when empty); traffic only goes to the app container. Shared volumes are scratch directories, emptied when the pod is replaced, mounted at the same path in the app and every companion. Replaces the previous containers and volumes; empty lists remove them. Changes are rolled out with the next deployment. Git services only.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param containers body dto.ServiceContainersRequest true "Companion containers and shared volumes, at most 5 each"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/containers [put]
+func (c *ServiceController) SetContainers(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ServiceContainersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	service, err := c.serviceService.SetContainers(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// ListRevisions returns the config change history of a service
+// @Summary List config revisions of a service
+// @Description Every change to environment variables, resources or the custom domain is kept as a numbered revision with who made it and when.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.ServiceRevisionListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/revisions [get]
+func (c *ServiceController) ListRevisions(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	revisions, err := c.serviceService.ListRevisions(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": revisions,
+	})
+}
+
+// RevertToRevision restores the config of a prior revision and redeploys the service
+// @Summary Revert a service to a config revision
+// @Description Restores the environment variables, resources and custom domain of the revision and redeploys. The image is not changed.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /services/{id}/revisions/{revision}/revert [post]
+func (c *ServiceController) RevertToRevision(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	revision, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || revision < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "revision must be a positive number",
+		})
+		return
+	}
+
+	service, err := c.serviceService.RevertToRevision(ctx.Param("id"), revision, userID, isAdmin)
+	if errors.Is(err, services.ErrServiceRevisionNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if respondEnvVarApprovalRequired(ctx, err) {
+		return
+	}
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// ImportEnvVars sets a service's environment variables from a .env file
+// @Summary Import environment variables from a .env file
+// @Description Parses KEY=VALUE lines (quotes, export prefixes and comments allowed). Merge keeps variables missing from the file, replace removes them. Masked values (********) keep the current value, so a masked export can be imported back. The diff is returned with sensitive values masked; with dryRun nothing is applied, otherwise the service is redeployed when anything changed. In an environment with envVarApprovalRequired the import is proposed instead (see proposal) and applied once approved.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param request body dto.EnvImportRequest true ".env content and import mode"
+// @Success 200 {object} object{data=dto.EnvImportResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/env/import [post]
+func (c *ServiceController) ImportEnvVars(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.EnvImportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	result, err := c.serviceService.ImportEnvVars(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": result,
+	})
+}
+
+// ExportEnvVars downloads a service's environment variables as a .env file
+// @Summary Export environment variables as a .env file
+// @Description Values of variables flagged as secret or that look like secrets (passwords, tokens, keys, URLs with credentials) are masked unless reveal is set, which only the project owner may do and is recorded in the audit log. Vault and external secret references are exported as written.
+// @Tags services
+// @Produce text/plain
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param reveal query bool false "Export unmasked values (project owner only)"
+// @Success 200 {file} file
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/env/export [get]
+func (c *ServiceController) ExportEnvVars(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	reveal, _ := strconv.ParseBool(ctx.Query("reveal"))
+
+	content, service, err := c.serviceService.ExportEnvVars(ctx.Param("id"), reveal, userID, isAdmin)
+	if errors.Is(err, services.ErrEnvRevealForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="`+service.Name+`.env"`)
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// RevealEnvVars returns the unmasked values of a service's secret environment variables
+// @Summary Reveal secret environment variable values
+// @Description Values of variables flagged in secretEnvKeys are masked in every other response. Only the project owner may reveal them, and each reveal is recorded in the audit log.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param request body dto.EnvRevealRequest false "Variables to reveal; all secret ones when empty"
+// @Success 200 {object} object{data=models.EnvVars}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Router /services/{id}/env/reveal [post]
+func (c *ServiceController) RevealEnvVars(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.EnvRevealRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondValidationProblem(ctx, err)
+			return
+		}
+	}
+
+	values, err := c.serviceService.RevealEnvVars(ctx.Param("id"), req.Keys, userID, isAdmin)
+	if errors.Is(err, services.ErrEnvRevealForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": values,
+	})
+}
+
+// GetEnvRevealLog returns who revealed secret environment variable values of a service
+// @Summary List reveals of secret environment variable values
+// @Description The 100 most recent reveals and unmasked exports, newest first
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=[]models.EnvRevealAuditLog}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/env/reveals [get]
+func (c *ServiceController) GetEnvRevealLog(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	entries, err := c.serviceService.GetEnvRevealLog(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": entries,
+	})
+}
+
+// ProposeEnvVarChange proposes a change of a service's environment variables
+// @Summary Propose an environment variable change
+// @Description In an environment with envVarApprovalRequired, environment variable changes are not applied by updates, patches, reverts or imports but proposed here. The proposal records the rendered diff (added, changed and removed keys, sensitive values masked) and waits as pending_approval; the service is only redeployed or restarted once someone other than the proposer approves it.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param request body dto.EnvVarChangeRequest true "Variables to set and unset"
+// @Success 201 {object} object{data=dto.EnvVarChangeResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/env/changes [post]
+func (c *ServiceController) ProposeEnvVarChange(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.EnvVarChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	change, err := c.serviceService.ProposeEnvVarChange(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": change,
+	})
+}
+
+// ListEnvVarChanges returns the proposed environment variable changes of a service
+// @Summary List proposed environment variable changes
+// @Description Newest first, each with its diff; sensitive values are masked.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param status query string false "pending_approval, approved, rejected, applied or failed"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.EnvVarChangeListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/env/changes [get]
+func (c *ServiceController) ListEnvVarChanges(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	changes, err := c.serviceService.ListEnvVarChanges(ctx.Param("id"), ctx.Query("status"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": changes,
+	})
+}
+
+// ApproveEnvVarChange approves a proposed environment variable change and applies it
+// @Summary Approve an environment variable change
+// @Description Applies the proposed variables like any other update, redeploying or restarting the service. The proposer cannot approve their own change, and a change whose service's variables changed since it was proposed has to be proposed again.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param changeId path string true "Change ID"
+// @Param review body dto.EnvVarChangeReviewRequest false "Review comment"
+// @Success 200 {object} object{data=dto.EnvVarChangeResponse}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /services/{id}/env/changes/{changeId}/approve [post]
+func (c *ServiceController) ApproveEnvVarChange(ctx *gin.Context) {
+	c.reviewEnvVarChange(ctx, c.serviceService.ApproveEnvVarChange)
+}
+
+// RejectEnvVarChange rejects a proposed environment variable change
+// @Summary Reject an environment variable change
+// @Description Rejects a change waiting for approval; nothing is applied.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param changeId path string true "Change ID"
+// @Param review body dto.EnvVarChangeReviewRequest false "Review comment"
+// @Success 200 {object} object{data=dto.EnvVarChangeResponse}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /services/{id}/env/changes/{changeId}/reject [post]
+func (c *ServiceController) RejectEnvVarChange(ctx *gin.Context) {
+	c.reviewEnvVarChange(ctx, c.serviceService.RejectEnvVarChange)
+}
+
+// reviewEnvVarChange handles approving and rejecting, which differ only in the decision
+func (c *ServiceController) reviewEnvVarChange(ctx *gin.Context, decide func(serviceID string, changeID string, comment string, userID string, isAdmin bool) (dto.EnvVarChangeResponse, error)) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.EnvVarChangeReviewRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondValidationProblem(ctx, err)
+			return
+		}
+	}
+
+	change, err := decide(ctx.Param("id"), ctx.Param("changeId"), req.Comment, userID, isAdmin)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, gin.H{
+			"data": change,
+		})
+	case errors.Is(err, services.ErrEnvVarChangeNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrEnvVarChangeNotPending), errors.Is(err, services.ErrEnvVarChangeStale):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrEnvVarChangeSelfApprove):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+	case respondDeployLocked(ctx, err):
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	}
+}
+
+// respondEnvVarApprovalRequired answers an update changing env vars that need approval
+// with 409 Conflict and reports whether it did
+func respondEnvVarApprovalRequired(ctx *gin.Context, err error) bool {
+	if !errors.Is(err, services.ErrEnvVarApprovalRequired) {
+		return false
+	}
+	ctx.JSON(http.StatusConflict, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
+
+// GetDrift reports manual changes to the cluster objects of a git service
+// @Summary Detect drift between a service's declared spec and its live objects
+// @Description Compares the Deployment, Service and Ingresses PenDeploy would generate with the live objects. Fields set by the cluster are ignored and environment variable values are never returned.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=dto.ServiceDriftReport}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/drift [get]
+func (c *ServiceController) GetDrift(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	report, err := c.driftService.DetectDrift(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
+
+// ResyncDrift re-applies the declared spec of a git service over manual changes
+// @Summary Re-sync a service's live objects to its declared spec
+// @Description Re-applies the generated Deployment, Service and Ingresses with the image of the latest successful deployment, then reports any drift left.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=dto.ServiceDriftReport}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/drift/resync [post]
+func (c *ServiceController) ResyncDrift(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	report, err := c.driftService.Resync(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
+
+// GetManifests returns the Kubernetes resources generated for a service as YAML
+// @Summary Export the rendered Kubernetes manifests of a service
+// @Description Multi-document YAML of every resource PenDeploy applies for the service, rendered from its current config (git services with the image of the latest successful deployment). Secret and sensitive values are masked and owner references are omitted, so the output can be inspected or used to eject to raw manifests.
+// @Tags services
+// @Produce application/yaml
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {file} file
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/manifests [get]
+func (c *ServiceController) GetManifests(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	manifests, service, err := c.manifestService.RenderManifests(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `inline; filename="`+service.Name+`.yaml"`)
+	ctx.Data(http.StatusOK, "application/yaml; charset=utf-8", []byte(manifests))
+}
+
+// GetRightSizing compares a service's actual usage with its limits
+// @Summary Get right-sizing recommendations for a service
+// @Description Compares the 95th percentile per-pod CPU and memory usage over a rolling window with the service's limits. Limits used below 40% are recommended to shrink, limits used above 85% to grow; recommended limits leave 30% headroom.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param days query int false "Window in days (1-30, default 7)"
+// @Success 200 {object} object{data=dto.ServiceRightSizing}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/rightsizing [get]
+func (c *ServiceController) GetRightSizing(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	days := 0
+	if value := ctx.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 30 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "days must be between 1 and 30",
+			})
+			return
+		}
+		days = parsed
+	}
+
+	sizing, err := c.serviceService.GetRightSizing(ctx.Param("id"), days, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": sizing,
+	})
+}
+
+// ApplyRightSizing sets the recommended limits and redeploys the service
+// @Summary Apply right-sizing recommendations
+// @Description Sets the currently recommended CPU and/or memory limits through the regular update, which records a config revision and redeploys. Grown limits are checked against cluster capacity first.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param request body dto.RightSizingApplyRequest false "Resources to apply and window"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string}
+// @Router /services/{id}/rightsizing/apply [post]
+func (c *ServiceController) ApplyRightSizing(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.RightSizingApplyRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			respondValidationProblem(ctx, err)
+			return
+		}
+	}
+
+	service, err := c.serviceService.ApplyRightSizing(ctx.Param("id"), req, userID, isAdmin)
+	if errors.Is(err, services.ErrNoRightSizing) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": service,
+	})
+}
+
+// ListVersionUpdates returns the automatic version updates of a managed service
+// @Summary List automatic version updates of a managed service
+// @Description Services on the patch or minor channel are updated in their maintenance window. Each update snapshots the data volumes first and is rolled back when the new version does not become ready; the snapshot names are recorded for manual restores.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.ServiceVersionUpdateListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/version-updates [get]
+func (c *ServiceController) ListVersionUpdates(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	updates, err := c.versionUpdateService.ListUpdates(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": updates,
+	})
+}
+
+// ListIncidents returns the incident timeline of a service
+// @Summary List detected incidents of a service
+// @Description Deployment failures, crash loops, readiness probe failures and failed uptime checks are correlated into incidents with start and end times, a timeline of signals and a probable cause, for postmortems.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.ServiceIncidentListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/incidents [get]
+func (c *ServiceController) ListIncidents(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	incidents, err := c.incidentService.ListIncidents(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": incidents,
+	})
+}
+
+// ListAnomalies returns the usage anomalies detected on a service
+// @Summary List usage anomalies of a service
+// @Description Sudden memory growth, CPU saturation and restart storms found by comparing the service's last 30 minutes of usage with its baseline (the previous 7 days by default). The service's attentionNeeded flag is set while any of them is open.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.UsageAnomalyListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/anomalies [get]
+func (c *ServiceController) ListAnomalies(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	anomalies, err := c.anomalyService.ListAnomalies(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": anomalies,
+	})
+}
+
+// GetRestartHistory returns the container restarts of a service's pods
+// @Summary Get the container restart history of a service
+// @Description For each current pod: the restart count, state and last termination (reason, exit code, signal) of every container, and the last lines of the previous instance of restarted containers, read with timestamps and secret values masked. The kubelet keeps only the instance right before the current one.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param tailLines query int false "Lines of each previous container instance (default 100, max 1000)"
+// @Success 200 {object} object{data=dto.ServiceRestartHistory}
+// @Failure 400 {object} object{error=string}
+// @Router /services/{id}/restarts [get]
+func (c *ServiceController) GetRestartHistory(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	tailLines := services.DefaultRestartLogLines
+	if value := ctx.Query("tailLines"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "tailLines must be a number",
+			})
+			return
+		}
+		tailLines = parsed
+	}
+
+	history, err := c.restartService.GetRestartHistory(ctx.Param("id"), tailLines, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": history,
+	})
+}
+
+// StreamUsage streams live CPU and memory samples of a service's pods
+// @Summary Stream live resource usage of a service
+// @Description Server-Sent Events, each a JSON dto.ServiceUsageSnapshot with the CPU and memory use and limits of every running pod, sent when the metrics API has a new sample (polled every USAGE_STREAM_INTERVAL_SECONDS, default 5). Sampling failures are sent as "error" events. The stream closes after an hour.
+// @Tags services
+// @Produce event-stream
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {string} string "Server-Sent Events"
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/usage/stream [get]
+func (c *ServiceController) StreamUsage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.usageStreamService.GetStreamableService(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	// Set headers for SSE streaming
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
+
+	if err := c.usageStreamService.StreamUsage(service, w, ctx.Request.Context().Done()); err != nil {
+		// Headers are already sent, so report the error as an event
+		utils.WriteSSEMessage(w, "error: "+err.Error())
+	}
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// defaultInspectionLogLines is the number of lines read per container when none are requested
+const defaultInspectionLogLines = 200
+
+// InspectServiceManifests returns the rendered manifests of any service
+// @Summary Inspect the manifests of a service (admin only)
+// @Description Read-only access to any project's service without impersonating its owner. The access is recorded in the service inspection audit trail first; nothing is returned when it cannot be. Secret values are masked.
+// @Tags admin
+// @Produce application/yaml
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param reason query string false "Why the service is inspected, e.g. a support ticket; stored in the audit trail"
+// @Success 200 {string} string "Multi-document YAML"
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/services/{id}/manifests [get]
+func InspectServiceManifests(c *gin.Context) {
+	manifests, service, err := services.NewServiceInspectionService().InspectManifests(newInspectionRequest(c))
+	if err != nil {
+		c.JSON(inspectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+service.Name+`.yaml"`)
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", []byte(manifests))
+}
+
+// InspectServiceEvents returns the Kubernetes events of any service
+// @Summary Inspect the Kubernetes events of a service (admin only)
+// @Description Events of the service's workload, ReplicaSets and pods, newest first; the API server keeps them for about an hour. The access is recorded in the service inspection audit trail.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param reason query string false "Why the service is inspected; stored in the audit trail"
+// @Success 200 {object} object{data=[]dto.ServiceEvent}
+// @Failure 404 {object} object{error=string}
+// @Failure 500 {object} object{error=string}
+// @Router /admin/services/{id}/events [get]
+func InspectServiceEvents(c *gin.Context) {
+	events, err := services.NewServiceInspectionService().InspectEvents(newInspectionRequest(c))
+	if err != nil {
+		c.JSON(inspectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+// InspectServiceLogs returns the recent logs of any service
+// @Summary Inspect the recent logs of a service (admin only)
+// @Description The last lines of every container of the service's pods, with the previous run of restarted containers. Secret values are redacted. The access is recorded in the service inspection audit trail.
+// @Tags admin
+// @Produce plain
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param tailLines query int false "Lines per container (1-2000, default 200)"
+// @Param reason query string false "Why the service is inspected; stored in the audit trail"
+// @Success 200 {string} string "Log lines"
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/services/{id}/logs [get]
+func InspectServiceLogs(c *gin.Context) {
+	tailLines := defaultInspectionLogLines
+	if value := c.Query("tailLines"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > utils.MaxInspectionLogLines {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tailLines must be between 1 and 2000"})
+			return
+		}
+		tailLines = parsed
+	}
+
+	logs, _, err := services.NewServiceInspectionService().InspectLogs(newInspectionRequest(c), int64(tailLines))
+	if err != nil {
+		c.JSON(inspectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(logs))
+}
+
+// ListServiceInspectionAudit lists the service inspection audit trail, newest first
+// @Summary List service inspection audit entries (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param adminId query string false "Only inspections by this admin"
+// @Param serviceId query string false "Only inspections of this service"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size (max 100)"
+// @Success 200 {object} object{data=dto.ServiceInspectionAuditListResponse}
+// @Router /admin/service-inspections [get]
+func ListServiceInspectionAudit(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	entries, err := services.NewServiceInspectionService().ListAudit(c.Query("adminId"), c.Query("serviceId"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+func newInspectionRequest(c *gin.Context) services.InspectionRequest {
+	adminID, _ := getRequestUser(c)
+	return services.InspectionRequest{
+		ServiceID: c.Param("id"),
+		AdminID:   adminID,
+		Reason:    c.Query("reason"),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+func inspectionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInspectedServiceNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ServiceMoveController handles moves of services between environments
+type ServiceMoveController struct {
+	moveService *services.ServiceMoveService
+}
+
+// NewServiceMoveController creates a new service move controller
+func NewServiceMoveController() *ServiceMoveController {
+	return &ServiceMoveController{
+		moveService: services.NewServiceMoveService(),
+	}
+}
+
+// RegisterRoutes registers service move routes
+func (c *ServiceMoveController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.POST("/:id/move", c.Move)
+		svc.GET("/:id/moves", c.ListMoves)
+		svc.GET("/:id/moves/:moveId", c.GetMove)
+	}
+}
+
+// Move migrates a service to another environment
+// @Summary Move a service to another environment
+// @Description Migrates a service to another environment of its project. The workload is recreated in the target namespace from the running image, or for managed services from their config with the data volumes copied over by a data-mover job; managed services are stopped while their data is copied, git services keep serving. Once the target is ready its Ingresses take over the hostnames, the TCP proxy of managed services switches over and the source is removed. A step that fails rolls the move back so the service runs in its source environment again. The service keeps its name, hostnames and TCP port; deploys are blocked until the move finishes. Poll the returned move for progress.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param move body dto.ServiceMoveRequest true "Target environment"
+// @Success 202 {object} object{data=models.ServiceMove}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string}
+// @Failure 422 {object} object{error=string}
+// @Failure 423 {object} object{error=string}
+// @Router /services/{id}/move [post]
+func (c *ServiceMoveController) Move(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ServiceMoveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	move, err := c.moveService.Move(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if respondDeployLocked(ctx, err) {
+		return
+	}
+	var conflict *services.NameConflictError
+	if errors.As(err, &conflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":      conflict.Error(),
+			"field":      conflict.Field,
+			"suggestion": conflict.Suggestion,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(serviceMoveErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"data": move,
+	})
+}
+
+// ListMoves returns the moves of a service
+// @Summary List moves of a service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.ServiceMoveListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/moves [get]
+func (c *ServiceMoveController) ListMoves(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	moves, err := c.moveService.ListMoves(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": moves,
+	})
+}
+
+// GetMove returns the progress of a service move
+// @Summary Get a service move
+// @Description Reports the step the move is at (copying, deploying, switching) or how it ended: completed, rolled_back with the cause, or failed when the rollback did not finish either.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param moveId path string true "Service move ID"
+// @Success 200 {object} object{data=models.ServiceMove}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/moves/{moveId} [get]
+func (c *ServiceMoveController) GetMove(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	move, err := c.moveService.GetMove(ctx.Param("id"), ctx.Param("moveId"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(serviceMoveErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": move,
+	})
+}
+
+func serviceMoveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrServiceMoveNotFound),
+		errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrServiceMoveInProgress):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrServiceMoveRejected):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
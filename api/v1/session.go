@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+	"gorm.io/gorm"
+)
+
+// ListSessions lists the caller's active sessions and API tokens
+// @Summary List active sessions and API tokens
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.SessionListResponse}
+// @Router /auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	sessions, err := services.NewSessionService().ListSessions(c.GetString("userId"), c.GetString("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to list sessions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   sessions,
+	})
+}
+
+// RevokeSession revokes one of the caller's sessions
+// @Summary Revoke a session
+// @Description The session's token is refused from then on, also on other API replicas within SESSION_REVOCATION_REFRESH_SECONDS.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} object{status=string,message=string}
+// @Failure 404 {object} object{status=string,message=string}
+// @Router /auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	err := services.NewSessionService().RevokeSession(c.Param("id"), c.GetString("userId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Session not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to revoke session",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Session revoked",
+	})
+}
+
+// RevokeAllSessions signs the caller out everywhere
+// @Summary Revoke all sessions
+// @Description Revokes every session of the caller except the current one unless keepCurrent=false, and with includeApiTokens=true every API token. Tokens issued before sessions were tracked are always revoked.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param keepCurrent query bool false "Keep the session making the request (default true)"
+// @Param includeApiTokens query bool false "Also revoke API tokens (default false)"
+// @Success 200 {object} object{status=string,data=dto.RevokeSessionsResponse}
+// @Router /auth/sessions [delete]
+func RevokeAllSessions(c *gin.Context) {
+	keepSessionID := c.GetString("sessionId")
+	if c.Query("keepCurrent") == "false" {
+		keepSessionID = ""
+	}
+
+	response, err := services.NewSessionService().RevokeAll(c.GetString("userId"), keepSessionID, c.Query("includeApiTokens") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to revoke sessions",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   response,
+	})
+}
+
+// ListUserSessions lists a user's active sessions and API tokens
+// @Summary List a user's sessions and API tokens (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} object{data=dto.SessionListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/sessions [get]
+func ListUserSessions(c *gin.Context) {
+	if _, err := services.GetUser(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	sessions, err := services.NewSessionService().ListSessions(c.Param("id"), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeUserSessions revokes every session of a user, e.g. after a credential leak
+// @Summary Revoke a user's sessions (admin only)
+// @Description Revokes every session and, unless includeApiTokens=false, every API token of the user.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param includeApiTokens query bool false "Also revoke API tokens (default true)"
+// @Success 200 {object} object{data=dto.RevokeSessionsResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /admin/users/{id}/sessions [delete]
+func RevokeUserSessions(c *gin.Context) {
+	if _, err := services.GetUser(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	response, err := services.NewSessionService().RevokeAll(c.Param("id"), "", c.Query("includeApiTokens") != "false")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
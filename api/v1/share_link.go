@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ShareLinkController handles time-limited, read-only preview links to environments
+type ShareLinkController struct {
+	shareLinkService *services.ShareLinkService
+}
+
+// NewShareLinkController creates a new share link controller
+func NewShareLinkController() *ShareLinkController {
+	return &ShareLinkController{
+		shareLinkService: services.NewShareLinkService(),
+	}
+}
+
+// RegisterRoutes registers the authenticated share link management routes
+func (c *ShareLinkController) RegisterRoutes(router *gin.RouterGroup) {
+	environments := router.Group("/environments")
+	{
+		environments.GET("/:id/share-links", c.ListShareLinks)
+		environments.POST("/:id/share-links", c.CreateShareLink)
+		environments.DELETE("/:id/share-links/:linkId", c.RevokeShareLink)
+	}
+}
+
+// RegisterPublicRoutes registers the routes opened through a share link, which need no account
+func (c *ShareLinkController) RegisterPublicRoutes(router *gin.RouterGroup) {
+	shared := router.Group("/shared")
+	{
+		shared.GET("/:token", c.GetSharedView)
+		shared.GET("/:token/services/:serviceId/logs", c.StreamSharedLogs)
+	}
+}
+
+// ListShareLinks returns the active share links of an environment
+// @Summary List the share links of an environment
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Success 200 {object} object{data=[]models.ShareLink}
+// @Failure 403 {object} object{error=string}
+// @Router /environments/{id}/share-links [get]
+func (c *ShareLinkController) ListShareLinks(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	links, err := c.shareLinkService.ListLinks(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": links,
+	})
+}
+
+// CreateShareLink issues a read-only preview link to an environment or one of its services
+// @Summary Create a share link
+// @Description The link opens the status and runtime logs of the shared services without an account until it expires or is revoked. The URL and token are returned only once.
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param link body dto.ShareLinkRequest true "Share link"
+// @Success 201 {object} object{data=dto.ShareLinkResponse}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /environments/{id}/share-links [post]
+func (c *ShareLinkController) CreateShareLink(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.ShareLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	link, err := c.shareLinkService.CreateLink(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": link,
+	})
+}
+
+// RevokeShareLink revokes a share link
+// @Summary Revoke a share link
+// @Tags share-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param linkId path string true "Share link ID"
+// @Success 200 {object} object{message=string}
+// @Failure 404 {object} object{error=string}
+// @Router /environments/{id}/share-links/{linkId} [delete]
+func (c *ShareLinkController) RevokeShareLink(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	err := c.shareLinkService.RevokeLink(ctx.Param("id"), ctx.Param("linkId"), userID, isAdmin)
+	if errors.Is(err, services.ErrShareLinkNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Share link revoked",
+	})
+}
+
+// GetSharedView returns the status of the services a share link covers
+// @Summary Open a share link
+// @Description Public: the signed token is the only credential.
+// @Tags share-links
+// @Produce json
+// @Param token path string true "Share link token"
+// @Success 200 {object} object{data=dto.SharedView}
+// @Failure 404 {object} object{error=string}
+// @Router /shared/{token} [get]
+func (c *ShareLinkController) GetSharedView(ctx *gin.Context) {
+	view, err := c.shareLinkService.GetSharedView(ctx.Param("token"))
+	if err != nil {
+		ctx.JSON(shareLinkErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": view,
+	})
+}
+
+// StreamSharedLogs streams the runtime logs of a service through a share link
+// @Summary Stream runtime logs through a share link
+// @Description Public: the signed token is the only credential. Secrets are redacted. Resumable with Last-Event-ID like the authenticated runtime log stream.
+// @Tags share-links
+// @Produce event-stream
+// @Param token path string true "Share link token"
+// @Param serviceId path string true "Service ID"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume the stream"
+// @Param lastEventId query string false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success 200 {string} string "Server-Sent Events"
+// @Failure 404 {object} object{error=string}
+// @Router /shared/{token}/services/{serviceId}/logs [get]
+func (c *ShareLinkController) StreamSharedLogs(ctx *gin.Context) {
+	serviceID := ctx.Param("serviceId")
+	if err := c.shareLinkService.CheckServiceAccess(ctx.Param("token"), serviceID); err != nil {
+		ctx.JSON(shareLinkErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Set headers for SSE streaming
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
+
+	if err := c.shareLinkService.StreamServiceLogs(serviceID, w, utils.GetLastEventID(ctx.Request)); err != nil {
+		// Headers are already sent; report the error as an event
+		w.Write([]byte("data: {\"error\": \"" + err.Error() + "\"}\n\n"))
+	}
+}
+
+func shareLinkErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidShareLink) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
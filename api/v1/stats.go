@@ -8,6 +8,13 @@ import (
 )
 
 // GetPodStats returns stats about pods in the cluster
+// @Summary Get pod statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.PodStatsResponse
+// @Router /admin/stats/pods [get]
 func GetPodStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -29,6 +36,12 @@ func GetPodStats(c *gin.Context) {
 }
 
 // GetNodeStats returns stats about nodes in the cluster
+// @Summary Get node statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.NodeStatsResponse
+// @Router /admin/stats/nodes [get]
 func GetNodeStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -50,6 +63,13 @@ func GetNodeStats(c *gin.Context) {
 }
 
 // GetDeploymentStats returns stats about deployments in the cluster
+// @Summary Get deployment statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.DeploymentStatsResponse
+// @Router /admin/stats/deployments [get]
 func GetDeploymentStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -74,6 +94,12 @@ func GetDeploymentStats(c *gin.Context) {
 }
 
 // GetClusterInfo returns general information about the cluster
+// @Summary Get cluster information (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.ClusterInfoResponse
+// @Router /admin/cluster/info [get]
 func GetClusterInfo(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -95,6 +121,13 @@ func GetClusterInfo(c *gin.Context) {
 }
 
 // GetServiceStats returns statistics about Kubernetes services
+// @Summary Get Kubernetes service statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.ServiceStatsResponse
+// @Router /admin/stats/services [get]
 func GetServiceStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -119,6 +152,13 @@ func GetServiceStats(c *gin.Context) {
 }
 
 // GetIngressStats returns statistics about Kubernetes ingress resources
+// @Summary Get ingress statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.IngressStatsResponse
+// @Router /admin/stats/ingress [get]
 func GetIngressStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -143,6 +183,13 @@ func GetIngressStats(c *gin.Context) {
 }
 
 // GetCertificateStats returns statistics about cert-manager certificates
+// @Summary Get certificate statistics (admin only)
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} dto.CertificateStatsResponse
+// @Router /admin/stats/certificates [get]
 func GetCertificateStats(c *gin.Context) {
 	statsService, err := services.NewStatsService()
 	if err != nil {
@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// StorageExpansionController handles online expansion of service volumes
+type StorageExpansionController struct {
+	expansionService *services.StorageExpansionService
+}
+
+// NewStorageExpansionController creates a new storage expansion controller
+func NewStorageExpansionController() *StorageExpansionController {
+	return &StorageExpansionController{
+		expansionService: services.NewStorageExpansionService(),
+	}
+}
+
+// RegisterRoutes registers storage expansion routes
+func (c *StorageExpansionController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.POST("/:id/storage/expand", c.Expand)
+		svc.GET("/:id/storage/expansions", c.ListExpansions)
+		svc.GET("/:id/storage/expansions/:expansionId", c.GetExpansion)
+	}
+}
+
+// Expand grows the volumes of a managed service
+// @Summary Expand the storage of a managed service
+// @Description Raises the size of each data volume of a managed service in place, without redeploying it. The storage class of the volumes must allow expansion, volumes never shrink, and the size must fit the environment's maximum and the namespace's ResourceQuotas. The resize is tracked until the volumes and their filesystems report the new size; poll the returned expansion for progress. Drivers that resize the filesystem on the node report filesystem_resize_pending until the pod mounts the volume again.
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param expansion body dto.StorageExpansionRequest true "New volume size"
+// @Success 202 {object} object{data=models.StorageExpansion}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 409 {object} object{error=string}
+// @Failure 422 {object} object{error=string}
+// @Router /services/{id}/storage/expand [post]
+func (c *StorageExpansionController) Expand(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.StorageExpansionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateStorageExpansionRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	expansion, err := c.expansionService.Expand(ctx.Param("id"), req, userID, isAdmin)
+	var fieldErrors utils.FieldErrors
+	if errors.As(err, &fieldErrors) {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err != nil {
+		ctx.JSON(storageExpansionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"data": expansion,
+	})
+}
+
+// ListExpansions returns the storage expansions of a service
+// @Summary List storage expansions of a service
+// @Tags storage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=dto.StorageExpansionListResponse}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/storage/expansions [get]
+func (c *StorageExpansionController) ListExpansions(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+	page, pageSize := parsePagination(ctx)
+
+	expansions, err := c.expansionService.ListExpansions(ctx.Param("id"), page, pageSize, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found or access denied",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": expansions,
+	})
+}
+
+// GetExpansion returns the progress of a storage expansion
+// @Summary Get a storage expansion
+// @Description Reports the state of the expansion and the capacity and state of each volume.
+// @Tags storage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param expansionId path string true "Storage expansion ID"
+// @Success 200 {object} object{data=models.StorageExpansion}
+// @Failure 404 {object} object{error=string}
+// @Router /services/{id}/storage/expansions/{expansionId} [get]
+func (c *StorageExpansionController) GetExpansion(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	expansion, err := c.expansionService.GetExpansion(ctx.Param("id"), ctx.Param("expansionId"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(storageExpansionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": expansion,
+	})
+}
+
+func storageExpansionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrStorageExpansionNotFound),
+		errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrStorageExpansionInProgress):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrStorageExpansionRejected):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetTenantIsolation returns the tenant isolation policy
+// @Summary Get the tenant isolation policy (admin only)
+// @Description When enabled, every environment's namespace gets a NetworkPolicy that keeps its pods from reaching the platform's namespaces (registry, build-and-deploy, platform API) directly; public endpoints stay reachable through the ingress. Enabled by default.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=models.TenantIsolationPolicy}
+// @Router /admin/tenant-isolation [get]
+func GetTenantIsolation(c *gin.Context) {
+	policy, err := services.NewTenantIsolationService().GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// UpdateTenantIsolation turns the tenant isolation on or off
+// @Summary Enable or disable tenant isolation (admin only)
+// @Description The NetworkPolicies are applied to or removed from every existing environment namespace at once; result lists the namespaces changed and any failures.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.TenantIsolationUpdateRequest true "Toggle"
+// @Success 200 {object} object{data=models.TenantIsolationPolicy,result=dto.TenantIsolationReconcileResult}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /admin/tenant-isolation [put]
+func UpdateTenantIsolation(c *gin.Context) {
+	var req dto.TenantIsolationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(c, err)
+		return
+	}
+
+	userID, _ := getRequestUser(c)
+	policy, result, err := services.NewTenantIsolationService().UpdatePolicy(req, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policy, "result": result})
+}
+
+// GetTenantIsolationReport checks every environment namespace against the policy
+// @Summary Tenant isolation conformance report (admin only)
+// @Description Lists each environment's namespace with whether its isolation NetworkPolicy is present and current. NetworkPolicies only take effect with a CNI that enforces them.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{data=dto.TenantIsolationReport}
+// @Router /admin/tenant-isolation/report [get]
+func GetTenantIsolationReport(c *gin.Context) {
+	report, err := services.NewTenantIsolationService().Report()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// TrafficController handles traffic analytics of services
+type TrafficController struct {
+	trafficService *services.TrafficService
+}
+
+// NewTrafficController creates a new traffic controller
+func NewTrafficController() *TrafficController {
+	return &TrafficController{
+		trafficService: services.NewTrafficService(),
+	}
+}
+
+// RegisterRoutes registers traffic routes
+func (c *TrafficController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.GET("/:id/traffic", c.GetServiceTraffic)
+	}
+}
+
+// GetServiceTraffic returns the traffic analytics of a service
+// @Summary Get traffic analytics of a service
+// @Description Request counts, status code distribution, latency percentiles, top paths and a timeline of the requests the ingress served for the service's hostnames, read from Traefik's JSON access logs. accessLogFound is false when the ingress writes no JSON access log.
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param window query string false "Period before now, e.g. 15m, 1h (default) or 24h (maximum)"
+// @Success 200 {object} object{data=dto.ServiceTraffic}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 503 {object} object{error=string}
+// @Router /services/{id}/traffic [get]
+func (c *TrafficController) GetServiceTraffic(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var window time.Duration
+	if value := ctx.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "window must be a positive duration such as 15m or 1h",
+			})
+			return
+		}
+		window = parsed
+	}
+
+	traffic, err := c.trafficService.GetServiceTraffic(ctx.Param("id"), window, userID, isAdmin)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, gin.H{
+			"data": traffic,
+		})
+	case errors.Is(err, services.ErrTrafficServiceNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAccessLogsUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// GetTwoFactorStatus reports whether the signed-in user has two-factor authentication
+// @Summary Get two-factor authentication status
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.TwoFactorStatus}
+// @Router /auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	status, err := services.NewTwoFactorService().GetStatus(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to retrieve two-factor status",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   status,
+	})
+}
+
+// SetupTwoFactor generates a TOTP secret for the signed-in user's authenticator app
+// @Summary Start two-factor authentication setup
+// @Description Returns a new TOTP secret and its otpauth:// URI. 2FA stays off until a code from the app is confirmed with /auth/2fa/enable.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{status=string,data=dto.TwoFactorSetupResponse}
+// @Failure 409 {object} object{status=string,message=string,error=string}
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	setup, err := services.NewTwoFactorService().Setup(c.GetString("userId"))
+	if err != nil {
+		respondTwoFactorError(c, "Failed to start two-factor setup", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   setup,
+	})
+}
+
+// EnableTwoFactor confirms the setup with a code and turns on two-factor authentication
+// @Summary Enable two-factor authentication
+// @Description Returns the recovery codes, which are shown only once, and a new session token that is also set as the access_token cookie. The current session is revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} object{status=string,data=dto.TwoFactorEnableResponse}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Failure 409 {object} object{status=string,message=string,error=string}
+// @Router /auth/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	client := services.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	response, err := services.NewTwoFactorService().Enable(c.GetString("userId"), req.Code, client, c.GetString("sessionId"))
+	if err != nil {
+		respondTwoFactorError(c, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	// Replace a setup-only session with a full one
+	c.SetCookie("access_token", response.Token, 86400, "/", "", true, true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   response,
+	})
+}
+
+// DisableTwoFactor turns off two-factor authentication for the signed-in user
+// @Summary Disable two-factor authentication
+// @Description Needs the password and a current code or a recovery code. Refused while the auth policy requires 2FA for the user.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.TwoFactorDisableRequest true "Password and second factor"
+// @Success 200 {object} object{status=string,message=string}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Failure 403 {object} object{status=string,message=string,error=string}
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := services.NewTwoFactorService().Disable(c.GetString("userId"), req); err != nil {
+		respondTwoFactorError(c, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the signed-in user's recovery codes
+// @Summary Regenerate two-factor recovery codes
+// @Description The previous codes stop working. The new codes are shown only once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} object{status=string,data=dto.RecoveryCodesResponse}
+// @Failure 400 {object} object{status=string,message=string,error=string}
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	codes, err := services.NewTwoFactorService().RegenerateRecoveryCodes(c.GetString("userId"), req.Code)
+	if err != nil {
+		respondTwoFactorError(c, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dto.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// respondTwoFactorError maps two-factor service errors to status codes
+func respondTwoFactorError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode),
+		errors.Is(err, services.ErrTwoFactorRequired),
+		errors.Is(err, services.ErrInvalidCredentials),
+		errors.Is(err, services.ErrTwoFactorNotSetUp),
+		errors.Is(err, services.ErrTwoFactorNotEnabled):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrTwoFactorEnforced):
+		status = http.StatusForbidden
+	}
+
+	c.JSON(status, gin.H{
+		"status":  "error",
+		"message": message,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,330 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+)
+
+// UptimeController handles uptime monitors, project status pages and incidents
+type UptimeController struct {
+	uptimeService     *services.UptimeService
+	statusPageService *services.StatusPageService
+}
+
+// NewUptimeController creates a new uptime controller
+func NewUptimeController() *UptimeController {
+	return &UptimeController{
+		uptimeService:     services.NewUptimeService(),
+		statusPageService: services.NewStatusPageService(),
+	}
+}
+
+// RegisterRoutes registers the authenticated uptime and status page routes
+func (c *UptimeController) RegisterRoutes(router *gin.RouterGroup) {
+	svc := router.Group("/services")
+	{
+		svc.GET("/:id/uptime", c.GetUptime)
+		svc.PUT("/:id/uptime/monitor", c.SaveMonitor)
+	}
+
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/status-page", c.GetStatusPage)
+		projects.PUT("/:id/status-page", c.SaveStatusPage)
+		projects.GET("/:id/incidents", c.ListIncidents)
+		projects.POST("/:id/incidents", c.CreateIncident)
+		projects.PUT("/:id/incidents/:incidentId", c.UpdateIncident)
+		projects.DELETE("/:id/incidents/:incidentId", c.DeleteIncident)
+	}
+}
+
+// RegisterPublicRoutes registers the unauthenticated status page route
+func (c *UptimeController) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.GET("/status-pages/:slug", c.GetPublicStatusPage)
+}
+
+// GetUptime returns the uptime history of a service
+// @Summary Get the uptime of a service
+// @Description Returns the monitor configuration, current status, uptime percentages over 24 hours, 7 and 30 days, and the most recent checks.
+// @Tags uptime
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=dto.UptimeSummary}
+// @Failure 403 {object} object{error=string}
+// @Router /services/{id}/uptime [get]
+func (c *UptimeController) GetUptime(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	summary, err := c.uptimeService.GetUptime(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": summary,
+	})
+}
+
+// SaveMonitor configures the uptime monitor of a service
+// @Summary Configure the uptime monitor of a service
+// @Description The checker requests the path on the service's domain over HTTPS every intervalSeconds; any response below 400 counts as up. Only git services can be monitored.
+// @Tags uptime
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Param monitor body dto.UptimeMonitorRequest true "Monitor configuration"
+// @Success 200 {object} object{data=models.UptimeMonitor}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /services/{id}/uptime/monitor [put]
+func (c *UptimeController) SaveMonitor(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.UptimeMonitorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateUptimeMonitorRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	monitor, err := c.uptimeService.SaveMonitor(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": monitor,
+	})
+}
+
+// GetStatusPage returns the status page configuration of a project
+// @Summary Get the status page of a project
+// @Tags status-pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=models.StatusPage}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/status-page [get]
+func (c *UptimeController) GetStatusPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	page, err := c.statusPageService.GetStatusPage(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(statusPageErrorStatus(err, http.StatusForbidden), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": page,
+	})
+}
+
+// SaveStatusPage configures the public status page of a project
+// @Summary Configure the status page of a project
+// @Description The page is served without authentication at /status-pages/{slug} and lists the project's monitored services with their uptime and recent incidents.
+// @Tags status-pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param page body dto.StatusPageRequest true "Status page configuration"
+// @Success 200 {object} object{data=models.StatusPage}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/status-page [put]
+func (c *UptimeController) SaveStatusPage(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.StatusPageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+	if err := utils.ValidateStatusPageRequest(req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	page, err := c.statusPageService.SaveStatusPage(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": page,
+	})
+}
+
+// ListIncidents returns the incidents of a project
+// @Summary List the incidents of a project
+// @Description Returns open incidents and those resolved in the last 14 days, newest first.
+// @Tags status-pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.Incident}
+// @Failure 403 {object} object{error=string}
+// @Router /projects/{id}/incidents [get]
+func (c *UptimeController) ListIncidents(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	incidents, err := c.statusPageService.ListIncidents(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": incidents,
+	})
+}
+
+// CreateIncident annotates a project's status page with an incident
+// @Summary Create an incident
+// @Tags status-pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param incident body dto.IncidentRequest true "Incident"
+// @Success 201 {object} object{data=models.Incident}
+// @Failure 400 {object} dto.ProblemDetails
+// @Router /projects/{id}/incidents [post]
+func (c *UptimeController) CreateIncident(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.IncidentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	incident, err := c.statusPageService.CreateIncident(ctx.Param("id"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"data": incident,
+	})
+}
+
+// UpdateIncident changes an incident
+// @Summary Update an incident
+// @Description Setting the status to resolved records when the incident ended.
+// @Tags status-pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param incidentId path string true "Incident ID"
+// @Param incident body dto.IncidentUpdateRequest true "Fields to change"
+// @Success 200 {object} object{data=models.Incident}
+// @Failure 400 {object} dto.ProblemDetails
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/incidents/{incidentId} [put]
+func (c *UptimeController) UpdateIncident(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var req dto.IncidentUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondValidationProblem(ctx, err)
+		return
+	}
+
+	incident, err := c.statusPageService.UpdateIncident(ctx.Param("id"), ctx.Param("incidentId"), req, userID, isAdmin)
+	if err != nil {
+		ctx.JSON(statusPageErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": incident,
+	})
+}
+
+// DeleteIncident removes an incident
+// @Summary Delete an incident
+// @Tags status-pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param incidentId path string true "Incident ID"
+// @Success 200 {object} object{data=object{message=string}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /projects/{id}/incidents/{incidentId} [delete]
+func (c *UptimeController) DeleteIncident(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	if err := c.statusPageService.DeleteIncident(ctx.Param("id"), ctx.Param("incidentId"), userID, isAdmin); err != nil {
+		ctx.JSON(statusPageErrorStatus(err, http.StatusBadRequest), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"message": "Incident deleted",
+		},
+	})
+}
+
+// GetPublicStatusPage renders a project's public status page
+// @Summary Get a public status page
+// @Description Unauthenticated. Lists the project's monitored services by name with their uptime, and open or recently resolved incidents.
+// @Tags status-pages
+// @Produce json
+// @Param slug path string true "Status page slug"
+// @Success 200 {object} object{data=dto.PublicStatusPage}
+// @Failure 404 {object} object{error=string}
+// @Router /status-pages/{slug} [get]
+func (c *UptimeController) GetPublicStatusPage(ctx *gin.Context) {
+	page, err := c.statusPageService.GetPublicStatusPage(ctx.Param("slug"))
+	if err != nil {
+		ctx.JSON(statusPageErrorStatus(err, http.StatusInternalServerError), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": page,
+	})
+}
+
+func statusPageErrorStatus(err error, fallback int) int {
+	if errors.Is(err, services.ErrStatusPageNotFound) || errors.Is(err, services.ErrIncidentNotFound) {
+		return http.StatusNotFound
+	}
+	return fallback
+}
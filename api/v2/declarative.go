@@ -0,0 +1,237 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// DeclarativeController serves create-or-update-by-name and import endpoints for
+// infrastructure-as-code tools such as a Terraform provider
+type DeclarativeController struct {
+	declarativeService *services.DeclarativeService
+}
+
+// NewDeclarativeController creates a new declarative controller
+func NewDeclarativeController() *DeclarativeController {
+	return &DeclarativeController{
+		declarativeService: services.NewDeclarativeService(),
+	}
+}
+
+// RegisterRoutes registers the declarative routes
+func (c *DeclarativeController) RegisterRoutes(router *gin.RouterGroup) {
+	projects := router.Group("/projects")
+	{
+		projects.GET("/by-name/:name", c.GetProjectByName)
+		projects.PUT("/by-name/:name", c.ApplyProject)
+		projects.GET("/:id/environments/by-name/:name", c.GetEnvironmentByName)
+		projects.PUT("/:id/environments/by-name/:name", c.ApplyEnvironment)
+	}
+
+	environments := router.Group("/environments")
+	{
+		environments.GET("/:id/services/by-name/:name", c.GetServiceByName)
+		environments.PUT("/:id/services/by-name/:name", c.ApplyService)
+	}
+}
+
+// GetProjectByName looks up one of the caller's projects by name, e.g. to import it
+// @Summary Import a project by name
+// @Tags declarative
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Project name"
+// @Success 200 {object} object{data=models.Project}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Failure 409 {object} object{error=v2.ErrorBody}
+// @Router /projects/by-name/{name} [get]
+func (c *DeclarativeController) GetProjectByName(ctx *gin.Context) {
+	userID, _ := getRequestUser(ctx)
+
+	project, err := c.declarativeService.FindProjectByName(ctx.Param("name"), userID)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respond(ctx, http.StatusOK, project, nil)
+}
+
+// ApplyProject creates or updates one of the caller's projects by name
+// @Summary Create or update a project by name
+// @Tags declarative
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Project name"
+// @Param project body dto.ProjectApplyRequest true "Desired state"
+// @Success 200 {object} object{data=models.Project,meta=v2.Meta}
+// @Success 201 {object} object{data=models.Project,meta=v2.Meta}
+// @Failure 400 {object} object{error=v2.ErrorBody}
+// @Failure 409 {object} object{error=v2.ErrorBody}
+// @Router /projects/by-name/{name} [put]
+func (c *DeclarativeController) ApplyProject(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var spec dto.ProjectApplyRequest
+	if err := ctx.ShouldBindJSON(&spec); err != nil {
+		respondError(ctx, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	project, result, err := c.declarativeService.ApplyProject(ctx.Param("name"), spec, userID, isAdmin)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respondApplied(ctx, project, result)
+}
+
+// GetEnvironmentByName looks up an environment of a project by name, e.g. to import it
+// @Summary Import an environment by name
+// @Tags declarative
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param name path string true "Environment name"
+// @Success 200 {object} object{data=models.Environment}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Router /projects/{id}/environments/by-name/{name} [get]
+func (c *DeclarativeController) GetEnvironmentByName(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	env, err := c.declarativeService.FindEnvironmentByName(ctx.Param("id"), ctx.Param("name"), userID, isAdmin)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respond(ctx, http.StatusOK, env, nil)
+}
+
+// ApplyEnvironment creates or updates an environment of a project by name
+// @Summary Create or update an environment by name
+// @Tags declarative
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param name path string true "Environment name"
+// @Param environment body dto.EnvironmentApplyRequest true "Desired state"
+// @Success 200 {object} object{data=models.Environment,meta=v2.Meta}
+// @Success 201 {object} object{data=models.Environment,meta=v2.Meta}
+// @Failure 400 {object} object{error=v2.ErrorBody}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Router /projects/{id}/environments/by-name/{name} [put]
+func (c *DeclarativeController) ApplyEnvironment(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var spec dto.EnvironmentApplyRequest
+	if err := ctx.ShouldBindJSON(&spec); err != nil {
+		respondError(ctx, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	env, result, err := c.declarativeService.ApplyEnvironment(ctx.Param("id"), ctx.Param("name"), spec, userID, isAdmin)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respondApplied(ctx, env, result)
+}
+
+// GetServiceByName looks up a service of an environment by name, e.g. to import it
+// @Summary Import a service by name
+// @Tags declarative
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param name path string true "Service name"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Router /environments/{id}/services/by-name/{name} [get]
+func (c *DeclarativeController) GetServiceByName(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.declarativeService.FindServiceByName(ctx.Param("id"), ctx.Param("name"), userID, isAdmin)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respond(ctx, http.StatusOK, service, nil)
+}
+
+// ApplyService creates or updates a service of an environment by name. Repeating the
+// request with the same body neither writes nor redeploys.
+// @Summary Create or update a service by name
+// @Tags declarative
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Environment ID"
+// @Param name path string true "Service name"
+// @Param service body dto.ServiceApplyRequest true "Desired state"
+// @Success 200 {object} object{data=models.Service,meta=v2.Meta}
+// @Success 201 {object} object{data=models.Service,meta=v2.Meta}
+// @Failure 400 {object} object{error=v2.ErrorBody}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Failure 409 {object} object{error=v2.ErrorBody}
+// @Router /environments/{id}/services/by-name/{name} [put]
+func (c *DeclarativeController) ApplyService(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	var spec dto.ServiceApplyRequest
+	if err := ctx.ShouldBindJSON(&spec); err != nil {
+		respondError(ctx, http.StatusBadRequest, "invalid_request", err)
+		return
+	}
+
+	service, result, err := c.declarativeService.ApplyService(ctx.Param("id"), ctx.Param("name"), spec, userID, isAdmin)
+	if err != nil {
+		respondDeclarativeError(ctx, err)
+		return
+	}
+	respondApplied(ctx, service, result)
+}
+
+// respondApplied answers 201 when the resource was created and 200 otherwise
+func respondApplied(ctx *gin.Context, data interface{}, result dto.ApplyResult) {
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	respond(ctx, status, data, &Meta{Created: result.Created, Changed: result.Changed})
+}
+
+// respondDeclarativeError maps service errors to machine-readable error codes
+func respondDeclarativeError(ctx *gin.Context, err error) {
+	var fieldErrors utils.FieldErrors
+	var conflict *services.NameConflictError
+	var ambiguous *services.AmbiguousNameError
+	var replacement *services.ReplacementRequiredError
+	var protected *services.ProtectedServicesError
+
+	switch {
+	case errors.As(err, &fieldErrors):
+		respondError(ctx, http.StatusBadRequest, "validation_failed", err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		respondError(ctx, http.StatusNotFound, "not_found", err)
+	case errors.As(err, &conflict):
+		respondError(ctx, http.StatusConflict, "name_conflict", err)
+	case errors.As(err, &ambiguous):
+		respondError(ctx, http.StatusConflict, "ambiguous_name", err)
+	case errors.As(err, &replacement):
+		respondError(ctx, http.StatusConflict, "replacement_required", err)
+	case errors.As(err, &protected):
+		respondError(ctx, http.StatusConflict, "deletion_protected", err)
+	case strings.Contains(err.Error(), "unauthorized"):
+		respondError(ctx, http.StatusForbidden, "forbidden", err)
+	default:
+		respondError(ctx, http.StatusBadRequest, "apply_failed", err)
+	}
+}
@@ -0,0 +1,49 @@
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// v2 responses always use the same envelope:
+//
+//	{"data": ..., "meta": {...}}                     on success
+//	{"error": {"code": "...", "message": "..."}}    on failure
+
+// Meta carries pagination and other response metadata
+type Meta struct {
+	Page       int   `json:"page,omitempty"`
+	PageSize   int   `json:"pageSize,omitempty"`
+	TotalCount int64 `json:"totalCount,omitempty"`
+	TotalPages int   `json:"totalPages,omitempty"`
+
+	// Declarative (PUT by name) requests: whether the resource was created and which fields changed
+	Created bool     `json:"created,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// ErrorBody is a machine-readable error
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Meta  *Meta       `json:"meta,omitempty"`
+	Error *ErrorBody  `json:"error,omitempty"`
+}
+
+// respond writes a successful response
+func respond(c *gin.Context, status int, data interface{}, meta *Meta) {
+	c.JSON(status, envelope{Data: data, Meta: meta})
+}
+
+// respondError writes an error response and aborts the chain
+func respondError(c *gin.Context, status int, code string, err error) {
+	c.AbortWithStatusJSON(status, envelope{Error: &ErrorBody{Code: code, Message: err.Error()}})
+}
+
+// getRequestUser extracts the user ID and admin flag set by AuthMiddleware
+func getRequestUser(c *gin.Context) (string, bool) {
+	return c.GetString("userId"), c.GetString("role") == "admin"
+}
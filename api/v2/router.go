@@ -0,0 +1,15 @@
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers all v2 API routes. Every v2 response uses the
+// {"data", "meta", "error"} envelope from response.go.
+func RegisterRoutes(router *gin.RouterGroup) {
+	serviceController := NewServiceController()
+	serviceController.RegisterRoutes(router)
+
+	declarativeController := NewDeclarativeController()
+	declarativeController.RegisterRoutes(router)
+}
@@ -0,0 +1,136 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/services"
+)
+
+// ServiceController serves the v2 service endpoints
+type ServiceController struct {
+	serviceService *services.ServiceService
+}
+
+// NewServiceController creates a new v2 service controller
+func NewServiceController() *ServiceController {
+	return &ServiceController{
+		serviceService: services.NewServiceService(),
+	}
+}
+
+// RegisterRoutes registers v2 service routes
+func (c *ServiceController) RegisterRoutes(router *gin.RouterGroup) {
+	servicesGroup := router.Group("/services")
+	{
+		servicesGroup.GET("", c.ListServices)
+		servicesGroup.GET("/:id", c.GetService)
+	}
+
+	projects := router.Group("/projects")
+	{
+		projects.GET("/:id/services", c.ListProjectServices)
+	}
+}
+
+// ListServices lists services with pagination metadata (admin only)
+// @Summary List all services (admin only)
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Search term"
+// @Param status query string false "Service status"
+// @Param type query string false "git or managed"
+// @Param environmentId query string false "Environment ID"
+// @Param projectId query string false "Project ID"
+// @Param sortBy query string false "Sort column"
+// @Param sortOrder query string false "asc or desc"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} object{data=[]models.Service,meta=v2.Meta}
+// @Failure 403 {object} object{error=v2.ErrorBody}
+// @Router /services [get]
+func (c *ServiceController) ListServices(ctx *gin.Context) {
+	_, isAdmin := getRequestUser(ctx)
+	if !isAdmin {
+		respondError(ctx, http.StatusForbidden, "forbidden", errors.New("only administrators can view all services"))
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	result, err := c.serviceService.ListAllServices(dto.ServiceFilter{
+		Search:        ctx.Query("search"),
+		Status:        ctx.Query("status"),
+		Type:          ctx.Query("type"),
+		EnvironmentID: ctx.Query("environmentId"),
+		ProjectID:     ctx.Query("projectId"),
+		SortBy:        ctx.DefaultQuery("sortBy", "created_at"),
+		SortOrder:     ctx.DefaultQuery("sortOrder", "desc"),
+		Page:          page,
+		PageSize:      pageSize,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, "internal_error", err)
+		return
+	}
+
+	respond(ctx, http.StatusOK, result.Services, &Meta{
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		TotalCount: result.TotalCount,
+		TotalPages: result.TotalPages,
+	})
+}
+
+// GetService returns a single service
+// @Summary Get a service
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Service ID"
+// @Success 200 {object} object{data=models.Service}
+// @Failure 404 {object} object{error=v2.ErrorBody}
+// @Router /services/{id} [get]
+func (c *ServiceController) GetService(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	service, err := c.serviceService.GetServiceDetail(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		respondError(ctx, http.StatusNotFound, "not_found", err)
+		return
+	}
+
+	respond(ctx, http.StatusOK, service, nil)
+}
+
+// ListProjectServices lists the services of a project
+// @Summary List the services of a project
+// @Tags services
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 200 {object} object{data=[]models.Service,meta=v2.Meta}
+// @Failure 403 {object} object{error=v2.ErrorBody}
+// @Router /projects/{id}/services [get]
+func (c *ServiceController) ListProjectServices(ctx *gin.Context) {
+	userID, isAdmin := getRequestUser(ctx)
+
+	services, err := c.serviceService.ListProjectServices(ctx.Param("id"), userID, isAdmin)
+	if err != nil {
+		respondError(ctx, http.StatusForbidden, "forbidden", err)
+		return
+	}
+
+	respond(ctx, http.StatusOK, services, &Meta{TotalCount: int64(len(services))})
+}
@@ -0,0 +1,84 @@
+// Command migrate applies, rolls back and reports versioned schema migrations
+// against DATABASE_URL. It is meant for CI/CD jobs that migrate before rolling out
+// the backend (run the backend with DB_AUTO_MIGRATE=false in that setup).
+//
+//	go run ./cmd/migrate up              apply all pending migrations
+//	go run ./cmd/migrate down [steps]    roll back the last migration(s), default 1
+//	go run ./cmd/migrate status [-check] list migrations; -check exits 1 if any are pending
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"github.com/pendeploy-simple/database"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	switch command := os.Args[1]; command {
+	case "up":
+		if err := database.Migrate(database.DB); err != nil {
+			log.Fatalf("%v", err)
+		}
+		log.Println("Database is up to date")
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			n, err := strconv.Atoi(os.Args[2])
+			if err != nil || n <= 0 {
+				log.Fatalf("invalid step count %q", os.Args[2])
+			}
+			steps = n
+		}
+		if err := database.Rollback(database.DB, steps); err != nil {
+			log.Fatalf("%v", err)
+		}
+
+	case "status":
+		flags := flag.NewFlagSet("status", flag.ExitOnError)
+		check := flags.Bool("check", false, "exit with status 1 when migrations are pending")
+		flags.Parse(os.Args[2:])
+
+		report, err := database.GetMigrationReport(database.DB)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		for _, migration := range report.Migrations {
+			state := "pending"
+			if migration.Applied {
+				state = "applied " + migration.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if migration.Unknown {
+				state += " (unknown to this build)"
+			}
+			fmt.Printf("%-40s %s\n", migration.ID, state)
+		}
+		fmt.Printf("%d pending\n", report.Pending)
+		if *check && report.Pending > 0 {
+			os.Exit(1)
+		}
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | status [-check]")
+	os.Exit(2)
+}
@@ -0,0 +1,678 @@
+// Command openapi-gen builds the OpenAPI 3 document served at /api/v1/openapi.json.
+//
+// Operations come from swag-style annotations on handler functions
+// (@Summary, @Tags, @Param, @Success, @Failure, @Router, ...) and schemas are
+// derived from the structs in dto/ and models/, including binding rules.
+//
+// Run it through go generate from api/openapi:
+//
+//	go generate ./api/openapi
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// operationDirs maps handler packages to the prefix their routes are mounted under
+var operationDirs = map[string]string{
+	"api/v1":      "/api/v1",
+	"controllers": "/api/v1",
+	"api/v2":      "/api/v2",
+}
+
+// schemaDirs are the packages whose exported types all become component schemas
+var schemaDirs = []string{"dto", "models"}
+
+// refOnlyDirs maps packages whose types are only emitted when an annotation
+// references them to the qualifier used in annotations
+var refOnlyDirs = map[string]string{
+	"api/v2": "v2",
+}
+
+type schema = map[string]interface{}
+
+type generator struct {
+	fset    *token.FileSet
+	types   map[string]*ast.TypeSpec // "dto.ServiceRequest" -> spec
+	enums   map[string][]string      // "models.ServiceType" -> const values
+	schemas map[string]schema
+	paths   map[string]map[string]interface{}
+}
+
+func main() {
+	root := flag.String("root", ".", "repository root")
+	out := flag.String("out", "api/openapi/openapi.json", "output file")
+	flag.Parse()
+
+	g := &generator{
+		fset:    token.NewFileSet(),
+		types:   make(map[string]*ast.TypeSpec),
+		enums:   make(map[string][]string),
+		schemas: make(map[string]schema),
+		paths:   make(map[string]map[string]interface{}),
+	}
+
+	for _, dir := range schemaDirs {
+		g.loadTypes(filepath.Join(*root, dir), dir)
+	}
+	for name := range g.types {
+		g.buildComponent(name)
+	}
+	for dir, pkg := range refOnlyDirs {
+		g.loadTypes(filepath.Join(*root, dir), pkg)
+	}
+
+	dirs := make([]string, 0, len(operationDirs))
+	for dir := range operationDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		g.loadOperations(filepath.Join(*root, dir), operationDirs[dir])
+	}
+
+	document := schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":       "PenDeploy API",
+			"version":     "1.0.0",
+			"description": "Generated by cmd/openapi-gen. Do not edit by hand.",
+		},
+		"servers": []schema{{"url": "/"}},
+		"paths":   g.paths,
+		"components": schema{
+			"schemas": g.schemas,
+			"securitySchemes": schema{
+				"BearerAuth": schema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to encode document: %v", err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatalf("failed to write %s: %v", *out, err)
+	}
+	log.Printf("wrote %s: %d paths, %d schemas", *out, len(g.paths), len(g.schemas))
+}
+
+// parseDir parses the non-test Go files of a directory
+func (g *generator) parseDir(dir string) []*ast.File {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		log.Fatalf("failed to list %s: %v", dir, err)
+	}
+
+	var files []*ast.File
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(g.fset, path, nil, parser.ParseComments)
+		if err != nil {
+			log.Fatalf("failed to parse %s: %v", path, err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// loadTypes records exported type declarations and typed string constants
+func (g *generator) loadTypes(dir, pkg string) {
+	for _, file := range g.parseDir(dir) {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			switch gen.Tok {
+			case token.TYPE:
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					if !typeSpec.Name.IsExported() {
+						continue
+					}
+					if typeSpec.Doc == nil && len(gen.Specs) == 1 {
+						typeSpec.Doc = gen.Doc
+					}
+					g.types[pkg+"."+typeSpec.Name.Name] = typeSpec
+				}
+			case token.CONST:
+				for _, spec := range gen.Specs {
+					valueSpec := spec.(*ast.ValueSpec)
+					ident, ok := valueSpec.Type.(*ast.Ident)
+					if !ok {
+						continue
+					}
+					for _, value := range valueSpec.Values {
+						if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							if unquoted, err := strconv.Unquote(lit.Value); err == nil {
+								key := pkg + "." + ident.Name
+								g.enums[key] = append(g.enums[key], unquoted)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// buildComponent renders a named type into components.schemas
+func (g *generator) buildComponent(name string) {
+	if _, done := g.schemas[name]; done {
+		return
+	}
+	spec, ok := g.types[name]
+	if !ok {
+		return
+	}
+	// Reserve the name first so recursive types terminate
+	g.schemas[name] = schema{}
+
+	pkg := strings.SplitN(name, ".", 2)[0]
+	s := g.schemaForExpr(spec.Type, pkg)
+	if values, ok := g.enums[name]; ok {
+		s["enum"] = values
+	}
+	if spec.Doc != nil {
+		s["description"] = strings.TrimSpace(spec.Doc.Text())
+	}
+	g.schemas[name] = s
+}
+
+func ref(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaForExpr converts a Go type expression from package pkg into a schema
+func (g *generator) schemaForExpr(expr ast.Expr, pkg string) schema {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if s := primitiveSchema(t.Name); s != nil {
+			return s
+		}
+		name := pkg + "." + t.Name
+		if _, ok := g.types[name]; ok {
+			g.buildComponent(name)
+			return ref(name)
+		}
+		return schema{}
+	case *ast.SelectorExpr:
+		qualifier, _ := t.X.(*ast.Ident)
+		if qualifier == nil {
+			return schema{}
+		}
+		full := qualifier.Name + "." + t.Sel.Name
+		switch full {
+		case "time.Time":
+			return schema{"type": "string", "format": "date-time"}
+		case "time.Duration":
+			return schema{"type": "integer", "format": "int64"}
+		case "gorm.DeletedAt":
+			return schema{"type": "string", "format": "date-time", "nullable": true}
+		}
+		if _, ok := g.types[full]; ok {
+			g.buildComponent(full)
+			return ref(full)
+		}
+		return schema{}
+	case *ast.StarExpr:
+		inner := g.schemaForExpr(t.X, pkg)
+		if _, isRef := inner["$ref"]; !isRef && len(inner) > 0 {
+			inner["nullable"] = true
+		}
+		return inner
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return schema{"type": "string", "format": "byte"}
+		}
+		return schema{"type": "array", "items": g.schemaForExpr(t.Elt, pkg)}
+	case *ast.MapType:
+		return schema{"type": "object", "additionalProperties": g.schemaForExpr(t.Value, pkg)}
+	case *ast.InterfaceType:
+		return schema{}
+	case *ast.StructType:
+		return g.schemaForStruct(t, pkg)
+	}
+	return schema{}
+}
+
+func primitiveSchema(name string) schema {
+	switch name {
+	case "string":
+		return schema{"type": "string"}
+	case "bool":
+		return schema{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+		return schema{"type": "integer", "format": "int32"}
+	case "int64", "uint64":
+		return schema{"type": "integer", "format": "int64"}
+	case "float32", "float64":
+		return schema{"type": "number"}
+	case "any":
+		return schema{}
+	}
+	return nil
+}
+
+// schemaForStruct builds an object schema from struct fields, json tags and binding rules
+func (g *generator) schemaForStruct(st *ast.StructType, pkg string) schema {
+	properties := schema{}
+	var required []string
+
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			unquoted, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(unquoted)
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened, like encoding/json does
+		if len(field.Names) == 0 && jsonName == "" {
+			if embedded := g.embeddedStruct(field.Type, pkg); embedded != nil {
+				if props, ok := embedded["properties"].(schema); ok {
+					for k, v := range props {
+						properties[k] = v
+					}
+				}
+				if req, ok := embedded["required"].([]string); ok {
+					required = append(required, req...)
+				}
+			}
+			continue
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: jsonName}}
+		}
+		for _, name := range names {
+			if !name.IsExported() && jsonName == "" {
+				continue
+			}
+			propertyName := jsonName
+			if propertyName == "" {
+				propertyName = name.Name
+			}
+
+			property := g.schemaForExpr(field.Type, pkg)
+			if description := fieldDescription(field); description != "" {
+				property = withExtra(property, "description", description)
+			}
+			if applyBindingRules(&property, tag.Get("binding")) {
+				required = append(required, propertyName)
+			}
+			properties[propertyName] = property
+		}
+	}
+
+	s := schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+// embeddedStruct resolves an embedded field to its struct schema
+func (g *generator) embeddedStruct(expr ast.Expr, pkg string) schema {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	var name string
+	switch t := expr.(type) {
+	case *ast.Ident:
+		name = pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		if qualifier, ok := t.X.(*ast.Ident); ok {
+			name = qualifier.Name + "." + t.Sel.Name
+		}
+	}
+
+	spec, ok := g.types[name]
+	if !ok {
+		return nil
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	return g.schemaForStruct(st, strings.SplitN(name, ".", 2)[0])
+}
+
+func fieldDescription(field *ast.Field) string {
+	if field.Doc != nil {
+		return strings.TrimSpace(field.Doc.Text())
+	}
+	if field.Comment != nil {
+		return strings.TrimSpace(field.Comment.Text())
+	}
+	return ""
+}
+
+// withExtra adds a keyword next to a $ref by wrapping it in allOf (OpenAPI 3.0
+// ignores siblings of $ref)
+func withExtra(s schema, key string, value interface{}) schema {
+	if _, isRef := s["$ref"]; isRef {
+		return schema{"allOf": []schema{s}, key: value}
+	}
+	s[key] = value
+	return s
+}
+
+// applyBindingRules maps gin binding tags onto schema keywords and reports whether
+// the field is required
+func applyBindingRules(s *schema, binding string) bool {
+	if binding == "" {
+		return false
+	}
+
+	required := false
+	kind, _ := (*s)["type"].(string)
+	for _, rule := range strings.Split(binding, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			*s = withExtra(*s, "format", "email")
+		case "url":
+			*s = withExtra(*s, "format", "uri")
+		case "uuid":
+			*s = withExtra(*s, "format", "uuid")
+		case "oneof":
+			*s = withExtra(*s, "enum", strings.Fields(arg))
+		case "min", "max", "gte", "lte":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte"
+			switch kind {
+			case "string":
+				if lower {
+					(*s)["minLength"] = n
+				} else {
+					(*s)["maxLength"] = n
+				}
+			case "array":
+				if lower {
+					(*s)["minItems"] = n
+				} else {
+					(*s)["maxItems"] = n
+				}
+			case "integer", "number":
+				if lower {
+					(*s)["minimum"] = n
+				} else {
+					(*s)["maximum"] = n
+				}
+			}
+		}
+	}
+	return required
+}
+
+var (
+	paramPattern    = regexp.MustCompile(`^(\S+)\s+(\S+)\s+(\S+)\s+(\S+)(?:\s+"(.*)")?$`)
+	responsePattern = regexp.MustCompile(`^(\d+)\s+\{(\w+)\}\s+(\S+)(?:\s+"(.*)")?$`)
+	routerPattern   = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]$`)
+)
+
+// loadOperations reads annotated handler functions into paths
+func (g *generator) loadOperations(dir, prefix string) {
+	for _, file := range g.parseDir(dir) {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			g.addOperation(fn, prefix)
+		}
+	}
+}
+
+func (g *generator) addOperation(fn *ast.FuncDecl, prefix string) {
+	operation := schema{"operationId": fn.Name.Name}
+	responses := schema{}
+	var parameters []schema
+	var path, method string
+	var description []string
+
+	for _, line := range strings.Split(fn.Doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			if line != "" && !strings.HasSuffix(line, "godoc") {
+				description = append(description, line)
+			}
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch keyword {
+		case "@Summary":
+			operation["summary"] = rest
+		case "@Description":
+			operation["description"] = rest
+		case "@Tags":
+			operation["tags"] = strings.Split(rest, ",")
+		case "@ID":
+			operation["operationId"] = rest
+		case "@Deprecated":
+			operation["deprecated"] = true
+		case "@Security":
+			security, _ := operation["security"].([]schema)
+			operation["security"] = append(security, schema{rest: []string{}})
+		case "@Param":
+			match := paramPattern.FindStringSubmatch(rest)
+			if match == nil {
+				log.Fatalf("%s: invalid @Param %q", fn.Name.Name, rest)
+			}
+			name, in, typeRef, required, desc := match[1], match[2], match[3], match[4] == "true", match[5]
+			if in == "body" {
+				operation["requestBody"] = schema{
+					"required":    required,
+					"description": desc,
+					"content":     schema{"application/json": schema{"schema": g.parseTypeRef(typeRef)}},
+				}
+				continue
+			}
+			parameters = append(parameters, schema{
+				"name":        name,
+				"in":          in,
+				"required":    required || in == "path",
+				"description": desc,
+				"schema":      g.parseTypeRef(typeRef),
+			})
+		case "@Success", "@Failure":
+			match := responsePattern.FindStringSubmatch(rest)
+			if match == nil {
+				log.Fatalf("%s: invalid %s %q", fn.Name.Name, keyword, rest)
+			}
+			code, kind, typeRef, desc := match[1], match[2], match[3], match[4]
+			if desc == "" {
+				desc = defaultResponseDescription(code)
+			}
+			response := schema{"description": desc}
+			switch kind {
+			case "object":
+				response["content"] = schema{"application/json": schema{"schema": g.parseTypeRef(typeRef)}}
+			case "array":
+				response["content"] = schema{"application/json": schema{"schema": schema{"type": "array", "items": g.parseTypeRef(typeRef)}}}
+			case "string":
+				contentType := "text/plain"
+				if produces, ok := operation["x-produces"].(string); ok {
+					contentType = produces
+				}
+				response["content"] = schema{contentType: schema{"schema": schema{"type": "string"}}}
+			}
+			responses[code] = response
+		case "@Produce":
+			if rest != "json" {
+				operation["x-produces"] = mimeType(rest)
+			}
+		case "@Router":
+			match := routerPattern.FindStringSubmatch(rest)
+			if match == nil {
+				log.Fatalf("%s: invalid @Router %q", fn.Name.Name, rest)
+			}
+			path, method = prefix+strings.TrimSuffix(match[1], "/"), strings.ToLower(match[2])
+		}
+	}
+
+	if path == "" {
+		return
+	}
+	delete(operation, "x-produces")
+	if _, ok := operation["summary"]; !ok && len(description) > 0 {
+		operation["summary"] = strings.TrimPrefix(description[0], fn.Name.Name+" ")
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if len(responses) == 0 {
+		responses["200"] = schema{"description": "OK"}
+	}
+	operation["responses"] = responses
+
+	if g.paths[path] == nil {
+		g.paths[path] = make(map[string]interface{})
+	}
+	if _, exists := g.paths[path][method]; exists {
+		log.Fatalf("duplicate operation %s %s (%s)", method, path, fn.Name.Name)
+	}
+	g.paths[path][method] = operation
+}
+
+func mimeType(short string) string {
+	switch short {
+	case "json":
+		return "application/json"
+	case "plain":
+		return "text/plain"
+	case "event-stream":
+		return "text/event-stream"
+	case "octet-stream":
+		return "application/octet-stream"
+	}
+	return short
+}
+
+func defaultResponseDescription(code string) string {
+	n, _ := strconv.Atoi(code)
+	switch n {
+	case 200:
+		return "OK"
+	case 201:
+		return "Created"
+	case 202:
+		return "Accepted"
+	case 204:
+		return "No Content"
+	case 304:
+		return "Not Modified"
+	case 400:
+		return "Bad Request"
+	case 401:
+		return "Unauthorized"
+	case 403:
+		return "Forbidden"
+	case 404:
+		return "Not Found"
+	case 409:
+		return "Conflict"
+	}
+	return fmt.Sprintf("HTTP %s", code)
+}
+
+// parseTypeRef parses annotation types: string, dto.X, []dto.X, map[string]T and
+// swag-style compositions such as object{data=dto.X,meta=v2.Meta}
+func (g *generator) parseTypeRef(typeRef string) schema {
+	switch typeRef {
+	case "string", "file":
+		return schema{"type": "string"}
+	case "int", "integer":
+		return schema{"type": "integer"}
+	case "number":
+		return schema{"type": "number"}
+	case "bool", "boolean":
+		return schema{"type": "boolean"}
+	case "object":
+		return schema{"type": "object"}
+	case "interface{}", "any":
+		return schema{}
+	}
+
+	if strings.HasPrefix(typeRef, "[]") {
+		return schema{"type": "array", "items": g.parseTypeRef(typeRef[2:])}
+	}
+	if strings.HasPrefix(typeRef, "map[string]") {
+		return schema{"type": "object", "additionalProperties": g.parseTypeRef(typeRef[len("map[string]"):])}
+	}
+
+	if open := strings.Index(typeRef, "{"); open > 0 && strings.HasSuffix(typeRef, "}") {
+		base := g.parseTypeRef(typeRef[:open])
+		properties := schema{}
+		for _, field := range splitTopLevel(typeRef[open+1 : len(typeRef)-1]) {
+			name, fieldType, ok := strings.Cut(field, "=")
+			if !ok {
+				log.Fatalf("invalid composition field %q in %q", field, typeRef)
+			}
+			properties[name] = g.parseTypeRef(fieldType)
+		}
+		override := schema{"type": "object", "properties": properties}
+		if _, isRef := base["$ref"]; isRef {
+			return schema{"allOf": []schema{base, override}}
+		}
+		return override
+	}
+
+	if _, ok := g.types[typeRef]; ok {
+		g.buildComponent(typeRef)
+		return ref(typeRef)
+	}
+	log.Fatalf("unknown type %q in annotation", typeRef)
+	return nil
+}
+
+// splitTopLevel splits on commas that are not nested inside braces
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
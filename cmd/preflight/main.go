@@ -0,0 +1,46 @@
+// Command preflight checks the cluster prerequisites of the platform without changing
+// anything: Traefik's CRDs, cert-manager and its ClusterIssuer, metrics-server, a default
+// StorageClass and free NodePorts. It reaches the cluster like the backend does, so run
+// it in the cluster or with K8S_PROXY_URL pointing at kubectl proxy.
+//
+//	go run ./cmd/preflight          print the report
+//	go run ./cmd/preflight -json    print the report as JSON
+//
+// It exits with status 1 when a check failed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/pendeploy-simple/services"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	report := services.NewPreflightService().RunChecks()
+	if *asJSON {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		for _, check := range report.Checks {
+			fmt.Printf("%-8s %-22s %s\n", check.Status, check.Name, check.Message)
+			if check.Remedy != "" {
+				fmt.Printf("%-31s %s\n", "", check.Remedy)
+			}
+		}
+	}
+
+	if !report.Ready {
+		fmt.Fprintln(os.Stderr, "cluster prerequisites are missing")
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "cluster ready (%d checks, warnings are optional features)\n", len(report.Checks))
+}
@@ -2,25 +2,33 @@ package controllers
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/middleware"
 	"github.com/pendeploy-simple/services"
 	"github.com/pendeploy-simple/utils"
 )
 
 // DeploymentController handles HTTP requests for deployments
 type DeploymentController struct {
-	deploymentService *services.DeploymentService
+	deploymentService  *services.DeploymentService
+	provenanceService  *services.ProvenanceService
+	logDownloadService *services.LogDownloadService
 }
 
 // NewDeploymentController creates a new DeploymentController
 func NewDeploymentController() *DeploymentController {
 	return &DeploymentController{
-		deploymentService: services.NewDeploymentService(),
+		deploymentService:  services.NewDeploymentService(),
+		provenanceService:  services.NewProvenanceService(),
+		logDownloadService: services.NewLogDownloadService(),
 	}
 }
 
@@ -29,14 +37,30 @@ func (c *DeploymentController) RegisterRoutes(router *gin.RouterGroup) {
 	deployGroup := router.Group("/deployments")
 	{
 		deployGroup.POST("/git", c.CreateDeployment)
-		deployGroup.GET("/:id", c.GetDeployment)
+		deployGroup.POST("/image", c.CreateImageDeployment)
+		deployGroup.GET("/:id", middleware.ResponseCache(), c.GetDeployment)
+		deployGroup.GET("/:id/sbom", c.GetSBOM)
 		deployGroup.GET("/:id/logs/build", c.StreamBuildLogs)
 		deployGroup.GET("/:id/logs/runtime", c.StreamRuntimeLogs)
+		deployGroup.GET("/:id/logs/build/download", c.DownloadBuildLogs)
+		deployGroup.GET("/:id/logs/runtime/download", c.DownloadRuntimeLogs)
 	}
 }
 
 // CreateDeployment handles POST /api/deployments/git
 // Creates a new Kubernetes job for building and deploying a Git repository
+// @Summary Build and deploy a git service
+// @Tags deployments
+// @Accept json
+// @Produce json
+// @Param deployment body dto.GitDeployRequest true "Deployment"
+// @Description Pushes to a service pinned to a deployment are ignored: the response is 200 with status "ignored" and the pinned deployment's ID. A commit the service's last build was built from, or another service of the project built, with the same build config is deployed without a build by reusing that image (no jobName in the response); set forceRebuild to build anyway.
+// @Success 201 {object} dto.GitDeployResponse
+// @Success 200 {object} dto.GitDeployResponse
+// @Failure 400 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Failure 429 {object} object{error=string}
+// @Router /deployments/git [post]
 func (c *DeploymentController) CreateDeployment(ctx *gin.Context) {
 	var request dto.GitDeployRequest
 	
@@ -64,9 +88,22 @@ func (c *DeploymentController) CreateDeployment(ctx *gin.Context) {
 		return
 	}
 
+	// Admins may deploy through deploy locks
+	role, _ := ctx.Get("role")
+	request.ByAdmin = role == "admin"
+	request.FromWebhook = true
+
 	response, err := c.deploymentService.CreateGitDeployment(request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var lockedErr *services.DeployLockedError
+		var quotaErr *services.BuildQuotaExceededError
+		if errors.As(err, &lockedErr) {
+			ctx.JSON(http.StatusLocked, gin.H{"error": err.Error(), "lock": lockedErr.Lock})
+		} else if errors.As(err, &quotaErr) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		// If there's a callbackUrl, notify of deployment creation error
 		if request.CallbackUrl != "" {
 			go utils.SendErrorWebhook(request.CallbackUrl, "Deployment error: " + err.Error())
@@ -74,11 +111,75 @@ func (c *DeploymentController) CreateDeployment(ctx *gin.Context) {
 		return
 	}
 
+	if response.Status == services.GitDeployStatusIgnored {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// CreateImageDeployment handles POST /api/deployments/image
+// Rolls out an image external CI built and pushed, without a build
+// @Summary Deploy an image pushed by external CI
+// @Tags deployments
+// @Accept json
+// @Produce json
+// @Param deployment body dto.ImageDeployRequest true "Pushed image"
+// @Description For services built outside the platform, e.g. in GitHub Actions: push the image to <registry>/<serviceId> of the platform registry and report it here with its digest. The registry is checked for the digest and the image is deployed pinned to it. Authenticated with the service's API key; images reported while the service is pinned are ignored (200 with status "ignored").
+// @Success 201 {object} dto.GitDeployResponse
+// @Success 200 {object} dto.GitDeployResponse
+// @Failure 400 {object} object{error=string}
+// @Failure 401 {object} object{error=string}
+// @Failure 423 {object} object{error=string,lock=models.DeployLock}
+// @Router /deployments/image [post]
+func (c *DeploymentController) CreateImageDeployment(ctx *gin.Context) {
+	var request dto.ImageDeployRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Admins may deploy through deploy locks
+	role, _ := ctx.Get("role")
+	request.ByAdmin = role == "admin"
+
+	response, err := c.deploymentService.CreateImageDeployment(request)
+	if err != nil {
+		var lockedErr *services.DeployLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			ctx.JSON(http.StatusLocked, gin.H{"error": err.Error(), "lock": lockedErr.Lock})
+		case errors.Is(err, services.ErrInvalidDeployAPIKey):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPushedImage):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		// Callers that failed to authenticate are not called back
+		if request.CallbackUrl != "" && !errors.Is(err, services.ErrInvalidDeployAPIKey) {
+			go utils.SendErrorWebhook(request.CallbackUrl, "Deployment error: "+err.Error())
+		}
+		return
+	}
+
+	if response.Status == services.GitDeployStatusIgnored {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
 	ctx.JSON(http.StatusCreated, response)
 }
 
 // GetDeployment handles GET /api/deployments/:id
 // Gets status of a deployment
+// @Summary Get a deployment with its Kubernetes resource status
+// @Tags deployments
+// @Produce json
+// @Param id path string true "Deployment ID"
+// @Success 200 {object} object{deployment=dto.DeploymentResponse,resources=dto.ResourceStatusResponse}
+// @Success 304 {string} string "Not Modified"
+// @Failure 404 {object} object{error=string}
+// @Router /deployments/{id} [get]
 func (c *DeploymentController) GetDeployment(ctx *gin.Context) {
 	id := ctx.Param("id")
 	
@@ -96,15 +197,54 @@ func (c *DeploymentController) GetDeployment(ctx *gin.Context) {
 		"deployment": deployment,
 	}
 	
+	etagParts := []string{deployment.ID, deployment.Status, deployment.Image}
 	if resourceStatus != nil && err == nil {
 		response["resources"] = resourceStatus
+		if resourceStatus.Deployment != nil {
+			etagParts = append(etagParts, fmt.Sprint(resourceStatus.Deployment.ReadyReplicas, resourceStatus.Deployment.Replicas))
+		}
+	}
+	
+	if middleware.CheckETag(ctx, middleware.ComputeETag(etagParts...)) {
+		return
 	}
 	
 	ctx.JSON(http.StatusOK, response)
 }
 
+// GetSBOM handles GET /api/deployments/:id/sbom
+// Returns the SBOM generated for the deployment's image
+// @Summary Get the SBOM of a deployment's image
+// @Description CycloneDX JSON document generated by syft after the build (BUILD_SBOM_ENABLED). Only owners of the deployment's project and admins may read it.
+// @Tags deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Success 200 {object} object
+// @Failure 404 {object} object{error=string}
+// @Router /deployments/{id}/sbom [get]
+func (c *DeploymentController) GetSBOM(ctx *gin.Context) {
+	sbom, err := c.provenanceService.GetSBOM(ctx.Param("id"), ctx.GetString("userId"), ctx.GetString("role") == "admin")
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "SBOM not found or access denied"})
+		return
+	}
+
+	ctx.Header("X-SBOM-Format", sbom.Format)
+	ctx.Data(http.StatusOK, "application/json", []byte(sbom.Document))
+}
+
 // StreamBuildLogs handles GET /api/deployments/:id/logs/build
 // Streams build logs from Kubernetes job in Server-Sent Events format
+// @Summary Stream build logs
+// @Description Output of the service's test stage, if it has a test command, is sent first as events of type "test"; the build output follows as unnamed events. Each line's event ID is its stage and line number (e.g. "build-42"); reconnecting with Last-Event-ID resumes after that line. A comment is sent every 15 seconds to keep the stream open.
+// @Tags deployments
+// @Produce event-stream
+// @Param id path string true "Deployment ID"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume the stream"
+// @Param lastEventId query string false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success 200 {string} string "Server-Sent Events"
+// @Router /deployments/{id}/logs/build [get]
 func (c *DeploymentController) StreamBuildLogs(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -114,19 +254,21 @@ func (c *DeploymentController) StreamBuildLogs(ctx *gin.Context) {
 	ctx.Writer.Header().Set("Connection", "keep-alive")
 	ctx.Writer.Header().Set("Transfer-Encoding", "chunked")
 	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
 
 	// Get the deployment by ID
 	deployment, err := c.deploymentService.GetDeploymentByID(id)
 	if err != nil {
-		ctx.Writer.Write([]byte("data: {\"error\": \"Deployment not found\"}\n\n"))
+		w.Write([]byte("data: {\"error\": \"Deployment not found\"}\n\n"))
 		return
 	}
 
 	// Stream build logs
-	err = c.deploymentService.GetServiceBuildLogsRealtime(deployment.ID, ctx.Writer)
+	err = c.deploymentService.GetServiceBuildLogsRealtime(deployment.ID, w, utils.GetLastEventID(ctx.Request))
 	if err != nil {
 		// Don't send error as JSON as we've already started streaming
-		ctx.Writer.Write([]byte("data: {\"error\": \"" + err.Error() + "\"}\n\n"))
+		w.Write([]byte("data: {\"error\": \"" + err.Error() + "\"}\n\n"))
 	}
 }
 
@@ -134,6 +276,15 @@ func (c *DeploymentController) StreamBuildLogs(ctx *gin.Context) {
 // Streams deployment logs from Kubernetes pods in Server-Sent Events format
 
 
+// @Summary Stream runtime logs
+// @Description Logs of the service's running pod, starting with its last 50 lines, following new pods on rollouts and restarted containers. Each line's event ID is its pod and timestamp; reconnecting with Last-Event-ID resumes with the lines logged after it. A comment is sent every 15 seconds to keep the stream open.
+// @Tags deployments
+// @Produce event-stream
+// @Param id path string true "Deployment ID"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume the stream"
+// @Param lastEventId query string false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success 200 {string} string "Server-Sent Events"
+// @Router /deployments/{id}/logs/runtime [get]
 func (c *DeploymentController) StreamRuntimeLogs(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -143,18 +294,93 @@ func (c *DeploymentController) StreamRuntimeLogs(ctx *gin.Context) {
 	ctx.Writer.Header().Set("Connection", "keep-alive")
 	ctx.Writer.Header().Set("Transfer-Encoding", "chunked")
 	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
 
 	// Get the deployment by ID
 	deployment, err := c.deploymentService.GetDeploymentByID(id)
 	if err != nil {
-		ctx.Writer.Write([]byte("data: {\"error\": \"Deployment not found\"}\n\n"))
+		w.Write([]byte("data: {\"error\": \"Deployment not found\"}\n\n"))
 		return
 	}
 
 	// Stream runtime logs from the service's pods
-	err = c.deploymentService.GetServiceRuntimeLogsRealtime(deployment.ServiceID, ctx.Writer)
+	err = c.deploymentService.GetServiceRuntimeLogsRealtime(deployment.ServiceID, w, utils.GetLastEventID(ctx.Request))
 	if err != nil {
 		// Don't send error as JSON as we've already started streaming
-		ctx.Writer.Write([]byte("data: {\"error\": \"" + err.Error() + "}\n\n"))
+		w.Write([]byte("data: {\"error\": \"" + err.Error() + "}\n\n"))
+	}
+}
+
+// DownloadBuildLogs handles GET /api/deployments/:id/logs/build/download
+// Returns the complete build output as a file
+// @Summary Download build logs as a file
+// @Description Build output of every build container, read from the archive in the artifact store (ARTIFACTS_S3_*) when the build was archived, otherwise from the build pods until the job is garbage collected. Secret values are masked. Only owners of the deployment's project and admins may download it.
+// @Tags deployments
+// @Produce text/plain
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param format query string false "txt (default) or gz"
+// @Success 200 {file} file
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /deployments/{id}/logs/build/download [get]
+func (c *DeploymentController) DownloadBuildLogs(ctx *gin.Context) {
+	logs, fileName, err := c.logDownloadService.GetBuildLogs(ctx.Param("id"), ctx.GetString("userId"), ctx.GetString("role") == "admin")
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	writeLogFile(ctx, fileName, logs)
+}
+
+// DownloadRuntimeLogs handles GET /api/deployments/:id/logs/runtime/download
+// Returns the logs of the service's pods as a file
+// @Summary Download runtime logs as a file
+// @Description Logs of every container of the service's current pods with timestamps, including the previous run of restarted containers, up to 20 MiB per container. Secret values are masked. Only owners of the deployment's project and admins may download them.
+// @Tags deployments
+// @Produce text/plain
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param format query string false "txt (default) or gz"
+// @Success 200 {file} file
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Failure 500 {object} object{error=string}
+// @Router /deployments/{id}/logs/runtime/download [get]
+func (c *DeploymentController) DownloadRuntimeLogs(ctx *gin.Context) {
+	deployment, err := c.deploymentService.GetDeploymentByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+		return
+	}
+
+	logs, fileName, err := c.logDownloadService.GetRuntimeLogs(deployment.ServiceID, ctx.GetString("userId"), ctx.GetString("role") == "admin")
+	if errors.Is(err, services.ErrLogsAccessDenied) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writeLogFile(ctx, fileName, logs)
+}
+
+// writeLogFile sends logs as an attachment, gzipped when the format query parameter is "gz"
+func writeLogFile(ctx *gin.Context, fileName string, logs string) {
+	switch ctx.DefaultQuery("format", "txt") {
+	case "txt":
+		ctx.Header("Content-Disposition", `attachment; filename="`+fileName+`.txt"`)
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(logs))
+	case "gz":
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		writer.Write([]byte(logs))
+		writer.Close()
+		ctx.Header("Content-Disposition", `attachment; filename="`+fileName+`.gz"`)
+		ctx.Data(http.StatusOK, "application/gzip", buffer.Bytes())
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be txt or gz"})
 	}
 }
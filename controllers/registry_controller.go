@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
 )
 
 // RegistryController handles HTTP requests for registries
@@ -22,6 +26,18 @@ func NewRegistryController() *RegistryController {
 }
 
 // GetRegistries handles GET /api/registries
+// @Summary List registries
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param search query string false "Search term"
+// @Param sortBy query string false "Sort column"
+// @Param sortOrder query string false "asc or desc"
+// @Param onlyActive query bool false "Only active registries"
+// @Success 200 {object} dto.RegistryListResponse
+// @Router /registries [get]
 func (c *RegistryController) GetRegistries(ctx *gin.Context) {
 	// Parse query parameters
 	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
@@ -52,6 +68,14 @@ func (c *RegistryController) GetRegistries(ctx *gin.Context) {
 }
 
 // GetRegistry handles GET /api/registries/:id
+// @Summary Get a registry
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 200 {object} dto.RegistryResponse
+// @Failure 404 {object} object{error=string}
+// @Router /registries/{id} [get]
 func (c *RegistryController) GetRegistry(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -65,6 +89,14 @@ func (c *RegistryController) GetRegistry(ctx *gin.Context) {
 }
 
 // GetRegistryDetails handles GET /api/registries/:id/details
+// @Summary Get registry details and images
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 200 {object} dto.RegistryDetailsResponse
+// @Failure 404 {object} object{error=string}
+// @Router /registries/{id}/details [get]
 func (c *RegistryController) GetRegistryDetails(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -78,16 +110,29 @@ func (c *RegistryController) GetRegistryDetails(ctx *gin.Context) {
 }
 
 // CreateRegistry handles POST /api/registries
+// @Summary Create a registry
+// @Tags registries
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param registry body dto.CreateRegistryRequest true "Registry"
+// @Success 201 {object} dto.RegistryResponse
+// @Failure 400 {object} object{error=string}
+// @Router /registries [post]
 func (c *RegistryController) CreateRegistry(ctx *gin.Context) {
 	var request dto.CreateRegistryRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := utils.ValidateCreateRegistryRequest(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	registry, err := c.registryService.CreateRegistry(request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -95,6 +140,16 @@ func (c *RegistryController) CreateRegistry(ctx *gin.Context) {
 }
 
 // UpdateRegistry handles PUT /api/registries/:id
+// @Summary Update a registry
+// @Tags registries
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Param registry body dto.UpdateRegistryRequest true "Registry"
+// @Success 200 {object} dto.RegistryResponse
+// @Failure 400 {object} object{error=string}
+// @Router /registries/{id} [put]
 func (c *RegistryController) UpdateRegistry(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -103,17 +158,103 @@ func (c *RegistryController) UpdateRegistry(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := utils.ValidateUpdateRegistryRequest(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	registry, err := c.registryService.UpdateRegistry(id, request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, registry)
 }
 
+// UpdateStorage handles PUT /api/registries/:id/storage
+// @Summary Switch the storage backend of a registry
+// @Description Moves a registry between its volume and an S3-compatible bucket on a managed MinIO service or external S3. With migrate the blobs on the volume are copied into the bucket first. Runs asynchronously; the registry is building until it completes.
+// @Tags registries
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Param storage body dto.RegistryStorageRequest true "Storage backend"
+// @Success 202 {object} dto.RegistryResponse
+// @Failure 400 {object} object{error=string}
+// @Failure 409 {object} object{error=string}
+// @Router /registries/{id}/storage [put]
+func (c *RegistryController) UpdateStorage(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var request dto.RegistryStorageRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.ValidateRegistryStorageRequest(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	registry, err := c.registryService.UpdateStorage(id, request, ctx.GetString("userId"), ctx.GetString("role") == "admin")
+	if err != nil {
+		ctx.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, registry)
+}
+
+// GetDependencies handles GET /api/registries/:id/dependencies
+// @Summary List registry dependency images
+// @Description Checks the registry catalog for the images build jobs need (git client, Kaniko executor) and reports the most recent build job of each, the digest each source is pinned to and whether the registry's copy was built from it.
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 200 {object} dto.RegistryDependenciesResponse
+// @Failure 409 {object} object{error=string}
+// @Router /registries/{id}/dependencies [get]
+func (c *RegistryController) GetDependencies(ctx *gin.Context) {
+	dependencies, err := c.registryService.GetDependencies(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dependencies)
+}
+
+// RerunDependencies handles POST /api/registries/:id/dependencies/rerun
+// @Summary Rebuild missing registry dependency images
+// @Description Rebuilds the dependency images missing from the registry, for example after a failed build. Builds run in the background.
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 202 {object} dto.RegistryDependencyRerunResponse
+// @Failure 409 {object} object{error=string}
+// @Router /registries/{id}/dependencies/rerun [post]
+func (c *RegistryController) RerunDependencies(ctx *gin.Context) {
+	response, err := c.registryService.RerunDependencies(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(registryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, response)
+}
+
 // DeleteRegistry handles DELETE /api/registries/:id
+// @Summary Delete a registry
+// @Tags registries
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 200 {object} object{message=string}
+// @Router /registries/{id} [delete]
 func (c *RegistryController) DeleteRegistry(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -128,6 +269,13 @@ func (c *RegistryController) DeleteRegistry(ctx *gin.Context) {
 
 // StreamBuildLogs handles GET /api/registries/:id/logs/stream
 // This endpoint streams build logs from Kubernetes
+// @Summary Stream registry build logs
+// @Tags registries
+// @Produce plain
+// @Security BearerAuth
+// @Param id path string true "Registry ID"
+// @Success 200 {string} string "Log stream"
+// @Router /registries/{id}/logs/stream [get]
 func (c *RegistryController) StreamBuildLogs(ctx *gin.Context) {
 	id := ctx.Param("id")
 
@@ -144,3 +292,68 @@ func (c *RegistryController) StreamBuildLogs(ctx *gin.Context) {
 		ctx.Writer.Write([]byte("Stream ended: " + err.Error()))
 	}
 }
+
+// CopyImage handles POST /api/registries/:id/copy
+// Copies an image to another registry, streaming progress in Server-Sent Events format
+// @Summary Copy an image to another registry
+// @Description Copies the manifest, config and every layer of an image (all platforms of a multi-arch image) over the registry HTTP API. Each blob is verified against its sha256 digest; blobs already in the target are skipped. Progress events are streamed as they happen, followed by a final event holding either result or error.
+// @Tags registries
+// @Accept json
+// @Produce event-stream
+// @Security BearerAuth
+// @Param id path string true "Source registry ID"
+// @Param copy body dto.RegistryCopyRequest true "Image to copy"
+// @Success 200 {string} string "Server-Sent Events"
+// @Failure 400 {object} object{error=string}
+// @Router /registries/{id}/copy [post]
+func (c *RegistryController) CopyImage(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var request dto.RegistryCopyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := utils.ValidateRegistryCopyRequest(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set headers for SSE streaming
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.Header().Set("Connection", "keep-alive")
+	ctx.Writer.Header().Set("X-Accel-Buffering", "no") // Prevent Nginx from buffering the response
+	w := utils.NewSSEWriter(ctx.Writer)
+	defer w.Close()
+
+	writeEvent := func(event interface{}) {
+		data, _ := json.Marshal(event)
+		utils.WriteSSEData(w, string(data))
+		w.Flush()
+	}
+
+	result, err := c.registryService.CopyImage(ctx.Request.Context(), id, request, func(progress dto.RegistryCopyProgress) {
+		writeEvent(progress)
+	})
+	if err != nil {
+		writeEvent(gin.H{"error": err.Error()})
+		return
+	}
+	writeEvent(gin.H{"result": result})
+}
+
+// registryErrorStatus maps registry service errors onto HTTP status codes
+func registryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProxyRegistryDefault), errors.Is(err, services.ErrStorageMigrationUnsupported),
+		errors.Is(err, services.ErrProxyRegistryPush), errors.Is(err, services.ErrRegistryReplicasNeedS3),
+		errors.Is(err, services.ErrRegistryVolumeResize):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrRegistryBusy), errors.Is(err, services.ErrDependencyBuildRunning):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -1,11 +1,12 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/pendeploy-simple/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -13,8 +14,22 @@ import (
 
 var DB *gorm.DB
 
-// Initialize sets up the GORM database connection
+// Initialize sets up the GORM database connection and applies pending migrations
+// (unless DB_AUTO_MIGRATE=false, when migrations are run separately with cmd/migrate)
 func Initialize() {
+	if err := Connect(); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("DB_AUTO_MIGRATE")), "false") {
+		if err := Migrate(DB); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+}
+
+// Connect opens the database connection without migrating it
+func Connect() error {
 	// Get database URL from environment
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
@@ -35,40 +50,30 @@ func Initialize() {
 	)
 
 	// Connect to database
-	var err error
-	DB, err = gorm.Open(postgres.Open(dbURL), &gorm.Config{
+	pool := LoadPoolConfig()
+	config := &gorm.Config{
 		Logger: newLogger,
-	})
+	}
+	var err error
+	DB, err = gorm.Open(postgres.Open(pool.DSN(dbURL)), config)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
 	// Get and configure the underlying SQL DB
 	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatalf("Failed to get SQL DB: %v", err)
+		return fmt.Errorf("failed to get SQL DB: %v", err)
 	}
 
-	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// Auto migrate models
-	err = DB.AutoMigrate(
-		&models.Registry{},
-		&models.User{},
-		&models.Project{},
-		&models.Environment{},
-		&models.Service{},
-		&models.Deployment{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to auto migrate: %v", err)
-	}
+	// Set connection pool settings (DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, ...)
+	pool.Apply(sqlDB)
 
 	log.Println("✅ Connected to database")
 
+	// Optional read replica for stats and list queries
+	connectReadReplica(config, pool)
+
 	// Print connection info
 	rows, err := sqlDB.Query("SELECT version()")
 	if err == nil {
@@ -80,4 +85,5 @@ func Initialize() {
 		}
 		rows.Close()
 	}
+	return nil
 }
@@ -15,10 +15,9 @@ import (
 
 // DBConnection represents a database connection
 type DBConnection struct {
-	DB     *gorm.DB
-	Name   string
-	DbURL  string
-	Models []interface{}
+	DB    *gorm.DB
+	Name  string
+	DbURL string
 }
 
 // NewDBConnection creates a new database connection
@@ -72,29 +71,17 @@ func NewDBConnection(name, dbURL string) (*DBConnection, error) {
 		rows.Close()
 	}
 
-	// Define models to migrate
-	models := []interface{}{
-		&models.Registry{},
-		&models.User{},
-		&models.Project{},
-		&models.Environment{},
-		&models.Service{},
-		&models.Deployment{},
-	}
-
 	return &DBConnection{
-		DB:     db,
-		Name:   name,
-		DbURL:  dbURL,
-		Models: models,
+		DB:    db,
+		Name:  name,
+		DbURL: dbURL,
 	}, nil
 }
 
 // Migrate migrates the database schema
 func (c *DBConnection) Migrate() error {
 	log.Printf("Migrating %s database schema...", c.Name)
-	err := c.DB.AutoMigrate(c.Models...)
-	if err != nil {
+	if err := Migrate(c.DB); err != nil {
 		return fmt.Errorf("failed to migrate %s database: %v", c.Name, err)
 	}
 	log.Printf("✅ %s database schema migrated", c.Name)
@@ -0,0 +1,1089 @@
+package database
+
+import (
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// migrations is the ordered schema history. Append new entries with the next sequence
+// number; never edit or reorder applied ones. Migrations that add columns to an
+// existing table call AutoMigrate on its model, which only adds what is missing.
+//
+// Databases created before versioned migrations already contain these tables; the
+// AutoMigrate-based steps are idempotent, so they are simply recorded as applied.
+var migrations = []Migration{
+	{
+		ID:          "0001_initial_schema",
+		Description: "registries, users, projects, environments, services and deployments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(
+				&models.Registry{},
+				&models.User{},
+				&models.Project{},
+				&models.Environment{},
+				&models.Service{},
+				&models.Deployment{},
+			)
+		},
+		// The baseline holds all platform data and is never rolled back
+	},
+	{
+		ID:            "0002_search_indexes",
+		Description:   "pg_trgm indexes for global search",
+		NoTransaction: true, // best effort: index failures are logged, not fatal
+		Up: func(tx *gorm.DB) error {
+			ensureSearchIndexes(tx)
+			return nil
+		},
+		Down: dropSearchIndexes,
+	},
+	{
+		ID:          "0003_console_audit_logs",
+		Description: "audit log of database console queries",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ConsoleAuditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ConsoleAuditLog{})
+		},
+	},
+	{
+		ID:          "0004_service_pause_schedules",
+		Description: "cron pause/resume schedules for managed services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServicePauseSchedule{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServicePauseSchedule{})
+		},
+	},
+	{
+		ID:          "0005_outbox_events",
+		Description: "transactional outbox for deployment webhooks",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.OutboxEvent{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.OutboxEvent{})
+		},
+	},
+	{
+		ID:          "0006_environment_archive_and_defaults",
+		Description: "environment archiving, per-environment service defaults and service deletion protection",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Environment{}, &models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"ArchivedAt", "DefaultCPULimit", "DefaultMemoryLimit", "DefaultReplicas"} {
+				if err := tx.Migrator().DropColumn(&models.Environment{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "DeletionProtected")
+		},
+	},
+	{
+		ID:          "0007_base_domains",
+		Description: "per-project base domain for generated hostnames",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Project{}, &models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Project{}, "BaseDomain"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "BaseDomain")
+		},
+	},
+	{
+		ID:          "0008_custom_certificates",
+		Description: "uploaded TLS certificates for custom domains",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.CustomCertificate{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.CustomCertificate{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "CustomTLSSecret")
+		},
+	},
+	{
+		ID:          "0009_tls_challenge",
+		Description: "per-service ACME challenge type (http01/dns01)",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "TLSChallenge")
+		},
+	},
+	{
+		ID:          "0010_api_tokens_and_device_auth",
+		Description: "scoped API tokens and device-code logins for CLIs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.APIToken{}, &models.DeviceAuthorization{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.DeviceAuthorization{}, &models.APIToken{})
+		},
+	},
+	{
+		ID:          "0011_build_artifacts",
+		Description: "optional build artifact export per service and deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"ArtifactKey", "ArtifactSize", "ArtifactError"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "ArtifactPath")
+		},
+	},
+	{
+		ID:          "0012_build_environment",
+		Description: "build environment captured per deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"BuildEnv", "DockerfileDigest", "ImageDigest"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0013_image_provenance",
+		Description: "SBOMs and image signing status per deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{}, &models.DeploymentSBOM{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.DeploymentSBOM{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"SBOMFormat", "ImageSigned", "ProvenanceError"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0014_workload_policies",
+		Description: "admin-configured workload policy rules and violation audit",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PolicyRule{}, &models.PolicyViolation{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.PolicyViolation{}, &models.PolicyRule{})
+		},
+	},
+	{
+		ID:          "0015_service_accounts",
+		Description: "workload identity annotations for per-service ServiceAccounts",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "ServiceAccountAnnotations")
+		},
+	},
+	{
+		ID:          "0016_pull_credentials",
+		Description: "private registry pull credentials per project and environment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PullCredential{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.PullCredential{})
+		},
+	},
+	{
+		ID:          "0017_priority_tiers",
+		Description: "scheduling priority tiers mapped to environments and build jobs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PriorityTier{}, &models.Environment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Environment{}, "PriorityTier"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&models.PriorityTier{})
+		},
+	},
+	{
+		ID:          "0018_high_availability",
+		Description: "high availability toggle spreading service replicas across nodes and zones",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "HighAvailability")
+		},
+	},
+	{
+		ID:          "0019_environment_resource_presets",
+		Description: "default storage size and allowed resource ranges per environment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Environment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"DefaultStorageSize", "MinCPULimit", "MaxCPULimit", "MinMemoryLimit", "MaxMemoryLimit", "MaxStorageSize"} {
+				if err := tx.Migrator().DropColumn(&models.Environment{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0020_service_revisions",
+		Description: "config revisions of services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServiceRevision{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServiceRevision{})
+		},
+	},
+	{
+		ID:          "0021_pod_metadata",
+		Description: "user-defined pod labels and annotations for observability tooling",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"PodLabels", "PodAnnotations"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0022_log_drains",
+		Description: "per-project log drains forwarding service logs to external sinks",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.LogDrain{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.LogDrain{})
+		},
+	},
+	{
+		ID:          "0023_uptime_status_pages",
+		Description: "uptime monitors and history, project status pages and incidents",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.UptimeMonitor{}, &models.UptimeCheck{}, &models.StatusPage{}, &models.Incident{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.Incident{}, &models.StatusPage{}, &models.UptimeCheck{}, &models.UptimeMonitor{})
+		},
+	},
+	{
+		ID:          "0024_service_incidents",
+		Description: "incidents detected from deployment failures, crash loops and probe failures",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServiceIncident{}, &models.ServiceIncidentEvent{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServiceIncidentEvent{}, &models.ServiceIncident{})
+		},
+	},
+	{
+		ID:          "0025_load_tests",
+		Description: "service load tests with latency percentiles per deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.LoadTest{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.LoadTest{})
+		},
+	},
+	{
+		ID:          "0026_registry_proxy_mode",
+		Description: "pull-through cache registry mode with upstream URL and credentials",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Registry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"Mode", "ProxyRemoteURL", "ProxyUsername", "ProxyPassword"} {
+				if err := tx.Migrator().DropColumn(&models.Registry{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0027_registry_storage_backend",
+		Description: "registry storage backend selection between volume and S3-compatible buckets",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Registry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"StorageBackend", "StorageServiceID", "S3Endpoint", "S3Region", "S3Bucket", "S3AccessKey", "S3SecretKey"} {
+				if err := tx.Migrator().DropColumn(&models.Registry{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0028_registry_replicas_storage_size",
+		Description: "registry replica count and volume size",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Registry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Registry{}, "Replicas"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Registry{}, "StorageSize")
+		},
+	},
+	{
+		ID:          "0029_service_build_platforms",
+		Description: "per-service target build platforms",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "BuildPlatforms")
+		},
+	},
+	{
+		ID:          "0030_project_secrets",
+		Description: "per-project secrets vault referenced from service env vars",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ProjectSecret{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ProjectSecret{})
+		},
+	},
+	{
+		ID:          "0031_secret_stores",
+		Description: "external secret managers (Vault, AWS Secrets Manager) per project",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.SecretStore{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.SecretStore{})
+		},
+	},
+	{
+		ID:          "0032_secret_env_vars",
+		Description: "secret flag for service env vars and reveal audit log",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.EnvRevealAuditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.EnvRevealAuditLog{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "SecretEnvKeys")
+		},
+	},
+	{
+		ID:          "0033_usage_samples",
+		Description: "cluster and per-service resource usage history for capacity forecasts",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ClusterUsageSample{}, &models.ServiceUsageSample{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServiceUsageSample{}, &models.ClusterUsageSample{})
+		},
+	},
+	{
+		ID:          "0034_service_vpa_mode",
+		Description: "vertical pod autoscaler mode for managed services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "VPAMode")
+		},
+	},
+	{
+		ID:          "0035_build_env_keys",
+		Description: "build-time flag for service env vars",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "BuildEnvKeys")
+		},
+	},
+	{
+		ID:          "0036_deploy_locks",
+		Description: "project and environment deploy locks",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.DeployLock{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.DeployLock{})
+		},
+	},
+	{
+		ID:          "0037_clone_options",
+		Description: "clone depth and sparse checkout paths for git services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "CloneDepth"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "SparseCheckoutPaths")
+		},
+	},
+	{
+		ID:          "0038_test_stage",
+		Description: "pre-build test command of git services and its duration per deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"TestCommand", "TestImage"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&models.Deployment{}, "TestDurationMs")
+		},
+	},
+	{
+		ID:          "0039_deployment_image_layers",
+		Description: "image size and layer digests of each deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Deployment{}, "ImageSize"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Deployment{}, "ImageLayers")
+		},
+	},
+	{
+		ID:          "0040_dockerfile_path_build_args",
+		Description: "custom Dockerfile path and static build args for git services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "DockerfilePath"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "BuildArgs")
+		},
+	},
+	{
+		ID:          "0041_deployment_port_check",
+		Description: "result of the post-rollout port check of each deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Deployment{}, "PortCheckError")
+		},
+	},
+	{
+		ID:          "0042_deployment_health_check",
+		Description: "HTTP health check of the service's domain after each deployment",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Deployment{}, "HealthCheck")
+		},
+	},
+	{
+		ID:          "0043_external_allowed_cidrs",
+		Description: "source allowlist of managed services exposed through the TCP proxy",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "ExternalAllowedCIDRs")
+		},
+	},
+	{
+		ID:          "0044_database_tls",
+		Description: "TLS for external connections to managed databases",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "DatabaseTLS")
+		},
+	},
+	{
+		ID:          "0045_service_topology",
+		Description: "high-availability topology of managed services, e.g. Redis Sentinel",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "Topology")
+		},
+	},
+	{
+		ID:          "0046_managed_auto_update",
+		Description: "version update channel and maintenance window of managed services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.ServiceVersionUpdate{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.ServiceVersionUpdate{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"AutoUpdate", "MaintenanceWindow"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0047_login_protection",
+		Description: "two-factor authentication, recovery codes, login lockout and the login audit trail",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.User{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.AuthPolicy{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.AuthPolicy{}, &models.LoginAttempt{}, &models.RecoveryCode{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"TwoFactorEnabled", "TwoFactorSecret", "TwoFactorLastStep", "FailedLogins", "LockedUntil"} {
+				if err := tx.Migrator().DropColumn(&models.User{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0048_user_sessions",
+		Description: "revocable user sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.User{}, &models.UserSession{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.UserSession{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.User{}, "SessionsRevokedAt")
+		},
+	},
+	{
+		ID:          "0049_impersonation",
+		Description: "admin impersonation sessions and their audit trail",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.UserSession{}, &models.ImpersonationAuditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.ImpersonationAuditLog{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"ImpersonatorID", "ImpersonationReason"} {
+				if err := tx.Migrator().DropColumn(&models.UserSession{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0050_share_links",
+		Description: "time-limited read-only share links to environments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ShareLink{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ShareLink{})
+		},
+	},
+	{
+		ID:          "0051_build_quotas",
+		Description: "build job run time and per-project build quotas",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{}, &models.BuildQuota{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.BuildQuota{}); err != nil {
+				return err
+			}
+			for _, column := range []string{"BuildStartedAt", "BuildFinishedAt", "BuildDurationMs"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0052_scheduled_deployments",
+		Description: "deployments scheduled for a future time",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ScheduledDeployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ScheduledDeployment{})
+		},
+	},
+	{
+		ID:          "0053_service_pinning",
+		Description: "pin services to a deployment, ignoring webhook pushes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"PinnedDeploymentID", "PinnedAt", "PinnedBy", "PinReason"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0054_service_internal_aliases",
+		Description: "stable internal DNS aliases, derived from the name for existing services",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&models.Service{}); err != nil {
+				return err
+			}
+			// The oldest service keeps a derived alias shared with others in its environment;
+			// names that yield no valid alias are left without one
+			return tx.Exec(`
+				UPDATE services SET internal_alias = derived.alias
+				FROM (
+					SELECT id, alias, ROW_NUMBER() OVER (PARTITION BY environment_id, alias ORDER BY created_at) AS n
+					FROM (
+						SELECT id, environment_id, created_at,
+							LEFT(TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name), '[^a-z0-9]+', '-', 'g')), 63) AS alias
+						FROM services WHERE deleted_at IS NULL
+					) names
+				) derived
+				WHERE services.id = derived.id AND derived.n = 1
+					AND derived.alias ~ '^[a-z]([-a-z0-9]*[a-z0-9])?$'
+					AND derived.alias NOT LIKE 's-%' AND derived.alias <> 'kubernetes'`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "InternalAlias")
+		},
+	},
+	{
+		ID:          "0055_promotions",
+		Description: "Add cross-environment promotions and the environment approval gate",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Environment{}, &models.Promotion{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.Promotion{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Environment{}, "PromotionApprovalRequired")
+		},
+	},
+	{
+		ID:          "0056_service_last_build",
+		Description: "Track the last built commit of services so config-only redeploys skip the build",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"LastBuiltCommitSHA", "LastBuiltImage", "LastBuildInputsDigest"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0057_build_cache",
+		Description: "Add the build cache shared by services building the same commit",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.BuildCacheEntry{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.BuildCacheEntry{})
+		},
+	},
+	{
+		ID:          "0058_service_cache_rules",
+		Description: "Add the HTTP cache policy of service routes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Service{}, "CacheRules")
+		},
+	},
+	{
+		ID:          "0059_project_error_pages",
+		Description: "Add branded ingress error pages to projects",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Project{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Project{}, "ErrorPage")
+		},
+	},
+	{
+		ID:          "0060_tenant_isolation_policy",
+		Description: "Add the admin toggle of workload namespace isolation",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.TenantIsolationPolicy{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.TenantIsolationPolicy{})
+		},
+	},
+	{
+		ID:          "0061_platform_settings",
+		Description: "Add the admin-managed platform settings (ingress provider)",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PlatformSettings{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.PlatformSettings{})
+		},
+	},
+	{
+		ID:          "0062_domain_statuses",
+		Description: "Add the DNS and HTTPS propagation checks of service hostnames",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.DomainStatus{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.DomainStatus{})
+		},
+	},
+	{
+		ID:          "0063_deployment_build_logs",
+		Description: "Add the archived build output of deployments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Deployment{}, "BuildLogKey")
+		},
+	},
+	{
+		ID:          "0064_deployment_stats_rollups",
+		Description: "Add the failure class of deployments and the daily deployment statistics rollups",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{}, &models.DeploymentStatsRollup{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.DeploymentStatsRollup{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Deployment{}, "FailureClass")
+		},
+	},
+	{
+		ID:          "0065_deployment_build_errors",
+		Description: "Add the classified cause of failed builds",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"ErrorCode", "ErrorHint"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "0066_build_timeouts",
+		Description: "Add the build timeout of services and its cap in the platform settings",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.PlatformSettings{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "MaxBuildTimeoutMinutes"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "BuildTimeoutMinutes")
+		},
+	},
+	{
+		ID:          "0067_gitops_configs",
+		Description: "Add the GitOps config repositories of projects",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.GitOpsConfig{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.GitOpsConfig{})
+		},
+	},
+	{
+		ID:          "0068_maintenance_tasks",
+		Description: "Add scheduled maintenance tasks of environments and their runs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.MaintenanceTask{}, &models.MaintenanceTaskRun{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.MaintenanceTaskRun{}, &models.MaintenanceTask{})
+		},
+	},
+	{
+		ID:          "0069_storage_expansions",
+		Description: "Track online expansions of service volumes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.StorageExpansion{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.StorageExpansion{})
+		},
+	},
+	{
+		ID:          "0070_exposed_port_checks",
+		Description: "Add build-time port warnings of deployments and port auto-correction of services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Deployment{}, &models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "AutoCorrectPort"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Deployment{}, "PortWarning")
+		},
+	},
+	{
+		ID:          "0071_image_size_budgets",
+		Description: "Add image size budgets of services and budget warnings of deployments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Deployment{}, "ImageSizeWarning"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.Service{}, "ImageSizeBudgetAction"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "MaxImageSize")
+		},
+	},
+	{
+		ID:          "0072_service_containers",
+		Description: "Add companion containers and shared volumes of git services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "SharedVolumes"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "Containers")
+		},
+	},
+	{
+		ID:          "0073_graceful_termination",
+		Description: "Add termination grace periods and preStop hooks of services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "PreStopCommand"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "TerminationGracePeriodSeconds")
+		},
+	},
+	{
+		ID:          "0074_notification_digests",
+		Description: "Add the SMTP settings and the digest email subscriptions of projects",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.SMTPSettings{}, &models.DigestSubscription{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.DigestSubscription{}, &models.SMTPSettings{})
+		},
+	},
+	{
+		ID:          "0075_port_ranges",
+		Description: "Add the NodePort range and the managed service port ranges to the platform settings",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PlatformSettings{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "ManagedPortRanges"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.PlatformSettings{}, "NodePortRange")
+		},
+	},
+	{
+		ID:          "0076_service_inspection_audit",
+		Description: "Add the audit trail of admin service inspections",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServiceInspectionAuditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServiceInspectionAuditLog{})
+		},
+	},
+	{
+		ID:          "0077_environment_domain_templates",
+		Description: "Add the domain template of environments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Environment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.Environment{}, "DomainTemplate")
+		},
+	},
+	{
+		ID:          "0078_archive_retention",
+		Description: "Add the retention policy and project quotas of archived build logs and artifacts",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ArchiveRetentionSettings{}, &models.ArchiveQuota{}, &models.Deployment{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"ArchivePurgedAt", "BuildLogSize"} {
+				if err := tx.Migrator().DropColumn(&models.Deployment{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&models.ArchiveQuota{}, &models.ArchiveRetentionSettings{})
+		},
+	},
+	{
+		ID:          "0079_build_backends",
+		Description: "Add the build backend and build secrets of services and the platform's build backend",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{}, &models.PlatformSettings{})
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"BuildBackend", "BuildSecrets"} {
+				if err := tx.Migrator().DropColumn(&models.Service{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&models.PlatformSettings{}, "BuildBackend")
+		},
+	},
+	{
+		ID:          "0080_env_var_changes",
+		Description: "Add env var change approval of environments and proposed env var changes",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Environment{}, &models.EnvVarChange{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.EnvVarChange{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Environment{}, "EnvVarApprovalRequired")
+		},
+	},
+	{
+		ID:          "0081_project_deletions",
+		Description: "Add the status of projects and the namespace teardown of deleted projects",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Project{}, &models.ProjectNamespaceDeletion{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.ProjectNamespaceDeletion{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Project{}, "Status")
+		},
+	},
+	{
+		ID:          "0082_dependency_image_pins",
+		Description: "Add pinned dependency image digests and the copies registries were built from",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.DependencyImagePin{}, &models.RegistryDependencyImage{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.RegistryDependencyImage{}, &models.DependencyImagePin{})
+		},
+	},
+	{
+		ID:          "0083_service_moves",
+		Description: "Add moves of services between environments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServiceMove{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ServiceMove{})
+		},
+	},
+	{
+		ID:          "0084_container_overrides",
+		Description: "Add command, args and working directory overrides of git services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Service{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.Service{}, "WorkingDir"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.Service{}, "Args"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.Service{}, "Command")
+		},
+	},
+	{
+		ID:          "0085_usage_anomalies",
+		Description: "Add usage anomalies, restart counts of usage samples and the attention flag of services",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ServiceUsageSample{}, &models.Service{}, &models.UsageAnomaly{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.UsageAnomaly{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.Service{}, "AttentionReason"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.Service{}, "AttentionNeeded"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.ServiceUsageSample{}, "Restarts")
+		},
+	},
+	{
+		ID:          "0086_platform_endpoint_settings",
+		Description: "Add the default domain, public addresses, CORS origins, cluster issuer and build namespace to the platform settings",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.PlatformSettings{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "DefaultDomain"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "PublicAddresses"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "CORSAllowedOrigins"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&models.PlatformSettings{}, "ClusterIssuer"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&models.PlatformSettings{}, "BuildNamespace")
+		},
+	},
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the Postgres advisory lock key serializing migration runs across replicas
+const migrationLockID = 724_311_905
+
+// Migration is one versioned schema change. IDs are applied in lexical order, so they
+// are prefixed with a zero-padded sequence number (e.g. "0006_add_backups").
+type Migration struct {
+	ID          string
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+	// NoTransaction runs the migration outside a transaction, for statements that
+	// cannot run inside one or whose failures are tolerated
+	NoTransaction bool
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	ID        string    `gorm:"primaryKey;type:varchar(255)"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName keeps the conventional migrations table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrate applies every pending migration in order
+func Migrate(db *gorm.DB) error {
+	return withMigrationLock(db, func() error {
+		applied, err := appliedMigrations(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range sortedMigrations() {
+			if _, ok := applied[migration.ID]; ok {
+				continue
+			}
+
+			log.Printf("Applying migration %s: %s", migration.ID, migration.Description)
+			if err := runMigration(db, migration.NoTransaction, func(tx *gorm.DB) error {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{ID: migration.ID, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return fmt.Errorf("migration %s failed: %v", migration.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback reverts the last steps applied migrations, newest first
+func Rollback(db *gorm.DB, steps int) error {
+	return withMigrationLock(db, func() error {
+		applied, err := appliedMigrations(db)
+		if err != nil {
+			return err
+		}
+
+		migrations := sortedMigrations()
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := migrations[i]
+			if _, ok := applied[migration.ID]; !ok {
+				continue
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("migration %s cannot be rolled back", migration.ID)
+			}
+
+			log.Printf("Rolling back migration %s: %s", migration.ID, migration.Description)
+			if err := runMigration(db, migration.NoTransaction, func(tx *gorm.DB) error {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, "id = ?", migration.ID).Error
+			}); err != nil {
+				return fmt.Errorf("rollback of %s failed: %v", migration.ID, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// GetMigrationReport lists every known migration and whether it has been applied
+func GetMigrationReport(db *gorm.DB) (dto.MigrationReport, error) {
+	report := dto.MigrationReport{Migrations: []dto.MigrationStatus{}}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return report, err
+	}
+
+	known := make(map[string]bool)
+	for _, migration := range sortedMigrations() {
+		known[migration.ID] = true
+		status := dto.MigrationStatus{ID: migration.ID, Description: migration.Description}
+		if appliedAt, ok := applied[migration.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			report.Current = migration.ID
+		} else {
+			report.Pending++
+		}
+		report.Migrations = append(report.Migrations, status)
+	}
+
+	// Applied by a newer build; rolling back to this build leaves them in place
+	for id, appliedAt := range applied {
+		if known[id] {
+			continue
+		}
+		appliedAt := appliedAt
+		report.Migrations = append(report.Migrations, dto.MigrationStatus{
+			ID: id, Applied: true, AppliedAt: &appliedAt, Unknown: true,
+		})
+	}
+	sort.Slice(report.Migrations, func(i, j int) bool {
+		return report.Migrations[i].ID < report.Migrations[j].ID
+	})
+
+	return report, nil
+}
+
+func sortedMigrations() []Migration {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]time.Time, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+
+	applied := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func runMigration(db *gorm.DB, noTransaction bool, fn func(tx *gorm.DB) error) error {
+	if noTransaction {
+		return fn(db)
+	}
+	return db.Transaction(fn)
+}
+
+// withMigrationLock holds a session-level advisory lock on a dedicated connection so
+// replicas starting together run migrations one at a time
+func withMigrationLock(db *gorm.DB, fn func() error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get SQL DB: %v", err)
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	return fn()
+}
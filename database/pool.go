@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// ReadDB serves heavy read-only queries (stats, lists). It points at the replica from
+// DATABASE_READ_URL when one is configured and at DB otherwise.
+var ReadDB *gorm.DB
+
+// poolSaturationThreshold is the in-use/max ratio above which /healthz reports "degraded"
+const poolSaturationThreshold = 0.9
+
+// PoolConfig holds connection pool and session settings, read from the environment
+type PoolConfig struct {
+	MaxOpenConns       int           // DB_MAX_OPEN_CONNS, default 100
+	MaxIdleConns       int           // DB_MAX_IDLE_CONNS, default 10
+	ConnMaxLifetime    time.Duration // DB_CONN_MAX_LIFETIME_MINUTES, default 60
+	ConnMaxIdleTime    time.Duration // DB_CONN_MAX_IDLE_MINUTES, default 0 (no limit)
+	StatementTimeoutMs int           // DB_STATEMENT_TIMEOUT_MS, default 0 (no limit)
+}
+
+// LoadPoolConfig reads the pool settings, falling back to the defaults on invalid values
+func LoadPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:       envInt("DB_MAX_OPEN_CONNS", 100),
+		MaxIdleConns:       envInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime:    time.Duration(envInt("DB_CONN_MAX_LIFETIME_MINUTES", 60)) * time.Minute,
+		ConnMaxIdleTime:    time.Duration(envInt("DB_CONN_MAX_IDLE_MINUTES", 0)) * time.Minute,
+		StatementTimeoutMs: envInt("DB_STATEMENT_TIMEOUT_MS", 0),
+	}
+}
+
+// Apply configures the pool of an open connection
+func (c PoolConfig) Apply(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// DSN adds the statement timeout to a connection string as a session parameter
+func (c PoolConfig) DSN(dsn string) string {
+	if c.StatementTimeoutMs <= 0 {
+		return dsn
+	}
+	timeout := strconv.Itoa(c.StatementTimeoutMs)
+
+	if !strings.Contains(dsn, "://") {
+		// keyword/value form: host=... user=...
+		return dsn + " statement_timeout=" + timeout
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		log.Printf("⚠️ Could not parse database URL, statement timeout not applied: %v", err)
+		return dsn
+	}
+	query := parsed.Query()
+	query.Set("statement_timeout", timeout)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// connectReadReplica opens DATABASE_READ_URL, leaving ReadDB on the primary when it is
+// unset or unreachable
+func connectReadReplica(config *gorm.Config, pool PoolConfig) {
+	ReadDB = DB
+
+	replicaURL := strings.TrimSpace(os.Getenv("DATABASE_READ_URL"))
+	if replicaURL == "" {
+		return
+	}
+
+	replica, err := gorm.Open(postgres.Open(pool.DSN(replicaURL)), config)
+	if err != nil {
+		log.Printf("⚠️ Read replica unavailable, reads use the primary: %v", err)
+		return
+	}
+	sqlDB, err := replica.DB()
+	if err != nil {
+		log.Printf("⚠️ Read replica unavailable, reads use the primary: %v", err)
+		return
+	}
+	pool.Apply(sqlDB)
+
+	ReadDB = replica
+	log.Println("✅ Connected to read replica")
+}
+
+// Reader returns the connection for read-heavy queries that tolerate replica lag
+func Reader() *gorm.DB {
+	if ReadDB == nil {
+		return DB
+	}
+	return ReadDB
+}
+
+// GetDatabaseHealth pings the primary (and replica, if separate) and reports pool usage
+func GetDatabaseHealth(ctx context.Context) dto.DatabaseHealth {
+	health := dto.DatabaseHealth{Primary: poolHealth(ctx, DB)}
+	if ReadDB != nil && ReadDB != DB {
+		replica := poolHealth(ctx, ReadDB)
+		health.Replica = &replica
+	}
+	return health
+}
+
+func poolHealth(ctx context.Context, db *gorm.DB) dto.DBPoolHealth {
+	health := dto.DBPoolHealth{Status: "ok"}
+	if db == nil {
+		health.Status = "down"
+		health.Error = "not connected"
+		return health
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		health.Status = "down"
+		health.Error = err.Error()
+		return health
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := sqlDB.PingContext(pingCtx); err != nil {
+		health.Status = "down"
+		health.Error = err.Error()
+	}
+
+	stats := sqlDB.Stats()
+	health.MaxOpen = stats.MaxOpenConnections
+	health.Open = stats.OpenConnections
+	health.InUse = stats.InUse
+	health.Idle = stats.Idle
+	health.WaitCount = stats.WaitCount
+	health.WaitDurationMs = stats.WaitDuration.Milliseconds()
+	if stats.MaxOpenConnections > 0 {
+		health.Saturation = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		if health.Status == "ok" && health.Saturation >= poolSaturationThreshold {
+			health.Status = "degraded"
+		}
+	}
+	return health
+}
+
+func envInt(name string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("⚠️ Invalid %s=%q, using %d", name, value, fallback)
+		return fallback
+	}
+	return n
+}
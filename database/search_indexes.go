@@ -0,0 +1,42 @@
+package database
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// searchIndexes are trigram indexes backing the ILIKE '%term%' queries of the global search
+var searchIndexes = []struct{ name, definition string }{
+	{"idx_projects_name_trgm", "ON projects USING gin (name gin_trgm_ops)"},
+	{"idx_services_name_trgm", "ON services USING gin (name gin_trgm_ops)"},
+	{"idx_services_domain_trgm", "ON services USING gin (domain gin_trgm_ops)"},
+	{"idx_services_custom_domain_trgm", "ON services USING gin (custom_domain gin_trgm_ops)"},
+	{"idx_deployments_commit_sha", "ON deployments (commit_sha text_pattern_ops)"},
+	{"idx_deployments_commit_message_trgm", "ON deployments USING gin (commit_message gin_trgm_ops)"},
+}
+
+// ensureSearchIndexes creates the pg_trgm extension and search indexes. Search still
+// works without them (just slower), so failures are logged rather than fatal.
+func ensureSearchIndexes(db *gorm.DB) {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("⚠️ Could not enable pg_trgm, search indexes skipped: %v", err)
+		return
+	}
+
+	for _, index := range searchIndexes {
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS " + index.name + " " + index.definition).Error; err != nil {
+			log.Printf("⚠️ Failed to create search index: %v", err)
+		}
+	}
+}
+
+// dropSearchIndexes removes the search indexes (the pg_trgm extension is left in place)
+func dropSearchIndexes(db *gorm.DB) error {
+	for _, index := range searchIndexes {
+		if err := db.Exec("DROP INDEX IF EXISTS " + index.name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+)
+
+// Activity kinds in a project's activity feed
+const (
+	ActivityDeployment   = "deployment"    // a build or rollout of a service
+	ActivityConfigChange = "config_change" // env vars, resources or domain changed
+	ActivityScaling      = "scaling"       // only the replica settings changed
+	ActivityIncident     = "incident"      // detected on a service or annotated on the status page
+)
+
+// ActivityItem is one entry of a project's activity feed
+type ActivityItem struct {
+	Kind        string    `json:"kind"`
+	ID          string    `json:"id"` // of the deployment, revision or incident
+	OccurredAt  time.Time `json:"occurredAt"`
+	ServiceID   string    `json:"serviceId,omitempty"`
+	ServiceName string    `json:"serviceName,omitempty"`
+	Summary     string    `json:"summary"`
+	Status      string    `json:"status,omitempty"` // deployment or incident status
+	ActorID     string    `json:"actorId,omitempty"`
+}
+
+// ActivityFeed is a page of a project's activity, newest first. NextCursor is empty on
+// the last page.
+type ActivityFeed struct {
+	Items      []ActivityItem `json:"items"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
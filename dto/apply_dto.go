@@ -0,0 +1,77 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// Declarative (create-or-update by name) requests for infrastructure-as-code tools.
+// Omitted fields keep their current value, so repeating a request is a no-op.
+
+// ProjectApplyRequest is the desired state of a project identified by its name
+type ProjectApplyRequest struct {
+	Description *string `json:"description"`
+	BaseDomain  string  `json:"baseDomain"` // one of GET /domains; empty keeps the current one
+}
+
+// EnvironmentApplyRequest is the desired state of an environment identified by its name
+type EnvironmentApplyRequest struct {
+	Description        *string `json:"description"`
+	DefaultCPULimit    *string `json:"defaultCpuLimit"`
+	DefaultMemoryLimit *string `json:"defaultMemoryLimit"`
+	DefaultReplicas    *int    `json:"defaultReplicas"`
+	DefaultStorageSize *string `json:"defaultStorageSize"`
+	MinCPULimit        *string `json:"minCpuLimit"`
+	MaxCPULimit        *string `json:"maxCpuLimit"`
+	MinMemoryLimit     *string `json:"minMemoryLimit"`
+	MaxMemoryLimit     *string `json:"maxMemoryLimit"`
+	MaxStorageSize     *string `json:"maxStorageSize"`
+}
+
+// ServiceApplyRequest is the desired state of a service identified by its name.
+// type, repoUrl and managedType cannot change in place; the service must be replaced.
+// gitUsername, gitToken and isPublic are only used when the service is created.
+type ServiceApplyRequest struct {
+	Type models.ServiceType `json:"type" binding:"required"` // "git" or "managed"
+
+	// Git services
+	RepoURL      string `json:"repoUrl"`
+	Branch       string `json:"branch"`
+	IsPublic     bool   `json:"isPublic"`
+	GitUsername  string `json:"gitUsername"`
+	GitToken     string `json:"gitToken"`
+	Port         int    `json:"port"`
+	BuildCommand string `json:"buildCommand"`
+	StartCommand string `json:"startCommand"`
+	TLSChallenge string `json:"tlsChallenge"`
+
+	// Managed services
+	ManagedType    string  `json:"managedType"`
+	Version        string  `json:"version"`
+	StorageSize    string  `json:"storageSize"`
+	PoolingEnabled *bool   `json:"poolingEnabled"`
+	PoolMode       string  `json:"poolMode"`
+	PoolSize       int     `json:"poolSize"`
+	MaxClientConn  int     `json:"maxClientConn"`
+	VPAMode        *string `json:"vpaMode"` // recommend or auto; "" removes the VPA
+
+	// Common configuration
+	EnvVars           models.EnvVars `json:"envVars"`
+	CPULimit          string         `json:"cpuLimit"`
+	MemoryLimit       string         `json:"memoryLimit"`
+	IsStaticReplica   *bool          `json:"isStaticReplica"`
+	Replicas          int            `json:"replicas"`
+	MinReplicas       int            `json:"minReplicas"`
+	MaxReplicas       int            `json:"maxReplicas"`
+	HighAvailability  *bool          `json:"highAvailability"`
+	AutoCorrectPort   *bool          `json:"autoCorrectPort"`
+	CustomDomain      string         `json:"customDomain"`
+	DeletionProtected *bool          `json:"deletionProtected"`
+
+	ServiceAccountAnnotations models.EnvVars `json:"serviceAccountAnnotations"`
+	PodLabels                 models.EnvVars `json:"podLabels"`
+	PodAnnotations            models.EnvVars `json:"podAnnotations"`
+}
+
+// ApplyResult reports what a declarative request did
+type ApplyResult struct {
+	Created bool     `json:"created"`
+	Changed []string `json:"changed,omitempty"` // fields that differed from the current state
+}
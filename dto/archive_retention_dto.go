@@ -0,0 +1,46 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ArchiveRetentionSettingsRequest changes the retention policy of archived build logs and
+// artifacts; omitted fields are left unchanged and 0 keeps archives forever
+type ArchiveRetentionSettingsRequest struct {
+	BuildLogRetentionDays *int `json:"buildLogRetentionDays" binding:"omitempty,min=0"`
+	ArtifactRetentionDays *int `json:"artifactRetentionDays" binding:"omitempty,min=0"`
+	DefaultProjectQuotaMB *int `json:"defaultProjectQuotaMb" binding:"omitempty,min=0"`
+}
+
+// ArchiveQuotaRequest sets the archive size quota of one project; 0 exempts the project
+type ArchiveQuotaRequest struct {
+	QuotaMB int `json:"quotaMb" binding:"min=0"`
+}
+
+// ProjectArchiveUsage is the size of a project's archived build logs and artifacts against
+// its quota
+type ProjectArchiveUsage struct {
+	ProjectID     string `json:"projectId"`
+	ProjectName   string `json:"projectName,omitempty"`
+	BuildLogs     int64  `json:"buildLogs"`
+	BuildLogBytes int64  `json:"buildLogBytes"`
+	Artifacts     int64  `json:"artifacts"`
+	ArtifactBytes int64  `json:"artifactBytes"`
+	TotalBytes    int64  `json:"totalBytes"`
+	QuotaMB       int    `json:"quotaMb"`               // 0 when not enforced
+	QuotaSource   string `json:"quotaSource,omitempty"` // project or default
+	OverQuota     bool   `json:"overQuota"`             // purged down on the next run
+}
+
+// ArchiveUsageOverview lists the archive usage of every project, largest first
+type ArchiveUsageOverview struct {
+	Settings   models.ArchiveRetentionSettings `json:"settings"`
+	TotalBytes int64                           `json:"totalBytes"`
+	Projects   []ProjectArchiveUsage           `json:"projects"`
+}
+
+// ArchivePurgeReport summarizes one run of the archive purger
+type ArchivePurgeReport struct {
+	PurgedBuildLogs int               `json:"purgedBuildLogs"`
+	PurgedArtifacts int               `json:"purgedArtifacts"`
+	FreedBytes      int64             `json:"freedBytes"`
+	Failed          map[string]string `json:"failed,omitempty"` // deployment ID -> error
+}
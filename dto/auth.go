@@ -12,6 +12,12 @@ type TokenClaims struct {
 	UserID string `json:"userId"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// TwoFactorSetup marks a session of a user the auth policy requires to set up 2FA; it
+	// only grants access to the 2FA setup endpoints
+	TwoFactorSetup bool `json:"twoFactorSetup,omitempty"`
+	// ImpersonatorID and ImpersonatorEmail identify the admin acting as the user
+	ImpersonatorID    string `json:"impersonatorId,omitempty"`
+	ImpersonatorEmail string `json:"impersonatorEmail,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -19,6 +25,9 @@ type TokenClaims struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Second factor of accounts with 2FA: a code from the authenticator app or a recovery code
+	TwoFactorCode string `json:"twoFactorCode"`
+	RecoveryCode  string `json:"recoveryCode"`
 }
 
 // RegisterRequest represents registration data
@@ -34,4 +43,7 @@ type AuthResponse struct {
 	Token     string      `json:"token"`
 	User      models.User `json:"user"`
 	ExpiresAt time.Time   `json:"expiresAt"`
+	// TwoFactorSetupRequired is set when the auth policy requires 2FA the user has not set
+	// up yet; the token only works for /auth/me and /auth/2fa until it is enabled
+	TwoFactorSetupRequired bool `json:"twoFactorSetupRequired,omitempty"`
 }
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"github.com/pendeploy-simple/models"
+)
+
+// BuildQuotaRequest sets the build caps of a project; 0 removes a cap
+type BuildQuotaRequest struct {
+	MaxConcurrentBuilds     int `json:"maxConcurrentBuilds" binding:"min=0"`
+	MonthlyMinutesSoftLimit int `json:"monthlyMinutesSoftLimit" binding:"min=0"` // builds run one at a time past it
+	MonthlyMinutesHardLimit int `json:"monthlyMinutesHardLimit" binding:"min=0"` // builds are rejected past it
+}
+
+// BuildMonthUsage is the build job consumption of a project in one calendar month (UTC)
+type BuildMonthUsage struct {
+	Month           string  `json:"month"` // YYYY-MM
+	Builds          int     `json:"builds"`
+	BuildMinutes    float64 `json:"buildMinutes"`
+	PeakConcurrency int     `json:"peakConcurrency"` // most build jobs running at once
+}
+
+// BuildUsageReport is a project's build consumption, newest month first, against its quota
+type BuildUsageReport struct {
+	ProjectID         string             `json:"projectId"`
+	Quota             *models.BuildQuota `json:"quota"` // nil when the project has no caps
+	RunningBuilds     int64              `json:"runningBuilds"`
+	QueuedBuilds      int64              `json:"queuedBuilds"`
+	SoftLimitExceeded bool               `json:"softLimitExceeded"` // this month
+	HardLimitExceeded bool               `json:"hardLimitExceeded"` // this month
+	Months            []BuildMonthUsage  `json:"months"`
+}
+
+// ProjectBuildUsage is one project's row in the build usage overview
+type ProjectBuildUsage struct {
+	ProjectID    string             `json:"projectId"`
+	ProjectName  string             `json:"projectName"`
+	Builds       int64              `json:"builds"`
+	BuildMinutes float64            `json:"buildMinutes"`
+	Quota        *models.BuildQuota `json:"quota"`
+}
+
+// BuildUsageOverview lists the build consumption of every project in a month, heaviest first
+type BuildUsageOverview struct {
+	Month    string              `json:"month"`
+	Projects []ProjectBuildUsage `json:"projects"`
+}
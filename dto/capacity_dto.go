@@ -0,0 +1,65 @@
+package dto
+
+import "time"
+
+// CapacityForecast projects when the cluster runs out of resources at the current growth
+// and lists the services whose limits are furthest from their actual usage
+type CapacityForecast struct {
+	WindowDays      int                       `json:"windowDays"`
+	Samples         int                       `json:"samples"`
+	SampledFrom     *time.Time                `json:"sampledFrom,omitempty"`
+	Forecasts       []ResourceForecast        `json:"forecasts"`
+	Recommendations []RightSizeRecommendation `json:"recommendations"`
+}
+
+// ResourceForecast is the linear trend of one resource. CPU values are in millicores,
+// memory and storage values in bytes.
+type ResourceForecast struct {
+	Resource           string     `json:"resource"` // cpu, memory or storage
+	Basis              string     `json:"basis"`    // requests (reserved by the scheduler) or usage
+	Current            int64      `json:"current"`
+	Capacity           int64      `json:"capacity"`
+	Percentage         float64    `json:"percentage"`
+	GrowthPerDay       float64    `json:"growthPerDay"`
+	DaysUntilExhausted *float64   `json:"daysUntilExhausted,omitempty"` // absent when not growing or too little history
+	ExhaustedAt        *time.Time `json:"exhaustedAt,omitempty"`
+	Note               string     `json:"note,omitempty"`
+}
+
+// RightSizeRecommendation suggests a new per-pod limit from the 95th percentile usage
+type RightSizeRecommendation struct {
+	ServiceID        string  `json:"serviceId"`
+	ServiceName      string  `json:"serviceName"`
+	ProjectID        string  `json:"projectId"`
+	EnvironmentID    string  `json:"environmentId"`
+	Resource         string  `json:"resource"`  // cpu or memory
+	Direction        string  `json:"direction"` // shrink or grow
+	CurrentLimit     string  `json:"currentLimit"`
+	RecommendedLimit string  `json:"recommendedLimit"`
+	P95Usage         string  `json:"p95Usage"`
+	PeakUsage        string  `json:"peakUsage"`
+	Utilization      float64 `json:"utilization"` // p95 usage as a percentage of the current limit
+	Replicas         int     `json:"replicas"`
+}
+
+// ServiceRightSizing compares a service's per-pod usage with its limits over a window
+type ServiceRightSizing struct {
+	ServiceID       string                    `json:"serviceId"`
+	WindowDays      int                       `json:"windowDays"`
+	Samples         int64                     `json:"samples"`
+	SampledFrom     *time.Time                `json:"sampledFrom,omitempty"`
+	CPULimit        string                    `json:"cpuLimit"`
+	MemoryLimit     string                    `json:"memoryLimit"`
+	CPUP95          string                    `json:"cpuP95,omitempty"`
+	CPUPeak         string                    `json:"cpuPeak,omitempty"`
+	MemoryP95       string                    `json:"memoryP95,omitempty"`
+	MemoryPeak      string                    `json:"memoryPeak,omitempty"`
+	Recommendations []RightSizeRecommendation `json:"recommendations"`
+	Note            string                    `json:"note,omitempty"`
+}
+
+// RightSizingApplyRequest applies a service's current right-sizing recommendations
+type RightSizingApplyRequest struct {
+	Resources []string `json:"resources" binding:"omitempty,dive,oneof=cpu memory"` // default: every recommendation
+	Days      int      `json:"days" binding:"omitempty,min=1,max=30"`               // default: 7
+}
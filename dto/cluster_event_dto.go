@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+// ClusterEvent is an important Kubernetes event streamed to admins
+type ClusterEvent struct {
+	Severity  string    `json:"severity"` // warning or critical
+	Category  string    `json:"category"` // node, storage, certificate or scheduling
+	Reason    string    `json:"reason"`   // e.g. NodeNotReady, ProvisioningFailed
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"` // kind of the involved object
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	Count     int32     `json:"count"`
+	Source    string    `json:"source,omitempty"` // component that reported the event
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// ConsoleQueryRequest is a single statement/command to run against a managed service
+type ConsoleQueryRequest struct {
+	Query string `json:"query" binding:"required"`
+}
+
+// ConsoleQueryResult is the tabular result of a console query.
+// Redis replies are returned as a single "result" column.
+type ConsoleQueryResult struct {
+	Columns    []string        `json:"columns"`
+	Rows       [][]interface{} `json:"rows"`
+	RowCount   int             `json:"rowCount"`
+	Truncated  bool            `json:"truncated"`
+	DurationMs int64           `json:"durationMs"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+// CostPricing is the configured price list used for cost estimates
+type CostPricing struct {
+	Currency       string  `json:"currency"`
+	CPUHour        float64 `json:"cpuHour"`        // per core-hour
+	MemoryGBHour   float64 `json:"memoryGbHour"`   // per GiB-hour
+	StorageGBMonth float64 `json:"storageGbMonth"` // per GiB-month of persistent volume
+	HoursPerMonth  float64 `json:"hoursPerMonth"`
+}
+
+// CostSummary is a monthly estimate from configured limits and, when the metrics API
+// is available, from current usage
+type CostSummary struct {
+	Currency      string   `json:"currency"`
+	LimitsMonthly float64  `json:"limitsMonthly"`
+	UsageMonthly  *float64 `json:"usageMonthly"` // null without metrics
+}
+
+// ServiceCostEstimate is the monthly estimate of one service
+type ServiceCostEstimate struct {
+	ServiceID     string   `json:"serviceId"`
+	ServiceName   string   `json:"serviceName"`
+	EnvironmentID string   `json:"environmentId"`
+	Replicas      int      `json:"replicas"` // billed replicas: 0 when paused or archived, the minimum when autoscaled
+	CPUCores      float64  `json:"cpuCores"` // limit per replica
+	MemoryGB      float64  `json:"memoryGb"` // limit per replica
+	StorageGB     float64  `json:"storageGb"`
+	CPUUsage      *float64 `json:"cpuUsage"` // cores across all pods; null without metrics
+	MemoryUsageGB *float64 `json:"memoryUsageGb"`
+	LimitsMonthly float64  `json:"limitsMonthly"`
+	UsageMonthly  *float64 `json:"usageMonthly"` // usage-based compute plus storage
+}
+
+// EnvironmentCostEstimate is the monthly estimate of one environment
+type EnvironmentCostEstimate struct {
+	EnvironmentID   string `json:"environmentId"`
+	EnvironmentName string `json:"environmentName"`
+	CostSummary
+}
+
+// ProjectCostEstimate is the monthly cost breakdown of a project
+type ProjectCostEstimate struct {
+	ProjectID string      `json:"projectId"`
+	Pricing   CostPricing `json:"pricing"`
+	CostSummary
+	Environments []EnvironmentCostEstimate `json:"environments"`
+	Services     []ServiceCostEstimate     `json:"services"`
+}
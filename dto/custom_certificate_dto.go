@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+)
+
+// CustomCertificateRequest uploads a PEM certificate chain and private key for a custom domain
+type CustomCertificateRequest struct {
+	Certificate string `json:"certificate" binding:"required"` // leaf first, then intermediates
+	PrivateKey  string `json:"privateKey" binding:"required"`
+}
+
+// CustomCertificateResponse describes an uploaded certificate (the key is never returned)
+type CustomCertificateResponse struct {
+	ServiceID     string    `json:"serviceId"`
+	Domain        string    `json:"domain"`
+	SecretName    string    `json:"secretName"`
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	DNSNames      []string  `json:"dnsNames"`
+	Fingerprint   string    `json:"fingerprint"`
+	NotBefore     time.Time `json:"notBefore"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+	ExpiringSoon  bool      `json:"expiringSoon"` // within the renewal reminder window
+	UploadedAt    time.Time `json:"uploadedAt"`
+}
+
+// CertificateExpiryPayload is the webhook body of a certificate renewal reminder
+type CertificateExpiryPayload struct {
+	Event         string    `json:"event"`
+	ServiceID     string    `json:"serviceId"`
+	ServiceName   string    `json:"serviceName"`
+	Domain        string    `json:"domain"`
+	NotAfter      time.Time `json:"notAfter"`
+	DaysRemaining int       `json:"daysRemaining"`
+}
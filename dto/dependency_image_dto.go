@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// DependencyImageUpdateRequest proposes a newer tag of a dependency image's source, e.g.
+// gcr.io/kaniko-project/executor:v1.24.0. The tag is resolved to a digest for review.
+type DependencyImageUpdateRequest struct {
+	SourceImage string `json:"sourceImage" binding:"required"`
+}
+
+// DependencyImageApproveRequest approves the update of a dependency image. Digest must be
+// the candidate digest that was reviewed.
+type DependencyImageApproveRequest struct {
+	Digest string `json:"digest" binding:"required"`
+}
+
+// DependencyImageRolloutRequest rebuilds outdated dependency images in every registry that
+// holds them; Names limits the rollout to some images
+type DependencyImageRolloutRequest struct {
+	Names []string `json:"names"`
+}
+
+// DependencyImageRegistryStatus tells whether a registry's copy of a dependency image was
+// built from the pinned digest
+type DependencyImageRegistryStatus struct {
+	RegistryID   string     `json:"registryId"`
+	RegistryName string     `json:"registryName"`
+	Digest       string     `json:"digest"` // the pinned digest the copy was built from
+	BuiltAt      *time.Time `json:"builtAt"`
+	UpToDate     bool       `json:"upToDate"`
+}
+
+// DependencyImageStatus is a dependency image with its pin, the update waiting for review
+// and how far the pin is rolled out across registries
+type DependencyImageStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	SourceImage string     `json:"sourceImage"`
+	Digest      string     `json:"digest"` // empty until the image is pinned on its first build
+	PinnedBy    *string    `json:"pinnedBy"`
+	PinnedAt    *time.Time `json:"pinnedAt"`
+
+	CandidateImage   string     `json:"candidateImage,omitempty"`
+	CandidateDigest  string     `json:"candidateDigest,omitempty"`
+	CandidateFoundAt *time.Time `json:"candidateFoundAt,omitempty"`
+
+	Registries []DependencyImageRegistryStatus `json:"registries"`
+}
+
+// DependencyImageListResponse lists the dependency images. Errors names the images whose
+// source could not be checked for updates.
+type DependencyImageListResponse struct {
+	Images []DependencyImageStatus `json:"images"`
+	Errors []string                `json:"errors,omitempty"`
+}
+
+// DependencyImageRollout names the dependency images being rebuilt in a registry
+type DependencyImageRollout struct {
+	RegistryID   string   `json:"registryId"`
+	RegistryName string   `json:"registryName"`
+	Rebuilding   []string `json:"rebuilding"`
+}
+
+// DependencyImageRolloutSkip names a registry left out of a rollout and why
+type DependencyImageRolloutSkip struct {
+	RegistryID   string `json:"registryId"`
+	RegistryName string `json:"registryName"`
+	Reason       string `json:"reason"`
+}
+
+// DependencyImageRolloutResponse lists the registries whose dependency images are being
+// rebuilt in the background and the ones skipped
+type DependencyImageRolloutResponse struct {
+	Registries []DependencyImageRollout     `json:"registries"`
+	Skipped    []DependencyImageRolloutSkip `json:"skipped"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// DeployLockRequest locks deployments in a project or one of its environments
+type DeployLockRequest struct {
+	EnvironmentID   string     `json:"environmentId"`                             // empty locks the whole project
+	Reason          string     `json:"reason" binding:"required,max=500"`         // shown in every rejected deployment
+	ExpiresAt       *time.Time `json:"expiresAt"`                                 // RFC 3339; empty holds until removed
+	DurationMinutes int        `json:"durationMinutes" binding:"omitempty,min=1"` // alternative to expiresAt
+}
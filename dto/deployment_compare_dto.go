@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// DeploymentImageSummary describes the image of one side of a deployment comparison
+type DeploymentImageSummary struct {
+	DeploymentID string    `json:"deploymentId"`
+	CommitSHA    string    `json:"commitSha"`
+	Image        string    `json:"image"`
+	ImageDigest  string    `json:"imageDigest,omitempty"`
+	ImageSize    int64     `json:"imageSize"`
+	LayerCount   int       `json:"layerCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ImageLayerChange is a layer position that differs between two images: changed (both
+// images have a different layer there), added (only b has one) or removed (only a has one)
+type ImageLayerChange struct {
+	Index     int    `json:"index"`
+	Status    string `json:"status"`
+	DigestA   string `json:"digestA,omitempty"`
+	DigestB   string `json:"digestB,omitempty"`
+	SizeA     int64  `json:"sizeA"`
+	SizeB     int64  `json:"sizeB"`
+	SizeDelta int64  `json:"sizeDelta"`
+}
+
+// DeploymentComparison compares the images of two deployments of a service; deltas are b - a
+type DeploymentComparison struct {
+	A                DeploymentImageSummary `json:"a"`
+	B                DeploymentImageSummary `json:"b"`
+	SizeDelta        int64                  `json:"sizeDelta"`
+	SizeDeltaPercent float64                `json:"sizeDeltaPercent"`
+	UnchangedLayers  int                    `json:"unchangedLayers"`
+	ChangedLayers    []ImageLayerChange     `json:"changedLayers"`
+}
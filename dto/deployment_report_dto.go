@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"github.com/pendeploy-simple/models"
+)
+
+// FailureClassRate is how many finished deployments failed in a stage, see models.FailureClass*
+type FailureClassRate struct {
+	FailureClass string  `json:"failureClass"`
+	Count        int64   `json:"count"`
+	Rate         float64 `json:"rate"` // share of the finished deployments, 0-1
+}
+
+// DeploymentReportSummary totals the daily rollups of a report's date range
+type DeploymentReportSummary struct {
+	Deployments        int64                     `json:"deployments"`
+	Succeeded          int64                     `json:"succeeded"`
+	Failed             int64                     `json:"failed"`
+	FailureRate        float64                   `json:"failureRate"` // failed of the finished deployments, 0-1
+	Builds             int64                     `json:"builds"`
+	BuildsPerDay       float64                   `json:"buildsPerDay"`
+	AverageBuildMs     int64                     `json:"averageBuildMs"`
+	FailuresByClass    []FailureClassRate        `json:"failuresByClass"`    // most frequent first
+	TopCPUConsumers    []models.ResourceConsumer `json:"topCpuConsumers"`    // averaged over the days sampled
+	TopMemoryConsumers []models.ResourceConsumer `json:"topMemoryConsumers"` // averaged over the days sampled
+}
+
+// DeploymentReport is the platform-wide deployment statistics of a date range (UTC days,
+// inclusive), from the nightly rollups
+type DeploymentReport struct {
+	From        string                         `json:"from"` // YYYY-MM-DD
+	To          string                         `json:"to"`
+	Summary     DeploymentReportSummary        `json:"summary"`
+	Days        []models.DeploymentStatsRollup `json:"days"`        // oldest first
+	MissingDays []string                       `json:"missingDays"` // days of the range without a rollup yet
+}
@@ -8,27 +8,71 @@ import (
 
 // DeploymentResponse represents a deployment response
 type DeploymentResponse struct {
-	ID            string    `json:"id"`
-	ServiceID     string    `json:"serviceId"`
-	Status        string    `json:"status"`
-	CommitSHA     string    `json:"commitSha"`
-	CommitMessage string    `json:"commitMessage"`
-	Image         string    `json:"image"`
-	Version       string    `json:"version"`
-	CreatedAt     time.Time `json:"createdAt"`
+	ID               string                   `json:"id"`
+	ServiceID        string                   `json:"serviceId"`
+	Status           string                   `json:"status"`
+	FailureClass     string                   `json:"failureClass,omitempty"` // stage a failed deployment failed in
+	ErrorCode        string                   `json:"errorCode,omitempty"`    // classified cause of a failed build
+	ErrorHint        string                   `json:"errorHint,omitempty"`    // how to fix it
+	CommitSHA        string                   `json:"commitSha"`
+	CommitMessage    string                   `json:"commitMessage"`
+	Image            string                   `json:"image"`
+	Version          string                   `json:"version"`
+	BuildEnv         *models.BuildEnvironment `json:"buildEnv,omitempty"`
+	DockerfileDigest string                   `json:"dockerfileDigest,omitempty"`
+	ImageDigest      string                   `json:"imageDigest,omitempty"`
+	TestDurationMs   int64                    `json:"testDurationMs,omitempty"`
+	ImageSize        int64                    `json:"imageSize,omitempty"`
+	LayerCount       int                      `json:"layerCount,omitempty"`
+	ImageSizeWarning string                   `json:"imageSizeWarning,omitempty"` // the image exceeds the service's size budget
+	PortCheckError   string                   `json:"portCheckError,omitempty"` // the app did not listen on the service port after the rollout
+	PortWarning      string                   `json:"portWarning,omitempty"`    // the service port is not a port the Dockerfile EXPOSEs
+	HealthCheck      *models.DeploymentHealthCheck `json:"healthCheck,omitempty"` // GET against the service's domain after the deployment
+	HasSBOM          bool                     `json:"hasSbom"`
+	ImageSigned      bool                     `json:"imageSigned"`
+	ProvenanceError  string                   `json:"provenanceError,omitempty"`
+	HasArtifact      bool                     `json:"hasArtifact"`
+	ArtifactSize     int64                    `json:"artifactSize,omitempty"`
+	ArtifactError    string                   `json:"artifactError,omitempty"`
+	HasBuildLogs     bool                     `json:"hasBuildLogs"` // the build output was archived and can be downloaded
+	BuildLogSize     int64                    `json:"buildLogSize,omitempty"`
+	ArchivePurgedAt  *time.Time               `json:"archivePurgedAt,omitempty"` // archives removed by the retention policy
+	CreatedAt        time.Time                `json:"createdAt"`
 }
 
 // NewDeploymentResponseFromModel creates a new DeploymentResponse from a models.Deployment
 func NewDeploymentResponseFromModel(deployment models.Deployment) DeploymentResponse {
 	return DeploymentResponse{
-		ID:            deployment.ID,
-		ServiceID:     deployment.ServiceID,
-		Status:        string(deployment.Status),
-		CommitSHA:     deployment.CommitSHA,
-		CommitMessage: deployment.CommitMessage,
-		Image:         deployment.Image,
-		Version:       deployment.Version,
-		CreatedAt:     deployment.CreatedAt,
+		ID:               deployment.ID,
+		ServiceID:        deployment.ServiceID,
+		Status:           string(deployment.Status),
+		FailureClass:     deployment.FailureClass,
+		ErrorCode:        deployment.ErrorCode,
+		ErrorHint:        deployment.ErrorHint,
+		CommitSHA:        deployment.CommitSHA,
+		CommitMessage:    deployment.CommitMessage,
+		Image:            deployment.Image,
+		Version:          deployment.Version,
+		BuildEnv:         deployment.BuildEnv,
+		DockerfileDigest: deployment.DockerfileDigest,
+		ImageDigest:      deployment.ImageDigest,
+		TestDurationMs:   deployment.TestDurationMs,
+		ImageSize:        deployment.ImageSize,
+		LayerCount:       len(deployment.ImageLayers),
+		ImageSizeWarning: deployment.ImageSizeWarning,
+		PortCheckError:   deployment.PortCheckError,
+		PortWarning:      deployment.PortWarning,
+		HealthCheck:      deployment.HealthCheck,
+		HasSBOM:          deployment.SBOMFormat != "",
+		ImageSigned:      deployment.ImageSigned,
+		ProvenanceError:  deployment.ProvenanceError,
+		HasArtifact:      deployment.ArtifactKey != "",
+		ArtifactSize:     deployment.ArtifactSize,
+		ArtifactError:    deployment.ArtifactError,
+		HasBuildLogs:     deployment.BuildLogKey != "",
+		BuildLogSize:     deployment.BuildLogSize,
+		ArchivePurgedAt:  deployment.ArchivePurgedAt,
+		CreatedAt:        deployment.CreatedAt,
 	}
 }
 
@@ -78,3 +122,21 @@ type HPAStatusInfo struct {
 	CurrentCPU     int32  `json:"currentCpu"`
 	Age            string `json:"age"`
 }
+
+// BatchStatusRequest asks for the status of several services at once
+type BatchStatusRequest struct {
+	ServiceIDs []string `json:"serviceIds" binding:"required,min=1,max=200"`
+}
+
+// ServiceStatusSummary is the cached workload status of one service
+type ServiceStatusSummary struct {
+	ServiceID         string                `json:"serviceId"`
+	Status            string                `json:"status"`
+	Kind              string                `json:"kind"`
+	Found             bool                  `json:"found"`
+	Replicas          int32                 `json:"replicas"`
+	ReadyReplicas     int32                 `json:"readyReplicas"`
+	AvailableReplicas int32                 `json:"availableReplicas"`
+	Health            *models.ServiceHealth `json:"health,omitempty"`
+	Error             string                `json:"error,omitempty"`
+}
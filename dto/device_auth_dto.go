@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// DeviceCodeRequest starts a device-code login for a CLI
+type DeviceCodeRequest struct {
+	ClientName string   `json:"clientName"`                                       // shown to the user on approval, e.g. "pendeploy-cli on laptop"
+	Scopes     []string `json:"scopes" binding:"omitempty,dive,oneof=read write"` // default: read, write
+}
+
+// DeviceCodeResponse tells the CLI what to show the user and how to poll
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"deviceCode"` // secret, only sent to the token endpoint
+	UserCode                string `json:"userCode"`   // e.g. WDJB-MJHT, entered by the user
+	VerificationURI         string `json:"verificationUri"`
+	VerificationURIComplete string `json:"verificationUriComplete"`
+	ExpiresIn               int    `json:"expiresIn"` // seconds
+	Interval                int    `json:"interval"`  // minimum seconds between polls
+}
+
+// DeviceTokenRequest exchanges an approved device code for an API token
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"deviceCode" binding:"required"`
+}
+
+// DeviceTokenResponse carries the issued API token
+type DeviceTokenResponse struct {
+	AccessToken string     `json:"accessToken"`
+	TokenType   string     `json:"tokenType"` // Bearer
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+// DeviceAuthorizationInfo describes a pending login to the user approving it
+type DeviceAuthorizationInfo struct {
+	UserCode   string    `json:"userCode"`
+	ClientName string    `json:"clientName"`
+	Scopes     []string  `json:"scopes"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// DeviceApprovalRequest approves or denies a pending login
+type DeviceApprovalRequest struct {
+	UserCode string `json:"userCode" binding:"required"`
+	Approve  *bool  `json:"approve" binding:"required"`
+}
+
+// APITokenListResponse lists the caller's API tokens (secrets are never returned)
+type APITokenListResponse struct {
+	Tokens []models.APIToken `json:"tokens"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+// DomainListResponse lists the base domains projects can select
+type DomainListResponse struct {
+	Default string   `json:"default"`
+	Domains []string `json:"domains"`
+}
+
+// DomainCheck reports whether a base domain is ready to serve generated hostnames
+type DomainCheck struct {
+	Domain            string   `json:"domain"`
+	IsDefault         bool     `json:"isDefault"`
+	Ready             bool     `json:"ready"`       // wildcard DNS and TLS both configured
+	WildcardDNS       bool     `json:"wildcardDns"` // a random label under the domain resolves
+	ResolvedAddresses []string `json:"resolvedAddresses,omitempty"`
+	DNSError          string   `json:"dnsError,omitempty"`
+	TLSReady          bool     `json:"tlsReady"` // the cert-manager ClusterIssuer is ready
+	TLSError          string   `json:"tlsError,omitempty"`
+}
+
+// DNS01Status reports the DNS-01 challenge configuration and its ClusterIssuer
+type DNS01Status struct {
+	Enabled    bool     `json:"enabled"` // a DNS provider is configured
+	Provider   string   `json:"provider,omitempty"`
+	IssuerName string   `json:"issuerName"`
+	Zones      []string `json:"zones,omitempty"` // empty = every zone the credentials can manage
+	Ready      bool     `json:"ready"`
+	Error      string   `json:"error,omitempty"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// FieldDrift is a field whose live value differs from the spec PenDeploy generates
+type FieldDrift struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ResourceDrift lists how one live object differs from its generated spec
+type ResourceDrift struct {
+	Kind    string       `json:"kind"`
+	Name    string       `json:"name"`
+	Missing bool         `json:"missing"` // the object does not exist in the cluster
+	Fields  []FieldDrift `json:"fields,omitempty"`
+}
+
+// ServiceDriftReport compares a service's live cluster objects with its declared spec
+type ServiceDriftReport struct {
+	ServiceID string          `json:"serviceId"`
+	Image     string          `json:"image"` // image of the latest successful deployment
+	InSync    bool            `json:"inSync"`
+	Resources []ResourceDrift `json:"resources"` // drifted objects only
+	CheckedAt time.Time       `json:"checkedAt"`
+}
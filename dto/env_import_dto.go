@@ -0,0 +1,38 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// EnvImportRequest sets a git service's environment variables from a .env file
+type EnvImportRequest struct {
+	Content string `json:"content" binding:"required"`                   // .env file content
+	Mode    string `json:"mode" binding:"omitempty,oneof=merge replace"` // default merge; replace removes variables missing from the file
+	DryRun  bool   `json:"dryRun"`                                       // only return the diff
+}
+
+// EnvVarChange is one variable added, changed or removed by an import. Values of
+// sensitive variables are masked.
+type EnvVarChange struct {
+	Key             string `json:"key"`
+	Change          string `json:"change"` // added, changed or removed
+	OldValue        string `json:"oldValue,omitempty"`
+	NewValue        string `json:"newValue,omitempty"`
+	RequiresRebuild bool   `json:"requiresRebuild"`         // false when the running Deployment is patched instead
+	RebuildReason   string `json:"rebuildReason,omitempty"` // why the image has to be rebuilt
+}
+
+// EnvImportResponse is the diff of an import and, when applied, the updated service
+type EnvImportResponse struct {
+	Mode      string          `json:"mode"`
+	Changes   []EnvVarChange  `json:"changes"`
+	Unchanged int             `json:"unchanged"`
+	Applied   bool            `json:"applied"`
+	Service   *models.Service `json:"service,omitempty"`
+	// In an environment that requires approval the import is proposed instead of applied
+	Proposal *EnvVarChangeResponse `json:"proposal,omitempty"`
+}
+
+// EnvRevealRequest names the env vars whose values to reveal; empty reveals every
+// variable flagged as secret
+type EnvRevealRequest struct {
+	Keys []string `json:"keys"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// EnvVarChangeRequest proposes a change of a service's env vars in an environment that
+// requires approval. Set adds or changes variables, masked values (********) keeping the
+// current value, and Unset removes them.
+type EnvVarChangeRequest struct {
+	Set   models.EnvVars `json:"set"`
+	Unset []string       `json:"unset"`
+	Note  string         `json:"note" binding:"max=1000"`
+}
+
+// EnvVarChangeReviewRequest approves or rejects a proposed env var change
+type EnvVarChangeReviewRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// EnvVarChangeResponse is a proposed env var change with its diff, the variables added,
+// changed and removed going from the service's variables when it was proposed to the
+// proposed ones. Values of sensitive variables are masked.
+type EnvVarChangeResponse struct {
+	models.EnvVarChange
+	Changes   []EnvVarChange `json:"changes"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// EnvVarChangeListResponse is a page of a service's proposed env var changes, newest first
+type EnvVarChangeListResponse struct {
+	Changes    []EnvVarChangeResponse `json:"changes"`
+	TotalCount int64                  `json:"totalCount"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"pageSize"`
+}
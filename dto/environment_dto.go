@@ -9,6 +9,34 @@ type EnvironmentRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	ProjectID   string `json:"projectId" binding:"required"`
+	// DomainTemplate generates the hostnames of git services, e.g. {service}.{env}.example.com
+	DomainTemplate string `json:"domainTemplate"`
+}
+
+// EnvironmentUpdateRequest renames an environment or changes its description, service
+// defaults and allowed resource ranges. Omitted fields are left unchanged; an empty
+// string clears a default or bound.
+type EnvironmentUpdateRequest struct {
+	Name               string  `json:"name"`
+	Description        *string `json:"description"`
+	DefaultCPULimit    *string `json:"defaultCpuLimit"`
+	DefaultMemoryLimit *string `json:"defaultMemoryLimit"`
+	DefaultReplicas    *int    `json:"defaultReplicas"` // 0 clears the default
+	DefaultStorageSize *string `json:"defaultStorageSize"`
+	MinCPULimit        *string `json:"minCpuLimit"`
+	MaxCPULimit        *string `json:"maxCpuLimit"`
+	MinMemoryLimit     *string `json:"minMemoryLimit"`
+	MaxMemoryLimit     *string `json:"maxMemoryLimit"`
+	MaxStorageSize     *string `json:"maxStorageSize"`
+	PriorityTier       *string `json:"priorityTier"` // admins only; empty clears the tier
+	// Promotions into the environment need approval by someone other than the requester
+	PromotionApprovalRequired *bool `json:"promotionApprovalRequired"`
+	// Env var changes of the environment's services need approval by someone other than
+	// the proposer before they are applied
+	EnvVarApprovalRequired *bool `json:"envVarApprovalRequired"`
+	// DomainTemplate generates the hostnames of git services, e.g. {service}.{env}.example.com;
+	// changing it moves every git service of the environment to its new hostname
+	DomainTemplate *string `json:"domainTemplate"`
 }
 
 // EnvironmentResponse is the structure for environment responses
@@ -19,6 +47,30 @@ type EnvironmentResponse struct {
 	ProjectID   string    `json:"projectId"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Archived           bool       `json:"archived"`
+	ArchivedAt         *time.Time `json:"archivedAt,omitempty"`
+	DefaultCPULimit    string     `json:"defaultCpuLimit,omitempty"`
+	DefaultMemoryLimit string     `json:"defaultMemoryLimit,omitempty"`
+	DefaultReplicas    int        `json:"defaultReplicas,omitempty"`
+	DefaultStorageSize string     `json:"defaultStorageSize,omitempty"`
+	MinCPULimit        string     `json:"minCpuLimit,omitempty"`
+	MaxCPULimit        string     `json:"maxCpuLimit,omitempty"`
+	MinMemoryLimit     string     `json:"minMemoryLimit,omitempty"`
+	MaxMemoryLimit     string     `json:"maxMemoryLimit,omitempty"`
+	MaxStorageSize     string     `json:"maxStorageSize,omitempty"`
+	PriorityTier       string     `json:"priorityTier,omitempty"`
+	DomainTemplate     string     `json:"domainTemplate,omitempty"`
+
+	PromotionApprovalRequired bool `json:"promotionApprovalRequired"`
+	EnvVarApprovalRequired    bool `json:"envVarApprovalRequired"`
+}
+
+// EnvironmentArchiveResponse reports the outcome of archiving or unarchiving an environment
+type EnvironmentArchiveResponse struct {
+	Environment EnvironmentResponse `json:"environment"`
+	Scaled      []string            `json:"scaled"`           // IDs of services scaled down or back up
+	Failed      map[string]string   `json:"failed,omitempty"` // service ID -> error
 }
 
 // EnvironmentListResponse wraps a list of environments
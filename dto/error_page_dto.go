@@ -0,0 +1,11 @@
+package dto
+
+// ErrorPageRequest sets the branded page served for 502, 503 and 504 responses of a
+// project's services. Title defaults to the project name; HTML, when set, replaces the
+// platform template and may use {{status}} for the status code.
+type ErrorPageRequest struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	LogoURL string `json:"logoUrl"`
+	HTML    string `json:"html"`
+}
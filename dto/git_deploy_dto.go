@@ -7,6 +7,9 @@ type GitDeployRequest struct {
 	CommitID      string `json:"commitId"`                     // Git commit SHA/ID to deploy (if empty, latest from default branch)
 	CommitMessage string `json:"commitMessage"`                // Optional override for Git commit message to deploy
 	CallbackUrl   string `json:"callbackUrl"`                 // Optional webhook URL to call on deployment success/failure
+	ForceRebuild  bool   `json:"forceRebuild"`                // build even when the commit was already built with the same config, by this or another service
+	ByAdmin       bool   `json:"-"`                           // set from the caller's role; admins deploy through deploy locks
+	FromWebhook   bool   `json:"-"`                           // set for pushes received on the deploy webhook; pinned services ignore them
 }
 
 // GitDeployResponse represents the response for a Git deployment request
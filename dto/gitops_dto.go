@@ -0,0 +1,52 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// GitOpsConfigRequest points a project at the config repository holding its spec file.
+// Empty optional fields keep their current value.
+type GitOpsConfigRequest struct {
+	RepoURL             string `json:"repoUrl" binding:"required"` // GitHub or GitLab HTTPS URL
+	Branch              string `json:"branch"`                     // default main
+	Path                string `json:"path"`                       // default pendeploy.yaml
+	GitUsername         string `json:"gitUsername"`
+	GitToken            string `json:"gitToken"`            // read access to the repository, never returned
+	PollMinutes         *int   `json:"pollMinutes"`         // default 5, 0 only syncs on push
+	Paused              *bool  `json:"paused"`              // stop syncing without removing the configuration
+	RotateWebhookSecret bool   `json:"rotateWebhookSecret"` // issue a new webhook secret
+}
+
+// GitOpsConfigResponse is the GitOps configuration of a project. WebhookSecret is only set
+// when it was generated by the request and cannot be shown again.
+type GitOpsConfigResponse struct {
+	models.GitOpsConfig
+	WebhookPath   string `json:"webhookPath"` // push webhook, relative to the API base URL
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+// GitOpsSpec is the spec file of a config repository: the environments of the project and
+// their services, keyed by name. Resources missing from the file are left in place.
+type GitOpsSpec struct {
+	Environments map[string]GitOpsEnvironmentSpec `json:"environments"`
+}
+
+// GitOpsEnvironmentSpec is the desired state of an environment and its services
+type GitOpsEnvironmentSpec struct {
+	EnvironmentApplyRequest
+	Services map[string]ServiceApplyRequest `json:"services"`
+}
+
+// GitOpsResourceResult reports what a sync did to one environment or service
+type GitOpsResourceResult struct {
+	Kind    string   `json:"kind"` // environment or service
+	Name    string   `json:"name"` // services as environment/service
+	Created bool     `json:"created"`
+	Changed []string `json:"changed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// GitOpsSyncResult reports a sync of the spec file at a commit
+type GitOpsSyncResult struct {
+	Commit    string                 `json:"commit"`
+	Skipped   bool                   `json:"skipped"` // the commit was already synced
+	Resources []GitOpsResourceResult `json:"resources"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+// DBPoolHealth reports reachability and connection pool usage of one database
+type DBPoolHealth struct {
+	Status         string  `json:"status"` // ok, degraded (pool nearly exhausted) or down
+	Error          string  `json:"error,omitempty"`
+	MaxOpen        int     `json:"maxOpen"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"inUse"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"waitCount"`      // total waits for a free connection
+	WaitDurationMs int64   `json:"waitDurationMs"` // total time spent waiting
+	Saturation     float64 `json:"saturation"`     // inUse / maxOpen
+}
+
+// DatabaseHealth covers the primary and, when configured, the read replica
+type DatabaseHealth struct {
+	Primary DBPoolHealth  `json:"primary"`
+	Replica *DBPoolHealth `json:"replica,omitempty"`
+}
+
+// HealthzResponse is the detailed readiness report served at /healthz
+type HealthzResponse struct {
+	Status   string         `json:"status"` // worst status of its components
+	Database DatabaseHealth `json:"database"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+// ImageDeployRequest reports an image external CI pushed for a service, to be rolled out
+// without a build
+type ImageDeployRequest struct {
+	ServiceID string `json:"serviceId" binding:"required"` // ID of the service to deploy
+	APIKey    string `json:"apiKey" binding:"required"`    // API Key for authentication
+	// Image pushed to the service's repository of the platform registry,
+	// <registry>/<serviceId>[:tag][@digest]
+	Image string `json:"image" binding:"required"`
+	// Digest of the pushed manifest (sha256:...); may instead be part of Image
+	Digest        string `json:"digest"`
+	CommitID      string `json:"commitId"`      // Git commit the image was built from, if any
+	CommitMessage string `json:"commitMessage"` // Optional commit message shown with the deployment
+	CallbackUrl   string `json:"callbackUrl"`   // Optional webhook URL to call on deployment success/failure
+	ByAdmin       bool   `json:"-"`             // set from the caller's role; admins deploy through deploy locks
+}
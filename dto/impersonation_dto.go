@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// ImpersonationRequest starts acting as another user
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=500"` // e.g. the support ticket, recorded in the audit trail
+}
+
+// ImpersonationResponse carries the session token of an impersonation. Requests made with
+// it act as the user and carry the X-Impersonated-By response header.
+type ImpersonationResponse struct {
+	SessionID string      `json:"sessionId"`
+	Token     string      `json:"token"`
+	User      models.User `json:"user"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// ImpersonationAuditListResponse is a page of the impersonation audit trail
+type ImpersonationAuditListResponse struct {
+	Entries    []models.ImpersonationAuditLog `json:"entries"`
+	TotalCount int64                          `json:"totalCount"`
+	Page       int                            `json:"page"`
+	PageSize   int                            `json:"pageSize"`
+}
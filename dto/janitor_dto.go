@@ -0,0 +1,11 @@
+package dto
+
+// JanitorReport lists what a janitor run removed
+type JanitorReport struct {
+	DeletedJobs       []string `json:"deletedJobs"`
+	DeletedPods       []string `json:"deletedPods"`
+	DeletedSecrets    []string `json:"deletedSecrets"`    // namespace/name
+	DeletedSessions   int64    `json:"deletedSessions"`   // user sessions expired for over a week
+	DeletedShareLinks int64    `json:"deletedShareLinks"` // share links expired for over a week
+	Errors            []string `json:"errors,omitempty"`
+}
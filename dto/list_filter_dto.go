@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// ServiceFilter represents filter criteria for services
+type ServiceFilter struct {
+	Search        string
+	Status        string
+	Type          string
+	EnvironmentID string
+	ProjectID     string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	SortBy        string
+	SortOrder     string
+	Page          int
+	PageSize      int
+}
+
+// ServiceListResponse represents paginated service list response
+type ServiceListResponse struct {
+	Services   []models.Service `json:"services"`
+	TotalCount int64            `json:"totalCount"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"pageSize"`
+	TotalPages int              `json:"totalPages"`
+}
+
+// DeploymentFilter represents filter criteria for a service's deployments
+type DeploymentFilter struct {
+	Status      string
+	ImageDigest string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string
+	SortOrder   string
+	Page        int
+	PageSize    int
+}
+
+// DeploymentListResponse represents paginated deployment list response
+type DeploymentListResponse struct {
+	Deployments []DeploymentResponse `json:"deployments"`
+	TotalCount  int64                `json:"totalCount"`
+	Page        int                  `json:"page"`
+	PageSize    int                  `json:"pageSize"`
+	TotalPages  int                  `json:"totalPages"`
+}
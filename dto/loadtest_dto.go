@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// LoadTestRequest starts a load test against a service's internal URL
+type LoadTestRequest struct {
+	Rate            int    `json:"rate" binding:"omitempty,min=1,max=1000"`           // requests per second, default 50
+	DurationSeconds int    `json:"durationSeconds" binding:"omitempty,min=5,max=300"` // default 30
+	Path            string `json:"path"`                                              // default "/"
+}
+
+// LoadTestResponse is a load test compared with the latest completed test of the previous
+// deployment at the same rate
+type LoadTestResponse struct {
+	models.LoadTest
+	Baseline         *models.LoadTest `json:"baseline"`
+	P95ChangePercent *float64         `json:"p95ChangePercent"` // relative to the baseline
+	Regressed        bool             `json:"regressed"`        // p95 grew past the threshold or success ratio dropped
+}
+
+// LoadTestListResponse is a page of a service's load tests
+type LoadTestListResponse struct {
+	LoadTests  []LoadTestResponse `json:"loadTests"`
+	TotalCount int64              `json:"totalCount"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"pageSize"`
+}
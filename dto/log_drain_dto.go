@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// LogDrainRequest registers an external sink for the project's service logs
+type LogDrainRequest struct {
+	Name     string `json:"name" binding:"required"`                          // DNS label, unique per project
+	Type     string `json:"type" binding:"required,oneof=loki datadog https"` // sink protocol
+	Endpoint string `json:"endpoint" binding:"required"`                      // push URL, e.g. https://loki.example.com/loki/api/v1/push
+	Username string `json:"username"`                                         // Loki basic auth user (e.g. Grafana Cloud tenant)
+	Token    string `json:"token"`                                            // API key or bearer token, never returned
+	Enabled  *bool  `json:"enabled"`                                          // default true
+}
+
+// LogDrainUpdateRequest changes a log drain; empty fields are left unchanged
+type LogDrainUpdateRequest struct {
+	Endpoint string  `json:"endpoint"`
+	Username *string `json:"username"`
+	Token    *string `json:"token"` // empty string removes the token
+	Enabled  *bool   `json:"enabled"`
+}
+
+// LogDrainResponse is a log drain with its delivery health
+type LogDrainResponse struct {
+	models.LogDrain
+	Status   string `json:"status"` // pending, healthy, failing or disabled
+	HasToken bool   `json:"hasToken"`
+}
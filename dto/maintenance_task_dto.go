@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// MaintenanceTaskRequest creates a recurring maintenance Job of an environment
+type MaintenanceTaskRequest struct {
+	Name           string         `json:"name" binding:"required"`
+	Schedule       string         `json:"schedule" binding:"required"`  // 5-field cron in UTC
+	ServiceID      string         `json:"serviceId" binding:"required"` // git service of the environment whose image runs the task
+	Command        string         `json:"command" binding:"required"`   // run with sh -c
+	InheritEnv     bool           `json:"inheritEnv"`
+	EnvVars        models.EnvVars `json:"envVars"`
+	CPULimit       string         `json:"cpuLimit"`       // default 500m
+	MemoryLimit    string         `json:"memoryLimit"`    // default 512Mi
+	TimeoutMinutes int            `json:"timeoutMinutes"` // default 30
+	Suspended      bool           `json:"suspended"`
+}
+
+// MaintenanceTaskUpdateRequest changes the fields of a maintenance task that are set
+type MaintenanceTaskUpdateRequest struct {
+	Schedule       *string         `json:"schedule"`
+	ServiceID      *string         `json:"serviceId"`
+	Command        *string         `json:"command"`
+	InheritEnv     *bool           `json:"inheritEnv"`
+	EnvVars        *models.EnvVars `json:"envVars"`
+	CPULimit       *string         `json:"cpuLimit"`
+	MemoryLimit    *string         `json:"memoryLimit"`
+	TimeoutMinutes *int            `json:"timeoutMinutes"`
+	Suspended      *bool           `json:"suspended"`
+}
+
+// MaintenanceTaskResponse is a maintenance task with its next scheduled run and last run
+type MaintenanceTaskResponse struct {
+	models.MaintenanceTask
+	ServiceName string                     `json:"serviceName"`
+	NextRunAt   *time.Time                 `json:"nextRunAt"` // nil while suspended
+	LastRun     *models.MaintenanceTaskRun `json:"lastRun"`
+}
+
+// MaintenanceTaskRunListResponse is a page of a maintenance task's runs
+type MaintenanceTaskRunListResponse struct {
+	Runs       []models.MaintenanceTaskRun `json:"runs"`
+	TotalCount int64                       `json:"totalCount"`
+	Page       int                         `json:"page"`
+	PageSize   int                         `json:"pageSize"`
+}
+
+// MaintenanceTaskRunLogsResponse is the kept end of the output of a finished run
+type MaintenanceTaskRunLogsResponse struct {
+	RunID  string `json:"runId"`
+	Status string `json:"status"`
+	Logs   string `json:"logs"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+// ManagedServiceCatalog describes the managed service types that can be created
+type ManagedServiceCatalog struct {
+	Types            []ManagedServiceCatalogEntry `json:"types"`
+	DefaultResources ManagedServiceResources      `json:"defaultResources"` // used when the environment defines no presets
+	ExternalAccess   ManagedServicePortRange      `json:"externalAccess"`
+}
+
+// ManagedServiceCatalogEntry describes one managed service type
+type ManagedServiceCatalogEntry struct {
+	Type            string                   `json:"type"` // postgresql, redis, minio, etc.
+	DefaultVersion  string                   `json:"defaultVersion"`
+	Versions        []string                 `json:"versions"`
+	Port            int                      `json:"port"`
+	RequiresStorage bool                     `json:"requiresStorage"`
+	Workload        string                   `json:"workload"`   // StatefulSet or Deployment
+	Topologies      []string                 `json:"topologies"` // besides standalone, e.g. sentinel
+	SupportsTLS     bool                     `json:"supportsTls"`
+	SupportsPooling bool                     `json:"supportsPooling"`
+	Endpoints       []ManagedServiceEndpoint `json:"endpoints"`
+	// Range the external ports of this type are allocated from
+	ExternalPortStart int `json:"externalPortStart"`
+	ExternalPortEnd   int `json:"externalPortEnd"`
+}
+
+// ManagedServiceEndpoint describes a port of a managed service and how it is exposed
+type ManagedServiceEndpoint struct {
+	Name        string `json:"name"` // primary, console, management, etc.
+	Port        int    `json:"port"`
+	IsHTTP      bool   `json:"isHttp"`
+	Description string `json:"description"`
+	Exposure    string `json:"exposure"` // TCPProxy or Ingress
+}
+
+// ManagedServiceResources holds resource presets of a managed service
+type ManagedServiceResources struct {
+	CPULimit    string `json:"cpuLimit"`
+	MemoryLimit string `json:"memoryLimit"`
+	StorageSize string `json:"storageSize"`
+}
+
+// ManagedServicePortRange is the TCP proxy host and the range external ports are allocated from
+type ManagedServicePortRange struct {
+	Host      string `json:"host"`
+	PortStart int    `json:"portStart"`
+	PortEnd   int    `json:"portEnd"`
+}
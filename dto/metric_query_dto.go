@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+// MetricQueryRequest aggregates a metric of the stored usage samples, uptime checks,
+// deployments or incidents over time, for dashboard widgets. GET /metrics/catalog lists
+// the sources with their metrics and dimensions.
+type MetricQueryRequest struct {
+	Source        string    `json:"source" binding:"required"`
+	Metric        string    `json:"metric" binding:"required"`
+	Aggregation   string    `json:"aggregation" binding:"omitempty,oneof=avg min max sum count p95"` // default avg
+	GroupBy       []string  `json:"groupBy"`                                                         // dimensions, e.g. ["service"]
+	Bucket        string    `json:"bucket"`                                                          // e.g. 5m, 1h, 1d; empty aggregates the whole range
+	From          time.Time `json:"from"`                                                            // default 24 hours before to
+	To            time.Time `json:"to"`                                                              // default now
+	ProjectID     string    `json:"projectId"`                                                       // limit to a project; default all projects of the caller
+	EnvironmentID string    `json:"environmentId"`
+	ServiceIDs    []string  `json:"serviceIds"`
+}
+
+// MetricPoint is the aggregated value of a time bucket
+type MetricPoint struct {
+	Time  time.Time `json:"time"` // start of the bucket, or of the range without buckets
+	Value float64   `json:"value"`
+}
+
+// MetricSeries is the points of one combination of the grouped dimensions
+type MetricSeries struct {
+	Group  map[string]string `json:"group"`  // dimension → value, e.g. service → ID
+	Labels map[string]string `json:"labels"` // dimension → display name, e.g. service → name
+	Points []MetricPoint     `json:"points"` // oldest first; buckets without data are left out
+}
+
+// MetricQueryResponse is the result of a metric query
+type MetricQueryResponse struct {
+	Source      string         `json:"source"`
+	Metric      string         `json:"metric"`
+	Unit        string         `json:"unit"`
+	Aggregation string         `json:"aggregation"`
+	Bucket      string         `json:"bucket"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Series      []MetricSeries `json:"series"`
+	Truncated   bool           `json:"truncated"` // more rows matched than are returned; narrow the query
+}
+
+// MetricInfo describes a queryable metric
+type MetricInfo struct {
+	Name        string `json:"name"`
+	Unit        string `json:"unit"` // millicores, bytes, ms, seconds, ratio or count
+	Description string `json:"description"`
+}
+
+// MetricSourceInfo describes a queryable source of samples or events
+type MetricSourceInfo struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Metrics     []MetricInfo `json:"metrics"`
+	Dimensions  []string     `json:"dimensions"` // groupBy values
+}
+
+// MetricCatalog lists what the metric query API can aggregate
+type MetricCatalog struct {
+	Sources      []MetricSourceInfo `json:"sources"`
+	Aggregations []string           `json:"aggregations"`
+	Buckets      []string           `json:"buckets"`
+	MaxGroupBy   int                `json:"maxGroupBy"`
+	MaxRangeDays int                `json:"maxRangeDays"`
+	MaxPoints    int                `json:"maxPoints"` // rows returned by one query
+}
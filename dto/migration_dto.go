@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// MigrationStatus describes one versioned schema migration
+type MigrationStatus struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+	Unknown     bool       `json:"unknown,omitempty"` // applied in the database but not defined in this build
+}
+
+// MigrationReport summarizes the schema version of the database
+type MigrationReport struct {
+	Current    string            `json:"current"` // last applied migration ID
+	Pending    int               `json:"pending"`
+	Migrations []MigrationStatus `json:"migrations"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+// MinIOLifecycleRule expires objects under Prefix after ExpireDays
+type MinIOLifecycleRule struct {
+	Prefix     string `json:"prefix"`
+	ExpireDays int    `json:"expireDays" binding:"required,min=1"`
+}
+
+// MinIOBucketRequest creates a bucket on a managed MinIO instance.
+// Policy is the anonymous access policy: none, download, upload or public.
+type MinIOBucketRequest struct {
+	Name              string               `json:"name" binding:"required"`
+	Policy            string               `json:"policy"`
+	Versioning        bool                 `json:"versioning"`
+	LifecycleRules    []MinIOLifecycleRule `json:"lifecycleRules" binding:"dive"`
+	CreateCredentials bool                 `json:"createCredentials"`
+}
+
+// MinIOLifecycleRequest replaces all lifecycle rules of a bucket
+type MinIOLifecycleRequest struct {
+	Rules []MinIOLifecycleRule `json:"rules" binding:"dive"`
+}
+
+// MinIOBucketCredentials are bucket-scoped keys for consuming services
+type MinIOBucketCredentials struct {
+	AccessKey        string `json:"accessKey"`
+	SecretKey        string `json:"secretKey"`
+	Bucket           string `json:"bucket"`
+	Endpoint         string `json:"endpoint"`
+	ExternalEndpoint string `json:"externalEndpoint"`
+	SecretName       string `json:"secretName"`
+}
+
+// MinIOBucketResponse describes a bucket
+type MinIOBucketResponse struct {
+	Name           string                  `json:"name"`
+	Policy         string                  `json:"policy,omitempty"`
+	Versioning     bool                    `json:"versioning"`
+	LifecycleRules []MinIOLifecycleRule    `json:"lifecycleRules,omitempty"`
+	Credentials    *MinIOBucketCredentials `json:"credentials,omitempty"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // NodeResource represents a Kubernetes node resource (CPU, Memory, Storage)
 type NodeResource struct {
 	Capacity    string  `json:"capacity"`
@@ -37,3 +39,43 @@ type NodeStats struct {
 type NodeStatsResponse struct {
 	Nodes []NodeStats `json:"nodes"`
 }
+
+// NodeCapacity represents the schedulable resources of a single Ready node
+// CPU values are in milliCores, memory values are in bytes
+type NodeCapacity struct {
+	Name              string `json:"name"`
+	AllocatableCPU    int64  `json:"allocatableCpu"`
+	AllocatableMemory int64  `json:"allocatableMemory"`
+	RequestedCPU      int64  `json:"requestedCpu"`
+	RequestedMemory   int64  `json:"requestedMemory"`
+}
+
+// PlacementCheckResult describes whether a service's resources can be scheduled
+type PlacementCheckResult struct {
+	Schedulable bool     `json:"schedulable"`
+	Reason      string   `json:"reason,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// NodeActionRequest is a maintenance action on a node
+type NodeActionRequest struct {
+	Action             string `json:"action" binding:"required,oneof=cordon uncordon drain"`
+	Force              bool   `json:"force"`                                             // drain: also evict pods no controller recreates
+	DeleteEmptyDirData bool   `json:"deleteEmptyDirData"`                                // drain: evict pods with emptyDir volumes, losing their data
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds" binding:"omitempty,min=0"`      // drain: overrides the pods' termination grace period
+	TimeoutSeconds     int    `json:"timeoutSeconds" binding:"omitempty,min=1,max=3600"` // drain: defaults to 600
+}
+
+// NodeOperation reports the progress of a maintenance action on a node
+type NodeOperation struct {
+	Node        string     `json:"node"`
+	Action      string     `json:"action"`
+	Status      string     `json:"status"` // running, succeeded or failed
+	Message     string     `json:"message,omitempty"`
+	PodsTotal   int        `json:"podsTotal"`
+	PodsEvicted int        `json:"podsEvicted"`
+	PendingPods []string   `json:"pendingPods,omitempty"` // namespace/name of pods still on the node
+	SkippedPods []string   `json:"skippedPods,omitempty"` // DaemonSet and mirror pods left in place
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// SMTPSettingsUpdateRequest changes the mail server notification emails are sent through.
+// Omitted fields keep their current value.
+type SMTPSettingsUpdateRequest struct {
+	Host        *string `json:"host"`
+	Port        *int    `json:"port" binding:"omitempty,min=1,max=65535"`
+	Security    *string `json:"security" binding:"omitempty,oneof=starttls tls none"`
+	Username    *string `json:"username"`
+	Password    *string `json:"password"` // never returned; empty string removes it
+	FromAddress *string `json:"fromAddress" binding:"omitempty,email"`
+}
+
+// SMTPSettingsResponse is the SMTP configuration without its password
+type SMTPSettingsResponse struct {
+	models.SMTPSettings
+	Configured  bool `json:"configured"`
+	HasPassword bool `json:"hasPassword"`
+}
+
+// SMTPTestRequest sends a test email with the saved SMTP settings
+type SMTPTestRequest struct {
+	To string `json:"to" binding:"required,email"`
+}
+
+// DigestSubscriptionRequest subscribes an email address to a project's digest
+type DigestSubscriptionRequest struct {
+	Email     string `json:"email" binding:"required,email"`
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekly"`
+	Enabled   *bool  `json:"enabled"` // default true
+}
+
+// DigestSubscriptionUpdateRequest changes a digest subscription; omitted fields are left unchanged
+type DigestSubscriptionUpdateRequest struct {
+	Frequency string `json:"frequency" binding:"omitempty,oneof=daily weekly"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+// ProjectDigest summarizes a project's deployments, failures and resource warnings over a period
+type ProjectDigest struct {
+	ProjectID   string    `json:"projectId"`
+	ProjectName string    `json:"projectName"`
+	Frequency   string    `json:"frequency"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+
+	Deployments           int `json:"deployments"`
+	SuccessfulDeployments int `json:"successfulDeployments"`
+	FailedDeployments     int `json:"failedDeployments"`
+
+	Services []DigestServiceSummary `json:"services"`
+	Failures []DigestFailure        `json:"failures"`
+	Warnings []DigestWarning        `json:"warnings"`
+}
+
+// DigestServiceSummary counts the deployments of one service in a digest
+type DigestServiceSummary struct {
+	ServiceID   string `json:"serviceId"`
+	ServiceName string `json:"serviceName"`
+	Deployments int    `json:"deployments"`
+	Failed      int    `json:"failed"`
+}
+
+// DigestFailure is a failed deployment in a digest
+type DigestFailure struct {
+	DeploymentID string    `json:"deploymentId"`
+	ServiceName  string    `json:"serviceName"`
+	ErrorCode    string    `json:"errorCode"`
+	ErrorHint    string    `json:"errorHint"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// DigestWarning is a resource or configuration problem seen in a digest's period: a service
+// incident, or a warning recorded on a deployment
+type DigestWarning struct {
+	Kind        string    `json:"kind"` // incident, anomaly, image_size, port or port_check
+	ServiceName string    `json:"serviceName"`
+	Message     string    `json:"message"`
+	At          time.Time `json:"at"`
+}
@@ -0,0 +1,61 @@
+package dto
+
+// Offboarding decisions for an owned project
+const (
+	OffboardingTransfer = "transfer"
+	OffboardingDelete   = "delete"
+)
+
+// OffboardingProject is a project the user owns and must decide on before the account goes
+type OffboardingProject struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Environments int    `json:"environments"`
+	Services     int    `json:"services"`
+}
+
+// OffboardingPlan lists what deleting an account involves
+type OffboardingPlan struct {
+	UserID   string               `json:"userId"`
+	Email    string               `json:"email"`
+	Projects []OffboardingProject `json:"projects"` // each needs a transfer or delete decision
+	// AuditRetentionDays is how long audit records are kept; the user's older records are
+	// deleted and newer ones anonymized
+	AuditRetentionDays int `json:"auditRetentionDays"`
+}
+
+// OffboardingDecision says what becomes of an owned project
+type OffboardingDecision struct {
+	ProjectID  string `json:"projectId" binding:"required"`
+	Action     string `json:"action" binding:"required,oneof=transfer delete"`
+	TransferTo string `json:"transferTo,omitempty"` // email of the new owner, for transfer
+}
+
+// OffboardingRequest deletes an account once every owned project has a decision
+type OffboardingRequest struct {
+	Decisions []OffboardingDecision `json:"decisions" binding:"dive"`
+	// ConfirmEmail must repeat the account's email
+	ConfirmEmail string `json:"confirmEmail" binding:"required"`
+}
+
+// OffboardingNamespace is the teardown state of a namespace of a deleted project
+type OffboardingNamespace struct {
+	Namespace string `json:"namespace"`
+	ProjectID string `json:"projectId"`
+	State     string `json:"state"` // gone, terminating, skipped or failed
+	Error     string `json:"error,omitempty"`
+}
+
+// OffboardingResult reports an offboarding run. The account is only deleted once every
+// project is transferred or deleted; otherwise the request can be repeated.
+type OffboardingResult struct {
+	Transferred      []string               `json:"transferred"`
+	Deleted          []string               `json:"deleted"`
+	Namespaces       []OffboardingNamespace `json:"namespaces"`
+	RevokedSessions  int                    `json:"revokedSessions"`
+	RevokedAPITokens int64                  `json:"revokedApiTokens"`
+	AuditAnonymized  int64                  `json:"auditAnonymized"`
+	AuditDeleted     int64                  `json:"auditDeleted"`
+	AccountDeleted   bool                   `json:"accountDeleted"`
+	Errors           []string               `json:"errors,omitempty"`
+}
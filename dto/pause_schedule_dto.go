@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// PauseScheduleRequest creates or replaces a managed service pause schedule
+type PauseScheduleRequest struct {
+	PauseCron  string `json:"pauseCron" binding:"required"`  // e.g. "0 20 * * 1-5"
+	ResumeCron string `json:"resumeCron" binding:"required"` // e.g. "0 8 * * 1-5"
+	Timezone   string `json:"timezone"`                      // IANA name, defaults to UTC
+	Enabled    *bool  `json:"enabled"`                       // defaults to true
+}
+
+// PauseScheduleResponse is a schedule with its computed state
+type PauseScheduleResponse struct {
+	models.ServicePauseSchedule
+	DesiredState models.PauseState `json:"desiredState"`
+	NextPauseAt  *time.Time        `json:"nextPauseAt"`
+	NextResumeAt *time.Time        `json:"nextResumeAt"`
+}
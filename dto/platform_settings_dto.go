@@ -0,0 +1,44 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// PlatformSettingsUpdateRequest changes the admin-managed platform settings
+type PlatformSettingsUpdateRequest struct {
+	IngressProvider        string `json:"ingressProvider" binding:"required,oneof=traefik nginx gateway"`
+	MaxBuildTimeoutMinutes *int   `json:"maxBuildTimeoutMinutes" binding:"omitempty,min=1,max=1440"` // kept when omitted
+	// NodePortRange must match the API server's --service-node-port-range; kept when
+	// omitted, and an empty string restores SERVICE_NODE_PORT_RANGE
+	NodePortRange *string `json:"nodePortRange"`
+	// ManagedPortRanges replaces the external port ranges of managed service types; kept
+	// when omitted, and an empty object removes them all
+	ManagedPortRanges models.PortRanges `json:"managedPortRanges"`
+	// BuildBackend builds the images of services that do not select one: kaniko, buildkit,
+	// or external when BUILD_API_URL is set; kept when omitted
+	BuildBackend *string `json:"buildBackend"`
+	// DefaultDomain is the base domain of services whose project has not selected one; kept
+	// when omitted, and an empty string restores DEFAULT_DOMAIN
+	DefaultDomain *string `json:"defaultDomain"`
+	// PublicAddresses are the comma-separated IPs or hostnames hostnames must resolve to;
+	// kept when omitted, and an empty string restores INGRESS_PUBLIC_ADDRESSES
+	PublicAddresses *string `json:"publicAddresses"`
+	// CORSAllowedOrigins are the comma-separated origins the dashboard may call the API
+	// from; kept when omitted, and an empty string restores CORS_ALLOWED
+	CORSAllowedOrigins *string `json:"corsAllowedOrigins"`
+	// ClusterIssuer names a ready cert-manager ClusterIssuer; kept when omitted, and an empty
+	// string restores letsencrypt-prod
+	ClusterIssuer *string `json:"clusterIssuer"`
+	// BuildNamespace names an existing namespace for build jobs, used after the API
+	// restarts; kept when omitted, and an empty string restores BUILD_NAMESPACE
+	BuildNamespace *string `json:"buildNamespace"`
+}
+
+// IngressProviderSwitchResult reports re-rendering the platform's Ingresses and TCP exposure
+// for a new ingress provider, and the saved settings waiting for a restart
+type IngressProviderSwitchResult struct {
+	Switched    bool     `json:"switched"`    // false when the provider did not change
+	Ingresses   int      `json:"ingresses"`   // services and registries whose Ingresses were re-applied
+	TCPExposure bool     `json:"tcpExposure"` // the TCP ports of managed services were re-published
+	Errors      []string `json:"errors,omitempty"`
+	// RestartRequired names the saved settings that take effect when the API restarts
+	RestartRequired []string `json:"restartRequired,omitempty"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// PolicyRuleInfo describes a built-in policy rule and its current configuration
+type PolicyRuleInfo struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Mode        models.PolicyMode `json:"mode"`
+	Limit       int               `json:"limit,omitempty"`
+	UsesLimit   bool              `json:"usesLimit"`
+	UpdatedBy   string            `json:"updatedBy,omitempty"`
+	UpdatedAt   *time.Time        `json:"updatedAt,omitempty"`
+}
+
+// PolicyRuleUpdateRequest changes the mode (and threshold) of a policy rule
+type PolicyRuleUpdateRequest struct {
+	Mode  models.PolicyMode `json:"mode" binding:"required,oneof=disabled audit enforce"`
+	Limit *int              `json:"limit" binding:"omitempty,min=0"`
+}
+
+// PolicyViolationListResponse is a page of recorded policy violations
+type PolicyViolationListResponse struct {
+	Violations []models.PolicyViolation `json:"violations"`
+	TotalCount int64                    `json:"totalCount"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"pageSize"`
+}
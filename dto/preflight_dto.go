@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// Preflight check statuses
+const (
+	PreflightOK      = "ok"
+	PreflightWarning = "warning" // deploys work, but a feature depending on it does not
+	PreflightFailed  = "failed"  // deploys fail until it is fixed
+)
+
+// PreflightCheck is the result of one cluster prerequisite check
+type PreflightCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Remedy  string `json:"remedy,omitempty"` // what to do when the check did not pass
+}
+
+// PreflightReport tells whether the cluster has what the platform needs to deploy
+type PreflightReport struct {
+	Ready     bool             `json:"ready"` // no check failed
+	Checks    []PreflightCheck `json:"checks"`
+	CheckedAt time.Time        `json:"checkedAt"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// PriorityTierRequest creates or updates a scheduling priority tier
+type PriorityTierRequest struct {
+	Value                *int32 `json:"value" binding:"required"` // -1000000000 to 1000000000
+	Description          string `json:"description"`
+	PreemptLowerPriority *bool  `json:"preemptLowerPriority"` // defaults to true
+	Builds               bool   `json:"builds"`               // use this tier for image build jobs
+}
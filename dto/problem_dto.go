@@ -0,0 +1,19 @@
+package dto
+
+// FieldError describes a single invalid request field
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. "cpuLimit" or "git.port"
+	Message string `json:"message"`
+}
+
+// ProblemDetails is an RFC 7807 problem response (application/problem+json)
+type ProblemDetails struct {
+	Type          string       `json:"type"`
+	Title         string       `json:"title"`
+	Status        int          `json:"status"`
+	Detail        string       `json:"detail,omitempty"`
+	Instance      string       `json:"instance,omitempty"`
+	InvalidParams []FieldError `json:"invalidParams,omitempty"`
+	// Error mirrors Detail for clients that read the usual {"error": "..."} shape
+	Error string `json:"error"`
+}
@@ -53,6 +53,8 @@ type ProjectStatsResponse struct {
 		InProgress  int64   `json:"inProgress"`
 		SuccessRate float64 `json:"successRate"`
 	} `json:"deployments"`
+
+	Costs CostSummary `json:"costs"`
 }
 
 // ProjectEnvironmentItem represents an environment item in project statistics
@@ -88,12 +90,14 @@ type ProjectServiceStatsItem struct {
 type CreateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	BaseDomain  string `json:"baseDomain"` // one of GET /domains; empty = platform default
 }
 
 // UpdateProjectRequest represents the request payload for updating an existing project
 type UpdateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	BaseDomain  string `json:"baseDomain"` // applies to services created afterwards; empty keeps the current one
 }
 
 // ProjectResponse represents the standard response format for a project
@@ -102,6 +106,20 @@ type ProjectResponse struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	UserID      string    `json:"userId"`
+	BaseDomain  string    `json:"baseDomain"`
+	Status      string    `json:"status"` // active, or deleting while its namespaces are torn down
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
+
+// ProjectDeletionResponse reports the progress of deleting a project. Status is deleting
+// while namespaces are torn down and deleted once the project is gone; namespaces that
+// failed are retried by deleting the project again.
+type ProjectDeletionResponse struct {
+	ProjectID  string                            `json:"projectId"`
+	Status     string                            `json:"status"` // active, deleting or deleted
+	Total      int                               `json:"total"`
+	Deleted    int                               `json:"deleted"`
+	Failed     int                               `json:"failed"`
+	Namespaces []models.ProjectNamespaceDeletion `json:"namespaces"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// ProjectSecretRequest adds a value to a project's secrets vault
+type ProjectSecretRequest struct {
+	Name  string `json:"name" binding:"required"`  // referenced as ${secret:name}, e.g. db_password
+	Value string `json:"value" binding:"required"` // never returned
+}
+
+// ProjectSecretUpdateRequest replaces the value of a project secret
+type ProjectSecretUpdateRequest struct {
+	Value string `json:"value" binding:"required"`
+}
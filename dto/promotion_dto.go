@@ -0,0 +1,63 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// How an env var differs between two services
+const (
+	EnvVarDiffChanged = "changed"
+	EnvVarDiffOnlyInA = "only_in_a"
+	EnvVarDiffOnlyInB = "only_in_b"
+)
+
+// ConfigFieldDiff is a setting whose value differs between two services
+type ConfigFieldDiff struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+}
+
+// EnvVarDiff is an env var that differs between two services; values of secret env vars
+// are masked
+type EnvVarDiff struct {
+	Key    string `json:"key"`
+	Status string `json:"status"` // changed, only_in_a or only_in_b
+	A      string `json:"a,omitempty"`
+	B      string `json:"b,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// PromotionRequest promotes a successful deployment to the counterpart service, the service
+// with the same name, in another environment of the project
+type PromotionRequest struct {
+	DeploymentID        string `json:"deploymentId" binding:"required,uuid"`
+	TargetEnvironmentID string `json:"targetEnvironmentId" binding:"required,uuid"`
+	Note                string `json:"note" binding:"max=1000"`
+}
+
+// PromotionReviewRequest approves or rejects a promotion waiting for approval
+type PromotionReviewRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// PromotionPreview shows what a promotion would deploy and how the configuration of the
+// counterpart service differs; in the diffs a is the source and b the target service
+type PromotionPreview struct {
+	SourceDeployment DeploymentImageSummary `json:"sourceDeployment"`
+	SourceService    models.Service         `json:"sourceService"`
+	TargetService    models.Service         `json:"targetService"`
+	Image            string                 `json:"image"` // repository@digest deployed to the target
+	ApprovalRequired bool                   `json:"approvalRequired"`
+	ConfigDiff       []ConfigFieldDiff      `json:"configDiff"`
+	EnvVarDiff       []EnvVarDiff           `json:"envVarDiff"`
+	// Warnings point out differences the promoted image does not pick up, e.g. build-time
+	// env vars baked in with the source environment's values
+	Warnings []string `json:"warnings"`
+}
+
+// PromotionListResponse is a page of a project's promotions, newest first
+type PromotionListResponse struct {
+	Promotions []models.Promotion `json:"promotions"`
+	TotalCount int64              `json:"totalCount"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"pageSize"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// PullCredentialRequest registers a login for a private external registry
+type PullCredentialRequest struct {
+	Name          string `json:"name" binding:"required"`   // DNS label, unique per project
+	Server        string `json:"server" binding:"required"` // registry host, e.g. ghcr.io or registry.example.com:5000
+	Username      string `json:"username" binding:"required"`
+	Password      string `json:"password" binding:"required"` // password or access token, never returned
+	EnvironmentID string `json:"environmentId"`               // empty: every environment of the project
+}
+
+// PullCredentialUpdateRequest rotates the login of a pull credential
+type PullCredentialUpdateRequest struct {
+	Username string `json:"username"` // unchanged when empty
+	Password string `json:"password" binding:"required"`
+}
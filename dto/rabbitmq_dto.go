@@ -0,0 +1,32 @@
+package dto
+
+// RabbitMQVhostRequest creates a new virtual host
+type RabbitMQVhostRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RabbitMQUserRequest creates a user scoped to a single vhost.
+// Permission patterns default to ".*" (full access within the vhost).
+type RabbitMQUserRequest struct {
+	Username  string `json:"username" binding:"required"`
+	Vhost     string `json:"vhost" binding:"required"`
+	Configure string `json:"configure"`
+	Write     string `json:"write"`
+	Read      string `json:"read"`
+}
+
+// RabbitMQVhostResponse describes a vhost on the broker
+type RabbitMQVhostResponse struct {
+	Name     string `json:"name"`
+	Messages int    `json:"messages"`
+}
+
+// RabbitMQUserResponse describes a provisioned user and its connection strings
+type RabbitMQUserResponse struct {
+	Username    string `json:"username"`
+	Password    string `json:"password,omitempty"`
+	Vhost       string `json:"vhost"`
+	SecretName  string `json:"secretName"`
+	URL         string `json:"url"`
+	ExternalURL string `json:"externalUrl"`
+}
@@ -37,4 +37,32 @@ type ManifestLayer struct {
 
 type ManifestItem struct {
 	V1Compatibility string `json:"v1Compatibility"`
-}
\ No newline at end of file
+}
+// RegistryCopyRequest copies an image from one registry to another
+type RegistryCopyRequest struct {
+	TargetRegistryID string `json:"targetRegistryId" binding:"required"`
+	Repository       string `json:"repository" binding:"required"`
+	Tag              string `json:"tag" binding:"required"` // tag or sha256 digest
+	TargetRepository string `json:"targetRepository"`       // default: repository
+	TargetTag        string `json:"targetTag"`              // default: tag
+}
+
+// RegistryCopyProgress is a progress event of an image copy
+type RegistryCopyProgress struct {
+	Stage     string `json:"stage"` // blob, manifest
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Skipped   bool   `json:"skipped,omitempty"` // the blob already existed in the target
+	Completed int    `json:"completed"`         // blobs copied or skipped so far
+	Total     int    `json:"total"`             // blobs discovered so far
+}
+
+// RegistryCopyResult summarizes a completed image copy
+type RegistryCopyResult struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Digest       string `json:"digest"`
+	BlobsCopied  int    `json:"blobsCopied"`
+	BlobsSkipped int    `json:"blobsSkipped"`
+	BytesCopied  int64  `json:"bytesCopied"`
+}
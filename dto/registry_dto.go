@@ -18,14 +18,21 @@ type RegistryFilter struct {
 
 // RegistryResponse represents the response format for a registry
 type RegistryResponse struct {
-	ID        string             `json:"id"`
-	Name      string             `json:"name"`
-	URL       string             `json:"url"`
-	IsDefault bool               `json:"isDefault"`
-	IsActive  bool               `json:"isActive"`
-	Status    models.RegistryStatus `json:"status"`
-	CreatedAt time.Time          `json:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt"`
+	ID             string                        `json:"id"`
+	Name           string                        `json:"name"`
+	URL            string                        `json:"url"`
+	IsDefault      bool                          `json:"isDefault"`
+	IsActive       bool                          `json:"isActive"`
+	Status         models.RegistryStatus         `json:"status"`
+	Mode           models.RegistryMode           `json:"mode"`
+	ProxyRemoteURL string                        `json:"proxyRemoteUrl,omitempty"`
+	StorageBackend models.RegistryStorageBackend `json:"storageBackend"`
+	S3Endpoint     string                        `json:"s3Endpoint,omitempty"`
+	S3Bucket       string                        `json:"s3Bucket,omitempty"`
+	Replicas       int                           `json:"replicas"`
+	StorageSize    string                        `json:"storageSize"`
+	CreatedAt      time.Time                     `json:"createdAt"`
+	UpdatedAt      time.Time                     `json:"updatedAt"`
 }
 
 // RegistryListResponse represents paginated registry list response
@@ -41,12 +48,42 @@ type RegistryListResponse struct {
 type CreateRegistryRequest struct {
 	Name      string `json:"name" binding:"required"`
 	IsDefault bool   `json:"isDefault"`
+	// Mode is "standard" (default) or "proxy" for a Docker Hub pull-through cache
+	Mode           models.RegistryMode `json:"mode"`
+	ProxyRemoteURL string              `json:"proxyRemoteUrl"`
+	ProxyUsername  string              `json:"proxyUsername"`
+	ProxyPassword  string              `json:"proxyPassword"`
+	// StorageSize of the registry volume, 20Gi when empty
+	StorageSize string `json:"storageSize"`
 }
 
 // UpdateRegistryRequest represents the request payload for updating an existing registry
 type UpdateRegistryRequest struct {
 	Name      string `json:"name"`
 	IsDefault bool   `json:"isDefault"`
+	// Proxy settings only apply to proxy registries; empty values keep the current ones
+	ProxyRemoteURL string `json:"proxyRemoteUrl"`
+	ProxyUsername  string `json:"proxyUsername"`
+	ProxyPassword  string `json:"proxyPassword"`
+	// Replicas above one need the S3 storage backend; 0 keeps the current count
+	Replicas int `json:"replicas"`
+	// StorageSize expands the registry volume online; volumes cannot shrink
+	StorageSize string `json:"storageSize"`
+}
+
+// RegistryStorageRequest switches the storage backend of a registry. An S3 backend uses
+// either a managed MinIO service (a bucket and scoped credentials are created on it) or
+// an external endpoint with explicit credentials.
+type RegistryStorageRequest struct {
+	Backend   models.RegistryStorageBackend `json:"backend" binding:"required"`
+	ServiceID string                        `json:"serviceId"`
+	Endpoint  string                        `json:"endpoint"`
+	Region    string                        `json:"region"`
+	Bucket    string                        `json:"bucket"`
+	AccessKey string                        `json:"accessKey"`
+	SecretKey string                        `json:"secretKey"`
+	// Migrate copies the blobs on the registry volume into the bucket before switching
+	Migrate bool `json:"migrate"`
 }
 
 // RegistryCredentials holds the access information for a registry
@@ -81,3 +118,31 @@ type RegistryImagesResponse struct {
 	Page       int                 `json:"page"`
 	PageSize   int                 `json:"pageSize"`
 }
+
+// RegistryDependencyStatus reports whether a build system image is present in a registry
+type RegistryDependencyStatus struct {
+	Name        string `json:"name"`
+	SourceImage string `json:"sourceImage"`
+	Digest      string `json:"digest"` // the digest the source is pinned to; empty until pinned
+	TargetImage string `json:"targetImage"`
+	Description string `json:"description"`
+	Available   bool   `json:"available"`
+	// Outdated is set when the registry's copy was not built from the pinned digest; roll
+	// it out with POST /admin/dependency-images/rollout
+	Outdated    bool   `json:"outdated"`
+	BuiltDigest string `json:"builtDigest,omitempty"` // the pinned digest the copy was built from
+	// LastBuild is running, succeeded or failed while the build job is retained, empty otherwise
+	LastBuild    string `json:"lastBuild,omitempty"`
+	LastBuildJob string `json:"lastBuildJob,omitempty"`
+}
+
+// RegistryDependenciesResponse lists the dependency images of a registry
+type RegistryDependenciesResponse struct {
+	Dependencies []RegistryDependencyStatus `json:"dependencies"`
+	Missing      []string                   `json:"missing"`
+}
+
+// RegistryDependencyRerunResponse names the dependency images being rebuilt
+type RegistryDependencyRerunResponse struct {
+	Rebuilding []string `json:"rebuilding"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// ContainerTermination is how a container instance ended
+type ContainerTermination struct {
+	Reason     string    `json:"reason"` // e.g. Error, OOMKilled, Completed
+	ExitCode   int32     `json:"exitCode"`
+	Signal     int32     `json:"signal,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// ContainerRestartInfo is the restart history of one container of a pod
+type ContainerRestartInfo struct {
+	Name            string                `json:"name"`
+	Init            bool                  `json:"init"`
+	RestartCount    int32                 `json:"restartCount"`
+	Ready           bool                  `json:"ready"`
+	State           string                `json:"state"`                 // running, waiting or terminated
+	StateReason     string                `json:"stateReason,omitempty"` // e.g. CrashLoopBackOff
+	LastTermination *ContainerTermination `json:"lastTermination,omitempty"`
+	// Last lines of the previous container instance, when it was restarted
+	PreviousLogs      string `json:"previousLogs,omitempty"`
+	PreviousLogsError string `json:"previousLogsError,omitempty"`
+}
+
+// PodRestartHistory lists the restarts of a pod's containers
+type PodRestartHistory struct {
+	Name       string                 `json:"name"`
+	Phase      string                 `json:"phase"`
+	NodeName   string                 `json:"nodeName,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	Restarts   int32                  `json:"restarts"` // total over its containers
+	Containers []ContainerRestartInfo `json:"containers"`
+}
+
+// ServiceRestartHistory is the restart history of a service's current pods, for diagnosing
+// crash loops
+type ServiceRestartHistory struct {
+	ServiceID string              `json:"serviceId"`
+	Pods      []PodRestartHistory `json:"pods"`
+	CheckedAt time.Time           `json:"checkedAt"`
+}
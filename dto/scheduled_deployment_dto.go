@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ScheduledDeploymentRequest schedules a deployment of a git service. Without commitSha and
+// deploymentId the head of the service's branch is built when the time comes.
+type ScheduledDeploymentRequest struct {
+	ScheduledAt   string `json:"scheduledAt" binding:"required"` // RFC3339, or local time (2006-01-02T15:04) in timezone
+	Timezone      string `json:"timezone"`                       // IANA name, defaults to UTC
+	CommitSHA     string `json:"commitSha" binding:"omitempty,max=64"`
+	CommitMessage string `json:"commitMessage" binding:"max=500"`
+	DeploymentID  string `json:"deploymentId" binding:"omitempty,uuid"` // roll out the image of this earlier deployment instead of building
+}
+
+// ScheduledDeploymentResponse is a scheduled deployment with its time in the timezone it
+// was given in
+type ScheduledDeploymentResponse struct {
+	models.ScheduledDeployment
+	ScheduledAtLocal string `json:"scheduledAtLocal"` // RFC3339 with the timezone's offset
+}
+
+// ScheduledDeploymentListResponse is a page of a service's scheduled deployments
+type ScheduledDeploymentListResponse struct {
+	Items      []ScheduledDeploymentResponse `json:"items"`
+	TotalCount int64                         `json:"totalCount"`
+	Page       int                           `json:"page"`
+	PageSize   int                           `json:"pageSize"`
+}
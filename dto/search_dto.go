@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// SearchProjectResult is a project matching a search query
+type SearchProjectResult struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SearchServiceResult is a service matching a search query
+type SearchServiceResult struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Status        string `json:"status"`
+	ProjectID     string `json:"projectId"`
+	EnvironmentID string `json:"environmentId"`
+}
+
+// SearchDeploymentResult is a deployment whose commit matches a search query
+type SearchDeploymentResult struct {
+	ID            string    `json:"id"`
+	ServiceID     string    `json:"serviceId"`
+	ServiceName   string    `json:"serviceName"`
+	ProjectID     string    `json:"projectId"`
+	Status        string    `json:"status"`
+	CommitSHA     string    `json:"commitSha"`
+	CommitMessage string    `json:"commitMessage"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// SearchDomainResult is a service domain matching a search query
+type SearchDomainResult struct {
+	Domain      string `json:"domain"`
+	IsCustom    bool   `json:"isCustom"`
+	ServiceID   string `json:"serviceId"`
+	ServiceName string `json:"serviceName"`
+	ProjectID   string `json:"projectId"`
+}
+
+// SearchResponse groups search results by kind
+type SearchResponse struct {
+	Query       string                   `json:"query"`
+	Projects    []SearchProjectResult    `json:"projects"`
+	Services    []SearchServiceResult    `json:"services"`
+	Deployments []SearchDeploymentResult `json:"deployments"`
+	Domains     []SearchDomainResult     `json:"domains"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+// SecretStoreRequest connects an external secret manager to a project
+type SecretStoreRequest struct {
+	Name            string `json:"name" binding:"required"`                              // DNS label, unique per project; used in ${external:name/path#key}
+	Type            string `json:"type" binding:"required,oneof=vault aws"`              // vault or aws (Secrets Manager)
+	Address         string `json:"address"`                                              // Vault address, e.g. https://vault.example.com:8200
+	VaultNamespace  string `json:"vaultNamespace"`                                       // Vault Enterprise namespace
+	Token           string `json:"token"`                                                // Vault token, never returned
+	Region          string `json:"region"`                                               // AWS region, e.g. eu-west-1
+	AccessKeyID     string `json:"accessKeyId"`                                          // AWS access key with secretsmanager:GetSecretValue
+	SecretAccessKey string `json:"secretAccessKey"`                                      // never returned
+	RefreshMinutes  *int   `json:"refreshMinutes"`                                       // default 60, 0 only syncs on deploy
+	RestartPolicy   string `json:"restartPolicy" binding:"omitempty,oneof=none rolling"` // default none
+}
+
+// SecretStoreUpdateRequest changes a secret store; empty fields are left unchanged
+type SecretStoreUpdateRequest struct {
+	Address         string  `json:"address"`
+	VaultNamespace  *string `json:"vaultNamespace"`
+	Token           string  `json:"token"`
+	Region          string  `json:"region"`
+	AccessKeyID     string  `json:"accessKeyId"`
+	SecretAccessKey string  `json:"secretAccessKey"`
+	RefreshMinutes  *int    `json:"refreshMinutes"`
+	RestartPolicy   string  `json:"restartPolicy" binding:"omitempty,oneof=none rolling"`
+}
+
+// SecretStoreRefreshResponse reports a manual refresh of the services using a store
+type SecretStoreRefreshResponse struct {
+	Services  int `json:"services"`  // services referencing the store
+	Updated   int `json:"updated"`   // services whose env Secret changed
+	Restarted int `json:"restarted"` // services rolled because of the restart policy
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ServiceIncidentListResponse is a page of a service's detected incidents
+type ServiceIncidentListResponse struct {
+	Incidents  []models.ServiceIncident `json:"incidents"`
+	TotalCount int64                    `json:"totalCount"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"pageSize"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// ServiceEvent is a Kubernetes event about one of a service's objects
+type ServiceEvent struct {
+	Type      string    `json:"type"`   // Normal or Warning
+	Reason    string    `json:"reason"` // e.g. BackOff, FailedScheduling, Pulled
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"` // kind of the involved object
+	Name      string    `json:"name"`
+	Count     int32     `json:"count"`
+	Source    string    `json:"source,omitempty"` // component that reported the event
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// ServiceInspectionAuditListResponse is a page of the audit trail of admin service inspections
+type ServiceInspectionAuditListResponse struct {
+	Entries    []models.ServiceInspectionAuditLog `json:"entries"`
+	TotalCount int64                              `json:"totalCount"`
+	Page       int                                `json:"page"`
+	PageSize   int                                `json:"pageSize"`
+}
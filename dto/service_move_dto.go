@@ -0,0 +1,16 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ServiceMoveRequest moves a service to another environment of its project
+type ServiceMoveRequest struct {
+	EnvironmentID string `json:"environmentId" binding:"required"`
+}
+
+// ServiceMoveListResponse is a page of a service's moves
+type ServiceMoveListResponse struct {
+	Moves      []models.ServiceMove `json:"moves"`
+	TotalCount int64                `json:"totalCount"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"pageSize"`
+}
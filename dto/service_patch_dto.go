@@ -0,0 +1,73 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ServicePatchDocument holds the fields of a service a JSON merge patch may change, under
+// the names a GET returns them. Patches are applied to this document, so a field left out
+// keeps its value and a map key set to null is removed.
+type ServicePatchDocument struct {
+	// All services
+	Name                      string         `json:"name"`
+	CPULimit                  string         `json:"cpuLimit"`
+	MemoryLimit               string         `json:"memoryLimit"`
+	IsStaticReplica           bool           `json:"isStaticReplica"`
+	Replicas                  int            `json:"replicas"`
+	MinReplicas               int            `json:"minReplicas"`
+	MaxReplicas               int            `json:"maxReplicas"`
+	CustomDomain              string         `json:"customDomain"`
+	ServiceAccountAnnotations models.EnvVars `json:"serviceAccountAnnotations"`
+	PodLabels                 models.EnvVars `json:"podLabels"`
+	PodAnnotations            models.EnvVars `json:"podAnnotations"`
+
+	// Git services
+	EnvVars                       models.EnvVars `json:"envVars"`
+	Branch                        string         `json:"branch"`
+	Port                          int            `json:"port"`
+	BuildCommand                  string         `json:"buildCommand"`
+	StartCommand                  string         `json:"startCommand"`
+	DockerfilePath                string         `json:"dockerfilePath"`
+	BuildArgs                     models.EnvVars `json:"buildArgs"`
+	TestCommand                   string         `json:"testCommand"`
+	TestImage                     string         `json:"testImage"`
+	TLSChallenge                  string         `json:"tlsChallenge"`
+	ArtifactPath                  string         `json:"artifactPath"`
+	BuildPlatforms                string         `json:"buildPlatforms"` // comma-separated
+	BuildBackend                  string         `json:"buildBackend"`
+	BuildSecrets                  bool           `json:"buildSecrets"`
+	SecretEnvKeys                 string         `json:"secretEnvKeys"` // comma-separated
+	BuildEnvKeys                  string         `json:"buildEnvKeys"`  // comma-separated
+	HighAvailability              bool           `json:"highAvailability"`
+	TerminationGracePeriodSeconds int            `json:"terminationGracePeriodSeconds"`
+	PreStopCommand                string         `json:"preStopCommand"`
+	Command                       []string       `json:"command"`
+	Args                          []string       `json:"args"`
+	WorkingDir                    string         `json:"workingDir"`
+	AutoCorrectPort               bool           `json:"autoCorrectPort"`
+	CloneDepth                    int            `json:"cloneDepth"`
+	BuildTimeoutMinutes           int            `json:"buildTimeoutMinutes"`
+	MaxImageSize                  string         `json:"maxImageSize"`
+	ImageSizeBudgetAction         string         `json:"imageSizeBudgetAction"`
+	SparseCheckoutPaths           string         `json:"sparseCheckoutPaths"` // comma-separated
+
+	// Managed services
+	Version        string `json:"version"`
+	StorageSize    string `json:"storageSize"`
+	PoolingEnabled bool   `json:"poolingEnabled"`
+	PoolMode       string `json:"poolMode"`
+	PoolSize       int    `json:"poolSize"`
+	MaxClientConn  int    `json:"maxClientConn"`
+	VPAMode        string `json:"vpaMode"`
+	// Sources allowed through the TCP proxy, comma-separated
+	ExternalAllowedCIDRs string `json:"externalAllowedCidrs"`
+	DatabaseTLS          bool   `json:"databaseTls"`
+	AutoUpdate           string `json:"autoUpdate"`
+	MaintenanceWindow    string `json:"maintenanceWindow"`
+}
+
+// ServiceUpdatePlan tells what an update changed and how it is rolled out: none (stored
+// only), restart (the running image is rolled out with the new config) or rebuild
+type ServiceUpdatePlan struct {
+	Action        string   `json:"action"`
+	ChangedFields []string `json:"changedFields"`
+	RebuildFields []string `json:"rebuildFields,omitempty"` // changed fields that need a new image
+}
@@ -17,21 +17,87 @@ type ServiceRequest struct {
 	GitUsername   string             `json:"gitUsername"` // optional; defaults per-provider on clone
 	GitToken      string             `json:"gitToken"`    // PAT, required for private repos
 	Port          int                `json:"port"`
+	AutoCorrectPort bool             `json:"autoCorrectPort"` // follow the single port the Dockerfile EXPOSEs
 	BuildCommand  string             `json:"buildCommand"`
+	DockerfilePath string            `json:"dockerfilePath"` // relative to the repository root, e.g. docker/api.Dockerfile; empty = Dockerfile
+	BuildArgs     map[string]string  `json:"buildArgs"`      // fixed --build-arg values
 	StartCommand  string             `json:"startCommand"`
+	TestCommand   string             `json:"testCommand"` // runs on the checkout before the build; a failure fails the deployment
+	TestImage     string             `json:"testImage"`   // image the test command runs in, e.g. node:20-alpine
+	ArtifactPath  string             `json:"artifactPath"` // directory in the image to export as a build artifact
+	BuildPlatforms []string          `json:"buildPlatforms"` // linux/amd64, linux/arm64; several build a multi-arch image
+	BuildBackend  string             `json:"buildBackend"` // kaniko, buildkit or external; empty = the cluster's
+	BuildSecrets  bool               `json:"buildSecrets"` // mount the secret env vars as build secrets
+	CloneDepth    int                `json:"cloneDepth"`          // history depth cloned for builds; 0 = 1
+	BuildTimeoutMinutes int          `json:"buildTimeoutMinutes"` // how long a build may run; 0 = 12, capped by the platform settings
+	MaxImageSize  string             `json:"maxImageSize"`          // size budget of the pushed image, e.g. 500Mi; empty = none
+	ImageSizeBudgetAction string     `json:"imageSizeBudgetAction"` // warn (default) or fail when the image exceeds maxImageSize
+	SparseCheckoutPaths []string     `json:"sparseCheckoutPaths"` // directories to check out, e.g. apps/web; empty = all
 	
 	// Managed service specific fields (required only when Type is "managed")
 	ManagedType   string             `json:"managedType"` // postgresql, redis, minio, etc.
 	Version       string             `json:"version"`     // 14, 6.0, latest, etc.
 	StorageSize   string             `json:"storageSize"` // 1Gi, 10Gi, etc.
+	PoolingEnabled bool              `json:"poolingEnabled"` // PgBouncer, postgresql only
+	PoolMode      string             `json:"poolMode"`       // session, transaction, statement
+	PoolSize      int                `json:"poolSize"`
+	MaxClientConn int                `json:"maxClientConn"`
+	VPAMode       string             `json:"vpaMode"`        // recommend or auto; empty disables the VPA
+	ExternalAllowedCIDRs []string    `json:"externalAllowedCidrs"` // sources allowed through the TCP proxy, e.g. 203.0.113.0/24; empty = any
+	DatabaseTLS   bool               `json:"databaseTls"`    // TLS for external connections; postgresql, mysql and redis
+	Topology      string             `json:"topology"`       // sentinel (redis) for primary, replica and failover; empty = standalone; fixed after creation
+	AutoUpdate    string             `json:"autoUpdate"`     // pinned (default), patch or minor
+	MaintenanceWindow string         `json:"maintenanceWindow"` // cron in UTC opening a one-hour window for automatic updates, e.g. 0 3 * * 0
 	
 	// Common configuration fields
 	EnvVars       models.EnvVars     `json:"envVars"`
+	SecretEnvKeys []string           `json:"secretEnvKeys"` // env vars whose values are masked and injected from a Secret
+	BuildEnvKeys  []string           `json:"buildEnvKeys"`  // env vars the build depends on; changing them rebuilds the image
 	CPULimit      string             `json:"cpuLimit"`
 	MemoryLimit   string             `json:"memoryLimit"`
 	IsStaticReplica bool             `json:"isStaticReplica"`
 	Replicas      int                `json:"replicas"`
 	MinReplicas   int                `json:"minReplicas"`
 	MaxReplicas   int                `json:"maxReplicas"`
+	HighAvailability bool            `json:"highAvailability"` // spread replicas across nodes and zones
+	TerminationGracePeriodSeconds int `json:"terminationGracePeriodSeconds"` // time to drain after SIGTERM; 0 = 30
+	PreStopCommand string            `json:"preStopCommand"` // runs before SIGTERM, e.g. sleep 10
+	Command       []string           `json:"command"`    // replaces the image's ENTRYPOINT, e.g. ["node", "worker.js"]
+	Args          []string           `json:"args"`       // replaces the image's CMD
+	WorkingDir    string             `json:"workingDir"` // replaces the image's WORKDIR
 	CustomDomain  string             `json:"customDomain"`
-}
\ No newline at end of file
+	TLSChallenge  string             `json:"tlsChallenge"` // http01 (default) or dns01
+	DeletionProtected bool           `json:"deletionProtected"`
+	ServiceAccountAnnotations map[string]string `json:"serviceAccountAnnotations"` // e.g. cloud workload identity bindings
+	PodLabels      map[string]string `json:"podLabels"`      // e.g. team or Datadog tags
+	PodAnnotations map[string]string `json:"podAnnotations"` // e.g. prometheus.io/scrape
+}
+
+// DeletionProtectionRequest turns deletion protection of a service on or off
+type DeletionProtectionRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// InternalAliasRequest sets the internal alias of a service; empty removes it
+type InternalAliasRequest struct {
+	InternalAlias string `json:"internalAlias"`
+}
+
+// CachePolicyRequest replaces the HTTP cache policy of a service; no rules turns caching off
+type CachePolicyRequest struct {
+	Rules models.CacheRules `json:"rules"`
+}
+
+// ServiceContainersRequest replaces the companion containers and shared volumes of a service;
+// empty lists remove them
+type ServiceContainersRequest struct {
+	Containers    models.ServiceContainers `json:"containers"`
+	SharedVolumes models.SharedVolumes     `json:"sharedVolumes"`
+}
+
+// ServicePinRequest pins a service to one of its deployments; the latest successful one
+// when DeploymentID is empty
+type ServicePinRequest struct {
+	DeploymentID string `json:"deploymentId" binding:"omitempty,uuid"`
+	Reason       string `json:"reason" binding:"max=500"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ServiceRevisionListResponse is a page of a service's config revisions
+type ServiceRevisionListResponse struct {
+	Revisions  []models.ServiceRevision `json:"revisions"`
+	TotalCount int64                    `json:"totalCount"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"pageSize"`
+}
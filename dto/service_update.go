@@ -3,6 +3,7 @@ package dto
 
 import (
 	"fmt"
+	"strings"
 	"github.com/pendeploy-simple/models"
 )
 
@@ -17,6 +18,9 @@ type BaseServiceUpdateRequest struct {
 	MinReplicas   *int             `json:"minReplicas,omitempty"`
 	MaxReplicas   *int             `json:"maxReplicas,omitempty"`
 	CustomDomain  string           `json:"customDomain,omitempty"`
+	ServiceAccountAnnotations models.EnvVars `json:"serviceAccountAnnotations,omitempty"` // replaces all annotations when present
+	PodLabels      models.EnvVars `json:"podLabels,omitempty"`      // replaces all pod labels when present
+	PodAnnotations models.EnvVars `json:"podAnnotations,omitempty"` // replaces all pod annotations when present
 }
 
 // GitServiceUpdateRequest berisi field yang boleh diupdate untuk service bertipe git
@@ -25,7 +29,30 @@ type GitServiceUpdateRequest struct {
 	Branch        string           `json:"branch,omitempty"`
 	Port          *int             `json:"port,omitempty"`
 	BuildCommand  string           `json:"buildCommand,omitempty"`
+	DockerfilePath *string         `json:"dockerfilePath,omitempty"` // "" restores the default Dockerfile
+	BuildArgs     models.EnvVars   `json:"buildArgs,omitempty"`      // replaces all build args when present
 	StartCommand  string           `json:"startCommand,omitempty"`
+	TestCommand   *string          `json:"testCommand,omitempty"` // "" removes the test stage
+	TestImage     string           `json:"testImage,omitempty"`
+	TLSChallenge  string           `json:"tlsChallenge,omitempty"` // http01 or dns01
+	ArtifactPath  string           `json:"artifactPath,omitempty"`
+	BuildPlatforms []string        `json:"buildPlatforms,omitempty"` // replaces the target platforms when not empty
+	BuildBackend  *string          `json:"buildBackend,omitempty"`   // kaniko, buildkit or external; "" restores the cluster's
+	BuildSecrets  *bool            `json:"buildSecrets,omitempty"`   // mount the secret env vars as build secrets
+	SecretEnvKeys *[]string        `json:"secretEnvKeys,omitempty"`  // replaces the secret env vars when present; [] clears them
+	BuildEnvKeys  *[]string        `json:"buildEnvKeys,omitempty"`   // replaces the build-time env vars when present; [] clears them
+	CloneDepth    *int             `json:"cloneDepth,omitempty"`     // 0 restores the default depth of 1
+	BuildTimeoutMinutes *int       `json:"buildTimeoutMinutes,omitempty"` // 0 restores the default of 12 minutes
+	MaxImageSize  *string          `json:"maxImageSize,omitempty"`          // "" removes the image size budget
+	ImageSizeBudgetAction *string  `json:"imageSizeBudgetAction,omitempty"` // warn or fail; "" restores warn
+	SparseCheckoutPaths *[]string  `json:"sparseCheckoutPaths,omitempty"` // replaces the checked out directories when present; [] checks out all
+	HighAvailability *bool         `json:"highAvailability,omitempty"` // spread replicas across nodes and zones
+	TerminationGracePeriodSeconds *int `json:"terminationGracePeriodSeconds,omitempty"` // 0 restores the default of 30 seconds
+	PreStopCommand *string         `json:"preStopCommand,omitempty"` // "" removes the preStop hook
+	Command       *[]string        `json:"command,omitempty"`    // replaces the ENTRYPOINT override when present; [] restores the image's
+	Args          *[]string        `json:"args,omitempty"`       // replaces the CMD override when present; [] restores the image's
+	WorkingDir    *string          `json:"workingDir,omitempty"` // "" restores the image's WORKDIR
+	AutoCorrectPort *bool          `json:"autoCorrectPort,omitempty"`  // follow the single port the Dockerfile EXPOSEs
 }
 
 // ManagedServiceUpdateRequest berisi field yang boleh diupdate untuk service bertipe managed
@@ -33,6 +60,15 @@ type ManagedServiceUpdateRequest struct {
 	BaseServiceUpdateRequest
 	Version       string           `json:"version,omitempty"`
 	StorageSize   string           `json:"storageSize,omitempty"`
+	PoolingEnabled *bool           `json:"poolingEnabled,omitempty"` // PgBouncer, postgresql only
+	PoolMode      string           `json:"poolMode,omitempty"`
+	PoolSize      *int             `json:"poolSize,omitempty"`
+	MaxClientConn *int             `json:"maxClientConn,omitempty"`
+	VPAMode       *string          `json:"vpaMode,omitempty"` // recommend or auto; "" removes the VPA
+	ExternalAllowedCIDRs *[]string `json:"externalAllowedCidrs,omitempty"` // replaces the TCP proxy allowlist when present; [] allows any source
+	DatabaseTLS   *bool            `json:"databaseTls,omitempty"` // TLS for external connections; postgresql, mysql and redis
+	AutoUpdate    *string          `json:"autoUpdate,omitempty"`  // pinned, patch or minor
+	MaintenanceWindow *string      `json:"maintenanceWindow,omitempty"` // cron in UTC opening a one-hour update window; "" removes it
 }
 
 // ServiceUpdateRequest adalah wrapper untuk request update service
@@ -111,6 +147,18 @@ func (req *ServiceUpdateRequest) UpdateServiceModel(service *models.Service) {
 		service.CustomDomain = base.CustomDomain
 	}
 	
+	if base.ServiceAccountAnnotations != nil {
+		service.ServiceAccountAnnotations = base.ServiceAccountAnnotations
+	}
+	
+	if base.PodLabels != nil {
+		service.PodLabels = base.PodLabels
+	}
+	
+	if base.PodAnnotations != nil {
+		service.PodAnnotations = base.PodAnnotations
+	}
+	
 	// Update type-specific fields jika disediakan
 	if req.Type == "git" && req.Git != nil {
 		if req.Git.Branch != "" {
@@ -128,6 +176,98 @@ func (req *ServiceUpdateRequest) UpdateServiceModel(service *models.Service) {
 		if req.Git.StartCommand != "" {
 			service.StartCommand = req.Git.StartCommand
 		}
+		
+		if req.Git.DockerfilePath != nil {
+			service.DockerfilePath = *req.Git.DockerfilePath
+		}
+		
+		if req.Git.BuildArgs != nil {
+			service.BuildArgs = req.Git.BuildArgs
+		}
+		
+		if req.Git.TestCommand != nil {
+			service.TestCommand = *req.Git.TestCommand
+		}
+		
+		if req.Git.TestImage != "" {
+			service.TestImage = req.Git.TestImage
+		}
+		
+		if req.Git.TLSChallenge != "" {
+			service.TLSChallenge = req.Git.TLSChallenge
+		}
+		
+		if req.Git.ArtifactPath != "" {
+			service.ArtifactPath = req.Git.ArtifactPath
+		}
+		
+		if len(req.Git.BuildPlatforms) > 0 {
+			service.BuildPlatforms = strings.Join(req.Git.BuildPlatforms, ",")
+		}
+		
+		if req.Git.BuildBackend != nil {
+			service.BuildBackend = *req.Git.BuildBackend
+		}
+		
+		if req.Git.BuildSecrets != nil {
+			service.BuildSecrets = *req.Git.BuildSecrets
+		}
+		
+		if req.Git.SecretEnvKeys != nil {
+			service.SecretEnvKeys = strings.Join(*req.Git.SecretEnvKeys, ",")
+		}
+		
+		if req.Git.BuildEnvKeys != nil {
+			service.BuildEnvKeys = strings.Join(*req.Git.BuildEnvKeys, ",")
+		}
+		
+		if req.Git.CloneDepth != nil {
+			service.CloneDepth = *req.Git.CloneDepth
+		}
+		
+		if req.Git.BuildTimeoutMinutes != nil {
+			service.BuildTimeoutMinutes = *req.Git.BuildTimeoutMinutes
+		}
+		
+		if req.Git.MaxImageSize != nil {
+			service.MaxImageSize = *req.Git.MaxImageSize
+		}
+		
+		if req.Git.ImageSizeBudgetAction != nil {
+			service.ImageSizeBudgetAction = *req.Git.ImageSizeBudgetAction
+		}
+		
+		if req.Git.SparseCheckoutPaths != nil {
+			service.SparseCheckoutPaths = strings.Join(*req.Git.SparseCheckoutPaths, ",")
+		}
+		
+		if req.Git.HighAvailability != nil {
+			service.HighAvailability = *req.Git.HighAvailability
+		}
+		
+		if req.Git.TerminationGracePeriodSeconds != nil {
+			service.TerminationGracePeriodSeconds = *req.Git.TerminationGracePeriodSeconds
+		}
+		
+		if req.Git.PreStopCommand != nil {
+			service.PreStopCommand = *req.Git.PreStopCommand
+		}
+		
+		if req.Git.Command != nil {
+			service.Command = models.ContainerArgs(*req.Git.Command)
+		}
+		
+		if req.Git.Args != nil {
+			service.Args = models.ContainerArgs(*req.Git.Args)
+		}
+		
+		if req.Git.WorkingDir != nil {
+			service.WorkingDir = *req.Git.WorkingDir
+		}
+		
+		if req.Git.AutoCorrectPort != nil {
+			service.AutoCorrectPort = *req.Git.AutoCorrectPort
+		}
 	} else if req.Type == "managed" && req.Managed != nil {
 		if req.Managed.Version != "" {
 			service.Version = req.Managed.Version
@@ -136,6 +276,42 @@ func (req *ServiceUpdateRequest) UpdateServiceModel(service *models.Service) {
 		if req.Managed.StorageSize != "" {
 			service.StorageSize = req.Managed.StorageSize
 		}
+		
+		if req.Managed.PoolingEnabled != nil {
+			service.PoolingEnabled = *req.Managed.PoolingEnabled
+		}
+		
+		if req.Managed.PoolMode != "" {
+			service.PoolMode = req.Managed.PoolMode
+		}
+		
+		if req.Managed.PoolSize != nil {
+			service.PoolSize = *req.Managed.PoolSize
+		}
+		
+		if req.Managed.MaxClientConn != nil {
+			service.MaxClientConn = *req.Managed.MaxClientConn
+		}
+		
+		if req.Managed.VPAMode != nil {
+			service.VPAMode = *req.Managed.VPAMode
+		}
+		
+		if req.Managed.ExternalAllowedCIDRs != nil {
+			service.ExternalAllowedCIDRs = strings.Join(*req.Managed.ExternalAllowedCIDRs, ",")
+		}
+		
+		if req.Managed.DatabaseTLS != nil {
+			service.DatabaseTLS = *req.Managed.DatabaseTLS
+		}
+		
+		if req.Managed.AutoUpdate != nil {
+			service.AutoUpdate = *req.Managed.AutoUpdate
+		}
+		
+		if req.Managed.MaintenanceWindow != nil {
+			service.MaintenanceWindow = *req.Managed.MaintenanceWindow
+		}
 	}
 }
 
@@ -160,11 +336,6 @@ func (req *ServiceUpdateRequest) ValidateServiceUpdateRequest() error {
 		if req.Managed.EnvVars != nil && len(req.Managed.EnvVars) > 0 {
 			return fmt.Errorf("environment variables are auto-generated for managed services and cannot be modified")
 		}
-		
-		// Validate storage size format if provided
-		if req.Managed.StorageSize != "" && len(req.Managed.StorageSize) < 2 {
-			return fmt.Errorf("invalid storage size format")
-		}
 	}
 	
 	return nil
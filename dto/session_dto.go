@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// SessionInfo describes an active signed-in session
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	IPAddress  string    `json:"ipAddress"`
+	UserAgent  string    `json:"userAgent"`
+	Current    bool      `json:"current"` // the session making the request
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// ImpersonatorID is set on sessions an admin opened to act as the user
+	ImpersonatorID *string `json:"impersonatorId,omitempty"`
+}
+
+// SessionListResponse lists the credentials of a user that can reach the API: signed-in
+// sessions and API tokens
+type SessionListResponse struct {
+	Sessions  []SessionInfo     `json:"sessions"`
+	APITokens []models.APIToken `json:"apiTokens"`
+}
+
+// RevokeSessionsResponse reports what a revoke-all request revoked
+type RevokeSessionsResponse struct {
+	RevokedSessions  int   `json:"revokedSessions"`
+	RevokedAPITokens int64 `json:"revokedApiTokens"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// ShareLinkRequest creates a read-only preview link to an environment or one of its services
+type ShareLinkRequest struct {
+	ServiceID        string `json:"serviceId" binding:"omitempty,uuid"`                   // empty shares every service
+	Label            string `json:"label" binding:"max=200"`                              // e.g. the collaborator's name
+	ExpiresInMinutes int    `json:"expiresInMinutes" binding:"omitempty,min=5,max=10080"` // default: 60, at most 7 days
+}
+
+// ShareLinkResponse carries a new link. The URL and token are shown only once.
+type ShareLinkResponse struct {
+	Link  models.ShareLink `json:"link"`
+	Token string           `json:"token"`
+	URL   string           `json:"url"`
+}
+
+// SharedServiceStatus is the status of one service as seen through a share link
+type SharedServiceStatus struct {
+	Name string             `json:"name"`
+	Type models.ServiceType `json:"type"`
+	ServiceStatusSummary
+}
+
+// SharedView is what a share link shows: the status of the shared services. Their runtime
+// logs stream from /shared/{token}/services/{serviceId}/logs.
+type SharedView struct {
+	EnvironmentName string                `json:"environmentName"`
+	ExpiresAt       time.Time             `json:"expiresAt"`
+	Services        []SharedServiceStatus `json:"services"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// StorageExpansionRequest grows the data volumes of a managed service online
+type StorageExpansionRequest struct {
+	Size string `json:"size" binding:"required"` // new size of each volume, e.g. 20Gi
+}
+
+// StorageExpansionListResponse is a page of a service's storage expansions
+type StorageExpansionListResponse struct {
+	Expansions []models.StorageExpansion `json:"expansions"`
+	TotalCount int64                     `json:"totalCount"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"pageSize"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// TenantIsolationUpdateRequest turns the isolation of workload namespaces on or off
+type TenantIsolationUpdateRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// TenantIsolationReport is the conformance of every workload namespace to the isolation
+// policy
+type TenantIsolationReport struct {
+	Enabled             bool                             `json:"enabled"`
+	ProtectedNamespaces []string                         `json:"protectedNamespaces"`
+	ClusterCIDRs        []string                         `json:"clusterCidrs"` // pod and Service ranges workloads only reach by namespace
+	Namespaces          []TenantIsolationNamespaceStatus `json:"namespaces"`
+	Conformant          int                              `json:"conformant"`
+	NonConformant       int                              `json:"nonConformant"`
+	CheckedAt           time.Time                        `json:"checkedAt"`
+}
+
+// TenantIsolationNamespaceStatus is the isolation state of one environment's namespace
+type TenantIsolationNamespaceStatus struct {
+	Namespace     string `json:"namespace"` // the environment ID
+	Environment   string `json:"environment"`
+	ProjectID     string `json:"projectId"`
+	Exists        bool   `json:"exists"`        // namespaces are created with the first deployment
+	PolicyPresent bool   `json:"policyPresent"` // the NetworkPolicy exists
+	UpToDate      bool   `json:"upToDate"`      // and matches the current protected namespaces and CIDRs
+	Conformant    bool   `json:"conformant"`
+	Problem       string `json:"problem,omitempty"`
+}
+
+// TenantIsolationReconcileResult reports a pass applying the isolation policy
+type TenantIsolationReconcileResult struct {
+	Applied []string `json:"applied"` // namespaces
+	Removed []string `json:"removed"`
+	Errors  []string `json:"errors,omitempty"`
+}
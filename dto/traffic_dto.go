@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// ServiceTraffic summarizes the requests the ingress served for a service over a window,
+// read from the ingress access logs
+type ServiceTraffic struct {
+	ServiceID string    `json:"serviceId"`
+	Hostnames []string  `json:"hostnames"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+
+	Requests     int64   `json:"requests"`
+	ErrorRate    float64 `json:"errorRate"` // share of 5xx responses, 0-1
+	LatencyP50Ms float64 `json:"latencyP50Ms"`
+	LatencyP95Ms float64 `json:"latencyP95Ms"`
+	// Requests by status code ("200") and by class ("2xx")
+	StatusCodes   map[string]int64 `json:"statusCodes"`
+	StatusClasses map[string]int64 `json:"statusClasses"`
+	Methods       map[string]int64 `json:"methods"`
+	// TopPaths are the most requested paths, with numeric and UUID segments shown as :id
+	TopPaths []TrafficPath `json:"topPaths"`
+	// Timeline counts the requests per interval, oldest first
+	Timeline []TrafficBucket `json:"timeline"`
+
+	// AccessLogFound is false when the ingress logs held no JSON access log lines, usually
+	// because Traefik's access log is off or not in JSON format
+	AccessLogFound bool `json:"accessLogFound"`
+	// Truncated is set when the window held more log lines than are read per request; the
+	// figures then cover the oldest part of the window
+	Truncated bool `json:"truncated"`
+}
+
+// TrafficPath is the traffic of one request path
+type TrafficPath struct {
+	Path         string  `json:"path"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"` // 5xx responses
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+}
+
+// TrafficBucket is the traffic of one interval of the timeline
+type TrafficBucket struct {
+	Start    time.Time `json:"start"`
+	Requests int64     `json:"requests"`
+	Errors   int64     `json:"errors"` // 5xx responses
+}
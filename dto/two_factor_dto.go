@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// TwoFactorStatus describes the two-factor authentication of the signed-in user
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // by the auth policy; 2FA cannot be disabled
+	RecoveryCodesRemaining int64 `json:"recoveryCodesRemaining"`
+}
+
+// TwoFactorSetupResponse carries a new TOTP secret for the authenticator app. It is not
+// active until confirmed with /auth/2fa/enable.
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`          // base32, for manual entry
+	ProvisioningURI string `json:"provisioningUri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// TwoFactorCodeRequest confirms an action with a code from the authenticator app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorEnableResponse carries the recovery codes, shown once, and a new session token
+// that replaces a setup-only one
+type TwoFactorEnableResponse struct {
+	RecoveryCodes []string  `json:"recoveryCodes"`
+	Token         string    `json:"token"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// TwoFactorDisableRequest turns off 2FA; it needs the password and a current code or a
+// recovery code
+type TwoFactorDisableRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// RecoveryCodesResponse carries newly generated recovery codes, shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// AuthPolicyUpdateRequest changes the login protection policy. Omitted fields keep their
+// current value.
+type AuthPolicyUpdateRequest struct {
+	RequireTwoFactor      *string `json:"requireTwoFactor" binding:"omitempty,oneof=optional admins all"`
+	MaxFailedLogins       *int    `json:"maxFailedLogins" binding:"omitempty,min=0,max=100"`
+	LockoutMinutes        *int    `json:"lockoutMinutes" binding:"omitempty,min=1,max=10080"`
+	MaxFailedLoginsPerIP  *int    `json:"maxFailedLoginsPerIp" binding:"omitempty,min=0,max=10000"`
+	ThrottleWindowMinutes *int    `json:"throttleWindowMinutes" binding:"omitempty,min=1,max=1440"`
+}
+
+// LoginAttemptListResponse is a page of the login audit trail
+type LoginAttemptListResponse struct {
+	Attempts   []models.LoginAttempt `json:"attempts"`
+	TotalCount int64                 `json:"totalCount"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"pageSize"`
+}
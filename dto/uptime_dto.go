@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// UptimeMonitorRequest configures the uptime checks of a git service
+type UptimeMonitorRequest struct {
+	Path            string `json:"path"`                                                // default "/"
+	IntervalSeconds int    `json:"intervalSeconds" binding:"omitempty,min=30,max=3600"` // default 60
+	Enabled         *bool  `json:"enabled"`                                             // default true
+}
+
+// UptimeSummary reports the uptime of a service
+type UptimeSummary struct {
+	ServiceID   string                `json:"serviceId"`
+	ServiceName string                `json:"serviceName"`
+	Monitor     *models.UptimeMonitor `json:"monitor"`
+	Status      string                `json:"status"`    // up, down or unknown
+	Uptime24h   *float64              `json:"uptime24h"` // percentage; null without checks
+	Uptime7d    *float64              `json:"uptime7d"`
+	Uptime30d   *float64              `json:"uptime30d"`
+	Checks      []models.UptimeCheck  `json:"checks,omitempty"` // most recent first
+}
+
+// StatusPageRequest configures the public status page of a project
+type StatusPageRequest struct {
+	Slug    string `json:"slug" binding:"required"` // DNS label used in the public URL
+	Title   string `json:"title"`
+	Enabled *bool  `json:"enabled"` // default true
+}
+
+// IncidentRequest creates an incident annotation
+type IncidentRequest struct {
+	Title     string     `json:"title" binding:"required,max=200"`
+	Message   string     `json:"message"`
+	Status    string     `json:"status" binding:"omitempty,oneof=investigating identified monitoring resolved"` // default investigating
+	ServiceID string     `json:"serviceId"`                                                                     // optional affected service
+	StartedAt *time.Time `json:"startedAt"`                                                                     // default now
+}
+
+// IncidentUpdateRequest changes an incident; resolving it sets resolvedAt
+type IncidentUpdateRequest struct {
+	Title   string `json:"title" binding:"omitempty,max=200"`
+	Message string `json:"message"`
+	Status  string `json:"status" binding:"omitempty,oneof=investigating identified monitoring resolved"`
+}
+
+// PublicStatusService is a monitored service as shown on a public status page
+type PublicStatusService struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"` // up, down or unknown
+	Uptime24h *float64 `json:"uptime24h"`
+	Uptime7d  *float64 `json:"uptime7d"`
+	Uptime30d *float64 `json:"uptime30d"`
+}
+
+// PublicStatusIncident is an incident as shown on a public status page
+type PublicStatusIncident struct {
+	Title      string     `json:"title"`
+	Message    string     `json:"message"`
+	Status     string     `json:"status"`
+	Service    string     `json:"service,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+}
+
+// PublicStatusPage is the unauthenticated view of a project's status page
+type PublicStatusPage struct {
+	Title       string                 `json:"title"`
+	Status      string                 `json:"status"` // operational, degraded or unknown
+	Services    []PublicStatusService  `json:"services"`
+	Incidents   []PublicStatusIncident `json:"incidents"` // open and resolved in the last 14 days
+	GeneratedAt time.Time              `json:"generatedAt"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// UsageAnomalyListResponse is a page of a service's detected usage anomalies
+type UsageAnomalyListResponse struct {
+	Anomalies  []models.UsageAnomaly `json:"anomalies"`
+	TotalCount int64                 `json:"totalCount"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"pageSize"`
+}
+
+// UsageAnomalyPayload is the webhook body of a usage anomaly alert
+type UsageAnomalyPayload struct {
+	Event       string    `json:"event"`
+	ServiceID   string    `json:"serviceId"`
+	ServiceName string    `json:"serviceName"`
+	ProjectID   string    `json:"projectId"`
+	Kind        string    `json:"kind"`
+	Message     string    `json:"message"`
+	DetectedAt  time.Time `json:"detectedAt"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// PodUsageSample is the CPU and memory use of one pod as reported by the metrics API, with
+// the limits of its containers (zero when a container has none)
+type PodUsageSample struct {
+	Pod           string `json:"pod"`
+	CPUMillicores int64  `json:"cpuMillicores"`
+	MemoryBytes   int64  `json:"memoryBytes"`
+	CPULimit      int64  `json:"cpuLimitMillicores,omitempty"`
+	MemoryLimit   int64  `json:"memoryLimitBytes,omitempty"`
+}
+
+// ServiceUsageSnapshot is one sample of the live usage stream of a service
+type ServiceUsageSnapshot struct {
+	ServiceID     string           `json:"serviceId"`
+	Timestamp     time.Time        `json:"timestamp"` // when the metrics were scraped
+	CPUMillicores int64            `json:"cpuMillicores"`
+	MemoryBytes   int64            `json:"memoryBytes"`
+	Pods          []PodUsageSample `json:"pods"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/pendeploy-simple/models"
+
+// ServiceVersionUpdateListResponse is a page of a service's automatic version updates
+type ServiceVersionUpdateListResponse struct {
+	Updates    []models.ServiceVersionUpdate `json:"updates"`
+	TotalCount int64                         `json:"totalCount"`
+	Page       int                           `json:"page"`
+	PageSize   int                           `json:"pageSize"`
+}
@@ -7,7 +7,8 @@ import type {
   IngressStats, 
   CertificateStats,
   ClusterInfo,
-  PVCStats 
+  PVCStats,
+  CapacityForecast
 } from '~/types/stats';
 
 // Get API URL from environment
@@ -361,3 +362,39 @@ export async function getClusterInfo(request: Request): Promise<ClusterInfo> {
     throw new Response('Failed to fetch cluster information', { status: 500 });
   }
 }
+
+/**
+ * Fetch the cluster capacity forecast and right-sizing recommendations
+ * 
+ * @param {Request} request - Remix request with credentials
+ * @param {number} days - Optional history window in days
+ * @returns Capacity forecast data
+ */
+export async function getCapacityForecast(request: Request, days?: number): Promise<CapacityForecast> {
+  try {
+    const url = new URL(`${API_URL}/admin/capacity/forecast`);
+    if (days) {
+      url.searchParams.append('days', String(days));
+    }
+
+    const response = await fetch(url.toString(), {
+      method: 'GET',
+      credentials: 'include',
+      headers: {
+        'Cookie': request.headers.get('Cookie') || ''
+      }
+    });
+
+    if (!response.ok) {
+      await handleApiError(response, 'capacity forecast');
+    }
+
+    const { data } = await response.json();
+    return data;
+  } catch (error) {
+    if (error instanceof Response) {
+      throw error;
+    }
+    throw new Response('Failed to fetch capacity forecast', { status: 500 });
+  }
+}
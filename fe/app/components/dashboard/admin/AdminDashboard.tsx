@@ -7,7 +7,8 @@ import type {
   ServiceStats,
   IngressStats,
   CertificateStats,
-  PVCStats
+  PVCStats,
+  CapacityForecast
 } from '~/types/stats';
 import type { Registry } from '~/types/registry';
 
@@ -19,6 +20,7 @@ import NetworkingOverview from './NetworkingOverview';
 import SecurityOverview from './SecurityOverview';
 import RegistriesOverview from './RegistriesOverview';
 import PVCOverview from './PVCOverview';
+import CapacityOverview from './CapacityOverview';
 
 interface AdminDashboardProps {
   clusterInfo: ClusterInfo;
@@ -30,6 +32,7 @@ interface AdminDashboardProps {
   certificates: CertificateStats[];
   registries: Registry[];
   pvcs: PVCStats[];
+  capacityForecast: CapacityForecast;
 }
 
 export default function AdminDashboard({ 
@@ -41,7 +44,8 @@ export default function AdminDashboard({
   ingresses,
   certificates,
   registries,
-  pvcs
+  pvcs,
+  capacityForecast
 }: AdminDashboardProps) {
   // Define available tabs
   const tabs = [
@@ -50,6 +54,7 @@ export default function AdminDashboard({
     { id: 'workloads', name: 'Workloads' },
     { id: 'networking', name: 'Networking' },
     { id: 'storage', name: 'Storage' },
+    { id: 'capacity', name: 'Capacity' },
     { id: 'security', name: 'Security' },
     { id: 'registries', name: 'Registries' }
   ];
@@ -117,6 +122,10 @@ export default function AdminDashboard({
             <PVCOverview pvcs={pvcs} />
           )}
           
+          {currentTab === 'capacity' && (
+            <CapacityOverview forecast={capacityForecast} />
+          )}
+          
           {currentTab === 'security' && <SecurityOverview certificates={certificates} />}
           {currentTab === 'registries' && <RegistriesOverview initialRegistries={registries} />}
         </div>
@@ -0,0 +1,122 @@
+import type { CapacityForecast, ResourceForecast } from '~/types/stats';
+import { formatBytesConsistently, formatDate } from '~/utils/formatters';
+
+interface CapacityOverviewProps {
+  forecast: CapacityForecast;
+}
+
+// Format a forecast value: millicores as cores, everything else as bytes
+const formatAmount = (resource: ResourceForecast['resource'], value: number): string => {
+  if (resource === 'cpu') {
+    return `${(value / 1000).toFixed(2)} cores`;
+  }
+  return formatBytesConsistently(Math.round(value));
+};
+
+const resourceLabels: Record<ResourceForecast['resource'], string> = {
+  cpu: 'CPU',
+  memory: 'Memory',
+  storage: 'Storage'
+};
+
+// Color the exhaustion estimate by how soon it is
+const getExhaustionColor = (days?: number): string => {
+  if (days === undefined) return 'text-gray-500';
+  if (days <= 7) return 'text-red-600';
+  if (days <= 30) return 'text-yellow-600';
+  return 'text-green-600';
+};
+
+export default function CapacityOverview({ forecast }: CapacityOverviewProps) {
+  return (
+    <div className="space-y-8">
+      <div className="bg-white shadow rounded-lg p-6">
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-xl font-bold">Capacity Forecast</h2>
+          <p className="text-sm text-gray-500">
+            {forecast.samples} samples over the last {forecast.windowDays} days
+            {forecast.sampledFrom && ` (since ${formatDate(forecast.sampledFrom)})`}
+          </p>
+        </div>
+
+        <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-4">
+          {forecast.forecasts.map((item) => (
+            <div key={`${item.resource}-${item.basis}`} className="bg-gray-50 p-4 rounded-md">
+              <h3 className="text-sm font-medium text-gray-500">
+                {resourceLabels[item.resource]} {item.basis}
+              </h3>
+              <p className="mt-1 text-lg font-semibold">
+                {formatAmount(item.resource, item.current)} / {formatAmount(item.resource, item.capacity)}
+                <span className="ml-2 text-sm font-normal text-gray-500">{item.percentage}%</span>
+              </p>
+              <div className="mt-2 w-full bg-gray-200 rounded-full h-2">
+                <div
+                  className={`h-2 rounded-full ${item.percentage >= 85 ? 'bg-red-500' : item.percentage >= 70 ? 'bg-yellow-500' : 'bg-indigo-500'}`}
+                  style={{ width: `${Math.min(item.percentage, 100)}%` }}
+                />
+              </div>
+              {item.growthPerDay !== 0 && (
+                <p className="mt-2 text-sm text-gray-600">
+                  {item.growthPerDay > 0 ? '+' : '-'}{formatAmount(item.resource, Math.abs(item.growthPerDay))} per day
+                </p>
+              )}
+              <p className={`mt-1 text-sm font-medium ${getExhaustionColor(item.daysUntilExhausted)}`}>
+                {item.daysUntilExhausted !== undefined && item.exhaustedAt
+                  ? `Exhausted in ${item.daysUntilExhausted} days (${formatDate(item.exhaustedAt)})`
+                  : item.note}
+              </p>
+            </div>
+          ))}
+        </div>
+      </div>
+
+      <div className="bg-white shadow rounded-lg p-6">
+        <h2 className="text-xl font-bold mb-1">Right-sizing Recommendations</h2>
+        <p className="text-sm text-gray-500 mb-4">
+          Per-pod limits compared with the 95th percentile usage. Services that need more come first.
+        </p>
+
+        {forecast.recommendations.length === 0 ? (
+          <p className="text-sm text-gray-500">No service limits are far from their usage.</p>
+        ) : (
+          <div className="overflow-x-auto">
+            <table className="min-w-full divide-y divide-gray-200">
+              <thead className="bg-gray-50">
+                <tr>
+                  <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Service</th>
+                  <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Resource</th>
+                  <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">P95 / Peak</th>
+                  <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Limit</th>
+                  <th scope="col" className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Recommended</th>
+                </tr>
+              </thead>
+              <tbody className="bg-white divide-y divide-gray-200">
+                {forecast.recommendations.map((item) => (
+                  <tr key={`${item.serviceId}-${item.resource}`}>
+                    <td className="px-6 py-4 whitespace-nowrap text-sm">
+                      <a href={`/services/${item.serviceId}`} className="font-medium text-indigo-600 hover:text-indigo-900">
+                        {item.serviceName}
+                      </a>
+                      <span className="ml-2 text-gray-500">×{item.replicas}</span>
+                    </td>
+                    <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900">{resourceLabels[item.resource]}</td>
+                    <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900">
+                      {item.p95Usage} / {item.peakUsage}
+                      <span className="ml-2 text-gray-500">({item.utilization}%)</span>
+                    </td>
+                    <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900">{item.currentLimit}</td>
+                    <td className="px-6 py-4 whitespace-nowrap text-sm">
+                      <span className={`px-2 inline-flex text-xs leading-5 font-semibold rounded-full ${item.direction === 'grow' ? 'bg-red-100 text-red-800' : 'bg-green-100 text-green-800'}`}>
+                        {item.direction === 'grow' ? '↑' : '↓'} {item.recommendedLimit}
+                      </span>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
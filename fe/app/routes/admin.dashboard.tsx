@@ -9,7 +9,8 @@ import {
   getServiceStats,
   getIngressStats,
   getCertificateStats,
-  getPVCStats
+  getPVCStats,
+  getCapacityForecast
 } from '~/actions/stats.server';
 import { getRegistries } from '~/actions/registry.server';
 
@@ -25,7 +26,8 @@ export async function loader({ request }: LoaderFunctionArgs) {
       ingressesData,
       certificatesData,
       pvcStatsData,
-      registriesData
+      registriesData,
+      capacityForecast
     ] = await Promise.all([
       getClusterInfo(request),
       getNodeStats(request),
@@ -35,7 +37,8 @@ export async function loader({ request }: LoaderFunctionArgs) {
       getIngressStats(request),
       getCertificateStats(request),
       getPVCStats(request),
-      getRegistries({}, request)
+      getRegistries({}, request),
+      getCapacityForecast(request)
     ]);
     
     // Extract the actual data from the responses
@@ -58,7 +61,8 @@ export async function loader({ request }: LoaderFunctionArgs) {
       ingresses,
       registries,
       certificates,
-      pvcs
+      pvcs,
+      capacityForecast
     });
   } catch (error) {
     if (error instanceof Response) {
@@ -78,7 +82,8 @@ export default function AdminDashboardRoute() {
     ingresses,
     certificates,
     registries,
-    pvcs
+    pvcs,
+    capacityForecast
   } = useLoaderData<typeof loader>();
 
   return (
@@ -92,6 +97,7 @@ export default function AdminDashboardRoute() {
       certificates={certificates}
       registries={registries}
       pvcs={pvcs}
+      capacityForecast={capacityForecast}
     />
   );
 }
@@ -136,3 +136,38 @@ export interface PVCStats {
   phase: string;
   annotations?: string[];
 }
+
+export interface ResourceForecast {
+  resource: 'cpu' | 'memory' | 'storage';
+  basis: 'requests' | 'usage';
+  current: number; // millicores for cpu, bytes otherwise
+  capacity: number;
+  percentage: number;
+  growthPerDay: number;
+  daysUntilExhausted?: number;
+  exhaustedAt?: string;
+  note?: string;
+}
+
+export interface RightSizeRecommendation {
+  serviceId: string;
+  serviceName: string;
+  projectId: string;
+  environmentId: string;
+  resource: 'cpu' | 'memory';
+  direction: 'shrink' | 'grow';
+  currentLimit: string;
+  recommendedLimit: string;
+  p95Usage: string;
+  peakUsage: string;
+  utilization: number;
+  replicas: number;
+}
+
+export interface CapacityForecast {
+  windowDays: number;
+  samples: number;
+  sampledFrom?: string;
+  forecasts: ResourceForecast[];
+  recommendations: RightSizeRecommendation[];
+}
@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -18,15 +19,44 @@ type ProxyOptions struct {
 
 // Client represents a kubernetes client
 type Client struct {
-	Clientset     *kubernetes.Clientset
-	MetricsClient *metricsv1beta1.Clientset
+	Clientset     kubernetes.Interface
+	MetricsClient metricsv1beta1.Interface
 	DynamicClient dynamic.Interface
 }
 
+var (
+	overrideMu     sync.RWMutex
+	overrideClient *Client
+	overrideConfig *rest.Config
+)
+
+// Override makes NewClient return client, and GetConfig return config when it is not nil,
+// until the returned restore function is called. It lets tests run the deployment flows
+// against a fake clientset or a test API server; see the kubetest package.
+func Override(client *Client, config *rest.Config) (restore func()) {
+	overrideMu.Lock()
+	previousClient, previousConfig := overrideClient, overrideConfig
+	overrideClient, overrideConfig = client, config
+	overrideMu.Unlock()
+
+	return func() {
+		overrideMu.Lock()
+		overrideClient, overrideConfig = previousClient, previousConfig
+		overrideMu.Unlock()
+	}
+}
+
 // NewClient creates a Kubernetes client.
 // If K8S_PROXY_URL is set, it is used for local development. Otherwise the
 // client uses in-cluster ServiceAccount credentials.
 func NewClient() (*Client, error) {
+	overrideMu.RLock()
+	client := overrideClient
+	overrideMu.RUnlock()
+	if client != nil {
+		return client, nil
+	}
+
 	proxyURL := os.Getenv("K8S_PROXY_URL")
 	if proxyURL != "" {
 		return NewClientWithOptions(ProxyOptions{Host: proxyURL})
@@ -64,29 +94,40 @@ func NewClientWithConfig(config *rest.Config) (*Client, error) {
 		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
 	}
 
-	metricsClient, err := metricsv1beta1.NewForConfig(config)
-	if err != nil {
-		// If metrics client fails, we'll continue without it
-		fmt.Printf("Warning: Unable to create metrics client: %v\n", err)
-	}
-
 	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		// If dynamic client fails, return error as it's needed for custom resources
 		return nil, fmt.Errorf("failed to create dynamic client: %v", err)
 	}
 
-	return &Client{
+	client := &Client{
 		Clientset:     clientset,
-		MetricsClient: metricsClient,
 		DynamicClient: dynamicClient,
-	}, nil
+	}
+
+	// Left nil on failure so callers' nil checks skip metrics
+	metricsClient, err := metricsv1beta1.NewForConfig(config)
+	if err != nil {
+		// If metrics client fails, we'll continue without it
+		fmt.Printf("Warning: Unable to create metrics client: %v\n", err)
+	} else {
+		client.MetricsClient = metricsClient
+	}
+
+	return client, nil
 }
 
 // GetConfig returns a Kubernetes REST config.
 // If K8S_PROXY_URL is set, it is used for local development. Otherwise the
 // config uses in-cluster ServiceAccount credentials.
 func GetConfig() (*rest.Config, error) {
+	overrideMu.RLock()
+	config := overrideConfig
+	overrideMu.RUnlock()
+	if config != nil {
+		return rest.CopyConfig(config), nil
+	}
+
 	proxyURL := os.Getenv("K8S_PROXY_URL")
 	if proxyURL != "" {
 		return GetConfigWithHost(proxyURL)
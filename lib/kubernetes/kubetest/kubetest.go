@@ -0,0 +1,251 @@
+// Package kubetest runs the platform's Kubernetes flows (DeployToKubernetesAtomically,
+// managed service deployment, resource deletion) without a live cluster. It swaps the
+// client returned by kubernetes.NewClient for client-go's fake clientsets, or for a client
+// of a test API server such as envtest:
+//
+//	cluster := kubetest.NewFakeCluster(t)
+//	deployed, err := utils.DeployToKubernetesAtomically("nginx:1.27", service)
+//	...
+//	if cluster.Get(kubetest.Deployments, service.EnvironmentID, utils.GetResourceName(*deployed)) == nil {
+//		t.Fatal("deployment was not created")
+//	}
+//
+// Against envtest (sigs.k8s.io/controller-runtime/pkg/envtest), start the environment in
+// the test and hand its config over:
+//
+//	env := &envtest.Environment{}
+//	config, err := env.Start()
+//	...
+//	t.Cleanup(func() { env.Stop() })
+//	kubetest.UseConfig(t, config)
+//
+// The client is swapped process-wide, so tests installing one run one after another even
+// when marked parallel. The database and the other settings the flows read are left to the
+// test.
+package kubetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// Resources of the objects the deployment flows create, for Get and Actions
+var (
+	Namespaces               = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	ConfigMaps               = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	Secrets                  = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	Services                 = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	ServiceAccounts          = schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}
+	PersistentVolumeClaims   = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	Pods                     = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	Deployments              = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	StatefulSets             = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}
+	Ingresses                = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	HorizontalPodAutoscalers = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+)
+
+// customResourceLists are the list kinds of the custom resources the platform manages
+// through the dynamic client, which the fake dynamic client cannot infer
+var customResourceLists = map[schema.GroupVersionResource]string{
+	{Group: "traefik.io", Version: "v1alpha1", Resource: "middlewares"}:              "MiddlewareList",
+	{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}:              "CertificateList",
+	{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}:            "ClusterIssuerList",
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gatewayclasses"}:  "GatewayClassList",
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}:        "GatewayList",
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}:      "HTTPRouteList",
+	{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "tcproutes"}: "TCPRouteList",
+	{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}:   "VolumeSnapshotList",
+	{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}: "VerticalPodAutoscalerList",
+}
+
+// clusterMu serializes the tests that swap the process-wide client
+var clusterMu sync.Mutex
+
+// Cluster is an in-memory cluster that kubernetes.NewClient returns while a test runs
+type Cluster struct {
+	t testing.TB
+
+	// Client is what kubernetes.NewClient returns
+	Client *kubernetes.Client
+	// Clientset holds the built-in resources and records every request made to them
+	Clientset *fake.Clientset
+	// Dynamic holds the custom resources, e.g. Traefik Middlewares and cert-manager Certificates
+	Dynamic *dynamicfake.FakeDynamicClient
+	// Metrics serves pod and node metrics; it is empty unless a test adds some
+	Metrics *metricsfake.Clientset
+}
+
+// NewFakeCluster installs an in-memory cluster seeded with objects until the test ends.
+// Unstructured objects go to the dynamic client, the others to the clientset.
+func NewFakeCluster(t testing.TB, objects ...runtime.Object) *Cluster {
+	t.Helper()
+
+	var builtIn, custom []runtime.Object
+	for _, object := range objects {
+		if _, ok := object.(*unstructured.Unstructured); ok {
+			custom = append(custom, object)
+		} else {
+			builtIn = append(builtIn, object)
+		}
+	}
+
+	cluster := &Cluster{
+		t:         t,
+		Clientset: fake.NewClientset(builtIn...),
+		Dynamic:   dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), customResourceLists, custom...),
+		Metrics:   metricsfake.NewSimpleClientset(),
+	}
+	cluster.Client = &kubernetes.Client{
+		Clientset:     cluster.Clientset,
+		MetricsClient: cluster.Metrics,
+		DynamicClient: cluster.Dynamic,
+	}
+
+	install(t, cluster.Client, nil)
+	return cluster
+}
+
+// UseConfig installs a client of the API server at config, e.g. one started by envtest,
+// until the test ends. kubernetes.GetConfig returns config meanwhile.
+func UseConfig(t testing.TB, config *rest.Config) *kubernetes.Client {
+	t.Helper()
+
+	client, err := kubernetes.NewClientWithConfig(config)
+	if err != nil {
+		t.Fatalf("kubetest: %v", err)
+	}
+	install(t, client, config)
+	return client
+}
+
+// install swaps the process-wide client until the test ends
+func install(t testing.TB, client *kubernetes.Client, config *rest.Config) {
+	clusterMu.Lock()
+	restore := kubernetes.Override(client, config)
+	t.Cleanup(func() {
+		restore()
+		clusterMu.Unlock()
+	})
+}
+
+// Get returns a built-in object, or nil when it does not exist
+func (c *Cluster) Get(resource schema.GroupVersionResource, namespace string, name string) runtime.Object {
+	c.t.Helper()
+
+	object, err := c.Clientset.Tracker().Get(resource, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		c.t.Fatalf("kubetest: get %s %s/%s: %v", resource.Resource, namespace, name, err)
+	}
+	return object
+}
+
+// GetCustom returns a custom resource, or nil when it does not exist
+func (c *Cluster) GetCustom(resource schema.GroupVersionResource, namespace string, name string) *unstructured.Unstructured {
+	c.t.Helper()
+
+	object, err := c.Dynamic.Tracker().Get(resource, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		c.t.Fatalf("kubetest: get %s %s/%s: %v", resource.Resource, namespace, name, err)
+	}
+	custom, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		c.t.Fatalf("kubetest: %s %s/%s is a %T", resource.Resource, namespace, name, object)
+	}
+	return custom
+}
+
+// Actions counts the requests made with verb (create, update, patch, delete, ...) to a
+// built-in resource
+func (c *Cluster) Actions(verb string, resource schema.GroupVersionResource) int {
+	count := 0
+	for _, action := range c.Clientset.Actions() {
+		if action.Matches(verb, resource.Resource) && action.GetResource().Group == resource.Group {
+			count++
+		}
+	}
+	return count
+}
+
+// Fail makes the next times requests with verb to a built-in resource return err, or every
+// such request when times is 0. Use "*" to match any verb.
+func (c *Cluster) Fail(verb string, resource schema.GroupVersionResource, times int, err error) {
+	c.Clientset.PrependReactor(verb, resource.Resource, failReactor(times, err))
+}
+
+// FailCustom is Fail for the custom resources of the dynamic client
+func (c *Cluster) FailCustom(verb string, resource schema.GroupVersionResource, times int, err error) {
+	c.Dynamic.PrependReactor(verb, resource.Resource, failReactor(times, err))
+}
+
+// Conflict is the error of a write that lost a race with another writer, for Fail
+func Conflict(resource schema.GroupVersionResource, name string) error {
+	return apierrors.NewConflict(resource.GroupResource(), name, errors.New("the object has been modified"))
+}
+
+// AlreadyExists is the error of a create that lost a race with another creator, for Fail
+func AlreadyExists(resource schema.GroupVersionResource, name string) error {
+	return apierrors.NewAlreadyExists(resource.GroupResource(), name)
+}
+
+func failReactor(times int, err error) k8stesting.ReactionFunc {
+	var calls atomic.Int64
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if times > 0 && calls.Add(1) > int64(times) {
+			return false, nil, nil
+		}
+		return true, nil, err
+	}
+}
+
+// Namespace is a namespace object for seeding a cluster, e.g. a service's environment
+func Namespace(name string) runtime.Object {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+// RunConcurrently calls fn n times at once, e.g. to deploy the same service from several
+// goroutines, and returns the error of each call by index
+func RunConcurrently(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			<-start
+			errs[i] = fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
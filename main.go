@@ -3,15 +3,17 @@ package main
 import (
 	"log"
 	"os"
-	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/pendeploy-simple/api/openapi"
 	"github.com/pendeploy-simple/api/v1"
+	"github.com/pendeploy-simple/api/v2"
 	"github.com/pendeploy-simple/database"
 	"github.com/pendeploy-simple/middleware"
 	"github.com/pendeploy-simple/services"
+	"github.com/pendeploy-simple/utils"
 )
 
 func main() {
@@ -29,26 +31,91 @@ func main() {
 	if err := services.EnsureAdminExists(); err != nil {
 		log.Fatalf("Failed to ensure default admin user exists: %v", err)
 	}
-	if err := services.NewRegistryService().EnsureRegistryExists(); err != nil {
+	// Render Ingresses for the ingress provider chosen in the platform settings
+	services.NewPlatformSettingsService().StartSettingsRefresher()
+	// Report missing cluster prerequisites before anything is deployed
+	if err := services.NewPreflightService().CheckOnStartup(); err != nil {
+		log.Fatalf("Preflight failed: %v", err)
+	}
+	registryService := services.NewRegistryService()
+	if err := registryService.EnsureRegistryExists(); err != nil {
 		log.Fatalf("Failed to ensure default registry exists: %v", err)
 	}
+	// Route Docker Hub pulls through a ready pull-through cache registry, if any
+	registryService.SyncRegistryMirror()
 	if err := services.NewManagedServiceService().EnsureTCPProxyExists(); err != nil {
 		log.Fatalf("Failed to ensure TCP proxy exists: %v", err)
 	}
 
-	// CORS configuration
-	corsAllowed := os.Getenv("CORS_ALLOWED")
-	if corsAllowed == "" {
-		corsAllowed = "http://localhost:5173" // Default value if not set
-	}
+	// Scale managed services up and down according to their pause schedules
+	services.NewPauseScheduleService().StartPauseScheduler()
+
+	// Update managed services on a patch or minor channel during their maintenance window
+	services.NewVersionUpdateService().StartVersionUpdater()
+
+	// Remove finished build jobs, evicted/test pods and stale TLS secrets
+	services.NewJanitorService().StartJanitor()
+
+	// Fail deployments and services left building or starting by an instance that stopped mid-flight
+	services.NewStaleStatusSweeperService().StartSweeper()
+
+	// Delete archived build logs and artifacts past their retention period or project quota
+	services.NewArchiveRetentionService().StartPurger()
+
+	// Deliver deployment webhooks recorded in the outbox, including ones left over from a crash
+	services.NewOutboxService().StartOutboxDispatcher()
+
+	// Remind about uploaded TLS certificates nearing expiry
+	services.NewCustomCertificateService().StartCertificateExpiryMonitor()
+
+	// Forward service pod logs to the log drains configured on their projects
+	services.NewLogDrainService().StartLogDrainCollector()
+
+	// Probe monitored services and record their uptime history
+	services.NewUptimeService().StartUptimeChecker()
+
+	// Correlate deployment failures, crash loops and probe failures into service incidents
+	services.NewServiceIncidentService().StartIncidentDetector()
+
+	// Record cluster and service usage history for capacity forecasts
+	services.NewCapacityService().StartUsageSampler()
 
-	// Split by comma and trim spaces
-	allowedOrigins := strings.Split(strings.ReplaceAll(corsAllowed, " ", ""), ",")
+	// Alert about memory growth, CPU saturation and restart storms found in the usage history
+	services.NewUsageAnomalyService().StartAnomalyDetector()
 
+	// Roll deployments and usage up into daily statistics for the admin reports
+	services.NewDeploymentReportService().StartRollupJob()
+
+	// Sync projects from their GitOps config repositories when a push webhook was missed
+	services.NewGitOpsService().StartGitOpsPoller()
+
+	// Run the scheduled maintenance tasks of environments
+	services.NewMaintenanceTaskService().StartMaintenanceScheduler()
+
+	// Email the daily and weekly digests of subscribed projects
+	services.NewNotificationDigestService().StartDigestScheduler()
+
+	// Re-sync env vars read from external secret managers on each store's schedule
+	services.NewSecretStoreService().StartSecretStoreRefresher()
+
+	// Keep workload namespaces from reaching the registry, build and platform namespaces
+	services.NewTenantIsolationService().StartIsolationReconciler()
+
+	// Check again the service hostnames that did not resolve or answer yet
+	services.NewDomainPropagationService().StartPropagationChecker()
+
+	// Keep the DNS-01 ClusterIssuer in line with the configured DNS provider credentials
+	if _, err := services.NewDomainService().ApplyDNS01Issuer(); err != nil {
+		log.Printf("⚠️ DNS-01 issuer not applied: %v", err)
+	}
+
+	// CORS configuration: CORS_ALLOWED, or the origins of the platform settings, checked per
+	// request so a change applies without a restart
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     allowedOrigins,
+		AllowOriginFunc:  utils.IsAllowedOrigin,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Accept-Version", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"ETag", "API-Version", "Deprecation", "Sunset", "Link"},
 		AllowCredentials: true,
 	}))
 
@@ -61,13 +128,29 @@ func main() {
 		})
 	})
 
+	// Readiness endpoint with database pool saturation, for probes
+	router.GET("/healthz", v1.Healthz)
+
 	// Setup API v1 routes
 	apiV1 := router.Group("/api/v1")
 	// Apply middleware to the group - it has built-in exceptions for auth routes
 	apiV1.Use(middleware.AuthMiddleware())
+	// Drop cached GET responses when the resource they belong to is written
+	apiV1.Use(middleware.ResponseCacheInvalidator())
+	apiV1.Use(middleware.APIVersion("v1"))
+	// Reject requests that do not match the generated OpenAPI spec
+	apiV1.Use(openapi.ValidateRequest())
 	// Register all routes
 	v1.RegisterRoutes(apiV1)
 
+	// Setup API v2 routes (response envelope, see api/v2/response.go)
+	apiV2 := router.Group("/api/v2")
+	apiV2.Use(middleware.AuthMiddleware())
+	apiV2.Use(middleware.ResponseCacheInvalidator())
+	apiV2.Use(middleware.APIVersion("v2"))
+	apiV2.Use(openapi.ValidateRequest())
+	v2.RegisterRoutes(apiV2)
+
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -76,7 +159,7 @@ func main() {
 
 	// Start server
 	log.Printf("🚀 PenDeploy API v1 starting on port %s", port)
-	log.Printf("📚 API docs available at: http://localhost:%s/api/v1/health", port)
+	log.Printf("📚 API docs available at: http://localhost:%s/api/v1/openapi.json", port)
 
 	if err := router.Run(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SupportedAPIVersions lists the API versions served, oldest first
+var SupportedAPIVersions = []string{"v1", "v2"}
+
+// vendorMediaType matches Accept: application/vnd.pendeploy.v2+json
+var vendorMediaType = regexp.MustCompile(`application/vnd\.pendeploy\.(v\d+)\+json`)
+
+// requestedAPIVersion reads the version a client asked for from the Accept-Version
+// header or a vendor media type in Accept. Empty if the client didn't ask.
+func requestedAPIVersion(c *gin.Context) string {
+	if version := strings.ToLower(strings.TrimSpace(c.GetHeader("Accept-Version"))); version != "" {
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		return version
+	}
+	if match := vendorMediaType.FindStringSubmatch(c.GetHeader("Accept")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range SupportedAPIVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// APIVersion tags a route group with its version and negotiates with clients that
+// ask for a specific one: a different supported version is redirected to the same
+// path under that version's prefix, an unsupported version is rejected with 406.
+func APIVersion(version string) gin.HandlerFunc {
+	prefix := "/api/" + version
+
+	return func(c *gin.Context) {
+		c.Set("apiVersion", version)
+		c.Header("API-Version", version)
+
+		requested := requestedAPIVersion(c)
+		if requested == "" || requested == version {
+			c.Next()
+			return
+		}
+
+		if !isSupportedAPIVersion(requested) {
+			c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+				"error":             "unsupported API version: " + requested,
+				"supportedVersions": SupportedAPIVersions,
+			})
+			return
+		}
+
+		location := "/api/" + requested + strings.TrimPrefix(c.Request.URL.Path, prefix)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		// 307 keeps the method and body
+		c.Redirect(http.StatusTemporaryRedirect, location)
+		c.Abort()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/services"
 )
 
@@ -14,11 +15,18 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Skip auth for public endpoints
 		if c.Request.URL.Path == "/" || 
 		   c.Request.URL.Path == "/api/v1/health" || 
+		   c.Request.URL.Path == "/api/v1/healthz" ||
+		   c.Request.URL.Path == "/api/v1/openapi.json" ||
 		   c.Request.URL.Path == "/api/v1/auth/login" ||
 		   c.Request.URL.Path == "/api/v1/auth/register" ||
 		   c.Request.URL.Path == "/api/v1/auth/logout" ||
 		   c.Request.URL.Path == "/api/v1/auth/refresh" ||
-		   strings.HasPrefix(c.Request.URL.Path, "/api/v1/deployments") {
+		   c.Request.URL.Path == "/api/v1/auth/device/code" ||
+		   c.Request.URL.Path == "/api/v1/auth/device/token" ||
+		   isPublicDeploymentPath(c.Request.URL.Path) ||
+		   isGitOpsWebhookPath(c.Request.URL.Path) ||
+		   strings.HasPrefix(c.Request.URL.Path, "/api/v1/status-pages/") ||
+		   strings.HasPrefix(c.Request.URL.Path, "/api/v1/shared/") {
 			c.Next()
 			return
 		}
@@ -54,6 +62,12 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		
+		// API tokens (automation, CLIs) carry scopes instead of a session
+		if services.IsAPIToken(tokenString) {
+			authenticateAPIToken(c, tokenString)
+			return
+		}
+
 		// Validate token
 		claims, err := services.ValidateToken(tokenString)
 		if err != nil {
@@ -65,12 +79,146 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Revoked sessions are refused before their token expires
+		sessions := services.NewSessionService()
+		if sessions.IsRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "Session has been revoked. Please login again.",
+			})
+			c.Abort()
+			return
+		}
+		sessions.Touch(claims.ID)
+
+		// A session issued for 2FA setup only reaches the setup endpoints
+		if claims.TwoFactorSetup && !isTwoFactorSetupPath(c.Request.URL.Path) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":            "error",
+				"message":           "Two-factor authentication must be set up before using the API",
+				"twoFactorRequired": true,
+			})
+			c.Abort()
+			return
+		}
+
+		// An admin acting as the user cannot manage the user's credentials
+		if claims.ImpersonatorID != "" && !isImpersonationAllowedPath(c.Request.URL.Path) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Not available while impersonating a user",
+			})
+			c.Abort()
+			return
+		}
+
+		// Routes that repeat AuthMiddleware must not audit a request twice
+		_, authenticated := c.Get("userId")
+
 		// Set user info in context
 		c.Set("userId", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("sessionId", claims.ID)
+
+		if claims.ImpersonatorID == "" || authenticated {
+			// Continue to the next handler
+			c.Next()
+			return
+		}
+
+		// Flag impersonated responses so the dashboard shows a banner, and audit writes
+		c.Set("impersonatorId", claims.ImpersonatorID)
+		c.Set("impersonatorEmail", claims.ImpersonatorEmail)
+		c.Header("X-Impersonated-By", claims.ImpersonatorEmail)
+		c.Next()
 
-		// Continue to the next handler
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && c.Request.Method != http.MethodOptions {
+			services.NewImpersonationService().RecordRequest(claims, c.Request.Method, c.Request.URL.RequestURI(), c.Writer.Status(), c.ClientIP())
+		}
+	}
+}
+
+// isTwoFactorSetupPath reports whether a setup-only session may call the path
+func isTwoFactorSetupPath(path string) bool {
+	return path == "/api/v1/auth/me" || strings.HasPrefix(path, "/api/v1/auth/2fa")
+}
+
+// isImpersonationAllowedPath reports whether an impersonation session may call the path:
+// everything but the credential endpoints under /auth
+func isImpersonationAllowedPath(path string) bool {
+	if !strings.HasPrefix(path, "/api/v1/auth/") {
+		return true
+	}
+	return path == "/api/v1/auth/me" || path == "/api/v1/auth/impersonation/end"
+}
+
+// authenticateAPIToken authenticates a scoped API token. Tokens without the write
+// scope may only read.
+func authenticateAPIToken(c *gin.Context, tokenString string) {
+	token, user, err := services.NewAPITokenService().Authenticate(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	if !token.HasScope(models.APITokenScopeWrite) && !(readOnly && token.HasScope(models.APITokenScopeRead)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "API token lacks the scope for this request",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("userId", user.ID)
+	c.Set("email", user.Email)
+	c.Set("role", string(user.Role))
+	c.Set("authMethod", "api_token")
+	c.Set("apiTokenId", token.ID)
+
+	c.Next()
+}
+
+// SessionOnlyMiddleware rejects API tokens, for endpoints that manage credentials
+// themselves. This middleware should be used after AuthMiddleware
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authMethod") == "api_token" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "This endpoint requires a signed-in session, not an API token",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// isPublicDeploymentPath reports whether a deployments route is open to the CI jobs and
+// webhooks that trigger builds. The SBOM lists every package of a service's image and log
+// downloads hold complete build and runtime output, so they need a signed-in owner of the
+// project.
+func isPublicDeploymentPath(path string) bool {
+	if !strings.HasPrefix(path, "/api/v1/deployments") {
+		return false
+	}
+	return !strings.HasSuffix(path, "/sbom") && !strings.HasSuffix(path, "/download")
+}
+
+// isGitOpsWebhookPath matches /api/v1/gitops/<projectId>/webhook exactly. Config repository
+// pushes carry no account; HandlePush authenticates them by their HMAC signature.
+func isGitOpsWebhookPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/v1/gitops/")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(rest, "/")
+	return len(parts) == 2 && parts[0] != "" && parts[1] == "webhook"
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks an endpoint as deprecated with the standard Deprecation, Sunset
+// and Link headers. sunset may be zero when no removal date has been set, and
+// successor may be empty when there is no replacement.
+func Deprecated(since time.Time, sunset time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		}
+
+		log.Printf("Deprecated endpoint called: %s %s", c.Request.Method, c.FullPath())
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComputeETag builds a weak ETag from the parts that identify a resource version,
+// typically its ID and UpdatedAt
+func ComputeETag(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "|")))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// CheckETag sets the ETag header and answers 304 Not Modified when the client's
+// If-None-Match already matches. Returns true if the response has been written.
+func CheckETag(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		c.Abort()
+		return true
+	}
+	return false
+}
+
+// etagMatches implements the weak comparison of If-None-Match
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
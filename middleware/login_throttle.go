@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pendeploy-simple/services"
+)
+
+// LoginThrottleMiddleware refuses logins from addresses with too many recent failed logins,
+// as configured in the auth policy. Per-account lockout is applied by the login itself.
+func LoginThrottleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		protection := services.NewLoginProtectionService()
+		retryAfter, throttled := protection.CheckThrottle(c.ClientIP())
+		if !throttled {
+			c.Next()
+			return
+		}
+
+		protection.RecordThrottled(services.LoginClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+		c.Header("Retry-After", fmt.Sprint(int(retryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"status":  "error",
+			"message": "Too many failed logins from this address, try again later",
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultResponseCacheTTL keeps polled GET responses for a few seconds
+const defaultResponseCacheTTL = 3 * time.Second
+
+type cachedResponse struct {
+	status      int
+	contentType string
+	etag        string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// responseCache stores rendered GET responses per path and caller
+type responseCache struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]cachedResponse // path -> caller -> response
+}
+
+var sharedResponseCache = &responseCache{entries: make(map[string]map[string]cachedResponse)}
+
+// bodyRecorder captures the response body while writing it through
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// getResponseCacheTTL reads RESPONSE_CACHE_TTL_SECONDS (0 disables the cache)
+func getResponseCacheTTL() time.Duration {
+	value := strings.TrimSpace(os.Getenv("RESPONSE_CACHE_TTL_SECONDS"))
+	if value == "" {
+		return defaultResponseCacheTTL
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return defaultResponseCacheTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ResponseCache serves repeated GET requests from a short-lived in-memory cache,
+// keyed by path and caller, honouring If-None-Match against the cached ETag
+func ResponseCache() gin.HandlerFunc {
+	ttl := getResponseCacheTTL()
+
+	return func(c *gin.Context) {
+		if ttl == 0 || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.RequestURI()
+		caller := c.GetString("userId") + ":" + c.GetString("role")
+
+		if entry, ok := sharedResponseCache.get(path, caller); ok {
+			if entry.etag != "" {
+				c.Header("ETag", entry.etag)
+				c.Header("Cache-Control", "private, no-cache")
+				if etagMatches(c.GetHeader("If-None-Match"), entry.etag) {
+					c.AbortWithStatus(http.StatusNotModified)
+					return
+				}
+			}
+			c.Data(entry.status, entry.contentType, entry.body)
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() == http.StatusOK {
+			sharedResponseCache.set(path, caller, cachedResponse{
+				status:      http.StatusOK,
+				contentType: recorder.Header().Get("Content-Type"),
+				etag:        recorder.Header().Get("ETag"),
+				body:        recorder.body.Bytes(),
+				expiresAt:   time.Now().Add(ttl),
+			})
+		}
+	}
+}
+
+// ResponseCacheInvalidator drops cached responses under the resource path of any
+// successful write, e.g. PUT /api/v1/services/:id clears /api/v1/services/:id/...
+func ResponseCacheInvalidator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		sharedResponseCache.invalidate(resourcePrefix(c.Request.URL.Path))
+	}
+}
+
+// resourcePrefix trims a path to /api/v1/<collection>/<id>
+func resourcePrefix(path string) string {
+	segments := strings.SplitN(strings.Trim(path, "/"), "/", 5)
+	if len(segments) > 4 {
+		segments = segments[:4]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func (r *responseCache) get(path, caller string) (cachedResponse, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[path][caller]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+func (r *responseCache) set(path, caller string, entry cachedResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Sweep expired entries as we go so the map stays small
+	now := time.Now()
+	for p, callers := range r.entries {
+		for k, e := range callers {
+			if now.After(e.expiresAt) {
+				delete(callers, k)
+			}
+		}
+		if len(callers) == 0 {
+			delete(r.entries, p)
+		}
+	}
+
+	if r.entries[path] == nil {
+		r.entries[path] = make(map[string]cachedResponse)
+	}
+	r.entries[path][caller] = entry
+}
+
+func (r *responseCache) invalidate(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for path := range r.entries {
+		if strings.HasPrefix(path, prefix) {
+			delete(r.entries, path)
+		}
+	}
+}
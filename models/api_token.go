@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API token scopes
+const (
+	APITokenScopeRead  = "read"  // GET requests only
+	APITokenScopeWrite = "write" // every request the owner may make
+)
+
+// APITokenPrefix marks API tokens so they can be told apart from session JWTs
+const APITokenPrefix = "pdk_"
+
+// APIToken is a long-lived, scoped credential for automation (CLIs, CI, IaC tools).
+// Only a SHA-256 hash of the token is stored; the token itself is shown once.
+type APIToken struct {
+	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string         `json:"userId" gorm:"type:uuid;not null;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	TokenHash   string         `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	TokenPrefix string         `json:"tokenPrefix" gorm:"type:varchar(16)"` // first characters, to recognise a token
+	Scopes      string         `json:"scopes" gorm:"not null"`              // comma-separated
+	ExpiresAt   *time.Time     `json:"expiresAt" gorm:"default:null"`
+	LastUsedAt  *time.Time     `json:"lastUsedAt" gorm:"default:null"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"` // revoked
+
+	// Relation
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// HasScope reports whether the token was granted scope; write implies read
+func (t APIToken) HasScope(scope string) bool {
+	for _, granted := range strings.Split(t.Scopes, ",") {
+		if granted == scope || (granted == APITokenScopeWrite && scope == APITokenScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the token can no longer be used
+func (t APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
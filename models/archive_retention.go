@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// ArchiveRetentionSettingsID is the primary key of the single archive retention settings row
+const ArchiveRetentionSettingsID = 1
+
+// ArchiveRetentionSettings is the admin policy for the build logs and artifacts archived in
+// the artifact store. Zero values keep archives forever. The archives of each service's
+// latest deployment are never purged.
+type ArchiveRetentionSettings struct {
+	ID                    int `json:"-" gorm:"primaryKey"`
+	BuildLogRetentionDays int `json:"buildLogRetentionDays" gorm:"not null;default:0"`
+	ArtifactRetentionDays int `json:"artifactRetentionDays" gorm:"not null;default:0"`
+	// Archives of a project beyond this size are purged oldest first; ArchiveQuota overrides it
+	DefaultProjectQuotaMB int `json:"defaultProjectQuotaMb" gorm:"not null;default:0"`
+
+	UpdatedBy string    `json:"updatedBy,omitempty" gorm:"type:uuid;default:null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DefaultArchiveRetentionSettings are the settings in effect until an admin sets a policy
+func DefaultArchiveRetentionSettings() ArchiveRetentionSettings {
+	return ArchiveRetentionSettings{ID: ArchiveRetentionSettingsID}
+}
+
+// ArchiveQuota caps the size of the archived build logs and artifacts of one project,
+// replacing the default project quota. Zero keeps the project's archives regardless of size.
+type ArchiveQuota struct {
+	ProjectID string `json:"projectId" gorm:"primaryKey;type:uuid"`
+	QuotaMB   int    `json:"quotaMb"`
+
+	UpdatedBy string    `json:"updatedBy" gorm:"type:uuid;default:null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// Who must use two-factor authentication
+const (
+	TwoFactorOptional = "optional" // users may enable it
+	TwoFactorAdmins   = "admins"   // required for admins
+	TwoFactorAll      = "all"      // required for everyone
+)
+
+// Why a login attempt failed
+const (
+	LoginFailureCredentials   = "invalid_credentials"
+	LoginFailureTwoFactor     = "invalid_two_factor_code"
+	LoginFailureAccountLocked = "account_locked"
+	LoginFailureThrottled     = "ip_throttled"
+)
+
+// AuthPolicyID is the primary key of the single auth policy row
+const AuthPolicyID = 1
+
+// AuthPolicy is the admin configuration of login protection for the platform. Without a
+// row the defaults of DefaultAuthPolicy apply.
+type AuthPolicy struct {
+	ID                    int       `json:"-" gorm:"primaryKey"`
+	RequireTwoFactor      string    `json:"requireTwoFactor" gorm:"type:varchar(10);not null;default:'optional'"`
+	MaxFailedLogins       int       `json:"maxFailedLogins" gorm:"not null;default:5"`        // consecutive failures before an account locks; 0 disables lockout
+	LockoutMinutes        int       `json:"lockoutMinutes" gorm:"not null;default:15"`        // how long a locked account stays locked
+	MaxFailedLoginsPerIP  int       `json:"maxFailedLoginsPerIp" gorm:"not null;default:20"`  // failures from one address before it is throttled; 0 disables throttling
+	ThrottleWindowMinutes int       `json:"throttleWindowMinutes" gorm:"not null;default:15"` // window the per-address failures are counted in
+	UpdatedBy             string    `json:"updatedBy,omitempty" gorm:"type:uuid;default:null"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+// DefaultAuthPolicy is the policy in effect until an admin changes it
+func DefaultAuthPolicy() AuthPolicy {
+	return AuthPolicy{
+		ID:                    AuthPolicyID,
+		RequireTwoFactor:      TwoFactorOptional,
+		MaxFailedLogins:       5,
+		LockoutMinutes:        15,
+		MaxFailedLoginsPerIP:  20,
+		ThrottleWindowMinutes: 15,
+	}
+}
+
+// RequiresTwoFactor reports whether the policy forces a user with role to use 2FA
+func (p AuthPolicy) RequiresTwoFactor(role Role) bool {
+	return p.RequireTwoFactor == TwoFactorAll || (p.RequireTwoFactor == TwoFactorAdmins && role == RoleAdmin)
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code when the authenticator is
+// lost. Only a SHA-256 hash is stored; the codes are shown once.
+type RecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string     `json:"userId" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	UsedAt    *time.Time `json:"usedAt" gorm:"default:null"`
+	CreatedAt time.Time  `json:"createdAt"`
+
+	// Relation
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// LoginAttempt records every password login for auditing and per-address throttling. It
+// keeps no foreign keys so the audit trail outlives deleted users.
+type LoginAttempt struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Email     string    `json:"email" gorm:"index"`
+	UserID    *string   `json:"userId" gorm:"type:uuid;default:null"` // unset for unknown emails
+	IPAddress string    `json:"ipAddress" gorm:"type:varchar(45);index:idx_login_attempts_ip_created"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:varchar(30)"`
+	UserAgent string    `json:"userAgent" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_login_attempts_ip_created"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// BuildCacheEntry records an image pushed by a build, so other services of the project that
+// build the same commit with the same build config (e.g. apps of a monorepo sharing a
+// Dockerfile) deploy it instead of building it again
+type BuildCacheEntry struct {
+	ID string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	// CacheKey fingerprints the project, commit and build inputs, see utils.BuildCacheKey
+	CacheKey  string `json:"cacheKey" gorm:"not null;uniqueIndex"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;index"`
+	RepoURL   string `json:"repoUrl" gorm:"not null"`
+	CommitSHA string `json:"commitSha" gorm:"not null"`
+	// The build context is the checkout (narrowed by sparse checkout); the Dockerfile is given
+	// by its path, which the commit fixes, and its digest as built
+	SparseCheckoutPaths string `json:"sparseCheckoutPaths" gorm:"default:null"`
+	DockerfilePath      string `json:"dockerfilePath" gorm:"default:null"`
+	DockerfileDigest    string `json:"dockerfileDigest" gorm:"default:null"`
+
+	Image              string `json:"image" gorm:"not null"`
+	ImageDigest        string `json:"imageDigest" gorm:"default:null"`
+	SourceServiceID    string `json:"sourceServiceId" gorm:"type:uuid;not null"`
+	SourceDeploymentID string `json:"sourceDeploymentId" gorm:"type:uuid;not null"`
+
+	HitCount   int        `json:"hitCount"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// BuildQuota caps the image builds of a project. Zero values are not enforced.
+type BuildQuota struct {
+	ProjectID string `json:"projectId" gorm:"primaryKey;type:uuid"`
+
+	// Builds beyond this many at once wait in a queue for a running one to finish
+	MaxConcurrentBuilds int `json:"maxConcurrentBuilds"`
+	// Past the soft limit of build minutes in a month builds run one at a time; past the
+	// hard limit they are rejected until the next month
+	MonthlyMinutesSoftLimit int `json:"monthlyMinutesSoftLimit"`
+	MonthlyMinutesHardLimit int `json:"monthlyMinutesHardLimit"`
+
+	UpdatedBy string    `json:"updatedBy" gorm:"type:uuid;default:null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
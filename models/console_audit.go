@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// ConsoleAuditLog records every query run through the in-browser database console
+type ConsoleAuditLog struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID  string    `json:"serviceId" gorm:"type:uuid;not null;index"`
+	UserID     string    `json:"userId" gorm:"type:uuid;not null;index"`
+	Query      string    `json:"query" gorm:"type:text;not null"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error" gorm:"type:text;default:null"`
+	RowCount   int       `json:"rowCount"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// CustomCertificate is a user-supplied TLS certificate for a service's custom domain.
+// The certificate and key live in a TLS Secret in the environment namespace; only
+// metadata needed for expiry tracking is stored here.
+type CustomCertificate struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID   string    `json:"serviceId" gorm:"type:uuid;not null;uniqueIndex"`
+	Domain      string    `json:"domain" gorm:"not null"`
+	SecretName  string    `json:"secretName" gorm:"not null"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    string    `json:"dnsNames" gorm:"type:text"`           // comma-separated SANs
+	Fingerprint string    `json:"fingerprint" gorm:"type:varchar(64)"` // SHA-256 of the leaf certificate
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter" gorm:"index"`
+
+	// Last renewal reminder sent for this certificate
+	LastReminderAt *time.Time `json:"lastReminderAt" gorm:"default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// DependencyImagePin pins a dependency image the build system copies into every registry,
+// e.g. the Kaniko executor, to the digest its source image had when it was pinned. Builds
+// of dependency images pull the source by that digest, so an upstream retag cannot change
+// them; a retag or a newer tag is recorded as a candidate an admin reviews and approves.
+type DependencyImagePin struct {
+	Name        string `json:"name" gorm:"primaryKey;type:varchar(50)"` // e.g. kaniko-executor
+	SourceImage string `json:"sourceImage" gorm:"not null"`
+	Digest      string `json:"digest" gorm:"type:varchar(71);not null"`
+
+	// An update waiting for review: the digest the source tag moved to, or a newer tag
+	CandidateImage   string     `json:"candidateImage" gorm:"default:null"`
+	CandidateDigest  string     `json:"candidateDigest" gorm:"type:varchar(71);default:null"`
+	CandidateFoundAt *time.Time `json:"candidateFoundAt"`
+
+	PinnedBy  *string   `json:"pinnedBy" gorm:"type:uuid;default:null"` // nil when pinned on first use
+	PinnedAt  time.Time `json:"pinnedAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RegistryDependencyImage records which pinned source a registry's copy of a dependency
+// image was last built from, so registries still on an older pin can be rolled out
+type RegistryDependencyImage struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	RegistryID  string    `json:"registryId" gorm:"type:uuid;not null;uniqueIndex:idx_registry_dependency_image"`
+	Name        string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_registry_dependency_image"`
+	SourceImage string    `json:"sourceImage" gorm:"not null"`
+	Digest      string    `json:"digest" gorm:"type:varchar(71);default:null"` // empty when built from an unpinned tag
+	BuiltAt     time.Time `json:"builtAt"`
+
+	// Relation
+	Registry Registry `json:"-" gorm:"foreignKey:RegistryID;constraint:OnDelete:CASCADE"`
+}
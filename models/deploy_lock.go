@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// DeployLock blocks deployments and managed service changes in a project, or in one of its
+// environments, for everyone but admins, e.g. during incident response or a change freeze
+type DeployLock struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID     string     `json:"projectId" gorm:"type:uuid;not null;index"`
+	EnvironmentID string     `json:"environmentId" gorm:"type:uuid;default:null;index"` // empty locks the whole project
+	Reason        string     `json:"reason" gorm:"type:text;not null"`
+	ExpiresAt     *time.Time `json:"expiresAt" gorm:"default:null"` // nil holds the lock until it is removed
+	CreatedBy     string     `json:"createdBy" gorm:"type:uuid;not null"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// IsActive reports whether the lock still blocks changes at the given time
+func (l DeployLock) IsActive(now time.Time) bool {
+	return l.ExpiresAt == nil || l.ExpiresAt.After(now)
+}
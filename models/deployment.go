@@ -2,6 +2,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -9,11 +12,108 @@ import (
 type DeploymentStatus string
 
 const (
+	DeploymentStatusQueued    DeploymentStatus = "queued" // waiting for a build slot of the project
 	DeploymentStatusBuilding  DeploymentStatus = "building"
 	DeploymentStatusSuccess   DeploymentStatus = "success"
 	DeploymentStatusFailed    DeploymentStatus = "failed"
 )
 
+// Failure classes of failed deployments, the stage that failed, for reporting
+const (
+	FailureClassBuildQuota = "build_quota" // no build slot of the project became free
+	FailureClassTest       = "test"        // the pre-build test stage failed
+	FailureClassBuild      = "build"
+	FailureClassImagePull  = "image_pull"
+	FailureClassPolicy     = "policy" // rejected by an enforced policy rule
+	FailureClassRollout    = "rollout"
+	FailureClassTimeout    = "timeout"
+	FailureClassAbandoned  = "abandoned" // no API instance reported the result, e.g. after a crash
+	FailureClassOther      = "other"
+)
+
+// BuildEnvironment records how a deployment's image was built, so a build can be audited
+// and reproduced later
+type BuildEnvironment struct {
+	Backend      string   `json:"backend,omitempty"` // build backend that built the image
+	BuilderImage string   `json:"builderImage"`
+	KanikoArgs   []string `json:"kanikoArgs"` // build-arg values are redacted
+	BaseImages   []string `json:"baseImages"` // FROM images of the final Dockerfile
+	Branch       string   `json:"branch"`
+	Dockerfile   string   `json:"dockerfile,omitempty"` // path of the Dockerfile in the repository
+	Platforms    []string `json:"platforms,omitempty"` // target platforms of a multi-platform build
+	ExposedPorts []int    `json:"exposedPorts,omitempty"` // TCP ports the final stage EXPOSEs
+}
+
+func (b BuildEnvironment) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+func (b *BuildEnvironment) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, b)
+}
+
+// DeploymentHealthCheck is the result of the HTTP GET against a service's domain after a
+// successful deployment
+type DeploymentHealthCheck struct {
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode"` // 0 when no response was received
+	LatencyMs  int64     `json:"latencyMs"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+func (h DeploymentHealthCheck) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
+func (h *DeploymentHealthCheck) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, h)
+}
+
+// ImageLayer is a layer of a built image, with its compressed size in the registry
+type ImageLayer struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// ImageLayers are the layers of a built image in manifest order, base image first
+type ImageLayers []ImageLayer
+
+func (l ImageLayers) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+func (l *ImageLayers) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, l)
+}
+
 // Deployment represents a deployment instance
 type Deployment struct {
 	ID            string            `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
@@ -25,10 +125,56 @@ type Deployment struct {
 	
 	// Build info
 	Status        DeploymentStatus  `json:"status" gorm:"type:varchar(20);default:'building'"`
+	FailureClass  string            `json:"failureClass" gorm:"type:varchar(20);index;default:null"` // set when failed
+	// Classified cause of a failed build (see utils.BuildError*) and how to fix it
+	ErrorCode     string            `json:"errorCode" gorm:"type:varchar(40);index;default:null"`
+	ErrorHint     string            `json:"errorHint" gorm:"type:text;default:null"`
 	Image         string            `json:"image" gorm:"default:null"` // optional for managed services
 	// Managed service specific
 	Version       string            `json:"version" gorm:"type:varchar(50);default:null"` // For tracking version changes in managed services
 	
+	// Build environment captured from the build job
+	BuildEnv         *BuildEnvironment `json:"buildEnv,omitempty" gorm:"type:jsonb;default:null"`
+	DockerfileDigest string            `json:"dockerfileDigest" gorm:"index;default:null"` // sha256 of the Dockerfile as built
+	ImageDigest      string            `json:"imageDigest" gorm:"index;default:null"`      // digest of the pushed image
+	TestDurationMs   int64             `json:"testDurationMs" gorm:"default:0"`            // run time of the pre-build test stage
+	
+	// Image size (config and compressed layers) and layers read from the registry after the build
+	ImageSize     int64             `json:"imageSize" gorm:"default:0"`
+	ImageLayers   ImageLayers       `json:"imageLayers,omitempty" gorm:"type:jsonb;default:null"`
+	// Set when the image is larger than the service's MaxImageSize
+	ImageSizeWarning string         `json:"imageSizeWarning" gorm:"type:text;default:null"`
+	
+	// Post-rollout check that the app listens on the service port; empty when it passed
+	PortCheckError string           `json:"portCheckError" gorm:"default:null"`
+	// Build-time comparison of the service port with the ports the Dockerfile EXPOSEs;
+	// empty when they match or the Dockerfile exposes none
+	PortWarning    string           `json:"portWarning" gorm:"type:text;default:null"`
+	// HTTP GET against the service's domain after a successful deployment
+	HealthCheck   *DeploymentHealthCheck `json:"healthCheck,omitempty" gorm:"type:jsonb;default:null"`
+	
+	// Provenance: SBOM (stored in DeploymentSBOM) and cosign signature of the pushed image
+	SBOMFormat      string            `json:"sbomFormat" gorm:"type:varchar(30);default:null"`
+	ImageSigned     bool              `json:"imageSigned" gorm:"default:false"`
+	ProvenanceError string            `json:"provenanceError" gorm:"default:null"`
+	
+	// Build artifact exported from the service's ArtifactPath (object key in the artifact store)
+	ArtifactKey   string            `json:"artifactKey" gorm:"default:null"`
+	ArtifactSize  int64             `json:"artifactSize" gorm:"default:0"`
+	ArtifactError string            `json:"artifactError" gorm:"default:null"`
+	
+	// Build output archived in the artifact store after the build (object key), so it can be
+	// downloaded after the build job is gone
+	BuildLogKey   string            `json:"buildLogKey" gorm:"default:null"`
+	BuildLogSize  int64             `json:"buildLogSize" gorm:"default:0"` // compressed bytes
+	// When the retention policy last removed the archived build logs or artifact
+	ArchivePurgedAt *time.Time `json:"archivePurgedAt" gorm:"default:null"`
+	
+	// Run time of the Kaniko build job, counted towards the project's build minutes
+	BuildStartedAt  *time.Time `json:"buildStartedAt" gorm:"default:null"`
+	BuildFinishedAt *time.Time `json:"buildFinishedAt" gorm:"default:null"`
+	BuildDurationMs int64      `json:"buildDurationMs" gorm:"default:0"`
+	
 	// Timestamps
 	CreatedAt     time.Time         `json:"createdAt" gorm:"autoCreateTime"`
 	DeployedAt    time.Time         `json:"deployedAt" gorm:"default:null"`
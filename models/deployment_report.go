@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// FailureClassCounts counts the failed deployments of a day by Deployment.FailureClass
+type FailureClassCounts map[string]int64
+
+func (c FailureClassCounts) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *FailureClassCounts) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// ResourceConsumer is a service's pod usage over a day, from its usage samples.
+// CPU values are in millicores, memory values in bytes.
+type ResourceConsumer struct {
+	ServiceID      string `json:"serviceId"`
+	ServiceName    string `json:"serviceName"`
+	ProjectID      string `json:"projectId"`
+	AvgCPUUsed     int64  `json:"avgCpuUsed"`
+	AvgMemoryUsed  int64  `json:"avgMemoryUsed"`
+	PeakCPUUsed    int64  `json:"peakCpuUsed"`
+	PeakMemoryUsed int64  `json:"peakMemoryUsed"`
+}
+
+// ResourceConsumers are the heaviest services of a day, heaviest first
+type ResourceConsumers []ResourceConsumer
+
+func (r ResourceConsumers) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *ResourceConsumers) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// DeploymentStatsRollup is the platform-wide deployment and resource statistics of one UTC
+// day, computed by the nightly rollup job so reports do not scan deployments and samples
+type DeploymentStatsRollup struct {
+	Day                string             `json:"day" gorm:"primaryKey;type:varchar(10)"` // YYYY-MM-DD
+	Deployments        int64              `json:"deployments"`                            // created that day
+	Succeeded          int64              `json:"succeeded"`
+	Failed             int64              `json:"failed"`
+	Builds             int64              `json:"builds"` // build jobs started that day
+	AverageBuildMs     int64              `json:"averageBuildMs"`
+	FailuresByClass    FailureClassCounts `json:"failuresByClass" gorm:"type:jsonb;default:null"`
+	TopCPUConsumers    ResourceConsumers  `json:"topCpuConsumers" gorm:"type:jsonb;default:null"`
+	TopMemoryConsumers ResourceConsumers  `json:"topMemoryConsumers" gorm:"type:jsonb;default:null"`
+	ComputedAt         time.Time          `json:"computedAt"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// DeploymentSBOM is the software bill of materials generated for a deployment's image.
+// It is kept out of the deployments table because documents can be several megabytes.
+type DeploymentSBOM struct {
+	DeploymentID string    `json:"deploymentId" gorm:"primaryKey;type:uuid"`
+	Format       string    `json:"format" gorm:"type:varchar(30);not null"` // e.g. cyclonedx-json
+	Document     string    `json:"-" gorm:"type:text;not null"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	// Relation
+	Deployment Deployment `json:"-" gorm:"foreignKey:DeploymentID;constraint:OnDelete:CASCADE"`
+}
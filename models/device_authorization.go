@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Device authorization states
+const (
+	DeviceAuthPending  = "pending"
+	DeviceAuthApproved = "approved"
+	DeviceAuthDenied   = "denied"
+	DeviceAuthConsumed = "consumed" // exchanged for an API token
+)
+
+// DeviceAuthorization is a pending device-code login (RFC 8628 style): a CLI polls with
+// the device code while the user approves the short user code in a signed-in browser.
+type DeviceAuthorization struct {
+	ID             string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	DeviceCodeHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	UserCode       string     `json:"userCode" gorm:"type:varchar(9);not null;uniqueIndex"`
+	ClientName     string     `json:"clientName"`
+	Scopes         string     `json:"scopes" gorm:"not null"` // comma-separated, granted to the issued token
+	Status         string     `json:"status" gorm:"type:varchar(10);not null;default:'pending'"`
+	UserID         *string    `json:"userId" gorm:"type:uuid;default:null"` // set on approval
+	ExpiresAt      time.Time  `json:"expiresAt" gorm:"index"`
+	LastPolledAt   *time.Time `json:"-" gorm:"default:null"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// DomainPropagation is how far a hostname of a service is from serving traffic
+type DomainPropagation string
+
+const (
+	DomainPending     DomainPropagation = "pending"     // the hostname does not resolve yet
+	DomainMisdirected DomainPropagation = "misdirected" // it resolves, but not to the ingress
+	DomainTLSPending  DomainPropagation = "tls_pending" // the ingress answers, the certificate is not issued yet
+	DomainUnreachable DomainPropagation = "unreachable" // it resolves to the ingress, which does not answer
+	DomainReachable   DomainPropagation = "reachable"   // HTTPS requests reach the service
+)
+
+// DomainStatus is the result of the last DNS and HTTP check of one hostname of a service
+type DomainStatus struct {
+	ID        string            `json:"-" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID string            `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_domain_statuses_service_host"`
+	Hostname  string            `json:"hostname" gorm:"not null;uniqueIndex:idx_domain_statuses_service_host"`
+	Status    DomainPropagation `json:"status" gorm:"type:varchar(20);not null"`
+	// Comma-separated addresses the hostname resolved to, and those of the ingress
+	ResolvedAddresses string     `json:"resolvedAddresses" gorm:"type:text"`
+	IngressAddresses  string     `json:"ingressAddresses" gorm:"type:text"`
+	HTTPStatusCode    int        `json:"httpStatusCode"` // 0 when no response was received
+	Error             string     `json:"error,omitempty" gorm:"type:text;default:null"`
+	CheckedAt         time.Time  `json:"checkedAt"`
+	ReachableSince    *time.Time `json:"reachableSince" gorm:"default:null"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
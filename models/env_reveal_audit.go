@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// EnvRevealAuditLog records every time unmasked env var values of a service were returned
+type EnvRevealAuditLog struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID string    `json:"serviceId" gorm:"type:uuid;not null;index"`
+	UserID    string    `json:"userId" gorm:"type:uuid;not null;index"`
+	Keys      string    `json:"keys" gorm:"type:text;not null"`          // comma-separated names of the revealed variables
+	Source    string    `json:"source" gorm:"type:varchar(20);not null"` // reveal or export
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// EnvVarChangeStatus is the state of a proposed env var change
+type EnvVarChangeStatus string
+
+const (
+	EnvVarChangePendingApproval EnvVarChangeStatus = "pending_approval"
+	EnvVarChangeApproved        EnvVarChangeStatus = "approved" // the change is being applied
+	EnvVarChangeRejected        EnvVarChangeStatus = "rejected"
+	EnvVarChangeApplied         EnvVarChangeStatus = "applied"
+	EnvVarChangeFailed          EnvVarChangeStatus = "failed" // the change could not be applied, see Error
+)
+
+// EnvVarChange is a change of a service's env vars proposed in an environment that requires
+// approval of env var changes. It is applied, and the service redeployed or restarted, only
+// once someone other than the requester approves it.
+type EnvVarChange struct {
+	ID            string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID     string `json:"projectId" gorm:"type:uuid;not null;index"`
+	EnvironmentID string `json:"environmentId" gorm:"type:uuid;not null"`
+	ServiceID     string `json:"serviceId" gorm:"type:uuid;not null;index"`
+
+	// The service's variables when the change was proposed, and the variables proposed to
+	// replace them. Values are never returned, only the masked diff between the two.
+	BaseEnvVars     EnvVars `json:"-" gorm:"type:jsonb;default:'{}'"`
+	ProposedEnvVars EnvVars `json:"-" gorm:"type:jsonb;default:'{}'"`
+
+	Status      EnvVarChangeStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Note        string             `json:"note" gorm:"type:text;default:null"`
+	RequestedBy string             `json:"requestedBy" gorm:"type:uuid;not null"`
+
+	// Set when the change is approved or rejected
+	ReviewedBy    *string    `json:"reviewedBy" gorm:"type:uuid;default:null"`
+	ReviewedAt    *time.Time `json:"reviewedAt"`
+	ReviewComment string     `json:"reviewComment" gorm:"type:text;default:null"`
+
+	Error string `json:"error" gorm:"type:text;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
@@ -11,6 +11,36 @@ type Environment struct {
 	Name        string         `json:"name" gorm:"not null"` // Name must be unique per project
 	Description string         `json:"description" gorm:"default:null"` // Optional description
 	ProjectID   string         `json:"projectId" gorm:"type:uuid;not null;index"`
+
+	// Archived environments keep their data but have every workload scaled to zero
+	ArchivedAt *time.Time `json:"archivedAt" gorm:"default:null"`
+
+	// Defaults applied to services created in this environment without explicit values
+	DefaultCPULimit    string `json:"defaultCpuLimit" gorm:"default:null"`
+	DefaultMemoryLimit string `json:"defaultMemoryLimit" gorm:"default:null"`
+	DefaultReplicas    int    `json:"defaultReplicas" gorm:"default:null"`
+	DefaultStorageSize string `json:"defaultStorageSize" gorm:"default:null"` // managed services with volumes
+
+	// Allowed ranges for service resources; empty bounds are not enforced
+	MinCPULimit    string `json:"minCpuLimit" gorm:"default:null"`
+	MaxCPULimit    string `json:"maxCpuLimit" gorm:"default:null"`
+	MinMemoryLimit string `json:"minMemoryLimit" gorm:"default:null"`
+	MaxMemoryLimit string `json:"maxMemoryLimit" gorm:"default:null"`
+	MaxStorageSize string `json:"maxStorageSize" gorm:"default:null"`
+
+	// PriorityTier names the admin-defined tier the environment's workloads schedule with
+	PriorityTier string `json:"priorityTier" gorm:"type:varchar(50);default:null;index"`
+
+	// DomainTemplate generates the hostnames of the environment's git services, e.g.
+	// {service}.{env}.example.com; empty keeps the platform's repo-branch.env-id format
+	DomainTemplate string `json:"domainTemplate" gorm:"default:null"`
+
+	// Promotions into the environment wait until someone other than the requester approves them
+	PromotionApprovalRequired bool `json:"promotionApprovalRequired"`
+	// Env var changes of the environment's services are proposed with a diff and applied only
+	// once someone other than the proposer approves them
+	EnvVarApprovalRequired bool `json:"envVarApprovalRequired"`
+
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -20,6 +50,11 @@ type Environment struct {
 	Services  []Service `json:"services,omitempty" gorm:"foreignKey:EnvironmentID;constraint:OnDelete:CASCADE"`
 }
 
+// IsArchived reports whether the environment is archived
+func (e Environment) IsArchived() bool {
+	return e.ArchivedAt != nil
+}
+
 // TableName sets the table name for Environment model
 func (Environment) TableName() string {
 	return "environments"
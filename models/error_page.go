@@ -0,0 +1,34 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// ErrorPageConfig brands the page the ingress serves in place of a 502, 503 or 504 of the
+// project's services, e.g. while a backend is down or scaled to zero
+type ErrorPageConfig struct {
+	Title   string `json:"title"`             // defaults to the project name
+	Message string `json:"message,omitempty"` // shown below the status
+	LogoURL string `json:"logoUrl,omitempty"`
+	// HTML replaces the platform template; {{status}} is replaced with the status code
+	HTML string `json:"html,omitempty"`
+}
+
+func (c ErrorPageConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *ErrorPageConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
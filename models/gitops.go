@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Outcomes of a GitOps sync
+const (
+	GitOpsSyncSucceeded = "succeeded"
+	GitOpsSyncFailed    = "failed"
+)
+
+// DefaultGitOpsSpecPath is where the project spec is read from when no path is configured
+const DefaultGitOpsSpecPath = "pendeploy.yaml"
+
+// GitOpsConfig points a project at a Git repository holding its declarative spec. The sync
+// controller applies the environments and services of the spec file on each push to the
+// branch and every PollMinutes, as the project owner.
+type GitOpsConfig struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;uniqueIndex"`
+
+	RepoURL     string `json:"repoUrl" gorm:"not null"` // GitHub or GitLab HTTPS URL
+	Branch      string `json:"branch" gorm:"default:'main'"`
+	Path        string `json:"path" gorm:"default:'pendeploy.yaml'"` // spec file, relative to the repository root
+	GitUsername string `json:"gitUsername" gorm:"default:null"`
+	GitToken    string `json:"-" gorm:"default:null"` // never returned
+
+	// WebhookSecret authenticates push webhooks: the GitHub HMAC signature key or the GitLab
+	// secret token. Only returned when it is generated.
+	WebhookSecret string `json:"-" gorm:"not null"`
+
+	PollMinutes int  `json:"pollMinutes"` // 0 only syncs on push; no gorm default: a literal 0 must persist
+	Paused      bool `json:"paused"`      // neither pushes nor polls sync
+
+	// Sync health, updated by the sync controller
+	LastSyncedCommit string     `json:"lastSyncedCommit" gorm:"type:varchar(64);default:null"` // last commit applied without errors
+	LastCheckedAt    *time.Time `json:"lastCheckedAt" gorm:"default:null"`
+	LastSyncStatus   string     `json:"lastSyncStatus" gorm:"type:varchar(20);default:null"`
+	LastSyncError    string     `json:"lastSyncError" gorm:"type:text;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
+
+// PollDue reports whether the repository should be checked for a new commit at now
+func (c GitOpsConfig) PollDue(now time.Time) bool {
+	if c.Paused || c.PollMinutes <= 0 {
+		return false
+	}
+	return c.LastCheckedAt == nil || now.Sub(*c.LastCheckedAt) >= time.Duration(c.PollMinutes)*time.Minute
+}
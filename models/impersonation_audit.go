@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Impersonation audit actions
+const (
+	ImpersonationStarted = "start"
+	ImpersonationRequest = "request" // a write request made while impersonating
+	ImpersonationEnded   = "end"
+)
+
+// ImpersonationAuditLog records an admin acting as another user: when the impersonation
+// started and ended, and every write request made meanwhile. It keeps no foreign keys so
+// the audit trail outlives deleted users.
+type ImpersonationAuditLog struct {
+	ID             string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	SessionID      string    `json:"sessionId" gorm:"type:uuid;not null;index"`
+	ImpersonatorID string    `json:"impersonatorId" gorm:"type:uuid;not null;index"`
+	UserID         string    `json:"userId" gorm:"type:uuid;not null;index"`
+	Action         string    `json:"action" gorm:"type:varchar(10);not null"`
+	Method         string    `json:"method,omitempty" gorm:"type:varchar(10)"`
+	Path           string    `json:"path,omitempty" gorm:"type:text"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Detail         string    `json:"detail,omitempty" gorm:"type:text"` // the reason, at start
+	IPAddress      string    `json:"ipAddress" gorm:"type:varchar(45)"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"index"`
+}
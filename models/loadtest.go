@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Load test statuses
+const (
+	LoadTestStatusRunning   = "running"
+	LoadTestStatusCompleted = "completed"
+	LoadTestStatusFailed    = "failed"
+)
+
+// LoadTest is a short benchmark of a service's internal URL run as a vegeta Job. The
+// latency percentiles are kept per deployment so regressions between versions show up.
+type LoadTest struct {
+	ID              string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID       string `json:"serviceId" gorm:"type:uuid;not null;index:idx_load_tests_service_created"`
+	DeploymentID    string `json:"deploymentId" gorm:"type:uuid;index"` // deployment serving during the test
+	Path            string `json:"path" gorm:"not null"`
+	Rate            int    `json:"rate" gorm:"not null"` // requests per second
+	DurationSeconds int    `json:"durationSeconds" gorm:"not null"`
+	Status          string `json:"status" gorm:"type:varchar(20);not null;default:'running'"`
+	Error           string `json:"error" gorm:"default:null"`
+
+	// Results, filled in when the Job completes
+	Requests      int64   `json:"requests"`
+	SuccessRatio  float64 `json:"successRatio"`  // 0..1, non-2xx/3xx responses and errors count as failures
+	ThroughputRPS float64 `json:"throughputRps"` // successful requests per second
+	LatencyMeanMs float64 `json:"latencyMeanMs"`
+	LatencyP50Ms  float64 `json:"latencyP50Ms"`
+	LatencyP90Ms  float64 `json:"latencyP90Ms"`
+	LatencyP95Ms  float64 `json:"latencyP95Ms"`
+	LatencyP99Ms  float64 `json:"latencyP99Ms"`
+	LatencyMaxMs  float64 `json:"latencyMaxMs"`
+
+	CreatedBy  string     `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"autoCreateTime;index:idx_load_tests_service_created"`
+	FinishedAt *time.Time `json:"finishedAt"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
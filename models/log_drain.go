@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Log drain types
+const (
+	LogDrainTypeLoki    = "loki"    // Loki push API
+	LogDrainTypeDatadog = "datadog" // Datadog HTTP logs intake
+	LogDrainTypeHTTPS   = "https"   // generic HTTPS endpoint receiving JSON batches
+)
+
+// Log drain health states
+const (
+	LogDrainStatusPending  = "pending"  // nothing delivered yet
+	LogDrainStatusHealthy  = "healthy"  // the last delivery succeeded
+	LogDrainStatusFailing  = "failing"  // the last delivery failed
+	LogDrainStatusDisabled = "disabled" // not forwarding
+)
+
+// LogDrain forwards the pod logs of every service in a project to an external sink
+type LogDrain struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;uniqueIndex:idx_log_drains_project_name"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_log_drains_project_name"`
+	Type      string `json:"type" gorm:"type:varchar(20);not null"`
+	Endpoint  string `json:"endpoint" gorm:"not null"`
+	// Username is used with Token as basic auth by Loki; other sinks only use the token
+	Username string `json:"username" gorm:"default:null"`
+	Token    string `json:"-" gorm:"default:null"` // API key or bearer token, never returned
+	Enabled  bool   `json:"enabled"`               // no gorm default: a literal false must persist
+
+	// Delivery health, updated by the collector
+	LastDeliveredAt *time.Time `json:"lastDeliveredAt" gorm:"default:null"`
+	LastErrorAt     *time.Time `json:"lastErrorAt" gorm:"default:null"`
+	LastError       string     `json:"lastError" gorm:"type:text;default:null"`
+	DeliveredLines  int64      `json:"deliveredLines"`
+	DroppedLines    int64      `json:"droppedLines"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
+
+// Status summarizes the delivery health of the drain
+func (d LogDrain) Status() string {
+	switch {
+	case !d.Enabled:
+		return LogDrainStatusDisabled
+	case d.LastErrorAt != nil && (d.LastDeliveredAt == nil || d.LastErrorAt.After(*d.LastDeliveredAt)):
+		return LogDrainStatusFailing
+	case d.LastDeliveredAt != nil:
+		return LogDrainStatusHealthy
+	default:
+		return LogDrainStatusPending
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// Maintenance task run statuses
+const (
+	MaintenanceRunRunning   = "running"
+	MaintenanceRunSucceeded = "succeeded"
+	MaintenanceRunFailed    = "failed"
+)
+
+// What started a maintenance task run
+const (
+	MaintenanceTriggerSchedule = "schedule"
+	MaintenanceTriggerManual   = "manual"
+)
+
+// MaintenanceTask is a recurring Job of an environment, e.g. a cache warmer or a report
+// generator. It runs a command in the image of the latest successful deployment of a git
+// service of the environment, on a cron schedule in UTC; a run is skipped while the
+// previous one is still running.
+type MaintenanceTask struct {
+	ID            string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EnvironmentID string `json:"environmentId" gorm:"type:uuid;not null;uniqueIndex:idx_maintenance_tasks_environment_name"`
+	Name          string `json:"name" gorm:"not null;uniqueIndex:idx_maintenance_tasks_environment_name"`
+	Schedule      string `json:"schedule" gorm:"not null"`                  // 5-field cron in UTC
+	ServiceID     string `json:"serviceId" gorm:"type:uuid;not null;index"` // git service whose image runs the task
+	Command       string `json:"command" gorm:"type:text;not null"`         // run with sh -c
+
+	// InheritEnv passes the env vars of the service, secrets included, before EnvVars
+	InheritEnv bool    `json:"inheritEnv"`
+	EnvVars    EnvVars `json:"envVars" gorm:"type:jsonb;default:'{}'"`
+
+	CPULimit       string `json:"cpuLimit" gorm:"default:'500m'"`
+	MemoryLimit    string `json:"memoryLimit" gorm:"default:'512Mi'"`
+	TimeoutMinutes int    `json:"timeoutMinutes" gorm:"default:30"`
+	Suspended      bool   `json:"suspended"` // scheduled runs are skipped; manual runs still start
+
+	// LastScheduledAt is the schedule time of the last scheduled run, so a minute is not
+	// run twice
+	LastScheduledAt *time.Time `json:"lastScheduledAt" gorm:"default:null"`
+
+	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Environment Environment `json:"-" gorm:"foreignKey:EnvironmentID;constraint:OnDelete:CASCADE"`
+}
+
+// MaintenanceTaskRun is one run of a maintenance task as a Kubernetes Job. The end of its
+// output is kept after the Job is removed.
+type MaintenanceTaskRun struct {
+	ID       string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	TaskID   string `json:"taskId" gorm:"type:uuid;not null;index:idx_maintenance_task_runs_task_started"`
+	Trigger  string `json:"trigger" gorm:"type:varchar(20);not null"`
+	Image    string `json:"image" gorm:"not null"`
+	Status   string `json:"status" gorm:"type:varchar(20);not null;default:'running'"`
+	Error    string `json:"error" gorm:"type:text;default:null"`
+	ExitCode *int   `json:"exitCode" gorm:"default:null"`
+	Logs     string `json:"-" gorm:"type:text;default:null"` // redacted end of the output
+
+	StartedBy  string     `json:"startedBy" gorm:"type:uuid;default:null"` // manual runs
+	StartedAt  time.Time  `json:"startedAt" gorm:"not null;index:idx_maintenance_task_runs_task_started"`
+	FinishedAt *time.Time `json:"finishedAt" gorm:"default:null"`
+
+	Task MaintenanceTask `json:"-" gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// How often a digest email is sent
+const (
+	DigestFrequencyDaily  = "daily"  // every day, covering the previous 24 hours
+	DigestFrequencyWeekly = "weekly" // every Monday, covering the previous 7 days
+)
+
+// SMTP connection security
+const (
+	SMTPSecurityStartTLS = "starttls" // plain connection upgraded with STARTTLS, usually port 587
+	SMTPSecurityTLS      = "tls"      // TLS from the start, usually port 465
+	SMTPSecurityNone     = "none"     // unencrypted; only for relays on a trusted network
+)
+
+// SMTPSettingsID is the primary key of the single SMTP settings row
+const SMTPSettingsID = 1
+
+// SMTPSettings is the admin configuration of the mail server notification emails are sent
+// through. Without a row, or without a host, no emails are sent.
+type SMTPSettings struct {
+	ID          int       `json:"-" gorm:"primaryKey"`
+	Host        string    `json:"host" gorm:"default:null"`
+	Port        int       `json:"port" gorm:"not null;default:587"`
+	Security    string    `json:"security" gorm:"type:varchar(10);not null;default:'starttls'"`
+	Username    string    `json:"username" gorm:"default:null"`
+	Password    string    `json:"-" gorm:"default:null"` // never returned
+	FromAddress string    `json:"fromAddress" gorm:"default:null"`
+	UpdatedBy   string    `json:"updatedBy,omitempty" gorm:"type:uuid;default:null"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// DefaultSMTPSettings are the settings in effect until an admin configures a mail server
+func DefaultSMTPSettings() SMTPSettings {
+	return SMTPSettings{
+		ID:       SMTPSettingsID,
+		Port:     587,
+		Security: SMTPSecurityStartTLS,
+	}
+}
+
+// Configured reports whether emails can be sent
+func (s SMTPSettings) Configured() bool {
+	return s.Host != "" && s.FromAddress != ""
+}
+
+// DigestSubscription sends a summary of a project's deployments, failures and resource
+// warnings to an email address daily or weekly
+type DigestSubscription struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;uniqueIndex:idx_digest_subscriptions_project_email"`
+	Email     string `json:"email" gorm:"not null;uniqueIndex:idx_digest_subscriptions_project_email"`
+	Frequency string `json:"frequency" gorm:"type:varchar(10);not null"`
+	Enabled   bool   `json:"enabled"` // no gorm default: a literal false must persist
+
+	// End of the last period a digest was sent for, and why the last send failed
+	LastPeriodEnd *time.Time `json:"lastPeriodEnd" gorm:"default:null"`
+	LastError     string     `json:"lastError" gorm:"type:text;default:null"`
+
+	CreatedBy string    `json:"createdBy" gorm:"type:uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
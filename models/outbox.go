@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// OutboxStatus is the delivery state of an outbox event
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	OutboxStatusDead      OutboxStatus = "dead" // gave up after the maximum number of attempts
+)
+
+// Outbox event types
+const (
+	OutboxEventDeploymentStatus    = "deployment.status"
+	OutboxEventCertificateExpiring = "certificate.expiring"
+	OutboxEventUsageAnomaly        = "service.anomaly"
+	OutboxEventScheduledDeployment = "deployment.scheduled" // runs a scheduled deployment; no webhook
+	OutboxEventNamespaceDeletion   = "namespace.delete"     // tears down a namespace of a deleted project; no webhook
+)
+
+// OutboxEvent is a notification written in the same transaction as the state change it
+// describes and delivered afterwards by the outbox dispatcher (at least once). Scheduled
+// deployment and namespace deletion events are jobs instead: the dispatcher runs them at
+// NextAttemptAt.
+type OutboxEvent struct {
+	ID          string       `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EventType   string       `json:"eventType" gorm:"type:varchar(50);not null"`
+	AggregateID string       `json:"aggregateId" gorm:"type:uuid;not null;index"` // e.g. the deployment ID
+	Payload     string       `json:"payload" gorm:"type:jsonb;not null"`
+	CallbackURL string       `json:"callbackUrl" gorm:"type:text;not null"`
+	Status      OutboxStatus `json:"status" gorm:"type:varchar(20);default:'pending';index:idx_outbox_due,priority:1"`
+	Attempts    int          `json:"attempts" gorm:"default:0"`
+	LastError   string       `json:"lastError" gorm:"type:text;default:null"`
+
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"not null;index:idx_outbox_due,priority:2"`
+	DeliveredAt   *time.Time `json:"deliveredAt" gorm:"default:null"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"autoCreateTime"`
+}
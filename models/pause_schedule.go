@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// PauseState is the state a pause schedule wants a managed service in
+type PauseState string
+
+const (
+	PauseStateRunning PauseState = "running"
+	PauseStatePaused  PauseState = "paused"
+)
+
+// ServicePauseSchedule scales a managed service to zero and back on a cron
+// schedule (e.g. stop dev databases at night and on weekends)
+type ServicePauseSchedule struct {
+	ID         string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID  string `json:"serviceId" gorm:"type:uuid;not null;uniqueIndex"`
+	PauseCron  string `json:"pauseCron" gorm:"not null"`  // e.g. "0 20 * * 1-5"
+	ResumeCron string `json:"resumeCron" gorm:"not null"` // e.g. "0 8 * * 1-5"
+	Timezone   string `json:"timezone" gorm:"default:UTC"`
+	Enabled    bool   `json:"enabled"` // no gorm default: a literal false must persist
+
+	// Manual override wins over the schedule until OverrideUntil (the next scheduled transition)
+	OverrideState PauseState `json:"overrideState" gorm:"type:varchar(20);default:null"`
+	OverrideUntil *time.Time `json:"overrideUntil" gorm:"default:null"`
+
+	// Last state applied by the scheduler
+	AppliedState     PauseState `json:"appliedState" gorm:"type:varchar(20);default:null"`
+	LastTransitionAt *time.Time `json:"lastTransitionAt" gorm:"default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PlatformSettingsID is the primary key of the single platform settings row
+const PlatformSettingsID = 1
+
+// PlatformSettings holds the admin-managed settings of the platform's cluster integration.
+// Without a row the defaults of DefaultPlatformSettings apply.
+type PlatformSettings struct {
+	ID int `json:"-" gorm:"primaryKey"`
+	// IngressProvider is the ingress controller Ingresses and TCP exposure are rendered for:
+	// traefik or nginx. Empty until saved, which means the deployment's INGRESS_PROVIDER.
+	IngressProvider string `json:"ingressProvider" gorm:"type:varchar(20)"`
+	// MaxBuildTimeoutMinutes caps the build timeout services may set. 0 until saved, which
+	// means utils.DefaultMaxBuildTimeoutMinutes.
+	MaxBuildTimeoutMinutes int `json:"maxBuildTimeoutMinutes" gorm:"default:null"`
+	// NodePortRange is the API server's --service-node-port-range, e.g. 30000-32767. Empty
+	// until saved, which means the deployment's SERVICE_NODE_PORT_RANGE.
+	NodePortRange string `json:"nodePortRange" gorm:"type:varchar(20);default:null"`
+	// ManagedPortRanges narrows the external ports allocated to managed services of a type;
+	// types without a range use the TCP proxy's whole range
+	ManagedPortRanges PortRanges `json:"managedPortRanges" gorm:"type:jsonb;default:null"`
+	// BuildBackend builds the images of services that do not select one: kaniko, buildkit or
+	// external. Empty until saved, which means the deployment's BUILD_BACKEND.
+	BuildBackend string `json:"buildBackend" gorm:"type:varchar(20);default:null"`
+	// DefaultDomain is the base domain of services whose project has not selected one. Empty
+	// until saved, which means the deployment's DEFAULT_DOMAIN.
+	DefaultDomain string `json:"defaultDomain" gorm:"type:varchar(253);default:null"`
+	// PublicAddresses are the comma-separated addresses hostnames must resolve to when the
+	// cluster is behind NAT. Empty until saved, which means the deployment's
+	// INGRESS_PUBLIC_ADDRESSES, or the load balancer's addresses without it.
+	PublicAddresses string `json:"publicAddresses" gorm:"type:text;default:null"`
+	// CORSAllowedOrigins are the comma-separated origins the dashboard may call the API from,
+	// the first also being the one links to the dashboard are built with. Empty until saved,
+	// which means the deployment's CORS_ALLOWED.
+	CORSAllowedOrigins string `json:"corsAllowedOrigins" gorm:"type:text;default:null"`
+	// ClusterIssuer is the cert-manager ClusterIssuer that signs ingress certificates. Empty
+	// until saved, which means utils.DefaultClusterIssuerName.
+	ClusterIssuer string `json:"clusterIssuer" gorm:"type:varchar(253);default:null"`
+	// BuildNamespace is the namespace build jobs run in, taking effect when the API restarts.
+	// Empty until saved, which means the deployment's BUILD_NAMESPACE.
+	BuildNamespace string    `json:"buildNamespace" gorm:"type:varchar(63);default:null"`
+	UpdatedBy      string    `json:"updatedBy,omitempty" gorm:"type:uuid;default:null"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Contains reports whether port lies in the range
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// PortRanges maps a managed service type to its external port range
+type PortRanges map[string]PortRange
+
+func (r PortRanges) Value() (driver.Value, error) {
+	if r == nil {
+		return json.Marshal(map[string]PortRange{})
+	}
+	return json.Marshal(map[string]PortRange(r))
+}
+
+func (r *PortRanges) Scan(value interface{}) error {
+	if value == nil {
+		*r = PortRanges{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// DefaultPlatformSettings returns the settings in effect until an admin changes them
+func DefaultPlatformSettings() PlatformSettings {
+	return PlatformSettings{ID: PlatformSettingsID}
+}
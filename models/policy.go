@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// PolicyMode controls what happens when a workload violates a policy rule
+type PolicyMode string
+
+const (
+	PolicyModeDisabled PolicyMode = "disabled"
+	PolicyModeAudit    PolicyMode = "audit"   // record the violation, apply anyway
+	PolicyModeEnforce  PolicyMode = "enforce" // record the violation and refuse to apply
+)
+
+// Built-in policy rules
+const (
+	PolicyRuleForbidLatestTag  = "forbid-latest-tag"
+	PolicyRuleRequireProbes    = "require-probes"
+	PolicyRuleMaxExternalPorts = "max-external-ports"
+)
+
+// PolicyRule is the admin configuration of a built-in policy rule. Rules without a row
+// are disabled.
+type PolicyRule struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:varchar(50)"`
+	Mode      PolicyMode `json:"mode" gorm:"type:varchar(10);not null;default:'disabled'"`
+	Limit     int        `json:"limit" gorm:"default:0"` // for rules with a threshold
+	UpdatedBy string     `json:"updatedBy" gorm:"type:uuid;default:null"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// PolicyViolation records a workload that failed a policy rule. It keeps no foreign keys
+// so the audit trail outlives deleted services.
+type PolicyViolation struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	RuleID    string     `json:"ruleId" gorm:"type:varchar(50);not null;index"`
+	Mode      PolicyMode `json:"mode" gorm:"type:varchar(10);not null"`
+	Blocked   bool       `json:"blocked"`
+	ServiceID string     `json:"serviceId" gorm:"type:uuid;index"`
+	ProjectID string     `json:"projectId" gorm:"type:uuid;index"`
+	Resource  string     `json:"resource"` // e.g. Deployment/<namespace>/<name>
+	Message   string     `json:"message" gorm:"type:text"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"index"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// PriorityTier is an admin-defined scheduling tier backed by a Kubernetes PriorityClass.
+// Environments are mapped to a tier; one tier can be marked for build jobs.
+type PriorityTier struct {
+	Name        string `json:"name" gorm:"primaryKey;type:varchar(50)"`
+	Value       int32  `json:"value" gorm:"not null"` // higher schedules first and may preempt lower
+	Description string `json:"description"`
+
+	// Pods of a non-preempting tier wait for free capacity instead of evicting lower tiers
+	PreemptLowerPriority bool `json:"preemptLowerPriority"`
+
+	// Builds marks the tier used by image build jobs
+	Builds bool `json:"builds"`
+
+	UpdatedBy string    `json:"updatedBy" gorm:"type:uuid;default:null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -5,15 +5,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// Project statuses
+const (
+	ProjectStatusActive   = "active"
+	ProjectStatusDeleting = "deleting"
+)
+
 // Project represents a project container
 type Project struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description string         `json:"description" gorm:"default:null"`
 	UserID      string         `json:"userId" gorm:"type:uuid;not null;index"`
+	BaseDomain  string         `json:"baseDomain" gorm:"default:null"` // wildcard domain for generated hostnames; empty = platform default
+	Status      string         `json:"status" gorm:"type:varchar(20);default:'active'"` // active, or deleting while its namespaces are torn down
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// ErrorPage brands the 502/503/504 pages of the project's services; nil keeps Traefik's
+	ErrorPage *ErrorPageConfig `json:"errorPage,omitempty" gorm:"type:jsonb"`
 	
 	// Relations
 	User         User          `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
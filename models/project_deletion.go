@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// NamespaceDeletionStatus is the state of the teardown of one namespace of a deleted project
+type NamespaceDeletionStatus string
+
+const (
+	NamespaceDeletionPending     NamespaceDeletionStatus = "pending"
+	NamespaceDeletionTerminating NamespaceDeletionStatus = "terminating" // deleted, waiting for Kubernetes to finish
+	NamespaceDeletionDeleted     NamespaceDeletionStatus = "deleted"
+	NamespaceDeletionFailed      NamespaceDeletionStatus = "failed" // gave up retrying, see LastError
+)
+
+// ProjectNamespaceDeletion tracks the teardown of the namespace of one environment of a
+// project being deleted. Each is torn down by an outbox job that is retried until the
+// namespace is gone; the project is deleted once all of them are.
+type ProjectNamespaceDeletion struct {
+	ID            string                  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID     string                  `json:"projectId" gorm:"type:uuid;not null;index"`
+	EnvironmentID string                  `json:"environmentId" gorm:"type:uuid;not null"`
+	Namespace     string                  `json:"namespace" gorm:"not null"`
+	Status        NamespaceDeletionStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts      int                     `json:"attempts" gorm:"default:0"`
+	LastError     string                  `json:"lastError" gorm:"type:text;default:null"`
+
+	DeletedAt *time.Time `json:"deletedAt" gorm:"default:null"` // when the namespace was gone
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// ProjectSecret is an entry of a project's secrets vault that service environment variables
+// reference as ${secret:name}. The value only lives in a Kubernetes Secret.
+type ProjectSecret struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;uniqueIndex:idx_project_secrets_project_name"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_project_secrets_project_name"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// PromotionStatus is the state of a promotion
+type PromotionStatus string
+
+const (
+	PromotionPendingApproval PromotionStatus = "pending_approval" // the target environment requires approval
+	PromotionApproved        PromotionStatus = "approved"         // the target deployment is being started
+	PromotionRejected        PromotionStatus = "rejected"
+	PromotionDeployed        PromotionStatus = "deployed" // the target deployment was created, see TargetDeploymentID
+	PromotionFailed          PromotionStatus = "failed"   // the target deployment could not be started, see Error
+)
+
+// Promotion deploys the exact image of a successful deployment, pinned to its digest, to the
+// counterpart service (the service with the same name) in another environment of the project
+type Promotion struct {
+	ID                  string          `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID           string          `json:"projectId" gorm:"type:uuid;not null;index"`
+	SourceDeploymentID  string          `json:"sourceDeploymentId" gorm:"type:uuid;not null;index"`
+	SourceServiceID     string          `json:"sourceServiceId" gorm:"type:uuid;not null"`
+	SourceEnvironmentID string          `json:"sourceEnvironmentId" gorm:"type:uuid;not null"`
+	TargetServiceID     string          `json:"targetServiceId" gorm:"type:uuid;not null;index"`
+	TargetEnvironmentID string          `json:"targetEnvironmentId" gorm:"type:uuid;not null"`
+	Image               string          `json:"image" gorm:"not null"` // repository@digest
+	CommitSHA           string          `json:"commitSha" gorm:"default:null"`
+	Status              PromotionStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Note                string          `json:"note" gorm:"type:text;default:null"`
+	RequestedBy         string          `json:"requestedBy" gorm:"type:uuid;not null"`
+
+	// Set when a promotion that required approval is approved or rejected
+	ReviewedBy    *string    `json:"reviewedBy" gorm:"type:uuid;default:null"`
+	ReviewedAt    *time.Time `json:"reviewedAt"`
+	ReviewComment string     `json:"reviewComment" gorm:"type:text;default:null"`
+
+	TargetDeploymentID *string `json:"targetDeploymentId" gorm:"type:uuid;default:null"`
+	Error              string  `json:"error" gorm:"type:text;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
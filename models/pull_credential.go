@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// PullCredential is a login for a private external registry that generated workloads use
+// to pull their images. It applies to one environment, or to every environment of the
+// project when EnvironmentID is nil. The password only lives in a dockerconfigjson Secret.
+type PullCredential struct {
+	ID            string  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID     string  `json:"projectId" gorm:"type:uuid;not null;uniqueIndex:idx_pull_credentials_project_name"`
+	EnvironmentID *string `json:"environmentId" gorm:"type:uuid;index"`
+	Name          string  `json:"name" gorm:"not null;uniqueIndex:idx_pull_credentials_project_name"`
+	Server        string  `json:"server" gorm:"not null"` // e.g. ghcr.io, registry.example.com:5000
+	Username      string  `json:"username" gorm:"not null"`
+	SecretName    string  `json:"secretName" gorm:"not null"` // Secret copied into environment namespaces
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Project     Project      `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+	Environment *Environment `json:"-" gorm:"foreignKey:EnvironmentID;constraint:OnDelete:CASCADE"`
+}
+
+// AppliesTo reports whether the credential is used by workloads of the environment
+func (c PullCredential) AppliesTo(environmentID string) bool {
+	return c.EnvironmentID == nil || *c.EnvironmentID == environmentID
+}
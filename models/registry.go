@@ -14,6 +14,24 @@ const (
 	RegistryStatusFailed   RegistryStatus = "failed"
 )
 
+// RegistryMode selects whether a registry stores pushed images or mirrors a remote one
+type RegistryMode string
+
+const (
+	RegistryModeStandard RegistryMode = "standard"
+	// RegistryModeProxy runs the registry as a read-only pull-through cache of ProxyRemoteURL
+	RegistryModeProxy RegistryMode = "proxy"
+)
+
+// RegistryStorageBackend selects where a registry keeps its blobs
+type RegistryStorageBackend string
+
+const (
+	RegistryStorageFilesystem RegistryStorageBackend = "filesystem"
+	// RegistryStorageS3 keeps blobs in an S3-compatible bucket, external or on a managed MinIO
+	RegistryStorageS3 RegistryStorageBackend = "s3"
+)
+
 // Registry represents a container registry configuration
 type Registry struct {
 	ID           string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
@@ -23,6 +41,22 @@ type Registry struct {
 	IsActive     bool           `json:"isActive" gorm:"default:true"`
 	Status       RegistryStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
 	BuildPodName string         `json:"-" gorm:"default:null"` // Name of the K8s pod handling the build
-	CreatedAt    time.Time      `json:"createdAt"`
-	UpdatedAt    time.Time      `json:"updatedAt"`
+	Mode         RegistryMode   `json:"mode" gorm:"type:varchar(20);default:'standard'"`
+	// Upstream mirrored in proxy mode, Docker Hub when empty
+	ProxyRemoteURL string                 `json:"proxyRemoteUrl" gorm:"default:null"`
+	ProxyUsername  string                 `json:"-" gorm:"default:null"`
+	ProxyPassword  string                 `json:"-" gorm:"default:null"`
+	StorageBackend RegistryStorageBackend `json:"storageBackend" gorm:"type:varchar(20);default:'filesystem'"`
+	// Managed MinIO service backing the bucket, empty for external S3
+	StorageServiceID string `json:"storageServiceId,omitempty" gorm:"type:uuid;default:null"`
+	S3Endpoint       string `json:"s3Endpoint,omitempty" gorm:"default:null"`
+	S3Region         string `json:"s3Region,omitempty" gorm:"default:null"`
+	S3Bucket         string `json:"s3Bucket,omitempty" gorm:"default:null"`
+	S3AccessKey      string `json:"-" gorm:"default:null"`
+	S3SecretKey      string `json:"-" gorm:"default:null"`
+	// Replicas above one need the S3 backend since the volume is ReadWriteOnce
+	Replicas    int       `json:"replicas" gorm:"default:1"`
+	StorageSize string    `json:"storageSize" gorm:"default:'20Gi'"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
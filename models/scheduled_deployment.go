@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ScheduledDeploymentStatus is the state of a scheduled deployment
+type ScheduledDeploymentStatus string
+
+const (
+	ScheduledDeploymentPending   ScheduledDeploymentStatus = "scheduled"
+	ScheduledDeploymentStarted   ScheduledDeploymentStatus = "started" // the deployment was created
+	ScheduledDeploymentFailed    ScheduledDeploymentStatus = "failed"  // the deployment could not be started, see Error
+	ScheduledDeploymentCancelled ScheduledDeploymentStatus = "cancelled"
+)
+
+// ScheduledDeployment deploys a git service at a future time: a build of CommitSHA (the
+// branch head when empty) or, with SourceDeploymentID, the image of an earlier deployment.
+// It runs from the outbox, so it survives restarts and runs once across replicas.
+type ScheduledDeployment struct {
+	ID                 string                    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID          string                    `json:"serviceId" gorm:"type:uuid;not null;index"`
+	CommitSHA          string                    `json:"commitSha" gorm:"default:null"`
+	CommitMessage      string                    `json:"commitMessage" gorm:"default:null"`
+	SourceDeploymentID *string                   `json:"sourceDeploymentId" gorm:"type:uuid;default:null"`
+	ScheduledAt        time.Time                 `json:"scheduledAt" gorm:"not null;index"`
+	Timezone           string                    `json:"timezone" gorm:"default:UTC"` // the time was given in
+	Status             ScheduledDeploymentStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	DeploymentID       *string                   `json:"deploymentId" gorm:"type:uuid;default:null"` // set once started
+	Error              string                    `json:"error" gorm:"type:text;default:null"`
+	CreatedBy          string                    `json:"createdBy" gorm:"type:uuid;not null"`
+	CancelledBy        string                    `json:"cancelledBy" gorm:"type:uuid;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// Secret store types
+const (
+	SecretStoreTypeVault = "vault" // HashiCorp Vault, KV secrets engine v1 or v2
+	SecretStoreTypeAWS   = "aws"   // AWS Secrets Manager
+)
+
+// What happens to running pods when a refresh changes a value
+const (
+	SecretRestartNone    = "none"    // new values apply the next time pods start
+	SecretRestartRolling = "rolling" // roll the pods of affected services
+)
+
+// SecretStore is an external secret manager of a project. Service env vars reference its
+// entries as ${external:store/path#key}; PenDeploy syncs them into the service's env Secret
+// on deploy and every RefreshMinutes.
+type SecretStore struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID string `json:"projectId" gorm:"type:uuid;not null;uniqueIndex:idx_secret_stores_project_name"`
+	Name      string `json:"name" gorm:"not null;uniqueIndex:idx_secret_stores_project_name"`
+	Type      string `json:"type" gorm:"type:varchar(20);not null"`
+
+	// Vault: server address, optional Enterprise namespace and token
+	Address        string `json:"address" gorm:"default:null"` // e.g. https://vault.example.com:8200
+	VaultNamespace string `json:"vaultNamespace" gorm:"default:null"`
+	Token          string `json:"-" gorm:"default:null"` // never returned
+
+	// AWS Secrets Manager: region and access key
+	Region          string `json:"region" gorm:"default:null"`
+	AccessKeyID     string `json:"accessKeyId" gorm:"default:null"`
+	SecretAccessKey string `json:"-" gorm:"default:null"` // never returned
+
+	RefreshMinutes int    `json:"refreshMinutes"` // 0 only syncs on deploy; no gorm default: a literal 0 must persist
+	RestartPolicy  string `json:"restartPolicy" gorm:"type:varchar(20);default:'none'"`
+
+	// Refresh health, updated by the refresher
+	LastRefreshAt    *time.Time `json:"lastRefreshAt" gorm:"default:null"`
+	LastRefreshError string     `json:"lastRefreshError" gorm:"type:text;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relations
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
+
+// RefreshDue reports whether the store's references should be refreshed at now
+func (s SecretStore) RefreshDue(now time.Time) bool {
+	if s.RefreshMinutes <= 0 {
+		return false
+	}
+	return s.LastRefreshAt == nil || now.Sub(*s.LastRefreshAt) >= time.Duration(s.RefreshMinutes)*time.Minute
+}
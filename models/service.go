@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
+	"strings"
 	"time"
 )
 
+// MaskedSecretValue replaces the values of secret env vars wherever they would be shown
+const MaskedSecretValue = "********"
+
 // EnvVars custom type for JSON storage
 type EnvVars map[string]string
 
@@ -30,6 +34,127 @@ func (e *EnvVars) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, e)
 }
 
+// CacheRule caches responses under a path prefix at the ingress for TTLSeconds, e.g. the
+// static assets under /assets
+type CacheRule struct {
+	PathPrefix string `json:"pathPrefix"`
+	TTLSeconds int    `json:"ttlSeconds"`
+}
+
+// CacheRules is the HTTP cache policy of a service's routes
+type CacheRules []CacheRule
+
+func (c CacheRules) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]CacheRule{})
+	}
+	return json.Marshal([]CacheRule(c))
+}
+
+func (c *CacheRules) Scan(value interface{}) error {
+	if value == nil {
+		*c = CacheRules{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// ServiceContainer is a companion process of a git service, e.g. a queue worker. It runs
+// from the service's image in every pod beside the app container, sharing its network, env
+// vars and shared volumes.
+type ServiceContainer struct {
+	Name        string `json:"name"`
+	Command     string `json:"command"`               // run with sh -c
+	CPULimit    string `json:"cpuLimit,omitempty"`    // empty for the service's limit
+	MemoryLimit string `json:"memoryLimit,omitempty"` // empty for the service's limit
+}
+
+// ServiceContainers are the companion containers of a git service
+type ServiceContainers []ServiceContainer
+
+func (c ServiceContainers) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]ServiceContainer{})
+	}
+	return json.Marshal([]ServiceContainer(c))
+}
+
+func (c *ServiceContainers) Scan(value interface{}) error {
+	if value == nil {
+		*c = ServiceContainers{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// SharedVolume is scratch space mounted at the same path in the app container and every
+// companion container. It lives as long as the pod.
+type SharedVolume struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	SizeLimit string `json:"sizeLimit,omitempty"` // e.g. 1Gi; empty for no limit
+}
+
+// SharedVolumes are the shared volumes of a git service's pods
+type SharedVolumes []SharedVolume
+
+func (v SharedVolumes) Value() (driver.Value, error) {
+	if v == nil {
+		return json.Marshal([]SharedVolume{})
+	}
+	return json.Marshal([]SharedVolume(v))
+}
+
+func (v *SharedVolumes) Scan(value interface{}) error {
+	if value == nil {
+		*v = SharedVolumes{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, v)
+}
+
+// ContainerArgs is the exec form of a container's command or arguments, e.g. ["node", "worker.js"]
+type ContainerArgs []string
+
+func (a ContainerArgs) Value() (driver.Value, error) {
+	if a == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *ContainerArgs) Scan(value interface{}) error {
+	if value == nil {
+		*a = ContainerArgs{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, a)
+}
+
 // ServiceType represents different service types
 type ServiceType string
 
@@ -54,12 +179,26 @@ type Service struct {
 	// returned in API responses.
 	GitUsername string `json:"gitUsername" gorm:"default:null"`
 	GitToken    string `json:"-" gorm:"default:null"`
+	// Clone options for large repositories: CloneDepth is the history depth fetched for builds
+	// (0 = 1), SparseCheckoutPaths the comma-separated directories checked out (empty = all)
+	CloneDepth          int    `json:"cloneDepth" gorm:"default:null"`
+	SparseCheckoutPaths string `json:"sparseCheckoutPaths" gorm:"default:null"`
 
 	// Managed services specific fields (only applicable for ServiceTypeManaged)
 	ManagedType string `json:"managedType" gorm:"default:null"` // postgresql, redis, minio, etc.
 	Version     string `json:"version" gorm:"default:null"`     // 14, 6.0, latest, etc.
 	StorageSize string `json:"storageSize" gorm:"default:null"` // 1Gi, 10Gi, etc.
 
+	// Connection pooling (managed PostgreSQL only) - PgBouncer in front of the database
+	PoolingEnabled bool   `json:"poolingEnabled"`                    // no gorm default: a literal false must persist
+	PoolMode       string `json:"poolMode" gorm:"default:null"`      // session, transaction, statement
+	PoolSize       int    `json:"poolSize" gorm:"default:null"`      // server connections per user/database pair
+	MaxClientConn  int    `json:"maxClientConn" gorm:"default:null"` // max client connections accepted by PgBouncer
+
+	// Vertical Pod Autoscaler (managed services only): recommend publishes CPU/memory
+	// recommendations, auto also applies them by recreating the pod; empty disables it
+	VPAMode string `json:"vpaMode" gorm:"type:varchar(10);default:null"`
+
 	// Environment reference
 	EnvironmentID string `json:"environmentId" gorm:"type:uuid;index"`
 
@@ -69,6 +208,42 @@ type Service struct {
 	BuildCommand string  `json:"buildCommand" gorm:"default:null"`
 	StartCommand string  `json:"startCommand" gorm:"default:null"`
 
+	// AutoCorrectPort deploys on the port the Dockerfile EXPOSEs when it exposes exactly
+	// one and it differs from Port; otherwise a mismatch is only reported on the deployment
+	AutoCorrectPort bool `json:"autoCorrectPort"`
+
+	// Dockerfile relative to the repository root (empty = Dockerfile) and fixed --build-arg
+	// values; unlike env vars, build args are not added to the Dockerfile
+	DockerfilePath string  `json:"dockerfilePath" gorm:"default:null"`
+	BuildArgs      EnvVars `json:"buildArgs" gorm:"type:jsonb;default:'{}'"`
+	// TestCommand runs in TestImage on the checkout before the build; a non-zero exit fails the deployment
+	TestCommand string `json:"testCommand" gorm:"default:null"`
+	TestImage   string `json:"testImage" gorm:"default:null"`
+	// Directory in the built image exported to the artifact store after each build
+	ArtifactPath string `json:"artifactPath" gorm:"default:null"`
+	// Comma-separated target platforms, e.g. linux/amd64,linux/arm64; more than one
+	// publishes a manifest list. Empty builds for the architecture of the build node.
+	BuildPlatforms string `json:"buildPlatforms" gorm:"default:null"`
+	// Build backend building the service's images: kaniko, buildkit or external. Empty uses
+	// the cluster's, or another one when the cluster's lacks a capability the build needs.
+	BuildBackend string `json:"buildBackend" gorm:"type:varchar(20);default:null"`
+	// BuildSecrets mounts the secret env vars as build secrets (RUN --mount=type=secret,id=NAME)
+	// instead of keeping them out of the build; needs a backend with build secrets
+	BuildSecrets bool `json:"buildSecrets" gorm:"default:false"`
+	// How long a build (test stage included) may run; 0 for the default of 12 minutes. Capped
+	// by the platform settings.
+	BuildTimeoutMinutes int `json:"buildTimeoutMinutes" gorm:"default:null"`
+	// Largest image (compressed layers and config, as pushed) a build may produce, e.g. 500Mi;
+	// empty for no budget. Larger images warn, or fail the deployment with the fail action.
+	MaxImageSize          string `json:"maxImageSize" gorm:"default:null"`
+	ImageSizeBudgetAction string `json:"imageSizeBudgetAction" gorm:"type:varchar(10);default:null"` // warn (default) or fail
+	// Comma-separated names of env vars flagged as secret. Their values are masked in API
+	// responses and logs, injected from the service's env Secret and never passed to builds.
+	SecretEnvKeys string `json:"secretEnvKeys" gorm:"default:null"`
+	// Comma-separated names of env vars the build depends on (e.g. inlined by a bundler).
+	// Changing them rebuilds the image; other changes are applied to the running Deployment.
+	BuildEnvKeys string `json:"buildEnvKeys" gorm:"default:null"`
+
 	// Resources & Scaling
 	CPULimit        string `json:"cpuLimit" gorm:"default:1024m"`
 	MemoryLimit     string `json:"memoryLimit" gorm:"default:2Gi"`
@@ -76,15 +251,117 @@ type Service struct {
 	Replicas        int    `json:"replicas" gorm:"default:1"`
 	MinReplicas     int    `json:"minReplicas" gorm:"default:1"`
 	MaxReplicas     int    `json:"maxReplicas" gorm:"default:3"`
+	// HighAvailability spreads the replicas across nodes (strictly) and zones (best effort)
+	HighAvailability bool `json:"highAvailability"`
+
+	// Graceful termination: PreStopCommand runs (with sh -c) in the app container before it
+	// gets SIGTERM, e.g. to stop consuming or wait for the ingress to stop routing to the pod.
+	// Both must finish within TerminationGracePeriodSeconds (0 for the Kubernetes default of
+	// 30) or the pod is killed.
+	TerminationGracePeriodSeconds int    `json:"terminationGracePeriodSeconds" gorm:"default:null"`
+	PreStopCommand                string `json:"preStopCommand" gorm:"type:text;default:null"`
+
+	// Overrides of the image's ENTRYPOINT, CMD and WORKDIR for the app container, so one image
+	// can run as a web server in one service and as a worker in another; empty keeps the
+	// image's own. Not run through a shell.
+	Command    ContainerArgs `json:"command" gorm:"type:jsonb;default:'[]'"`
+	Args       ContainerArgs `json:"args" gorm:"type:jsonb;default:'[]'"`
+	WorkingDir string        `json:"workingDir" gorm:"default:null"`
 
 	// Domain
-	Domain       string `json:"domain" gorm:"default:null"` // auto-generated
+	BaseDomain   string `json:"baseDomain" gorm:"default:null"` // copied from the project at creation; empty = platform default
+	Domain       string `json:"domain" gorm:"default:null"`     // auto-generated
 	CustomDomain string `json:"customDomain" gorm:"default:null"`
+	// TLS Secret with an uploaded certificate for CustomDomain; empty = issued by cert-manager
+	CustomTLSSecret string `json:"customTlsSecret" gorm:"default:null"`
+	// InternalAlias is a stable name other services in the environment reach this one at
+	// (e.g. "db" instead of s-<id>.<environment-id>.svc.cluster.local); unique per environment
+	InternalAlias string `json:"internalAlias" gorm:"default:null"`
+	// ACME challenge for generated certificates: http01 (default) or dns01 for
+	// domains behind proxies or not reachable from the internet
+	TLSChallenge string `json:"tlsChallenge" gorm:"type:varchar(10);default:null"`
+	// CacheRules cache responses under path prefixes at the ingress (git services only)
+	CacheRules CacheRules `json:"cacheRules" gorm:"type:jsonb;default:'[]'"`
+	// Companion containers run beside the app container in every pod, and the volumes they
+	// share with it (git services only)
+	Containers    ServiceContainers `json:"containers" gorm:"type:jsonb;default:'[]'"`
+	SharedVolumes SharedVolumes     `json:"sharedVolumes" gorm:"type:jsonb;default:'[]'"`
+
+	// Annotations of the service's dedicated ServiceAccount (cloud workload identity)
+	ServiceAccountAnnotations EnvVars `json:"serviceAccountAnnotations" gorm:"type:jsonb;default:'{}'"`
+
+	// User-defined pod labels and annotations for observability tooling (e.g. prometheus.io/scrape)
+	PodLabels      EnvVars `json:"podLabels" gorm:"type:jsonb;default:'{}'"`
+	PodAnnotations EnvVars `json:"podAnnotations" gorm:"type:jsonb;default:'{}'"`
+
 	ExternalHost string `json:"externalHost" gorm:"default:null"`
 	ExternalPort int    `json:"externalPort" gorm:"default:null"`
+	// Comma-separated CIDRs or IPs allowed to connect through the TCP proxy; empty allows any source
+	ExternalAllowedCIDRs string `json:"externalAllowedCidrs" gorm:"default:null"`
+	// DatabaseTLS serves external connections over TLS with a cert-manager certificate for the
+	// TCP proxy host (postgresql, mysql and redis)
+	DatabaseTLS bool `json:"databaseTls"`
+	// Topology is chosen at creation; empty runs a single instance, "sentinel" (redis) runs a
+	// primary and a replica with Sentinel failover
+	Topology string `json:"topology" gorm:"type:varchar(20);default:null"`
+	// AutoUpdate is the version update channel: pinned (default), patch or minor. Updates
+	// start in the maintenance window, a cron expression in UTC opening a one-hour window.
+	AutoUpdate        string `json:"autoUpdate" gorm:"type:varchar(10);default:null"`
+	MaintenanceWindow string `json:"maintenanceWindow" gorm:"default:null"`
 
 	// Status
-	Status string `json:"status" gorm:"default:inactive"` // inactive, building, running, failed
+	Status string `json:"status" gorm:"default:inactive"` // inactive, building, starting, running, failed
+
+	// Protected services cannot be deleted, nor can the environment that contains them
+	DeletionProtected bool `json:"deletionProtected"` // no gorm default: a literal false must persist
+
+	// A service pinned to a deployment keeps running it: pushes received on the deploy
+	// webhook are ignored until it is unpinned (git services only)
+	PinnedDeploymentID *string    `json:"pinnedDeploymentId" gorm:"type:uuid;default:null"`
+	PinnedAt           *time.Time `json:"pinnedAt"`
+	PinnedBy           string     `json:"pinnedBy" gorm:"default:null"`
+	PinReason          string     `json:"pinReason" gorm:"default:null"`
+
+	// Set by the anomaly detector while the service's usage departs from its baseline, e.g.
+	// sudden memory growth; AttentionReason describes the open anomalies
+	AttentionNeeded bool   `json:"attentionNeeded"`
+	AttentionReason string `json:"attentionReason" gorm:"type:text;default:null"`
+
+	// The commit, image and build config (utils.BuildInputsDigest) of the last successful
+	// build: deploying the same commit with the same build config reuses the image
+	LastBuiltCommitSHA    string `json:"lastBuiltCommitSha" gorm:"default:null"`
+	LastBuiltImage        string `json:"lastBuiltImage" gorm:"default:null"`
+	LastBuildInputsDigest string `json:"-" gorm:"default:null"`
+
+	// Health is filled in from readiness probes when a service is fetched (managed services only)
+	Health *ServiceHealth `json:"health,omitempty" gorm:"-"`
+
+	// VPARecommendation is read from the service's VerticalPodAutoscaler when it is fetched
+	VPARecommendation *VPARecommendation `json:"vpaRecommendation,omitempty" gorm:"-"`
+
+	// DomainStatuses are the last propagation checks of the service's hostnames, filled in
+	// when a git service is fetched
+	DomainStatuses []DomainStatus `json:"domainStatuses,omitempty" gorm:"-"`
+
+	// SecretEnvVars are the env vars whose ${secret:name} references were resolved against the
+	// project secrets vault, filled in before each deploy
+	SecretEnvVars EnvVars `json:"-" gorm:"-"`
+
+	// ImagePullSecrets are the pull credential Secrets of the environment, resolved before each deploy
+	ImagePullSecrets []string `json:"-" gorm:"-"`
+
+	// DomainTemplate is the environment's template for generated hostnames with the
+	// environment placeholders filled in, resolved before each deploy
+	DomainTemplate string `json:"-" gorm:"-"`
+
+	// PriorityClassName and BuildPriorityClassName come from the environment's and the build
+	// tier, resolved before each deploy or build
+	PriorityClassName      string `json:"-" gorm:"-"`
+	BuildPriorityClassName string `json:"-" gorm:"-"`
+
+	// DeferIngress leaves the Ingresses out of a deploy. A service moved to another
+	// environment starts there first and has its Ingresses flipped over once it is ready.
+	DeferIngress bool `json:"-" gorm:"-"`
 
 	// API Key for webhooks
 	APIKey string `json:"apiKey" gorm:"type:uuid;default:gen_random_uuid()"`
@@ -98,3 +375,48 @@ type Service struct {
 	Environment Environment  `json:"environment,omitempty" gorm:"foreignKey:EnvironmentID"`
 	Deployments []Deployment `json:"deployments,omitempty" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
 }
+
+// IsSecretEnvVar reports whether the env var is flagged as secret
+func (s Service) IsSecretEnvVar(key string) bool {
+	for _, secretKey := range strings.Split(s.SecretEnvKeys, ",") {
+		if strings.TrimSpace(secretKey) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBuildEnvVar reports whether the env var is flagged as needed at build time
+func (s Service) IsBuildEnvVar(key string) bool {
+	for _, buildKey := range strings.Split(s.BuildEnvKeys, ",") {
+		if strings.TrimSpace(buildKey) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskSecretEnvVars returns a copy of envVars, e.g. the service's or those of one of its
+// revisions, with the values of the service's secret env vars masked
+func (s Service) MaskSecretEnvVars(envVars EnvVars) EnvVars {
+	if s.SecretEnvKeys == "" || envVars == nil {
+		return envVars
+	}
+	masked := make(EnvVars, len(envVars))
+	for key, value := range envVars {
+		if value != "" && s.IsSecretEnvVar(key) {
+			value = MaskedSecretValue
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// MarshalJSON masks secret env vars so no API response carries their values.
+// The reveal endpoint returns them explicitly.
+func (s Service) MarshalJSON() ([]byte, error) {
+	type service Service
+	masked := service(s)
+	masked.EnvVars = s.MaskSecretEnvVars(s.EnvVars)
+	return json.Marshal(masked)
+}
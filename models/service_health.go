@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ServiceHealthStatus represents probe-derived runtime health of a service
+type ServiceHealthStatus string
+
+const (
+	ServiceHealthHealthy     ServiceHealthStatus = "healthy"     // at least one pod passes its readiness probe
+	ServiceHealthStarting    ServiceHealthStatus = "starting"    // pods exist but are still booting
+	ServiceHealthUnhealthy   ServiceHealthStatus = "unhealthy"   // pods are crash-looping or failing probes
+	ServiceHealthUnavailable ServiceHealthStatus = "unavailable" // no pods are running
+)
+
+// ServiceHealth is computed from the live pods on read and never persisted
+type ServiceHealth struct {
+	Status        ServiceHealthStatus `json:"status"`
+	ReadyReplicas int                 `json:"readyReplicas"`
+	Replicas      int                 `json:"replicas"`
+	Restarts      int32               `json:"restarts"`
+	Message       string              `json:"message,omitempty"`
+	CheckedAt     time.Time           `json:"checkedAt"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Signal kinds correlated into service incidents
+const (
+	IncidentSignalDeploymentFailed = "deployment_failed"
+	IncidentSignalCrashLoop        = "crash_loop"
+	IncidentSignalProbeFailure     = "probe_failure"
+	IncidentSignalUptimeDown       = "uptime_down"
+)
+
+// ServiceIncident groups the failure signals observed on a service into one record. It is
+// opened by the incident detector when a signal appears and resolved once every signal has
+// been clear for a grace period. Unlike Incident, it is never shown on status pages.
+type ServiceIncident struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID     string     `json:"serviceId" gorm:"type:uuid;not null;index:idx_service_incidents_service_started"`
+	StartedAt     time.Time  `json:"startedAt" gorm:"not null;index:idx_service_incidents_service_started"`
+	ResolvedAt    *time.Time `json:"resolvedAt"`
+	LastSignalAt  time.Time  `json:"lastSignalAt" gorm:"not null"`
+	ProbableCause string     `json:"probableCause"`
+
+	Events []ServiceIncidentEvent `json:"events" gorm:"foreignKey:IncidentID;constraint:OnDelete:CASCADE"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"autoUpdateTime"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
+
+// ServiceIncidentEvent is one signal on an incident's timeline. Key identifies the signal
+// source (a deployment, a pod container) so repeated observations extend the same event.
+type ServiceIncidentEvent struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	IncidentID string     `json:"incidentId" gorm:"type:uuid;not null;index"`
+	Kind       string     `json:"kind" gorm:"type:varchar(30);not null"`
+	Key        string     `json:"key" gorm:"not null"`
+	Message    string     `json:"message"`
+	StartedAt  time.Time  `json:"startedAt" gorm:"not null"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+}
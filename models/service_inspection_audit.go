@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// What an admin inspected through the admin service inspection endpoints
+const (
+	ServiceInspectionManifests = "manifests"
+	ServiceInspectionEvents    = "events"
+	ServiceInspectionLogs      = "logs"
+)
+
+// ServiceInspectionAuditLog records every time an admin read a service's manifests, events
+// or logs through the admin inspection endpoints. Like the impersonation audit trail it keeps
+// no foreign keys, so it outlives deleted services and users.
+type ServiceInspectionAuditLog struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	AdminID   string    `json:"adminId" gorm:"type:uuid;not null;index"`
+	ServiceID string    `json:"serviceId" gorm:"type:uuid;not null;index"`
+	ProjectID string    `json:"projectId" gorm:"type:uuid;not null;index"`
+	Resource  string    `json:"resource" gorm:"type:varchar(20);not null"` // manifests, events or logs
+	Reason    string    `json:"reason,omitempty" gorm:"type:text"`
+	IPAddress string    `json:"ipAddress" gorm:"type:varchar(45)"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
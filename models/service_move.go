@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Service move states
+const (
+	ServiceMovePending    = "pending"
+	ServiceMoveCopying    = "copying"     // the data volumes are copied into the target namespace
+	ServiceMoveDeploying  = "deploying"   // the workload starts in the target namespace
+	ServiceMoveSwitching  = "switching"   // Ingresses, the TCP proxy and the service record move over
+	ServiceMoveCompleted  = "completed"   // the service runs in the target environment
+	ServiceMoveRolledBack = "rolled_back" // the move failed; the service runs in the source environment again
+	ServiceMoveFailed     = "failed"      // the move failed and could not be rolled back completely
+)
+
+// ServiceMove is the migration of a service to another environment of its project. The
+// workload is recreated in the target namespace, with the data volumes of managed services
+// copied over, before the Ingresses and the record are switched and the source is removed.
+type ServiceMove struct {
+	ID                  string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID           string     `json:"serviceId" gorm:"type:uuid;not null;index:idx_service_moves_service_started"`
+	SourceEnvironmentID string     `json:"sourceEnvironmentId" gorm:"type:uuid;not null"`
+	TargetEnvironmentID string     `json:"targetEnvironmentId" gorm:"type:uuid;not null"`
+	Status              string     `json:"status" gorm:"type:varchar(20);not null"`
+	Message             string     `json:"message" gorm:"type:text;default:null"`
+	RequestedBy         string     `json:"requestedBy" gorm:"type:uuid"`
+	StartedAt           time.Time  `json:"startedAt" gorm:"not null;index:idx_service_moves_service_started"`
+	CompletedAt         *time.Time `json:"completedAt" gorm:"default:null"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
+
+// IsFinished reports whether the move completed, was rolled back or failed
+func (m ServiceMove) IsFinished() bool {
+	return m.Status == ServiceMoveCompleted || m.Status == ServiceMoveRolledBack || m.Status == ServiceMoveFailed
+}
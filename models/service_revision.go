@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// ServiceRevision is a snapshot of the user-editable configuration of a service
+// (environment variables, resources and domain), recorded each time it changes
+type ServiceRevision struct {
+	ID        string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID string `json:"serviceId" gorm:"type:uuid;not null;uniqueIndex:idx_service_revisions_number"`
+	Revision  int    `json:"revision" gorm:"not null;uniqueIndex:idx_service_revisions_number"`
+
+	EnvVars         EnvVars `json:"envVars" gorm:"type:jsonb;default:'{}'"`
+	CPULimit        string  `json:"cpuLimit" gorm:"default:null"`
+	MemoryLimit     string  `json:"memoryLimit" gorm:"default:null"`
+	StorageSize     string  `json:"storageSize" gorm:"default:null"`
+	IsStaticReplica bool    `json:"isStaticReplica"`
+	Replicas        int     `json:"replicas"`
+	MinReplicas     int     `json:"minReplicas"`
+	MaxReplicas     int     `json:"maxReplicas"`
+	CustomDomain    string  `json:"customDomain" gorm:"default:null"`
+
+	// RevertedFrom is the revision restored when this one was created by a revert
+	RevertedFrom *int      `json:"revertedFrom,omitempty" gorm:"default:null"`
+	CreatedBy    string    `json:"createdBy" gorm:"type:uuid;default:null"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"autoCreateTime"`
+}
+
+// SameConfig reports whether two revisions hold the same configuration
+func (r ServiceRevision) SameConfig(other ServiceRevision) bool {
+	if len(r.EnvVars) != len(other.EnvVars) {
+		return false
+	}
+	for key, value := range r.EnvVars {
+		if otherValue, ok := other.EnvVars[key]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return r.CPULimit == other.CPULimit &&
+		r.MemoryLimit == other.MemoryLimit &&
+		r.StorageSize == other.StorageSize &&
+		r.IsStaticReplica == other.IsStaticReplica &&
+		r.Replicas == other.Replicas &&
+		r.MinReplicas == other.MinReplicas &&
+		r.MaxReplicas == other.MaxReplicas &&
+		r.CustomDomain == other.CustomDomain
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Outcomes of an automatic version update
+const (
+	VersionUpdateRunning    = "running"
+	VersionUpdateSucceeded  = "succeeded"
+	VersionUpdateFailed     = "failed"      // nothing was changed, e.g. the backup failed
+	VersionUpdateRolledBack = "rolled_back" // the new version was unhealthy and the old one was restored
+)
+
+// ServiceVersionUpdate records an automatic version update of a managed service, including
+// the volume snapshots taken before it so data can be restored by hand
+type ServiceVersionUpdate struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID   string     `json:"serviceId" gorm:"type:uuid;not null;index:idx_service_version_updates_service_started"`
+	FromVersion string     `json:"fromVersion" gorm:"not null"`
+	ToVersion   string     `json:"toVersion" gorm:"not null"`
+	Snapshots   string     `json:"snapshots"` // comma-separated VolumeSnapshot names
+	Status      string     `json:"status" gorm:"type:varchar(20);not null"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"startedAt" gorm:"not null;index:idx_service_version_updates_service_started"`
+	FinishedAt  *time.Time `json:"finishedAt"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
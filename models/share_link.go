@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// ShareLink grants read-only access to the status and runtime logs of an environment's
+// services, or of one service, through a signed URL until it expires or is revoked. The
+// signed token is shown once and is not stored.
+type ShareLink struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	EnvironmentID string     `json:"environmentId" gorm:"type:uuid;not null;index"`
+	ServiceID     *string    `json:"serviceId" gorm:"type:uuid;default:null"` // nil shares every service of the environment
+	Label         string     `json:"label" gorm:"default:null"`               // who or what the link is for
+	CreatedBy     string     `json:"createdBy" gorm:"type:uuid;not null"`
+	ExpiresAt     time.Time  `json:"expiresAt" gorm:"not null"`
+	RevokedAt     *time.Time `json:"revokedAt" gorm:"default:null"`
+	LastUsedAt    *time.Time `json:"lastUsedAt" gorm:"default:null"`
+	CreatedAt     time.Time  `json:"createdAt"`
+
+	// Relation
+	Environment Environment `json:"-" gorm:"foreignKey:EnvironmentID;constraint:OnDelete:CASCADE"`
+}
+
+// IsActive reports whether the link still grants access
+func (l ShareLink) IsActive() bool {
+	return l.RevokedAt == nil && time.Now().Before(l.ExpiresAt)
+}
+
+// Covers reports whether the link grants access to the service
+func (l ShareLink) Covers(serviceID string) bool {
+	return l.ServiceID == nil || *l.ServiceID == serviceID
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// Incident states, in the order they usually progress
+const (
+	IncidentStatusInvestigating = "investigating"
+	IncidentStatusIdentified    = "identified"
+	IncidentStatusMonitoring    = "monitoring"
+	IncidentStatusResolved      = "resolved"
+)
+
+// StatusPage publishes the uptime of a project's monitored services at a public slug
+type StatusPage struct {
+	ProjectID string `json:"projectId" gorm:"primaryKey;type:uuid"`
+	Slug      string `json:"slug" gorm:"type:varchar(63);not null;uniqueIndex"`
+	Title     string `json:"title" gorm:"default:null"` // defaults to the project name
+	Enabled   bool   `json:"enabled"`                   // no gorm default: a literal false must persist
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
+
+// Incident is an annotation shown on the project's status page, optionally tied to a service
+type Incident struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ProjectID  string     `json:"projectId" gorm:"type:uuid;not null;index"`
+	ServiceID  *string    `json:"serviceId" gorm:"type:uuid;index"`
+	Title      string     `json:"title" gorm:"not null"`
+	Message    string     `json:"message" gorm:"type:text;default:null"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null"`
+	StartedAt  time.Time  `json:"startedAt" gorm:"not null"`
+	ResolvedAt *time.Time `json:"resolvedAt" gorm:"default:null"`
+	CreatedBy  string     `json:"createdBy" gorm:"type:uuid;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Project Project `json:"-" gorm:"foreignKey:ProjectID;constraint:OnDelete:CASCADE"`
+}
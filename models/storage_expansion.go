@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Storage expansion states, of an expansion and of each of its claims
+const (
+	StorageExpansionResizing                = "resizing"                  // the volume is being grown by the storage driver
+	StorageExpansionFileSystemResizePending = "filesystem_resize_pending" // the volume grew; the filesystem grows when a pod mounts it
+	StorageExpansionCompleted               = "completed"
+	StorageExpansionFailed                  = "failed"
+)
+
+// StorageClaimStatus is the progress of one PersistentVolumeClaim of an expansion
+type StorageClaimStatus struct {
+	Name     string `json:"name"`
+	Capacity string `json:"capacity"` // reported by the volume
+	State    string `json:"state"`
+	Message  string `json:"message,omitempty"`
+}
+
+// StorageClaimStatuses are the claims of an expansion in name order
+type StorageClaimStatuses []StorageClaimStatus
+
+func (c StorageClaimStatuses) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *StorageClaimStatuses) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// StorageExpansion is the online expansion of the data volumes of a managed service. The
+// claims are grown in place and watched until the volume and its filesystem report the new
+// size, without redeploying the service.
+type StorageExpansion struct {
+	ID          string               `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID   string               `json:"serviceId" gorm:"type:uuid;not null;index:idx_storage_expansions_service_started"`
+	FromSize    string               `json:"fromSize" gorm:"not null"`
+	ToSize      string               `json:"toSize" gorm:"not null"`
+	Status      string               `json:"status" gorm:"type:varchar(30);not null"`
+	Message     string               `json:"message" gorm:"type:text;default:null"`
+	Claims      StorageClaimStatuses `json:"claims" gorm:"type:jsonb"`
+	RequestedBy string               `json:"requestedBy" gorm:"type:uuid"`
+	StartedAt   time.Time            `json:"startedAt" gorm:"not null;index:idx_storage_expansions_service_started"`
+	CompletedAt *time.Time           `json:"completedAt" gorm:"default:null"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
+
+// IsFinished reports whether the expansion completed or failed
+func (e StorageExpansion) IsFinished() bool {
+	return e.Status == StorageExpansionCompleted || e.Status == StorageExpansionFailed
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TenantIsolationPolicyID is the primary key of the single tenant isolation policy row
+const TenantIsolationPolicyID = 1
+
+// TenantIsolationPolicy is the admin toggle of the NetworkPolicies that keep workload
+// namespaces from reaching the platform's own namespaces. Without a row the defaults of
+// DefaultTenantIsolationPolicy apply.
+type TenantIsolationPolicy struct {
+	ID        int       `json:"-" gorm:"primaryKey"`
+	Enabled   bool      `json:"enabled"` // no gorm default: a literal false must persist
+	UpdatedBy string    `json:"updatedBy,omitempty" gorm:"type:uuid;default:null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DefaultTenantIsolationPolicy is the policy in effect until an admin changes it
+func DefaultTenantIsolationPolicy() TenantIsolationPolicy {
+	return TenantIsolationPolicy{
+		ID:      TenantIsolationPolicyID,
+		Enabled: true,
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// UptimeMonitor configures periodic HTTP checks of a git service's health URL
+type UptimeMonitor struct {
+	ServiceID       string     `json:"serviceId" gorm:"primaryKey;type:uuid"`
+	Path            string     `json:"path" gorm:"not null;default:'/'"` // requested on the service's public hostname
+	IntervalSeconds int        `json:"intervalSeconds" gorm:"not null;default:60"`
+	Enabled         bool       `json:"enabled"` // no gorm default: a literal false must persist
+	LastCheckedAt   *time.Time `json:"lastCheckedAt" gorm:"default:null"`
+	UpdatedBy       string     `json:"updatedBy" gorm:"type:uuid;default:null"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relation
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
+
+// UptimeCheck is the result of one probe of a service's health URL
+type UptimeCheck struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID  string    `json:"serviceId" gorm:"type:uuid;not null;index:idx_uptime_checks_service_time"`
+	CheckedAt  time.Time `json:"checkedAt" gorm:"not null;index:idx_uptime_checks_service_time"`
+	Up         bool      `json:"up"`
+	StatusCode int       `json:"statusCode"` // 0 when no response was received
+	LatencyMs  int64     `json:"latencyMs"`
+	Error      string    `json:"error,omitempty" gorm:"type:text;default:null"`
+}
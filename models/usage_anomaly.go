@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Kinds of usage anomalies
+const (
+	UsageAnomalyMemoryGrowth  = "memory_growth"
+	UsageAnomalyCPUSaturation = "cpu_saturation"
+	UsageAnomalyRestartStorm  = "restart_storm"
+)
+
+// UsageAnomaly is a departure of a service's recent usage from its baseline, found in the
+// usage history by the anomaly detector. It stays open while the departure lasts and marks
+// the service as needing attention. Observed and Baseline are per-pod millicores for CPU,
+// per-pod bytes for memory and container restarts within the detection window for restarts.
+type UsageAnomaly struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID  string     `json:"serviceId" gorm:"type:uuid;not null;index:idx_usage_anomalies_service_detected"`
+	Kind       string     `json:"kind" gorm:"type:varchar(30);not null"`
+	Message    string     `json:"message"`
+	Observed   int64      `json:"observed"`
+	Baseline   int64      `json:"baseline"`
+	DetectedAt time.Time  `json:"detectedAt" gorm:"not null;index:idx_usage_anomalies_service_detected"`
+	LastSeenAt time.Time  `json:"lastSeenAt" gorm:"not null"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+
+	Service Service `json:"-" gorm:"foreignKey:ServiceID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// ClusterUsageSample is a periodic snapshot of the cluster's total resource usage.
+// CPU values are in millicores, memory and storage values in bytes.
+type ClusterUsageSample struct {
+	ID                string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	SampledAt         time.Time `json:"sampledAt" gorm:"not null;index"`
+	Nodes             int       `json:"nodes"` // Ready, schedulable nodes
+	CPUAllocatable    int64     `json:"cpuAllocatable"`
+	CPURequested      int64     `json:"cpuRequested"`
+	CPUUsed           int64     `json:"cpuUsed"` // 0 when the metrics API was unavailable
+	MemoryAllocatable int64     `json:"memoryAllocatable"`
+	MemoryRequested   int64     `json:"memoryRequested"`
+	MemoryUsed        int64     `json:"memoryUsed"`
+	StorageCapacity   int64     `json:"storageCapacity"` // node filesystems, 0 when kubelet stats were unavailable
+	StorageUsed       int64     `json:"storageUsed"`
+}
+
+// ServiceUsageSample is a periodic snapshot of a service's pod usage. The per-pod
+// maximums are compared with the per-pod limits when sizing a service.
+type ServiceUsageSample struct {
+	ID               string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ServiceID        string    `json:"serviceId" gorm:"type:uuid;not null;index:idx_service_usage_samples_service_time"`
+	SampledAt        time.Time `json:"sampledAt" gorm:"not null;index:idx_service_usage_samples_service_time"`
+	Pods             int       `json:"pods"`
+	CPUUsed          int64     `json:"cpuUsed"`
+	MemoryUsed       int64     `json:"memoryUsed"`
+	MaxPodCPUUsed    int64     `json:"maxPodCpuUsed"`
+	MaxPodMemoryUsed int64     `json:"maxPodMemoryUsed"`
+	// Container restarts of the running pods so far; it drops when pods are replaced. Nil on
+	// samples recorded before restarts were.
+	Restarts *int `json:"restarts"`
+}
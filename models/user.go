@@ -24,4 +24,18 @@ type User struct {
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Two-factor authentication. The TOTP secret is generated at setup and only takes
+	// effect once a code has confirmed it.
+	TwoFactorEnabled  bool   `json:"twoFactorEnabled" gorm:"default:false"`
+	TwoFactorSecret   string `json:"-" gorm:"default:null"`
+	TwoFactorLastStep int64  `json:"-" gorm:"default:0"` // time step of the last accepted code, against replays
+
+	// Brute-force lockout
+	FailedLogins int        `json:"-" gorm:"default:0"` // consecutive failed logins
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty" gorm:"default:null"`
+
+	// Session JWTs issued before sessions were tracked carry no ID; these are rejected when
+	// issued before this time
+	SessionsRevokedAt *time.Time `json:"-" gorm:"default:null"`
 }
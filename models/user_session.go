@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession is a signed-in browser or client session. Its ID is the jti claim of the
+// session JWT, so the token can be revoked before it expires.
+type UserSession struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID     string     `json:"userId" gorm:"type:uuid;not null;index"`
+	IPAddress  string     `json:"ipAddress" gorm:"type:varchar(45)"`
+	UserAgent  string     `json:"userAgent" gorm:"type:text"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"index"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" gorm:"default:null;index"`
+	CreatedAt  time.Time  `json:"createdAt"`
+
+	// Set on sessions an admin opened to act as the user for support
+	ImpersonatorID      *string `json:"impersonatorId,omitempty" gorm:"type:uuid;default:null;index"`
+	ImpersonationReason string  `json:"impersonationReason,omitempty" gorm:"type:text"`
+
+	// Relation
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// IsActive reports whether the session can still be used
+func (s UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
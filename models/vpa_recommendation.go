@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// VPAResources is a CPU/memory pair as reported by the Vertical Pod Autoscaler
+type VPAResources struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// VPAContainerRecommendation is the recommender's estimate for one container
+type VPAContainerRecommendation struct {
+	ContainerName string       `json:"containerName"`
+	Target        VPAResources `json:"target"`
+	LowerBound    VPAResources `json:"lowerBound"`
+	UpperBound    VPAResources `json:"upperBound"`
+}
+
+// VPARecommendation is read from the service's VerticalPodAutoscaler on fetch and never persisted
+type VPARecommendation struct {
+	Mode       string                       `json:"mode"`
+	Containers []VPAContainerRecommendation `json:"containers"`
+	Message    string                       `json:"message,omitempty"` // e.g. while the recommender is still collecting samples
+	CheckedAt  time.Time                    `json:"checkedAt"`
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ActivityRepository reads the records a project's activity feed is built from. Every
+// query returns at most limit rows older than the cursor, newest first, ordered by time
+// and then ID so rows with the same timestamp page consistently.
+type ActivityRepository struct{}
+
+// NewActivityRepository creates a new activity repository instance
+func NewActivityRepository() *ActivityRepository {
+	return &ActivityRepository{}
+}
+
+// ActivityCursor is the position of the last item of a feed page; the zero value starts
+// from the newest item
+type ActivityCursor struct {
+	At time.Time
+	ID string
+}
+
+// before restricts a query to rows older than the cursor
+func (c ActivityCursor) before(query *gorm.DB, timeColumn, idColumn string) *gorm.DB {
+	if c.At.IsZero() {
+		return query
+	}
+	return query.Where("("+timeColumn+" < ? OR ("+timeColumn+" = ? AND "+idColumn+" < ?))", c.At, c.At, c.ID)
+}
+
+// FindDeployments retrieves the deployments of a project's services
+func (r *ActivityRepository) FindDeployments(projectID string, cursor ActivityCursor, limit int) ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	query := database.Reader().
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ?", projectID)
+	result := cursor.before(query, "deployments.created_at", "deployments.id").
+		Order("deployments.created_at DESC, deployments.id DESC").
+		Limit(limit).
+		Find(&deployments)
+	return deployments, result.Error
+}
+
+// FindRevisions retrieves the config revisions of a project's services
+func (r *ActivityRepository) FindRevisions(projectID string, cursor ActivityCursor, limit int) ([]models.ServiceRevision, error) {
+	var revisions []models.ServiceRevision
+	query := database.Reader().
+		Joins("JOIN services ON services.id = service_revisions.service_id").
+		Where("services.project_id = ?", projectID)
+	result := cursor.before(query, "service_revisions.created_at", "service_revisions.id").
+		Order("service_revisions.created_at DESC, service_revisions.id DESC").
+		Limit(limit).
+		Find(&revisions)
+	return revisions, result.Error
+}
+
+// FindPreviousRevisions retrieves, for each revision, the one it replaced, keyed by
+// service ID and revision number
+func (r *ActivityRepository) FindPreviousRevisions(revisions []models.ServiceRevision) (map[string]map[int]models.ServiceRevision, error) {
+	previous := make(map[string]map[int]models.ServiceRevision)
+	if len(revisions) == 0 {
+		return previous, nil
+	}
+
+	serviceIDs := make([]string, 0, len(revisions))
+	numbers := make([]int, 0, len(revisions))
+	for _, revision := range revisions {
+		serviceIDs = append(serviceIDs, revision.ServiceID)
+		numbers = append(numbers, revision.Revision-1)
+	}
+
+	// Matches a few extra rows when services share revision numbers; they are ignored
+	var found []models.ServiceRevision
+	result := database.Reader().
+		Where("service_id IN ? AND revision IN ?", serviceIDs, numbers).
+		Find(&found)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, revision := range found {
+		if previous[revision.ServiceID] == nil {
+			previous[revision.ServiceID] = make(map[int]models.ServiceRevision)
+		}
+		previous[revision.ServiceID][revision.Revision] = revision
+	}
+	return previous, nil
+}
+
+// FindServiceIncidents retrieves the detected incidents of a project's services
+func (r *ActivityRepository) FindServiceIncidents(projectID string, cursor ActivityCursor, limit int) ([]models.ServiceIncident, error) {
+	var incidents []models.ServiceIncident
+	query := database.Reader().
+		Joins("JOIN services ON services.id = service_incidents.service_id").
+		Where("services.project_id = ?", projectID)
+	result := cursor.before(query, "service_incidents.started_at", "service_incidents.id").
+		Order("service_incidents.started_at DESC, service_incidents.id DESC").
+		Limit(limit).
+		Find(&incidents)
+	return incidents, result.Error
+}
+
+// FindStatusPageIncidents retrieves the incidents annotated on a project's status page
+func (r *ActivityRepository) FindStatusPageIncidents(projectID string, cursor ActivityCursor, limit int) ([]models.Incident, error) {
+	var incidents []models.Incident
+	query := database.Reader().Where("project_id = ?", projectID)
+	result := cursor.before(query, "started_at", "id").
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&incidents)
+	return incidents, result.Error
+}
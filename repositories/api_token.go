@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// APITokenRepository handles database operations for API tokens
+type APITokenRepository struct{}
+
+// NewAPITokenRepository creates a new API token repository instance
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{}
+}
+
+// FindByHash retrieves an unrevoked token by the hash of its secret
+func (r *APITokenRepository) FindByHash(hash string) (models.APIToken, error) {
+	var token models.APIToken
+	result := database.DB.First(&token, "token_hash = ?", hash)
+	return token, result.Error
+}
+
+// FindByUserID retrieves the unrevoked tokens of a user, newest first
+func (r *APITokenRepository) FindByUserID(userID string) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	result := database.Reader().Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens)
+	return tokens, result.Error
+}
+
+// CreateTx inserts a token inside the caller's transaction
+func (r *APITokenRepository) CreateTx(tx *gorm.DB, token models.APIToken) (models.APIToken, error) {
+	result := tx.Create(&token)
+	return token, result.Error
+}
+
+// TouchLastUsed records when a token was last used
+func (r *APITokenRepository) TouchLastUsed(id string, at time.Time) error {
+	return database.DB.Model(&models.APIToken{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// Revoke soft-deletes a token of a user
+func (r *APITokenRepository) Revoke(id string, userID string) (int64, error) {
+	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIToken{})
+	return result.RowsAffected, result.Error
+}
+
+// DB returns the database handle for transactions
+func (r *APITokenRepository) DB() *gorm.DB {
+	return database.DB
+}
+
+// RevokeAllForUser soft-deletes every token of a user
+func (r *APITokenRepository) RevokeAllForUser(userID string) (int64, error) {
+	result := database.DB.Where("user_id = ?", userID).Delete(&models.APIToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// latestDeploymentPerService selects the newest deployment of each service, whose archives
+// the retention policy keeps
+const latestDeploymentPerService = `SELECT DISTINCT ON (service_id) id FROM deployments ORDER BY service_id, created_at DESC`
+
+// ArchiveRetentionRepository handles database operations for the retention of archived build
+// logs and artifacts
+type ArchiveRetentionRepository struct{}
+
+// NewArchiveRetentionRepository creates a new archive retention repository instance
+func NewArchiveRetentionRepository() *ArchiveRetentionRepository {
+	return &ArchiveRetentionRepository{}
+}
+
+// ProjectArchiveUsage sums the archived build logs and artifacts of a project
+type ProjectArchiveUsage struct {
+	ProjectID     string
+	BuildLogs     int64
+	BuildLogBytes int64
+	Artifacts     int64
+	ArtifactBytes int64
+}
+
+// FindSettings returns the retention settings, or the defaults when none were saved
+func (r *ArchiveRetentionRepository) FindSettings() (models.ArchiveRetentionSettings, error) {
+	var settings models.ArchiveRetentionSettings
+	result := database.Reader().First(&settings, models.ArchiveRetentionSettingsID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.DefaultArchiveRetentionSettings(), nil
+	}
+	return settings, result.Error
+}
+
+// SaveSettings creates or updates the retention settings
+func (r *ArchiveRetentionRepository) SaveSettings(settings models.ArchiveRetentionSettings) (models.ArchiveRetentionSettings, error) {
+	settings.ID = models.ArchiveRetentionSettingsID
+	result := database.DB.Save(&settings)
+	return settings, result.Error
+}
+
+// FindQuota retrieves the archive quota of a project
+func (r *ArchiveRetentionRepository) FindQuota(projectID string) (models.ArchiveQuota, error) {
+	var quota models.ArchiveQuota
+	result := database.DB.First(&quota, "project_id = ?", projectID)
+	return quota, result.Error
+}
+
+// FindQuotas retrieves every project archive quota
+func (r *ArchiveRetentionRepository) FindQuotas() ([]models.ArchiveQuota, error) {
+	var quotas []models.ArchiveQuota
+	result := database.Reader().Find(&quotas)
+	return quotas, result.Error
+}
+
+// SaveQuota creates or updates the archive quota of a project
+func (r *ArchiveRetentionRepository) SaveQuota(quota models.ArchiveQuota) (models.ArchiveQuota, error) {
+	result := database.DB.Omit("Project").Save(&quota)
+	return quota, result.Error
+}
+
+// DeleteQuota removes the archive quota of a project
+func (r *ArchiveRetentionRepository) DeleteQuota(projectID string) (int64, error) {
+	result := database.DB.Delete(&models.ArchiveQuota{}, "project_id = ?", projectID)
+	return result.RowsAffected, result.Error
+}
+
+// FindUsage sums the archives of every project that has any, or of one project when
+// projectID is set
+func (r *ArchiveRetentionRepository) FindUsage(projectID string) ([]ProjectArchiveUsage, error) {
+	var usage []ProjectArchiveUsage
+	query := database.Reader().Model(&models.Deployment{}).
+		Select(`services.project_id AS project_id,
+			COUNT(*) FILTER (WHERE deployments.build_log_key <> '') AS build_logs,
+			COALESCE(SUM(deployments.build_log_size) FILTER (WHERE deployments.build_log_key <> ''), 0) AS build_log_bytes,
+			COUNT(*) FILTER (WHERE deployments.artifact_key <> '') AS artifacts,
+			COALESCE(SUM(deployments.artifact_size) FILTER (WHERE deployments.artifact_key <> ''), 0) AS artifact_bytes`).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("deployments.build_log_key <> '' OR deployments.artifact_key <> ''")
+	if projectID != "" {
+		query = query.Where("services.project_id = ?", projectID)
+	}
+	result := query.Group("services.project_id").Scan(&usage)
+	return usage, result.Error
+}
+
+// FindExpiredBuildLogs returns the deployments created before cutoff that still have archived
+// build logs, except the latest deployment of each service
+func (r *ArchiveRetentionRepository) FindExpiredBuildLogs(cutoff time.Time) ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	result := database.DB.
+		Where("build_log_key <> '' AND created_at < ?", cutoff).
+		Where("id NOT IN (" + latestDeploymentPerService + ")").
+		Order("created_at ASC").
+		Find(&deployments)
+	return deployments, result.Error
+}
+
+// FindExpiredArtifacts returns the deployments created before cutoff that still have an
+// archived artifact, except the latest deployment of each service
+func (r *ArchiveRetentionRepository) FindExpiredArtifacts(cutoff time.Time) ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	result := database.DB.
+		Where("artifact_key <> '' AND created_at < ?", cutoff).
+		Where("id NOT IN (" + latestDeploymentPerService + ")").
+		Order("created_at ASC").
+		Find(&deployments)
+	return deployments, result.Error
+}
+
+// FindPurgeableArchives returns a project's deployments with archives, oldest first, except
+// the latest deployment of each service
+func (r *ArchiveRetentionRepository) FindPurgeableArchives(projectID string) ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	result := database.DB.Select("deployments.*").
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ?", projectID).
+		Where("deployments.build_log_key <> '' OR deployments.artifact_key <> ''").
+		Where("deployments.id NOT IN (" + latestDeploymentPerService + ")").
+		Order("deployments.created_at ASC").
+		Find(&deployments)
+	return deployments, result.Error
+}
+
+// ClearBuildLog records that a deployment's archived build logs were purged
+func (r *ArchiveRetentionRepository) ClearBuildLog(id string, at time.Time) error {
+	return database.DB.Model(&models.Deployment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"build_log_key":     "",
+		"build_log_size":    0,
+		"archive_purged_at": at,
+	}).Error
+}
+
+// ClearArtifact records that a deployment's archived artifact was purged
+func (r *ArchiveRetentionRepository) ClearArtifact(id string, at time.Time) error {
+	return database.DB.Model(&models.Deployment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"artifact_key":      "",
+		"artifact_size":     0,
+		"archive_purged_at": at,
+	}).Error
+}
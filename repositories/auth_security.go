@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// AuthSecurityRepository handles database operations for login protection: the auth
+// policy, the login audit trail, lockout counters and two-factor state
+type AuthSecurityRepository struct{}
+
+// NewAuthSecurityRepository creates a new auth security repository instance
+func NewAuthSecurityRepository() *AuthSecurityRepository {
+	return &AuthSecurityRepository{}
+}
+
+// FindPolicy retrieves the auth policy, or the defaults when none was saved
+func (r *AuthSecurityRepository) FindPolicy() (models.AuthPolicy, error) {
+	var policy models.AuthPolicy
+	result := database.DB.First(&policy, models.AuthPolicyID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.DefaultAuthPolicy(), nil
+	}
+	return policy, result.Error
+}
+
+// SavePolicy creates or updates the auth policy
+func (r *AuthSecurityRepository) SavePolicy(policy models.AuthPolicy) (models.AuthPolicy, error) {
+	policy.ID = models.AuthPolicyID
+	result := database.DB.Save(&policy)
+	return policy, result.Error
+}
+
+// CreateAttempt records a login attempt
+func (r *AuthSecurityRepository) CreateAttempt(attempt models.LoginAttempt) error {
+	return database.DB.Create(&attempt).Error
+}
+
+// CountFailuresFromIP counts failed logins from an address since a point in time
+func (r *AuthSecurityRepository) CountFailuresFromIP(ipAddress string, since time.Time) (int64, error) {
+	var count int64
+	result := database.DB.Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND created_at >= ?", ipAddress, false, since).
+		Count(&count)
+	return count, result.Error
+}
+
+// FindAttempts retrieves login attempts, newest first, optionally for one email address
+func (r *AuthSecurityRepository) FindAttempts(email string, page, pageSize int) ([]models.LoginAttempt, int64, error) {
+	query := database.Reader().Model(&models.LoginAttempt{})
+	if email != "" {
+		query = query.Where("email = ?", email)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var attempts []models.LoginAttempt
+	result := query.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&attempts)
+	return attempts, total, result.Error
+}
+
+// IncrementFailedLogins adds a failed login to a user's counter and returns the new count
+func (r *AuthSecurityRepository) IncrementFailedLogins(userID string) (int, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Select("failed_logins").First(&user, "id = ?", userID).Error
+	})
+	return user.FailedLogins, err
+}
+
+// LockUser locks a user out until a point in time and resets the failure counter
+func (r *AuthSecurityRepository) LockUser(userID string, until time.Time) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": until}).Error
+}
+
+// UnlockUser clears a user's lockout and failure counter
+func (r *AuthSecurityRepository) UnlockUser(userID string) (int64, error) {
+	result := database.DB.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": nil})
+	return result.RowsAffected, result.Error
+}
+
+// SetTwoFactorSecret stores a not yet confirmed TOTP secret
+func (r *AuthSecurityRepository) SetTwoFactorSecret(userID string, secret string) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("two_factor_secret", secret).Error
+}
+
+// AcceptTwoFactorStep records the time step of an accepted TOTP code. It reports false when
+// a code of this or a later step was already accepted, i.e. the code is being replayed.
+func (r *AuthSecurityRepository) AcceptTwoFactorStep(userID string, step int64) (bool, error) {
+	result := database.DB.Model(&models.User{}).Where("id = ? AND two_factor_last_step < ?", userID, step).
+		UpdateColumn("two_factor_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// EnableTwoFactorTx turns on two-factor authentication and replaces the recovery codes
+// inside the caller's transaction
+func (r *AuthSecurityRepository) EnableTwoFactorTx(tx *gorm.DB, userID string, step int64, codeHashes []string) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{"two_factor_enabled": true, "two_factor_last_step": step}).Error; err != nil {
+		return err
+	}
+	return r.ReplaceRecoveryCodesTx(tx, userID, codeHashes)
+}
+
+// DisableTwoFactor turns off two-factor authentication and deletes the secret and the
+// recovery codes
+func (r *AuthSecurityRepository) DisableTwoFactor(userID string) (int64, error) {
+	var affected int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).
+			UpdateColumns(map[string]interface{}{"two_factor_enabled": false, "two_factor_secret": nil, "two_factor_last_step": 0})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+	return affected, err
+}
+
+// ReplaceRecoveryCodesTx deletes a user's recovery codes and stores new ones
+func (r *AuthSecurityRepository) ReplaceRecoveryCodesTx(tx *gorm.DB, userID string, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return tx.Omit("User").Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused recovery code of a user as used and reports whether one
+// matched
+func (r *AuthSecurityRepository) UseRecoveryCode(userID string, codeHash string) (bool, error) {
+	result := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		UpdateColumn("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes a user has left
+func (r *AuthSecurityRepository) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	var count int64
+	result := database.Reader().Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count)
+	return count, result.Error
+}
+
+// DB returns the database handle for transactions
+func (r *AuthSecurityRepository) DB() *gorm.DB {
+	return database.DB
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BuildCacheRepository handles database operations for build cache entries
+type BuildCacheRepository struct{}
+
+// NewBuildCacheRepository creates a new build cache repository instance
+func NewBuildCacheRepository() *BuildCacheRepository {
+	return &BuildCacheRepository{}
+}
+
+// FindByKey retrieves the cache entry of a build
+func (r *BuildCacheRepository) FindByKey(cacheKey string) (models.BuildCacheEntry, error) {
+	var entry models.BuildCacheEntry
+	result := database.Reader().First(&entry, "cache_key = ?", cacheKey)
+	return entry, result.Error
+}
+
+// Create records the image of a build. The first build of a key wins: a concurrent identical
+// build leaves the existing entry in place.
+func (r *BuildCacheRepository) Create(entry models.BuildCacheEntry) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoNothing: true,
+	}).Create(&entry).Error
+}
+
+// RecordHit counts a deployment that reused the entry's image
+func (r *BuildCacheRepository) RecordHit(id string, at time.Time) error {
+	return database.DB.Model(&models.BuildCacheEntry{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"hit_count":    gorm.Expr("hit_count + 1"),
+			"last_used_at": at,
+		}).Error
+}
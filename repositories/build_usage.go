@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BuildUsageRepository handles database operations for build quotas and build job usage
+type BuildUsageRepository struct{}
+
+// NewBuildUsageRepository creates a new build usage repository instance
+func NewBuildUsageRepository() *BuildUsageRepository {
+	return &BuildUsageRepository{}
+}
+
+// BuildInterval is the run time of one build job; FinishedAt is nil while it runs
+type BuildInterval struct {
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	DurationMs int64
+}
+
+// ProjectBuildTotals sums the build jobs of a project
+type ProjectBuildTotals struct {
+	ProjectID  string
+	Builds     int64
+	DurationMs int64
+}
+
+// FindQuota retrieves the build quota of a project
+func (r *BuildUsageRepository) FindQuota(projectID string) (models.BuildQuota, error) {
+	var quota models.BuildQuota
+	result := database.DB.First(&quota, "project_id = ?", projectID)
+	return quota, result.Error
+}
+
+// FindQuotas retrieves every build quota
+func (r *BuildUsageRepository) FindQuotas() ([]models.BuildQuota, error) {
+	var quotas []models.BuildQuota
+	result := database.Reader().Find(&quotas)
+	return quotas, result.Error
+}
+
+// SaveQuota creates or updates the build quota of a project
+func (r *BuildUsageRepository) SaveQuota(quota models.BuildQuota) (models.BuildQuota, error) {
+	result := database.DB.Omit("Project").Save(&quota)
+	return quota, result.Error
+}
+
+// DeleteQuota removes the build quota of a project
+func (r *BuildUsageRepository) DeleteQuota(projectID string) (int64, error) {
+	result := database.DB.Delete(&models.BuildQuota{}, "project_id = ?", projectID)
+	return result.RowsAffected, result.Error
+}
+
+// LockProjectTx locks the project row so build slots of the project are handed out one
+// at a time across replicas
+func (r *BuildUsageRepository) LockProjectTx(tx *gorm.DB, projectID string) error {
+	var project models.Project
+	return tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&project, "id = ?", projectID).Error
+}
+
+// CountRunningBuildsTx counts the project's build jobs that started after since and have
+// not finished
+func (r *BuildUsageRepository) CountRunningBuildsTx(tx *gorm.DB, projectID string, since time.Time) (int64, error) {
+	var count int64
+	result := tx.Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.build_started_at > ? AND deployments.build_finished_at IS NULL", projectID, since).
+		Count(&count)
+	return count, result.Error
+}
+
+// CountQueuedBeforeTx counts the project's queued deployments created before the given one
+func (r *BuildUsageRepository) CountQueuedBeforeTx(tx *gorm.DB, projectID string, deployment models.Deployment) (int64, error) {
+	var count int64
+	result := tx.Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.status = ?", projectID, models.DeploymentStatusQueued).
+		Where("(deployments.created_at < ? OR (deployments.created_at = ? AND deployments.id < ?))", deployment.CreatedAt, deployment.CreatedAt, deployment.ID).
+		Count(&count)
+	return count, result.Error
+}
+
+// CountQueued counts the project's deployments waiting for a build slot
+func (r *BuildUsageRepository) CountQueued(projectID string) (int64, error) {
+	var count int64
+	result := database.Reader().Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.status = ?", projectID, models.DeploymentStatusQueued).
+		Count(&count)
+	return count, result.Error
+}
+
+// SumBuildDuration returns the total run time of the project's build jobs that started
+// in [from, to)
+func (r *BuildUsageRepository) SumBuildDuration(projectID string, from, to time.Time) (time.Duration, error) {
+	var totalMs int64
+	result := database.Reader().Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.build_started_at >= ? AND deployments.build_started_at < ?", projectID, from, to).
+		Select("COALESCE(SUM(deployments.build_duration_ms), 0)").
+		Scan(&totalMs)
+	return time.Duration(totalMs) * time.Millisecond, result.Error
+}
+
+// FindBuildIntervals retrieves the run times of the project's build jobs that started in
+// [from, to), oldest first
+func (r *BuildUsageRepository) FindBuildIntervals(projectID string, from, to time.Time) ([]BuildInterval, error) {
+	var intervals []BuildInterval
+	result := database.Reader().Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.build_started_at >= ? AND deployments.build_started_at < ?", projectID, from, to).
+		Select("deployments.build_started_at AS started_at, deployments.build_finished_at AS finished_at, deployments.build_duration_ms AS duration_ms").
+		Order("deployments.build_started_at ASC").
+		Scan(&intervals)
+	return intervals, result.Error
+}
+
+// SumByProject returns the build count and run time of every project with build jobs
+// that started in [from, to)
+func (r *BuildUsageRepository) SumByProject(from, to time.Time) ([]ProjectBuildTotals, error) {
+	var totals []ProjectBuildTotals
+	result := database.Reader().Model(&models.Deployment{}).
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("deployments.build_started_at >= ? AND deployments.build_started_at < ?", from, to).
+		Select("services.project_id AS project_id, COUNT(*) AS builds, COALESCE(SUM(deployments.build_duration_ms), 0) AS duration_ms").
+		Group("services.project_id").
+		Order("duration_ms DESC").
+		Scan(&totals)
+	return totals, result.Error
+}
+
+// DB returns the database handle for transactions
+func (r *BuildUsageRepository) DB() *gorm.DB {
+	return database.DB
+}
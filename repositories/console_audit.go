@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ConsoleAuditRepository handles database operations for console audit logs
+type ConsoleAuditRepository struct{}
+
+// NewConsoleAuditRepository creates a new console audit repository instance
+func NewConsoleAuditRepository() *ConsoleAuditRepository {
+	return &ConsoleAuditRepository{}
+}
+
+// Create stores a new console audit log entry
+func (r *ConsoleAuditRepository) Create(entry models.ConsoleAuditLog) (models.ConsoleAuditLog, error) {
+	result := database.DB.Create(&entry)
+	return entry, result.Error
+}
+
+// FindByServiceID retrieves the most recent console audit logs for a service
+func (r *ConsoleAuditRepository) FindByServiceID(serviceID string, limit int) ([]models.ConsoleAuditLog, error) {
+	var entries []models.ConsoleAuditLog
+	result := database.DB.Where("service_id = ?", serviceID).Order("created_at DESC").Limit(limit).Find(&entries)
+	return entries, result.Error
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// CustomCertificateRepository handles database operations for uploaded TLS certificates
+type CustomCertificateRepository struct{}
+
+// NewCustomCertificateRepository creates a new custom certificate repository instance
+func NewCustomCertificateRepository() *CustomCertificateRepository {
+	return &CustomCertificateRepository{}
+}
+
+// FindByServiceID retrieves the uploaded certificate of a service
+func (r *CustomCertificateRepository) FindByServiceID(serviceID string) (models.CustomCertificate, error) {
+	var certificate models.CustomCertificate
+	result := database.DB.First(&certificate, "service_id = ?", serviceID)
+	return certificate, result.Error
+}
+
+// FindExpiringBefore retrieves certificates that expire before the cutoff, soonest first
+func (r *CustomCertificateRepository) FindExpiringBefore(cutoff time.Time) ([]models.CustomCertificate, error) {
+	var certificates []models.CustomCertificate
+	result := database.DB.Where("not_after < ?", cutoff).Order("not_after ASC").Find(&certificates)
+	return certificates, result.Error
+}
+
+// Save creates or updates an uploaded certificate
+func (r *CustomCertificateRepository) Save(certificate models.CustomCertificate) (models.CustomCertificate, error) {
+	result := database.DB.Save(&certificate)
+	return certificate, result.Error
+}
+
+// MarkRemindedTx records when a renewal reminder was sent, inside the caller's transaction
+func (r *CustomCertificateRepository) MarkRemindedTx(tx *gorm.DB, id string, at time.Time) error {
+	return tx.Model(&models.CustomCertificate{}).
+		Where("id = ?", id).
+		Update("last_reminder_at", at).Error
+}
+
+// DeleteByServiceID removes the uploaded certificate of a service
+func (r *CustomCertificateRepository) DeleteByServiceID(serviceID string) error {
+	return database.DB.Where("service_id = ?", serviceID).Delete(&models.CustomCertificate{}).Error
+}
+
+// DB returns the database instance
+func (r *CustomCertificateRepository) DB() *gorm.DB {
+	return database.DB
+}
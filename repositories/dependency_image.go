@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DependencyImageRepository handles database operations for pinned dependency images and
+// the copies registries were built from
+type DependencyImageRepository struct{}
+
+// NewDependencyImageRepository creates a new dependency image repository instance
+func NewDependencyImageRepository() *DependencyImageRepository {
+	return &DependencyImageRepository{}
+}
+
+// FindPins retrieves every pinned dependency image
+func (r *DependencyImageRepository) FindPins() ([]models.DependencyImagePin, error) {
+	var pins []models.DependencyImagePin
+	result := database.DB.Order("name").Find(&pins)
+	return pins, result.Error
+}
+
+// FindPin retrieves the pin of a dependency image
+func (r *DependencyImageRepository) FindPin(name string) (models.DependencyImagePin, error) {
+	var pin models.DependencyImagePin
+	result := database.DB.First(&pin, "name = ?", name)
+	return pin, result.Error
+}
+
+// CreatePin pins a dependency image unless it is pinned already; the pin in place is
+// returned either way
+func (r *DependencyImageRepository) CreatePin(pin models.DependencyImagePin) (models.DependencyImagePin, error) {
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&pin).Error; err != nil {
+		return pin, err
+	}
+	return r.FindPin(pin.Name)
+}
+
+// SetCandidate records an update of a pinned image waiting for review
+func (r *DependencyImageRepository) SetCandidate(name string, image string, digest string, at time.Time) error {
+	return database.DB.Model(&models.DependencyImagePin{}).
+		Where("name = ?", name).
+		Updates(map[string]interface{}{
+			"candidate_image":    image,
+			"candidate_digest":   digest,
+			"candidate_found_at": at,
+		}).Error
+}
+
+// OfferCandidate records an update found upstream unless another one is waiting for review
+func (r *DependencyImageRepository) OfferCandidate(name string, image string, digest string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.DependencyImagePin{}).
+		Where("name = ? AND digest <> ? AND candidate_digest IS NULL", name, digest).
+		Updates(map[string]interface{}{
+			"candidate_image":    image,
+			"candidate_digest":   digest,
+			"candidate_found_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClearCandidate dismisses the update of a pinned image
+func (r *DependencyImageRepository) ClearCandidate(name string) error {
+	return database.DB.Model(&models.DependencyImagePin{}).
+		Where("name = ?", name).
+		Updates(map[string]interface{}{
+			"candidate_image":    nil,
+			"candidate_digest":   nil,
+			"candidate_found_at": nil,
+		}).Error
+}
+
+// ApproveCandidate moves the pin to its candidate. Only the candidate that was reviewed is
+// approved, so a candidate replaced in the meantime is not pinned unseen.
+func (r *DependencyImageRepository) ApproveCandidate(name string, digest string, userID string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.DependencyImagePin{}).
+		Where("name = ? AND candidate_digest = ?", name, digest).
+		Updates(map[string]interface{}{
+			"source_image":       gorm.Expr("candidate_image"),
+			"digest":             digest,
+			"candidate_image":    nil,
+			"candidate_digest":   nil,
+			"candidate_found_at": nil,
+			"pinned_by":          userID,
+			"pinned_at":          at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindBuiltByRegistryID retrieves the dependency images built into a registry
+func (r *DependencyImageRepository) FindBuiltByRegistryID(registryID string) ([]models.RegistryDependencyImage, error) {
+	var images []models.RegistryDependencyImage
+	result := database.DB.Where("registry_id = ?", registryID).Order("name").Find(&images)
+	return images, result.Error
+}
+
+// FindBuilt retrieves the dependency images built into every registry
+func (r *DependencyImageRepository) FindBuilt() ([]models.RegistryDependencyImage, error) {
+	var images []models.RegistryDependencyImage
+	result := database.DB.Order("registry_id, name").Find(&images)
+	return images, result.Error
+}
+
+// RecordBuilt stores the source a registry's copy of a dependency image was built from
+func (r *DependencyImageRepository) RecordBuilt(image models.RegistryDependencyImage) error {
+	return database.DB.Omit("Registry").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "registry_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source_image", "digest", "built_at"}),
+	}).Create(&image).Error
+}
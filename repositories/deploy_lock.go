@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// DeployLockRepository handles database operations for deploy locks
+type DeployLockRepository struct{}
+
+// NewDeployLockRepository creates a new deploy lock repository instance
+func NewDeployLockRepository() *DeployLockRepository {
+	return &DeployLockRepository{}
+}
+
+// FindByID retrieves a deploy lock by ID
+func (r *DeployLockRepository) FindByID(id string) (models.DeployLock, error) {
+	var lock models.DeployLock
+	result := database.Reader().First(&lock, "id = ?", id)
+	return lock, result.Error
+}
+
+// FindActiveByProjectID retrieves the locks of a project that have not expired, newest first
+func (r *DeployLockRepository) FindActiveByProjectID(projectID string, now time.Time) ([]models.DeployLock, error) {
+	var locks []models.DeployLock
+	result := database.Reader().
+		Where("project_id = ? AND (expires_at IS NULL OR expires_at > ?)", projectID, now).
+		Order("created_at DESC").
+		Find(&locks)
+	return locks, result.Error
+}
+
+// FindActiveForEnvironment retrieves the unexpired locks covering an environment: its own
+// and those of the whole project, newest first
+func (r *DeployLockRepository) FindActiveForEnvironment(projectID, environmentID string, now time.Time) ([]models.DeployLock, error) {
+	var locks []models.DeployLock
+	result := database.Reader().
+		Where("project_id = ? AND (environment_id IS NULL OR environment_id = ?)", projectID, environmentID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&locks)
+	return locks, result.Error
+}
+
+// Create stores a new deploy lock
+func (r *DeployLockRepository) Create(lock models.DeployLock) (models.DeployLock, error) {
+	result := database.DB.Create(&lock)
+	return lock, result.Error
+}
+
+// Delete removes a deploy lock
+func (r *DeployLockRepository) Delete(id string) error {
+	return database.DB.Delete(&models.DeployLock{}, "id = ?", id).Error
+}
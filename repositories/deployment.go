@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pendeploy-simple/database"
@@ -37,6 +39,68 @@ func (r *DeploymentRepository) FindByServiceID(serviceID string) ([]models.Deplo
 	return deployments, result.Error
 }
 
+// FindByServiceIDWithPagination retrieves a service's deployments with pagination, filtering and sorting
+func (r *DeploymentRepository) FindByServiceIDWithPagination(
+	serviceID string,
+	page, pageSize int,
+	sortBy, sortOrder string,
+	status string,
+	imageDigest string,
+	createdFrom, createdTo *time.Time) ([]models.Deployment, int64, error) {
+
+	// Calculate offset
+	offset := (page - 1) * pageSize
+
+	// Valid sort columns (whitelist approach for security)
+	validSortColumns := map[string]bool{
+		"created_at":  true,
+		"deployed_at": true,
+		"status":      true,
+	}
+
+	if !validSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+
+	sortOrder = strings.ToLower(sortOrder)
+	if sortOrder != "asc" && sortOrder != "desc" {
+		sortOrder = "desc"
+	}
+
+	// Build query
+	query := database.Reader().Model(&models.Deployment{}).Where("service_id = ?", serviceID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if imageDigest != "" {
+		query = query.Where("image_digest = ?", imageDigest)
+	}
+	if createdFrom != nil {
+		query = query.Where("created_at >= ?", *createdFrom)
+	}
+	if createdTo != nil {
+		query = query.Where("created_at <= ?", *createdTo)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting and, unless pageSize is 0, pagination
+	query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
+	if pageSize > 0 {
+		query = query.Limit(pageSize).Offset(offset)
+	}
+
+	var deployments []models.Deployment
+	result := query.Find(&deployments)
+
+	return deployments, total, result.Error
+}
+
 func (r *DeploymentRepository) UpdateImage(id string, image string) error {
 	var updates = map[string]interface{}{
 		"image": image,
@@ -47,6 +111,135 @@ func (r *DeploymentRepository) UpdateImage(id string, image string) error {
 	return result.Error
 }
 
+// UpdateReusedImage records the image of an earlier build a deployment deploys instead of
+// building one
+func (r *DeploymentRepository) UpdateReusedImage(id string, image string, imageDigest string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"image":        image,
+			"image_digest": imageDigest,
+		})
+	return result.Error
+}
+
+// UpdateBuildEnvironment records the environment a deployment's image was built in
+func (r *DeploymentRepository) UpdateBuildEnvironment(id string, buildEnv models.BuildEnvironment, dockerfileDigest string, imageDigest string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"build_env":         buildEnv,
+			"dockerfile_digest": dockerfileDigest,
+			"image_digest":      imageDigest,
+		})
+	return result.Error
+}
+
+// UpdateTestDuration records how long the pre-build test stage of a deployment ran
+func (r *DeploymentRepository) UpdateTestDuration(id string, durationMs int64) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("test_duration_ms", durationMs)
+	return result.Error
+}
+
+// UpdateImageLayers records the size and layers of a deployment's image
+func (r *DeploymentRepository) UpdateImageLayers(id string, size int64, layers models.ImageLayers) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"image_size":   size,
+			"image_layers": layers,
+		})
+	return result.Error
+}
+
+// UpdateImageSizeWarning records that a deployment's image exceeds the service's size budget
+func (r *DeploymentRepository) UpdateImageSizeWarning(id string, warning string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("image_size_warning", warning)
+	return result.Error
+}
+
+// UpdateHealthCheckTx records the post-deployment health check of a deployment within tx
+func (r *DeploymentRepository) UpdateHealthCheckTx(tx *gorm.DB, id string, healthCheck models.DeploymentHealthCheck) error {
+	result := tx.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("health_check", healthCheck)
+	return result.Error
+}
+
+// UpdateFailureClassTx records the stage a failed deployment failed in within tx
+func (r *DeploymentRepository) UpdateFailureClassTx(tx *gorm.DB, id string, failureClass string) error {
+	result := tx.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("failure_class", failureClass)
+	return result.Error
+}
+
+// UpdateBuildErrorTx records the classified cause of a failed build within tx
+func (r *DeploymentRepository) UpdateBuildErrorTx(tx *gorm.DB, id string, errorCode string, errorHint string) error {
+	result := tx.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"error_code": errorCode,
+			"error_hint": errorHint,
+		})
+	return result.Error
+}
+
+// UpdatePortCheck records the failure of a deployment's post-rollout port check
+func (r *DeploymentRepository) UpdatePortCheck(id string, portCheckError string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("port_check_error", portCheckError)
+	return result.Error
+}
+
+// UpdatePortWarning records a mismatch between the service port and the Dockerfile's EXPOSE
+func (r *DeploymentRepository) UpdatePortWarning(id string, portWarning string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Update("port_warning", portWarning)
+	return result.Error
+}
+
+// UpdateProvenance records the outcome of SBOM generation and image signing
+func (r *DeploymentRepository) UpdateProvenance(id string, sbomFormat string, signed bool, provenanceError string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sbom_format":      sbomFormat,
+			"image_signed":     signed,
+			"provenance_error": provenanceError,
+		})
+	return result.Error
+}
+
+// UpdateArtifact records the outcome of a build artifact export
+func (r *DeploymentRepository) UpdateArtifact(id string, key string, size int64, artifactError string) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"artifact_key":   key,
+			"artifact_size":  size,
+			"artifact_error": artifactError,
+		})
+	return result.Error
+}
+
+// UpdateBuildLogKey records where a deployment's build output was archived and its size
+func (r *DeploymentRepository) UpdateBuildLogKey(id string, key string, size int64) error {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"build_log_key":  key,
+			"build_log_size": size,
+		})
+	return result.Error
+}
+
 // Create inserts a new deployment into the database
 func (r *DeploymentRepository) Create(deployment models.Deployment) (models.Deployment, error) {
 	result := database.DB.Create(&deployment)
@@ -55,6 +248,11 @@ func (r *DeploymentRepository) Create(deployment models.Deployment) (models.Depl
 
 // UpdateStatus updates the status of a deployment
 func (r *DeploymentRepository) UpdateStatus(id string, status models.DeploymentStatus) error {
+	return r.UpdateStatusTx(database.DB, id, status)
+}
+
+// UpdateStatusTx updates the status of a deployment inside the caller's transaction
+func (r *DeploymentRepository) UpdateStatusTx(tx *gorm.DB, id string, status models.DeploymentStatus) error {
 	var updates = map[string]interface{}{
 		"status": status,
 	}
@@ -65,7 +263,7 @@ func (r *DeploymentRepository) UpdateStatus(id string, status models.DeploymentS
 		updates["deployed_at"] = &now
 	}
 	
-	result := database.DB.Model(&models.Deployment{}).
+	result := tx.Model(&models.Deployment{}).
 		Where("id = ?", id).
 		Updates(updates)
 		
@@ -73,6 +271,53 @@ func (r *DeploymentRepository) UpdateStatus(id string, status models.DeploymentS
 }
 
 
+// MarkQueued records that a deployment waits for a build slot
+func (r *DeploymentRepository) MarkQueued(id string) error {
+	return database.DB.Model(&models.Deployment{}).Where("id = ?", id).
+		UpdateColumn("status", models.DeploymentStatusQueued).Error
+}
+
+// FindInProgress returns the deployments that are queued or building, oldest first
+func (r *DeploymentRepository) FindInProgress() ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	result := database.DB.Where("status IN ?", []models.DeploymentStatus{
+		models.DeploymentStatusQueued, models.DeploymentStatusBuilding,
+	}).Order("created_at ASC").Find(&deployments)
+	return deployments, result.Error
+}
+
+// MarkAbandoned fails a deployment no API instance reported a result for. It only changes a
+// deployment that is still queued or building, and reports whether it did.
+func (r *DeploymentRepository) MarkAbandoned(id string, errorCode string, errorHint string) (bool, error) {
+	result := database.DB.Model(&models.Deployment{}).
+		Where("id = ? AND status IN ?", id, []models.DeploymentStatus{
+			models.DeploymentStatusQueued, models.DeploymentStatusBuilding,
+		}).
+		Updates(map[string]interface{}{
+			"status":        models.DeploymentStatusFailed,
+			"failure_class": models.FailureClassAbandoned,
+			"error_code":    errorCode,
+			"error_hint":    errorHint,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// StartBuildTx records that a deployment's build job started, inside the caller's transaction
+func (r *DeploymentRepository) StartBuildTx(tx *gorm.DB, id string, at time.Time) error {
+	return tx.Model(&models.Deployment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           models.DeploymentStatusBuilding,
+		"build_started_at": at,
+	}).Error
+}
+
+// FinishBuild records when a deployment's build job ended and how long it ran
+func (r *DeploymentRepository) FinishBuild(id string, at time.Time, duration time.Duration) error {
+	return database.DB.Model(&models.Deployment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"build_finished_at": at,
+		"build_duration_ms": duration.Milliseconds(),
+	}).Error
+}
+
 // GetLatestSuccessfulDeployment retrieves the most recent successful deployment for a service
 func (r *DeploymentRepository) GetLatestSuccessfulDeployment(serviceID string) (models.Deployment, error) {
 	var deployment models.Deployment
@@ -92,7 +337,7 @@ func (r *DeploymentRepository) GetLatestDeployment(serviceID string) (models.Dep
 // CountByServiceID counts the number of deployments for a service
 func (r *DeploymentRepository) CountByServiceID(serviceID string) (int64, error) {
 	var count int64
-	result := database.DB.Model(&models.Deployment{}).
+	result := database.Reader().Model(&models.Deployment{}).
 		Where("service_id = ?", serviceID).Count(&count)
 	return count, result.Error
 }
@@ -106,7 +351,7 @@ func (r *DeploymentRepository) GetSuccessRate(serviceID string) (float64, error)
 	
 	var result Result
 	
-	err := database.DB.Raw(`
+	err := database.Reader().Raw(`
 		SELECT 
 			COUNT(*) as total,
 			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as successful
@@ -114,7 +359,6 @@ func (r *DeploymentRepository) GetSuccessRate(serviceID string) (float64, error)
 			deployments
 		WHERE 
 			service_id = ?
-			AND deleted_at IS NULL
 	`, models.DeploymentStatusSuccess, serviceID).Scan(&result).Error
 	
 	if err != nil {
@@ -146,7 +390,7 @@ func (r *DeploymentRepository) CountByProjectID(projectID string) (int64, error)
 	var count int64
 	
 	// Join with services to filter by project ID
-	result := database.DB.Model(&models.Deployment{}).
+	result := database.Reader().Model(&models.Deployment{}).
 		Joins("JOIN services ON services.id = deployments.service_id").
 		Where("services.project_id = ?", projectID).
 		Count(&count)
@@ -159,7 +403,7 @@ func (r *DeploymentRepository) CountDeploymentsByProjectIDAndStatus(projectID st
 	var count int64
 	
 	// Join with services to filter by project ID
-	result := database.DB.Model(&models.Deployment{}).
+	result := database.Reader().Model(&models.Deployment{}).
 		Joins("JOIN services ON services.id = deployments.service_id").
 		Where("services.project_id = ? AND deployments.status = ?", projectID, status).
 		Count(&count)
@@ -167,6 +411,41 @@ func (r *DeploymentRepository) CountDeploymentsByProjectIDAndStatus(projectID st
 	return count, result.Error
 }
 
+// ServiceDeploymentCounts holds the deployment counters of one service
+type ServiceDeploymentCounts struct {
+	ServiceID  string
+	Total      int64
+	Successful int64
+	Failed     int64
+	InProgress int64
+}
+
+// CountByServiceForProject aggregates deployment counts per service of a project
+// in a single grouped query, replacing per-service CountByServiceID/GetSuccessRate calls
+func (r *DeploymentRepository) CountByServiceForProject(projectID string) ([]ServiceDeploymentCounts, error) {
+	var counts []ServiceDeploymentCounts
+
+	err := database.Reader().Raw(`
+		SELECT
+			d.service_id,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE d.status = ?) AS successful,
+			COUNT(*) FILTER (WHERE d.status = ?) AS failed,
+			COUNT(*) FILTER (WHERE d.status = ?) AS in_progress
+		FROM
+			deployments d
+			JOIN services s ON s.id = d.service_id
+		WHERE
+			s.project_id = ?
+			AND s.deleted_at IS NULL
+		GROUP BY
+			d.service_id
+	`, models.DeploymentStatusSuccess, models.DeploymentStatusFailed, models.DeploymentStatusBuilding, projectID).
+		Scan(&counts).Error
+
+	return counts, err
+}
+
 // DB returns the database instance
 func (r *DeploymentRepository) DB() *gorm.DB {
 	return database.DB
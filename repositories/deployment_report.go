@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm/clause"
+)
+
+// DeploymentReportRepository aggregates deployments and usage samples into daily rollups
+// and stores them
+type DeploymentReportRepository struct{}
+
+// NewDeploymentReportRepository creates a new deployment report repository instance
+func NewDeploymentReportRepository() *DeploymentReportRepository {
+	return &DeploymentReportRepository{}
+}
+
+// DeploymentOutcomes counts the deployments created in a window by final status
+type DeploymentOutcomes struct {
+	Deployments int64 `gorm:"column:deployments"`
+	Succeeded   int64 `gorm:"column:succeeded"`
+	Failed      int64 `gorm:"column:failed"`
+}
+
+// BuildTotals counts the build jobs started in a window and their average run time
+type BuildTotals struct {
+	Builds         int64   `gorm:"column:builds"`
+	AverageBuildMs float64 `gorm:"column:average_build_ms"` // finished builds only
+}
+
+// FailureClassCount is the number of failed deployments of a failure class
+type FailureClassCount struct {
+	FailureClass string `gorm:"column:failure_class"`
+	Count        int64  `gorm:"column:count"`
+}
+
+// CountDeploymentOutcomes counts the deployments created in [from, to)
+func (r *DeploymentReportRepository) CountDeploymentOutcomes(from, to time.Time) (DeploymentOutcomes, error) {
+	var outcomes DeploymentOutcomes
+	result := database.Reader().Model(&models.Deployment{}).
+		Select(`COUNT(*) AS deployments,
+			COUNT(*) FILTER (WHERE status = ?) AS succeeded,
+			COUNT(*) FILTER (WHERE status = ?) AS failed`, models.DeploymentStatusSuccess, models.DeploymentStatusFailed).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Scan(&outcomes)
+	return outcomes, result.Error
+}
+
+// SumBuilds counts the build jobs started in [from, to) and averages the run time of the
+// finished ones
+func (r *DeploymentReportRepository) SumBuilds(from, to time.Time) (BuildTotals, error) {
+	var totals BuildTotals
+	result := database.Reader().Model(&models.Deployment{}).
+		Select(`COUNT(*) AS builds,
+			COALESCE(AVG(build_duration_ms) FILTER (WHERE build_finished_at IS NOT NULL), 0) AS average_build_ms`).
+		Where("build_started_at >= ? AND build_started_at < ?", from, to).
+		Scan(&totals)
+	return totals, result.Error
+}
+
+// CountFailuresByClass counts the failed deployments created in [from, to) by failure class.
+// Deployments that failed before failure classes were recorded are counted as "other".
+func (r *DeploymentReportRepository) CountFailuresByClass(from, to time.Time) ([]FailureClassCount, error) {
+	var counts []FailureClassCount
+	err := database.Reader().Raw(`
+		SELECT
+			COALESCE(failure_class, ?) AS failure_class,
+			COUNT(*) AS count
+		FROM deployments
+		WHERE status = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1
+	`, models.FailureClassOther, models.DeploymentStatusFailed, from, to).Scan(&counts).Error
+	return counts, err
+}
+
+// FindTopConsumers returns the services with the highest average CPU (byMemory false) or
+// memory usage sampled in [from, to), heaviest first
+func (r *DeploymentReportRepository) FindTopConsumers(from, to time.Time, byMemory bool, limit int) ([]models.ResourceConsumer, error) {
+	order := "avg_cpu_used DESC"
+	if byMemory {
+		order = "avg_memory_used DESC"
+	}
+
+	var consumers []models.ResourceConsumer
+	result := database.Reader().Model(&models.ServiceUsageSample{}).
+		Joins("JOIN services ON services.id = service_usage_samples.service_id").
+		Select(`service_usage_samples.service_id AS service_id,
+			services.name AS service_name,
+			services.project_id AS project_id,
+			ROUND(AVG(service_usage_samples.cpu_used)) AS avg_cpu_used,
+			ROUND(AVG(service_usage_samples.memory_used)) AS avg_memory_used,
+			MAX(service_usage_samples.cpu_used) AS peak_cpu_used,
+			MAX(service_usage_samples.memory_used) AS peak_memory_used`).
+		Where("service_usage_samples.sampled_at >= ? AND service_usage_samples.sampled_at < ?", from, to).
+		Group("service_usage_samples.service_id, services.name, services.project_id").
+		Order(order).
+		Limit(limit).
+		Scan(&consumers)
+	return consumers, result.Error
+}
+
+// SaveRollup creates or replaces the rollup of a day
+func (r *DeploymentReportRepository) SaveRollup(rollup models.DeploymentStatsRollup) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		UpdateAll: true,
+	}).Create(&rollup).Error
+}
+
+// FindRollups retrieves the rollups of the days from through to (YYYY-MM-DD, inclusive),
+// oldest first
+func (r *DeploymentReportRepository) FindRollups(from, to string) ([]models.DeploymentStatsRollup, error) {
+	var rollups []models.DeploymentStatsRollup
+	result := database.Reader().Where("day >= ? AND day <= ?", from, to).Order("day ASC").Find(&rollups)
+	return rollups, result.Error
+}
+
+// FindRollupDays lists the days from through to (YYYY-MM-DD, inclusive) that have a rollup
+func (r *DeploymentReportRepository) FindRollupDays(from, to string) ([]string, error) {
+	var days []string
+	result := database.Reader().Model(&models.DeploymentStatsRollup{}).
+		Where("day >= ? AND day <= ?", from, to).
+		Pluck("day", &days)
+	return days, result.Error
+}
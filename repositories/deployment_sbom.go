@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// DeploymentSBOMRepository handles database operations for deployment SBOMs
+type DeploymentSBOMRepository struct{}
+
+// NewDeploymentSBOMRepository creates a new deployment SBOM repository instance
+func NewDeploymentSBOMRepository() *DeploymentSBOMRepository {
+	return &DeploymentSBOMRepository{}
+}
+
+// FindByDeploymentID retrieves the SBOM of a deployment
+func (r *DeploymentSBOMRepository) FindByDeploymentID(deploymentID string) (models.DeploymentSBOM, error) {
+	var sbom models.DeploymentSBOM
+	result := database.Reader().First(&sbom, "deployment_id = ?", deploymentID)
+	return sbom, result.Error
+}
+
+// Save creates or replaces the SBOM of a deployment
+func (r *DeploymentSBOMRepository) Save(sbom models.DeploymentSBOM) error {
+	return database.DB.Save(&sbom).Error
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeviceAuthorizationRepository handles database operations for device-code logins
+type DeviceAuthorizationRepository struct{}
+
+// NewDeviceAuthorizationRepository creates a new device authorization repository instance
+func NewDeviceAuthorizationRepository() *DeviceAuthorizationRepository {
+	return &DeviceAuthorizationRepository{}
+}
+
+// Create inserts a new device authorization
+func (r *DeviceAuthorizationRepository) Create(authorization models.DeviceAuthorization) (models.DeviceAuthorization, error) {
+	result := database.DB.Create(&authorization)
+	return authorization, result.Error
+}
+
+// FindByUserCode retrieves a device authorization by the code shown to the user
+func (r *DeviceAuthorizationRepository) FindByUserCode(userCode string) (models.DeviceAuthorization, error) {
+	var authorization models.DeviceAuthorization
+	result := database.DB.First(&authorization, "user_code = ?", userCode)
+	return authorization, result.Error
+}
+
+// FindByDeviceCodeHashForUpdateTx locks a device authorization for the token exchange
+func (r *DeviceAuthorizationRepository) FindByDeviceCodeHashForUpdateTx(tx *gorm.DB, hash string) (models.DeviceAuthorization, error) {
+	var authorization models.DeviceAuthorization
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&authorization, "device_code_hash = ?", hash)
+	return authorization, result.Error
+}
+
+// UpdateTx saves a device authorization inside the caller's transaction
+func (r *DeviceAuthorizationRepository) UpdateTx(tx *gorm.DB, authorization models.DeviceAuthorization) error {
+	return tx.Save(&authorization).Error
+}
+
+// Update saves a device authorization
+func (r *DeviceAuthorizationRepository) Update(authorization models.DeviceAuthorization) error {
+	return r.UpdateTx(database.DB, authorization)
+}
+
+// DeleteExpiredBefore removes authorizations that expired before the cutoff
+func (r *DeviceAuthorizationRepository) DeleteExpiredBefore(cutoff time.Time) (int64, error) {
+	result := database.DB.Where("expires_at < ?", cutoff).Delete(&models.DeviceAuthorization{})
+	return result.RowsAffected, result.Error
+}
+
+// DB returns the database handle for transactions
+func (r *DeviceAuthorizationRepository) DB() *gorm.DB {
+	return database.DB
+}
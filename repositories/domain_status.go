@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm/clause"
+)
+
+// DomainStatusRepository handles database operations for the propagation checks of hostnames
+type DomainStatusRepository struct{}
+
+// NewDomainStatusRepository creates a new domain status repository instance
+func NewDomainStatusRepository() *DomainStatusRepository {
+	return &DomainStatusRepository{}
+}
+
+// FindByServiceID retrieves the checks of a service's hostnames
+func (r *DomainStatusRepository) FindByServiceID(serviceID string) ([]models.DomainStatus, error) {
+	var statuses []models.DomainStatus
+	result := database.Reader().Where("service_id = ?", serviceID).Order("hostname").Find(&statuses)
+	return statuses, result.Error
+}
+
+// FindServiceIDsNotReachable lists the services with a hostname that was not reachable at
+// its last check, made before checkedBefore
+func (r *DomainStatusRepository) FindServiceIDsNotReachable(checkedBefore time.Time) ([]string, error) {
+	var serviceIDs []string
+	result := database.Reader().Model(&models.DomainStatus{}).
+		Where("status <> ? AND checked_at < ?", models.DomainReachable, checkedBefore).
+		Distinct().
+		Pluck("service_id", &serviceIDs)
+	return serviceIDs, result.Error
+}
+
+// Save creates or updates the check of a hostname
+func (r *DomainStatusRepository) Save(status models.DomainStatus) error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "service_id"}, {Name: "hostname"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "resolved_addresses", "ingress_addresses", "http_status_code", "error", "checked_at", "reachable_since",
+		}),
+	}).Create(&status).Error
+}
+
+// DeleteOtherHostnames removes the checks of hostnames the service no longer serves
+func (r *DomainStatusRepository) DeleteOtherHostnames(serviceID string, hostnames []string) error {
+	query := database.DB.Where("service_id = ?", serviceID)
+	if len(hostnames) > 0 {
+		query = query.Where("hostname NOT IN ?", hostnames)
+	}
+	return query.Delete(&models.DomainStatus{}).Error
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// EnvRevealAuditRepository handles database operations for env var reveal audit logs
+type EnvRevealAuditRepository struct{}
+
+// NewEnvRevealAuditRepository creates a new env var reveal audit repository instance
+func NewEnvRevealAuditRepository() *EnvRevealAuditRepository {
+	return &EnvRevealAuditRepository{}
+}
+
+// Create stores a new reveal audit log entry
+func (r *EnvRevealAuditRepository) Create(entry models.EnvRevealAuditLog) (models.EnvRevealAuditLog, error) {
+	result := database.DB.Create(&entry)
+	return entry, result.Error
+}
+
+// FindByServiceID retrieves the most recent reveals of a service
+func (r *EnvRevealAuditRepository) FindByServiceID(serviceID string, limit int) ([]models.EnvRevealAuditLog, error) {
+	var entries []models.EnvRevealAuditLog
+	result := database.Reader().Where("service_id = ?", serviceID).Order("created_at DESC").Limit(limit).Find(&entries)
+	return entries, result.Error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// EnvVarChangeRepository handles database operations for proposed env var changes
+type EnvVarChangeRepository struct{}
+
+// NewEnvVarChangeRepository creates a new env var change repository instance
+func NewEnvVarChangeRepository() *EnvVarChangeRepository {
+	return &EnvVarChangeRepository{}
+}
+
+// Create inserts a proposed env var change
+func (r *EnvVarChangeRepository) Create(change models.EnvVarChange) (models.EnvVarChange, error) {
+	result := database.DB.Omit("Service").Create(&change)
+	return change, result.Error
+}
+
+// FindByID retrieves a proposed env var change
+func (r *EnvVarChangeRepository) FindByID(id string) (models.EnvVarChange, error) {
+	var change models.EnvVarChange
+	result := database.DB.First(&change, "id = ?", id)
+	return change, result.Error
+}
+
+// FindByServiceID retrieves a page of a service's proposed env var changes, newest first
+func (r *EnvVarChangeRepository) FindByServiceID(serviceID string, status string, page, pageSize int) ([]models.EnvVarChange, int64, error) {
+	var changes []models.EnvVarChange
+	var total int64
+
+	query := database.Reader().Model(&models.EnvVarChange{}).Where("service_id = ?", serviceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&changes)
+	return changes, total, result.Error
+}
+
+// Review approves or rejects a change waiting for approval. Only one reviewer succeeds, so
+// a change is never applied twice.
+func (r *EnvVarChangeRepository) Review(id string, status models.EnvVarChangeStatus, reviewerID string, comment string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.EnvVarChange{}).
+		Where("id = ? AND status = ?", id, models.EnvVarChangePendingApproval).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    reviewerID,
+			"reviewed_at":    at,
+			"review_comment": comment,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordResult stores whether an approved change was applied, or why it failed
+func (r *EnvVarChangeRepository) RecordResult(id string, applyErr error) error {
+	updates := map[string]interface{}{"status": models.EnvVarChangeApplied}
+	if applyErr != nil {
+		updates = map[string]interface{}{"status": models.EnvVarChangeFailed, "error": applyErr.Error()}
+	}
+	return database.DB.Model(&models.EnvVarChange{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -30,7 +30,14 @@ func (r *EnvironmentRepository) FindByProjectID(projectID string) ([]models.Envi
 // CountByProjectID counts the number of environments for a project
 func (r *EnvironmentRepository) CountByProjectID(projectID string) (int64, error) {
 	var count int64
-	result := database.DB.Model(&models.Environment{}).Where("project_id = ?", projectID).Count(&count)
+	result := database.Reader().Model(&models.Environment{}).Where("project_id = ?", projectID).Count(&count)
+	return count, result.Error
+}
+
+// CountByPriorityTier counts the environments mapped to a priority tier
+func (r *EnvironmentRepository) CountByPriorityTier(tier string) (int64, error) {
+	var count int64
+	result := database.DB.Model(&models.Environment{}).Where("priority_tier = ?", tier).Count(&count)
 	return count, result.Error
 }
 
@@ -46,6 +53,13 @@ func (r *EnvironmentRepository) Update(environment models.Environment) error {
 	return result.Error
 }
 
+// FindByNameAndProject retrieves the environment of a project with the given name
+func (r *EnvironmentRepository) FindByNameAndProject(name string, projectID string) (models.Environment, error) {
+	var environment models.Environment
+	result := database.DB.Where("name = ? AND project_id = ?", name, projectID).First(&environment)
+	return environment, result.Error
+}
+
 // ExistsByNameAndProject checks if an environment with the given name exists in a project
 func (r *EnvironmentRepository) ExistsByNameAndProject(name string, projectID string) (bool, error) {
 	var count int64
@@ -62,6 +76,20 @@ func (r *EnvironmentRepository) Delete(id string) error {
 // CountServicesInEnvironment counts the number of services in an environment
 func (r *EnvironmentRepository) CountServicesInEnvironment(environmentID string) (int, error) {
 	var count int64
-	result := database.DB.Model(&models.Service{}).Where("environment_id = ?", environmentID).Count(&count)
+	result := database.Reader().Model(&models.Service{}).Where("environment_id = ?", environmentID).Count(&count)
 	return int(count), result.Error
 }
+
+// FindAllIDs retrieves the IDs of all environments (each ID is also a namespace)
+func (r *EnvironmentRepository) FindAllIDs() ([]string, error) {
+	var ids []string
+	result := database.DB.Model(&models.Environment{}).Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// FindAll retrieves all environments, ordered by project
+func (r *EnvironmentRepository) FindAll() ([]models.Environment, error) {
+	var environments []models.Environment
+	result := database.Reader().Order("project_id, name").Find(&environments)
+	return environments, result.Error
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// GitOpsRepository handles database operations for the GitOps configuration of projects
+type GitOpsRepository struct{}
+
+// NewGitOpsRepository creates a new GitOps repository instance
+func NewGitOpsRepository() *GitOpsRepository {
+	return &GitOpsRepository{}
+}
+
+// FindByProjectID retrieves the GitOps configuration of a project
+func (r *GitOpsRepository) FindByProjectID(projectID string) (models.GitOpsConfig, error) {
+	var config models.GitOpsConfig
+	result := database.Reader().First(&config, "project_id = ?", projectID)
+	return config, result.Error
+}
+
+// FindPolling retrieves the active configurations polled on a schedule
+func (r *GitOpsRepository) FindPolling() ([]models.GitOpsConfig, error) {
+	var configs []models.GitOpsConfig
+	result := database.Reader().Where("poll_minutes > 0 AND paused = ?", false).Find(&configs)
+	return configs, result.Error
+}
+
+// Create inserts a new GitOps configuration
+func (r *GitOpsRepository) Create(config models.GitOpsConfig) (models.GitOpsConfig, error) {
+	result := database.DB.Create(&config)
+	return config, result.Error
+}
+
+// Update saves changes to a GitOps configuration
+func (r *GitOpsRepository) Update(config models.GitOpsConfig) (models.GitOpsConfig, error) {
+	result := database.DB.Save(&config)
+	return config, result.Error
+}
+
+// RecordCheck stores the outcome of a sync; commit is only recorded as synced when it
+// applied without errors, so a failed commit is retried
+func (r *GitOpsRepository) RecordCheck(id string, commit string, status string, message string, at time.Time) error {
+	updates := map[string]interface{}{
+		"last_checked_at":  at,
+		"last_sync_status": status,
+		"last_sync_error":  message,
+	}
+	if status == models.GitOpsSyncSucceeded {
+		updates["last_synced_commit"] = commit
+	}
+	return database.DB.Model(&models.GitOpsConfig{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// TouchCheck records a check that found no new commit
+func (r *GitOpsRepository) TouchCheck(id string, at time.Time) error {
+	return database.DB.Model(&models.GitOpsConfig{}).Where("id = ?", id).Update("last_checked_at", at).Error
+}
+
+// Delete removes the GitOps configuration of a project
+func (r *GitOpsRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.GitOpsConfig{}).Error
+}
+
+// DB returns the database instance
+func (r *GitOpsRepository) DB() *gorm.DB {
+	return database.DB
+}
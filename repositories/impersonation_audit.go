@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ImpersonationAuditRepository handles database operations for the impersonation audit trail
+type ImpersonationAuditRepository struct{}
+
+// NewImpersonationAuditRepository creates a new impersonation audit repository instance
+func NewImpersonationAuditRepository() *ImpersonationAuditRepository {
+	return &ImpersonationAuditRepository{}
+}
+
+// Create stores an audit entry
+func (r *ImpersonationAuditRepository) Create(entry models.ImpersonationAuditLog) error {
+	return database.DB.Create(&entry).Error
+}
+
+// Find retrieves audit entries, newest first, optionally for one admin or one impersonated user
+func (r *ImpersonationAuditRepository) Find(impersonatorID string, userID string, page, pageSize int) ([]models.ImpersonationAuditLog, int64, error) {
+	query := database.Reader().Model(&models.ImpersonationAuditLog{})
+	if impersonatorID != "" {
+		query = query.Where("impersonator_id = ?", impersonatorID)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.ImpersonationAuditLog
+	result := query.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&entries)
+	return entries, total, result.Error
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// LoadTestRepository handles database operations for service load tests
+type LoadTestRepository struct{}
+
+// NewLoadTestRepository creates a new load test repository instance
+func NewLoadTestRepository() *LoadTestRepository {
+	return &LoadTestRepository{}
+}
+
+// FindByID retrieves a load test by ID
+func (r *LoadTestRepository) FindByID(id string) (models.LoadTest, error) {
+	var test models.LoadTest
+	result := database.Reader().First(&test, "id = ?", id)
+	return test, result.Error
+}
+
+// FindByServiceID retrieves a page of a service's load tests, newest first
+func (r *LoadTestRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.LoadTest, int64, error) {
+	var tests []models.LoadTest
+	var total int64
+
+	query := database.Reader().Model(&models.LoadTest{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&tests)
+	return tests, total, result.Error
+}
+
+// FindBaseline retrieves the latest completed test of a service at the same rate that ran
+// against another deployment before the given time
+func (r *LoadTestRepository) FindBaseline(test models.LoadTest) (models.LoadTest, error) {
+	var baseline models.LoadTest
+	result := database.Reader().
+		Where("service_id = ? AND status = ? AND rate = ? AND deployment_id <> ? AND created_at < ?",
+			test.ServiceID, models.LoadTestStatusCompleted, test.Rate, test.DeploymentID, test.CreatedAt).
+		Order("created_at DESC").First(&baseline)
+	return baseline, result.Error
+}
+
+// ExistsRunning reports whether a test of the service started after the given time is still running
+func (r *LoadTestRepository) ExistsRunning(serviceID string, since time.Time) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.LoadTest{}).
+		Where("service_id = ? AND status = ? AND created_at > ?", serviceID, models.LoadTestStatusRunning, since).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// Create saves a new load test
+func (r *LoadTestRepository) Create(test models.LoadTest) (models.LoadTest, error) {
+	result := database.DB.Omit("Service").Create(&test)
+	return test, result.Error
+}
+
+// Update saves the status and results of a load test
+func (r *LoadTestRepository) Update(test models.LoadTest) error {
+	return database.DB.Omit("Service").Save(&test).Error
+}
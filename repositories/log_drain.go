@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// LogDrainRepository handles database operations for project log drains
+type LogDrainRepository struct{}
+
+// NewLogDrainRepository creates a new log drain repository instance
+func NewLogDrainRepository() *LogDrainRepository {
+	return &LogDrainRepository{}
+}
+
+// FindByID retrieves a log drain by ID
+func (r *LogDrainRepository) FindByID(id string) (models.LogDrain, error) {
+	var drain models.LogDrain
+	result := database.Reader().First(&drain, "id = ?", id)
+	return drain, result.Error
+}
+
+// FindByProjectID retrieves the log drains of a project, ordered by name
+func (r *LogDrainRepository) FindByProjectID(projectID string) ([]models.LogDrain, error) {
+	var drains []models.LogDrain
+	result := database.Reader().Where("project_id = ?", projectID).Order("name ASC").Find(&drains)
+	return drains, result.Error
+}
+
+// FindEnabled retrieves every enabled log drain
+func (r *LogDrainRepository) FindEnabled() ([]models.LogDrain, error) {
+	var drains []models.LogDrain
+	result := database.Reader().Where("enabled = ?", true).Order("project_id, name").Find(&drains)
+	return drains, result.Error
+}
+
+// Create stores a new log drain
+func (r *LogDrainRepository) Create(drain models.LogDrain) (models.LogDrain, error) {
+	result := database.DB.Create(&drain)
+	return drain, result.Error
+}
+
+// Update saves the configuration of a log drain
+func (r *LogDrainRepository) Update(drain models.LogDrain) (models.LogDrain, error) {
+	result := database.DB.Model(&drain).Select("Name", "Endpoint", "Username", "Token", "Enabled").Updates(&drain)
+	return drain, result.Error
+}
+
+// RecordDelivery counts delivered lines and marks the drain healthy
+func (r *LogDrainRepository) RecordDelivery(id string, lines int, at time.Time) error {
+	return database.DB.Model(&models.LogDrain{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_delivered_at": at,
+		"delivered_lines":   gorm.Expr("delivered_lines + ?", lines),
+	}).Error
+}
+
+// RecordFailure counts dropped lines and stores the delivery error
+func (r *LogDrainRepository) RecordFailure(id string, lines int, message string, at time.Time) error {
+	return database.DB.Model(&models.LogDrain{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_error_at": at,
+		"last_error":    message,
+		"dropped_lines": gorm.Expr("dropped_lines + ?", lines),
+	}).Error
+}
+
+// Delete removes a log drain
+func (r *LogDrainRepository) Delete(id string) error {
+	return database.DB.Delete(&models.LogDrain{}, "id = ?", id).Error
+}
+
+// DeleteByProjectIDTx removes every log drain of a project within a transaction
+func (r *LogDrainRepository) DeleteByProjectIDTx(tx *gorm.DB, projectID string) error {
+	return tx.Delete(&models.LogDrain{}, "project_id = ?", projectID).Error
+}
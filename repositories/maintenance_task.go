@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// MaintenanceTaskRepository handles database operations for maintenance tasks and their runs
+type MaintenanceTaskRepository struct{}
+
+// NewMaintenanceTaskRepository creates a new maintenance task repository instance
+func NewMaintenanceTaskRepository() *MaintenanceTaskRepository {
+	return &MaintenanceTaskRepository{}
+}
+
+// FindByID retrieves a maintenance task by ID
+func (r *MaintenanceTaskRepository) FindByID(id string) (models.MaintenanceTask, error) {
+	var task models.MaintenanceTask
+	result := database.Reader().First(&task, "id = ?", id)
+	return task, result.Error
+}
+
+// FindByEnvironmentID retrieves the maintenance tasks of an environment, ordered by name
+func (r *MaintenanceTaskRepository) FindByEnvironmentID(environmentID string) ([]models.MaintenanceTask, error) {
+	var tasks []models.MaintenanceTask
+	result := database.Reader().Where("environment_id = ?", environmentID).Order("name ASC").Find(&tasks)
+	return tasks, result.Error
+}
+
+// FindScheduled retrieves the tasks that are not suspended
+func (r *MaintenanceTaskRepository) FindScheduled() ([]models.MaintenanceTask, error) {
+	var tasks []models.MaintenanceTask
+	result := database.Reader().Where("suspended = ?", false).Find(&tasks)
+	return tasks, result.Error
+}
+
+// Create inserts a new maintenance task
+func (r *MaintenanceTaskRepository) Create(task models.MaintenanceTask) (models.MaintenanceTask, error) {
+	result := database.DB.Omit("Environment").Create(&task)
+	return task, result.Error
+}
+
+// Update saves changes to a maintenance task
+func (r *MaintenanceTaskRepository) Update(task models.MaintenanceTask) (models.MaintenanceTask, error) {
+	result := database.DB.Omit("Environment").Save(&task)
+	return task, result.Error
+}
+
+// MarkScheduled records the schedule time of a run, unless a run of that time was already
+// recorded. It reports whether this caller claimed the run.
+func (r *MaintenanceTaskRepository) MarkScheduled(id string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.MaintenanceTask{}).
+		Where("id = ? AND (last_scheduled_at IS NULL OR last_scheduled_at < ?)", id, at).
+		Update("last_scheduled_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes a maintenance task and its run history
+func (r *MaintenanceTaskRepository) Delete(id string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", id).Delete(&models.MaintenanceTaskRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.MaintenanceTask{}).Error
+	})
+}
+
+// FindRunByID retrieves a run by ID
+func (r *MaintenanceTaskRepository) FindRunByID(id string) (models.MaintenanceTaskRun, error) {
+	var run models.MaintenanceTaskRun
+	result := database.Reader().First(&run, "id = ?", id)
+	return run, result.Error
+}
+
+// FindRunsByTaskID retrieves a page of a task's runs, newest first
+func (r *MaintenanceTaskRepository) FindRunsByTaskID(taskID string, page, pageSize int) ([]models.MaintenanceTaskRun, int64, error) {
+	var runs []models.MaintenanceTaskRun
+	var total int64
+
+	query := database.Reader().Model(&models.MaintenanceTaskRun{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("started_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&runs)
+	return runs, total, result.Error
+}
+
+// FindLatestRun retrieves the most recent run of a task
+func (r *MaintenanceTaskRepository) FindLatestRun(taskID string) (models.MaintenanceTaskRun, error) {
+	var run models.MaintenanceTaskRun
+	result := database.Reader().Where("task_id = ?", taskID).Order("started_at DESC").First(&run)
+	return run, result.Error
+}
+
+// ExistsRunning reports whether a run of the task started after the given time is still running
+func (r *MaintenanceTaskRepository) ExistsRunning(taskID string, since time.Time) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.MaintenanceTaskRun{}).
+		Where("task_id = ? AND status = ? AND started_at > ?", taskID, models.MaintenanceRunRunning, since).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// CreateRun saves a new run
+func (r *MaintenanceTaskRepository) CreateRun(run models.MaintenanceTaskRun) (models.MaintenanceTaskRun, error) {
+	result := database.DB.Omit("Task").Create(&run)
+	return run, result.Error
+}
+
+// UpdateRun saves the outcome of a run
+func (r *MaintenanceTaskRepository) UpdateRun(run models.MaintenanceTaskRun) error {
+	return database.DB.Omit("Task").Save(&run).Error
+}
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/dto"
+)
+
+// metricSource is a table of samples or events of services that metric queries aggregate.
+// Metrics and dimensions are fixed SQL over the row (alias m) and its service (s),
+// environment (e) and project (p); queries only select them by name.
+type metricSource struct {
+	name        string
+	description string
+	table       string
+	timeColumn  string
+	metrics     []metricDefinition
+	dimensions  []metricDimension // besides the common ones
+}
+
+type metricDefinition struct {
+	name        string
+	unit        string
+	description string
+	expression  string
+}
+
+type metricDimension struct {
+	name  string
+	key   string
+	label string
+}
+
+// commonMetricDimensions group every source by the service the rows belong to
+var commonMetricDimensions = []metricDimension{
+	{name: "service", key: "CAST(s.id AS text)", label: "s.name"},
+	{name: "environment", key: "CAST(s.environment_id AS text)", label: "e.name"},
+	{name: "project", key: "CAST(s.project_id AS text)", label: "p.name"},
+	{name: "serviceType", key: "s.type", label: "s.type"},
+}
+
+var metricSources = []metricSource{
+	{
+		name:        "usage",
+		description: "Resource usage of the pods of each service, sampled every few minutes",
+		table:       "service_usage_samples",
+		timeColumn:  "sampled_at",
+		metrics: []metricDefinition{
+			{name: "cpuUsed", unit: "millicores", description: "CPU used by all pods of the service", expression: "m.cpu_used"},
+			{name: "memoryUsed", unit: "bytes", description: "Memory used by all pods of the service", expression: "m.memory_used"},
+			{name: "maxPodCpuUsed", unit: "millicores", description: "CPU used by the busiest pod", expression: "m.max_pod_cpu_used"},
+			{name: "maxPodMemoryUsed", unit: "bytes", description: "Memory used by the largest pod", expression: "m.max_pod_memory_used"},
+			{name: "pods", unit: "count", description: "Running pods", expression: "m.pods"},
+		},
+	},
+	{
+		name:        "uptime",
+		description: "Results of the uptime monitor probes of each service",
+		table:       "uptime_checks",
+		timeColumn:  "checked_at",
+		metrics: []metricDefinition{
+			{name: "availability", unit: "ratio", description: "1 for probes that found the service up, 0 otherwise; avg is the uptime", expression: "CASE WHEN m.up THEN 1 ELSE 0 END"},
+			{name: "latencyMs", unit: "ms", description: "Response time of the probe", expression: "m.latency_ms"},
+			{name: "checks", unit: "count", description: "Probes; use sum or count", expression: "1"},
+		},
+		dimensions: []metricDimension{
+			{name: "statusCode", key: "CAST(m.status_code AS text)", label: "CAST(m.status_code AS text)"},
+		},
+	},
+	{
+		name:        "deployments",
+		description: "Deployments of each service, by the time they were created",
+		table:       "deployments",
+		timeColumn:  "created_at",
+		metrics: []metricDefinition{
+			{name: "deployments", unit: "count", description: "Deployments; use sum or count", expression: "1"},
+			{name: "failed", unit: "count", description: "1 for failed deployments, 0 otherwise; sum counts failures, avg is the failure rate", expression: "CASE WHEN m.status = 'failed' THEN 1 ELSE 0 END"},
+			{name: "buildDurationMs", unit: "ms", description: "Run time of the build job, for deployments that built an image", expression: "NULLIF(m.build_duration_ms, 0)"},
+			{name: "imageSize", unit: "bytes", description: "Compressed size of the built image", expression: "NULLIF(m.image_size, 0)"},
+		},
+		dimensions: []metricDimension{
+			{name: "status", key: "m.status", label: "m.status"},
+			{name: "failureClass", key: "m.failure_class", label: "m.failure_class"},
+			{name: "errorCode", key: "m.error_code", label: "m.error_code"},
+		},
+	},
+	{
+		name:        "incidents",
+		description: "Service incidents, by the time they started",
+		table:       "service_incidents",
+		timeColumn:  "started_at",
+		metrics: []metricDefinition{
+			{name: "incidents", unit: "count", description: "Incidents; use sum or count", expression: "1"},
+			{name: "durationSeconds", unit: "seconds", description: "Time to resolve; open incidents count until now", expression: "EXTRACT(EPOCH FROM COALESCE(m.resolved_at, NOW()) - m.started_at)"},
+		},
+		dimensions: []metricDimension{
+			{name: "probableCause", key: "m.probable_cause", label: "m.probable_cause"},
+		},
+	},
+}
+
+// metricAggregations maps the aggregations of metric queries to SQL
+var metricAggregations = map[string]string{
+	"avg":   "AVG(%s)",
+	"min":   "MIN(%s)",
+	"max":   "MAX(%s)",
+	"sum":   "SUM(%s)",
+	"count": "COUNT(%s)",
+	"p95":   "percentile_cont(0.95) WITHIN GROUP (ORDER BY %s)",
+}
+
+// MetricQuery is a metric query whose names were checked against the catalog
+type MetricQuery struct {
+	Source        string
+	Metric        string
+	Aggregation   string
+	GroupBy       []string
+	BucketSeconds int64 // 0 aggregates the whole range
+	From          time.Time
+	To            time.Time
+	ProjectID     string
+	EnvironmentID string
+	ServiceIDs    []string
+	Limit         int
+}
+
+// MetricRow is an aggregated value of a bucket and combination of the grouped dimensions.
+// Keys and Labels follow the order of MetricQuery.GroupBy.
+type MetricRow struct {
+	Bucket *time.Time
+	Keys   []string
+	Labels []string
+	Value  float64
+}
+
+// metricRow is scanned from the query; up to three dimensions can be grouped
+type metricRow struct {
+	Bucket *time.Time `gorm:"column:bucket"`
+	Key0   string     `gorm:"column:key0"`
+	Label0 string     `gorm:"column:label0"`
+	Key1   string     `gorm:"column:key1"`
+	Label1 string     `gorm:"column:label1"`
+	Key2   string     `gorm:"column:key2"`
+	Label2 string     `gorm:"column:label2"`
+	Value  *float64   `gorm:"column:value"`
+}
+
+// MaxMetricGroupBy is how many dimensions a metric query can group by
+const MaxMetricGroupBy = 3
+
+// MetricQueryRepository aggregates stored samples and events for dashboard widgets. Every
+// query is scoped to the caller's projects unless the caller is an admin.
+type MetricQueryRepository struct{}
+
+// NewMetricQueryRepository creates a new metric query repository instance
+func NewMetricQueryRepository() *MetricQueryRepository {
+	return &MetricQueryRepository{}
+}
+
+// Sources lists the queryable sources with their metrics and dimensions
+func (r *MetricQueryRepository) Sources() []dto.MetricSourceInfo {
+	sources := make([]dto.MetricSourceInfo, 0, len(metricSources))
+	for _, source := range metricSources {
+		info := dto.MetricSourceInfo{Name: source.name, Description: source.description}
+		for _, metric := range source.metrics {
+			info.Metrics = append(info.Metrics, dto.MetricInfo{Name: metric.name, Unit: metric.unit, Description: metric.description})
+		}
+		for _, dimension := range append(append([]metricDimension{}, commonMetricDimensions...), source.dimensions...) {
+			info.Dimensions = append(info.Dimensions, dimension.name)
+		}
+		sources = append(sources, info)
+	}
+	return sources
+}
+
+// Aggregations lists the aggregations of metric queries
+func (r *MetricQueryRepository) Aggregations() []string {
+	return []string{"avg", "min", "max", "sum", "count", "p95"}
+}
+
+// Query aggregates a metric over [From, To), per bucket and combination of the grouped
+// dimensions, ordered by bucket. It returns at most Limit rows and whether more matched.
+func (r *MetricQueryRepository) Query(q MetricQuery, userID string, isAdmin bool) ([]MetricRow, bool, error) {
+	source, metric, dimensions, err := lookupMetric(q)
+	if err != nil {
+		return nil, false, err
+	}
+	aggregation, ok := metricAggregations[q.Aggregation]
+	if !ok {
+		return nil, false, fmt.Errorf("unknown aggregation %q", q.Aggregation)
+	}
+
+	var selects, groups []string
+	if q.BucketSeconds > 0 {
+		selects = append(selects, fmt.Sprintf("to_timestamp(floor(extract(epoch FROM m.%s) / %d) * %d) AS bucket", source.timeColumn, q.BucketSeconds, q.BucketSeconds))
+		groups = append(groups, "bucket")
+	}
+	for i, dimension := range dimensions {
+		selects = append(selects,
+			fmt.Sprintf("COALESCE(%s, '') AS key%d", dimension.key, i),
+			fmt.Sprintf("COALESCE(%s, '') AS label%d", dimension.label, i))
+		groups = append(groups, fmt.Sprintf("key%d", i), fmt.Sprintf("label%d", i))
+	}
+	selects = append(selects, fmt.Sprintf(aggregation, metric.expression)+" AS value")
+
+	query := database.Reader().Table(source.table+" AS m").
+		Select(strings.Join(selects, ", ")).
+		Joins("JOIN services AS s ON s.id = m.service_id").
+		Joins("JOIN projects AS p ON p.id = s.project_id AND p.deleted_at IS NULL").
+		Joins("LEFT JOIN environments AS e ON e.id = s.environment_id").
+		Where(fmt.Sprintf("m.%s >= ? AND m.%s < ?", source.timeColumn, source.timeColumn), q.From, q.To)
+	query = scopeToOwner(query, userID, isAdmin)
+	if q.ProjectID != "" {
+		query = query.Where("s.project_id = ?", q.ProjectID)
+	}
+	if q.EnvironmentID != "" {
+		query = query.Where("s.environment_id = ?", q.EnvironmentID)
+	}
+	if len(q.ServiceIDs) > 0 {
+		query = query.Where("s.id IN ?", q.ServiceIDs)
+	}
+	if len(groups) > 0 {
+		query = query.Group(strings.Join(groups, ", ")).Order(strings.Join(groups, ", "))
+	}
+
+	var scanned []metricRow
+	if err := query.Limit(q.Limit + 1).Scan(&scanned).Error; err != nil {
+		return nil, false, err
+	}
+	truncated := len(scanned) > q.Limit
+	if truncated {
+		scanned = scanned[:q.Limit]
+	}
+
+	rows := make([]MetricRow, 0, len(scanned))
+	for _, row := range scanned {
+		if row.Value == nil {
+			// No non-null values in the group, e.g. no builds among the deployments
+			continue
+		}
+		keys := []string{row.Key0, row.Key1, row.Key2}[:len(dimensions)]
+		labels := []string{row.Label0, row.Label1, row.Label2}[:len(dimensions)]
+		rows = append(rows, MetricRow{Bucket: row.Bucket, Keys: keys, Labels: labels, Value: *row.Value})
+	}
+	return rows, truncated, nil
+}
+
+// MetricUnit returns the unit of a metric of a source, empty for unknown metrics
+func (r *MetricQueryRepository) MetricUnit(sourceName, metricName string) string {
+	_, metric, _, err := lookupMetric(MetricQuery{Source: sourceName, Metric: metricName})
+	if err != nil {
+		return ""
+	}
+	return metric.unit
+}
+
+// lookupMetric resolves the names of a query to their definitions
+func lookupMetric(q MetricQuery) (metricSource, metricDefinition, []metricDimension, error) {
+	for _, source := range metricSources {
+		if source.name != q.Source {
+			continue
+		}
+
+		var metric *metricDefinition
+		for i := range source.metrics {
+			if source.metrics[i].name == q.Metric {
+				metric = &source.metrics[i]
+			}
+		}
+		if metric == nil {
+			return source, metricDefinition{}, nil, fmt.Errorf("unknown metric %q of source %s", q.Metric, q.Source)
+		}
+
+		available := append(append([]metricDimension{}, commonMetricDimensions...), source.dimensions...)
+		dimensions := make([]metricDimension, 0, len(q.GroupBy))
+		for _, name := range q.GroupBy {
+			found := false
+			for _, dimension := range available {
+				if dimension.name == name {
+					dimensions = append(dimensions, dimension)
+					found = true
+				}
+			}
+			if !found {
+				return source, *metric, nil, fmt.Errorf("unknown dimension %q of source %s", name, q.Source)
+			}
+		}
+		if len(dimensions) > MaxMetricGroupBy {
+			return source, *metric, nil, fmt.Errorf("at most %d dimensions can be grouped", MaxMetricGroupBy)
+		}
+		return source, *metric, dimensions, nil
+	}
+	return metricSource{}, metricDefinition{}, nil, fmt.Errorf("unknown source %q", q.Source)
+}
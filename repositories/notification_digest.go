@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// NotificationDigestRepository handles database operations for the SMTP settings and the
+// digest email subscriptions of projects
+type NotificationDigestRepository struct{}
+
+// NewNotificationDigestRepository creates a new notification digest repository instance
+func NewNotificationDigestRepository() *NotificationDigestRepository {
+	return &NotificationDigestRepository{}
+}
+
+// FindSMTPSettings retrieves the SMTP settings, or the defaults when none were saved
+func (r *NotificationDigestRepository) FindSMTPSettings() (models.SMTPSettings, error) {
+	var settings models.SMTPSettings
+	result := database.Reader().First(&settings, models.SMTPSettingsID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.DefaultSMTPSettings(), nil
+	}
+	return settings, result.Error
+}
+
+// SaveSMTPSettings creates or updates the SMTP settings
+func (r *NotificationDigestRepository) SaveSMTPSettings(settings models.SMTPSettings) (models.SMTPSettings, error) {
+	settings.ID = models.SMTPSettingsID
+	result := database.DB.Save(&settings)
+	return settings, result.Error
+}
+
+// FindSubscriptionByID retrieves a digest subscription by ID
+func (r *NotificationDigestRepository) FindSubscriptionByID(id string) (models.DigestSubscription, error) {
+	var subscription models.DigestSubscription
+	result := database.Reader().First(&subscription, "id = ?", id)
+	return subscription, result.Error
+}
+
+// FindSubscriptionsByProjectID retrieves the digest subscriptions of a project, ordered by email
+func (r *NotificationDigestRepository) FindSubscriptionsByProjectID(projectID string) ([]models.DigestSubscription, error) {
+	var subscriptions []models.DigestSubscription
+	result := database.Reader().Where("project_id = ?", projectID).Order("email ASC").Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+// FindEnabledSubscriptions retrieves the enabled digest subscriptions of one frequency
+func (r *NotificationDigestRepository) FindEnabledSubscriptions(frequency string) ([]models.DigestSubscription, error) {
+	var subscriptions []models.DigestSubscription
+	result := database.Reader().
+		Joins("JOIN projects ON projects.id = digest_subscriptions.project_id AND projects.deleted_at IS NULL").
+		Where("digest_subscriptions.enabled = ? AND digest_subscriptions.frequency = ?", true, frequency).
+		Order("digest_subscriptions.project_id, digest_subscriptions.email").
+		Find(&subscriptions)
+	return subscriptions, result.Error
+}
+
+// CreateSubscription stores a new digest subscription
+func (r *NotificationDigestRepository) CreateSubscription(subscription models.DigestSubscription) (models.DigestSubscription, error) {
+	result := database.DB.Create(&subscription)
+	return subscription, result.Error
+}
+
+// UpdateSubscription saves the frequency, state and last sent period of a digest subscription
+func (r *NotificationDigestRepository) UpdateSubscription(subscription models.DigestSubscription) (models.DigestSubscription, error) {
+	result := database.DB.Model(&subscription).Select("Frequency", "Enabled", "LastPeriodEnd").Updates(&subscription)
+	return subscription, result.Error
+}
+
+// ClaimPeriod marks the period ending at periodEnd as sent for a subscription. It reports
+// false when it already was, e.g. by another replica, so each digest goes out once.
+func (r *NotificationDigestRepository) ClaimPeriod(id string, periodEnd time.Time) (bool, error) {
+	result := database.DB.Model(&models.DigestSubscription{}).
+		Where("id = ? AND (last_period_end IS NULL OR last_period_end < ?)", id, periodEnd).
+		Update("last_period_end", periodEnd)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordSendError stores why the last digest of a subscription could not be sent; an empty
+// message clears it
+func (r *NotificationDigestRepository) RecordSendError(id string, message string) error {
+	return database.DB.Model(&models.DigestSubscription{}).Where("id = ?", id).Update("last_error", message).Error
+}
+
+// DeleteSubscription removes a digest subscription
+func (r *NotificationDigestRepository) DeleteSubscription(id string) error {
+	return database.DB.Delete(&models.DigestSubscription{}, "id = ?", id).Error
+}
+
+// FindProjectDeployments retrieves the deployments of a project's services created in
+// [from, to), oldest first
+func (r *NotificationDigestRepository) FindProjectDeployments(projectID string, from, to time.Time) ([]models.Deployment, error) {
+	var deployments []models.Deployment
+	result := database.Reader().
+		Joins("JOIN services ON services.id = deployments.service_id").
+		Where("services.project_id = ? AND deployments.created_at >= ? AND deployments.created_at < ?", projectID, from, to).
+		Order("deployments.created_at ASC").
+		Find(&deployments)
+	return deployments, result.Error
+}
+
+// FindProjectIncidents retrieves the incidents of a project's services started in [from, to)
+// or still open at from, with their timelines
+func (r *NotificationDigestRepository) FindProjectIncidents(projectID string, from, to time.Time) ([]models.ServiceIncident, error) {
+	var incidents []models.ServiceIncident
+	result := database.Reader().
+		Joins("JOIN services ON services.id = service_incidents.service_id").
+		Where("services.project_id = ? AND service_incidents.started_at < ?", projectID, to).
+		Where("service_incidents.resolved_at IS NULL OR service_incidents.resolved_at >= ?", from).
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("started_at ASC")
+		}).
+		Order("service_incidents.started_at ASC").
+		Find(&incidents)
+	return incidents, result.Error
+}
+
+// FindProjectAnomalies retrieves the usage anomalies of a project's services detected in
+// [from, to) or still open at from
+func (r *NotificationDigestRepository) FindProjectAnomalies(projectID string, from, to time.Time) ([]models.UsageAnomaly, error) {
+	var anomalies []models.UsageAnomaly
+	result := database.Reader().
+		Joins("JOIN services ON services.id = usage_anomalies.service_id").
+		Where("services.project_id = ? AND usage_anomalies.detected_at < ?", projectID, to).
+		Where("usage_anomalies.resolved_at IS NULL OR usage_anomalies.resolved_at >= ?", from).
+		Order("usage_anomalies.detected_at ASC").
+		Find(&anomalies)
+	return anomalies, result.Error
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// OffboardingRepository handles the database side of deleting an account: looking up
+// transfer targets and anonymizing the user and their audit trail
+type OffboardingRepository struct{}
+
+// NewOffboardingRepository creates a new offboarding repository instance
+func NewOffboardingRepository() *OffboardingRepository {
+	return &OffboardingRepository{}
+}
+
+// FindUserByEmail retrieves an active user by email
+func (r *OffboardingRepository) FindUserByEmail(email string) (models.User, error) {
+	var user models.User
+	result := database.Reader().Where("LOWER(email) = ?", strings.ToLower(email)).First(&user)
+	return user, result.Error
+}
+
+// CountAdmins counts the active admin accounts
+func (r *OffboardingRepository) CountAdmins() (int64, error) {
+	var count int64
+	result := database.Reader().Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&count)
+	return count, result.Error
+}
+
+// AnonymizedEmail is the address a deleted account and its login records are left with
+func AnonymizedEmail(userID string) string {
+	return "deleted-" + userID + "@deleted.invalid"
+}
+
+// DeleteAccount deletes the user's audit records created before cutoff, strips the personal
+// data (email, IP addresses, user agents) from the newer ones, and soft-deletes the user
+// after replacing its email, names, password and second factor. The records keep the user
+// ID, which no longer leads to a person.
+func (r *OffboardingRepository) DeleteAccount(userID string, cutoff time.Time) (anonymized int64, deleted int64, err error) {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		pseudonym := AnonymizedEmail(userID)
+
+		purges := []*gorm.DB{
+			tx.Where("(user_id = ? OR email = ?) AND created_at < ?", userID, user.Email, cutoff).Delete(&models.LoginAttempt{}),
+			tx.Where("user_id = ? AND created_at < ?", userID, cutoff).Delete(&models.ConsoleAuditLog{}),
+			tx.Where("user_id = ? AND created_at < ?", userID, cutoff).Delete(&models.EnvRevealAuditLog{}),
+			tx.Where("(user_id = ? OR impersonator_id = ?) AND created_at < ?", userID, userID, cutoff).Delete(&models.ImpersonationAuditLog{}),
+		}
+		for _, result := range purges {
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+
+		result := tx.Model(&models.LoginAttempt{}).Where("user_id = ? OR email = ?", userID, user.Email).
+			UpdateColumns(map[string]interface{}{"email": pseudonym, "ip_address": "", "user_agent": ""})
+		if result.Error != nil {
+			return result.Error
+		}
+		anonymized += result.RowsAffected
+
+		// The IP address of an impersonation record is the admin's
+		result = tx.Model(&models.ImpersonationAuditLog{}).Where("impersonator_id = ?", userID).
+			UpdateColumn("ip_address", "")
+		if result.Error != nil {
+			return result.Error
+		}
+		anonymized += result.RowsAffected
+
+		if err := tx.Model(&models.UserSession{}).Where("user_id = ?", userID).
+			UpdateColumns(map[string]interface{}{"ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).UpdateColumns(map[string]interface{}{
+			"email":                pseudonym,
+			"username":             nil,
+			"name":                 nil,
+			"password":             "",
+			"two_factor_enabled":   false,
+			"two_factor_secret":    nil,
+			"two_factor_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, "id = ?", userID).Error
+	})
+	return anonymized, deleted, err
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// OutboxRepository handles database operations for outbox events
+type OutboxRepository struct{}
+
+// NewOutboxRepository creates a new outbox repository instance
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{}
+}
+
+// Enqueue writes an event inside the caller's transaction
+func (r *OutboxRepository) Enqueue(tx *gorm.DB, event models.OutboxEvent) error {
+	event.Status = models.OutboxStatusPending
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now()
+	}
+	return tx.Create(&event).Error
+}
+
+// ClaimDue leases up to limit pending events that are due. Claimed events are pushed
+// back by lease so a dispatcher that crashes mid-delivery leaves them to be retried,
+// and concurrent dispatchers skip rows another one has locked.
+func (r *OutboxRepository) ClaimDue(limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	now := time.Now()
+	err := database.DB.Raw(`
+		UPDATE outbox_events
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, now.Add(lease), models.OutboxStatusPending, now, limit).Scan(&events).Error
+	return events, err
+}
+
+// MarkDelivered records a successful delivery
+func (r *OutboxRepository) MarkDelivered(id string, attempts int) error {
+	now := time.Now()
+	return database.DB.Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxStatusDelivered,
+			"attempts":     attempts,
+			"delivered_at": &now,
+			"last_error":   nil,
+		}).Error
+}
+
+// MarkFailed records a failed attempt and schedules the next one, or gives up when dead is set
+func (r *OutboxRepository) MarkFailed(id string, attempts int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := models.OutboxStatusPending
+	if dead {
+		status = models.OutboxStatusDead
+	}
+	return database.DB.Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+// DeleteDeliveredBefore removes delivered events older than cutoff
+func (r *OutboxRepository) DeleteDeliveredBefore(cutoff time.Time) (int64, error) {
+	result := database.DB.Where("status = ? AND delivered_at < ?", models.OutboxStatusDelivered, cutoff).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// PauseScheduleRepository handles database operations for service pause schedules
+type PauseScheduleRepository struct{}
+
+// NewPauseScheduleRepository creates a new pause schedule repository instance
+func NewPauseScheduleRepository() *PauseScheduleRepository {
+	return &PauseScheduleRepository{}
+}
+
+// FindByServiceID retrieves the pause schedule of a service
+func (r *PauseScheduleRepository) FindByServiceID(serviceID string) (models.ServicePauseSchedule, error) {
+	var schedule models.ServicePauseSchedule
+	result := database.DB.First(&schedule, "service_id = ?", serviceID)
+	return schedule, result.Error
+}
+
+// FindEnabled retrieves all enabled pause schedules
+func (r *PauseScheduleRepository) FindEnabled() ([]models.ServicePauseSchedule, error) {
+	var schedules []models.ServicePauseSchedule
+	result := database.DB.Where("enabled = ?", true).Find(&schedules)
+	return schedules, result.Error
+}
+
+// Save creates or updates a pause schedule
+func (r *PauseScheduleRepository) Save(schedule models.ServicePauseSchedule) (models.ServicePauseSchedule, error) {
+	result := database.DB.Save(&schedule)
+	return schedule, result.Error
+}
+
+// DeleteByServiceID removes the pause schedule of a service
+func (r *PauseScheduleRepository) DeleteByServiceID(serviceID string) error {
+	return database.DB.Where("service_id = ?", serviceID).Delete(&models.ServicePauseSchedule{}).Error
+}
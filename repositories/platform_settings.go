@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// PlatformSettingsRepository handles database operations for the platform settings
+type PlatformSettingsRepository struct{}
+
+// NewPlatformSettingsRepository creates a new platform settings repository instance
+func NewPlatformSettingsRepository() *PlatformSettingsRepository {
+	return &PlatformSettingsRepository{}
+}
+
+// FindSettings retrieves the platform settings, or the defaults when none were saved
+func (r *PlatformSettingsRepository) FindSettings() (models.PlatformSettings, error) {
+	var settings models.PlatformSettings
+	result := database.Reader().First(&settings, models.PlatformSettingsID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.DefaultPlatformSettings(), nil
+	}
+	return settings, result.Error
+}
+
+// SaveSettings creates or updates the platform settings
+func (r *PlatformSettingsRepository) SaveSettings(settings models.PlatformSettings) (models.PlatformSettings, error) {
+	settings.ID = models.PlatformSettingsID
+	result := database.DB.Save(&settings)
+	return settings, result.Error
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// PolicyRepository handles database operations for policy rules and their violations
+type PolicyRepository struct{}
+
+// NewPolicyRepository creates a new policy repository instance
+func NewPolicyRepository() *PolicyRepository {
+	return &PolicyRepository{}
+}
+
+// FindRules retrieves all configured policy rules
+func (r *PolicyRepository) FindRules() ([]models.PolicyRule, error) {
+	var rules []models.PolicyRule
+	result := database.DB.Find(&rules)
+	return rules, result.Error
+}
+
+// SaveRule creates or updates a policy rule
+func (r *PolicyRepository) SaveRule(rule models.PolicyRule) (models.PolicyRule, error) {
+	result := database.DB.Save(&rule)
+	return rule, result.Error
+}
+
+// CreateViolations stores violations found in one evaluation
+func (r *PolicyRepository) CreateViolations(violations []models.PolicyViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return database.DB.Create(&violations).Error
+}
+
+// FindViolations retrieves violations, newest first, optionally for one rule or service
+func (r *PolicyRepository) FindViolations(ruleID string, serviceID string, page, pageSize int) ([]models.PolicyViolation, int64, error) {
+	query := database.Reader().Model(&models.PolicyViolation{})
+	if ruleID != "" {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+	if serviceID != "" {
+		query = query.Where("service_id = ?", serviceID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var violations []models.PolicyViolation
+	result := query.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&violations)
+	return violations, total, result.Error
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// PriorityTierRepository handles database operations for scheduling priority tiers
+type PriorityTierRepository struct{}
+
+// NewPriorityTierRepository creates a new priority tier repository instance
+func NewPriorityTierRepository() *PriorityTierRepository {
+	return &PriorityTierRepository{}
+}
+
+// FindAll retrieves every tier, highest priority first
+func (r *PriorityTierRepository) FindAll() ([]models.PriorityTier, error) {
+	var tiers []models.PriorityTier
+	result := database.Reader().Order("value DESC").Find(&tiers)
+	return tiers, result.Error
+}
+
+// FindByName retrieves a tier by name
+func (r *PriorityTierRepository) FindByName(name string) (models.PriorityTier, error) {
+	var tier models.PriorityTier
+	result := database.Reader().First(&tier, "name = ?", name)
+	return tier, result.Error
+}
+
+// FindBuildTier retrieves the tier marked for build jobs
+func (r *PriorityTierRepository) FindBuildTier() (models.PriorityTier, error) {
+	var tier models.PriorityTier
+	result := database.Reader().First(&tier, "builds = ?", true)
+	return tier, result.Error
+}
+
+// Save creates or updates a tier. Marking it for builds unmarks the previous build tier.
+func (r *PriorityTierRepository) Save(tier models.PriorityTier) (models.PriorityTier, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if tier.Builds {
+			if err := tx.Model(&models.PriorityTier{}).
+				Where("builds = ? AND name <> ?", true, tier.Name).
+				Update("builds", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&tier).Error
+	})
+	return tier, err
+}
+
+// Delete removes a tier
+func (r *PriorityTierRepository) Delete(name string) error {
+	return database.DB.Where("name = ?", name).Delete(&models.PriorityTier{}).Error
+}
+
+// DB returns the database instance
+func (r *PriorityTierRepository) DB() *gorm.DB {
+	return database.DB
+}
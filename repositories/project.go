@@ -28,6 +28,13 @@ func (r *ProjectRepository) FindByID(id string) (models.Project, error) {
 	return project, result.Error
 }
 
+// FindByIDUnscoped retrieves a project even when it was deleted
+func (r *ProjectRepository) FindByIDUnscoped(id string) (models.Project, error) {
+	var project models.Project
+	result := database.DB.Unscoped().First(&project, "id = ?", id)
+	return project, result.Error
+}
+
 // FindByUserID retrieves all projects belonging to a user
 func (r *ProjectRepository) FindByUserID(userID string) ([]models.Project, error) {
 	var projects []models.Project
@@ -35,6 +42,20 @@ func (r *ProjectRepository) FindByUserID(userID string) ([]models.Project, error
 	return projects, result.Error
 }
 
+// FindDeletedByUserID retrieves the soft-deleted projects of a user
+func (r *ProjectRepository) FindDeletedByUserID(userID string) ([]models.Project, error) {
+	var projects []models.Project
+	result := database.DB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Find(&projects)
+	return projects, result.Error
+}
+
+// FindByUserIDAndName retrieves the projects of a user with the given name
+func (r *ProjectRepository) FindByUserIDAndName(userID string, name string) ([]models.Project, error) {
+	var projects []models.Project
+	result := database.DB.Where("user_id = ? AND name = ?", userID, name).Order("created_at").Find(&projects)
+	return projects, result.Error
+}
+
 // Create inserts a new project into the database
 func (r *ProjectRepository) Create(project models.Project) (models.Project, error) {
 	result := database.DB.Create(&project)
@@ -47,6 +68,27 @@ func (r *ProjectRepository) Update(project models.Project) error {
 	return result.Error
 }
 
+// UpdateErrorPage sets the error page config of a project; nil removes it
+func (r *ProjectRepository) UpdateErrorPage(id string, config *models.ErrorPageConfig) error {
+	return database.DB.Model(&models.Project{}).
+		Where("id = ?", id).
+		Update("error_page", config).Error
+}
+
+// UpdateStatusTx sets the status of a project inside the caller's transaction
+func (r *ProjectRepository) UpdateStatusTx(tx *gorm.DB, id string, status string) error {
+	return tx.Model(&models.Project{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// UpdateOwner transfers a project to another user
+func (r *ProjectRepository) UpdateOwner(id string, userID string) error {
+	return database.DB.Model(&models.Project{}).
+		Where("id = ?", id).
+		Update("user_id", userID).Error
+}
+
 // Delete removes a project from the database (soft delete with cascade)
 func (r *ProjectRepository) Delete(id string) error {
 	// Let cascade handle the related services
@@ -105,7 +147,7 @@ func (r *ProjectRepository) FindWithPagination(
 	var totalCount int64
 	
 	// Buat query dasar
-	db := database.DB.Model(&models.Project{})
+	db := database.Reader().Model(&models.Project{})
 	
 	// Filter by user ID jika bukan admin
 	if !isAdmin && userID != "" {
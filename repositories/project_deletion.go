@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ProjectDeletionRepository handles database operations for the namespace teardown of
+// deleted projects
+type ProjectDeletionRepository struct{}
+
+// NewProjectDeletionRepository creates a new project deletion repository instance
+func NewProjectDeletionRepository() *ProjectDeletionRepository {
+	return &ProjectDeletionRepository{}
+}
+
+// CreateTx inserts a namespace deletion inside the caller's transaction
+func (r *ProjectDeletionRepository) CreateTx(tx *gorm.DB, deletion models.ProjectNamespaceDeletion) (models.ProjectNamespaceDeletion, error) {
+	result := tx.Omit("Project").Create(&deletion)
+	return deletion, result.Error
+}
+
+// FindByID retrieves a namespace deletion
+func (r *ProjectDeletionRepository) FindByID(id string) (models.ProjectNamespaceDeletion, error) {
+	var deletion models.ProjectNamespaceDeletion
+	result := database.DB.First(&deletion, "id = ?", id)
+	return deletion, result.Error
+}
+
+// FindByProjectID retrieves the namespace deletions of a project
+func (r *ProjectDeletionRepository) FindByProjectID(projectID string) ([]models.ProjectNamespaceDeletion, error) {
+	var deletions []models.ProjectNamespaceDeletion
+	result := database.DB.Where("project_id = ?", projectID).Order("created_at").Find(&deletions)
+	return deletions, result.Error
+}
+
+// CountUnfinished counts the namespaces of a project that are not deleted yet
+func (r *ProjectDeletionRepository) CountUnfinished(projectID string) (int64, error) {
+	var count int64
+	result := database.DB.Model(&models.ProjectNamespaceDeletion{}).
+		Where("project_id = ? AND status <> ?", projectID, models.NamespaceDeletionDeleted).
+		Count(&count)
+	return count, result.Error
+}
+
+// RecordAttempt stores the state a teardown attempt left the namespace in. A deleted
+// namespace is never moved back, as a late delivery of the job may record an attempt.
+func (r *ProjectDeletionRepository) RecordAttempt(id string, status models.NamespaceDeletionStatus, attemptErr error) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": nil,
+	}
+	if attemptErr != nil {
+		updates["last_error"] = attemptErr.Error()
+	}
+	if status == models.NamespaceDeletionDeleted {
+		updates["deleted_at"] = time.Now()
+	}
+	return database.DB.Model(&models.ProjectNamespaceDeletion{}).
+		Where("id = ? AND status <> ?", id, models.NamespaceDeletionDeleted).
+		Updates(updates).Error
+}
+
+// MarkFailed gives up on a namespace whose job ran out of attempts
+func (r *ProjectDeletionRepository) MarkFailed(id string, lastError string) error {
+	return database.DB.Model(&models.ProjectNamespaceDeletion{}).
+		Where("id = ? AND status <> ?", id, models.NamespaceDeletionDeleted).
+		Updates(map[string]interface{}{
+			"status":     models.NamespaceDeletionFailed,
+			"last_error": lastError,
+		}).Error
+}
+
+// RetryTx puts a failed namespace deletion back in line inside the caller's transaction
+func (r *ProjectDeletionRepository) RetryTx(tx *gorm.DB, id string) (bool, error) {
+	result := tx.Model(&models.ProjectNamespaceDeletion{}).
+		Where("id = ? AND status = ?", id, models.NamespaceDeletionFailed).
+		Updates(map[string]interface{}{
+			"status":     models.NamespaceDeletionPending,
+			"attempts":   0,
+			"last_error": nil,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ProjectSecretRepository handles database operations for project secrets vault entries
+type ProjectSecretRepository struct{}
+
+// NewProjectSecretRepository creates a new project secret repository instance
+func NewProjectSecretRepository() *ProjectSecretRepository {
+	return &ProjectSecretRepository{}
+}
+
+// FindByID retrieves a project secret by ID
+func (r *ProjectSecretRepository) FindByID(id string) (models.ProjectSecret, error) {
+	var secret models.ProjectSecret
+	result := database.Reader().First(&secret, "id = ?", id)
+	return secret, result.Error
+}
+
+// FindByProjectID retrieves the secrets of a project, ordered by name
+func (r *ProjectSecretRepository) FindByProjectID(projectID string) ([]models.ProjectSecret, error) {
+	var secrets []models.ProjectSecret
+	result := database.Reader().Where("project_id = ?", projectID).Order("name ASC").Find(&secrets)
+	return secrets, result.Error
+}
+
+// Create inserts a new project secret
+func (r *ProjectSecretRepository) Create(secret models.ProjectSecret) (models.ProjectSecret, error) {
+	result := database.DB.Create(&secret)
+	return secret, result.Error
+}
+
+// Touch records that the value of a project secret changed
+func (r *ProjectSecretRepository) Touch(secret models.ProjectSecret) (models.ProjectSecret, error) {
+	result := database.DB.Save(&secret)
+	return secret, result.Error
+}
+
+// Delete removes a project secret
+func (r *ProjectSecretRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.ProjectSecret{}).Error
+}
+
+// DB returns the database instance
+func (r *ProjectSecretRepository) DB() *gorm.DB {
+	return database.DB
+}
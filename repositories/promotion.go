@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// PromotionRepository handles database operations for promotions
+type PromotionRepository struct{}
+
+// NewPromotionRepository creates a new promotion repository instance
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{}
+}
+
+// Create inserts a promotion
+func (r *PromotionRepository) Create(promotion models.Promotion) (models.Promotion, error) {
+	result := database.DB.Omit("Project").Create(&promotion)
+	return promotion, result.Error
+}
+
+// FindByID retrieves a promotion
+func (r *PromotionRepository) FindByID(id string) (models.Promotion, error) {
+	var promotion models.Promotion
+	result := database.DB.First(&promotion, "id = ?", id)
+	return promotion, result.Error
+}
+
+// FindByProjectID retrieves a page of a project's promotions, newest first
+func (r *PromotionRepository) FindByProjectID(projectID string, status string, page, pageSize int) ([]models.Promotion, int64, error) {
+	var promotions []models.Promotion
+	var total int64
+
+	query := database.Reader().Model(&models.Promotion{}).Where("project_id = ?", projectID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&promotions)
+	return promotions, total, result.Error
+}
+
+// Review approves or rejects a promotion waiting for approval. Only one reviewer succeeds,
+// so a promotion is never deployed twice.
+func (r *PromotionRepository) Review(id string, status models.PromotionStatus, reviewerID string, comment string, at time.Time) (bool, error) {
+	result := database.DB.Model(&models.Promotion{}).
+		Where("id = ? AND status = ?", id, models.PromotionPendingApproval).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    reviewerID,
+			"reviewed_at":    at,
+			"review_comment": comment,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordResult stores the deployment a promotion created, or why it failed
+func (r *PromotionRepository) RecordResult(id string, deploymentID string, runErr error) error {
+	updates := map[string]interface{}{"status": models.PromotionDeployed, "target_deployment_id": deploymentID}
+	if runErr != nil {
+		updates = map[string]interface{}{"status": models.PromotionFailed, "error": runErr.Error()}
+	}
+	return database.DB.Model(&models.Promotion{}).Where("id = ?", id).Updates(updates).Error
+}
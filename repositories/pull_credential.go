@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// PullCredentialRepository handles database operations for private registry pull credentials
+type PullCredentialRepository struct{}
+
+// NewPullCredentialRepository creates a new pull credential repository instance
+func NewPullCredentialRepository() *PullCredentialRepository {
+	return &PullCredentialRepository{}
+}
+
+// FindByID retrieves a pull credential by ID
+func (r *PullCredentialRepository) FindByID(id string) (models.PullCredential, error) {
+	var credential models.PullCredential
+	result := database.Reader().First(&credential, "id = ?", id)
+	return credential, result.Error
+}
+
+// FindByProjectID retrieves the pull credentials of a project, ordered by name
+func (r *PullCredentialRepository) FindByProjectID(projectID string) ([]models.PullCredential, error) {
+	var credentials []models.PullCredential
+	result := database.Reader().Where("project_id = ?", projectID).Order("name ASC").Find(&credentials)
+	return credentials, result.Error
+}
+
+// FindForEnvironment retrieves the project-wide credentials and those of the environment
+func (r *PullCredentialRepository) FindForEnvironment(projectID, environmentID string) ([]models.PullCredential, error) {
+	var credentials []models.PullCredential
+	result := database.Reader().
+		Where("project_id = ? AND (environment_id IS NULL OR environment_id = ?)", projectID, environmentID).
+		Order("name ASC").
+		Find(&credentials)
+	return credentials, result.Error
+}
+
+// Create inserts a new pull credential
+func (r *PullCredentialRepository) Create(credential models.PullCredential) (models.PullCredential, error) {
+	result := database.DB.Create(&credential)
+	return credential, result.Error
+}
+
+// Update saves changes to a pull credential
+func (r *PullCredentialRepository) Update(credential models.PullCredential) (models.PullCredential, error) {
+	result := database.DB.Save(&credential)
+	return credential, result.Error
+}
+
+// Delete removes a pull credential
+func (r *PullCredentialRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.PullCredential{}).Error
+}
+
+// DB returns the database instance
+func (r *PullCredentialRepository) DB() *gorm.DB {
+	return database.DB
+}
@@ -45,6 +45,15 @@ func (r *RegistryRepository) FindDefault() (models.Registry, error) {
 	return registry, result.Error
 }
 
+// FindReadyProxy retrieves the oldest ready, active pull-through cache registry
+func (r *RegistryRepository) FindReadyProxy() (models.Registry, error) {
+	var registry models.Registry
+	result := database.DB.Where("mode = ? AND is_active = ? AND status = ?", models.RegistryModeProxy, true, models.RegistryStatusReady).
+		Order("created_at ASC").
+		First(&registry)
+	return registry, result.Error
+}
+
 // FindWithPagination retrieves registries with pagination, filtering and sorting
 func (r *RegistryRepository) FindWithPagination(
 	page, pageSize int,
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ScheduledDeploymentRepository handles database operations for scheduled deployments
+type ScheduledDeploymentRepository struct{}
+
+// NewScheduledDeploymentRepository creates a new scheduled deployment repository instance
+func NewScheduledDeploymentRepository() *ScheduledDeploymentRepository {
+	return &ScheduledDeploymentRepository{}
+}
+
+// CreateTx inserts a scheduled deployment inside the caller's transaction
+func (r *ScheduledDeploymentRepository) CreateTx(tx *gorm.DB, schedule models.ScheduledDeployment) (models.ScheduledDeployment, error) {
+	result := tx.Omit("Service").Create(&schedule)
+	return schedule, result.Error
+}
+
+// FindByID retrieves a scheduled deployment
+func (r *ScheduledDeploymentRepository) FindByID(id string) (models.ScheduledDeployment, error) {
+	var schedule models.ScheduledDeployment
+	result := database.DB.First(&schedule, "id = ?", id)
+	return schedule, result.Error
+}
+
+// FindByServiceID retrieves a page of a service's scheduled deployments, latest time first
+func (r *ScheduledDeploymentRepository) FindByServiceID(serviceID string, status string, page, pageSize int) ([]models.ScheduledDeployment, int64, error) {
+	var schedules []models.ScheduledDeployment
+	var total int64
+
+	query := database.Reader().Model(&models.ScheduledDeployment{}).Where("service_id = ?", serviceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("scheduled_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&schedules)
+	return schedules, total, result.Error
+}
+
+// Cancel cancels a pending scheduled deployment of a service
+func (r *ScheduledDeploymentRepository) Cancel(id string, serviceID string, userID string) (int64, error) {
+	result := database.DB.Model(&models.ScheduledDeployment{}).
+		Where("id = ? AND service_id = ? AND status = ?", id, serviceID, models.ScheduledDeploymentPending).
+		Updates(map[string]interface{}{
+			"status":       models.ScheduledDeploymentCancelled,
+			"cancelled_by": userID,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Claim moves a pending scheduled deployment to started. Only one caller succeeds, so a
+// redelivered outbox event does not deploy twice.
+func (r *ScheduledDeploymentRepository) Claim(id string) (bool, error) {
+	result := database.DB.Model(&models.ScheduledDeployment{}).
+		Where("id = ? AND status = ?", id, models.ScheduledDeploymentPending).
+		UpdateColumn("status", models.ScheduledDeploymentStarted)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordResult stores the deployment a scheduled deployment created, or why it failed
+func (r *ScheduledDeploymentRepository) RecordResult(id string, deploymentID string, runErr error) error {
+	updates := map[string]interface{}{}
+	if runErr != nil {
+		updates["status"] = models.ScheduledDeploymentFailed
+		updates["error"] = runErr.Error()
+	} else {
+		updates["deployment_id"] = deploymentID
+	}
+	return database.DB.Model(&models.ScheduledDeployment{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DB returns the database handle for transactions
+func (r *ScheduledDeploymentRepository) DB() *gorm.DB {
+	return database.DB
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"strings"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/dto"
+	"gorm.io/gorm"
+)
+
+// SearchRepository runs the global search queries. Every query is scoped to the
+// caller's projects unless the caller is an admin.
+type SearchRepository struct{}
+
+// NewSearchRepository creates a new search repository instance
+func NewSearchRepository() *SearchRepository {
+	return &SearchRepository{}
+}
+
+// escapeLike escapes LIKE wildcards so the search term is matched literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
+
+// scopeToOwner restricts a query joined on projects (alias p) to the user's projects
+func scopeToOwner(query *gorm.DB, userID string, isAdmin bool) *gorm.DB {
+	if isAdmin {
+		return query
+	}
+	return query.Where("p.user_id = ?", userID)
+}
+
+// SearchProjects finds projects by name or description
+func (r *SearchRepository) SearchProjects(term string, userID string, isAdmin bool, limit int) ([]dto.SearchProjectResult, error) {
+	pattern := "%" + escapeLike(term) + "%"
+	results := []dto.SearchProjectResult{}
+
+	query := database.Reader().Table("projects AS p").
+		Select("p.id, p.name, p.description").
+		Where("p.deleted_at IS NULL").
+		Where("(p.name ILIKE ? OR p.description ILIKE ?)", pattern, pattern)
+	query = scopeToOwner(query, userID, isAdmin)
+
+	err := query.Order("p.updated_at DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+// SearchServices finds services by name
+func (r *SearchRepository) SearchServices(term string, userID string, isAdmin bool, limit int) ([]dto.SearchServiceResult, error) {
+	pattern := "%" + escapeLike(term) + "%"
+	results := []dto.SearchServiceResult{}
+
+	query := database.Reader().Table("services AS s").
+		Select("s.id, s.name, s.type, s.status, s.project_id, s.environment_id").
+		Joins("JOIN projects AS p ON p.id = s.project_id AND p.deleted_at IS NULL").
+		Where("s.deleted_at IS NULL").
+		Where("s.name ILIKE ?", pattern)
+	query = scopeToOwner(query, userID, isAdmin)
+
+	err := query.Order("s.updated_at DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+// SearchDeployments finds deployments by commit SHA prefix or commit message
+func (r *SearchRepository) SearchDeployments(term string, userID string, isAdmin bool, limit int) ([]dto.SearchDeploymentResult, error) {
+	escaped := escapeLike(term)
+	results := []dto.SearchDeploymentResult{}
+
+	query := database.Reader().Table("deployments AS d").
+		Select("d.id, d.service_id, s.name AS service_name, s.project_id, d.status, d.commit_sha, d.commit_message, d.created_at").
+		Joins("JOIN services AS s ON s.id = d.service_id AND s.deleted_at IS NULL").
+		Joins("JOIN projects AS p ON p.id = s.project_id AND p.deleted_at IS NULL").
+		Where("(d.commit_sha LIKE ? OR d.commit_message ILIKE ?)", strings.ToLower(escaped)+"%", "%"+escaped+"%")
+	query = scopeToOwner(query, userID, isAdmin)
+
+	err := query.Order("d.created_at DESC").Limit(limit).Scan(&results).Error
+	return results, err
+}
+
+// SearchDomains finds generated and custom service domains
+func (r *SearchRepository) SearchDomains(term string, userID string, isAdmin bool, limit int) ([]dto.SearchDomainResult, error) {
+	pattern := "%" + escapeLike(term) + "%"
+
+	var rows []struct {
+		ServiceID    string
+		ServiceName  string
+		ProjectID    string
+		Domain       string
+		CustomDomain string
+	}
+
+	query := database.Reader().Table("services AS s").
+		Select("s.id AS service_id, s.name AS service_name, s.project_id, COALESCE(s.domain, '') AS domain, COALESCE(s.custom_domain, '') AS custom_domain").
+		Joins("JOIN projects AS p ON p.id = s.project_id AND p.deleted_at IS NULL").
+		Where("s.deleted_at IS NULL").
+		Where("(s.domain ILIKE ? OR s.custom_domain ILIKE ?)", pattern, pattern)
+	query = scopeToOwner(query, userID, isAdmin)
+
+	if err := query.Order("s.updated_at DESC").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	lowerTerm := strings.ToLower(term)
+	results := []dto.SearchDomainResult{}
+	for _, row := range rows {
+		if row.Domain != "" && strings.Contains(strings.ToLower(row.Domain), lowerTerm) {
+			results = append(results, dto.SearchDomainResult{Domain: row.Domain, ServiceID: row.ServiceID, ServiceName: row.ServiceName, ProjectID: row.ProjectID})
+		}
+		if row.CustomDomain != "" && strings.Contains(strings.ToLower(row.CustomDomain), lowerTerm) {
+			results = append(results, dto.SearchDomainResult{Domain: row.CustomDomain, IsCustom: true, ServiceID: row.ServiceID, ServiceName: row.ServiceName, ProjectID: row.ProjectID})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// SecretStoreRepository handles database operations for external secret stores
+type SecretStoreRepository struct{}
+
+// NewSecretStoreRepository creates a new secret store repository instance
+func NewSecretStoreRepository() *SecretStoreRepository {
+	return &SecretStoreRepository{}
+}
+
+// FindByID retrieves a secret store by ID
+func (r *SecretStoreRepository) FindByID(id string) (models.SecretStore, error) {
+	var store models.SecretStore
+	result := database.Reader().First(&store, "id = ?", id)
+	return store, result.Error
+}
+
+// FindByProjectID retrieves the secret stores of a project, ordered by name
+func (r *SecretStoreRepository) FindByProjectID(projectID string) ([]models.SecretStore, error) {
+	var stores []models.SecretStore
+	result := database.Reader().Where("project_id = ?", projectID).Order("name ASC").Find(&stores)
+	return stores, result.Error
+}
+
+// FindRefreshing retrieves the stores refreshed on a schedule
+func (r *SecretStoreRepository) FindRefreshing() ([]models.SecretStore, error) {
+	var stores []models.SecretStore
+	result := database.Reader().Where("refresh_minutes > 0").Find(&stores)
+	return stores, result.Error
+}
+
+// Create inserts a new secret store
+func (r *SecretStoreRepository) Create(store models.SecretStore) (models.SecretStore, error) {
+	result := database.DB.Create(&store)
+	return store, result.Error
+}
+
+// Update saves changes to a secret store
+func (r *SecretStoreRepository) Update(store models.SecretStore) (models.SecretStore, error) {
+	result := database.DB.Save(&store)
+	return store, result.Error
+}
+
+// RecordRefresh stores the outcome of a scheduled refresh; an empty message clears the error
+func (r *SecretStoreRepository) RecordRefresh(id string, message string, at time.Time) error {
+	return database.DB.Model(&models.SecretStore{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_refresh_at":    at,
+		"last_refresh_error": message,
+	}).Error
+}
+
+// Delete removes a secret store
+func (r *SecretStoreRepository) Delete(id string) error {
+	return database.DB.Where("id = ?", id).Delete(&models.SecretStore{}).Error
+}
+
+// DB returns the database instance
+func (r *SecretStoreRepository) DB() *gorm.DB {
+	return database.DB
+}
@@ -1,6 +1,10 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/pendeploy-simple/database"
 	"github.com/pendeploy-simple/models"
 	"gorm.io/gorm"
@@ -21,6 +25,78 @@ func (r *ServiceRepository) FindAll() ([]models.Service, error) {
 	return services, result.Error
 }
 
+// FindWithPagination retrieves services with pagination, filtering and sorting
+func (r *ServiceRepository) FindWithPagination(
+	page, pageSize int,
+	sortBy, sortOrder string,
+	search, status, serviceType, environmentID, projectID string,
+	createdFrom, createdTo *time.Time) ([]models.Service, int64, error) {
+
+	// Calculate offset
+	offset := (page - 1) * pageSize
+
+	// Valid sort columns (whitelist approach for security)
+	validSortColumns := map[string]bool{
+		"created_at": true,
+		"updated_at": true,
+		"name":       true,
+		"status":     true,
+		"type":       true,
+	}
+
+	if !validSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+
+	sortOrder = strings.ToLower(sortOrder)
+	if sortOrder != "asc" && sortOrder != "desc" {
+		sortOrder = "desc"
+	}
+
+	// Build query
+	query := database.Reader().Model(&models.Service{})
+
+	if search != "" {
+		searchTerm := fmt.Sprintf("%%%s%%", search)
+		query = query.Where("name ILIKE ? OR domain ILIKE ?", searchTerm, searchTerm)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if serviceType != "" {
+		query = query.Where("type = ?", serviceType)
+	}
+	if environmentID != "" {
+		query = query.Where("environment_id = ?", environmentID)
+	}
+	if projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
+	if createdFrom != nil {
+		query = query.Where("created_at >= ?", *createdFrom)
+	}
+	if createdTo != nil {
+		query = query.Where("created_at <= ?", *createdTo)
+	}
+
+	// Get total count
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply sorting and, unless pageSize is 0, pagination
+	query = query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder))
+	if pageSize > 0 {
+		query = query.Limit(pageSize).Offset(offset)
+	}
+
+	var services []models.Service
+	result := query.Find(&services)
+
+	return services, total, result.Error
+}
+
 // FindByID retrieves a service by its ID
 func (r *ServiceRepository) FindByID(id string) (models.Service, error) {
 	var service models.Service
@@ -28,6 +104,13 @@ func (r *ServiceRepository) FindByID(id string) (models.Service, error) {
 	return service, result.Error
 }
 
+// FindByIDs retrieves the services with the given IDs
+func (r *ServiceRepository) FindByIDs(ids []string) ([]models.Service, error) {
+	var services []models.Service
+	result := database.DB.Where("id IN ?", ids).Find(&services)
+	return services, result.Error
+}
+
 // FindByProjectID retrieves all services belonging to a project
 func (r *ServiceRepository) FindByProjectID(projectID string) ([]models.Service, error) {
 	var services []models.Service
@@ -35,6 +118,82 @@ func (r *ServiceRepository) FindByProjectID(projectID string) ([]models.Service,
 	return services, result.Error
 }
 
+// FindByEnvironmentID retrieves all services in an environment
+func (r *ServiceRepository) FindByEnvironmentID(environmentID string) ([]models.Service, error) {
+	var services []models.Service
+	result := database.DB.Where("environment_id = ?", environmentID).Find(&services)
+	return services, result.Error
+}
+
+// FindByNameInEnvironment retrieves a service of the environment by name, case-insensitively
+// like ExistsByNameInEnvironment
+func (r *ServiceRepository) FindByNameInEnvironment(name string, environmentID string) (models.Service, error) {
+	var service models.Service
+	result := database.DB.Where("LOWER(name) = LOWER(?) AND environment_id = ?", name, environmentID).First(&service)
+	return service, result.Error
+}
+
+// ExistsByNameInEnvironment checks case-insensitively whether another service in the
+// environment already uses the name. excludeID skips the service being renamed.
+func (r *ServiceRepository) ExistsByNameInEnvironment(name string, environmentID string, excludeID string) (bool, error) {
+	var count int64
+	query := database.DB.Model(&models.Service{}).Where("LOWER(name) = LOWER(?) AND environment_id = ?", name, environmentID)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	result := query.Count(&count)
+	return count > 0, result.Error
+}
+
+// ExistsByInternalAlias checks whether another service in the environment already uses the
+// internal alias. excludeID skips the service being changed.
+func (r *ServiceRepository) ExistsByInternalAlias(alias string, environmentID string, excludeID string) (bool, error) {
+	var count int64
+	query := database.DB.Model(&models.Service{}).Where("internal_alias = ? AND environment_id = ?", alias, environmentID)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	result := query.Count(&count)
+	return count > 0, result.Error
+}
+
+// UpdateInternalAlias sets the internal alias of a service; empty removes it
+func (r *ServiceRepository) UpdateInternalAlias(id string, alias string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("internal_alias", gorm.Expr("NULLIF(?, '')", alias)).Error
+}
+
+// ExistsByHostname checks whether another service already serves the hostname,
+// either as its generated domain or as its custom domain
+func (r *ServiceRepository) ExistsByHostname(hostname string, excludeID string) (bool, error) {
+	var count int64
+	query := database.DB.Model(&models.Service{}).Where("LOWER(domain) = LOWER(?) OR LOWER(custom_domain) = LOWER(?)", hostname, hostname)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	result := query.Count(&count)
+	return count > 0, result.Error
+}
+
+// ExistsByHostnameOutsideEnvironment checks whether a service of another environment
+// already serves the hostname
+func (r *ServiceRepository) ExistsByHostnameOutsideEnvironment(hostname string, environmentID string) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.Service{}).
+		Where("LOWER(domain) = LOWER(?) OR LOWER(custom_domain) = LOWER(?)", hostname, hostname).
+		Where("environment_id <> ?", environmentID).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// UpdateDomain changes the generated hostname of a service
+func (r *ServiceRepository) UpdateDomain(id string, domain string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("domain", domain).Error
+}
+
 // Create inserts a new service into the database
 func (r *ServiceRepository) Create(service models.Service) (models.Service, error) {
 	result := database.DB.Create(&service)
@@ -43,7 +202,12 @@ func (r *ServiceRepository) Create(service models.Service) (models.Service, erro
 
 // Update modifies an existing service
 func (r *ServiceRepository) Update(service models.Service) error {
-	result := database.DB.Save(&service)
+	return r.UpdateTx(database.DB, service)
+}
+
+// UpdateTx saves a service inside the caller's transaction
+func (r *ServiceRepository) UpdateTx(tx *gorm.DB, service models.Service) error {
+	result := tx.Save(&service)
 	return result.Error
 }
 
@@ -63,7 +227,7 @@ func (r *ServiceRepository) WithDeployments(id string) (models.Service, error) {
 // CountByProjectID counts services belonging to a project
 func (r *ServiceRepository) CountByProjectID(projectID string) (int64, error) {
 	var count int64
-	result := database.DB.Model(&models.Service{}).Where("project_id = ?", projectID).Count(&count)
+	result := database.Reader().Model(&models.Service{}).Where("project_id = ?", projectID).Count(&count)
 	return count, result.Error
 }
 
@@ -79,6 +243,109 @@ func (r *ServiceRepository) UpdateScalingConfig(id string, isStatic bool, replic
 		}).Error
 }
 
+// FindByStatusUpdatedBefore returns the services in a status that last changed before a time
+func (r *ServiceRepository) FindByStatusUpdatedBefore(status string, before time.Time) ([]models.Service, error) {
+	var services []models.Service
+	result := database.DB.Where("status = ? AND updated_at < ?", status, before).Find(&services)
+	return services, result.Error
+}
+
+// UpdateStatusIf changes the status of a service that is still in the expected status, and
+// reports whether it did
+func (r *ServiceRepository) UpdateStatusIf(id string, expected string, status string) (bool, error) {
+	result := database.DB.Model(&models.Service{}).
+		Where("id = ? AND status = ?", id, expected).
+		Update("status", status)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateColumns saves the named fields of a service, e.g. "EnvironmentID", leaving the rest
+// of the row to concurrent edits
+func (r *ServiceRepository) UpdateColumns(service models.Service, columns ...string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", service.ID).
+		Select(columns).
+		Updates(&service).Error
+}
+
+// UpdateStatus sets the status of a service, leaving the rest of the row to concurrent edits
+func (r *ServiceRepository) UpdateStatus(id string, status string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// UpdateDeletionProtection sets the deletion protection flag of a service
+func (r *ServiceRepository) UpdateDeletionProtection(id string, protected bool) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("deletion_protected", protected).Error
+}
+
+// UpdatePort changes the port a service's traffic is routed to
+func (r *ServiceRepository) UpdatePort(id string, port int) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("port", port).Error
+}
+
+// UpdateStorageSize records the size the data volumes of a service were expanded to
+func (r *ServiceRepository) UpdateStorageSize(id string, size string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("storage_size", size).Error
+}
+
+// UpdateCacheRules replaces the HTTP cache policy of a service
+func (r *ServiceRepository) UpdateCacheRules(id string, rules models.CacheRules) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Update("cache_rules", rules).Error
+}
+
+// UpdateContainers replaces the companion containers and shared volumes of a service
+func (r *ServiceRepository) UpdateContainers(id string, containers models.ServiceContainers, volumes models.SharedVolumes) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"containers":     containers,
+			"shared_volumes": volumes,
+		}).Error
+}
+
+// UpdateLastBuild records the commit, image and build config digest of a successful build
+func (r *ServiceRepository) UpdateLastBuild(id string, commitSHA string, image string, inputsDigest string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_built_commit_sha":    commitSHA,
+			"last_built_image":         image,
+			"last_build_inputs_digest": inputsDigest,
+		}).Error
+}
+
+// UpdatePin pins a service to a deployment, or unpins it when deploymentID is nil
+func (r *ServiceRepository) UpdatePin(id string, deploymentID *string, pinnedAt *time.Time, pinnedBy string, reason string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"pinned_deployment_id": deploymentID,
+			"pinned_at":            pinnedAt,
+			"pinned_by":            pinnedBy,
+			"pin_reason":           reason,
+		}).Error
+}
+
+// UpdateAttention flags a service as needing attention, or clears the flag
+func (r *ServiceRepository) UpdateAttention(id string, needed bool, reason string) error {
+	return database.DB.Model(&models.Service{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attention_needed": needed,
+			"attention_reason": reason,
+		}).Error
+}
+
 // DB returns the database instance
 func (r *ServiceRepository) DB() *gorm.DB {
 	return database.DB
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ServiceIncidentRepository handles database operations for detected service incidents
+type ServiceIncidentRepository struct{}
+
+// NewServiceIncidentRepository creates a new service incident repository instance
+func NewServiceIncidentRepository() *ServiceIncidentRepository {
+	return &ServiceIncidentRepository{}
+}
+
+// FindByServiceID retrieves the incidents of a service with their timelines, newest first
+func (r *ServiceIncidentRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.ServiceIncident, int64, error) {
+	var incidents []models.ServiceIncident
+	var total int64
+
+	query := database.Reader().Model(&models.ServiceIncident{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("started_at ASC")
+	}).Order("started_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&incidents)
+	return incidents, total, result.Error
+}
+
+// FindOpen retrieves the unresolved incident of a service with its timeline
+func (r *ServiceIncidentRepository) FindOpen(serviceID string) (models.ServiceIncident, error) {
+	var incident models.ServiceIncident
+	result := database.DB.Preload("Events").
+		Where("service_id = ? AND resolved_at IS NULL", serviceID).
+		Order("started_at DESC").First(&incident)
+	return incident, result.Error
+}
+
+// EventKeyExists reports whether a signal was already recorded on any incident of a service
+func (r *ServiceIncidentRepository) EventKeyExists(serviceID, key string) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.ServiceIncidentEvent{}).
+		Joins("JOIN service_incidents ON service_incidents.id = service_incident_events.incident_id").
+		Where("service_incidents.service_id = ? AND service_incident_events.key = ?", serviceID, key).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// Create saves a new incident together with its initial events
+func (r *ServiceIncidentRepository) Create(incident models.ServiceIncident) (models.ServiceIncident, error) {
+	result := database.DB.Omit("Service").Create(&incident)
+	return incident, result.Error
+}
+
+// Save updates an incident and upserts its events
+func (r *ServiceIncidentRepository) Save(incident models.ServiceIncident) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range incident.Events {
+			incident.Events[i].IncidentID = incident.ID
+			if err := tx.Save(&incident.Events[i]).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Service", "Events").Save(&incident).Error
+	})
+}
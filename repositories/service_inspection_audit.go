@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ServiceInspectionAuditRepository handles database operations for the audit trail of admin
+// service inspections
+type ServiceInspectionAuditRepository struct{}
+
+// NewServiceInspectionAuditRepository creates a new service inspection audit repository instance
+func NewServiceInspectionAuditRepository() *ServiceInspectionAuditRepository {
+	return &ServiceInspectionAuditRepository{}
+}
+
+// Create stores an audit entry
+func (r *ServiceInspectionAuditRepository) Create(entry models.ServiceInspectionAuditLog) error {
+	return database.DB.Create(&entry).Error
+}
+
+// Find retrieves audit entries, newest first, optionally for one admin or one service
+func (r *ServiceInspectionAuditRepository) Find(adminID string, serviceID string, page, pageSize int) ([]models.ServiceInspectionAuditLog, int64, error) {
+	query := database.Reader().Model(&models.ServiceInspectionAuditLog{})
+	if adminID != "" {
+		query = query.Where("admin_id = ?", adminID)
+	}
+	if serviceID != "" {
+		query = query.Where("service_id = ?", serviceID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.ServiceInspectionAuditLog
+	result := query.Order("created_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&entries)
+	return entries, total, result.Error
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ServiceMoveRepository handles database operations for moves of services between environments
+type ServiceMoveRepository struct{}
+
+// NewServiceMoveRepository creates a new service move repository instance
+func NewServiceMoveRepository() *ServiceMoveRepository {
+	return &ServiceMoveRepository{}
+}
+
+// FindByID retrieves a service move by ID
+func (r *ServiceMoveRepository) FindByID(id string) (models.ServiceMove, error) {
+	var move models.ServiceMove
+	result := database.Reader().First(&move, "id = ?", id)
+	return move, result.Error
+}
+
+// FindByServiceID retrieves a page of a service's moves, newest first
+func (r *ServiceMoveRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.ServiceMove, int64, error) {
+	var moves []models.ServiceMove
+	var total int64
+
+	query := database.Reader().Model(&models.ServiceMove{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("started_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&moves)
+	return moves, total, result.Error
+}
+
+// ExistsInProgress reports whether a move of the service started after the given time is
+// still in progress
+func (r *ServiceMoveRepository) ExistsInProgress(serviceID string, since time.Time) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.ServiceMove{}).
+		Where("service_id = ? AND status NOT IN ? AND started_at > ?", serviceID,
+			[]string{models.ServiceMoveCompleted, models.ServiceMoveRolledBack, models.ServiceMoveFailed}, since).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// Create inserts a new service move
+func (r *ServiceMoveRepository) Create(move models.ServiceMove) (models.ServiceMove, error) {
+	result := database.DB.Omit("Service").Create(&move)
+	return move, result.Error
+}
+
+// Update saves the progress of a service move
+func (r *ServiceMoveRepository) Update(move models.ServiceMove) error {
+	return database.DB.Omit("Service").Save(&move).Error
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ServiceRevisionRepository handles database operations for service config revisions
+type ServiceRevisionRepository struct{}
+
+// NewServiceRevisionRepository creates a new service revision repository instance
+func NewServiceRevisionRepository() *ServiceRevisionRepository {
+	return &ServiceRevisionRepository{}
+}
+
+// FindByServiceID retrieves a page of a service's revisions, newest first
+func (r *ServiceRevisionRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.ServiceRevision, int64, error) {
+	var revisions []models.ServiceRevision
+	var total int64
+
+	query := database.Reader().Model(&models.ServiceRevision{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("revision DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&revisions)
+	return revisions, total, result.Error
+}
+
+// FindByRevision retrieves one revision of a service
+func (r *ServiceRevisionRepository) FindByRevision(serviceID string, revision int) (models.ServiceRevision, error) {
+	var found models.ServiceRevision
+	result := database.Reader().First(&found, "service_id = ? AND revision = ?", serviceID, revision)
+	return found, result.Error
+}
+
+// CreateIfChanged stores the snapshot as the service's next revision unless its config
+// equals the latest one. It reports whether a revision was created.
+func (r *ServiceRevisionRepository) CreateIfChanged(revision *models.ServiceRevision) (bool, error) {
+	created := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var latest models.ServiceRevision
+		result := tx.Where("service_id = ?", revision.ServiceID).Order("revision DESC").Limit(1).Find(&latest)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && latest.SameConfig(*revision) {
+			return nil
+		}
+
+		revision.Revision = latest.Revision + 1
+		created = true
+		return tx.Create(revision).Error
+	})
+	return created, err
+}
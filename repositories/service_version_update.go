@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ServiceVersionUpdateRepository handles database operations for automatic version updates
+type ServiceVersionUpdateRepository struct{}
+
+// NewServiceVersionUpdateRepository creates a new version update repository instance
+func NewServiceVersionUpdateRepository() *ServiceVersionUpdateRepository {
+	return &ServiceVersionUpdateRepository{}
+}
+
+// FindByServiceID retrieves a page of a service's version updates, newest first
+func (r *ServiceVersionUpdateRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.ServiceVersionUpdate, int64, error) {
+	var updates []models.ServiceVersionUpdate
+	var total int64
+
+	query := database.Reader().Model(&models.ServiceVersionUpdate{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("started_at DESC").Limit(pageSize).Offset((page - 1) * pageSize).Find(&updates)
+	return updates, total, result.Error
+}
+
+// StartedSince reports whether an update of the service was attempted since the given time
+func (r *ServiceVersionUpdateRepository) StartedSince(serviceID string, since time.Time) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.ServiceVersionUpdate{}).
+		Where("service_id = ? AND started_at >= ?", serviceID, since).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// Create saves a new version update
+func (r *ServiceVersionUpdateRepository) Create(update models.ServiceVersionUpdate) (models.ServiceVersionUpdate, error) {
+	result := database.DB.Omit("Service").Create(&update)
+	return update, result.Error
+}
+
+// Save updates a version update
+func (r *ServiceVersionUpdateRepository) Save(update models.ServiceVersionUpdate) error {
+	return database.DB.Omit("Service").Save(&update).Error
+}
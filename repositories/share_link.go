@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// ShareLinkRepository handles database operations for shareable preview links
+type ShareLinkRepository struct{}
+
+// NewShareLinkRepository creates a new share link repository instance
+func NewShareLinkRepository() *ShareLinkRepository {
+	return &ShareLinkRepository{}
+}
+
+// Create inserts a share link
+func (r *ShareLinkRepository) Create(link models.ShareLink) (models.ShareLink, error) {
+	result := database.DB.Omit("Environment").Create(&link)
+	return link, result.Error
+}
+
+// FindByID retrieves a share link
+func (r *ShareLinkRepository) FindByID(id string) (models.ShareLink, error) {
+	var link models.ShareLink
+	result := database.DB.First(&link, "id = ?", id)
+	return link, result.Error
+}
+
+// FindByEnvironmentID retrieves the unrevoked, unexpired links of an environment, newest first
+func (r *ShareLinkRepository) FindByEnvironmentID(environmentID string) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	result := database.Reader().
+		Where("environment_id = ? AND revoked_at IS NULL AND expires_at > ?", environmentID, time.Now()).
+		Order("created_at DESC").
+		Find(&links)
+	return links, result.Error
+}
+
+// Revoke revokes an active link of an environment
+func (r *ShareLinkRepository) Revoke(id string, environmentID string) (int64, error) {
+	result := database.DB.Model(&models.ShareLink{}).
+		Where("id = ? AND environment_id = ? AND revoked_at IS NULL", id, environmentID).
+		UpdateColumn("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// TouchLastUsed records when a link was last opened
+func (r *ShareLinkRepository) TouchLastUsed(id string, at time.Time) error {
+	return database.DB.Model(&models.ShareLink{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// DeleteExpiredBefore removes links that expired before the cutoff
+func (r *ShareLinkRepository) DeleteExpiredBefore(cutoff time.Time) (int64, error) {
+	result := database.DB.Where("expires_at < ?", cutoff).Delete(&models.ShareLink{})
+	return result.RowsAffected, result.Error
+}
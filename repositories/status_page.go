@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// StatusPageRepository handles database operations for public status pages and incidents
+type StatusPageRepository struct{}
+
+// NewStatusPageRepository creates a new status page repository instance
+func NewStatusPageRepository() *StatusPageRepository {
+	return &StatusPageRepository{}
+}
+
+// FindByProjectID retrieves the status page of a project
+func (r *StatusPageRepository) FindByProjectID(projectID string) (models.StatusPage, error) {
+	var page models.StatusPage
+	result := database.Reader().First(&page, "project_id = ?", projectID)
+	return page, result.Error
+}
+
+// FindBySlug retrieves a status page by its public slug
+func (r *StatusPageRepository) FindBySlug(slug string) (models.StatusPage, error) {
+	var page models.StatusPage
+	result := database.Reader().First(&page, "slug = ?", slug)
+	return page, result.Error
+}
+
+// Save creates or updates a status page
+func (r *StatusPageRepository) Save(page models.StatusPage) (models.StatusPage, error) {
+	result := database.DB.Save(&page)
+	return page, result.Error
+}
+
+// DeleteByProjectIDTx removes the status page and incidents of a project within a transaction
+func (r *StatusPageRepository) DeleteByProjectIDTx(tx *gorm.DB, projectID string) error {
+	if err := tx.Delete(&models.Incident{}, "project_id = ?", projectID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&models.StatusPage{}, "project_id = ?", projectID).Error
+}
+
+// FindIncidentByID retrieves an incident by ID
+func (r *StatusPageRepository) FindIncidentByID(id string) (models.Incident, error) {
+	var incident models.Incident
+	result := database.Reader().First(&incident, "id = ?", id)
+	return incident, result.Error
+}
+
+// FindIncidents retrieves the incidents of a project that are unresolved or were resolved
+// after the given time, newest first
+func (r *StatusPageRepository) FindIncidents(projectID string, resolvedAfter time.Time) ([]models.Incident, error) {
+	var incidents []models.Incident
+	result := database.Reader().
+		Where("project_id = ? AND (resolved_at IS NULL OR resolved_at >= ?)", projectID, resolvedAfter).
+		Order("started_at DESC").
+		Find(&incidents)
+	return incidents, result.Error
+}
+
+// CreateIncident stores a new incident
+func (r *StatusPageRepository) CreateIncident(incident models.Incident) (models.Incident, error) {
+	result := database.DB.Create(&incident)
+	return incident, result.Error
+}
+
+// UpdateIncident saves an incident
+func (r *StatusPageRepository) UpdateIncident(incident models.Incident) (models.Incident, error) {
+	result := database.DB.Save(&incident)
+	return incident, result.Error
+}
+
+// DeleteIncident removes an incident
+func (r *StatusPageRepository) DeleteIncident(id string) error {
+	return database.DB.Delete(&models.Incident{}, "id = ?", id).Error
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// StorageExpansionRepository handles database operations for storage expansions
+type StorageExpansionRepository struct{}
+
+// NewStorageExpansionRepository creates a new storage expansion repository instance
+func NewStorageExpansionRepository() *StorageExpansionRepository {
+	return &StorageExpansionRepository{}
+}
+
+// FindByID retrieves a storage expansion by ID
+func (r *StorageExpansionRepository) FindByID(id string) (models.StorageExpansion, error) {
+	var expansion models.StorageExpansion
+	result := database.Reader().First(&expansion, "id = ?", id)
+	return expansion, result.Error
+}
+
+// FindByServiceID retrieves a page of a service's expansions, newest first
+func (r *StorageExpansionRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.StorageExpansion, int64, error) {
+	var expansions []models.StorageExpansion
+	var total int64
+
+	query := database.Reader().Model(&models.StorageExpansion{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	result := query.Order("started_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&expansions)
+	return expansions, total, result.Error
+}
+
+// ExistsInProgress reports whether an expansion of the service started after the given
+// time is still in progress
+func (r *StorageExpansionRepository) ExistsInProgress(serviceID string, since time.Time) (bool, error) {
+	var count int64
+	result := database.DB.Model(&models.StorageExpansion{}).
+		Where("service_id = ? AND status NOT IN ? AND started_at > ?", serviceID,
+			[]string{models.StorageExpansionCompleted, models.StorageExpansionFailed}, since).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// Create inserts a new storage expansion
+func (r *StorageExpansionRepository) Create(expansion models.StorageExpansion) (models.StorageExpansion, error) {
+	result := database.DB.Omit("Service").Create(&expansion)
+	return expansion, result.Error
+}
+
+// Update saves the progress of a storage expansion
+func (r *StorageExpansionRepository) Update(expansion models.StorageExpansion) error {
+	return database.DB.Omit("Service").Save(&expansion).Error
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// TenantIsolationRepository handles database operations for the tenant isolation policy
+type TenantIsolationRepository struct{}
+
+// NewTenantIsolationRepository creates a new tenant isolation repository instance
+func NewTenantIsolationRepository() *TenantIsolationRepository {
+	return &TenantIsolationRepository{}
+}
+
+// FindPolicy retrieves the tenant isolation policy, or the defaults when none was saved
+func (r *TenantIsolationRepository) FindPolicy() (models.TenantIsolationPolicy, error) {
+	var policy models.TenantIsolationPolicy
+	result := database.Reader().First(&policy, models.TenantIsolationPolicyID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.DefaultTenantIsolationPolicy(), nil
+	}
+	return policy, result.Error
+}
+
+// SavePolicy creates or updates the tenant isolation policy
+func (r *TenantIsolationRepository) SavePolicy(policy models.TenantIsolationPolicy) (models.TenantIsolationPolicy, error) {
+	policy.ID = models.TenantIsolationPolicyID
+	result := database.DB.Save(&policy)
+	return policy, result.Error
+}
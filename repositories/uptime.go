@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// UptimeRepository handles database operations for uptime monitors and their checks
+type UptimeRepository struct{}
+
+// NewUptimeRepository creates a new uptime repository instance
+func NewUptimeRepository() *UptimeRepository {
+	return &UptimeRepository{}
+}
+
+// UptimeTotals counts the checks of a service in a time window
+type UptimeTotals struct {
+	Total int64
+	Up    int64
+}
+
+// FindMonitor retrieves the monitor of a service
+func (r *UptimeRepository) FindMonitor(serviceID string) (models.UptimeMonitor, error) {
+	var monitor models.UptimeMonitor
+	result := database.Reader().First(&monitor, "service_id = ?", serviceID)
+	return monitor, result.Error
+}
+
+// FindMonitorsByServiceIDs retrieves the monitors of the given services
+func (r *UptimeRepository) FindMonitorsByServiceIDs(serviceIDs []string) ([]models.UptimeMonitor, error) {
+	var monitors []models.UptimeMonitor
+	if len(serviceIDs) == 0 {
+		return monitors, nil
+	}
+	result := database.Reader().Where("service_id IN ?", serviceIDs).Find(&monitors)
+	return monitors, result.Error
+}
+
+// FindEnabledMonitors retrieves every enabled monitor with its service
+func (r *UptimeRepository) FindEnabledMonitors() ([]models.UptimeMonitor, error) {
+	var monitors []models.UptimeMonitor
+	result := database.Reader().Preload("Service").Where("enabled = ?", true).Find(&monitors)
+	return monitors, result.Error
+}
+
+// SaveMonitor creates or updates a monitor
+func (r *UptimeRepository) SaveMonitor(monitor models.UptimeMonitor) (models.UptimeMonitor, error) {
+	result := database.DB.Omit("Service").Save(&monitor)
+	return monitor, result.Error
+}
+
+// RecordCheck stores a check result and marks the monitor as checked
+func (r *UptimeRepository) RecordCheck(check models.UptimeCheck) error {
+	if err := database.DB.Create(&check).Error; err != nil {
+		return err
+	}
+	return database.DB.Model(&models.UptimeMonitor{}).Where("service_id = ?", check.ServiceID).
+		Update("last_checked_at", check.CheckedAt).Error
+}
+
+// FindRecentChecks retrieves the latest checks of a service, newest first
+func (r *UptimeRepository) FindRecentChecks(serviceID string, limit int) ([]models.UptimeCheck, error) {
+	var checks []models.UptimeCheck
+	result := database.Reader().Where("service_id = ?", serviceID).Order("checked_at DESC").Limit(limit).Find(&checks)
+	return checks, result.Error
+}
+
+// CountChecksSince counts all and successful checks of a service since the given time
+func (r *UptimeRepository) CountChecksSince(serviceID string, since time.Time) (UptimeTotals, error) {
+	var totals UptimeTotals
+	result := database.Reader().Model(&models.UptimeCheck{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE up) AS up").
+		Where("service_id = ? AND checked_at >= ?", serviceID, since).
+		Scan(&totals)
+	return totals, result.Error
+}
+
+// DeleteChecksBefore prunes check history older than the given time
+func (r *UptimeRepository) DeleteChecksBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("checked_at < ?", before).Delete(&models.UptimeCheck{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// UsageAnomalyRepository handles database operations for detected usage anomalies
+type UsageAnomalyRepository struct{}
+
+// NewUsageAnomalyRepository creates a new usage anomaly repository instance
+func NewUsageAnomalyRepository() *UsageAnomalyRepository {
+	return &UsageAnomalyRepository{}
+}
+
+// FindByServiceID retrieves the anomalies of a service, newest first
+func (r *UsageAnomalyRepository) FindByServiceID(serviceID string, page, pageSize int) ([]models.UsageAnomaly, int64, error) {
+	var anomalies []models.UsageAnomaly
+	var total int64
+
+	query := database.Reader().Model(&models.UsageAnomaly{}).Where("service_id = ?", serviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.Order("detected_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&anomalies)
+	return anomalies, total, result.Error
+}
+
+// FindOpen retrieves the unresolved anomalies of a service
+func (r *UsageAnomalyRepository) FindOpen(serviceID string) ([]models.UsageAnomaly, error) {
+	var anomalies []models.UsageAnomaly
+	result := database.DB.
+		Where("service_id = ? AND resolved_at IS NULL", serviceID).
+		Order("detected_at ASC").
+		Find(&anomalies)
+	return anomalies, result.Error
+}
+
+// CreateTx saves a new anomaly inside the caller's transaction
+func (r *UsageAnomalyRepository) CreateTx(tx *gorm.DB, anomaly models.UsageAnomaly) (models.UsageAnomaly, error) {
+	result := tx.Omit("Service").Create(&anomaly)
+	return anomaly, result.Error
+}
+
+// Save updates an anomaly
+func (r *UsageAnomalyRepository) Save(anomaly models.UsageAnomaly) error {
+	return database.DB.Omit("Service").Save(&anomaly).Error
+}
+
+// DB returns the database instance
+func (r *UsageAnomalyRepository) DB() *gorm.DB {
+	return database.DB
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// UsageSampleRepository handles database operations for cluster and service usage history
+type UsageSampleRepository struct{}
+
+// NewUsageSampleRepository creates a new usage sample repository instance
+func NewUsageSampleRepository() *UsageSampleRepository {
+	return &UsageSampleRepository{}
+}
+
+// ServiceUsagePercentiles summarizes the per-pod usage of a service over a window
+type ServiceUsagePercentiles struct {
+	ServiceID      string    `gorm:"column:service_id"`
+	Samples        int64     `gorm:"column:samples"`
+	CPUP95         float64   `gorm:"column:cpu_p95"`    // millicores
+	MemoryP95      float64   `gorm:"column:memory_p95"` // bytes
+	CPUMax         int64     `gorm:"column:cpu_max"`
+	MemoryMax      int64     `gorm:"column:memory_max"`
+	FirstSampledAt time.Time `gorm:"column:first_sampled_at"`
+}
+
+// RecordClusterSample stores a cluster usage snapshot
+func (r *UsageSampleRepository) RecordClusterSample(sample models.ClusterUsageSample) error {
+	return database.DB.Create(&sample).Error
+}
+
+// RecordServiceSamples stores the usage snapshots of several services
+func (r *UsageSampleRepository) RecordServiceSamples(samples []models.ServiceUsageSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return database.DB.CreateInBatches(&samples, 200).Error
+}
+
+// FindClusterSamplesSince retrieves cluster snapshots since the given time, oldest first
+func (r *UsageSampleRepository) FindClusterSamplesSince(since time.Time) ([]models.ClusterUsageSample, error) {
+	var samples []models.ClusterUsageSample
+	result := database.Reader().Where("sampled_at >= ?", since).Order("sampled_at ASC").Find(&samples)
+	return samples, result.Error
+}
+
+// FindServiceSamplesSince retrieves the snapshots of a service since the given time, oldest first
+func (r *UsageSampleRepository) FindServiceSamplesSince(serviceID string, since time.Time) ([]models.ServiceUsageSample, error) {
+	var samples []models.ServiceUsageSample
+	result := database.Reader().
+		Where("service_id = ? AND sampled_at >= ?", serviceID, since).
+		Order("sampled_at ASC").
+		Find(&samples)
+	return samples, result.Error
+}
+
+// FindServicePercentilesSince computes the 95th percentile and peak per-pod usage of every
+// service sampled since the given time
+func (r *UsageSampleRepository) FindServicePercentilesSince(since time.Time) ([]ServiceUsagePercentiles, error) {
+	var percentiles []ServiceUsagePercentiles
+	result := servicePercentilesQuery(since).Scan(&percentiles)
+	return percentiles, result.Error
+}
+
+// FindServicePercentiles computes the 95th percentile and peak per-pod usage of one
+// service since the given time. Samples is 0 when the service has no history.
+func (r *UsageSampleRepository) FindServicePercentiles(serviceID string, since time.Time) (ServiceUsagePercentiles, error) {
+	var percentiles []ServiceUsagePercentiles
+	result := servicePercentilesQuery(since).Where("service_id = ?", serviceID).Scan(&percentiles)
+	if result.Error != nil || len(percentiles) == 0 {
+		return ServiceUsagePercentiles{ServiceID: serviceID}, result.Error
+	}
+	return percentiles[0], nil
+}
+
+// servicePercentilesQuery aggregates the samples of running services by service
+func servicePercentilesQuery(since time.Time) *gorm.DB {
+	return database.Reader().Model(&models.ServiceUsageSample{}).
+		Select(`service_id,
+			COUNT(*) AS samples,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY max_pod_cpu_used) AS cpu_p95,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY max_pod_memory_used) AS memory_p95,
+			MAX(max_pod_cpu_used) AS cpu_max,
+			MAX(max_pod_memory_used) AS memory_max,
+			MIN(sampled_at) AS first_sampled_at`).
+		Where("sampled_at >= ? AND pods > 0", since).
+		Group("service_id")
+}
+
+// DeleteSamplesBefore prunes cluster and service usage history older than the given time
+func (r *UsageSampleRepository) DeleteSamplesBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("sampled_at < ?", before).Delete(&models.ClusterUsageSample{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	deleted := result.RowsAffected
+	result = database.DB.Where("sampled_at < ?", before).Delete(&models.ServiceUsageSample{})
+	return deleted + result.RowsAffected, result.Error
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/models"
+)
+
+// UserSessionRepository handles database operations for user sessions
+type UserSessionRepository struct{}
+
+// NewUserSessionRepository creates a new user session repository instance
+func NewUserSessionRepository() *UserSessionRepository {
+	return &UserSessionRepository{}
+}
+
+// Create inserts a session
+func (r *UserSessionRepository) Create(session models.UserSession) (models.UserSession, error) {
+	result := database.DB.Omit("User").Create(&session)
+	return session, result.Error
+}
+
+// FindActiveByUserID retrieves the unrevoked, unexpired sessions of a user, most recently
+// used first
+func (r *UserSessionRepository) FindActiveByUserID(userID string) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	result := database.Reader().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions)
+	return sessions, result.Error
+}
+
+// Revoke revokes an active session of a user
+func (r *UserSessionRepository) Revoke(id string, userID string) (int64, error) {
+	result := database.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		UpdateColumn("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAllForUser revokes every active session of a user except keepID, and rejects
+// the untracked session tokens issued before now. It returns the IDs of the revoked sessions.
+func (r *UserSessionRepository) RevokeAllForUser(userID string, keepID string) ([]string, error) {
+	now := time.Now()
+	query := database.DB.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now)
+	if keepID != "" {
+		query = query.Where("id <> ?", keepID)
+	}
+
+	var ids []string
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		if err := database.DB.Model(&models.UserSession{}).Where("id IN ?", ids).UpdateColumn("revoked_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := database.DB.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("sessions_revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindRevokedUnexpired retrieves the sessions that are revoked but whose tokens have not
+// expired yet: the revocation list
+func (r *UserSessionRepository) FindRevokedUnexpired() ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	result := database.DB.Select("id", "expires_at").
+		Where("revoked_at IS NOT NULL AND expires_at > ?", time.Now()).
+		Find(&sessions)
+	return sessions, result.Error
+}
+
+// FindUserRevocationsSince retrieves users whose untracked sessions were revoked after since
+func (r *UserSessionRepository) FindUserRevocationsSince(since time.Time) ([]models.User, error) {
+	var users []models.User
+	result := database.DB.Select("id", "sessions_revoked_at").
+		Where("sessions_revoked_at > ?", since).
+		Find(&users)
+	return users, result.Error
+}
+
+// TouchLastSeen records when a session was last used
+func (r *UserSessionRepository) TouchLastSeen(id string, at time.Time) error {
+	return database.DB.Model(&models.UserSession{}).Where("id = ?", id).UpdateColumn("last_seen_at", at).Error
+}
+
+// DeleteExpiredBefore deletes sessions that expired before a point in time
+func (r *UserSessionRepository) DeleteExpiredBefore(before time.Time) (int64, error) {
+	result := database.DB.Where("expires_at < ?", before).Delete(&models.UserSession{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+)
+
+// ErrInvalidActivityCursor is returned for cursors that were not issued by the feed
+var ErrInvalidActivityCursor = errors.New("invalid activity cursor")
+
+// activityCursorIDPattern matches the UUIDs feed items are identified by
+var activityCursorIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ActivityService builds a project's activity feed from its deployments, config
+// revisions and incidents
+type ActivityService struct {
+	activityRepo *repositories.ActivityRepository
+	projectRepo  *repositories.ProjectRepository
+	serviceRepo  *repositories.ServiceRepository
+}
+
+// NewActivityService creates a new activity service instance
+func NewActivityService() *ActivityService {
+	return &ActivityService{
+		activityRepo: repositories.NewActivityRepository(),
+		projectRepo:  repositories.NewProjectRepository(),
+		serviceRepo:  repositories.NewServiceRepository(),
+	}
+}
+
+// GetProjectActivity returns a page of the project's activity older than the cursor
+// (empty for the newest), limited to kinds when given
+func (s *ActivityService) GetProjectActivity(projectID string, cursor string, limit int, kinds []string, userID string, isAdmin bool) (dto.ActivityFeed, error) {
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(projectID)
+		if err != nil {
+			return dto.ActivityFeed{}, err
+		}
+		if ownerID != userID {
+			return dto.ActivityFeed{}, errors.New("unauthorized access to project")
+		}
+	}
+
+	position, err := decodeActivityCursor(cursor)
+	if err != nil {
+		return dto.ActivityFeed{}, err
+	}
+	wanted := func(kind string) bool {
+		if len(kinds) == 0 {
+			return true
+		}
+		for _, k := range kinds {
+			if k == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Each source returns one row more than the page, so a merged page that is full
+	// proves there is a next one
+	var items []dto.ActivityItem
+	if wanted(dto.ActivityDeployment) {
+		deployments, err := s.activityRepo.FindDeployments(projectID, position, limit+1)
+		if err != nil {
+			return dto.ActivityFeed{}, err
+		}
+		for _, deployment := range deployments {
+			items = append(items, deploymentActivity(deployment))
+		}
+	}
+	if wanted(dto.ActivityConfigChange) || wanted(dto.ActivityScaling) {
+		revisionItems, err := s.revisionActivity(projectID, position, limit+1)
+		if err != nil {
+			return dto.ActivityFeed{}, err
+		}
+		for _, item := range revisionItems {
+			if wanted(item.Kind) {
+				items = append(items, item)
+			}
+		}
+	}
+	if wanted(dto.ActivityIncident) {
+		incidents, err := s.activityRepo.FindServiceIncidents(projectID, position, limit+1)
+		if err != nil {
+			return dto.ActivityFeed{}, err
+		}
+		for _, incident := range incidents {
+			items = append(items, serviceIncidentActivity(incident))
+		}
+		annotated, err := s.activityRepo.FindStatusPageIncidents(projectID, position, limit+1)
+		if err != nil {
+			return dto.ActivityFeed{}, err
+		}
+		for _, incident := range annotated {
+			items = append(items, statusPageIncidentActivity(incident))
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].OccurredAt.Equal(items[j].OccurredAt) {
+			return items[i].OccurredAt.After(items[j].OccurredAt)
+		}
+		return items[i].ID > items[j].ID
+	})
+
+	feed := dto.ActivityFeed{Items: items}
+	if len(items) > limit {
+		feed.Items = items[:limit]
+		last := feed.Items[limit-1]
+		feed.NextCursor = encodeActivityCursor(repositories.ActivityCursor{At: last.OccurredAt, ID: last.ID})
+	}
+	if feed.Items == nil {
+		feed.Items = []dto.ActivityItem{}
+	}
+	s.addServiceNames(projectID, feed.Items)
+	return feed, nil
+}
+
+// revisionActivity turns config revisions into config change and scaling items. The
+// first revision of a service is its creation and counts as a config change.
+func (s *ActivityService) revisionActivity(projectID string, position repositories.ActivityCursor, limit int) ([]dto.ActivityItem, error) {
+	revisions, err := s.activityRepo.FindRevisions(projectID, position, limit)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.activityRepo.FindPreviousRevisions(revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.ActivityItem, 0, len(revisions))
+	for _, revision := range revisions {
+		item := dto.ActivityItem{
+			Kind:       dto.ActivityConfigChange,
+			ID:         revision.ID,
+			OccurredAt: revision.CreatedAt,
+			ServiceID:  revision.ServiceID,
+			ActorID:    revision.CreatedBy,
+		}
+		prev, hasPrev := previous[revision.ServiceID][revision.Revision-1]
+		switch {
+		case revision.RevertedFrom != nil:
+			item.Summary = fmt.Sprintf("Reverted to revision %d", *revision.RevertedFrom)
+		case !hasPrev:
+			item.Summary = fmt.Sprintf("Configuration saved (revision %d)", revision.Revision)
+		case onlyScalingChanged(prev, revision):
+			item.Kind = dto.ActivityScaling
+			item.Summary = describeScaling(revision)
+		default:
+			item.Summary = fmt.Sprintf("Changed %s (revision %d)", strings.Join(changedConfig(prev, revision), ", "), revision.Revision)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// addServiceNames fills in the names of the services items refer to
+func (s *ActivityService) addServiceNames(projectID string, items []dto.ActivityItem) {
+	services, err := s.serviceRepo.FindByProjectID(projectID)
+	if err != nil {
+		return
+	}
+	names := make(map[string]string, len(services))
+	for _, service := range services {
+		names[service.ID] = service.Name
+	}
+	for i := range items {
+		items[i].ServiceName = names[items[i].ServiceID]
+	}
+}
+
+func deploymentActivity(deployment models.Deployment) dto.ActivityItem {
+	summary := "Deployed"
+	switch {
+	case deployment.CommitSHA != "":
+		sha := deployment.CommitSHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		summary = "Deployed " + sha
+		if message := strings.SplitN(deployment.CommitMessage, "\n", 2)[0]; message != "" {
+			summary += ": " + message
+		}
+	case deployment.Version != "":
+		summary = "Deployed version " + deployment.Version
+	}
+	return dto.ActivityItem{
+		Kind:       dto.ActivityDeployment,
+		ID:         deployment.ID,
+		OccurredAt: deployment.CreatedAt,
+		ServiceID:  deployment.ServiceID,
+		Summary:    summary,
+		Status:     string(deployment.Status),
+	}
+}
+
+func serviceIncidentActivity(incident models.ServiceIncident) dto.ActivityItem {
+	status := "open"
+	if incident.ResolvedAt != nil {
+		status = "resolved"
+	}
+	summary := "Incident detected"
+	if incident.ProbableCause != "" {
+		summary += ": " + incident.ProbableCause
+	}
+	return dto.ActivityItem{
+		Kind:       dto.ActivityIncident,
+		ID:         incident.ID,
+		OccurredAt: incident.StartedAt,
+		ServiceID:  incident.ServiceID,
+		Summary:    summary,
+		Status:     status,
+	}
+}
+
+func statusPageIncidentActivity(incident models.Incident) dto.ActivityItem {
+	item := dto.ActivityItem{
+		Kind:       dto.ActivityIncident,
+		ID:         incident.ID,
+		OccurredAt: incident.StartedAt,
+		Summary:    incident.Title,
+		Status:     incident.Status,
+		ActorID:    incident.CreatedBy,
+	}
+	if incident.ServiceID != nil {
+		item.ServiceID = *incident.ServiceID
+	}
+	return item
+}
+
+// onlyScalingChanged reports whether two revisions differ in their replica settings only
+func onlyScalingChanged(prev, next models.ServiceRevision) bool {
+	rescaled := prev
+	rescaled.IsStaticReplica = next.IsStaticReplica
+	rescaled.Replicas = next.Replicas
+	rescaled.MinReplicas = next.MinReplicas
+	rescaled.MaxReplicas = next.MaxReplicas
+	return rescaled.SameConfig(next) && !prev.SameConfig(next)
+}
+
+func describeScaling(revision models.ServiceRevision) string {
+	if revision.IsStaticReplica {
+		return fmt.Sprintf("Scaled to %d replicas", revision.Replicas)
+	}
+	return fmt.Sprintf("Autoscaling set to %d-%d replicas", revision.MinReplicas, revision.MaxReplicas)
+}
+
+// changedConfig names the parts of the configuration that differ between two revisions
+func changedConfig(prev, next models.ServiceRevision) []string {
+	var changed []string
+	// Comparing revisions that differ in nothing but their env vars
+	if !(models.ServiceRevision{EnvVars: prev.EnvVars}).SameConfig(models.ServiceRevision{EnvVars: next.EnvVars}) {
+		changed = append(changed, "environment variables")
+	}
+	if prev.CPULimit != next.CPULimit || prev.MemoryLimit != next.MemoryLimit || prev.StorageSize != next.StorageSize {
+		changed = append(changed, "resources")
+	}
+	if prev.IsStaticReplica != next.IsStaticReplica || prev.Replicas != next.Replicas ||
+		prev.MinReplicas != next.MinReplicas || prev.MaxReplicas != next.MaxReplicas {
+		changed = append(changed, "scaling")
+	}
+	if prev.CustomDomain != next.CustomDomain {
+		changed = append(changed, "domain")
+	}
+	if len(changed) == 0 {
+		changed = append(changed, "configuration")
+	}
+	return changed
+}
+
+// encodeActivityCursor returns the opaque cursor of a feed position
+func encodeActivityCursor(cursor repositories.ActivityCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.At.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID))
+}
+
+func decodeActivityCursor(cursor string) (repositories.ActivityCursor, error) {
+	if cursor == "" {
+		return repositories.ActivityCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return repositories.ActivityCursor{}, ErrInvalidActivityCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || !activityCursorIDPattern.MatchString(parts[1]) {
+		return repositories.ActivityCursor{}, ErrInvalidActivityCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return repositories.ActivityCursor{}, ErrInvalidActivityCursor
+	}
+	return repositories.ActivityCursor{At: at, ID: parts[1]}, nil
+}
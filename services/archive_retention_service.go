@@ -0,0 +1,347 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ErrArchiveQuotaNotFound is returned when a project has no archive quota of its own
+var ErrArchiveQuotaNotFound = errors.New("archive quota not found")
+
+var (
+	archivePurgerOnce sync.Once
+	// archivePurgeMu keeps a purge requested by an admin from overlapping the scheduled one
+	archivePurgeMu sync.Mutex
+)
+
+// ArchiveRetentionService keeps the build logs and artifacts archived in the artifact store
+// within the admin's retention policy: archives older than the retention period, and the
+// oldest archives of projects over their size quota, are deleted. The archives of each
+// service's latest deployment are always kept.
+type ArchiveRetentionService struct {
+	retentionRepo *repositories.ArchiveRetentionRepository
+	projectRepo   *repositories.ProjectRepository
+}
+
+// NewArchiveRetentionService creates a new archive retention service instance
+func NewArchiveRetentionService() *ArchiveRetentionService {
+	return &ArchiveRetentionService{
+		retentionRepo: repositories.NewArchiveRetentionRepository(),
+		projectRepo:   repositories.NewProjectRepository(),
+	}
+}
+
+// GetSettings returns the retention policy
+func (s *ArchiveRetentionService) GetSettings() (models.ArchiveRetentionSettings, error) {
+	return s.retentionRepo.FindSettings()
+}
+
+// UpdateSettings changes the retention policy; it applies from the next purger run
+func (s *ArchiveRetentionService) UpdateSettings(req dto.ArchiveRetentionSettingsRequest, userID string) (models.ArchiveRetentionSettings, error) {
+	settings, err := s.retentionRepo.FindSettings()
+	if err != nil {
+		return settings, err
+	}
+	if req.BuildLogRetentionDays != nil {
+		settings.BuildLogRetentionDays = *req.BuildLogRetentionDays
+	}
+	if req.ArtifactRetentionDays != nil {
+		settings.ArtifactRetentionDays = *req.ArtifactRetentionDays
+	}
+	if req.DefaultProjectQuotaMB != nil {
+		settings.DefaultProjectQuotaMB = *req.DefaultProjectQuotaMB
+	}
+	settings.UpdatedBy = userID
+	return s.retentionRepo.SaveSettings(settings)
+}
+
+// SaveQuota sets the archive size quota of a project, replacing the default quota
+func (s *ArchiveRetentionService) SaveQuota(projectID string, req dto.ArchiveQuotaRequest, userID string) (models.ArchiveQuota, error) {
+	if exists, err := s.projectRepo.Exists(projectID); err != nil || !exists {
+		return models.ArchiveQuota{}, errors.New("project not found")
+	}
+	quota := models.ArchiveQuota{
+		ProjectID: projectID,
+		QuotaMB:   req.QuotaMB,
+		UpdatedBy: userID,
+	}
+	if existing, err := s.retentionRepo.FindQuota(projectID); err == nil {
+		quota.CreatedAt = existing.CreatedAt
+	}
+	return s.retentionRepo.SaveQuota(quota)
+}
+
+// DeleteQuota puts a project back on the default quota
+func (s *ArchiveRetentionService) DeleteQuota(projectID string) error {
+	deleted, err := s.retentionRepo.DeleteQuota(projectID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrArchiveQuotaNotFound
+	}
+	return nil
+}
+
+// GetUsageOverview returns the archive usage of every project against its quota, largest first
+func (s *ArchiveRetentionService) GetUsageOverview() (dto.ArchiveUsageOverview, error) {
+	settings, err := s.retentionRepo.FindSettings()
+	if err != nil {
+		return dto.ArchiveUsageOverview{}, err
+	}
+	usage, err := s.retentionRepo.FindUsage("")
+	if err != nil {
+		return dto.ArchiveUsageOverview{}, err
+	}
+	quotas, err := s.projectQuotas()
+	if err != nil {
+		return dto.ArchiveUsageOverview{}, err
+	}
+	projects, err := s.projectRepo.FindAll()
+	if err != nil {
+		return dto.ArchiveUsageOverview{}, err
+	}
+	names := make(map[string]string, len(projects))
+	for _, project := range projects {
+		names[project.ID] = project.Name
+	}
+
+	overview := dto.ArchiveUsageOverview{Settings: settings, Projects: []dto.ProjectArchiveUsage{}}
+	for _, total := range usage {
+		projectUsage := toProjectArchiveUsage(total, settings, quotas)
+		projectUsage.ProjectName = names[total.ProjectID]
+		overview.TotalBytes += projectUsage.TotalBytes
+		overview.Projects = append(overview.Projects, projectUsage)
+	}
+	sort.Slice(overview.Projects, func(i, j int) bool {
+		return overview.Projects[i].TotalBytes > overview.Projects[j].TotalBytes
+	})
+	return overview, nil
+}
+
+// GetProjectUsage returns the archive usage of one project against its quota
+func (s *ArchiveRetentionService) GetProjectUsage(projectID string, userID string, isAdmin bool) (dto.ProjectArchiveUsage, error) {
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(projectID)
+		if err != nil {
+			return dto.ProjectArchiveUsage{}, err
+		}
+		if ownerID != userID {
+			return dto.ProjectArchiveUsage{}, errors.New("unauthorized access to project")
+		}
+	}
+
+	settings, err := s.retentionRepo.FindSettings()
+	if err != nil {
+		return dto.ProjectArchiveUsage{}, err
+	}
+	usage, err := s.retentionRepo.FindUsage(projectID)
+	if err != nil {
+		return dto.ProjectArchiveUsage{}, err
+	}
+	quotas, err := s.projectQuotas()
+	if err != nil {
+		return dto.ProjectArchiveUsage{}, err
+	}
+	total := repositories.ProjectArchiveUsage{ProjectID: projectID}
+	if len(usage) > 0 {
+		total = usage[0]
+	}
+	return toProjectArchiveUsage(total, settings, quotas), nil
+}
+
+// RunOnce applies the retention policy: it deletes expired build logs and artifacts, then the
+// oldest archives of each project over its quota. Objects that cannot be deleted are kept on
+// record and retried on the next run.
+func (s *ArchiveRetentionService) RunOnce() (dto.ArchivePurgeReport, error) {
+	archivePurgeMu.Lock()
+	defer archivePurgeMu.Unlock()
+
+	report := dto.ArchivePurgeReport{}
+	config, ok := utils.LoadArtifactStoreConfig()
+	if !ok {
+		return report, nil
+	}
+	settings, err := s.retentionRepo.FindSettings()
+	if err != nil {
+		return report, err
+	}
+
+	if settings.BuildLogRetentionDays > 0 {
+		expired, err := s.retentionRepo.FindExpiredBuildLogs(time.Now().AddDate(0, 0, -settings.BuildLogRetentionDays))
+		if err != nil {
+			return report, err
+		}
+		for _, deployment := range expired {
+			s.purgeBuildLog(config, deployment, &report)
+		}
+	}
+	if settings.ArtifactRetentionDays > 0 {
+		expired, err := s.retentionRepo.FindExpiredArtifacts(time.Now().AddDate(0, 0, -settings.ArtifactRetentionDays))
+		if err != nil {
+			return report, err
+		}
+		for _, deployment := range expired {
+			s.purgeArtifact(config, deployment, &report)
+		}
+	}
+
+	if err := s.enforceQuotas(config, settings, &report); err != nil {
+		return report, err
+	}
+	if report.PurgedBuildLogs > 0 || report.PurgedArtifacts > 0 {
+		log.Printf("Archive purger removed %d build logs and %d artifacts (%d bytes)",
+			report.PurgedBuildLogs, report.PurgedArtifacts, report.FreedBytes)
+	}
+	return report, nil
+}
+
+// StartPurger starts the background purge loop (ARCHIVE_PURGE_INTERVAL_MINUTES, default 60)
+func (s *ArchiveRetentionService) StartPurger() {
+	archivePurgerOnce.Do(func() {
+		interval := time.Duration(getArchivePurgeInterval()) * time.Minute
+		go func() {
+			log.Printf("Archive purger started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if _, err := s.RunOnce(); err != nil {
+					log.Printf("Archive purge failed: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+// enforceQuotas deletes the oldest archives of each project over its quota until it fits
+func (s *ArchiveRetentionService) enforceQuotas(config utils.ArtifactStoreConfig, settings models.ArchiveRetentionSettings, report *dto.ArchivePurgeReport) error {
+	usage, err := s.retentionRepo.FindUsage("")
+	if err != nil {
+		return err
+	}
+	quotas, err := s.projectQuotas()
+	if err != nil {
+		return err
+	}
+
+	for _, total := range usage {
+		projectUsage := toProjectArchiveUsage(total, settings, quotas)
+		if !projectUsage.OverQuota {
+			continue
+		}
+		excess := projectUsage.TotalBytes - int64(projectUsage.QuotaMB)*1024*1024
+
+		deployments, err := s.retentionRepo.FindPurgeableArchives(total.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to load archives of project %s: %v", total.ProjectID, err)
+		}
+		for _, deployment := range deployments {
+			if excess <= 0 {
+				break
+			}
+			if deployment.BuildLogKey != "" && s.purgeBuildLog(config, deployment, report) {
+				excess -= deployment.BuildLogSize
+			}
+			if deployment.ArtifactKey != "" && s.purgeArtifact(config, deployment, report) {
+				excess -= deployment.ArtifactSize
+			}
+		}
+		if excess > 0 {
+			log.Printf("Archives of project %s stay %d bytes over quota: only the latest deployment of each service is left", total.ProjectID, excess)
+		}
+	}
+	return nil
+}
+
+// purgeBuildLog deletes a deployment's archived build logs and reports whether it did
+func (s *ArchiveRetentionService) purgeBuildLog(config utils.ArtifactStoreConfig, deployment models.Deployment, report *dto.ArchivePurgeReport) bool {
+	if err := utils.DeleteArchivedObject(config, deployment.BuildLogKey); err != nil {
+		recordPurgeFailure(report, deployment.ID, fmt.Sprintf("build logs: %v", err))
+		return false
+	}
+	if err := s.retentionRepo.ClearBuildLog(deployment.ID, time.Now()); err != nil {
+		recordPurgeFailure(report, deployment.ID, fmt.Sprintf("build logs: %v", err))
+		return false
+	}
+	report.PurgedBuildLogs++
+	report.FreedBytes += deployment.BuildLogSize
+	return true
+}
+
+// purgeArtifact deletes a deployment's archived artifact and reports whether it did
+func (s *ArchiveRetentionService) purgeArtifact(config utils.ArtifactStoreConfig, deployment models.Deployment, report *dto.ArchivePurgeReport) bool {
+	if err := utils.DeleteArchivedObject(config, deployment.ArtifactKey); err != nil {
+		recordPurgeFailure(report, deployment.ID, fmt.Sprintf("artifact: %v", err))
+		return false
+	}
+	if err := s.retentionRepo.ClearArtifact(deployment.ID, time.Now()); err != nil {
+		recordPurgeFailure(report, deployment.ID, fmt.Sprintf("artifact: %v", err))
+		return false
+	}
+	report.PurgedArtifacts++
+	report.FreedBytes += deployment.ArtifactSize
+	return true
+}
+
+// projectQuotas returns the archive quotas of the projects that have their own
+func (s *ArchiveRetentionService) projectQuotas() (map[string]int, error) {
+	quotas, err := s.retentionRepo.FindQuotas()
+	if err != nil {
+		return nil, err
+	}
+	byProject := make(map[string]int, len(quotas))
+	for _, quota := range quotas {
+		byProject[quota.ProjectID] = quota.QuotaMB
+	}
+	return byProject, nil
+}
+
+// toProjectArchiveUsage compares a project's archive usage with its own or the default quota
+func toProjectArchiveUsage(total repositories.ProjectArchiveUsage, settings models.ArchiveRetentionSettings, quotas map[string]int) dto.ProjectArchiveUsage {
+	usage := dto.ProjectArchiveUsage{
+		ProjectID:     total.ProjectID,
+		BuildLogs:     total.BuildLogs,
+		BuildLogBytes: total.BuildLogBytes,
+		Artifacts:     total.Artifacts,
+		ArtifactBytes: total.ArtifactBytes,
+		TotalBytes:    total.BuildLogBytes + total.ArtifactBytes,
+	}
+	if quotaMB, ok := quotas[total.ProjectID]; ok {
+		usage.QuotaMB, usage.QuotaSource = quotaMB, "project"
+	} else if settings.DefaultProjectQuotaMB > 0 {
+		usage.QuotaMB, usage.QuotaSource = settings.DefaultProjectQuotaMB, "default"
+	}
+	usage.OverQuota = usage.QuotaMB > 0 && usage.TotalBytes > int64(usage.QuotaMB)*1024*1024
+	return usage
+}
+
+func recordPurgeFailure(report *dto.ArchivePurgeReport, deploymentID string, message string) {
+	log.Printf("Failed to purge archive of deployment %s: %s", deploymentID, message)
+	if report.Failed == nil {
+		report.Failed = make(map[string]string)
+	}
+	report.Failed[deploymentID] = message
+}
+
+func getArchivePurgeInterval() int {
+	value := optionalEnvString("ARCHIVE_PURGE_INTERVAL_MINUTES")
+	if value == nil {
+		return 60
+	}
+	minutes, err := strconv.Atoi(*value)
+	if err != nil || minutes <= 0 {
+		return 60
+	}
+	return minutes
+}
@@ -129,23 +129,48 @@ func GetUser(id string) (*models.User, error) {
 	return &user, nil
 }
 
-// Login authenticates a user and returns a token
-func Login(req dto.LoginRequest) (*dto.AuthResponse, error) {
+// Login authenticates a user and returns a token. Accounts with 2FA also need a TOTP code
+// or a recovery code. Failed logins count towards the auth policy's lockout.
+func Login(req dto.LoginRequest, client LoginClient) (*dto.AuthResponse, error) {
+	protection := NewLoginProtectionService()
+	policy, err := protection.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find user by email
 	var user models.User
 	result := database.DB.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, protection.recordFailure(nil, req.Email, client, policy, models.LoginFailureCredentials, ErrInvalidCredentials)
+	}
+	if err := protection.checkLocked(user, client); err != nil {
+		return nil, err
 	}
 
 	// Check password
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, protection.recordFailure(&user, user.Email, client, policy, models.LoginFailureCredentials, ErrInvalidCredentials)
 	}
 
-	// Generate token
-	token, expiresAt, err := GenerateToken(user.ID, user.Email, string(user.Role))
+	// Check the second factor; a missing one is not a failed attempt, the client asks for it
+	if user.TwoFactorEnabled {
+		err := NewTwoFactorService().verifySecondFactor(user, req.TwoFactorCode, req.RecoveryCode)
+		if errors.Is(err, ErrTwoFactorRequired) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, protection.recordFailure(&user, user.Email, client, policy, models.LoginFailureTwoFactor, err)
+		}
+	}
+	protection.recordSuccess(user, client)
+
+	// Users the policy requires to use 2FA get a session that can only set it up
+	setupRequired := policy.RequiresTwoFactor(user.Role) && !user.TwoFactorEnabled
+
+	// Generate token for a new revocable session
+	token, expiresAt, err := NewSessionService().CreateSession(user, client, setupRequired)
 	if err != nil {
 		return nil, err
 	}
@@ -155,33 +180,26 @@ func Login(req dto.LoginRequest) (*dto.AuthResponse, error) {
 	responseUser.Password = ""
 
 	return &dto.AuthResponse{
-		Token:     token,
-		User:      responseUser,
-		ExpiresAt: expiresAt,
+		Token:                  token,
+		User:                   responseUser,
+		ExpiresAt:              expiresAt,
+		TwoFactorSetupRequired: setupRequired,
 	}, nil
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID, email, role string) (string, time.Time, error) {
+// generateToken signs the JWT of a session, adding the registered claims
+func generateToken(claims dto.TokenClaims, sessionID string, expiresAt time.Time) (string, time.Time, error) {
 	// Get secret key from environment
 	secretKey := os.Getenv("JWT_SECRET")
 	if secretKey == "" {
 		return "", time.Time{}, errors.New("JWT_SECRET not set in environment")
 	}
 
-	// Set expiration time
-	expiresAt := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
-
-	// Create claims with expiry time
-	claims := dto.TokenClaims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        sessionID,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
 	}
 
 	// Create the token
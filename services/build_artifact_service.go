@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// artifactURLTTL is how long the presigned URL the API downloads from stays valid
+const artifactURLTTL = 5 * time.Minute
+
+// ErrArtifactNotFound is returned when a deployment has no exported artifact
+var ErrArtifactNotFound = errors.New("this deployment has no build artifact")
+
+// BuildArtifactService exports a service's artifact directory after each successful
+// build and serves it for download, so builds double as CI artifact storage
+type BuildArtifactService struct {
+	serviceRepo    *repositories.ServiceRepository
+	deploymentRepo *repositories.DeploymentRepository
+}
+
+// NewBuildArtifactService creates a new build artifact service instance
+func NewBuildArtifactService() *BuildArtifactService {
+	return &BuildArtifactService{
+		serviceRepo:    repositories.NewServiceRepository(),
+		deploymentRepo: repositories.NewDeploymentRepository(),
+	}
+}
+
+// ShouldExport reports whether builds of the service publish an artifact
+func (s *BuildArtifactService) ShouldExport(service models.Service) bool {
+	if service.ArtifactPath == "" {
+		return false
+	}
+	_, ok := utils.LoadArtifactStoreConfig()
+	return ok
+}
+
+// Export uploads the artifact of a built image and records the outcome on the deployment.
+// A failed export never fails the deployment itself.
+func (s *BuildArtifactService) Export(deployment models.Deployment, service models.Service, registry models.Registry, image string) {
+	key, size, err := utils.ExportBuildArtifact(deployment, service, image, utils.IsInsecureRegistry(registry.URL))
+	artifactError := ""
+	if err != nil {
+		log.Printf("Failed to export build artifact for deployment %s: %v", deployment.ID, err)
+		artifactError = err.Error()
+	}
+	if err := s.deploymentRepo.UpdateArtifact(deployment.ID, key, size, artifactError); err != nil {
+		log.Printf("Failed to record build artifact for deployment %s: %v", deployment.ID, err)
+	}
+}
+
+// OpenArtifact returns a reader for a deployment's artifact, its size and a file name.
+// The caller must close the reader.
+func (s *BuildArtifactService) OpenArtifact(serviceID string, deploymentID string) (io.ReadCloser, int64, string, error) {
+	deployment, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil || deployment.ServiceID != serviceID {
+		return nil, 0, "", ErrArtifactNotFound
+	}
+	if deployment.ArtifactKey == "" {
+		return nil, 0, "", ErrArtifactNotFound
+	}
+
+	config, ok := utils.LoadArtifactStoreConfig()
+	if !ok {
+		return nil, 0, "", errors.New("artifact store is not configured")
+	}
+	downloadURL, err := utils.PresignArtifactURL(config, deployment.ArtifactKey, artifactURLTTL)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to reach artifact store: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, 0, "", ErrArtifactNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, "", fmt.Errorf("artifact store returned %s", resp.Status)
+	}
+
+	fileName := fmt.Sprintf("artifact-%s.tar.gz", deployment.ID)
+	return resp.Body, resp.ContentLength, fileName, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// buildsInProgress holds the builds this instance is running by cache key; deployments of an
+// identical build wait for it instead of building the same image again
+var buildsInProgress = struct {
+	mu     sync.Mutex
+	builds map[string]chan struct{} // closed when the build finishes
+}{builds: map[string]chan struct{}{}}
+
+// BuildCacheService shares images between the builds of a project: a service building a
+// commit another service already built with the same build config, e.g. apps of a monorepo,
+// deploys that image instead of building it again
+type BuildCacheService struct {
+	cacheRepo *repositories.BuildCacheRepository
+}
+
+// NewBuildCacheService creates a new build cache service instance
+func NewBuildCacheService() *BuildCacheService {
+	return &BuildCacheService{
+		cacheRepo: repositories.NewBuildCacheRepository(),
+	}
+}
+
+// Lookup returns the cached image of the service's build of the commit. Builds of the branch
+// head are never cached: the commit they build is not known beforehand.
+func (s *BuildCacheService) Lookup(service models.Service, commitSHA string) (models.BuildCacheEntry, bool) {
+	if commitSHA == "" {
+		return models.BuildCacheEntry{}, false
+	}
+	entry, err := s.cacheRepo.FindByKey(utils.BuildCacheKey(service, commitSHA))
+	if err != nil {
+		return entry, false
+	}
+	if err := s.cacheRepo.RecordHit(entry.ID, time.Now()); err != nil {
+		log.Printf("Failed to record build cache hit of %s: %v", entry.ID, err)
+	}
+	return entry, true
+}
+
+// Acquire returns the cached image of the build, waiting while an identical build is in
+// progress. When there is none, the caller is to build it and must call Release once done;
+// deployments waiting meanwhile then use its image, or build themselves if it failed.
+func (s *BuildCacheService) Acquire(service models.Service, commitSHA string) (models.BuildCacheEntry, bool) {
+	if commitSHA == "" {
+		return models.BuildCacheEntry{}, false
+	}
+	key := utils.BuildCacheKey(service, commitSHA)
+	for {
+		if entry, found := s.Lookup(service, commitSHA); found {
+			return entry, true
+		}
+
+		buildsInProgress.mu.Lock()
+		done, building := buildsInProgress.builds[key]
+		if !building {
+			buildsInProgress.builds[key] = make(chan struct{})
+			buildsInProgress.mu.Unlock()
+			return models.BuildCacheEntry{}, false
+		}
+		buildsInProgress.mu.Unlock()
+
+		log.Printf("Service %s waits for an identical build of commit %s in progress", service.ID, commitSHA)
+		<-done
+	}
+}
+
+// Release ends a build claimed with Acquire
+func (s *BuildCacheService) Release(service models.Service, commitSHA string) {
+	if commitSHA == "" {
+		return
+	}
+	key := utils.BuildCacheKey(service, commitSHA)
+
+	buildsInProgress.mu.Lock()
+	defer buildsInProgress.mu.Unlock()
+	if done, building := buildsInProgress.builds[key]; building {
+		close(done)
+		delete(buildsInProgress.builds, key)
+	}
+}
+
+// Record adds the image of a successful build to the cache
+func (s *BuildCacheService) Record(service models.Service, deployment models.Deployment, image string, record utils.BuildRecord) {
+	if deployment.CommitSHA == "" {
+		return
+	}
+	err := s.cacheRepo.Create(models.BuildCacheEntry{
+		CacheKey:            utils.BuildCacheKey(service, deployment.CommitSHA),
+		ProjectID:           service.ProjectID,
+		RepoURL:             service.RepoURL,
+		CommitSHA:           deployment.CommitSHA,
+		SparseCheckoutPaths: service.SparseCheckoutPaths,
+		DockerfilePath:      utils.GetDockerfilePath(service),
+		DockerfileDigest:    record.DockerfileDigest,
+		Image:               image,
+		ImageDigest:         record.ImageDigest,
+		SourceServiceID:     service.ID,
+		SourceDeploymentID:  deployment.ID,
+	})
+	if err != nil {
+		log.Printf("Failed to add the image of deployment %s to the build cache: %v", deployment.ID, err)
+	}
+}
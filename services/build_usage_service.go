@@ -0,0 +1,389 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+const (
+	// buildQueuePollInterval is how often a queued build checks for a free slot
+	buildQueuePollInterval = 5 * time.Second
+	// buildSlotStaleAfter frees the slot of a build that never reported its end, e.g.
+	// because its API instance restarted; build jobs time out long before
+	buildSlotStaleAfter = 30 * time.Minute
+	// maxBuildUsageMonths caps the history of the build usage report
+	maxBuildUsageMonths = 24
+)
+
+// ErrBuildQuotaNotFound is returned when a project has no build quota
+var ErrBuildQuotaNotFound = errors.New("build quota not found")
+
+// BuildQuotaExceededError reports that a project used up its monthly build minutes
+type BuildQuotaExceededError struct {
+	UsedMinutes  float64
+	LimitMinutes int
+}
+
+func (e *BuildQuotaExceededError) Error() string {
+	return fmt.Sprintf("the project used %.0f of its %d build minutes this month; builds resume next month or when an admin raises the limit",
+		e.UsedMinutes, e.LimitMinutes)
+}
+
+// BuildUsageService accounts the run time of image build jobs per project and enforces the
+// build caps admins set: a concurrency limit and monthly soft and hard limits of build
+// minutes
+type BuildUsageService struct {
+	usageRepo      *repositories.BuildUsageRepository
+	deploymentRepo *repositories.DeploymentRepository
+	projectRepo    *repositories.ProjectRepository
+}
+
+// NewBuildUsageService creates a new build usage service instance
+func NewBuildUsageService() *BuildUsageService {
+	return &BuildUsageService{
+		usageRepo:      repositories.NewBuildUsageRepository(),
+		deploymentRepo: repositories.NewDeploymentRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+	}
+}
+
+// CheckBuildAllowed returns a BuildQuotaExceededError when the service's project is past
+// its hard limit of build minutes this month
+func (s *BuildUsageService) CheckBuildAllowed(service models.Service) error {
+	quota, err := s.usageRepo.FindQuota(service.ProjectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && quota.MonthlyMinutesHardLimit == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check build quota: %v", err)
+	}
+
+	used, err := s.minutesThisMonth(service.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to check build quota: %v", err)
+	}
+	if used >= float64(quota.MonthlyMinutesHardLimit) {
+		return &BuildQuotaExceededError{UsedMinutes: used, LimitMinutes: quota.MonthlyMinutesHardLimit}
+	}
+	return nil
+}
+
+// AcquireBuildSlot waits until the project may start another build job, then records that
+// the deployment's build started and returns when. Builds wait in the order they were
+// created; one waiting longer than BUILD_QUEUE_TIMEOUT_MINUTES fails.
+func (s *BuildUsageService) AcquireBuildSlot(deployment models.Deployment, service models.Service) (time.Time, error) {
+	deadline := time.Now().Add(getBuildQueueTimeout())
+	queued := false
+	for {
+		limit, err := s.concurrencyLimit(service.ProjectID)
+		if err != nil {
+			log.Printf("Failed to read build quota of project %s, not limiting: %v", service.ProjectID, err)
+			limit = 0
+		}
+		startedAt, started, err := s.tryStartBuild(deployment, service.ProjectID, limit)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to start build: %v", err)
+		}
+		if started {
+			return startedAt, nil
+		}
+
+		if !queued {
+			queued = true
+			log.Printf("Deployment %s queued: project %s is running %d build(s) at once", deployment.ID, service.ProjectID, limit)
+			if err := s.deploymentRepo.MarkQueued(deployment.ID); err != nil {
+				log.Printf("Failed to mark deployment %s as queued: %v", deployment.ID, err)
+			}
+		}
+		if time.Now().After(deadline) {
+			return time.Time{}, fmt.Errorf("timed out waiting for a build slot of the project")
+		}
+		time.Sleep(buildQueuePollInterval)
+	}
+}
+
+// FinishBuild records the end and run time of a deployment's build job
+func (s *BuildUsageService) FinishBuild(deploymentID string, startedAt time.Time) {
+	now := time.Now()
+	if err := s.deploymentRepo.FinishBuild(deploymentID, now, now.Sub(startedAt)); err != nil {
+		log.Printf("Failed to record build time of deployment %s: %v", deploymentID, err)
+	}
+}
+
+// GetProjectUsage returns the project's build consumption over the last months against
+// its quota
+func (s *BuildUsageService) GetProjectUsage(projectID string, months int, userID string, isAdmin bool) (dto.BuildUsageReport, error) {
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(projectID)
+		if err != nil {
+			return dto.BuildUsageReport{}, err
+		}
+		if ownerID != userID {
+			return dto.BuildUsageReport{}, errors.New("unauthorized access to project")
+		}
+	}
+	if months < 1 {
+		months = 1
+	}
+	if months > maxBuildUsageMonths {
+		months = maxBuildUsageMonths
+	}
+
+	report := dto.BuildUsageReport{ProjectID: projectID, Months: make([]dto.BuildMonthUsage, 0, months)}
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < months; i++ {
+		from := monthStart.AddDate(0, -i, 0)
+		intervals, err := s.usageRepo.FindBuildIntervals(projectID, from, from.AddDate(0, 1, 0))
+		if err != nil {
+			return report, err
+		}
+		report.Months = append(report.Months, summarizeBuildMonth(from, intervals, now))
+	}
+
+	running, err := s.usageRepo.CountRunningBuildsTx(s.usageRepo.DB(), projectID, time.Now().Add(-buildSlotStaleAfter))
+	if err != nil {
+		return report, err
+	}
+	report.RunningBuilds = running
+	if report.QueuedBuilds, err = s.usageRepo.CountQueued(projectID); err != nil {
+		return report, err
+	}
+
+	quota, err := s.usageRepo.FindQuota(projectID)
+	if err == nil {
+		report.Quota = &quota
+		used := report.Months[0].BuildMinutes
+		report.SoftLimitExceeded = quota.MonthlyMinutesSoftLimit > 0 && used >= float64(quota.MonthlyMinutesSoftLimit)
+		report.HardLimitExceeded = quota.MonthlyMinutesHardLimit > 0 && used >= float64(quota.MonthlyMinutesHardLimit)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return report, err
+	}
+	return report, nil
+}
+
+// GetUsageOverview returns the build consumption of every project in a month (YYYY-MM,
+// empty for the current one), together with the projects that have a quota
+func (s *BuildUsageService) GetUsageOverview(month string) (dto.BuildUsageOverview, error) {
+	from, err := parseBuildMonth(month)
+	if err != nil {
+		return dto.BuildUsageOverview{}, err
+	}
+	totals, err := s.usageRepo.SumByProject(from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return dto.BuildUsageOverview{}, err
+	}
+	quotas, err := s.usageRepo.FindQuotas()
+	if err != nil {
+		return dto.BuildUsageOverview{}, err
+	}
+	projects, err := s.projectRepo.FindAll()
+	if err != nil {
+		return dto.BuildUsageOverview{}, err
+	}
+
+	names := make(map[string]string, len(projects))
+	for _, project := range projects {
+		names[project.ID] = project.Name
+	}
+	usageByProject := make(map[string]*dto.ProjectBuildUsage)
+	overview := dto.BuildUsageOverview{Month: from.Format("2006-01"), Projects: []dto.ProjectBuildUsage{}}
+	for _, total := range totals {
+		usageByProject[total.ProjectID] = &dto.ProjectBuildUsage{
+			ProjectID:    total.ProjectID,
+			ProjectName:  names[total.ProjectID],
+			Builds:       total.Builds,
+			BuildMinutes: roundMinutes(time.Duration(total.DurationMs) * time.Millisecond),
+		}
+	}
+	for i := range quotas {
+		usage, ok := usageByProject[quotas[i].ProjectID]
+		if !ok {
+			usage = &dto.ProjectBuildUsage{ProjectID: quotas[i].ProjectID, ProjectName: names[quotas[i].ProjectID]}
+			usageByProject[quotas[i].ProjectID] = usage
+		}
+		usage.Quota = &quotas[i]
+	}
+	for _, usage := range usageByProject {
+		overview.Projects = append(overview.Projects, *usage)
+	}
+	sort.Slice(overview.Projects, func(i, j int) bool {
+		if overview.Projects[i].BuildMinutes != overview.Projects[j].BuildMinutes {
+			return overview.Projects[i].BuildMinutes > overview.Projects[j].BuildMinutes
+		}
+		return overview.Projects[i].ProjectName < overview.Projects[j].ProjectName
+	})
+	return overview, nil
+}
+
+// SaveQuota sets the build caps of a project
+func (s *BuildUsageService) SaveQuota(projectID string, req dto.BuildQuotaRequest, userID string) (models.BuildQuota, error) {
+	if exists, err := s.projectRepo.Exists(projectID); err != nil || !exists {
+		return models.BuildQuota{}, errors.New("project not found")
+	}
+	if req.MonthlyMinutesSoftLimit > 0 && req.MonthlyMinutesHardLimit > 0 && req.MonthlyMinutesSoftLimit > req.MonthlyMinutesHardLimit {
+		return models.BuildQuota{}, errors.New("monthlyMinutesSoftLimit cannot exceed monthlyMinutesHardLimit")
+	}
+
+	quota := models.BuildQuota{
+		ProjectID:               projectID,
+		MaxConcurrentBuilds:     req.MaxConcurrentBuilds,
+		MonthlyMinutesSoftLimit: req.MonthlyMinutesSoftLimit,
+		MonthlyMinutesHardLimit: req.MonthlyMinutesHardLimit,
+		UpdatedBy:               userID,
+	}
+	if existing, err := s.usageRepo.FindQuota(projectID); err == nil {
+		quota.CreatedAt = existing.CreatedAt
+	}
+	return s.usageRepo.SaveQuota(quota)
+}
+
+// DeleteQuota removes the build caps of a project
+func (s *BuildUsageService) DeleteQuota(projectID string) error {
+	deleted, err := s.usageRepo.DeleteQuota(projectID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrBuildQuotaNotFound
+	}
+	return nil
+}
+
+// concurrencyLimit returns how many builds the project may run at once (0 for no limit).
+// Past the soft limit of build minutes builds run one at a time.
+func (s *BuildUsageService) concurrencyLimit(projectID string) (int, error) {
+	quota, err := s.usageRepo.FindQuota(projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	limit := quota.MaxConcurrentBuilds
+	if quota.MonthlyMinutesSoftLimit > 0 && limit != 1 {
+		used, err := s.minutesThisMonth(projectID)
+		if err != nil {
+			return limit, err
+		}
+		if used >= float64(quota.MonthlyMinutesSoftLimit) {
+			limit = 1
+		}
+	}
+	return limit, nil
+}
+
+// tryStartBuild starts the deployment's build when the project has a free slot and no
+// older build is waiting. The project row is locked so replicas hand out slots one at a time.
+func (s *BuildUsageService) tryStartBuild(deployment models.Deployment, projectID string, limit int) (time.Time, bool, error) {
+	startedAt := time.Now()
+	started := false
+	err := s.usageRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if limit > 0 {
+			if err := s.usageRepo.LockProjectTx(tx, projectID); err != nil {
+				return err
+			}
+			running, err := s.usageRepo.CountRunningBuildsTx(tx, projectID, startedAt.Add(-buildSlotStaleAfter))
+			if err != nil {
+				return err
+			}
+			waiting, err := s.usageRepo.CountQueuedBeforeTx(tx, projectID, deployment)
+			if err != nil {
+				return err
+			}
+			if running+waiting >= int64(limit) {
+				return nil
+			}
+		}
+		started = true
+		return s.deploymentRepo.StartBuildTx(tx, deployment.ID, startedAt)
+	})
+	return startedAt, started && err == nil, err
+}
+
+func (s *BuildUsageService) minutesThisMonth(projectID string) (float64, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	used, err := s.usageRepo.SumBuildDuration(projectID, from, from.AddDate(0, 1, 0))
+	return roundMinutes(used), err
+}
+
+// summarizeBuildMonth totals a month's build jobs and finds how many ran at once at most.
+// Running jobs count until now.
+func summarizeBuildMonth(from time.Time, intervals []repositories.BuildInterval, now time.Time) dto.BuildMonthUsage {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	usage := dto.BuildMonthUsage{Month: from.Format("2006-01"), Builds: len(intervals)}
+	edges := make([]edge, 0, 2*len(intervals))
+	var total time.Duration
+	for _, interval := range intervals {
+		end := now
+		if interval.FinishedAt != nil {
+			end = *interval.FinishedAt
+		}
+		total += time.Duration(interval.DurationMs) * time.Millisecond
+		edges = append(edges, edge{interval.StartedAt, 1}, edge{end, -1})
+	}
+	usage.BuildMinutes = roundMinutes(total)
+
+	// A job ending when another starts does not overlap it
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].at.Equal(edges[j].at) {
+			return edges[i].at.Before(edges[j].at)
+		}
+		return edges[i].delta < edges[j].delta
+	})
+	running := 0
+	for _, e := range edges {
+		running += e.delta
+		if running > usage.PeakConcurrency {
+			usage.PeakConcurrency = running
+		}
+	}
+	return usage
+}
+
+// roundMinutes converts a duration to minutes with one decimal
+func roundMinutes(d time.Duration) float64 {
+	return math.Round(d.Minutes()*10) / 10
+}
+
+// parseBuildMonth parses a YYYY-MM month, defaulting to the current one, as its first
+// instant in UTC
+func parseBuildMonth(month string) (time.Time, error) {
+	if month == "" {
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	parsed, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q: use YYYY-MM", month)
+	}
+	return parsed, nil
+}
+
+// getBuildQueueTimeout reads BUILD_QUEUE_TIMEOUT_MINUTES (default 60)
+func getBuildQueueTimeout() time.Duration {
+	value := optionalEnvString("BUILD_QUEUE_TIMEOUT_MINUTES")
+	if value == nil {
+		return time.Hour
+	}
+	minutes, err := strconv.Atoi(*value)
+	if err != nil || minutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	defaultForecastWindowDays  = 14
+	maxForecastRecommendations = 10
+)
+
+var usageSamplerOnce sync.Once
+
+// CapacityService records cluster and service usage history and forecasts capacity from it
+type CapacityService struct {
+	usageRepo   *repositories.UsageSampleRepository
+	serviceRepo *repositories.ServiceRepository
+}
+
+// NewCapacityService creates a new capacity service instance
+func NewCapacityService() *CapacityService {
+	return &CapacityService{
+		usageRepo:   repositories.NewUsageSampleRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+	}
+}
+
+// StartUsageSampler starts the background loop recording usage samples and pruning old ones
+func (s *CapacityService) StartUsageSampler() {
+	usageSamplerOnce.Do(func() {
+		go func() {
+			interval := utils.GetUsageSampleInterval()
+			log.Printf("Usage sampler started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			prune := time.NewTicker(time.Hour)
+			defer prune.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.recordSamples()
+				case <-prune.C:
+					if deleted, err := s.usageRepo.DeleteSamplesBefore(time.Now().Add(-utils.GetUsageSampleRetention())); err != nil {
+						log.Printf("Usage sample prune failed: %v", err)
+					} else if deleted > 0 {
+						log.Printf("Usage sampler pruned %d samples", deleted)
+					}
+				}
+			}
+		}()
+	})
+}
+
+// recordSamples stores one cluster snapshot and one snapshot per running service
+func (s *CapacityService) recordSamples() {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		log.Printf("Usage sampler: failed to create Kubernetes client: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	clusterSample, err := utils.CollectClusterUsage(ctx, k8sClient)
+	if err != nil {
+		log.Printf("Usage sampler: %v", err)
+	} else if err := s.usageRepo.RecordClusterSample(clusterSample); err != nil {
+		log.Printf("Usage sampler: failed to record cluster sample: %v", err)
+	}
+
+	serviceSamples, err := utils.CollectServiceUsage(ctx, k8sClient)
+	if err != nil {
+		log.Printf("Usage sampler: %v", err)
+		return
+	}
+	if err := s.usageRepo.RecordServiceSamples(serviceSamples); err != nil {
+		log.Printf("Usage sampler: failed to record service samples: %v", err)
+	}
+}
+
+// GetCapacityForecast projects when cluster CPU, memory and storage run out from the
+// usage history of the last windowDays, and recommends the services most worth resizing
+func (s *CapacityService) GetCapacityForecast(windowDays int) (dto.CapacityForecast, error) {
+	if windowDays <= 0 {
+		windowDays = defaultForecastWindowDays
+	}
+	since := time.Now().AddDate(0, 0, -windowDays)
+
+	samples, err := s.usageRepo.FindClusterSamplesSince(since)
+	if err != nil {
+		return dto.CapacityForecast{}, err
+	}
+
+	forecast := dto.CapacityForecast{
+		WindowDays:      windowDays,
+		Samples:         len(samples),
+		Forecasts:       s.forecastResources(samples),
+		Recommendations: []dto.RightSizeRecommendation{},
+	}
+	if len(samples) > 0 {
+		forecast.SampledFrom = &samples[0].SampledAt
+	}
+
+	recommendations, err := s.findRecommendations(since)
+	if err != nil {
+		return forecast, err
+	}
+	if len(recommendations) > maxForecastRecommendations {
+		recommendations = recommendations[:maxForecastRecommendations]
+	}
+	forecast.Recommendations = recommendations
+	return forecast, nil
+}
+
+// forecastResources trends requests, which decide whether new pods can be scheduled,
+// and actual usage. Samples without usage data are left out of the usage trends.
+func (s *CapacityService) forecastResources(samples []models.ClusterUsageSample) []dto.ResourceForecast {
+	var cpuRequested, cpuUsed, memoryRequested, memoryUsed, storageUsed []utils.TrendPoint
+	for _, sample := range samples {
+		cpuRequested = append(cpuRequested, utils.TrendPoint{At: sample.SampledAt, Value: float64(sample.CPURequested)})
+		memoryRequested = append(memoryRequested, utils.TrendPoint{At: sample.SampledAt, Value: float64(sample.MemoryRequested)})
+		if sample.CPUUsed > 0 {
+			cpuUsed = append(cpuUsed, utils.TrendPoint{At: sample.SampledAt, Value: float64(sample.CPUUsed)})
+		}
+		if sample.MemoryUsed > 0 {
+			memoryUsed = append(memoryUsed, utils.TrendPoint{At: sample.SampledAt, Value: float64(sample.MemoryUsed)})
+		}
+		if sample.StorageCapacity > 0 {
+			storageUsed = append(storageUsed, utils.TrendPoint{At: sample.SampledAt, Value: float64(sample.StorageUsed)})
+		}
+	}
+
+	// Capacity is taken from the latest sample, after any node changes
+	var latest models.ClusterUsageSample
+	if len(samples) > 0 {
+		latest = samples[len(samples)-1]
+	}
+	storageCapacity := latest.StorageCapacity
+	for i := len(samples) - 1; storageCapacity == 0 && i >= 0; i-- {
+		storageCapacity = samples[i].StorageCapacity
+	}
+
+	return []dto.ResourceForecast{
+		utils.ForecastResource("cpu", "requests", cpuRequested, latest.CPUAllocatable),
+		utils.ForecastResource("memory", "requests", memoryRequested, latest.MemoryAllocatable),
+		utils.ForecastResource("cpu", "usage", cpuUsed, latest.CPUAllocatable),
+		utils.ForecastResource("memory", "usage", memoryUsed, latest.MemoryAllocatable),
+		utils.ForecastResource("storage", "usage", storageUsed, storageCapacity),
+	}
+}
+
+// findRecommendations compares every sampled service's per-pod p95 usage with its limits.
+// Services that need more come first, then the ones with the most capacity to give back.
+func (s *CapacityService) findRecommendations(since time.Time) ([]dto.RightSizeRecommendation, error) {
+	percentiles, err := s.usageRepo.FindServicePercentilesSince(since)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(percentiles))
+	for _, p := range percentiles {
+		if p.Samples >= utils.MinRightSizeSamples {
+			ids = append(ids, p.ServiceID)
+		}
+	}
+	services, err := s.serviceRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	serviceByID := make(map[string]models.Service, len(services))
+	for _, service := range services {
+		serviceByID[service.ID] = service
+	}
+
+	type ranked struct {
+		recommendation dto.RightSizeRecommendation
+		change         float64 // fraction of the current limit given back or added
+	}
+	var candidates []ranked
+	for _, p := range percentiles {
+		service, ok := serviceByID[p.ServiceID]
+		if !ok {
+			continue
+		}
+		for _, recommendation := range rightSizeService(service, p) {
+			limit, _ := utils.ParseResourceLimit(recommendation.Resource, recommendation.CurrentLimit)
+			recommended, _ := utils.ParseResourceLimit(recommendation.Resource, recommendation.RecommendedLimit)
+			change := 0.0
+			if limit > 0 {
+				change = float64(limit-recommended) / float64(limit) * float64(max(service.Replicas, 1))
+			}
+			candidates = append(candidates, ranked{recommendation, change})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		growI := candidates[i].recommendation.Direction == utils.RightSizeGrow
+		growJ := candidates[j].recommendation.Direction == utils.RightSizeGrow
+		if growI != growJ {
+			return growI
+		}
+		if growI {
+			return candidates[i].change < candidates[j].change
+		}
+		return candidates[i].change > candidates[j].change
+	})
+
+	recommendations := make([]dto.RightSizeRecommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		recommendations = append(recommendations, candidate.recommendation)
+	}
+	return recommendations, nil
+}
+
+// rightSizeService returns the CPU and memory recommendations of a service, if any
+func rightSizeService(service models.Service, p repositories.ServiceUsagePercentiles) []dto.RightSizeRecommendation {
+	var recommendations []dto.RightSizeRecommendation
+	for _, resource := range []struct {
+		name  string
+		limit string
+		p95   float64
+		peak  float64
+	}{
+		{"cpu", service.CPULimit, p.CPUP95, float64(p.CPUMax)},
+		{"memory", service.MemoryLimit, p.MemoryP95, float64(p.MemoryMax)},
+	} {
+		limit, err := utils.ParseResourceLimit(resource.name, resource.limit)
+		if err != nil {
+			continue
+		}
+		direction, recommended := utils.RecommendLimit(resource.name, limit, resource.p95, resource.peak)
+		if direction == "" {
+			continue
+		}
+		recommendations = append(recommendations, dto.RightSizeRecommendation{
+			ServiceID:        service.ID,
+			ServiceName:      service.Name,
+			ProjectID:        service.ProjectID,
+			EnvironmentID:    service.EnvironmentID,
+			Resource:         resource.name,
+			Direction:        direction,
+			CurrentLimit:     resource.limit,
+			RecommendedLimit: utils.FormatResourceLimit(resource.name, recommended),
+			P95Usage:         utils.FormatResourceUsage(resource.name, resource.p95),
+			PeakUsage:        utils.FormatResourceUsage(resource.name, resource.peak),
+			Utilization:      math.Round(resource.p95/float64(limit)*1000) / 10,
+			Replicas:         service.Replicas,
+		})
+	}
+	return recommendations
+}
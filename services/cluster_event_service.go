@@ -0,0 +1,77 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/utils"
+)
+
+// clusterEventKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const clusterEventKeepAlive = 30 * time.Second
+
+// ClusterEventService streams important cluster events to admins
+type ClusterEventService struct{}
+
+// NewClusterEventService creates a new cluster event service
+func NewClusterEventService() *ClusterEventService {
+	return &ClusterEventService{}
+}
+
+// ValidateSeverity checks a minimum severity filter; empty means every important event
+func (s *ClusterEventService) ValidateSeverity(severity string) error {
+	if severity != "" && utils.ClusterEventSeverityRank(severity) == 0 {
+		return fmt.Errorf("severity must be %s or %s", utils.ClusterEventSeverityWarning, utils.ClusterEventSeverityCritical)
+	}
+	return nil
+}
+
+// StreamClusterEvents writes recent and then live events at or above minSeverity as
+// Server-Sent Events until the client disconnects
+func (s *ClusterEventService) StreamClusterEvents(minSeverity string, w http.ResponseWriter, done <-chan struct{}) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	backlog, events, unsubscribe, err := utils.SubscribeClusterEvents()
+	if err != nil {
+		return err
+	}
+	defer unsubscribe()
+
+	minRank := utils.ClusterEventSeverityRank(minSeverity)
+	write := func(event dto.ClusterEvent) {
+		if utils.ClusterEventSeverityRank(event.Severity) < minRank {
+			return
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		utils.WriteSSEData(w, string(data))
+	}
+
+	for _, event := range backlog {
+		write(event)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(clusterEventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case event := <-events:
+			write(event)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ConsoleService runs audited console queries against managed services
+type ConsoleService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+	auditRepo   *repositories.ConsoleAuditRepository
+}
+
+// NewConsoleService creates a new console service instance
+func NewConsoleService() *ConsoleService {
+	return &ConsoleService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+		auditRepo:   repositories.NewConsoleAuditRepository(),
+	}
+}
+
+// ExecuteQuery runs a query against the managed service and records it in the audit log
+func (s *ConsoleService) ExecuteQuery(serviceID string, query string, userID string, isAdmin bool) (dto.ConsoleQueryResult, error) {
+	service, err := s.getConsoleService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ConsoleQueryResult{}, err
+	}
+
+	result, queryErr := utils.RunConsoleQuery(service, query)
+
+	entry := models.ConsoleAuditLog{
+		ServiceID:  service.ID,
+		UserID:     userID,
+		Query:      query,
+		Success:    queryErr == nil,
+		RowCount:   result.RowCount,
+		DurationMs: result.DurationMs,
+	}
+	if queryErr != nil {
+		entry.Error = queryErr.Error()
+	}
+	if _, err := s.auditRepo.Create(entry); err != nil {
+		log.Printf("Failed to write console audit log for service %s: %v", service.ID, err)
+	}
+
+	log.Printf("Console query on service %s by user %s (success=%t, %dms)", service.ID, userID, queryErr == nil, result.DurationMs)
+	return result, queryErr
+}
+
+// GetAuditLog returns recent console queries for a service
+func (s *ConsoleService) GetAuditLog(serviceID string, userID string, isAdmin bool) ([]models.ConsoleAuditLog, error) {
+	service, err := s.getConsoleService(serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auditRepo.FindByServiceID(service.ID, 100)
+}
+
+// getConsoleService loads the service and checks access and console support
+func (s *ConsoleService) getConsoleService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+
+	if service.Type != models.ServiceTypeManaged {
+		return service, errors.New("console is only available for managed services")
+	}
+	if !utils.IsConsoleSupported(service.ManagedType) {
+		return service, fmt.Errorf("console is not supported for %s services", service.ManagedType)
+	}
+
+	return service, nil
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// hoursPerMonth is the average number of hours in a month (365 * 24 / 12)
+const hoursPerMonth = 730
+
+// CostService estimates monthly costs of services from their resources and the
+// configured pricing
+type CostService struct {
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+	serviceRepo     *repositories.ServiceRepository
+}
+
+// NewCostService creates a new cost service instance
+func NewCostService() *CostService {
+	return &CostService{
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+	}
+}
+
+// GetProjectCosts returns the monthly cost estimate of a project by environment and service
+func (s *CostService) GetProjectCosts(projectID string, userID string, isAdmin bool) (dto.ProjectCostEstimate, error) {
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return dto.ProjectCostEstimate{}, err
+	}
+	if !isAdmin && project.UserID != userID {
+		return dto.ProjectCostEstimate{}, fmt.Errorf("unauthorized: you don't have permission to access this project")
+	}
+
+	environments, err := s.environmentRepo.FindByProjectID(projectID)
+	if err != nil {
+		return dto.ProjectCostEstimate{}, err
+	}
+	services, err := s.serviceRepo.FindByProjectID(projectID)
+	if err != nil {
+		return dto.ProjectCostEstimate{}, err
+	}
+	return s.estimate(project, environments, services), nil
+}
+
+// estimate prices every service from its limits and, per environment where the metrics
+// API answers, from its current usage. Usage totals are only reported when every
+// environment had metrics.
+func (s *CostService) estimate(project models.Project, environments []models.Environment, services []models.Service) dto.ProjectCostEstimate {
+	pricing := getCostPricing()
+	result := dto.ProjectCostEstimate{
+		ProjectID:    project.ID,
+		Pricing:      pricing,
+		CostSummary:  dto.CostSummary{Currency: pricing.Currency, UsageMonthly: float64Ptr(0)},
+		Environments: make([]dto.EnvironmentCostEstimate, 0, len(environments)),
+		Services:     make([]dto.ServiceCostEstimate, 0, len(services)),
+	}
+
+	for _, env := range environments {
+		envCost := dto.EnvironmentCostEstimate{
+			EnvironmentID:   env.ID,
+			EnvironmentName: env.Name,
+			CostSummary:     dto.CostSummary{Currency: pricing.Currency},
+		}
+
+		usage, err := utils.GetNamespaceUsageByApp(env.ID)
+		if err != nil {
+			log.Printf("Cost estimate: no usage for environment %s: %v", env.ID, err)
+		} else {
+			envCost.UsageMonthly = float64Ptr(0)
+		}
+
+		for _, service := range services {
+			if service.EnvironmentID != env.ID {
+				continue
+			}
+			serviceCost := estimateServiceCost(service, pricing)
+			if usage != nil {
+				applyServiceUsage(&serviceCost, usage[utils.GetResourceName(service)], pricing)
+				*envCost.UsageMonthly += *serviceCost.UsageMonthly
+			}
+			envCost.LimitsMonthly += serviceCost.LimitsMonthly
+			result.Services = append(result.Services, serviceCost)
+		}
+
+		result.LimitsMonthly += envCost.LimitsMonthly
+		if envCost.UsageMonthly == nil {
+			result.UsageMonthly = nil
+		} else {
+			*envCost.UsageMonthly = roundCost(*envCost.UsageMonthly)
+			if result.UsageMonthly != nil {
+				*result.UsageMonthly += *envCost.UsageMonthly
+			}
+		}
+		envCost.LimitsMonthly = roundCost(envCost.LimitsMonthly)
+		result.Environments = append(result.Environments, envCost)
+	}
+
+	result.LimitsMonthly = roundCost(result.LimitsMonthly)
+	if result.UsageMonthly != nil {
+		*result.UsageMonthly = roundCost(*result.UsageMonthly)
+	}
+	return result
+}
+
+// estimateServiceCost prices a service's billed replicas at their limits plus its storage
+func estimateServiceCost(service models.Service, pricing dto.CostPricing) dto.ServiceCostEstimate {
+	estimate := dto.ServiceCostEstimate{
+		ServiceID:     service.ID,
+		ServiceName:   service.Name,
+		EnvironmentID: service.EnvironmentID,
+		Replicas:      billedReplicas(service),
+		CPUCores:      utils.QuantityToCores(service.CPULimit),
+		MemoryGB:      utils.QuantityToGB(service.MemoryLimit),
+	}
+	if service.StorageSize != "" {
+		estimate.StorageGB = utils.QuantityToGB(service.StorageSize)
+	}
+
+	compute := float64(estimate.Replicas) * computeCost(estimate.CPUCores, estimate.MemoryGB, pricing)
+	estimate.LimitsMonthly = roundCost(compute + storageCost(estimate.StorageGB, pricing))
+	return estimate
+}
+
+// applyServiceUsage prices the current usage of a service's pods as if sustained for a month
+func applyServiceUsage(estimate *dto.ServiceCostEstimate, usage utils.ResourceUsage, pricing dto.CostPricing) {
+	cpu := usage.CPUCores
+	memory := usage.MemoryGB
+	estimate.CPUUsage = &cpu
+	estimate.MemoryUsageGB = &memory
+	estimate.UsageMonthly = float64Ptr(roundCost(computeCost(cpu, memory, pricing) + storageCost(estimate.StorageGB, pricing)))
+}
+
+// billedReplicas is the number of replicas a service runs most of the time: none while
+// paused, archived or never deployed, and the minimum when autoscaled
+func billedReplicas(service models.Service) int {
+	switch service.Status {
+	case "inactive", "paused", "archived":
+		return 0
+	}
+	if !service.IsStaticReplica {
+		return service.MinReplicas
+	}
+	return service.Replicas
+}
+
+func computeCost(cpuCores, memoryGB float64, pricing dto.CostPricing) float64 {
+	return (cpuCores*pricing.CPUHour + memoryGB*pricing.MemoryGBHour) * pricing.HoursPerMonth
+}
+
+func storageCost(storageGB float64, pricing dto.CostPricing) float64 {
+	return storageGB * pricing.StorageGBMonth
+}
+
+func roundCost(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+// getCostPricing reads the price list from the environment; unset prices are zero
+func getCostPricing() dto.CostPricing {
+	pricing := dto.CostPricing{
+		Currency:       "USD",
+		CPUHour:        getCostRate("COST_CPU_HOUR"),
+		MemoryGBHour:   getCostRate("COST_MEMORY_GB_HOUR"),
+		StorageGBMonth: getCostRate("COST_STORAGE_GB_MONTH"),
+		HoursPerMonth:  hoursPerMonth,
+	}
+	if currency := optionalEnvString("COST_CURRENCY"); currency != nil {
+		pricing.Currency = *currency
+	}
+	return pricing
+}
+
+func getCostRate(key string) float64 {
+	value := optionalEnvString(key)
+	if value == nil {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(*value, 64)
+	if err != nil || rate < 0 {
+		log.Printf("Warning: ignoring invalid %s=%q", key, *value)
+		return 0
+	}
+	return rate
+}
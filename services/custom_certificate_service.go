@@ -0,0 +1,274 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	certificateMonitorInterval = time.Hour
+	certificateReminderEvery   = 24 * time.Hour
+	defaultReminderDays        = 30
+)
+
+var certificateMonitorOnce sync.Once
+
+// CustomCertificateService manages user-uploaded TLS certificates for custom domains
+type CustomCertificateService struct {
+	certificateRepo *repositories.CustomCertificateRepository
+	serviceRepo     *repositories.ServiceRepository
+	projectRepo     *repositories.ProjectRepository
+	outboxRepo      *repositories.OutboxRepository
+}
+
+// NewCustomCertificateService creates a new custom certificate service instance
+func NewCustomCertificateService() *CustomCertificateService {
+	return &CustomCertificateService{
+		certificateRepo: repositories.NewCustomCertificateRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+		projectRepo:     repositories.NewProjectRepository(),
+		outboxRepo:      repositories.NewOutboxRepository(),
+	}
+}
+
+// GetCertificate returns the uploaded certificate of a service
+func (s *CustomCertificateService) GetCertificate(serviceID string, userID string, isAdmin bool) (dto.CustomCertificateResponse, error) {
+	if _, err := s.getService(serviceID, userID, isAdmin); err != nil {
+		return dto.CustomCertificateResponse{}, err
+	}
+
+	certificate, err := s.certificateRepo.FindByServiceID(serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.CustomCertificateResponse{}, errors.New("no certificate uploaded for this service")
+		}
+		return dto.CustomCertificateResponse{}, err
+	}
+	return buildCertificateResponse(certificate, time.Now()), nil
+}
+
+// UploadCertificate validates a certificate and key for the service's custom domain,
+// stores them as a TLS Secret and switches the custom domain's Ingress to it
+func (s *CustomCertificateService) UploadCertificate(serviceID string, req dto.CustomCertificateRequest, userID string, isAdmin bool) (dto.CustomCertificateResponse, error) {
+	service, err := s.getService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.CustomCertificateResponse{}, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return dto.CustomCertificateResponse{}, errors.New("certificates can only be uploaded for git services")
+	}
+	if service.CustomDomain == "" {
+		return dto.CustomCertificateResponse{}, errors.New("set a custom domain before uploading a certificate")
+	}
+
+	parsed, err := utils.ParseCertificateBundle(req.Certificate, req.PrivateKey, service.CustomDomain)
+	if err != nil {
+		return dto.CustomCertificateResponse{}, err
+	}
+
+	secretName, err := utils.ApplyCustomTLSSecret(service, req.Certificate, req.PrivateKey)
+	if err != nil {
+		return dto.CustomCertificateResponse{}, err
+	}
+
+	certificate, err := s.certificateRepo.FindByServiceID(serviceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.CustomCertificateResponse{}, err
+	}
+	parsed.ID = certificate.ID
+	parsed.CreatedAt = certificate.CreatedAt
+	parsed.ServiceID = serviceID
+	parsed.SecretName = secretName
+	// A new certificate restarts the reminder cycle
+	parsed.LastReminderAt = nil
+
+	certificate, err = s.certificateRepo.Save(parsed)
+	if err != nil {
+		return dto.CustomCertificateResponse{}, fmt.Errorf("failed to save certificate: %v", err)
+	}
+
+	service.CustomTLSSecret = secretName
+	if err := s.serviceRepo.Update(service); err != nil {
+		return dto.CustomCertificateResponse{}, fmt.Errorf("failed to update service: %v", err)
+	}
+	if err := utils.ApplyServiceIngress(service); err != nil {
+		return dto.CustomCertificateResponse{}, fmt.Errorf("certificate stored but ingress update failed: %v", err)
+	}
+	NewDomainPropagationService().Watch(service)
+
+	return buildCertificateResponse(certificate, time.Now()), nil
+}
+
+// DeleteCertificate removes the uploaded certificate; the custom domain goes back to a
+// certificate issued by cert-manager
+func (s *CustomCertificateService) DeleteCertificate(serviceID string, userID string, isAdmin bool) error {
+	service, err := s.getService(serviceID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if service.CustomTLSSecret == "" {
+		return errors.New("no certificate uploaded for this service")
+	}
+
+	service.CustomTLSSecret = ""
+	if err := s.serviceRepo.Update(service); err != nil {
+		return fmt.Errorf("failed to update service: %v", err)
+	}
+	if err := utils.ApplyServiceIngress(service); err != nil {
+		return fmt.Errorf("failed to update ingress: %v", err)
+	}
+	if err := utils.DeleteCustomTLSSecret(service); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return s.certificateRepo.DeleteByServiceID(serviceID)
+}
+
+// StartCertificateExpiryMonitor starts the background loop that sends renewal reminders
+// for uploaded certificates nearing expiry (CERT_RENEWAL_REMINDER_DAYS, default 30)
+func (s *CustomCertificateService) StartCertificateExpiryMonitor() {
+	certificateMonitorOnce.Do(func() {
+		go func() {
+			log.Printf("Certificate expiry monitor started (interval %v, window %d days)", certificateMonitorInterval, getReminderDays())
+			ticker := time.NewTicker(certificateMonitorInterval)
+			defer ticker.Stop()
+
+			s.checkExpiringCertificates()
+			for range ticker.C {
+				s.checkExpiringCertificates()
+			}
+		}()
+	})
+}
+
+// checkExpiringCertificates sends at most one reminder a day per expiring certificate.
+// Reminders are logged and, when CERT_REMINDER_WEBHOOK_URL is set, posted there through the outbox.
+func (s *CustomCertificateService) checkExpiringCertificates() {
+	now := time.Now()
+	certificates, err := s.certificateRepo.FindExpiringBefore(now.AddDate(0, 0, getReminderDays()))
+	if err != nil {
+		log.Printf("Certificate monitor: failed to load certificates: %v", err)
+		return
+	}
+
+	webhookURL := optionalEnvString("CERT_REMINDER_WEBHOOK_URL")
+	for _, certificate := range certificates {
+		if certificate.LastReminderAt != nil && now.Sub(*certificate.LastReminderAt) < certificateReminderEvery {
+			continue
+		}
+
+		service, err := s.serviceRepo.FindByID(certificate.ServiceID)
+		if err != nil {
+			// The service was deleted; its Secret went with it
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.certificateRepo.DeleteByServiceID(certificate.ServiceID)
+			}
+			continue
+		}
+
+		daysRemaining := daysUntil(certificate.NotAfter, now)
+		log.Printf("Certificate for %s (service %s) expires in %d day(s) on %s",
+			certificate.Domain, service.Name, daysRemaining, certificate.NotAfter.Format(time.RFC3339))
+
+		err = s.certificateRepo.DB().Transaction(func(tx *gorm.DB) error {
+			if webhookURL != nil {
+				payload, err := json.Marshal(dto.CertificateExpiryPayload{
+					Event:         models.OutboxEventCertificateExpiring,
+					ServiceID:     service.ID,
+					ServiceName:   service.Name,
+					Domain:        certificate.Domain,
+					NotAfter:      certificate.NotAfter,
+					DaysRemaining: daysRemaining,
+				})
+				if err != nil {
+					return err
+				}
+				if err := s.outboxRepo.Enqueue(tx, models.OutboxEvent{
+					EventType:   models.OutboxEventCertificateExpiring,
+					AggregateID: certificate.ID,
+					Payload:     string(payload),
+					CallbackURL: *webhookURL,
+				}); err != nil {
+					return err
+				}
+			}
+			return s.certificateRepo.MarkRemindedTx(tx, certificate.ID, now)
+		})
+		if err != nil {
+			log.Printf("Certificate monitor: failed to record reminder for %s: %v", certificate.Domain, err)
+			continue
+		}
+		if webhookURL != nil {
+			NotifyOutbox()
+		}
+	}
+}
+
+// getService loads the service and checks access
+func (s *CustomCertificateService) getService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+	return service, nil
+}
+
+func buildCertificateResponse(certificate models.CustomCertificate, now time.Time) dto.CustomCertificateResponse {
+	dnsNames := []string{}
+	if certificate.DNSNames != "" {
+		dnsNames = strings.Split(certificate.DNSNames, ",")
+	}
+	daysRemaining := daysUntil(certificate.NotAfter, now)
+
+	return dto.CustomCertificateResponse{
+		ServiceID:     certificate.ServiceID,
+		Domain:        certificate.Domain,
+		SecretName:    certificate.SecretName,
+		Subject:       certificate.Subject,
+		Issuer:        certificate.Issuer,
+		DNSNames:      dnsNames,
+		Fingerprint:   certificate.Fingerprint,
+		NotBefore:     certificate.NotBefore,
+		NotAfter:      certificate.NotAfter,
+		DaysRemaining: daysRemaining,
+		ExpiringSoon:  daysRemaining <= getReminderDays(),
+		UploadedAt:    certificate.UpdatedAt,
+	}
+}
+
+func daysUntil(t time.Time, now time.Time) int {
+	return int(t.Sub(now).Hours() / 24)
+}
+
+func getReminderDays() int {
+	value := optionalEnvString("CERT_RENEWAL_REMINDER_DAYS")
+	if value == nil {
+		return defaultReminderDays
+	}
+	days, err := strconv.Atoi(*value)
+	if err != nil || days <= 0 {
+		return defaultReminderDays
+	}
+	return days
+}
@@ -0,0 +1,530 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// applyMu serialises declarative applies so concurrent retries of the same request
+// cannot both miss the lookup and create duplicates
+var applyMu sync.Mutex
+
+// AmbiguousNameError reports a name that matches more than one resource
+type AmbiguousNameError struct {
+	Resource string
+	Name     string
+	IDs      []string
+}
+
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf("%d %ss are named %q; manage them by ID instead", len(e.IDs), e.Resource, e.Name)
+}
+
+// ReplacementRequiredError reports fields that cannot change without recreating the resource
+type ReplacementRequiredError struct {
+	Fields []string
+}
+
+func (e *ReplacementRequiredError) Error() string {
+	return fmt.Sprintf("changing %s requires replacing the service", strings.Join(e.Fields, ", "))
+}
+
+// DeclarativeService creates or updates projects, environments and services by name,
+// for infrastructure-as-code tools. Applying the same desired state twice changes nothing.
+type DeclarativeService struct {
+	projectRepo        *repositories.ProjectRepository
+	environmentRepo    *repositories.EnvironmentRepository
+	serviceRepo        *repositories.ServiceRepository
+	projectService     *ProjectService
+	environmentService *EnvironmentService
+	serviceService     *ServiceService
+}
+
+// NewDeclarativeService creates a new declarative service instance
+func NewDeclarativeService() *DeclarativeService {
+	return &DeclarativeService{
+		projectRepo:        repositories.NewProjectRepository(),
+		environmentRepo:    repositories.NewEnvironmentRepository(),
+		serviceRepo:        repositories.NewServiceRepository(),
+		projectService:     NewProjectService(),
+		environmentService: NewEnvironmentService(),
+		serviceService:     NewServiceService(),
+	}
+}
+
+// FindProjectByName returns the caller's project with the given name (import)
+func (s *DeclarativeService) FindProjectByName(name string, userID string) (models.Project, error) {
+	projects, err := s.projectRepo.FindByUserIDAndName(userID, name)
+	if err != nil {
+		return models.Project{}, err
+	}
+	switch len(projects) {
+	case 0:
+		return models.Project{}, gorm.ErrRecordNotFound
+	case 1:
+		return projects[0], nil
+	default:
+		ids := make([]string, 0, len(projects))
+		for _, project := range projects {
+			ids = append(ids, project.ID)
+		}
+		return models.Project{}, &AmbiguousNameError{Resource: "project", Name: name, IDs: ids}
+	}
+}
+
+// FindEnvironmentByName returns the environment of a project with the given name (import)
+func (s *DeclarativeService) FindEnvironmentByName(projectID string, name string, userID string, isAdmin bool) (models.Environment, error) {
+	if _, err := s.projectService.GetProjectDetail(projectID, userID, isAdmin); err != nil {
+		return models.Environment{}, err
+	}
+	return s.environmentRepo.FindByNameAndProject(name, projectID)
+}
+
+// FindServiceByName returns the service of an environment with the given name (import)
+func (s *DeclarativeService) FindServiceByName(environmentID string, name string, userID string, isAdmin bool) (models.Service, error) {
+	if _, err := s.environmentService.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return models.Service{}, err
+	}
+	return s.serviceRepo.FindByNameInEnvironment(name, environmentID)
+}
+
+// ApplyProject creates the caller's project with the given name or updates it to match spec
+func (s *DeclarativeService) ApplyProject(name string, spec dto.ProjectApplyRequest, userID string, isAdmin bool) (models.Project, dto.ApplyResult, error) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	var result dto.ApplyResult
+	if strings.TrimSpace(name) == "" {
+		return models.Project{}, result, utils.FieldErrors{{Field: "name", Message: "must not be blank"}}
+	}
+
+	existing, err := s.FindProjectByName(name, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		project := models.Project{Name: name, UserID: userID, BaseDomain: spec.BaseDomain}
+		if spec.Description != nil {
+			project.Description = *spec.Description
+		}
+		project, err = s.projectService.CreateProject(project)
+		result.Created = err == nil
+		return project, result, err
+	}
+	if err != nil {
+		return models.Project{}, result, err
+	}
+
+	desired := existing
+	if spec.Description != nil && *spec.Description != existing.Description {
+		desired.Description = *spec.Description
+		result.Changed = append(result.Changed, "description")
+	}
+	if spec.BaseDomain != "" {
+		baseDomain, err := resolveBaseDomain(spec.BaseDomain)
+		if err != nil {
+			return existing, result, err
+		}
+		if baseDomain != existing.BaseDomain {
+			desired.BaseDomain = baseDomain
+			result.Changed = append(result.Changed, "baseDomain")
+		}
+	}
+	if len(result.Changed) == 0 {
+		return existing, result, nil
+	}
+
+	project, err := s.projectService.UpdateProject(desired, userID, isAdmin)
+	return project, result, err
+}
+
+// ApplyEnvironment creates the environment of a project with the given name or updates it to match spec
+func (s *DeclarativeService) ApplyEnvironment(projectID string, name string, spec dto.EnvironmentApplyRequest, userID string, isAdmin bool) (models.Environment, dto.ApplyResult, error) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	var result dto.ApplyResult
+	update := dto.EnvironmentUpdateRequest{
+		Name:               name,
+		Description:        spec.Description,
+		DefaultCPULimit:    spec.DefaultCPULimit,
+		DefaultMemoryLimit: spec.DefaultMemoryLimit,
+		DefaultReplicas:    spec.DefaultReplicas,
+		DefaultStorageSize: spec.DefaultStorageSize,
+		MinCPULimit:        spec.MinCPULimit,
+		MaxCPULimit:        spec.MaxCPULimit,
+		MinMemoryLimit:     spec.MinMemoryLimit,
+		MaxMemoryLimit:     spec.MaxMemoryLimit,
+		MaxStorageSize:     spec.MaxStorageSize,
+	}
+	if err := utils.ValidateEnvironmentUpdateRequest(update); err != nil {
+		return models.Environment{}, result, err
+	}
+	if strings.TrimSpace(name) == "" {
+		return models.Environment{}, result, utils.FieldErrors{{Field: "name", Message: "must not be blank"}}
+	}
+
+	existing, err := s.FindEnvironmentByName(projectID, name, userID, isAdmin)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		env := models.Environment{Name: name, ProjectID: projectID}
+		if spec.Description != nil {
+			env.Description = *spec.Description
+		}
+		if spec.DefaultCPULimit != nil {
+			env.DefaultCPULimit = *spec.DefaultCPULimit
+		}
+		if spec.DefaultMemoryLimit != nil {
+			env.DefaultMemoryLimit = *spec.DefaultMemoryLimit
+		}
+		if spec.DefaultReplicas != nil {
+			env.DefaultReplicas = *spec.DefaultReplicas
+		}
+		for _, field := range environmentPresetFields(spec, &env, &dto.EnvironmentUpdateRequest{}) {
+			if field.value != nil {
+				*field.current = *field.value
+			}
+		}
+		env, err = s.environmentService.CreateEnvironment(env, userID, isAdmin)
+		result.Created = err == nil
+		return env, result, err
+	}
+	if err != nil {
+		return models.Environment{}, result, err
+	}
+
+	// Only send the fields that differ, so an unchanged spec writes nothing
+	changes := dto.EnvironmentUpdateRequest{}
+	if spec.Description != nil && *spec.Description != existing.Description {
+		changes.Description = spec.Description
+		result.Changed = append(result.Changed, "description")
+	}
+	if spec.DefaultCPULimit != nil && *spec.DefaultCPULimit != existing.DefaultCPULimit {
+		changes.DefaultCPULimit = spec.DefaultCPULimit
+		result.Changed = append(result.Changed, "defaultCpuLimit")
+	}
+	if spec.DefaultMemoryLimit != nil && *spec.DefaultMemoryLimit != existing.DefaultMemoryLimit {
+		changes.DefaultMemoryLimit = spec.DefaultMemoryLimit
+		result.Changed = append(result.Changed, "defaultMemoryLimit")
+	}
+	if spec.DefaultReplicas != nil && *spec.DefaultReplicas != existing.DefaultReplicas {
+		changes.DefaultReplicas = spec.DefaultReplicas
+		result.Changed = append(result.Changed, "defaultReplicas")
+	}
+	for _, field := range environmentPresetFields(spec, &existing, &changes) {
+		if field.value != nil && *field.value != *field.current {
+			*field.change = field.value
+			result.Changed = append(result.Changed, field.name)
+		}
+	}
+	if len(result.Changed) == 0 {
+		return existing, result, nil
+	}
+
+	env, err := s.environmentService.UpdateEnvironment(existing.ID, changes, userID, isAdmin)
+	return env, result, err
+}
+
+// ApplyService creates the service of an environment with the given name or updates it to
+// match spec. An unchanged spec neither writes nor redeploys.
+func (s *DeclarativeService) ApplyService(environmentID string, name string, spec dto.ServiceApplyRequest, userID string, isAdmin bool) (models.Service, dto.ApplyResult, error) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	var result dto.ApplyResult
+	env, err := s.environmentService.GetEnvironmentDetail(environmentID, userID, isAdmin)
+	if err != nil {
+		return models.Service{}, result, err
+	}
+
+	existing, err := s.serviceRepo.FindByNameInEnvironment(name, environmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		service := applyServiceSpec(models.Service{Name: name, Type: spec.Type, ProjectID: env.ProjectID, EnvironmentID: environmentID}, spec)
+		service.IsPublic = spec.IsPublic
+		service.GitUsername = spec.GitUsername
+		service.GitToken = spec.GitToken
+		if spec.DeletionProtected != nil {
+			service.DeletionProtected = *spec.DeletionProtected
+		}
+		if spec.IsStaticReplica == nil {
+			service.IsStaticReplica = true
+		}
+		if err := utils.ValidateServiceRequest(serviceToRequest(service)); err != nil {
+			return service, result, err
+		}
+		if err := s.checkPlacement(service); err != nil {
+			return service, result, err
+		}
+
+		service, err = s.serviceService.CreateService(service, userID, isAdmin)
+		result.Created = err == nil
+		return service, result, err
+	}
+	if err != nil {
+		return models.Service{}, result, err
+	}
+
+	var immutable []string
+	if spec.Type != existing.Type {
+		immutable = append(immutable, "type")
+	}
+	if spec.RepoURL != "" && spec.RepoURL != existing.RepoURL {
+		immutable = append(immutable, "repoUrl")
+	}
+	if spec.ManagedType != "" && spec.ManagedType != existing.ManagedType {
+		immutable = append(immutable, "managedType")
+	}
+	if len(immutable) > 0 {
+		return existing, result, &ReplacementRequiredError{Fields: immutable}
+	}
+
+	desired := applyServiceSpec(existing, spec)
+	result.Changed = diffServiceFields(existing, desired)
+	protectionChanged := spec.DeletionProtected != nil && *spec.DeletionProtected != existing.DeletionProtected
+	if protectionChanged {
+		result.Changed = append(result.Changed, "deletionProtected")
+	}
+	if len(result.Changed) == 0 {
+		return existing, result, nil
+	}
+
+	service := existing
+	if len(result.Changed) > 1 || !protectionChanged {
+		check := desired
+		if check.Type == models.ServiceTypeManaged {
+			// Auto-generated, not part of the request
+			check.EnvVars = nil
+		}
+		if err := utils.ValidateServiceRequest(serviceToRequest(check)); err != nil {
+			return existing, result, err
+		}
+		if err := s.checkPlacement(desired); err != nil {
+			return existing, result, err
+		}
+		if service, err = s.serviceService.UpdateService(desired, userID, isAdmin); err != nil {
+			return existing, result, err
+		}
+	}
+	if protectionChanged {
+		if service, err = s.serviceService.SetDeletionProtection(existing.ID, *spec.DeletionProtected, userID, isAdmin); err != nil {
+			return existing, result, err
+		}
+	}
+	return service, result, nil
+}
+
+// checkPlacement rejects a service whose resources cannot be scheduled
+func (s *DeclarativeService) checkPlacement(service models.Service) error {
+	placement, err := s.serviceService.CheckPlacement(service)
+	if err != nil {
+		return err
+	}
+	if !placement.Schedulable {
+		return fmt.Errorf("insufficient cluster capacity: %s", placement.Reason)
+	}
+	return nil
+}
+
+// applyServiceSpec overlays the fields present in spec onto service
+func applyServiceSpec(service models.Service, spec dto.ServiceApplyRequest) models.Service {
+	setString := func(target *string, value string) {
+		if value != "" {
+			*target = value
+		}
+	}
+	setInt := func(target *int, value int) {
+		if value > 0 {
+			*target = value
+		}
+	}
+
+	setString(&service.RepoURL, spec.RepoURL)
+	setString(&service.Branch, spec.Branch)
+	setInt(&service.Port, spec.Port)
+	setString(&service.BuildCommand, spec.BuildCommand)
+	setString(&service.StartCommand, spec.StartCommand)
+	setString(&service.TLSChallenge, spec.TLSChallenge)
+
+	setString(&service.ManagedType, spec.ManagedType)
+	setString(&service.Version, spec.Version)
+	setString(&service.StorageSize, spec.StorageSize)
+	if spec.PoolingEnabled != nil {
+		service.PoolingEnabled = *spec.PoolingEnabled
+	}
+	setString(&service.PoolMode, spec.PoolMode)
+	setInt(&service.PoolSize, spec.PoolSize)
+	setInt(&service.MaxClientConn, spec.MaxClientConn)
+	if spec.VPAMode != nil {
+		service.VPAMode = *spec.VPAMode
+	}
+
+	if spec.EnvVars != nil && service.Type == models.ServiceTypeGit {
+		service.EnvVars = spec.EnvVars
+	}
+	setString(&service.CPULimit, spec.CPULimit)
+	setString(&service.MemoryLimit, spec.MemoryLimit)
+	if spec.IsStaticReplica != nil {
+		service.IsStaticReplica = *spec.IsStaticReplica
+	}
+	setInt(&service.Replicas, spec.Replicas)
+	setInt(&service.MinReplicas, spec.MinReplicas)
+	setInt(&service.MaxReplicas, spec.MaxReplicas)
+	if spec.HighAvailability != nil {
+		service.HighAvailability = *spec.HighAvailability
+	}
+	if spec.AutoCorrectPort != nil {
+		service.AutoCorrectPort = *spec.AutoCorrectPort
+	}
+	setString(&service.CustomDomain, spec.CustomDomain)
+	if spec.ServiceAccountAnnotations != nil {
+		service.ServiceAccountAnnotations = spec.ServiceAccountAnnotations
+	}
+	if spec.PodLabels != nil {
+		service.PodLabels = spec.PodLabels
+	}
+	if spec.PodAnnotations != nil {
+		service.PodAnnotations = spec.PodAnnotations
+	}
+	return service
+}
+
+// environmentPresetField links a resource preset of an environment spec to the
+// environment's value and to the update request field that changes it
+type environmentPresetField struct {
+	name    string
+	value   *string // desired value; nil keeps the current one
+	current *string
+	change  **string
+}
+
+func environmentPresetFields(spec dto.EnvironmentApplyRequest, env *models.Environment, changes *dto.EnvironmentUpdateRequest) []environmentPresetField {
+	return []environmentPresetField{
+		{"defaultStorageSize", spec.DefaultStorageSize, &env.DefaultStorageSize, &changes.DefaultStorageSize},
+		{"minCpuLimit", spec.MinCPULimit, &env.MinCPULimit, &changes.MinCPULimit},
+		{"maxCpuLimit", spec.MaxCPULimit, &env.MaxCPULimit, &changes.MaxCPULimit},
+		{"minMemoryLimit", spec.MinMemoryLimit, &env.MinMemoryLimit, &changes.MinMemoryLimit},
+		{"maxMemoryLimit", spec.MaxMemoryLimit, &env.MaxMemoryLimit, &changes.MaxMemoryLimit},
+		{"maxStorageSize", spec.MaxStorageSize, &env.MaxStorageSize, &changes.MaxStorageSize},
+	}
+}
+
+// diffServiceFields lists the JSON names of the updatable fields that differ
+func diffServiceFields(current, desired models.Service) []string {
+	fields := []struct {
+		name           string
+		current, value interface{}
+	}{
+		{"branch", current.Branch, desired.Branch},
+		{"port", current.Port, desired.Port},
+		{"buildCommand", current.BuildCommand, desired.BuildCommand},
+		{"startCommand", current.StartCommand, desired.StartCommand},
+		{"tlsChallenge", current.TLSChallenge, desired.TLSChallenge},
+		{"version", current.Version, desired.Version},
+		{"storageSize", current.StorageSize, desired.StorageSize},
+		{"poolingEnabled", current.PoolingEnabled, desired.PoolingEnabled},
+		{"poolMode", current.PoolMode, desired.PoolMode},
+		{"poolSize", current.PoolSize, desired.PoolSize},
+		{"maxClientConn", current.MaxClientConn, desired.MaxClientConn},
+		{"vpaMode", current.VPAMode, desired.VPAMode},
+		{"envVars", current.EnvVars, desired.EnvVars},
+		{"cpuLimit", current.CPULimit, desired.CPULimit},
+		{"memoryLimit", current.MemoryLimit, desired.MemoryLimit},
+		{"isStaticReplica", current.IsStaticReplica, desired.IsStaticReplica},
+		{"replicas", current.Replicas, desired.Replicas},
+		{"minReplicas", current.MinReplicas, desired.MinReplicas},
+		{"maxReplicas", current.MaxReplicas, desired.MaxReplicas},
+		{"highAvailability", current.HighAvailability, desired.HighAvailability},
+		{"autoCorrectPort", current.AutoCorrectPort, desired.AutoCorrectPort},
+		{"customDomain", current.CustomDomain, desired.CustomDomain},
+		{"serviceAccountAnnotations", current.ServiceAccountAnnotations, desired.ServiceAccountAnnotations},
+		{"podLabels", current.PodLabels, desired.PodLabels},
+		{"podAnnotations", current.PodAnnotations, desired.PodAnnotations},
+	}
+
+	var changed []string
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.current, field.value) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// serviceToRequest converts a service to a creation request for field validation
+func serviceToRequest(service models.Service) dto.ServiceRequest {
+	return dto.ServiceRequest{
+		Name:                          service.Name,
+		Type:                          service.Type,
+		ProjectID:                     service.ProjectID,
+		EnvironmentID:                 service.EnvironmentID,
+		RepoURL:                       service.RepoURL,
+		Branch:                        service.Branch,
+		IsPublic:                      service.IsPublic,
+		GitUsername:                   service.GitUsername,
+		GitToken:                      service.GitToken,
+		Port:                          service.Port,
+		BuildCommand:                  service.BuildCommand,
+		StartCommand:                  service.StartCommand,
+		ArtifactPath:                  service.ArtifactPath,
+		BuildPlatforms:                splitList(service.BuildPlatforms),
+		BuildBackend:                  service.BuildBackend,
+		BuildSecrets:                  service.BuildSecrets,
+		CloneDepth:                    service.CloneDepth,
+		ManagedType:                   service.ManagedType,
+		Version:                       service.Version,
+		StorageSize:                   service.StorageSize,
+		PoolingEnabled:                service.PoolingEnabled,
+		PoolMode:                      service.PoolMode,
+		PoolSize:                      service.PoolSize,
+		MaxClientConn:                 service.MaxClientConn,
+		VPAMode:                       service.VPAMode,
+		EnvVars:                       service.EnvVars,
+		SecretEnvKeys:                 splitList(service.SecretEnvKeys),
+		BuildEnvKeys:                  splitList(service.BuildEnvKeys),
+		CPULimit:                      service.CPULimit,
+		MemoryLimit:                   service.MemoryLimit,
+		IsStaticReplica:               service.IsStaticReplica,
+		Replicas:                      service.Replicas,
+		MinReplicas:                   service.MinReplicas,
+		MaxReplicas:                   service.MaxReplicas,
+		HighAvailability:              service.HighAvailability,
+		TerminationGracePeriodSeconds: service.TerminationGracePeriodSeconds,
+		PreStopCommand:                service.PreStopCommand,
+		Command:                       service.Command,
+		Args:                          service.Args,
+		WorkingDir:                    service.WorkingDir,
+		CustomDomain:                  service.CustomDomain,
+		TLSChallenge:                  service.TLSChallenge,
+		DeletionProtected:             service.DeletionProtected,
+
+		ServiceAccountAnnotations: service.ServiceAccountAnnotations,
+		PodLabels:                 service.PodLabels,
+		PodAnnotations:            service.PodAnnotations,
+		SparseCheckoutPaths:       splitList(service.SparseCheckoutPaths),
+		BuildTimeoutMinutes:       service.BuildTimeoutMinutes,
+		MaxImageSize:              service.MaxImageSize,
+		ImageSizeBudgetAction:     service.ImageSizeBudgetAction,
+		DockerfilePath:            service.DockerfilePath,
+		BuildArgs:                 service.BuildArgs,
+		TestCommand:               service.TestCommand,
+		TestImage:                 service.TestImage,
+		ExternalAllowedCIDRs:      splitList(service.ExternalAllowedCIDRs),
+		DatabaseTLS:               service.DatabaseTLS,
+		Topology:                  service.Topology,
+		AutoUpdate:                service.AutoUpdate,
+		MaintenanceWindow:         service.MaintenanceWindow,
+	}
+}
+
+// splitList splits a comma-separated field of a service; empty means none
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
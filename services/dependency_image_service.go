@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// Dependency image errors the API maps to client errors
+var (
+	ErrDependencyImageNotFound    = errors.New("dependency image not found")
+	ErrDependencyImageNoCandidate = errors.New("the dependency image has no update with that digest waiting for review")
+)
+
+// DependencyImageService lets admins review updates of the pinned dependency images every
+// registry holds and roll approved ones out across registries
+type DependencyImageService struct {
+	depRepo      *repositories.DependencyImageRepository
+	registryRepo *repositories.RegistryRepository
+	depService   *RegistryDependencyService
+}
+
+// NewDependencyImageService creates a new dependency image service instance
+func NewDependencyImageService() *DependencyImageService {
+	return &DependencyImageService{
+		depRepo:      repositories.NewDependencyImageRepository(),
+		registryRepo: repositories.NewRegistryRepository(),
+		depService:   NewRegistryDependencyService(),
+	}
+}
+
+// ListImages returns every dependency image with its pin, pending update and rollout state
+func (s *DependencyImageService) ListImages() (dto.DependencyImageListResponse, error) {
+	pins, err := s.depRepo.FindPins()
+	if err != nil {
+		return dto.DependencyImageListResponse{}, err
+	}
+	pinned := make(map[string]models.DependencyImagePin, len(pins))
+	for _, pin := range pins {
+		pinned[pin.Name] = pin
+	}
+	registries, err := s.pushRegistries()
+	if err != nil {
+		return dto.DependencyImageListResponse{}, err
+	}
+	built, err := s.depRepo.FindBuilt()
+	if err != nil {
+		return dto.DependencyImageListResponse{}, err
+	}
+	builtByRegistry := map[string]map[string]models.RegistryDependencyImage{}
+	for _, image := range built {
+		if builtByRegistry[image.RegistryID] == nil {
+			builtByRegistry[image.RegistryID] = map[string]models.RegistryDependencyImage{}
+		}
+		builtByRegistry[image.RegistryID][image.Name] = image
+	}
+
+	response := dto.DependencyImageListResponse{Images: []dto.DependencyImageStatus{}}
+	for _, img := range s.depService.GetRequiredImages() {
+		status := dto.DependencyImageStatus{
+			Name:        img.Name,
+			Description: img.Description,
+			SourceImage: img.SourceImage,
+			Digest:      img.Digest,
+			Registries:  []dto.DependencyImageRegistryStatus{},
+		}
+		if pin, ok := pinned[img.Name]; ok {
+			pinnedAt := pin.PinnedAt
+			status.PinnedBy = pin.PinnedBy
+			status.PinnedAt = &pinnedAt
+			status.CandidateImage = pin.CandidateImage
+			status.CandidateDigest = pin.CandidateDigest
+			status.CandidateFoundAt = pin.CandidateFoundAt
+		}
+		for _, registry := range registries {
+			registryStatus := dto.DependencyImageRegistryStatus{
+				RegistryID:   registry.ID,
+				RegistryName: registry.Name,
+				UpToDate:     !IsDependencyOutdated(img, builtByRegistry[registry.ID]),
+			}
+			if image, ok := builtByRegistry[registry.ID][img.Name]; ok {
+				builtAt := image.BuiltAt
+				registryStatus.Digest = image.Digest
+				registryStatus.BuiltAt = &builtAt
+			}
+			status.Registries = append(status.Registries, registryStatus)
+		}
+		response.Images = append(response.Images, status)
+	}
+	return response, nil
+}
+
+// CheckForUpdates resolves the source tag of every dependency image. Images not pinned yet
+// are pinned to the current digest; a tag that moved since it was pinned is offered as an
+// update for review unless another update is waiting.
+func (s *DependencyImageService) CheckForUpdates() (dto.DependencyImageListResponse, error) {
+	var checkErrors []string
+	for _, img := range s.depService.GetRequiredImages() {
+		current, err := utils.ResolveImageDigest(img.SourceImage)
+		if err != nil {
+			checkErrors = append(checkErrors, fmt.Sprintf("%s: %v", img.Name, err))
+			continue
+		}
+		if img.Digest == "" {
+			if _, err := s.depRepo.CreatePin(models.DependencyImagePin{
+				Name:        img.Name,
+				SourceImage: img.SourceImage,
+				Digest:      current,
+				PinnedAt:    time.Now(),
+			}); err != nil {
+				return dto.DependencyImageListResponse{}, err
+			}
+			log.Printf("Pinned dependency image %s to %s@%s", img.Name, img.SourceImage, current)
+			continue
+		}
+		if current == img.Digest {
+			continue
+		}
+		offered, err := s.depRepo.OfferCandidate(img.Name, img.SourceImage, current, time.Now())
+		if err != nil {
+			return dto.DependencyImageListResponse{}, err
+		}
+		if offered {
+			log.Printf("Dependency image %s: %s moved to %s, waiting for review", img.Name, img.SourceImage, current)
+		}
+	}
+
+	response, err := s.ListImages()
+	response.Errors = checkErrors
+	return response, err
+}
+
+// ProposeUpdate offers another tag of a dependency image's source for review, replacing the
+// update waiting for review. The tag must be of the same repository as the pinned source.
+func (s *DependencyImageService) ProposeUpdate(name string, req dto.DependencyImageUpdateRequest) (dto.DependencyImageStatus, error) {
+	img, err := s.findImage(name)
+	if err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+
+	var errs utils.FieldErrors
+	sourceImage := strings.TrimSpace(req.SourceImage)
+	if strings.Contains(sourceImage, "@") {
+		errs.Add("sourceImage", "must be a tag, the digest is resolved from it")
+	} else if imageRepository(sourceImage) != imageRepository(img.SourceImage) {
+		errs.Add("sourceImage", "must be a tag of %s", imageRepository(img.SourceImage))
+	}
+	if err := errs.Err(); err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	digest, err := utils.ResolveImageDigest(sourceImage)
+	if err != nil {
+		errs.Add("sourceImage", "could not be resolved: %v", err)
+		return dto.DependencyImageStatus{}, errs.Err()
+	}
+	if digest == img.Digest {
+		errs.Add("sourceImage", "resolves to the pinned digest %s", digest)
+		return dto.DependencyImageStatus{}, errs.Err()
+	}
+
+	if img.Digest == "" {
+		// The update is reviewed against the current source, so that is pinned first
+		if err := s.pinCurrent(img); err != nil {
+			return dto.DependencyImageStatus{}, err
+		}
+	}
+	if err := s.depRepo.SetCandidate(img.Name, sourceImage, digest, time.Now()); err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	log.Printf("Dependency image %s: update to %s@%s proposed", img.Name, sourceImage, digest)
+	return s.imageStatus(img.Name)
+}
+
+// ApproveUpdate pins a dependency image to the update waiting for review. Registries keep
+// their copies until the update is rolled out.
+func (s *DependencyImageService) ApproveUpdate(name string, req dto.DependencyImageApproveRequest, userID string) (dto.DependencyImageStatus, error) {
+	img, err := s.findImage(name)
+	if err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	approved, err := s.depRepo.ApproveCandidate(img.Name, req.Digest, userID, time.Now())
+	if err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	if !approved {
+		return dto.DependencyImageStatus{}, ErrDependencyImageNoCandidate
+	}
+	log.Printf("Dependency image %s pinned to %s by %s", img.Name, req.Digest, userID)
+	return s.imageStatus(img.Name)
+}
+
+// DismissUpdate drops the update waiting for review. A tag that moved is offered again the
+// next time it is checked.
+func (s *DependencyImageService) DismissUpdate(name string) (dto.DependencyImageStatus, error) {
+	img, err := s.findImage(name)
+	if err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	if err := s.depRepo.ClearCandidate(img.Name); err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	return s.imageStatus(img.Name)
+}
+
+// RollOut rebuilds the dependency images not built from their pinned digest in every ready
+// registry that stores pushed images. Registries already building are skipped; builds run
+// in the background, one registry after another.
+func (s *DependencyImageService) RollOut(req dto.DependencyImageRolloutRequest) (dto.DependencyImageRolloutResponse, error) {
+	response := dto.DependencyImageRolloutResponse{
+		Registries: []dto.DependencyImageRollout{},
+		Skipped:    []dto.DependencyImageRolloutSkip{},
+	}
+
+	images := s.depService.GetRequiredImages()
+	if len(req.Names) > 0 {
+		known := make(map[string]bool, len(images))
+		for _, img := range images {
+			known[img.Name] = true
+		}
+		var errs utils.FieldErrors
+		for _, name := range req.Names {
+			if !known[name] {
+				errs.Add("names", "%q is not a dependency image", name)
+			}
+		}
+		if err := errs.Err(); err != nil {
+			return response, err
+		}
+	}
+	wanted := make(map[string]bool, len(req.Names))
+	for _, name := range req.Names {
+		wanted[name] = true
+	}
+
+	registries, err := s.pushRegistries()
+	if err != nil {
+		return response, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var rollouts []models.Registry
+	outdatedByRegistry := map[string][]string{}
+	for _, registry := range registries {
+		skip := dto.DependencyImageRolloutSkip{RegistryID: registry.ID, RegistryName: registry.Name}
+		if registry.Status != models.RegistryStatusReady || registry.URL == "" {
+			skip.Reason = ErrRegistryBusy.Error()
+			response.Skipped = append(response.Skipped, skip)
+			continue
+		}
+		built, err := s.depService.BuiltImages(registry.ID)
+		if err != nil {
+			return response, err
+		}
+		var outdated []string
+		for _, img := range images {
+			if (len(wanted) == 0 || wanted[img.Name]) && IsDependencyOutdated(img, built) {
+				outdated = append(outdated, img.Name)
+			}
+		}
+		if len(outdated) == 0 {
+			continue
+		}
+		running, err := s.depService.HasRunningBuilds(ctx, registry.ID)
+		if err != nil {
+			return response, err
+		}
+		if running {
+			skip.Reason = ErrDependencyBuildRunning.Error()
+			response.Skipped = append(response.Skipped, skip)
+			continue
+		}
+
+		rollouts = append(rollouts, registry)
+		outdatedByRegistry[registry.ID] = outdated
+		response.Registries = append(response.Registries, dto.DependencyImageRollout{
+			RegistryID:   registry.ID,
+			RegistryName: registry.Name,
+			Rebuilding:   outdated,
+		})
+	}
+	if len(rollouts) == 0 {
+		return response, nil
+	}
+
+	go func() {
+		for _, registry := range rollouts {
+			outdated := outdatedByRegistry[registry.ID]
+			buildCtx, cancel := context.WithTimeout(context.Background(), time.Duration(len(outdated))*dependencyBuildTimeout)
+			err := s.depService.RebuildDependencies(buildCtx, registry, outdated)
+			cancel()
+			if err != nil {
+				log.Printf("Rolling out dependency images to registry %s failed: %v", registry.ID, err)
+				continue
+			}
+			log.Printf("Rolled out dependency images %s to registry %s", strings.Join(outdated, ", "), registry.ID)
+		}
+	}()
+	return response, nil
+}
+
+// findImage returns a dependency image with its pin
+func (s *DependencyImageService) findImage(name string) (DependencyImage, error) {
+	for _, img := range s.depService.GetRequiredImages() {
+		if img.Name == name {
+			return img, nil
+		}
+	}
+	return DependencyImage{}, ErrDependencyImageNotFound
+}
+
+// pinCurrent pins a dependency image to the digest its source has now
+func (s *DependencyImageService) pinCurrent(img DependencyImage) error {
+	digest, err := utils.ResolveImageDigest(img.SourceImage)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", img.SourceImage, err)
+	}
+	_, err = s.depRepo.CreatePin(models.DependencyImagePin{
+		Name:        img.Name,
+		SourceImage: img.SourceImage,
+		Digest:      digest,
+		PinnedAt:    time.Now(),
+	})
+	return err
+}
+
+// imageStatus returns the status of one dependency image
+func (s *DependencyImageService) imageStatus(name string) (dto.DependencyImageStatus, error) {
+	response, err := s.ListImages()
+	if err != nil {
+		return dto.DependencyImageStatus{}, err
+	}
+	for _, status := range response.Images {
+		if status.Name == name {
+			return status, nil
+		}
+	}
+	return dto.DependencyImageStatus{}, ErrDependencyImageNotFound
+}
+
+// pushRegistries returns the registries dependency images are built into; pull-through
+// caches cannot hold them
+func (s *DependencyImageService) pushRegistries() ([]models.Registry, error) {
+	registries, err := s.registryRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	var push []models.Registry
+	for _, registry := range registries {
+		if registry.Mode != models.RegistryModeProxy {
+			push = append(push, registry)
+		}
+	}
+	return push, nil
+}
+
+// imageRepository strips the tag and digest of an image reference
+func imageRepository(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image
+}
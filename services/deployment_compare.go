@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ErrImageLayersNotRecorded is returned when a deployment's image was not inspected, e.g.
+// because its build failed or predates layer recording
+var ErrImageLayersNotRecorded = errors.New("image layers were not recorded for this deployment")
+
+// CompareDeployments compares the images of two deployments of a service: the size delta
+// and the layers that differ, b relative to a
+func (s *ServiceService) CompareDeployments(serviceID, deploymentA, deploymentB string, userID string, isAdmin bool) (dto.DeploymentComparison, error) {
+	var comparison dto.DeploymentComparison
+
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return comparison, err
+	}
+	a, err := s.findServiceDeployment(serviceID, deploymentA)
+	if err != nil {
+		return comparison, err
+	}
+	b, err := s.findServiceDeployment(serviceID, deploymentB)
+	if err != nil {
+		return comparison, err
+	}
+
+	comparison.A = deploymentImageSummary(a)
+	comparison.B = deploymentImageSummary(b)
+	comparison.SizeDelta = b.ImageSize - a.ImageSize
+	if a.ImageSize > 0 {
+		comparison.SizeDeltaPercent = float64(comparison.SizeDelta) / float64(a.ImageSize) * 100
+	}
+	comparison.ChangedLayers, comparison.UnchangedLayers = utils.CompareImageLayers(a.ImageLayers, b.ImageLayers)
+	return comparison, nil
+}
+
+// findServiceDeployment returns a deployment of the service whose image layers were recorded
+func (s *ServiceService) findServiceDeployment(serviceID, deploymentID string) (models.Deployment, error) {
+	deployment, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil || deployment.ServiceID != serviceID {
+		return deployment, fmt.Errorf("deployment %s not found for this service", deploymentID)
+	}
+	if len(deployment.ImageLayers) == 0 {
+		return deployment, fmt.Errorf("deployment %s: %w", deploymentID, ErrImageLayersNotRecorded)
+	}
+	return deployment, nil
+}
+
+func deploymentImageSummary(deployment models.Deployment) dto.DeploymentImageSummary {
+	return dto.DeploymentImageSummary{
+		DeploymentID: deployment.ID,
+		CommitSHA:    deployment.CommitSHA,
+		Image:        deployment.Image,
+		ImageDigest:  deployment.ImageDigest,
+		ImageSize:    deployment.ImageSize,
+		LayerCount:   len(deployment.ImageLayers),
+		CreatedAt:    deployment.CreatedAt,
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+)
+
+const (
+	// reportDayLayout is how rollup days are written, in UTC
+	reportDayLayout = "2006-01-02"
+	// reportRollupDelay is how long after the end of a day its rollup is computed, so the
+	// deployments started just before midnight are finished
+	reportRollupDelay = time.Hour
+	// reportBackfillDays is how many past days missing a rollup are computed; usage
+	// samples are not kept longer by default
+	reportBackfillDays = 30
+	// defaultReportDays is the range of a report without dates
+	defaultReportDays = 30
+	// maxReportDays bounds the range of a report
+	maxReportDays = 366
+	// reportTopConsumers is how many services are kept per resource
+	reportTopConsumers = 10
+)
+
+var reportRollupOnce sync.Once
+
+// DeploymentReportService rolls deployments and usage samples up into daily platform-wide
+// statistics every night and reports them over date ranges
+type DeploymentReportService struct {
+	reportRepo *repositories.DeploymentReportRepository
+}
+
+// NewDeploymentReportService creates a new deployment report service instance
+func NewDeploymentReportService() *DeploymentReportService {
+	return &DeploymentReportService{
+		reportRepo: repositories.NewDeploymentReportRepository(),
+	}
+}
+
+// StartRollupJob starts the background loop computing the rollup of each day once it is
+// over, and of the past days still missing one
+func (s *DeploymentReportService) StartRollupJob() {
+	reportRollupOnce.Do(func() {
+		go func() {
+			log.Printf("Deployment report rollup started")
+			s.rollupMissingDays()
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.rollupMissingDays()
+			}
+		}()
+	})
+}
+
+// rollupMissingDays computes the rollups of the finished days of the backfill window that
+// do not have one
+func (s *DeploymentReportService) rollupMissingDays() {
+	last := time.Now().UTC().Add(-reportRollupDelay).Truncate(24*time.Hour).AddDate(0, 0, -1)
+	first := last.AddDate(0, 0, -(reportBackfillDays - 1))
+
+	existing, err := s.reportRepo.FindRollupDays(first.Format(reportDayLayout), last.Format(reportDayLayout))
+	if err != nil {
+		log.Printf("Deployment report rollup: failed to list rollups: %v", err)
+		return
+	}
+	done := make(map[string]bool, len(existing))
+	for _, day := range existing {
+		done[day] = true
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if done[day.Format(reportDayLayout)] {
+			continue
+		}
+		if _, err := s.RollupDay(day); err != nil {
+			log.Printf("Deployment report rollup of %s failed: %v", day.Format(reportDayLayout), err)
+		}
+	}
+}
+
+// RollupDay computes and stores the statistics of a UTC day, replacing an earlier rollup
+func (s *DeploymentReportService) RollupDay(day time.Time) (models.DeploymentStatsRollup, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	rollup := models.DeploymentStatsRollup{
+		Day:             from.Format(reportDayLayout),
+		FailuresByClass: models.FailureClassCounts{},
+	}
+
+	outcomes, err := s.reportRepo.CountDeploymentOutcomes(from, to)
+	if err != nil {
+		return rollup, fmt.Errorf("failed to count deployments: %v", err)
+	}
+	rollup.Deployments, rollup.Succeeded, rollup.Failed = outcomes.Deployments, outcomes.Succeeded, outcomes.Failed
+
+	builds, err := s.reportRepo.SumBuilds(from, to)
+	if err != nil {
+		return rollup, fmt.Errorf("failed to sum builds: %v", err)
+	}
+	rollup.Builds, rollup.AverageBuildMs = builds.Builds, int64(math.Round(builds.AverageBuildMs))
+
+	failures, err := s.reportRepo.CountFailuresByClass(from, to)
+	if err != nil {
+		return rollup, fmt.Errorf("failed to count failures: %v", err)
+	}
+	for _, failure := range failures {
+		rollup.FailuresByClass[failure.FailureClass] = failure.Count
+	}
+
+	if rollup.TopCPUConsumers, err = s.reportRepo.FindTopConsumers(from, to, false, reportTopConsumers); err != nil {
+		return rollup, fmt.Errorf("failed to rank CPU consumers: %v", err)
+	}
+	if rollup.TopMemoryConsumers, err = s.reportRepo.FindTopConsumers(from, to, true, reportTopConsumers); err != nil {
+		return rollup, fmt.Errorf("failed to rank memory consumers: %v", err)
+	}
+
+	rollup.ComputedAt = time.Now()
+	if err := s.reportRepo.SaveRollup(rollup); err != nil {
+		return rollup, fmt.Errorf("failed to save rollup: %v", err)
+	}
+	return rollup, nil
+}
+
+// RecomputeDay recomputes the rollup of a finished day (YYYY-MM-DD)
+func (s *DeploymentReportService) RecomputeDay(day string) (models.DeploymentStatsRollup, error) {
+	parsed, err := parseReportDay(day)
+	if err != nil {
+		return models.DeploymentStatsRollup{}, err
+	}
+	if !parsed.AddDate(0, 0, 1).Before(time.Now()) {
+		return models.DeploymentStatsRollup{}, fmt.Errorf("day %s is not over yet", day)
+	}
+	return s.RollupDay(parsed)
+}
+
+// GetReport returns the daily rollups of the days from through to (YYYY-MM-DD, inclusive)
+// with their totals. Without dates the last 30 finished days are reported.
+func (s *DeploymentReportService) GetReport(from, to string) (dto.DeploymentReport, error) {
+	toDay := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if to != "" {
+		parsed, err := parseReportDay(to)
+		if err != nil {
+			return dto.DeploymentReport{}, err
+		}
+		toDay = parsed
+	}
+	fromDay := toDay.AddDate(0, 0, -(defaultReportDays - 1))
+	if from != "" {
+		parsed, err := parseReportDay(from)
+		if err != nil {
+			return dto.DeploymentReport{}, err
+		}
+		fromDay = parsed
+	}
+	if fromDay.After(toDay) {
+		return dto.DeploymentReport{}, fmt.Errorf("from must not be after to")
+	}
+	days := int(toDay.Sub(fromDay).Hours()/24) + 1
+	if days > maxReportDays {
+		return dto.DeploymentReport{}, fmt.Errorf("date range must not exceed %d days", maxReportDays)
+	}
+
+	rollups, err := s.reportRepo.FindRollups(fromDay.Format(reportDayLayout), toDay.Format(reportDayLayout))
+	if err != nil {
+		return dto.DeploymentReport{}, err
+	}
+
+	report := dto.DeploymentReport{
+		From:        fromDay.Format(reportDayLayout),
+		To:          toDay.Format(reportDayLayout),
+		Summary:     summarizeRollups(rollups, days),
+		Days:        rollups,
+		MissingDays: []string{},
+	}
+	found := make(map[string]bool, len(rollups))
+	for _, rollup := range rollups {
+		found[rollup.Day] = true
+	}
+	for day := fromDay; !day.After(toDay); day = day.AddDate(0, 0, 1) {
+		if !found[day.Format(reportDayLayout)] {
+			report.MissingDays = append(report.MissingDays, day.Format(reportDayLayout))
+		}
+	}
+	return report, nil
+}
+
+// summarizeRollups totals the rollups of a range of days. The average build time is
+// weighted by each day's builds; consumers are ranked by their average over the days they
+// were sampled.
+func summarizeRollups(rollups []models.DeploymentStatsRollup, days int) dto.DeploymentReportSummary {
+	summary := dto.DeploymentReportSummary{FailuresByClass: []dto.FailureClassRate{}}
+	failures := make(map[string]int64)
+	var buildMs float64
+	for _, rollup := range rollups {
+		summary.Deployments += rollup.Deployments
+		summary.Succeeded += rollup.Succeeded
+		summary.Failed += rollup.Failed
+		summary.Builds += rollup.Builds
+		buildMs += float64(rollup.AverageBuildMs) * float64(rollup.Builds)
+		for class, count := range rollup.FailuresByClass {
+			failures[class] += count
+		}
+	}
+
+	if summary.Builds > 0 {
+		summary.AverageBuildMs = int64(math.Round(buildMs / float64(summary.Builds)))
+	}
+	if days > 0 {
+		summary.BuildsPerDay = math.Round(float64(summary.Builds)/float64(days)*100) / 100
+	}
+	finished := summary.Succeeded + summary.Failed
+	if finished > 0 {
+		summary.FailureRate = math.Round(float64(summary.Failed)/float64(finished)*10000) / 10000
+	}
+	for class, count := range failures {
+		rate := 0.0
+		if finished > 0 {
+			rate = math.Round(float64(count)/float64(finished)*10000) / 10000
+		}
+		summary.FailuresByClass = append(summary.FailuresByClass, dto.FailureClassRate{FailureClass: class, Count: count, Rate: rate})
+	}
+	sort.Slice(summary.FailuresByClass, func(i, j int) bool {
+		a, b := summary.FailuresByClass[i], summary.FailuresByClass[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.FailureClass < b.FailureClass
+	})
+
+	summary.TopCPUConsumers = mergeConsumers(rollups, func(r models.DeploymentStatsRollup) models.ResourceConsumers { return r.TopCPUConsumers },
+		func(c models.ResourceConsumer) int64 { return c.AvgCPUUsed })
+	summary.TopMemoryConsumers = mergeConsumers(rollups, func(r models.DeploymentStatsRollup) models.ResourceConsumers { return r.TopMemoryConsumers },
+		func(c models.ResourceConsumer) int64 { return c.AvgMemoryUsed })
+	return summary
+}
+
+// mergeConsumers averages the daily entries of each service of one ranking and ranks the
+// services again by the averaged value
+func mergeConsumers(rollups []models.DeploymentStatsRollup, ranking func(models.DeploymentStatsRollup) models.ResourceConsumers, value func(models.ResourceConsumer) int64) []models.ResourceConsumer {
+	type total struct {
+		consumer models.ResourceConsumer
+		cpu      int64
+		memory   int64
+		days     int64
+	}
+	totals := make(map[string]*total)
+	for _, rollup := range rollups {
+		for _, consumer := range ranking(rollup) {
+			t, ok := totals[consumer.ServiceID]
+			if !ok {
+				t = &total{consumer: consumer}
+				totals[consumer.ServiceID] = t
+			}
+			t.cpu += consumer.AvgCPUUsed
+			t.memory += consumer.AvgMemoryUsed
+			t.days++
+			t.consumer.PeakCPUUsed = max(t.consumer.PeakCPUUsed, consumer.PeakCPUUsed)
+			t.consumer.PeakMemoryUsed = max(t.consumer.PeakMemoryUsed, consumer.PeakMemoryUsed)
+		}
+	}
+
+	merged := make([]models.ResourceConsumer, 0, len(totals))
+	for _, t := range totals {
+		t.consumer.AvgCPUUsed = t.cpu / t.days
+		t.consumer.AvgMemoryUsed = t.memory / t.days
+		merged = append(merged, t.consumer)
+	}
+	sort.Slice(merged, func(i, j int) bool { return value(merged[i]) > value(merged[j]) })
+	if len(merged) > reportTopConsumers {
+		merged = merged[:reportTopConsumers]
+	}
+	return merged
+}
+
+func parseReportDay(day string) (time.Time, error) {
+	parsed, err := time.Parse(reportDayLayout, day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day %q: use YYYY-MM-DD", day)
+	}
+	return parsed, nil
+}
@@ -3,10 +3,13 @@ package services
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pendeploy-simple/dto"
@@ -14,15 +17,26 @@ import (
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/repositories"
 	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 )
 
+// GitDeployStatusIgnored is the status of a webhook push to a pinned service
+const GitDeployStatusIgnored = "ignored"
+
+// Errors of image deployments reported by external CI the API maps to client errors
+var (
+	ErrInvalidDeployAPIKey = errors.New("unauthorized: invalid API key")
+	ErrInvalidPushedImage  = errors.New("invalid pushed image")
+)
+
 type DeploymentService struct {
 	serviceRepo    *repositories.ServiceRepository
 	deploymentRepo *repositories.DeploymentRepository
 	registryRepo   *repositories.RegistryRepository
+	outboxRepo     *repositories.OutboxRepository
 }
 
 func NewDeploymentService() *DeploymentService {
@@ -30,6 +44,7 @@ func NewDeploymentService() *DeploymentService {
 		serviceRepo:    repositories.NewServiceRepository(),
 		deploymentRepo: repositories.NewDeploymentRepository(),
 		registryRepo:   repositories.NewRegistryRepository(),
+		outboxRepo:     repositories.NewOutboxRepository(),
 	}
 }
 
@@ -48,6 +63,34 @@ func (s *DeploymentService) CreateGitDeployment(request dto.GitDeployRequest) (d
 	if !isValid {
 		return dto.GitDeployResponse{}, fmt.Errorf("unauthorized: invalid API key")
 	}
+	if request.FromWebhook && service.PinnedDeploymentID != nil {
+		log.Printf("Service %s is pinned to deployment %s; ignoring push of commit %q", service.ID, *service.PinnedDeploymentID, request.CommitID)
+		return dto.GitDeployResponse{
+			DeploymentID: *service.PinnedDeploymentID,
+			ServiceID:    service.ID,
+			Status:       GitDeployStatusIgnored,
+			Message:      "Service is pinned; push ignored until it is unpinned",
+		}, nil
+	}
+	if env, err := repositories.NewEnvironmentRepository().FindByID(service.EnvironmentID); err == nil && env.IsArchived() {
+		return dto.GitDeployResponse{}, fmt.Errorf("environment is archived; unarchive it before deploying")
+	}
+	if err := NewDeployLockService().CheckDeployAllowed(service, request.ByAdmin); err != nil {
+		return dto.GitDeployResponse{}, err
+	}
+	if !request.ForceRebuild && canReuseLastBuild(service, request.CommitID) {
+		return s.deployBuiltImage(service, request, service.LastBuiltImage, "",
+			"Commit already built with the same build config; deploying the existing image")
+	}
+	if !request.ForceRebuild {
+		if entry, found := NewBuildCacheService().Lookup(service, request.CommitID); found {
+			return s.deployBuiltImage(service, request, entry.Image, entry.ImageDigest,
+				"Commit already built for another service with the same build config; deploying its image")
+		}
+	}
+	if err := NewBuildUsageService().CheckBuildAllowed(service); err != nil {
+		return dto.GitDeployResponse{}, err
+	}
 
 	deployment, err := s.deploymentRepo.Create(models.Deployment{
 		ServiceID:     service.ID,
@@ -68,7 +111,7 @@ func (s *DeploymentService) CreateGitDeployment(request dto.GitDeployRequest) (d
 		return dto.GitDeployResponse{}, err
 	}
 
-	go s.ProcessGitDeployment(deployment, service, registry, request.CallbackUrl)
+	go s.ProcessGitDeployment(deployment, service, registry, request.CallbackUrl, request.ForceRebuild)
 
 	return dto.GitDeployResponse{
 		DeploymentID: deployment.ID,
@@ -80,58 +123,501 @@ func (s *DeploymentService) CreateGitDeployment(request dto.GitDeployRequest) (d
 	}, nil
 }
 
-func (s *DeploymentService) ProcessGitDeployment(deployment models.Deployment, service models.Service, registry models.Registry, callbackUrl string) error {
+// CreateImageDeployment rolls out an image external CI built and pushed to the service's
+// repository of the platform registry. The image is deployed by digest, after checking the
+// registry holds it. Like pushes, reported images are ignored while the service is pinned.
+func (s *DeploymentService) CreateImageDeployment(request dto.ImageDeployRequest) (dto.GitDeployResponse, error) {
+	service, err := s.serviceRepo.FindByID(request.ServiceID)
+	if err != nil || service.APIKey != request.APIKey {
+		return dto.GitDeployResponse{}, ErrInvalidDeployAPIKey
+	}
+	if service.Type != models.ServiceTypeGit {
+		return dto.GitDeployResponse{}, fmt.Errorf("%w: only git services deploy images", ErrInvalidPushedImage)
+	}
+	if service.PinnedDeploymentID != nil {
+		log.Printf("Service %s is pinned to deployment %s; ignoring pushed image %s", service.ID, *service.PinnedDeploymentID, request.Image)
+		return dto.GitDeployResponse{
+			DeploymentID: *service.PinnedDeploymentID,
+			ServiceID:    service.ID,
+			Status:       GitDeployStatusIgnored,
+			Message:      "Service is pinned; pushed image ignored until it is unpinned",
+		}, nil
+	}
+
+	registry, err := s.registryRepo.FindDefault()
+	if err != nil {
+		return dto.GitDeployResponse{}, fmt.Errorf("failed to fetch registry: %v", err)
+	}
+	digest, err := utils.ResolvePushedImage(request.Image, request.Digest, registry.URL, service)
+	if err != nil {
+		return dto.GitDeployResponse{}, fmt.Errorf("%w: %v", ErrInvalidPushedImage, err)
+	}
+	api, err := utils.NewRegistryAPIFromRegistry(registry.URL)
+	if err != nil {
+		return dto.GitDeployResponse{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	exists, err := utils.ImageDigestExists(ctx, api, service.ID, digest)
+	if err != nil {
+		return dto.GitDeployResponse{}, fmt.Errorf("failed to look up the image in the registry: %v", err)
+	}
+	if !exists {
+		return dto.GitDeployResponse{}, fmt.Errorf("%w: the registry has no image %s in %s", ErrInvalidPushedImage, digest, utils.PushedImageRepository(registry.URL, service))
+	}
+
+	commitMessage := request.CommitMessage
+	if commitMessage == "" {
+		commitMessage = "Image pushed by external CI"
+	}
+	deployment, err := s.deployImage(service, models.Deployment{
+		CommitSHA:     request.CommitID,
+		CommitMessage: commitMessage,
+		Image:         utils.ImageByDigest(request.Image, digest),
+		ImageDigest:   digest,
+	}, request.ByAdmin, request.CallbackUrl)
+	if err != nil {
+		return dto.GitDeployResponse{}, err
+	}
+	log.Printf("Deploying image %s pushed by external CI to service %s", deployment.Image, service.ID)
+
+	return dto.GitDeployResponse{
+		DeploymentID: deployment.ID,
+		ServiceID:    service.ID,
+		Status:       string(deployment.Status),
+		Message:      "Deploying the pushed image",
+		CreatedAt:    deployment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// RedeployImage rolls the image of an earlier deployment out again as a new deployment of
+// the service, without a build. The rollout runs in the background.
+func (s *DeploymentService) RedeployImage(service models.Service, source models.Deployment, byAdmin bool) (models.Deployment, error) {
+	if source.ServiceID != service.ID || source.Image == "" {
+		return models.Deployment{}, fmt.Errorf("deployment %s has no image of this service to roll out", source.ID)
+	}
+	return s.DeployImage(service, models.Deployment{
+		CommitSHA:     source.CommitSHA,
+		CommitMessage: source.CommitMessage,
+		Image:         source.Image,
+	}, byAdmin)
+}
+
+// DeployImage rolls an already built image out as a new deployment of the service, without a
+// build; deployment carries the image and the commit it was built from. The rollout runs in
+// the background.
+func (s *DeploymentService) DeployImage(service models.Service, deployment models.Deployment, byAdmin bool) (models.Deployment, error) {
+	return s.deployImage(service, deployment, byAdmin, "")
+}
+
+// deployImage is DeployImage notifying callbackUrl, if set, of the result
+func (s *DeploymentService) deployImage(service models.Service, deployment models.Deployment, byAdmin bool, callbackUrl string) (models.Deployment, error) {
+	if env, err := repositories.NewEnvironmentRepository().FindByID(service.EnvironmentID); err == nil && env.IsArchived() {
+		return models.Deployment{}, fmt.Errorf("environment is archived; unarchive it before deploying")
+	}
+	if err := NewDeployLockService().CheckDeployAllowed(service, byAdmin); err != nil {
+		return models.Deployment{}, err
+	}
+
+	deployment.ServiceID = service.ID
+	deployment.Status = models.DeploymentStatusBuilding
+	deployment, err := s.deploymentRepo.Create(deployment)
+	if err != nil {
+		return deployment, err
+	}
+
+	go s.rolloutImage(deployment, service, callbackUrl)
+	return deployment, nil
+}
+
+// canReuseLastBuild reports whether the image of the service's last build can be deployed
+// for the commit: it was built from that commit with the service's current build config, so
+// only runtime config changed since. Deployments of the branch head always build.
+func canReuseLastBuild(service models.Service, commitSHA string) bool {
+	return commitSHA != "" &&
+		service.LastBuiltImage != "" &&
+		service.LastBuiltCommitSHA == commitSHA &&
+		service.LastBuildInputsDigest == utils.BuildInputsDigest(service)
+}
+
+// deployBuiltImage rolls an image already built from the requested commit out as the
+// deployment of the commit, skipping the build
+func (s *DeploymentService) deployBuiltImage(service models.Service, request dto.GitDeployRequest, image string, imageDigest string, message string) (dto.GitDeployResponse, error) {
+	deployment, err := s.deploymentRepo.Create(models.Deployment{
+		ServiceID:     service.ID,
+		Status:        models.DeploymentStatusBuilding,
+		CommitSHA:     request.CommitID,
+		CommitMessage: request.CommitMessage,
+		Image:         image,
+		ImageDigest:   imageDigest,
+	})
+	if err != nil {
+		log.Println("Error creating deployment:", err)
+		return dto.GitDeployResponse{}, err
+	}
+	log.Printf("Commit %s of service %s was already built with the same config; deploying %s without a build", request.CommitID, service.ID, image)
+
+	go s.rolloutImage(deployment, service, request.CallbackUrl)
+
+	return dto.GitDeployResponse{
+		DeploymentID: deployment.ID,
+		ServiceID:    service.ID,
+		Status:       string(models.DeploymentStatusBuilding),
+		Message:      message,
+		CreatedAt:    deployment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+// rolloutImage deploys the deployment's already built image and records the result
+func (s *DeploymentService) rolloutImage(deployment models.Deployment, service models.Service, callbackUrl string) {
+	updatedService, err := s.DeployToKubernetes(deployment.Image, NewPriorityTierService().ResolveClassNames(service))
+	if err != nil {
+		s.recordDeploymentResult(deployment, updatedService, callbackUrl, err, nil)
+		return
+	}
+	NewDomainPropagationService().Watch(*updatedService)
+	healthCheck := utils.CheckDeploymentHealth(*updatedService)
+	s.recordDeploymentResult(deployment, updatedService, callbackUrl, nil, &healthCheck)
+}
+
+func (s *DeploymentService) ProcessGitDeployment(deployment models.Deployment, service models.Service, registry models.Registry, callbackUrl string, forceRebuild bool) error {
 	log.Println("Processing Git deployment for service:", service.Name)
+	service = NewPriorityTierService().ResolveClassNames(service)
 	
-	image, err := utils.BuildFromGit(deployment, service, registry)
+	image, exposedPorts, err := s.buildImage(deployment, service, registry, forceRebuild)
 	if err != nil {
-		log.Println("Error building image:", err)
-		s.deploymentRepo.UpdateStatus(deployment.ID, models.DeploymentStatusFailed)
-		if callbackUrl != "" {
-			go utils.SendWebhookNotification(callbackUrl, deployment.ID, "failed", err.Error())
-		}
+		s.recordDeploymentResult(deployment, nil, callbackUrl, err, nil)
+		return err
+	}
+	s.recordLastBuild(deployment, &service, image)
+	s.checkExposedPorts(deployment, &service, exposedPorts)
+
+	// Publishing the artifact and provenance runs alongside the rollout and cannot fail it
+	if artifactService := NewBuildArtifactService(); artifactService.ShouldExport(service) {
+		go artifactService.Export(deployment, service, registry, image)
+	}
+	if provenanceService := NewProvenanceService(); provenanceService.Enabled() {
+		go provenanceService.Run(deployment.ID, service, registry, image)
+	}
+
+	rolloutStart := time.Now()
+	updatedService, err := s.DeployToKubernetes(image, service)
+	if err != nil {
+		s.recordDeploymentResult(deployment, updatedService, callbackUrl, err, nil)
 		return err
 	}
 	
-	err = s.deploymentRepo.UpdateImage(deployment.ID, image)
+	log.Println("Deployment successful for service:", service.Name)
+	go s.checkServicePort(deployment, *updatedService, rolloutStart)
+	// Tell the user when the hostnames are actually reachable
+	NewDomainPropagationService().Watch(*updatedService)
+	healthCheck := utils.CheckDeploymentHealth(*updatedService)
+	return s.recordDeploymentResult(deployment, updatedService, callbackUrl, nil, &healthCheck)
+}
+
+// buildImage builds the image of a deployment and returns it with the ports its Dockerfile
+// EXPOSEs. Unless forceRebuild is set, an image of another service of the project built from
+// the same commit and build config is used instead, also when that build is still in progress.
+func (s *DeploymentService) buildImage(deployment models.Deployment, service models.Service, registry models.Registry, forceRebuild bool) (string, []int, error) {
+	buildCache := NewBuildCacheService()
+	if !forceRebuild {
+		if entry, found := buildCache.Acquire(service, deployment.CommitSHA); found {
+			log.Printf("Deployment %s reuses image %s built by deployment %s", deployment.ID, entry.Image, entry.SourceDeploymentID)
+			if err := s.deploymentRepo.UpdateReusedImage(deployment.ID, entry.Image, entry.ImageDigest); err != nil {
+				log.Println("Error updating image:", err)
+				return "", nil, err
+			}
+			var exposedPorts []int
+			if source, err := s.deploymentRepo.FindByID(entry.SourceDeploymentID); err == nil {
+				if source.BuildEnv != nil {
+					exposedPorts = source.BuildEnv.ExposedPorts
+				}
+				if source.ImageSize > 0 {
+					if err := s.deploymentRepo.UpdateImageLayers(deployment.ID, source.ImageSize, source.ImageLayers); err != nil {
+						log.Printf("Failed to record image layers of deployment %s: %v", deployment.ID, err)
+					}
+				}
+				// The budget is the reusing service's own
+				if err := s.checkImageSizeBudget(deployment, service, source.ImageSize); err != nil {
+					return "", nil, err
+				}
+			}
+			return entry.Image, exposedPorts, nil
+		}
+		defer buildCache.Release(service, deployment.CommitSHA)
+	}
+
+	buildUsage := NewBuildUsageService()
+	buildStart, err := buildUsage.AcquireBuildSlot(deployment, service)
+	if err != nil {
+		log.Println("Error waiting for a build slot:", err)
+		return "", nil, err
+	}
+
+	// Build secrets are the resolved secret env vars, which are otherwise only read on deploy
+	buildService := service
+	if service.BuildSecrets {
+		buildService, err = NewProjectSecretService().ResolveEnvVars(service)
+		if err != nil {
+			buildUsage.FinishBuild(deployment.ID, buildStart)
+			return "", nil, fmt.Errorf("failed to resolve build secrets: %v", err)
+		}
+	}
+
+	image, err := utils.BuildFromGit(deployment, buildService, registry)
+	buildUsage.FinishBuild(deployment.ID, buildStart)
+	record := s.recordBuildEnvironment(deployment, service)
+	// Failed builds are archived too: their logs are the ones that get shared
+	if logService := NewLogDownloadService(); logService.ShouldArchive() {
+		go logService.ArchiveBuildLogs(deployment, service)
+	}
 	if err != nil {
+		log.Println("Error building image:", err)
+		// Classified while the build pods still exist
+		return "", nil, utils.DiagnoseBuildFailure(service, deployment, err)
+	}
+
+	if err := s.deploymentRepo.UpdateImage(deployment.ID, image); err != nil {
 		log.Println("Error updating image:", err)
-		s.deploymentRepo.UpdateStatus(deployment.ID, models.DeploymentStatusFailed)
-		if callbackUrl != "" {
-			go utils.SendWebhookNotification(callbackUrl, deployment.ID, "failed", err.Error())
+		return "", nil, err
+	}
+	size := s.recordImageLayers(deployment, service, registry)
+	// Images over a failing budget are not offered to other services
+	if err := s.checkImageSizeBudget(deployment, service, size); err != nil {
+		return "", nil, err
+	}
+	buildCache.Record(service, deployment, image, record)
+	return image, record.Environment.ExposedPorts, nil
+}
+
+// checkExposedPorts records on the deployment when the service port is not a port the
+// Dockerfile EXPOSEs. With AutoCorrectPort set and a single exposed port the mismatch is
+// unambiguous, so the service is deployed on that port instead. A PORT env var the user
+// set pins the port the app listens on and is never overridden.
+func (s *DeploymentService) checkExposedPorts(deployment models.Deployment, service *models.Service, exposedPorts []int) {
+	warning := utils.ExposedPortMismatchWarning(*service, exposedPorts)
+	if warning == "" {
+		return
+	}
+	if _, declared := service.EnvVars[utils.PortEnvVar]; service.AutoCorrectPort && len(exposedPorts) == 1 && !declared {
+		if err := s.serviceRepo.UpdatePort(service.ID, exposedPorts[0]); err != nil {
+			log.Printf("Failed to correct port of service %s: %v", service.ID, err)
+		} else {
+			warning = fmt.Sprintf("the service port was changed from %d to %d, the only port the Dockerfile exposes", service.Port, exposedPorts[0])
+			service.Port = exposedPorts[0]
 		}
-		return err
 	}
+	log.Printf("Deployment %s: %s", deployment.ID, warning)
+	if err := s.deploymentRepo.UpdatePortWarning(deployment.ID, warning); err != nil {
+		log.Printf("Failed to record port warning of deployment %s: %v", deployment.ID, err)
+	}
+}
 
-	updatedService, err := s.DeployToKubernetes(image, service)
+// checkServicePort records on the deployment when the rolled out app does not accept
+// connections on the service port (and PORT), which leaves the ingress answering 502.
+// The rollout has already succeeded, so the result is informational.
+func (s *DeploymentService) checkServicePort(deployment models.Deployment, service models.Service, rolloutStart time.Time) {
+	err := utils.CheckServicePort(service, rolloutStart)
+	if err == nil {
+		return
+	}
+	log.Printf("Port check of deployment %s failed: %v", deployment.ID, err)
+	if err := s.deploymentRepo.UpdatePortCheck(deployment.ID, err.Error()); err != nil {
+		log.Printf("Failed to record port check of deployment %s: %v", deployment.ID, err)
+	}
+}
+
+// recordBuildEnvironment stores how the image was built, also for failed builds, so every
+// build can be audited and reproduced, and returns what it captured
+func (s *DeploymentService) recordBuildEnvironment(deployment models.Deployment, service models.Service) utils.BuildRecord {
+	record, err := utils.CaptureBuildEnvironment(deployment, service)
 	if err != nil {
-		s.deploymentRepo.UpdateStatus(deployment.ID, models.DeploymentStatusFailed)
-		s.serviceRepo.Update(*updatedService)
-		if callbackUrl != "" {
-			go utils.SendWebhookNotification(callbackUrl, deployment.ID, "failed", err.Error())
+		log.Printf("Failed to capture build environment of deployment %s: %v", deployment.ID, err)
+		return record
+	}
+	if err := s.deploymentRepo.UpdateBuildEnvironment(deployment.ID, record.Environment, record.DockerfileDigest, record.ImageDigest); err != nil {
+		log.Printf("Failed to record build environment of deployment %s: %v", deployment.ID, err)
+	}
+	if record.TestDuration > 0 {
+		if err := s.deploymentRepo.UpdateTestDuration(deployment.ID, record.TestDuration.Milliseconds()); err != nil {
+			log.Printf("Failed to record test duration of deployment %s: %v", deployment.ID, err)
+		}
+	}
+	return record
+}
+
+// recordLastBuild remembers the build of a commit so a later deployment of the same commit
+// and build config can reuse its image. Builds of the branch head are not remembered: the
+// commit they built is not known.
+func (s *DeploymentService) recordLastBuild(deployment models.Deployment, service *models.Service, image string) {
+	if deployment.CommitSHA == "" {
+		return
+	}
+	// The service is saved again with the deployment result, so it carries the values too
+	service.LastBuiltCommitSHA = deployment.CommitSHA
+	service.LastBuiltImage = image
+	service.LastBuildInputsDigest = utils.BuildInputsDigest(*service)
+	if err := s.serviceRepo.UpdateLastBuild(service.ID, service.LastBuiltCommitSHA, service.LastBuiltImage, service.LastBuildInputsDigest); err != nil {
+		log.Printf("Failed to record the build of deployment %s: %v", deployment.ID, err)
+	}
+}
+
+// recordImageLayers stores the size and layers of the pushed image, so the images of two
+// deployments can be compared, and returns the size (0 when unknown). It is best effort and
+// cannot fail the deployment.
+func (s *DeploymentService) recordImageLayers(deployment models.Deployment, service models.Service, registry models.Registry) int64 {
+	api, err := utils.NewRegistryAPIFromRegistry(registry.URL)
+	if err != nil {
+		log.Printf("Failed to inspect image of deployment %s: %v", deployment.ID, err)
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Images are pushed as <service ID>:<deployment ID>, see utils.GenerateImage
+	layers, size, err := utils.InspectImageLayers(ctx, api, service.ID, deployment.ID)
+	if err != nil {
+		log.Printf("Failed to inspect image of deployment %s: %v", deployment.ID, err)
+		return 0
+	}
+	if err := s.deploymentRepo.UpdateImageLayers(deployment.ID, size, layers); err != nil {
+		log.Printf("Failed to record image layers of deployment %s: %v", deployment.ID, err)
+	}
+	return size
+}
+
+// checkImageSizeBudget records on the deployment when its image exceeds the service's size
+// budget. The error, a BuildFailure, is returned when the service fails such deployments.
+func (s *DeploymentService) checkImageSizeBudget(deployment models.Deployment, service models.Service, size int64) error {
+	warning, budgetErr := utils.CheckImageSizeBudget(service, size)
+	if warning == "" {
+		return nil
+	}
+	log.Printf("Deployment %s: %s", deployment.ID, warning)
+	if err := s.deploymentRepo.UpdateImageSizeWarning(deployment.ID, warning); err != nil {
+		log.Printf("Failed to record image size warning of deployment %s: %v", deployment.ID, err)
+	}
+	return budgetErr
+}
+
+// recordDeploymentResult stores the final deployment status (and the updated service, if
+// any) together with the callback notification in one transaction. The outbox dispatcher
+// delivers the notification afterwards, so a crash can neither lose it nor send it for a
+// status that was never saved. healthCheck, the request against the domain after a
+// successful deployment, is stored and sent along when set.
+func (s *DeploymentService) recordDeploymentResult(deployment models.Deployment, updatedService *models.Service, callbackUrl string, deployErr error, healthCheck *models.DeploymentHealthCheck) error {
+	status, webhookStatus, errorMessage := models.DeploymentStatusSuccess, "running", ""
+	if deployErr != nil {
+		status, webhookStatus, errorMessage = models.DeploymentStatusFailed, "failed", deployErr.Error()
+	}
+	var buildFailure *utils.BuildFailure
+	errors.As(deployErr, &buildFailure)
+	callbackUrl = strings.TrimSpace(callbackUrl)
+
+	err := s.deploymentRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if updatedService != nil {
+			if err := s.serviceRepo.UpdateTx(tx, *updatedService); err != nil {
+				return fmt.Errorf("failed to update service: %v", err)
+			}
+		}
+		if err := s.deploymentRepo.UpdateStatusTx(tx, deployment.ID, status); err != nil {
+			return fmt.Errorf("failed to update deployment status: %v", err)
+		}
+		if deployErr != nil {
+			if err := s.deploymentRepo.UpdateFailureClassTx(tx, deployment.ID, classifyDeploymentFailure(deployErr)); err != nil {
+				return fmt.Errorf("failed to record failure class: %v", err)
+			}
 		}
+		if buildFailure != nil {
+			if err := s.deploymentRepo.UpdateBuildErrorTx(tx, deployment.ID, buildFailure.Code, buildFailure.Hint); err != nil {
+				return fmt.Errorf("failed to record build error: %v", err)
+			}
+		}
+		if healthCheck != nil {
+			if err := s.deploymentRepo.UpdateHealthCheckTx(tx, deployment.ID, *healthCheck); err != nil {
+				return fmt.Errorf("failed to record health check: %v", err)
+			}
+		}
+		if callbackUrl == "" {
+			return nil
+		}
+
+		payload, err := utils.BuildDeploymentWebhookPayload(deployment.ID, webhookStatus, errorMessage, buildFailure, healthCheck)
+		if err != nil {
+			return fmt.Errorf("failed to build webhook payload: %v", err)
+		}
+		return s.outboxRepo.Enqueue(tx, models.OutboxEvent{
+			EventType:   models.OutboxEventDeploymentStatus,
+			AggregateID: deployment.ID,
+			Payload:     string(payload),
+			CallbackURL: callbackUrl,
+		})
+	})
+	if err != nil {
+		log.Printf("Error recording result of deployment %s: %v", deployment.ID, err)
 		return err
 	}
-	
-	log.Println("Deployment successful for service:", service.Name)
-	s.serviceRepo.Update(*updatedService)
-	s.deploymentRepo.UpdateStatus(deployment.ID, models.DeploymentStatusSuccess)
+
 	if callbackUrl != "" {
-		go utils.SendWebhookNotification(callbackUrl, deployment.ID, "running", "")
+		NotifyOutbox()
 	}
 	return nil
 }
 
+// classifyDeploymentFailure names the stage a deployment failed in, see models.FailureClass*
+func classifyDeploymentFailure(err error) string {
+	var policyErr *PolicyViolationError
+	if errors.As(err, &policyErr) {
+		return models.FailureClassPolicy
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "waiting for a build slot"):
+		return models.FailureClassBuildQuota
+	case strings.Contains(message, "tests failed"):
+		return models.FailureClassTest
+	case strings.Contains(message, "image pull failed"), strings.Contains(message, "ImagePullBackOff"), strings.Contains(message, "ErrImagePull"):
+		return models.FailureClassImagePull
+	case strings.Contains(message, "timeout"), strings.Contains(message, "timed out"), strings.Contains(message, "deadline exceeded"):
+		return models.FailureClassTimeout
+	case strings.Contains(message, "failed to deploy to Kubernetes"):
+		return models.FailureClassRollout
+	case strings.Contains(message, "build job failed"), strings.Contains(message, "failed to start build"):
+		return models.FailureClassBuild
+	}
+	return models.FailureClassOther
+}
+
 func (s *DeploymentService) DeployToKubernetes(imageUrl string, service models.Service) (*models.Service, error) {
 	log.Println("Deploying to Kubernetes for service:", service.Name)
+	service = resolveClusterConfig(service)
+	if err := NewPolicyService().Evaluate(imageUrl, service); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+	service, err := NewProjectSecretService().ResolveEnvVars(service)
+	if err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
 	updatedService, err := utils.DeployToKubernetesAtomically(imageUrl, service)
 	if err != nil {
 		log.Println("Error deploying to Kubernetes:", err)
-		return nil, fmt.Errorf("failed to deploy to Kubernetes: %v", err)
+		// updatedService carries the "failed" status so it can be saved
+		return updatedService, fmt.Errorf("failed to deploy to Kubernetes: %v", err)
 	}
 	return updatedService, nil
 }
 
+// resolveClusterConfig fills in the pull Secrets, domain template and PriorityClasses the
+// service's workloads are generated with
+func resolveClusterConfig(service models.Service) models.Service {
+	service.ImagePullSecrets = NewPullCredentialService().ResolveSecretNames(service)
+	service = resolveDomainTemplate(service)
+	return NewPriorityTierService().ResolveClassNames(service)
+}
+
 func (s *DeploymentService) GetDeploymentByID(id string) (*dto.DeploymentResponse, error) {
 	deployment, err := s.deploymentRepo.FindByID(id)
 	if err != nil {
@@ -211,7 +697,10 @@ func (s *DeploymentService) GetResourceStatus(serviceID string) (*dto.ResourceSt
 	return response, nil
 }
 
-func (s *DeploymentService) GetServiceBuildLogsRealtime(deploymentID string, w http.ResponseWriter) error {
+// GetServiceBuildLogsRealtime streams the logs of a deployment's build as Server-Sent Events.
+// Each line carries its position (see buildLogEventID) as event ID; a client reconnecting
+// with lastEventID gets the lines after it instead of the whole output again.
+func (s *DeploymentService) GetServiceBuildLogsRealtime(deploymentID string, w http.ResponseWriter, lastEventID string) error {
 	log.Println("Starting build log streaming for deployment ID:", deploymentID)
 
 	deployment, err := s.deploymentRepo.FindByID(deploymentID)
@@ -254,10 +743,181 @@ func (s *DeploymentService) GetServiceBuildLogsRealtime(deploymentID string, w h
 		return err
 	}
 	
-	return s.streamPodLogs(ctx, k8sClient, namespace, podName, w, flusher)
+	redact := utils.NewSecretRedactor(service)
+	stage, offset := parseBuildLogEventID(lastEventID)
+	if service.TestCommand != "" && stage != buildLogStageBuild {
+		passed, err := s.streamTestLogs(ctx, k8sClient, namespace, podName, w, flusher, redact, offset)
+		if err != nil || !passed {
+			return err
+		}
+		offset = 0
+	}
+	
+	return s.streamBuildOutput(ctx, k8sClient, namespace, podName, w, flusher, redact, offset)
+}
+
+const (
+	buildLogStageTest  = "test"
+	buildLogStageBuild = "build"
+)
+
+// buildLogEventID identifies a line of build output by stage and line number, e.g. "build-42"
+func buildLogEventID(stage string, line int) string {
+	return fmt.Sprintf("%s-%d", stage, line)
+}
+
+// parseBuildLogEventID returns the stage and the number of lines of it already sent; an
+// unknown ID starts from the beginning
+func parseBuildLogEventID(id string) (string, int) {
+	stage, line, ok := strings.Cut(id, "-")
+	if !ok || (stage != buildLogStageTest && stage != buildLogStageBuild) {
+		return "", 0
+	}
+	offset, err := strconv.Atoi(line)
+	if err != nil || offset < 0 {
+		return "", 0
+	}
+	return stage, offset
+}
+
+// streamBuildOutput streams the whole output of a build pod's Kaniko container, skipping
+// the first offset lines a resumed client already has
+func (s *DeploymentService) streamBuildOutput(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string, w http.ResponseWriter, flusher http.Flusher, redact func(string) string, offset int) error {
+	if err := s.waitForPodReady(ctx, k8sClient, namespace, podName); err != nil {
+		log.Printf("Pod %s not ready: %v", podName, err)
+		return err
+	}
+	
+	logs, err := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Follow: true,
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("error opening log stream for pod %s: %v", podName, err)
+	}
+	defer logs.Close()
+	
+	scanner := bufio.NewScanner(logs)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line <= offset {
+			continue
+		}
+		utils.WriteSSEEventWithID(w, "", buildLogEventID(buildLogStageBuild, line), redact(scanner.Text()))
+		flusher.Flush()
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return fmt.Errorf("error reading logs from pod %s: %v", podName, err)
+	}
+	return nil
+}
+
+// streamTestLogs streams the output of the build's test stage as "test" events, apart from the
+// build output that follows it, and reports whether the tests passed
+func (s *DeploymentService) streamTestLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string, w http.ResponseWriter, flusher http.Flusher, redact func(string) string, offset int) (bool, error) {
+	if err := s.waitForTestStage(ctx, k8sClient, namespace, podName); err != nil {
+		return false, err
+	}
+	
+	logs, err := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: utils.TestContainerName,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error opening test log stream for pod %s: %v", podName, err)
+	}
+	defer logs.Close()
+	
+	scanner := bufio.NewScanner(logs)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line <= offset {
+			continue
+		}
+		utils.WriteSSEEventWithID(w, utils.TestLogEvent, buildLogEventID(buildLogStageTest, line), redact(scanner.Text()))
+		flusher.Flush()
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return false, fmt.Errorf("error reading test logs from pod %s: %v", podName, err)
+	}
+	
+	// The stream ends when the test container exits
+	pod, err := k8sClient.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get pod %s: %v", podName, err)
+	}
+	state := utils.TestContainerState(*pod)
+	if state == nil || state.Terminated == nil {
+		return false, fmt.Errorf("test stage of pod %s did not finish", podName)
+	}
+	if state.Terminated.ExitCode != 0 {
+		utils.WriteSSEEvent(w, utils.TestLogEvent, fmt.Sprintf("Tests failed with exit code %d, the build is skipped", state.Terminated.ExitCode))
+		flusher.Flush()
+		return false, nil
+	}
+	return true, nil
+}
+
+// waitForTestStage waits until the test container of a build pod has started, or the pod has
+// failed before it (e.g. in the clone)
+func (s *DeploymentService) waitForTestStage(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string) error {
+	started := func(pod *corev1.Pod) (bool, error) {
+		if state := utils.TestContainerState(*pod); state != nil && (state.Running != nil || state.Terminated != nil) {
+			return true, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			return false, fmt.Errorf("pod %s failed before the tests ran", podName)
+		}
+		return false, nil
+	}
+	
+	pod, err := k8sClient.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err == nil {
+		if ok, err := started(pod); ok || err != nil {
+			return err
+		}
+	}
+	
+	watcher, err := k8sClient.Clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", podName),
+		Watch:         true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pod status watcher: %v", err)
+	}
+	defer watcher.Stop()
+	
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer timeoutCancel()
+	
+	for {
+		select {
+		case <-timeoutCtx.Done():
+			return fmt.Errorf("timeout waiting for the tests of pod %s to start", podName)
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return fmt.Errorf("watcher channel closed for pod %s", podName)
+			}
+			if event.Type == watch.Error {
+				return fmt.Errorf("watch error: %v", event.Object)
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			if ok, err := started(pod); ok || err != nil {
+				return err
+			}
+		}
+	}
 }
 
-func (s *DeploymentService) GetServiceRuntimeLogsRealtime(serviceID string, w http.ResponseWriter) error {
+// GetServiceRuntimeLogsRealtime streams the logs of a service's running pod as Server-Sent
+// Events, switching to the new pod on rollouts. Each line carries its pod and timestamp (see
+// runtimeLogEventID) as event ID; a client reconnecting with lastEventID gets the lines
+// logged after it instead of the last 50 again.
+func (s *DeploymentService) GetServiceRuntimeLogsRealtime(serviceID string, w http.ResponseWriter, lastEventID string) error {
 	log.Println("Starting runtime log streaming for service ID:", serviceID)
 
 	service, err := s.serviceRepo.FindByID(serviceID)
@@ -290,7 +950,30 @@ func (s *DeploymentService) GetServiceRuntimeLogsRealtime(serviceID string, w ht
 		}()
 	}
 	
-	return s.watchAndStreamRuntimeLogs(ctx, k8sClient, namespace, deploymentResourceName, w, flusher)
+	var since *time.Time
+	if _, at, ok := parseRuntimeLogEventID(lastEventID); ok {
+		since = &at
+	}
+	return s.watchAndStreamRuntimeLogs(ctx, k8sClient, namespace, deploymentResourceName, w, flusher, utils.NewSecretRedactor(service), since)
+}
+
+// runtimeLogEventID identifies a line of runtime logs by pod and timestamp, e.g.
+// "web-5d9c-x2x7k@2025-01-02T15:04:05.123456789Z"
+func runtimeLogEventID(podName string, at time.Time) string {
+	return podName + "@" + at.UTC().Format(time.RFC3339Nano)
+}
+
+// parseRuntimeLogEventID returns the pod and timestamp of a runtime log event ID
+func parseRuntimeLogEventID(id string) (string, time.Time, bool) {
+	podName, timestamp, ok := strings.Cut(id, "@")
+	if !ok {
+		return "", time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return podName, at, true
 }
 
 // FIXED: watchForJobPod with proper cleanup
@@ -336,7 +1019,8 @@ func (s *DeploymentService) watchForJobPod(ctx context.Context, k8sClient *kuber
 }
 
 // FIXED: watchAndStreamRuntimeLogs to prevent goroutine leaks
-func (s *DeploymentService) watchAndStreamRuntimeLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, deploymentName string, w http.ResponseWriter, flusher http.Flusher) error {
+// since, when set, resumes a stream: only lines logged after it are sent
+func (s *DeploymentService) watchAndStreamRuntimeLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, deploymentName string, w http.ResponseWriter, flusher http.Flusher, redact func(string) string, since *time.Time) error {
 	streamCtx, streamCancel := context.WithCancel(ctx)
 	defer streamCancel()
 	
@@ -362,9 +1046,9 @@ func (s *DeploymentService) watchAndStreamRuntimeLogs(ctx context.Context, k8sCl
 		flusher.Flush()
 		currentStreamingPod = currentPod.Name
 		
-		go func() {
-			s.streamPodLogs(streamCtx, k8sClient, namespace, currentPod.Name, w, flusher)
-		}()
+		go func(streamCtx context.Context, podName string) {
+			s.streamPodLogs(streamCtx, k8sClient, namespace, podName, w, flusher, redact, since)
+		}(streamCtx, currentPod.Name)
 	}
 	
 	watchOpts := metav1.ListOptions{
@@ -404,51 +1088,113 @@ func (s *DeploymentService) watchAndStreamRuntimeLogs(ctx context.Context, k8sCl
 					streamCtx, streamCancel = context.WithCancel(ctx)
 					currentStreamingPod = pod.Name
 					
-					go func(podName string) {
-						s.streamPodLogs(streamCtx, k8sClient, namespace, podName, w, flusher)
-					}(pod.Name)
+					go func(streamCtx context.Context, podName string) {
+						s.streamPodLogs(streamCtx, k8sClient, namespace, podName, w, flusher, redact, since)
+					}(streamCtx, pod.Name)
 				}
 			}
 		}
 	}
 }
 
-// FIXED: streamPodLogs with better resource management
-func (s *DeploymentService) streamPodLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string, w http.ResponseWriter, flusher http.Flusher) error {
-	err := s.waitForPodReady(ctx, k8sClient, namespace, podName)
-	if err != nil {
-		log.Printf("Pod %s not ready: %v", podName, err)
-		return err
+// FIXED: streamPodLogs with better resource management; redact, when set, masks secret values.
+// Lines are sent with runtimeLogEventID IDs. Without since the last 50 lines come first. When
+// the container restarts mid-stream, the stream re-attaches and continues after the last line.
+func (s *DeploymentService) streamPodLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string, w http.ResponseWriter, flusher http.Flusher, redact func(string) string, since *time.Time) error {
+	for {
+		err := s.waitForPodReady(ctx, k8sClient, namespace, podName)
+		if err != nil {
+			log.Printf("Pod %s not ready: %v", podName, err)
+			return err
+		}
+		
+		attachedAt := time.Now()
+		last, err := s.followPodLogs(ctx, k8sClient, namespace, podName, w, flusher, redact, since)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Log stream of pod %s ended: %v", podName, err)
+		}
+		if last != nil {
+			since = last
+		} else if since == nil {
+			since = &attachedAt
+		}
+		
+		// The stream ends when the container exits; follow it again once it is restarted,
+		// unless the pod itself is done
+		pod, err := k8sClient.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil || pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(podLogReattachDelay):
+		}
+		if last != nil {
+			utils.WriteSSEData(w, fmt.Sprintf("Re-attaching to the logs of pod %s...", podName))
+			flusher.Flush()
+		}
 	}
-	
+}
+
+// podLogReattachDelay is the pause before following the logs of a restarted container again
+const podLogReattachDelay = 2 * time.Second
+
+// followPodLogs streams a pod's logs until the stream ends and returns the timestamp of the
+// last line sent. Lines at or before since are skipped: the API only filters by whole seconds.
+func (s *DeploymentService) followPodLogs(ctx context.Context, k8sClient *kubernetes.Client, namespace, podName string, w http.ResponseWriter, flusher http.Flusher, redact func(string) string, since *time.Time) (*time.Time, error) {
 	logOpts := &corev1.PodLogOptions{
 		Follow:     true,
-		Timestamps: false,
-		TailLines:  int64Ptr(50),
+		Timestamps: true,
+	}
+	if since != nil {
+		sinceTime := metav1.NewTime(since.Truncate(time.Second))
+		logOpts.SinceTime = &sinceTime
+	} else {
+		logOpts.TailLines = int64Ptr(50)
+	}
+	if pod, err := k8sClient.Clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{}); err == nil {
+		logOpts.Container = utils.LogContainerName(*pod)
 	}
 	
 	req := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(podName, logOpts)
 	logs, err := req.Stream(ctx)
 	if err != nil {
-		return fmt.Errorf("error opening log stream for pod %s: %v", podName, err)
+		return nil, fmt.Errorf("error opening log stream for pod %s: %v", podName, err)
 	}
 	defer logs.Close()
 	
+	var last *time.Time
 	scanner := bufio.NewScanner(logs)
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return last, ctx.Err()
 		default:
-			utils.WriteSSEData(w, scanner.Text())
+			timestamp, line, _ := strings.Cut(scanner.Text(), " ")
+			at, err := time.Parse(time.RFC3339Nano, timestamp)
+			if err != nil {
+				continue
+			}
+			if since != nil && !at.After(*since) {
+				continue
+			}
+			if redact != nil {
+				line = redact(line)
+			}
+			utils.WriteSSEEventWithID(w, "", runtimeLogEventID(podName, at), line)
 			flusher.Flush()
+			last = &at
 		}
 	}
 	
 	if err := scanner.Err(); err != nil && err != io.EOF {
-		return fmt.Errorf("error reading logs from pod %s: %v", podName, err)
+		return last, fmt.Errorf("error reading logs from pod %s: %v", podName, err)
 	}
-	return nil
+	return last, nil
 }
 
 // FIXED: waitForPodReady with better context handling
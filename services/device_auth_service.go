@@ -0,0 +1,263 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	deviceCodeTTL      = 10 * time.Minute
+	devicePollInterval = 5 * time.Second
+	// Letters only, without vowels and look-alikes, so codes are easy to type and spell nothing
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+)
+
+// Device token exchange error codes (RFC 8628 section 3.5)
+const (
+	DeviceErrorPending      = "authorization_pending"
+	DeviceErrorSlowDown     = "slow_down"
+	DeviceErrorAccessDenied = "access_denied"
+	DeviceErrorExpired      = "expired_token"
+	DeviceErrorInvalidGrant = "invalid_grant"
+)
+
+// DeviceTokenError is a device token exchange failure the CLI acts on by code
+type DeviceTokenError struct {
+	Code string
+}
+
+func (e *DeviceTokenError) Error() string {
+	return e.Code
+}
+
+// DeviceAuthService implements the device-code login used by CLIs: the CLI never sees a
+// password, and receives a scoped API token once the user approves in the browser
+type DeviceAuthService struct {
+	deviceRepo   *repositories.DeviceAuthorizationRepository
+	tokenService *APITokenService
+}
+
+// NewDeviceAuthService creates a new device auth service instance
+func NewDeviceAuthService() *DeviceAuthService {
+	return &DeviceAuthService{
+		deviceRepo:   repositories.NewDeviceAuthorizationRepository(),
+		tokenService: NewAPITokenService(),
+	}
+}
+
+// StartAuthorization creates a pending login and returns the codes for the CLI
+func (s *DeviceAuthService) StartAuthorization(req dto.DeviceCodeRequest) (dto.DeviceCodeResponse, error) {
+	// Drop logins nobody finished; they can no longer be exchanged anyway
+	if _, err := s.deviceRepo.DeleteExpiredBefore(time.Now().Add(-deviceCodeTTL)); err != nil {
+		log.Printf("Failed to remove expired device authorizations: %v", err)
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{models.APITokenScopeRead, models.APITokenScopeWrite}
+	}
+	clientName := strings.TrimSpace(req.ClientName)
+	if clientName == "" {
+		clientName = "CLI"
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return dto.DeviceCodeResponse{}, fmt.Errorf("failed to generate device code: %v", err)
+	}
+	deviceCode := base64.RawURLEncoding.EncodeToString(buf)
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return dto.DeviceCodeResponse{}, err
+	}
+
+	authorization, err := s.deviceRepo.Create(models.DeviceAuthorization{
+		DeviceCodeHash: hashSecret(deviceCode),
+		UserCode:       userCode,
+		ClientName:     clientName,
+		Scopes:         strings.Join(scopes, ","),
+		Status:         models.DeviceAuthPending,
+		ExpiresAt:      time.Now().Add(deviceCodeTTL),
+	})
+	if err != nil {
+		return dto.DeviceCodeResponse{}, err
+	}
+
+	verificationURI := getDeviceVerificationURI()
+	return dto.DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(authorization.UserCode),
+		ExpiresIn:               int(deviceCodeTTL.Seconds()),
+		Interval:                int(devicePollInterval.Seconds()),
+	}, nil
+}
+
+// GetAuthorization describes a pending login to the signed-in user about to approve it
+func (s *DeviceAuthService) GetAuthorization(userCode string) (dto.DeviceAuthorizationInfo, error) {
+	authorization, err := s.deviceRepo.FindByUserCode(normalizeUserCode(userCode))
+	if err != nil {
+		return dto.DeviceAuthorizationInfo{}, err
+	}
+	return toDeviceAuthorizationInfo(authorization), nil
+}
+
+// Decide approves or denies a pending login on behalf of the signed-in user
+func (s *DeviceAuthService) Decide(userCode string, approve bool, userID string) (dto.DeviceAuthorizationInfo, error) {
+	authorization, err := s.deviceRepo.FindByUserCode(normalizeUserCode(userCode))
+	if err != nil {
+		return dto.DeviceAuthorizationInfo{}, err
+	}
+	if time.Now().After(authorization.ExpiresAt) {
+		return dto.DeviceAuthorizationInfo{}, errors.New("this code has expired; start the login again")
+	}
+	if authorization.Status != models.DeviceAuthPending {
+		return dto.DeviceAuthorizationInfo{}, fmt.Errorf("this code was already %s", authorization.Status)
+	}
+
+	authorization.Status = models.DeviceAuthDenied
+	if approve {
+		authorization.Status = models.DeviceAuthApproved
+		authorization.UserID = &userID
+	}
+	if err := s.deviceRepo.Update(authorization); err != nil {
+		return dto.DeviceAuthorizationInfo{}, err
+	}
+	return toDeviceAuthorizationInfo(authorization), nil
+}
+
+// ExchangeDeviceCode issues the API token for an approved login. Until then it fails with
+// a DeviceTokenError telling the CLI to keep polling, slow down or give up.
+func (s *DeviceAuthService) ExchangeDeviceCode(deviceCode string) (dto.DeviceTokenResponse, error) {
+	var response dto.DeviceTokenResponse
+
+	err := s.deviceRepo.DB().Transaction(func(tx *gorm.DB) error {
+		authorization, err := s.deviceRepo.FindByDeviceCodeHashForUpdateTx(tx, hashSecret(deviceCode))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &DeviceTokenError{Code: DeviceErrorInvalidGrant}
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if now.After(authorization.ExpiresAt) {
+			return &DeviceTokenError{Code: DeviceErrorExpired}
+		}
+
+		switch authorization.Status {
+		case models.DeviceAuthDenied:
+			return &DeviceTokenError{Code: DeviceErrorAccessDenied}
+		case models.DeviceAuthConsumed:
+			// A device code yields exactly one token
+			return &DeviceTokenError{Code: DeviceErrorInvalidGrant}
+		case models.DeviceAuthPending:
+			if authorization.LastPolledAt != nil && now.Sub(*authorization.LastPolledAt) < devicePollInterval {
+				return &DeviceTokenError{Code: DeviceErrorSlowDown}
+			}
+			return &DeviceTokenError{Code: DeviceErrorPending}
+		}
+
+		scopes := strings.Split(authorization.Scopes, ",")
+		secret, token, err := s.tokenService.IssueTokenTx(tx, *authorization.UserID, authorization.ClientName, scopes)
+		if err != nil {
+			return err
+		}
+
+		authorization.Status = models.DeviceAuthConsumed
+		if err := s.deviceRepo.UpdateTx(tx, authorization); err != nil {
+			return err
+		}
+
+		response = dto.DeviceTokenResponse{
+			AccessToken: secret,
+			TokenType:   "Bearer",
+			Scopes:      scopes,
+			ExpiresAt:   token.ExpiresAt,
+		}
+		return nil
+	})
+
+	// The transaction rolled back, but the poll time must be kept to detect fast polling
+	var tokenErr *DeviceTokenError
+	if errors.As(err, &tokenErr) && (tokenErr.Code == DeviceErrorPending || tokenErr.Code == DeviceErrorSlowDown) {
+		s.recordPoll(deviceCode)
+	}
+	return response, err
+}
+
+// recordPoll stores the poll time outside the rolled-back exchange transaction
+func (s *DeviceAuthService) recordPoll(deviceCode string) {
+	err := s.deviceRepo.DB().Transaction(func(tx *gorm.DB) error {
+		authorization, err := s.deviceRepo.FindByDeviceCodeHashForUpdateTx(tx, hashSecret(deviceCode))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		authorization.LastPolledAt = &now
+		return s.deviceRepo.UpdateTx(tx, authorization)
+	})
+	if err != nil {
+		log.Printf("Failed to record device code poll: %v", err)
+	}
+}
+
+func toDeviceAuthorizationInfo(authorization models.DeviceAuthorization) dto.DeviceAuthorizationInfo {
+	return dto.DeviceAuthorizationInfo{
+		UserCode:   authorization.UserCode,
+		ClientName: authorization.ClientName,
+		Scopes:     strings.Split(authorization.Scopes, ","),
+		Status:     authorization.Status,
+		ExpiresAt:  authorization.ExpiresAt,
+	}
+}
+
+// generateUserCode returns a code like WDJB-MJHT
+func generateUserCode() (string, error) {
+	code := make([]byte, 0, 9)
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate user code: %v", err)
+		}
+		code = append(code, userCodeAlphabet[n.Int64()])
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode accepts codes typed in lower case, without the dash or with spaces
+func normalizeUserCode(userCode string) string {
+	code := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// getDeviceVerificationURI is the dashboard page where users enter the user code
+// (DEVICE_VERIFICATION_URL, default <first CORS origin>/device)
+func getDeviceVerificationURI() string {
+	if value := optionalEnvString("DEVICE_VERIFICATION_URL"); value != nil {
+		return *value
+	}
+	origin := utils.GetCORSOrigins()[0]
+	return strings.TrimRight(origin, "/") + "/device"
+}
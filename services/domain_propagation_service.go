@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	// domainWatchInterval is the pause between checks while a new hostname propagates
+	domainWatchInterval = 30 * time.Second
+	// domainWatchTimeout is how long hostnames are watched after an Ingress was applied;
+	// the periodic checker takes over after that
+	domainWatchTimeout = 30 * time.Minute
+	// domainRecheckInterval is how often hostnames that were not reachable are checked again
+	domainRecheckInterval = 15 * time.Minute
+)
+
+var (
+	// domainWatches holds the IDs of the services whose hostnames are being watched
+	domainWatches         sync.Map
+	domainPropagationOnce sync.Once
+)
+
+// DomainPropagationService checks, after an Ingress is created for a service, whether its
+// hostnames resolve to the ingress and answer over HTTPS, so users see when their app is
+// reachable
+type DomainPropagationService struct {
+	domainRepo  *repositories.DomainStatusRepository
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewDomainPropagationService creates a new domain propagation service instance
+func NewDomainPropagationService() *DomainPropagationService {
+	return &DomainPropagationService{
+		domainRepo:  repositories.NewDomainStatusRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// GetStatuses returns the last checks of a service's hostnames
+func (s *DomainPropagationService) GetStatuses(serviceID string, userID string, isAdmin bool) ([]models.DomainStatus, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.domainRepo.FindByServiceID(serviceID)
+}
+
+// CheckNow checks a service's hostnames right away
+func (s *DomainPropagationService) CheckNow(serviceID string, userID string, isAdmin bool) ([]models.DomainStatus, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.Check(service)
+}
+
+// Check runs the DNS and HTTPS checks of every hostname of a git service and records them.
+// Hostnames the service no longer serves are dropped.
+func (s *DomainPropagationService) Check(service models.Service) ([]models.DomainStatus, error) {
+	if service.Type != models.ServiceTypeGit {
+		return nil, errors.New("domain checks are only available for git services")
+	}
+
+	previous, err := s.domainRepo.FindByServiceID(service.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load domain statuses: %v", err)
+	}
+	reachableSince := map[string]*time.Time{}
+	for _, status := range previous {
+		reachableSince[status.Hostname] = status.ReachableSince
+	}
+
+	// Without the ingress addresses the resolved ones cannot be compared, but the
+	// hostnames are still checked over HTTPS
+	addresses, err := utils.GetIngressAddresses(service)
+	if err != nil {
+		log.Printf("Domain check of service %s: %v", service.ID, err)
+	}
+
+	hostnames := utils.GetServiceHostnames(service)
+	statuses := make([]models.DomainStatus, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		status := utils.CheckDomainPropagation(hostname, addresses)
+		status.ServiceID = service.ID
+		if status.Status == models.DomainReachable {
+			status.ReachableSince = reachableSince[hostname]
+			if status.ReachableSince == nil {
+				status.ReachableSince = &status.CheckedAt
+			}
+		}
+		if err := s.domainRepo.Save(status); err != nil {
+			return statuses, fmt.Errorf("failed to save domain status: %v", err)
+		}
+		statuses = append(statuses, status)
+	}
+	if err := s.domainRepo.DeleteOtherHostnames(service.ID, hostnames); err != nil {
+		return statuses, fmt.Errorf("failed to remove old domain statuses: %v", err)
+	}
+	return statuses, nil
+}
+
+// Watch checks the hostnames of a service whose Ingress was just applied every
+// domainWatchInterval until all are reachable or domainWatchTimeout passes
+func (s *DomainPropagationService) Watch(service models.Service) {
+	if service.Type != models.ServiceTypeGit {
+		return
+	}
+	if _, running := domainWatches.LoadOrStore(service.ID, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer domainWatches.Delete(service.ID)
+		deadline := time.Now().Add(domainWatchTimeout)
+		for {
+			statuses, err := s.Check(service)
+			if err != nil {
+				log.Printf("Domain check of service %s failed: %v", service.ID, err)
+			} else if allDomainsReachable(statuses) {
+				return
+			}
+			if time.Now().Add(domainWatchInterval).After(deadline) {
+				return
+			}
+			time.Sleep(domainWatchInterval)
+		}
+	}()
+}
+
+// StartPropagationChecker periodically checks again the hostnames that were not reachable,
+// so DNS records created long after the deploy are picked up
+func (s *DomainPropagationService) StartPropagationChecker() {
+	domainPropagationOnce.Do(func() {
+		go func() {
+			log.Printf("Domain propagation checker started (interval %v)", domainRecheckInterval)
+			ticker := time.NewTicker(domainRecheckInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				serviceIDs, err := s.domainRepo.FindServiceIDsNotReachable(time.Now().Add(-domainRecheckInterval / 2))
+				if err != nil {
+					log.Printf("Domain propagation checker: %v", err)
+					continue
+				}
+				for _, serviceID := range serviceIDs {
+					if _, running := domainWatches.Load(serviceID); running {
+						continue
+					}
+					service, err := s.serviceRepo.FindByID(serviceID)
+					if err != nil {
+						continue
+					}
+					if _, err := s.Check(service); err != nil {
+						log.Printf("Domain check of service %s failed: %v", serviceID, err)
+					}
+				}
+			}
+		}()
+	})
+}
+
+// allDomainsReachable reports whether every checked hostname is reachable
+func allDomainsReachable(statuses []models.DomainStatus) bool {
+	for _, status := range statuses {
+		if status.Status != models.DomainReachable {
+			return false
+		}
+	}
+	return true
+}
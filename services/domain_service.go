@@ -0,0 +1,42 @@
+package services
+
+import (
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/utils"
+)
+
+// DomainService reports the base wildcard domains configured on the platform
+type DomainService struct{}
+
+// NewDomainService creates a new domain service instance
+func NewDomainService() *DomainService {
+	return &DomainService{}
+}
+
+// ListDomains returns the base domains projects can select
+func (s *DomainService) ListDomains() dto.DomainListResponse {
+	return dto.DomainListResponse{
+		Default: utils.GetDefaultDomain(),
+		Domains: utils.GetPlatformDomains(),
+	}
+}
+
+// CheckDomains verifies wildcard DNS and TLS for every configured base domain
+func (s *DomainService) CheckDomains() []dto.DomainCheck {
+	domains := utils.GetPlatformDomains()
+	checks := make([]dto.DomainCheck, 0, len(domains))
+	for _, domain := range domains {
+		checks = append(checks, utils.CheckBaseDomain(domain))
+	}
+	return checks
+}
+
+// GetDNS01Status reports the DNS-01 provider configuration and issuer readiness
+func (s *DomainService) GetDNS01Status() dto.DNS01Status {
+	return utils.GetDNS01Status()
+}
+
+// ApplyDNS01Issuer (re)creates the DNS-01 ClusterIssuer from the configured credentials
+func (s *DomainService) ApplyDNS01Issuer() (dto.DNS01Status, error) {
+	return utils.EnsureDNS01Issuer()
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// DriftService detects manual changes to the cluster objects of git services and
+// re-applies the declared spec on request
+type DriftService struct {
+	serviceRepo       *repositories.ServiceRepository
+	projectRepo       *repositories.ProjectRepository
+	deploymentRepo    *repositories.DeploymentRepository
+	deploymentService *DeploymentService
+}
+
+// NewDriftService creates a new drift service instance
+func NewDriftService() *DriftService {
+	return &DriftService{
+		serviceRepo:       repositories.NewServiceRepository(),
+		projectRepo:       repositories.NewProjectRepository(),
+		deploymentRepo:    repositories.NewDeploymentRepository(),
+		deploymentService: NewDeploymentService(),
+	}
+}
+
+// DetectDrift compares the live Deployment, Service and Ingresses of a git service with
+// the spec generated from its config and latest successful image
+func (s *DriftService) DetectDrift(serviceID string, userID string, isAdmin bool) (dto.ServiceDriftReport, error) {
+	service, image, err := s.loadDeployedService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceDriftReport{}, err
+	}
+	return s.report(service, image)
+}
+
+// Resync re-applies the declared spec over manual changes and reports the drift left
+func (s *DriftService) Resync(serviceID string, userID string, isAdmin bool) (dto.ServiceDriftReport, error) {
+	service, image, err := s.loadDeployedService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceDriftReport{}, err
+	}
+	if service.Status == "paused" || service.Status == "archived" {
+		return dto.ServiceDriftReport{}, fmt.Errorf("service is %s; resume it instead of re-syncing", service.Status)
+	}
+
+	updatedService, deployErr := s.deploymentService.DeployToKubernetes(image, service)
+	if err := s.serviceRepo.Update(*updatedService); err != nil {
+		log.Printf("Failed to save service %s after re-sync: %v", serviceID, err)
+	}
+	if deployErr != nil {
+		return dto.ServiceDriftReport{}, deployErr
+	}
+	log.Printf("Service %s re-synced to its declared spec by %s", serviceID, userID)
+	return s.report(*updatedService, image)
+}
+
+func (s *DriftService) report(service models.Service, image string) (dto.ServiceDriftReport, error) {
+	drifts, err := utils.DetectServiceDrift(image, resolveClusterConfig(service))
+	if err != nil {
+		return dto.ServiceDriftReport{}, err
+	}
+	if drifts == nil {
+		drifts = []dto.ResourceDrift{}
+	}
+	return dto.ServiceDriftReport{
+		ServiceID: service.ID,
+		Image:     image,
+		InSync:    len(drifts) == 0,
+		Resources: drifts,
+		CheckedAt: time.Now(),
+	}, nil
+}
+
+// loadDeployedService loads a git service the user may manage, with the image it runs
+func (s *DriftService) loadDeployedService(serviceID string, userID string, isAdmin bool) (models.Service, string, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, "", fmt.Errorf("service not found: %v", err)
+	}
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, "", err
+		}
+		if ownerID != userID {
+			return service, "", errors.New("unauthorized access to service")
+		}
+	}
+	if service.Type != models.ServiceTypeGit {
+		return service, "", errors.New("drift detection is only available for git services")
+	}
+
+	deployment, err := s.deploymentRepo.GetLatestSuccessfulDeployment(serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && deployment.Image == "") {
+		return service, "", errors.New("service has no successful deployment to compare against")
+	}
+	if err != nil {
+		return service, "", err
+	}
+	return service, deployment.Image, nil
+}
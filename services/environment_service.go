@@ -4,26 +4,47 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
 )
 
 // EnvironmentService handles business logic for environments
 type EnvironmentService struct {
-	environmentRepo *repositories.EnvironmentRepository
-	projectRepo     *repositories.ProjectRepository
+	environmentRepo  *repositories.EnvironmentRepository
+	projectRepo      *repositories.ProjectRepository
+	serviceRepo      *repositories.ServiceRepository
+	priorityTierRepo *repositories.PriorityTierRepository
+	managedService   *ManagedServiceService
+	errorPageService *ErrorPageService
 }
 
 // NewEnvironmentService creates a new environment service instance
 func NewEnvironmentService() *EnvironmentService {
 	return &EnvironmentService{
-		environmentRepo: repositories.NewEnvironmentRepository(),
-		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo:  repositories.NewEnvironmentRepository(),
+		projectRepo:      repositories.NewProjectRepository(),
+		serviceRepo:      repositories.NewServiceRepository(),
+		priorityTierRepo: repositories.NewPriorityTierRepository(),
+		managedService:   NewManagedServiceService(),
+		errorPageService: NewErrorPageService(),
 	}
 }
 
+// ProtectedServicesError reports deletion-protected services blocking a delete
+type ProtectedServicesError struct {
+	Services []string // service names
+}
+
+func (e *ProtectedServicesError) Error() string {
+	return fmt.Sprintf("deletion protected service(s): %s; disable protection first", strings.Join(e.Services, ", "))
+}
+
 // ListEnvironments retrieves all environments for a project
 func (s *EnvironmentService) ListEnvironments(projectID string, userID string, isAdmin bool) ([]models.Environment, error) {
 	// Check if user can access this project
@@ -78,6 +99,15 @@ func (s *EnvironmentService) CreateEnvironment(env models.Environment, userID st
 		}
 	}
 	
+	// A new namespace would outlive a project being deleted
+	project, err := s.projectRepo.FindByID(env.ProjectID)
+	if err != nil {
+		return env, err
+	}
+	if project.Status == models.ProjectStatusDeleting {
+		return models.Environment{}, ErrProjectDeleting
+	}
+	
 	// Validate environment name uniqueness within project
 	exists, err := s.environmentRepo.ExistsByNameAndProject(env.Name, env.ProjectID)
 	if err != nil {
@@ -88,23 +118,37 @@ func (s *EnvironmentService) CreateEnvironment(env models.Environment, userID st
 		return models.Environment{}, fmt.Errorf("environment with name '%s' already exists in this project", env.Name)
 	}
 	
+	if err := utils.ValidateEnvironmentResources(env); err != nil {
+		return env, err
+	}
+
 	// Create the environment
-	return s.environmentRepo.Create(env)
+	created, err := s.environmentRepo.Create(env)
+	if err != nil {
+		return created, err
+	}
+	if err := s.errorPageService.ApplyToEnvironment(created); err != nil {
+		log.Printf("Failed to apply the project's error page to environment %s: %v", created.ID, err)
+	}
+	return created, nil
 }
 
-// UpdateEnvironment updates an existing environment
-func (s *EnvironmentService) UpdateEnvironment(env models.Environment, userID string, isAdmin bool) (models.Environment, error) {
+// UpdateEnvironment renames an environment or changes its description, service defaults and
+// domain template. The namespace is named after the environment ID, so a rename only touches
+// the Ingresses of services whose hostnames include the environment name.
+func (s *EnvironmentService) UpdateEnvironment(environmentID string, req dto.EnvironmentUpdateRequest, userID string, isAdmin bool) (models.Environment, error) {
 	// Fetch current environment
-	currentEnv, err := s.environmentRepo.FindByID(env.ID)
+	currentEnv, err := s.environmentRepo.FindByID(environmentID)
 	if err != nil {
-		return env, err
+		return currentEnv, err
 	}
+	previousTemplate := utils.ExpandEnvironmentDomainTemplate(currentEnv)
 	
 	// Check if user can access this project
 	if !isAdmin {
 		ownerID, err := s.projectRepo.GetOwnerID(currentEnv.ProjectID)
 		if err != nil {
-			return env, err
+			return currentEnv, err
 		}
 		
 		if ownerID != userID {
@@ -113,32 +157,192 @@ func (s *EnvironmentService) UpdateEnvironment(env models.Environment, userID st
 	}
 	
 	// If name is changing, check uniqueness
-	if env.Name != currentEnv.Name {
-		exists, err := s.environmentRepo.ExistsByNameAndProject(env.Name, currentEnv.ProjectID)
+	if req.Name != "" && req.Name != currentEnv.Name {
+		exists, err := s.environmentRepo.ExistsByNameAndProject(req.Name, currentEnv.ProjectID)
 		if err != nil {
-			return env, err
+			return currentEnv, err
 		}
 		
 		if exists {
-			return models.Environment{}, fmt.Errorf("environment with name '%s' already exists in this project", env.Name)
+			return models.Environment{}, fmt.Errorf("environment with name '%s' already exists in this project", req.Name)
 		}
+		currentEnv.Name = req.Name
 	}
 	
-	// Update only allowed fields
-	currentEnv.Name = env.Name
-	currentEnv.Description = env.Description
+	// Update only the fields provided
+	if req.Description != nil {
+		currentEnv.Description = *req.Description
+	}
+	if req.DefaultCPULimit != nil {
+		currentEnv.DefaultCPULimit = *req.DefaultCPULimit
+	}
+	if req.DefaultMemoryLimit != nil {
+		currentEnv.DefaultMemoryLimit = *req.DefaultMemoryLimit
+	}
+	if req.DefaultReplicas != nil {
+		currentEnv.DefaultReplicas = *req.DefaultReplicas
+	}
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{req.DefaultStorageSize, &currentEnv.DefaultStorageSize},
+		{req.MinCPULimit, &currentEnv.MinCPULimit},
+		{req.MaxCPULimit, &currentEnv.MaxCPULimit},
+		{req.MinMemoryLimit, &currentEnv.MinMemoryLimit},
+		{req.MaxMemoryLimit, &currentEnv.MaxMemoryLimit},
+		{req.MaxStorageSize, &currentEnv.MaxStorageSize},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	// Defaults and bounds may come from separate requests, so check them together
+	if err := utils.ValidateEnvironmentResources(currentEnv); err != nil {
+		return currentEnv, err
+	}
+	if req.PriorityTier != nil && *req.PriorityTier != currentEnv.PriorityTier {
+		if !isAdmin {
+			return models.Environment{}, errors.New("only admins can change the priority tier of an environment")
+		}
+		if *req.PriorityTier != "" {
+			if _, err := s.priorityTierRepo.FindByName(*req.PriorityTier); err != nil {
+				return models.Environment{}, fmt.Errorf("priority tier '%s' does not exist", *req.PriorityTier)
+			}
+		}
+		currentEnv.PriorityTier = *req.PriorityTier
+	}
+	if req.PromotionApprovalRequired != nil {
+		currentEnv.PromotionApprovalRequired = *req.PromotionApprovalRequired
+	}
+	if req.EnvVarApprovalRequired != nil {
+		currentEnv.EnvVarApprovalRequired = *req.EnvVarApprovalRequired
+	}
+	if req.DomainTemplate != nil {
+		currentEnv.DomainTemplate = *req.DomainTemplate
+	}
 	
 	// Save changes
 	err = s.environmentRepo.Update(currentEnv)
 	if err != nil {
 		return currentEnv, err
 	}
+
+	// A new template, or a rename with {env} in it, moves the git services to new hostnames
+	if utils.ExpandEnvironmentDomainTemplate(currentEnv) != previousTemplate {
+		failed, err := regenerateEnvironmentDomains(s.serviceRepo, currentEnv)
+		if err != nil {
+			return currentEnv, err
+		}
+		for serviceID, message := range failed {
+			log.Printf("Failed to move service %s to the domain template of environment %s: %s", serviceID, currentEnv.ID, message)
+		}
+	}
 	
 	return currentEnv, nil
 }
 
-// DeleteEnvironment removes an environment and its associated Kubernetes namespace if it has no associated services
-func (s *EnvironmentService) DeleteEnvironment(environmentID string, userID string, isAdmin bool) error {
+// ArchiveEnvironment scales every service of the environment to zero. Volumes, images
+// and configuration are kept, so UnarchiveEnvironment brings the services back as they were.
+func (s *EnvironmentService) ArchiveEnvironment(environmentID string, userID string, isAdmin bool) (models.Environment, dto.EnvironmentArchiveResponse, error) {
+	env, err := s.GetEnvironmentDetail(environmentID, userID, isAdmin)
+	if err != nil {
+		return env, dto.EnvironmentArchiveResponse{}, err
+	}
+	if env.IsArchived() {
+		return env, dto.EnvironmentArchiveResponse{}, errors.New("environment is already archived")
+	}
+
+	// Mark the environment first so deployments and pause schedules leave it alone
+	now := time.Now()
+	env.ArchivedAt = &now
+	if err := s.environmentRepo.Update(env); err != nil {
+		return env, dto.EnvironmentArchiveResponse{}, err
+	}
+
+	result, err := s.scaleEnvironmentServices(environmentID, true)
+	return env, result, err
+}
+
+// UnarchiveEnvironment scales the services of an archived environment back up
+func (s *EnvironmentService) UnarchiveEnvironment(environmentID string, userID string, isAdmin bool) (models.Environment, dto.EnvironmentArchiveResponse, error) {
+	env, err := s.GetEnvironmentDetail(environmentID, userID, isAdmin)
+	if err != nil {
+		return env, dto.EnvironmentArchiveResponse{}, err
+	}
+	if !env.IsArchived() {
+		return env, dto.EnvironmentArchiveResponse{}, errors.New("environment is not archived")
+	}
+
+	env.ArchivedAt = nil
+	if err := s.environmentRepo.Update(env); err != nil {
+		return env, dto.EnvironmentArchiveResponse{}, err
+	}
+
+	result, err := s.scaleEnvironmentServices(environmentID, false)
+	return env, result, err
+}
+
+// scaleEnvironmentServices scales each service down to zero (status "archived") or back up.
+// Failures are collected per service so one broken workload does not block the rest.
+func (s *EnvironmentService) scaleEnvironmentServices(environmentID string, scaleDown bool) (dto.EnvironmentArchiveResponse, error) {
+	result := dto.EnvironmentArchiveResponse{Scaled: make([]string, 0)}
+
+	services, err := s.serviceRepo.FindByEnvironmentID(environmentID)
+	if err != nil {
+		return result, fmt.Errorf("failed to load environment services: %v", err)
+	}
+
+	for _, service := range services {
+		if scaleDown == (service.Status == "archived") {
+			continue
+		}
+		// Never-deployed and paused services have nothing to scale down
+		if scaleDown && (service.Status == "inactive" || service.Status == "paused") {
+			continue
+		}
+
+		var scaleErr error
+		switch {
+		case service.Type == models.ServiceTypeManaged && scaleDown:
+			scaleErr = utils.ScaleManagedService(service, 0)
+		case service.Type == models.ServiceTypeManaged:
+			scaleErr = utils.ScaleManagedService(service, utils.GetManagedServiceDataReplicas(service))
+		default:
+			scaleErr = utils.ScaleGitService(service, scaleDown)
+		}
+		if scaleErr != nil {
+			log.Printf("Failed to scale service %s in environment %s: %v", service.ID, environmentID, scaleErr)
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[service.ID] = scaleErr.Error()
+			continue
+		}
+
+		if scaleDown {
+			service.Status = "archived"
+		} else if service.Type == models.ServiceTypeManaged {
+			service.Status = "starting"
+		} else {
+			service.Status = "running"
+		}
+		if err := s.serviceRepo.Update(service); err != nil {
+			log.Printf("Failed to update status of service %s: %v", service.ID, err)
+		}
+		if service.Status == "starting" {
+			go s.managedService.waitUntilReady(service)
+		}
+		result.Scaled = append(result.Scaled, service.ID)
+	}
+
+	return result, nil
+}
+
+// DeleteEnvironment removes an environment and its associated Kubernetes namespace.
+// Environments with services are only deleted with force, which deletes the services
+// too; deletion-protected services always block the delete.
+func (s *EnvironmentService) DeleteEnvironment(environmentID string, force bool, userID string, isAdmin bool) error {
 	// Fetch the environment
 	env, err := s.environmentRepo.FindByID(environmentID)
 	if err != nil {
@@ -158,13 +362,30 @@ func (s *EnvironmentService) DeleteEnvironment(environmentID string, userID stri
 	}
 	
 	// Check if environment has services
-	count, err := s.environmentRepo.CountServicesInEnvironment(environmentID)
+	services, err := s.serviceRepo.FindByEnvironmentID(environmentID)
 	if err != nil {
 		return err
 	}
 	
-	if count > 0 {
-		return errors.New("cannot delete environment that has services")
+	var protected []string
+	for _, service := range services {
+		if service.DeletionProtected {
+			protected = append(protected, service.Name)
+		}
+	}
+	if len(protected) > 0 {
+		return &ProtectedServicesError{Services: protected}
+	}
+	
+	if len(services) > 0 && !force {
+		return errors.New("cannot delete environment that has services (use force to delete them too)")
+	}
+	
+	serviceService := NewServiceService()
+	for _, service := range services {
+		if err := serviceService.DeleteService(service.ID, userID, isAdmin); err != nil {
+			return fmt.Errorf("failed to delete service %s: %v", service.Name, err)
+		}
 	}
 	
 	// Init Kubernetes client
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ErrorPageService manages the branded pages the ingress serves when a project's service
+// answers 502, 503 or 504, e.g. while its backend is down or scaled to zero
+type ErrorPageService struct {
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+	serviceRepo     *repositories.ServiceRepository
+}
+
+// NewErrorPageService creates a new error page service instance
+func NewErrorPageService() *ErrorPageService {
+	return &ErrorPageService{
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+	}
+}
+
+// GetErrorPage returns the error page config of a project; nil when it has none
+func (s *ErrorPageService) GetErrorPage(projectID string, userID string, isAdmin bool) (*models.ErrorPageConfig, error) {
+	project, err := s.findAccessibleProject(projectID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return project.ErrorPage, nil
+}
+
+// SetErrorPage stores the project's error page and serves it in every environment
+func (s *ErrorPageService) SetErrorPage(projectID string, config models.ErrorPageConfig, userID string, isAdmin bool) (*models.ErrorPageConfig, error) {
+	project, err := s.findAccessibleProject(projectID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.GetIngressProvider().SupportsMiddlewares() {
+		return nil, utils.ErrMiddlewaresUnsupported
+	}
+	config.Title = strings.TrimSpace(config.Title)
+	config.LogoURL = strings.TrimSpace(config.LogoURL)
+	if err := utils.ValidateErrorPage(config); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectRepo.UpdateErrorPage(projectID, &config); err != nil {
+		return nil, fmt.Errorf("failed to update project: %v", err)
+	}
+	project.ErrorPage = &config
+	if err := s.applyToEnvironments(project); err != nil {
+		return &config, fmt.Errorf("error page saved but not applied everywhere: %v", err)
+	}
+	return &config, nil
+}
+
+// RemoveErrorPage goes back to Traefik's own error responses in every environment
+func (s *ErrorPageService) RemoveErrorPage(projectID string, userID string, isAdmin bool) error {
+	project, err := s.findAccessibleProject(projectID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if project.ErrorPage == nil {
+		return errors.New("the project has no error page")
+	}
+
+	if err := s.projectRepo.UpdateErrorPage(projectID, nil); err != nil {
+		return fmt.Errorf("failed to update project: %v", err)
+	}
+	project.ErrorPage = nil
+	if err := s.applyToEnvironments(project); err != nil {
+		return fmt.Errorf("error page removed but not from everywhere: %v", err)
+	}
+	return nil
+}
+
+// PreviewErrorPage renders the page the project's services answer the status with
+func (s *ErrorPageService) PreviewErrorPage(projectID string, status int, userID string, isAdmin bool) (string, error) {
+	project, err := s.findAccessibleProject(projectID, userID, isAdmin)
+	if err != nil {
+		return "", err
+	}
+	if project.ErrorPage == nil {
+		return "", errors.New("the project has no error page")
+	}
+	if _, ok := utils.ErrorPageStatusHeading(status); !ok {
+		return "", fmt.Errorf("status must be one of %v", utils.ErrorPageStatuses)
+	}
+	return utils.RenderErrorPage(project.Name, *project.ErrorPage, status)
+}
+
+// ApplyToEnvironment serves the project's error page, if any, in a new environment
+func (s *ErrorPageService) ApplyToEnvironment(environment models.Environment) error {
+	project, err := s.projectRepo.FindByID(environment.ProjectID)
+	if err != nil || project.ErrorPage == nil {
+		return err
+	}
+	return utils.ApplyErrorPages(environment.ID, project.Name, *project.ErrorPage)
+}
+
+// applyToEnvironments applies or removes the error pages of every environment of the
+// project, then re-applies the Ingresses of its deployed git services so they add or drop
+// the errors Middleware
+func (s *ErrorPageService) applyToEnvironments(project models.Project) error {
+	environments, err := s.environmentRepo.FindByProjectID(project.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %v", err)
+	}
+
+	var failures []string
+	for _, environment := range environments {
+		if project.ErrorPage != nil {
+			err = utils.ApplyErrorPages(environment.ID, project.Name, *project.ErrorPage)
+		} else {
+			err = utils.DeleteErrorPages(environment.ID)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("environment %s: %v", environment.Name, err))
+		}
+	}
+
+	services, err := s.serviceRepo.FindByProjectID(project.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %v", err)
+	}
+	for _, service := range services {
+		if service.Type != models.ServiceTypeGit {
+			continue
+		}
+		if err := utils.ApplyServiceIngress(service); err != nil {
+			log.Printf("Failed to update the ingress of service %s for its error page: %v", service.ID, err)
+			failures = append(failures, fmt.Sprintf("service %s: %v", service.Name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (s *ErrorPageService) findAccessibleProject(projectID string, userID string, isAdmin bool) (models.Project, error) {
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return project, err
+	}
+	if !isAdmin && project.UserID != userID {
+		return project, errors.New("unauthorized access to project")
+	}
+	return project, nil
+}
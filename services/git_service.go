@@ -17,6 +17,7 @@ type GitService struct {
 	serviceRepo       *repositories.ServiceRepository
 	deploymentRepo    *repositories.DeploymentRepository
 	deploymentService *DeploymentService
+	deployLocks       *DeployLockService
 }
 
 // NewGitService creates a new git service instance
@@ -27,6 +28,7 @@ func NewGitService() *GitService {
 		serviceRepo:       repositories.NewServiceRepository(),
 		deploymentRepo:    repositories.NewDeploymentRepository(),
 		deploymentService: NewDeploymentService(),
+		deployLocks:       NewDeployLockService(),
 	}
 }
 
@@ -54,6 +56,11 @@ func (s *GitService) CreateGitService(service models.Service, userID string, isA
 		return service, errors.New("environment does not belong to the specified project")
 	}
 
+	// Creating a service deploys it right away
+	if err := s.deployLocks.CheckDeployAllowed(service, isAdmin); err != nil {
+		return service, err
+	}
+
 	// Validate service fields for git type
 	if service.RepoURL == "" {
 		return service, errors.New("repository URL is required for git services")
@@ -72,6 +79,7 @@ func (s *GitService) CreateGitService(service models.Service, userID string, isA
 		APIKey:        service.APIKey,
 		CommitID:      "",
 		CommitMessage: "Init",
+		ByAdmin:       isAdmin,
 	})
 
 	// Create the service
@@ -145,6 +153,29 @@ func (s *GitService) UpdateGitService(newService models.Service, userID string,
 		updatedService.StartCommand = newService.StartCommand
 	}
 	
+	if newService.ArtifactPath != "" {
+		updatedService.ArtifactPath = newService.ArtifactPath
+	}
+	
+	updatedService.DockerfilePath = newService.DockerfilePath
+	if newService.BuildArgs != nil {
+		updatedService.BuildArgs = newService.BuildArgs
+	}
+	
+	updatedService.TestCommand = newService.TestCommand
+	if newService.TestImage != "" {
+		updatedService.TestImage = newService.TestImage
+	}
+	if updatedService.TestCommand != "" && updatedService.TestImage == "" {
+		var errs utils.FieldErrors
+		errs.Add("git.testImage", "is required with a test command")
+		return newService, errs
+	}
+	
+	if newService.BuildPlatforms != "" {
+		updatedService.BuildPlatforms = newService.BuildPlatforms
+	}
+	
 	// Update resource constraints if provided
 	if newService.CPULimit != "" {
 		updatedService.CPULimit = newService.CPULimit
@@ -171,17 +202,62 @@ func (s *GitService) UpdateGitService(newService models.Service, userID string,
 		updatedService.MaxReplicas = newService.MaxReplicas
 	}
 	
+	updatedService.HighAvailability = newService.HighAvailability
+	updatedService.TerminationGracePeriodSeconds = newService.TerminationGracePeriodSeconds
+	updatedService.PreStopCommand = newService.PreStopCommand
+	updatedService.Command = newService.Command
+	updatedService.Args = newService.Args
+	updatedService.WorkingDir = newService.WorkingDir
+	updatedService.AutoCorrectPort = newService.AutoCorrectPort
+	updatedService.SecretEnvKeys = newService.SecretEnvKeys
+	updatedService.BuildEnvKeys = newService.BuildEnvKeys
+	updatedService.CloneDepth = newService.CloneDepth
+	updatedService.BuildTimeoutMinutes = newService.BuildTimeoutMinutes
+	updatedService.MaxImageSize = newService.MaxImageSize
+	updatedService.ImageSizeBudgetAction = newService.ImageSizeBudgetAction
+	updatedService.SparseCheckoutPaths = newService.SparseCheckoutPaths
+	updatedService.BuildBackend = newService.BuildBackend
+	updatedService.BuildSecrets = newService.BuildSecrets
+	var backendErrs utils.FieldErrors
+	backendErrs.CheckBuildBackend("git.buildBackend", updatedService.BuildBackend, utils.RequiredBuildCapabilities(updatedService))
+	if err := backendErrs.Err(); err != nil {
+		return newService, err
+	}
+	
 	// Update custom domain if provided
 	if newService.CustomDomain != "" {
 		updatedService.CustomDomain = newService.CustomDomain
 	}
 	
+	if newService.TLSChallenge != "" {
+		updatedService.TLSChallenge = newService.TLSChallenge
+	}
+	
+	if newService.ServiceAccountAnnotations != nil {
+		updatedService.ServiceAccountAnnotations = newService.ServiceAccountAnnotations
+	}
+	
+	if newService.PodLabels != nil {
+		updatedService.PodLabels = newService.PodLabels
+	}
+	
+	if newService.PodAnnotations != nil {
+		updatedService.PodAnnotations = newService.PodAnnotations
+	}
+	
 	// Update environment variables if provided
 	if newService.EnvVars != nil && len(newService.EnvVars) > 0 {
 		log.Println("update env vars")
-		// If we want to completely replace env vars
-		updatedService.EnvVars = newService.EnvVars
-		log.Println(updatedService.EnvVars)
+		// If we want to completely replace env vars; masked values sent back keep the secret
+		updatedService.EnvVars = utils.KeepMaskedEnvValues(newService.EnvVars, existingService.EnvVars)
+	}
+
+	// Changes that are rolled out are blocked by deploy locks; those only stored are not
+	plan := utils.PlanServiceUpdate(existingService, updatedService)
+	if plan.Action != utils.UpdateActionNone {
+		if err := s.deployLocks.CheckDeployAllowed(existingService, isAdmin); err != nil {
+			return newService, err
+		}
 	}
 
 	// Update service in the database
@@ -190,6 +266,19 @@ func (s *GitService) UpdateGitService(newService models.Service, userID string,
 		return newService, errUpdate
 	}
 
+	// Changes the running image does not depend on are rolled out without a rebuild, and
+	// changes that only need storing are not rolled out at all
+	switch plan.Action {
+	case utils.UpdateActionNone:
+		return s.serviceRepo.FindByID(newService.ID)
+	case utils.UpdateActionRestart:
+		latest, err := s.deploymentRepo.GetLatestSuccessfulDeployment(updatedService.ID)
+		if err == nil && latest.Image != "" && updatedService.Status != "paused" && updatedService.Status != "archived" {
+			go s.applyConfigChanges(updatedService, latest.Image)
+			return s.serviceRepo.FindByID(newService.ID)
+		}
+	}
+
 	// Trigger redeployment for git services
 	deployment, errDeployment := s.deploymentRepo.GetLatestDeployment(updatedService.ID)
 	if errDeployment == nil {
@@ -205,12 +294,27 @@ func (s *GitService) UpdateGitService(newService models.Service, userID string,
 			APIKey:        updatedService.APIKey,
 			CommitID:      deployment.CommitSHA,
 			CommitMessage: deployment.CommitMessage,
+			ByAdmin:       isAdmin,
 		})
 	}
 	// Fetch the updated service with its relationships
 	return s.serviceRepo.FindByID(newService.ID)
 }
 
+// applyConfigChanges rolls the running image out again with the service's new config,
+// without building a new image
+func (s *GitService) applyConfigChanges(service models.Service, image string) {
+	updatedService, err := s.deploymentService.DeployToKubernetes(image, service)
+	if saveErr := s.serviceRepo.Update(*updatedService); saveErr != nil {
+		log.Printf("Failed to save service %s after applying config changes: %v", service.ID, saveErr)
+	}
+	if err != nil {
+		log.Printf("Failed to apply config changes of service %s: %v", service.ID, err)
+		return
+	}
+	log.Printf("Config changes of service %s applied without a rebuild", service.ID)
+}
+
 // deleteGitService handles git service deletion (MOVED from original DeleteService)
 func (s *GitService) DeleteGitService(serviceID string, userID string, isAdmin bool) error {
 	// Fetch the service
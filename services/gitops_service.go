@@ -0,0 +1,366 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultGitOpsBranch      = "main"
+	defaultGitOpsPollMinutes = 5
+	// gitopsPollerInterval is how often the poller looks for configurations that are due
+	gitopsPollerInterval = time.Minute
+)
+
+var (
+	gitopsPollerOnce sync.Once
+	// gitopsSyncing holds the projects being synced, so a push during a poll does not
+	// apply the same spec twice at once
+	gitopsSyncing sync.Map
+)
+
+var (
+	// ErrGitOpsNotConfigured is returned for projects without a config repository
+	ErrGitOpsNotConfigured = errors.New("GitOps is not configured for this project")
+	// ErrGitOpsSyncInProgress is returned when the project is already being synced
+	ErrGitOpsSyncInProgress = errors.New("a GitOps sync of this project is already running")
+	// ErrGitOpsSyncFailed is returned when the spec could not be read or some resources
+	// could not be applied
+	ErrGitOpsSyncFailed = errors.New("GitOps sync failed")
+	// ErrGitOpsInvalidSignature is returned for webhooks not signed with the project's secret
+	ErrGitOpsInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// GitOpsService connects projects to a config repository and reconciles the environments
+// and services of its spec file into the platform on push and on a schedule
+type GitOpsService struct {
+	gitopsRepo         *repositories.GitOpsRepository
+	projectRepo        *repositories.ProjectRepository
+	declarativeService *DeclarativeService
+}
+
+// NewGitOpsService creates a new GitOps service instance
+func NewGitOpsService() *GitOpsService {
+	return &GitOpsService{
+		gitopsRepo:         repositories.NewGitOpsRepository(),
+		projectRepo:        repositories.NewProjectRepository(),
+		declarativeService: NewDeclarativeService(),
+	}
+}
+
+// GetConfig returns the GitOps configuration of a project
+func (s *GitOpsService) GetConfig(projectID string, userID string, isAdmin bool) (dto.GitOpsConfigResponse, error) {
+	config, err := s.getConfig(projectID, userID, isAdmin)
+	if err != nil {
+		return dto.GitOpsConfigResponse{}, err
+	}
+	return gitopsConfigResponse(config, ""), nil
+}
+
+// SaveConfig points a project at a config repository or changes its configuration. The
+// webhook secret is generated with the configuration, or on request, and only returned then.
+func (s *GitOpsService) SaveConfig(projectID string, req dto.GitOpsConfigRequest, userID string, isAdmin bool) (dto.GitOpsConfigResponse, bool, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return dto.GitOpsConfigResponse{}, false, err
+	}
+
+	config, err := s.gitopsRepo.FindByProjectID(projectID)
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return dto.GitOpsConfigResponse{}, false, err
+	}
+	if created {
+		config = models.GitOpsConfig{
+			ProjectID:   projectID,
+			Branch:      defaultGitOpsBranch,
+			Path:        models.DefaultGitOpsSpecPath,
+			PollMinutes: defaultGitOpsPollMinutes,
+		}
+	}
+
+	if req.RepoURL != config.RepoURL || (req.Branch != "" && req.Branch != config.Branch) || (req.Path != "" && req.Path != config.Path) {
+		// Another spec: sync it even if the commit was synced before
+		config.LastSyncedCommit = ""
+	}
+	config.RepoURL = req.RepoURL
+	if req.Branch != "" {
+		config.Branch = req.Branch
+	}
+	if req.Path != "" {
+		config.Path = req.Path
+	}
+	if req.GitUsername != "" {
+		config.GitUsername = req.GitUsername
+	}
+	if req.GitToken != "" {
+		config.GitToken = req.GitToken
+	}
+	if req.PollMinutes != nil {
+		config.PollMinutes = *req.PollMinutes
+	}
+	if req.Paused != nil {
+		config.Paused = *req.Paused
+	}
+
+	var secret string
+	if created || req.RotateWebhookSecret {
+		if secret, err = generateGitOpsWebhookSecret(); err != nil {
+			return dto.GitOpsConfigResponse{}, false, err
+		}
+		config.WebhookSecret = secret
+	}
+
+	if created {
+		config, err = s.gitopsRepo.Create(config)
+	} else {
+		config, err = s.gitopsRepo.Update(config)
+	}
+	if err != nil {
+		return dto.GitOpsConfigResponse{}, false, err
+	}
+	log.Printf("GitOps of project %s: syncing %s (%s) from %s", projectID, config.Path, config.Branch, config.RepoURL)
+	return gitopsConfigResponse(config, secret), created, nil
+}
+
+// DeleteConfig disconnects the config repository of a project. The environments and
+// services it created are kept.
+func (s *GitOpsService) DeleteConfig(projectID string, userID string, isAdmin bool) error {
+	config, err := s.getConfig(projectID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.gitopsRepo.Delete(config.ID)
+}
+
+// SyncNow applies the spec file at the head of the branch right away, even if that commit
+// was synced before
+func (s *GitOpsService) SyncNow(projectID string, userID string, isAdmin bool) (dto.GitOpsSyncResult, error) {
+	config, err := s.getConfig(projectID, userID, isAdmin)
+	if err != nil {
+		return dto.GitOpsSyncResult{}, err
+	}
+	return s.sync(config, true)
+}
+
+// HandlePush authenticates a push webhook of the config repository and, for pushes to the
+// configured branch, syncs the project in the background. It reports whether a sync started.
+func (s *GitOpsService) HandlePush(projectID string, header http.Header, body []byte) (bool, error) {
+	config, err := s.gitopsRepo.FindByProjectID(projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, ErrGitOpsNotConfigured
+	}
+	if err != nil {
+		return false, err
+	}
+	if !utils.VerifyGitOpsWebhook(config.WebhookSecret, header, body) {
+		return false, ErrGitOpsInvalidSignature
+	}
+
+	branch, ok := utils.GitOpsPushBranch(body)
+	if !ok || branch != config.Branch || config.Paused {
+		return false, nil
+	}
+	go func() {
+		if _, err := s.sync(config, false); err != nil && !errors.Is(err, ErrGitOpsSyncInProgress) {
+			log.Printf("GitOps sync of project %s after push failed: %v", projectID, err)
+		}
+	}()
+	return true, nil
+}
+
+// StartGitOpsPoller periodically checks the config repositories whose poll interval has
+// elapsed for new commits, covering pushes whose webhook was lost
+func (s *GitOpsService) StartGitOpsPoller() {
+	gitopsPollerOnce.Do(func() {
+		go func() {
+			log.Printf("GitOps poller started (interval %v)", gitopsPollerInterval)
+			ticker := time.NewTicker(gitopsPollerInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.pollDueConfigs()
+			}
+		}()
+	})
+}
+
+func (s *GitOpsService) pollDueConfigs() {
+	configs, err := s.gitopsRepo.FindPolling()
+	if err != nil {
+		log.Printf("GitOps poller: failed to load configurations: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, config := range configs {
+		if !config.PollDue(now) {
+			continue
+		}
+		result, err := s.sync(config, false)
+		if err != nil && !errors.Is(err, ErrGitOpsSyncInProgress) {
+			log.Printf("GitOps poller: project %s: %v", config.ProjectID, err)
+		} else if err == nil && !result.Skipped {
+			log.Printf("GitOps poller: project %s synced at %s", config.ProjectID, result.Commit)
+		}
+	}
+}
+
+// sync applies the spec file at the head of the branch as the project owner. Unless forced,
+// a commit that was already synced without errors is skipped.
+func (s *GitOpsService) sync(config models.GitOpsConfig, force bool) (dto.GitOpsSyncResult, error) {
+	if _, running := gitopsSyncing.LoadOrStore(config.ProjectID, true); running {
+		return dto.GitOpsSyncResult{}, ErrGitOpsSyncInProgress
+	}
+	defer gitopsSyncing.Delete(config.ProjectID)
+
+	result := dto.GitOpsSyncResult{Resources: []dto.GitOpsResourceResult{}}
+	commit, err := utils.ResolveGitOpsCommit(config)
+	if err != nil {
+		return result, s.recordSync(config, commit, err.Error())
+	}
+	result.Commit = commit
+
+	if !force && commit == config.LastSyncedCommit && config.LastSyncStatus == models.GitOpsSyncSucceeded {
+		result.Skipped = true
+		if err := s.gitopsRepo.TouchCheck(config.ID, time.Now()); err != nil {
+			log.Printf("Failed to record GitOps check of project %s: %v", config.ProjectID, err)
+		}
+		return result, nil
+	}
+
+	content, err := utils.FetchGitOpsSpec(config, commit)
+	if err != nil {
+		return result, s.recordSync(config, commit, err.Error())
+	}
+	spec, err := utils.ParseGitOpsSpec(content)
+	if err != nil {
+		return result, s.recordSync(config, commit, err.Error())
+	}
+	ownerID, err := s.projectRepo.GetOwnerID(config.ProjectID)
+	if err != nil {
+		return result, s.recordSync(config, commit, fmt.Sprintf("failed to load project owner: %v", err))
+	}
+
+	result.Resources = s.applySpec(config, spec, ownerID)
+	var failures []string
+	for _, resource := range result.Resources {
+		if resource.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s %s: %s", resource.Kind, resource.Name, resource.Error))
+		}
+	}
+	return result, s.recordSync(config, commit, strings.Join(failures, "; "))
+}
+
+// applySpec applies the environments of the spec, then their services, in name order. The
+// services of an environment that could not be applied are left out.
+func (s *GitOpsService) applySpec(config models.GitOpsConfig, spec dto.GitOpsSpec, ownerID string) []dto.GitOpsResourceResult {
+	results := []dto.GitOpsResourceResult{}
+	for _, envName := range sortedKeys(spec.Environments) {
+		envSpec := spec.Environments[envName]
+		env, applied, err := s.declarativeService.ApplyEnvironment(config.ProjectID, envName, envSpec.EnvironmentApplyRequest, ownerID, false)
+		results = append(results, gitopsResourceResult("environment", envName, applied, err))
+		if err != nil {
+			continue
+		}
+
+		for _, serviceName := range sortedKeys(envSpec.Services) {
+			serviceSpec := envSpec.Services[serviceName]
+			if serviceSpec.Type == models.ServiceTypeGit && !serviceSpec.IsPublic {
+				serviceSpec.GitUsername = config.GitUsername
+				serviceSpec.GitToken = config.GitToken
+			}
+			_, applied, err := s.declarativeService.ApplyService(env.ID, serviceName, serviceSpec, ownerID, false)
+			results = append(results, gitopsResourceResult("service", envName+"/"+serviceName, applied, err))
+		}
+	}
+	return results
+}
+
+// recordSync stores the outcome of a sync; message lists what failed, empty on success
+func (s *GitOpsService) recordSync(config models.GitOpsConfig, commit string, message string) error {
+	status := models.GitOpsSyncSucceeded
+	if message != "" {
+		status = models.GitOpsSyncFailed
+	}
+	if err := s.gitopsRepo.RecordCheck(config.ID, commit, status, message, time.Now()); err != nil {
+		log.Printf("Failed to record GitOps sync of project %s: %v", config.ProjectID, err)
+	}
+	if message != "" {
+		return fmt.Errorf("%w: %s", ErrGitOpsSyncFailed, message)
+	}
+	return nil
+}
+
+func (s *GitOpsService) getConfig(projectID string, userID string, isAdmin bool) (models.GitOpsConfig, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.GitOpsConfig{}, err
+	}
+
+	config, err := s.gitopsRepo.FindByProjectID(projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.GitOpsConfig{}, ErrGitOpsNotConfigured
+	}
+	return config, err
+}
+
+func (s *GitOpsService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
+
+func gitopsConfigResponse(config models.GitOpsConfig, secret string) dto.GitOpsConfigResponse {
+	return dto.GitOpsConfigResponse{
+		GitOpsConfig:  config,
+		WebhookPath:   fmt.Sprintf("/api/v1/gitops/%s/webhook", config.ProjectID),
+		WebhookSecret: secret,
+	}
+}
+
+func gitopsResourceResult(kind string, name string, applied dto.ApplyResult, err error) dto.GitOpsResourceResult {
+	result := dto.GitOpsResourceResult{Kind: kind, Name: name, Created: applied.Created, Changed: applied.Changed}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func generateGitOpsWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+// Impersonation errors the API maps to client errors
+var (
+	ErrImpersonateSelf  = errors.New("you cannot impersonate yourself")
+	ErrImpersonateAdmin = errors.New("admins cannot be impersonated")
+	ErrNotImpersonating = errors.New("this session is not an impersonation")
+)
+
+// ImpersonationService lets support admins act as a user to reproduce permission-scoped
+// issues without the user's credentials. Every impersonation is a short-lived, revocable
+// session and is recorded in an audit trail.
+type ImpersonationService struct {
+	auditRepo *repositories.ImpersonationAuditRepository
+	sessions  *SessionService
+}
+
+// NewImpersonationService creates a new impersonation service instance
+func NewImpersonationService() *ImpersonationService {
+	return &ImpersonationService{
+		auditRepo: repositories.NewImpersonationAuditRepository(),
+		sessions:  NewSessionService(),
+	}
+}
+
+// Start opens a session in which the admin acts as the user
+func (s *ImpersonationService) Start(adminID string, userID string, reason string, client LoginClient) (dto.ImpersonationResponse, error) {
+	if adminID == userID {
+		return dto.ImpersonationResponse{}, ErrImpersonateSelf
+	}
+	admin, err := GetUser(adminID)
+	if err != nil {
+		return dto.ImpersonationResponse{}, err
+	}
+	user, err := GetUser(userID)
+	if err != nil {
+		return dto.ImpersonationResponse{}, err
+	}
+	// Acting as another admin would grant nothing support needs and hide who did what
+	if user.Role == models.RoleAdmin {
+		return dto.ImpersonationResponse{}, ErrImpersonateAdmin
+	}
+
+	session, token, err := s.sessions.CreateImpersonationSession(*admin, *user, reason, client, getImpersonationTTL())
+	if err != nil {
+		return dto.ImpersonationResponse{}, err
+	}
+	s.record(models.ImpersonationAuditLog{
+		SessionID:      session.ID,
+		ImpersonatorID: admin.ID,
+		UserID:         user.ID,
+		Action:         models.ImpersonationStarted,
+		Detail:         reason,
+		IPAddress:      client.IPAddress,
+	})
+	log.Printf("Admin %s started impersonating user %s: %s", admin.Email, user.Email, reason)
+
+	responseUser := *user
+	responseUser.Password = ""
+	return dto.ImpersonationResponse{
+		SessionID: session.ID,
+		Token:     token,
+		User:      responseUser,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// End closes an impersonation session; its token stops working at once
+func (s *ImpersonationService) End(sessionID string, userID string, impersonatorID string, ipAddress string) error {
+	if impersonatorID == "" {
+		return ErrNotImpersonating
+	}
+	if err := s.sessions.RevokeSession(sessionID, userID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	s.record(models.ImpersonationAuditLog{
+		SessionID:      sessionID,
+		ImpersonatorID: impersonatorID,
+		UserID:         userID,
+		Action:         models.ImpersonationEnded,
+		IPAddress:      ipAddress,
+	})
+	return nil
+}
+
+// RecordRequest adds a write request made while impersonating to the audit trail
+func (s *ImpersonationService) RecordRequest(claims *dto.TokenClaims, method, path string, status int, ipAddress string) {
+	s.record(models.ImpersonationAuditLog{
+		SessionID:      claims.ID,
+		ImpersonatorID: claims.ImpersonatorID,
+		UserID:         claims.UserID,
+		Action:         models.ImpersonationRequest,
+		Method:         method,
+		Path:           path,
+		StatusCode:     status,
+		IPAddress:      ipAddress,
+	})
+}
+
+// ListAudit returns a page of the impersonation audit trail, newest first
+func (s *ImpersonationService) ListAudit(impersonatorID string, userID string, page, pageSize int) (dto.ImpersonationAuditListResponse, error) {
+	entries, total, err := s.auditRepo.Find(impersonatorID, userID, page, pageSize)
+	if err != nil {
+		return dto.ImpersonationAuditListResponse{}, err
+	}
+	return dto.ImpersonationAuditListResponse{
+		Entries:    entries,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// record writes the audit trail; a failed write is logged so the action is still traceable
+func (s *ImpersonationService) record(entry models.ImpersonationAuditLog) {
+	if err := s.auditRepo.Create(entry); err != nil {
+		log.Printf("Failed to record impersonation %s by %s as %s (%s %s): %v",
+			entry.Action, entry.ImpersonatorID, entry.UserID, entry.Method, entry.Path, err)
+	}
+}
+
+// getImpersonationTTL reads IMPERSONATION_TTL_MINUTES (default 60)
+func getImpersonationTTL() time.Duration {
+	value := optionalEnvString("IMPERSONATION_TTL_MINUTES")
+	if value == nil {
+		return time.Hour
+	}
+	minutes, err := strconv.Atoi(*value)
+	if err != nil || minutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(minutes) * time.Minute
+}
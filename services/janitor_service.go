@@ -0,0 +1,92 @@
+package services
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+var janitorOnce sync.Once
+
+// JanitorService periodically removes leftover build and TLS resources and expired sessions
+type JanitorService struct {
+	environmentRepo *repositories.EnvironmentRepository
+}
+
+// NewJanitorService creates a new janitor service instance
+func NewJanitorService() *JanitorService {
+	return &JanitorService{
+		environmentRepo: repositories.NewEnvironmentRepository(),
+	}
+}
+
+// RunOnce performs a single cleanup pass with the configured TTL policy
+func (s *JanitorService) RunOnce() (dto.JanitorReport, error) {
+	policy := utils.DefaultJanitorPolicy()
+	report := dto.JanitorReport{
+		DeletedJobs:    []string{},
+		DeletedPods:    []string{},
+		DeletedSecrets: []string{},
+	}
+
+	if err := utils.CleanupBuildNamespace(policy, &report); err != nil {
+		return report, err
+	}
+
+	namespaces, err := s.environmentRepo.FindAllIDs()
+	if err != nil {
+		return report, err
+	}
+	if err := utils.CleanupStaleTLSSecrets(namespaces, policy.TLSSecretTTL, &report); err != nil {
+		return report, err
+	}
+
+	deleted, err := NewSessionService().PruneExpired()
+	if err != nil {
+		return report, err
+	}
+	report.DeletedSessions = deleted
+
+	deletedLinks, err := NewShareLinkService().PruneExpired()
+	if err != nil {
+		return report, err
+	}
+	report.DeletedShareLinks = deletedLinks
+
+	return report, nil
+}
+
+// StartJanitor starts the background cleanup loop (JANITOR_INTERVAL_MINUTES, default 30)
+func (s *JanitorService) StartJanitor() {
+	janitorOnce.Do(func() {
+		interval := time.Duration(getJanitorInterval()) * time.Minute
+		go func() {
+			log.Printf("Janitor started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if _, err := s.RunOnce(); err != nil {
+					log.Printf("Janitor run failed: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+func getJanitorInterval() int {
+	value := optionalEnvString("JANITOR_INTERVAL_MINUTES")
+	if value == nil {
+		return 30
+	}
+	minutes, err := strconv.Atoi(*value)
+	if err != nil || minutes <= 0 {
+		return 30
+	}
+	return minutes
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLoadTestRate     = 50
+	defaultLoadTestDuration = 30
+	// loadTestRegressionThreshold is the p95 latency growth over the baseline reported as a regression
+	loadTestRegressionThreshold = 0.2
+)
+
+// ErrLoadTestNotFound is returned for load tests that do not exist on the service
+var ErrLoadTestNotFound = errors.New("load test not found")
+
+// LoadTestService runs load tests against git services and compares their results
+// between deployments
+type LoadTestService struct {
+	loadTestRepo      *repositories.LoadTestRepository
+	serviceRepo       *repositories.ServiceRepository
+	projectRepo       *repositories.ProjectRepository
+	deploymentRepo    *repositories.DeploymentRepository
+	deploymentService *DeploymentService
+}
+
+// NewLoadTestService creates a new load test service instance
+func NewLoadTestService() *LoadTestService {
+	return &LoadTestService{
+		loadTestRepo:      repositories.NewLoadTestRepository(),
+		serviceRepo:       repositories.NewServiceRepository(),
+		projectRepo:       repositories.NewProjectRepository(),
+		deploymentRepo:    repositories.NewDeploymentRepository(),
+		deploymentService: NewDeploymentService(),
+	}
+}
+
+// StartLoadTest starts a load test Job against the running deployment of a git service.
+// The test runs in the background; its output can be streamed and its results are
+// recorded when the Job finishes.
+func (s *LoadTestService) StartLoadTest(serviceID string, req dto.LoadTestRequest, userID string, isAdmin bool) (models.LoadTest, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return models.LoadTest{}, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return models.LoadTest{}, errors.New("load tests are only available for git services")
+	}
+	if service.Status != "running" {
+		return models.LoadTest{}, fmt.Errorf("service must be running to be load tested (status: %s)", service.Status)
+	}
+
+	deployment, err := s.deploymentRepo.GetLatestSuccessfulDeployment(serviceID)
+	if err != nil {
+		return models.LoadTest{}, errors.New("service has no successful deployment to test")
+	}
+
+	test := models.LoadTest{
+		ServiceID:       serviceID,
+		DeploymentID:    deployment.ID,
+		Path:            req.Path,
+		Rate:            req.Rate,
+		DurationSeconds: req.DurationSeconds,
+		Status:          models.LoadTestStatusRunning,
+		CreatedBy:       userID,
+	}
+	if test.Path == "" {
+		test.Path = "/"
+	}
+	if test.Rate == 0 {
+		test.Rate = defaultLoadTestRate
+	}
+	if test.DurationSeconds == 0 {
+		test.DurationSeconds = defaultLoadTestDuration
+	}
+
+	// Tests left running by a restart of the API are ignored once their Job must have ended
+	running, err := s.loadTestRepo.ExistsRunning(serviceID, time.Now().Add(-utils.GetLoadTestTimeout(test)))
+	if err != nil {
+		return models.LoadTest{}, err
+	}
+	if running {
+		return models.LoadTest{}, errors.New("a load test is already running for this service")
+	}
+
+	test, err = s.loadTestRepo.Create(test)
+	if err != nil {
+		return test, err
+	}
+
+	go s.run(service, test)
+	return test, nil
+}
+
+func (s *LoadTestService) run(service models.Service, test models.LoadTest) {
+	err := utils.RunLoadTest(service, &test)
+	now := time.Now()
+	test.FinishedAt = &now
+	test.Status = models.LoadTestStatusCompleted
+	if err != nil {
+		log.Printf("Load test %s of service %s failed: %v", test.ID, service.ID, err)
+		test.Status = models.LoadTestStatusFailed
+		test.Error = err.Error()
+	}
+	if err := s.loadTestRepo.Update(test); err != nil {
+		log.Printf("Failed to record load test %s: %v", test.ID, err)
+	}
+}
+
+// ListLoadTests returns a page of a service's load tests, each compared with its baseline
+func (s *LoadTestService) ListLoadTests(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.LoadTestListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.LoadTestListResponse{}, err
+	}
+
+	tests, total, err := s.loadTestRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.LoadTestListResponse{}, err
+	}
+
+	response := dto.LoadTestListResponse{
+		LoadTests:  make([]dto.LoadTestResponse, 0, len(tests)),
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}
+	for _, test := range tests {
+		response.LoadTests = append(response.LoadTests, s.compareWithBaseline(test))
+	}
+	return response, nil
+}
+
+// GetLoadTest returns a load test of a service compared with its baseline
+func (s *LoadTestService) GetLoadTest(serviceID, loadTestID string, userID string, isAdmin bool) (dto.LoadTestResponse, error) {
+	test, err := s.getLoadTest(serviceID, loadTestID, userID, isAdmin)
+	if err != nil {
+		return dto.LoadTestResponse{}, err
+	}
+	return s.compareWithBaseline(test), nil
+}
+
+// StreamLoadTest streams the periodic vegeta reports of a load test in SSE format
+func (s *LoadTestService) StreamLoadTest(serviceID, loadTestID string, userID string, isAdmin bool, w http.ResponseWriter) error {
+	test, err := s.getLoadTest(serviceID, loadTestID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), utils.GetLoadTestTimeout(test))
+	defer cancel()
+	if cn, ok := w.(http.CloseNotifier); ok {
+		go func() {
+			<-cn.CloseNotify()
+			cancel()
+		}()
+	}
+
+	namespace := utils.GetJobNamespace()
+	podName, err := s.deploymentService.watchForJobPod(ctx, k8sClient, namespace, utils.GetLoadTestJobName(test), w, flusher)
+	if err != nil {
+		return err
+	}
+	return s.deploymentService.streamPodLogs(ctx, k8sClient, namespace, podName, w, flusher, nil, nil)
+}
+
+// compareWithBaseline flags a regression when p95 latency grew past the threshold or the
+// success ratio dropped compared with the previous deployment
+func (s *LoadTestService) compareWithBaseline(test models.LoadTest) dto.LoadTestResponse {
+	response := dto.LoadTestResponse{LoadTest: test}
+	if test.Status != models.LoadTestStatusCompleted {
+		return response
+	}
+
+	baseline, err := s.loadTestRepo.FindBaseline(test)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load baseline of load test %s: %v", test.ID, err)
+		}
+		return response
+	}
+	response.Baseline = &baseline
+
+	if baseline.LatencyP95Ms > 0 {
+		change := (test.LatencyP95Ms - baseline.LatencyP95Ms) / baseline.LatencyP95Ms * 100
+		response.P95ChangePercent = &change
+		response.Regressed = change > loadTestRegressionThreshold*100
+	}
+	if test.SuccessRatio < baseline.SuccessRatio {
+		response.Regressed = true
+	}
+	return response
+}
+
+func (s *LoadTestService) getLoadTest(serviceID, loadTestID string, userID string, isAdmin bool) (models.LoadTest, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return models.LoadTest{}, err
+	}
+
+	test, err := s.loadTestRepo.FindByID(loadTestID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && test.ServiceID != serviceID) {
+		return models.LoadTest{}, ErrLoadTestNotFound
+	}
+	return test, err
+}
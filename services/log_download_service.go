@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ErrBuildLogsNotFound is returned when a deployment's build output was neither archived nor
+// is still available in the cluster
+var ErrBuildLogsNotFound = errors.New("the build logs of this deployment are no longer available")
+
+// ErrLogsAccessDenied is returned when the service does not exist or belongs to a project
+// of another user
+var ErrLogsAccessDenied = errors.New("service not found or access denied")
+
+// LogDownloadService archives the output of each build in the artifact store and assembles
+// complete build and runtime logs as files, for sharing where an event stream cannot go
+type LogDownloadService struct {
+	serviceRepo    *repositories.ServiceRepository
+	deploymentRepo *repositories.DeploymentRepository
+	projectRepo    *repositories.ProjectRepository
+}
+
+// NewLogDownloadService creates a new log download service instance
+func NewLogDownloadService() *LogDownloadService {
+	return &LogDownloadService{
+		serviceRepo:    repositories.NewServiceRepository(),
+		deploymentRepo: repositories.NewDeploymentRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+	}
+}
+
+// ShouldArchive reports whether build output is archived, which needs the artifact store
+func (s *LogDownloadService) ShouldArchive() bool {
+	_, ok := utils.LoadArtifactStoreConfig()
+	return ok
+}
+
+// ArchiveBuildLogs uploads the output of a finished build, failed or not, and records its key
+// on the deployment. It must run before the build job is garbage collected.
+func (s *LogDownloadService) ArchiveBuildLogs(deployment models.Deployment, service models.Service) {
+	config, ok := utils.LoadArtifactStoreConfig()
+	if !ok {
+		return
+	}
+	logs, err := utils.ReadBuildLogs(service, deployment)
+	if err != nil {
+		log.Printf("Failed to read build logs of deployment %s: %v", deployment.ID, err)
+		return
+	}
+	key := utils.GetBuildLogKey(service, deployment)
+	size, err := utils.ArchiveLogs(config, key, logs)
+	if err != nil {
+		log.Printf("Failed to archive build logs of deployment %s: %v", deployment.ID, err)
+		return
+	}
+	if err := s.deploymentRepo.UpdateBuildLogKey(deployment.ID, key, size); err != nil {
+		log.Printf("Failed to record build logs of deployment %s: %v", deployment.ID, err)
+	}
+}
+
+// GetBuildLogs returns the complete build output of a deployment and a file name for it:
+// the archived copy when there is one, otherwise what the build pods still hold
+func (s *LogDownloadService) GetBuildLogs(deploymentID string, userID string, isAdmin bool) (string, string, error) {
+	deployment, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil {
+		return "", "", fmt.Errorf("deployment not found: %v", err)
+	}
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, deployment.ServiceID, userID, isAdmin)
+	if err != nil {
+		return "", "", ErrLogsAccessDenied
+	}
+	fileName := fmt.Sprintf("build-%s.log", deployment.ID)
+
+	if deployment.BuildLogKey != "" {
+		if config, ok := utils.LoadArtifactStoreConfig(); ok {
+			logs, err := utils.ReadArchivedLogs(config, deployment.BuildLogKey)
+			if err == nil {
+				return logs, fileName, nil
+			}
+			log.Printf("Failed to read archived build logs of deployment %s: %v", deployment.ID, err)
+		}
+	}
+
+	logs, err := utils.ReadBuildLogs(service, deployment)
+	if err != nil {
+		log.Printf("Build logs of deployment %s are not available: %v", deployment.ID, err)
+		return "", "", ErrBuildLogsNotFound
+	}
+	return logs, fileName, nil
+}
+
+// GetRuntimeLogs returns the logs of a service's pods and a file name for them
+func (s *LogDownloadService) GetRuntimeLogs(serviceID string, userID string, isAdmin bool) (string, string, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return "", "", ErrLogsAccessDenied
+	}
+
+	logs, err := utils.ReadRuntimeLogs(service)
+	if err != nil {
+		return "", "", err
+	}
+	return logs, fmt.Sprintf("runtime-%s-%s.log", service.Name, time.Now().UTC().Format("20060102-150405")), nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLogDrainSeconds = 10
+	// logDrainMaxLines bounds the lines read per container and pass
+	logDrainMaxLines = 2000
+	// logDrainBatchSize bounds the lines sent per request
+	logDrainBatchSize = 1000
+)
+
+var logDrainOnce sync.Once
+
+// ErrLogDrainNotFound is returned for drains that do not exist in the project
+var ErrLogDrainNotFound = errors.New("log drain not found")
+
+// LogDrainService manages per-project log drains and runs the collector that forwards
+// service pod logs to them
+type LogDrainService struct {
+	drainRepo   *repositories.LogDrainRepository
+	projectRepo *repositories.ProjectRepository
+	serviceRepo *repositories.ServiceRepository
+
+	// cursors holds, per service, the last forwarded timestamp of each container.
+	// Only the collector goroutine touches it.
+	cursors map[string]map[string]time.Time
+}
+
+// NewLogDrainService creates a new log drain service instance
+func NewLogDrainService() *LogDrainService {
+	return &LogDrainService{
+		drainRepo:   repositories.NewLogDrainRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+		cursors:     make(map[string]map[string]time.Time),
+	}
+}
+
+// ListDrains returns the log drains of a project with their delivery health
+func (s *LogDrainService) ListDrains(projectID string, userID string, isAdmin bool) ([]dto.LogDrainResponse, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	drains, err := s.drainRepo.FindByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.LogDrainResponse, 0, len(drains))
+	for _, drain := range drains {
+		responses = append(responses, toLogDrainResponse(drain))
+	}
+	return responses, nil
+}
+
+// CreateDrain registers a log drain for the project
+func (s *LogDrainService) CreateDrain(projectID string, req dto.LogDrainRequest, userID string, isAdmin bool) (dto.LogDrainResponse, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return dto.LogDrainResponse{}, err
+	}
+
+	existing, err := s.drainRepo.FindByProjectID(projectID)
+	if err != nil {
+		return dto.LogDrainResponse{}, err
+	}
+	for _, other := range existing {
+		if other.Name == req.Name {
+			return dto.LogDrainResponse{}, fmt.Errorf("a log drain named %q already exists in this project", req.Name)
+		}
+	}
+
+	drain, err := s.drainRepo.Create(models.LogDrain{
+		ProjectID: projectID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Endpoint:  req.Endpoint,
+		Username:  req.Username,
+		Token:     req.Token,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	})
+	if err != nil {
+		return dto.LogDrainResponse{}, err
+	}
+	log.Printf("Log drain %s (%s) registered in project %s", drain.Name, drain.Type, projectID)
+	return toLogDrainResponse(drain), nil
+}
+
+// UpdateDrain changes the endpoint, credentials or state of a log drain
+func (s *LogDrainService) UpdateDrain(projectID, drainID string, req dto.LogDrainUpdateRequest, userID string, isAdmin bool) (dto.LogDrainResponse, error) {
+	drain, err := s.getDrain(projectID, drainID, userID, isAdmin)
+	if err != nil {
+		return dto.LogDrainResponse{}, err
+	}
+
+	if req.Endpoint != "" {
+		if err := utils.ValidateLogDrainEndpoint(drain.Type, req.Endpoint); err != nil {
+			return dto.LogDrainResponse{}, err
+		}
+		drain.Endpoint = req.Endpoint
+	}
+	if req.Username != nil {
+		drain.Username = *req.Username
+	}
+	if req.Token != nil {
+		if *req.Token == "" && drain.Type == models.LogDrainTypeDatadog {
+			return dto.LogDrainResponse{}, utils.FieldErrors{{Field: "token", Message: "is required for Datadog drains"}}
+		}
+		drain.Token = *req.Token
+	}
+	if req.Enabled != nil {
+		drain.Enabled = *req.Enabled
+	}
+
+	updated, err := s.drainRepo.Update(drain)
+	if err != nil {
+		return dto.LogDrainResponse{}, err
+	}
+	return toLogDrainResponse(updated), nil
+}
+
+// DeleteDrain removes a log drain; forwarding stops with the next collector pass
+func (s *LogDrainService) DeleteDrain(projectID, drainID string, userID string, isAdmin bool) error {
+	drain, err := s.getDrain(projectID, drainID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.drainRepo.Delete(drain.ID)
+}
+
+// StartLogDrainCollector starts the background loop that tails the pod logs of services in
+// projects with enabled drains and forwards them (LOG_DRAIN_POLL_SECONDS, default 10).
+// Delivery is best effort: lines a sink rejects are counted as dropped, not retried.
+func (s *LogDrainService) StartLogDrainCollector() {
+	logDrainOnce.Do(func() {
+		interval := time.Duration(getLogDrainInterval()) * time.Second
+		go func() {
+			log.Printf("Log drain collector started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.collectOnce(interval)
+			}
+		}()
+	})
+}
+
+// collectOnce forwards the lines logged since the previous pass. Containers seen for the
+// first time start one interval back, so history from before startup is not replayed.
+func (s *LogDrainService) collectOnce(interval time.Duration) {
+	drains, err := s.drainRepo.FindEnabled()
+	if err != nil {
+		log.Printf("Log drain collector: failed to load drains: %v", err)
+		return
+	}
+
+	byProject := make(map[string][]models.LogDrain)
+	for _, drain := range drains {
+		byProject[drain.ProjectID] = append(byProject[drain.ProjectID], drain)
+	}
+
+	defaultSince := time.Now().Add(-interval)
+	cursors := make(map[string]map[string]time.Time)
+	for projectID, projectDrains := range byProject {
+		services, err := s.serviceRepo.FindByProjectID(projectID)
+		if err != nil {
+			log.Printf("Log drain collector: failed to load services of project %s: %v", projectID, err)
+			continue
+		}
+
+		var lines []utils.LogLine
+		for _, service := range services {
+			serviceLines, next, err := utils.ReadServiceLogs(service, s.cursors[service.ID], defaultSince, logDrainMaxLines)
+			if err != nil {
+				log.Printf("Log drain collector: failed to read logs of service %s: %v", service.ID, err)
+				cursors[service.ID] = s.cursors[service.ID]
+				continue
+			}
+			cursors[service.ID] = next
+			lines = append(lines, serviceLines...)
+		}
+
+		if len(lines) > 0 {
+			for _, drain := range projectDrains {
+				s.deliver(drain, lines)
+			}
+		}
+	}
+	// Services of projects without drains are forgotten, so re-enabling starts fresh
+	s.cursors = cursors
+}
+
+// deliver sends the lines to one drain in batches and records its health
+func (s *LogDrainService) deliver(drain models.LogDrain, lines []utils.LogLine) {
+	delivered, dropped := 0, 0
+	var lastErr error
+	for start := 0; start < len(lines); start += logDrainBatchSize {
+		end := min(start+logDrainBatchSize, len(lines))
+		if err := utils.SendLogDrainBatch(drain, lines[start:end]); err != nil {
+			lastErr = err
+			dropped += end - start
+			continue
+		}
+		delivered += end - start
+	}
+
+	now := time.Now()
+	if delivered > 0 {
+		if err := s.drainRepo.RecordDelivery(drain.ID, delivered, now); err != nil {
+			log.Printf("Log drain %s: failed to record delivery: %v", drain.ID, err)
+		}
+	}
+	if lastErr != nil {
+		log.Printf("Log drain %s: dropped %d lines: %v", drain.ID, dropped, lastErr)
+		if err := s.drainRepo.RecordFailure(drain.ID, dropped, lastErr.Error(), now); err != nil {
+			log.Printf("Log drain %s: failed to record failure: %v", drain.ID, err)
+		}
+	}
+}
+
+func (s *LogDrainService) getDrain(projectID, drainID string, userID string, isAdmin bool) (models.LogDrain, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.LogDrain{}, err
+	}
+
+	drain, err := s.drainRepo.FindByID(drainID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && drain.ProjectID != projectID) {
+		return models.LogDrain{}, ErrLogDrainNotFound
+	}
+	return drain, err
+}
+
+func (s *LogDrainService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
+
+func toLogDrainResponse(drain models.LogDrain) dto.LogDrainResponse {
+	return dto.LogDrainResponse{
+		LogDrain: drain,
+		Status:   drain.Status(),
+		HasToken: drain.Token != "",
+	}
+}
+
+func getLogDrainInterval() int {
+	value := optionalEnvString("LOG_DRAIN_POLL_SECONDS")
+	if value == nil {
+		return defaultLogDrainSeconds
+	}
+	seconds, err := strconv.Atoi(*value)
+	if err != nil || seconds <= 0 {
+		return defaultLogDrainSeconds
+	}
+	return seconds
+}
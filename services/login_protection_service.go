@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCredentials is returned for an unknown email or a wrong password alike, so
+// logins do not reveal which accounts exist
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// AccountLockedError is returned while an account is locked after repeated failed logins
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked after too many failed logins, try again after %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// LoginClient identifies where a login attempt came from, for the audit trail
+type LoginClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// LoginProtectionService applies the admin-configured auth policy: it locks accounts after
+// consecutive failed logins, throttles addresses with many failures and keeps the login
+// audit trail
+type LoginProtectionService struct {
+	securityRepo *repositories.AuthSecurityRepository
+}
+
+// NewLoginProtectionService creates a new login protection service instance
+func NewLoginProtectionService() *LoginProtectionService {
+	return &LoginProtectionService{
+		securityRepo: repositories.NewAuthSecurityRepository(),
+	}
+}
+
+// GetPolicy returns the auth policy in effect
+func (s *LoginProtectionService) GetPolicy() (models.AuthPolicy, error) {
+	return s.securityRepo.FindPolicy()
+}
+
+// UpdatePolicy changes the auth policy. A stricter 2FA requirement takes effect at each
+// user's next login.
+func (s *LoginProtectionService) UpdatePolicy(req dto.AuthPolicyUpdateRequest, userID string) (models.AuthPolicy, error) {
+	policy, err := s.securityRepo.FindPolicy()
+	if err != nil {
+		return policy, err
+	}
+
+	if req.RequireTwoFactor != nil {
+		policy.RequireTwoFactor = *req.RequireTwoFactor
+	}
+	if req.MaxFailedLogins != nil {
+		policy.MaxFailedLogins = *req.MaxFailedLogins
+	}
+	if req.LockoutMinutes != nil {
+		policy.LockoutMinutes = *req.LockoutMinutes
+	}
+	if req.MaxFailedLoginsPerIP != nil {
+		policy.MaxFailedLoginsPerIP = *req.MaxFailedLoginsPerIP
+	}
+	if req.ThrottleWindowMinutes != nil {
+		policy.ThrottleWindowMinutes = *req.ThrottleWindowMinutes
+	}
+	policy.UpdatedBy = userID
+
+	return s.securityRepo.SavePolicy(policy)
+}
+
+// CheckThrottle reports whether an address has too many recent failed logins, and how
+// long the client should wait before trying again
+func (s *LoginProtectionService) CheckThrottle(ipAddress string) (time.Duration, bool) {
+	policy, err := s.securityRepo.FindPolicy()
+	if err != nil || policy.MaxFailedLoginsPerIP == 0 {
+		return 0, false
+	}
+
+	window := time.Duration(policy.ThrottleWindowMinutes) * time.Minute
+	failures, err := s.securityRepo.CountFailuresFromIP(ipAddress, time.Now().Add(-window))
+	if err != nil {
+		// Failing open keeps logins working during a database hiccup; account lockout
+		// still applies
+		log.Printf("Failed to count failed logins of %s: %v", ipAddress, err)
+		return 0, false
+	}
+	if failures < int64(policy.MaxFailedLoginsPerIP) {
+		return 0, false
+	}
+	return window, true
+}
+
+// RecordThrottled records a login refused because its address is throttled
+func (s *LoginProtectionService) RecordThrottled(client LoginClient) {
+	s.recordAttempt("", nil, client, false, models.LoginFailureThrottled)
+}
+
+// ListAttempts returns a page of the login audit trail, newest first
+func (s *LoginProtectionService) ListAttempts(email string, page, pageSize int) (dto.LoginAttemptListResponse, error) {
+	attempts, total, err := s.securityRepo.FindAttempts(email, page, pageSize)
+	if err != nil {
+		return dto.LoginAttemptListResponse{}, err
+	}
+	return dto.LoginAttemptListResponse{
+		Attempts:   attempts,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// UnlockUser lifts the lockout of an account before it expires
+func (s *LoginProtectionService) UnlockUser(userID string) error {
+	unlocked, err := s.securityRepo.UnlockUser(userID)
+	if err != nil {
+		return err
+	}
+	if unlocked == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// checkLocked returns an AccountLockedError while the user is locked out
+func (s *LoginProtectionService) checkLocked(user models.User, client LoginClient) error {
+	if user.LockedUntil == nil || !time.Now().Before(*user.LockedUntil) {
+		return nil
+	}
+	s.recordAttempt(user.Email, &user.ID, client, false, models.LoginFailureAccountLocked)
+	return &AccountLockedError{Until: *user.LockedUntil}
+}
+
+// recordFailure records a failed login and locks the account once the policy's limit of
+// consecutive failures is reached. It returns the error to report to the client.
+func (s *LoginProtectionService) recordFailure(user *models.User, email string, client LoginClient, policy models.AuthPolicy, reason string, failure error) error {
+	if user == nil {
+		s.recordAttempt(email, nil, client, false, reason)
+		return failure
+	}
+	s.recordAttempt(user.Email, &user.ID, client, false, reason)
+	if policy.MaxFailedLogins == 0 {
+		return failure
+	}
+
+	failures, err := s.securityRepo.IncrementFailedLogins(user.ID)
+	if err != nil {
+		log.Printf("Failed to count failed login of user %s: %v", user.ID, err)
+		return failure
+	}
+	if failures < policy.MaxFailedLogins {
+		return failure
+	}
+
+	until := time.Now().Add(time.Duration(policy.LockoutMinutes) * time.Minute)
+	if err := s.securityRepo.LockUser(user.ID, until); err != nil {
+		log.Printf("Failed to lock user %s: %v", user.ID, err)
+		return failure
+	}
+	log.Printf("User %s locked until %s after %d failed logins", user.ID, until.UTC().Format(time.RFC3339), failures)
+	return &AccountLockedError{Until: until}
+}
+
+// recordSuccess records a successful login and resets the failure counter
+func (s *LoginProtectionService) recordSuccess(user models.User, client LoginClient) {
+	s.recordAttempt(user.Email, &user.ID, client, true, "")
+	if user.FailedLogins == 0 && user.LockedUntil == nil {
+		return
+	}
+	if _, err := s.securityRepo.UnlockUser(user.ID); err != nil {
+		log.Printf("Failed to reset failed logins of user %s: %v", user.ID, err)
+	}
+}
+
+// recordAttempt writes the audit trail; a failed write must not fail the login
+func (s *LoginProtectionService) recordAttempt(email string, userID *string, client LoginClient, success bool, reason string) {
+	attempt := models.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		Success:   success,
+		Reason:    reason,
+		UserAgent: client.UserAgent,
+	}
+	if err := s.securityRepo.CreateAttempt(attempt); err != nil {
+		log.Printf("Failed to record login attempt: %v", err)
+	}
+}
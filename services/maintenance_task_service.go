@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaintenanceCPULimit    = "500m"
+	defaultMaintenanceMemoryLimit = "512Mi"
+	// maintenanceSchedulerInterval is how often schedules are checked (cron has minute resolution)
+	maintenanceSchedulerInterval = time.Minute
+)
+
+var maintenanceSchedulerOnce sync.Once
+
+var (
+	// ErrMaintenanceTaskNotFound is returned for tasks that do not exist in the environment
+	ErrMaintenanceTaskNotFound = errors.New("maintenance task not found")
+	// ErrMaintenanceRunNotFound is returned for runs that do not belong to the task
+	ErrMaintenanceRunNotFound = errors.New("maintenance task run not found")
+	// ErrMaintenanceTaskExists is returned when the environment has a task of the same name
+	ErrMaintenanceTaskExists = errors.New("a maintenance task with this name already exists in the environment")
+	// ErrMaintenanceTaskRunning is returned when a run is started while the previous one runs
+	ErrMaintenanceTaskRunning = errors.New("a run of this maintenance task is still running")
+)
+
+// MaintenanceTaskService manages the recurring maintenance Jobs of environments, such as
+// cache warmers or report generators, which run a command in the image of a git service
+// of the environment on a cron schedule
+type MaintenanceTaskService struct {
+	taskRepo           *repositories.MaintenanceTaskRepository
+	serviceRepo        *repositories.ServiceRepository
+	environmentRepo    *repositories.EnvironmentRepository
+	deploymentRepo     *repositories.DeploymentRepository
+	environmentService *EnvironmentService
+	deploymentService  *DeploymentService
+}
+
+// NewMaintenanceTaskService creates a new maintenance task service instance
+func NewMaintenanceTaskService() *MaintenanceTaskService {
+	return &MaintenanceTaskService{
+		taskRepo:           repositories.NewMaintenanceTaskRepository(),
+		serviceRepo:        repositories.NewServiceRepository(),
+		environmentRepo:    repositories.NewEnvironmentRepository(),
+		deploymentRepo:     repositories.NewDeploymentRepository(),
+		environmentService: NewEnvironmentService(),
+		deploymentService:  NewDeploymentService(),
+	}
+}
+
+// ListTasks returns the maintenance tasks of an environment with their next and last runs
+func (s *MaintenanceTaskService) ListTasks(environmentID string, userID string, isAdmin bool) ([]dto.MaintenanceTaskResponse, error) {
+	if _, err := s.environmentService.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.taskRepo.FindByEnvironmentID(environmentID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.MaintenanceTaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responses = append(responses, s.buildResponse(task))
+	}
+	return responses, nil
+}
+
+// GetTask returns a maintenance task of an environment
+func (s *MaintenanceTaskService) GetTask(environmentID, taskID string, userID string, isAdmin bool) (dto.MaintenanceTaskResponse, error) {
+	task, err := s.getTask(environmentID, taskID, userID, isAdmin)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+	return s.buildResponse(task), nil
+}
+
+// CreateTask adds a maintenance task to an environment
+func (s *MaintenanceTaskService) CreateTask(environmentID string, req dto.MaintenanceTaskRequest, userID string, isAdmin bool) (dto.MaintenanceTaskResponse, error) {
+	env, err := s.environmentService.GetEnvironmentDetail(environmentID, userID, isAdmin)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+
+	existing, err := s.taskRepo.FindByEnvironmentID(environmentID)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+	for _, task := range existing {
+		if task.Name == req.Name {
+			return dto.MaintenanceTaskResponse{}, ErrMaintenanceTaskExists
+		}
+	}
+
+	task := models.MaintenanceTask{
+		EnvironmentID:  environmentID,
+		Name:           req.Name,
+		Schedule:       req.Schedule,
+		ServiceID:      req.ServiceID,
+		Command:        req.Command,
+		InheritEnv:     req.InheritEnv,
+		EnvVars:        req.EnvVars,
+		CPULimit:       req.CPULimit,
+		MemoryLimit:    req.MemoryLimit,
+		TimeoutMinutes: req.TimeoutMinutes,
+		Suspended:      req.Suspended,
+		CreatedBy:      userID,
+	}
+	if task.EnvVars == nil {
+		task.EnvVars = models.EnvVars{}
+	}
+	if task.CPULimit == "" {
+		task.CPULimit = defaultMaintenanceCPULimit
+	}
+	if task.MemoryLimit == "" {
+		task.MemoryLimit = defaultMaintenanceMemoryLimit
+	}
+	if task.TimeoutMinutes == 0 {
+		task.TimeoutMinutes = utils.DefaultMaintenanceTaskTimeoutMinutes
+	}
+	if err := s.checkTask(env, task); err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+
+	task, err = s.taskRepo.Create(task)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+	return s.buildResponse(task), nil
+}
+
+// UpdateTask changes the fields of a maintenance task that are set in the request
+func (s *MaintenanceTaskService) UpdateTask(environmentID, taskID string, req dto.MaintenanceTaskUpdateRequest, userID string, isAdmin bool) (dto.MaintenanceTaskResponse, error) {
+	task, err := s.getTask(environmentID, taskID, userID, isAdmin)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+	env, err := s.environmentRepo.FindByID(environmentID)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+
+	if req.Schedule != nil {
+		task.Schedule = *req.Schedule
+	}
+	if req.ServiceID != nil {
+		task.ServiceID = *req.ServiceID
+	}
+	if req.Command != nil {
+		task.Command = *req.Command
+	}
+	if req.InheritEnv != nil {
+		task.InheritEnv = *req.InheritEnv
+	}
+	if req.EnvVars != nil {
+		task.EnvVars = *req.EnvVars
+		if task.EnvVars == nil {
+			task.EnvVars = models.EnvVars{}
+		}
+	}
+	if req.CPULimit != nil && *req.CPULimit != "" {
+		task.CPULimit = *req.CPULimit
+	}
+	if req.MemoryLimit != nil && *req.MemoryLimit != "" {
+		task.MemoryLimit = *req.MemoryLimit
+	}
+	if req.TimeoutMinutes != nil {
+		task.TimeoutMinutes = *req.TimeoutMinutes
+		if task.TimeoutMinutes == 0 {
+			task.TimeoutMinutes = utils.DefaultMaintenanceTaskTimeoutMinutes
+		}
+	}
+	if req.Suspended != nil {
+		task.Suspended = *req.Suspended
+	}
+	if err := s.checkTask(env, task); err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+
+	task, err = s.taskRepo.Update(task)
+	if err != nil {
+		return dto.MaintenanceTaskResponse{}, err
+	}
+	return s.buildResponse(task), nil
+}
+
+// DeleteTask removes a maintenance task and its run history. Running Jobs finish on their own.
+func (s *MaintenanceTaskService) DeleteTask(environmentID, taskID string, userID string, isAdmin bool) error {
+	if _, err := s.getTask(environmentID, taskID, userID, isAdmin); err != nil {
+		return err
+	}
+	return s.taskRepo.Delete(taskID)
+}
+
+// TriggerRun starts a run of a maintenance task now, also while it is suspended
+func (s *MaintenanceTaskService) TriggerRun(environmentID, taskID string, userID string, isAdmin bool) (models.MaintenanceTaskRun, error) {
+	task, err := s.getTask(environmentID, taskID, userID, isAdmin)
+	if err != nil {
+		return models.MaintenanceTaskRun{}, err
+	}
+	return s.startRun(task, models.MaintenanceTriggerManual, userID)
+}
+
+// ListRuns returns a page of the runs of a maintenance task, newest first
+func (s *MaintenanceTaskService) ListRuns(environmentID, taskID string, page, pageSize int, userID string, isAdmin bool) (dto.MaintenanceTaskRunListResponse, error) {
+	if _, err := s.getTask(environmentID, taskID, userID, isAdmin); err != nil {
+		return dto.MaintenanceTaskRunListResponse{}, err
+	}
+
+	runs, total, err := s.taskRepo.FindRunsByTaskID(taskID, page, pageSize)
+	if err != nil {
+		return dto.MaintenanceTaskRunListResponse{}, err
+	}
+	return dto.MaintenanceTaskRunListResponse{
+		Runs:       runs,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// GetRunLogs returns the kept end of the output of a run; it is empty while the run is running
+func (s *MaintenanceTaskService) GetRunLogs(environmentID, taskID, runID string, userID string, isAdmin bool) (dto.MaintenanceTaskRunLogsResponse, error) {
+	_, run, err := s.getRun(environmentID, taskID, runID, userID, isAdmin)
+	if err != nil {
+		return dto.MaintenanceTaskRunLogsResponse{}, err
+	}
+	return dto.MaintenanceTaskRunLogsResponse{RunID: run.ID, Status: run.Status, Logs: run.Logs}, nil
+}
+
+// StreamRunLogs streams the output of a running run in SSE format, with secrets masked. The
+// kept output of a finished run is sent instead.
+func (s *MaintenanceTaskService) StreamRunLogs(environmentID, taskID, runID string, userID string, isAdmin bool, w http.ResponseWriter) error {
+	task, run, err := s.getRun(environmentID, taskID, runID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if run.Status != models.MaintenanceRunRunning {
+		for _, line := range strings.Split(strings.TrimRight(run.Logs, "\n"), "\n") {
+			utils.WriteSSEMessage(w, line)
+		}
+		return nil
+	}
+
+	source, err := s.serviceRepo.FindByID(task.ServiceID)
+	if err != nil {
+		return err
+	}
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), utils.GetMaintenanceTaskTimeout(task))
+	defer cancel()
+	if cn, ok := w.(http.CloseNotifier); ok {
+		go func() {
+			<-cn.CloseNotify()
+			cancel()
+		}()
+	}
+
+	namespace := task.EnvironmentID
+	podName, err := s.deploymentService.watchForJobPod(ctx, k8sClient, namespace, utils.GetMaintenanceTaskJobName(run), w, flusher)
+	if err != nil {
+		return err
+	}
+	return s.deploymentService.streamPodLogs(ctx, k8sClient, namespace, podName, w, flusher, maintenanceTaskRedactor(task, source), nil)
+}
+
+// StartMaintenanceScheduler starts the background loop that runs maintenance tasks when
+// their schedule matches. It is safe to call more than once.
+func (s *MaintenanceTaskService) StartMaintenanceScheduler() {
+	maintenanceSchedulerOnce.Do(func() {
+		go func() {
+			log.Printf("Maintenance scheduler started (interval %v)", maintenanceSchedulerInterval)
+			ticker := time.NewTicker(maintenanceSchedulerInterval)
+			defer ticker.Stop()
+
+			s.runDueTasks()
+			for range ticker.C {
+				s.runDueTasks()
+			}
+		}()
+	})
+}
+
+// runDueTasks starts the tasks whose schedule matches the current minute. Each minute is
+// claimed in the database first, so it runs once even with several API replicas.
+func (s *MaintenanceTaskService) runDueTasks() {
+	tasks, err := s.taskRepo.FindScheduled()
+	if err != nil {
+		log.Printf("Maintenance scheduler: failed to load tasks: %v", err)
+		return
+	}
+
+	minute := time.Now().UTC().Truncate(time.Minute)
+	for _, task := range tasks {
+		schedule, err := utils.ParseCron(task.Schedule)
+		if err != nil {
+			log.Printf("Maintenance scheduler: invalid schedule of task %s: %v", task.ID, err)
+			continue
+		}
+		if !schedule.Matches(minute) {
+			continue
+		}
+		if env, err := s.environmentRepo.FindByID(task.EnvironmentID); err != nil || env.IsArchived() {
+			continue
+		}
+
+		claimed, err := s.taskRepo.MarkScheduled(task.ID, minute)
+		if err != nil {
+			log.Printf("Maintenance scheduler: failed to claim task %s: %v", task.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if _, err := s.startRun(task, models.MaintenanceTriggerSchedule, ""); err != nil {
+			log.Printf("Maintenance scheduler: skipped task %s (%s): %v", task.Name, task.ID, err)
+		}
+	}
+}
+
+// startRun records a run of a task from the latest successful deployment of its service and
+// runs the Job in the background. A scheduled run that cannot start is recorded as failed,
+// so the run history shows it.
+func (s *MaintenanceTaskService) startRun(task models.MaintenanceTask, trigger string, userID string) (models.MaintenanceTaskRun, error) {
+	// Runs left running by a restart of the API are ignored once their Job must have ended
+	running, err := s.taskRepo.ExistsRunning(task.ID, time.Now().Add(-utils.GetMaintenanceTaskTimeout(task)))
+	if err != nil {
+		return models.MaintenanceTaskRun{}, err
+	}
+	if running {
+		return models.MaintenanceTaskRun{}, ErrMaintenanceTaskRunning
+	}
+
+	run := models.MaintenanceTaskRun{
+		TaskID:    task.ID,
+		Trigger:   trigger,
+		Status:    models.MaintenanceRunRunning,
+		StartedBy: userID,
+		StartedAt: time.Now(),
+	}
+
+	source, err := s.serviceRepo.FindByID(task.ServiceID)
+	if err == nil {
+		var deployment models.Deployment
+		deployment, err = s.deploymentRepo.GetLatestSuccessfulDeployment(source.ID)
+		if err != nil || deployment.Image == "" {
+			err = fmt.Errorf("service %s has no successful deployment to take the image from", source.Name)
+		}
+		run.Image = deployment.Image
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New("the service of the task no longer exists")
+	}
+	if err != nil {
+		if trigger == models.MaintenanceTriggerSchedule {
+			now := time.Now()
+			run.Status = models.MaintenanceRunFailed
+			run.Error = err.Error()
+			run.FinishedAt = &now
+			if _, createErr := s.taskRepo.CreateRun(run); createErr != nil {
+				log.Printf("Failed to record maintenance run of task %s: %v", task.ID, createErr)
+			}
+		}
+		return models.MaintenanceTaskRun{}, err
+	}
+
+	run, err = s.taskRepo.CreateRun(run)
+	if err != nil {
+		return run, err
+	}
+
+	go s.run(task, source, run)
+	return run, nil
+}
+
+func (s *MaintenanceTaskService) run(task models.MaintenanceTask, source models.Service, run models.MaintenanceTaskRun) {
+	err := utils.RunMaintenanceTask(task, source, &run, maintenanceTaskRedactor(task, source))
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.MaintenanceRunSucceeded
+	if err != nil {
+		log.Printf("Maintenance run %s of task %s failed: %v", run.ID, task.ID, err)
+		run.Status = models.MaintenanceRunFailed
+		run.Error = err.Error()
+	}
+	if err := s.taskRepo.UpdateRun(run); err != nil {
+		log.Printf("Failed to record maintenance run %s: %v", run.ID, err)
+	}
+}
+
+// maintenanceTaskRedactor masks the secrets of the source service and the task's own
+// sensitive-looking variables in the output of a run
+func maintenanceTaskRedactor(task models.MaintenanceTask, source models.Service) func(string) string {
+	envVars := models.EnvVars{}
+	for key, value := range source.EnvVars {
+		envVars[key] = value
+	}
+	for key, value := range task.EnvVars {
+		envVars[key] = value
+	}
+	source.EnvVars = envVars
+	return utils.NewSecretRedactor(source)
+}
+
+// checkTask validates a task against its environment: the service must be a git service of
+// the environment and the resources within the environment's bounds
+func (s *MaintenanceTaskService) checkTask(env models.Environment, task models.MaintenanceTask) error {
+	var errs utils.FieldErrors
+
+	source, err := s.serviceRepo.FindByID(task.ServiceID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		errs.Add("serviceId", "service not found")
+	case err != nil:
+		return err
+	case source.EnvironmentID != env.ID:
+		errs.Add("serviceId", "must be a service of this environment")
+	case source.Type != models.ServiceTypeGit:
+		errs.Add("serviceId", "must be a git service, whose built image runs the task")
+	}
+	if err := utils.CheckEnvironmentResourceRanges(env, "", task.CPULimit, task.MemoryLimit, ""); err != nil {
+		var fieldErrors utils.FieldErrors
+		if errors.As(err, &fieldErrors) {
+			errs = append(errs, fieldErrors...)
+		}
+	}
+	return errs.Err()
+}
+
+// buildResponse adds the service name, next scheduled run and last run to a task
+func (s *MaintenanceTaskService) buildResponse(task models.MaintenanceTask) dto.MaintenanceTaskResponse {
+	response := dto.MaintenanceTaskResponse{MaintenanceTask: task}
+	if source, err := s.serviceRepo.FindByID(task.ServiceID); err == nil {
+		response.ServiceName = source.Name
+	}
+	if schedule, err := utils.ParseCron(task.Schedule); err == nil && !task.Suspended {
+		if next := schedule.Next(time.Now().UTC()); !next.IsZero() {
+			response.NextRunAt = &next
+		}
+	}
+	if run, err := s.taskRepo.FindLatestRun(task.ID); err == nil {
+		response.LastRun = &run
+	}
+	return response
+}
+
+func (s *MaintenanceTaskService) getTask(environmentID, taskID string, userID string, isAdmin bool) (models.MaintenanceTask, error) {
+	if _, err := s.environmentService.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return models.MaintenanceTask{}, err
+	}
+
+	task, err := s.taskRepo.FindByID(taskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && task.EnvironmentID != environmentID) {
+		return models.MaintenanceTask{}, ErrMaintenanceTaskNotFound
+	}
+	return task, err
+}
+
+func (s *MaintenanceTaskService) getRun(environmentID, taskID, runID string, userID string, isAdmin bool) (models.MaintenanceTask, models.MaintenanceTaskRun, error) {
+	task, err := s.getTask(environmentID, taskID, userID, isAdmin)
+	if err != nil {
+		return task, models.MaintenanceTaskRun{}, err
+	}
+
+	run, err := s.taskRepo.FindRunByID(runID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && run.TaskID != taskID) {
+		return task, models.MaintenanceTaskRun{}, ErrMaintenanceRunNotFound
+	}
+	return task, run, err
+}
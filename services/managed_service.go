@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
+	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/repositories"
 	"github.com/pendeploy-simple/utils"
@@ -19,6 +21,8 @@ type ManagedServiceService struct {
 	serviceRepo     *repositories.ServiceRepository
 	projectRepo     *repositories.ProjectRepository
 	environmentRepo *repositories.EnvironmentRepository
+	scheduleRepo    *repositories.PauseScheduleRepository
+	deployLocks     *DeployLockService
 }
 
 // NewManagedServiceService creates a new managed service service instance
@@ -27,6 +31,8 @@ func NewManagedServiceService() *ManagedServiceService {
 		serviceRepo:     repositories.NewServiceRepository(),
 		projectRepo:     repositories.NewProjectRepository(),
 		environmentRepo: repositories.NewEnvironmentRepository(),
+		scheduleRepo:    repositories.NewPauseScheduleRepository(),
+		deployLocks:     NewDeployLockService(),
 	}
 }
 
@@ -34,6 +40,62 @@ func (s *ManagedServiceService) EnsureTCPProxyExists() error {
 	return s.ensureTCPProxyFromDB()
 }
 
+// GetCatalog describes the managed service types, versions and defaults that can be created
+func (s *ManagedServiceService) GetCatalog() dto.ManagedServiceCatalog {
+	configs := utils.GetManagedServiceConfigs()
+	managedTypes := make([]string, 0, len(configs))
+	for managedType := range configs {
+		managedTypes = append(managedTypes, managedType)
+	}
+	sort.Strings(managedTypes)
+
+	entries := make([]dto.ManagedServiceCatalogEntry, 0, len(managedTypes))
+	for _, managedType := range managedTypes {
+		config := configs[managedType]
+		var endpoints []dto.ManagedServiceEndpoint
+		for _, exposure := range utils.GetManagedServiceExposureConfig(managedType) {
+			endpoints = append(endpoints, dto.ManagedServiceEndpoint{
+				Name:        exposure.Name,
+				Port:        exposure.Port,
+				IsHTTP:      exposure.IsHTTP,
+				Description: exposure.Description,
+				Exposure:    exposure.ExposureType,
+			})
+		}
+		portStart, portEnd := utils.GetManagedServicePortRange(managedType)
+		entries = append(entries, dto.ManagedServiceCatalogEntry{
+			Type:            managedType,
+			DefaultVersion:  config.DefaultVersion,
+			Versions:        config.Versions,
+			Port:            config.Port,
+			RequiresStorage: config.RequiresStorage,
+			Workload:        config.ServiceType,
+			Topologies:      config.Topologies,
+			SupportsTLS:     utils.SupportsDatabaseTLS(managedType),
+			SupportsPooling: managedType == "postgresql",
+			Endpoints:       endpoints,
+
+			ExternalPortStart: portStart,
+			ExternalPortEnd:   portEnd,
+		})
+	}
+
+	proxy := utils.GetTCPProxyConfig()
+	return dto.ManagedServiceCatalog{
+		Types: entries,
+		DefaultResources: dto.ManagedServiceResources{
+			CPULimit:    utils.DefaultManagedCPULimit,
+			MemoryLimit: utils.DefaultManagedMemoryLimit,
+			StorageSize: utils.DefaultManagedStorageSize,
+		},
+		ExternalAccess: dto.ManagedServicePortRange{
+			Host:      proxy.Host,
+			PortStart: proxy.PortStart,
+			PortEnd:   proxy.PortEnd,
+		},
+	}
+}
+
 // CreateManagedService creates and deploys a new managed service
 func (s *ManagedServiceService) CreateManagedService(service models.Service, userID string, isAdmin bool) (models.Service, error) {
 	// Validate user access to project
@@ -58,10 +120,17 @@ func (s *ManagedServiceService) CreateManagedService(service models.Service, use
 		return service, errors.New("environment does not belong to the specified project")
 	}
 
+	if err := s.deployLocks.CheckDeployAllowed(service, isAdmin); err != nil {
+		return service, err
+	}
+
 	// Validate managed service configuration
 	if err := s.validateManagedServiceConfig(service); err != nil {
 		return service, err
 	}
+	if err := utils.VerifyManagedServiceVersion(service.ManagedType, service.Version); err != nil {
+		return service, err
+	}
 
 	// Set defaults for managed service
 	service = s.setManagedServiceDefaults(service)
@@ -86,7 +155,6 @@ func (s *ManagedServiceService) CreateManagedService(service models.Service, use
 			log.Printf("Failed to deploy managed service %s: %v", createdService.ID, err)
 			return
 		}
-		deployedService.Status = "active"
 		// Update service with deployment results (env vars, domain, etc.)
 		err = s.serviceRepo.Update(*deployedService)
 		if err != nil {
@@ -96,6 +164,7 @@ func (s *ManagedServiceService) CreateManagedService(service models.Service, use
 		if err := s.ensureTCPProxyFromDB(); err != nil {
 			log.Printf("Failed to update TCP proxy after managed service deployment: %v", err)
 		}
+		s.waitUntilReady(*deployedService)
 	}()
 
 	log.Printf("Successfully created managed service: %s (%s)", createdService.Name, createdService.ManagedType)
@@ -127,6 +196,10 @@ func (s *ManagedServiceService) UpdateManagedService(serviceChanges models.Servi
 		}
 	}
 
+	if err := s.deployLocks.CheckDeployAllowed(existingService, isAdmin); err != nil {
+		return serviceChanges, err
+	}
+
 	// Start with existing service for selective updates
 	updatedService := existingService
 
@@ -176,7 +249,10 @@ func (s *ManagedServiceService) UpdateManagedService(serviceChanges models.Servi
 	}
 
 	// Allow version updates (will trigger redeployment)
-	if serviceChanges.Version != "" {
+	if serviceChanges.Version != "" && serviceChanges.Version != existingService.Version {
+		if err := utils.VerifyManagedServiceVersion(existingService.ManagedType, serviceChanges.Version); err != nil {
+			return serviceChanges, err
+		}
 		updatedService.Version = serviceChanges.Version
 	}
 
@@ -185,6 +261,50 @@ func (s *ManagedServiceService) UpdateManagedService(serviceChanges models.Servi
 		updatedService.CustomDomain = serviceChanges.CustomDomain
 	}
 
+	// Workload identity annotations take effect on the ServiceAccount at redeploy
+	if serviceChanges.ServiceAccountAnnotations != nil {
+		updatedService.ServiceAccountAnnotations = serviceChanges.ServiceAccountAnnotations
+	}
+
+	// Pod labels and annotations are rendered into the pod template at redeploy
+	if serviceChanges.PodLabels != nil {
+		updatedService.PodLabels = serviceChanges.PodLabels
+	}
+	if serviceChanges.PodAnnotations != nil {
+		updatedService.PodAnnotations = serviceChanges.PodAnnotations
+	}
+
+	// Allow connection pooling updates (PgBouncer is added/removed on redeploy)
+	updatedService.PoolingEnabled = serviceChanges.PoolingEnabled
+	if serviceChanges.PoolMode != "" {
+		updatedService.PoolMode = serviceChanges.PoolMode
+	}
+	if serviceChanges.PoolSize != 0 {
+		updatedService.PoolSize = serviceChanges.PoolSize
+	}
+	if serviceChanges.MaxClientConn != 0 {
+		updatedService.MaxClientConn = serviceChanges.MaxClientConn
+	}
+	if updatedService.PoolingEnabled {
+		updatedService = setPoolingDefaults(updatedService)
+	}
+
+	// The VerticalPodAutoscaler is applied or removed on redeploy
+	updatedService.VPAMode = serviceChanges.VPAMode
+
+	// Switching TLS on or off redeploys the server with or without its certificate
+	updatedService.DatabaseTLS = serviceChanges.DatabaseTLS
+
+	// The version updater reads the channel and window on its next run
+	updatedService.AutoUpdate = serviceChanges.AutoUpdate
+	updatedService.MaintenanceWindow = serviceChanges.MaintenanceWindow
+
+	// The allowlist is enforced by the TCP proxy, which is reconfigured below without a redeploy
+	updatedService.ExternalAllowedCIDRs = serviceChanges.ExternalAllowedCIDRs
+	if err := s.validateManagedServiceConfig(updatedService); err != nil {
+		return serviceChanges, err
+	}
+
 	// Note: EnvVars are auto-generated and read-only for managed services
 	// We don't allow user modifications
 
@@ -221,6 +341,7 @@ func (s *ManagedServiceService) UpdateManagedService(serviceChanges models.Servi
 			if err := s.ensureTCPProxyFromDB(); err != nil {
 				log.Printf("Failed to update TCP proxy after managed service redeploy: %v", err)
 			}
+			s.waitUntilReady(updatedService)
 		}()
 	}
 
@@ -230,6 +351,12 @@ func (s *ManagedServiceService) UpdateManagedService(serviceChanges models.Servi
 		return updatedService, fmt.Errorf("failed to update service in database: %v", err)
 	}
 
+	if !needsRedeployment && updatedService.ExternalAllowedCIDRs != existingService.ExternalAllowedCIDRs {
+		if err := s.ensureTCPProxyFromDB(); err != nil {
+			log.Printf("Failed to update TCP proxy allowlist of managed service %s: %v", updatedService.ID, err)
+		}
+	}
+
 	log.Printf("Successfully updated managed service: %s", updatedService.Name)
 	return updatedService, nil
 }
@@ -259,6 +386,10 @@ func (s *ManagedServiceService) DeleteManagedService(serviceID string, userID st
 		}
 	}
 
+	if err := s.deployLocks.CheckDeployAllowed(service, isAdmin); err != nil {
+		return err
+	}
+
 	// Delete Kubernetes resources
 	err = s.deleteManagedServiceFromKubernetes(service)
 	if err != nil {
@@ -272,6 +403,11 @@ func (s *ManagedServiceService) DeleteManagedService(serviceID string, userID st
 		return fmt.Errorf("failed to delete service from database: %v", err)
 	}
 
+	// Services are soft-deleted, so the schedule has to be removed explicitly
+	if err := s.scheduleRepo.DeleteByServiceID(serviceID); err != nil {
+		log.Printf("Warning: failed to delete pause schedule for service %s: %v", serviceID, err)
+	}
+
 	if err := s.ensureTCPProxyFromDB(); err != nil {
 		log.Printf("Warning: failed to update TCP proxy after managed service deletion: %v", err)
 	}
@@ -287,35 +423,8 @@ func (s *ManagedServiceService) validateManagedServiceConfig(service models.Serv
 		return errors.New("service type must be 'managed'")
 	}
 
-	// Validate managed type
-	if !utils.IsValidManagedServiceType(service.ManagedType) {
-		return fmt.Errorf("unsupported managed service type: %s", service.ManagedType)
-	}
-
-	// Validate storage size format if provided
-	if service.StorageSize != "" {
-		// This is a basic validation - Kubernetes will do more thorough validation
-		if len(service.StorageSize) < 2 {
-			return errors.New("invalid storage size format")
-		}
-	}
-
-	// Validate CPU and memory limits if provided
-	if service.CPULimit != "" {
-		// Basic validation - should end with 'm' or be a number
-		if len(service.CPULimit) < 2 {
-			return errors.New("invalid CPU limit format")
-		}
-	}
-
-	if service.MemoryLimit != "" {
-		// Basic validation - should end with 'Mi', 'Gi', etc.
-		if len(service.MemoryLimit) < 3 {
-			return errors.New("invalid memory limit format")
-		}
-	}
-
-	return nil
+	// Validate managed type, resource quantities and pooling settings
+	return utils.ValidateManagedServiceFields(service)
 }
 
 // validateStorageSizeIncrease validates that storage size can only be increased
@@ -350,18 +459,22 @@ func (s *ManagedServiceService) setManagedServiceDefaults(service models.Service
 		service.Version = utils.GetManagedServiceDefaultVersion(service.ManagedType)
 	}
 
-	// Set default storage size if empty and storage is required
+	// Environment presets were applied by ServiceService; fall back to the platform presets
 	if service.StorageSize == "" && utils.RequiresPersistentStorage(service.ManagedType) {
-		service.StorageSize = "1Gi"
+		service.StorageSize = utils.DefaultManagedStorageSize
 	}
 
-	// Set default resource limits if empty
 	if service.CPULimit == "" {
-		service.CPULimit = "500m"
+		service.CPULimit = utils.DefaultManagedCPULimit
 	}
 
 	if service.MemoryLimit == "" {
-		service.MemoryLimit = "512Mi"
+		service.MemoryLimit = utils.DefaultManagedMemoryLimit
+	}
+
+	// Set pooling defaults only when PgBouncer is enabled
+	if service.PoolingEnabled {
+		service = setPoolingDefaults(service)
 	}
 
 	// Managed services are always single replica for data consistency
@@ -391,6 +504,12 @@ func (s *ManagedServiceService) deployManagedServiceToKubernetes(service models.
 		return &service, err
 	}
 
+	preparedService = resolveClusterConfig(preparedService)
+	if err := NewPolicyService().Evaluate("", preparedService); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+
 	// Use the Kubernetes deployment utility
 	deployedService, err := utils.DeployManagedServiceToKubernetes(preparedService)
 	if err != nil {
@@ -401,6 +520,20 @@ func (s *ManagedServiceService) deployManagedServiceToKubernetes(service models.
 	return deployedService, nil
 }
 
+// waitUntilReady waits for the readiness probe to pass before reporting the service as running
+func (s *ManagedServiceService) waitUntilReady(service models.Service) {
+	status := "running"
+	if err := utils.WaitForManagedServiceReady(service, utils.ManagedServiceReadyTimeout); err != nil {
+		log.Printf("Managed service %s did not become ready: %v", service.ID, err)
+		status = "failed"
+	}
+
+	// Only the status: the service may have been edited during the wait
+	if err := s.serviceRepo.UpdateStatus(service.ID, status); err != nil {
+		log.Printf("Failed to update service status after readiness check: %v", err)
+	}
+}
+
 func (s *ManagedServiceService) ensureManagedServiceProxyAllocation(service models.Service) (models.Service, error) {
 	proxyConfig := utils.GetTCPProxyConfig()
 	service.ExternalHost = proxyConfig.Host
@@ -421,14 +554,16 @@ func (s *ManagedServiceService) ensureManagedServiceProxyAllocation(service mode
 		}
 	}
 
-	for port := proxyConfig.PortStart; port <= proxyConfig.PortEnd; port++ {
+	// Ports allocated before an admin narrowed the type's range are kept above
+	portStart, portEnd := utils.GetManagedServicePortRange(service.ManagedType)
+	for port := portStart; port <= portEnd; port++ {
 		if !usedPorts[port] {
 			service.ExternalPort = port
 			return service, nil
 		}
 	}
 
-	return service, fmt.Errorf("no available TCP proxy ports in range %d-%d", proxyConfig.PortStart, proxyConfig.PortEnd)
+	return service, fmt.Errorf("no available TCP proxy ports for %s in range %d-%d", service.ManagedType, portStart, portEnd)
 }
 
 func (s *ManagedServiceService) ensureTCPProxyFromDB() error {
@@ -455,11 +590,19 @@ func (s *ManagedServiceService) deleteManagedServiceFromKubernetes(service model
 
 // checkIfRedeploymentNeeded determines if changes require redeployment
 func (s *ManagedServiceService) checkIfRedeploymentNeeded(existing, updated models.Service) bool {
-	// Changes that require redeployment
-	return existing.Version != updated.Version ||
-		existing.CPULimit != updated.CPULimit ||
-		existing.MemoryLimit != updated.MemoryLimit ||
-		existing.StorageSize != updated.StorageSize ||
-		existing.EnvironmentID != updated.EnvironmentID ||
-		existing.CustomDomain != updated.CustomDomain
+	return utils.PlanServiceUpdate(existing, updated).Action != utils.UpdateActionNone
+}
+
+// setPoolingDefaults fills in PgBouncer settings that were left empty
+func setPoolingDefaults(service models.Service) models.Service {
+	if service.PoolMode == "" {
+		service.PoolMode = utils.DefaultPoolMode
+	}
+	if service.PoolSize == 0 {
+		service.PoolSize = utils.DefaultPoolSize
+	}
+	if service.MaxClientConn == 0 {
+		service.MaxClientConn = utils.DefaultMaxClientConn
+	}
+	return service
 }
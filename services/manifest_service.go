@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ManifestService renders the Kubernetes resources PenDeploy applies for a service
+type ManifestService struct {
+	serviceRepo    *repositories.ServiceRepository
+	projectRepo    *repositories.ProjectRepository
+	deploymentRepo *repositories.DeploymentRepository
+}
+
+// NewManifestService creates a new manifest service instance
+func NewManifestService() *ManifestService {
+	return &ManifestService{
+		serviceRepo:    repositories.NewServiceRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+		deploymentRepo: repositories.NewDeploymentRepository(),
+	}
+}
+
+// RenderManifests returns the YAML of every resource generated for the service from its
+// current config. Git services are rendered with the image of their latest successful
+// deployment, which is what a redeploy would apply.
+func (s *ManifestService) RenderManifests(serviceID string, userID string, isAdmin bool) (string, models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return "", service, fmt.Errorf("service not found: %v", err)
+	}
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return "", service, err
+		}
+		if ownerID != userID {
+			return "", service, errors.New("unauthorized access to service")
+		}
+	}
+
+	image := ""
+	if service.Type == models.ServiceTypeGit {
+		deployment, err := s.deploymentRepo.GetLatestSuccessfulDeployment(serviceID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && deployment.Image == "") {
+			return "", service, errors.New("service has no successful deployment to render")
+		}
+		if err != nil {
+			return "", service, err
+		}
+		image = deployment.Image
+	}
+
+	manifests, err := utils.RenderServiceManifests(image, resolveClusterConfig(service))
+	return manifests, service, err
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	// defaultMetricRange is the range of a metric query without from
+	defaultMetricRange = 24 * time.Hour
+	// maxMetricRangeDays bounds the range of a metric query
+	maxMetricRangeDays = 90
+	// maxMetricPoints bounds the rows of a metric query, over all series
+	maxMetricPoints = 5000
+)
+
+// metricBuckets are the time buckets of metric queries, in seconds
+var metricBuckets = []struct {
+	name    string
+	seconds int64
+}{
+	{"1m", 60},
+	{"5m", 5 * 60},
+	{"15m", 15 * 60},
+	{"1h", 60 * 60},
+	{"6h", 6 * 60 * 60},
+	{"1d", 24 * 60 * 60},
+	{"7d", 7 * 24 * 60 * 60},
+}
+
+// MetricQueryService answers the aggregation queries of dashboard widgets over the stored
+// usage samples, uptime checks, deployments and incidents
+type MetricQueryService struct {
+	metricRepo *repositories.MetricQueryRepository
+}
+
+// NewMetricQueryService creates a new metric query service instance
+func NewMetricQueryService() *MetricQueryService {
+	return &MetricQueryService{
+		metricRepo: repositories.NewMetricQueryRepository(),
+	}
+}
+
+// GetCatalog lists the sources, metrics, dimensions, aggregations and buckets of queries
+func (s *MetricQueryService) GetCatalog() dto.MetricCatalog {
+	catalog := dto.MetricCatalog{
+		Sources:      s.metricRepo.Sources(),
+		Aggregations: s.metricRepo.Aggregations(),
+		MaxGroupBy:   repositories.MaxMetricGroupBy,
+		MaxRangeDays: maxMetricRangeDays,
+		MaxPoints:    maxMetricPoints,
+	}
+	for _, bucket := range metricBuckets {
+		catalog.Buckets = append(catalog.Buckets, bucket.name)
+	}
+	return catalog
+}
+
+// Query aggregates a metric over time for the caller's projects (all projects for admins)
+func (s *MetricQueryService) Query(req dto.MetricQueryRequest, userID string, isAdmin bool) (dto.MetricQueryResponse, error) {
+	query, err := s.checkQuery(req)
+	if err != nil {
+		return dto.MetricQueryResponse{}, err
+	}
+
+	rows, truncated, err := s.metricRepo.Query(query, userID, isAdmin)
+	if err != nil {
+		return dto.MetricQueryResponse{}, err
+	}
+
+	response := dto.MetricQueryResponse{
+		Source:      query.Source,
+		Metric:      query.Metric,
+		Unit:        s.metricRepo.MetricUnit(query.Source, query.Metric),
+		Aggregation: query.Aggregation,
+		Bucket:      req.Bucket,
+		From:        query.From,
+		To:          query.To,
+		Series:      []dto.MetricSeries{},
+		Truncated:   truncated,
+	}
+
+	series := map[string]*dto.MetricSeries{}
+	var order []string
+	for _, row := range rows {
+		id := strings.Join(row.Keys, "\x00")
+		current, ok := series[id]
+		if !ok {
+			current = &dto.MetricSeries{Group: map[string]string{}, Labels: map[string]string{}, Points: []dto.MetricPoint{}}
+			for i, dimension := range query.GroupBy {
+				current.Group[dimension] = row.Keys[i]
+				current.Labels[dimension] = row.Labels[i]
+			}
+			series[id] = current
+			order = append(order, id)
+		}
+		point := dto.MetricPoint{Time: query.From, Value: row.Value}
+		if row.Bucket != nil {
+			point.Time = row.Bucket.UTC()
+		}
+		current.Points = append(current.Points, point)
+	}
+	sort.Strings(order)
+	for _, id := range order {
+		// Rows are ordered by bucket, so the points already are
+		response.Series = append(response.Series, *series[id])
+	}
+	return response, nil
+}
+
+// checkQuery validates the names, range and bucket of a request against the catalog and
+// fills in the defaults
+func (s *MetricQueryService) checkQuery(req dto.MetricQueryRequest) (repositories.MetricQuery, error) {
+	var errs utils.FieldErrors
+	query := repositories.MetricQuery{
+		Source:        req.Source,
+		Metric:        req.Metric,
+		Aggregation:   req.Aggregation,
+		GroupBy:       req.GroupBy,
+		From:          req.From.UTC(),
+		To:            req.To.UTC(),
+		ProjectID:     req.ProjectID,
+		EnvironmentID: req.EnvironmentID,
+		ServiceIDs:    req.ServiceIDs,
+		Limit:         maxMetricPoints,
+	}
+	if query.Aggregation == "" {
+		query.Aggregation = "avg"
+	}
+	if req.To.IsZero() {
+		query.To = time.Now().UTC()
+	}
+	if req.From.IsZero() {
+		query.From = query.To.Add(-defaultMetricRange)
+	}
+
+	var source *dto.MetricSourceInfo
+	sources := s.metricRepo.Sources()
+	for i := range sources {
+		if sources[i].Name == req.Source {
+			source = &sources[i]
+		}
+	}
+	if source == nil {
+		errs.Add("source", "unknown source %q", req.Source)
+	} else {
+		known := false
+		for _, metric := range source.Metrics {
+			known = known || metric.Name == req.Metric
+		}
+		if !known {
+			errs.Add("metric", "unknown metric %q of source %s", req.Metric, req.Source)
+		}
+		for _, dimension := range req.GroupBy {
+			known := false
+			for _, name := range source.Dimensions {
+				known = known || name == dimension
+			}
+			if !known {
+				errs.Add("groupBy", "unknown dimension %q of source %s", dimension, req.Source)
+			}
+		}
+	}
+	if len(req.GroupBy) > repositories.MaxMetricGroupBy {
+		errs.Add("groupBy", "must not list more than %d dimensions", repositories.MaxMetricGroupBy)
+	}
+
+	if !query.From.Before(query.To) {
+		errs.Add("from", "must be before to")
+	} else if query.To.Sub(query.From) > maxMetricRangeDays*24*time.Hour {
+		errs.Add("from", "the range must not exceed %d days", maxMetricRangeDays)
+	}
+
+	if req.Bucket != "" {
+		for _, bucket := range metricBuckets {
+			if bucket.name == req.Bucket {
+				query.BucketSeconds = bucket.seconds
+			}
+		}
+		switch {
+		case query.BucketSeconds == 0:
+			errs.Add("bucket", "must be one of 1m, 5m, 15m, 1h, 6h, 1d or 7d")
+		case query.From.Before(query.To) && int64(query.To.Sub(query.From).Seconds())/query.BucketSeconds > maxMetricPoints:
+			errs.Add("bucket", "the range holds more than %d buckets; use a larger bucket", maxMetricPoints)
+		}
+	}
+
+	return query, errs.Err()
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"github.com/pendeploy-simple/database"
+	"github.com/pendeploy-simple/dto"
+)
+
+// MigrationService reports the schema migration state of the platform database
+type MigrationService struct{}
+
+// NewMigrationService creates a new migration service instance
+func NewMigrationService() *MigrationService {
+	return &MigrationService{}
+}
+
+// GetStatus lists applied and pending migrations
+func (s *MigrationService) GetStatus() (dto.MigrationReport, error) {
+	return database.GetMigrationReport(database.DB)
+}
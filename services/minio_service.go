@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// MinIOService provisions buckets, policies and lifecycle rules on managed MinIO services
+type MinIOService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewMinIOService creates a new MinIO service instance
+func NewMinIOService() *MinIOService {
+	return &MinIOService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// ListBuckets lists the buckets on the MinIO instance
+func (s *MinIOService) ListBuckets(serviceID string, userID string, isAdmin bool) ([]dto.MinIOBucketResponse, error) {
+	service, err := s.getMinIOService(serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ListMinIOBuckets(service)
+}
+
+// CreateBucket creates a bucket and optionally bucket-scoped credentials
+func (s *MinIOService) CreateBucket(serviceID string, req dto.MinIOBucketRequest, userID string, isAdmin bool) (dto.MinIOBucketResponse, error) {
+	service, err := s.getMinIOService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.MinIOBucketResponse{}, err
+	}
+
+	if !utils.IsValidBucketName(req.Name) {
+		return dto.MinIOBucketResponse{}, errors.New("invalid bucket name: use 3-63 lowercase letters, digits, '.' or '-'")
+	}
+	if req.Policy == "" {
+		req.Policy = "none"
+	}
+	if !utils.IsValidBucketPolicy(req.Policy) {
+		return dto.MinIOBucketResponse{}, fmt.Errorf("invalid bucket policy: %s (must be none, download, upload or public)", req.Policy)
+	}
+
+	if err := utils.CreateMinIOBucket(service, req); err != nil {
+		return dto.MinIOBucketResponse{}, err
+	}
+
+	response := dto.MinIOBucketResponse{
+		Name:           req.Name,
+		Policy:         req.Policy,
+		Versioning:     req.Versioning,
+		LifecycleRules: req.LifecycleRules,
+	}
+
+	if req.CreateCredentials {
+		credentials, err := utils.CreateMinIOBucketCredentials(service, req.Name)
+		if err != nil {
+			return response, fmt.Errorf("bucket created but credentials failed: %v", err)
+		}
+		response.Credentials = &credentials
+	}
+
+	log.Printf("Created bucket %s on MinIO service %s", req.Name, service.ID)
+	return response, nil
+}
+
+// GetBucketCredentials returns the bucket-scoped credentials, creating them if needed
+func (s *MinIOService) GetBucketCredentials(serviceID string, bucket string, userID string, isAdmin bool) (dto.MinIOBucketCredentials, error) {
+	service, err := s.getMinIOService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.MinIOBucketCredentials{}, err
+	}
+
+	credentials, err := utils.GetMinIOBucketCredentials(service, bucket)
+	if err != nil {
+		return dto.MinIOBucketCredentials{}, err
+	}
+	if credentials != nil {
+		return *credentials, nil
+	}
+
+	return utils.CreateMinIOBucketCredentials(service, bucket)
+}
+
+// SetLifecycleRules replaces the lifecycle/expiry rules of a bucket
+func (s *MinIOService) SetLifecycleRules(serviceID string, bucket string, req dto.MinIOLifecycleRequest, userID string, isAdmin bool) error {
+	service, err := s.getMinIOService(serviceID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	return utils.SetMinIOBucketLifecycle(service, bucket, req.Rules)
+}
+
+// DeleteBucket deletes a bucket, its contents and its scoped credentials
+func (s *MinIOService) DeleteBucket(serviceID string, bucket string, userID string, isAdmin bool) error {
+	service, err := s.getMinIOService(serviceID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.DeleteMinIOBucket(service, bucket); err != nil {
+		return err
+	}
+
+	log.Printf("Deleted bucket %s on MinIO service %s", bucket, service.ID)
+	return nil
+}
+
+// getMinIOService loads the service and checks access and type
+func (s *MinIOService) getMinIOService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+
+	if service.Type != models.ServiceTypeManaged || service.ManagedType != "minio" {
+		return service, errors.New("service is not a managed MinIO service")
+	}
+
+	return service, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Statuses of a node operation
+const (
+	NodeOperationRunning   = "running"
+	NodeOperationSucceeded = "succeeded"
+	NodeOperationFailed    = "failed"
+)
+
+// ErrNodeNotFound is returned for actions on a node that doesn't exist
+var ErrNodeNotFound = errors.New("node not found")
+
+// ErrNodeOperationRunning is returned while a drain of the node is still in progress
+var ErrNodeOperationRunning = errors.New("a drain of this node is already running")
+
+// ErrNoNodeOperation is returned when no action has been run on the node since startup
+var ErrNoNodeOperation = errors.New("no action has been run on this node")
+
+// nodeOperations holds the latest operation per node; drains run in the background
+// and report their progress here
+var (
+	nodeOperations   = map[string]*dto.NodeOperation{}
+	nodeOperationsMu sync.Mutex
+)
+
+// RunNodeAction cordons, uncordons or drains a node. Cordon and uncordon finish before
+// returning; a drain cordons the node, then evicts its pods in the background, so the
+// returned operation is still running and GetNodeOperation reports its progress.
+func (s *NodeStatsService) RunNodeAction(nodeName string, req dto.NodeActionRequest) (dto.NodeOperation, error) {
+	ctx := context.Background()
+
+	kubeClient, err := kubernetes.NewClient()
+	if err != nil {
+		return dto.NodeOperation{}, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	if _, err := kubeClient.Clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return dto.NodeOperation{}, ErrNodeNotFound
+		}
+		return dto.NodeOperation{}, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+
+	nodeOperationsMu.Lock()
+	if current, ok := nodeOperations[nodeName]; ok && current.Status == NodeOperationRunning {
+		nodeOperationsMu.Unlock()
+		return dto.NodeOperation{}, ErrNodeOperationRunning
+	}
+	operation := &dto.NodeOperation{
+		Node:      nodeName,
+		Action:    req.Action,
+		Status:    NodeOperationRunning,
+		StartedAt: time.Now(),
+	}
+	nodeOperations[nodeName] = operation
+	nodeOperationsMu.Unlock()
+
+	if req.Action != utils.NodeActionDrain {
+		err := utils.SetNodeUnschedulable(ctx, kubeClient, nodeName, req.Action == utils.NodeActionCordon)
+		s.finishNodeOperation(operation, err)
+		return s.snapshotNodeOperation(operation), err
+	}
+
+	// Refuse the drain before cordoning when pods would be lost
+	pods, skipped, err := utils.DrainPods(ctx, kubeClient, nodeName, req.Force, req.DeleteEmptyDirData)
+	if err == nil {
+		err = utils.SetNodeUnschedulable(ctx, kubeClient, nodeName, true)
+	}
+	if err != nil {
+		s.finishNodeOperation(operation, err)
+		return s.snapshotNodeOperation(operation), err
+	}
+
+	nodeOperationsMu.Lock()
+	operation.PodsTotal = len(pods)
+	operation.SkippedPods = skipped
+	operation.PendingPods = podKeys(pods)
+	nodeOperationsMu.Unlock()
+
+	log.Printf("Draining node %s: %d pod(s) to evict, %d skipped", nodeName, len(pods), len(skipped))
+	go s.drainNode(kubeClient, operation, pods, req)
+	return s.snapshotNodeOperation(operation), nil
+}
+
+// GetNodeOperation returns the latest action run on a node
+func (s *NodeStatsService) GetNodeOperation(nodeName string) (dto.NodeOperation, error) {
+	nodeOperationsMu.Lock()
+	operation, ok := nodeOperations[nodeName]
+	nodeOperationsMu.Unlock()
+	if !ok {
+		return dto.NodeOperation{}, ErrNoNodeOperation
+	}
+	return s.snapshotNodeOperation(operation), nil
+}
+
+// drainNode evicts the pods until all of them are gone or the timeout passes. Evictions
+// refused by a PodDisruptionBudget are retried, so budgets are never violated.
+func (s *NodeStatsService) drainNode(kubeClient *kubernetes.Client, operation *dto.NodeOperation, pods []corev1.Pod, req dto.NodeActionRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), utils.GetDrainTimeout(req.TimeoutSeconds))
+	defer cancel()
+
+	evictionRequested := make(map[string]bool)
+	for {
+		var pending []string
+		var blocked []string
+		for _, pod := range pods {
+			key := pod.Namespace + "/" + pod.Name
+
+			current, err := kubeClient.Clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+				continue
+			}
+
+			if !evictionRequested[key] {
+				err := utils.EvictPod(ctx, kubeClient, pod, req.GracePeriodSeconds)
+				switch {
+				case err == nil:
+					evictionRequested[key] = true
+				case apierrors.IsNotFound(err):
+					continue
+				case apierrors.IsTooManyRequests(err):
+					blocked = append(blocked, key)
+				case ctx.Err() != nil:
+					// Reported as a timeout below
+				default:
+					s.finishNodeOperation(operation, fmt.Errorf("failed to evict pod %s: %v", key, err))
+					return
+				}
+			}
+			pending = append(pending, key)
+		}
+
+		nodeOperationsMu.Lock()
+		operation.PendingPods = pending
+		operation.PodsEvicted = len(pods) - len(pending)
+		operation.Message = ""
+		if len(blocked) > 0 {
+			operation.Message = fmt.Sprintf("Waiting for PodDisruptionBudgets to allow evicting %s", strings.Join(blocked, ", "))
+		}
+		nodeOperationsMu.Unlock()
+
+		if len(pending) == 0 {
+			log.Printf("Drained node %s", operation.Node)
+			s.finishNodeOperation(operation, nil)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			s.finishNodeOperation(operation, fmt.Errorf("timed out with %d pod(s) still on the node: %s", len(pending), strings.Join(pending, ", ")))
+			return
+		case <-time.After(utils.DrainRetryInterval):
+		}
+	}
+}
+
+// finishNodeOperation records the outcome of an operation
+func (s *NodeStatsService) finishNodeOperation(operation *dto.NodeOperation, err error) {
+	nodeOperationsMu.Lock()
+	defer nodeOperationsMu.Unlock()
+
+	now := time.Now()
+	operation.FinishedAt = &now
+	if err != nil {
+		log.Printf("Failed to %s node %s: %v", operation.Action, operation.Node, err)
+		operation.Status = NodeOperationFailed
+		operation.Message = err.Error()
+		return
+	}
+	operation.Status = NodeOperationSucceeded
+}
+
+// snapshotNodeOperation copies an operation so it can be returned while a drain updates it
+func (s *NodeStatsService) snapshotNodeOperation(operation *dto.NodeOperation) dto.NodeOperation {
+	nodeOperationsMu.Lock()
+	defer nodeOperationsMu.Unlock()
+
+	snapshot := *operation
+	snapshot.PendingPods = append([]string(nil), operation.PendingPods...)
+	snapshot.SkippedPods = append([]string(nil), operation.SkippedPods...)
+	return snapshot
+}
+
+func podKeys(pods []corev1.Pod) []string {
+	keys := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		keys = append(keys, pod.Namespace+"/"+pod.Name)
+	}
+	return keys
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/utils"
+	resource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -255,3 +256,158 @@ func (s *NodeStatsService) getNodeStorageUsage(ctx context.Context, nodeName str
 	
 	return nil, fmt.Errorf("no storage stats available for node %s", nodeName)
 }
+
+
+// GetNodeCapacity returns allocatable and already-requested resources for every Ready node
+func (s *NodeStatsService) GetNodeCapacity() ([]dto.NodeCapacity, error) {
+	ctx := context.Background()
+
+	kubeClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	nodes, err := kubeClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	// Only pods that still hold their requests count against a node
+	pods, err := kubeClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	requestedCPU := make(map[string]int64)
+	requestedMemory := make(map[string]int64)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		cpuRequest, _, memoryRequest, _ := utils.GetContainerResourceTotals(pod)
+		requestedCPU[pod.Spec.NodeName] += cpuRequest
+		requestedMemory[pod.Spec.NodeName] += memoryRequest
+	}
+
+	capacities := make([]dto.NodeCapacity, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if utils.GetNodeStatus(node) != "Ready" || node.Spec.Unschedulable {
+			continue
+		}
+		capacities = append(capacities, dto.NodeCapacity{
+			Name:              node.Name,
+			AllocatableCPU:    node.Status.Allocatable.Cpu().MilliValue(),
+			AllocatableMemory: node.Status.Allocatable.Memory().Value(),
+			RequestedCPU:      requestedCPU[node.Name],
+			RequestedMemory:   requestedMemory[node.Name],
+		})
+	}
+
+	return capacities, nil
+}
+
+// CheckPlacement verifies that a pod with the given CPU/memory limits fits on at least
+// one node. Limits no node can provide are rejected; limits that only exceed the
+// currently unreserved capacity produce warnings, since those pods may stay Pending
+// or get evicted under pressure.
+func (s *NodeStatsService) CheckPlacement(cpuLimit, memoryLimit string, replicas int) (dto.PlacementCheckResult, error) {
+	result := dto.PlacementCheckResult{Schedulable: true}
+
+	var cpuMilli, memoryBytes int64
+	if cpuLimit != "" {
+		quantity, err := resource.ParseQuantity(cpuLimit)
+		if err != nil {
+			return result, fmt.Errorf("invalid CPU limit %q: %v", cpuLimit, err)
+		}
+		cpuMilli = quantity.MilliValue()
+	}
+	if memoryLimit != "" {
+		quantity, err := resource.ParseQuantity(memoryLimit)
+		if err != nil {
+			return result, fmt.Errorf("invalid memory limit %q: %v", memoryLimit, err)
+		}
+		memoryBytes = quantity.Value()
+	}
+	if cpuMilli == 0 && memoryBytes == 0 {
+		return result, nil
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	capacities, err := s.GetNodeCapacity()
+	if err != nil {
+		// Don't block service changes when the cluster can't be inspected
+		log.Printf("Warning: skipping capacity check: %v", err)
+		result.Warnings = append(result.Warnings, "Cluster capacity could not be checked; scheduling is not guaranteed")
+		return result, nil
+	}
+
+	if len(capacities) == 0 {
+		result.Warnings = append(result.Warnings, "No Ready nodes are available; pods will stay Pending until a node becomes Ready")
+		return result, nil
+	}
+
+	var largest dto.NodeCapacity
+	fitsAnyNode := false
+	podsThatFit := 0
+	for _, node := range capacities {
+		if node.AllocatableCPU > largest.AllocatableCPU {
+			largest.AllocatableCPU = node.AllocatableCPU
+		}
+		if node.AllocatableMemory > largest.AllocatableMemory {
+			largest.AllocatableMemory = node.AllocatableMemory
+		}
+
+		if cpuMilli <= node.AllocatableCPU && memoryBytes <= node.AllocatableMemory {
+			fitsAnyNode = true
+		}
+
+		podsThatFit += podsFittingOnNode(node, cpuMilli, memoryBytes)
+	}
+
+	if !fitsAnyNode {
+		result.Schedulable = false
+		result.Reason = fmt.Sprintf(
+			"requested resources (CPU %s, memory %s) exceed the allocatable capacity of every node (largest node: CPU %s cores, memory %s)",
+			cpuLimit, memoryLimit,
+			utils.FormatCPUCores(largest.AllocatableCPU),
+			utils.FormatBytesToHumanReadable(largest.AllocatableMemory),
+		)
+		return result, nil
+	}
+
+	if podsThatFit < replicas {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"Only %d of %d replica(s) fit within the cluster's unreserved capacity; the rest may stay Pending or be evicted under pressure",
+			podsThatFit, replicas,
+		))
+	}
+
+	return result, nil
+}
+
+// podsFittingOnNode returns how many pods with the given resources fit in a node's unreserved capacity
+func podsFittingOnNode(node dto.NodeCapacity, cpuMilli, memoryBytes int64) int {
+	freeCPU := node.AllocatableCPU - node.RequestedCPU
+	freeMemory := node.AllocatableMemory - node.RequestedMemory
+	if freeCPU < 0 || freeMemory < 0 {
+		return 0
+	}
+
+	// At least one of cpuMilli/memoryBytes is non-zero (checked by the caller)
+	count := -1
+	if cpuMilli > 0 {
+		count = int(freeCPU / cpuMilli)
+	}
+	if memoryBytes > 0 {
+		byMemory := int(freeMemory / memoryBytes)
+		if count < 0 || byMemory < count {
+			count = byMemory
+		}
+	}
+	return count
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	digestSchedulerInterval = time.Minute
+	// digestSendHour is the hour (UTC) digests go out: daily ones every day, weekly ones on Mondays
+	digestSendHour = 8
+)
+
+var digestSchedulerOnce sync.Once
+
+// ErrDigestSubscriptionNotFound is returned for subscriptions that do not exist in the project
+var ErrDigestSubscriptionNotFound = errors.New("digest subscription not found")
+
+// NotificationDigestService manages the SMTP settings and the digest subscriptions of
+// projects, and runs the scheduler that emails the digests
+type NotificationDigestService struct {
+	digestRepo  *repositories.NotificationDigestRepository
+	projectRepo *repositories.ProjectRepository
+	serviceRepo *repositories.ServiceRepository
+}
+
+// NewNotificationDigestService creates a new notification digest service instance
+func NewNotificationDigestService() *NotificationDigestService {
+	return &NotificationDigestService{
+		digestRepo:  repositories.NewNotificationDigestRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+	}
+}
+
+// GetSMTPSettings returns the SMTP settings without the password
+func (s *NotificationDigestService) GetSMTPSettings() (dto.SMTPSettingsResponse, error) {
+	settings, err := s.digestRepo.FindSMTPSettings()
+	if err != nil {
+		return dto.SMTPSettingsResponse{}, err
+	}
+	return toSMTPSettingsResponse(settings), nil
+}
+
+// UpdateSMTPSettings changes the SMTP settings; they apply to the next email sent
+func (s *NotificationDigestService) UpdateSMTPSettings(req dto.SMTPSettingsUpdateRequest, userID string) (dto.SMTPSettingsResponse, error) {
+	settings, err := s.digestRepo.FindSMTPSettings()
+	if err != nil {
+		return dto.SMTPSettingsResponse{}, err
+	}
+
+	if req.Host != nil {
+		settings.Host = strings.TrimSpace(*req.Host)
+	}
+	if req.Port != nil {
+		settings.Port = *req.Port
+	}
+	if req.Security != nil {
+		settings.Security = *req.Security
+	}
+	if req.Username != nil {
+		settings.Username = *req.Username
+	}
+	if req.Password != nil {
+		settings.Password = *req.Password
+	}
+	if req.FromAddress != nil {
+		settings.FromAddress = *req.FromAddress
+	}
+	if settings.Username != "" && settings.Security == models.SMTPSecurityNone {
+		return dto.SMTPSettingsResponse{}, utils.FieldErrors{{Field: "security", Message: "must be starttls or tls when a username is set"}}
+	}
+	settings.UpdatedBy = userID
+
+	saved, err := s.digestRepo.SaveSMTPSettings(settings)
+	if err != nil {
+		return dto.SMTPSettingsResponse{}, err
+	}
+	return toSMTPSettingsResponse(saved), nil
+}
+
+// SendTestEmail sends a short email with the saved SMTP settings, so an admin can check them
+func (s *NotificationDigestService) SendTestEmail(to string) error {
+	settings, err := s.digestRepo.FindSMTPSettings()
+	if err != nil {
+		return err
+	}
+	return utils.SendEmail(settings, []string{to}, "Test email",
+		"This is a test email. The SMTP settings of the deployment platform work.\n")
+}
+
+// ListSubscriptions returns the digest subscriptions of a project
+func (s *NotificationDigestService) ListSubscriptions(projectID string, userID string, isAdmin bool) ([]models.DigestSubscription, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.digestRepo.FindSubscriptionsByProjectID(projectID)
+}
+
+// CreateSubscription subscribes an email address to the project's digest. The first digest
+// covers the first full period after subscribing.
+func (s *NotificationDigestService) CreateSubscription(projectID string, req dto.DigestSubscriptionRequest, userID string, isAdmin bool) (models.DigestSubscription, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.DigestSubscription{}, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	existing, err := s.digestRepo.FindSubscriptionsByProjectID(projectID)
+	if err != nil {
+		return models.DigestSubscription{}, err
+	}
+	for _, other := range existing {
+		if other.Email == email {
+			return models.DigestSubscription{}, fmt.Errorf("%s is already subscribed to this project's digest", email)
+		}
+	}
+
+	periodEnd := digestPeriodEnd(req.Frequency, time.Now())
+	subscription, err := s.digestRepo.CreateSubscription(models.DigestSubscription{
+		ProjectID:     projectID,
+		Email:         email,
+		Frequency:     req.Frequency,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+		LastPeriodEnd: &periodEnd,
+		CreatedBy:     userID,
+	})
+	if err != nil {
+		return models.DigestSubscription{}, err
+	}
+	log.Printf("%s subscribed to the %s digest of project %s", email, subscription.Frequency, projectID)
+	return subscription, nil
+}
+
+// UpdateSubscription changes the frequency or state of a digest subscription
+func (s *NotificationDigestService) UpdateSubscription(projectID, subscriptionID string, req dto.DigestSubscriptionUpdateRequest, userID string, isAdmin bool) (models.DigestSubscription, error) {
+	subscription, err := s.getSubscription(projectID, subscriptionID, userID, isAdmin)
+	if err != nil {
+		return subscription, err
+	}
+
+	if req.Frequency != "" && req.Frequency != subscription.Frequency {
+		// Start over with the new period instead of sending a catch-up digest right away
+		subscription.Frequency = req.Frequency
+		periodEnd := digestPeriodEnd(req.Frequency, time.Now())
+		subscription.LastPeriodEnd = &periodEnd
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	return s.digestRepo.UpdateSubscription(subscription)
+}
+
+// DeleteSubscription unsubscribes an email address from the project's digest
+func (s *NotificationDigestService) DeleteSubscription(projectID, subscriptionID string, userID string, isAdmin bool) error {
+	subscription, err := s.getSubscription(projectID, subscriptionID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.digestRepo.DeleteSubscription(subscription.ID)
+}
+
+// PreviewDigest builds the digest of a project for the period ending now, as it would be emailed
+func (s *NotificationDigestService) PreviewDigest(projectID, frequency string, userID string, isAdmin bool) (dto.ProjectDigest, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return dto.ProjectDigest{}, err
+	}
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return dto.ProjectDigest{}, err
+	}
+	to := time.Now().UTC()
+	return s.buildDigest(project, frequency, to.Add(-digestPeriod(frequency)), to)
+}
+
+// StartDigestScheduler starts the background loop that emails the digests of subscribed
+// projects when their period ends. It is safe to call more than once.
+func (s *NotificationDigestService) StartDigestScheduler() {
+	digestSchedulerOnce.Do(func() {
+		go func() {
+			log.Printf("Digest scheduler started (interval %v)", digestSchedulerInterval)
+			ticker := time.NewTicker(digestSchedulerInterval)
+			defer ticker.Stop()
+
+			s.sendDueDigests()
+			for range ticker.C {
+				s.sendDueDigests()
+			}
+		}()
+	})
+}
+
+// sendDueDigests emails the digests whose period has ended and was not sent yet. Each period
+// is claimed in the database first, so it is sent once even with several API replicas; a
+// digest that fails to send is recorded on the subscription, not retried.
+func (s *NotificationDigestService) sendDueDigests() {
+	settings, err := s.digestRepo.FindSMTPSettings()
+	if err != nil {
+		log.Printf("Digest scheduler: failed to load SMTP settings: %v", err)
+		return
+	}
+	if !settings.Configured() {
+		return
+	}
+
+	now := time.Now()
+	for _, frequency := range []string{models.DigestFrequencyDaily, models.DigestFrequencyWeekly} {
+		subscriptions, err := s.digestRepo.FindEnabledSubscriptions(frequency)
+		if err != nil {
+			log.Printf("Digest scheduler: failed to load %s subscriptions: %v", frequency, err)
+			continue
+		}
+
+		periodEnd := digestPeriodEnd(frequency, now)
+		digests := make(map[string]*dto.ProjectDigest)
+		for _, subscription := range subscriptions {
+			if subscription.LastPeriodEnd != nil && !subscription.LastPeriodEnd.Before(periodEnd) {
+				continue
+			}
+			claimed, err := s.digestRepo.ClaimPeriod(subscription.ID, periodEnd)
+			if err != nil {
+				log.Printf("Digest scheduler: failed to claim subscription %s: %v", subscription.ID, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+
+			digest, ok := digests[subscription.ProjectID]
+			if !ok {
+				digest, err = s.projectDigest(subscription.ProjectID, frequency, periodEnd)
+				if err != nil {
+					log.Printf("Digest scheduler: failed to build the digest of project %s: %v", subscription.ProjectID, err)
+					s.recordSendError(subscription.ID, err)
+					continue
+				}
+				digests[subscription.ProjectID] = digest
+			}
+
+			subject, body := utils.RenderDigestEmail(*digest)
+			err = utils.SendEmail(settings, []string{subscription.Email}, subject, body)
+			if err != nil {
+				log.Printf("Digest scheduler: failed to email the %s digest of project %s to %s: %v", frequency, subscription.ProjectID, subscription.Email, err)
+			}
+			s.recordSendError(subscription.ID, err)
+		}
+	}
+}
+
+func (s *NotificationDigestService) projectDigest(projectID, frequency string, periodEnd time.Time) (*dto.ProjectDigest, error) {
+	project, err := s.projectRepo.FindByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := s.buildDigest(project, frequency, periodEnd.Add(-digestPeriod(frequency)), periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+func (s *NotificationDigestService) recordSendError(subscriptionID string, sendErr error) {
+	message := ""
+	if sendErr != nil {
+		message = sendErr.Error()
+	}
+	if err := s.digestRepo.RecordSendError(subscriptionID, message); err != nil {
+		log.Printf("Digest scheduler: failed to record the result of subscription %s: %v", subscriptionID, err)
+	}
+}
+
+// buildDigest summarizes a project's deployments, failed deployments, service incidents, usage
+// anomalies and the warnings recorded on deployments in [from, to)
+func (s *NotificationDigestService) buildDigest(project models.Project, frequency string, from, to time.Time) (dto.ProjectDigest, error) {
+	digest := dto.ProjectDigest{
+		ProjectID:   project.ID,
+		ProjectName: project.Name,
+		Frequency:   frequency,
+		From:        from,
+		To:          to,
+		Services:    []dto.DigestServiceSummary{},
+		Failures:    []dto.DigestFailure{},
+		Warnings:    []dto.DigestWarning{},
+	}
+
+	services, err := s.serviceRepo.FindByProjectID(project.ID)
+	if err != nil {
+		return digest, err
+	}
+	names := make(map[string]string, len(services))
+	for _, service := range services {
+		names[service.ID] = service.Name
+	}
+	serviceName := func(id string) string {
+		if name, ok := names[id]; ok {
+			return name
+		}
+		return id // deleted since
+	}
+
+	deployments, err := s.digestRepo.FindProjectDeployments(project.ID, from, to)
+	if err != nil {
+		return digest, err
+	}
+	summaries := make(map[string]*dto.DigestServiceSummary)
+	for _, deployment := range deployments {
+		summary, ok := summaries[deployment.ServiceID]
+		if !ok {
+			summary = &dto.DigestServiceSummary{ServiceID: deployment.ServiceID, ServiceName: serviceName(deployment.ServiceID)}
+			summaries[deployment.ServiceID] = summary
+		}
+		summary.Deployments++
+		digest.Deployments++
+
+		switch deployment.Status {
+		case models.DeploymentStatusSuccess:
+			digest.SuccessfulDeployments++
+		case models.DeploymentStatusFailed:
+			summary.Failed++
+			digest.FailedDeployments++
+			digest.Failures = append(digest.Failures, dto.DigestFailure{
+				DeploymentID: deployment.ID,
+				ServiceName:  summary.ServiceName,
+				ErrorCode:    deployment.ErrorCode,
+				ErrorHint:    deployment.ErrorHint,
+				CreatedAt:    deployment.CreatedAt,
+			})
+		}
+
+		for _, warning := range []struct{ kind, message string }{
+			{"image_size", deployment.ImageSizeWarning},
+			{"port", deployment.PortWarning},
+			{"port_check", deployment.PortCheckError},
+		} {
+			if warning.message != "" {
+				digest.Warnings = append(digest.Warnings, dto.DigestWarning{
+					Kind:        warning.kind,
+					ServiceName: summary.ServiceName,
+					Message:     warning.message,
+					At:          deployment.CreatedAt,
+				})
+			}
+		}
+	}
+	for _, summary := range summaries {
+		digest.Services = append(digest.Services, *summary)
+	}
+	sort.Slice(digest.Services, func(i, j int) bool {
+		return digest.Services[i].ServiceName < digest.Services[j].ServiceName
+	})
+
+	incidents, err := s.digestRepo.FindProjectIncidents(project.ID, from, to)
+	if err != nil {
+		return digest, err
+	}
+	for _, incident := range incidents {
+		digest.Warnings = append(digest.Warnings, dto.DigestWarning{
+			Kind:        "incident",
+			ServiceName: serviceName(incident.ServiceID),
+			Message:     describeDigestIncident(incident),
+			At:          incident.StartedAt,
+		})
+	}
+
+	anomalies, err := s.digestRepo.FindProjectAnomalies(project.ID, from, to)
+	if err != nil {
+		return digest, err
+	}
+	for _, anomaly := range anomalies {
+		message := anomaly.Message
+		if anomaly.ResolvedAt == nil {
+			message += " (ongoing)"
+		}
+		digest.Warnings = append(digest.Warnings, dto.DigestWarning{
+			Kind:        "anomaly",
+			ServiceName: serviceName(anomaly.ServiceID),
+			Message:     message,
+			At:          anomaly.DetectedAt,
+		})
+	}
+	sort.SliceStable(digest.Warnings, func(i, j int) bool {
+		return digest.Warnings[i].At.Before(digest.Warnings[j].At)
+	})
+	return digest, nil
+}
+
+// describeDigestIncident names the signals of an incident, its probable cause and whether
+// it is still open
+func describeDigestIncident(incident models.ServiceIncident) string {
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, event := range incident.Events {
+		if !seen[event.Kind] {
+			seen[event.Kind] = true
+			kinds = append(kinds, strings.ReplaceAll(event.Kind, "_", " "))
+		}
+	}
+
+	message := "incident"
+	if len(kinds) > 0 {
+		message += " (" + strings.Join(kinds, ", ") + ")"
+	}
+	if incident.ProbableCause != "" {
+		message += ": " + incident.ProbableCause
+	}
+	if incident.ResolvedAt == nil {
+		return message + ", still open"
+	}
+	return message + ", resolved after " + incident.ResolvedAt.Sub(incident.StartedAt).Round(time.Minute).String()
+}
+
+// digestPeriod is the time a digest of the given frequency covers
+func digestPeriod(frequency string) time.Duration {
+	if frequency == models.DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digestPeriodEnd returns the latest send time at or before now: today's (or yesterday's)
+// digestSendHour for daily digests, and that of the latest Monday for weekly ones
+func digestPeriodEnd(frequency string, now time.Time) time.Time {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), digestSendHour, 0, 0, 0, time.UTC)
+	if end.After(now) {
+		end = end.AddDate(0, 0, -1)
+	}
+	if frequency == models.DigestFrequencyWeekly {
+		end = end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+	}
+	return end
+}
+
+func (s *NotificationDigestService) getSubscription(projectID, subscriptionID string, userID string, isAdmin bool) (models.DigestSubscription, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.DigestSubscription{}, err
+	}
+
+	subscription, err := s.digestRepo.FindSubscriptionByID(subscriptionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && subscription.ProjectID != projectID) {
+		return models.DigestSubscription{}, ErrDigestSubscriptionNotFound
+	}
+	return subscription, err
+}
+
+func (s *NotificationDigestService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
+
+func toSMTPSettingsResponse(settings models.SMTPSettings) dto.SMTPSettingsResponse {
+	return dto.SMTPSettingsResponse{
+		SMTPSettings: settings,
+		Configured:   settings.Configured(),
+		HasPassword:  settings.Password != "",
+	}
+}
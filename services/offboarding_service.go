@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ErrOffboardingIncomplete is returned when a project or namespace could not be dealt with;
+// the account is kept so the request can be repeated
+var ErrOffboardingIncomplete = errors.New("offboarding incomplete, the account was not deleted")
+
+// OffboardingService deletes accounts: the user's projects are transferred or deleted as
+// they decide, the namespaces of deleted projects torn down, their credentials revoked, and
+// the account and audit trail anonymized per the audit retention
+type OffboardingService struct {
+	offboardingRepo *repositories.OffboardingRepository
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+	serviceRepo     *repositories.ServiceRepository
+	projectService  *ProjectService
+	sessionService  *SessionService
+}
+
+// NewOffboardingService creates a new offboarding service instance
+func NewOffboardingService() *OffboardingService {
+	return &OffboardingService{
+		offboardingRepo: repositories.NewOffboardingRepository(),
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+		projectService:  NewProjectService(),
+		sessionService:  NewSessionService(),
+	}
+}
+
+// GetPlan lists the projects the user must decide on before the account can be deleted
+func (s *OffboardingService) GetPlan(userID string) (dto.OffboardingPlan, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return dto.OffboardingPlan{}, err
+	}
+	projects, err := s.projectRepo.FindByUserID(userID)
+	if err != nil {
+		return dto.OffboardingPlan{}, fmt.Errorf("failed to list projects: %v", err)
+	}
+
+	plan := dto.OffboardingPlan{
+		UserID:             user.ID,
+		Email:              user.Email,
+		Projects:           []dto.OffboardingProject{},
+		AuditRetentionDays: int(utils.GetAuditRetention() / (24 * time.Hour)),
+	}
+	for _, project := range projects {
+		environments, err := s.environmentRepo.CountByProjectID(project.ID)
+		if err != nil {
+			return plan, fmt.Errorf("failed to count environments: %v", err)
+		}
+		services, err := s.serviceRepo.CountByProjectID(project.ID)
+		if err != nil {
+			return plan, fmt.Errorf("failed to count services: %v", err)
+		}
+		plan.Projects = append(plan.Projects, dto.OffboardingProject{
+			ID:           project.ID,
+			Name:         project.Name,
+			Environments: int(environments),
+			Services:     int(services),
+		})
+	}
+	return plan, nil
+}
+
+// Offboard carries out the decisions on the user's projects, tears down the namespaces left
+// by their deleted projects and, when nothing failed, revokes the user's sessions and API
+// tokens and deletes the account. On ErrOffboardingIncomplete the result says what failed.
+func (s *OffboardingService) Offboard(userID string, req dto.OffboardingRequest) (dto.OffboardingResult, error) {
+	result := dto.OffboardingResult{
+		Transferred: []string{},
+		Deleted:     []string{},
+		Namespaces:  []dto.OffboardingNamespace{},
+	}
+	user, err := GetUser(userID)
+	if err != nil {
+		return result, err
+	}
+	targets, err := s.validateDecisions(*user, req)
+	if err != nil {
+		return result, err
+	}
+
+	for _, decision := range req.Decisions {
+		switch decision.Action {
+		case dto.OffboardingTransfer:
+			if err := s.projectRepo.UpdateOwner(decision.ProjectID, targets[decision.ProjectID]); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("transfer project %s: %v", decision.ProjectID, err))
+				continue
+			}
+			result.Transferred = append(result.Transferred, decision.ProjectID)
+		case dto.OffboardingDelete:
+			if _, err := s.projectService.DeleteProject(decision.ProjectID, userID, true); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("delete project %s: %v", decision.ProjectID, err))
+				continue
+			}
+			result.Deleted = append(result.Deleted, decision.ProjectID)
+		}
+	}
+
+	// Projects deleted before namespace teardown was retried in the background may have left
+	// namespaces behind, so every deleted project of the user is checked for leftovers
+	deletedProjects, err := s.projectRepo.FindDeletedByUserID(userID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list deleted projects: %v", err)
+	}
+	for _, project := range deletedProjects {
+		environments, err := s.environmentRepo.FindByProjectID(project.ID)
+		if err != nil {
+			return result, fmt.Errorf("failed to list environments: %v", err)
+		}
+		for _, environment := range environments {
+			namespace := dto.OffboardingNamespace{Namespace: environment.ID, ProjectID: project.ID}
+			namespace.State, err = utils.TeardownNamespace(environment.ID)
+			if err != nil {
+				namespace.Error = err.Error()
+				result.Errors = append(result.Errors, fmt.Sprintf("namespace %s: %v", environment.ID, err))
+			}
+			result.Namespaces = append(result.Namespaces, namespace)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result, ErrOffboardingIncomplete
+	}
+
+	revoked, err := s.sessionService.RevokeAll(userID, "", true)
+	if err != nil {
+		return result, fmt.Errorf("failed to revoke credentials: %v", err)
+	}
+	result.RevokedSessions = revoked.RevokedSessions
+	result.RevokedAPITokens = revoked.RevokedAPITokens
+
+	cutoff := time.Now().Add(-utils.GetAuditRetention())
+	result.AuditAnonymized, result.AuditDeleted, err = s.offboardingRepo.DeleteAccount(userID, cutoff)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete account: %v", err)
+	}
+	result.AccountDeleted = true
+	log.Printf("Account %s offboarded: %d projects transferred, %d deleted", userID, len(result.Transferred), len(result.Deleted))
+	return result, nil
+}
+
+// validateDecisions checks that the email is confirmed and every owned project has exactly
+// one decision, and returns the IDs of the users projects are transferred to
+func (s *OffboardingService) validateDecisions(user models.User, req dto.OffboardingRequest) (map[string]string, error) {
+	var errs utils.FieldErrors
+	if !strings.EqualFold(strings.TrimSpace(req.ConfirmEmail), user.Email) {
+		errs.Add("confirmEmail", "must match the account's email")
+	}
+	if user.Role == models.RoleAdmin {
+		admins, err := s.offboardingRepo.CountAdmins()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count admins: %v", err)
+		}
+		if admins <= 1 {
+			return nil, errors.New("the last admin account cannot be deleted")
+		}
+	}
+
+	projects, err := s.projectRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %v", err)
+	}
+	owned := map[string]bool{}
+	for _, project := range projects {
+		owned[project.ID] = true
+	}
+
+	targets := map[string]string{}
+	decided := map[string]bool{}
+	for i, decision := range req.Decisions {
+		field := fmt.Sprintf("decisions[%d]", i)
+		switch {
+		case !owned[decision.ProjectID]:
+			errs.Add(field+".projectId", "is not a project of the user")
+			continue
+		case decided[decision.ProjectID]:
+			errs.Add(field+".projectId", "has more than one decision")
+			continue
+		}
+		decided[decision.ProjectID] = true
+
+		if decision.Action != dto.OffboardingTransfer {
+			continue
+		}
+		if decision.TransferTo == "" {
+			errs.Add(field+".transferTo", "is required to transfer a project")
+			continue
+		}
+		target, err := s.offboardingRepo.FindUserByEmail(strings.TrimSpace(decision.TransferTo))
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			errs.Add(field+".transferTo", "is not the email of a user")
+		case err != nil:
+			return nil, fmt.Errorf("failed to find user: %v", err)
+		case target.ID == user.ID:
+			errs.Add(field+".transferTo", "must be another user")
+		default:
+			targets[decision.ProjectID] = target.ID
+		}
+	}
+	for _, project := range projects {
+		if !decided[project.ID] {
+			errs.Add("decisions", "project %s (%s) needs a transfer or delete decision", project.Name, project.ID)
+		}
+	}
+	return targets, errs.Err()
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	outboxBatchSize      = 50
+	outboxLease          = 2 * time.Minute // longer than a webhook timeout
+	outboxMaxAttempts    = 10
+	outboxMaxBackoff     = time.Hour
+	outboxRetention      = 7 * 24 * time.Hour
+	defaultOutboxSeconds = 5
+)
+
+var (
+	outboxOnce sync.Once
+	// outboxWake lets writers trigger a dispatch pass without waiting for the next tick
+	outboxWake = make(chan struct{}, 1)
+)
+
+// OutboxService delivers outbox events written alongside deployment status changes and
+// runs scheduled deployments and namespace teardowns when they come due
+type OutboxService struct {
+	outboxRepo *repositories.OutboxRepository
+}
+
+// NewOutboxService creates a new outbox service instance
+func NewOutboxService() *OutboxService {
+	return &OutboxService{
+		outboxRepo: repositories.NewOutboxRepository(),
+	}
+}
+
+// NotifyOutbox wakes the dispatcher after events were committed
+func NotifyOutbox() {
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartOutboxDispatcher starts the background delivery loop (OUTBOX_POLL_SECONDS, default 5).
+// Events left pending by a crash are picked up again on the next start.
+func (s *OutboxService) StartOutboxDispatcher() {
+	outboxOnce.Do(func() {
+		interval := time.Duration(getOutboxInterval()) * time.Second
+		go func() {
+			log.Printf("Outbox dispatcher started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			prune := time.NewTicker(time.Hour)
+			defer prune.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+				case <-outboxWake:
+				case <-prune.C:
+					if deleted, err := s.outboxRepo.DeleteDeliveredBefore(time.Now().Add(-outboxRetention)); err != nil {
+						log.Printf("Outbox prune failed: %v", err)
+					} else if deleted > 0 {
+						log.Printf("Outbox pruned %d delivered events", deleted)
+					}
+					continue
+				}
+				s.DispatchOnce()
+			}
+		}()
+	})
+}
+
+// DispatchOnce delivers every due event, draining the backlog batch by batch
+func (s *OutboxService) DispatchOnce() {
+	for {
+		events, err := s.outboxRepo.ClaimDue(outboxBatchSize, outboxLease)
+		if err != nil {
+			log.Printf("Outbox claim failed: %v", err)
+			return
+		}
+		for _, event := range events {
+			s.deliver(event)
+		}
+		if len(events) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (s *OutboxService) deliver(event models.OutboxEvent) {
+	attempts := event.Attempts + 1
+
+	var err error
+	switch event.EventType {
+	case models.OutboxEventScheduledDeployment:
+		err = NewScheduledDeploymentService().Run(event.AggregateID)
+	case models.OutboxEventNamespaceDeletion:
+		err = NewProjectService().RunNamespaceDeletion(event.AggregateID)
+	default:
+		err = utils.PostWebhook(event.CallbackURL, []byte(event.Payload))
+	}
+	if err == nil {
+		if err := s.outboxRepo.MarkDelivered(event.ID, attempts); err != nil {
+			// The lease expires and the event is sent again; receivers must tolerate duplicates
+			log.Printf("Outbox event %s delivered but not marked: %v", event.ID, err)
+		}
+		return
+	}
+
+	dead := attempts >= outboxMaxAttempts
+	if dead {
+		log.Printf("Outbox event %s (%s) dropped after %d attempts: %v", event.ID, event.EventType, attempts, err)
+		if event.EventType == models.OutboxEventNamespaceDeletion {
+			NewProjectService().FailNamespaceDeletion(event.AggregateID, err)
+		}
+	} else {
+		log.Printf("Outbox event %s (%s) attempt %d failed: %v", event.ID, event.EventType, attempts, err)
+	}
+	if markErr := s.outboxRepo.MarkFailed(event.ID, attempts, err.Error(), time.Now().Add(outboxBackoff(attempts)), dead); markErr != nil {
+		log.Printf("Failed to record outbox attempt for %s: %v", event.ID, markErr)
+	}
+}
+
+// outboxBackoff doubles the retry delay from 10s up to outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	backoff := 10 * time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+func getOutboxInterval() int {
+	value := optionalEnvString("OUTBOX_POLL_SECONDS")
+	if value == nil {
+		return defaultOutboxSeconds
+	}
+	seconds, err := strconv.Atoi(*value)
+	if err != nil || seconds <= 0 {
+		return defaultOutboxSeconds
+	}
+	return seconds
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// pauseSchedulerInterval is how often schedules are reconciled (cron has minute resolution)
+const pauseSchedulerInterval = time.Minute
+
+var pauseSchedulerOnce sync.Once
+
+// PauseScheduleService manages pause schedules that scale managed services to zero and back
+type PauseScheduleService struct {
+	scheduleRepo    *repositories.PauseScheduleRepository
+	serviceRepo     *repositories.ServiceRepository
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+	managedService  *ManagedServiceService
+}
+
+// NewPauseScheduleService creates a new pause schedule service instance
+func NewPauseScheduleService() *PauseScheduleService {
+	return &PauseScheduleService{
+		scheduleRepo:    repositories.NewPauseScheduleRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+		managedService:  NewManagedServiceService(),
+	}
+}
+
+// GetSchedule returns the pause schedule of a service
+func (s *PauseScheduleService) GetSchedule(serviceID string, userID string, isAdmin bool) (dto.PauseScheduleResponse, error) {
+	if _, err := s.getManagedService(serviceID, userID, isAdmin); err != nil {
+		return dto.PauseScheduleResponse{}, err
+	}
+
+	schedule, err := s.scheduleRepo.FindByServiceID(serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.PauseScheduleResponse{}, errors.New("no pause schedule configured for this service")
+		}
+		return dto.PauseScheduleResponse{}, err
+	}
+
+	return buildPauseScheduleResponse(schedule, time.Now())
+}
+
+// SaveSchedule creates or replaces the pause schedule of a service
+func (s *PauseScheduleService) SaveSchedule(serviceID string, req dto.PauseScheduleRequest, userID string, isAdmin bool) (dto.PauseScheduleResponse, error) {
+	if _, err := s.getManagedService(serviceID, userID, isAdmin); err != nil {
+		return dto.PauseScheduleResponse{}, err
+	}
+
+	if _, err := utils.ParseCron(req.PauseCron); err != nil {
+		return dto.PauseScheduleResponse{}, fmt.Errorf("pauseCron: %v", err)
+	}
+	if _, err := utils.ParseCron(req.ResumeCron); err != nil {
+		return dto.PauseScheduleResponse{}, fmt.Errorf("resumeCron: %v", err)
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return dto.PauseScheduleResponse{}, fmt.Errorf("invalid timezone: %s", req.Timezone)
+	}
+
+	schedule, err := s.scheduleRepo.FindByServiceID(serviceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.PauseScheduleResponse{}, err
+	}
+
+	schedule.ServiceID = serviceID
+	schedule.PauseCron = req.PauseCron
+	schedule.ResumeCron = req.ResumeCron
+	schedule.Timezone = req.Timezone
+	schedule.Enabled = true
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	// A new schedule invalidates any override computed from the old one
+	schedule.OverrideState = ""
+	schedule.OverrideUntil = nil
+
+	schedule, err = s.scheduleRepo.Save(schedule)
+	if err != nil {
+		return dto.PauseScheduleResponse{}, fmt.Errorf("failed to save pause schedule: %v", err)
+	}
+
+	return buildPauseScheduleResponse(schedule, time.Now())
+}
+
+// DeleteSchedule removes the pause schedule of a service. The service keeps its current state.
+func (s *PauseScheduleService) DeleteSchedule(serviceID string, userID string, isAdmin bool) error {
+	if _, err := s.getManagedService(serviceID, userID, isAdmin); err != nil {
+		return err
+	}
+
+	return s.scheduleRepo.DeleteByServiceID(serviceID)
+}
+
+// SetManualState pauses or resumes a service immediately. When a schedule exists the
+// override holds until the schedule's next transition.
+func (s *PauseScheduleService) SetManualState(serviceID string, state models.PauseState, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.getManagedService(serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+
+	schedule, err := s.scheduleRepo.FindByServiceID(serviceID)
+	if err == nil {
+		now := time.Now()
+		response, err := buildPauseScheduleResponse(schedule, now)
+		if err != nil {
+			return service, err
+		}
+
+		until := nextTransition(response)
+		schedule.OverrideState = state
+		schedule.OverrideUntil = until
+		if _, err := s.scheduleRepo.Save(schedule); err != nil {
+			return service, fmt.Errorf("failed to save override: %v", err)
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return service, err
+	}
+
+	if err := s.applyState(&service, state); err != nil {
+		return service, err
+	}
+	s.recordTransition(serviceID, state)
+	return service, nil
+}
+
+// StartPauseScheduler starts the background loop that applies pause schedules
+func (s *PauseScheduleService) StartPauseScheduler() {
+	pauseSchedulerOnce.Do(func() {
+		go func() {
+			log.Printf("Pause scheduler started (interval %v)", pauseSchedulerInterval)
+			ticker := time.NewTicker(pauseSchedulerInterval)
+			defer ticker.Stop()
+
+			s.reconcileSchedules()
+			for range ticker.C {
+				s.reconcileSchedules()
+			}
+		}()
+	})
+}
+
+// reconcileSchedules scales every scheduled service to the state its schedule wants
+func (s *PauseScheduleService) reconcileSchedules() {
+	schedules, err := s.scheduleRepo.FindEnabled()
+	if err != nil {
+		log.Printf("Pause scheduler: failed to load schedules: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, schedule := range schedules {
+		response, err := buildPauseScheduleResponse(schedule, now)
+		if err != nil {
+			log.Printf("Pause scheduler: invalid schedule for service %s: %v", schedule.ServiceID, err)
+			continue
+		}
+
+		// Drop expired overrides so the schedule takes over again
+		if schedule.OverrideState != "" && (schedule.OverrideUntil == nil || !now.Before(*schedule.OverrideUntil)) {
+			schedule.OverrideState = ""
+			schedule.OverrideUntil = nil
+			if _, err := s.scheduleRepo.Save(schedule); err != nil {
+				log.Printf("Pause scheduler: failed to clear override for service %s: %v", schedule.ServiceID, err)
+			}
+			response, _ = buildPauseScheduleResponse(schedule, now)
+		}
+
+		if response.DesiredState == schedule.AppliedState {
+			continue
+		}
+
+		service, err := s.serviceRepo.FindByID(schedule.ServiceID)
+		if err != nil {
+			log.Printf("Pause scheduler: service %s not found: %v", schedule.ServiceID, err)
+			continue
+		}
+		// Archived environments stay scaled down until they are unarchived
+		if s.inArchivedEnvironment(service) {
+			continue
+		}
+		if err := s.applyState(&service, response.DesiredState); err != nil {
+			log.Printf("Pause scheduler: failed to apply %s to service %s: %v", response.DesiredState, service.ID, err)
+			continue
+		}
+		s.recordTransition(schedule.ServiceID, response.DesiredState)
+	}
+}
+
+// applyState scales the workload and updates the service status. Only the status column is
+// written, as the service may be edited while the workload scales.
+func (s *PauseScheduleService) applyState(service *models.Service, state models.PauseState) error {
+	if state == models.PauseStatePaused {
+		if service.Status == "paused" {
+			return nil
+		}
+		if err := utils.ScaleManagedService(*service, 0); err != nil {
+			return err
+		}
+		service.Status = "paused"
+		return s.serviceRepo.UpdateStatus(service.ID, service.Status)
+	}
+
+	if service.Status != "paused" {
+		return nil
+	}
+	if s.inArchivedEnvironment(*service) {
+		return errors.New("environment is archived; unarchive it to resume the service")
+	}
+	if err := utils.ScaleManagedService(*service, utils.GetManagedServiceDataReplicas(*service)); err != nil {
+		return err
+	}
+	service.Status = "starting"
+	if err := s.serviceRepo.UpdateStatus(service.ID, service.Status); err != nil {
+		return err
+	}
+	go s.managedService.waitUntilReady(*service)
+	return nil
+}
+
+// inArchivedEnvironment reports whether the service's environment is archived
+func (s *PauseScheduleService) inArchivedEnvironment(service models.Service) bool {
+	env, err := s.environmentRepo.FindByID(service.EnvironmentID)
+	return err == nil && env.IsArchived()
+}
+
+// recordTransition stores the state the scheduler last applied
+func (s *PauseScheduleService) recordTransition(serviceID string, state models.PauseState) {
+	schedule, err := s.scheduleRepo.FindByServiceID(serviceID)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	schedule.AppliedState = state
+	schedule.LastTransitionAt = &now
+	if _, err := s.scheduleRepo.Save(schedule); err != nil {
+		log.Printf("Failed to record pause transition for service %s: %v", serviceID, err)
+	}
+}
+
+// getManagedService loads the service and checks access and type
+func (s *PauseScheduleService) getManagedService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+
+	if service.Type != models.ServiceTypeManaged {
+		return service, errors.New("pause schedules are only available for managed services")
+	}
+
+	return service, nil
+}
+
+// buildPauseScheduleResponse computes the desired state and next transitions.
+// The desired state is whichever of the two crons fired most recently.
+func buildPauseScheduleResponse(schedule models.ServicePauseSchedule, now time.Time) (dto.PauseScheduleResponse, error) {
+	response := dto.PauseScheduleResponse{ServicePauseSchedule: schedule}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return response, fmt.Errorf("invalid timezone: %s", schedule.Timezone)
+	}
+	pauseCron, err := utils.ParseCron(schedule.PauseCron)
+	if err != nil {
+		return response, err
+	}
+	resumeCron, err := utils.ParseCron(schedule.ResumeCron)
+	if err != nil {
+		return response, err
+	}
+
+	local := now.In(location)
+	response.DesiredState = models.PauseStateRunning
+	if pauseCron.Prev(local).After(resumeCron.Prev(local)) {
+		response.DesiredState = models.PauseStatePaused
+	}
+
+	if next := pauseCron.Next(local); !next.IsZero() {
+		response.NextPauseAt = &next
+	}
+	if next := resumeCron.Next(local); !next.IsZero() {
+		response.NextResumeAt = &next
+	}
+
+	if schedule.OverrideState != "" && schedule.OverrideUntil != nil && now.Before(*schedule.OverrideUntil) {
+		response.DesiredState = schedule.OverrideState
+	}
+	return response, nil
+}
+
+// nextTransition returns the earliest upcoming pause or resume time
+func nextTransition(response dto.PauseScheduleResponse) *time.Time {
+	switch {
+	case response.NextPauseAt == nil:
+		return response.NextResumeAt
+	case response.NextResumeAt == nil:
+		return response.NextPauseAt
+	case response.NextPauseAt.Before(*response.NextResumeAt):
+		return response.NextPauseAt
+	default:
+		return response.NextResumeAt
+	}
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// platformSettingsRefreshInterval is how often replicas pick up settings saved by another one
+const platformSettingsRefreshInterval = time.Minute
+
+var platformSettingsOnce sync.Once
+
+// PlatformSettingsService manages the admin-managed platform settings and applies them to
+// the running process
+type PlatformSettingsService struct {
+	settingsRepo *repositories.PlatformSettingsRepository
+	serviceRepo  *repositories.ServiceRepository
+	registryRepo *repositories.RegistryRepository
+}
+
+// NewPlatformSettingsService creates a new platform settings service instance
+func NewPlatformSettingsService() *PlatformSettingsService {
+	return &PlatformSettingsService{
+		settingsRepo: repositories.NewPlatformSettingsRepository(),
+		serviceRepo:  repositories.NewServiceRepository(),
+		registryRepo: repositories.NewRegistryRepository(),
+	}
+}
+
+// GetSettings returns the platform settings with the deployment's defaults filled in
+func (s *PlatformSettingsService) GetSettings() (models.PlatformSettings, error) {
+	settings, err := s.settingsRepo.FindSettings()
+	if err != nil {
+		return settings, err
+	}
+	if settings.IngressProvider == "" {
+		settings.IngressProvider = utils.GetDefaultIngressProvider()
+	}
+	if settings.MaxBuildTimeoutMinutes <= 0 {
+		settings.MaxBuildTimeoutMinutes = utils.DefaultMaxBuildTimeoutMinutes
+	}
+	if settings.BuildBackend == "" {
+		settings.BuildBackend = utils.GetDefaultBuildBackend()
+	}
+	return settings, nil
+}
+
+// UpdateSettings saves the platform settings. A new ingress provider is switched to at once
+// and the Ingresses and TCP exposure of everything deployed are re-rendered for it. A lower
+// build timeout cap applies to the next builds of services set above it, a new build backend
+// to the next builds of services that do not select one. The default domain, public
+// addresses, CORS origins and cluster issuer apply at once; a new build namespace only when
+// the API restarts, as running builds and their secrets stay in the current one.
+func (s *PlatformSettingsService) UpdateSettings(req dto.PlatformSettingsUpdateRequest, userID string) (models.PlatformSettings, dto.IngressProviderSwitchResult, error) {
+	var result dto.IngressProviderSwitchResult
+	settings, err := s.GetSettings()
+	if err != nil {
+		return settings, result, err
+	}
+
+	previous := settings.IngressProvider
+	previousNodePortRange := settings.NodePortRange
+	previousSettings := settings
+	settings.IngressProvider = req.IngressProvider
+	if req.MaxBuildTimeoutMinutes != nil {
+		settings.MaxBuildTimeoutMinutes = *req.MaxBuildTimeoutMinutes
+	}
+	if req.NodePortRange != nil {
+		settings.NodePortRange = strings.TrimSpace(*req.NodePortRange)
+	}
+	if req.ManagedPortRanges != nil {
+		settings.ManagedPortRanges = req.ManagedPortRanges
+	}
+	if req.BuildBackend != nil {
+		backend := strings.TrimSpace(*req.BuildBackend)
+		if !utils.IsValidBuildBackend(backend) {
+			return settings, result, utils.FieldErrors{{Field: "buildBackend", Message: "must be one of: " + strings.Join(utils.AvailableBuildBackends(), ", ")}}
+		}
+		settings.BuildBackend = backend
+	}
+	if req.DefaultDomain != nil {
+		settings.DefaultDomain = utils.NormalizeDomain(*req.DefaultDomain)
+	}
+	if req.PublicAddresses != nil {
+		settings.PublicAddresses = strings.Join(utils.SplitList(*req.PublicAddresses), ",")
+	}
+	if req.CORSAllowedOrigins != nil {
+		settings.CORSAllowedOrigins = strings.Join(utils.SplitList(*req.CORSAllowedOrigins), ",")
+	}
+	if req.ClusterIssuer != nil {
+		settings.ClusterIssuer = strings.TrimSpace(*req.ClusterIssuer)
+	}
+	if req.BuildNamespace != nil {
+		settings.BuildNamespace = strings.TrimSpace(*req.BuildNamespace)
+	}
+	if err := s.validatePortRanges(settings, previousNodePortRange); err != nil {
+		return settings, result, err
+	}
+	if err := s.validateEndpointSettings(settings, previousSettings); err != nil {
+		return settings, result, err
+	}
+	settings.UpdatedBy = userID
+	settings, err = s.settingsRepo.SaveSettings(settings)
+	if err != nil {
+		return settings, result, err
+	}
+	if err := utils.SetIngressProvider(settings.IngressProvider); err != nil {
+		return settings, result, err
+	}
+	utils.SetMaxBuildTimeoutMinutes(settings.MaxBuildTimeoutMinutes)
+	utils.SetPlatformPortRanges(settings.NodePortRange, settings.ManagedPortRanges)
+	if err := utils.SetBuildBackend(settings.BuildBackend); err != nil {
+		return settings, result, err
+	}
+	applyEndpointSettings(settings)
+
+	if previous != settings.IngressProvider {
+		result = s.switchIngressProvider()
+	}
+	buildNamespace := settings.BuildNamespace
+	if buildNamespace == "" {
+		buildNamespace = utils.GetDeploymentBuildNamespace()
+	}
+	if buildNamespace != utils.GetJobNamespace() {
+		result.RestartRequired = append(result.RestartRequired, "buildNamespace")
+	}
+	return settings, result, nil
+}
+
+// validateEndpointSettings checks the default domain, public addresses, CORS origins,
+// cluster issuer and build namespace of the settings, and a newly set issuer or namespace
+// against the cluster
+func (s *PlatformSettingsService) validateEndpointSettings(settings, previous models.PlatformSettings) error {
+	var errs utils.FieldErrors
+	if settings.DefaultDomain != "" {
+		errs.CheckHostname("defaultDomain", settings.DefaultDomain)
+	}
+	for _, address := range utils.SplitList(settings.PublicAddresses) {
+		if net.ParseIP(address) == nil && len(validation.IsDNS1123Subdomain(address)) > 0 {
+			errs.Add("publicAddresses", "%q is not an IP address or hostname", address)
+		}
+	}
+	for _, origin := range utils.SplitList(settings.CORSAllowedOrigins) {
+		if err := utils.ValidateCORSOrigin(origin); err != nil {
+			errs.Add("corsAllowedOrigins", "%v", err)
+		}
+	}
+	if settings.ClusterIssuer != "" {
+		errs.CheckHostname("clusterIssuer", settings.ClusterIssuer)
+	}
+	if settings.BuildNamespace != "" {
+		errs.CheckDNSLabel("buildNamespace", settings.BuildNamespace)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// An issuer that is not ready would leave every Ingress applied from now on without a
+	// certificate, and build jobs need the registry and signing secrets of their namespace
+	if settings.ClusterIssuer != "" && settings.ClusterIssuer != previous.ClusterIssuer {
+		if err := utils.CheckClusterIssuerReady(settings.ClusterIssuer); err != nil {
+			errs.Add("clusterIssuer", "%v", err)
+		}
+	}
+	if settings.BuildNamespace != "" && settings.BuildNamespace != previous.BuildNamespace {
+		if err := checkNamespaceExists(settings.BuildNamespace); err != nil {
+			errs.Add("buildNamespace", "%v", err)
+		}
+	}
+	return errs.Err()
+}
+
+// checkNamespaceExists returns an error unless the namespace exists in the cluster
+func checkNamespaceExists(name string) error {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("could not be checked against the cluster: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := client.Clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("namespace %s does not exist; create it with the build secrets first", name)
+		}
+		return fmt.Errorf("could not be checked against the cluster: %v", err)
+	}
+	return nil
+}
+
+// applyEndpointSettings loads the settings that are safe to change while the API runs:
+// the default domain, public addresses, CORS origins and cluster issuer
+func applyEndpointSettings(settings models.PlatformSettings) {
+	utils.SetDefaultDomain(settings.DefaultDomain)
+	utils.SetIngressPublicAddresses(utils.SplitList(settings.PublicAddresses))
+	utils.SetCORSOrigins(utils.SplitList(settings.CORSAllowedOrigins))
+	utils.SetClusterIssuer(settings.ClusterIssuer)
+}
+
+// validatePortRanges checks the port ranges of the settings, and a newly set NodePort range
+// against the API server's --service-node-port-range, which a range only stored here cannot
+// change
+func (s *PlatformSettingsService) validatePortRanges(settings models.PlatformSettings, previousNodePortRange string) error {
+	nodePortRange := settings.NodePortRange
+	if nodePortRange == "" {
+		nodePortRange = utils.GetDefaultNodePortRange()
+	}
+	if err := utils.ValidatePortRanges(nodePortRange, settings.ManagedPortRanges); err != nil {
+		return err
+	}
+	if settings.NodePortRange == "" || settings.NodePortRange == previousNodePortRange {
+		return nil
+	}
+
+	portRange, _ := utils.ParsePortRange(settings.NodePortRange)
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return utils.FieldErrors{{Field: "nodePortRange", Message: fmt.Sprintf("could not be checked against the API server: %v", err)}}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := utils.CheckNodePortRangeWithCluster(ctx, client, portRange); err != nil {
+		return utils.FieldErrors{{Field: "nodePortRange", Message: err.Error()}}
+	}
+	return nil
+}
+
+// switchIngressProvider re-applies the Ingresses of deployed services and registries and
+// re-publishes the TCP ports of managed services with the current provider
+func (s *PlatformSettingsService) switchIngressProvider() dto.IngressProviderSwitchResult {
+	result := dto.IngressProviderSwitchResult{Switched: true}
+
+	services, err := s.serviceRepo.FindAll()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to list services: %v", err))
+	}
+	for _, service := range services {
+		apply := utils.ApplyServiceIngress
+		if service.Type == models.ServiceTypeManaged {
+			apply = utils.ApplyManagedServiceIngresses
+		}
+		if err := apply(service); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("service %s: %v", service.ID, err))
+			continue
+		}
+		result.Ingresses++
+	}
+
+	registries, err := s.registryRepo.FindActive()
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to list registries: %v", err))
+	}
+	for _, registry := range registries {
+		if registry.Status != models.RegistryStatusReady {
+			continue
+		}
+		if err := utils.CreateRegistryIngress(context.Background(), utils.RegistryNamespace, registry); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("registry %s: %v", registry.ID, err))
+			continue
+		}
+		result.Ingresses++
+	}
+
+	if err := NewManagedServiceService().EnsureTCPProxyExists(); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("TCP exposure: %v", err))
+	} else {
+		result.TCPExposure = true
+	}
+	log.Printf("Ingress provider switched: %d Ingresses re-applied, %d errors", result.Ingresses, len(result.Errors))
+	return result
+}
+
+// refresh loads the saved ingress provider, build timeout cap, port ranges, build backend
+// and endpoint settings into the running process, and the build namespace at startup
+func (s *PlatformSettingsService) refresh(startup bool) error {
+	settings, err := s.GetSettings()
+	if err != nil {
+		return err
+	}
+	if startup {
+		utils.SetBuildNamespace(settings.BuildNamespace)
+	}
+	applyEndpointSettings(settings)
+	utils.SetMaxBuildTimeoutMinutes(settings.MaxBuildTimeoutMinutes)
+	utils.SetPlatformPortRanges(settings.NodePortRange, settings.ManagedPortRanges)
+	// A saved backend that is no longer available, e.g. external without BUILD_API_URL,
+	// leaves builds on the default one
+	if err := utils.SetBuildBackend(settings.BuildBackend); err != nil {
+		log.Printf("WARNING: %v, using %s", err, utils.GetDefaultBuildBackend())
+		utils.SetBuildBackend("")
+	}
+	return utils.SetIngressProvider(settings.IngressProvider)
+}
+
+// StartSettingsRefresher applies the saved settings before anything is deployed and then
+// reloads them periodically, so every replica follows a change made through another one
+func (s *PlatformSettingsService) StartSettingsRefresher() {
+	platformSettingsOnce.Do(func() {
+		if err := s.refresh(true); err != nil {
+			log.Printf("Failed to load platform settings, using defaults: %v", err)
+		}
+		go func() {
+			ticker := time.NewTicker(platformSettingsRefreshInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if err := s.refresh(false); err != nil {
+					log.Printf("Failed to refresh platform settings: %v", err)
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// policyRuleDefinition is a built-in rule: check returns one message per violation
+type policyRuleDefinition struct {
+	description string
+	usesLimit   bool
+	check       func(s *PolicyService, spec utils.WorkloadSpec, service models.Service, limit int) ([]string, error)
+}
+
+var policyRules = map[string]policyRuleDefinition{
+	models.PolicyRuleForbidLatestTag: {
+		description: "Container images must be pinned to a tag other than latest",
+		check: func(s *PolicyService, spec utils.WorkloadSpec, service models.Service, limit int) ([]string, error) {
+			return utils.CheckImageTags(spec), nil
+		},
+	},
+	models.PolicyRuleRequireProbes: {
+		description: "Every container must define readiness and liveness probes",
+		check: func(s *PolicyService, spec utils.WorkloadSpec, service models.Service, limit int) ([]string, error) {
+			return utils.CheckProbes(spec), nil
+		},
+	},
+	models.PolicyRuleMaxExternalPorts: {
+		description: "Caps the externally exposed TCP ports (NodePort/TCP proxy) per project",
+		usesLimit:   true,
+		check: func(s *PolicyService, spec utils.WorkloadSpec, service models.Service, limit int) ([]string, error) {
+			return s.checkExternalPorts(service, limit)
+		},
+	},
+}
+
+// policyRuleOrder keeps API listings stable
+var policyRuleOrder = []string{
+	models.PolicyRuleForbidLatestTag,
+	models.PolicyRuleRequireProbes,
+	models.PolicyRuleMaxExternalPorts,
+}
+
+// ErrUnknownPolicyRule is returned for rule IDs that are not built in
+var ErrUnknownPolicyRule = errors.New("unknown policy rule")
+
+// PolicyViolationError reports enforced policy rules a workload violates
+type PolicyViolationError struct {
+	Violations []string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("rejected by policy: %s", strings.Join(e.Violations, "; "))
+}
+
+// PolicyService validates generated workloads against admin-configured rules before they
+// are applied, and keeps an audit trail of violations
+type PolicyService struct {
+	policyRepo  *repositories.PolicyRepository
+	serviceRepo *repositories.ServiceRepository
+}
+
+// NewPolicyService creates a new policy service instance
+func NewPolicyService() *PolicyService {
+	return &PolicyService{
+		policyRepo:  repositories.NewPolicyRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+	}
+}
+
+// ListRules returns every built-in rule with its configuration
+func (s *PolicyService) ListRules() ([]dto.PolicyRuleInfo, error) {
+	configured, err := s.configuredRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]dto.PolicyRuleInfo, 0, len(policyRuleOrder))
+	for _, id := range policyRuleOrder {
+		rules = append(rules, toPolicyRuleInfo(id, configured[id]))
+	}
+	return rules, nil
+}
+
+// UpdateRule changes the mode and threshold of a built-in rule
+func (s *PolicyService) UpdateRule(id string, req dto.PolicyRuleUpdateRequest, userID string) (dto.PolicyRuleInfo, error) {
+	definition, ok := policyRules[id]
+	if !ok {
+		return dto.PolicyRuleInfo{}, ErrUnknownPolicyRule
+	}
+
+	configured, err := s.configuredRules()
+	if err != nil {
+		return dto.PolicyRuleInfo{}, err
+	}
+	rule := configured[id]
+	rule.ID = id
+	rule.Mode = req.Mode
+	rule.UpdatedBy = userID
+	if req.Limit != nil {
+		rule.Limit = *req.Limit
+	}
+	if definition.usesLimit && rule.Mode != models.PolicyModeDisabled && rule.Limit <= 0 {
+		return dto.PolicyRuleInfo{}, errors.New("limit must be greater than 0 for this rule")
+	}
+
+	saved, err := s.policyRepo.SaveRule(rule)
+	if err != nil {
+		return dto.PolicyRuleInfo{}, err
+	}
+	log.Printf("Policy rule %s set to %s (limit %d) by %s", id, saved.Mode, saved.Limit, userID)
+	return toPolicyRuleInfo(id, saved), nil
+}
+
+// ListViolations returns recorded violations, newest first
+func (s *PolicyService) ListViolations(ruleID string, serviceID string, page, pageSize int) (dto.PolicyViolationListResponse, error) {
+	violations, total, err := s.policyRepo.FindViolations(ruleID, serviceID, page, pageSize)
+	if err != nil {
+		return dto.PolicyViolationListResponse{}, err
+	}
+	return dto.PolicyViolationListResponse{
+		Violations: violations,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// Evaluate checks the workload generated for the service against all active rules.
+// Violations are recorded; it returns a PolicyViolationError if an enforced rule failed.
+func (s *PolicyService) Evaluate(imageURL string, service models.Service) error {
+	configured, err := s.configuredRules()
+	if err != nil {
+		// An unreadable policy table must not take deployments down
+		log.Printf("Failed to load policy rules, skipping evaluation: %v", err)
+		return nil
+	}
+
+	spec := utils.RenderWorkloadSpec(imageURL, service)
+	var records []models.PolicyViolation
+	var blocking []string
+	for _, id := range policyRuleOrder {
+		rule, ok := configured[id]
+		if !ok || rule.Mode == models.PolicyModeDisabled {
+			continue
+		}
+
+		messages, err := policyRules[id].check(s, spec, service, rule.Limit)
+		if err != nil {
+			log.Printf("Policy rule %s could not be evaluated for service %s: %v", id, service.ID, err)
+			continue
+		}
+		blocked := rule.Mode == models.PolicyModeEnforce
+		for _, message := range messages {
+			records = append(records, models.PolicyViolation{
+				RuleID:    id,
+				Mode:      rule.Mode,
+				Blocked:   blocked,
+				ServiceID: service.ID,
+				ProjectID: service.ProjectID,
+				Resource:  spec.Resource(),
+				Message:   message,
+			})
+			if blocked {
+				blocking = append(blocking, fmt.Sprintf("%s: %s", id, message))
+			}
+		}
+	}
+
+	if err := s.policyRepo.CreateViolations(records); err != nil {
+		log.Printf("Failed to record policy violations for service %s: %v", service.ID, err)
+	}
+	if len(blocking) > 0 {
+		return &PolicyViolationError{Violations: blocking}
+	}
+	return nil
+}
+
+// checkExternalPorts counts the project's services exposed through the TCP proxy
+func (s *PolicyService) checkExternalPorts(service models.Service, limit int) ([]string, error) {
+	if service.ExternalPort <= 0 {
+		return nil, nil
+	}
+	projectServices, err := s.serviceRepo.FindByProjectID(service.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	used := 1
+	for _, existing := range projectServices {
+		if existing.ID != service.ID && existing.ExternalPort > 0 {
+			used++
+		}
+	}
+	if used > limit {
+		return []string{fmt.Sprintf("project would expose %d external ports, the limit is %d", used, limit)}, nil
+	}
+	return nil, nil
+}
+
+func (s *PolicyService) configuredRules() (map[string]models.PolicyRule, error) {
+	rules, err := s.policyRepo.FindRules()
+	if err != nil {
+		return nil, err
+	}
+	configured := make(map[string]models.PolicyRule, len(rules))
+	for _, rule := range rules {
+		configured[rule.ID] = rule
+	}
+	return configured, nil
+}
+
+func toPolicyRuleInfo(id string, rule models.PolicyRule) dto.PolicyRuleInfo {
+	definition := policyRules[id]
+	info := dto.PolicyRuleInfo{
+		ID:          id,
+		Description: definition.description,
+		Mode:        models.PolicyModeDisabled,
+		UsesLimit:   definition.usesLimit,
+	}
+	if rule.ID != "" {
+		info.Mode = rule.Mode
+		info.Limit = rule.Limit
+		info.UpdatedBy = rule.UpdatedBy
+		updatedAt := rule.UpdatedAt
+		info.UpdatedAt = &updatedAt
+	}
+	return info
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/utils"
+)
+
+// PreflightService checks that the cluster has what the platform needs before the first
+// deploy runs into a missing CRD, issuer, StorageClass or NodePort
+type PreflightService struct{}
+
+// NewPreflightService creates a new preflight service instance
+func NewPreflightService() *PreflightService {
+	return &PreflightService{}
+}
+
+// RunChecks runs the cluster prerequisite checks; nothing in the cluster is changed
+func (s *PreflightService) RunChecks() dto.PreflightReport {
+	return utils.RunPreflightChecks()
+}
+
+// CheckOnStartup logs every check that did not pass with its remedy. With PREFLIGHT_STRICT=true
+// a failed check is returned as an error so the API refuses to start.
+func (s *PreflightService) CheckOnStartup() error {
+	report := s.RunChecks()
+	for _, check := range report.Checks {
+		switch check.Status {
+		case dto.PreflightFailed:
+			log.Printf("❌ Preflight %s: %s. %s", check.Name, check.Message, check.Remedy)
+		case dto.PreflightWarning:
+			log.Printf("⚠️ Preflight %s: %s. %s", check.Name, check.Message, check.Remedy)
+		}
+	}
+	if report.Ready {
+		log.Printf("✅ Preflight passed (%d checks)", len(report.Checks))
+		return nil
+	}
+	if os.Getenv("PREFLIGHT_STRICT") == "true" {
+		return errors.New("cluster prerequisites are missing, see the preflight messages above")
+	}
+	log.Printf("⚠️ Cluster prerequisites are missing; deploys will fail until the preflight errors above are fixed")
+	return nil
+}
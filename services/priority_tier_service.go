@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ErrPriorityTierNotFound is returned for tiers that are not defined
+var ErrPriorityTierNotFound = errors.New("priority tier not found")
+
+// PriorityTierService manages admin-defined scheduling tiers and resolves the
+// PriorityClasses workloads and build jobs run with
+type PriorityTierService struct {
+	tierRepo        *repositories.PriorityTierRepository
+	environmentRepo *repositories.EnvironmentRepository
+}
+
+// NewPriorityTierService creates a new priority tier service instance
+func NewPriorityTierService() *PriorityTierService {
+	return &PriorityTierService{
+		tierRepo:        repositories.NewPriorityTierRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+	}
+}
+
+// ListTiers returns every tier, highest priority first
+func (s *PriorityTierService) ListTiers() ([]models.PriorityTier, error) {
+	return s.tierRepo.FindAll()
+}
+
+// SaveTier creates or updates a tier and its PriorityClass
+func (s *PriorityTierService) SaveTier(name string, req dto.PriorityTierRequest, userID string) (models.PriorityTier, error) {
+	if messages := validation.IsDNS1123Label(name); len(messages) > 0 || len(name) > 50 {
+		return models.PriorityTier{}, fmt.Errorf("invalid tier name %q: must be a DNS label of at most 50 characters", name)
+	}
+	if *req.Value < -utils.MaxPriorityTierValue || *req.Value > utils.MaxPriorityTierValue {
+		return models.PriorityTier{}, fmt.Errorf("value must be between %d and %d", -utils.MaxPriorityTierValue, utils.MaxPriorityTierValue)
+	}
+
+	tier := models.PriorityTier{
+		Name:                 name,
+		Value:                *req.Value,
+		Description:          req.Description,
+		PreemptLowerPriority: req.PreemptLowerPriority == nil || *req.PreemptLowerPriority,
+		Builds:               req.Builds,
+		UpdatedBy:            userID,
+	}
+	if existing, err := s.tierRepo.FindByName(name); err == nil {
+		tier.CreatedAt = existing.CreatedAt
+	}
+
+	// The class is applied first so environments never reference a missing one
+	if err := utils.ApplyPriorityClass(tier); err != nil {
+		return models.PriorityTier{}, err
+	}
+	saved, err := s.tierRepo.Save(tier)
+	if err != nil {
+		return models.PriorityTier{}, err
+	}
+	log.Printf("Priority tier %s set to %d (preempt: %t, builds: %t) by %s", name, saved.Value, saved.PreemptLowerPriority, saved.Builds, userID)
+	return saved, nil
+}
+
+// DeleteTier removes a tier that no environment is mapped to
+func (s *PriorityTierService) DeleteTier(name string) error {
+	if _, err := s.tierRepo.FindByName(name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPriorityTierNotFound
+		}
+		return err
+	}
+
+	count, err := s.environmentRepo.CountByPriorityTier(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("priority tier %s is used by %d environment(s); map them to another tier first", name, count)
+	}
+
+	if err := utils.DeletePriorityClass(name); err != nil {
+		return err
+	}
+	return s.tierRepo.Delete(name)
+}
+
+// ResolveClassNames fills in the PriorityClasses of the service's environment tier and of
+// the build tier. Lookup failures leave them empty, i.e. the cluster default priority.
+func (s *PriorityTierService) ResolveClassNames(service models.Service) models.Service {
+	if env, err := s.environmentRepo.FindByID(service.EnvironmentID); err == nil {
+		service.PriorityClassName = utils.GetPriorityClassName(env.PriorityTier)
+	} else {
+		log.Printf("Failed to load environment of service %s for its priority tier: %v", service.ID, err)
+	}
+
+	buildTier, err := s.tierRepo.FindBuildTier()
+	if err == nil {
+		service.BuildPriorityClassName = utils.GetPriorityClassName(buildTier.Name)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to load the build priority tier: %v", err)
+	}
+	return service
+}
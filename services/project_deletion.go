@@ -0,0 +1,255 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ErrProjectDeleting is returned for changes to a project that is being deleted
+var ErrProjectDeleting = errors.New("project is being deleted")
+
+// DeleteProject starts deleting a project. Its log drains, status page, secrets vault and
+// pull credentials are removed right away, the project is marked deleting and the namespace
+// of each environment is torn down by an outbox job that retries until the namespace is
+// gone; the project itself is deleted once all of them are. Deleting a project that is
+// already being deleted retries the namespaces that failed.
+func (s *ProjectService) DeleteProject(projectID string, userID string, isAdmin bool) (dto.ProjectDeletionResponse, error) {
+	project, err := s.findDeletableProject(projectID, userID, isAdmin)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	if project.DeletedAt.Valid {
+		return s.deletionProgress(project)
+	}
+
+	// Get all project environments before deleting
+	environments, err := s.environmentRepo.FindByProjectID(projectID)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, fmt.Errorf("error fetching project environments: %w", err)
+	}
+	existing, err := s.deletionRepo.FindByProjectID(projectID)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	tracked := make(map[string]models.ProjectNamespaceDeletion, len(existing))
+	for _, deletion := range existing {
+		tracked[deletion.EnvironmentID] = deletion
+	}
+
+	// Pull credential Secrets and the secrets vault live outside the environment namespaces
+	// and are revoked before any namespace is torn down
+	if err := utils.DeleteProjectPullSecrets(projectID); err != nil {
+		log.Printf("Warning: Failed to delete pull secrets of project %s: %v", projectID, err)
+	}
+	if err := utils.DeleteProjectVault(projectID); err != nil {
+		log.Printf("Warning: Failed to delete secrets vault of project %s: %v", projectID, err)
+	}
+
+	queued := 0
+	err = s.projectRepo.DB().Transaction(func(tx *gorm.DB) error {
+		if err := s.projectRepo.UpdateStatusTx(tx, projectID, models.ProjectStatusDeleting); err != nil {
+			return err
+		}
+		// Drains hold sink tokens and must stop forwarding right away
+		if err := s.logDrainRepo.DeleteByProjectIDTx(tx, projectID); err != nil {
+			return fmt.Errorf("error deleting log drains: %w", err)
+		}
+		// The public status page would otherwise stay reachable while the namespaces go
+		if err := s.statusPageRepo.DeleteByProjectIDTx(tx, projectID); err != nil {
+			return fmt.Errorf("error deleting status page: %w", err)
+		}
+		for _, env := range environments {
+			deletion, ok := tracked[env.ID]
+			if ok {
+				if deletion.Status != models.NamespaceDeletionFailed {
+					continue
+				}
+				retried, err := s.deletionRepo.RetryTx(tx, deletion.ID)
+				if err != nil {
+					return err
+				}
+				if !retried {
+					continue
+				}
+			} else {
+				created, err := s.deletionRepo.CreateTx(tx, models.ProjectNamespaceDeletion{
+					ProjectID:     projectID,
+					EnvironmentID: env.ID,
+					Namespace:     env.ID, // The namespace name is the environment ID
+					Status:        models.NamespaceDeletionPending,
+				})
+				if err != nil {
+					return err
+				}
+				deletion = created
+			}
+
+			payload, err := json.Marshal(map[string]string{"projectId": projectID, "namespace": deletion.Namespace})
+			if err != nil {
+				return err
+			}
+			if err := s.outboxRepo.Enqueue(tx, models.OutboxEvent{
+				EventType:   models.OutboxEventNamespaceDeletion,
+				AggregateID: deletion.ID,
+				Payload:     string(payload),
+			}); err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	if queued > 0 {
+		log.Printf("Project %s is being deleted, %d namespaces queued for teardown", projectID, queued)
+		NotifyOutbox()
+	}
+
+	// A project without environments has nothing to wait for
+	if err := s.finishDeletion(projectID); err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	project, err = s.projectRepo.FindByIDUnscoped(projectID)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	return s.deletionProgress(project)
+}
+
+// GetDeletionProgress reports how far the deletion of a project got, including after the
+// project is gone
+func (s *ProjectService) GetDeletionProgress(projectID string, userID string, isAdmin bool) (dto.ProjectDeletionResponse, error) {
+	project, err := s.findDeletableProject(projectID, userID, isAdmin)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+	return s.deletionProgress(project)
+}
+
+// RunNamespaceDeletion tears down one namespace of a project being deleted when its outbox
+// job comes due. An error retries the job, so a namespace that is still terminating is
+// checked again until it is gone.
+func (s *ProjectService) RunNamespaceDeletion(deletionID string) error {
+	deletion, err := s.deletionRepo.FindByID(deletionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The project was removed for good
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if deletion.Status == models.NamespaceDeletionDeleted {
+		// An earlier delivery of the job tore the namespace down
+		return s.finishDeletion(deletion.ProjectID)
+	}
+
+	state, teardownErr := utils.TeardownNamespace(deletion.Namespace)
+	switch {
+	case teardownErr != nil:
+		if err := s.deletionRepo.RecordAttempt(deletion.ID, deletion.Status, teardownErr); err != nil {
+			log.Printf("Failed to record teardown attempt of namespace %s: %v", deletion.Namespace, err)
+		}
+		return teardownErr
+	case state == utils.NamespaceTerminating:
+		if err := s.deletionRepo.RecordAttempt(deletion.ID, models.NamespaceDeletionTerminating, nil); err != nil {
+			return err
+		}
+		return fmt.Errorf("namespace %s is still terminating", deletion.Namespace)
+	}
+
+	if err := s.deletionRepo.RecordAttempt(deletion.ID, models.NamespaceDeletionDeleted, nil); err != nil {
+		return err
+	}
+	log.Printf("Namespace %s of project %s deleted", deletion.Namespace, deletion.ProjectID)
+	return s.finishDeletion(deletion.ProjectID)
+}
+
+// FailNamespaceDeletion gives up on a namespace whose outbox job ran out of attempts. The
+// project stays deleting until it is deleted again, which retries the namespace.
+func (s *ProjectService) FailNamespaceDeletion(deletionID string, jobErr error) {
+	deletion, err := s.deletionRepo.FindByID(deletionID)
+	if err != nil {
+		log.Printf("Failed to load namespace deletion %s: %v", deletionID, err)
+		return
+	}
+	log.Printf("Warning: Gave up deleting namespace %s of project %s: %v", deletion.Namespace, deletion.ProjectID, jobErr)
+	if err := s.deletionRepo.MarkFailed(deletion.ID, jobErr.Error()); err != nil {
+		log.Printf("Failed to record failed teardown of namespace %s: %v", deletion.Namespace, err)
+	}
+}
+
+// finishDeletion deletes the project once every namespace is gone; DeleteProject already
+// removed what must not outlive the request
+func (s *ProjectService) finishDeletion(projectID string) error {
+	unfinished, err := s.deletionRepo.CountUnfinished(projectID)
+	if err != nil {
+		return err
+	}
+	if unfinished > 0 {
+		return nil
+	}
+
+	// Lakukan soft delete - cascade will handle related records
+	if err := s.projectRepo.Delete(projectID); err != nil {
+		return err
+	}
+	log.Printf("Project %s deleted", projectID)
+	return nil
+}
+
+// findDeletableProject loads a project, even one already deleted, that the user may delete
+func (s *ProjectService) findDeletableProject(projectID string, userID string, isAdmin bool) (models.Project, error) {
+	// Cek apakah project dengan ID tersebut ada di database (tanpa filter deleted_at)
+	exists, err := s.projectRepo.Exists(projectID)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if !exists {
+		return models.Project{}, fmt.Errorf("project not found or already deleted")
+	}
+
+	project, err := s.projectRepo.FindByIDUnscoped(projectID)
+	if err != nil {
+		return models.Project{}, err
+	}
+	if !isAdmin && project.UserID != userID {
+		return models.Project{}, fmt.Errorf("unauthorized: you don't have permission to delete this project")
+	}
+	return project, nil
+}
+
+// deletionProgress counts the namespaces of a project torn down so far
+func (s *ProjectService) deletionProgress(project models.Project) (dto.ProjectDeletionResponse, error) {
+	deletions, err := s.deletionRepo.FindByProjectID(project.ID)
+	if err != nil {
+		return dto.ProjectDeletionResponse{}, err
+	}
+
+	response := dto.ProjectDeletionResponse{
+		ProjectID:  project.ID,
+		Status:     project.Status,
+		Total:      len(deletions),
+		Namespaces: deletions,
+	}
+	if project.DeletedAt.Valid {
+		response.Status = "deleted"
+	}
+	for _, deletion := range deletions {
+		switch deletion.Status {
+		case models.NamespaceDeletionDeleted:
+			response.Deleted++
+		case models.NamespaceDeletionFailed:
+			response.Failed++
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ErrProjectSecretNotFound is returned for secrets that do not exist in the project
+var ErrProjectSecretNotFound = errors.New("project secret not found")
+
+// ProjectSecretService manages a project's secrets vault and interpolates the
+// ${secret:name} references of service env vars at deploy time
+type ProjectSecretService struct {
+	secretRepo  *repositories.ProjectSecretRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewProjectSecretService creates a new project secret service instance
+func NewProjectSecretService() *ProjectSecretService {
+	return &ProjectSecretService{
+		secretRepo:  repositories.NewProjectSecretRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// ListSecrets returns the vault entries of a project, without their values
+func (s *ProjectSecretService) ListSecrets(projectID string, userID string, isAdmin bool) ([]models.ProjectSecret, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.secretRepo.FindByProjectID(projectID)
+}
+
+// CreateSecret adds a value to the project's vault
+func (s *ProjectSecretService) CreateSecret(projectID string, req dto.ProjectSecretRequest, userID string, isAdmin bool) (models.ProjectSecret, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.ProjectSecret{}, err
+	}
+
+	existing, err := s.secretRepo.FindByProjectID(projectID)
+	if err != nil {
+		return models.ProjectSecret{}, err
+	}
+	for _, other := range existing {
+		if other.Name == req.Name {
+			return models.ProjectSecret{}, fmt.Errorf("a secret named %q already exists in this project", req.Name)
+		}
+	}
+
+	// The value is written first so a stored entry always has one
+	if err := utils.ApplyProjectSecretValue(projectID, req.Name, req.Value); err != nil {
+		return models.ProjectSecret{}, err
+	}
+	created, err := s.secretRepo.Create(models.ProjectSecret{ProjectID: projectID, Name: req.Name})
+	if err != nil {
+		if cleanupErr := utils.DeleteProjectSecretValue(projectID, req.Name); cleanupErr != nil {
+			log.Printf("Warning: %v", cleanupErr)
+		}
+		return models.ProjectSecret{}, err
+	}
+	log.Printf("Secret %s added to the vault of project %s", created.Name, projectID)
+	return created, nil
+}
+
+// UpdateSecret replaces the value of a vault entry. Services referencing it pick the new
+// value up on their next deploy.
+func (s *ProjectSecretService) UpdateSecret(projectID, secretID string, req dto.ProjectSecretUpdateRequest, userID string, isAdmin bool) (models.ProjectSecret, error) {
+	secret, err := s.getSecret(projectID, secretID, userID, isAdmin)
+	if err != nil {
+		return secret, err
+	}
+	if err := utils.ApplyProjectSecretValue(projectID, secret.Name, req.Value); err != nil {
+		return secret, err
+	}
+	return s.secretRepo.Touch(secret)
+}
+
+// DeleteSecret removes a vault entry. Running services keep their interpolated values, but
+// deploys of services still referencing it fail.
+func (s *ProjectSecretService) DeleteSecret(projectID, secretID string, userID string, isAdmin bool) error {
+	secret, err := s.getSecret(projectID, secretID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	if err := utils.DeleteProjectSecretValue(projectID, secret.Name); err != nil {
+		return err
+	}
+	return s.secretRepo.Delete(secret.ID)
+}
+
+// ResolveEnvVars interpolates the vault and external store references of the service's env
+// vars into SecretEnvVars. EnvVars keep their templates, so no secret is ever saved with the
+// service. Env vars flagged as secret are added as they are, so they are read from the env
+// Secret too.
+func (s *ProjectSecretService) ResolveEnvVars(service models.Service) (models.Service, error) {
+	service.SecretEnvVars = nil
+	referenced := false
+	for _, value := range service.EnvVars {
+		if utils.HasSecretReferences(value) {
+			referenced = true
+			break
+		}
+	}
+	if !referenced {
+		service.SecretEnvVars = flaggedSecretEnvVars(service, models.EnvVars{})
+		return service, nil
+	}
+
+	secrets, err := utils.ReadProjectSecrets(service.ProjectID)
+	if err != nil {
+		return service, err
+	}
+	external, err := NewSecretStoreService().FetchReferences(service.ProjectID, utils.ExternalSecretReferences(service.EnvVars))
+	if err != nil {
+		return service, err
+	}
+	resolved, err := utils.InterpolateSecretEnvVars(service.EnvVars, secrets, external)
+	if err != nil {
+		return service, err
+	}
+	service.SecretEnvVars = flaggedSecretEnvVars(service, resolved)
+	return service, nil
+}
+
+// flaggedSecretEnvVars adds the plain values of the service's secret env vars to resolved,
+// returning nil when there is nothing to put in the env Secret
+func flaggedSecretEnvVars(service models.Service, resolved models.EnvVars) models.EnvVars {
+	for key, value := range service.EnvVars {
+		if service.IsSecretEnvVar(key) && !utils.HasSecretReferences(value) {
+			resolved[key] = value
+		}
+	}
+	if len(resolved) == 0 {
+		return nil
+	}
+	return resolved
+}
+
+func (s *ProjectSecretService) getSecret(projectID, secretID string, userID string, isAdmin bool) (models.ProjectSecret, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.ProjectSecret{}, err
+	}
+
+	secret, err := s.secretRepo.FindByID(secretID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && secret.ProjectID != projectID) {
+		return models.ProjectSecret{}, ErrProjectSecretNotFound
+	}
+	return secret, err
+}
+
+func (s *ProjectSecretService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
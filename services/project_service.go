@@ -2,18 +2,22 @@ package services
 
 import (
 	"fmt"
-	"log"
+	"strings"
 
 	"github.com/pendeploy-simple/dto"
-	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
 )
 
 // ProjectService handles business logic for projects
 type ProjectService struct {
 	projectRepo *repositories.ProjectRepository
 	environmentRepo *repositories.EnvironmentRepository
+	logDrainRepo *repositories.LogDrainRepository
+	statusPageRepo *repositories.StatusPageRepository
+	deletionRepo *repositories.ProjectDeletionRepository
+	outboxRepo *repositories.OutboxRepository
 }
 
 // NewProjectService creates a new project service instance
@@ -21,6 +25,10 @@ func NewProjectService() *ProjectService {
 	return &ProjectService{
 		projectRepo: repositories.NewProjectRepository(),
 		environmentRepo: repositories.NewEnvironmentRepository(),
+		logDrainRepo: repositories.NewLogDrainRepository(),
+		statusPageRepo: repositories.NewStatusPageRepository(),
+		deletionRepo: repositories.NewProjectDeletionRepository(),
+		outboxRepo: repositories.NewOutboxRepository(),
 	}
 }
 
@@ -141,8 +149,22 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 		return dto.ProjectStatsResponse{}, err
 	}
 	
-	// Get deployment stats
+	// Aggregate deployment counts for every service in one query
 	deploymentRepo := repositories.NewDeploymentRepository()
+	deploymentCounts, err := deploymentRepo.CountByServiceForProject(projectID)
+	if err != nil {
+		return dto.ProjectStatsResponse{}, err
+	}
+	countsByService := make(map[string]repositories.ServiceDeploymentCounts, len(deploymentCounts))
+	for _, counts := range deploymentCounts {
+		countsByService[counts.ServiceID] = counts
+	}
+	
+	// Count services per environment from the services already loaded
+	servicesPerEnvironment := make(map[string]int)
+	for _, service := range services {
+		servicesPerEnvironment[service.EnvironmentID]++
+	}
 	
 	// Prepare stats result
 	stats := dto.ProjectStatsResponse{}
@@ -162,15 +184,12 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 	for _, env := range environments {
 		envMap[env.ID] = env
 		
-		// Count services in this environment
-		serviceCount, _ := environmentRepo.CountServicesInEnvironment(env.ID)
-		
 		// Add environment to list
 		envItem := dto.ProjectEnvironmentItem{
 			ID:            env.ID,
 			Name:          env.Name,
 			Description:   env.Description,
-			ServicesCount: serviceCount,
+			ServicesCount: servicesPerEnvironment[env.ID],
 			CreatedAt:     env.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
 		stats.Environments.Environments = append(stats.Environments.Environments, envItem)
@@ -182,23 +201,6 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 	stats.Services.ByStatus = make(map[string]int)
 	stats.Services.ServiceList = make([]dto.ProjectServiceStatsItem, 0)
 	
-	// Initialize deployment stats
-	stats.Deployments.Total = 0
-	stats.Deployments.Successful = 0
-	stats.Deployments.Failed = 0
-	stats.Deployments.InProgress = 0
-	stats.Deployments.SuccessRate = 0.0
-	
-	// Variables to track total deployments
-	var totalDeployments int64 = 0
-	
-	// Get deployments stats directly from repository using the project ID
-	// This is more efficient than counting per service
-	totalDeployments, _ = deploymentRepo.CountByProjectID(projectID)
-	successfulDeployments, _ := deploymentRepo.CountDeploymentsByProjectIDAndStatus(projectID, models.DeploymentStatusSuccess)
-	failedDeployments, _ := deploymentRepo.CountDeploymentsByProjectIDAndStatus(projectID, models.DeploymentStatusFailed)
-	inProgressDeployments, _ := deploymentRepo.CountDeploymentsByProjectIDAndStatus(projectID, models.DeploymentStatusBuilding)
-	
 	// Process each service
 	for _, service := range services {
 		// Increment service type counter
@@ -207,11 +209,18 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 		// Increment status counter
 		stats.Services.ByStatus[service.Status]++
 		
-		// Get deployments for this service
-		deploymentCount, _ := deploymentRepo.CountByServiceID(service.ID)
+		// Deployment counters for this service (zero when it has none)
+		counts := countsByService[service.ID]
+		var successRate float64
+		if counts.Total > 0 {
+			successRate = float64(counts.Successful) / float64(counts.Total)
+		}
 		
-		// Get successful deployments
-		successRate, _ := deploymentRepo.GetSuccessRate(service.ID)
+		// Add to project-wide deployment totals
+		stats.Deployments.Total += counts.Total
+		stats.Deployments.Successful += counts.Successful
+		stats.Deployments.Failed += counts.Failed
+		stats.Deployments.InProgress += counts.InProgress
 		
 		// Get environment information
 		var environmentID string = service.EnvironmentID
@@ -230,7 +239,7 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 			Status:          service.Status,
 			EnvironmentID:   environmentID,
 			EnvironmentName: environmentName,
-			Deployments:     deploymentCount,
+			Deployments:     counts.Total,
 			SuccessRate:     successRate,
 			Replicas:        service.Replicas,
 			IsAutoScaling:   !service.IsStaticReplica,
@@ -238,21 +247,24 @@ func (s *ProjectService) GetProjectStats(projectID string, userID string, isAdmi
 		stats.Services.ServiceList = append(stats.Services.ServiceList, serviceItem)
 	}
 	
-	// Update deployment stats
-	stats.Deployments.Total = totalDeployments
-	stats.Deployments.Successful = successfulDeployments
-	stats.Deployments.Failed = failedDeployments
-	stats.Deployments.InProgress = inProgressDeployments
-	
-	if totalDeployments > 0 {
-		stats.Deployments.SuccessRate = float64(successfulDeployments) / float64(totalDeployments)
+	if stats.Deployments.Total > 0 {
+		stats.Deployments.SuccessRate = float64(stats.Deployments.Successful) / float64(stats.Deployments.Total)
 	}
 	
+	// Monthly cost estimate; the per-service breakdown is served by /projects/:id/costs
+	stats.Costs = NewCostService().estimate(project, environments, services).CostSummary
+	
 	return stats, nil
 }
 
 // CreateProject creates a new project with a default environment
 func (s *ProjectService) CreateProject(project models.Project) (models.Project, error) {
+	baseDomain, err := resolveBaseDomain(project.BaseDomain)
+	if err != nil {
+		return project, err
+	}
+	project.BaseDomain = baseDomain
+
 	// Begin a transaction to ensure both project and environment are created together
 	db := s.projectRepo.DB().Begin()
 	defer func() {
@@ -300,8 +312,20 @@ func (s *ProjectService) UpdateProject(project models.Project, userID string, is
 		return models.Project{}, fmt.Errorf("unauthorized: you don't have permission to update this project")
 	}
 	
-	// Preserve the user ID (it shouldn't be changed)
+	if existingProject.Status == models.ProjectStatusDeleting {
+		return models.Project{}, ErrProjectDeleting
+	}
+	
+	// Preserve the user ID and status (they shouldn't be changed here)
 	project.UserID = existingProject.UserID
+	project.Status = existingProject.Status
+	
+	// Base domain only changes when a new one is selected
+	if project.BaseDomain == "" {
+		project.BaseDomain = existingProject.BaseDomain
+	} else if project.BaseDomain, err = resolveBaseDomain(project.BaseDomain); err != nil {
+		return models.Project{}, err
+	}
 	
 	// Update project
 	err = s.projectRepo.Update(project)
@@ -312,66 +336,12 @@ func (s *ProjectService) UpdateProject(project models.Project, userID string, is
 	return project, nil
 }
 
-// DeleteProject deletes a project and all associated kubernetes resources
-func (s *ProjectService) DeleteProject(projectID string, userID string, isAdmin bool) error {
-	// Cek apakah project dengan ID tersebut ada di database (tanpa filter deleted_at)
-	exists, err := s.projectRepo.Exists(projectID)
-	if err != nil {
-		return err
-	}
-	
-	if !exists {
-		return fmt.Errorf("project not found or already deleted")
-	}
-	
-	// Jika kita ingin memvalidasi akses kontrol, maka kita perlu mendapatkan project
-	// dengan Unscoped (untuk melihat bahkan yang sudah dihapus)
-	if !isAdmin {
-		owner, err := s.projectRepo.GetOwnerID(projectID)
-		if err != nil {
-			return err
-		}
-			
-		if owner != userID {
-			return fmt.Errorf("unauthorized: you don't have permission to delete this project")
-		}
-	}
-
-	// Get all project environments before deleting
-	environments, err := s.environmentRepo.FindByProjectID(projectID)
-	if err != nil {
-		return fmt.Errorf("error fetching project environments: %w", err)
-	}
-
-	// Init Kubernetes client
-	k8sClient, err := kubernetes.NewClient()
-	if err != nil {
-		return fmt.Errorf("error initializing kubernetes client: %w", err)
-	}
-
-	// Delete all kubernetes namespaces for each environment
-	for _, env := range environments {
-		// Delete namespace for environment
-		namespace := env.ID // The namespace name is the environment ID
-		
-		// Check if namespace exists
-		exists, err := k8sClient.NamespaceExists(namespace)
-		if err != nil {
-			log.Printf("Warning: Error checking namespace %s: %v", namespace, err)
-			continue
-		}
-
-		if exists {
-			err = k8sClient.DeleteNamespace(namespace)
-			if err != nil {
-				log.Printf("Warning: Failed to delete namespace %s: %v", namespace, err)
-				// Continue with other namespaces even if one fails
-			} else {
-				log.Printf("Successfully deleted namespace %s for environment %s", namespace, env.Name)
-			}
-		}
-	}
-
-	// Lakukan soft delete - cascade will handle related records
-	return s.projectRepo.Delete(projectID)
+// resolveBaseDomain normalizes a selected base domain and rejects domains that are
+// not configured on the platform
+func resolveBaseDomain(domain string) (string, error) {
+	domain = utils.NormalizeDomain(domain)
+	if domain == "" || utils.IsPlatformDomain(domain) {
+		return domain, nil
+	}
+	return "", fmt.Errorf("base domain %q is not available; choose one of: %s", domain, strings.Join(utils.GetPlatformDomains(), ", "))
 }
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// Promotion errors the API maps to client errors
+var (
+	ErrPromotionNotFound    = errors.New("promotion not found")
+	ErrPromotionNotPending  = errors.New("promotion is not waiting for approval")
+	ErrPromotionSelfApprove = errors.New("a promotion must be approved by someone other than its requester")
+)
+
+// PromotionService promotes deployments between the environments of a project: the exact
+// image of a successful deployment, pinned to its digest, is rolled out to the counterpart
+// service in the target environment, after approval when the environment requires it
+type PromotionService struct {
+	promotionRepo     *repositories.PromotionRepository
+	deploymentRepo    *repositories.DeploymentRepository
+	serviceRepo       *repositories.ServiceRepository
+	environmentRepo   *repositories.EnvironmentRepository
+	projectRepo       *repositories.ProjectRepository
+	deploymentService *DeploymentService
+}
+
+// NewPromotionService creates a new promotion service instance
+func NewPromotionService() *PromotionService {
+	return &PromotionService{
+		promotionRepo:     repositories.NewPromotionRepository(),
+		deploymentRepo:    repositories.NewDeploymentRepository(),
+		serviceRepo:       repositories.NewServiceRepository(),
+		environmentRepo:   repositories.NewEnvironmentRepository(),
+		projectRepo:       repositories.NewProjectRepository(),
+		deploymentService: NewDeploymentService(),
+	}
+}
+
+// promotionPlan is what a promotion deploys, and where
+type promotionPlan struct {
+	source        models.Deployment
+	sourceService models.Service
+	target        models.Service
+	targetEnv     models.Environment
+	image         string
+}
+
+// Preview shows what promoting the deployment to the target environment would deploy and
+// how the configuration of the counterpart service differs from the source's
+func (s *PromotionService) Preview(deploymentID string, targetEnvironmentID string, userID string, isAdmin bool) (dto.PromotionPreview, error) {
+	plan, err := s.plan(deploymentID, targetEnvironmentID, userID, isAdmin)
+	if err != nil {
+		return dto.PromotionPreview{}, err
+	}
+	return dto.PromotionPreview{
+		SourceDeployment: deploymentImageSummary(plan.source),
+		SourceService:    plan.sourceService,
+		TargetService:    plan.target,
+		Image:            plan.image,
+		ApprovalRequired: plan.targetEnv.PromotionApprovalRequired,
+		ConfigDiff:       utils.DiffRuntimeConfig(plan.sourceService, plan.target),
+		EnvVarDiff:       utils.DiffEnvVars(plan.sourceService, plan.target),
+		Warnings:         promotionWarnings(plan.sourceService, plan.target),
+	}, nil
+}
+
+// Promote requests a promotion. It is deployed at once unless the target environment
+// requires approval, in which case it waits for Approve.
+func (s *PromotionService) Promote(req dto.PromotionRequest, userID string, isAdmin bool) (models.Promotion, error) {
+	plan, err := s.plan(req.DeploymentID, req.TargetEnvironmentID, userID, isAdmin)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	status := models.PromotionApproved
+	if plan.targetEnv.PromotionApprovalRequired {
+		status = models.PromotionPendingApproval
+	}
+	promotion, err := s.promotionRepo.Create(models.Promotion{
+		ProjectID:           plan.sourceService.ProjectID,
+		SourceDeploymentID:  plan.source.ID,
+		SourceServiceID:     plan.sourceService.ID,
+		SourceEnvironmentID: plan.sourceService.EnvironmentID,
+		TargetServiceID:     plan.target.ID,
+		TargetEnvironmentID: plan.target.EnvironmentID,
+		Image:               plan.image,
+		CommitSHA:           plan.source.CommitSHA,
+		Status:              status,
+		Note:                strings.TrimSpace(req.Note),
+		RequestedBy:         userID,
+	})
+	if err != nil {
+		return promotion, fmt.Errorf("failed to record promotion: %v", err)
+	}
+	log.Printf("Promotion %s of deployment %s to service %s requested by %s (%s)", promotion.ID, plan.source.ID, plan.target.ID, userID, status)
+
+	if status == models.PromotionPendingApproval {
+		return promotion, nil
+	}
+	return s.deploy(promotion, plan, isAdmin)
+}
+
+// Approve approves a promotion waiting for approval and deploys it. The requester cannot
+// approve their own promotion.
+func (s *PromotionService) Approve(promotionID string, comment string, userID string, isAdmin bool) (models.Promotion, error) {
+	promotion, err := s.findReviewable(promotionID, userID, isAdmin)
+	if err != nil {
+		return promotion, err
+	}
+	// The source or target may have changed since the request, so the plan is made again
+	plan, err := s.plan(promotion.SourceDeploymentID, promotion.TargetEnvironmentID, userID, isAdmin)
+	if err != nil {
+		return promotion, err
+	}
+	if plan.target.ID != promotion.TargetServiceID || plan.image != promotion.Image {
+		return promotion, errors.New("the source deployment or the target service changed since the promotion was requested; request it again")
+	}
+
+	if err := s.review(&promotion, models.PromotionApproved, comment, userID); err != nil {
+		return promotion, err
+	}
+	log.Printf("Promotion %s approved by %s", promotion.ID, userID)
+	return s.deploy(promotion, plan, isAdmin)
+}
+
+// Reject rejects a promotion waiting for approval
+func (s *PromotionService) Reject(promotionID string, comment string, userID string, isAdmin bool) (models.Promotion, error) {
+	promotion, err := s.findReviewable(promotionID, userID, isAdmin)
+	if err != nil {
+		return promotion, err
+	}
+	if err := s.review(&promotion, models.PromotionRejected, comment, userID); err != nil {
+		return promotion, err
+	}
+	log.Printf("Promotion %s rejected by %s", promotion.ID, userID)
+	return promotion, nil
+}
+
+// ListPromotions returns a page of a project's promotions, newest first
+func (s *PromotionService) ListPromotions(projectID string, status string, page, pageSize int, userID string, isAdmin bool) (dto.PromotionListResponse, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return dto.PromotionListResponse{}, err
+	}
+	promotions, total, err := s.promotionRepo.FindByProjectID(projectID, status, page, pageSize)
+	if err != nil {
+		return dto.PromotionListResponse{}, err
+	}
+	return dto.PromotionListResponse{
+		Promotions: promotions,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// plan resolves the source deployment and the counterpart service it would be promoted to
+func (s *PromotionService) plan(deploymentID string, targetEnvironmentID string, userID string, isAdmin bool) (promotionPlan, error) {
+	var plan promotionPlan
+
+	source, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil {
+		return plan, errors.New("deployment not found")
+	}
+	sourceService, err := s.serviceRepo.FindByID(source.ServiceID)
+	if err != nil {
+		return plan, errors.New("deployment not found")
+	}
+	if err := s.checkProjectAccess(sourceService.ProjectID, userID, isAdmin); err != nil {
+		return plan, err
+	}
+	if source.Status != models.DeploymentStatusSuccess {
+		return plan, errors.New("only successful deployments can be promoted")
+	}
+	if source.Image == "" || source.ImageDigest == "" {
+		return plan, errors.New("the deployment's image digest was not recorded, so its exact image cannot be promoted")
+	}
+
+	targetEnv, err := s.environmentRepo.FindByID(targetEnvironmentID)
+	if err != nil || targetEnv.ProjectID != sourceService.ProjectID {
+		return plan, errors.New("target environment not found in this project")
+	}
+	if targetEnv.ID == sourceService.EnvironmentID {
+		return plan, errors.New("target environment must differ from the deployment's environment")
+	}
+	if targetEnv.IsArchived() {
+		return plan, errors.New("target environment is archived; unarchive it before promoting")
+	}
+
+	target, err := s.serviceRepo.FindByNameInEnvironment(sourceService.Name, targetEnv.ID)
+	if err != nil {
+		return plan, fmt.Errorf("environment %q has no service named %q to promote to", targetEnv.Name, sourceService.Name)
+	}
+	if target.Type != models.ServiceTypeGit {
+		return plan, fmt.Errorf("service %q in environment %q is not a git service", target.Name, targetEnv.Name)
+	}
+
+	return promotionPlan{
+		source:        source,
+		sourceService: sourceService,
+		target:        target,
+		targetEnv:     targetEnv,
+		image:         utils.ImageByDigest(source.Image, source.ImageDigest),
+	}, nil
+}
+
+// deploy rolls the promoted image out to the target service and records the outcome
+func (s *PromotionService) deploy(promotion models.Promotion, plan promotionPlan, byAdmin bool) (models.Promotion, error) {
+	deployment, err := s.deploymentService.DeployImage(plan.target, models.Deployment{
+		CommitSHA:     plan.source.CommitSHA,
+		CommitMessage: plan.source.CommitMessage,
+		Image:         promotion.Image,
+		ImageDigest:   plan.source.ImageDigest,
+	}, byAdmin)
+	if recordErr := s.promotionRepo.RecordResult(promotion.ID, deployment.ID, err); recordErr != nil {
+		log.Printf("Failed to record the result of promotion %s: %v", promotion.ID, recordErr)
+	}
+	if err != nil {
+		promotion.Status = models.PromotionFailed
+		promotion.Error = err.Error()
+		return promotion, err
+	}
+	promotion.Status = models.PromotionDeployed
+	promotion.TargetDeploymentID = &deployment.ID
+	return promotion, nil
+}
+
+// findReviewable loads a promotion waiting for approval that the user may review
+func (s *PromotionService) findReviewable(promotionID string, userID string, isAdmin bool) (models.Promotion, error) {
+	promotion, err := s.promotionRepo.FindByID(promotionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return promotion, ErrPromotionNotFound
+	}
+	if err != nil {
+		return promotion, err
+	}
+	if err := s.checkProjectAccess(promotion.ProjectID, userID, isAdmin); err != nil {
+		return promotion, err
+	}
+	if promotion.Status != models.PromotionPendingApproval {
+		return promotion, ErrPromotionNotPending
+	}
+	if promotion.RequestedBy == userID {
+		return promotion, ErrPromotionSelfApprove
+	}
+	return promotion, nil
+}
+
+// review records the decision; it fails when another reviewer decided first
+func (s *PromotionService) review(promotion *models.Promotion, status models.PromotionStatus, comment string, userID string) error {
+	now := time.Now()
+	comment = strings.TrimSpace(comment)
+	reviewed, err := s.promotionRepo.Review(promotion.ID, status, userID, comment, now)
+	if err != nil {
+		return err
+	}
+	if !reviewed {
+		return ErrPromotionNotPending
+	}
+	promotion.Status = status
+	promotion.ReviewedBy = &userID
+	promotion.ReviewedAt = &now
+	promotion.ReviewComment = comment
+	return nil
+}
+
+func (s *PromotionService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
+
+// promotionWarnings points out differences between the environments the promoted image
+// does not pick up
+func promotionWarnings(source, target models.Service) []string {
+	warnings := []string{}
+	for _, key := range utils.ChangedEnvVars(source, target) {
+		if source.IsBuildEnvVar(key) || target.IsBuildEnvVar(key) {
+			warnings = append(warnings, fmt.Sprintf("%s is needed at build time, so the image keeps the value it was built with in the source environment", key))
+		}
+	}
+	for _, field := range utils.PlanServiceUpdate(target, source).RebuildFields {
+		if field == "envVars" || field == "secretEnvKeys" {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s differs; the image was built with the source service's setting", field))
+	}
+	return warnings
+}
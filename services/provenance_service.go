@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// ProvenanceService generates SBOMs for and signs the images of successful builds
+type ProvenanceService struct {
+	deploymentRepo *repositories.DeploymentRepository
+	sbomRepo       *repositories.DeploymentSBOMRepository
+	serviceRepo    *repositories.ServiceRepository
+	projectRepo    *repositories.ProjectRepository
+}
+
+// NewProvenanceService creates a new provenance service instance
+func NewProvenanceService() *ProvenanceService {
+	return &ProvenanceService{
+		deploymentRepo: repositories.NewDeploymentRepository(),
+		sbomRepo:       repositories.NewDeploymentSBOMRepository(),
+		serviceRepo:    repositories.NewServiceRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+	}
+}
+
+// Enabled reports whether SBOM generation or image signing is configured
+func (s *ProvenanceService) Enabled() bool {
+	return utils.LoadProvenanceConfig().Enabled()
+}
+
+// Run generates the SBOM and signature of a deployment's image and records the outcome.
+// Failures are recorded on the deployment and never fail it.
+func (s *ProvenanceService) Run(deploymentID string, service models.Service, registry models.Registry, image string) {
+	// Reload for the image digest captured after the build
+	deployment, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil {
+		log.Printf("Failed to load deployment %s for provenance: %v", deploymentID, err)
+		return
+	}
+
+	result, err := utils.RunProvenanceJob(deployment, service, image, utils.IsInsecureRegistry(registry.URL))
+	var problems []string
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, problem := range []string{result.SBOMError, result.SignError} {
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	sbomFormat := ""
+	if result.SBOM != "" {
+		if err := s.sbomRepo.Save(models.DeploymentSBOM{
+			DeploymentID: deployment.ID,
+			Format:       utils.SBOMFormat,
+			Document:     result.SBOM,
+		}); err != nil {
+			problems = append(problems, "failed to store SBOM: "+err.Error())
+		} else {
+			sbomFormat = utils.SBOMFormat
+		}
+	}
+
+	provenanceError := strings.Join(problems, "; ")
+	if provenanceError != "" {
+		log.Printf("Provenance of deployment %s incomplete: %s", deployment.ID, provenanceError)
+	}
+	if err := s.deploymentRepo.UpdateProvenance(deployment.ID, sbomFormat, result.Signed, provenanceError); err != nil {
+		log.Printf("Failed to record provenance of deployment %s: %v", deployment.ID, err)
+	}
+}
+
+// GetSBOM returns the stored SBOM of a deployment to an owner of its project or an admin
+func (s *ProvenanceService) GetSBOM(deploymentID string, userID string, isAdmin bool) (models.DeploymentSBOM, error) {
+	deployment, err := s.deploymentRepo.FindByID(deploymentID)
+	if err != nil {
+		return models.DeploymentSBOM{}, err
+	}
+	if !isAdmin {
+		service, err := s.serviceRepo.FindByID(deployment.ServiceID)
+		if err != nil {
+			return models.DeploymentSBOM{}, err
+		}
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return models.DeploymentSBOM{}, err
+		}
+		if ownerID != userID {
+			return models.DeploymentSBOM{}, errors.New("unauthorized access to deployment")
+		}
+	}
+	return s.sbomRepo.FindByDeploymentID(deployment.ID)
+}
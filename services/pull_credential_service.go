@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// ErrPullCredentialNotFound is returned for credentials that do not exist in the project
+var ErrPullCredentialNotFound = errors.New("pull credential not found")
+
+// PullCredentialService manages logins for private external registries and resolves the
+// pull Secrets generated workloads reference
+type PullCredentialService struct {
+	credentialRepo  *repositories.PullCredentialRepository
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+}
+
+// NewPullCredentialService creates a new pull credential service instance
+func NewPullCredentialService() *PullCredentialService {
+	return &PullCredentialService{
+		credentialRepo:  repositories.NewPullCredentialRepository(),
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+	}
+}
+
+// ListCredentials returns the pull credentials of a project
+func (s *PullCredentialService) ListCredentials(projectID string, userID string, isAdmin bool) ([]models.PullCredential, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.credentialRepo.FindByProjectID(projectID)
+}
+
+// CreateCredential registers a pull credential for the project or one of its environments
+func (s *PullCredentialService) CreateCredential(projectID string, req dto.PullCredentialRequest, userID string, isAdmin bool) (models.PullCredential, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.PullCredential{}, err
+	}
+
+	credential := models.PullCredential{
+		ProjectID:  projectID,
+		Name:       req.Name,
+		Server:     req.Server,
+		Username:   req.Username,
+		SecretName: utils.GetPullSecretName(req.Name),
+	}
+	if req.EnvironmentID != "" {
+		env, err := s.environmentRepo.FindByID(req.EnvironmentID)
+		if err != nil || env.ProjectID != projectID {
+			return models.PullCredential{}, errors.New("environment not found in this project")
+		}
+		credential.EnvironmentID = &env.ID
+	}
+
+	existing, err := s.credentialRepo.FindByProjectID(projectID)
+	if err != nil {
+		return models.PullCredential{}, err
+	}
+	for _, other := range existing {
+		if other.Name == credential.Name {
+			return models.PullCredential{}, fmt.Errorf("a pull credential named %q already exists in this project", credential.Name)
+		}
+	}
+
+	// The Secret is written first so a stored credential always has one
+	if err := utils.ApplyPullCredentialSecret(credential, req.Password, nil); err != nil {
+		return models.PullCredential{}, err
+	}
+	created, err := s.credentialRepo.Create(credential)
+	if err != nil {
+		if cleanupErr := utils.DeletePullCredentialSecrets(credential, nil); cleanupErr != nil {
+			log.Printf("Warning: %v", cleanupErr)
+		}
+		return models.PullCredential{}, err
+	}
+	log.Printf("Pull credential %s for %s registered in project %s", created.Name, created.Server, projectID)
+	return created, nil
+}
+
+// UpdateCredential rotates the login of a pull credential. Copies in environment namespaces
+// are refreshed right away; running pods pick them up on their next image pull.
+func (s *PullCredentialService) UpdateCredential(projectID, credentialID string, req dto.PullCredentialUpdateRequest, userID string, isAdmin bool) (models.PullCredential, error) {
+	credential, err := s.getCredential(projectID, credentialID, userID, isAdmin)
+	if err != nil {
+		return credential, err
+	}
+	if req.Username != "" {
+		credential.Username = req.Username
+	}
+
+	namespaces, err := s.credentialNamespaces(credential)
+	if err != nil {
+		return credential, err
+	}
+	if err := utils.ApplyPullCredentialSecret(credential, req.Password, namespaces); err != nil {
+		return credential, err
+	}
+	return s.credentialRepo.Update(credential)
+}
+
+// DeleteCredential removes a pull credential and its Secrets. Workloads stop referencing it
+// on their next deploy.
+func (s *PullCredentialService) DeleteCredential(projectID, credentialID string, userID string, isAdmin bool) error {
+	credential, err := s.getCredential(projectID, credentialID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := s.credentialNamespaces(credential)
+	if err != nil {
+		return err
+	}
+	if err := utils.DeletePullCredentialSecrets(credential, namespaces); err != nil {
+		return err
+	}
+	return s.credentialRepo.Delete(credential.ID)
+}
+
+// ResolveSecretNames returns the pull Secrets workloads of the service's environment use.
+// A failed lookup only drops the Secrets, so public images keep deploying.
+func (s *PullCredentialService) ResolveSecretNames(service models.Service) []string {
+	credentials, err := s.credentialRepo.FindForEnvironment(service.ProjectID, service.EnvironmentID)
+	if err != nil {
+		log.Printf("Failed to load pull credentials for service %s: %v", service.ID, err)
+		return nil
+	}
+
+	names := make([]string, 0, len(credentials))
+	for _, credential := range credentials {
+		names = append(names, credential.SecretName)
+	}
+	return names
+}
+
+// credentialNamespaces lists the environment namespaces a credential is copied into
+func (s *PullCredentialService) credentialNamespaces(credential models.PullCredential) ([]string, error) {
+	environments, err := s.environmentRepo.FindByProjectID(credential.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	for _, env := range environments {
+		if credential.AppliesTo(env.ID) {
+			namespaces = append(namespaces, env.ID)
+		}
+	}
+	return namespaces, nil
+}
+
+func (s *PullCredentialService) getCredential(projectID, credentialID string, userID string, isAdmin bool) (models.PullCredential, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.PullCredential{}, err
+	}
+
+	credential, err := s.credentialRepo.FindByID(credentialID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && credential.ProjectID != projectID) {
+		return models.PullCredential{}, ErrPullCredentialNotFound
+	}
+	return credential, err
+}
+
+func (s *PullCredentialService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// RabbitMQService provisions vhosts and users on managed RabbitMQ services
+type RabbitMQService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewRabbitMQService creates a new RabbitMQ service instance
+func NewRabbitMQService() *RabbitMQService {
+	return &RabbitMQService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// ListVhosts lists the vhosts on the broker
+func (s *RabbitMQService) ListVhosts(serviceID string, userID string, isAdmin bool) ([]dto.RabbitMQVhostResponse, error) {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.NewRabbitMQManagementClient(service).ListVhosts()
+}
+
+// CreateVhost creates a new vhost
+func (s *RabbitMQService) CreateVhost(serviceID string, req dto.RabbitMQVhostRequest, userID string, isAdmin bool) (dto.RabbitMQVhostResponse, error) {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.RabbitMQVhostResponse{}, err
+	}
+
+	if !utils.IsValidRabbitMQName(req.Name) {
+		return dto.RabbitMQVhostResponse{}, errors.New("invalid vhost name: use lowercase letters, digits, '-' or '_'")
+	}
+
+	if err := utils.NewRabbitMQManagementClient(service).CreateVhost(req.Name); err != nil {
+		return dto.RabbitMQVhostResponse{}, fmt.Errorf("failed to create vhost: %v", err)
+	}
+
+	log.Printf("Created vhost %s on RabbitMQ service %s", req.Name, service.ID)
+	return dto.RabbitMQVhostResponse{Name: req.Name}, nil
+}
+
+// DeleteVhost deletes a vhost together with the users provisioned for it
+func (s *RabbitMQService) DeleteVhost(serviceID string, vhost string, userID string, isAdmin bool) error {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if vhost == "/" {
+		return errors.New("the default vhost cannot be deleted")
+	}
+
+	client := utils.NewRabbitMQManagementClient(service)
+	if err := client.DeleteVhost(vhost); err != nil {
+		return fmt.Errorf("failed to delete vhost: %v", err)
+	}
+
+	users, err := utils.ListRabbitMQUserSecrets(service)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Vhost != vhost {
+			continue
+		}
+		if err := client.DeleteUser(user.Username); err != nil {
+			log.Printf("Warning: failed to delete RabbitMQ user %s: %v", user.Username, err)
+		}
+		if err := utils.DeleteRabbitMQUserSecret(service, user.Username); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	log.Printf("Deleted vhost %s on RabbitMQ service %s", vhost, service.ID)
+	return nil
+}
+
+// ListUsers lists the users provisioned through the API
+func (s *RabbitMQService) ListUsers(serviceID string, userID string, isAdmin bool) ([]dto.RabbitMQUserResponse, error) {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ListRabbitMQUserSecrets(service)
+}
+
+// CreateUser creates a user with a generated password, grants it access to a
+// vhost and stores the credentials in a Secret
+func (s *RabbitMQService) CreateUser(serviceID string, req dto.RabbitMQUserRequest, userID string, isAdmin bool) (dto.RabbitMQUserResponse, error) {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.RabbitMQUserResponse{}, err
+	}
+
+	if !utils.IsValidRabbitMQName(req.Username) {
+		return dto.RabbitMQUserResponse{}, errors.New("invalid username: use lowercase letters, digits, '-' or '_'")
+	}
+	if req.Username == service.EnvVars["RABBITMQ_DEFAULT_USER"] {
+		return dto.RabbitMQUserResponse{}, errors.New("username is reserved for the root user")
+	}
+	if req.Vhost != "/" && !utils.IsValidRabbitMQName(req.Vhost) {
+		return dto.RabbitMQUserResponse{}, errors.New("invalid vhost name")
+	}
+
+	// Default to full access within the vhost
+	configure, write, read := req.Configure, req.Write, req.Read
+	if configure == "" {
+		configure = ".*"
+	}
+	if write == "" {
+		write = ".*"
+	}
+	if read == "" {
+		read = ".*"
+	}
+
+	password := utils.GenerateSecurePassword(20)
+	client := utils.NewRabbitMQManagementClient(service)
+	if err := client.PutUser(req.Username, password); err != nil {
+		return dto.RabbitMQUserResponse{}, fmt.Errorf("failed to create user: %v", err)
+	}
+	if err := client.SetPermissions(req.Vhost, req.Username, configure, write, read); err != nil {
+		client.DeleteUser(req.Username)
+		return dto.RabbitMQUserResponse{}, fmt.Errorf("failed to set permissions: %v", err)
+	}
+
+	user := utils.BuildRabbitMQUserResponse(service, req.Username, password, req.Vhost)
+	if err := utils.SaveRabbitMQUserSecret(service, user); err != nil {
+		client.DeleteUser(req.Username)
+		return dto.RabbitMQUserResponse{}, err
+	}
+
+	log.Printf("Created RabbitMQ user %s on vhost %s for service %s", req.Username, req.Vhost, service.ID)
+	return user, nil
+}
+
+// DeleteUser deletes a provisioned user and its Secret
+func (s *RabbitMQService) DeleteUser(serviceID string, username string, userID string, isAdmin bool) error {
+	service, err := s.getRabbitMQService(serviceID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+
+	if username == service.EnvVars["RABBITMQ_DEFAULT_USER"] {
+		return errors.New("the root user cannot be deleted")
+	}
+
+	if err := utils.NewRabbitMQManagementClient(service).DeleteUser(username); err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	return utils.DeleteRabbitMQUserSecret(service, username)
+}
+
+// getRabbitMQService loads the service and checks access and type
+func (s *RabbitMQService) getRabbitMQService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+
+	if service.Type != models.ServiceTypeManaged || service.ManagedType != "rabbitmq" {
+		return service, errors.New("service is not a managed RabbitMQ service")
+	}
+
+	return service, nil
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
 	"github.com/pendeploy-simple/utils"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -19,17 +22,21 @@ import (
 
 const (
 	CAConfigMapName = "pendeploy-registry-ca"
+	// dependencyBuildTimeout bounds a single dependency image build including pulls
+	dependencyBuildTimeout = 15 * time.Minute
 )
 
 // RegistryDependencyService handles setup and management of required images for a registry
 type RegistryDependencyService struct {
 	kubeClient *kubernetes.Client
+	depRepo    *repositories.DependencyImageRepository
 }
 
 // DependencyImage represents a required image for the build system
 type DependencyImage struct {
 	Name        string
 	SourceImage string
+	Digest      string // the digest SourceImage is pinned to; empty until it is pinned
 	TargetTag   string
 	Description string
 }
@@ -43,11 +50,35 @@ func NewRegistryDependencyService() *RegistryDependencyService {
 
 	return &RegistryDependencyService{
 		kubeClient: client,
+		depRepo:    repositories.NewDependencyImageRepository(),
 	}
 }
 
-// GetRequiredImages returns the list of images required for the build system
+// GetRequiredImages returns the list of images required for the build system, with the
+// source and digest each one is pinned to
 func (s *RegistryDependencyService) GetRequiredImages() []DependencyImage {
+	images := defaultDependencyImages()
+	pins, err := s.depRepo.FindPins()
+	if err != nil {
+		log.Printf("Warning: Failed to load pinned dependency images: %v", err)
+		return images
+	}
+	pinned := make(map[string]models.DependencyImagePin, len(pins))
+	for _, pin := range pins {
+		pinned[pin.Name] = pin
+	}
+	for i, img := range images {
+		if pin, ok := pinned[img.Name]; ok {
+			images[i].SourceImage = pin.SourceImage
+			images[i].Digest = pin.Digest
+			images[i].TargetTag = img.Name + ":" + utils.ImageReferenceTag(pin.SourceImage)
+		}
+	}
+	return images
+}
+
+// defaultDependencyImages are the dependency images before any of them is pinned
+func defaultDependencyImages() []DependencyImage {
 	return []DependencyImage{
 		{
 			Name:        "alpine-git",
@@ -81,16 +112,57 @@ func (s *RegistryDependencyService) SetupRegistryDependencies(ctx context.Contex
 	log.Printf("Registry is ready, proceeding with dependency setup")
 
 	// Ensure namespace exists
-	err = s.ensureNamespaceExists(ctx, "build-and-deploy")
+	err = s.ensureNamespaceExists(ctx, utils.GetJobNamespace())
 	if err != nil {
 		return fmt.Errorf("failed to ensure namespace exists: %v", err)
 	}
 
-	// Get required images
-	images := s.GetRequiredImages()
+	if err := s.buildImages(ctx, registry, s.GetRequiredImages()); err != nil {
+		return err
+	}
+
+	// A finished job only means the push succeeded; confirm the images are served
+	missing, err := s.ValidateDependencies(ctx, registry)
+	if err != nil {
+		return fmt.Errorf("failed to verify dependencies: %v", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("dependency images missing after build: %s", strings.Join(missing, ", "))
+	}
+
+	log.Printf("Successfully set up all dependencies for registry %s", registry.Name)
+	return nil
+}
+
+// RebuildDependencies rebuilds the given dependency images, typically the ones
+// ValidateDependencies reported missing after a failed build
+func (s *RegistryDependencyService) RebuildDependencies(ctx context.Context, registry models.Registry, names []string) error {
+	if s.kubeClient == nil {
+		return fmt.Errorf("kubernetes client not initialized")
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var images []DependencyImage
+	for _, img := range s.GetRequiredImages() {
+		if wanted[img.Name] {
+			images = append(images, img)
+		}
+	}
+
+	if err := s.ensureNamespaceExists(ctx, utils.GetJobNamespace()); err != nil {
+		return fmt.Errorf("failed to ensure namespace exists: %v", err)
+	}
+	return s.buildImages(ctx, registry, images)
+}
 
-	// Process each image - ALL USING KANIKO NOW!
+// buildImages builds the images one after another from their pinned digests, stopping at
+// the first failure
+func (s *RegistryDependencyService) buildImages(ctx context.Context, registry models.Registry, images []DependencyImage) error {
 	for _, img := range images {
+		img = s.pinImage(img)
 		log.Printf("Building dependency image with Kaniko: %s", img.Name)
 
 		err := s.buildImageWithKaniko(ctx, registry, img)
@@ -98,12 +170,63 @@ func (s *RegistryDependencyService) SetupRegistryDependencies(ctx context.Contex
 			log.Printf("Failed to build image %s with Kaniko: %v", img.Name, err)
 			return fmt.Errorf("failed to build image %s: %v", img.Name, err)
 		}
-	}
 
-	log.Printf("Successfully set up all dependencies for registry %s", registry.Name)
+		if err := s.depRepo.RecordBuilt(models.RegistryDependencyImage{
+			RegistryID:  registry.ID,
+			Name:        img.Name,
+			SourceImage: img.SourceImage,
+			Digest:      img.Digest,
+			BuiltAt:     time.Now(),
+		}); err != nil {
+			log.Printf("Warning: Failed to record the build of %s for registry %s: %v", img.Name, registry.ID, err)
+		}
+	}
 	return nil
 }
 
+// pinImage makes sure a dependency image is built from a pinned digest. An image is pinned
+// to the digest its source has when it is first built; when the source tag moved since, the
+// new digest is offered as an update for review and the pinned one is still built.
+func (s *RegistryDependencyService) pinImage(img DependencyImage) DependencyImage {
+	current, err := utils.ResolveImageDigest(img.SourceImage)
+	if err != nil {
+		if img.Digest == "" {
+			log.Printf("Warning: Building %s from unpinned %s, its digest could not be resolved: %v", img.Name, img.SourceImage, err)
+		} else {
+			log.Printf("Warning: Could not check %s for updates: %v", img.SourceImage, err)
+		}
+		return img
+	}
+
+	if img.Digest == "" {
+		pin, err := s.depRepo.CreatePin(models.DependencyImagePin{
+			Name:        img.Name,
+			SourceImage: img.SourceImage,
+			Digest:      current,
+			PinnedAt:    time.Now(),
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to pin %s to %s: %v", img.SourceImage, current, err)
+			img.Digest = current
+			return img
+		}
+		img.SourceImage, img.Digest = pin.SourceImage, pin.Digest
+		log.Printf("Pinned dependency image %s to %s@%s", img.Name, img.SourceImage, img.Digest)
+		return img
+	}
+
+	if current != img.Digest {
+		offered, err := s.depRepo.OfferCandidate(img.Name, img.SourceImage, current, time.Now())
+		if err != nil {
+			log.Printf("Warning: Failed to record the update of %s: %v", img.SourceImage, err)
+		} else if offered {
+			log.Printf("Warning: %s now resolves to %s instead of the pinned %s; building the pinned digest until the update is approved",
+				img.SourceImage, current, img.Digest)
+		}
+	}
+	return img
+}
+
 // waitForRegistryReady waits for registry to be accessible
 func (s *RegistryDependencyService) waitForRegistryReady(ctx context.Context, registry models.Registry, timeout time.Duration) error {
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -123,7 +246,7 @@ func (s *RegistryDependencyService) waitForRegistryReady(ctx context.Context, re
 			testPod := &corev1.Pod{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testPodName,
-					Namespace: "build-and-deploy",
+					Namespace: utils.GetJobNamespace(),
 					Labels: map[string]string{
 						"app": "pendeploy-test",
 					},
@@ -145,7 +268,7 @@ func (s *RegistryDependencyService) waitForRegistryReady(ctx context.Context, re
 			utils.SecurePodSpec(&testPod.Spec)
 
 			// Create test pod
-			_, err := s.kubeClient.Clientset.CoreV1().Pods("build-and-deploy").Create(ctx, testPod, metav1.CreateOptions{})
+			_, err := s.kubeClient.Clientset.CoreV1().Pods(utils.GetJobNamespace()).Create(ctx, testPod, metav1.CreateOptions{})
 			if err != nil {
 				log.Printf("Failed to create test pod: %v", err)
 				continue
@@ -155,16 +278,16 @@ func (s *RegistryDependencyService) waitForRegistryReady(ctx context.Context, re
 			time.Sleep(6 * time.Second)
 
 			// Check if test passed
-			pod, err := s.kubeClient.Clientset.CoreV1().Pods("build-and-deploy").Get(ctx, testPodName, metav1.GetOptions{})
+			pod, err := s.kubeClient.Clientset.CoreV1().Pods(utils.GetJobNamespace()).Get(ctx, testPodName, metav1.GetOptions{})
 			if err == nil && pod.Status.Phase == corev1.PodSucceeded {
 				// Cleanup test pod
-				s.kubeClient.Clientset.CoreV1().Pods("build-and-deploy").Delete(ctx, testPodName, metav1.DeleteOptions{})
+				s.kubeClient.Clientset.CoreV1().Pods(utils.GetJobNamespace()).Delete(ctx, testPodName, metav1.DeleteOptions{})
 				log.Printf("Registry connectivity test passed")
 				return nil
 			}
 
 			// Cleanup failed test pod
-			s.kubeClient.Clientset.CoreV1().Pods("build-and-deploy").Delete(ctx, testPodName, metav1.DeleteOptions{})
+			s.kubeClient.Clientset.CoreV1().Pods(utils.GetJobNamespace()).Delete(ctx, testPodName, metav1.DeleteOptions{})
 			log.Printf("Registry not ready yet, retrying...")
 		}
 	}
@@ -172,7 +295,7 @@ func (s *RegistryDependencyService) waitForRegistryReady(ctx context.Context, re
 
 // buildImageWithKaniko builds and pushes ALL images using Kaniko
 func (s *RegistryDependencyService) buildImageWithKaniko(ctx context.Context, registry models.Registry, img DependencyImage) error {
-	log.Printf("Building image with Kaniko: %s -> %s/%s", img.SourceImage, registry.URL, img.TargetTag)
+	log.Printf("Building image with Kaniko: %s -> %s/%s", utils.PinnedImageReference(img.SourceImage, img.Digest), registry.URL, img.TargetTag)
 
 	// Generate unique job name
 	jobName := fmt.Sprintf("build-%s-%s", img.Name, utils.GenerateShortID())
@@ -184,32 +307,39 @@ func (s *RegistryDependencyService) buildImageWithKaniko(ctx context.Context, re
 	}
 
 	// Submit job to Kubernetes
-	_, err = s.kubeClient.Clientset.BatchV1().Jobs("build-and-deploy").Create(ctx, job, metav1.CreateOptions{})
+	_, err = s.kubeClient.Clientset.BatchV1().Jobs(utils.GetJobNamespace()).Create(ctx, job, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to submit Kaniko job: %v", err)
 	}
 
-	log.Printf("Kaniko build job %s submitted successfully", jobName)
+	log.Printf("Kaniko build job %s submitted, waiting for completion", jobName)
+	if err := utils.WaitForJobCompletion(s.kubeClient, jobName, utils.GetJobNamespace(), "kaniko-builder", dependencyBuildTimeout); err != nil {
+		return err
+	}
+
+	log.Printf("Kaniko build job %s completed", jobName)
 	return nil
 }
 
 // createRegistryDependencyKanikoJob creates a Kubernetes job for building ALL images with Kaniko
 func (s *RegistryDependencyService) createRegistryDependencyKanikoJob(registry models.Registry, img DependencyImage, jobName string) (*batchv1.Job, error) {
-	// Generate dockerfile content based on image type
+	// Generate dockerfile content based on image type; a pinned source is pulled by digest,
+	// so the build fails instead of using anything else
 	var dockerfileContent string
+	source := utils.PinnedImageReference(img.SourceImage, img.Digest)
 
 	switch img.Name {
 	case "alpine-git":
 		// Simple retagging - just use the base image
 		dockerfileContent = fmt.Sprintf(`FROM %s
 # Alpine Git image ready for use
-WORKDIR /workspace`, img.SourceImage)
+WORKDIR /workspace`, source)
 
 	case "kaniko-executor":
 		// Simple retagging - just use the base image
 		dockerfileContent = fmt.Sprintf(`FROM %s
 # Kaniko executor ready for use
-WORKDIR /workspace`, img.SourceImage)
+WORKDIR /workspace`, source)
 
 	default:
 		return nil, fmt.Errorf("unknown image: %s", img.Name)
@@ -221,7 +351,7 @@ WORKDIR /workspace`, img.SourceImage)
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      jobName,
-			Namespace: "build-and-deploy",
+			Namespace: utils.GetJobNamespace(),
 			Labels: map[string]string{
 				"app":         "pendeploy",
 				"job-type":    "kaniko-build",
@@ -283,7 +413,7 @@ WORKDIR /workspace`, img.SourceImage)
 								"--cleanup",
 								"--verbosity=info",
 								"--force",
-							}, utils.KanikoRegistryArgs(registry.URL)...),
+							}, append(utils.KanikoRegistryArgs(registry.URL), utils.KanikoMirrorArgs()...)...),
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "workspace", MountPath: "/workspace"},
 							},
@@ -324,26 +454,186 @@ WORKDIR /workspace`, img.SourceImage)
 	}
 
 	utils.SecurePodSpec(&job.Spec.Template.Spec)
+	utils.ApplyBuildNodePlacement(&job.Spec.Template.Spec)
 	return job, nil
 }
 
-// ValidateDependencies checks if all required images are available in the registry
+// ValidateDependencies checks the registry catalog and tag lists for every required
+// image and returns the names of the missing ones
 func (s *RegistryDependencyService) ValidateDependencies(ctx context.Context, registry models.Registry) ([]string, error) {
-	var missingImages []string
+	missingImages := []string{}
 
-	// Get required images
-	images := s.GetRequiredImages()
+	api, err := utils.NewRegistryAPIFromRegistry(registry.URL)
+	if err != nil {
+		return nil, err
+	}
 
-	// Check each image (this would typically involve registry API calls)
-	for _, img := range images {
-		// For now, we'll assume they need to be checked via registry API
-		// TODO: Implement actual registry API validation
-		log.Printf("Would validate image: %s/%s", registry.URL, img.TargetTag)
+	repositories, err := utils.GetRepositories(ctx, api)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]bool, len(repositories))
+	for _, repository := range repositories {
+		catalog[repository] = true
+	}
+
+	for _, img := range s.GetRequiredImages() {
+		repository, tag := splitDependencyTag(img.TargetTag)
+		// The catalog's first page holds 100 repositories; past that ask for the tags directly
+		if !catalog[repository] && len(repositories) < 100 {
+			missingImages = append(missingImages, img.Name)
+			continue
+		}
+
+		tags, err := utils.GetTags(ctx, api, repository)
+		if err != nil {
+			if catalog[repository] {
+				return nil, err
+			}
+			missingImages = append(missingImages, img.Name)
+			continue
+		}
+		found := false
+		for _, t := range tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missingImages = append(missingImages, img.Name)
+		}
 	}
 
 	return missingImages, nil
 }
 
+// DependencyStatuses reports availability and the most recent retained build job of
+// every required image
+func (s *RegistryDependencyService) DependencyStatuses(ctx context.Context, registry models.Registry) (dto.RegistryDependenciesResponse, error) {
+	response := dto.RegistryDependenciesResponse{Dependencies: []dto.RegistryDependencyStatus{}}
+
+	missing, err := s.ValidateDependencies(ctx, registry)
+	if err != nil {
+		return response, err
+	}
+	response.Missing = missing
+	isMissing := make(map[string]bool, len(missing))
+	for _, name := range missing {
+		isMissing[name] = true
+	}
+
+	latestJobs := map[string]batchv1.Job{}
+	if s.kubeClient != nil {
+		jobs, err := s.kubeClient.Clientset.BatchV1().Jobs(utils.GetJobNamespace()).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("registry-id=%s,job-type=kaniko-build", registry.ID),
+		})
+		if err != nil {
+			return response, fmt.Errorf("failed to list dependency jobs: %v", err)
+		}
+		for _, job := range jobs.Items {
+			name := job.Labels["image-name"]
+			if latest, ok := latestJobs[name]; !ok || latest.CreationTimestamp.Before(&job.CreationTimestamp) {
+				latestJobs[name] = job
+			}
+		}
+	}
+
+	built, err := s.BuiltImages(registry.ID)
+	if err != nil {
+		return response, err
+	}
+
+	for _, img := range s.GetRequiredImages() {
+		status := dto.RegistryDependencyStatus{
+			Name:        img.Name,
+			SourceImage: img.SourceImage,
+			Digest:      img.Digest,
+			TargetImage: fmt.Sprintf("%s/%s", utils.CleanRegistryURL(registry.URL), img.TargetTag),
+			Description: img.Description,
+			Available:   !isMissing[img.Name],
+			Outdated:    !isMissing[img.Name] && IsDependencyOutdated(img, built),
+		}
+		if builtImage, ok := built[img.Name]; ok {
+			status.BuiltDigest = builtImage.Digest
+		}
+		if job, ok := latestJobs[img.Name]; ok {
+			status.LastBuildJob = job.Name
+			status.LastBuild = dependencyJobState(job)
+		}
+		response.Dependencies = append(response.Dependencies, status)
+	}
+
+	return response, nil
+}
+
+// BuiltImages returns what each dependency image of a registry was last built from, by name
+func (s *RegistryDependencyService) BuiltImages(registryID string) (map[string]models.RegistryDependencyImage, error) {
+	images, err := s.depRepo.FindBuiltByRegistryID(registryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load built dependency images: %v", err)
+	}
+	built := make(map[string]models.RegistryDependencyImage, len(images))
+	for _, image := range images {
+		built[image.Name] = image
+	}
+	return built, nil
+}
+
+// IsDependencyOutdated reports whether a registry's copy of a pinned dependency image was not
+// built from the pin, including copies built before the image was pinned
+func IsDependencyOutdated(img DependencyImage, built map[string]models.RegistryDependencyImage) bool {
+	if img.Digest == "" {
+		return false
+	}
+	builtImage, ok := built[img.Name]
+	return !ok || builtImage.Digest != img.Digest
+}
+
+// HasRunningBuilds reports whether a dependency build for the registry is in progress
+func (s *RegistryDependencyService) HasRunningBuilds(ctx context.Context, registryID string) (bool, error) {
+	if s.kubeClient == nil {
+		return false, fmt.Errorf("kubernetes client not initialized")
+	}
+
+	jobs, err := s.kubeClient.Clientset.BatchV1().Jobs(utils.GetJobNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("registry-id=%s,job-type=kaniko-build", registryID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list dependency jobs: %v", err)
+	}
+	for _, job := range jobs.Items {
+		if dependencyJobState(job) == "running" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dependencyJobState maps job conditions onto running, succeeded or failed
+func dependencyJobState(job batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "succeeded"
+		case batchv1.JobFailed:
+			return "failed"
+		}
+	}
+	return "running"
+}
+
+// splitDependencyTag splits a target tag such as alpine-git:2.43.0 into repository and tag
+func splitDependencyTag(targetTag string) (string, string) {
+	if colon := strings.LastIndex(targetTag, ":"); colon >= 0 {
+		return targetTag[:colon], targetTag[colon+1:]
+	}
+	return targetTag, "latest"
+}
+
 // CleanupDependencyJobs removes old dependency setup jobs
 func (s *RegistryDependencyService) CleanupDependencyJobs(ctx context.Context, registryID string, olderThan time.Duration) error {
 	if s.kubeClient == nil {
@@ -351,7 +641,7 @@ func (s *RegistryDependencyService) CleanupDependencyJobs(ctx context.Context, r
 	}
 
 	// List jobs related to this registry
-	jobs, err := s.kubeClient.Clientset.BatchV1().Jobs("build-and-deploy").List(ctx, metav1.ListOptions{
+	jobs, err := s.kubeClient.Clientset.BatchV1().Jobs(utils.GetJobNamespace()).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("registry-id=%s", registryID),
 	})
 	if err != nil {
@@ -364,7 +654,7 @@ func (s *RegistryDependencyService) CleanupDependencyJobs(ctx context.Context, r
 		if job.CreationTimestamp.Before(&metav1.Time{Time: cutoffTime}) {
 			log.Printf("Cleaning up old dependency job: %s", job.Name)
 
-			err := s.kubeClient.Clientset.BatchV1().Jobs("build-and-deploy").Delete(
+			err := s.kubeClient.Clientset.BatchV1().Jobs(utils.GetJobNamespace()).Delete(
 				ctx, job.Name, metav1.DeleteOptions{},
 			)
 			if err != nil && !errors.IsNotFound(err) {
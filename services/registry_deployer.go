@@ -13,11 +13,11 @@ import (
 
 // RegistryDeployer handles Kubernetes operations for deploying registries
 type RegistryDeployer struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 }
 
 // NewRegistryDeployer creates a new registry deployer instance
-func NewRegistryDeployer(clientset *kubernetes.Clientset) *RegistryDeployer {
+func NewRegistryDeployer(clientset kubernetes.Interface) *RegistryDeployer {
 	return &RegistryDeployer{
 		clientset: clientset,
 	}
@@ -44,12 +44,17 @@ func (d *RegistryDeployer) DeployRegistry(ctx context.Context, registry models.R
 		return "", "", fmt.Errorf("failed to create service: %w", err)
 	}
 
+	// Configuration and proxy credentials must exist before the pod mounts them
+	if err := d.applyRegistryConfig(ctx, registry); err != nil {
+		return "", "", err
+	}
+
 	// Create Deployment
 	if err := utils.CreateRegistryDeployment(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
 		return "", "", fmt.Errorf("failed to create deployment: %w", err)
 	}
 
-	if err := utils.CreateRegistryIngress(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+	if err := utils.CreateRegistryIngress(ctx, utils.RegistryNamespace, registry); err != nil {
 		return "", "", fmt.Errorf("failed to create ingress: %w", err)
 	}
 
@@ -70,6 +75,20 @@ func (d *RegistryDeployer) DeployRegistry(ctx context.Context, registry models.R
 
 // UpdateRegistry updates a registry in Kubernetes
 func (d *RegistryDeployer) UpdateRegistry(ctx context.Context, registry models.Registry) error {
+	if err := d.applyRegistryConfig(ctx, registry); err != nil {
+		return err
+	}
+
+	// Expands the volume online when the requested size grew
+	if err := utils.CreatePVC(ctx, registry, utils.RegistryNamespace, d.clientset); err != nil {
+		return fmt.Errorf("failed to resize persistent volume claim: %w", err)
+	}
+
+	// Reapply the spec so registries created before config mounts pick them up
+	if err := utils.CreateRegistryDeployment(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+		return fmt.Errorf("failed to apply deployment: %w", err)
+	}
+
 	// Update Deployment (will trigger a rolling update)
 	if err := utils.UpdateDeployment(ctx, registry, d.clientset, utils.RegistryNamespace); err != nil {
 		return fmt.Errorf("failed to update deployment: %w", err)
@@ -79,13 +98,27 @@ func (d *RegistryDeployer) UpdateRegistry(ctx context.Context, registry models.R
 		return fmt.Errorf("failed to update service: %w", err)
 	}
 
-	if err := utils.CreateRegistryIngress(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+	if err := utils.CreateRegistryIngress(ctx, utils.RegistryNamespace, registry); err != nil {
 		return fmt.Errorf("failed to update ingress: %w", err)
 	}
 
 	return nil
 }
 
+// applyRegistryConfig writes the registry ConfigMap and the proxy and storage credentials Secrets
+func (d *RegistryDeployer) applyRegistryConfig(ctx context.Context, registry models.Registry) error {
+	if err := utils.CreateRegistryConfigMap(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+		return fmt.Errorf("failed to create config map: %w", err)
+	}
+	if err := utils.CreateRegistryProxySecret(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+		return fmt.Errorf("failed to create proxy secret: %w", err)
+	}
+	if err := utils.CreateRegistryStorageSecret(ctx, utils.RegistryNamespace, registry, d.clientset); err != nil {
+		return fmt.Errorf("failed to create storage secret: %w", err)
+	}
+	return nil
+}
+
 // DeleteRegistry deletes a registry from Kubernetes
 func (d *RegistryDeployer) DeleteRegistry(ctx context.Context, registryID string) error {
 	resourceName := utils.GetRegistryResourceName(registryID)
@@ -141,6 +174,27 @@ func (d *RegistryDeployer) DeleteRegistry(ctx context.Context, registryID string
 	} else {
 		fmt.Printf("Successfully deleted ingress %s\n", resourceName)
 	}
+	if err := utils.DeleteGatewayRouting(ctx, utils.RegistryNamespace, resourceName); err != nil {
+		errs = append(errs, fmt.Sprintf("Error deleting gateway routes for registry %s: %v", registryID, err))
+	}
+
+	if err := d.clientset.CoreV1().ConfigMaps(utils.RegistryNamespace).Delete(ctx, utils.GetRegistryConfigMapName(registryID), metav1.DeleteOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("Error deleting config map for registry %s: %v", registryID, err))
+		}
+	}
+
+	if err := d.clientset.CoreV1().Secrets(utils.RegistryNamespace).Delete(ctx, utils.GetRegistryProxySecretName(registryID), metav1.DeleteOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("Error deleting proxy secret for registry %s: %v", registryID, err))
+		}
+	}
+
+	if err := d.clientset.CoreV1().Secrets(utils.RegistryNamespace).Delete(ctx, utils.GetRegistryStorageSecretName(registryID), metav1.DeleteOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("Error deleting storage secret for registry %s: %v", registryID, err))
+		}
+	}
 
 	// Delete PVC
 	if err := d.clientset.CoreV1().PersistentVolumeClaims(utils.RegistryNamespace).Delete(ctx, resourceName, metav1.DeleteOptions{}); err != nil {
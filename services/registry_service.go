@@ -29,6 +29,24 @@ const (
 	registryTimeout   = 10 * time.Minute
 )
 
+// ErrProxyRegistryDefault is returned when a pull-through cache would become the build push target
+var ErrProxyRegistryDefault = errors.New("a proxy registry cannot be the default registry")
+
+var (
+	// ErrRegistryBusy is returned while a registry is being deployed or reconfigured
+	ErrRegistryBusy = errors.New("registry is being updated, try again when it is ready")
+	// ErrStorageMigrationUnsupported is returned for migrations other than volume to S3
+	ErrStorageMigrationUnsupported = errors.New("blobs can only be migrated from the registry volume to S3")
+	// ErrDependencyBuildRunning is returned when dependency images are already being built
+	ErrDependencyBuildRunning = errors.New("dependency images are already being built for this registry")
+	// ErrRegistryReplicasNeedS3 is returned when scaling a registry whose volume is ReadWriteOnce
+	ErrRegistryReplicasNeedS3 = errors.New("registries need the S3 storage backend to run more than one replica")
+	// ErrRegistryVolumeResize is returned when the registry volume cannot be resized as requested
+	ErrRegistryVolumeResize = errors.New("registry volume cannot be resized")
+	// ErrProxyRegistryPush is returned for operations that push into a read-only pull-through cache
+	ErrProxyRegistryPush = errors.New("proxy registries are read-only and cannot hold dependency images")
+)
+
 // RegistryService handles business logic for registries
 type RegistryService struct {
 	registryRepo *repositories.RegistryRepository
@@ -193,12 +211,25 @@ func (s *RegistryService) GetRegistryByID(id string) (dto.RegistryResponse, erro
 func (s *RegistryService) CreateRegistry(req dto.CreateRegistryRequest) (dto.RegistryResponse, error) {
 	// Create registry model
 	registry := models.Registry{
-		Name:      req.Name,
-		IsDefault: req.IsDefault,
-		IsActive:  true,
-		Status:    models.RegistryStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Name:           req.Name,
+		IsDefault:      req.IsDefault,
+		IsActive:       true,
+		Status:         models.RegistryStatusPending,
+		Mode:           models.RegistryModeStandard,
+		StorageBackend: models.RegistryStorageFilesystem,
+		Replicas:       1,
+		StorageSize:    req.StorageSize,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if req.Mode == models.RegistryModeProxy {
+		if req.IsDefault {
+			return dto.RegistryResponse{}, ErrProxyRegistryDefault
+		}
+		registry.Mode = models.RegistryModeProxy
+		registry.ProxyRemoteURL = req.ProxyRemoteURL
+		registry.ProxyUsername = req.ProxyUsername
+		registry.ProxyPassword = req.ProxyPassword
 	}
 
 	// Save to database
@@ -224,6 +255,30 @@ func (s *RegistryService) UpdateRegistry(id string, req dto.UpdateRegistryReques
 	if req.Name != "" {
 		registry.Name = req.Name
 	}
+	if registry.Mode == models.RegistryModeProxy {
+		if req.IsDefault {
+			return dto.RegistryResponse{}, ErrProxyRegistryDefault
+		}
+		if req.ProxyRemoteURL != "" {
+			registry.ProxyRemoteURL = req.ProxyRemoteURL
+		}
+		if req.ProxyUsername != "" {
+			registry.ProxyUsername = req.ProxyUsername
+			registry.ProxyPassword = req.ProxyPassword
+		}
+	}
+	if req.Replicas > 0 {
+		if req.Replicas > 1 && registry.StorageBackend != models.RegistryStorageS3 {
+			return dto.RegistryResponse{}, ErrRegistryReplicasNeedS3
+		}
+		registry.Replicas = req.Replicas
+	}
+	if req.StorageSize != "" && req.StorageSize != utils.GetRegistryStorageSize(registry) {
+		if err := s.checkVolumeResize(registry, req.StorageSize); err != nil {
+			return dto.RegistryResponse{}, err
+		}
+		registry.StorageSize = req.StorageSize
+	}
 	registry.IsDefault = req.IsDefault
 	registry.UpdatedAt = time.Now()
 
@@ -238,6 +293,142 @@ func (s *RegistryService) UpdateRegistry(id string, req dto.UpdateRegistryReques
 	return convertRegistryToResponse(registry), nil
 }
 
+// UpdateStorage switches the storage backend of a registry. For a managed MinIO the
+// bucket and its scoped credentials are created first; with Migrate the blobs on the
+// registry volume are copied into the bucket before the registry is reconfigured.
+// The volume is kept until the registry is deleted.
+func (s *RegistryService) UpdateStorage(id string, req dto.RegistryStorageRequest, userID string, isAdmin bool) (dto.RegistryResponse, error) {
+	registry, err := s.registryRepo.FindByID(id)
+	if err != nil {
+		return dto.RegistryResponse{}, err
+	}
+	if registry.Status == models.RegistryStatusBuilding || registry.Status == models.RegistryStatusPending {
+		return dto.RegistryResponse{}, ErrRegistryBusy
+	}
+	if req.Migrate && (req.Backend != models.RegistryStorageS3 || registry.StorageBackend == models.RegistryStorageS3) {
+		return dto.RegistryResponse{}, ErrStorageMigrationUnsupported
+	}
+	if req.Backend != models.RegistryStorageS3 && registry.Replicas > 1 {
+		return dto.RegistryResponse{}, ErrRegistryReplicasNeedS3
+	}
+
+	target := registry
+	target.StorageBackend = req.Backend
+	target.StorageServiceID = ""
+	target.S3Endpoint = ""
+	target.S3Region = ""
+	target.S3Bucket = ""
+	target.S3AccessKey = ""
+	target.S3SecretKey = ""
+
+	var minioService *models.Service
+	if req.Backend == models.RegistryStorageS3 {
+		target.S3Bucket = req.Bucket
+		target.S3Region = req.Region
+		if req.ServiceID != "" {
+			service, err := NewMinIOService().getMinIOService(req.ServiceID, userID, isAdmin)
+			if err != nil {
+				return dto.RegistryResponse{}, err
+			}
+			minioService = &service
+			target.StorageServiceID = service.ID
+			target.S3Endpoint = fmt.Sprintf("http://%s", service.EnvVars["MINIO_ENDPOINT"])
+			if target.S3Bucket == "" {
+				target.S3Bucket = utils.GetRegistryBucketName(registry.ID)
+			}
+		} else {
+			target.S3Endpoint = req.Endpoint
+			target.S3AccessKey = req.AccessKey
+			target.S3SecretKey = req.SecretKey
+		}
+	}
+
+	s.updateRegistryStatus(registry.ID, models.RegistryStatusBuilding, fmt.Sprintf("Switching storage to %s", req.Backend))
+	go s.applyRegistryStorage(target, minioService, req.Migrate)
+
+	registry.Status = models.RegistryStatusBuilding
+	return convertRegistryToResponse(registry), nil
+}
+
+// applyRegistryStorage provisions the bucket, migrates blobs when asked and rolls the
+// registry onto the new storage configuration
+func (s *RegistryService) applyRegistryStorage(target models.Registry, minioService *models.Service, migrate bool) {
+	if s.kubeClient == nil {
+		s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, "Kubernetes client is not initialized")
+		return
+	}
+
+	if minioService != nil {
+		if err := utils.CreateMinIOBucket(*minioService, dto.MinIOBucketRequest{Name: target.S3Bucket, Policy: "none"}); err != nil {
+			s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to create bucket: %v", err))
+			return
+		}
+		credentials, err := utils.CreateMinIOBucketCredentials(*minioService, target.S3Bucket)
+		if err != nil {
+			s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to create bucket credentials: %v", err))
+			return
+		}
+		target.S3AccessKey = credentials.AccessKey
+		target.S3SecretKey = credentials.SecretKey
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	if migrate {
+		// The migration Job reads the target bucket from the storage Secret while the
+		// registry keeps serving from its volume
+		if err := utils.CreateRegistryStorageSecret(ctx, utils.RegistryNamespace, target, s.kubeClient.Clientset); err != nil {
+			s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to create storage secret: %v", err))
+			return
+		}
+		if err := utils.MigrateRegistryStorage(target); err != nil {
+			s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to migrate blobs: %v", err))
+			return
+		}
+	}
+
+	registry, err := s.registryRepo.FindByID(target.ID)
+	if err != nil {
+		s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to get registry: %v", err))
+		return
+	}
+	registry.StorageBackend = target.StorageBackend
+	registry.StorageServiceID = target.StorageServiceID
+	registry.S3Endpoint = target.S3Endpoint
+	registry.S3Region = target.S3Region
+	registry.S3Bucket = target.S3Bucket
+	registry.S3AccessKey = target.S3AccessKey
+	registry.S3SecretKey = target.S3SecretKey
+	registry.UpdatedAt = time.Now()
+	if err := s.registryRepo.Update(registry); err != nil {
+		s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to save storage settings: %v", err))
+		return
+	}
+
+	if err := NewRegistryDeployer(s.kubeClient.Clientset).UpdateRegistry(ctx, registry); err != nil {
+		s.updateRegistryStatus(target.ID, models.RegistryStatusFailed, fmt.Sprintf("Failed to apply storage settings: %v", err))
+		return
+	}
+
+	s.updateRegistryStatus(target.ID, models.RegistryStatusReady, fmt.Sprintf("Storage switched to %s", registry.StorageBackend))
+}
+
+// checkVolumeResize verifies the registry volume can be expanded online to size
+func (s *RegistryService) checkVolumeResize(registry models.Registry, size string) error {
+	if s.kubeClient == nil {
+		return fmt.Errorf("kubernetes client is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := utils.CheckRegistryVolumeExpansion(ctx, utils.RegistryNamespace, registry, size, s.kubeClient.Clientset); err != nil {
+		return fmt.Errorf("%w: %v", ErrRegistryVolumeResize, err)
+	}
+	return nil
+}
+
 // DeleteRegistry removes a registry
 func (s *RegistryService) DeleteRegistry(id string) error {
 	// Check if registry exists
@@ -256,7 +447,38 @@ func (s *RegistryService) DeleteRegistry(id string) error {
 	}
 
 	// Only delete from database if Kubernetes deletion succeeded
-	return s.registryRepo.Delete(id)
+	if err := s.registryRepo.Delete(id); err != nil {
+		return err
+	}
+
+	if registry.Mode == models.RegistryModeProxy {
+		s.SyncRegistryMirror()
+	}
+	return nil
+}
+
+// SyncRegistryMirror points build jobs and managed service images at the ready
+// Docker Hub pull-through cache, or back at Docker Hub when there is none
+func (s *RegistryService) SyncRegistryMirror() {
+	registry, err := s.registryRepo.FindReadyProxy()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Warning: failed to look up proxy registry: %v", err)
+		}
+		utils.SetRegistryMirror("")
+		return
+	}
+
+	if !utils.IsDockerHubRemote(registry) {
+		log.Printf("Proxy registry %s mirrors %s, not Docker Hub; skipping mirror setup", registry.ID, utils.GetRegistryProxyRemoteURL(registry))
+		utils.SetRegistryMirror("")
+		return
+	}
+
+	if utils.GetRegistryMirror() != utils.CleanRegistryURL(registry.URL) {
+		log.Printf("Using proxy registry %s as Docker Hub mirror", registry.URL)
+	}
+	utils.SetRegistryMirror(registry.URL)
 }
 
 // GetRegistryDetails retrieves detailed registry information including Kubernetes data
@@ -341,6 +563,49 @@ func (s *RegistryService) GetRegistryDetails(id string) (dto.RegistryDetailsResp
 	return response, nil
 }
 
+// CopyImage copies an image with all its layers from one registry to another, e.g. to
+// promote a staging image to the production registry. Progress is reported per blob.
+func (s *RegistryService) CopyImage(ctx context.Context, sourceID string, req dto.RegistryCopyRequest, progress func(dto.RegistryCopyProgress)) (dto.RegistryCopyResult, error) {
+	if req.TargetRepository == "" {
+		req.TargetRepository = req.Repository
+	}
+	if req.TargetTag == "" {
+		req.TargetTag = req.Tag
+	}
+	if sourceID == req.TargetRegistryID && req.Repository == req.TargetRepository && req.Tag == req.TargetTag {
+		return dto.RegistryCopyResult{}, errors.New("source and target image are the same")
+	}
+
+	source, err := s.readyRegistryAPI(sourceID)
+	if err != nil {
+		return dto.RegistryCopyResult{}, err
+	}
+	target, err := s.readyRegistryAPI(req.TargetRegistryID)
+	if err != nil {
+		return dto.RegistryCopyResult{}, err
+	}
+
+	result, err := utils.CopyRegistryImage(ctx, source, target, req, progress)
+	if err != nil {
+		return result, err
+	}
+	log.Printf("Copied image %s from registry %s to %s in registry %s (%d blobs copied, %d skipped, %d bytes)",
+		result.Source, sourceID, result.Target, req.TargetRegistryID, result.BlobsCopied, result.BlobsSkipped, result.BytesCopied)
+	return result, nil
+}
+
+// readyRegistryAPI returns an API client for a registry that is deployed and ready
+func (s *RegistryService) readyRegistryAPI(id string) (*dto.RegistryAPI, error) {
+	registry, err := s.registryRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("registry %s not found", id)
+	}
+	if registry.Status != models.RegistryStatusReady || registry.URL == "" {
+		return nil, fmt.Errorf("registry %s is not ready (status: %s)", registry.Name, registry.Status)
+	}
+	return utils.NewRegistryAPIFromRegistry(registry.URL)
+}
+
 // SetupRegistryDependencies manually sets up dependencies for an existing registry
 func (s *RegistryService) SetupRegistryDependencies(registryID string) error {
 	if s.kubeClient == nil {
@@ -353,8 +618,8 @@ func (s *RegistryService) SetupRegistryDependencies(registryID string) error {
 		return fmt.Errorf("failed to get registry: %v", err)
 	}
 
-	// Setup dependencies
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	// Setup dependencies; builds now run to completion one after another
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(len(s.depService.GetRequiredImages())+1)*dependencyBuildTimeout)
 	defer cancel()
 
 	log.Printf("Manually setting up dependencies for registry %s", registryID)
@@ -376,6 +641,78 @@ func (s *RegistryService) ValidateDependencies(registryID string) ([]string, err
 	return s.depService.ValidateDependencies(ctx, registry)
 }
 
+// GetDependencies reports which build system images are present in a registry
+func (s *RegistryService) GetDependencies(registryID string) (dto.RegistryDependenciesResponse, error) {
+	registry, err := s.dependencyRegistry(registryID)
+	if err != nil {
+		return dto.RegistryDependenciesResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return s.depService.DependencyStatuses(ctx, registry)
+}
+
+// RerunDependencies rebuilds the dependency images missing from a registry, usually
+// after a failed build. Builds run in the background; the names being rebuilt are returned.
+func (s *RegistryService) RerunDependencies(registryID string) (dto.RegistryDependencyRerunResponse, error) {
+	response := dto.RegistryDependencyRerunResponse{Rebuilding: []string{}}
+
+	registry, err := s.dependencyRegistry(registryID)
+	if err != nil {
+		return response, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	running, err := s.depService.HasRunningBuilds(ctx, registry.ID)
+	if err != nil {
+		return response, err
+	}
+	if running {
+		return response, ErrDependencyBuildRunning
+	}
+
+	missing, err := s.depService.ValidateDependencies(ctx, registry)
+	if err != nil {
+		return response, fmt.Errorf("failed to check dependencies: %v", err)
+	}
+	if len(missing) == 0 {
+		return response, nil
+	}
+	response.Rebuilding = missing
+
+	go func() {
+		buildCtx, cancel := context.WithTimeout(context.Background(), time.Duration(len(missing))*dependencyBuildTimeout)
+		defer cancel()
+
+		if err := s.depService.RebuildDependencies(buildCtx, registry, missing); err != nil {
+			log.Printf("Rebuilding dependencies for registry %s failed: %v", registry.ID, err)
+			return
+		}
+		log.Printf("Rebuilt dependencies %s for registry %s", strings.Join(missing, ", "), registry.ID)
+	}()
+
+	return response, nil
+}
+
+// dependencyRegistry loads a ready registry that can hold dependency images
+func (s *RegistryService) dependencyRegistry(registryID string) (models.Registry, error) {
+	registry, err := s.registryRepo.FindByID(registryID)
+	if err != nil {
+		return registry, err
+	}
+	if registry.Mode == models.RegistryModeProxy {
+		return registry, ErrProxyRegistryPush
+	}
+	if registry.Status != models.RegistryStatusReady || registry.URL == "" {
+		return registry, ErrRegistryBusy
+	}
+	return registry, nil
+}
+
 // StreamRegistryBuildLogs streams build logs from a registry deployment pod
 func (s *RegistryService) StreamRegistryBuildLogs(ctx context.Context, registryID string, w io.Writer) error {
 	log.Println("Starting StreamRegistryBuildLogs for registry ID:", registryID)
@@ -567,6 +904,9 @@ func (s *RegistryService) deployRegistryInKubernetes(registryID string) {
 	time.Sleep(10 * time.Second)
 
 	s.updateRegistryStatus(registryID, models.RegistryStatusReady, "Registry ready")
+	if registry.Mode == models.RegistryModeProxy {
+		s.SyncRegistryMirror()
+	}
 }
 
 // updateRegistryInKubernetes updates registry configuration in Kubernetes
@@ -606,6 +946,9 @@ func (s *RegistryService) updateRegistryInKubernetes(registryID string) {
 
 	// Update status to ready
 	s.updateRegistryStatus(registryID, models.RegistryStatusReady, "")
+	if registry.Mode == models.RegistryModeProxy {
+		s.SyncRegistryMirror()
+	}
 }
 
 // deleteRegistryFromKubernetes deletes registry resources from Kubernetes
@@ -681,13 +1024,20 @@ func (s *RegistryService) updateRegistryStatus(id string, status models.Registry
 // convertRegistryToResponse converts a registry model to a DTO response
 func convertRegistryToResponse(registry models.Registry) dto.RegistryResponse {
 	return dto.RegistryResponse{
-		ID:        registry.ID,
-		Name:      registry.Name,
-		URL:       registry.URL,
-		IsDefault: registry.IsDefault,
-		IsActive:  registry.IsActive,
-		Status:    registry.Status,
-		CreatedAt: registry.CreatedAt,
-		UpdatedAt: registry.UpdatedAt,
+		ID:             registry.ID,
+		Name:           registry.Name,
+		URL:            registry.URL,
+		IsDefault:      registry.IsDefault,
+		IsActive:       registry.IsActive,
+		Status:         registry.Status,
+		Mode:           registry.Mode,
+		ProxyRemoteURL: registry.ProxyRemoteURL,
+		StorageBackend: registry.StorageBackend,
+		S3Endpoint:     registry.S3Endpoint,
+		S3Bucket:       registry.S3Bucket,
+		Replicas:       int(utils.GetRegistryReplicas(registry)),
+		StorageSize:    utils.GetRegistryStorageSize(registry),
+		CreatedAt:      registry.CreatedAt,
+		UpdatedAt:      registry.UpdatedAt,
 	}
 }
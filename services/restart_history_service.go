@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	// DefaultRestartLogLines is how many lines of each previous container instance are returned
+	DefaultRestartLogLines = 100
+	// MaxRestartLogLines bounds the lines a client may request per previous container instance
+	MaxRestartLogLines = 1000
+)
+
+// RestartHistoryService reports container restarts of a service's pods with the logs of the
+// crashed instances
+type RestartHistoryService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewRestartHistoryService creates a new restart history service instance
+func NewRestartHistoryService() *RestartHistoryService {
+	return &RestartHistoryService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// GetRestartHistory returns the restart history of a service's pods with up to tailLines
+// lines of each previous container instance
+func (s *RestartHistoryService) GetRestartHistory(serviceID string, tailLines int, userID string, isAdmin bool) (dto.ServiceRestartHistory, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceRestartHistory{}, err
+	}
+	if tailLines <= 0 || tailLines > MaxRestartLogLines {
+		return dto.ServiceRestartHistory{}, errors.New("tailLines must be between 1 and 1000")
+	}
+	return utils.GetRestartHistory(service, int64(tailLines))
+}
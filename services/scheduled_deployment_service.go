@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+// maxScheduleAhead is how far in the future a deployment can be scheduled
+const maxScheduleAhead = 90 * 24 * time.Hour
+
+// ErrScheduledDeploymentNotFound is returned for unknown or no longer pending scheduled
+// deployments of a service
+var ErrScheduledDeploymentNotFound = errors.New("scheduled deployment not found or no longer pending")
+
+// scheduledLocalLayouts are the local time formats accepted alongside RFC3339
+var scheduledLocalLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// ScheduledDeploymentService schedules deployments of git services for a future time. Each
+// schedule is written together with an outbox event due at that time, and the outbox
+// dispatcher starts the deployment.
+type ScheduledDeploymentService struct {
+	scheduleRepo      *repositories.ScheduledDeploymentRepository
+	serviceRepo       *repositories.ServiceRepository
+	projectRepo       *repositories.ProjectRepository
+	deploymentRepo    *repositories.DeploymentRepository
+	outboxRepo        *repositories.OutboxRepository
+	deploymentService *DeploymentService
+}
+
+// NewScheduledDeploymentService creates a new scheduled deployment service instance
+func NewScheduledDeploymentService() *ScheduledDeploymentService {
+	return &ScheduledDeploymentService{
+		scheduleRepo:      repositories.NewScheduledDeploymentRepository(),
+		serviceRepo:       repositories.NewServiceRepository(),
+		projectRepo:       repositories.NewProjectRepository(),
+		deploymentRepo:    repositories.NewDeploymentRepository(),
+		outboxRepo:        repositories.NewOutboxRepository(),
+		deploymentService: NewDeploymentService(),
+	}
+}
+
+// Schedule records a deployment of the service at the requested time
+func (s *ScheduledDeploymentService) Schedule(serviceID string, req dto.ScheduledDeploymentRequest, userID string, isAdmin bool) (dto.ScheduledDeploymentResponse, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ScheduledDeploymentResponse{}, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return dto.ScheduledDeploymentResponse{}, errors.New("only git services can be scheduled for deployment")
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	scheduledAt, err := parseScheduledTime(req.ScheduledAt, req.Timezone)
+	if err != nil {
+		return dto.ScheduledDeploymentResponse{}, err
+	}
+	now := time.Now()
+	if !scheduledAt.After(now) {
+		return dto.ScheduledDeploymentResponse{}, errors.New("scheduledAt must be in the future")
+	}
+	if scheduledAt.After(now.Add(maxScheduleAhead)) {
+		return dto.ScheduledDeploymentResponse{}, fmt.Errorf("deployments can be scheduled at most %d days ahead", int(maxScheduleAhead.Hours()/24))
+	}
+
+	schedule := models.ScheduledDeployment{
+		ServiceID:     service.ID,
+		CommitSHA:     req.CommitSHA,
+		CommitMessage: req.CommitMessage,
+		ScheduledAt:   scheduledAt.UTC(),
+		Timezone:      req.Timezone,
+		Status:        models.ScheduledDeploymentPending,
+		CreatedBy:     userID,
+	}
+	if req.DeploymentID != "" {
+		if req.CommitSHA != "" {
+			return dto.ScheduledDeploymentResponse{}, errors.New("set either commitSha or deploymentId, not both")
+		}
+		source, err := s.deploymentRepo.FindByID(req.DeploymentID)
+		if err != nil || source.ServiceID != service.ID || source.Image == "" {
+			return dto.ScheduledDeploymentResponse{}, errors.New("deploymentId must be a deployment of this service with a built image")
+		}
+		schedule.SourceDeploymentID = &source.ID
+		schedule.CommitSHA = source.CommitSHA
+		if schedule.CommitMessage == "" {
+			schedule.CommitMessage = source.CommitMessage
+		}
+	}
+
+	err = s.scheduleRepo.DB().Transaction(func(tx *gorm.DB) error {
+		created, err := s.scheduleRepo.CreateTx(tx, schedule)
+		if err != nil {
+			return err
+		}
+		schedule = created
+
+		payload, err := json.Marshal(map[string]string{"scheduledDeploymentId": schedule.ID})
+		if err != nil {
+			return err
+		}
+		return s.outboxRepo.Enqueue(tx, models.OutboxEvent{
+			EventType:     models.OutboxEventScheduledDeployment,
+			AggregateID:   schedule.ID,
+			Payload:       string(payload),
+			NextAttemptAt: schedule.ScheduledAt,
+		})
+	})
+	if err != nil {
+		return dto.ScheduledDeploymentResponse{}, err
+	}
+	return toScheduledDeploymentResponse(schedule), nil
+}
+
+// ListScheduled returns a page of the service's scheduled deployments, optionally only
+// those in one status
+func (s *ScheduledDeploymentService) ListScheduled(serviceID string, status string, page, pageSize int, userID string, isAdmin bool) (dto.ScheduledDeploymentListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.ScheduledDeploymentListResponse{}, err
+	}
+
+	schedules, total, err := s.scheduleRepo.FindByServiceID(serviceID, status, page, pageSize)
+	if err != nil {
+		return dto.ScheduledDeploymentListResponse{}, err
+	}
+	items := make([]dto.ScheduledDeploymentResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		items = append(items, toScheduledDeploymentResponse(schedule))
+	}
+	return dto.ScheduledDeploymentListResponse{
+		Items:      items,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// Cancel cancels a pending scheduled deployment. Its outbox event still fires and is ignored.
+func (s *ScheduledDeploymentService) Cancel(serviceID string, scheduleID string, userID string, isAdmin bool) error {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return err
+	}
+	cancelled, err := s.scheduleRepo.Cancel(scheduleID, serviceID, userID)
+	if err != nil {
+		return err
+	}
+	if cancelled == 0 {
+		return ErrScheduledDeploymentNotFound
+	}
+	return nil
+}
+
+// Run starts a scheduled deployment when its outbox event comes due. Failures to start
+// the deployment are recorded on the schedule; only database errors are returned, so the
+// event is retried.
+func (s *ScheduledDeploymentService) Run(scheduleID string) error {
+	schedule, err := s.scheduleRepo.FindByID(scheduleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The service and its schedules were deleted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	claimed, err := s.scheduleRepo.Claim(scheduleID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Cancelled, or already started by an earlier delivery of the event
+		return nil
+	}
+
+	deploymentID, runErr := s.start(schedule)
+	if runErr != nil {
+		log.Printf("Scheduled deployment %s of service %s failed to start: %v", schedule.ID, schedule.ServiceID, runErr)
+	} else {
+		log.Printf("Scheduled deployment %s of service %s started deployment %s", schedule.ID, schedule.ServiceID, deploymentID)
+	}
+	return s.scheduleRepo.RecordResult(schedule.ID, deploymentID, runErr)
+}
+
+// start creates the deployment of a schedule with the rights its creator has now
+func (s *ScheduledDeploymentService) start(schedule models.ScheduledDeployment) (string, error) {
+	service, err := s.serviceRepo.FindByID(schedule.ServiceID)
+	if err != nil {
+		return "", fmt.Errorf("service not found: %v", err)
+	}
+	creator, err := GetUser(schedule.CreatedBy)
+	if err != nil {
+		return "", fmt.Errorf("the user who scheduled the deployment no longer exists")
+	}
+	byAdmin := creator.Role == models.RoleAdmin
+
+	if schedule.SourceDeploymentID != nil {
+		source, err := s.deploymentRepo.FindByID(*schedule.SourceDeploymentID)
+		if err != nil {
+			return "", fmt.Errorf("source deployment not found: %v", err)
+		}
+		deployment, err := s.deploymentService.RedeployImage(service, source, byAdmin)
+		return deployment.ID, err
+	}
+
+	commitMessage := schedule.CommitMessage
+	if commitMessage == "" {
+		commitMessage = "Scheduled deployment"
+	}
+	response, err := s.deploymentService.CreateGitDeployment(dto.GitDeployRequest{
+		ServiceID:     service.ID,
+		APIKey:        service.APIKey,
+		CommitID:      schedule.CommitSHA,
+		CommitMessage: commitMessage,
+		ByAdmin:       byAdmin,
+	})
+	return response.DeploymentID, err
+}
+
+// parseScheduledTime reads an RFC3339 time, whose offset wins over timezone, or a local
+// time in timezone
+func parseScheduledTime(value string, timezone string) (time.Time, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone: %s", timezone)
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range scheduledLocalLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid scheduledAt %q: use RFC3339 or YYYY-MM-DDTHH:MM", value)
+}
+
+func toScheduledDeploymentResponse(schedule models.ScheduledDeployment) dto.ScheduledDeploymentResponse {
+	response := dto.ScheduledDeploymentResponse{ScheduledDeployment: schedule}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	response.ScheduledAtLocal = schedule.ScheduledAt.In(location).Format(time.RFC3339)
+	return response
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/repositories"
+)
+
+const (
+	searchMinQueryLength = 2
+	searchDefaultLimit   = 10
+	searchMaxLimit       = 50
+)
+
+// SearchService handles the global search across projects, services, deployments and domains
+type SearchService struct {
+	searchRepo *repositories.SearchRepository
+}
+
+// NewSearchService creates a new search service instance
+func NewSearchService() *SearchService {
+	return &SearchService{
+		searchRepo: repositories.NewSearchRepository(),
+	}
+}
+
+// Search returns up to limit results of each kind that the caller can access
+func (s *SearchService) Search(query string, limit int, userID string, isAdmin bool) (dto.SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	response := dto.SearchResponse{Query: query}
+
+	if len(query) < searchMinQueryLength {
+		return response, errors.New("search query must be at least 2 characters")
+	}
+	if limit <= 0 {
+		limit = searchDefaultLimit
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+
+	var err error
+	if response.Projects, err = s.searchRepo.SearchProjects(query, userID, isAdmin, limit); err != nil {
+		return response, err
+	}
+	if response.Services, err = s.searchRepo.SearchServices(query, userID, isAdmin, limit); err != nil {
+		return response, err
+	}
+	if response.Deployments, err = s.searchRepo.SearchDeployments(query, userID, isAdmin, limit); err != nil {
+		return response, err
+	}
+	if response.Domains, err = s.searchRepo.SearchDomains(query, userID, isAdmin, limit); err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSecretRefreshMinutes = 60
+	// secretRefresherInterval is how often the refresher looks for stores that are due
+	secretRefresherInterval = time.Minute
+)
+
+var secretRefresherOnce sync.Once
+
+var (
+	// ErrSecretStoreNotFound is returned for stores that do not exist in the project
+	ErrSecretStoreNotFound = errors.New("secret store not found")
+	// ErrSecretRefreshFailed is returned when some services could not be refreshed
+	ErrSecretRefreshFailed = errors.New("secret refresh failed")
+)
+
+// SecretStoreService manages the external secret managers of projects, fetches the values
+// env vars reference and refreshes deployed services on each store's schedule
+type SecretStoreService struct {
+	storeRepo   *repositories.SecretStoreRepository
+	projectRepo *repositories.ProjectRepository
+	serviceRepo *repositories.ServiceRepository
+}
+
+// NewSecretStoreService creates a new secret store service instance
+func NewSecretStoreService() *SecretStoreService {
+	return &SecretStoreService{
+		storeRepo:   repositories.NewSecretStoreRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+	}
+}
+
+// ListStores returns the secret stores of a project
+func (s *SecretStoreService) ListStores(projectID string, userID string, isAdmin bool) ([]models.SecretStore, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.storeRepo.FindByProjectID(projectID)
+}
+
+// CreateStore connects an external secret manager to a project
+func (s *SecretStoreService) CreateStore(projectID string, req dto.SecretStoreRequest, userID string, isAdmin bool) (models.SecretStore, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.SecretStore{}, err
+	}
+
+	existing, err := s.storeRepo.FindByProjectID(projectID)
+	if err != nil {
+		return models.SecretStore{}, err
+	}
+	for _, other := range existing {
+		if other.Name == req.Name {
+			return models.SecretStore{}, fmt.Errorf("a secret store named %q already exists in this project", req.Name)
+		}
+	}
+
+	store := models.SecretStore{
+		ProjectID:       projectID,
+		Name:            req.Name,
+		Type:            req.Type,
+		Address:         req.Address,
+		VaultNamespace:  req.VaultNamespace,
+		Token:           req.Token,
+		Region:          req.Region,
+		AccessKeyID:     req.AccessKeyID,
+		SecretAccessKey: req.SecretAccessKey,
+		RefreshMinutes:  defaultSecretRefreshMinutes,
+		RestartPolicy:   models.SecretRestartNone,
+	}
+	if req.RefreshMinutes != nil {
+		store.RefreshMinutes = *req.RefreshMinutes
+	}
+	if req.RestartPolicy != "" {
+		store.RestartPolicy = req.RestartPolicy
+	}
+
+	created, err := s.storeRepo.Create(store)
+	if err != nil {
+		return models.SecretStore{}, err
+	}
+	log.Printf("Secret store %s (%s) connected to project %s", created.Name, created.Type, projectID)
+	return created, nil
+}
+
+// UpdateStore changes the connection, credentials or refresh settings of a store
+func (s *SecretStoreService) UpdateStore(projectID, storeID string, req dto.SecretStoreUpdateRequest, userID string, isAdmin bool) (models.SecretStore, error) {
+	store, err := s.getStore(projectID, storeID, userID, isAdmin)
+	if err != nil {
+		return store, err
+	}
+
+	if req.Address != "" {
+		store.Address = req.Address
+	}
+	if req.VaultNamespace != nil {
+		store.VaultNamespace = *req.VaultNamespace
+	}
+	if req.Token != "" {
+		store.Token = req.Token
+	}
+	if req.Region != "" {
+		store.Region = req.Region
+	}
+	if req.AccessKeyID != "" {
+		store.AccessKeyID = req.AccessKeyID
+	}
+	if req.SecretAccessKey != "" {
+		store.SecretAccessKey = req.SecretAccessKey
+	}
+	if req.RefreshMinutes != nil {
+		store.RefreshMinutes = *req.RefreshMinutes
+	}
+	if req.RestartPolicy != "" {
+		store.RestartPolicy = req.RestartPolicy
+	}
+	return s.storeRepo.Update(store)
+}
+
+// DeleteStore disconnects a secret store. Running services keep their synced values, but
+// deploys of services still referencing it fail.
+func (s *SecretStoreService) DeleteStore(projectID, storeID string, userID string, isAdmin bool) error {
+	store, err := s.getStore(projectID, storeID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.storeRepo.Delete(store.ID)
+}
+
+// RefreshStore re-syncs the services referencing a store right away
+func (s *SecretStoreService) RefreshStore(projectID, storeID string, userID string, isAdmin bool) (dto.SecretStoreRefreshResponse, error) {
+	store, err := s.getStore(projectID, storeID, userID, isAdmin)
+	if err != nil {
+		return dto.SecretStoreRefreshResponse{}, err
+	}
+	return s.refreshStore(store)
+}
+
+// FetchReferences reads the values of external references from the project's stores, keyed
+// by ExternalSecretReference.String(). References to unknown stores are left out, so
+// interpolation reports them.
+func (s *SecretStoreService) FetchReferences(projectID string, references []utils.ExternalSecretReference) (map[string]string, error) {
+	values := map[string]string{}
+	if len(references) == 0 {
+		return values, nil
+	}
+
+	stores, err := s.storeRepo.FindByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.SecretStore, len(stores))
+	for _, store := range stores {
+		byName[store.Name] = store
+	}
+
+	for _, reference := range references {
+		store, ok := byName[reference.Store]
+		if !ok {
+			continue
+		}
+		value, err := utils.FetchExternalSecret(store, reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read external secret %v", err)
+		}
+		values[reference.String()] = value
+	}
+	return values, nil
+}
+
+// StartSecretStoreRefresher periodically re-syncs the env Secrets of services referencing
+// stores whose refresh interval has elapsed
+func (s *SecretStoreService) StartSecretStoreRefresher() {
+	secretRefresherOnce.Do(func() {
+		go func() {
+			log.Printf("Secret store refresher started (interval %v)", secretRefresherInterval)
+			ticker := time.NewTicker(secretRefresherInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.refreshDueStores()
+			}
+		}()
+	})
+}
+
+func (s *SecretStoreService) refreshDueStores() {
+	stores, err := s.storeRepo.FindRefreshing()
+	if err != nil {
+		log.Printf("Secret store refresher: failed to load stores: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, store := range stores {
+		if !store.RefreshDue(now) {
+			continue
+		}
+		result, err := s.refreshStore(store)
+		if err != nil {
+			log.Printf("Secret store refresher: %s of project %s: %v", store.Name, store.ProjectID, err)
+		} else if result.Updated > 0 {
+			log.Printf("Secret store refresher: %s updated %d service(s), restarted %d", store.Name, result.Updated, result.Restarted)
+		}
+	}
+}
+
+// refreshStore re-resolves the env vars of the deployed git services referencing the store,
+// updates their env Secrets and, with the rolling restart policy, rolls changed services
+func (s *SecretStoreService) refreshStore(store models.SecretStore) (dto.SecretStoreRefreshResponse, error) {
+	var result dto.SecretStoreRefreshResponse
+
+	services, err := s.serviceRepo.FindByProjectID(store.ProjectID)
+	if err != nil {
+		return result, err
+	}
+
+	var failures []string
+	for _, service := range services {
+		if service.Type != models.ServiceTypeGit || !utils.ReferencesSecretStore(service.EnvVars, store.Name) {
+			continue
+		}
+		result.Services++
+
+		resolved, err := NewProjectSecretService().ResolveEnvVars(service)
+		if err == nil {
+			var changed bool
+			changed, err = utils.SyncServiceEnvSecret(resolved)
+			if changed {
+				result.Updated++
+				if store.RestartPolicy == models.SecretRestartRolling && service.Status == "running" {
+					if err = utils.RestartForEnvSecret(resolved); err == nil {
+						result.Restarted++
+					}
+				}
+			}
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", service.Name, err))
+		}
+	}
+
+	message := strings.Join(failures, "; ")
+	if err := s.storeRepo.RecordRefresh(store.ID, message, time.Now()); err != nil {
+		log.Printf("Failed to record refresh of secret store %s: %v", store.ID, err)
+	}
+	if message != "" {
+		return result, fmt.Errorf("%w: %s", ErrSecretRefreshFailed, message)
+	}
+	return result, nil
+}
+
+func (s *SecretStoreService) getStore(projectID, storeID string, userID string, isAdmin bool) (models.SecretStore, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.SecretStore{}, err
+	}
+
+	store, err := s.storeRepo.FindByID(storeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && store.ProjectID != projectID) {
+		return models.SecretStore{}, ErrSecretStoreNotFound
+	}
+	return store, err
+}
+
+func (s *SecretStoreService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
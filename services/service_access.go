@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+)
+
+// findAccessibleService loads a service the user may manage: any service for an admin,
+// otherwise only one whose project the user owns
+func findAccessibleService(serviceRepo *repositories.ServiceRepository, projectRepo *repositories.ProjectRepository, serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %w", err)
+	}
+	if isAdmin {
+		return service, nil
+	}
+
+	ownerID, err := projectRepo.GetOwnerID(service.ProjectID)
+	if err != nil {
+		return service, err
+	}
+	if ownerID != userID {
+		return service, errors.New("unauthorized access to service")
+	}
+	return service, nil
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// Env var change errors the API maps to client errors
+var (
+	ErrEnvVarApprovalRequired  = errors.New("the environment requires approval of environment variable changes; propose them with POST /services/{id}/env/changes")
+	ErrEnvVarChangeNotFound    = errors.New("environment variable change not found")
+	ErrEnvVarChangeNotPending  = errors.New("environment variable change is not waiting for approval")
+	ErrEnvVarChangeSelfApprove = errors.New("an environment variable change must be approved by someone other than its proposer")
+	ErrEnvVarChangeStale       = errors.New("the service's environment variables changed since the change was proposed; propose it again")
+)
+
+// ProposeEnvVarChange proposes setting and removing variables of a git service in an
+// environment that requires approval of env var changes. Nothing is applied until someone
+// other than the proposer approves it.
+func (s *ServiceService) ProposeEnvVarChange(serviceID string, req dto.EnvVarChangeRequest, userID string, isAdmin bool) (dto.EnvVarChangeResponse, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.EnvVarChangeResponse{}, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return dto.EnvVarChangeResponse{}, errors.New("environment variable changes can only be proposed for git services")
+	}
+	if !s.envVarApprovalRequired(service) {
+		return dto.EnvVarChangeResponse{}, errors.New("the service's environment does not require approval of environment variable changes; update them directly")
+	}
+	if err := utils.ValidateEnvVarChange(req, service.EnvVars); err != nil {
+		return dto.EnvVarChangeResponse{}, err
+	}
+
+	proposed := models.EnvVars{}
+	for key, value := range service.EnvVars {
+		proposed[key] = value
+	}
+	for key, value := range utils.KeepMaskedEnvValues(req.Set, service.EnvVars) {
+		proposed[key] = value
+	}
+	for _, key := range req.Unset {
+		delete(proposed, key)
+	}
+	return s.proposeEnvVarChange(service, proposed, req.Note, userID)
+}
+
+// proposeEnvVarChange records a proposal to replace the service's variables with proposed
+func (s *ServiceService) proposeEnvVarChange(service models.Service, proposed models.EnvVars, note string, userID string) (dto.EnvVarChangeResponse, error) {
+	if len(proposed) == 0 {
+		// The update cannot clear every variable, so such a change could never be applied
+		return dto.EnvVarChangeResponse{}, errors.New("a change cannot remove every environment variable")
+	}
+	if maps.Equal(proposed, service.EnvVars) {
+		return dto.EnvVarChangeResponse{}, errors.New("the change does not change any environment variable")
+	}
+
+	base := service.EnvVars
+	if base == nil {
+		base = models.EnvVars{}
+	}
+	change, err := s.envVarChangeRepo.Create(models.EnvVarChange{
+		ProjectID:       service.ProjectID,
+		EnvironmentID:   service.EnvironmentID,
+		ServiceID:       service.ID,
+		BaseEnvVars:     base,
+		ProposedEnvVars: proposed,
+		Status:          models.EnvVarChangePendingApproval,
+		Note:            strings.TrimSpace(note),
+		RequestedBy:     userID,
+	})
+	if err != nil {
+		return dto.EnvVarChangeResponse{}, err
+	}
+	log.Printf("Environment variable change %s of service %s proposed by %s", change.ID, service.ID, userID)
+	return envVarChangeResponse(service, change), nil
+}
+
+// ListEnvVarChanges returns a page of a service's proposed env var changes, newest first
+func (s *ServiceService) ListEnvVarChanges(serviceID string, status string, page, pageSize int, userID string, isAdmin bool) (dto.EnvVarChangeListResponse, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.EnvVarChangeListResponse{}, err
+	}
+	changes, total, err := s.envVarChangeRepo.FindByServiceID(service.ID, status, page, pageSize)
+	if err != nil {
+		return dto.EnvVarChangeListResponse{}, err
+	}
+
+	response := dto.EnvVarChangeListResponse{
+		Changes:    make([]dto.EnvVarChangeResponse, 0, len(changes)),
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, envVarChangeResponse(service, change))
+	}
+	return response, nil
+}
+
+// ApproveEnvVarChange approves a proposed env var change and applies it like any other
+// update, which redeploys or restarts the service. The proposer cannot approve their own
+// change, and a change proposed before the variables changed again cannot be approved.
+func (s *ServiceService) ApproveEnvVarChange(serviceID string, changeID string, comment string, userID string, isAdmin bool) (dto.EnvVarChangeResponse, error) {
+	service, change, err := s.findReviewableEnvVarChange(serviceID, changeID, userID, isAdmin)
+	if err != nil {
+		return dto.EnvVarChangeResponse{}, err
+	}
+	if !maps.Equal(service.EnvVars, change.BaseEnvVars) {
+		return envVarChangeResponse(service, change), ErrEnvVarChangeStale
+	}
+	if err := s.reviewEnvVarChange(&change, models.EnvVarChangeApproved, comment, userID); err != nil {
+		return envVarChangeResponse(service, change), err
+	}
+	log.Printf("Environment variable change %s of service %s approved by %s", change.ID, service.ID, userID)
+
+	update := service
+	update.Deployments = nil
+	update.EnvVars = change.ProposedEnvVars
+	updated, applyErr := s.applyServiceUpdate(update, userID, isAdmin, true)
+	if err := s.envVarChangeRepo.RecordResult(change.ID, applyErr); err != nil {
+		log.Printf("Failed to record the result of environment variable change %s: %v", change.ID, err)
+	}
+	if applyErr != nil {
+		change.Status = models.EnvVarChangeFailed
+		change.Error = applyErr.Error()
+		return envVarChangeResponse(service, change), applyErr
+	}
+	// The revision is the proposer's change
+	s.recordRevision(updated, change.RequestedBy, nil)
+	change.Status = models.EnvVarChangeApplied
+	return envVarChangeResponse(service, change), nil
+}
+
+// RejectEnvVarChange rejects a proposed env var change; nothing is applied
+func (s *ServiceService) RejectEnvVarChange(serviceID string, changeID string, comment string, userID string, isAdmin bool) (dto.EnvVarChangeResponse, error) {
+	service, change, err := s.findReviewableEnvVarChange(serviceID, changeID, userID, isAdmin)
+	if err != nil {
+		return dto.EnvVarChangeResponse{}, err
+	}
+	if err := s.reviewEnvVarChange(&change, models.EnvVarChangeRejected, comment, userID); err != nil {
+		return envVarChangeResponse(service, change), err
+	}
+	log.Printf("Environment variable change %s of service %s rejected by %s", change.ID, service.ID, userID)
+	return envVarChangeResponse(service, change), nil
+}
+
+// findReviewableEnvVarChange loads a change of the service waiting for approval that the
+// user may review
+func (s *ServiceService) findReviewableEnvVarChange(serviceID string, changeID string, userID string, isAdmin bool) (models.Service, models.EnvVarChange, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, models.EnvVarChange{}, err
+	}
+	change, err := s.envVarChangeRepo.FindByID(changeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service, change, ErrEnvVarChangeNotFound
+	}
+	if err != nil {
+		return service, change, err
+	}
+	if change.ServiceID != service.ID {
+		return service, change, ErrEnvVarChangeNotFound
+	}
+	if change.Status != models.EnvVarChangePendingApproval {
+		return service, change, ErrEnvVarChangeNotPending
+	}
+	if change.RequestedBy == userID {
+		return service, change, ErrEnvVarChangeSelfApprove
+	}
+	return service, change, nil
+}
+
+// reviewEnvVarChange records the decision; it fails when another reviewer decided first
+func (s *ServiceService) reviewEnvVarChange(change *models.EnvVarChange, status models.EnvVarChangeStatus, comment string, userID string) error {
+	now := time.Now()
+	comment = strings.TrimSpace(comment)
+	reviewed, err := s.envVarChangeRepo.Review(change.ID, status, userID, comment, now)
+	if err != nil {
+		return err
+	}
+	if !reviewed {
+		return ErrEnvVarChangeNotPending
+	}
+	change.Status = status
+	change.ReviewedBy = &userID
+	change.ReviewedAt = &now
+	change.ReviewComment = comment
+	return nil
+}
+
+// envVarApprovalRequired reports whether env var changes of the service need approval
+func (s *ServiceService) envVarApprovalRequired(service models.Service) bool {
+	env, err := s.environmentRepo.FindByID(service.EnvironmentID)
+	return err == nil && env.EnvVarApprovalRequired
+}
+
+// envVarsChanged reports whether an update changes the variables of a git service. The
+// update keeps the variables when it has none, and masked values sent back keep theirs.
+func envVarsChanged(existing models.Service, update models.Service) bool {
+	if existing.Type != models.ServiceTypeGit || len(update.EnvVars) == 0 {
+		return false
+	}
+	return !maps.Equal(utils.KeepMaskedEnvValues(update.EnvVars, existing.EnvVars), existing.EnvVars)
+}
+
+// envVarChangeResponse renders the masked diff of a change
+func envVarChangeResponse(service models.Service, change models.EnvVarChange) dto.EnvVarChangeResponse {
+	base := service
+	base.EnvVars = change.BaseEnvVars
+	changes, unchanged := diffEnvVars(base, change.ProposedEnvVars)
+	return dto.EnvVarChangeResponse{
+		EnvVarChange: change,
+		Changes:      changes,
+		Unchanged:    unchanged,
+	}
+}
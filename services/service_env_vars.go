@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	EnvImportModeMerge   = "merge"
+	EnvImportModeReplace = "replace"
+)
+
+// Sources of an env var reveal audit entry
+const (
+	EnvRevealSourceReveal = "reveal"
+	EnvRevealSourceExport = "export"
+)
+
+// ErrEnvRevealForbidden is returned when someone other than the project owner asks for
+// unmasked variable values
+var ErrEnvRevealForbidden = errors.New("only the project owner can reveal secret values")
+
+// ImportEnvVars sets a git service's variables from a .env file. Merge keeps variables
+// missing from the file, replace removes them. The diff is returned either way; unless it
+// is a dry run and when anything changed, the update redeploys the service like any other.
+// In an environment that requires approval of env var changes the import is proposed
+// instead.
+func (s *ServiceService) ImportEnvVars(serviceID string, req dto.EnvImportRequest, userID string, isAdmin bool) (dto.EnvImportResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = EnvImportModeMerge
+	}
+	response := dto.EnvImportResponse{Mode: mode, Changes: []dto.EnvVarChange{}}
+
+	existing, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return response, err
+	}
+	if existing.Type != models.ServiceTypeGit {
+		return response, errors.New("environment variables can only be imported into git services")
+	}
+
+	parsed, err := utils.ParseDotEnv(req.Content)
+	if err != nil {
+		var errs utils.FieldErrors
+		errs.Add("content", "%v", err)
+		return response, errs.Err()
+	}
+	// A masked export imported back keeps the current secret values
+	imported := utils.KeepMaskedEnvValues(parsed, existing.EnvVars)
+	if err := utils.ValidateEnvImport(imported); err != nil {
+		return response, err
+	}
+
+	target := models.EnvVars{}
+	if mode == EnvImportModeMerge {
+		for key, value := range existing.EnvVars {
+			target[key] = value
+		}
+	}
+	for key, value := range imported {
+		target[key] = value
+	}
+
+	response.Changes, response.Unchanged = diffEnvVars(existing, target)
+	if req.DryRun || len(response.Changes) == 0 {
+		return response, nil
+	}
+	if s.envVarApprovalRequired(existing) {
+		proposal, err := s.proposeEnvVarChange(existing, target, fmt.Sprintf("Imported from a .env file (%s)", mode), userID)
+		if err != nil {
+			return response, err
+		}
+		response.Proposal = &proposal
+		return response, nil
+	}
+
+	update := existing
+	update.Deployments = nil
+	update.EnvVars = target
+	updated, err := s.updateService(update, userID, isAdmin)
+	if err != nil {
+		return response, err
+	}
+	s.recordRevision(updated, userID, nil)
+	log.Printf("Imported %d variable changes into service %s (%s) by %s", len(response.Changes), serviceID, mode, userID)
+
+	response.Applied = true
+	response.Service = &updated
+	return response, nil
+}
+
+// ExportEnvVars renders a service's variables as a .env file. Secret and sensitive values
+// are masked unless reveal is set, which only the project owner may do and is audited.
+func (s *ServiceService) ExportEnvVars(serviceID string, reveal bool, userID string, isAdmin bool) (string, models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return "", service, err
+	}
+	if !reveal {
+		return utils.FormatDotEnv(utils.MaskEnvVars(service, service.EnvVars)), service, nil
+	}
+
+	if err := s.checkRevealAllowed(service, userID); err != nil {
+		return "", service, err
+	}
+	keys := make([]string, 0, len(service.EnvVars))
+	for key := range service.EnvVars {
+		keys = append(keys, key)
+	}
+	s.recordReveal(service, userID, keys, EnvRevealSourceExport)
+	return utils.FormatDotEnv(service.EnvVars), service, nil
+}
+
+// RevealEnvVars returns the unmasked values of a service's secret env vars, or of the given
+// ones. Only the project owner may reveal values, and every reveal is audited.
+func (s *ServiceService) RevealEnvVars(serviceID string, keys []string, userID string, isAdmin bool) (models.EnvVars, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRevealAllowed(service, userID); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		for key := range service.EnvVars {
+			if service.IsSecretEnvVar(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	revealed := models.EnvVars{}
+	for _, key := range keys {
+		value, ok := service.EnvVars[key]
+		if !ok {
+			return nil, fmt.Errorf("service has no environment variable %s", key)
+		}
+		revealed[key] = value
+	}
+	if len(revealed) > 0 {
+		s.recordReveal(service, userID, keys, EnvRevealSourceReveal)
+	}
+	return revealed, nil
+}
+
+// GetEnvRevealLog returns the recent reveals of a service's env var values
+func (s *ServiceService) GetEnvRevealLog(serviceID string, userID string, isAdmin bool) ([]models.EnvRevealAuditLog, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.revealAuditRepo.FindByServiceID(service.ID, 100)
+}
+
+// checkRevealAllowed restricts unmasked values to the project owner, admins included
+func (s *ServiceService) checkRevealAllowed(service models.Service, userID string) error {
+	ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return ErrEnvRevealForbidden
+	}
+	return nil
+}
+
+// recordReveal writes the audit entry of a reveal. Failures are only logged, like console
+// audit entries.
+func (s *ServiceService) recordReveal(service models.Service, userID string, keys []string, source string) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	entry := models.EnvRevealAuditLog{
+		ServiceID: service.ID,
+		UserID:    userID,
+		Keys:      strings.Join(sorted, ","),
+		Source:    source,
+	}
+	if _, err := s.revealAuditRepo.Create(entry); err != nil {
+		log.Printf("Failed to write env reveal audit log for service %s: %v", service.ID, err)
+	}
+	log.Printf("User %s revealed %d environment variables of service %s (%s)", userID, len(keys), service.ID, source)
+}
+
+// diffEnvVars lists the variables added, changed and removed going from the service's
+// variables to target, sorted by name, and counts the unchanged ones. Each change says
+// whether it needs a rebuild or is applied to the running Deployment.
+func diffEnvVars(service models.Service, target models.EnvVars) ([]dto.EnvVarChange, int) {
+	current := service.EnvVars
+	updated := service
+	updated.EnvVars = target
+	changes := []dto.EnvVarChange{}
+	unchanged := 0
+	for key, value := range target {
+		oldValue, found := current[key]
+		switch {
+		case !found:
+			changes = append(changes, dto.EnvVarChange{Key: key, Change: "added", NewValue: utils.MaskEnvValue(service, key, value)})
+		case oldValue != value:
+			changes = append(changes, dto.EnvVarChange{
+				Key:      key,
+				Change:   "changed",
+				OldValue: utils.MaskEnvValue(service, key, oldValue),
+				NewValue: utils.MaskEnvValue(service, key, value),
+			})
+		default:
+			unchanged++
+		}
+	}
+	for key, value := range current {
+		if _, found := target[key]; !found {
+			changes = append(changes, dto.EnvVarChange{Key: key, Change: "removed", OldValue: utils.MaskEnvValue(service, key, value)})
+		}
+	}
+	for i := range changes {
+		changes[i].RebuildReason = utils.EnvVarRebuildReason(service, updated, changes[i].Key)
+		changes[i].RequiresRebuild = changes[i].RebuildReason != ""
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes, unchanged
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultIncidentDetectorSeconds = 30
+	// incidentResolveGrace is how long every signal must stay clear before an incident
+	// is resolved, so a flapping service keeps one incident
+	incidentResolveGrace = 5 * time.Minute
+	// incidentDeploymentLookback bounds which failed deployments still open an incident,
+	// so the first run does not back-fill old failures
+	incidentDeploymentLookback = time.Hour
+	// incidentDeploymentWindow is how soon after a deployment a crash loop is blamed on it
+	incidentDeploymentWindow = 30 * time.Minute
+	uptimeDownThreshold      = 3
+)
+
+var incidentDetectorOnce sync.Once
+
+// ServiceIncidentService correlates deployment failures, crash loops and probe failures
+// into per-service incident timelines
+type ServiceIncidentService struct {
+	incidentRepo   *repositories.ServiceIncidentRepository
+	serviceRepo    *repositories.ServiceRepository
+	projectRepo    *repositories.ProjectRepository
+	deploymentRepo *repositories.DeploymentRepository
+	uptimeRepo     *repositories.UptimeRepository
+}
+
+// NewServiceIncidentService creates a new service incident service instance
+func NewServiceIncidentService() *ServiceIncidentService {
+	return &ServiceIncidentService{
+		incidentRepo:   repositories.NewServiceIncidentRepository(),
+		serviceRepo:    repositories.NewServiceRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+		deploymentRepo: repositories.NewDeploymentRepository(),
+		uptimeRepo:     repositories.NewUptimeRepository(),
+	}
+}
+
+// ListIncidents returns a page of a service's detected incidents, newest first
+func (s *ServiceIncidentService) ListIncidents(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.ServiceIncidentListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.ServiceIncidentListResponse{}, err
+	}
+
+	incidents, total, err := s.incidentRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.ServiceIncidentListResponse{}, err
+	}
+	return dto.ServiceIncidentListResponse{
+		Incidents:  incidents,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// StartIncidentDetector starts the background loop collecting failure signals and
+// folding them into incidents
+func (s *ServiceIncidentService) StartIncidentDetector() {
+	incidentDetectorOnce.Do(func() {
+		interval := time.Duration(getIncidentDetectorInterval()) * time.Second
+		go func() {
+			log.Printf("Incident detector started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.detectOnce()
+			}
+		}()
+	})
+}
+
+func (s *ServiceIncidentService) detectOnce() {
+	services, err := s.serviceRepo.FindAll()
+	if err != nil {
+		log.Printf("Incident detector: failed to load services: %v", err)
+		return
+	}
+
+	for _, service := range services {
+		if service.Status == "inactive" {
+			continue
+		}
+		signals, err := s.collectSignals(service)
+		if err != nil {
+			// Without a full picture an open incident must not be resolved
+			log.Printf("Incident detector: failed to collect signals of service %s: %v", service.ID, err)
+			continue
+		}
+		if err := s.correlate(service, signals, time.Now()); err != nil {
+			log.Printf("Incident detector: failed to record incident of service %s: %v", service.ID, err)
+		}
+	}
+}
+
+// collectSignals gathers the failures currently observed on a service. A failed
+// deployment is a point-in-time signal reported once.
+func (s *ServiceIncidentService) collectSignals(service models.Service) ([]utils.IncidentSignal, error) {
+	var signals []utils.IncidentSignal
+
+	deployment, err := s.deploymentRepo.GetLatestDeployment(service.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && deployment.Status == models.DeploymentStatusFailed && time.Since(deployment.CreatedAt) < incidentDeploymentLookback {
+		key := "deployment_failed:" + deployment.ID
+		recorded, err := s.incidentRepo.EventKeyExists(service.ID, key)
+		if err != nil {
+			return nil, err
+		}
+		if !recorded {
+			signals = append(signals, utils.IncidentSignal{
+				Kind:    models.IncidentSignalDeploymentFailed,
+				Key:     key,
+				Message: "Deployment " + describeDeployment(deployment) + " failed",
+				Since:   deployment.CreatedAt,
+			})
+		}
+	}
+
+	podSignals, err := utils.CollectPodIncidentSignals(service)
+	if err != nil {
+		return nil, err
+	}
+	signals = append(signals, podSignals...)
+
+	monitor, err := s.uptimeRepo.FindMonitor(service.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && monitor.Enabled {
+		checks, err := s.uptimeRepo.FindRecentChecks(service.ID, uptimeDownThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if signal, down := uptimeDownSignal(checks); down {
+			signals = append(signals, signal)
+		}
+	}
+
+	return signals, nil
+}
+
+// correlate folds the current signals into the service's open incident, opening one when
+// a signal appears and resolving it once every signal has been clear for the grace period
+func (s *ServiceIncidentService) correlate(service models.Service, signals []utils.IncidentSignal, now time.Time) error {
+	incident, err := s.incidentRepo.FindOpen(service.ID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return err
+	}
+	if isNew {
+		if len(signals) == 0 {
+			return nil
+		}
+		incident = models.ServiceIncident{ServiceID: service.ID, StartedAt: now}
+	}
+
+	pending := make(map[string]utils.IncidentSignal, len(signals))
+	for _, signal := range signals {
+		pending[signal.Key] = signal
+	}
+	for i := range incident.Events {
+		event := &incident.Events[i]
+		if event.ResolvedAt != nil {
+			continue
+		}
+		if signal, ok := pending[event.Key]; ok {
+			event.Message = signal.Message
+			delete(pending, event.Key)
+			continue
+		}
+		resolvedAt := now
+		event.ResolvedAt = &resolvedAt
+	}
+	for _, signal := range signals {
+		if _, ok := pending[signal.Key]; !ok {
+			continue
+		}
+		event := models.ServiceIncidentEvent{
+			Kind:      signal.Kind,
+			Key:       signal.Key,
+			Message:   signal.Message,
+			StartedAt: signal.Since,
+		}
+		if event.StartedAt.IsZero() || event.StartedAt.After(now) {
+			event.StartedAt = now
+		}
+		if signal.Kind == models.IncidentSignalDeploymentFailed {
+			resolvedAt := event.StartedAt
+			event.ResolvedAt = &resolvedAt
+		}
+		if isNew && event.StartedAt.Before(incident.StartedAt) {
+			incident.StartedAt = event.StartedAt
+		}
+		incident.Events = append(incident.Events, event)
+	}
+
+	if len(signals) > 0 {
+		incident.LastSignalAt = now
+	} else if now.Sub(incident.LastSignalAt) >= incidentResolveGrace {
+		incident.ResolvedAt = &now
+	}
+	incident.ProbableCause = s.probableCause(service, incident)
+
+	if isNew {
+		_, err := s.incidentRepo.Create(incident)
+		return err
+	}
+	return s.incidentRepo.Save(incident)
+}
+
+// probableCause names the most likely cause from the kinds of signals on the timeline
+func (s *ServiceIncidentService) probableCause(service models.Service, incident models.ServiceIncident) string {
+	seen := make(map[string]models.ServiceIncidentEvent)
+	oomKilled := false
+	for _, event := range incident.Events {
+		if _, ok := seen[event.Kind]; !ok {
+			seen[event.Kind] = event
+		}
+		if event.Kind == models.IncidentSignalCrashLoop && strings.Contains(event.Message, "OOMKilled") {
+			oomKilled = true
+		}
+	}
+
+	if oomKilled {
+		return "Containers are being OOMKilled; the memory limit (" + service.MemoryLimit + ") is likely too low"
+	}
+	if event, ok := seen[models.IncidentSignalDeploymentFailed]; ok {
+		return event.Message + "; the previous version keeps serving if it was healthy"
+	}
+	if _, ok := seen[models.IncidentSignalCrashLoop]; ok {
+		deployment, err := s.deploymentRepo.GetLatestSuccessfulDeployment(service.ID)
+		if err == nil && !deployment.CreatedAt.After(incident.StartedAt) && incident.StartedAt.Sub(deployment.CreatedAt) < incidentDeploymentWindow {
+			return fmt.Sprintf("Crash loop began %s after deployment %s; the new version is likely faulty",
+				incident.StartedAt.Sub(deployment.CreatedAt).Round(time.Second), describeDeployment(deployment))
+		}
+		return "The application is crash-looping; check the container logs"
+	}
+	if _, ok := seen[models.IncidentSignalProbeFailure]; ok {
+		return fmt.Sprintf("Pods are running but failing their readiness probe; the application may be hung or not listening on port %d", service.Port)
+	}
+	if _, ok := seen[models.IncidentSignalUptimeDown]; ok {
+		return "The health URL is unreachable while the pods look healthy; check the domain, ingress and TLS certificate"
+	}
+	return ""
+}
+
+// uptimeDownSignal reports a failure when every one of the most recent checks was down
+func uptimeDownSignal(checks []models.UptimeCheck) (utils.IncidentSignal, bool) {
+	if len(checks) < uptimeDownThreshold {
+		return utils.IncidentSignal{}, false
+	}
+	for _, check := range checks {
+		if check.Up {
+			return utils.IncidentSignal{}, false
+		}
+	}
+
+	reason := checks[0].Error
+	if reason == "" {
+		reason = "HTTP " + strconv.Itoa(checks[0].StatusCode)
+	}
+	return utils.IncidentSignal{
+		Kind:    models.IncidentSignalUptimeDown,
+		Key:     models.IncidentSignalUptimeDown,
+		Message: fmt.Sprintf("Health URL failed %d consecutive uptime checks: %s", len(checks), reason),
+		Since:   checks[len(checks)-1].CheckedAt,
+	}, true
+}
+
+func describeDeployment(deployment models.Deployment) string {
+	if len(deployment.CommitSHA) >= 7 {
+		return deployment.CommitSHA[:7]
+	}
+	if deployment.Version != "" {
+		return deployment.Version
+	}
+	return deployment.ID
+}
+
+func getIncidentDetectorInterval() int {
+	value := optionalEnvString("INCIDENT_DETECTOR_SECONDS")
+	if value == nil {
+		return defaultIncidentDetectorSeconds
+	}
+	seconds, err := strconv.Atoi(*value)
+	if err != nil || seconds <= 0 {
+		return defaultIncidentDetectorSeconds
+	}
+	return seconds
+}
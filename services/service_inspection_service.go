@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// serviceInspectionTimeout bounds reading a service's events or logs from the cluster
+const serviceInspectionTimeout = 30 * time.Second
+
+// ErrInspectedServiceNotFound is returned when the inspected service does not exist
+var ErrInspectedServiceNotFound = errors.New("service not found")
+
+// ServiceInspectionService gives admins read-only access to the manifests, events and logs
+// of any service, so they can debug it without kubectl or impersonating its owner. Every
+// access is recorded before anything is read; when the audit entry cannot be written,
+// nothing is returned.
+type ServiceInspectionService struct {
+	serviceRepo     *repositories.ServiceRepository
+	auditRepo       *repositories.ServiceInspectionAuditRepository
+	manifestService *ManifestService
+}
+
+// NewServiceInspectionService creates a new service inspection service instance
+func NewServiceInspectionService() *ServiceInspectionService {
+	return &ServiceInspectionService{
+		serviceRepo:     repositories.NewServiceRepository(),
+		auditRepo:       repositories.NewServiceInspectionAuditRepository(),
+		manifestService: NewManifestService(),
+	}
+}
+
+// InspectionRequest identifies the admin inspecting a service and why
+type InspectionRequest struct {
+	ServiceID string
+	AdminID   string
+	Reason    string
+	IPAddress string
+}
+
+// InspectManifests returns the rendered manifests of a service, secret values masked
+func (s *ServiceInspectionService) InspectManifests(req InspectionRequest) (string, models.Service, error) {
+	service, err := s.audit(req, models.ServiceInspectionManifests)
+	if err != nil {
+		return "", service, err
+	}
+	return s.manifestService.RenderManifests(service.ID, req.AdminID, true)
+}
+
+// InspectEvents returns the Kubernetes events of a service's objects, newest first
+func (s *ServiceInspectionService) InspectEvents(req InspectionRequest) ([]dto.ServiceEvent, error) {
+	service, err := s.audit(req, models.ServiceInspectionEvents)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serviceInspectionTimeout)
+	defer cancel()
+	return utils.ListServiceEvents(ctx, service)
+}
+
+// InspectLogs returns the last lines of each container of a service's pods, secret values
+// redacted
+func (s *ServiceInspectionService) InspectLogs(req InspectionRequest, tailLines int64) (string, models.Service, error) {
+	service, err := s.audit(req, models.ServiceInspectionLogs)
+	if err != nil {
+		return "", service, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serviceInspectionTimeout)
+	defer cancel()
+	logs, err := utils.ReadRecentServiceLogs(ctx, service, tailLines)
+	return logs, service, err
+}
+
+// ListAudit returns a page of the service inspection audit trail, newest first
+func (s *ServiceInspectionService) ListAudit(adminID string, serviceID string, page, pageSize int) (dto.ServiceInspectionAuditListResponse, error) {
+	entries, total, err := s.auditRepo.Find(adminID, serviceID, page, pageSize)
+	if err != nil {
+		return dto.ServiceInspectionAuditListResponse{}, err
+	}
+	return dto.ServiceInspectionAuditListResponse{
+		Entries:    entries,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// audit loads the inspected service and records the access
+func (s *ServiceInspectionService) audit(req InspectionRequest, resource string) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(req.ServiceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service, ErrInspectedServiceNotFound
+	}
+	if err != nil {
+		return service, err
+	}
+
+	err = s.auditRepo.Create(models.ServiceInspectionAuditLog{
+		AdminID:   req.AdminID,
+		ServiceID: service.ID,
+		ProjectID: service.ProjectID,
+		Resource:  resource,
+		Reason:    req.Reason,
+		IPAddress: req.IPAddress,
+	})
+	if err != nil {
+		log.Printf("Failed to record inspection of the %s of service %s by %s: %v", resource, service.ID, req.AdminID, err)
+		return service, fmt.Errorf("the inspection could not be audit-logged: %v", err)
+	}
+	log.Printf("Admin %s inspected the %s of service %s", req.AdminID, resource, service.ID)
+	return service, nil
+}
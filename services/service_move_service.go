@@ -0,0 +1,372 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// serviceMoveTimeout is how long a move may stay in progress before it is treated as
+// abandoned, e.g. by a restart of the API
+const serviceMoveTimeout = 3 * time.Hour
+
+var (
+	// ErrServiceMoveNotFound is returned for moves that do not exist on the service
+	ErrServiceMoveNotFound = errors.New("service move not found")
+	// ErrServiceMoveInProgress is returned while an earlier move of the service is running
+	ErrServiceMoveInProgress = errors.New("a move of this service is still in progress")
+	// ErrServiceMoveRejected is returned when the service cannot be moved right now
+	ErrServiceMoveRejected = errors.New("service cannot be moved")
+)
+
+// ServiceMoveService migrates services to another environment of their project. The
+// workload is recreated in the target namespace while the source keeps serving; managed
+// services are stopped while their volumes are copied. A move that fails is rolled back so
+// the service runs in its source environment again.
+type ServiceMoveService struct {
+	moveRepo          *repositories.ServiceMoveRepository
+	serviceRepo       *repositories.ServiceRepository
+	projectRepo       *repositories.ProjectRepository
+	environmentRepo   *repositories.EnvironmentRepository
+	deploymentRepo    *repositories.DeploymentRepository
+	serviceService    *ServiceService
+	managedService    *ManagedServiceService
+	deploymentService *DeploymentService
+	deployLocks       *DeployLockService
+}
+
+// NewServiceMoveService creates a new service move service instance
+func NewServiceMoveService() *ServiceMoveService {
+	return &ServiceMoveService{
+		moveRepo:          repositories.NewServiceMoveRepository(),
+		serviceRepo:       repositories.NewServiceRepository(),
+		projectRepo:       repositories.NewProjectRepository(),
+		environmentRepo:   repositories.NewEnvironmentRepository(),
+		deploymentRepo:    repositories.NewDeploymentRepository(),
+		serviceService:    NewServiceService(),
+		managedService:    NewManagedServiceService(),
+		deploymentService: NewDeploymentService(),
+		deployLocks:       NewDeployLockService(),
+	}
+}
+
+// serviceMoveState records how far a move got, so a failure only undoes what was done
+type serviceMoveState struct {
+	source   models.Service
+	target   models.Service
+	image    string // the image a git service runs; empty when it was never deployed
+	replicas int32  // the replicas of the stopped source workload of a managed service
+	stopped  bool
+	flipped  bool
+	switched bool
+}
+
+// Move starts moving a service to another environment of its project. The service must
+// keep its name and internal alias there, and the target's deploy locks and resource
+// ranges apply. The move runs in the background; poll the returned move for progress.
+func (s *ServiceMoveService) Move(serviceID string, req dto.ServiceMoveRequest, userID string, isAdmin bool) (models.ServiceMove, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return models.ServiceMove{}, err
+	}
+
+	var errs utils.FieldErrors
+	target, err := s.environmentRepo.FindByID(req.EnvironmentID)
+	switch {
+	case req.EnvironmentID == service.EnvironmentID:
+		errs.Add("environmentId", "is the service's current environment")
+	case err != nil:
+		errs.Add("environmentId", "environment not found")
+	case target.ProjectID != service.ProjectID:
+		errs.Add("environmentId", "must be an environment of the service's project")
+	}
+	if err := errs.Err(); err != nil {
+		return models.ServiceMove{}, err
+	}
+	if err := s.checkMovable(service, target, isAdmin); err != nil {
+		return models.ServiceMove{}, err
+	}
+
+	// Moves left in progress by a restart of the API are ignored once they timed out
+	inProgress, err := s.moveRepo.ExistsInProgress(serviceID, time.Now().Add(-serviceMoveTimeout))
+	if err != nil {
+		return models.ServiceMove{}, err
+	}
+	if inProgress {
+		return models.ServiceMove{}, ErrServiceMoveInProgress
+	}
+
+	state := serviceMoveState{source: service, target: service}
+	state.target.EnvironmentID = target.ID
+	if service.Type == models.ServiceTypeGit {
+		if state.image, err = s.runningImage(service); err != nil {
+			return models.ServiceMove{}, err
+		}
+	}
+
+	move, err := s.moveRepo.Create(models.ServiceMove{
+		ServiceID:           serviceID,
+		SourceEnvironmentID: service.EnvironmentID,
+		TargetEnvironmentID: target.ID,
+		Status:              models.ServiceMovePending,
+		RequestedBy:         userID,
+		StartedAt:           time.Now(),
+	})
+	if err != nil {
+		return move, err
+	}
+
+	go s.run(move, state)
+	return move, nil
+}
+
+// ListMoves returns a page of a service's moves, newest first
+func (s *ServiceMoveService) ListMoves(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.ServiceMoveListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.ServiceMoveListResponse{}, err
+	}
+
+	moves, total, err := s.moveRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.ServiceMoveListResponse{}, err
+	}
+	return dto.ServiceMoveListResponse{
+		Moves:      moves,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// GetMove returns a move of a service
+func (s *ServiceMoveService) GetMove(serviceID, moveID string, userID string, isAdmin bool) (models.ServiceMove, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return models.ServiceMove{}, err
+	}
+
+	move, err := s.moveRepo.FindByID(moveID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && move.ServiceID != serviceID) {
+		return models.ServiceMove{}, ErrServiceMoveNotFound
+	}
+	return move, err
+}
+
+// checkMovable rejects moves the service or either environment is not ready for
+func (s *ServiceMoveService) checkMovable(service models.Service, target models.Environment, isAdmin bool) error {
+	switch service.Status {
+	case "building":
+		return fmt.Errorf("%w: wait for the running deployment to finish", ErrServiceMoveRejected)
+	case "paused", "archived":
+		return fmt.Errorf("%w: resume the service before moving it", ErrServiceMoveRejected)
+	}
+	if target.IsArchived() {
+		return fmt.Errorf("%w: the target environment is archived", ErrServiceMoveRejected)
+	}
+	if source, err := s.environmentRepo.FindByID(service.EnvironmentID); err == nil && source.IsArchived() {
+		return fmt.Errorf("%w: the service's environment is archived", ErrServiceMoveRejected)
+	}
+	if err := utils.CheckEnvironmentResourceRanges(target, "", service.CPULimit, service.MemoryLimit, service.StorageSize); err != nil {
+		return err
+	}
+
+	moved := service
+	moved.EnvironmentID = target.ID
+	if err := s.deployLocks.CheckDeployAllowed(service, isAdmin); err != nil {
+		return err
+	}
+	if err := s.deployLocks.CheckDeployAllowed(moved, isAdmin); err != nil {
+		return err
+	}
+
+	// Resource names, hostnames and the TCP port stay the same; the name and alias must be
+	// free in the target environment
+	if err := s.serviceService.checkServiceNameAvailable(service.Name, target.ID, service.ID); err != nil {
+		return err
+	}
+	if service.InternalAlias != "" {
+		if err := s.serviceService.checkInternalAliasAvailable(service.InternalAlias, target.ID, service.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runningImage returns the image a git service runs: the pinned deployment's, or the last
+// successful one's. It is empty for services that were never deployed.
+func (s *ServiceMoveService) runningImage(service models.Service) (string, error) {
+	var deployment models.Deployment
+	var err error
+	if service.PinnedDeploymentID != nil {
+		deployment, err = s.deploymentRepo.FindByID(*service.PinnedDeploymentID)
+	} else {
+		deployment, err = s.deploymentRepo.GetLatestSuccessfulDeployment(service.ID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find the running deployment: %v", err)
+	}
+	return deployment.Image, nil
+}
+
+// run carries out a move and rolls it back when a step fails
+func (s *ServiceMoveService) run(move models.ServiceMove, state serviceMoveState) {
+	if state.source.Type == models.ServiceTypeGit && state.image == "" {
+		// Nothing runs yet; the next deploy goes to the new environment
+		if err := s.serviceRepo.UpdateColumns(state.target, "EnvironmentID"); err != nil {
+			s.finish(&move, models.ServiceMoveFailed, fmt.Sprintf("failed to move the service record: %v", err))
+			return
+		}
+		s.finish(&move, models.ServiceMoveCompleted, "the service was never deployed; only its record moved")
+		return
+	}
+
+	if err := s.moveWorkload(&move, &state); err != nil {
+		s.rollBack(&move, state, err)
+		return
+	}
+
+	// The service runs in the target environment now; leftovers in the source only cost resources
+	message := ""
+	if err := utils.DeleteKubernetesResources(state.source); err != nil {
+		log.Printf("Failed to remove service %s from environment %s after moving it: %v", state.source.ID, state.source.EnvironmentID, err)
+		message = fmt.Sprintf("the service moved, but some resources were left in the source environment: %v", err)
+	}
+	s.finish(&move, models.ServiceMoveCompleted, message)
+}
+
+// moveWorkload recreates the service in the target namespace, waits until it is ready and
+// switches traffic and the service record over to it
+func (s *ServiceMoveService) moveWorkload(move *models.ServiceMove, state *serviceMoveState) error {
+	target := state.target
+	target.DeferIngress = true
+
+	var deployed *models.Service
+	var err error
+	if state.source.Type == models.ServiceTypeManaged {
+		if utils.RequiresPersistentStorage(state.source.ManagedType) {
+			s.progress(move, models.ServiceMoveCopying)
+			if state.replicas, err = utils.GetManagedServiceReplicas(state.source); err != nil {
+				return err
+			}
+			if err := utils.ScaleManagedService(state.source, 0); err != nil {
+				return fmt.Errorf("failed to stop the service in the source environment: %v", err)
+			}
+			state.stopped = true
+			if err := utils.MoveServiceVolumes(state.source, target); err != nil {
+				return err
+			}
+		}
+
+		s.progress(move, models.ServiceMoveDeploying)
+		if deployed, err = s.managedService.deployManagedServiceToKubernetes(target); err != nil {
+			return fmt.Errorf("failed to deploy to the target environment: %v", err)
+		}
+		if err := utils.WaitForManagedServiceReady(*deployed, utils.ManagedServiceReadyTimeout); err != nil {
+			return fmt.Errorf("the service did not become ready in the target environment: %v", err)
+		}
+	} else {
+		s.progress(move, models.ServiceMoveDeploying)
+		if deployed, err = s.deploymentService.DeployToKubernetes(state.image, target); err != nil {
+			return fmt.Errorf("failed to deploy to the target environment: %v", err)
+		}
+		if err := utils.WaitForGitServiceReady(*deployed, utils.ServiceMoveReadyTimeout); err != nil {
+			return fmt.Errorf("the service did not become ready in the target environment: %v", err)
+		}
+	}
+
+	s.progress(move, models.ServiceMoveSwitching)
+	deployed.DeferIngress = false
+	deployed.Status = "running"
+	state.target = *deployed
+	state.flipped = true
+	if err := utils.FlipServiceIngresses(state.source, state.target); err != nil {
+		return err
+	}
+
+	state.switched = true
+	if err := s.serviceRepo.UpdateColumns(state.target, movedColumns(state.target)...); err != nil {
+		return fmt.Errorf("failed to move the service record: %v", err)
+	}
+	// The TCP proxy routes by namespace, so managed services are reachable at the same port
+	if state.source.Type == models.ServiceTypeManaged {
+		if err := s.managedService.ensureTCPProxyFromDB(); err != nil {
+			return fmt.Errorf("failed to switch the TCP proxy: %v", err)
+		}
+	}
+	return nil
+}
+
+// rollBack undoes the steps of a failed move: the service record, Ingresses and TCP proxy
+// point at the source again, the source workload is restarted and the target is removed
+func (s *ServiceMoveService) rollBack(move *models.ServiceMove, state serviceMoveState, cause error) {
+	log.Printf("Move %s of service %s failed, rolling back: %v", move.ID, move.ServiceID, cause)
+	var rollbackErrors []string
+
+	if state.switched {
+		if err := s.serviceRepo.UpdateColumns(state.source, movedColumns(state.source)...); err != nil {
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("service record: %v", err))
+		}
+	}
+	if state.flipped {
+		if err := utils.FlipServiceIngresses(state.target, resolveClusterConfig(state.source)); err != nil {
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("ingresses: %v", err))
+		}
+	}
+	if err := utils.DeleteKubernetesResources(state.target); err != nil {
+		rollbackErrors = append(rollbackErrors, fmt.Sprintf("target resources: %v", err))
+	}
+	if state.stopped {
+		if err := utils.ScaleManagedService(state.source, state.replicas); err != nil {
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("restart: %v", err))
+		}
+	}
+	if state.switched && state.source.Type == models.ServiceTypeManaged {
+		if err := s.managedService.ensureTCPProxyFromDB(); err != nil {
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("TCP proxy: %v", err))
+		}
+	}
+
+	if len(rollbackErrors) > 0 {
+		s.finish(move, models.ServiceMoveFailed, fmt.Sprintf("%v; rolling back failed: %s", cause, strings.Join(rollbackErrors, "; ")))
+		return
+	}
+	s.finish(move, models.ServiceMoveRolledBack, cause.Error())
+}
+
+// movedColumns are the fields of the service record a move changes. The rest is left alone,
+// as the service may be edited while it moves, and a git service's env vars hold the
+// resolved project secrets after deploying.
+func movedColumns(service models.Service) []string {
+	if service.Type == models.ServiceTypeManaged {
+		return []string{"EnvironmentID", "Status", "Port", "EnvVars", "ExternalHost", "ExternalPort"}
+	}
+	return []string{"EnvironmentID", "Status", "Domain"}
+}
+
+func (s *ServiceMoveService) progress(move *models.ServiceMove, status string) {
+	move.Status = status
+	if err := s.moveRepo.Update(*move); err != nil {
+		log.Printf("Failed to record service move %s: %v", move.ID, err)
+	}
+}
+
+func (s *ServiceMoveService) finish(move *models.ServiceMove, status, message string) {
+	now := time.Now()
+	move.Status = status
+	move.Message = message
+	move.CompletedAt = &now
+	if err := s.moveRepo.Update(*move); err != nil {
+		log.Printf("Failed to record service move %s: %v", move.ID, err)
+	}
+	log.Printf("Move %s of service %s from %s to %s: %s %s", move.ID, move.ServiceID, move.SourceEnvironmentID, move.TargetEnvironmentID, status, message)
+}
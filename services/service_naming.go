@@ -0,0 +1,270 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// maxNameSuffix bounds the search for a free "-N" suffix
+const maxNameSuffix = 100
+
+// NameConflictError reports a name or hostname that is already taken, with a free alternative when one exists
+type NameConflictError struct {
+	Field      string `json:"field"`
+	Value      string `json:"value"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Reason     string `json:"-"`
+}
+
+func (e *NameConflictError) Error() string {
+	message := fmt.Sprintf("%s %q %s", e.Field, e.Value, e.Reason)
+	if e.Suggestion != "" {
+		message += fmt.Sprintf("; try %q", e.Suggestion)
+	}
+	return message
+}
+
+// checkServiceNameAvailable rejects a name already used by another service in the environment
+func (s *ServiceService) checkServiceNameAvailable(name string, environmentID string, excludeID string) error {
+	taken, err := s.serviceRepo.ExistsByNameInEnvironment(name, environmentID, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to check service name: %v", err)
+	}
+	if !taken {
+		return nil
+	}
+
+	conflict := &NameConflictError{Field: "name", Value: name, Reason: "is already used by another service in this environment"}
+	for i := 2; i <= maxNameSuffix; i++ {
+		candidate := suffixLabel(name, i)
+		taken, err := s.serviceRepo.ExistsByNameInEnvironment(candidate, environmentID, excludeID)
+		if err != nil {
+			return fmt.Errorf("failed to check service name: %v", err)
+		}
+		if !taken {
+			conflict.Suggestion = candidate
+			break
+		}
+	}
+	return conflict
+}
+
+// checkCustomDomainAvailable rejects a custom domain already served by another service
+func (s *ServiceService) checkCustomDomainAvailable(domain string, excludeID string) error {
+	taken, err := s.serviceRepo.ExistsByHostname(domain, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to check custom domain: %v", err)
+	}
+	if taken {
+		return &NameConflictError{Field: "customDomain", Value: domain, Reason: "is already served by another service"}
+	}
+	return nil
+}
+
+// resolveDefaultDomain pins a suffixed generated domain on a new git service when its
+// default domain (repo-branch.env, or the environment's domain template) is already used by
+// another service. Templated domains are always pinned: they may fall outside the
+// environment's own subdomain, so they are checked against every service.
+func (s *ServiceService) resolveDefaultDomain(service *models.Service) error {
+	if service.Type != models.ServiceTypeGit || service.Domain != "" {
+		return nil
+	}
+
+	existing, err := s.serviceRepo.FindByEnvironmentID(service.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to load environment services: %v", err)
+	}
+
+	used := make(map[string]bool, len(existing))
+	for _, other := range existing {
+		if other.Domain != "" {
+			used[strings.ToLower(other.Domain)] = true
+		} else if other.Type == models.ServiceTypeGit {
+			other.DomainTemplate = service.DomainTemplate
+			used[strings.ToLower(utils.GetDefaultDomainName(other))] = true
+		}
+	}
+
+	domain := strings.ToLower(utils.GetDefaultDomainName(*service))
+	if !used[domain] {
+		if service.DomainTemplate == "" {
+			return nil
+		}
+		taken, err := s.serviceRepo.ExistsByHostname(domain, "")
+		if err != nil {
+			return fmt.Errorf("failed to check domain: %v", err)
+		}
+		if !taken {
+			service.Domain = domain
+			return nil
+		}
+	}
+
+	host, rest, _ := strings.Cut(domain, ".")
+	for i := 2; i <= maxNameSuffix; i++ {
+		candidate := suffixLabel(host, i) + "." + rest
+		if used[candidate] {
+			continue
+		}
+		taken, err := s.serviceRepo.ExistsByHostname(candidate, "")
+		if err != nil {
+			return fmt.Errorf("failed to check domain: %v", err)
+		}
+		if !taken {
+			service.Domain = candidate
+			return nil
+		}
+	}
+	return &NameConflictError{Field: "domain", Value: domain, Reason: "and its suffixed variants are all in use"}
+}
+
+// resolveDomainTemplate fills in the domain template of the service's environment
+func resolveDomainTemplate(service models.Service) models.Service {
+	env, err := repositories.NewEnvironmentRepository().FindByID(service.EnvironmentID)
+	if err != nil {
+		log.Printf("Failed to load the domain template of environment %s: %v", service.EnvironmentID, err)
+		return service
+	}
+	service.DomainTemplate = utils.ExpandEnvironmentDomainTemplate(env)
+	return service
+}
+
+// regenerateEnvironmentDomains moves the git services of an environment to the hostnames of
+// its current domain template and re-applies the Ingresses of deployed ones. Older services
+// are placed first so they keep the unsuffixed hostname when two collide. Failures are
+// returned per service ID.
+func regenerateEnvironmentDomains(serviceRepo *repositories.ServiceRepository, env models.Environment) (map[string]string, error) {
+	services, err := serviceRepo.FindByEnvironmentID(env.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment services: %v", err)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].CreatedAt.Before(services[j].CreatedAt)
+	})
+
+	used := make(map[string]bool, len(services))
+	for _, service := range services {
+		if service.CustomDomain != "" {
+			used[strings.ToLower(service.CustomDomain)] = true
+		}
+	}
+
+	template := utils.ExpandEnvironmentDomainTemplate(env)
+	failed := make(map[string]string)
+	for _, service := range services {
+		if service.Type != models.ServiceTypeGit {
+			continue
+		}
+		service.DomainTemplate = template
+		domain, err := nextFreeEnvironmentDomain(serviceRepo, service, used)
+		if err != nil {
+			failed[service.ID] = err.Error()
+			continue
+		}
+		used[domain] = true
+		if strings.EqualFold(domain, service.Domain) {
+			continue
+		}
+
+		if err := serviceRepo.UpdateDomain(service.ID, domain); err != nil {
+			failed[service.ID] = fmt.Sprintf("failed to save domain: %v", err)
+			continue
+		}
+		log.Printf("Service %s moved from %s to %s after the domain template of environment %s changed",
+			service.ID, service.Domain, domain, env.ID)
+		service.Domain = domain
+		if err := utils.ApplyServiceIngress(service); err != nil {
+			failed[service.ID] = fmt.Sprintf("failed to apply ingress: %v", err)
+		}
+	}
+	return failed, nil
+}
+
+// nextFreeEnvironmentDomain returns the service's generated hostname, suffixed when it is
+// used within the environment or by a service of another environment
+func nextFreeEnvironmentDomain(serviceRepo *repositories.ServiceRepository, service models.Service, used map[string]bool) (string, error) {
+	domain := strings.ToLower(utils.GetDefaultDomainName(service))
+	host, rest, _ := strings.Cut(domain, ".")
+	for i := 1; i <= maxNameSuffix; i++ {
+		candidate := domain
+		if i > 1 {
+			candidate = suffixLabel(host, i) + "." + rest
+		}
+		if used[candidate] {
+			continue
+		}
+		taken, err := serviceRepo.ExistsByHostnameOutsideEnvironment(candidate, service.EnvironmentID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check domain: %v", err)
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", &NameConflictError{Field: "domain", Value: domain, Reason: "and its suffixed variants are all in use"}
+}
+
+// resolveInternalAlias gives a new service an internal alias derived from its name, suffixed
+// when another service in the environment already uses it. Names that yield no valid alias
+// leave the service without one.
+func (s *ServiceService) resolveInternalAlias(service *models.Service) error {
+	alias := utils.DefaultInternalAlias(service.Name)
+	if alias == "" {
+		return nil
+	}
+	for i := 1; i <= maxNameSuffix; i++ {
+		candidate := alias
+		if i > 1 {
+			candidate = suffixLabel(alias, i)
+		}
+		taken, err := s.serviceRepo.ExistsByInternalAlias(candidate, service.EnvironmentID, "")
+		if err != nil {
+			return fmt.Errorf("failed to check internal alias: %v", err)
+		}
+		if !taken {
+			service.InternalAlias = candidate
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkInternalAliasAvailable rejects an alias already used by another service in the environment
+func (s *ServiceService) checkInternalAliasAvailable(alias string, environmentID string, excludeID string) error {
+	taken, err := s.serviceRepo.ExistsByInternalAlias(alias, environmentID, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to check internal alias: %v", err)
+	}
+	if !taken {
+		return nil
+	}
+
+	conflict := &NameConflictError{Field: "internalAlias", Value: alias, Reason: "is already used by another service in this environment"}
+	for i := 2; i <= maxNameSuffix; i++ {
+		candidate := suffixLabel(alias, i)
+		taken, err := s.serviceRepo.ExistsByInternalAlias(candidate, environmentID, excludeID)
+		if err != nil {
+			return fmt.Errorf("failed to check internal alias: %v", err)
+		}
+		if !taken {
+			conflict.Suggestion = candidate
+			break
+		}
+	}
+	return conflict
+}
+
+// suffixLabel appends "-n" to a DNS label, trimming it to stay within 63 characters
+func suffixLabel(label string, n int) string {
+	suffix := fmt.Sprintf("-%d", n)
+	if len(label)+len(suffix) > 63 {
+		label = strings.TrimRight(label[:63-len(suffix)], "-")
+	}
+	return label + suffix
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+)
+
+// PatchService applies a JSON merge patch to the service's updatable fields. A patch that
+// changes nothing is a no-op; otherwise the regular update stores the changes, records a
+// revision and, depending on the changed fields, rolls out the running image again or
+// rebuilds. Fields the update cannot clear keep their value when patched to empty, so the
+// returned plan is computed from the service as it was saved.
+func (s *ServiceService) PatchService(serviceID string, patch []byte, userID string, isAdmin bool) (models.Service, dto.ServiceUpdatePlan, error) {
+	existing, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return existing, dto.ServiceUpdatePlan{}, err
+	}
+	existing.Deployments = nil
+
+	desired, err := utils.ApplyServiceMergePatch(existing, patch)
+	if err != nil {
+		return existing, dto.ServiceUpdatePlan{}, err
+	}
+	// Masked values sent back from a GET keep the secret
+	desired.EnvVars = utils.KeepMaskedEnvValues(desired.EnvVars, existing.EnvVars)
+
+	plan := utils.PlanServiceUpdate(existing, desired)
+	if len(plan.ChangedFields) == 0 {
+		return existing, plan, nil
+	}
+
+	check := desired
+	if check.Type == models.ServiceTypeManaged {
+		// Auto-generated, not part of the request
+		check.EnvVars = nil
+	}
+	if err := utils.ValidateServiceRequest(serviceToRequest(check)); err != nil {
+		return existing, plan, err
+	}
+	placement, err := s.CheckPlacement(desired)
+	if err != nil {
+		return existing, plan, err
+	}
+	if !placement.Schedulable {
+		return existing, plan, fmt.Errorf("insufficient cluster capacity: %s", placement.Reason)
+	}
+
+	updated, err := s.updateService(desired, userID, isAdmin)
+	if err != nil {
+		return updated, plan, err
+	}
+	s.recordRevision(updated, userID, nil)
+
+	plan = utils.PlanServiceUpdate(existing, updated)
+	log.Printf("Service %s patched by %s: %v (%s)", serviceID, userID, plan.ChangedFields, plan.Action)
+	return updated, plan, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"gorm.io/gorm"
+)
+
+// ErrServiceRevisionNotFound is returned for revisions the service does not have
+var ErrServiceRevisionNotFound = errors.New("service revision not found")
+
+// ListRevisions returns a page of the service's config revisions, newest first
+func (s *ServiceService) ListRevisions(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.ServiceRevisionListResponse, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceRevisionListResponse{}, err
+	}
+
+	revisions, total, err := s.revisionRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.ServiceRevisionListResponse{}, err
+	}
+	for i := range revisions {
+		revisions[i].EnvVars = service.MaskSecretEnvVars(revisions[i].EnvVars)
+	}
+	return dto.ServiceRevisionListResponse{
+		Revisions:  revisions,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// RevertToRevision restores the config of a prior revision through the regular update,
+// which redeploys the service. An empty custom domain or variable set in the revision
+// keeps the current one, as the update API cannot clear them.
+func (s *ServiceService) RevertToRevision(serviceID string, number int, userID string, isAdmin bool) (models.Service, error) {
+	existing, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return existing, err
+	}
+
+	revision, err := s.revisionRepo.FindByRevision(serviceID, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return existing, ErrServiceRevisionNotFound
+	}
+	if err != nil {
+		return existing, err
+	}
+	if revision.SameConfig(revisionSnapshot(existing)) {
+		return existing, fmt.Errorf("service already has the configuration of revision %d", number)
+	}
+
+	// Starting from the current service keeps everything the revision does not cover
+	target := existing
+	target.Deployments = nil
+	target.CPULimit = revision.CPULimit
+	target.MemoryLimit = revision.MemoryLimit
+	target.IsStaticReplica = revision.IsStaticReplica
+	target.Replicas = revision.Replicas
+	target.MinReplicas = revision.MinReplicas
+	target.MaxReplicas = revision.MaxReplicas
+	target.CustomDomain = revision.CustomDomain
+	if existing.Type == models.ServiceTypeGit {
+		target.EnvVars = revision.EnvVars
+	} else {
+		target.StorageSize = revision.StorageSize
+	}
+
+	updated, err := s.updateService(target, userID, isAdmin)
+	if err != nil {
+		return updated, err
+	}
+	s.recordRevision(updated, userID, &revision.Revision)
+	log.Printf("Service %s reverted to revision %d by %s", serviceID, number, userID)
+	return updated, nil
+}
+
+// recordRevision stores the service's config as a new revision when it changed. Failures
+// are only logged: the change itself has already been applied.
+func (s *ServiceService) recordRevision(service models.Service, userID string, revertedFrom *int) {
+	revision := revisionSnapshot(service)
+	revision.CreatedBy = userID
+	revision.RevertedFrom = revertedFrom
+	if _, err := s.revisionRepo.CreateIfChanged(&revision); err != nil {
+		log.Printf("Failed to record config revision of service %s: %v", service.ID, err)
+	}
+}
+
+// revisionSnapshot captures the config of a service tracked by revisions. Managed
+// services' variables are generated credentials, so they are not part of it.
+func revisionSnapshot(service models.Service) models.ServiceRevision {
+	revision := models.ServiceRevision{
+		ServiceID:       service.ID,
+		CPULimit:        service.CPULimit,
+		MemoryLimit:     service.MemoryLimit,
+		StorageSize:     service.StorageSize,
+		IsStaticReplica: service.IsStaticReplica,
+		Replicas:        service.Replicas,
+		MinReplicas:     service.MinReplicas,
+		MaxReplicas:     service.MaxReplicas,
+		CustomDomain:    service.CustomDomain,
+		EnvVars:         models.EnvVars{},
+	}
+	if service.Type == models.ServiceTypeGit && service.EnvVars != nil {
+		revision.EnvVars = service.EnvVars
+	}
+	return revision
+}
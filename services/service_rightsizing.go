@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/utils"
+)
+
+// defaultRightSizingWindowDays is the rolling window recommendations are computed over
+const defaultRightSizingWindowDays = 7
+
+// ErrNoRightSizing is returned when applying while the limits already fit the usage
+var ErrNoRightSizing = errors.New("the service's limits already fit its usage; nothing to apply")
+
+// GetRightSizing compares the service's p95 per-pod usage over the last windowDays with
+// its CPU and memory limits
+func (s *ServiceService) GetRightSizing(serviceID string, windowDays int, userID string, isAdmin bool) (dto.ServiceRightSizing, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceRightSizing{}, err
+	}
+	return s.rightSize(service, windowDays)
+}
+
+// ApplyRightSizing sets the recommended limits through the regular update, which records a
+// revision and redeploys the service. Resources limits which ones are applied.
+func (s *ServiceService) ApplyRightSizing(serviceID string, req dto.RightSizingApplyRequest, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+	sizing, err := s.rightSize(service, req.Days)
+	if err != nil {
+		return service, err
+	}
+
+	// Starting from the current service keeps everything else as it is
+	target := service
+	target.Deployments = nil
+	applied := false
+	for _, recommendation := range sizing.Recommendations {
+		if len(req.Resources) > 0 && !slices.Contains(req.Resources, recommendation.Resource) {
+			continue
+		}
+		if recommendation.Resource == "cpu" {
+			target.CPULimit = recommendation.RecommendedLimit
+		} else {
+			target.MemoryLimit = recommendation.RecommendedLimit
+		}
+		applied = true
+	}
+	if !applied {
+		return service, ErrNoRightSizing
+	}
+
+	// Growing limits can make the pods unschedulable
+	placement, err := s.CheckPlacement(target)
+	if err != nil {
+		return service, err
+	}
+	if !placement.Schedulable {
+		return service, fmt.Errorf("insufficient cluster capacity: %s", placement.Reason)
+	}
+
+	updated, err := s.updateService(target, userID, isAdmin)
+	if err != nil {
+		return updated, err
+	}
+	s.recordRevision(updated, userID, nil)
+	log.Printf("Service %s right-sized to CPU %s, memory %s by %s", serviceID, updated.CPULimit, updated.MemoryLimit, userID)
+	return updated, nil
+}
+
+// rightSize builds the recommendations of a service from its usage history
+func (s *ServiceService) rightSize(service models.Service, windowDays int) (dto.ServiceRightSizing, error) {
+	if windowDays <= 0 {
+		windowDays = defaultRightSizingWindowDays
+	}
+	sizing := dto.ServiceRightSizing{
+		ServiceID:       service.ID,
+		WindowDays:      windowDays,
+		CPULimit:        service.CPULimit,
+		MemoryLimit:     service.MemoryLimit,
+		Recommendations: []dto.RightSizeRecommendation{},
+	}
+
+	percentiles, err := s.usageRepo.FindServicePercentiles(service.ID, time.Now().AddDate(0, 0, -windowDays))
+	if err != nil {
+		return sizing, err
+	}
+	sizing.Samples = percentiles.Samples
+	if percentiles.Samples == 0 {
+		sizing.Note = "No usage recorded for this service yet"
+		return sizing, nil
+	}
+
+	sizing.SampledFrom = &percentiles.FirstSampledAt
+	sizing.CPUP95 = utils.FormatResourceUsage("cpu", percentiles.CPUP95)
+	sizing.CPUPeak = utils.FormatResourceUsage("cpu", float64(percentiles.CPUMax))
+	sizing.MemoryP95 = utils.FormatResourceUsage("memory", percentiles.MemoryP95)
+	sizing.MemoryPeak = utils.FormatResourceUsage("memory", float64(percentiles.MemoryMax))
+	if percentiles.Samples < utils.MinRightSizeSamples {
+		sizing.Note = fmt.Sprintf("Recommendations need at least %d usage samples", utils.MinRightSizeSamples)
+		return sizing, nil
+	}
+
+	if recommendations := rightSizeService(service, percentiles); len(recommendations) > 0 {
+		sizing.Recommendations = recommendations
+	}
+	return sizing, nil
+}
@@ -3,10 +3,15 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/pendeploy-simple/dto"
 	"github.com/pendeploy-simple/models"
 	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
 )
 
 // ServiceService handles business logic for services (UPDATED untuk managed services)
@@ -18,6 +23,12 @@ type ServiceService struct {
 	deploymentService *DeploymentService
 	gitService        *GitService
 	managedService    *ManagedServiceService // NEW: Managed service handler
+	nodeStatsService  *NodeStatsService
+	revisionRepo      *repositories.ServiceRevisionRepository
+	revealAuditRepo   *repositories.EnvRevealAuditRepository
+	envVarChangeRepo  *repositories.EnvVarChangeRepository
+	usageRepo         *repositories.UsageSampleRepository
+	domainRepo        *repositories.DomainStatusRepository
 }
 
 // NewServiceService creates a new service service instance (UPDATED)
@@ -30,13 +41,42 @@ func NewServiceService() *ServiceService {
 		deploymentService: NewDeploymentService(),
 		gitService:        NewGitService(),
 		managedService:    NewManagedServiceService(), // NEW
+		nodeStatsService:  NewNodeStatsService(),
+		revisionRepo:      repositories.NewServiceRevisionRepository(),
+		revealAuditRepo:   repositories.NewEnvRevealAuditRepository(),
+		envVarChangeRepo:  repositories.NewEnvVarChangeRepository(),
+		usageRepo:         repositories.NewUsageSampleRepository(),
+		domainRepo:        repositories.NewDomainStatusRepository(),
 	}
 }
 
-func (s *ServiceService) GetDeploymentList(serviceID string, userID string, isAdmin bool) ([]dto.DeploymentResponse, error) {
-	deployments, err := s.deploymentRepo.FindByServiceID(serviceID)
+// GetDeploymentList retrieves a service's deployments with pagination, filtering and sorting
+func (s *ServiceService) GetDeploymentList(serviceID string, filter dto.DeploymentFilter, userID string, isAdmin bool) (dto.DeploymentListResponse, error) {
+	var response dto.DeploymentListResponse
+
+	// Set defaults if not provided
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	// A PageSize of 0 or less returns every row on a single page
+	if filter.PageSize < 0 {
+		filter.PageSize = 0
+	}
+
+	deployments, total, err := s.deploymentRepo.FindByServiceIDWithPagination(
+		serviceID,
+		filter.Page,
+		filter.PageSize,
+		filter.SortBy,
+		filter.SortOrder,
+		filter.Status,
+		filter.ImageDigest,
+		filter.CreatedFrom,
+		filter.CreatedTo,
+	)
 	if err != nil {
-		return nil, err
+		return response, err
 	}
 	
 	// Map deployments to DTOs for API stability
@@ -44,13 +84,118 @@ func (s *ServiceService) GetDeploymentList(serviceID string, userID string, isAd
 	for i, deployment := range deployments {
 		deploymentResponses[i] = dto.NewDeploymentResponseFromModel(deployment)
 	}
+
+	response = dto.DeploymentListResponse{
+		Deployments: deploymentResponses,
+		TotalCount:  total,
+		Page:        filter.Page,
+		PageSize:    filter.PageSize,
+		TotalPages:  pageCount(total, filter.PageSize),
+	}
 	
-	return deploymentResponses, nil
+	return response, nil
 }
 
-// ListAllServices retrieves all services (admin only)
-func (s *ServiceService) ListAllServices() ([]models.Service, error) {
-	return s.serviceRepo.FindAll()
+// ListAllServices retrieves services with pagination, filtering and sorting (admin only)
+func (s *ServiceService) ListAllServices(filter dto.ServiceFilter) (dto.ServiceListResponse, error) {
+	var response dto.ServiceListResponse
+
+	// Set defaults if not provided
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	// A PageSize of 0 or less returns every row on a single page
+	if filter.PageSize < 0 {
+		filter.PageSize = 0
+	}
+
+	services, total, err := s.serviceRepo.FindWithPagination(
+		filter.Page,
+		filter.PageSize,
+		filter.SortBy,
+		filter.SortOrder,
+		filter.Search,
+		filter.Status,
+		filter.Type,
+		filter.EnvironmentID,
+		filter.ProjectID,
+		filter.CreatedFrom,
+		filter.CreatedTo,
+	)
+	if err != nil {
+		return response, err
+	}
+
+	response = dto.ServiceListResponse{
+		Services:   services,
+		TotalCount: total,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		TotalPages: pageCount(total, filter.PageSize),
+	}
+
+	return response, nil
+}
+
+// pageCount returns the number of pages total rows span, a pageSize of 0 putting them all on one
+func pageCount(total int64, pageSize int) int {
+	if pageSize <= 0 {
+		if total > 0 {
+			return 1
+		}
+		return 0
+	}
+	return int(math.Ceil(float64(total) / float64(pageSize)))
+}
+
+// GetBatchStatus returns the cached workload status of several services. IDs that
+// don't exist or aren't accessible are reported with an error instead of failing the batch.
+func (s *ServiceService) GetBatchStatus(serviceIDs []string, userID string, isAdmin bool) ([]dto.ServiceStatusSummary, error) {
+	services, err := s.serviceRepo.FindByIDs(serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.Service, len(services))
+	ownerByProject := make(map[string]string)
+	for _, service := range services {
+		if !isAdmin {
+			ownerID, ok := ownerByProject[service.ProjectID]
+			if !ok {
+				ownerID, _ = s.projectRepo.GetOwnerID(service.ProjectID)
+				ownerByProject[service.ProjectID] = ownerID
+			}
+			if ownerID != userID {
+				continue
+			}
+		}
+		byID[service.ID] = service
+	}
+
+	accessible := make([]models.Service, 0, len(byID))
+	for _, service := range byID {
+		accessible = append(accessible, service)
+	}
+	cached, err := utils.GetBatchServiceStatus(accessible)
+	if err != nil {
+		return nil, err
+	}
+	summaryByID := make(map[string]dto.ServiceStatusSummary, len(cached))
+	for _, summary := range cached {
+		summaryByID[summary.ServiceID] = summary
+	}
+
+	// Keep the order of the request
+	summaries := make([]dto.ServiceStatusSummary, 0, len(serviceIDs))
+	for _, id := range serviceIDs {
+		summary, ok := summaryByID[id]
+		if !ok {
+			summary = dto.ServiceStatusSummary{ServiceID: id, Error: "service not found"}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
 }
 
 // ListProjectServices retrieves all services for a project
@@ -89,30 +234,191 @@ func (s *ServiceService) GetServiceDetail(serviceID string, userID string, isAdm
 			return service, errors.New("unauthorized access to service")
 		}
 	}
+
+	// Managed services report probe-derived health so booting databases don't show as running
+	if service.Type == models.ServiceTypeManaged && (service.Status == "running" || service.Status == "starting") {
+		health, err := utils.GetManagedServiceHealth(service)
+		if err != nil {
+			log.Printf("Failed to get health for service %s: %v", service.ID, err)
+		} else {
+			service.Health = health
+			if service.Status == "running" && health.Status != models.ServiceHealthHealthy {
+				service.Status = "starting"
+			}
+		}
+	}
+
+	if service.Type == models.ServiceTypeManaged && service.VPAMode != "" {
+		recommendation, err := utils.GetVPARecommendation(service)
+		if err != nil {
+			log.Printf("Failed to get VPA recommendation for service %s: %v", service.ID, err)
+		} else {
+			service.VPARecommendation = recommendation
+		}
+	}
+
+	if service.Type == models.ServiceTypeGit {
+		statuses, err := s.domainRepo.FindByServiceID(service.ID)
+		if err != nil {
+			log.Printf("Failed to get domain statuses for service %s: %v", service.ID, err)
+		} else {
+			service.DomainStatuses = statuses
+		}
+	}
 	
 	return service, nil
 }
 
+// CheckPlacement checks the service's CPU/memory limits against node allocatable capacity
+// so that unschedulable configurations are rejected instead of leaving pods Pending
+func (s *ServiceService) CheckPlacement(service models.Service) (dto.PlacementCheckResult, error) {
+	// Autoscaled services can grow up to MaxReplicas, so check the worst case
+	replicas := service.Replicas
+	if !service.IsStaticReplica && service.MaxReplicas > replicas {
+		replicas = service.MaxReplicas
+	}
+	// A Sentinel topology runs a replica next to the primary
+	if utils.IsRedisSentinel(service) && replicas < utils.RedisSentinelDataReplicas {
+		replicas = utils.RedisSentinelDataReplicas
+	}
+
+	placement, err := s.nodeStatsService.CheckPlacement(service.CPULimit, service.MemoryLimit, replicas)
+	if err != nil || !placement.Schedulable || !service.HighAvailability {
+		return placement, err
+	}
+	return s.checkHighAvailability(service, placement), nil
+}
+
+// checkHighAvailability rejects high availability services that cannot have replicas on
+// different nodes: the node spread is strict, so extra replicas would stay Pending
+func (s *ServiceService) checkHighAvailability(service models.Service, placement dto.PlacementCheckResult) dto.PlacementCheckResult {
+	minReplicas := service.Replicas
+	if !service.IsStaticReplica {
+		minReplicas = service.MinReplicas
+	}
+	if minReplicas < utils.MinHighAvailabilityReplicas {
+		placement.Schedulable = false
+		placement.Reason = fmt.Sprintf("high availability needs at least %d replicas (minReplicas when autoscaling)", utils.MinHighAvailabilityReplicas)
+		return placement
+	}
+
+	nodes, err := s.nodeStatsService.GetNodeCapacity()
+	if err != nil {
+		log.Printf("Warning: skipping high availability node check: %v", err)
+		placement.Warnings = append(placement.Warnings, "Node count could not be checked; replicas may not spread across nodes")
+		return placement
+	}
+	if len(nodes) < utils.MinHighAvailabilityReplicas {
+		placement.Schedulable = false
+		placement.Reason = fmt.Sprintf("high availability needs at least %d schedulable nodes; the cluster has %d", utils.MinHighAvailabilityReplicas, len(nodes))
+	}
+	return placement
+}
+
 // CreateService creates a new service - UPDATED untuk handle managed services
 func (s *ServiceService) CreateService(service models.Service, userID string, isAdmin bool) (models.Service, error) {
+	env, err := s.environmentRepo.FindByID(service.EnvironmentID)
+	if err != nil {
+		return service, fmt.Errorf("environment not found: %v", err)
+	}
+	if env.IsArchived() {
+		return service, errors.New("environment is archived; unarchive it before adding services")
+	}
+	applyEnvironmentDefaults(&service, env)
+	if err := utils.CheckEnvironmentResourceRanges(env, "", service.CPULimit, service.MemoryLimit, service.StorageSize); err != nil {
+		return service, err
+	}
+
+	// Generated hostnames live under the project's base domain
+	if project, err := s.projectRepo.FindByID(service.ProjectID); err == nil {
+		service.BaseDomain = project.BaseDomain
+	}
+
+	// Names and generated hostnames must not collide within the environment
+	if err := s.checkServiceNameAvailable(service.Name, service.EnvironmentID, ""); err != nil {
+		return service, err
+	}
+	if service.CustomDomain != "" {
+		if err := s.checkCustomDomainAvailable(service.CustomDomain, ""); err != nil {
+			return service, err
+		}
+	}
+	service.DomainTemplate = utils.ExpandEnvironmentDomainTemplate(env)
+	if err := s.resolveDefaultDomain(&service); err != nil {
+		return service, err
+	}
+	if err := s.resolveInternalAlias(&service); err != nil {
+		return service, err
+	}
+
 	// Route to appropriate service type handler
+	var created models.Service
 	switch service.Type {
 	case models.ServiceTypeGit:
-		return s.gitService.CreateGitService(service, userID, isAdmin)
+		created, err = s.gitService.CreateGitService(service, userID, isAdmin)
 	case models.ServiceTypeManaged:
-		return s.managedService.CreateManagedService(service, userID, isAdmin) // NEW
+		created, err = s.managedService.CreateManagedService(service, userID, isAdmin) // NEW
 	default:
 		return service, errors.New("invalid service type")
 	}
+	if err != nil {
+		return created, err
+	}
+	s.recordRevision(created, userID, nil)
+	return created, nil
 }
 
 // UpdateService updates an existing service - UPDATED untuk handle managed services
 func (s *ServiceService) UpdateService(newService models.Service, userID string, isAdmin bool) (models.Service, error) {
+	updated, err := s.updateService(newService, userID, isAdmin)
+	if err != nil {
+		return updated, err
+	}
+	s.recordRevision(updated, userID, nil)
+	return updated, nil
+}
+
+// updateService applies the changes without recording a config revision
+func (s *ServiceService) updateService(newService models.Service, userID string, isAdmin bool) (models.Service, error) {
+	return s.applyServiceUpdate(newService, userID, isAdmin, false)
+}
+
+// applyServiceUpdate applies the changes; env var changes in an environment that requires
+// their approval are only applied once approved
+func (s *ServiceService) applyServiceUpdate(newService models.Service, userID string, isAdmin bool, envVarsApproved bool) (models.Service, error) {
 	// Get existing service to determine type
 	existingService, err := s.serviceRepo.FindByID(newService.ID)
 	if err != nil {
 		return newService, fmt.Errorf("service not found: %v", err)
 	}
+
+	if env, err := s.environmentRepo.FindByID(existingService.EnvironmentID); err == nil {
+		if env.IsArchived() {
+			return newService, errors.New("environment is archived; unarchive it before changing its services")
+		}
+		if env.EnvVarApprovalRequired && !envVarsApproved && envVarsChanged(existingService, newService) {
+			return newService, ErrEnvVarApprovalRequired
+		}
+		// Only changed values are checked; existing ones may predate the ranges
+		if err := utils.CheckEnvironmentResourceRanges(env, "", changedValue(newService.CPULimit, existingService.CPULimit),
+			changedValue(newService.MemoryLimit, existingService.MemoryLimit), changedValue(newService.StorageSize, existingService.StorageSize)); err != nil {
+			return newService, err
+		}
+	}
+
+	if newService.Name != "" && !strings.EqualFold(newService.Name, existingService.Name) {
+		if err := s.checkServiceNameAvailable(newService.Name, existingService.EnvironmentID, existingService.ID); err != nil {
+			return newService, err
+		}
+	}
+	if newService.CustomDomain != "" && !strings.EqualFold(newService.CustomDomain, existingService.CustomDomain) {
+		if existingService.CustomTLSSecret != "" {
+			return newService, errors.New("remove the uploaded certificate before changing the custom domain")
+		}
+		if err := s.checkCustomDomainAvailable(newService.CustomDomain, existingService.ID); err != nil {
+			return newService, err
+		}
+	}
 	
 	// Route to appropriate service type handler
 	switch existingService.Type {
@@ -133,6 +439,9 @@ func (s *ServiceService) DeleteService(serviceID string, userID string, isAdmin
 	if err != nil {
 		return fmt.Errorf("service not found: %v", err)
 	}
+	if service.DeletionProtected {
+		return &ProtectedServicesError{Services: []string{service.Name}}
+	}
 	
 	// Route to appropriate service type handler
 	switch service.Type {
@@ -145,6 +454,239 @@ func (s *ServiceService) DeleteService(serviceID string, userID string, isAdmin
 	}
 }
 
+// SetDeletionProtection turns deletion protection of a service on or off
+func (s *ServiceService) SetDeletionProtection(serviceID string, protected bool, userID string, isAdmin bool) (models.Service, error) {
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil {
+		return service, fmt.Errorf("service not found: %v", err)
+	}
+
+	if !isAdmin {
+		ownerID, err := s.projectRepo.GetOwnerID(service.ProjectID)
+		if err != nil {
+			return service, err
+		}
+		if ownerID != userID {
+			return service, errors.New("unauthorized access to service")
+		}
+	}
+
+	if err := s.serviceRepo.UpdateDeletionProtection(serviceID, protected); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	service.DeletionProtected = protected
+	return service, nil
+}
+
+// PinDeployment pins a git service to one of its successful deployments, the latest one when
+// req.DeploymentID is empty. Pinning an older deployment rolls its image out again as a new
+// deployment, which becomes the pinned one.
+func (s *ServiceService) PinDeployment(serviceID string, req dto.ServicePinRequest, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return service, errors.New("only git services can be pinned")
+	}
+
+	latest, err := s.deploymentRepo.GetLatestSuccessfulDeployment(serviceID)
+	if err != nil {
+		return service, errors.New("service has no successful deployment to pin")
+	}
+	pinned := latest
+	if req.DeploymentID != "" && req.DeploymentID != latest.ID {
+		source, err := s.deploymentRepo.FindByID(req.DeploymentID)
+		if err != nil || source.ServiceID != serviceID {
+			return service, errors.New("deployment not found for this service")
+		}
+		if source.Status != models.DeploymentStatusSuccess {
+			return service, errors.New("only successful deployments can be pinned")
+		}
+		if pinned, err = s.deploymentService.RedeployImage(service, source, isAdmin); err != nil {
+			return service, err
+		}
+	}
+
+	now := time.Now()
+	reason := strings.TrimSpace(req.Reason)
+	if err := s.serviceRepo.UpdatePin(serviceID, &pinned.ID, &now, userID, reason); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	log.Printf("Service %s pinned to deployment %s by %s", serviceID, pinned.ID, userID)
+	service.PinnedDeploymentID = &pinned.ID
+	service.PinnedAt = &now
+	service.PinnedBy = userID
+	service.PinReason = reason
+	return service, nil
+}
+
+// UnpinDeployment lets webhook pushes deploy the service again. The next push deploys; the
+// running deployment is left as is.
+func (s *ServiceService) UnpinDeployment(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+	if service.PinnedDeploymentID == nil {
+		return service, nil
+	}
+
+	if err := s.serviceRepo.UpdatePin(serviceID, nil, nil, "", ""); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	log.Printf("Service %s unpinned by %s", serviceID, userID)
+	service.PinnedDeploymentID = nil
+	service.PinnedAt = nil
+	service.PinnedBy = ""
+	service.PinReason = ""
+	return service, nil
+}
+
+// SetInternalAlias changes the name other services in the environment reach the service at;
+// an empty alias removes it. A deployed service's alias is switched over at once.
+func (s *ServiceService) SetInternalAlias(serviceID string, alias string, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	if alias != "" {
+		var errs utils.FieldErrors
+		errs.CheckDNS1035Label("internalAlias", alias)
+		if utils.IsReservedInternalAlias(alias) {
+			errs.Add("internalAlias", "is reserved for platform resources")
+		}
+		if err := errs.Err(); err != nil {
+			return service, err
+		}
+		if err := s.checkInternalAliasAvailable(alias, service.EnvironmentID, service.ID); err != nil {
+			return service, err
+		}
+	}
+
+	if err := s.serviceRepo.UpdateInternalAlias(serviceID, alias); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	service.InternalAlias = alias
+	if err := utils.ApplyInternalAlias(service); err != nil {
+		// The next deploy reconciles the alias
+		log.Printf("Failed to apply internal alias of service %s: %v", serviceID, err)
+	}
+	return service, nil
+}
+
+// SetCachePolicy replaces the HTTP cache policy of a git service's routes. A deployed
+// service's Ingresses are updated at once; others get them with the first deployment.
+func (s *ServiceService) SetCachePolicy(serviceID string, rules models.CacheRules, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return service, errors.New("cache policies are only available for git services")
+	}
+
+	if rules == nil {
+		rules = models.CacheRules{}
+	}
+	if len(rules) > 0 && !utils.GetIngressProvider().SupportsMiddlewares() {
+		return service, utils.ErrMiddlewaresUnsupported
+	}
+	for i := range rules {
+		rules[i].PathPrefix = strings.TrimSpace(rules[i].PathPrefix)
+	}
+	if err := utils.ValidateCacheRules(rules); err != nil {
+		return service, err
+	}
+
+	if err := s.serviceRepo.UpdateCacheRules(serviceID, rules); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	service.CacheRules = rules
+	if err := utils.ApplyServiceIngress(service); err != nil {
+		return service, fmt.Errorf("cache policy saved but ingress update failed: %v", err)
+	}
+	return service, nil
+}
+
+// SetContainers replaces the companion containers of a git service and the volumes they share
+// with the app container. They are rolled out with the next deployment.
+func (s *ServiceService) SetContainers(serviceID string, req dto.ServiceContainersRequest, userID string, isAdmin bool) (models.Service, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return service, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return service, errors.New("companion containers are only available for git services")
+	}
+
+	if req.Containers == nil {
+		req.Containers = models.ServiceContainers{}
+	}
+	if req.SharedVolumes == nil {
+		req.SharedVolumes = models.SharedVolumes{}
+	}
+	if err := utils.ValidateServiceContainers(req.Containers, req.SharedVolumes); err != nil {
+		return service, err
+	}
+	if env, err := s.environmentRepo.FindByID(service.EnvironmentID); err == nil {
+		if err := utils.CheckServiceContainerResources(env, req.Containers); err != nil {
+			return service, err
+		}
+	}
+
+	if err := s.serviceRepo.UpdateContainers(serviceID, req.Containers, req.SharedVolumes); err != nil {
+		return service, fmt.Errorf("failed to update service: %v", err)
+	}
+	service.Containers = req.Containers
+	service.SharedVolumes = req.SharedVolumes
+	return service, nil
+}
+
+// applyEnvironmentDefaults fills resource settings the request left empty from the environment defaults
+func applyEnvironmentDefaults(service *models.Service, env models.Environment) {
+	if service.CPULimit == "" {
+		service.CPULimit = env.DefaultCPULimit
+	}
+	if service.MemoryLimit == "" {
+		service.MemoryLimit = env.DefaultMemoryLimit
+	}
+	if service.Replicas == 0 && env.DefaultReplicas > 0 {
+		service.Replicas = env.DefaultReplicas
+	}
+
+	if service.Type != models.ServiceTypeManaged {
+		return
+	}
+	// Platform presets fill what the environment does not define, here rather than in
+	// setManagedServiceDefaults so the environment ranges are checked against them
+	service.CPULimit = firstNonEmpty(service.CPULimit, utils.DefaultManagedCPULimit)
+	service.MemoryLimit = firstNonEmpty(service.MemoryLimit, utils.DefaultManagedMemoryLimit)
+	if utils.RequiresPersistentStorage(service.ManagedType) {
+		service.StorageSize = firstNonEmpty(service.StorageSize, env.DefaultStorageSize, utils.DefaultManagedStorageSize)
+	}
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// changedValue returns value when it is set and differs from current, otherwise ""
+func changedValue(value, current string) string {
+	if value == current {
+		return ""
+	}
+	return value
+}
+
 func (s *ServiceService) GetLatestDeployment(serviceID string, userID string, isAdmin bool) (dto.DeploymentResponse, error) {
 	// Verify this is a git service first
 	service, err := s.serviceRepo.FindByID(serviceID)
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+// sessionTTL is the lifetime of session JWTs
+const sessionTTL = 24 * time.Hour
+
+// sessionRevocations is the in-memory revocation list the auth middleware checks on every
+// request. Revocations made by this instance apply at once; those made by other replicas
+// are picked up when the list is reloaded (SESSION_REVOCATION_REFRESH_SECONDS, default 10).
+var sessionRevocations = &revocationCache{
+	sessions: map[string]time.Time{},
+	users:    map[string]time.Time{},
+	touched:  map[string]time.Time{},
+}
+
+type revocationCache struct {
+	mu       sync.RWMutex
+	sessions map[string]time.Time // revoked session ID -> token expiry
+	users    map[string]time.Time // user ID -> untracked tokens issued before are revoked
+	loadedAt time.Time
+	loading  bool
+
+	touchMu sync.Mutex
+	touched map[string]time.Time // session ID -> last recorded use
+}
+
+// SessionService tracks the sessions issued at login so users and admins can list and
+// revoke them before their tokens expire
+type SessionService struct {
+	sessionRepo *repositories.UserSessionRepository
+	tokenRepo   *repositories.APITokenRepository
+}
+
+// NewSessionService creates a new session service instance
+func NewSessionService() *SessionService {
+	return &SessionService{
+		sessionRepo: repositories.NewUserSessionRepository(),
+		tokenRepo:   repositories.NewAPITokenRepository(),
+	}
+}
+
+// CreateSession records a session for the user and returns its JWT. twoFactorSetup limits
+// the token to setting up 2FA.
+func (s *SessionService) CreateSession(user models.User, client LoginClient, twoFactorSetup bool) (string, time.Time, error) {
+	session, err := s.createSession(models.UserSession{
+		UserID:    user.ID,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+	}, sessionTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return generateToken(dto.TokenClaims{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Role:           string(user.Role),
+		TwoFactorSetup: twoFactorSetup,
+	}, session.ID, session.ExpiresAt)
+}
+
+// CreateImpersonationSession records a session in which admin acts as user and returns its
+// JWT, which carries the admin as impersonator
+func (s *SessionService) CreateImpersonationSession(admin models.User, user models.User, reason string, client LoginClient, ttl time.Duration) (models.UserSession, string, error) {
+	session, err := s.createSession(models.UserSession{
+		UserID:              user.ID,
+		IPAddress:           client.IPAddress,
+		UserAgent:           client.UserAgent,
+		ImpersonatorID:      &admin.ID,
+		ImpersonationReason: reason,
+	}, ttl)
+	if err != nil {
+		return session, "", err
+	}
+	token, _, err := generateToken(dto.TokenClaims{
+		UserID:            user.ID,
+		Email:             user.Email,
+		Role:              string(user.Role),
+		ImpersonatorID:    admin.ID,
+		ImpersonatorEmail: admin.Email,
+	}, session.ID, session.ExpiresAt)
+	return session, token, err
+}
+
+func (s *SessionService) createSession(session models.UserSession, ttl time.Duration) (models.UserSession, error) {
+	now := time.Now()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(ttl)
+	return s.sessionRepo.Create(session)
+}
+
+// ListSessions returns the user's active sessions, marking the one making the request, and
+// API tokens
+func (s *SessionService) ListSessions(userID string, currentSessionID string) (dto.SessionListResponse, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return dto.SessionListResponse{}, err
+	}
+	tokens, err := s.tokenRepo.FindByUserID(userID)
+	if err != nil {
+		return dto.SessionListResponse{}, err
+	}
+
+	infos := make([]dto.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, dto.SessionInfo{
+			ID:             session.ID,
+			IPAddress:      session.IPAddress,
+			UserAgent:      session.UserAgent,
+			Current:        session.ID == currentSessionID,
+			CreatedAt:      session.CreatedAt,
+			LastSeenAt:     session.LastSeenAt,
+			ExpiresAt:      session.ExpiresAt,
+			ImpersonatorID: session.ImpersonatorID,
+		})
+	}
+	return dto.SessionListResponse{Sessions: infos, APITokens: tokens}, nil
+}
+
+// RevokeSession revokes one of the user's sessions; its token stops working at once
+func (s *SessionService) RevokeSession(sessionID string, userID string) error {
+	revoked, err := s.sessionRepo.Revoke(sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	sessionRevocations.revokeSession(sessionID, time.Now().Add(sessionTTL))
+	return nil
+}
+
+// EndSession revokes the session of a token at logout. Invalid and untracked tokens are
+// ignored: logging out always succeeds.
+func (s *SessionService) EndSession(tokenString string) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil || claims.ID == "" {
+		return
+	}
+	if claims.ImpersonatorID != "" {
+		if err := NewImpersonationService().End(claims.ID, claims.UserID, claims.ImpersonatorID, ""); err != nil {
+			log.Printf("Failed to end impersonation session %s at logout: %v", claims.ID, err)
+		}
+		return
+	}
+	if err := s.RevokeSession(claims.ID, claims.UserID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to revoke session %s at logout: %v", claims.ID, err)
+	}
+}
+
+// RevokeAll revokes every session of the user except keepSessionID (empty for none), and
+// with includeAPITokens also every API token
+func (s *SessionService) RevokeAll(userID string, keepSessionID string, includeAPITokens bool) (dto.RevokeSessionsResponse, error) {
+	ids, err := s.sessionRepo.RevokeAllForUser(userID, keepSessionID)
+	if err != nil {
+		return dto.RevokeSessionsResponse{}, err
+	}
+	now := time.Now()
+	for _, id := range ids {
+		sessionRevocations.revokeSession(id, now.Add(sessionTTL))
+	}
+	sessionRevocations.revokeUser(userID, now)
+
+	response := dto.RevokeSessionsResponse{RevokedSessions: len(ids)}
+	if includeAPITokens {
+		if response.RevokedAPITokens, err = s.tokenRepo.RevokeAllForUser(userID); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// IsRevoked reports whether a session token was revoked. Tokens without a session ID were
+// issued before sessions were tracked and are only revoked through revoke-all.
+func (s *SessionService) IsRevoked(claims *dto.TokenClaims) bool {
+	sessionRevocations.refresh(s.sessionRepo)
+
+	sessionRevocations.mu.RLock()
+	defer sessionRevocations.mu.RUnlock()
+	if claims.ID != "" {
+		_, revoked := sessionRevocations.sessions[claims.ID]
+		return revoked
+	}
+	cutoff, ok := sessionRevocations.users[claims.UserID]
+	return ok && claims.IssuedAt != nil && !claims.IssuedAt.Time.After(cutoff)
+}
+
+// Touch records that a session was used; at most once a minute per session
+func (s *SessionService) Touch(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	now := time.Now()
+	cache := sessionRevocations
+	cache.touchMu.Lock()
+	if last, ok := cache.touched[sessionID]; ok && now.Sub(last) < time.Minute {
+		cache.touchMu.Unlock()
+		return
+	}
+	cache.touched[sessionID] = now
+	// Sessions live a day; dropping old entries keeps the map small
+	for id, last := range cache.touched {
+		if now.Sub(last) > sessionTTL {
+			delete(cache.touched, id)
+		}
+	}
+	cache.touchMu.Unlock()
+
+	if err := s.sessionRepo.TouchLastSeen(sessionID, now); err != nil {
+		log.Printf("Failed to record session use: %v", err)
+	}
+}
+
+// PruneExpired deletes sessions that expired more than a week ago
+func (s *SessionService) PruneExpired() (int64, error) {
+	return s.sessionRepo.DeleteExpiredBefore(time.Now().AddDate(0, 0, -7))
+}
+
+// refresh reloads the revocation list from the database when it is older than the refresh
+// interval. Only one request reloads; the others use the current list meanwhile. A failed
+// reload keeps the current list.
+func (c *revocationCache) refresh(repo *repositories.UserSessionRepository) {
+	c.mu.Lock()
+	if c.loading || time.Since(c.loadedAt) < getSessionRevocationRefresh() {
+		c.mu.Unlock()
+		return
+	}
+	c.loading = true
+	c.mu.Unlock()
+
+	sessions, err := repo.FindRevokedUnexpired()
+	var users []models.User
+	if err == nil {
+		users, err = repo.FindUserRevocationsSince(time.Now().Add(-sessionTTL))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loading = false
+	// A failed reload is retried after the interval, not on every request
+	c.loadedAt = time.Now()
+	if err != nil {
+		log.Printf("Failed to reload session revocation list: %v", err)
+		return
+	}
+	c.sessions = make(map[string]time.Time, len(sessions))
+	for _, session := range sessions {
+		c.sessions[session.ID] = session.ExpiresAt
+	}
+	c.users = make(map[string]time.Time, len(users))
+	for _, user := range users {
+		if user.SessionsRevokedAt != nil {
+			c.users[user.ID] = *user.SessionsRevokedAt
+		}
+	}
+}
+
+func (c *revocationCache) revokeSession(sessionID string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[sessionID] = expiresAt
+}
+
+func (c *revocationCache) revokeUser(userID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[userID] = at
+}
+
+// getSessionRevocationRefresh reads SESSION_REVOCATION_REFRESH_SECONDS
+func getSessionRevocationRefresh() time.Duration {
+	value := optionalEnvString("SESSION_REVOCATION_REFRESH_SECONDS")
+	if value == nil {
+		return 10 * time.Second
+	}
+	seconds, err := strconv.Atoi(*value)
+	if err != nil || seconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// defaultShareLinkTTL is the lifetime of share links created without an expiry
+const defaultShareLinkTTL = time.Hour
+
+var (
+	// ErrShareLinkNotFound is returned for unknown links of an environment
+	ErrShareLinkNotFound = errors.New("share link not found")
+	// ErrInvalidShareLink is returned for tampered, expired and revoked link tokens, and for
+	// services the link does not cover; callers cannot tell which
+	ErrInvalidShareLink = errors.New("share link is invalid or has expired")
+)
+
+// ShareLinkService issues signed, expiring links that give someone without an account
+// read-only access to the status and runtime logs of an environment's services
+type ShareLinkService struct {
+	linkRepo          *repositories.ShareLinkRepository
+	serviceRepo       *repositories.ServiceRepository
+	environmentRepo   *repositories.EnvironmentRepository
+	environments      *EnvironmentService
+	deploymentService *DeploymentService
+}
+
+// NewShareLinkService creates a new share link service instance
+func NewShareLinkService() *ShareLinkService {
+	return &ShareLinkService{
+		linkRepo:          repositories.NewShareLinkRepository(),
+		serviceRepo:       repositories.NewServiceRepository(),
+		environmentRepo:   repositories.NewEnvironmentRepository(),
+		environments:      NewEnvironmentService(),
+		deploymentService: NewDeploymentService(),
+	}
+}
+
+// CreateLink issues a link to the environment, or to one of its services
+func (s *ShareLinkService) CreateLink(environmentID string, req dto.ShareLinkRequest, userID string, isAdmin bool) (dto.ShareLinkResponse, error) {
+	if _, err := s.environments.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+
+	link := models.ShareLink{
+		EnvironmentID: environmentID,
+		Label:         req.Label,
+		CreatedBy:     userID,
+		ExpiresAt:     time.Now().Add(defaultShareLinkTTL),
+	}
+	if req.ExpiresInMinutes > 0 {
+		link.ExpiresAt = time.Now().Add(time.Duration(req.ExpiresInMinutes) * time.Minute)
+	}
+	if req.ServiceID != "" {
+		service, err := s.serviceRepo.FindByID(req.ServiceID)
+		if err != nil || service.EnvironmentID != environmentID {
+			return dto.ShareLinkResponse{}, errors.New("service not found in this environment")
+		}
+		link.ServiceID = &service.ID
+	}
+
+	link, err := s.linkRepo.Create(link)
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+	token, err := signShareLink(link)
+	if err != nil {
+		return dto.ShareLinkResponse{}, err
+	}
+	return dto.ShareLinkResponse{
+		Link:  link,
+		Token: token,
+		URL:   getShareLinkBaseURL() + "/" + token,
+	}, nil
+}
+
+// ListLinks returns the active links of an environment
+func (s *ShareLinkService) ListLinks(environmentID string, userID string, isAdmin bool) ([]models.ShareLink, error) {
+	if _, err := s.environments.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.linkRepo.FindByEnvironmentID(environmentID)
+}
+
+// RevokeLink revokes a link of an environment; it stops working at once
+func (s *ShareLinkService) RevokeLink(environmentID string, linkID string, userID string, isAdmin bool) error {
+	if _, err := s.environments.GetEnvironmentDetail(environmentID, userID, isAdmin); err != nil {
+		return err
+	}
+	revoked, err := s.linkRepo.Revoke(linkID, environmentID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrShareLinkNotFound
+	}
+	return nil
+}
+
+// GetSharedView returns the status of the services a link token covers
+func (s *ShareLinkService) GetSharedView(token string) (dto.SharedView, error) {
+	link, err := s.resolve(token)
+	if err != nil {
+		return dto.SharedView{}, err
+	}
+	environment, err := s.environmentRepo.FindByID(link.EnvironmentID)
+	if err != nil {
+		return dto.SharedView{}, ErrInvalidShareLink
+	}
+
+	var services []models.Service
+	if link.ServiceID != nil {
+		service, err := s.serviceRepo.FindByID(*link.ServiceID)
+		if err != nil {
+			return dto.SharedView{}, ErrInvalidShareLink
+		}
+		services = []models.Service{service}
+	} else if services, err = s.serviceRepo.FindByEnvironmentID(link.EnvironmentID); err != nil {
+		return dto.SharedView{}, err
+	}
+
+	cached, err := utils.GetBatchServiceStatus(services)
+	if err != nil {
+		return dto.SharedView{}, err
+	}
+	summaryByID := make(map[string]dto.ServiceStatusSummary, len(cached))
+	for _, summary := range cached {
+		summaryByID[summary.ServiceID] = summary
+	}
+
+	view := dto.SharedView{
+		EnvironmentName: environment.Name,
+		ExpiresAt:       link.ExpiresAt,
+		Services:        make([]dto.SharedServiceStatus, 0, len(services)),
+	}
+	for _, service := range services {
+		summary, ok := summaryByID[service.ID]
+		if !ok {
+			summary = dto.ServiceStatusSummary{ServiceID: service.ID}
+		}
+		view.Services = append(view.Services, dto.SharedServiceStatus{
+			Name:                 service.Name,
+			Type:                 service.Type,
+			ServiceStatusSummary: summary,
+		})
+	}
+	return view, nil
+}
+
+// CheckServiceAccess verifies that a link token covers the service before its logs are
+// streamed, so the caller can still answer with a JSON error
+func (s *ShareLinkService) CheckServiceAccess(token string, serviceID string) error {
+	link, err := s.resolve(token)
+	if err != nil {
+		return err
+	}
+	if !link.Covers(serviceID) {
+		return ErrInvalidShareLink
+	}
+	service, err := s.serviceRepo.FindByID(serviceID)
+	if err != nil || service.EnvironmentID != link.EnvironmentID {
+		return ErrInvalidShareLink
+	}
+	return nil
+}
+
+// StreamServiceLogs streams the runtime logs of a shared service as Server-Sent Events,
+// resuming after lastEventID when set. Secrets are redacted as in the authenticated log stream.
+func (s *ShareLinkService) StreamServiceLogs(serviceID string, w http.ResponseWriter, lastEventID string) error {
+	return s.deploymentService.GetServiceRuntimeLogsRealtime(serviceID, w, lastEventID)
+}
+
+// PruneExpired deletes links that expired more than a week ago
+func (s *ShareLinkService) PruneExpired() (int64, error) {
+	return s.linkRepo.DeleteExpiredBefore(time.Now().AddDate(0, 0, -7))
+}
+
+// resolve verifies a link token's signature and expiry, then that the link was not revoked
+func (s *ShareLinkService) resolve(token string) (models.ShareLink, error) {
+	linkID, expiresAt, ok := verifyShareLinkToken(token)
+	if !ok || time.Now().After(expiresAt) {
+		return models.ShareLink{}, ErrInvalidShareLink
+	}
+
+	link, err := s.linkRepo.FindByID(linkID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !link.IsActive()) {
+		return models.ShareLink{}, ErrInvalidShareLink
+	}
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+
+	if err := s.linkRepo.TouchLastUsed(link.ID, time.Now()); err != nil {
+		log.Printf("Failed to record share link use: %v", err)
+	}
+	return link, nil
+}
+
+// signShareLink returns the token of a link: its ID and expiry, signed with JWT_SECRET
+func signShareLink(link models.ShareLink) (string, error) {
+	payload := link.ID + "." + strconv.FormatInt(link.ExpiresAt.Unix(), 10)
+	signature, err := shareLinkSignature(payload)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + signature, nil
+}
+
+// verifyShareLinkToken checks a token's signature and returns the link ID and expiry it carries
+func verifyShareLinkToken(token string) (string, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", time.Time{}, false
+	}
+	expected, err := shareLinkSignature(parts[0] + "." + parts[1])
+	if err != nil || !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return "", time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[0], time.Unix(unix, 0), true
+}
+
+func shareLinkSignature(payload string) (string, error) {
+	secretKey := os.Getenv("JWT_SECRET")
+	if secretKey == "" {
+		return "", errors.New("JWT_SECRET not set in environment")
+	}
+	// The prefix keeps link signatures apart from any other use of the secret
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(fmt.Sprintf("share-link:%s", payload)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// getShareLinkBaseURL is the dashboard page that opens share links
+// (SHARE_LINK_BASE_URL, default <first CORS origin>/shared)
+func getShareLinkBaseURL() string {
+	if value := optionalEnvString("SHARE_LINK_BASE_URL"); value != nil {
+		return strings.TrimRight(*value, "/")
+	}
+	origin := utils.GetCORSOrigins()[0]
+	return strings.TrimRight(origin, "/") + "/shared"
+}
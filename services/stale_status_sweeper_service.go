@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// staleRolloutGrace is added to a build's deadline for pushing the image and rolling it out
+	staleRolloutGrace = 30 * time.Minute
+	// staleServiceGrace is how long a service may stay building without an unfinished deployment,
+	// e.g. between saving a service and creating its deployment
+	staleServiceGrace = 15 * time.Minute
+	// staleJobCheckTimeout bounds reading a build job's state
+	staleJobCheckTimeout = 15 * time.Second
+)
+
+var staleSweeperOnce sync.Once
+
+// StaleStatusSweeperService fails deployments and services left in a transient status when
+// the API instance handling them stopped, e.g. crashed or was redeployed, mid-flight. A
+// record is only touched past the longest time its work may legitimately take, and the
+// cluster is checked first, so work that is still running is left alone.
+type StaleStatusSweeperService struct {
+	deploymentRepo *repositories.DeploymentRepository
+	serviceRepo    *repositories.ServiceRepository
+}
+
+// NewStaleStatusSweeperService creates a new stale status sweeper service instance
+func NewStaleStatusSweeperService() *StaleStatusSweeperService {
+	return &StaleStatusSweeperService{
+		deploymentRepo: repositories.NewDeploymentRepository(),
+		serviceRepo:    repositories.NewServiceRepository(),
+	}
+}
+
+// RunOnce performs a single sweep and returns how many deployments and services it failed
+func (s *StaleStatusSweeperService) RunOnce() (int, int, error) {
+	failedDeployments, err := s.sweepDeployments()
+	if err != nil {
+		return failedDeployments, 0, err
+	}
+	failedServices, err := s.sweepServices()
+	return failedDeployments, failedServices, err
+}
+
+// StartSweeper starts the background sweep loop (STALE_SWEEP_INTERVAL_MINUTES, default 5)
+func (s *StaleStatusSweeperService) StartSweeper() {
+	staleSweeperOnce.Do(func() {
+		interval := time.Duration(getStaleSweepInterval()) * time.Minute
+		go func() {
+			log.Printf("Stale status sweeper started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				if _, _, err := s.RunOnce(); err != nil {
+					log.Printf("Stale status sweep failed: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+// sweepDeployments fails the queued and building deployments past their deadline whose
+// build job is no longer running
+func (s *StaleStatusSweeperService) sweepDeployments() (int, error) {
+	deployments, err := s.deploymentRepo.FindInProgress()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	failed := 0
+	for _, deployment := range deployments {
+		service, err := s.serviceRepo.FindByID(deployment.ServiceID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Stale status sweep: failed to load service of deployment %s: %v", deployment.ID, err)
+			continue
+		}
+		if now.Before(deploymentDeadline(deployment, service)) {
+			continue
+		}
+
+		hint, stillRunning, err := s.abandonedDeploymentHint(deployment)
+		if err != nil {
+			log.Printf("Stale status sweep: failed to check build job of deployment %s: %v", deployment.ID, err)
+			continue
+		}
+		if stillRunning {
+			continue
+		}
+
+		changed, err := s.deploymentRepo.MarkAbandoned(deployment.ID, utils.BuildErrorAbandoned, hint)
+		if err != nil {
+			log.Printf("Stale status sweep: failed to fail deployment %s: %v", deployment.ID, err)
+			continue
+		}
+		if changed {
+			failed++
+			log.Printf("Stale status sweep: deployment %s was %s since %s and is failed: %s",
+				deployment.ID, deployment.Status, deployment.CreatedAt.Format(time.RFC3339), hint)
+		}
+	}
+	return failed, nil
+}
+
+// deploymentDeadline is when an unfinished deployment is considered abandoned: the longest
+// it may wait for a build slot, build and roll out, plus a grace period
+func deploymentDeadline(deployment models.Deployment, service models.Service) time.Time {
+	if deployment.Status == models.DeploymentStatusQueued {
+		return deployment.CreatedAt.Add(getBuildQueueTimeout() + staleRolloutGrace)
+	}
+	if deployment.BuildFinishedAt != nil {
+		return deployment.BuildFinishedAt.Add(staleRolloutGrace)
+	}
+	started := deployment.CreatedAt.Add(getBuildQueueTimeout())
+	if deployment.BuildStartedAt != nil {
+		started = *deployment.BuildStartedAt
+	}
+	return started.Add(utils.GetBuildTimeout(service) + staleRolloutGrace)
+}
+
+// abandonedDeploymentHint explains from its build job why a deployment never finished. It
+// reports whether the job is still running, in which case the deployment is left alone.
+func (s *StaleStatusSweeperService) abandonedDeploymentHint(deployment models.Deployment) (string, bool, error) {
+	if deployment.Status == models.DeploymentStatusQueued {
+		return "The deployment waited for a build slot and was never started, e.g. because the API instance handling it restarted. Redeploy the service.", false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), staleJobCheckTimeout)
+	defer cancel()
+	status, reason, err := utils.GetBuildJobStatus(ctx, deployment.ServiceID, deployment.ID)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch status {
+	case utils.BuildJobActive:
+		return "", true, nil
+	case utils.BuildJobSucceeded:
+		return "The image was built, but the API instance rolling it out stopped before recording the result. Redeploy the service.", false, nil
+	case utils.BuildJobFailed:
+		return fmt.Sprintf("The build job failed (%s), but the API instance handling it stopped before recording the result. Check the build logs and redeploy the service.", reason), false, nil
+	}
+	return "The deployment never finished and its build job no longer exists, e.g. because the API instance handling it restarted. Redeploy the service.", false, nil
+}
+
+// sweepServices settles git services left building without an unfinished deployment, and
+// managed services left starting past their boot timeout, from what is actually deployed
+func (s *StaleStatusSweeperService) sweepServices() (int, error) {
+	failed := 0
+
+	building, err := s.serviceRepo.FindByStatusUpdatedBefore("building", time.Now().Add(-staleServiceGrace))
+	if err != nil {
+		return failed, err
+	}
+	for _, service := range building {
+		status, err := s.settledBuildingStatus(service)
+		if err != nil {
+			log.Printf("Stale status sweep: failed to check service %s: %v", service.ID, err)
+			continue
+		}
+		if status != "" && s.settleService(service, status) && status == "failed" {
+			failed++
+		}
+	}
+
+	starting, err := s.serviceRepo.FindByStatusUpdatedBefore("starting", time.Now().Add(-(utils.ManagedServiceReadyTimeout + staleServiceGrace)))
+	if err != nil {
+		return failed, err
+	}
+	for _, service := range starting {
+		if service.Type != models.ServiceTypeManaged {
+			continue
+		}
+		health, err := utils.GetManagedServiceHealth(service)
+		if err != nil {
+			log.Printf("Stale status sweep: failed to check health of service %s: %v", service.ID, err)
+			continue
+		}
+		switch health.Status {
+		case models.ServiceHealthHealthy:
+			s.settleService(service, "running")
+		case models.ServiceHealthUnhealthy, models.ServiceHealthUnavailable:
+			log.Printf("Stale status sweep: managed service %s did not start: %s", service.ID, health.Message)
+			if s.settleService(service, "failed") {
+				failed++
+			}
+		}
+	}
+	return failed, nil
+}
+
+// settledBuildingStatus returns the status a service left building should have from its
+// latest deployment, or "" while that deployment is unfinished
+func (s *StaleStatusSweeperService) settledBuildingStatus(service models.Service) (string, error) {
+	deployment, err := s.deploymentRepo.GetLatestDeployment(service.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "failed", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	switch deployment.Status {
+	case models.DeploymentStatusSuccess:
+		return "running", nil
+	case models.DeploymentStatusFailed:
+		return "failed", nil
+	}
+	// Swept along with the deployment once it is abandoned
+	return "", nil
+}
+
+// settleService moves a service out of its transient status unless it changed meanwhile
+func (s *StaleStatusSweeperService) settleService(service models.Service, status string) bool {
+	changed, err := s.serviceRepo.UpdateStatusIf(service.ID, service.Status, status)
+	if err != nil {
+		log.Printf("Stale status sweep: failed to update service %s: %v", service.ID, err)
+		return false
+	}
+	if changed {
+		log.Printf("Stale status sweep: service %s was %s since %s and is now %s",
+			service.ID, service.Status, service.UpdatedAt.Format(time.RFC3339), status)
+	}
+	return changed
+}
+
+func getStaleSweepInterval() int {
+	value := optionalEnvString("STALE_SWEEP_INTERVAL_MINUTES")
+	if value == nil {
+		return 5
+	}
+	minutes, err := strconv.Atoi(*value)
+	if err != nil || minutes <= 0 {
+		return 5
+	}
+	return minutes
+}
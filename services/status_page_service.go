@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"gorm.io/gorm"
+)
+
+// statusPageIncidentWindow is how long resolved incidents stay on the public page
+const statusPageIncidentWindow = 14 * 24 * time.Hour
+
+var (
+	// ErrStatusPageNotFound is returned for unknown or disabled status pages
+	ErrStatusPageNotFound = errors.New("status page not found")
+	// ErrIncidentNotFound is returned for incidents that do not exist in the project
+	ErrIncidentNotFound = errors.New("incident not found")
+)
+
+// StatusPageService manages public status pages and the incidents annotated on them
+type StatusPageService struct {
+	pageRepo      *repositories.StatusPageRepository
+	projectRepo   *repositories.ProjectRepository
+	serviceRepo   *repositories.ServiceRepository
+	uptimeRepo    *repositories.UptimeRepository
+	uptimeService *UptimeService
+}
+
+// NewStatusPageService creates a new status page service instance
+func NewStatusPageService() *StatusPageService {
+	return &StatusPageService{
+		pageRepo:      repositories.NewStatusPageRepository(),
+		projectRepo:   repositories.NewProjectRepository(),
+		serviceRepo:   repositories.NewServiceRepository(),
+		uptimeRepo:    repositories.NewUptimeRepository(),
+		uptimeService: NewUptimeService(),
+	}
+}
+
+// GetStatusPage returns the status page configuration of a project
+func (s *StatusPageService) GetStatusPage(projectID string, userID string, isAdmin bool) (models.StatusPage, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.StatusPage{}, err
+	}
+
+	page, err := s.pageRepo.FindByProjectID(projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return page, ErrStatusPageNotFound
+	}
+	return page, err
+}
+
+// SaveStatusPage creates or updates the status page of a project
+func (s *StatusPageService) SaveStatusPage(projectID string, req dto.StatusPageRequest, userID string, isAdmin bool) (models.StatusPage, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.StatusPage{}, err
+	}
+
+	if other, err := s.pageRepo.FindBySlug(req.Slug); err == nil && other.ProjectID != projectID {
+		return models.StatusPage{}, fmt.Errorf("status page slug %q is already taken", req.Slug)
+	}
+
+	page := models.StatusPage{
+		ProjectID: projectID,
+		Slug:      req.Slug,
+		Title:     req.Title,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	}
+	if existing, err := s.pageRepo.FindByProjectID(projectID); err == nil {
+		page.CreatedAt = existing.CreatedAt
+	}
+	return s.pageRepo.Save(page)
+}
+
+// ListIncidents returns the open incidents of a project and those resolved recently
+func (s *StatusPageService) ListIncidents(projectID string, userID string, isAdmin bool) ([]models.Incident, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.pageRepo.FindIncidents(projectID, time.Now().Add(-statusPageIncidentWindow))
+}
+
+// CreateIncident annotates the project's status page with an incident
+func (s *StatusPageService) CreateIncident(projectID string, req dto.IncidentRequest, userID string, isAdmin bool) (models.Incident, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.Incident{}, err
+	}
+
+	incident := models.Incident{
+		ProjectID: projectID,
+		Title:     req.Title,
+		Message:   req.Message,
+		Status:    req.Status,
+		StartedAt: time.Now(),
+		CreatedBy: userID,
+	}
+	if incident.Status == "" {
+		incident.Status = models.IncidentStatusInvestigating
+	}
+	if req.StartedAt != nil {
+		incident.StartedAt = *req.StartedAt
+	}
+	if incident.Status == models.IncidentStatusResolved {
+		now := time.Now()
+		incident.ResolvedAt = &now
+	}
+	if req.ServiceID != "" {
+		service, err := s.serviceRepo.FindByID(req.ServiceID)
+		if err != nil || service.ProjectID != projectID {
+			return models.Incident{}, errors.New("service not found in this project")
+		}
+		incident.ServiceID = &service.ID
+	}
+	return s.pageRepo.CreateIncident(incident)
+}
+
+// UpdateIncident changes an incident; moving it to resolved records when it ended
+func (s *StatusPageService) UpdateIncident(projectID, incidentID string, req dto.IncidentUpdateRequest, userID string, isAdmin bool) (models.Incident, error) {
+	incident, err := s.getIncident(projectID, incidentID, userID, isAdmin)
+	if err != nil {
+		return incident, err
+	}
+
+	if req.Title != "" {
+		incident.Title = req.Title
+	}
+	if req.Message != "" {
+		incident.Message = req.Message
+	}
+	if req.Status != "" && req.Status != incident.Status {
+		incident.Status = req.Status
+		incident.ResolvedAt = nil
+		if req.Status == models.IncidentStatusResolved {
+			now := time.Now()
+			incident.ResolvedAt = &now
+		}
+	}
+	return s.pageRepo.UpdateIncident(incident)
+}
+
+// DeleteIncident removes an incident
+func (s *StatusPageService) DeleteIncident(projectID, incidentID string, userID string, isAdmin bool) error {
+	incident, err := s.getIncident(projectID, incidentID, userID, isAdmin)
+	if err != nil {
+		return err
+	}
+	return s.pageRepo.DeleteIncident(incident.ID)
+}
+
+// GetPublicStatusPage renders the unauthenticated view of an enabled status page. Only
+// services with an uptime monitor are listed, by name only.
+func (s *StatusPageService) GetPublicStatusPage(slug string) (dto.PublicStatusPage, error) {
+	page, err := s.pageRepo.FindBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !page.Enabled) {
+		return dto.PublicStatusPage{}, ErrStatusPageNotFound
+	}
+	if err != nil {
+		return dto.PublicStatusPage{}, err
+	}
+
+	result := dto.PublicStatusPage{
+		Title:       page.Title,
+		Status:      "unknown",
+		Services:    []dto.PublicStatusService{},
+		Incidents:   []dto.PublicStatusIncident{},
+		GeneratedAt: time.Now(),
+	}
+	if result.Title == "" {
+		if project, err := s.projectRepo.FindByID(page.ProjectID); err == nil {
+			result.Title = project.Name
+		}
+	}
+
+	services, err := s.serviceRepo.FindByProjectID(page.ProjectID)
+	if err != nil {
+		return result, err
+	}
+	serviceNames := make(map[string]string, len(services))
+	serviceIDs := make([]string, 0, len(services))
+	for _, service := range services {
+		serviceNames[service.ID] = service.Name
+		serviceIDs = append(serviceIDs, service.ID)
+	}
+
+	monitors, err := s.uptimeRepo.FindMonitorsByServiceIDs(serviceIDs)
+	if err != nil {
+		return result, err
+	}
+	for _, service := range services {
+		for i := range monitors {
+			if monitors[i].ServiceID != service.ID || !monitors[i].Enabled {
+				continue
+			}
+			summary, err := s.uptimeService.summarize(service, &monitors[i])
+			if err != nil {
+				return result, err
+			}
+			result.Services = append(result.Services, dto.PublicStatusService{
+				Name:      service.Name,
+				Status:    summary.Status,
+				Uptime24h: summary.Uptime24h,
+				Uptime7d:  summary.Uptime7d,
+				Uptime30d: summary.Uptime30d,
+			})
+		}
+	}
+	result.Status = overallStatus(result.Services)
+
+	incidents, err := s.pageRepo.FindIncidents(page.ProjectID, time.Now().Add(-statusPageIncidentWindow))
+	if err != nil {
+		return result, err
+	}
+	for _, incident := range incidents {
+		entry := dto.PublicStatusIncident{
+			Title:      incident.Title,
+			Message:    incident.Message,
+			Status:     incident.Status,
+			StartedAt:  incident.StartedAt,
+			ResolvedAt: incident.ResolvedAt,
+		}
+		if incident.ServiceID != nil {
+			entry.Service = serviceNames[*incident.ServiceID]
+		}
+		result.Incidents = append(result.Incidents, entry)
+	}
+	return result, nil
+}
+
+// overallStatus is operational when every known service is up and degraded when any is down
+func overallStatus(services []dto.PublicStatusService) string {
+	status := "unknown"
+	for _, service := range services {
+		switch service.Status {
+		case uptimeStatusDown:
+			return "degraded"
+		case uptimeStatusUp:
+			status = "operational"
+		}
+	}
+	return status
+}
+
+func (s *StatusPageService) getIncident(projectID, incidentID string, userID string, isAdmin bool) (models.Incident, error) {
+	if err := s.checkProjectAccess(projectID, userID, isAdmin); err != nil {
+		return models.Incident{}, err
+	}
+
+	incident, err := s.pageRepo.FindIncidentByID(incidentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && incident.ProjectID != projectID) {
+		return models.Incident{}, ErrIncidentNotFound
+	}
+	return incident, err
+}
+
+func (s *StatusPageService) checkProjectAccess(projectID string, userID string, isAdmin bool) error {
+	if isAdmin {
+		return nil
+	}
+
+	ownerID, err := s.projectRepo.GetOwnerID(projectID)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return errors.New("unauthorized access to project")
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// storageExpansionTimeout bounds how long an expansion is tracked
+	storageExpansionTimeout = 30 * time.Minute
+	// storageExpansionResync re-reads the claims when no watch event arrives
+	storageExpansionResync = 30 * time.Second
+)
+
+var (
+	// ErrStorageExpansionNotFound is returned for expansions that do not exist on the service
+	ErrStorageExpansionNotFound = errors.New("storage expansion not found")
+	// ErrStorageExpansionInProgress is returned while an earlier expansion is still tracked
+	ErrStorageExpansionInProgress = errors.New("a storage expansion of this service is still in progress")
+	// ErrStorageExpansionRejected is returned when the volumes cannot grow as requested
+	ErrStorageExpansionRejected = errors.New("storage cannot be expanded")
+)
+
+// StorageExpansionService grows the data volumes of managed services in place and tracks
+// the resize until the filesystem reports the new size, without redeploying the service
+type StorageExpansionService struct {
+	expansionRepo   *repositories.StorageExpansionRepository
+	serviceRepo     *repositories.ServiceRepository
+	projectRepo     *repositories.ProjectRepository
+	environmentRepo *repositories.EnvironmentRepository
+}
+
+// NewStorageExpansionService creates a new storage expansion service instance
+func NewStorageExpansionService() *StorageExpansionService {
+	return &StorageExpansionService{
+		expansionRepo:   repositories.NewStorageExpansionRepository(),
+		serviceRepo:     repositories.NewServiceRepository(),
+		projectRepo:     repositories.NewProjectRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+	}
+}
+
+// Expand raises the storage request of every volume of a managed service to the requested
+// size. The storage class must allow expansion and the environment's maximum and the
+// namespace's ResourceQuotas must leave room. The resize is tracked in the background.
+func (s *StorageExpansionService) Expand(serviceID string, req dto.StorageExpansionRequest, userID string, isAdmin bool) (models.StorageExpansion, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return models.StorageExpansion{}, err
+	}
+	if service.Type != models.ServiceTypeManaged || !utils.RequiresPersistentStorage(service.ManagedType) {
+		return models.StorageExpansion{}, fmt.Errorf("%w: only managed services with persistent storage have volumes to expand", ErrStorageExpansionRejected)
+	}
+	if err := s.checkEnvironmentMaximum(service, req.Size); err != nil {
+		return models.StorageExpansion{}, err
+	}
+
+	// Expansions left in progress by a restart of the API are ignored once they timed out
+	inProgress, err := s.expansionRepo.ExistsInProgress(serviceID, time.Now().Add(-storageExpansionTimeout))
+	if err != nil {
+		return models.StorageExpansion{}, err
+	}
+	if inProgress {
+		return models.StorageExpansion{}, ErrStorageExpansionInProgress
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return models.StorageExpansion{}, fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	claims, _, err := utils.ListServiceClaims(ctx, k8sClient, service)
+	if err != nil {
+		return models.StorageExpansion{}, err
+	}
+	if len(claims) == 0 {
+		return models.StorageExpansion{}, fmt.Errorf("%w: the service has no volumes yet; deploy it first", ErrStorageExpansionRejected)
+	}
+	increase, err := utils.CheckServiceStorageExpansion(ctx, k8sClient, claims, req.Size)
+	if err != nil {
+		return models.StorageExpansion{}, fmt.Errorf("%w: %v", ErrStorageExpansionRejected, err)
+	}
+	if increase.IsZero() {
+		return models.StorageExpansion{}, fmt.Errorf("%w: the volumes already have %s", ErrStorageExpansionRejected, req.Size)
+	}
+
+	expansion := models.StorageExpansion{
+		ServiceID:   serviceID,
+		FromSize:    service.StorageSize,
+		ToSize:      req.Size,
+		Status:      models.StorageExpansionResizing,
+		RequestedBy: userID,
+		StartedAt:   time.Now(),
+	}
+	for _, claim := range claims {
+		current := claim.Status.Capacity[corev1.ResourceStorage]
+		expansion.Claims = append(expansion.Claims, models.StorageClaimStatus{
+			Name:     claim.Name,
+			Capacity: current.String(),
+			State:    models.StorageExpansionResizing,
+		})
+	}
+	expansion, err = s.expansionRepo.Create(expansion)
+	if err != nil {
+		return expansion, err
+	}
+
+	if err := utils.ExpandServiceClaims(ctx, k8sClient, claims, req.Size); err != nil {
+		s.finish(&expansion, models.StorageExpansionFailed, err.Error())
+		return expansion, err
+	}
+	// The volumes are the source of truth now; later deploys keep the new size
+	if err := s.serviceRepo.UpdateStorageSize(serviceID, req.Size); err != nil {
+		log.Printf("Failed to record storage size of service %s: %v", serviceID, err)
+	}
+
+	go s.track(k8sClient, service, expansion)
+	return expansion, nil
+}
+
+// ListExpansions returns a page of a service's storage expansions, newest first
+func (s *StorageExpansionService) ListExpansions(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.StorageExpansionListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.StorageExpansionListResponse{}, err
+	}
+
+	expansions, total, err := s.expansionRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.StorageExpansionListResponse{}, err
+	}
+	return dto.StorageExpansionListResponse{
+		Expansions: expansions,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// GetExpansion returns a storage expansion of a service with the progress of each volume
+func (s *StorageExpansionService) GetExpansion(serviceID, expansionID string, userID string, isAdmin bool) (models.StorageExpansion, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return models.StorageExpansion{}, err
+	}
+
+	expansion, err := s.expansionRepo.FindByID(expansionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && expansion.ServiceID != serviceID) {
+		return models.StorageExpansion{}, ErrStorageExpansionNotFound
+	}
+	return expansion, err
+}
+
+// track watches the claims of an expansion and records their progress until every volume
+// and its filesystem report the new size, a resize fails or the expansion times out. The
+// claims are re-read on every watch event, and periodically in case an event is missed.
+func (s *StorageExpansionService) track(k8sClient *kubernetes.Client, service models.Service, expansion models.StorageExpansion) {
+	ctx, cancel := context.WithTimeout(context.Background(), storageExpansionTimeout)
+	defer cancel()
+
+	for {
+		claims, resourceVersion, err := utils.ListServiceClaims(ctx, k8sClient, service)
+		switch {
+		case ctx.Err() != nil:
+			message := fmt.Sprintf("the volumes did not report %s within %v", expansion.ToSize, storageExpansionTimeout)
+			if expansion.Status == models.StorageExpansionFileSystemResizePending {
+				message += "; restart the service so the filesystem is resized when the volume is mounted again"
+			}
+			s.finish(&expansion, models.StorageExpansionFailed, message)
+			return
+		case err != nil:
+			log.Printf("Storage expansion %s: %v, retrying", expansion.ID, err)
+		default:
+			if s.recordProgress(&expansion, claims) {
+				return
+			}
+		}
+
+		s.waitForClaimChange(ctx, k8sClient, service, resourceVersion)
+	}
+}
+
+// recordProgress stores the state of each claim and of the expansion as a whole, and
+// reports whether the expansion is finished
+func (s *StorageExpansionService) recordProgress(expansion *models.StorageExpansion, claims []corev1.PersistentVolumeClaim) bool {
+	statuses := make(models.StorageClaimStatuses, 0, len(claims))
+	status, message := models.StorageExpansionCompleted, ""
+	for _, claim := range claims {
+		claimStatus := utils.GetClaimExpansionStatus(claim)
+		statuses = append(statuses, claimStatus)
+
+		switch {
+		case claimStatus.State == models.StorageExpansionFailed:
+			status, message = models.StorageExpansionFailed, fmt.Sprintf("volume %s: %s", claim.Name, claimStatus.Message)
+		case status == models.StorageExpansionFailed:
+			// A failed volume fails the expansion
+		case claimStatus.State == models.StorageExpansionFileSystemResizePending:
+			status, message = models.StorageExpansionFileSystemResizePending, "the volumes grew; the filesystem is resized when the pod mounts them"
+		case claimStatus.State == models.StorageExpansionResizing && status == models.StorageExpansionCompleted:
+			status, message = models.StorageExpansionResizing, ""
+		}
+	}
+	expansion.Claims = statuses
+
+	if status == models.StorageExpansionCompleted || status == models.StorageExpansionFailed {
+		s.finish(expansion, status, message)
+		return true
+	}
+	if status != expansion.Status || message != expansion.Message {
+		expansion.Status = status
+		expansion.Message = message
+		if err := s.expansionRepo.Update(*expansion); err != nil {
+			log.Printf("Failed to record storage expansion %s: %v", expansion.ID, err)
+		}
+	}
+	return false
+}
+
+// waitForClaimChange blocks until a claim of the service changes after resourceVersion,
+// the resync interval passes or ctx ends. A failed watch falls back to the interval.
+func (s *StorageExpansionService) waitForClaimChange(ctx context.Context, k8sClient *kubernetes.Client, service models.Service, resourceVersion string) {
+	resync := time.NewTimer(storageExpansionResync)
+	defer resync.Stop()
+
+	watcher, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(service.EnvironmentID).Watch(ctx, metav1.ListOptions{
+		LabelSelector:   utils.ServiceOwnerSelector(service.ID),
+		ResourceVersion: resourceVersion,
+	})
+	if err != nil {
+		select {
+		case <-ctx.Done():
+		case <-resync.C:
+		}
+		return
+	}
+	defer watcher.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-resync.C:
+	case <-watcher.ResultChan():
+	}
+}
+
+func (s *StorageExpansionService) finish(expansion *models.StorageExpansion, status, message string) {
+	now := time.Now()
+	expansion.Status = status
+	expansion.Message = message
+	expansion.CompletedAt = &now
+	if err := s.expansionRepo.Update(*expansion); err != nil {
+		log.Printf("Failed to record storage expansion %s: %v", expansion.ID, err)
+	}
+	log.Printf("Storage expansion %s of service %s to %s: %s %s", expansion.ID, expansion.ServiceID, expansion.ToSize, status, message)
+}
+
+// checkEnvironmentMaximum rejects sizes above the storage maximum of the service's environment
+func (s *StorageExpansionService) checkEnvironmentMaximum(service models.Service, size string) error {
+	env, err := s.environmentRepo.FindByID(service.EnvironmentID)
+	if err != nil || env.MaxStorageSize == "" {
+		return nil
+	}
+
+	var errs utils.FieldErrors
+	requested, err := resource.ParseQuantity(size)
+	maximum, maxErr := resource.ParseQuantity(env.MaxStorageSize)
+	if err == nil && maxErr == nil && requested.Cmp(maximum) > 0 {
+		errs.Add("size", "must be at most %s in this environment", env.MaxStorageSize)
+	}
+	return errs.Err()
+}
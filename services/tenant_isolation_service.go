@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+var tenantIsolationOnce sync.Once
+
+// TenantIsolationService keeps workload namespaces from reaching the platform's own
+// namespaces (registry, builds, platform API) with a NetworkPolicy in each environment's
+// namespace, and reports which namespaces conform
+type TenantIsolationService struct {
+	isolationRepo   *repositories.TenantIsolationRepository
+	environmentRepo *repositories.EnvironmentRepository
+}
+
+// NewTenantIsolationService creates a new tenant isolation service instance
+func NewTenantIsolationService() *TenantIsolationService {
+	return &TenantIsolationService{
+		isolationRepo:   repositories.NewTenantIsolationRepository(),
+		environmentRepo: repositories.NewEnvironmentRepository(),
+	}
+}
+
+// GetPolicy returns the tenant isolation policy
+func (s *TenantIsolationService) GetPolicy() (models.TenantIsolationPolicy, error) {
+	return s.isolationRepo.FindPolicy()
+}
+
+// UpdatePolicy turns the isolation on or off and applies the change to every namespace
+func (s *TenantIsolationService) UpdatePolicy(req dto.TenantIsolationUpdateRequest, userID string) (models.TenantIsolationPolicy, dto.TenantIsolationReconcileResult, error) {
+	policy, err := s.isolationRepo.FindPolicy()
+	if err != nil {
+		return policy, dto.TenantIsolationReconcileResult{}, err
+	}
+
+	policy.Enabled = *req.Enabled
+	policy.UpdatedBy = userID
+	policy, err = s.isolationRepo.SavePolicy(policy)
+	if err != nil {
+		return policy, dto.TenantIsolationReconcileResult{}, err
+	}
+
+	result, err := s.Reconcile()
+	return policy, result, err
+}
+
+// Reconcile applies the isolation policy to the namespace of every environment when it is
+// enabled, or removes it when it is not. Environments not deployed yet have no namespace
+// and are picked up by a later pass.
+func (s *TenantIsolationService) Reconcile() (dto.TenantIsolationReconcileResult, error) {
+	result := dto.TenantIsolationReconcileResult{Applied: []string{}, Removed: []string{}}
+
+	policy, err := s.isolationRepo.FindPolicy()
+	if err != nil {
+		return result, err
+	}
+	namespaces, err := s.environmentRepo.FindAllIDs()
+	if err != nil {
+		return result, fmt.Errorf("failed to list environments: %v", err)
+	}
+
+	config := utils.GetTenantIsolationConfig()
+	for _, namespace := range namespaces {
+		if config.IsProtectedNamespace(namespace) {
+			continue
+		}
+		if policy.Enabled {
+			applied, err := utils.ApplyTenantIsolation(namespace, config)
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			} else if applied {
+				result.Applied = append(result.Applied, namespace)
+			}
+			continue
+		}
+		removed, err := utils.RemoveTenantIsolation(namespace)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		} else if removed {
+			result.Removed = append(result.Removed, namespace)
+		}
+	}
+	return result, nil
+}
+
+// Report checks every environment's namespace against the isolation policy
+func (s *TenantIsolationService) Report() (dto.TenantIsolationReport, error) {
+	policy, err := s.isolationRepo.FindPolicy()
+	if err != nil {
+		return dto.TenantIsolationReport{}, err
+	}
+	environments, err := s.environmentRepo.FindAll()
+	if err != nil {
+		return dto.TenantIsolationReport{}, fmt.Errorf("failed to list environments: %v", err)
+	}
+
+	config := utils.GetTenantIsolationConfig()
+	report := dto.TenantIsolationReport{
+		Enabled:             policy.Enabled,
+		ProtectedNamespaces: config.ProtectedNamespaces,
+		ClusterCIDRs:        config.ClusterCIDRs,
+		Namespaces:          []dto.TenantIsolationNamespaceStatus{},
+		CheckedAt:           time.Now(),
+	}
+	for _, environment := range environments {
+		status := dto.TenantIsolationNamespaceStatus{
+			Namespace:   environment.ID,
+			Environment: environment.Name,
+			ProjectID:   environment.ProjectID,
+		}
+
+		state, err := utils.CheckTenantIsolation(environment.ID, config)
+		switch {
+		case err != nil:
+			status.Problem = err.Error()
+		case config.IsProtectedNamespace(environment.ID):
+			status.Exists = state.Exists
+			status.Problem = "the namespace is one of the platform's protected namespaces"
+		default:
+			status.Exists = state.Exists
+			status.PolicyPresent = state.PolicyPresent
+			status.UpToDate = state.UpToDate
+			status.Conformant, status.Problem = tenantIsolationConformance(policy.Enabled, state)
+		}
+
+		if status.Conformant {
+			report.Conformant++
+		} else {
+			report.NonConformant++
+		}
+		report.Namespaces = append(report.Namespaces, status)
+	}
+	return report, nil
+}
+
+// tenantIsolationConformance decides whether a namespace matches the policy. Namespaces
+// that do not exist yet conform: they are isolated once created.
+func tenantIsolationConformance(enabled bool, state utils.TenantIsolationState) (bool, string) {
+	switch {
+	case !state.Exists:
+		return true, ""
+	case enabled && !state.PolicyPresent:
+		return false, "the isolation NetworkPolicy is missing"
+	case enabled && !state.UpToDate:
+		return false, "the isolation NetworkPolicy is outdated"
+	case !enabled && state.PolicyPresent:
+		return false, "isolation is disabled but the NetworkPolicy is still present"
+	}
+	return true, ""
+}
+
+// StartIsolationReconciler applies the isolation policy at startup and then periodically,
+// so namespaces created by new deployments are isolated and removed policies restored
+func (s *TenantIsolationService) StartIsolationReconciler() {
+	tenantIsolationOnce.Do(func() {
+		interval := time.Duration(utils.GetTenantIsolationInterval()) * time.Minute
+		go func() {
+			log.Printf("Tenant isolation reconciler started (interval %v)", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				result, err := s.Reconcile()
+				if err != nil {
+					log.Printf("Tenant isolation reconcile failed: %v", err)
+				}
+				for _, message := range result.Errors {
+					log.Printf("Tenant isolation: %s", message)
+				}
+				<-ticker.C
+			}
+		}()
+	})
+}
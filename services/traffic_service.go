@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+const (
+	defaultTrafficWindow = time.Hour
+	maxTrafficWindow     = 24 * time.Hour
+	// trafficMaxLinesPerPod bounds the access log lines read per ingress pod and request
+	trafficMaxLinesPerPod = 200000
+	trafficTopPaths       = 10
+)
+
+// Traffic errors the API maps to client errors
+var (
+	ErrTrafficServiceNotFound = errors.New("service not found or access denied")
+	ErrAccessLogsUnavailable  = errors.New("ingress access logs are unavailable")
+)
+
+// TrafficService reports per-service traffic analytics from the ingress access logs, so
+// users get request counts, status codes and top paths without external tools
+type TrafficService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewTrafficService creates a new traffic service instance
+func NewTrafficService() *TrafficService {
+	return &TrafficService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// GetServiceTraffic summarizes the requests the ingress served for the service over the last
+// window (default 1h, at most 24h)
+func (s *TrafficService) GetServiceTraffic(serviceID string, window time.Duration, userID string, isAdmin bool) (dto.ServiceTraffic, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.ServiceTraffic{}, ErrTrafficServiceNotFound
+	}
+	if service.Type != models.ServiceTypeGit {
+		return dto.ServiceTraffic{}, errors.New("traffic analytics are only available for git services")
+	}
+	if window <= 0 {
+		window = defaultTrafficWindow
+	}
+	if window > maxTrafficWindow {
+		return dto.ServiceTraffic{}, fmt.Errorf("window must be at most %s", maxTrafficWindow)
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	scan, err := utils.ReadServiceAccessLogs(service, from, trafficMaxLinesPerPod)
+	if err != nil {
+		return dto.ServiceTraffic{}, fmt.Errorf("%w: %v", ErrAccessLogsUnavailable, err)
+	}
+
+	traffic := summarizeTraffic(scan.Entries, from, to, trafficBucketSize(window))
+	traffic.ServiceID = service.ID
+	traffic.Hostnames = utils.ServiceHostnames(service)
+	traffic.AccessLogFound = scan.AccessLogFound
+	traffic.Truncated = scan.Truncated
+	return traffic, nil
+}
+
+// summarizeTraffic aggregates access log entries into the traffic of the window
+func summarizeTraffic(entries []utils.AccessLogEntry, from, to time.Time, bucketSize time.Duration) dto.ServiceTraffic {
+	traffic := dto.ServiceTraffic{
+		From:          from,
+		To:            to,
+		StatusCodes:   map[string]int64{},
+		StatusClasses: map[string]int64{},
+		Methods:       map[string]int64{},
+		TopPaths:      []dto.TrafficPath{},
+	}
+
+	start := from.Truncate(bucketSize)
+	for bucket := start; bucket.Before(to); bucket = bucket.Add(bucketSize) {
+		traffic.Timeline = append(traffic.Timeline, dto.TrafficBucket{Start: bucket})
+	}
+
+	type pathTotals struct {
+		requests, errors int64
+		latency          time.Duration
+	}
+	paths := map[string]*pathTotals{}
+	latencies := make([]float64, 0, len(entries))
+	var serverErrors int64
+
+	for _, entry := range entries {
+		isError := entry.Status >= 500
+		traffic.Requests++
+		traffic.StatusCodes[strconv.Itoa(entry.Status)]++
+		traffic.StatusClasses[fmt.Sprintf("%dxx", entry.Status/100)]++
+		traffic.Methods[entry.Method]++
+		latencies = append(latencies, float64(entry.Duration)/float64(time.Millisecond))
+
+		path := utils.NormalizeAccessLogPath(entry.Path)
+		totals, ok := paths[path]
+		if !ok {
+			totals = &pathTotals{}
+			paths[path] = totals
+		}
+		totals.requests++
+		totals.latency += entry.Duration
+
+		if index := int(entry.Time.Sub(start) / bucketSize); index >= 0 && index < len(traffic.Timeline) {
+			traffic.Timeline[index].Requests++
+			if isError {
+				traffic.Timeline[index].Errors++
+			}
+		}
+		if isError {
+			serverErrors++
+			totals.errors++
+		}
+	}
+
+	if traffic.Requests > 0 {
+		traffic.ErrorRate = float64(serverErrors) / float64(traffic.Requests)
+		sort.Float64s(latencies)
+		traffic.LatencyP50Ms = latencyPercentile(latencies, 0.50)
+		traffic.LatencyP95Ms = latencyPercentile(latencies, 0.95)
+	}
+
+	for path, totals := range paths {
+		traffic.TopPaths = append(traffic.TopPaths, dto.TrafficPath{
+			Path:         path,
+			Requests:     totals.requests,
+			Errors:       totals.errors,
+			AvgLatencyMs: float64(totals.latency) / float64(totals.requests) / float64(time.Millisecond),
+		})
+	}
+	sort.Slice(traffic.TopPaths, func(i, j int) bool {
+		if traffic.TopPaths[i].Requests != traffic.TopPaths[j].Requests {
+			return traffic.TopPaths[i].Requests > traffic.TopPaths[j].Requests
+		}
+		return traffic.TopPaths[i].Path < traffic.TopPaths[j].Path
+	})
+	if len(traffic.TopPaths) > trafficTopPaths {
+		traffic.TopPaths = traffic.TopPaths[:trafficTopPaths]
+	}
+	return traffic
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted latencies
+func latencyPercentile(sorted []float64, p float64) float64 {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// trafficBucketSize keeps the timeline at up to a few hundred intervals
+func trafficBucketSize(window time.Duration) time.Duration {
+	switch {
+	case window <= time.Hour:
+		return time.Minute
+	case window <= 6*time.Hour:
+		return 5 * time.Minute
+	}
+	return 15 * time.Minute
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+	// Lower case without look-alikes (0/o, 1/l/i); 10 characters give about 50 bits
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Two-factor errors the API maps to client errors
+var (
+	ErrTwoFactorRequired       = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("start two-factor setup first")
+	ErrTwoFactorEnforced       = errors.New("two-factor authentication is required by the auth policy and cannot be disabled")
+)
+
+// TwoFactorService manages TOTP two-factor authentication and recovery codes
+type TwoFactorService struct {
+	securityRepo *repositories.AuthSecurityRepository
+}
+
+// NewTwoFactorService creates a new two-factor service instance
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{
+		securityRepo: repositories.NewAuthSecurityRepository(),
+	}
+}
+
+// GetStatus describes the two-factor authentication of a user
+func (s *TwoFactorService) GetStatus(userID string) (dto.TwoFactorStatus, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return dto.TwoFactorStatus{}, err
+	}
+	policy, err := s.securityRepo.FindPolicy()
+	if err != nil {
+		return dto.TwoFactorStatus{}, err
+	}
+
+	status := dto.TwoFactorStatus{
+		Enabled:  user.TwoFactorEnabled,
+		Required: policy.RequiresTwoFactor(user.Role),
+	}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesRemaining, err = s.securityRepo.CountUnusedRecoveryCodes(userID); err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// Setup generates a new TOTP secret for the user's authenticator app. Starting setup again
+// replaces a secret that was never confirmed.
+func (s *TwoFactorService) Setup(userID string) (dto.TwoFactorSetupResponse, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return dto.TwoFactorSetupResponse{}, err
+	}
+	if user.TwoFactorEnabled {
+		return dto.TwoFactorSetupResponse{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return dto.TwoFactorSetupResponse{}, err
+	}
+	if err := s.securityRepo.SetTwoFactorSecret(userID, secret); err != nil {
+		return dto.TwoFactorSetupResponse{}, err
+	}
+
+	return dto.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(getTOTPIssuer(), user.Email, secret),
+	}, nil
+}
+
+// Enable confirms the secret from Setup with a code, turns on 2FA and returns the recovery
+// codes with a new full session that replaces the current one
+func (s *TwoFactorService) Enable(userID string, code string, client LoginClient, currentSessionID string) (dto.TwoFactorEnableResponse, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return dto.TwoFactorEnableResponse{}, err
+	}
+	if user.TwoFactorEnabled {
+		return dto.TwoFactorEnableResponse{}, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactorSecret == "" {
+		return dto.TwoFactorEnableResponse{}, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := utils.VerifyTOTP(user.TwoFactorSecret, code, time.Now())
+	if !ok {
+		return dto.TwoFactorEnableResponse{}, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return dto.TwoFactorEnableResponse{}, err
+	}
+	err = s.securityRepo.DB().Transaction(func(tx *gorm.DB) error {
+		return s.securityRepo.EnableTwoFactorTx(tx, userID, step, hashes)
+	})
+	if err != nil {
+		return dto.TwoFactorEnableResponse{}, err
+	}
+
+	sessions := NewSessionService()
+	token, expiresAt, err := sessions.CreateSession(*user, client, false)
+	if err != nil {
+		return dto.TwoFactorEnableResponse{}, err
+	}
+	if currentSessionID != "" {
+		if err := sessions.RevokeSession(currentSessionID, user.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to revoke session %s after enabling 2FA: %v", currentSessionID, err)
+		}
+	}
+	return dto.TwoFactorEnableResponse{
+		RecoveryCodes: codes,
+		Token:         token,
+		ExpiresAt:     expiresAt,
+	}, nil
+}
+
+// Disable turns off 2FA after checking the password and a second factor
+func (s *TwoFactorService) Disable(userID string, req dto.TwoFactorDisableRequest) error {
+	user, err := GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	policy, err := s.securityRepo.FindPolicy()
+	if err != nil {
+		return err
+	}
+	if policy.RequiresTwoFactor(user.Role) {
+		return ErrTwoFactorEnforced
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	if err := s.verifySecondFactor(*user, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	_, err = s.securityRepo.DisableTwoFactor(userID)
+	return err
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a current code
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID string, code string) ([]string, error) {
+	user, err := GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifySecondFactor(*user, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.securityRepo.DB().Transaction(func(tx *gorm.DB) error {
+		return s.securityRepo.ReplaceRecoveryCodesTx(tx, userID, hashes)
+	})
+	return codes, err
+}
+
+// Reset turns off 2FA of a user who lost their authenticator and recovery codes. With an
+// enforcing policy they must set it up again at their next login.
+func (s *TwoFactorService) Reset(userID string) error {
+	reset, err := s.securityRepo.DisableTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if reset == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// verifySecondFactor accepts a TOTP code or, instead, an unused recovery code. Each TOTP
+// code and each recovery code works only once.
+func (s *TwoFactorService) verifySecondFactor(user models.User, code string, recoveryCode string) error {
+	switch {
+	case code != "":
+		step, ok := utils.VerifyTOTP(user.TwoFactorSecret, code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		accepted, err := s.securityRepo.AcceptTwoFactorStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	case recoveryCode != "":
+		used, err := s.securityRepo.UseRecoveryCode(user.ID, hashSecret(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	default:
+		return ErrTwoFactorRequired
+	}
+}
+
+// generateRecoveryCodes returns new recovery codes like k7qm2-xr4tz and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := make([]byte, 0, 11)
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				code = append(code, '-')
+			}
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate recovery code: %v", err)
+			}
+			code = append(code, recoveryCodeAlphabet[n.Int64()])
+		}
+		codes[i] = string(code)
+		hashes[i] = hashSecret(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed in upper case, without the dash or with spaces
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// getTOTPIssuer is the account issuer shown in authenticator apps (TOTP_ISSUER, default
+// PenDeploy)
+func getTOTPIssuer() string {
+	if value := optionalEnvString("TOTP_ISSUER"); value != nil {
+		return *value
+	}
+	return "PenDeploy"
+}
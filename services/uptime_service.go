@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	uptimeTickInterval    = 15 * time.Second
+	uptimeRetention       = 30 * 24 * time.Hour
+	uptimeMaxConcurrency  = 10
+	uptimeRecentChecks    = 50
+	defaultUptimeInterval = 60
+	uptimeStatusUp        = "up"
+	uptimeStatusDown      = "down"
+	uptimeStatusUnknown   = "unknown"
+)
+
+var uptimeOnce sync.Once
+
+// UptimeService configures uptime monitors, runs the checker and summarizes the history
+type UptimeService struct {
+	uptimeRepo  *repositories.UptimeRepository
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewUptimeService creates a new uptime service instance
+func NewUptimeService() *UptimeService {
+	return &UptimeService{
+		uptimeRepo:  repositories.NewUptimeRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// GetUptime returns the monitor, uptime percentages and recent checks of a service
+func (s *UptimeService) GetUptime(serviceID string, userID string, isAdmin bool) (dto.UptimeSummary, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return dto.UptimeSummary{}, err
+	}
+
+	var monitor *models.UptimeMonitor
+	if found, err := s.uptimeRepo.FindMonitor(serviceID); err == nil {
+		monitor = &found
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.UptimeSummary{}, err
+	}
+
+	summary, err := s.summarize(service, monitor)
+	if err != nil {
+		return summary, err
+	}
+	summary.Checks, err = s.uptimeRepo.FindRecentChecks(serviceID, uptimeRecentChecks)
+	return summary, err
+}
+
+// SaveMonitor creates or updates the uptime monitor of a git service
+func (s *UptimeService) SaveMonitor(serviceID string, req dto.UptimeMonitorRequest, userID string, isAdmin bool) (models.UptimeMonitor, error) {
+	service, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+	if err != nil {
+		return models.UptimeMonitor{}, err
+	}
+	if service.Type != models.ServiceTypeGit {
+		return models.UptimeMonitor{}, errors.New("uptime monitoring is only available for git services")
+	}
+
+	monitor := models.UptimeMonitor{
+		ServiceID:       serviceID,
+		Path:            req.Path,
+		IntervalSeconds: req.IntervalSeconds,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		UpdatedBy:       userID,
+	}
+	if monitor.Path == "" {
+		monitor.Path = "/"
+	}
+	if monitor.IntervalSeconds == 0 {
+		monitor.IntervalSeconds = defaultUptimeInterval
+	}
+	if existing, err := s.uptimeRepo.FindMonitor(serviceID); err == nil {
+		monitor.CreatedAt = existing.CreatedAt
+		monitor.LastCheckedAt = existing.LastCheckedAt
+	}
+	return s.uptimeRepo.SaveMonitor(monitor)
+}
+
+// StartUptimeChecker starts the background loop probing enabled monitors when they are due
+func (s *UptimeService) StartUptimeChecker() {
+	uptimeOnce.Do(func() {
+		go func() {
+			log.Printf("Uptime checker started (tick %v)", uptimeTickInterval)
+			ticker := time.NewTicker(uptimeTickInterval)
+			defer ticker.Stop()
+			prune := time.NewTicker(time.Hour)
+			defer prune.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					s.checkDueMonitors()
+				case <-prune.C:
+					if deleted, err := s.uptimeRepo.DeleteChecksBefore(time.Now().Add(-uptimeRetention)); err != nil {
+						log.Printf("Uptime prune failed: %v", err)
+					} else if deleted > 0 {
+						log.Printf("Uptime pruned %d checks", deleted)
+					}
+				}
+			}
+		}()
+	})
+}
+
+// checkDueMonitors probes every monitor whose interval has elapsed. Services that were
+// never deployed are skipped rather than recorded as down.
+func (s *UptimeService) checkDueMonitors() {
+	monitors, err := s.uptimeRepo.FindEnabledMonitors()
+	if err != nil {
+		log.Printf("Uptime checker: failed to load monitors: %v", err)
+		return
+	}
+
+	now := time.Now()
+	semaphore := make(chan struct{}, uptimeMaxConcurrency)
+	var wg sync.WaitGroup
+	for _, monitor := range monitors {
+		if monitor.LastCheckedAt != nil && now.Sub(*monitor.LastCheckedAt) < time.Duration(monitor.IntervalSeconds)*time.Second {
+			continue
+		}
+		if monitor.Service.ID == "" || monitor.Service.Status == "inactive" {
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(monitor models.UptimeMonitor) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			check := utils.ProbeUptime(utils.GetUptimeCheckURL(monitor.Service, monitor.Path))
+			check.ServiceID = monitor.ServiceID
+			if err := s.uptimeRepo.RecordCheck(check); err != nil {
+				log.Printf("Uptime checker: failed to record check of service %s: %v", monitor.ServiceID, err)
+			}
+		}(monitor)
+	}
+	wg.Wait()
+}
+
+// summarize computes the current status and uptime percentages of a service
+func (s *UptimeService) summarize(service models.Service, monitor *models.UptimeMonitor) (dto.UptimeSummary, error) {
+	summary := dto.UptimeSummary{
+		ServiceID:   service.ID,
+		ServiceName: service.Name,
+		Monitor:     monitor,
+		Status:      uptimeStatusUnknown,
+	}
+	if monitor == nil {
+		return summary, nil
+	}
+
+	latest, err := s.uptimeRepo.FindRecentChecks(service.ID, 1)
+	if err != nil {
+		return summary, err
+	}
+	if len(latest) > 0 && monitor.Enabled {
+		summary.Status = uptimeStatusDown
+		if latest[0].Up {
+			summary.Status = uptimeStatusUp
+		}
+	}
+
+	now := time.Now()
+	for _, window := range []struct {
+		target   **float64
+		duration time.Duration
+	}{
+		{&summary.Uptime24h, 24 * time.Hour},
+		{&summary.Uptime7d, 7 * 24 * time.Hour},
+		{&summary.Uptime30d, 30 * 24 * time.Hour},
+	} {
+		totals, err := s.uptimeRepo.CountChecksSince(service.ID, now.Add(-window.duration))
+		if err != nil {
+			return summary, err
+		}
+		if totals.Total > 0 {
+			percentage := float64(totals.Up) * 100 / float64(totals.Total)
+			*window.target = &percentage
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+	"gorm.io/gorm"
+)
+
+// anomalyResolveGrace is how long an anomaly must stay clear before it is resolved, so a
+// service hovering around a threshold keeps one anomaly and is alerted about once
+const anomalyResolveGrace = 15 * time.Minute
+
+var anomalyDetectorOnce sync.Once
+
+// UsageAnomalyService analyzes the usage history of services for sudden memory growth, CPU
+// saturation and restart storms, alerts about them and flags the services as needing attention
+type UsageAnomalyService struct {
+	anomalyRepo *repositories.UsageAnomalyRepository
+	usageRepo   *repositories.UsageSampleRepository
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+	outboxRepo  *repositories.OutboxRepository
+}
+
+// NewUsageAnomalyService creates a new usage anomaly service instance
+func NewUsageAnomalyService() *UsageAnomalyService {
+	return &UsageAnomalyService{
+		anomalyRepo: repositories.NewUsageAnomalyRepository(),
+		usageRepo:   repositories.NewUsageSampleRepository(),
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+		outboxRepo:  repositories.NewOutboxRepository(),
+	}
+}
+
+// ListAnomalies returns a page of a service's detected usage anomalies, newest first
+func (s *UsageAnomalyService) ListAnomalies(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.UsageAnomalyListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.UsageAnomalyListResponse{}, err
+	}
+
+	anomalies, total, err := s.anomalyRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.UsageAnomalyListResponse{}, err
+	}
+	return dto.UsageAnomalyListResponse{
+		Anomalies:  anomalies,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// StartAnomalyDetector starts the background loop analyzing the usage history as it is
+// recorded by the usage sampler
+func (s *UsageAnomalyService) StartAnomalyDetector() {
+	anomalyDetectorOnce.Do(func() {
+		interval := utils.GetUsageSampleInterval()
+		go func() {
+			log.Printf("Usage anomaly detector started (interval %v, baseline %v)", interval, utils.GetUsageAnomalyBaseline())
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				s.detectOnce()
+			}
+		}()
+	})
+}
+
+func (s *UsageAnomalyService) detectOnce() {
+	services, err := s.serviceRepo.FindAll()
+	if err != nil {
+		log.Printf("Anomaly detector: failed to load services: %v", err)
+		return
+	}
+
+	webhookURL := optionalEnvString("ANOMALY_ALERT_WEBHOOK_URL")
+	now := time.Now()
+	for _, service := range services {
+		if service.Status == "inactive" {
+			if service.AttentionNeeded {
+				// A stopped service uses nothing; its anomalies are over
+				if err := s.reconcile(service, nil, now, nil); err != nil {
+					log.Printf("Anomaly detector: failed to clear anomalies of service %s: %v", service.ID, err)
+				}
+			}
+			continue
+		}
+
+		samples, err := s.usageRepo.FindServiceSamplesSince(service.ID, now.Add(-utils.GetUsageAnomalyBaseline()))
+		if err != nil {
+			log.Printf("Anomaly detector: failed to load usage of service %s: %v", service.ID, err)
+			continue
+		}
+		findings, ok := utils.DetectUsageAnomalies(service, samples, now)
+		if !ok {
+			continue
+		}
+		if err := s.reconcile(service, findings, now, webhookURL); err != nil {
+			log.Printf("Anomaly detector: failed to record anomalies of service %s: %v", service.ID, err)
+		}
+	}
+}
+
+// reconcile opens an anomaly, and alerts about it, for every new finding, keeps the open ones
+// that are still found and resolves those clear for the grace period. The service needs
+// attention while any anomaly is open.
+func (s *UsageAnomalyService) reconcile(service models.Service, findings []utils.UsageAnomalyFinding, now time.Time, webhookURL *string) error {
+	open, err := s.anomalyRepo.FindOpen(service.ID)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]utils.UsageAnomalyFinding, len(findings))
+	for _, finding := range findings {
+		found[finding.Kind] = finding
+	}
+
+	var reasons []string
+	for _, anomaly := range open {
+		finding, ok := found[anomaly.Kind]
+		switch {
+		case ok:
+			anomaly.Message = finding.Message
+			anomaly.Observed = finding.Observed
+			anomaly.Baseline = finding.Baseline
+			anomaly.LastSeenAt = now
+			delete(found, anomaly.Kind)
+		case now.Sub(anomaly.LastSeenAt) >= anomalyResolveGrace || service.Status == "inactive":
+			resolvedAt := now
+			anomaly.ResolvedAt = &resolvedAt
+		default:
+			reasons = append(reasons, anomaly.Message)
+			continue
+		}
+		if err := s.anomalyRepo.Save(anomaly); err != nil {
+			return err
+		}
+		if anomaly.ResolvedAt == nil {
+			reasons = append(reasons, anomaly.Message)
+		}
+	}
+
+	alerted := false
+	for _, finding := range findings {
+		if _, ok := found[finding.Kind]; !ok {
+			continue
+		}
+		anomaly := models.UsageAnomaly{
+			ServiceID:  service.ID,
+			Kind:       finding.Kind,
+			Message:    finding.Message,
+			Observed:   finding.Observed,
+			Baseline:   finding.Baseline,
+			DetectedAt: now,
+			LastSeenAt: now,
+		}
+		log.Printf("Usage anomaly on service %s (%s): %s", service.Name, service.ID, finding.Message)
+
+		err := s.anomalyRepo.DB().Transaction(func(tx *gorm.DB) error {
+			created, err := s.anomalyRepo.CreateTx(tx, anomaly)
+			if err != nil {
+				return err
+			}
+			if webhookURL == nil {
+				return nil
+			}
+			payload, err := json.Marshal(dto.UsageAnomalyPayload{
+				Event:       models.OutboxEventUsageAnomaly,
+				ServiceID:   service.ID,
+				ServiceName: service.Name,
+				ProjectID:   service.ProjectID,
+				Kind:        created.Kind,
+				Message:     created.Message,
+				DetectedAt:  created.DetectedAt,
+			})
+			if err != nil {
+				return err
+			}
+			return s.outboxRepo.Enqueue(tx, models.OutboxEvent{
+				EventType:   models.OutboxEventUsageAnomaly,
+				AggregateID: created.ID,
+				Payload:     string(payload),
+				CallbackURL: *webhookURL,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		alerted = alerted || webhookURL != nil
+		reasons = append(reasons, finding.Message)
+	}
+	if alerted {
+		NotifyOutbox()
+	}
+
+	reason := strings.Join(reasons, "; ")
+	needed := len(reasons) > 0
+	if needed == service.AttentionNeeded && reason == service.AttentionReason {
+		return nil
+	}
+	return s.serviceRepo.UpdateAttention(service.ID, needed, reason)
+}
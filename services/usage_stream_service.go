@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// usageStreamMaxDuration bounds a live usage stream; dashboards reconnect when it ends
+const usageStreamMaxDuration = time.Hour
+
+// UsageStreamService streams live per-pod CPU and memory samples of a service while a
+// dashboard watches it
+type UsageStreamService struct {
+	serviceRepo *repositories.ServiceRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+// NewUsageStreamService creates a new usage stream service instance
+func NewUsageStreamService() *UsageStreamService {
+	return &UsageStreamService{
+		serviceRepo: repositories.NewServiceRepository(),
+		projectRepo: repositories.NewProjectRepository(),
+	}
+}
+
+// GetStreamableService returns the service to stream, checking access before the stream starts
+func (s *UsageStreamService) GetStreamableService(serviceID string, userID string, isAdmin bool) (models.Service, error) {
+	return findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin)
+}
+
+// StreamUsage writes a dto.ServiceUsageSnapshot as Server-Sent Event each time the metrics
+// API has a new sample, until the client disconnects. Failed samples are sent as "error"
+// events and retried.
+func (s *UsageStreamService) StreamUsage(service models.Service, w http.ResponseWriter, done <-chan struct{}) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageStreamMaxDuration)
+	defer cancel()
+
+	var last dto.ServiceUsageSnapshot
+	lastError := ""
+	sample := func() {
+		sampleCtx, sampleCancel := context.WithTimeout(ctx, 10*time.Second)
+		snapshot, err := utils.SampleServiceUsage(sampleCtx, k8sClient, service)
+		sampleCancel()
+		if err != nil {
+			// Report each distinct failure once, not on every poll
+			if err.Error() != lastError {
+				lastError = err.Error()
+				utils.WriteSSEEvent(w, "error", lastError)
+				flusher.Flush()
+			}
+			return
+		}
+		lastError = ""
+		if snapshot.Timestamp.Equal(last.Timestamp) && len(snapshot.Pods) == len(last.Pods) {
+			return
+		}
+		last = snapshot
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return
+		}
+		utils.WriteSSEData(w, string(data))
+		flusher.Flush()
+	}
+
+	sample()
+	ticker := time.NewTicker(utils.GetUsageStreamInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			sample()
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"github.com/pendeploy-simple/repositories"
+	"github.com/pendeploy-simple/utils"
+)
+
+// versionUpdaterInterval is how often the updater looks for open maintenance windows; it
+// is well below the window length so every window is seen
+const versionUpdaterInterval = 10 * time.Minute
+
+// versionUpdateSnapshotReason labels the volume snapshots taken before an update
+const versionUpdateSnapshotReason = "pre-update"
+
+var versionUpdaterOnce sync.Once
+
+// VersionUpdateService rolls managed services on a patch or minor update channel to the
+// newest matching image during their maintenance window
+type VersionUpdateService struct {
+	updateRepo     *repositories.ServiceVersionUpdateRepository
+	serviceRepo    *repositories.ServiceRepository
+	projectRepo    *repositories.ProjectRepository
+	managedService *ManagedServiceService
+	deployLocks    *DeployLockService
+}
+
+// NewVersionUpdateService creates a new version update service instance
+func NewVersionUpdateService() *VersionUpdateService {
+	return &VersionUpdateService{
+		updateRepo:     repositories.NewServiceVersionUpdateRepository(),
+		serviceRepo:    repositories.NewServiceRepository(),
+		projectRepo:    repositories.NewProjectRepository(),
+		managedService: NewManagedServiceService(),
+		deployLocks:    NewDeployLockService(),
+	}
+}
+
+// ListUpdates returns a page of a service's automatic version updates, newest first
+func (s *VersionUpdateService) ListUpdates(serviceID string, page, pageSize int, userID string, isAdmin bool) (dto.ServiceVersionUpdateListResponse, error) {
+	if _, err := findAccessibleService(s.serviceRepo, s.projectRepo, serviceID, userID, isAdmin); err != nil {
+		return dto.ServiceVersionUpdateListResponse{}, err
+	}
+
+	updates, total, err := s.updateRepo.FindByServiceID(serviceID, page, pageSize)
+	if err != nil {
+		return dto.ServiceVersionUpdateListResponse{}, err
+	}
+	return dto.ServiceVersionUpdateListResponse{
+		Updates:    updates,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// StartVersionUpdater starts the background loop that applies automatic version updates
+func (s *VersionUpdateService) StartVersionUpdater() {
+	versionUpdaterOnce.Do(func() {
+		go func() {
+			log.Printf("Version updater started (interval %v)", versionUpdaterInterval)
+			ticker := time.NewTicker(versionUpdaterInterval)
+			defer ticker.Stop()
+
+			s.updateOnce()
+			for range ticker.C {
+				s.updateOnce()
+			}
+		}()
+	})
+}
+
+// updateOnce updates every service whose maintenance window is open and that was not
+// already updated in this window. Services are updated one at a time.
+func (s *VersionUpdateService) updateOnce() {
+	services, err := s.serviceRepo.FindAll()
+	if err != nil {
+		log.Printf("Version updater: failed to load services: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, service := range services {
+		if service.Type != models.ServiceTypeManaged || !utils.IsAutoUpdateEnabled(service.AutoUpdate) || service.Status != "running" {
+			continue
+		}
+		windowStart, open := utils.MaintenanceWindowStart(service.MaintenanceWindow, now)
+		if !open {
+			continue
+		}
+		attempted, err := s.updateRepo.StartedSince(service.ID, windowStart)
+		if err != nil {
+			log.Printf("Version updater: failed to load updates of service %s: %v", service.ID, err)
+			continue
+		}
+		if attempted {
+			continue
+		}
+		// Deploy locks freeze automatic updates just like deploys
+		if err := s.deployLocks.CheckDeployAllowed(service, false); err != nil {
+			continue
+		}
+
+		next, err := utils.NextManagedServiceVersion(service.ManagedType, service.Version, service.AutoUpdate)
+		if err != nil {
+			log.Printf("Version updater: failed to look up versions of service %s: %v", service.ID, err)
+			continue
+		}
+		if next == "" {
+			continue
+		}
+		s.update(service, next)
+	}
+}
+
+// update takes a snapshot of the service's volumes, rolls out the new version and waits for
+// it to become ready. An unhealthy new version is rolled back to the previous one.
+func (s *VersionUpdateService) update(service models.Service, version string) {
+	record, err := s.updateRepo.Create(models.ServiceVersionUpdate{
+		ServiceID:   service.ID,
+		FromVersion: service.Version,
+		ToVersion:   version,
+		Status:      models.VersionUpdateRunning,
+		StartedAt:   time.Now(),
+	})
+	if err != nil {
+		log.Printf("Version updater: failed to record update of service %s: %v", service.ID, err)
+		return
+	}
+	log.Printf("Version updater: updating service %s from %s to %s", service.ID, service.Version, version)
+
+	snapshots, err := utils.SnapshotManagedServiceVolumes(service, versionUpdateSnapshotReason)
+	record.Snapshots = strings.Join(snapshots, ",")
+	if err != nil {
+		s.finish(record, models.VersionUpdateFailed, fmt.Errorf("backup failed, version not changed: %v", err))
+		return
+	}
+
+	previousVersion := service.Version
+	service.Version = version
+	err = s.rollOut(service)
+	if err == nil {
+		s.finish(record, models.VersionUpdateSucceeded, nil)
+		return
+	}
+
+	log.Printf("Version updater: service %s is unhealthy on %s, rolling back: %v", service.ID, version, err)
+	service.Version = previousVersion
+	if rollbackErr := s.rollOut(service); rollbackErr != nil {
+		s.finish(record, models.VersionUpdateFailed, fmt.Errorf("%v; rollback to %s failed: %v (restore from the snapshots)", err, previousVersion, rollbackErr))
+		return
+	}
+	s.finish(record, models.VersionUpdateRolledBack, err)
+}
+
+// rollOut deploys the service with its current version and waits for the readiness probe.
+// Only the version and status columns are written, as the service may be edited meanwhile.
+func (s *VersionUpdateService) rollOut(service models.Service) error {
+	service.Status = "building"
+	if err := s.serviceRepo.UpdateColumns(service, "Version", "Status"); err != nil {
+		return fmt.Errorf("failed to save service: %v", err)
+	}
+
+	deployed, err := s.managedService.deployManagedServiceToKubernetes(service)
+	if err != nil {
+		s.serviceRepo.UpdateStatus(service.ID, "failed")
+		return err
+	}
+
+	err = utils.WaitForManagedServiceReady(*deployed, utils.ManagedServiceReadyTimeout)
+	status := "running"
+	if err != nil {
+		status = "failed"
+	}
+	if saveErr := s.serviceRepo.UpdateStatus(service.ID, status); saveErr != nil {
+		log.Printf("Version updater: failed to save status of service %s: %v", service.ID, saveErr)
+	}
+	return err
+}
+
+// finish records the outcome of an update
+func (s *VersionUpdateService) finish(record models.ServiceVersionUpdate, status string, err error) {
+	now := time.Now()
+	record.Status = status
+	record.FinishedAt = &now
+	if err != nil {
+		record.Error = err.Error()
+		log.Printf("Version updater: update of service %s to %s %s: %v", record.ServiceID, record.ToVersion, status, err)
+	}
+	if saveErr := s.updateRepo.Save(record); saveErr != nil {
+		log.Printf("Version updater: failed to record outcome of service %s update: %v", record.ServiceID, saveErr)
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessLogEntry is one request Traefik served, from its JSON access log
+type AccessLogEntry struct {
+	Time     time.Time
+	Host     string
+	Method   string
+	Path     string // without the query string
+	Status   int
+	Duration time.Duration
+}
+
+// traefikAccessLog holds the fields read from a line of Traefik's JSON access log
+// (accessLog.format=json)
+type traefikAccessLog struct {
+	StartUTC         time.Time `json:"StartUTC"`
+	RequestHost      string    `json:"RequestHost"`
+	RequestMethod    string    `json:"RequestMethod"`
+	RequestPath      string    `json:"RequestPath"`
+	DownstreamStatus int       `json:"DownstreamStatus"`
+	Duration         int64     `json:"Duration"` // nanoseconds
+}
+
+// AccessLogScan is the result of reading the ingress access logs
+type AccessLogScan struct {
+	Entries []AccessLogEntry
+	// AccessLogFound is false when no line of the ingress pods parsed as a JSON access log,
+	// usually because access logs are off or not in JSON format
+	AccessLogFound bool
+	// Truncated is set when maxLines was reached before the end of the window
+	Truncated bool
+}
+
+// GetIngressNamespace returns the namespace of the Traefik ingress controller
+// (TRAEFIK_NAMESPACE, default kube-system)
+func GetIngressNamespace() string {
+	if namespace := os.Getenv("TRAEFIK_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return "kube-system"
+}
+
+// GetIngressPodSelector returns the label selector of the Traefik pods
+// (TRAEFIK_POD_SELECTOR, default app.kubernetes.io/name=traefik)
+func GetIngressPodSelector() string {
+	if selector := os.Getenv("TRAEFIK_POD_SELECTOR"); selector != "" {
+		return selector
+	}
+	return "app.kubernetes.io/name=traefik"
+}
+
+// ServiceHostnames returns the hostnames the service's ingress serves, including a custom
+// domain with an uploaded certificate
+func ServiceHostnames(service models.Service) []string {
+	hostnames := buildHostnames(service)
+	if service.CustomDomain != "" && service.CustomTLSSecret != "" {
+		hostnames = append(hostnames, service.CustomDomain)
+	}
+	return hostnames
+}
+
+// ReadServiceAccessLogs reads the requests for the service's hostnames that the Traefik pods
+// logged since the given time, scanning at most maxLines lines per pod
+func ReadServiceAccessLogs(service models.Service, since time.Time, maxLines int) (AccessLogScan, error) {
+	var scan AccessLogScan
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return scan, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(GetIngressNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: GetIngressPodSelector(),
+	})
+	if err != nil {
+		return scan, fmt.Errorf("failed to list ingress pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return scan, fmt.Errorf("no ingress pods match %q in namespace %s", GetIngressPodSelector(), GetIngressNamespace())
+	}
+
+	hosts := map[string]bool{}
+	for _, host := range ServiceHostnames(service) {
+		hosts[strings.ToLower(host)] = true
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if err := scanAccessLog(ctx, k8sClient, pod, since, maxLines, hosts, &scan); err != nil {
+			return scan, fmt.Errorf("failed to read logs of ingress pod %s: %v", pod.Name, err)
+		}
+	}
+	return scan, nil
+}
+
+func scanAccessLog(ctx context.Context, client *kubernetes.Client, pod corev1.Pod, since time.Time, maxLines int, hosts map[string]bool, scan *AccessLogScan) error {
+	sinceTime := metav1.NewTime(since)
+	stream, err := client.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		SinceTime: &sinceTime,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lines := 0
+	for scanner.Scan() {
+		if lines++; lines > maxLines {
+			scan.Truncated = true
+			break
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var record traefikAccessLog
+		if err := json.Unmarshal(line, &record); err != nil || record.RequestHost == "" {
+			continue
+		}
+		scan.AccessLogFound = true
+
+		// RequestHost keeps the port of the Host header, if any
+		host := strings.ToLower(record.RequestHost)
+		if colon := strings.LastIndex(host, ":"); colon >= 0 && !strings.HasSuffix(host, "]") {
+			host = host[:colon]
+		}
+		if !hosts[host] || record.StartUTC.Before(since) {
+			continue
+		}
+		path, _, _ := strings.Cut(record.RequestPath, "?")
+		scan.Entries = append(scan.Entries, AccessLogEntry{
+			Time:     record.StartUTC,
+			Host:     host,
+			Method:   record.RequestMethod,
+			Path:     path,
+			Status:   record.DownstreamStatus,
+			Duration: time.Duration(record.Duration),
+		})
+	}
+	return scanner.Err()
+}
+
+// accessLogIDSegment matches path segments that identify a record: numbers, UUIDs and long
+// hex strings
+var accessLogIDSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// NormalizeAccessLogPath replaces the ID segments of a request path with ":id", so requests
+// for different records of the same route are counted together
+func NormalizeAccessLogPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if accessLogIDSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
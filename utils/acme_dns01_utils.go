@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DNS01ClusterIssuerName is the cert-manager ClusterIssuer that solves ACME DNS-01 challenges
+const DNS01ClusterIssuerName = "letsencrypt-dns01"
+
+// TLS challenge types a git service can request for its certificates
+const (
+	TLSChallengeHTTP01 = "http01"
+	TLSChallengeDNS01  = "dns01"
+)
+
+const (
+	defaultACMEServer           = "https://acme-v02.api.letsencrypt.org/directory"
+	defaultCertManagerNamespace = "cert-manager"
+	dns01CredentialsSecretName  = "pendeploy-dns01-credentials"
+)
+
+// DNS01Config describes the DNS provider used to solve DNS-01 challenges, read from
+// DNS01_PROVIDER (cloudflare or route53) and the provider's credential variables
+type DNS01Config struct {
+	Provider  string
+	Email     string   // ACME_EMAIL
+	Server    string   // ACME_SERVER, default Let's Encrypt production
+	Zones     []string // DNS01_ZONES, restricts the solver to these zones (all when empty)
+	Namespace string   // CERT_MANAGER_NAMESPACE, where ClusterIssuer secrets live
+
+	CloudflareAPIToken string // CLOUDFLARE_API_TOKEN
+
+	Route53Region          string // AWS_REGION
+	Route53HostedZoneID    string // ROUTE53_HOSTED_ZONE_ID (optional)
+	Route53AccessKeyID     string // AWS_ACCESS_KEY_ID (optional with IRSA/instance roles)
+	Route53SecretAccessKey string // AWS_SECRET_ACCESS_KEY
+}
+
+// LoadDNS01Config reads the DNS-01 configuration. ok is false when no provider is configured.
+func LoadDNS01Config() (config DNS01Config, ok bool, err error) {
+	config = DNS01Config{
+		Provider:  strings.ToLower(strings.TrimSpace(os.Getenv("DNS01_PROVIDER"))),
+		Email:     strings.TrimSpace(os.Getenv("ACME_EMAIL")),
+		Server:    strings.TrimSpace(os.Getenv("ACME_SERVER")),
+		Namespace: strings.TrimSpace(os.Getenv("CERT_MANAGER_NAMESPACE")),
+
+		CloudflareAPIToken: strings.TrimSpace(os.Getenv("CLOUDFLARE_API_TOKEN")),
+
+		Route53Region:          strings.TrimSpace(os.Getenv("AWS_REGION")),
+		Route53HostedZoneID:    strings.TrimSpace(os.Getenv("ROUTE53_HOSTED_ZONE_ID")),
+		Route53AccessKeyID:     strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID")),
+		Route53SecretAccessKey: strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY")),
+	}
+	if config.Provider == "" {
+		return config, false, nil
+	}
+	if config.Server == "" {
+		config.Server = defaultACMEServer
+	}
+	if config.Namespace == "" {
+		config.Namespace = defaultCertManagerNamespace
+	}
+	for _, zone := range strings.Split(os.Getenv("DNS01_ZONES"), ",") {
+		if zone = NormalizeDomain(zone); zone != "" {
+			config.Zones = append(config.Zones, zone)
+		}
+	}
+
+	if config.Email == "" {
+		return config, true, fmt.Errorf("ACME_EMAIL is required for DNS-01")
+	}
+	switch config.Provider {
+	case "cloudflare":
+		if config.CloudflareAPIToken == "" {
+			return config, true, fmt.Errorf("CLOUDFLARE_API_TOKEN is required for the cloudflare DNS-01 provider")
+		}
+	case "route53":
+		if config.Route53Region == "" {
+			return config, true, fmt.Errorf("AWS_REGION is required for the route53 DNS-01 provider")
+		}
+		if config.Route53AccessKeyID != "" && config.Route53SecretAccessKey == "" {
+			return config, true, fmt.Errorf("AWS_SECRET_ACCESS_KEY is required when AWS_ACCESS_KEY_ID is set")
+		}
+	default:
+		return config, true, fmt.Errorf("unsupported DNS01_PROVIDER %q (cloudflare or route53)", config.Provider)
+	}
+	return config, true, nil
+}
+
+// IsDNS01Enabled reports whether a valid DNS-01 provider is configured
+func IsDNS01Enabled() bool {
+	_, ok, err := LoadDNS01Config()
+	return ok && err == nil
+}
+
+// GetServiceClusterIssuer returns the ClusterIssuer that signs a service's ingress certificate
+func GetServiceClusterIssuer(service models.Service) string {
+	if service.TLSChallenge == TLSChallengeDNS01 {
+		return DNS01ClusterIssuerName
+	}
+	return GetClusterIssuer()
+}
+
+// EnsureDNS01Issuer creates or updates the provider credentials Secret and the DNS-01
+// ClusterIssuer from the current configuration
+func EnsureDNS01Issuer() (dto.DNS01Status, error) {
+	config, ok, err := LoadDNS01Config()
+	status := dto.DNS01Status{Enabled: ok, Provider: config.Provider, IssuerName: DNS01ClusterIssuerName, Zones: config.Zones}
+	if !ok {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return status, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := applyDNS01Credentials(ctx, k8sClient, config); err != nil {
+		return status, err
+	}
+
+	issuer := buildDNS01ClusterIssuer(config)
+	issuers := k8sClient.DynamicClient.Resource(clusterIssuerGVR)
+	existing, err := issuers.Get(ctx, DNS01ClusterIssuerName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = issuers.Create(ctx, issuer, metav1.CreateOptions{})
+	case err == nil:
+		issuer.SetResourceVersion(existing.GetResourceVersion())
+		_, err = issuers.Update(ctx, issuer, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to apply ClusterIssuer %s: %v", DNS01ClusterIssuerName, err)
+	}
+
+	log.Printf("DNS-01 ClusterIssuer %s applied (provider %s)", DNS01ClusterIssuerName, config.Provider)
+	if err := CheckClusterIssuerReady(DNS01ClusterIssuerName); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Ready = true
+	}
+	return status, nil
+}
+
+// GetDNS01Status reports the DNS-01 configuration and whether its ClusterIssuer is ready
+func GetDNS01Status() dto.DNS01Status {
+	config, ok, err := LoadDNS01Config()
+	status := dto.DNS01Status{Enabled: ok, Provider: config.Provider, IssuerName: DNS01ClusterIssuerName, Zones: config.Zones}
+	if !ok {
+		return status
+	}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if err := CheckClusterIssuerReady(DNS01ClusterIssuerName); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Ready = true
+	}
+	return status
+}
+
+// applyDNS01Credentials stores the provider secret where cert-manager reads ClusterIssuer secrets
+func applyDNS01Credentials(ctx context.Context, client *kubernetes.Client, config DNS01Config) error {
+	data := map[string][]byte{}
+	switch config.Provider {
+	case "cloudflare":
+		data["api-token"] = []byte(config.CloudflareAPIToken)
+	case "route53":
+		if config.Route53SecretAccessKey == "" {
+			// Ambient AWS credentials, nothing to store
+			return nil
+		}
+		data["secret-access-key"] = []byte(config.Route53SecretAccessKey)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dns01CredentialsSecretName,
+			Namespace: config.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "pendeploy"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+
+	secrets := client.Clientset.CoreV1().Secrets(config.Namespace)
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store DNS-01 credentials in %s: %v", config.Namespace, err)
+	}
+	return nil
+}
+
+// buildDNS01ClusterIssuer renders the cert-manager ClusterIssuer for the configured provider
+func buildDNS01ClusterIssuer(config DNS01Config) *unstructured.Unstructured {
+	var dns01 map[string]interface{}
+	switch config.Provider {
+	case "cloudflare":
+		dns01 = map[string]interface{}{
+			"cloudflare": map[string]interface{}{
+				"apiTokenSecretRef": map[string]interface{}{
+					"name": dns01CredentialsSecretName,
+					"key":  "api-token",
+				},
+			},
+		}
+	case "route53":
+		route53 := map[string]interface{}{"region": config.Route53Region}
+		if config.Route53HostedZoneID != "" {
+			route53["hostedZoneID"] = config.Route53HostedZoneID
+		}
+		if config.Route53AccessKeyID != "" {
+			route53["accessKeyID"] = config.Route53AccessKeyID
+			route53["secretAccessKeySecretRef"] = map[string]interface{}{
+				"name": dns01CredentialsSecretName,
+				"key":  "secret-access-key",
+			}
+		}
+		dns01 = map[string]interface{}{"route53": route53}
+	}
+
+	solver := map[string]interface{}{"dns01": dns01}
+	if len(config.Zones) > 0 {
+		zones := make([]interface{}, 0, len(config.Zones))
+		for _, zone := range config.Zones {
+			zones = append(zones, zone)
+		}
+		solver["selector"] = map[string]interface{}{"dnsZones": zones}
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "ClusterIssuer",
+		"metadata": map[string]interface{}{
+			"name":   DNS01ClusterIssuerName,
+			"labels": map[string]interface{}{"app.kubernetes.io/managed-by": "pendeploy"},
+		},
+		"spec": map[string]interface{}{
+			"acme": map[string]interface{}{
+				"server": config.Server,
+				"email":  config.Email,
+				"privateKeySecretRef": map[string]interface{}{
+					"name": DNS01ClusterIssuerName + "-account-key",
+				},
+				"solvers": []interface{}{solver},
+			},
+		},
+	}}
+}
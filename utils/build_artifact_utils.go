@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CraneImage exports the filesystem of a built image; the debug variant ships a shell
+	CraneImage = "gcr.io/go-containerregistry/crane/debug:v0.20.2"
+	// artifactExportTimeout bounds pulling the image, archiving and uploading
+	artifactExportTimeout   = 10 * time.Minute
+	artifactStoreSecretName = "pendeploy-artifact-store"
+)
+
+var artifactPathPattern = regexp.MustCompile(`^/[A-Za-z0-9._/-]+$`)
+
+// ArtifactStoreConfig is the S3-compatible bucket (usually MinIO) build artifacts are
+// uploaded to, read from the ARTIFACTS_S3_* variables
+type ArtifactStoreConfig struct {
+	Endpoint  string // ARTIFACTS_S3_ENDPOINT, e.g. http://minio.kubesa-system.svc:9000
+	Bucket    string // ARTIFACTS_S3_BUCKET
+	AccessKey string // ARTIFACTS_S3_ACCESS_KEY
+	SecretKey string // ARTIFACTS_S3_SECRET_KEY
+	Region    string // ARTIFACTS_S3_REGION, default us-east-1
+}
+
+// LoadArtifactStoreConfig reads the artifact store configuration; ok is false when it is incomplete
+func LoadArtifactStoreConfig() (config ArtifactStoreConfig, ok bool) {
+	config = ArtifactStoreConfig{
+		Endpoint:  strings.TrimRight(strings.TrimSpace(os.Getenv("ARTIFACTS_S3_ENDPOINT")), "/"),
+		Bucket:    strings.TrimSpace(os.Getenv("ARTIFACTS_S3_BUCKET")),
+		AccessKey: strings.TrimSpace(os.Getenv("ARTIFACTS_S3_ACCESS_KEY")),
+		SecretKey: strings.TrimSpace(os.Getenv("ARTIFACTS_S3_SECRET_KEY")),
+		Region:    strings.TrimSpace(os.Getenv("ARTIFACTS_S3_REGION")),
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	ok = config.Endpoint != "" && config.Bucket != "" && config.AccessKey != "" && config.SecretKey != ""
+	return config, ok
+}
+
+// GetArtifactKey returns the object key of a deployment's build artifact
+func GetArtifactKey(service models.Service, deployment models.Deployment) string {
+	return fmt.Sprintf("%s/%s/%s.tar.gz", service.ProjectID, service.ID, deployment.ID)
+}
+
+// ExportBuildArtifact archives service.ArtifactPath from the built image and uploads it to
+// the artifact store. It returns the object key and the archive size in bytes.
+func ExportBuildArtifact(deployment models.Deployment, service models.Service, image string, insecureRegistry bool) (string, int64, error) {
+	config, ok := LoadArtifactStoreConfig()
+	if !ok {
+		return "", 0, fmt.Errorf("artifact store is not configured (ARTIFACTS_S3_*)")
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+	namespace := GetJobNamespace()
+
+	if err := applyArtifactStoreSecret(ctx, k8sClient, namespace, config); err != nil {
+		return "", 0, err
+	}
+
+	key := GetArtifactKey(service, deployment)
+	jobName := GetJobName(service.ID, deployment.ID) + "-artifact"
+	job := createArtifactExportJob(jobName, namespace, deployment, service, image, insecureRegistry, key)
+
+	_ = cleanupExistingJob(k8sClient, jobName, namespace)
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return "", 0, fmt.Errorf("failed to create artifact export job: %v", err)
+	}
+
+	jobErr := waitForJobCompletion(k8sClient, jobName, namespace, artifactExportTimeout)
+	output := readJobContainerLogs(k8sClient, jobName, namespace, "upload")
+	if jobErr != nil {
+		extractOutput := readJobContainerLogs(k8sClient, jobName, namespace, "extract")
+		return "", 0, fmt.Errorf("artifact export failed: %v: %s", jobErr, lastLine(extractOutput+output))
+	}
+
+	// The upload container prints the archive size on its last line
+	size, err := strconv.ParseInt(lastLine(output), 10, 64)
+	if err != nil {
+		log.Printf("Could not read artifact size for deployment %s: %q", deployment.ID, lastLine(output))
+	}
+	log.Printf("Exported %s from %s to %s/%s (%d bytes)", service.ArtifactPath, image, config.Bucket, key, size)
+	return key, size, nil
+}
+
+// createArtifactExportJob extracts the artifact directory from the image with crane and
+// uploads it as a gzipped tarball with mc. No container runs code from the image itself.
+func createArtifactExportJob(jobName, namespace string, deployment models.Deployment, service models.Service, image string, insecureRegistry bool, key string) *batchv1.Job {
+	labels := map[string]string{
+		"app":              "pendeploy",
+		"component":        "artifact-export",
+		"service-id":       service.ID,
+		"deployment-id":    deployment.ID,
+		LabelServiceID:     service.ID,
+		LabelEnvironmentID: service.EnvironmentID,
+	}
+
+	craneFlags := ""
+	if insecureRegistry {
+		craneFlags = "--insecure"
+	}
+	relativePath := strings.TrimPrefix(service.ArtifactPath, "/")
+
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: artifactStoreSecretName},
+				Key:                  key,
+			},
+		}}
+	}
+	volumeMounts := []corev1.VolumeMount{{Name: "artifact", MountPath: "/artifact"}}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(artifactExportTimeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{
+						{
+							Name:    "extract",
+							Image:   CraneImage,
+							Command: []string{"sh", "-c"},
+							Args: []string{fmt.Sprintf(`set -e
+mkdir -p /artifact/rootfs
+crane export %s "$IMAGE" - | tar -xof - -C /artifact/rootfs "$ARTIFACT_PATH"
+if [ ! -d "/artifact/rootfs/$ARTIFACT_PATH" ]; then
+  echo "artifact directory /$ARTIFACT_PATH not found in image"
+  exit 1
+fi
+tar -czf /artifact/artifact.tar.gz -C "/artifact/rootfs/$ARTIFACT_PATH" .
+rm -rf /artifact/rootfs`, craneFlags)},
+							Env: []corev1.EnvVar{
+								{Name: "IMAGE", Value: image},
+								{Name: "ARTIFACT_PATH", Value: relativePath},
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "upload",
+							Image:   MinIOClientImage,
+							Command: []string{"/bin/sh", "-c"},
+							Args: []string{`set -e
+mc alias set store "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY" >/dev/null
+mc mb --ignore-existing "store/$S3_BUCKET" >/dev/null
+mc cp --quiet /artifact/artifact.tar.gz "store/$S3_BUCKET/$S3_KEY" >/dev/null
+wc -c < /artifact/artifact.tar.gz | tr -d ' '`},
+							Env: []corev1.EnvVar{
+								secretEnv("S3_ENDPOINT", "endpoint"),
+								secretEnv("S3_BUCKET", "bucket"),
+								secretEnv("S3_ACCESS_KEY", "access-key"),
+								secretEnv("S3_SECRET_KEY", "secret-key"),
+								{Name: "S3_KEY", Value: key},
+								{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "artifact",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{
+									SizeLimit: resource.NewQuantity(4*1024*1024*1024, resource.BinarySI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	return job
+}
+
+// applyArtifactStoreSecret stores the artifact store credentials for export jobs
+func applyArtifactStoreSecret(ctx context.Context, client *kubernetes.Client, namespace string, config ArtifactStoreConfig) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      artifactStoreSecretName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "pendeploy"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"endpoint":   config.Endpoint,
+			"bucket":     config.Bucket,
+			"access-key": config.AccessKey,
+			"secret-key": config.SecretKey,
+		},
+	}
+
+	secrets := client.Clientset.CoreV1().Secrets(namespace)
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store artifact store credentials: %v", err)
+	}
+	return nil
+}
+
+// PresignArtifactURL returns a time-limited GET URL for an object in the artifact store
+// (AWS Signature Version 4, query string authentication, path-style addressing)
+func PresignArtifactURL(config ArtifactStoreConfig, key string, expires time.Duration) (string, error) {
+	return presignArtifactRequest(config, "GET", key, expires)
+}
+
+// presignArtifactRequest signs a request of the given method for an object in the artifact store
+func presignArtifactRequest(config ArtifactStoreConfig, method string, key string, expires time.Duration) (string, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return "", fmt.Errorf("invalid ARTIFACTS_S3_ENDPOINT %q", config.Endpoint)
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + config.Region + "/s3/aws4_request"
+
+	segments := []string{config.Bucket}
+	segments = append(segments, strings.Split(key, "/")...)
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	canonicalURI := "/" + strings.Join(segments, "/")
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", config.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + endpoint.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+config.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s", endpoint.Scheme, endpoint.Host, canonicalURI, canonicalQuery, signature), nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
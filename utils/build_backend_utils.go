@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// Build backends
+const (
+	BuildBackendKaniko   = "kaniko"   // Kaniko executor in a build job (the default)
+	BuildBackendBuildKit = "buildkit" // rootless BuildKit in a build job
+	BuildBackendExternal = "external" // the build API at BUILD_API_URL
+)
+
+// BuildBackends are the build backends in the order they are tried when the selected one
+// lacks a capability a service needs
+var BuildBackends = []string{BuildBackendKaniko, BuildBackendBuildKit, BuildBackendExternal}
+
+// BuildCapabilities are the optional features of a build backend
+type BuildCapabilities struct {
+	Cache     bool `json:"cache"`     // reuses the layers of earlier builds from a registry cache
+	MultiArch bool `json:"multiArch"` // publishes one manifest list for several target platforms
+	Secrets   bool `json:"secrets"`   // mounts secret env vars into RUN steps without baking them into layers
+}
+
+// Missing names the capabilities of required that c lacks
+func (c BuildCapabilities) Missing(required BuildCapabilities) []string {
+	var missing []string
+	if required.Cache && !c.Cache {
+		missing = append(missing, "build cache")
+	}
+	if required.MultiArch && !c.MultiArch {
+		missing = append(missing, "multi-arch images")
+	}
+	if required.Secrets && !c.Secrets {
+		missing = append(missing, "build secrets")
+	}
+	return missing
+}
+
+// Builder builds the image of a deployment from its service's repository and pushes it to
+// the registry
+type Builder interface {
+	Name() string
+	Capabilities() BuildCapabilities
+	// Build waits for the image and returns its reference
+	Build(deployment models.Deployment, service models.Service, registry models.Registry) (string, error)
+}
+
+var buildBackend = struct {
+	mu   sync.RWMutex
+	name string
+}{}
+
+// newBuilder returns the build backend named name, or nil for an unknown name or the
+// external backend without BUILD_API_URL
+func newBuilder(name string) Builder {
+	switch name {
+	case BuildBackendKaniko:
+		return kanikoBuilder{}
+	case BuildBackendBuildKit:
+		return buildKitBuilder{}
+	case BuildBackendExternal:
+		if config, ok := getExternalBuildConfig(); ok {
+			return externalBuilder{config: config}
+		}
+	}
+	return nil
+}
+
+// IsValidBuildBackend reports whether name is a build backend that can be used
+func IsValidBuildBackend(name string) bool {
+	return newBuilder(name) != nil
+}
+
+// AvailableBuildBackends lists the build backends that can be used
+func AvailableBuildBackends() []string {
+	var available []string
+	for _, name := range BuildBackends {
+		if IsValidBuildBackend(name) {
+			available = append(available, name)
+		}
+	}
+	return available
+}
+
+// GetDefaultBuildBackend returns the backend used until one is saved in the platform
+// settings (BUILD_BACKEND, default kaniko)
+func GetDefaultBuildBackend() string {
+	if name := getEnvString("BUILD_BACKEND", BuildBackendKaniko); IsValidBuildBackend(name) {
+		return name
+	}
+	return BuildBackendKaniko
+}
+
+// SetBuildBackend switches the cluster's build backend; "" restores the default
+func SetBuildBackend(name string) error {
+	if name != "" && !IsValidBuildBackend(name) {
+		return fmt.Errorf("unknown build backend %q", name)
+	}
+
+	buildBackend.mu.Lock()
+	defer buildBackend.mu.Unlock()
+	if buildBackend.name != name {
+		log.Printf("Build backend set to %s", name)
+	}
+	buildBackend.name = name
+	return nil
+}
+
+// GetBuildBackend returns the cluster's build backend
+func GetBuildBackend() string {
+	buildBackend.mu.RLock()
+	name := buildBackend.name
+	buildBackend.mu.RUnlock()
+	if name != "" {
+		return name
+	}
+	return GetDefaultBuildBackend()
+}
+
+// RequiredBuildCapabilities returns what a build of the service needs from its backend
+func RequiredBuildCapabilities(service models.Service) BuildCapabilities {
+	return BuildCapabilities{
+		MultiArch: len(GetBuildPlatforms(service)) > 1,
+		Secrets:   service.BuildSecrets,
+	}
+}
+
+// ResolveBuilder negotiates the build backend of a service. A backend the service selects
+// must have every capability it needs. Otherwise the cluster's backend is used, or the
+// first other available backend that has them.
+func ResolveBuilder(service models.Service) (Builder, error) {
+	required := RequiredBuildCapabilities(service)
+
+	if service.BuildBackend != "" {
+		builder := newBuilder(service.BuildBackend)
+		if builder == nil {
+			return nil, fmt.Errorf("build backend %s is not available", service.BuildBackend)
+		}
+		if missing := builder.Capabilities().Missing(required); len(missing) > 0 {
+			return nil, fmt.Errorf("build backend %s does not support %s", builder.Name(), strings.Join(missing, ", "))
+		}
+		return builder, nil
+	}
+
+	clusterBackend := GetBuildBackend()
+	for _, name := range append([]string{clusterBackend}, BuildBackends...) {
+		builder := newBuilder(name)
+		if builder == nil || len(builder.Capabilities().Missing(required)) > 0 {
+			continue
+		}
+		if name != clusterBackend {
+			log.Printf("Build backend %s lacks what service %s needs, building with %s", clusterBackend, service.Name, name)
+		}
+		return builder, nil
+	}
+
+	missing := BuildCapabilities{}.Missing(required)
+	return nil, fmt.Errorf("no available build backend supports %s", strings.Join(missing, " and "))
+}
+
+// CheckBuildBackend validates the build backend a service selects, "" for the cluster's,
+// against the capabilities its builds need
+func (e *FieldErrors) CheckBuildBackend(field string, backend string, required BuildCapabilities) {
+	if backend == "" {
+		return
+	}
+	builder := newBuilder(backend)
+	if builder == nil {
+		e.Add(field, "must be one of: %s", strings.Join(AvailableBuildBackends(), ", "))
+		return
+	}
+	if missing := builder.Capabilities().Missing(required); len(missing) > 0 {
+		e.Add(field, "%s does not support %s", backend, strings.Join(missing, ", "))
+	}
+}
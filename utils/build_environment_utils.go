@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Markers the git-clone step prints so the build environment can be read from its log
+const (
+	buildMarkerDockerfileDigest = "PENDEPLOY_DOCKERFILE_SHA256="
+	buildMarkerFrom             = "PENDEPLOY_FROM="
+	buildMarkerExpose           = "PENDEPLOY_EXPOSE=" // followed by the number of the stage
+)
+
+// BuildRecord is the build environment captured from a finished build job
+type BuildRecord struct {
+	Environment      models.BuildEnvironment
+	DockerfileDigest string
+	ImageDigest      string
+	TestDuration     time.Duration // zero when the service has no test stage
+}
+
+// CaptureBuildEnvironment reads the rendered builder args, the Dockerfile digest, the base
+// images and the pushed image digest from a deployment's build job. It is best effort:
+// whatever could not be read is left empty.
+func CaptureBuildEnvironment(deployment models.Deployment, service models.Service) (BuildRecord, error) {
+	var record BuildRecord
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return record, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	namespace := GetJobNamespace()
+	jobName := GetJobName(service.ID, deployment.ID)
+
+	job, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Get(context.Background(), jobName, metav1.GetOptions{})
+	if err != nil {
+		return record, fmt.Errorf("failed to get build job %s: %v", jobName, err)
+	}
+	record.Environment.Backend = job.Labels["builder"]
+	for _, container := range job.Spec.Template.Spec.Containers {
+		if container.Name == KanikoContainerName || container.Name == BuildKitContainerName {
+			record.Environment.BuilderImage = container.Image
+			record.Environment.KanikoArgs = redactKanikoArgs(container.Args)
+		}
+	}
+	record.Environment.Dockerfile = GetDockerfilePath(service)
+	record.Environment.Branch = service.Branch
+	if record.Environment.Branch == "" {
+		record.Environment.Branch = "main"
+	}
+
+	cloneLogs := readJobContainerLogs(k8sClient, jobName, namespace, "git-clone")
+	record.DockerfileDigest, record.Environment.BaseImages, record.Environment.ExposedPorts = parseBuildMarkers(cloneLogs)
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err == nil && len(pods.Items) > 0 {
+		record.ImageDigest = builtImageDigest(pods.Items[0])
+		record.TestDuration = testStageDuration(pods.Items[0])
+	}
+	// A multi-platform build is deployed through its manifest list, not the first platform's image
+	if len(GetBuildPlatforms(service)) > 1 {
+		record.Environment.Platforms = GetBuildPlatforms(service)
+		if digest := manifestListDigest(k8sClient, jobName, namespace); digest != "" {
+			record.ImageDigest = digest
+		}
+	}
+	return record, nil
+}
+
+// redactKanikoArgs hides build-arg values, which carry the service's environment variables,
+// of Kaniko (--build-arg=) and BuildKit (--opt=build-arg:) args
+func redactKanikoArgs(args []string) []string {
+	redacted := make([]string, 0, len(args))
+	for _, arg := range args {
+		for _, prefix := range []string{"--build-arg=", "--opt=build-arg:"} {
+			if strings.HasPrefix(arg, prefix) {
+				if name, _, found := strings.Cut(strings.TrimPrefix(arg, prefix), "="); found {
+					arg = prefix + name + "=<redacted>"
+				}
+			}
+		}
+		redacted = append(redacted, arg)
+	}
+	return redacted
+}
+
+// parseBuildMarkers returns the Dockerfile digest, the external base images and the TCP
+// ports the final stage EXPOSEs from the git-clone log. Stages built FROM an earlier stage
+// are not base images.
+func parseBuildMarkers(logs string) (string, []string, []int) {
+	digest := ""
+	baseImages := []string{}
+	stages := map[string]bool{}
+	seen := map[string]bool{}
+	fromCount := 0
+	exposes := map[int][]string{} // EXPOSE arguments by stage number
+
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if value, found := strings.CutPrefix(line, buildMarkerDockerfileDigest); found {
+			digest = "sha256:" + strings.TrimSpace(value)
+			continue
+		}
+		if value, found := strings.CutPrefix(line, buildMarkerExpose); found {
+			// <stage> EXPOSE <port>[/<protocol>]...
+			fields := strings.Fields(value)
+			if len(fields) < 3 {
+				continue
+			}
+			if stage, err := strconv.Atoi(fields[0]); err == nil {
+				exposes[stage] = append(exposes[stage], fields[2:]...)
+			}
+			continue
+		}
+		value, found := strings.CutPrefix(line, buildMarkerFrom)
+		if !found {
+			continue
+		}
+		fromCount++
+
+		// FROM [--platform=...] <image> [AS <name>]
+		fields := strings.Fields(value)
+		var args []string
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "--") {
+				args = append(args, field)
+			}
+		}
+		if len(args) == 0 {
+			continue
+		}
+		image := args[0]
+		if len(args) >= 3 && strings.EqualFold(args[1], "as") {
+			stages[strings.ToLower(args[2])] = true
+		}
+		if stages[strings.ToLower(image)] || strings.EqualFold(image, "scratch") || seen[image] {
+			continue
+		}
+		seen[image] = true
+		baseImages = append(baseImages, image)
+	}
+	return digest, baseImages, parseExposedPorts(exposes[fromCount])
+}
+
+// parseExposedPorts returns the TCP ports of EXPOSE arguments in ascending order. Ports
+// given through a build argument or as a range cannot be compared and are skipped.
+func parseExposedPorts(args []string) []int {
+	var ports []int
+	seen := map[int]bool{}
+	for _, arg := range args {
+		portSpec, protocol, _ := strings.Cut(arg, "/")
+		if protocol != "" && !strings.EqualFold(protocol, "tcp") {
+			continue
+		}
+		port, err := strconv.Atoi(portSpec)
+		if err != nil || port <= 0 || port > 65535 || seen[port] {
+			continue
+		}
+		seen[port] = true
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// builtImageDigest reads the pushed digest from the builder's termination log: the digest
+// Kaniko wrote, or the build metadata BuildKit wrote
+func builtImageDigest(pod corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated == nil {
+			continue
+		}
+		message := strings.TrimSpace(status.State.Terminated.Message)
+		switch status.Name {
+		case KanikoContainerName:
+			if strings.HasPrefix(message, "sha256:") {
+				return message
+			}
+		case BuildKitContainerName:
+			var metadata struct {
+				Digest string `json:"containerimage.digest"`
+			}
+			if json.Unmarshal([]byte(message), &metadata) == nil && strings.HasPrefix(metadata.Digest, "sha256:") {
+				return metadata.Digest
+			}
+		}
+	}
+	return ""
+}
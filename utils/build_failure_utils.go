@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Machine-readable codes of failed builds, recorded on the deployment and sent in the
+// status webhook
+const (
+	BuildErrorDockerfileNotFound = "dockerfile_not_found"
+	BuildErrorGitCloneFailed     = "git_clone_failed"
+	BuildErrorOutOfMemory        = "build_oom"
+	BuildErrorBaseImageNotFound  = "base_image_not_found"
+	BuildErrorPushDenied         = "registry_push_denied"
+	BuildErrorNpmInstallFailed   = "npm_install_failed"
+	BuildErrorPipInstallFailed   = "pip_install_failed"
+	BuildErrorTestsFailed        = "tests_failed"
+	BuildErrorTimeout            = "build_timeout"
+	BuildErrorImageTooLarge      = "image_size_budget_exceeded"
+	BuildErrorStepFailed         = "build_step_failed"
+	BuildErrorUnknown            = "build_failed"
+	BuildErrorAbandoned          = "deployment_abandoned"
+)
+
+// BuildFailure is a failed build with the classified cause. It wraps the build error, so
+// the message and chain are unchanged.
+type BuildFailure struct {
+	Code string
+	Hint string // remediation for the user
+	Err  error
+}
+
+func (f *BuildFailure) Error() string {
+	return f.Err.Error()
+}
+
+func (f *BuildFailure) Unwrap() error {
+	return f.Err
+}
+
+// buildFailureRule maps build output matching any of its patterns to an error code
+type buildFailureRule struct {
+	code     string
+	hint     string
+	patterns []*regexp.Regexp
+}
+
+// buildFailureRules are tried in order; the first match wins. More specific causes come
+// first, as a failed npm install also fails its RUN step.
+var buildFailureRules = []buildFailureRule{
+	{
+		code: BuildErrorOutOfMemory,
+		hint: "The build ran out of memory (6Gi). Reduce the memory the build steps use, e.g. lower NODE_OPTIONS=--max-old-space-size, or split heavy steps into a multi-stage build.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`OOMKilled`),
+			regexp.MustCompile(`(?i)JavaScript heap out of memory`),
+			regexp.MustCompile(`(?i)exit (?:code|status) 137\b`),
+		},
+	},
+	{
+		code: BuildErrorTestsFailed,
+		hint: "The test command exited with an error before the image was built. Run the tests locally or fix the test command of the service.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`tests failed with exit code`),
+		},
+	},
+	{
+		code: BuildErrorDockerfileNotFound,
+		hint: "No Dockerfile was found at the configured path. Commit a Dockerfile or correct the Dockerfile path (and root directory) of the service.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`ERROR: \S*Dockerfile\S* not found!`),
+			regexp.MustCompile(`(?i)error resolving dockerfile path`),
+		},
+	},
+	{
+		code: BuildErrorGitCloneFailed,
+		hint: "The repository could not be cloned. Check the repository URL, branch and commit, and that the Git token has read access.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)fatal: (?:repository .* not found|could not read Username|Authentication failed|couldn't find remote ref|unable to access)`),
+			regexp.MustCompile(`(?i)fatal: reference is not a tree`),
+			regexp.MustCompile(`(?i)error: pathspec .* did not match`),
+		},
+	},
+	{
+		code: BuildErrorPushDenied,
+		hint: "The registry refused the image push. Check the registry credentials and that the repository accepts pushes (quota, immutable tags).",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)error checking push permissions`),
+			regexp.MustCompile(`(?i)failed to push to destination`),
+			regexp.MustCompile(`(?i)denied: requested access to the resource is denied`),
+		},
+	},
+	{
+		code: BuildErrorBaseImageNotFound,
+		hint: "A base image in FROM could not be pulled. Check the image name and tag, and the pull credentials of private registries.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)retrieving image .*(?:MANIFEST_UNKNOWN|manifest unknown|not found)`),
+			regexp.MustCompile(`(?i)unable to complete operation.*retrieving image`),
+		},
+	},
+	{
+		code: BuildErrorNpmInstallFailed,
+		hint: "Installing the npm dependencies failed. Run npm ci locally with the committed lockfile; fix peer dependency conflicts (ERESOLVE) or add the credentials of private registries as build variables.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`npm (?:ERR!|error) (?:code|ERESOLVE|404|403|notarget)`),
+			regexp.MustCompile(`(?i)npm ci can only install packages when your package\.json and package-lock\.json`),
+			regexp.MustCompile(`(?i)(?:yarn|pnpm) install.*(?:error|ERR_)`),
+		},
+	},
+	{
+		code: BuildErrorPipInstallFailed,
+		hint: "Installing the Python dependencies failed. Check the versions pinned in requirements.txt and the system packages they need to compile.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`ERROR: (?:Could not find a version that satisfies|No matching distribution found|Failed building wheel)`),
+		},
+	},
+	{
+		code: BuildErrorTimeout,
+		hint: "The build did not finish in time. Raise the build timeout of the service (buildTimeoutMinutes), or speed the build up with a smaller build context (.dockerignore) and cache-friendly layer order.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`timeout waiting for job`),
+			regexp.MustCompile(`DeadlineExceeded`),
+		},
+	},
+	{
+		code: BuildErrorStepFailed,
+		hint: "A RUN instruction of the Dockerfile exited with an error. The build logs above the error show its output.",
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)failed to execute command: waiting for process to exit: exit status`),
+			regexp.MustCompile(`(?i)error building image`),
+		},
+	},
+}
+
+// ClassifyBuildFailure matches the build error and the build output against the known
+// causes of failed builds
+func ClassifyBuildFailure(buildErr error, logs string) *BuildFailure {
+	evidence := buildErr.Error() + "\n" + logs
+	for _, rule := range buildFailureRules {
+		for _, pattern := range rule.patterns {
+			if pattern.MatchString(evidence) {
+				return &BuildFailure{Code: rule.code, Hint: rule.hint, Err: buildErr}
+			}
+		}
+	}
+	return &BuildFailure{Code: BuildErrorUnknown, Hint: "The build failed. The build logs show the cause.", Err: buildErr}
+}
+
+// DiagnoseBuildFailure classifies a failed build from its error, the termination reasons
+// of the build containers and their output, while the build pods still exist
+func DiagnoseBuildFailure(service models.Service, deployment models.Deployment, buildErr error) *BuildFailure {
+	var evidence strings.Builder
+	if reasons, err := buildTerminationReasons(deployment); err == nil {
+		evidence.WriteString(reasons)
+	}
+	if logs, err := ReadBuildLogs(service, deployment); err == nil {
+		evidence.WriteString(logs)
+	}
+	return ClassifyBuildFailure(buildErr, evidence.String())
+}
+
+// buildTerminationReasons lists why the containers of a deployment's build pods
+// terminated, e.g. "kaniko-executor: OOMKilled (exit code 137)"
+func buildTerminationReasons(deployment models.Deployment) (string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", err
+	}
+	pods, err := k8sClient.Clientset.CoreV1().Pods(GetJobNamespace()).List(context.Background(), metav1.ListOptions{
+		LabelSelector: buildPodSelector(deployment.ID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var reasons strings.Builder
+	for _, pod := range pods.Items {
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+				fmt.Fprintf(&reasons, "%s: %s (exit code %d)\n", status.Name, terminated.Reason, terminated.ExitCode)
+			}
+		}
+	}
+	return reasons.String(), nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pendeploy-simple/lib/kubernetes"
@@ -11,7 +13,18 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// buildJobPollInterval is how often a build job is checked when no watch event arrives,
+	// which also covers a watch that silently stopped delivering events
+	buildJobPollInterval = 30 * time.Second
+	// buildJobWatchRetryDelay spaces the attempts to re-establish an interrupted watch
+	buildJobWatchRetryDelay = 2 * time.Second
 )
 
 func GetJobName(serviceID string, deploymentID string) string {
@@ -19,35 +32,71 @@ func GetJobName(serviceID string, deploymentID string) string {
 	return deploymentID
 }
 
+// buildPodSelector selects the pods of every build job of a deployment, e.g. one per
+// platform of a multi-platform Kaniko build
+func buildPodSelector(deploymentID string) string {
+	return fmt.Sprintf("deployment-id=%s,builder in (%s,%s)", deploymentID, BuildBackendKaniko, BuildBackendBuildKit)
+}
+
+// DefaultBuildNamespace runs build jobs until an admin selects another namespace
+const DefaultBuildNamespace = "build-and-deploy"
+
+// buildNamespace is the namespace of the platform settings, set once at startup as running
+// build jobs, their secrets and the registry dependencies stay in the namespace they were
+// created in
+var buildNamespace = struct {
+	mu   sync.RWMutex
+	name string
+}{}
+
+// GetDeploymentBuildNamespace returns the deployment's BUILD_NAMESPACE
+func GetDeploymentBuildNamespace() string {
+	return getEnvString("BUILD_NAMESPACE", DefaultBuildNamespace)
+}
+
+// SetBuildNamespace changes the namespace of build jobs; "" restores the default
+func SetBuildNamespace(name string) {
+	buildNamespace.mu.Lock()
+	defer buildNamespace.mu.Unlock()
+	if buildNamespace.name != name {
+		log.Printf("Build namespace set to %s", name)
+	}
+	buildNamespace.name = name
+}
+
 // GetJobNamespace returns the namespace for build jobs
 func GetJobNamespace() string {
-	return "build-and-deploy"
+	buildNamespace.mu.RLock()
+	name := buildNamespace.name
+	buildNamespace.mu.RUnlock()
+	if name != "" {
+		return name
+	}
+	return GetDeploymentBuildNamespace()
 }
 
-// BuildFromGit creates a Kubernetes job with Kaniko and WAITS for completion
+// BuildFromGit builds the deployment's image with the build backend negotiated for the
+// service (see ResolveBuilder) and WAITS for completion
 // Returns the resulting image URL only after successful build
 // FAILS FAST on any error to prevent infinite loops
 func BuildFromGit(deployment models.Deployment, service models.Service, registry models.Registry) (string, error) {
-	// Define the image tag - use "latest" if commitSHA is empty
-	log.Printf("Building image for service: %s, deployment: %s", service.Name, deployment.ID)
-	cleanRegistryURL := CleanRegistryURL(registry.URL)
-
-	registryURL := GetRegistryURLForKaniko(registry.URL)
-	if IsInsecureRegistry(registry.URL) {
-		log.Printf("Using HTTP protocol for local/cluster registry: %s", cleanRegistryURL)
-	} else {
-		log.Printf("Using HTTPS protocol for external registry: %s", cleanRegistryURL)
+	builder, err := ResolveBuilder(service)
+	if err != nil {
+		log.Printf("FATAL: No build backend for service %s: %v", service.Name, err)
+		return "", err
 	}
+	log.Printf("Building image for service: %s, deployment: %s, backend: %s", service.Name, deployment.ID, builder.Name())
+	return builder.Build(deployment, service, registry)
+}
 
-	image := GenerateImage(cleanRegistryURL, service, deployment)
-	log.Printf("Target image (for K8s): %s", image)
-	log.Printf("Registry URL (for kaniko): %s", registryURL)
-
+// prepareBuildJobs creates the Kubernetes client of an in-cluster build and ensures the
+// build job namespace exists
+func prepareBuildJobs() (*kubernetes.Client, error) {
 	// Create Kubernetes client
 	k8sClient, err := kubernetes.NewClient()
 	if err != nil {
 		log.Printf("FATAL: Failed to create Kubernetes client: %v", err)
-		return "", fmt.Errorf("kubernetes client creation failed: %v", err)
+		return nil, fmt.Errorf("kubernetes client creation failed: %v", err)
 	}
 	log.Println("Kubernetes client created successfully")
 
@@ -56,31 +105,29 @@ func BuildFromGit(deployment models.Deployment, service models.Service, registry
 	err = EnsureNamespaceExists(namespace)
 	if err != nil {
 		log.Printf("FATAL: Failed to ensure namespace %s exists: %v", namespace, err)
-		return "", fmt.Errorf("namespace creation failed: %v", err)
+		return nil, fmt.Errorf("namespace creation failed: %v", err)
 	}
 	log.Printf("Namespace %s confirmed", namespace)
+	return k8sClient, nil
+}
+
+// runBuildJob replaces any job of the same name, submits the build job and waits for it to
+// complete
+func runBuildJob(k8sClient *kubernetes.Client, job *batchv1.Job, service models.Service) error {
+	namespace := GetJobNamespace()
+	jobName := job.Name
 
 	// Cleanup any existing job with the same name first
-	jobName := GetJobName(service.ID, deployment.ID)
 	log.Printf("Cleaning up existing job: %s", jobName)
-	err = cleanupExistingJob(k8sClient, jobName, namespace)
+	err := cleanupExistingJob(k8sClient, jobName, namespace)
 	if err != nil {
 		log.Printf("WARNING: Failed to cleanup existing job %s: %v", jobName, err)
 		// Continue anyway - this shouldn't be fatal
 	}
 
-	log.Printf("Creating Kaniko job: %s", jobName)
-	// Create the job - pass all necessary parameters
-	job, err := createKanikoBuildJob(registryURL, deployment, service, image)
-	if err != nil {
-		log.Printf("FATAL: Failed to create job definition: %v", err)
-		return "", fmt.Errorf("job definition creation failed: %v", err)
-	}
-	log.Println("Kaniko job definition created successfully")
-
 	// Submit the job to Kubernetes
 	log.Printf("Submitting job %s to Kubernetes", jobName)
-	_, err = k8sClient.Clientset.BatchV1().Jobs(GetJobNamespace()).Create(
+	_, err = k8sClient.Clientset.BatchV1().Jobs(namespace).Create(
 		context.Background(),
 		job,
 		metav1.CreateOptions{},
@@ -88,125 +135,330 @@ func BuildFromGit(deployment models.Deployment, service models.Service, registry
 
 	if err != nil {
 		log.Printf("FATAL: Failed to submit job to Kubernetes: %v", err)
-		return "", fmt.Errorf("job submission failed: %v", err)
+		return fmt.Errorf("job submission failed: %v", err)
 	}
 	log.Printf("Job %s submitted to Kubernetes successfully", jobName)
 
-	// Wait for build completion with 12-MINUTE timeout
-	log.Printf("Waiting for build job %s to complete (timeout: 12 minutes)...", jobName)
-	err = waitForJobCompletion(k8sClient, jobName, namespace, 12*time.Minute)
+	// Wait for build completion; the job's active deadline is the service's build timeout
+	timeout := getBuildWatchTimeout(service)
+	log.Printf("Waiting for build job %s to complete (timeout: %v)...", jobName, timeout)
+	err = waitForJobCompletion(k8sClient, jobName, namespace, timeout)
 	if err != nil {
 		log.Printf("BUILD FAILED: Job %s failed with error: %v", jobName, err)
 
 		// Try to get more detailed logs for debugging
-		detailedLogs := getDetailedJobLogs(k8sClient, jobName, namespace)
+		detailedLogs := NewSecretRedactor(service)(getDetailedJobLogs(k8sClient, jobName, namespace))
 		if detailedLogs != "" {
 			log.Printf("Detailed failure logs for job %s:\n%s", jobName, detailedLogs)
 		}
 
-		return "", fmt.Errorf("build job failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// buildWorkspaceVolume is the volume of a build job holding the checkout the image is built from
+const buildWorkspaceVolume = "build-workspace"
+
+// newBuildJob creates the job definition shared by the in-cluster build backends: the
+// git-clone init container checks out the commit and auto-fixes the Dockerfile, the test
+// stage runs when the service has one, and executor builds the image from /workspace.
+func newBuildJob(jobName string, deployment models.Deployment, service models.Service, backend string, executor corev1.Container) *batchv1.Job {
+	branch := service.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	log.Printf("Using branch: %s", branch)
+
+	// Authenticated URL for private repos; logged without credentials.
+	repoURL := buildGitCloneURL(service)
+	log.Printf("Repository URL: %s", service.RepoURL)
+
+	sharedVolumeName := buildWorkspaceVolume
+
+	// Generate Dockerfile fix script
+	dockerfileFixScript := generateDockerfileFixScript(buildEnvVars(service))
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: GetJobNamespace(),
+			Labels: map[string]string{
+				"app":              "pendeploy",
+				"service-id":       service.ID,
+				"deployment-id":    deployment.ID,
+				"builder":          backend,
+				LabelServiceID:     service.ID,
+				LabelEnvironmentID: service.EnvironmentID,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(GetBuildTimeout(service).Seconds())),
+
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":              "pendeploy",
+						"service-id":       service.ID,
+						"deployment-id":    deployment.ID,
+						"builder":          backend,
+						"job-name":         jobName, // For log compatibility
+						LabelServiceID:     service.ID,
+						LabelEnvironmentID: service.EnvironmentID,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:     corev1.RestartPolicyNever,
+					PriorityClassName: service.BuildPriorityClassName,
+
+					InitContainers: []corev1.Container{
+						{
+							Name:    "git-clone",
+							Image:   "alpine/git:2.43.0",
+							Command: []string{"sh", "-c"},
+							Args: []string{fmt.Sprintf(`%s
+                                echo "Git clone completed successfully"
+                                ls -la
+                                DOCKERFILE="%s"
+                                
+                                echo "=== Checking $DOCKERFILE ==="
+                                if [ ! -f "$DOCKERFILE" ]; then
+                                    echo "ERROR: $DOCKERFILE not found!"
+                                    exit 1
+                                fi
+                                
+                                echo "Original Dockerfile:"
+                                cat "$DOCKERFILE"
+                                echo "========================="
+                                
+                                echo "=== Auto-fixing Dockerfile ==="
+                                %s
+                                
+                                echo "Final Dockerfile:"
+                                cat "$DOCKERFILE"
+                                echo "================"
+                                echo "Dockerfile auto-fixing completed!"
+                                
+                                # Build environment markers, parsed by captureBuildEnvironment
+                                echo "%s$(sha256sum "$DOCKERFILE" | cut -d' ' -f1)"
+                                grep -iE '^[[:space:]]*FROM[[:space:]]' "$DOCKERFILE" | sed 's/^/%s/'
+                                awk -v marker="%s" '{ l = tolower($0) } l ~ /^[[:space:]]*from[[:space:]]/ { n++ } l ~ /^[[:space:]]*expose[[:space:]]/ { print marker n " " $0 }' "$DOCKERFILE"
+                            `,
+								getCloneScript(service, repoURL, branch, deployment.CommitSHA),
+								GetDockerfilePath(service),
+								dockerfileFixScript,
+								buildMarkerDockerfileDigest,
+								buildMarkerFrom,
+								buildMarkerExpose,
+							)},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      sharedVolumeName,
+									MountPath: "/workspace",
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:              resource.MustParse("100m"),
+									corev1.ResourceMemory:           resource.MustParse("128Mi"),
+									corev1.ResourceEphemeralStorage: resource.MustParse("512Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:              resource.MustParse("200m"),
+									corev1.ResourceMemory:           resource.MustParse("256Mi"),
+									corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+								},
+							},
+						},
+					},
+
+					Containers: []corev1.Container{executor},
+
+					Volumes: []corev1.Volume{
+						{
+							Name: sharedVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{
+									SizeLimit: resource.NewQuantity(8*1024*1024*1024, resource.BinarySI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
-	log.Printf("BUILD SUCCESS: Job %s completed successfully! Image ready: %s", jobName, image)
-	return image, nil
+	if service.TestCommand != "" {
+		job.Spec.Template.Spec.InitContainers = append(job.Spec.Template.Spec.InitContainers, createTestContainer(service, sharedVolumeName))
+	}
+
+	SecurePodSpec(&job.Spec.Template.Spec)
+	ApplyBuildNodePlacement(&job.Spec.Template.Spec)
+	return job
+}
+
+// WaitForJobCompletion waits for a job created outside this package. A failure carries
+// the last log line of the given container, which usually names the cause.
+func WaitForJobCompletion(k8sClient *kubernetes.Client, jobName, namespace, container string, timeout time.Duration) error {
+	err := waitForJobCompletion(k8sClient, jobName, namespace, timeout)
+	if err == nil {
+		return nil
+	}
+	if output := strings.TrimSpace(readJobContainerLogs(k8sClient, jobName, namespace, container)); output != "" {
+		return fmt.Errorf("%v: %s", err, lastLine(output))
+	}
+	return err
 }
 
-// waitForJobCompletion waits for a Kubernetes job to complete successfully
-// Uses WATCH API for real-time updates - no polling!
+// waitForJobCompletion waits for a Kubernetes job to complete successfully. The job and its
+// pods are listed and then watched from the listed resource versions, so no change is
+// missed; every change is checked against a fresh list. A watch that closes (API server
+// restart, watch timeout) is re-established, and while the API server cannot be reached the
+// job is polled instead, so a hiccup does not fail a build that is still running.
 func waitForJobCompletion(k8sClient *kubernetes.Client, jobName, namespace string, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	log.Printf("Starting REAL-TIME job monitoring for: %s (timeout: %v)", jobName, timeout)
 
-	// Start watching job status changes
-	jobWatch, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Watch(ctx, metav1.ListOptions{
-		FieldSelector: fmt.Sprintf("metadata.name=%s", jobName),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to start job watch: %v", err)
+	for {
+		job, pods, err := getBuildJobState(ctx, k8sClient, jobName, namespace)
+		switch {
+		case ctx.Err() != nil:
+			log.Printf("TIMEOUT: Job %s did not complete within %v", jobName, timeout)
+			return fmt.Errorf("timeout waiting for job %s to complete (waited %v)", jobName, timeout)
+		case apierrors.IsNotFound(err):
+			log.Printf("Job %s no longer exists", jobName)
+			return fmt.Errorf("job %s was deleted before it completed", jobName)
+		case err != nil:
+			log.Printf("WARNING: Failed to check job %s, retrying: %v", jobName, err)
+		default:
+			if done, result := checkBuildJobState(job, pods); done {
+				return result
+			}
+		}
+
+		waitForBuildJobChange(ctx, k8sClient, jobName, namespace, job, pods)
 	}
-	defer jobWatch.Stop()
+}
 
-	// Start watching pod status changes
-	podWatch, err := k8sClient.Clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+// getBuildJobState lists a build job and its pods
+func getBuildJobState(ctx context.Context, k8sClient *kubernetes.Client, jobName, namespace string) (*batchv1.Job, *corev1.PodList, error) {
+	job, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to start pod watch: %v", err)
+		return nil, nil, err
 	}
-	defer podWatch.Stop()
+	return job, pods, nil
+}
 
-	// Monitor both job and pod events simultaneously
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("TIMEOUT: Job %s did not complete within %v", jobName, timeout)
-			return fmt.Errorf("timeout waiting for job %s to complete (waited %v)", jobName, timeout)
+// checkBuildJobState reports whether a build job finished, with its error when it failed.
+// A failed test stage is reported before the job failure it causes, so the error says the
+// tests failed.
+func checkBuildJobState(job *batchv1.Job, pods *corev1.PodList) (bool, error) {
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// A failed test stage fails the build before the image is built
+		if testError := checkTestStage(pod); testError != nil {
+			log.Printf("🚨 TESTS FAILED: Pod %s: %v", pod.Name, testError)
+			return true, testError
+		}
+	}
 
-		case jobEvent := <-jobWatch.ResultChan():
-			if jobEvent.Object == nil {
-				log.Printf("Job watch closed, job may have been deleted")
-				return fmt.Errorf("job %s watch closed unexpectedly", jobName)
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			log.Printf("🎉 SUCCESS: Job %s completed successfully", job.Name)
+			return true, nil
+
+		case batchv1.JobFailed:
+			reason := "Unknown failure"
+			if condition.Reason != "" {
+				reason = condition.Reason
 			}
-
-			job, ok := jobEvent.Object.(*batchv1.Job)
-			if !ok {
-				continue
+			if condition.Message != "" {
+				reason = fmt.Sprintf("%s: %s", reason, condition.Message)
 			}
 
-			log.Printf("Job %s event: %s", jobName, jobEvent.Type)
-
-			// Check job conditions
-			for _, condition := range job.Status.Conditions {
-				switch condition.Type {
-				case batchv1.JobComplete:
-					if condition.Status == corev1.ConditionTrue {
-						log.Printf("🎉 SUCCESS: Job %s completed successfully", jobName)
-						return nil
-					}
-
-				case batchv1.JobFailed:
-					if condition.Status == corev1.ConditionTrue {
-						reason := "Unknown failure"
-						if condition.Reason != "" {
-							reason = condition.Reason
-						}
-						if condition.Message != "" {
-							reason = fmt.Sprintf("%s: %s", reason, condition.Message)
-						}
-
-						log.Printf("❌ FAILED: Job %s failed with reason: %s", jobName, reason)
-						return fmt.Errorf("job failed: %s", reason)
-					}
-				}
-			}
+			log.Printf("❌ FAILED: Job %s failed with reason: %s", job.Name, reason)
+			return true, fmt.Errorf("job failed: %s", reason)
+		}
+	}
 
-			// Log status changes
-			active := job.Status.Active
-			succeeded := job.Status.Succeeded
-			failed := job.Status.Failed
-			log.Printf("Job %s status update - Active: %d, Succeeded: %d, Failed: %d",
-				jobName, active, succeeded, failed)
+	for i := range pods.Items {
+		// Check for immediate pod failures
+		if podError := checkPodForErrors(&pods.Items[i]); podError != nil {
+			log.Printf("🚨 POD ERROR: Pod %s has error: %v", pods.Items[i].Name, podError)
+			return true, fmt.Errorf("pod error in job %s: %v", job.Name, podError)
+		}
+	}
 
-		case podEvent := <-podWatch.ResultChan():
-			if podEvent.Object == nil {
-				continue
-			}
+	log.Printf("Job %s status update - Active: %d, Succeeded: %d, Failed: %d",
+		job.Name, job.Status.Active, job.Status.Succeeded, job.Status.Failed)
+	return false, nil
+}
 
-			pod, ok := podEvent.Object.(*corev1.Pod)
-			if !ok {
-				continue
-			}
+// waitForBuildJobChange blocks until the build job or one of its pods changes after the
+// listed state, or buildJobPollInterval passes. Without a listed state (the API server
+// could not be reached) it only waits for the poll interval.
+func waitForBuildJobChange(ctx context.Context, k8sClient *kubernetes.Client, jobName, namespace string, job *batchv1.Job, pods *corev1.PodList) {
+	var jobEvents, podEvents <-chan watch.Event
+	if job != nil && pods != nil {
+		jobWatch, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fmt.Sprintf("metadata.name=%s", jobName),
+			ResourceVersion: job.ResourceVersion,
+		})
+		if err != nil {
+			log.Printf("WARNING: Failed to watch job %s, polling instead: %v", jobName, err)
+		} else {
+			defer jobWatch.Stop()
+			jobEvents = jobWatch.ResultChan()
+		}
 
-			log.Printf("Pod %s event: %s, phase: %s", pod.Name, podEvent.Type, pod.Status.Phase)
+		podWatch, err := k8sClient.Clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+			LabelSelector:   fmt.Sprintf("job-name=%s", jobName),
+			ResourceVersion: pods.ResourceVersion,
+		})
+		if err != nil {
+			log.Printf("WARNING: Failed to watch pods of job %s, polling instead: %v", jobName, err)
+		} else {
+			defer podWatch.Stop()
+			podEvents = podWatch.ResultChan()
+		}
+	}
 
-			// Check for immediate pod failures
-			podError := checkPodForErrors(pod)
-			if podError != nil {
-				log.Printf("🚨 POD ERROR: Pod %s has error: %v", pod.Name, podError)
-				return fmt.Errorf("pod error in job %s: %v", jobName, podError)
-			}
+	poll := time.NewTimer(buildJobPollInterval)
+	defer poll.Stop()
+
+	var event watch.Event
+	ok := true
+	select {
+	case <-ctx.Done():
+		return
+	case <-poll.C:
+		return
+	case event, ok = <-jobEvents:
+	case event, ok = <-podEvents:
+	}
+
+	// An expired resource version or a closed watch is re-established from a fresh list;
+	// the pause keeps a failing API server from being hammered
+	if !ok || event.Type == watch.Error {
+		log.Printf("Watch of job %s interrupted, re-establishing", jobName)
+		select {
+		case <-ctx.Done():
+		case <-time.After(buildJobWatchRetryDelay):
 		}
 	}
 }
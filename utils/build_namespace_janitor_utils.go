@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JanitorPolicy holds how long finished build artifacts are kept before cleanup
+type JanitorPolicy struct {
+	JobTTL        time.Duration // completed or failed Jobs
+	EvictedPodTTL time.Duration // pods evicted by the kubelet
+	TestPodTTL    time.Duration // registry-test-* pods left behind by dependency checks
+	TLSSecretTTL  time.Duration // TLS secrets no ingress references anymore
+}
+
+// DefaultJanitorPolicy reads TTLs (in minutes) from the environment
+func DefaultJanitorPolicy() JanitorPolicy {
+	return JanitorPolicy{
+		JobTTL:        time.Duration(getEnvInt("JANITOR_JOB_TTL_MINUTES", 60)) * time.Minute,
+		EvictedPodTTL: time.Duration(getEnvInt("JANITOR_EVICTED_POD_TTL_MINUTES", 30)) * time.Minute,
+		TestPodTTL:    time.Duration(getEnvInt("JANITOR_TEST_POD_TTL_MINUTES", 15)) * time.Minute,
+		TLSSecretTTL:  time.Duration(getEnvInt("JANITOR_TLS_SECRET_TTL_MINUTES", 24*60)) * time.Minute,
+	}
+}
+
+// CleanupBuildNamespace removes finished Jobs, evicted pods and orphaned test pods
+// in the build namespace that are older than the policy's TTLs
+func CleanupBuildNamespace(policy JanitorPolicy, report *dto.JanitorReport) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx := context.Background()
+	namespace := GetJobNamespace()
+	now := time.Now()
+
+	jobs, err := k8sClient.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %v", err)
+	}
+	for _, job := range jobs.Items {
+		finishedAt, finished := getJobFinishTime(job)
+		if !finished || now.Sub(finishedAt) < policy.JobTTL {
+			continue
+		}
+		err := k8sClient.Clientset.BatchV1().Jobs(namespace).Delete(ctx, job.Name, metav1.DeleteOptions{
+			PropagationPolicy: &[]metav1.DeletionPropagation{metav1.DeletePropagationBackground}[0],
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			report.Errors = append(report.Errors, fmt.Sprintf("job %s: %v", job.Name, err))
+			continue
+		}
+		report.DeletedJobs = append(report.DeletedJobs, job.Name)
+	}
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, pod := range pods.Items {
+		age := now.Sub(pod.CreationTimestamp.Time)
+		evicted := pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted"
+		testPod := strings.HasPrefix(pod.Name, "registry-test-")
+		if !(evicted && age >= policy.EvictedPodTTL) && !(testPod && age >= policy.TestPodTTL) {
+			continue
+		}
+		err := k8sClient.Clientset.CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			report.Errors = append(report.Errors, fmt.Sprintf("pod %s: %v", pod.Name, err))
+			continue
+		}
+		report.DeletedPods = append(report.DeletedPods, pod.Name)
+	}
+
+	log.Printf("Janitor: removed %d jobs and %d pods from %s", len(report.DeletedJobs), len(report.DeletedPods), namespace)
+	return nil
+}
+
+// CleanupStaleTLSSecrets removes TLS secrets that no ingress in their namespace
+// references anymore. cert-manager leaves these behind when an ingress is deleted.
+func CleanupStaleTLSSecrets(namespaces []string, ttl time.Duration, report *dto.JanitorReport) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	removed := 0
+
+	for _, namespace := range namespaces {
+		ingresses, err := k8sClient.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				report.Errors = append(report.Errors, fmt.Sprintf("ingresses in %s: %v", namespace, err))
+			}
+			continue
+		}
+
+		inUse := make(map[string]bool)
+		for _, ingress := range ingresses.Items {
+			for _, tls := range ingress.Spec.TLS {
+				inUse[tls.SecretName] = true
+			}
+		}
+
+		secrets, err := k8sClient.Clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+		})
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("secrets in %s: %v", namespace, err))
+			continue
+		}
+
+		for _, secret := range secrets.Items {
+			if inUse[secret.Name] || now.Sub(secret.CreationTimestamp.Time) < ttl {
+				continue
+			}
+			// Only touch secrets issued for ingresses; user-supplied TLS secrets are left alone
+			if _, issued := secret.Annotations["cert-manager.io/certificate-name"]; !issued {
+				continue
+			}
+
+			err := k8sClient.Clientset.CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				report.Errors = append(report.Errors, fmt.Sprintf("secret %s/%s: %v", namespace, secret.Name, err))
+				continue
+			}
+			report.DeletedSecrets = append(report.DeletedSecrets, namespace+"/"+secret.Name)
+			removed++
+		}
+	}
+
+	log.Printf("Janitor: removed %d stale TLS secrets across %d namespaces", removed, len(namespaces))
+	return nil
+}
+
+// getJobFinishTime returns when a Job completed or failed
+func getJobFinishTime(job batchv1.Job) (time.Time, bool) {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time, true
+	}
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// BuildNodeFallbackAnywhere schedules builds on any node while no build node is available
+	BuildNodeFallbackAnywhere = "anywhere"
+	// BuildNodeFallbackWait keeps builds pending until a build node joins (e.g. via an autoscaler)
+	BuildNodeFallbackWait = "wait"
+
+	// buildNodeCacheTTL bounds how long the build node availability check is reused
+	buildNodeCacheTTL = 30 * time.Second
+)
+
+// BuildNodeConfig places Kaniko and dependency build jobs on a dedicated node pool so heavy
+// builds cannot starve application workloads, read from the BUILD_NODE_* variables
+type BuildNodeConfig struct {
+	Selector   map[string]string  // BUILD_NODE_SELECTOR, e.g. pendeploy.io/pool=build
+	Toleration *corev1.Toleration // BUILD_NODE_TAINT, e.g. pendeploy.io/pool=build:NoSchedule
+	Fallback   string             // BUILD_NODE_FALLBACK, anywhere (default) or wait
+}
+
+var (
+	buildNodesAvailable bool
+	buildNodesCheckedAt time.Time
+	buildNodesSelector  string
+	buildNodesMu        sync.Mutex
+)
+
+// LoadBuildNodeConfig reads the build node pool configuration; ok is false when no
+// selector is configured. Invalid values are logged and ignored.
+func LoadBuildNodeConfig() (config BuildNodeConfig, ok bool) {
+	config.Fallback = strings.ToLower(strings.TrimSpace(os.Getenv("BUILD_NODE_FALLBACK")))
+	if config.Fallback != BuildNodeFallbackWait {
+		config.Fallback = BuildNodeFallbackAnywhere
+	}
+
+	selector := strings.TrimSpace(os.Getenv("BUILD_NODE_SELECTOR"))
+	if selector == "" {
+		return config, false
+	}
+	set, err := labels.ConvertSelectorToLabelsMap(selector)
+	if err != nil || len(set) == 0 {
+		log.Printf("Warning: ignoring invalid BUILD_NODE_SELECTOR %q: %v", selector, err)
+		return config, false
+	}
+	config.Selector = set
+
+	if taint := strings.TrimSpace(os.Getenv("BUILD_NODE_TAINT")); taint != "" {
+		toleration, err := parseBuildNodeTaint(taint)
+		if err != nil {
+			log.Printf("Warning: ignoring invalid BUILD_NODE_TAINT %q: %v", taint, err)
+		} else {
+			config.Toleration = &toleration
+		}
+	}
+
+	return config, true
+}
+
+// ApplyBuildNodePlacement adds the build pool node selector and taint toleration to a
+// build pod. Without a ready build node the pod is left unconstrained, unless
+// BUILD_NODE_FALLBACK=wait keeps it pending for one.
+func ApplyBuildNodePlacement(spec *corev1.PodSpec) {
+	config, ok := LoadBuildNodeConfig()
+	if !ok {
+		return
+	}
+
+	if config.Fallback == BuildNodeFallbackAnywhere && !hasReadyBuildNodes(config.Selector) {
+		log.Printf("No ready build nodes match %s; scheduling build on any node", labels.Set(config.Selector).String())
+		return
+	}
+
+	if spec.NodeSelector == nil {
+		spec.NodeSelector = map[string]string{}
+	}
+	for key, value := range config.Selector {
+		spec.NodeSelector[key] = value
+	}
+	if config.Toleration != nil {
+		spec.Tolerations = append(spec.Tolerations, *config.Toleration)
+	}
+}
+
+// hasReadyBuildNodes reports whether a schedulable, ready node matches the selector.
+// Lookup failures count as available so a flaky API does not move builds off the pool.
+func hasReadyBuildNodes(selector map[string]string) bool {
+	selectorString := labels.Set(selector).String()
+
+	buildNodesMu.Lock()
+	defer buildNodesMu.Unlock()
+	if buildNodesSelector == selectorString && time.Since(buildNodesCheckedAt) < buildNodeCacheTTL {
+		return buildNodesAvailable
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		log.Printf("Warning: failed to create Kubernetes client for build node check: %v", err)
+		return true
+	}
+	nodes, err := k8sClient.Clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: selectorString,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to list build nodes: %v", err)
+		return true
+	}
+
+	available := false
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+				available = true
+			}
+		}
+	}
+
+	buildNodesAvailable = available
+	buildNodesCheckedAt = time.Now()
+	buildNodesSelector = selectorString
+	return available
+}
+
+// parseBuildNodeTaint turns key[=value]:Effect into the matching toleration
+func parseBuildNodeTaint(taint string) (corev1.Toleration, error) {
+	colon := strings.LastIndex(taint, ":")
+	if colon <= 0 {
+		return corev1.Toleration{}, fmt.Errorf("expected key[=value]:Effect")
+	}
+
+	effect := corev1.TaintEffect(taint[colon+1:])
+	switch effect {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return corev1.Toleration{}, fmt.Errorf("effect must be NoSchedule, PreferNoSchedule or NoExecute")
+	}
+
+	toleration := corev1.Toleration{Key: taint[:colon], Operator: corev1.TolerationOpExists, Effect: effect}
+	if eq := strings.Index(toleration.Key, "="); eq >= 0 {
+		toleration.Value = toleration.Key[eq+1:]
+		toleration.Key = toleration.Key[:eq]
+		toleration.Operator = corev1.TolerationOpEqual
+	}
+	if toleration.Key == "" {
+		return corev1.Toleration{}, fmt.Errorf("taint key is empty")
+	}
+	return toleration, nil
+}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	PlatformLinuxAMD64 = "linux/amd64"
+	PlatformLinuxARM64 = "linux/arm64"
+
+	// ManifestToolImage publishes the manifest list of a multi-arch build
+	ManifestToolImage = "mplatform/manifest-tool:alpine-v2.1.6"
+
+	// manifestTimeout bounds the manifest-tool job, which only copies manifests
+	manifestTimeout = 5 * time.Minute
+)
+
+// SupportedBuildPlatforms are the target platforms a service can be built for
+var SupportedBuildPlatforms = []string{PlatformLinuxAMD64, PlatformLinuxARM64}
+
+// IsValidBuildPlatform reports whether a platform can be built
+func IsValidBuildPlatform(platform string) bool {
+	for _, supported := range SupportedBuildPlatforms {
+		if platform == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBuildPlatforms returns the target platforms of a service in their configured order.
+// Empty means the build runs on whichever node architecture the job lands on.
+func GetBuildPlatforms(service models.Service) []string {
+	platforms := []string{}
+	seen := map[string]bool{}
+	for _, platform := range strings.Split(service.BuildPlatforms, ",") {
+		platform = strings.TrimSpace(platform)
+		if platform == "" || seen[platform] {
+			continue
+		}
+		seen[platform] = true
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
+
+// platformArch returns the architecture part of os/arch
+func platformArch(platform string) string {
+	if slash := strings.LastIndex(platform, "/"); slash >= 0 {
+		return platform[slash+1:]
+	}
+	return platform
+}
+
+// platformImage returns the per-architecture tag pushed before the manifest list
+func platformImage(image, platform string) string {
+	return image + "-" + platformArch(platform)
+}
+
+// applyBuildPlatform pins a build pod to nodes of the target architecture, since Kaniko
+// cannot emulate a foreign architecture when running RUN steps
+func applyBuildPlatform(spec *corev1.PodSpec, platform string) {
+	if platform == "" {
+		return
+	}
+	if spec.NodeSelector == nil {
+		spec.NodeSelector = map[string]string{}
+	}
+	spec.NodeSelector[corev1.LabelOSStable] = strings.SplitN(platform, "/", 2)[0]
+	spec.NodeSelector[corev1.LabelArchStable] = platformArch(platform)
+}
+
+// buildMultiPlatform runs one Kaniko job per platform in parallel, each on a node of its
+// architecture, then joins the per-architecture images into a manifest list at image.
+// The first platform keeps the deployment's job name so log streaming and build
+// environment capture work as for single-platform builds.
+func buildMultiPlatform(k8sClient *kubernetes.Client, registryURL string, deployment models.Deployment, service models.Service, image string, platforms []string) error {
+	jobName := GetJobName(service.ID, deployment.ID)
+
+	errs := make(chan error, len(platforms))
+	for i, platform := range platforms {
+		platformJobName := jobName
+		if i > 0 {
+			platformJobName = jobName + "-" + platformArch(platform)
+		}
+		go func(platformJobName, platform string) {
+			if err := runKanikoBuildJob(k8sClient, platformJobName, registryURL, deployment, service, platformImage(image, platform), platform); err != nil {
+				errs <- fmt.Errorf("%s: %v", platform, err)
+				return
+			}
+			errs <- nil
+		}(platformJobName, platform)
+	}
+
+	var failures []string
+	for range platforms {
+		if err := <-errs; err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	manifestJobName := jobName + "-manifest"
+	namespace := GetJobNamespace()
+	_ = cleanupExistingJob(k8sClient, manifestJobName, namespace)
+	job := createManifestJob(manifestJobName, namespace, deployment, service, image, platforms, IsInsecureRegistry(registryURL))
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create manifest job: %v", err)
+	}
+	if err := WaitForJobCompletion(k8sClient, manifestJobName, namespace, "manifest-tool", manifestTimeout); err != nil {
+		return fmt.Errorf("manifest list push failed: %v", err)
+	}
+
+	log.Printf("Pushed manifest list %s for %s", image, strings.Join(platforms, ", "))
+	return nil
+}
+
+// createManifestJob builds the manifest-tool job that publishes the manifest list. The
+// list digest becomes the termination message, see manifestListDigest.
+func createManifestJob(jobName, namespace string, deployment models.Deployment, service models.Service, image string, platforms []string, insecureRegistry bool) *batchv1.Job {
+	labels := map[string]string{
+		"app":              "pendeploy",
+		"component":        "manifest",
+		"service-id":       service.ID,
+		"deployment-id":    deployment.ID,
+		LabelServiceID:     service.ID,
+		LabelEnvironmentID: service.EnvironmentID,
+	}
+
+	flags := ""
+	if insecureRegistry {
+		flags = "--insecure --plain-http "
+	}
+	// manifest-tool replaces ARCH in the template with each platform's architecture
+	script := fmt.Sprintf(`manifest-tool %spush from-args --platforms %s --template %s --target %s > /tmp/push.log 2>&1
+status=$?
+cat /tmp/push.log
+[ "$status" -eq 0 ] || exit "$status"
+grep -o 'sha256:[0-9a-f]\{64\}' /tmp/push.log | head -n 1 > /dev/termination-log
+`, flags, strings.Join(platforms, ","), image+"-ARCH", image)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(manifestTimeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:     corev1.RestartPolicyNever,
+					PriorityClassName: service.BuildPriorityClassName,
+					Containers: []corev1.Container{
+						{
+							Name:    "manifest-tool",
+							Image:   ManifestToolImage,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{script},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	ApplyBuildNodePlacement(&job.Spec.Template.Spec)
+	return job
+}
+
+// manifestListDigest reads the manifest list digest of a multi-platform build, empty when
+// the build was single-platform or the digest could not be read
+func manifestListDigest(k8sClient *kubernetes.Client, jobName, namespace string) string {
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName+"-manifest"),
+	})
+	if err != nil {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != "manifest-tool" || status.State.Terminated == nil {
+				continue
+			}
+			if message := strings.TrimSpace(status.State.Terminated.Message); strings.HasPrefix(message, "sha256:") {
+				return message
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// buildInputs is what an image built from a commit depends on besides the commit
+type buildInputs struct {
+	RepoURL             string         `json:"repoUrl"`
+	Branch              string         `json:"branch"`
+	SparseCheckoutPaths string         `json:"sparseCheckoutPaths"`
+	BuildCommand        string         `json:"buildCommand"`
+	StartCommand        string         `json:"startCommand"`
+	DockerfilePath      string         `json:"dockerfilePath"`
+	BuildPlatforms      string         `json:"buildPlatforms"`
+	BuildArgs           models.EnvVars `json:"buildArgs"`
+	BuildEnvVars        models.EnvVars `json:"buildEnvVars"`
+	BakedEnvKeys        []string       `json:"bakedEnvKeys"`
+	BuildSecrets        bool           `json:"buildSecrets,omitempty"` // omitted when off so earlier digests still match
+}
+
+// BuildInputsDigest fingerprints the config an image of the service is built with. Two
+// builds of the same commit with the same digest produce an interchangeable image, so the
+// second can reuse the first's. Like EnvVarRebuildReason, only the values of env vars flagged
+// as needed at build time count: the Deployment's env overrides the other baked-in values,
+// but not a removed or newly secret env var, so the set of baked-in names counts as well.
+func BuildInputsDigest(service models.Service) string {
+	return digestJSON(collectBuildInputs(service))
+}
+
+// BuildCacheKey keys the build of a commit in the build cache: services of the same project
+// whose builds of the commit share it, e.g. apps of a monorepo with a common Dockerfile, can
+// use each other's image. The branch does not count, the commit fixes the source. The cache is
+// scoped to the project so an image of a private repository is only reused where the
+// repository's credentials were already given.
+func BuildCacheKey(service models.Service, commitSHA string) string {
+	inputs := collectBuildInputs(service)
+	inputs.RepoURL = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(inputs.RepoURL), "/"), ".git")
+	inputs.Branch = ""
+	return digestJSON(struct {
+		ProjectID string      `json:"projectId"`
+		CommitSHA string      `json:"commitSha"`
+		Inputs    buildInputs `json:"inputs"`
+	}{service.ProjectID, commitSHA, inputs})
+}
+
+func collectBuildInputs(service models.Service) buildInputs {
+	inputs := buildInputs{
+		RepoURL:             service.RepoURL,
+		Branch:              service.Branch,
+		SparseCheckoutPaths: service.SparseCheckoutPaths,
+		BuildCommand:        service.BuildCommand,
+		StartCommand:        service.StartCommand,
+		DockerfilePath:      GetDockerfilePath(service),
+		BuildPlatforms:      service.BuildPlatforms,
+		BuildArgs:           service.BuildArgs,
+		BuildEnvVars:        models.EnvVars{},
+		BakedEnvKeys:        []string{},
+		BuildSecrets:        service.BuildSecrets,
+	}
+	for key, value := range buildEnvVars(service) {
+		inputs.BakedEnvKeys = append(inputs.BakedEnvKeys, key)
+		if service.IsBuildEnvVar(key) {
+			inputs.BuildEnvVars[key] = value
+		}
+	}
+	sort.Strings(inputs.BakedEnvKeys)
+	return inputs
+}
+
+// digestJSON hashes the JSON of v; maps are marshalled with sorted keys, so equal inputs
+// give equal digests
+func digestJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
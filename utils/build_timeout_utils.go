@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+const (
+	// DefaultBuildTimeoutMinutes is how long a build may run when its service does not set a timeout
+	DefaultBuildTimeoutMinutes = 12
+	// DefaultMaxBuildTimeoutMinutes caps the build timeout of services until an admin sets a cap
+	DefaultMaxBuildTimeoutMinutes = 60
+	// MaxBuildTimeoutCapMinutes bounds the cap an admin can set
+	MaxBuildTimeoutCapMinutes = 24 * 60
+	// buildWatchGrace lets the job controller report the exceeded deadline before the watch
+	// gives up, so a timeout fails with the job's DeadlineExceeded reason
+	buildWatchGrace = time.Minute
+)
+
+// maxBuildTimeoutMinutes is the admin-set cap of service build timeouts, 0 for the default
+var maxBuildTimeoutMinutes atomic.Int64
+
+// SetMaxBuildTimeoutMinutes applies the admin-set cap of service build timeouts; 0 restores
+// DefaultMaxBuildTimeoutMinutes
+func SetMaxBuildTimeoutMinutes(minutes int) {
+	maxBuildTimeoutMinutes.Store(int64(minutes))
+}
+
+// GetMaxBuildTimeoutMinutes returns the cap of service build timeouts
+func GetMaxBuildTimeoutMinutes() int {
+	if minutes := int(maxBuildTimeoutMinutes.Load()); minutes > 0 {
+		return minutes
+	}
+	return DefaultMaxBuildTimeoutMinutes
+}
+
+// GetBuildTimeout returns how long a build of the service may run, test stage included: its
+// own timeout or the default, bounded by the admin-set cap. It is the build job's active
+// deadline and bounds the wait for the job.
+func GetBuildTimeout(service models.Service) time.Duration {
+	minutes := service.BuildTimeoutMinutes
+	if minutes <= 0 {
+		minutes = DefaultBuildTimeoutMinutes
+	}
+	if limit := GetMaxBuildTimeoutMinutes(); minutes > limit {
+		minutes = limit
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// getBuildWatchTimeout returns how long to wait for a build job of the service
+func getBuildWatchTimeout(service models.Service) time.Duration {
+	return GetBuildTimeout(service) + buildWatchGrace
+}
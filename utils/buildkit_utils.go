@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BuildKitVersion is the pinned BuildKit version of build jobs
+	BuildKitVersion = "v0.16.0"
+	// BuildKitImage runs buildkitd and buildctl without root
+	BuildKitImage = "moby/buildkit:" + BuildKitVersion + "-rootless"
+	// BuildKitContainerName is the build job container running BuildKit
+	BuildKitContainerName = "buildkit"
+
+	// buildKitStateVolume holds buildkitd's state for the duration of the build
+	buildKitStateVolume = "buildkit-state"
+	// buildKitHome is the home directory of the rootless image's user
+	buildKitHome = "/home/user"
+)
+
+// buildKitScript writes the buildkitd config, which rootless buildkitd reads from the
+// user's home, then runs buildkitd with buildctl on the job's arguments
+const buildKitScript = `mkdir -p "$HOME/.config/buildkit"
+printf '%s' "$BUILDKITD_CONFIG" > "$HOME/.config/buildkit/buildkitd.toml"
+exec buildctl-daemonless.sh "$@"`
+
+// buildKitBuilder builds images with rootless BuildKit in a build job. Multi-arch images are
+// built by one job, emulating the foreign platforms, so the build nodes need QEMU binfmt
+// handlers (e.g. installed by tonistiigi/binfmt).
+type buildKitBuilder struct{}
+
+func (buildKitBuilder) Name() string {
+	return BuildBackendBuildKit
+}
+
+// Capabilities of BuildKit: a registry layer cache, multi-arch manifest lists and secret
+// mounts (RUN --mount=type=secret,id=NAME)
+func (buildKitBuilder) Capabilities() BuildCapabilities {
+	return BuildCapabilities{Cache: true, MultiArch: true, Secrets: true}
+}
+
+// Build creates a Kubernetes job with BuildKit and waits for completion
+func (buildKitBuilder) Build(deployment models.Deployment, service models.Service, registry models.Registry) (string, error) {
+	image := GenerateImage(CleanRegistryURL(registry.URL), service, deployment)
+	log.Printf("Target image (for K8s): %s", image)
+
+	k8sClient, err := prepareBuildJobs()
+	if err != nil {
+		return "", err
+	}
+
+	jobName := GetJobName(service.ID, deployment.ID)
+	secretName := ""
+	if service.BuildSecrets && len(service.SecretEnvVars) > 0 {
+		secretName = jobName + "-build-secrets"
+		if err := applyBuildSecrets(k8sClient, secretName, deployment, service); err != nil {
+			return "", fmt.Errorf("build secrets: %v", err)
+		}
+		defer deleteBuildSecrets(k8sClient, secretName)
+	}
+
+	log.Printf("Creating BuildKit job: %s", jobName)
+	job := createBuildKitBuildJob(jobName, registry.URL, deployment, service, image, secretName)
+	if err := runBuildJob(k8sClient, job, service); err != nil {
+		return "", fmt.Errorf("build job failed: %v", err)
+	}
+
+	log.Printf("BUILD SUCCESS: Job %s completed successfully! Image ready: %s", jobName, image)
+	return image, nil
+}
+
+// createBuildKitBuildJob creates the job definition building image with BuildKit. With
+// secretName set, the service's secret env vars are read from that Secret and mounted as
+// build secrets named after the env vars.
+func createBuildKitBuildJob(jobName string, registryURL string, deployment models.Deployment, service models.Service, image string, secretName string) *batchv1.Job {
+	dockerfile := GetDockerfilePath(service)
+	cacheRef := fmt.Sprintf("%s/cache:buildkit-%s", CleanRegistryURL(registryURL), service.ID)
+
+	args := []string{
+		"build",
+		"--frontend=dockerfile.v0",
+		"--local=context=/workspace",
+		"--local=dockerfile=" + path.Join("/workspace", path.Dir(dockerfile)),
+		"--opt=filename=" + path.Base(dockerfile),
+		fmt.Sprintf("--output=type=image,name=%s,push=true", image),
+		fmt.Sprintf("--import-cache=type=registry,ref=%s", cacheRef),
+		fmt.Sprintf("--export-cache=type=registry,ref=%s,mode=max", cacheRef),
+		// The pushed digest is in the metadata, see builtImageDigest
+		"--metadata-file=/dev/termination-log",
+	}
+	platforms := GetBuildPlatforms(service)
+	if len(platforms) > 0 {
+		args = append(args, "--opt=platform="+strings.Join(platforms, ","))
+	}
+	for key, value := range buildArgs(service) {
+		args = append(args, fmt.Sprintf("--opt=build-arg:%s=%s", key, value))
+	}
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "BUILDKITD_FLAGS",
+			Value: "--oci-worker-no-process-sandbox",
+		},
+		{
+			Name:  "BUILDKITD_CONFIG",
+			Value: buildKitConfig(registryURL),
+		},
+		{
+			Name:  "HOME",
+			Value: buildKitHome,
+		},
+	}
+	if secretName != "" {
+		for _, key := range sortedKeys(service.SecretEnvVars) {
+			args = append(args, fmt.Sprintf("--secret=id=%s,env=%s", key, key))
+			env = append(env, corev1.EnvVar{
+				Name: key,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+						Key:                  key,
+					},
+				},
+			})
+		}
+	}
+
+	executor := corev1.Container{
+		Name:    BuildKitContainerName,
+		Image:   BuildKitImage,
+		Command: []string{"sh", "-c", buildKitScript, "buildkit"},
+		Args:    args,
+		Env:     env,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      buildWorkspaceVolume,
+				MountPath: "/workspace",
+			},
+			{
+				Name:      buildKitStateVolume,
+				MountPath: buildKitHome + "/.local/share/buildkit",
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("500m"),
+				corev1.ResourceMemory:           resource.MustParse("1Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("2000m"),
+				corev1.ResourceMemory:           resource.MustParse("6Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("12Gi"),
+			},
+		},
+	}
+
+	job := newBuildJob(jobName, deployment, service, BuildBackendBuildKit, executor)
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: buildKitStateVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	// A single platform builds natively on a node of its architecture
+	if len(platforms) == 1 {
+		applyBuildPlatform(&job.Spec.Template.Spec, platforms[0])
+	}
+
+	// Rootless BuildKit runs as an unprivileged user, but creating its user namespace takes
+	// the setuid newuidmap helpers and syscalls the default seccomp and AppArmor profiles
+	// block, so those are unconfined for this container only
+	for i := range job.Spec.Template.Spec.Containers {
+		if job.Spec.Template.Spec.Containers[i].Name == BuildKitContainerName {
+			job.Spec.Template.Spec.Containers[i].SecurityContext = &corev1.SecurityContext{
+				RunAsUser:  int64Ptr(1000),
+				RunAsGroup: int64Ptr(1000),
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeUnconfined,
+				},
+				AppArmorProfile: &corev1.AppArmorProfile{
+					Type: corev1.AppArmorProfileTypeUnconfined,
+				},
+			}
+		}
+	}
+
+	log.Println("BuildKit job spec created successfully")
+	return job
+}
+
+// buildKitConfig renders buildkitd.toml: plain HTTP for an insecure registry, and Docker Hub
+// pulled through the registry mirror when one is ready
+func buildKitConfig(registryURL string) string {
+	var config strings.Builder
+	insecureHosts := []string{}
+	if IsInsecureRegistry(registryURL) {
+		insecureHosts = append(insecureHosts, CleanRegistryURL(registryURL))
+	}
+	if mirror := GetRegistryMirror(); mirror != "" {
+		fmt.Fprintf(&config, "[registry.\"docker.io\"]\n  mirrors = [%q]\n", CleanRegistryURL(mirror))
+		if IsInsecureRegistry(mirror) {
+			insecureHosts = append(insecureHosts, CleanRegistryURL(mirror))
+		}
+	}
+	for _, host := range insecureHosts {
+		fmt.Fprintf(&config, "[registry.%q]\n  http = true\n  insecure = true\n", host)
+	}
+	return config.String()
+}
+
+// applyBuildSecrets copies the service's secret env vars into a Secret of the build
+// namespace, which the build job reads them from
+func applyBuildSecrets(k8sClient *kubernetes.Client, name string, deployment models.Deployment, service models.Service) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: GetJobNamespace(),
+			Labels: map[string]string{
+				"app":              "pendeploy",
+				"deployment-id":    deployment.ID,
+				LabelServiceID:     service.ID,
+				LabelEnvironmentID: service.EnvironmentID,
+			},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: service.SecretEnvVars,
+	}
+
+	secrets := k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace())
+	_, err := secrets.Create(context.Background(), secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(context.Background(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// deleteBuildSecrets removes the Secret of a finished build
+func deleteBuildSecrets(k8sClient *kubernetes.Client, name string) {
+	err := k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace()).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Printf("WARNING: Failed to delete build secrets %s: %v", name, err)
+	}
+}
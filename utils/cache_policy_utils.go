@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LabelCacheRule marks the Ingresses and Traefik Middlewares that carry a cache rule of a service
+const LabelCacheRule = "pendeploy.io/cache-rule"
+
+const (
+	maxCacheRules      = 10
+	maxCacheTTLSeconds = 7 * 24 * 60 * 60
+	// cacheCleanupSeconds is how often the cache plugin evicts expired responses
+	cacheCleanupSeconds = 300
+)
+
+// traefikMiddlewaresAnnotation lists the Traefik Middlewares an Ingress's routers run through
+const traefikMiddlewaresAnnotation = "traefik.ingress.kubernetes.io/router.middlewares"
+
+var traefikMiddlewareGVR = schema.GroupVersionResource{Group: "traefik.io", Version: "v1alpha1", Resource: "middlewares"}
+
+// GetCachePluginName returns the name the HTTP cache plugin is registered with in Traefik's
+// static configuration (TRAEFIK_CACHE_PLUGIN, default cache). The plugin is expected to be
+// github.com/traefik/plugin-simplecache or one taking the same options.
+func GetCachePluginName() string {
+	if name := os.Getenv("TRAEFIK_CACHE_PLUGIN"); name != "" {
+		return name
+	}
+	return "cache"
+}
+
+// ValidateCacheRules checks the cache policy of a service: path prefixes without query or
+// wildcards, each listed once, and TTLs of at most a week
+func ValidateCacheRules(rules models.CacheRules) error {
+	var errs FieldErrors
+	if len(rules) > maxCacheRules {
+		errs.Add("rules", "must not contain more than %d rules", maxCacheRules)
+	}
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		switch {
+		case !strings.HasPrefix(rule.PathPrefix, "/"):
+			errs.Add(field+".pathPrefix", "must start with /")
+		case strings.ContainsAny(rule.PathPrefix, "?#* \t"):
+			errs.Add(field+".pathPrefix", "must be a plain path prefix without query, fragment, wildcards or spaces")
+		case seen[rule.PathPrefix]:
+			errs.Add(field+".pathPrefix", "is listed more than once")
+		}
+		seen[rule.PathPrefix] = true
+		if rule.TTLSeconds < 1 || rule.TTLSeconds > maxCacheTTLSeconds {
+			errs.Add(field+".ttlSeconds", "must be between 1 and %d", maxCacheTTLSeconds)
+		}
+	}
+	return errs.Err()
+}
+
+// getCacheRuleName returns the name of the Ingress and Middlewares of the service's nth cache rule
+func getCacheRuleName(service models.Service, index int) string {
+	return fmt.Sprintf("%s-cache-%d", GetResourceName(service), index+1)
+}
+
+// cacheRuleLabels labels the resources of a cache rule so stale ones can be found
+func cacheRuleLabels(service models.Service) map[string]string {
+	labels := GetResourceLabels(service)
+	labels[LabelCacheRule] = "true"
+	return labels
+}
+
+// buildCacheMiddlewares renders the Traefik Middlewares of a cache rule: the cache plugin,
+// and a Cache-Control header with the rule's TTL that the plugin and browsers honour
+func buildCacheMiddlewares(service models.Service, name string, rule models.CacheRule) []*unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	for key, value := range cacheRuleLabels(service) {
+		labels[key] = value
+	}
+	metadata := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":      name,
+			"namespace": service.EnvironmentID,
+			"labels":    labels,
+		}
+	}
+
+	return []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(name),
+			"spec": map[string]interface{}{
+				"plugin": map[string]interface{}{
+					GetCachePluginName(): map[string]interface{}{
+						"path":            "/tmp/traefik-cache",
+						"maxExpiry":       int64(rule.TTLSeconds),
+						"cleanup":         int64(cacheCleanupSeconds),
+						"addStatusHeader": true,
+					},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(name + "-headers"),
+			"spec": map[string]interface{}{
+				"headers": map[string]interface{}{
+					"customResponseHeaders": map[string]interface{}{
+						"Cache-Control": fmt.Sprintf("public, max-age=%d", rule.TTLSeconds),
+					},
+				},
+			},
+		}},
+	}
+}
+
+// createCacheRuleIngressSpec builds the Ingress routing the rule's path prefix on every
+// hostname of the service through its cache Middlewares. Traefik prefers the longer path,
+// so it takes precedence over the service's main Ingress.
+func createCacheRuleIngressSpec(service models.Service, name string, rule models.CacheRule) *networkingv1.Ingress {
+	ingress := createIngressSpecForHosts(service, name, buildHostnames(service), fmt.Sprintf("%s-tls", GetResourceName(service)))
+	// The main Ingress has the certificate issued; this one only reuses its Secret
+	delete(ingress.Annotations, "cert-manager.io/cluster-issuer")
+	ingress.Labels = cacheRuleLabels(service)
+
+	if service.CustomDomain != "" && service.CustomTLSSecret != "" {
+		custom := createIngressSpecForHosts(service, name, []string{service.CustomDomain}, service.CustomTLSSecret)
+		ingress.Spec.Rules = append(ingress.Spec.Rules, custom.Spec.Rules...)
+		ingress.Spec.TLS = append(ingress.Spec.TLS, custom.Spec.TLS...)
+	}
+	for i := range ingress.Spec.Rules {
+		ingress.Spec.Rules[i].HTTP.Paths[0].Path = rule.PathPrefix
+	}
+
+	// Middlewares run in the listed order, so the cache sees the Cache-Control header of the second
+	ingress.Annotations[traefikMiddlewaresAnnotation] = fmt.Sprintf(
+		"%[1]s-%[2]s@kubernetescrd,%[1]s-%[2]s-headers@kubernetescrd", service.EnvironmentID, name)
+	return ingress
+}
+
+// deployCachePolicy applies the Ingresses and Middlewares of the service's cache rules and
+// removes those of rules it no longer has
+func deployCachePolicy(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	rules := service.CacheRules
+	if !GetIngressProvider().SupportsMiddlewares() {
+		// Only Traefik runs the cache Middlewares; the rules apply again after a switch back
+		if len(rules) > 0 {
+			log.Printf("Cache policy of service %s not applied: the %s ingress provider has no Middlewares", service.ID, GetIngressProvider().Name())
+		}
+		rules = nil
+	}
+
+	desired := map[string]bool{}
+	for i, rule := range rules {
+		name := getCacheRuleName(service, i)
+		for _, middleware := range buildCacheMiddlewares(service, name, rule) {
+			setServiceOwner(middleware, owner)
+			if err := applyTraefikMiddleware(ctx, client, middleware); err != nil {
+				return err
+			}
+			desired[middleware.GetName()] = true
+		}
+
+		ingress := createCacheRuleIngressSpec(service, name, rule)
+		setServiceOwner(ingress, owner)
+		addErrorPagesMiddleware(ctx, client, ingress)
+		if err := applyIngress(ctx, client, ingress); err != nil {
+			return fmt.Errorf("failed to apply cache Ingress %s: %v", name, err)
+		}
+		desired[name] = true
+	}
+
+	selector := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s,%s=true", ServiceOwnerSelector(service.ID), LabelCacheRule)}
+	ingresses, err := client.Clientset.NetworkingV1().Ingresses(service.EnvironmentID).List(ctx, selector)
+	if err != nil {
+		return fmt.Errorf("failed to list cache Ingresses: %v", err)
+	}
+	for _, ingress := range ingresses.Items {
+		if desired[ingress.Name] {
+			continue
+		}
+		err := client.Clientset.NetworkingV1().Ingresses(service.EnvironmentID).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete cache Ingress %s: %v", ingress.Name, err)
+		}
+	}
+
+	middlewares := client.DynamicClient.Resource(traefikMiddlewareGVR).Namespace(service.EnvironmentID)
+	existing, err := middlewares.List(ctx, selector)
+	if errors.IsNotFound(err) {
+		// Traefik's CRDs are not installed, so there is nothing to clean up
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list cache Middlewares: %v", err)
+	}
+	for _, middleware := range existing.Items {
+		if desired[middleware.GetName()] {
+			continue
+		}
+		err := middlewares.Delete(ctx, middleware.GetName(), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete cache Middleware %s: %v", middleware.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// applyTraefikMiddleware creates or updates a Traefik Middleware
+func applyTraefikMiddleware(ctx context.Context, client *kubernetes.Client, middleware *unstructured.Unstructured) error {
+	middlewares := client.DynamicClient.Resource(traefikMiddlewareGVR).Namespace(middleware.GetNamespace())
+	existing, err := middlewares.Get(ctx, middleware.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = middlewares.Create(ctx, middleware, metav1.CreateOptions{})
+	case err == nil:
+		middleware.SetResourceVersion(existing.GetResourceVersion())
+		_, err = middlewares.Update(ctx, middleware, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply Middleware %s (are Traefik's CRDs installed?): %v", middleware.GetName(), err)
+	}
+	log.Printf("Traefik Middleware %s applied", middleware.GetName())
+	return nil
+}
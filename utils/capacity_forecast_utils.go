@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// minForecastSamples and minForecastSpan keep a few noisy samples from producing a trend
+	minForecastSamples = 12
+	minForecastSpan    = 6 * time.Hour
+
+	// forecastHorizonDays is how far ahead an exhaustion date is still reported
+	forecastHorizonDays = 365
+)
+
+// Right-sizing directions
+const (
+	RightSizeShrink = "shrink"
+	RightSizeGrow   = "grow"
+)
+
+const (
+	// MinRightSizeSamples is how many usage samples a service needs before its limits are judged
+	MinRightSizeSamples = 24
+
+	// rightSizeHeadroom is added on top of the observed usage when suggesting a limit
+	rightSizeHeadroom = 1.3
+
+	// Limits are shrunk below this p95 utilization and grown above the other (percent)
+	rightSizeShrinkBelow = 40.0
+	rightSizeGrowAbove   = 85.0
+
+	// Suggested limits are rounded up to these steps, which are also the minimums
+	cpuLimitStep    = 50               // millicores
+	memoryLimitStep = 64 * 1024 * 1024 // bytes
+)
+
+// TrendPoint is one observation of a resource total
+type TrendPoint struct {
+	At    time.Time
+	Value float64
+}
+
+// ForecastResource fits a least-squares line through the points and projects when the
+// latest value grows into the capacity at that rate
+func ForecastResource(resourceName, basis string, points []TrendPoint, capacity int64) dto.ResourceForecast {
+	forecast := dto.ResourceForecast{
+		Resource: resourceName,
+		Basis:    basis,
+		Capacity: capacity,
+	}
+	if len(points) == 0 {
+		forecast.Note = "No usage history recorded yet"
+		return forecast
+	}
+
+	last := points[len(points)-1]
+	forecast.Current = int64(last.Value)
+	forecast.Percentage = math.Round(CalculatePercentage(forecast.Current, capacity)*10) / 10
+	if len(points) < minForecastSamples || last.At.Sub(points[0].At) < minForecastSpan {
+		forecast.Note = fmt.Sprintf("Not enough history for a trend yet (needs %d samples over %v)", minForecastSamples, minForecastSpan)
+		return forecast
+	}
+
+	slope := linearSlopePerDay(points)
+	forecast.GrowthPerDay = math.Round(slope*100) / 100
+	if capacity <= 0 {
+		return forecast
+	}
+
+	var days float64
+	switch {
+	case forecast.Current >= capacity:
+		days = 0
+	case slope <= 0:
+		forecast.Note = "Not growing"
+		return forecast
+	default:
+		days = float64(capacity-forecast.Current) / slope
+	}
+	if days > forecastHorizonDays {
+		forecast.Note = fmt.Sprintf("Not exhausted within %d days at the current growth", forecastHorizonDays)
+		return forecast
+	}
+
+	days = math.Round(days*10) / 10
+	exhaustedAt := last.At.Add(time.Duration(days * float64(24*time.Hour)))
+	forecast.DaysUntilExhausted = &days
+	forecast.ExhaustedAt = &exhaustedAt
+	return forecast
+}
+
+// linearSlopePerDay returns the least-squares slope of the points in units per day
+func linearSlopePerDay(points []TrendPoint) float64 {
+	start := points[0].At
+	n := float64(len(points))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, point := range points {
+		x := point.At.Sub(start).Hours() / 24
+		sumX += x
+		sumY += point.Value
+		sumXY += x * point.Value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// RecommendLimit compares the p95 and peak per-pod usage of a resource with its per-pod
+// limit. It returns an empty direction when the limit fits the usage; otherwise the
+// suggested limit gives the p95 (or, when growing, the peak) some headroom.
+func RecommendLimit(resourceName string, limit int64, p95, peak float64) (direction string, recommended int64) {
+	if limit <= 0 {
+		return "", 0
+	}
+
+	utilization := p95 / float64(limit) * 100
+	switch {
+	case utilization > rightSizeGrowAbove:
+		recommended = roundUpLimit(resourceName, math.Max(p95, peak)*rightSizeHeadroom)
+		if recommended > limit {
+			return RightSizeGrow, recommended
+		}
+	case utilization < rightSizeShrinkBelow:
+		recommended = roundUpLimit(resourceName, math.Max(p95*rightSizeHeadroom, peak))
+		// Not worth a restart for a small change
+		if recommended < limit*4/5 {
+			return RightSizeShrink, recommended
+		}
+	}
+	return "", 0
+}
+
+func roundUpLimit(resourceName string, value float64) int64 {
+	step := int64(memoryLimitStep)
+	if resourceName == "cpu" {
+		step = cpuLimitStep
+	}
+	rounded := int64(math.Ceil(value/float64(step))) * step
+	if rounded < step {
+		return step
+	}
+	return rounded
+}
+
+// ParseResourceLimit parses a service's CPU limit to millicores or memory limit to bytes
+func ParseResourceLimit(resourceName, limit string) (int64, error) {
+	quantity, err := resource.ParseQuantity(limit)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s limit %q: %v", resourceName, limit, err)
+	}
+	if resourceName == "cpu" {
+		return quantity.MilliValue(), nil
+	}
+	return quantity.Value(), nil
+}
+
+// FormatResourceLimit formats millicores or bytes as a limit quantity such as 250m or 384Mi
+func FormatResourceLimit(resourceName string, value int64) string {
+	if resourceName == "cpu" {
+		return fmt.Sprintf("%dm", value)
+	}
+	return fmt.Sprintf("%dMi", value/(1024*1024))
+}
+
+// FormatResourceUsage formats millicores or bytes in human-readable units
+func FormatResourceUsage(resourceName string, value float64) string {
+	if resourceName == "cpu" {
+		return fmt.Sprintf("%.0fm", value)
+	}
+	return FormatBytesToHumanReadable(int64(value))
+}
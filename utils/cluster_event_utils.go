@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Severities of streamed cluster events, in increasing order
+const (
+	ClusterEventSeverityWarning  = "warning"
+	ClusterEventSeverityCritical = "critical"
+)
+
+const (
+	// clusterEventResync is how often the Event informer re-lists from the API server
+	clusterEventResync = 30 * time.Minute
+
+	// clusterEventBacklog is how many recent important events a new subscriber receives
+	clusterEventBacklog = 100
+
+	// clusterEventBacklogAge is how old an event may be to enter the backlog
+	clusterEventBacklogAge = time.Hour
+
+	// clusterEventBuffer is the per-subscriber queue; slower subscribers miss events
+	clusterEventBuffer = 64
+)
+
+// clusterEventRule marks events with a reason as important
+type clusterEventRule struct {
+	category string
+	severity string
+}
+
+// nodeEventRules are the node conditions worth an admin's attention
+var nodeEventRules = map[string]clusterEventRule{
+	"NodeNotReady":              {"node", ClusterEventSeverityCritical},
+	"NodeNotSchedulable":        {"node", ClusterEventSeverityWarning},
+	"Rebooted":                  {"node", ClusterEventSeverityWarning},
+	"NodeHasDiskPressure":       {"node", ClusterEventSeverityCritical},
+	"NodeHasInsufficientMemory": {"node", ClusterEventSeverityCritical},
+	"NodeHasInsufficientPID":    {"node", ClusterEventSeverityWarning},
+	"EvictionThresholdMet":      {"node", ClusterEventSeverityWarning},
+	"SystemOOM":                 {"node", ClusterEventSeverityWarning},
+	"FreeDiskSpaceFailed":       {"node", ClusterEventSeverityWarning},
+	"ImageGCFailed":             {"node", ClusterEventSeverityWarning},
+}
+
+// storageEventRules are volume provisioning and attach failures on any object
+var storageEventRules = map[string]clusterEventRule{
+	"ProvisioningFailed":     {"storage", ClusterEventSeverityCritical},
+	"FailedBinding":          {"storage", ClusterEventSeverityWarning},
+	"VolumeResizeFailed":     {"storage", ClusterEventSeverityWarning},
+	"FileSystemResizeFailed": {"storage", ClusterEventSeverityWarning},
+	"FailedAttachVolume":     {"storage", ClusterEventSeverityWarning},
+	"FailedMount":            {"storage", ClusterEventSeverityWarning},
+}
+
+// ClusterEventSeverityRank orders severities; unknown severities rank below warning
+func ClusterEventSeverityRank(severity string) int {
+	switch severity {
+	case ClusterEventSeverityCritical:
+		return 2
+	case ClusterEventSeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// classifyClusterEvent returns the streamed form of an important event, false otherwise
+func classifyClusterEvent(event *corev1.Event) (dto.ClusterEvent, bool) {
+	object := event.InvolvedObject
+	var rule clusterEventRule
+	var ok bool
+
+	switch {
+	case object.Kind == "Node":
+		rule, ok = nodeEventRules[event.Reason]
+	case strings.HasPrefix(object.APIVersion, "cert-manager.io/") || strings.HasPrefix(object.APIVersion, "acme.cert-manager.io/"):
+		// Every cert-manager warning means a certificate is not being issued or renewed
+		if event.Type == corev1.EventTypeWarning {
+			rule, ok = clusterEventRule{"certificate", ClusterEventSeverityWarning}, true
+			if object.Kind == "Certificate" || object.Kind == "ClusterIssuer" || object.Kind == "Issuer" {
+				rule.severity = ClusterEventSeverityCritical
+			}
+		}
+	case event.Reason == "FailedScheduling":
+		rule, ok = clusterEventRule{"scheduling", ClusterEventSeverityWarning}, true
+	default:
+		rule, ok = storageEventRules[event.Reason]
+	}
+	if !ok {
+		return dto.ClusterEvent{}, false
+	}
+
+	return dto.ClusterEvent{
+		Severity:  rule.severity,
+		Category:  rule.category,
+		Reason:    event.Reason,
+		Message:   strings.TrimSpace(event.Message),
+		Kind:      object.Kind,
+		Namespace: object.Namespace,
+		Name:      object.Name,
+		Count:     event.Count,
+		Source:    clusterEventSource(event),
+		FirstSeen: clusterEventTime(event.FirstTimestamp.Time, event),
+		LastSeen:  clusterEventTime(event.LastTimestamp.Time, event),
+	}, true
+}
+
+func clusterEventSource(event *corev1.Event) string {
+	if event.Source.Component != "" {
+		return event.Source.Component
+	}
+	return event.ReportingController
+}
+
+// clusterEventTime falls back to the event time, then the creation time, for events
+// recorded through the events.k8s.io API that leave the legacy timestamps empty
+func clusterEventTime(timestamp time.Time, event *corev1.Event) time.Time {
+	if !timestamp.IsZero() {
+		return timestamp
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// clusterEventHub fans important events from the shared Event informer out to subscribers
+type clusterEventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan dto.ClusterEvent]struct{}
+	backlog     []dto.ClusterEvent
+}
+
+var (
+	eventHub   *clusterEventHub
+	eventHubMu sync.Mutex
+)
+
+// getClusterEventHub starts the Event informer on first use. A failed start is retried on
+// the next call.
+func getClusterEventHub() (*clusterEventHub, error) {
+	eventHubMu.Lock()
+	defer eventHubMu.Unlock()
+
+	if eventHub != nil {
+		return eventHub, nil
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	hub := &clusterEventHub{subscribers: map[chan dto.ClusterEvent]struct{}{}}
+
+	factory := informers.NewSharedInformerFactory(k8sClient.Clientset, clusterEventResync)
+	informer := factory.Core().V1().Events().Informer()
+	handle := func(obj interface{}) {
+		event, ok := obj.(*corev1.Event)
+		if !ok {
+			return
+		}
+		clusterEvent, important := classifyClusterEvent(event)
+		if !important {
+			return
+		}
+		// The initial list also delivers old events; only recent ones go to the backlog
+		if time.Since(clusterEvent.LastSeen) > clusterEventBacklogAge {
+			return
+		}
+		hub.publish(clusterEvent)
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: handle,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Resyncs deliver unchanged objects; only a new occurrence is an event
+			oldEvent, okOld := oldObj.(*corev1.Event)
+			newEvent, okNew := newObj.(*corev1.Event)
+			if okOld && okNew && oldEvent.ResourceVersion == newEvent.ResourceVersion {
+				return
+			}
+			handle(newObj)
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to register event handler: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	factory.Start(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), statusCacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		close(stopCh)
+		return nil, fmt.Errorf("timed out syncing event informer")
+	}
+
+	log.Println("Cluster event informer synced")
+	eventHub = hub
+	return eventHub, nil
+}
+
+// publish records an event in the backlog and hands it to every subscriber
+func (h *clusterEventHub) publish(event dto.ClusterEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.backlog = append(h.backlog, event)
+	if len(h.backlog) > clusterEventBacklog {
+		h.backlog = h.backlog[len(h.backlog)-clusterEventBacklog:]
+	}
+	for subscriber := range h.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// A stalled client must not block the informer
+		}
+	}
+}
+
+// SubscribeClusterEvents returns the recent important cluster events and a channel of new
+// ones. unsubscribe must be called when the subscriber goes away; it closes the channel.
+func SubscribeClusterEvents() (backlog []dto.ClusterEvent, events <-chan dto.ClusterEvent, unsubscribe func(), err error) {
+	hub, err := getClusterEventHub()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	subscriber := make(chan dto.ClusterEvent, clusterEventBuffer)
+	hub.mu.Lock()
+	backlog = append([]dto.ClusterEvent(nil), hub.backlog...)
+	hub.subscribers[subscriber] = struct{}{}
+	hub.mu.Unlock()
+	// The initial list arrives in no particular order
+	sort.SliceStable(backlog, func(i, j int) bool { return backlog[i].LastSeen.Before(backlog[j].LastSeen) })
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			hub.mu.Lock()
+			delete(hub.subscribers, subscriber)
+			hub.mu.Unlock()
+			close(subscriber)
+		})
+	}
+	return backlog, subscriber, unsubscribe, nil
+}
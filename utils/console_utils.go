@@ -0,0 +1,340 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+const (
+	// ConsoleQueryTimeout bounds every console query, both server-side and on the connection
+	ConsoleQueryTimeout = 10 * time.Second
+	// ConsoleMaxRows caps how many rows are returned to the browser
+	ConsoleMaxRows = 500
+	// ConsoleMaxQueryLength rejects pasted dumps and other oversized input
+	ConsoleMaxQueryLength = 10000
+)
+
+// readOnlyRedisCommands are the only commands the console sends, like the read-only
+// transaction of the PostgreSQL console. KEYS is left out as it blocks the server on large
+// databases; SCAN pages through keys instead.
+var readOnlyRedisCommands = map[string]bool{
+	"PING": true, "ECHO": true, "TIME": true, "INFO": true, "DBSIZE": true, "ROLE": true,
+	"LASTSAVE": true, "RANDOMKEY": true, "SCAN": true, "EXISTS": true, "TYPE": true,
+	"TTL": true, "PTTL": true, "EXPIRETIME": true, "PEXPIRETIME": true, "OBJECT": true,
+	"GET": true, "MGET": true, "STRLEN": true, "GETRANGE": true, "GETBIT": true,
+	"BITCOUNT": true, "BITPOS": true, "HGET": true, "HMGET": true, "HGETALL": true,
+	"HKEYS": true, "HVALS": true, "HLEN": true, "HEXISTS": true, "HSTRLEN": true,
+	"HSCAN": true, "LRANGE": true, "LLEN": true, "LINDEX": true, "LPOS": true,
+	"SMEMBERS": true, "SISMEMBER": true, "SMISMEMBER": true, "SCARD": true, "SSCAN": true,
+	"ZRANGE": true, "ZRANGEBYSCORE": true, "ZRANGEBYLEX": true, "ZREVRANGE": true,
+	"ZREVRANGEBYSCORE": true, "ZREVRANGEBYLEX": true, "ZSCORE": true, "ZMSCORE": true,
+	"ZRANK": true, "ZREVRANK": true, "ZCARD": true, "ZCOUNT": true, "ZLEXCOUNT": true,
+	"ZSCAN": true, "XRANGE": true, "XREVRANGE": true, "XLEN": true, "XINFO": true,
+	"PFCOUNT": true, "GEOPOS": true, "GEODIST": true, "GEOHASH": true, "GEOSEARCH": true,
+	"MEMORY": true, "SLOWLOG": true,
+}
+
+// readOnlyRedisSubcommands narrows the allowed commands that also have writing subcommands
+var readOnlyRedisSubcommands = map[string]map[string]bool{
+	"MEMORY":  {"USAGE": true, "STATS": true, "DOCTOR": true},
+	"SLOWLOG": {"GET": true, "LEN": true},
+}
+
+// IsConsoleSupported reports whether the database console can talk to the managed type
+func IsConsoleSupported(managedType string) bool {
+	return managedType == "postgresql" || managedType == "redis"
+}
+
+// RunConsoleQuery runs a short query against a managed service over its in-cluster
+// address, so no external port or local client is needed
+func RunConsoleQuery(service models.Service, query string) (dto.ConsoleQueryResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return dto.ConsoleQueryResult{}, errors.New("query cannot be empty")
+	}
+	if len(query) > ConsoleMaxQueryLength {
+		return dto.ConsoleQueryResult{}, fmt.Errorf("query exceeds %d characters", ConsoleMaxQueryLength)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ConsoleQueryTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var result dto.ConsoleQueryResult
+	var err error
+	switch service.ManagedType {
+	case "postgresql":
+		result, err = runPostgresConsoleQuery(ctx, service, query)
+	case "redis":
+		result, err = runRedisConsoleCommand(ctx, service, query)
+	default:
+		return result, fmt.Errorf("console is not supported for %s services", service.ManagedType)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, err
+}
+
+// getConsoleHost returns the in-cluster address of the managed service
+func getConsoleHost(service models.Service) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", GetResourceName(service), service.EnvironmentID, GetManagedServicePort(service.ManagedType))
+}
+
+// runPostgresConsoleQuery executes the query in a read-only transaction with a
+// server-side statement_timeout
+func runPostgresConsoleQuery(ctx context.Context, service models.Service, query string) (dto.ConsoleQueryResult, error) {
+	var result dto.ConsoleQueryResult
+
+	connString := fmt.Sprintf("postgresql://%s:%s@%s/%s?sslmode=disable",
+		service.EnvVars["POSTGRES_USER"], service.EnvVars["POSTGRES_PASSWORD"], getConsoleHost(service), service.EnvVars["POSTGRES_DB"])
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return result, fmt.Errorf("invalid connection settings: %v", err)
+	}
+	config.RuntimeParams["statement_timeout"] = strconv.FormatInt(ConsoleQueryTimeout.Milliseconds(), 10)
+	config.RuntimeParams["application_name"] = "pendeploy-console"
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return result, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return result, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for _, field := range rows.FieldDescriptions() {
+		result.Columns = append(result.Columns, field.Name)
+	}
+	result.Rows = [][]interface{}{}
+	for rows.Next() {
+		if len(result.Rows) >= ConsoleMaxRows {
+			result.Truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return result, err
+		}
+		for i, value := range values {
+			values[i] = toConsoleValue(value)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
+
+// toConsoleValue converts driver values into something JSON-friendly
+func toConsoleValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, int16, int32, int64, float32, float64, time.Time:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// runRedisConsoleCommand sends a single command using the RESP protocol
+func runRedisConsoleCommand(ctx context.Context, service models.Service, command string) (dto.ConsoleQueryResult, error) {
+	result := dto.ConsoleQueryResult{Columns: []string{"result"}}
+
+	args, err := splitConsoleCommand(command)
+	if err != nil {
+		return result, err
+	}
+	if err := checkReadOnlyRedisCommand(args); err != nil {
+		return result, err
+	}
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", getConsoleHost(service))
+	if err != nil {
+		return result, fmt.Errorf("failed to connect to redis: %v", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	reader := bufio.NewReader(conn)
+
+	// Servers started without requirepass reject AUTH; the command runs unauthenticated then
+	if password := service.EnvVars["REDIS_PASSWORD"]; password != "" {
+		if _, err := sendRedisCommand(conn, reader, []string{"AUTH", password}); err != nil && !isRedisNoPasswordError(err) {
+			return result, fmt.Errorf("failed to authenticate: %v", err)
+		}
+	}
+
+	reply, err := sendRedisCommand(conn, reader, args)
+	if err != nil {
+		return result, err
+	}
+
+	if list, ok := reply.([]interface{}); ok {
+		result.Rows = [][]interface{}{}
+		for _, item := range list {
+			if len(result.Rows) >= ConsoleMaxRows {
+				result.Truncated = true
+				break
+			}
+			result.Rows = append(result.Rows, []interface{}{item})
+		}
+	} else {
+		result.Rows = [][]interface{}{{reply}}
+	}
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
+
+// checkReadOnlyRedisCommand rejects commands that may write, reconfigure the server or
+// block the connection
+func checkReadOnlyRedisCommand(args []string) error {
+	name := strings.ToUpper(args[0])
+	if !readOnlyRedisCommands[name] {
+		return fmt.Errorf("command %s is not allowed in the console, which only runs read-only commands", name)
+	}
+	if subcommands, ok := readOnlyRedisSubcommands[name]; ok {
+		if len(args) < 2 || !subcommands[strings.ToUpper(args[1])] {
+			return fmt.Errorf("command %s is only allowed in the console with a read-only subcommand", name)
+		}
+	}
+	return nil
+}
+
+// isRedisNoPasswordError reports whether AUTH failed because the server has no password
+func isRedisNoPasswordError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "without any password configured") || strings.Contains(message, "no password is set")
+}
+
+// sendRedisCommand writes a RESP array and reads a single reply
+func sendRedisCommand(conn net.Conn, reader *bufio.Reader, args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(conn, b.String()); err != nil {
+		return nil, fmt.Errorf("failed to send command: %v", err)
+	}
+	return readRedisReply(reader)
+}
+
+// readRedisReply parses one RESP reply
+func readRedisReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %v", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply from redis")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length: %v", err)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, fmt.Errorf("failed to read reply: %v", err)
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length: %v", err)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRedisReply(reader)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported reply type %q", line[0])
+	}
+}
+
+// splitConsoleCommand splits a redis-cli style command line, honouring quotes
+func splitConsoleCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in command")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+	return args, nil
+}
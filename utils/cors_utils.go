@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// fallbackCORSOrigin is allowed when no CORS_ALLOWED is configured (local dashboard)
+const fallbackCORSOrigin = "http://localhost:5173"
+
+var corsOrigins = struct {
+	mu      sync.RWMutex
+	origins []string
+}{}
+
+// SplitList splits a comma-separated setting into its trimmed, non-empty entries
+func SplitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// GetDefaultCORSOrigins returns the deployment's CORS_ALLOWED origins
+func GetDefaultCORSOrigins() []string {
+	origins := SplitList(os.Getenv("CORS_ALLOWED"))
+	if len(origins) == 0 {
+		return []string{fallbackCORSOrigin}
+	}
+	return origins
+}
+
+// SetCORSOrigins changes the origins the dashboard may call the API from; nil restores
+// the default
+func SetCORSOrigins(origins []string) {
+	corsOrigins.mu.Lock()
+	defer corsOrigins.mu.Unlock()
+	corsOrigins.origins = origins
+}
+
+// GetCORSOrigins returns the origins the dashboard may call the API from, the first being
+// the one links to the dashboard are built with
+func GetCORSOrigins() []string {
+	corsOrigins.mu.RLock()
+	origins := corsOrigins.origins
+	corsOrigins.mu.RUnlock()
+	if len(origins) > 0 {
+		return origins
+	}
+	return GetDefaultCORSOrigins()
+}
+
+// IsAllowedOrigin reports whether a browser request from origin may call the API
+func IsAllowedOrigin(origin string) bool {
+	origin = strings.TrimRight(origin, "/")
+	for _, allowed := range GetCORSOrigins() {
+		if allowed == "*" || strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCORSOrigin checks that origin is an http(s) scheme and host without a path
+func ValidateCORSOrigin(origin string) error {
+	parsed, err := url.Parse(origin)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%q is not an http(s) origin, e.g. https://dashboard.example.com", origin)
+	}
+	if strings.TrimRight(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+		return fmt.Errorf("%q must be a scheme and host only, without a path", origin)
+	}
+	return nil
+}
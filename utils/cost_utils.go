@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// bytesPerGiB converts memory and storage quantities for per-GB pricing
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// ResourceUsage is the summed live usage of a service's pods
+type ResourceUsage struct {
+	CPUCores float64
+	MemoryGB float64
+}
+
+// QuantityToCores converts a CPU quantity such as 500m to cores; invalid values count as zero
+func QuantityToCores(quantity string) float64 {
+	parsed, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return 0
+	}
+	return float64(parsed.MilliValue()) / 1000
+}
+
+// QuantityToGB converts a memory or storage quantity such as 512Mi to GiB; invalid values count as zero
+func QuantityToGB(quantity string) float64 {
+	parsed, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return 0
+	}
+	return float64(parsed.Value()) / bytesPerGiB
+}
+
+// GetNamespaceUsageByApp sums the metrics-server usage of the pods in a namespace by their
+// app label, which is the resource name of the service that owns them
+func GetNamespaceUsageByApp(namespace string) (map[string]ResourceUsage, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	if k8sClient.MetricsClient == nil {
+		return nil, fmt.Errorf("metrics API is not available")
+	}
+
+	ctx := context.Background()
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	appByPod := make(map[string]string, len(pods.Items))
+	for _, pod := range pods.Items {
+		appByPod[pod.Name] = pod.Labels["app"]
+	}
+
+	podMetrics, err := k8sClient.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %v", err)
+	}
+
+	usage := make(map[string]ResourceUsage)
+	for _, metrics := range podMetrics.Items {
+		app, ok := appByPod[metrics.Name]
+		if !ok {
+			continue
+		}
+		total := usage[app]
+		for _, container := range metrics.Containers {
+			total.CPUCores += float64(container.Usage.Cpu().MilliValue()) / 1000
+			total.MemoryGB += float64(container.Usage.Memory().Value()) / bytesPerGiB
+		}
+		usage[app] = total
+	}
+	return usage, nil
+}
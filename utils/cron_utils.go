@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds how far Next/Prev search for a matching minute; eight years cover
+// schedules firing on February 29 only, even across a century that is not a leap year
+const cronSearchLimit = 8 * 366 * 24 * time.Hour
+
+// cronMonthDays is the longest length of each month
+var cronMonthDays = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// CronSchedule is a parsed standard 5-field cron expression
+// (minute hour day-of-month month day-of-week)
+type CronSchedule struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// Cron semantics: when both day fields are restricted, either may match
+	anyDay     bool
+	anyWeekday bool
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a 5-field cron expression. Supports *, lists, ranges, steps
+// and three-letter month/weekday names.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	schedule := &CronSchedule{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	if err := parseCronField(fields[0], 0, 59, nil, schedule.minutes[:]); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %v", err)
+	}
+	if err := parseCronField(fields[1], 0, 23, nil, schedule.hours[:]); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %v", err)
+	}
+	if err := parseCronField(fields[2], 1, 31, nil, schedule.days[:]); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %v", err)
+	}
+	if err := parseCronField(fields[3], 1, 12, cronMonthNames, schedule.months[:]); err != nil {
+		return nil, fmt.Errorf("invalid month field: %v", err)
+	}
+
+	// Day-of-week accepts 7 as an alias for Sunday
+	var weekdays [8]bool
+	if err := parseCronField(fields[4], 0, 7, cronWeekdayNames, weekdays[:]); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %v", err)
+	}
+	copy(schedule.weekdays[:], weekdays[:7])
+	if weekdays[7] {
+		schedule.weekdays[0] = true
+	}
+
+	// e.g. 0 0 31 2 *, which would be searched for on every check
+	if !schedule.anyDay && schedule.anyWeekday && !schedule.hasDayInMonths() {
+		return nil, fmt.Errorf("cron expression %q never fires: none of its months has the days it selects", expr)
+	}
+
+	return schedule, nil
+}
+
+// hasDayInMonths reports whether a selected day of the month exists in a selected month
+func (s *CronSchedule) hasDayInMonths() bool {
+	for month := 1; month <= 12; month++ {
+		if !s.months[month] {
+			continue
+		}
+		for day := 1; day <= cronMonthDays[month]; day++ {
+			if s.days[day] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseCronField fills the allowed values of a single field
+func parseCronField(field string, min, max int, names map[string]int, allowed []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:idx]
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], names); err != nil {
+				return err
+			}
+			if end, err = parseCronValue(bounds[1], names); err != nil {
+				return err
+			}
+		default:
+			value, err := parseCronValue(part, names)
+			if err != nil {
+				return err
+			}
+			start = value
+			// "5/15" means starting at 5 through the end of the range
+			if step == 1 {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return fmt.Errorf("value out of range in %q (allowed %d-%d)", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			allowed[v] = true
+		}
+	}
+	return nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return n, nil
+}
+
+// Matches reports whether the schedule fires at t (to the minute)
+func (s *CronSchedule) Matches(t time.Time) bool {
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.months[int(t.Month())] && s.matchesDay(t)
+}
+
+// matchesDay reports whether the schedule fires on the day of t
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	dayMatch := s.days[t.Day()]
+	weekdayMatch := s.weekdays[int(t.Weekday())]
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekdayMatch
+	case s.anyWeekday:
+		return dayMatch
+	default:
+		return dayMatch || weekdayMatch
+	}
+}
+
+// Next returns the first time strictly after t at which the schedule fires, or the zero
+// time if there is none within cronSearchLimit. Months, days and hours that do not match
+// are skipped whole.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	candidate := t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(cronSearchLimit); candidate.Before(limit); {
+		year, month, day := candidate.Date()
+		location := candidate.Location()
+		var next time.Time
+		switch {
+		case !s.months[int(month)]:
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, location)
+		case !s.matchesDay(candidate):
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+		case !s.hours[candidate.Hour()]:
+			next = time.Date(year, month, day, candidate.Hour()+1, 0, 0, 0, location)
+		case !s.minutes[candidate.Minute()]:
+			next = candidate.Add(time.Minute)
+		default:
+			return candidate
+		}
+		// A daylight saving transition can put the boundary behind the candidate
+		if !next.After(candidate) {
+			next = candidate.Add(time.Minute)
+		}
+		candidate = next
+	}
+	return time.Time{}
+}
+
+// Prev returns the latest time at or before t at which the schedule fired, or the zero
+// time if there is none within cronSearchLimit
+func (s *CronSchedule) Prev(t time.Time) time.Time {
+	candidate := t.Truncate(time.Minute)
+	for limit := t.Add(-cronSearchLimit); candidate.After(limit); {
+		year, month, day := candidate.Date()
+		location := candidate.Location()
+		var previous time.Time
+		switch {
+		case !s.months[int(month)]:
+			previous = time.Date(year, month, 1, 0, 0, 0, 0, location).Add(-time.Minute)
+		case !s.matchesDay(candidate):
+			previous = time.Date(year, month, day, 0, 0, 0, 0, location).Add(-time.Minute)
+		case !s.hours[candidate.Hour()]:
+			previous = time.Date(year, month, day, candidate.Hour(), 0, 0, 0, location).Add(-time.Minute)
+		case !s.minutes[candidate.Minute()]:
+			previous = candidate.Add(-time.Minute)
+		default:
+			return candidate
+		}
+		if !previous.Before(candidate) {
+			previous = candidate.Add(-time.Minute)
+		}
+		candidate = previous
+	}
+	return time.Time{}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetCustomTLSSecretName returns the name of the Secret holding an uploaded certificate
+func GetCustomTLSSecretName(service models.Service) string {
+	return GetResourceName(service) + "-custom-tls"
+}
+
+// getCustomDomainIngressName returns the name of the Ingress serving the custom domain
+// with an uploaded certificate (kept apart so cert-manager does not manage its Secret)
+func getCustomDomainIngressName(service models.Service) string {
+	return GetResourceName(service) + "-custom"
+}
+
+// ParseCertificateBundle validates a PEM certificate chain and private key for domain and
+// returns the certificate metadata to track. The key must match the leaf certificate,
+// which must cover domain and must not be expired.
+func ParseCertificateBundle(certPEM, keyPEM, domain string) (models.CustomCertificate, error) {
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return models.CustomCertificate{}, fmt.Errorf("invalid certificate or key: %v", err)
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return models.CustomCertificate{}, fmt.Errorf("invalid certificate: %v", err)
+	}
+	if err := leaf.VerifyHostname(domain); err != nil {
+		return models.CustomCertificate{}, fmt.Errorf("certificate does not cover %s: %v", domain, err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return models.CustomCertificate{}, fmt.Errorf("certificate expired on %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	return models.CustomCertificate{
+		Domain:      domain,
+		Subject:     leaf.Subject.String(),
+		Issuer:      leaf.Issuer.String(),
+		DNSNames:    strings.Join(leaf.DNSNames, ","),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   leaf.NotBefore,
+		NotAfter:    leaf.NotAfter,
+	}, nil
+}
+
+// ApplyCustomTLSSecret stores an uploaded certificate and key as a TLS Secret in the
+// service's namespace, owned by the service so it is removed together with it
+func ApplyCustomTLSSecret(service models.Service, certPEM, keyPEM string) (string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := EnsureNamespaceExists(service.EnvironmentID); err != nil {
+		return "", fmt.Errorf("failed to ensure namespace: %v", err)
+	}
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return "", err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetCustomTLSSecretName(service),
+			Namespace: service.EnvironmentID,
+			Labels:    GetResourceLabels(service),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte(certPEM),
+			corev1.TLSPrivateKeyKey: []byte(keyPEM),
+		},
+	}
+	setServiceOwner(secret, owner)
+
+	secrets := k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID)
+	_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to store TLS secret: %v", err)
+	}
+	return secret.Name, nil
+}
+
+// DeleteCustomTLSSecret removes the Secret holding an uploaded certificate
+func DeleteCustomTLSSecret(service models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Delete(context.Background(), GetCustomTLSSecretName(service), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete TLS secret: %v", err)
+	}
+	return nil
+}
+
+// ApplyServiceIngress re-applies the ingresses of a deployed git service, e.g. after its
+// certificate changed. Services that were never deployed are left alone.
+func ApplyServiceIngress(service models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = k8sClient.Clientset.AppsV1().Deployments(service.EnvironmentID).Get(ctx, GetResourceName(service), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return err
+	}
+	return deployIngress(ctx, k8sClient, service, owner)
+}
+
+// createCustomDomainIngressSpec builds the Ingress serving the custom domain with the
+// uploaded certificate
+func createCustomDomainIngressSpec(service models.Service) *networkingv1.Ingress {
+	ingress := createIngressSpecForHosts(service, getCustomDomainIngressName(service), []string{service.CustomDomain}, service.CustomTLSSecret)
+	// The Secret is user-managed: no cert-manager issuer, or it would overwrite it
+	delete(ingress.Annotations, "cert-manager.io/cluster-issuer")
+	return ingress
+}
+
+// deployCustomDomainIngress serves the custom domain with the uploaded certificate, or
+// removes that Ingress when the service has none
+func deployCustomDomainIngress(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	name := getCustomDomainIngressName(service)
+	if service.CustomDomain == "" || service.CustomTLSSecret == "" {
+		err := client.Clientset.NetworkingV1().Ingresses(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return deleteGatewayRouting(ctx, client, service.EnvironmentID, name)
+	}
+
+	ingress := createCustomDomainIngressSpec(service)
+	setServiceOwner(ingress, owner)
+	addErrorPagesMiddleware(ctx, client, ingress)
+	log.Printf("Serving %s with uploaded certificate %s", service.CustomDomain, service.CustomTLSSecret)
+	return applyIngress(ctx, client, ingress)
+}
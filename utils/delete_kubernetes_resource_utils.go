@@ -10,6 +10,8 @@ import (
 	"github.com/pendeploy-simple/models"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 // DeleteKubernetesResources deletes all Kubernetes resources for the service with NodePort managed service support
@@ -24,9 +26,29 @@ func DeleteKubernetesResources(service models.Service) error {
 	// Create context for the operations
 	ctx := context.Background()
 
-	// Delete resources in reverse order for proper cleanup
-	// Order: HPA -> All Ingresses -> All Services -> Deployment/StatefulSet -> PVCs
+	// Deleting the parent ConfigMap lets Kubernetes GC cascade to every owned resource
+	if err := deleteServiceOwner(ctx, k8sClient, service); err != nil {
+		return fmt.Errorf("failed to delete owner ConfigMap: %v", err)
+	}
+
+	// Resources created before owner references existed are found by ownership label
+	if err := deleteResourcesBySelector(ctx, k8sClient, service.EnvironmentID, ServiceOwnerSelector(service.ID)); err != nil {
+		return fmt.Errorf("failed to delete labeled resources: %v", err)
+	}
+
+	// ...and ones older than the labels by name
+	if err := deleteLegacyResources(ctx, k8sClient, service); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully deleted all resources for service: %s", service.Name)
+	return nil
+}
 
+// deleteLegacyResources removes resources that carry neither owner references nor
+// ownership labels by reconstructing their names
+// Order: HPA -> All Ingresses -> All Services -> Deployment/StatefulSet -> PVCs
+func deleteLegacyResources(ctx context.Context, k8sClient *kubernetes.Client, service models.Service) error {
 	// Delete HPA if exists (both git and managed services may have HPA)
 	if err := deleteHPAData(ctx, k8sClient, service); err != nil {
 		log.Printf("Warning: Failed to delete HPA: %v", err)
@@ -42,27 +64,117 @@ func DeleteKubernetesResources(service models.Service) error {
 		return fmt.Errorf("failed to delete Services: %v", err)
 	}
 
-	// Delete workload based on service type
-	if service.Type == models.ServiceTypeManaged {
-		if err := deleteManagedServiceWorkload(ctx, k8sClient, service); err != nil {
-			return fmt.Errorf("failed to delete managed service workload: %v", err)
-		}
-		
-		// Delete all PVCs for managed services
-		if err := deleteAllManagedServicePVCs(ctx, k8sClient, service); err != nil {
-			log.Printf("Warning: Failed to delete all PVCs: %v", err)
-		}
-	} else {
+	if service.Type != models.ServiceTypeManaged {
 		// For git services, delete Deployment (original behavior)
 		if err := deleteDeployment(ctx, k8sClient, service); err != nil {
 			return fmt.Errorf("failed to delete Deployment: %v", err)
 		}
+		return nil
 	}
 
-	log.Printf("Successfully deleted all resources for service: %s", service.Name)
+	switch service.ManagedType {
+	case "postgresql":
+		if err := deletePgBouncer(ctx, k8sClient, service); err != nil {
+			log.Printf("Warning: Failed to delete PgBouncer: %v", err)
+		}
+	case "rabbitmq":
+		if err := deleteRabbitMQUserSecrets(ctx, k8sClient, service); err != nil {
+			log.Printf("Warning: Failed to delete RabbitMQ user secrets: %v", err)
+		}
+	case "minio":
+		if err := deleteMinIOBucketSecrets(ctx, k8sClient, service); err != nil {
+			log.Printf("Warning: Failed to delete MinIO bucket secrets: %v", err)
+		}
+	}
+
+	if err := deleteManagedServiceWorkload(ctx, k8sClient, service); err != nil {
+		return fmt.Errorf("failed to delete managed service workload: %v", err)
+	}
+
+	// Delete all PVCs for managed services
+	if err := deleteAllManagedServicePVCs(ctx, k8sClient, service); err != nil {
+		log.Printf("Warning: Failed to delete all PVCs: %v", err)
+	}
 	return nil
 }
 
+// deleteResourcesBySelector deletes every service-owned resource kind matching the label selector
+func deleteResourcesBySelector(ctx context.Context, k8sClient *kubernetes.Client, namespace string, selector string) error {
+	listOptions := metav1.ListOptions{LabelSelector: selector}
+	background := metav1.DeletePropagationBackground
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &background}
+
+	var deletionErrors []string
+
+	// Owner ConfigMaps first, so the garbage collector starts on their dependents
+	if err := k8sClient.Clientset.CoreV1().ConfigMaps(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("ConfigMaps: %v", err))
+	}
+	if err := k8sClient.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("HPAs: %v", err))
+	}
+	if err := k8sClient.Clientset.NetworkingV1().Ingresses(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("Ingresses: %v", err))
+	}
+
+	// Services are listed and deleted one by one; not every cluster supports DeleteCollection for them
+	services, err := k8sClient.Clientset.CoreV1().Services(namespace).List(ctx, listOptions)
+	if err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("Services: %v", err))
+	} else {
+		for _, svc := range services.Items {
+			err := k8sClient.Clientset.CoreV1().Services(namespace).Delete(ctx, svc.Name, deleteOptions)
+			if err != nil && !errors.IsNotFound(err) {
+				deletionErrors = append(deletionErrors, fmt.Sprintf("Service %s: %v", svc.Name, err))
+			} else if err == nil {
+				log.Printf("Service %s deleted successfully", svc.Name)
+			}
+		}
+	}
+
+	if err := k8sClient.Clientset.AppsV1().Deployments(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("Deployments: %v", err))
+	}
+	if err := k8sClient.Clientset.AppsV1().StatefulSets(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("StatefulSets: %v", err))
+	}
+	// Build and client jobs; background propagation removes their pods too
+	if err := k8sClient.Clientset.BatchV1().Jobs(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("Jobs: %v", err))
+	}
+	if err := k8sClient.Clientset.CoreV1().Secrets(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("Secrets: %v", err))
+	}
+	if err := k8sClient.Clientset.CoreV1().ServiceAccounts(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("ServiceAccounts: %v", err))
+	}
+	// StatefulSet PVCs inherit the labels of the volume claim template
+	if err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).DeleteCollection(ctx, deleteOptions, listOptions); err != nil {
+		deletionErrors = append(deletionErrors, fmt.Sprintf("PVCs: %v", err))
+	}
+
+	if len(deletionErrors) > 0 {
+		return fmt.Errorf("some resources failed to delete: %v", deletionErrors)
+	}
+
+	log.Printf("Deleted resources matching %q in namespace %s", selector, namespace)
+	return nil
+}
+
+// orphanSelector matches resources whose ownership label names none of the active services
+func orphanSelector(activeServiceIDs []string) (string, error) {
+	operator := selection.NotIn
+	if len(activeServiceIDs) == 0 {
+		operator = selection.Exists
+		activeServiceIDs = nil
+	}
+	requirement, err := labels.NewRequirement(LabelServiceID, operator, activeServiceIDs)
+	if err != nil {
+		return "", err
+	}
+	return labels.NewSelector().Add(*requirement).String(), nil
+}
+
 // deleteHPA deletes HorizontalPodAutoscaler
 func deleteHPAData(ctx context.Context, k8sClient *kubernetes.Client, service models.Service) error {
 	resourceName := GetResourceName(service)
@@ -99,6 +211,9 @@ func deleteAllIngresses(ctx context.Context, k8sClient *kubernetes.Client, servi
 				} else if err == nil {
 					log.Printf("HTTP Ingress %s deleted successfully", ingressName)
 				}
+				if err := deleteGatewayRouting(ctx, k8sClient, service.EnvironmentID, ingressName); err != nil {
+					log.Printf("Warning: Failed to delete Gateway API routes %s: %v", ingressName, err)
+				}
 			}
 		}
 	} else {
@@ -110,6 +225,9 @@ func deleteAllIngresses(ctx context.Context, k8sClient *kubernetes.Client, servi
 		if err == nil {
 			log.Printf("Ingress %s deleted successfully", resourceName)
 		}
+		if err := deleteGatewayRouting(ctx, k8sClient, service.EnvironmentID, resourceName); err != nil {
+			return err
+		}
 	}
 	
 	return nil
@@ -351,7 +469,22 @@ func CleanupOrphanedResources(environmentID string, activeServices []models.Serv
 	
 	ctx := context.Background()
 	
-	// Build list of expected resource names
+	log.Printf("Starting orphaned resource cleanup in namespace: %s", environmentID)
+	
+	// Labeled resources are orphaned when their owning service is no longer active
+	activeServiceIDs := make([]string, 0, len(activeServices))
+	for _, service := range activeServices {
+		activeServiceIDs = append(activeServiceIDs, service.ID)
+	}
+	selector, err := orphanSelector(activeServiceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to build orphan selector: %v", err)
+	}
+	if err := deleteResourcesBySelector(ctx, k8sClient, environmentID, selector); err != nil {
+		log.Printf("Warning: Failed to cleanup orphaned labeled resources: %v", err)
+	}
+	
+	// Resources created before ownership labels existed are matched by expected name
 	expectedResourceNames := make(map[string]bool)
 	for _, service := range activeServices {
 		resourceName := GetResourceName(service)
@@ -366,26 +499,28 @@ func CleanupOrphanedResources(environmentID string, activeServices []models.Serv
 					expectedResourceNames[secondaryName] = true
 				}
 			}
+			if IsPoolingEnabled(service) {
+				expectedResourceNames[GetPgBouncerResourceName(service)] = true
+			}
 		}
 	}
 	
-	log.Printf("Starting orphaned resource cleanup in namespace: %s", environmentID)
-	log.Printf("Expected resources: %v", expectedResourceNames)
+	// Only consider resources this platform created, never foreign ones in the namespace
+	legacy := metav1.ListOptions{LabelSelector: "managed-by=pendeploy,!" + LabelServiceID}
 	
-	// Find and delete orphaned resources
-	if err := cleanupOrphanedIngresses(ctx, k8sClient, environmentID, expectedResourceNames); err != nil {
+	if err := cleanupOrphanedIngresses(ctx, k8sClient, environmentID, legacy, expectedResourceNames); err != nil {
 		log.Printf("Warning: Failed to cleanup orphaned Ingresses: %v", err)
 	}
 	
-	if err := cleanupOrphanedServices(ctx, k8sClient, environmentID, expectedResourceNames); err != nil {
+	if err := cleanupOrphanedServices(ctx, k8sClient, environmentID, legacy, expectedResourceNames); err != nil {
 		log.Printf("Warning: Failed to cleanup orphaned Services: %v", err)
 	}
 	
-	if err := cleanupOrphanedWorkloads(ctx, k8sClient, environmentID, expectedResourceNames); err != nil {
+	if err := cleanupOrphanedWorkloads(ctx, k8sClient, environmentID, legacy, expectedResourceNames); err != nil {
 		log.Printf("Warning: Failed to cleanup orphaned Workloads: %v", err)
 	}
 	
-	if err := cleanupOrphanedPVCs(ctx, k8sClient, environmentID, expectedResourceNames); err != nil {
+	if err := cleanupOrphanedPVCs(ctx, k8sClient, environmentID, legacy, expectedResourceNames); err != nil {
 		log.Printf("Warning: Failed to cleanup orphaned PVCs: %v", err)
 	}
 	
@@ -393,8 +528,8 @@ func CleanupOrphanedResources(environmentID string, activeServices []models.Serv
 	return nil
 }
 
-func cleanupOrphanedIngresses(ctx context.Context, k8sClient *kubernetes.Client, namespace string, expected map[string]bool) error {
-	ingresses, err := k8sClient.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+func cleanupOrphanedIngresses(ctx context.Context, k8sClient *kubernetes.Client, namespace string, listOptions metav1.ListOptions, expected map[string]bool) error {
+	ingresses, err := k8sClient.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, listOptions)
 	if err != nil {
 		return err
 	}
@@ -412,8 +547,8 @@ func cleanupOrphanedIngresses(ctx context.Context, k8sClient *kubernetes.Client,
 	return nil
 }
 
-func cleanupOrphanedServices(ctx context.Context, k8sClient *kubernetes.Client, namespace string, expected map[string]bool) error {
-	services, err := k8sClient.Clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+func cleanupOrphanedServices(ctx context.Context, k8sClient *kubernetes.Client, namespace string, listOptions metav1.ListOptions, expected map[string]bool) error {
+	services, err := k8sClient.Clientset.CoreV1().Services(namespace).List(ctx, listOptions)
 	if err != nil {
 		return err
 	}
@@ -436,9 +571,9 @@ func cleanupOrphanedServices(ctx context.Context, k8sClient *kubernetes.Client,
 	return nil
 }
 
-func cleanupOrphanedWorkloads(ctx context.Context, k8sClient *kubernetes.Client, namespace string, expected map[string]bool) error {
+func cleanupOrphanedWorkloads(ctx context.Context, k8sClient *kubernetes.Client, namespace string, listOptions metav1.ListOptions, expected map[string]bool) error {
 	// Check StatefulSets
-	statefulSets, err := k8sClient.Clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	statefulSets, err := k8sClient.Clientset.AppsV1().StatefulSets(namespace).List(ctx, listOptions)
 	if err != nil {
 		return err
 	}
@@ -454,7 +589,7 @@ func cleanupOrphanedWorkloads(ctx context.Context, k8sClient *kubernetes.Client,
 	}
 	
 	// Check Deployments
-	deployments, err := k8sClient.Clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	deployments, err := k8sClient.Clientset.AppsV1().Deployments(namespace).List(ctx, listOptions)
 	if err != nil {
 		return err
 	}
@@ -472,8 +607,8 @@ func cleanupOrphanedWorkloads(ctx context.Context, k8sClient *kubernetes.Client,
 	return nil
 }
 
-func cleanupOrphanedPVCs(ctx context.Context, k8sClient *kubernetes.Client, namespace string, expected map[string]bool) error {
-	pvcs, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+func cleanupOrphanedPVCs(ctx context.Context, k8sClient *kubernetes.Client, namespace string, listOptions metav1.ListOptions, expected map[string]bool) error {
+	pvcs, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	domainLookupTimeout = 5 * time.Second
+	domainProbeTimeout  = 10 * time.Second
+)
+
+// GetServiceHostnames lists every hostname a git service is served on: its generated
+// domain and its custom domain, whichever Ingress serves it
+func GetServiceHostnames(service models.Service) []string {
+	hostnames := buildHostnames(service)
+	if service.CustomDomain != "" && service.CustomTLSSecret != "" {
+		hostnames = append(hostnames, service.CustomDomain)
+	}
+	return hostnames
+}
+
+// ingressPublicAddresses holds the public addresses saved in the platform settings
+var ingressPublicAddresses = struct {
+	mu        sync.RWMutex
+	addresses []string
+}{}
+
+// SetIngressPublicAddresses changes the addresses hostnames must resolve to for clusters
+// behind NAT; nil restores INGRESS_PUBLIC_ADDRESSES
+func SetIngressPublicAddresses(addresses []string) {
+	ingressPublicAddresses.mu.Lock()
+	defer ingressPublicAddresses.mu.Unlock()
+	ingressPublicAddresses.addresses = addresses
+}
+
+// GetIngressPublicAddresses returns the configured public addresses of the ingress, none
+// when the load balancer's are used
+func GetIngressPublicAddresses() []string {
+	ingressPublicAddresses.mu.RLock()
+	addresses := ingressPublicAddresses.addresses
+	ingressPublicAddresses.mu.RUnlock()
+	if len(addresses) > 0 {
+		return addresses
+	}
+	return SplitList(os.Getenv("INGRESS_PUBLIC_ADDRESSES"))
+}
+
+// GetIngressAddresses returns the addresses the service's hostnames must resolve to:
+// the public addresses of the platform settings or INGRESS_PUBLIC_ADDRESSES (comma-separated)
+// when set, for clusters behind NAT, otherwise
+// the load balancer addresses reported on its Ingress, or Gateway under the gateway provider.
+// Hostnames among them are resolved. Empty when none are known yet.
+func GetIngressAddresses(service models.Service) ([]string, error) {
+	reported := append([]string(nil), GetIngressPublicAddresses()...)
+
+	if len(reported) == 0 {
+		k8sClient, err := kubernetes.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+		}
+		ctx := context.Background()
+		name := GetResourceName(service)
+
+		if GetIngressProvider().Name() == IngressProviderGateway {
+			gateway, err := k8sClient.DynamicClient.Resource(gatewayGVR).Namespace(service.EnvironmentID).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Gateway %s: %v", name, err)
+			}
+			addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+			for _, item := range addresses {
+				if address, ok := item.(map[string]interface{}); ok {
+					if value, _ := address["value"].(string); value != "" {
+						reported = append(reported, value)
+					}
+				}
+			}
+		} else {
+			ingress, err := k8sClient.Clientset.NetworkingV1().Ingresses(service.EnvironmentID).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Ingress %s: %v", name, err)
+			}
+			for _, address := range ingress.Status.LoadBalancer.Ingress {
+				if address.IP != "" {
+					reported = append(reported, address.IP)
+				} else if address.Hostname != "" {
+					reported = append(reported, address.Hostname)
+				}
+			}
+		}
+	}
+
+	// Cloud load balancers report a hostname rather than an IP
+	var addresses []string
+	for _, address := range reported {
+		if net.ParseIP(address) != nil {
+			addresses = append(addresses, address)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), domainLookupTimeout)
+		resolved, err := net.DefaultResolver.LookupHost(ctx, address)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the ingress address %s: %v", address, err)
+		}
+		addresses = append(addresses, resolved...)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// CheckDomainPropagation resolves a hostname from inside the cluster, compares the result
+// with the ingress addresses (skipped when they are unknown), and requests it over HTTPS.
+// Any HTTP response counts as reachable: the hostname is routed, whatever the app answers.
+func CheckDomainPropagation(hostname string, ingressAddresses []string) models.DomainStatus {
+	status := models.DomainStatus{
+		Hostname:         hostname,
+		IngressAddresses: strings.Join(ingressAddresses, ","),
+		CheckedAt:        time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), domainLookupTimeout)
+	resolved, err := net.DefaultResolver.LookupHost(ctx, hostname)
+	cancel()
+	if err != nil {
+		status.Status = models.DomainPending
+		status.Error = fmt.Sprintf("%s does not resolve yet: %v", hostname, err)
+		return status
+	}
+	sort.Strings(resolved)
+	status.ResolvedAddresses = strings.Join(resolved, ",")
+
+	if len(ingressAddresses) > 0 && !sharesAddress(resolved, ingressAddresses) {
+		status.Status = models.DomainMisdirected
+		status.Error = fmt.Sprintf("%s resolves to %s, not to the ingress (%s)", hostname, status.ResolvedAddresses, status.IngressAddresses)
+		return status
+	}
+
+	status.HTTPStatusCode, err = probeDomain(hostname, false)
+	var verifyErr *tls.CertificateVerificationError
+	switch {
+	case err == nil:
+		status.Status = models.DomainReachable
+	case errors.As(err, &verifyErr):
+		// Tell a certificate still being issued apart from an ingress that does not answer
+		status.Status = models.DomainUnreachable
+		if code, insecureErr := probeDomain(hostname, true); insecureErr == nil {
+			status.Status = models.DomainTLSPending
+			status.HTTPStatusCode = code
+		}
+		status.Error = fmt.Sprintf("the certificate of %s is not valid yet: %v", hostname, verifyErr.Err)
+	default:
+		status.Status = models.DomainUnreachable
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// sharesAddress reports whether the two address lists have an address in common
+func sharesAddress(resolved []string, expected []string) bool {
+	for _, address := range resolved {
+		for _, candidate := range expected {
+			if net.ParseIP(address).Equal(net.ParseIP(candidate)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probeDomain requests the root of the hostname over HTTPS without following redirects and
+// returns the response status
+func probeDomain(hostname string, insecure bool) (int, error) {
+	client := &http.Client{
+		Timeout: domainProbeTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(fmt.Sprintf("https://%s/", hostname))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode, nil
+}
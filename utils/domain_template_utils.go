@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// domainTemplatePlaceholder matches a {placeholder} of an environment's domain template
+var domainTemplatePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// Placeholders of environment domain templates, e.g. {service}.{env}.example.com
+const (
+	DomainPlaceholderService = "{service}" // service name
+	DomainPlaceholderRepo    = "{repo}"    // repository name of the service's git URL
+	DomainPlaceholderBranch  = "{branch}"  // deployed branch, main when unset
+	DomainPlaceholderEnv     = "{env}"     // environment name
+	DomainPlaceholderEnvID   = "{envId}"   // first 6 characters of the environment ID
+	DomainPlaceholderBase    = "{base}"    // the project's base domain, or the platform default
+)
+
+var domainTemplatePlaceholders = map[string]bool{
+	DomainPlaceholderService: true,
+	DomainPlaceholderRepo:    true,
+	DomainPlaceholderBranch:  true,
+	DomainPlaceholderEnv:     true,
+	DomainPlaceholderEnvID:   true,
+	DomainPlaceholderBase:    true,
+}
+
+// CheckDomainTemplate validates an environment's domain template: known placeholders, one
+// that tells the environment's services apart, and a valid hostname once filled in
+func (e *FieldErrors) CheckDomainTemplate(field string, template string) {
+	if template == "" {
+		return
+	}
+
+	valid := true
+	for _, placeholder := range domainTemplatePlaceholder.FindAllString(template, -1) {
+		if !domainTemplatePlaceholders[placeholder] {
+			e.Add(field, "unknown placeholder %s; use {service}, {repo}, {branch}, {env}, {envId} or {base}", placeholder)
+			valid = false
+		}
+	}
+	if !strings.Contains(template, DomainPlaceholderService) && !strings.Contains(template, DomainPlaceholderRepo) {
+		e.Add(field, "must contain {service} or {repo} so the services of the environment get different hostnames")
+		valid = false
+	}
+	if !valid {
+		return
+	}
+
+	sample := strings.NewReplacer(
+		DomainPlaceholderService, "web",
+		DomainPlaceholderRepo, "app",
+		DomainPlaceholderBranch, "main",
+		DomainPlaceholderEnv, "staging",
+		DomainPlaceholderEnvID, "a1b2c3",
+		DomainPlaceholderBase, GetDefaultDomain(),
+	).Replace(template)
+	e.CheckHostname(field, sample)
+}
+
+// ExpandEnvironmentDomainTemplate fills the environment placeholders of an environment's
+// domain template; the hostnames of its services are generated from the result
+func ExpandEnvironmentDomainTemplate(env models.Environment) string {
+	if env.DomainTemplate == "" {
+		return ""
+	}
+	shortEnvID := env.ID
+	if len(shortEnvID) > 6 {
+		shortEnvID = shortEnvID[:6]
+	}
+	return strings.NewReplacer(
+		DomainPlaceholderEnv, domainLabel(env.Name, "env"),
+		DomainPlaceholderEnvID, shortEnvID,
+	).Replace(env.DomainTemplate)
+}
+
+// renderDomainTemplate generates a service's hostname from its environment's domain template
+func renderDomainTemplate(service models.Service) string {
+	hostname := strings.NewReplacer(
+		DomainPlaceholderService, domainLabel(service.Name, "app"),
+		DomainPlaceholderRepo, domainLabel(extractRepoNameFromURL(service.RepoURL), "app"),
+		DomainPlaceholderBranch, domainLabel(service.Branch, "main"),
+		DomainPlaceholderBase, GetServiceBaseDomain(service),
+	).Replace(service.DomainTemplate)
+
+	// Placeholders combined in one label may exceed the DNS limit together
+	labels := strings.Split(strings.ToLower(hostname), ".")
+	for i, label := range labels {
+		if len(label) > 63 {
+			labels[i] = strings.TrimRight(label[:63], "-")
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// domainLabel turns a name into a DNS label, or fallback when nothing valid is left
+func domainLabel(value string, fallback string) string {
+	label := strings.Trim(aliasInvalidChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	if label == "" {
+		return fallback
+	}
+	return label
+}
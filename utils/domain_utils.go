@@ -1,19 +1,199 @@
 package utils
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
 	"os"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
-const fallbackDefaultDomain = "app.isacitra.com"
+// fallbackDefaultDomain is used when no DEFAULT_DOMAIN is configured (local clusters)
+const fallbackDefaultDomain = "localhost"
 
-func GetDefaultDomain() string {
-	domain := strings.TrimSpace(os.Getenv("DEFAULT_DOMAIN"))
+// DefaultClusterIssuerName is the cert-manager ClusterIssuer that signs ingress certificates
+// until an admin selects another one
+const DefaultClusterIssuerName = "letsencrypt-prod"
+
+var clusterIssuerGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+
+// platformDomain holds the default domain and ClusterIssuer saved in the platform settings;
+// empty ones fall back to the deployment's
+var platformDomain = struct {
+	mu            sync.RWMutex
+	defaultDomain string
+	clusterIssuer string
+}{}
+
+// GetDeploymentDefaultDomain returns the deployment's DEFAULT_DOMAIN
+func GetDeploymentDefaultDomain() string {
+	domain := NormalizeDomain(os.Getenv("DEFAULT_DOMAIN"))
 	if domain == "" {
 		return fallbackDefaultDomain
 	}
+	return domain
+}
+
+// SetDefaultDomain changes the base domain of services whose project has not selected one;
+// "" restores the default. Hostnames saved on services keep their domain.
+func SetDefaultDomain(domain string) {
+	domain = NormalizeDomain(domain)
+	platformDomain.mu.Lock()
+	defer platformDomain.mu.Unlock()
+	if platformDomain.defaultDomain != domain {
+		log.Printf("Default domain set to %s", domain)
+	}
+	platformDomain.defaultDomain = domain
+}
+
+// GetDefaultDomain returns the base domain for services whose project has not selected one
+func GetDefaultDomain() string {
+	platformDomain.mu.RLock()
+	domain := platformDomain.defaultDomain
+	platformDomain.mu.RUnlock()
+	if domain != "" {
+		return domain
+	}
+	return GetDeploymentDefaultDomain()
+}
+
+// SetClusterIssuer changes the ClusterIssuer that signs ingress certificates; "" restores
+// the default. Ingresses pick it up when they are next applied.
+func SetClusterIssuer(name string) {
+	platformDomain.mu.Lock()
+	defer platformDomain.mu.Unlock()
+	if platformDomain.clusterIssuer != name {
+		log.Printf("Cluster issuer set to %s", name)
+	}
+	platformDomain.clusterIssuer = name
+}
+
+// GetClusterIssuer returns the cert-manager ClusterIssuer that signs ingress certificates
+func GetClusterIssuer() string {
+	platformDomain.mu.RLock()
+	name := platformDomain.clusterIssuer
+	platformDomain.mu.RUnlock()
+	if name != "" {
+		return name
+	}
+	return DefaultClusterIssuerName
+}
+
+// GetPlatformDomains lists the base wildcard domains projects may choose from: the
+// default domain followed by the comma-separated PLATFORM_DOMAINS
+func GetPlatformDomains() []string {
+	domains := []string{GetDefaultDomain()}
+	seen := map[string]bool{domains[0]: true}
+
+	for _, entry := range strings.Split(os.Getenv("PLATFORM_DOMAINS"), ",") {
+		domain := NormalizeDomain(entry)
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+}
 
+// IsPlatformDomain reports whether domain is one of the configured base domains
+func IsPlatformDomain(domain string) bool {
+	domain = NormalizeDomain(domain)
+	for _, candidate := range GetPlatformDomains() {
+		if candidate == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// GetServiceBaseDomain returns the base domain generated hostnames of a service live under
+func GetServiceBaseDomain(service models.Service) string {
+	if service.BaseDomain != "" {
+		return service.BaseDomain
+	}
+	return GetDefaultDomain()
+}
+
+// CheckBaseDomain verifies that a base domain can serve generated hostnames: a random
+// label under it must resolve (wildcard DNS) and the ClusterIssuer must be ready (TLS)
+func CheckBaseDomain(domain string) dto.DomainCheck {
+	check := dto.DomainCheck{Domain: domain, IsDefault: domain == GetDefaultDomain()}
+
+	probe := randomLabel() + "." + domain
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, probe)
+	if err != nil {
+		check.DNSError = fmt.Sprintf("%s does not resolve, add a wildcard record *.%s: %v", probe, domain, err)
+	} else {
+		check.WildcardDNS = true
+		check.ResolvedAddresses = addresses
+	}
+
+	if err := CheckClusterIssuerReady(GetClusterIssuer()); err != nil {
+		check.TLSError = err.Error()
+	} else {
+		check.TLSReady = true
+	}
+
+	check.Ready = check.WildcardDNS && check.TLSReady
+	return check
+}
+
+// CheckClusterIssuerReady returns an error unless the named ClusterIssuer reports Ready=True
+func CheckClusterIssuerReady(name string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	issuer, err := k8sClient.DynamicClient.Resource(clusterIssuerGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("ClusterIssuer %s not found: %v", name, err)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(issuer.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		if condition["status"] == "True" {
+			return nil
+		}
+		message, _ := condition["message"].(string)
+		return fmt.Errorf("ClusterIssuer %s is not ready: %s", name, message)
+	}
+	return fmt.Errorf("ClusterIssuer %s has not reported readiness", name)
+}
+
+// NormalizeDomain lowercases a domain and strips schemes, wildcards and trailing dots
+func NormalizeDomain(domain string) string {
+	domain = strings.TrimSpace(strings.ToLower(domain))
 	domain = strings.TrimPrefix(domain, "https://")
 	domain = strings.TrimPrefix(domain, "http://")
-	return strings.Trim(domain, "/")
+	domain = strings.TrimPrefix(domain, "*.")
+	return strings.Trim(domain, "/.")
+}
+
+func randomLabel() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "pendeploy-dns-check"
+	}
+	return "pendeploy-dns-check-" + hex.EncodeToString(buf)
 }
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// MaskedEnvValue replaces secret values in exports and diffs
+const MaskedEnvValue = models.MaskedSecretValue
+
+var (
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// sensitiveEnvKeyPattern matches variable names that conventionally hold secrets
+	sensitiveEnvKeyPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|PASS|SECRET|TOKEN|API_?KEY|PRIVATE|CREDENTIAL|AUTH|_KEY$|^KEY$)`)
+)
+
+// ParseDotEnv reads a .env file: KEY=VALUE lines with optional "export " prefixes, blank
+// lines and # comments. Double-quoted values support \n, \t, \" and \\ escapes and may span
+// lines; single-quoted values are literal; unquoted values end at an inline " #" comment.
+// Nothing is expanded, so ${secret:...} references are kept as written.
+func ParseDotEnv(content string) (models.EnvVars, error) {
+	envVars := models.EnvVars{}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+		if !envNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNumber, key)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// Collect continuation lines until the closing quote
+			raw := value[1:]
+			for !hasClosingQuote(raw) {
+				if i+1 >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated double-quoted value", lineNumber)
+				}
+				i++
+				raw += "\n" + lines[i]
+			}
+			unquoted, rest := unescapeDoubleQuoted(raw)
+			if err := checkTrailing(rest); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value", lineNumber)
+			}
+			if err := checkTrailing(value[end+2:]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			value = value[1 : end+1]
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = value[:comment]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		envVars[key] = value
+	}
+	return envVars, nil
+}
+
+// hasClosingQuote reports whether a double-quoted value contains its unescaped closing quote
+func hasClosingQuote(raw string) bool {
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeDoubleQuoted returns the value up to the closing quote and what follows it
+func unescapeDoubleQuoted(raw string) (string, string) {
+	var value strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			if i+1 < len(raw) {
+				i++
+				switch raw[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 't':
+					value.WriteByte('\t')
+				case 'r':
+					value.WriteByte('\r')
+				default:
+					value.WriteByte(raw[i])
+				}
+			}
+		case '"':
+			return value.String(), raw[i+1:]
+		default:
+			value.WriteByte(raw[i])
+		}
+	}
+	return value.String(), ""
+}
+
+// checkTrailing allows only whitespace and a comment after a quoted value
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	return nil
+}
+
+// FormatDotEnv renders variables as a .env file sorted by name. Values that would not
+// survive unquoted are double-quoted.
+func FormatDotEnv(envVars models.EnvVars) string {
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var content strings.Builder
+	for _, key := range keys {
+		content.WriteString(key + "=" + quoteDotEnvValue(envVars[key]) + "\n")
+	}
+	return content.String()
+}
+
+func quoteDotEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r#\"'\\") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// IsSensitiveEnvVar reports whether a variable probably holds a secret: its name suggests
+// one, or its value is a URL with a password. Vault and external references are templates
+// without plaintext secrets and are never sensitive.
+func IsSensitiveEnvVar(key, value string) bool {
+	if HasSecretReferences(value) {
+		return false
+	}
+	if sensitiveEnvKeyPattern.MatchString(key) {
+		return true
+	}
+	if parsed, err := url.Parse(value); err == nil && parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskEnvValue hides the value of a variable flagged as secret on the service or that
+// looks sensitive
+func MaskEnvValue(service models.Service, key, value string) string {
+	if value != "" && (service.IsSecretEnvVar(key) || IsSensitiveEnvVar(key, value)) {
+		return MaskedEnvValue
+	}
+	return value
+}
+
+// MaskEnvVars returns a copy of the variables with MaskEnvValue applied
+func MaskEnvVars(service models.Service, envVars models.EnvVars) models.EnvVars {
+	masked := make(models.EnvVars, len(envVars))
+	for key, value := range envVars {
+		masked[key] = MaskEnvValue(service, key, value)
+	}
+	return masked
+}
+
+// KeepMaskedEnvValues replaces masked values in incoming variables with the current value,
+// so a masked response or export sent back unchanged does not overwrite the secret
+func KeepMaskedEnvValues(incoming, current models.EnvVars) models.EnvVars {
+	kept := make(models.EnvVars, len(incoming))
+	for key, value := range incoming {
+		if currentValue, ok := current[key]; ok && value == MaskedEnvValue {
+			value = currentValue
+		}
+		kept[key] = value
+	}
+	return kept
+}
+
+// minRedactedLength is the shortest secret value redacted from logs; shorter values would
+// mask unrelated text
+const minRedactedLength = 4
+
+// minGuessedSecretLength is the shortest value redacted because its variable looks sensitive
+// (IsSensitiveEnvVar) without being flagged as secret; such values are often short defaults
+// like "postgres" that appear elsewhere in logs
+const minGuessedSecretLength = 8
+
+var (
+	// secretTokenPatterns match credentials with a recognizable format, redacted from logs
+	// even when they are not the value of one of the service's env vars
+	secretTokenPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`),                    // GitHub tokens
+		regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),                                // GitHub fine-grained tokens
+		regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),                                    // GitLab personal access tokens
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                                   // AWS access key IDs
+		regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),                               // Slack tokens
+		regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`),                        // Stripe keys
+		regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`),                                         // npm tokens
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), // JWTs
+	}
+	// urlCredentialsPattern matches the password of credentials embedded in a URL
+	urlCredentialsPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s/]+@`)
+	// authorizationPattern matches the credentials of an Authorization header
+	authorizationPattern = regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?(?:bearer|basic|token)\s+)[^\s"',]+`)
+	// bearerTokenPattern matches a bearer token outside of a header; short words after
+	// "bearer" in prose are left alone
+	bearerTokenPattern = regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]{20,}=*`)
+)
+
+// NewSecretRedactor returns a function that masks secrets in log output: the values of the
+// service's secret env vars and of variables that look sensitive, its Git token, passwords
+// in URLs, Authorization header tokens and tokens with a well-known format (GitHub, GitLab,
+// AWS, Slack, Stripe, npm, JWTs)
+func NewSecretRedactor(service models.Service) func(string) string {
+	var secrets []string
+	for key, value := range service.EnvVars {
+		if HasSecretReferences(value) {
+			continue
+		}
+		if (service.IsSecretEnvVar(key) && len(value) >= minRedactedLength) ||
+			(IsSensitiveEnvVar(key, value) && len(value) >= minGuessedSecretLength) {
+			secrets = append(secrets, value)
+		}
+	}
+	if len(service.GitToken) >= minRedactedLength {
+		secrets = append(secrets, service.GitToken)
+	}
+	// Longer values first, so a secret containing another one is masked whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, MaskedEnvValue)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	return func(line string) string {
+		line = replacer.Replace(line)
+		for _, pattern := range secretTokenPatterns {
+			line = pattern.ReplaceAllString(line, MaskedEnvValue)
+		}
+		line = urlCredentialsPattern.ReplaceAllString(line, "${1}"+MaskedEnvValue+"@")
+		line = authorizationPattern.ReplaceAllString(line, "${1}"+MaskedEnvValue)
+		return bearerTokenPattern.ReplaceAllString(line, "${1}"+MaskedEnvValue)
+	}
+}
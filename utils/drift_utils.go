@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DetectServiceDrift compares the Deployment, Service and Ingresses PenDeploy would generate
+// for a git service with the live objects. Only fields PenDeploy sets are compared, so
+// defaults, status and metadata written by the cluster are never reported. Environment
+// variable values are not included in the report.
+func DetectServiceDrift(imageURL string, service models.Service) ([]dto.ResourceDrift, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+	namespace := service.EnvironmentID
+
+	var drifts []dto.ResourceDrift
+	addDrift := func(drift dto.ResourceDrift) {
+		if drift.Missing || len(drift.Fields) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	expectedDeployment := createDeploymentSpec(imageURL, service)
+	liveDeployment, err := k8sClient.Clientset.AppsV1().Deployments(namespace).Get(ctx, expectedDeployment.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get deployment: %v", err)
+	}
+	addDrift(compareDeployment(expectedDeployment, liveDeployment, errors.IsNotFound(err), service))
+
+	expectedService := createServiceSpec(service)
+	liveService, err := k8sClient.Clientset.CoreV1().Services(namespace).Get(ctx, expectedService.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get service: %v", err)
+	}
+	addDrift(compareService(expectedService, liveService, errors.IsNotFound(err)))
+
+	expectedIngresses := []*networkingv1.Ingress{createIngressSpec(service)}
+	if service.CustomDomain != "" && service.CustomTLSSecret != "" {
+		expectedIngresses = append(expectedIngresses, createCustomDomainIngressSpec(service))
+	}
+	for _, expectedIngress := range expectedIngresses {
+		liveIngress, err := k8sClient.Clientset.NetworkingV1().Ingresses(namespace).Get(ctx, expectedIngress.Name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get ingress %s: %v", expectedIngress.Name, err)
+		}
+		addDrift(compareIngress(expectedIngress, liveIngress, errors.IsNotFound(err)))
+	}
+
+	return drifts, nil
+}
+
+func compareDeployment(expected, live *appsv1.Deployment, missing bool, service models.Service) dto.ResourceDrift {
+	drift := dto.ResourceDrift{Kind: "Deployment", Name: expected.Name, Missing: missing}
+	if missing {
+		return drift
+	}
+
+	// Autoscaled, paused and archived services are scaled outside the spec
+	if service.IsStaticReplica && service.Status != "paused" && service.Status != "archived" {
+		drift.Fields = appendFieldDrift(drift.Fields, "spec.replicas", formatReplicas(expected.Spec.Replicas), formatReplicas(live.Spec.Replicas))
+	}
+	drift.Fields = append(drift.Fields, compareLabels("metadata.labels", expected.Labels, live.Labels)...)
+
+	expectedPod, livePod := expected.Spec.Template.Spec, live.Spec.Template.Spec
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.template.spec.serviceAccountName", expectedPod.ServiceAccountName, livePod.ServiceAccountName)
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.template.spec.priorityClassName", expectedPod.PriorityClassName, livePod.PriorityClassName)
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.template.spec.imagePullSecrets", formatPullSecrets(expectedPod.ImagePullSecrets), formatPullSecrets(livePod.ImagePullSecrets))
+
+	for _, expectedContainer := range expectedPod.Containers {
+		prefix := fmt.Sprintf("containers[%s]", expectedContainer.Name)
+		liveContainer, ok := findContainer(livePod.Containers, expectedContainer.Name)
+		if !ok {
+			drift.Fields = append(drift.Fields, dto.FieldDrift{Field: prefix, Expected: "present", Actual: "missing"})
+			continue
+		}
+		if expectedContainer.Image != "" {
+			drift.Fields = appendFieldDrift(drift.Fields, prefix+".image", expectedContainer.Image, liveContainer.Image)
+		}
+		drift.Fields = appendFieldDrift(drift.Fields, prefix+".ports", formatContainerPorts(expectedContainer.Ports), formatContainerPorts(liveContainer.Ports))
+		drift.Fields = append(drift.Fields, compareResources(prefix+".resources.limits", expectedContainer.Resources.Limits, liveContainer.Resources.Limits)...)
+		drift.Fields = append(drift.Fields, compareResources(prefix+".resources.requests", expectedContainer.Resources.Requests, liveContainer.Resources.Requests)...)
+		drift.Fields = append(drift.Fields, compareEnv(prefix+".env", expectedContainer.Env, liveContainer.Env)...)
+	}
+	return drift
+}
+
+func compareService(expected, live *corev1.Service, missing bool) dto.ResourceDrift {
+	drift := dto.ResourceDrift{Kind: "Service", Name: expected.Name, Missing: missing}
+	if missing {
+		return drift
+	}
+
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.type", string(expected.Spec.Type), string(live.Spec.Type))
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.selector", formatStringMap(expected.Spec.Selector), formatStringMap(live.Spec.Selector))
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.ports", formatServicePorts(expected.Spec.Ports), formatServicePorts(live.Spec.Ports))
+	drift.Fields = append(drift.Fields, compareLabels("metadata.labels", expected.Labels, live.Labels)...)
+	return drift
+}
+
+func compareIngress(expected, live *networkingv1.Ingress, missing bool) dto.ResourceDrift {
+	drift := dto.ResourceDrift{Kind: "Ingress", Name: expected.Name, Missing: missing}
+	if missing {
+		return drift
+	}
+
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.rules", formatIngressRules(expected.Spec.Rules), formatIngressRules(live.Spec.Rules))
+	drift.Fields = appendFieldDrift(drift.Fields, "spec.tls", formatIngressTLS(expected.Spec.TLS), formatIngressTLS(live.Spec.TLS))
+	drift.Fields = append(drift.Fields, compareLabels("metadata.annotations", expected.Annotations, live.Annotations)...)
+	drift.Fields = append(drift.Fields, compareLabels("metadata.labels", expected.Labels, live.Labels)...)
+	return drift
+}
+
+// compareLabels reports expected keys that are missing or changed; keys added by other
+// controllers are ignored
+func compareLabels(field string, expected, live map[string]string) []dto.FieldDrift {
+	var fields []dto.FieldDrift
+	for _, key := range sortedKeys(expected) {
+		actual, ok := live[key]
+		if !ok {
+			actual = "<unset>"
+		}
+		fields = appendFieldDrift(fields, fmt.Sprintf("%s[%s]", field, key), expected[key], actual)
+	}
+	return fields
+}
+
+// compareEnv reports added, removed and changed variables without their values
+func compareEnv(field string, expected, live []corev1.EnvVar) []dto.FieldDrift {
+	liveValues := make(map[string]corev1.EnvVar, len(live))
+	for _, env := range live {
+		liveValues[env.Name] = env
+	}
+
+	var fields []dto.FieldDrift
+	seen := make(map[string]bool, len(expected))
+	for _, env := range expected {
+		seen[env.Name] = true
+		liveEnv, ok := liveValues[env.Name]
+		switch {
+		case !ok:
+			fields = append(fields, dto.FieldDrift{Field: fmt.Sprintf("%s[%s]", field, env.Name), Expected: "<set>", Actual: "<unset>"})
+		case !envSourceEqual(env, liveEnv):
+			fields = append(fields, dto.FieldDrift{Field: fmt.Sprintf("%s[%s]", field, env.Name), Expected: "<declared value>", Actual: "<changed>"})
+		}
+	}
+	for _, env := range live {
+		if !seen[env.Name] {
+			fields = append(fields, dto.FieldDrift{Field: fmt.Sprintf("%s[%s]", field, env.Name), Expected: "<unset>", Actual: "<set>"})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields
+}
+
+// envSourceEqual compares a literal value, or the Secret key a value is read from
+func envSourceEqual(expected, live corev1.EnvVar) bool {
+	if expected.ValueFrom == nil || expected.ValueFrom.SecretKeyRef == nil {
+		return live.ValueFrom == nil && live.Value == expected.Value
+	}
+	if live.ValueFrom == nil || live.ValueFrom.SecretKeyRef == nil {
+		return false
+	}
+	return live.ValueFrom.SecretKeyRef.Name == expected.ValueFrom.SecretKeyRef.Name &&
+		live.ValueFrom.SecretKeyRef.Key == expected.ValueFrom.SecretKeyRef.Key
+}
+
+func compareResources(field string, expected, live corev1.ResourceList) []dto.FieldDrift {
+	var fields []dto.FieldDrift
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		expectedQuantity, expectedSet := expected[name]
+		liveQuantity, liveSet := live[name]
+		if expectedSet == liveSet && (!expectedSet || expectedQuantity.Cmp(liveQuantity) == 0) {
+			continue
+		}
+
+		actual := "<unset>"
+		if liveSet {
+			actual = liveQuantity.String()
+		}
+		fields = append(fields, dto.FieldDrift{Field: fmt.Sprintf("%s.%s", field, name), Expected: expectedQuantity.String(), Actual: actual})
+	}
+	return fields
+}
+
+func appendFieldDrift(fields []dto.FieldDrift, field, expected, actual string) []dto.FieldDrift {
+	if expected == actual {
+		return fields
+	}
+	return append(fields, dto.FieldDrift{Field: field, Expected: expected, Actual: actual})
+}
+
+func findContainer(containers []corev1.Container, name string) (corev1.Container, bool) {
+	for _, container := range containers {
+		if container.Name == name {
+			return container, true
+		}
+	}
+	return corev1.Container{}, false
+}
+
+func formatReplicas(replicas *int32) string {
+	if replicas == nil {
+		return "<unset>"
+	}
+	return fmt.Sprintf("%d", *replicas)
+}
+
+func formatPullSecrets(refs []corev1.LocalObjectReference) string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func formatContainerPorts(ports []corev1.ContainerPort) string {
+	values := make([]string, 0, len(ports))
+	for _, port := range ports {
+		values = append(values, fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func formatServicePorts(ports []corev1.ServicePort) string {
+	values := make([]string, 0, len(ports))
+	for _, port := range ports {
+		values = append(values, fmt.Sprintf("%d->%s/%s", port.Port, port.TargetPort.String(), port.Protocol))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func formatIngressRules(rules []networkingv1.IngressRule) string {
+	var values []string
+	for _, rule := range rules {
+		if rule.HTTP == nil {
+			values = append(values, rule.Host)
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backend := "<none>"
+			if path.Backend.Service != nil {
+				backend = fmt.Sprintf("%s:%d", path.Backend.Service.Name, path.Backend.Service.Port.Number)
+			}
+			values = append(values, fmt.Sprintf("%s%s->%s", rule.Host, path.Path, backend))
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func formatIngressTLS(tls []networkingv1.IngressTLS) string {
+	values := make([]string, 0, len(tls))
+	for _, entry := range tls {
+		hosts := append([]string(nil), entry.Hosts...)
+		sort.Strings(hosts)
+		values = append(values, fmt.Sprintf("%s:%s", entry.SecretName, strings.Join(hosts, "|")))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func formatStringMap(values map[string]string) string {
+	pairs := make([]string, 0, len(values))
+	for _, key := range sortedKeys(values) {
+		pairs = append(pairs, key+"="+values[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// smtpTimeout bounds connecting to the mail server
+const smtpTimeout = 15 * time.Second
+
+// digestMaxItems bounds the failures and warnings listed in one digest email
+const digestMaxItems = 20
+
+// SendEmail sends a plain-text email through the configured mail server. Authentication is
+// only attempted when a username is set, and never over an unencrypted connection.
+func SendEmail(settings models.SMTPSettings, to []string, subject, body string) error {
+	if !settings.Configured() {
+		return errors.New("SMTP is not configured")
+	}
+
+	address := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+	tlsConfig := &tls.Config{ServerName: settings.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	if settings.Security == models.SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", address, err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %v", err)
+	}
+	defer client.Close()
+
+	if settings.Security == models.SMTPSecurityStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+	if settings.Username != "" {
+		if settings.Security == models.SMTPSecurityNone {
+			return errors.New("refusing to send SMTP credentials over an unencrypted connection")
+		}
+		if err := client.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(settings.FromAddress); err != nil {
+		return fmt.Errorf("sender rejected: %v", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(buildEmailMessage(settings.FromAddress, to, subject, body)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("message rejected: %v", err)
+	}
+	return client.Quit()
+}
+
+// buildEmailMessage assembles the headers and CRLF-terminated body of a plain-text email
+func buildEmailMessage(from string, to []string, subject, body string) []byte {
+	var message strings.Builder
+	message.WriteString("From: " + from + "\r\n")
+	message.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	message.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		// A lone dot would end the DATA section early
+		if strings.HasPrefix(line, ".") {
+			line = "." + line
+		}
+		message.WriteString(line + "\r\n")
+	}
+	return []byte(message.String())
+}
+
+// RenderDigestEmail returns the subject and plain-text body of a project digest
+func RenderDigestEmail(digest dto.ProjectDigest) (string, string) {
+	subject := fmt.Sprintf("[%s] %s digest: %d deployments, %d failed",
+		digest.ProjectName, digest.Frequency, digest.Deployments, digest.FailedDeployments)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Project %s, %s to %s (UTC)\n\n", digest.ProjectName,
+		digest.From.UTC().Format("2006-01-02 15:04"), digest.To.UTC().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&body, "Deployments: %d (%d successful, %d failed)\n",
+		digest.Deployments, digest.SuccessfulDeployments, digest.FailedDeployments)
+	for _, service := range digest.Services {
+		fmt.Fprintf(&body, "  %s: %d deployments, %d failed\n", service.ServiceName, service.Deployments, service.Failed)
+	}
+
+	body.WriteString("\nFailures\n")
+	if len(digest.Failures) == 0 {
+		body.WriteString("  None\n")
+	}
+	for i, failure := range digest.Failures {
+		if i == digestMaxItems {
+			fmt.Fprintf(&body, "  ... and %d more\n", len(digest.Failures)-digestMaxItems)
+			break
+		}
+		code := failure.ErrorCode
+		if code == "" {
+			code = "failed"
+		}
+		fmt.Fprintf(&body, "  %s %s: %s\n", failure.CreatedAt.UTC().Format("Jan 02 15:04"), failure.ServiceName, code)
+		if failure.ErrorHint != "" {
+			fmt.Fprintf(&body, "    %s\n", failure.ErrorHint)
+		}
+	}
+
+	body.WriteString("\nWarnings\n")
+	if len(digest.Warnings) == 0 {
+		body.WriteString("  None\n")
+	}
+	for i, warning := range digest.Warnings {
+		if i == digestMaxItems {
+			fmt.Fprintf(&body, "  ... and %d more\n", len(digest.Warnings)-digestMaxItems)
+			break
+		}
+		fmt.Fprintf(&body, "  %s %s: %s\n", warning.At.UTC().Format("Jan 02 15:04"), warning.ServiceName, warning.Message)
+	}
+
+	body.WriteString("\nYou receive this email because this address is subscribed to the project's digest.\n")
+	return subject, body.String()
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"sort"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// EnvVarRebuildReason explains why a change of the env var from the existing to the updated
+// service needs a new image, or returns "" when patching the running Deployment is enough.
+// Build args are baked into the image as ENV, so the Deployment's env overrides a changed
+// value but cannot unset a removed one.
+func EnvVarRebuildReason(existing, updated models.Service, key string) string {
+	oldValue, existed := existing.EnvVars[key]
+	_, exists := updated.EnvVars[key]
+	bakedIn := existed && isPassedToBuild(existing, key, oldValue)
+
+	switch {
+	case existing.IsBuildEnvVar(key) || updated.IsBuildEnvVar(key):
+		return "flagged as needed at build time"
+	case bakedIn && !exists:
+		return "removed, but the current image still sets it as ENV"
+	case bakedIn && updated.IsSecretEnvVar(key):
+		return "now secret, but the current image contains its value"
+	}
+	return ""
+}
+
+// ChangedEnvVars lists the env vars whose value, presence or secret flag differs between
+// the existing and the updated service, sorted by name
+func ChangedEnvVars(existing, updated models.Service) []string {
+	keys := map[string]bool{}
+	for key, value := range updated.EnvVars {
+		oldValue, found := existing.EnvVars[key]
+		if !found || oldValue != value || existing.IsSecretEnvVar(key) != updated.IsSecretEnvVar(key) {
+			keys[key] = true
+		}
+	}
+	for key := range existing.EnvVars {
+		if _, found := updated.EnvVars[key]; !found {
+			keys[key] = true
+		}
+	}
+
+	changed := make([]string, 0, len(keys))
+	for key := range keys {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// EnvVarsRequiringRebuild lists the changed env vars that cannot be applied to the running
+// Deployment without building a new image
+func EnvVarsRequiringRebuild(existing, updated models.Service) []string {
+	var keys []string
+	for _, key := range ChangedEnvVars(existing, updated) {
+		if EnvVarRebuildReason(existing, updated, key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// DiffEnvVars compares the env vars of two services, e.g. the same service in two
+// environments, with the values of secret env vars masked
+func DiffEnvVars(a, b models.Service) []dto.EnvVarDiff {
+	diffs := []dto.EnvVarDiff{}
+	for _, key := range ChangedEnvVars(a, b) {
+		valueA, inA := a.EnvVars[key]
+		valueB, inB := b.EnvVars[key]
+		diff := dto.EnvVarDiff{Key: key, Status: dto.EnvVarDiffChanged, A: valueA, B: valueB}
+		switch {
+		case !inB:
+			diff.Status = dto.EnvVarDiffOnlyInA
+		case !inA:
+			diff.Status = dto.EnvVarDiffOnlyInB
+		}
+		// Secret on either side masks both, so a value is never shown next to its secret twin
+		if a.IsSecretEnvVar(key) || b.IsSecretEnvVar(key) {
+			diff.Secret = true
+			if valueA != "" {
+				diff.A = models.MaskedSecretValue
+			}
+			if valueB != "" {
+				diff.B = models.MaskedSecretValue
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// LabelErrorPages marks the error page resources of an environment
+const LabelErrorPages = "pendeploy.io/error-pages"
+
+const (
+	// errorPagesName names the ConfigMap, Deployment, Service and Middleware of the error
+	// pages in each environment namespace
+	errorPagesName      = "error-pages"
+	errorPagesPort      = 8080
+	maxErrorPageHTML    = 64 * 1024
+	maxErrorPageTitle   = 100
+	maxErrorPageMessage = 1000
+)
+
+// ErrorPageStatuses are the backend statuses the ingress replaces with the project's page
+var ErrorPageStatuses = []int{502, 503, 504}
+
+var errorPageHeadings = map[int]string{
+	502: "Bad gateway",
+	503: "Service unavailable",
+	504: "Gateway timeout",
+}
+
+var errorPageTemplate = template.Must(template.New("error-page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Status}} {{.Heading}} - {{.Title}}</title>
+<style>
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:system-ui,sans-serif;background:#f6f7f9;color:#1f2328}
+main{max-width:32rem;padding:2rem;text-align:center}
+img{max-height:4rem;margin-bottom:1.5rem}
+h1{font-size:1.5rem;margin:0 0 .5rem}
+p{color:#59636e;line-height:1.5}
+</style>
+</head>
+<body>
+<main>
+{{if .LogoURL}}<img src="{{.LogoURL}}" alt="{{.Title}}">{{end}}
+<h1>{{.Title}}</h1>
+<p><strong>{{.Status}} {{.Heading}}</strong></p>
+<p>{{if .Message}}{{.Message}}{{else}}The service is temporarily unavailable. Please try again in a few moments.{{end}}</p>
+</main>
+</body>
+</html>
+`))
+
+// ErrorPageStatusHeading returns the heading of a status the ingress serves error pages for
+func ErrorPageStatusHeading(status int) (string, bool) {
+	heading, ok := errorPageHeadings[status]
+	return heading, ok
+}
+
+// ValidateErrorPage checks an error page config before it is stored
+func ValidateErrorPage(config models.ErrorPageConfig) error {
+	var errs FieldErrors
+	if len(config.Title) > maxErrorPageTitle {
+		errs.Add("title", "must not exceed %d characters", maxErrorPageTitle)
+	}
+	if len(config.Message) > maxErrorPageMessage {
+		errs.Add("message", "must not exceed %d characters", maxErrorPageMessage)
+	}
+	if config.LogoURL != "" {
+		parsed, err := url.Parse(config.LogoURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			errs.Add("logoUrl", "must be an http(s) URL")
+		}
+	}
+	if len(config.HTML) > maxErrorPageHTML {
+		errs.Add("html", "must not exceed %d bytes", maxErrorPageHTML)
+	}
+	return errs.Err()
+}
+
+// RenderErrorPage returns the page served for the status: the config's HTML, or the
+// platform template branded with its title, message and logo
+func RenderErrorPage(projectName string, config models.ErrorPageConfig, status int) (string, error) {
+	if config.HTML != "" {
+		return strings.ReplaceAll(config.HTML, "{{status}}", strconv.Itoa(status)), nil
+	}
+
+	title := config.Title
+	if title == "" {
+		title = projectName
+	}
+	var page bytes.Buffer
+	err := errorPageTemplate.Execute(&page, map[string]interface{}{
+		"Title":   title,
+		"Message": config.Message,
+		"LogoURL": config.LogoURL,
+		"Status":  status,
+		"Heading": errorPageHeadings[status],
+	})
+	return page.String(), err
+}
+
+// ApplyErrorPages serves the project's error pages in an environment: a small nginx
+// Deployment with the rendered pages, and the Traefik errors Middleware the environment's
+// service Ingresses pick up when they are next applied
+func ApplyErrorPages(environmentID string, projectName string, config models.ErrorPageConfig) error {
+	if !GetIngressProvider().SupportsMiddlewares() {
+		return ErrMiddlewaresUnsupported
+	}
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := EnsureNamespaceExists(environmentID); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %v", err)
+	}
+
+	pages := map[string]string{}
+	for _, status := range ErrorPageStatuses {
+		page, err := RenderErrorPage(projectName, config, status)
+		if err != nil {
+			return fmt.Errorf("failed to render the %d page: %v", status, err)
+		}
+		pages[fmt.Sprintf("%d.html", status)] = page
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: errorPagesName, Namespace: environmentID, Labels: errorPagesLabels(environmentID)},
+		Data:       pages,
+	}
+	_, err = k8sClient.Clientset.CoreV1().ConfigMaps(environmentID).Create(ctx, configMap, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = k8sClient.Clientset.CoreV1().ConfigMaps(environmentID).Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply error pages ConfigMap: %v", err)
+	}
+
+	if err := applyDeployment(ctx, k8sClient, createErrorPagesDeployment(environmentID)); err != nil {
+		return fmt.Errorf("failed to apply error pages Deployment: %v", err)
+	}
+	if err := applyService(ctx, k8sClient, createErrorPagesService(environmentID)); err != nil {
+		return fmt.Errorf("failed to apply error pages Service: %v", err)
+	}
+	if err := applyTraefikMiddleware(ctx, k8sClient, createErrorPagesMiddleware(environmentID)); err != nil {
+		return err
+	}
+
+	log.Printf("Error pages applied in environment %s", environmentID)
+	return nil
+}
+
+// DeleteErrorPages removes the error pages of an environment, if any
+func DeleteErrorPages(environmentID string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	err = k8sClient.DynamicClient.Resource(traefikMiddlewareGVR).Namespace(environmentID).Delete(ctx, errorPagesName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete error pages Middleware: %v", err)
+	}
+	err = k8sClient.Clientset.CoreV1().Services(environmentID).Delete(ctx, errorPagesName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete error pages Service: %v", err)
+	}
+	err = k8sClient.Clientset.AppsV1().Deployments(environmentID).Delete(ctx, errorPagesName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete error pages Deployment: %v", err)
+	}
+	err = k8sClient.Clientset.CoreV1().ConfigMaps(environmentID).Delete(ctx, errorPagesName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete error pages ConfigMap: %v", err)
+	}
+	return nil
+}
+
+// errorPagesLabels labels the error page resources. They carry no service labels, nor
+// managed-by, which the orphan cleanup of service resources selects on.
+func errorPagesLabels(environmentID string) map[string]string {
+	return map[string]string{
+		"app":              errorPagesName,
+		LabelErrorPages:    "true",
+		LabelEnvironmentID: environmentID,
+	}
+}
+
+func createErrorPagesDeployment(environmentID string) *appsv1.Deployment {
+	labels := errorPagesLabels(environmentID)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: errorPagesName, Namespace: environmentID, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(1),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": errorPagesName}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "nginx",
+						Image: getEnvString("ERROR_PAGES_IMAGE", "nginxinc/nginx-unprivileged:1.27-alpine"),
+						Ports: []corev1.ContainerPort{{ContainerPort: errorPagesPort}},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+								corev1.ResourceMemory: resource.MustParse("16Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+								corev1.ResourceMemory: resource.MustParse("32Mi"),
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{{Name: "pages", MountPath: "/usr/share/nginx/html", ReadOnly: true}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "pages",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: errorPagesName},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+func createErrorPagesService(environmentID string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: errorPagesName, Namespace: environmentID, Labels: errorPagesLabels(environmentID)},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": errorPagesName},
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromInt(errorPagesPort),
+			}},
+		},
+	}
+}
+
+func createErrorPagesMiddleware(environmentID string) *unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	for key, value := range errorPagesLabels(environmentID) {
+		labels[key] = value
+	}
+	statuses := make([]interface{}, 0, len(ErrorPageStatuses))
+	for _, status := range ErrorPageStatuses {
+		statuses = append(statuses, strconv.Itoa(status))
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "traefik.io/v1alpha1",
+		"kind":       "Middleware",
+		"metadata": map[string]interface{}{
+			"name":      errorPagesName,
+			"namespace": environmentID,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"errors": map[string]interface{}{
+				"status": statuses,
+				"service": map[string]interface{}{
+					"name": errorPagesName,
+					"port": int64(80),
+				},
+				"query": "/{status}.html",
+			},
+		},
+	}}
+}
+
+// addErrorPagesMiddleware routes an Ingress of a service through its environment's error
+// pages, when the project has them. The errors Middleware comes first so it also replaces
+// errors of the Middlewares after it.
+func addErrorPagesMiddleware(ctx context.Context, client *kubernetes.Client, ingress *networkingv1.Ingress) {
+	_, err := client.DynamicClient.Resource(traefikMiddlewareGVR).Namespace(ingress.Namespace).Get(ctx, errorPagesName, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	reference := fmt.Sprintf("%s-%s@kubernetescrd", ingress.Namespace, errorPagesName)
+	if existing := ingress.Annotations[traefikMiddlewaresAnnotation]; existing != "" {
+		reference += "," + existing
+	}
+	ingress.Annotations[traefikMiddlewaresAnnotation] = reference
+}
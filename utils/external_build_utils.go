@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+const (
+	// externalBuildRequestTimeout bounds a single request to the build API
+	externalBuildRequestTimeout = 15 * time.Second
+	// externalBuildPollInterval is how often the status of an external build is checked
+	externalBuildPollInterval = 10 * time.Second
+	// externalBuildCapabilitiesTTL is how long the capabilities the build API reported are used
+	externalBuildCapabilitiesTTL = 5 * time.Minute
+	// maxExternalBuildResponseBytes bounds the size of a build API response
+	maxExternalBuildResponseBytes = 1 << 20
+)
+
+// External build statuses
+const (
+	externalBuildSucceeded = "succeeded"
+	externalBuildFailed    = "failed"
+)
+
+var externalBuildClient = &http.Client{Timeout: externalBuildRequestTimeout}
+
+// externalBuildConfig is the build API builds are handed to (BUILD_API_URL, BUILD_API_TOKEN)
+type externalBuildConfig struct {
+	baseURL string
+	token   string
+}
+
+func getExternalBuildConfig() (externalBuildConfig, bool) {
+	baseURL := strings.TrimRight(getEnvString("BUILD_API_URL", ""), "/")
+	if baseURL == "" {
+		return externalBuildConfig{}, false
+	}
+	return externalBuildConfig{baseURL: baseURL, token: getEnvString("BUILD_API_TOKEN", "")}, true
+}
+
+var externalBuildCapabilities = struct {
+	mu           sync.Mutex
+	capabilities BuildCapabilities
+	fetchedAt    time.Time
+}{}
+
+// externalBuildRequest asks the build API for an image. The API clones the repository at
+// the commit, builds Dockerfile and pushes the result to image, so the registry must be
+// reachable from it.
+type externalBuildRequest struct {
+	DeploymentID     string            `json:"deploymentId"`
+	ServiceID        string            `json:"serviceId"`
+	RepoURL          string            `json:"repoUrl"`
+	GitUsername      string            `json:"gitUsername,omitempty"`
+	GitToken         string            `json:"gitToken,omitempty"`
+	Branch           string            `json:"branch"`
+	CommitSHA        string            `json:"commitSha,omitempty"` // empty builds the head of the branch
+	Dockerfile       string            `json:"dockerfile"`
+	Image            string            `json:"image"`
+	InsecureRegistry bool              `json:"insecureRegistry"`
+	Platforms        []string          `json:"platforms,omitempty"`
+	BuildArgs        map[string]string `json:"buildArgs,omitempty"`
+	Secrets          map[string]string `json:"secrets,omitempty"` // only sent to an API with build secrets
+	CacheRef         string            `json:"cacheRef,omitempty"`
+	TimeoutSeconds   int64             `json:"timeoutSeconds"`
+}
+
+// externalBuildStatus is the build API's view of a build
+type externalBuildStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // queued, running, succeeded or failed
+	Image  string `json:"image"`
+	Error  string `json:"error"`
+}
+
+// externalBuilder hands builds to an external build API, e.g. one in front of a cloud build
+// service: POST /builds starts a build, GET /builds/{id} reports it, POST /builds/{id}/cancel
+// stops it and GET /capabilities tells which capabilities it has
+type externalBuilder struct {
+	config externalBuildConfig
+}
+
+func (b externalBuilder) Name() string {
+	return BuildBackendExternal
+}
+
+// Capabilities are negotiated with the build API and cached for a few minutes; an API that
+// cannot be asked is assumed to have none
+func (b externalBuilder) Capabilities() BuildCapabilities {
+	externalBuildCapabilities.mu.Lock()
+	defer externalBuildCapabilities.mu.Unlock()
+	if time.Since(externalBuildCapabilities.fetchedAt) < externalBuildCapabilitiesTTL {
+		return externalBuildCapabilities.capabilities
+	}
+
+	var capabilities BuildCapabilities
+	if err := b.do(http.MethodGet, "/capabilities", nil, &capabilities); err != nil {
+		log.Printf("WARNING: Failed to read the capabilities of the build API: %v", err)
+		capabilities = BuildCapabilities{}
+	}
+	externalBuildCapabilities.capabilities = capabilities
+	externalBuildCapabilities.fetchedAt = time.Now()
+	return capabilities
+}
+
+// Build starts the build at the build API and polls it until it finishes or the service's
+// build timeout passes
+func (b externalBuilder) Build(deployment models.Deployment, service models.Service, registry models.Registry) (string, error) {
+	cleanRegistryURL := CleanRegistryURL(registry.URL)
+	image := GenerateImage(cleanRegistryURL, service, deployment)
+	log.Printf("Target image (for K8s): %s", image)
+
+	branch := service.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	timeout := GetBuildTimeout(service)
+	req := externalBuildRequest{
+		DeploymentID:     deployment.ID,
+		ServiceID:        service.ID,
+		RepoURL:          service.RepoURL,
+		Branch:           branch,
+		CommitSHA:        deployment.CommitSHA,
+		Dockerfile:       GetDockerfilePath(service),
+		Image:            image,
+		InsecureRegistry: IsInsecureRegistry(registry.URL),
+		Platforms:        GetBuildPlatforms(service),
+		BuildArgs:        buildArgs(service),
+		TimeoutSeconds:   int64(timeout.Seconds()),
+	}
+	if service.GitToken != "" {
+		req.GitUsername = service.GitUsername
+		req.GitToken = service.GitToken
+	}
+	capabilities := b.Capabilities()
+	if capabilities.Cache {
+		req.CacheRef = fmt.Sprintf("%s/cache:external-%s", cleanRegistryURL, service.ID)
+	}
+	if capabilities.Secrets && service.BuildSecrets {
+		req.Secrets = service.SecretEnvVars
+	}
+
+	var build externalBuildStatus
+	if err := b.do(http.MethodPost, "/builds", req, &build); err != nil {
+		return "", fmt.Errorf("failed to start external build: %v", err)
+	}
+	if build.ID == "" {
+		return "", fmt.Errorf("failed to start external build: the build API returned no build ID")
+	}
+	log.Printf("External build %s started for deployment %s", build.ID, deployment.ID)
+
+	deadline := time.Now().Add(timeout + buildWatchGrace)
+	for build.Status != externalBuildSucceeded && build.Status != externalBuildFailed {
+		if time.Now().After(deadline) {
+			if err := b.do(http.MethodPost, "/builds/"+url.PathEscape(build.ID)+"/cancel", nil, nil); err != nil {
+				log.Printf("WARNING: Failed to cancel external build %s: %v", build.ID, err)
+			}
+			return "", fmt.Errorf("timeout waiting for external build %s to complete (waited %v)", build.ID, timeout)
+		}
+		time.Sleep(externalBuildPollInterval)
+
+		var status externalBuildStatus
+		if err := b.do(http.MethodGet, "/builds/"+url.PathEscape(build.ID), nil, &status); err != nil {
+			// A hiccup of the build API does not fail a build that is still running
+			log.Printf("WARNING: Failed to check external build %s, retrying: %v", build.ID, err)
+			continue
+		}
+		status.ID = build.ID
+		build = status
+	}
+
+	if build.Status == externalBuildFailed {
+		reason := build.Error
+		if reason == "" {
+			reason = "Unknown failure"
+		}
+		return "", fmt.Errorf("external build %s failed: %s", build.ID, NewSecretRedactor(service)(reason))
+	}
+	if build.Image != "" {
+		image = build.Image
+	}
+	log.Printf("BUILD SUCCESS: External build %s completed successfully! Image ready: %s", build.ID, image)
+	return image, nil
+}
+
+// do sends a request to the build API and decodes its JSON response into out
+func (b externalBuilder) do(method, endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, b.config.baseURL+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.config.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.config.token)
+	}
+
+	resp, err := externalBuildClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalBuildResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("build API returned %s: %s", resp.Status, strings.TrimSpace(string(payload)))
+	}
+	if out == nil || len(bytes.TrimSpace(payload)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(payload, out)
+}
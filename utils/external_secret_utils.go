@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// externalSecretTimeout bounds a single request to a secret manager
+const externalSecretTimeout = 10 * time.Second
+
+var externalReferencePattern = regexp.MustCompile(`\$\{external:([a-z0-9]([-a-z0-9]*[a-z0-9])?)/([^#}]+)(#([^}]+))?\}`)
+
+var externalSecretClient = &http.Client{Timeout: externalSecretTimeout}
+
+// ExternalSecretReference is a ${external:store/path#key} reference. Key selects a field of
+// a secret holding several values; it may be omitted when the secret has a single value.
+type ExternalSecretReference struct {
+	Store string
+	Path  string
+	Key   string
+}
+
+// String returns the reference as written between ${external: and }
+func (r ExternalSecretReference) String() string {
+	if r.Key == "" {
+		return r.Store + "/" + r.Path
+	}
+	return r.Store + "/" + r.Path + "#" + r.Key
+}
+
+// ExternalSecretReferences lists the distinct external references of the env vars, sorted
+func ExternalSecretReferences(envVars models.EnvVars) []ExternalSecretReference {
+	seen := map[string]bool{}
+	var references []ExternalSecretReference
+	for _, value := range envVars {
+		for _, match := range externalReferencePattern.FindAllStringSubmatch(value, -1) {
+			reference := ExternalSecretReference{Store: match[1], Path: match[3], Key: match[5]}
+			if !seen[reference.String()] {
+				seen[reference.String()] = true
+				references = append(references, reference)
+			}
+		}
+	}
+	sort.Slice(references, func(i, j int) bool { return references[i].String() < references[j].String() })
+	return references
+}
+
+// ReferencesSecretStore reports whether any env var reads from the named store
+func ReferencesSecretStore(envVars models.EnvVars, storeName string) bool {
+	for _, reference := range ExternalSecretReferences(envVars) {
+		if reference.Store == storeName {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchExternalSecret reads the value a reference points to from its store
+func FetchExternalSecret(store models.SecretStore, reference ExternalSecretReference) (string, error) {
+	var values map[string]string
+	var err error
+	switch store.Type {
+	case models.SecretStoreTypeVault:
+		values, err = fetchVaultSecret(store, reference.Path)
+	case models.SecretStoreTypeAWS:
+		values, err = fetchAWSSecret(store, reference.Path)
+	default:
+		err = fmt.Errorf("unsupported secret store type %q", store.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", reference.String(), err)
+	}
+
+	if reference.Key != "" {
+		value, ok := values[reference.Key]
+		if !ok {
+			return "", fmt.Errorf("%s: key %q not found", reference.String(), reference.Key)
+		}
+		return value, nil
+	}
+	if len(values) != 1 {
+		return "", fmt.Errorf("%s: secret holds %d values, select one with #key", reference.String(), len(values))
+	}
+	for _, value := range values {
+		return value, nil
+	}
+	return "", nil
+}
+
+// fetchVaultSecret reads a KV secret. For KV v2 the path includes the data/ segment,
+// e.g. secret/data/app; the payload is unwrapped from data.data.
+func fetchVaultSecret(store models.SecretStore, path string) (map[string]string, error) {
+	request, err := http.NewRequest(http.MethodGet, strings.TrimRight(store.Address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-Vault-Token", store.Token)
+	if store.VaultNamespace != "" {
+		request.Header.Set("X-Vault-Namespace", store.VaultNamespace)
+	}
+
+	body, err := doExternalSecretRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid Vault response: %v", err)
+	}
+	data := response.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	return stringifySecretValues(data), nil
+}
+
+// fetchAWSSecret reads a Secrets Manager secret by name or ARN. A JSON object SecretString
+// is split into its fields; any other value is returned under the "value" key.
+func fetchAWSSecret(store models.SecretStore, secretID string) (map[string]string, error) {
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", store.Region)
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(request, payload, store.Region, "secretsmanager", store.AccessKeyID, store.SecretAccessKey, time.Now().UTC())
+
+	body, err := doExternalSecretRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid Secrets Manager response: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(response.SecretString), &fields); err == nil {
+		return stringifySecretValues(fields), nil
+	}
+	return map[string]string{"value": response.SecretString}, nil
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers to a request with the given body
+func signAWSRequest(request *http.Request, body []byte, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	payloadHash := sha256.Sum256(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalHeaders := "content-type:" + request.Header.Get("Content-Type") + "\n" +
+		"host:" + request.URL.Host + "\n" +
+		"x-amz-date:" + amzDate + "\n" +
+		"x-amz-target:" + request.Header.Get("X-Amz-Target") + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		"/",
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func doExternalSecretRequest(request *http.Request) ([]byte, error) {
+	response, err := externalSecretClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d: %s", request.URL.Host, response.StatusCode, lastLine(string(body)))
+	}
+	return body, nil
+}
+
+// stringifySecretValues renders non-string JSON values as JSON so they can be used as env values
+func stringifySecretValues(data map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		if text, ok := value.(string); ok {
+			values[key] = text
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		values[key] = string(encoded)
+	}
+	return values
+}
+
+// CheckSecretStoreAddress requires an absolute http(s) URL for a Vault server
+func CheckSecretStoreAddress(address string) error {
+	parsed, err := url.Parse(address)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("must be an http(s) URL such as https://vault.example.com:8200")
+	}
+	return nil
+}
+
+// SyncServiceEnvSecret replaces the contents of a deployed service's env Secret with its
+// freshly resolved SecretEnvVars. changed is false when the values are the same or the
+// service was never deployed with vault or external references.
+func SyncServiceEnvSecret(service models.Service) (changed bool, err error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return false, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	secrets := k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID)
+	secret, err := secrets.Get(ctx, GetServiceEnvSecretName(service), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read env secret: %v", err)
+	}
+
+	if len(secret.Data) == len(service.SecretEnvVars) {
+		same := true
+		for key, value := range service.SecretEnvVars {
+			if current, ok := secret.Data[key]; !ok || string(current) != value {
+				same = false
+				break
+			}
+		}
+		if same {
+			return false, nil
+		}
+	}
+
+	secret.Data = make(map[string][]byte, len(service.SecretEnvVars))
+	for key, value := range service.SecretEnvVars {
+		secret.Data[key] = []byte(value)
+	}
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to update env secret: %v", err)
+	}
+	return true, nil
+}
+
+// RestartForEnvSecret rolls the pods of a service by updating the env Secret checksum on its
+// pod template, the same annotation a deploy would set
+func RestartForEnvSecret(service models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	var template struct {
+		Spec struct {
+			Template struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	template.Spec.Template.Metadata.Annotations = map[string]string{
+		envSecretChecksumAnnotation: envSecretChecksum(service),
+	}
+	patch, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = k8sClient.Clientset.AppsV1().Deployments(service.EnvironmentID).Patch(
+		context.Background(), GetResourceName(service), types.StrategicMergePatchType, patch, metav1.PatchOptions{},
+	)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to restart deployment: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,1260 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FieldErrors collects every invalid field of a request so they can be reported together
+type FieldErrors []dto.FieldError
+
+// Error joins the field errors into a single message
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// Add records an invalid field
+func (e *FieldErrors) Add(field string, format string, args ...interface{}) {
+	*e = append(*e, dto.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil when no field is invalid
+func (e FieldErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// CheckDNSLabel validates a DNS-1123 label (lowercase alphanumerics and '-', at most 63 characters)
+func (e *FieldErrors) CheckDNSLabel(field string, value string) {
+	for _, message := range validation.IsDNS1123Label(value) {
+		e.Add(field, "%s", message)
+	}
+}
+
+// CheckDNS1035Label validates a DNS-1035 label, which Kubernetes Service names must be: a
+// DNS-1123 label that starts with a letter
+func (e *FieldErrors) CheckDNS1035Label(field string, value string) {
+	for _, message := range validation.IsDNS1035Label(value) {
+		e.Add(field, "%s", message)
+	}
+}
+
+// CheckHostname validates a DNS-1123 subdomain such as a custom domain
+func (e *FieldErrors) CheckHostname(field string, value string) {
+	for _, message := range validation.IsDNS1123Subdomain(value) {
+		e.Add(field, "%s", message)
+	}
+}
+
+// CheckQuantity validates a Kubernetes resource quantity (e.g. 500m, 512Mi, 10Gi) that must be positive
+func (e *FieldErrors) CheckQuantity(field string, value string) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		e.Add(field, "%q is not a valid quantity (e.g. 500m, 512Mi, 10Gi)", value)
+		return
+	}
+	if quantity.Sign() <= 0 {
+		e.Add(field, "must be greater than zero")
+	}
+}
+
+// CheckPort validates a TCP port number
+func (e *FieldErrors) CheckPort(field string, port int) {
+	for _, message := range validation.IsValidPortNum(port) {
+		e.Add(field, "%s", message)
+	}
+}
+
+// CheckReplicas validates replica counts and that min does not exceed max
+func (e *FieldErrors) CheckReplicas(prefix string, replicas, minReplicas, maxReplicas int) {
+	if replicas < 0 {
+		e.Add(prefix+"replicas", "must not be negative")
+	}
+	if minReplicas < 0 {
+		e.Add(prefix+"minReplicas", "must not be negative")
+	}
+	if maxReplicas < 0 {
+		e.Add(prefix+"maxReplicas", "must not be negative")
+	}
+	if minReplicas > 0 && maxReplicas > 0 && minReplicas > maxReplicas {
+		e.Add(prefix+"minReplicas", "must not be greater than maxReplicas (%d)", maxReplicas)
+	}
+}
+
+// ValidateServiceRequest validates a service creation request field by field
+func ValidateServiceRequest(req dto.ServiceRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("name", req.Name)
+	if req.CPULimit != "" {
+		errs.CheckQuantity("cpuLimit", req.CPULimit)
+	}
+	if req.MemoryLimit != "" {
+		errs.CheckQuantity("memoryLimit", req.MemoryLimit)
+	}
+	if req.CustomDomain != "" {
+		errs.CheckHostname("customDomain", req.CustomDomain)
+	}
+	checkAnnotations(&errs, "serviceAccountAnnotations", req.ServiceAccountAnnotations)
+	checkPodLabels(&errs, "podLabels", req.PodLabels)
+	checkPodAnnotations(&errs, "podAnnotations", req.PodAnnotations)
+	errs.CheckReplicas("", req.Replicas, req.MinReplicas, req.MaxReplicas)
+
+	switch req.Type {
+	case models.ServiceTypeGit:
+		switch {
+		case req.RepoURL == "":
+			errs.Add("repoUrl", "is required for git services")
+		case !strings.HasPrefix(req.RepoURL, "https://"):
+			// PAT authentication is HTTPS-only
+			errs.Add("repoUrl", "must be an HTTPS URL (e.g. https://github.com/owner/repo.git)")
+		}
+		if !req.IsPublic && req.GitToken == "" {
+			errs.Add("gitToken", "a personal access token is required for private repositories")
+		}
+		if req.Port != 0 {
+			errs.CheckPort("port", req.Port)
+		}
+		checkTLSChallenge(&errs, "tlsChallenge", req.TLSChallenge)
+		if req.ArtifactPath != "" {
+			checkArtifactPath(&errs, "artifactPath", req.ArtifactPath)
+		}
+		checkBuildPlatforms(&errs, "buildPlatforms", req.BuildPlatforms)
+		errs.CheckBuildBackend("buildBackend", req.BuildBackend, BuildCapabilities{MultiArch: len(req.BuildPlatforms) > 1, Secrets: req.BuildSecrets})
+		checkCloneDepth(&errs, "cloneDepth", req.CloneDepth)
+		checkBuildTimeout(&errs, "buildTimeoutMinutes", req.BuildTimeoutMinutes)
+		checkImageSizeBudget(&errs, "", req.MaxImageSize, req.ImageSizeBudgetAction)
+		checkGracefulTermination(&errs, "", req.TerminationGracePeriodSeconds, req.PreStopCommand)
+		checkContainerArgs(&errs, "command", req.Command)
+		checkContainerArgs(&errs, "args", req.Args)
+		checkWorkingDir(&errs, "workingDir", req.WorkingDir)
+		if req.DockerfilePath != "" {
+			checkDockerfilePath(&errs, "dockerfilePath", req.DockerfilePath, req.SparseCheckoutPaths)
+		}
+		checkBuildArgs(&errs, "buildArgs", req.BuildArgs)
+		checkTestCommand(&errs, req.TestCommand, req.TestImage)
+		checkSparseCheckoutPaths(&errs, "sparseCheckoutPaths", req.SparseCheckoutPaths)
+		checkSecretReferences(&errs, "envVars", req.EnvVars)
+		checkEnvVarKeys(&errs, "secretEnvKeys", req.SecretEnvKeys)
+		checkEnvVarKeys(&errs, "buildEnvKeys", req.BuildEnvKeys)
+		checkBuildEnvKeys(&errs, "buildEnvKeys", req.BuildEnvKeys, req.SecretEnvKeys)
+		if req.VPAMode != "" {
+			// Git services scale horizontally with the HPA instead
+			errs.Add("vpaMode", "is only available for managed services")
+		}
+		if len(req.ExternalAllowedCIDRs) > 0 {
+			// Git services are reached through the ingress, not the TCP proxy
+			errs.Add("externalAllowedCidrs", "is only available for managed services")
+		}
+		if req.DatabaseTLS {
+			errs.Add("databaseTls", "is only available for managed services")
+		}
+		if req.Topology != "" {
+			errs.Add("topology", "is only available for managed services")
+		}
+		if req.AutoUpdate != "" || req.MaintenanceWindow != "" {
+			// Git services are updated by deploying a new commit
+			errs.Add("autoUpdate", "is only available for managed services")
+		}
+	case models.ServiceTypeManaged:
+		if req.ManagedType == "" {
+			errs.Add("managedType", "is required for managed services")
+		} else if !IsValidManagedServiceType(req.ManagedType) {
+			errs.Add("managedType", "unsupported managed service type %q", req.ManagedType)
+		} else {
+			checkManagedVersion(&errs, "version", req.ManagedType, req.Version)
+		}
+		if len(req.EnvVars) > 0 {
+			errs.Add("envVars", "environment variables are auto-generated for managed services")
+		}
+		if req.HighAvailability {
+			errs.Add("highAvailability", "is not available for managed services, which run a single replica")
+		}
+		if req.TerminationGracePeriodSeconds != 0 {
+			errs.Add("terminationGracePeriodSeconds", "is not allowed for managed services")
+		}
+		gitFields := []struct{ name, value string }{
+			{"repoUrl", req.RepoURL}, {"branch", req.Branch}, {"buildCommand", req.BuildCommand},
+			{"startCommand", req.StartCommand}, {"gitUsername", req.GitUsername}, {"gitToken", req.GitToken},
+			{"tlsChallenge", req.TLSChallenge}, {"artifactPath", req.ArtifactPath},
+			{"testCommand", req.TestCommand}, {"testImage", req.TestImage}, {"dockerfilePath", req.DockerfilePath},
+			{"preStopCommand", req.PreStopCommand}, {"workingDir", req.WorkingDir},
+		}
+		for _, field := range gitFields {
+			if field.value != "" {
+				errs.Add(field.name, "is not allowed for managed services")
+			}
+		}
+		if len(req.BuildPlatforms) > 0 {
+			errs.Add("buildPlatforms", "is not allowed for managed services")
+		}
+		if len(req.Command) > 0 || len(req.Args) > 0 {
+			// Managed services run the command their image is made for
+			errs.Add("command", "is not allowed for managed services")
+		}
+		if req.BuildBackend != "" {
+			errs.Add("buildBackend", "is not allowed for managed services")
+		}
+		if req.BuildSecrets {
+			errs.Add("buildSecrets", "is not allowed for managed services")
+		}
+		if len(req.SecretEnvKeys) > 0 {
+			errs.Add("secretEnvKeys", "is not allowed for managed services")
+		}
+		if len(req.BuildEnvKeys) > 0 {
+			errs.Add("buildEnvKeys", "is not allowed for managed services")
+		}
+		if req.CloneDepth != 0 {
+			errs.Add("cloneDepth", "is not allowed for managed services")
+		}
+		if req.BuildTimeoutMinutes != 0 {
+			errs.Add("buildTimeoutMinutes", "is not allowed for managed services")
+		}
+		if req.MaxImageSize != "" {
+			errs.Add("maxImageSize", "is not allowed for managed services")
+		}
+		if req.ImageSizeBudgetAction != "" {
+			errs.Add("imageSizeBudgetAction", "is not allowed for managed services")
+		}
+		if len(req.SparseCheckoutPaths) > 0 {
+			errs.Add("sparseCheckoutPaths", "is not allowed for managed services")
+		}
+		if len(req.BuildArgs) > 0 {
+			errs.Add("buildArgs", "is not allowed for managed services")
+		}
+		if req.Port != 0 {
+			errs.Add("port", "is auto-determined for managed services")
+		}
+		if req.StorageSize != "" {
+			errs.CheckQuantity("storageSize", req.StorageSize)
+		}
+		checkPooling(&errs, req.ManagedType, req.PoolingEnabled, req.PoolMode, req.PoolSize, req.MaxClientConn)
+		checkVPAMode(&errs, "vpaMode", req.VPAMode)
+		checkAllowedCIDRs(&errs, "externalAllowedCidrs", req.ExternalAllowedCIDRs)
+		checkDatabaseTLS(&errs, "databaseTls", req.ManagedType, req.DatabaseTLS)
+		checkTopology(&errs, "topology", req.ManagedType, req.Topology)
+		checkAutoUpdate(&errs, "autoUpdate", req.AutoUpdate)
+		checkMaintenanceWindow(&errs, "maintenanceWindow", req.MaintenanceWindow)
+	default:
+		errs.Add("type", "must be one of: git, managed")
+	}
+
+	return errs.Err()
+}
+
+// ValidateServiceUpdateRequest validates the fields present in a service update request
+func ValidateServiceUpdateRequest(req dto.ServiceUpdateRequest) error {
+	var errs FieldErrors
+
+	var base dto.BaseServiceUpdateRequest
+	prefix := req.Type + "."
+	switch {
+	case req.Type == "git" && req.Git != nil:
+		base = req.Git.BaseServiceUpdateRequest
+		if req.Git.Port != nil {
+			errs.CheckPort(prefix+"port", *req.Git.Port)
+		}
+		checkTLSChallenge(&errs, prefix+"tlsChallenge", req.Git.TLSChallenge)
+		if req.Git.ArtifactPath != "" {
+			checkArtifactPath(&errs, prefix+"artifactPath", req.Git.ArtifactPath)
+		}
+		checkBuildPlatforms(&errs, prefix+"buildPlatforms", req.Git.BuildPlatforms)
+		if req.Git.DockerfilePath != nil && *req.Git.DockerfilePath != "" {
+			var sparsePaths []string
+			if req.Git.SparseCheckoutPaths != nil {
+				sparsePaths = *req.Git.SparseCheckoutPaths
+			}
+			checkDockerfilePath(&errs, prefix+"dockerfilePath", *req.Git.DockerfilePath, sparsePaths)
+		}
+		checkBuildArgs(&errs, prefix+"buildArgs", req.Git.BuildArgs)
+		if req.Git.TestImage != "" {
+			checkTestImage(&errs, prefix+"testImage", req.Git.TestImage)
+		}
+		if req.Git.CloneDepth != nil {
+			checkCloneDepth(&errs, prefix+"cloneDepth", *req.Git.CloneDepth)
+		}
+		if req.Git.BuildTimeoutMinutes != nil {
+			checkBuildTimeout(&errs, prefix+"buildTimeoutMinutes", *req.Git.BuildTimeoutMinutes)
+		}
+		if req.Git.TerminationGracePeriodSeconds != nil || req.Git.PreStopCommand != nil {
+			checkGracefulTermination(&errs, prefix, intValue(req.Git.TerminationGracePeriodSeconds), stringValue(req.Git.PreStopCommand))
+		}
+		if req.Git.Command != nil {
+			checkContainerArgs(&errs, prefix+"command", *req.Git.Command)
+		}
+		if req.Git.Args != nil {
+			checkContainerArgs(&errs, prefix+"args", *req.Git.Args)
+		}
+		if req.Git.WorkingDir != nil {
+			checkWorkingDir(&errs, prefix+"workingDir", *req.Git.WorkingDir)
+		}
+		if req.Git.MaxImageSize != nil || req.Git.ImageSizeBudgetAction != nil {
+			checkImageSizeBudget(&errs, prefix, stringValue(req.Git.MaxImageSize), stringValue(req.Git.ImageSizeBudgetAction))
+		}
+		if req.Git.SparseCheckoutPaths != nil {
+			checkSparseCheckoutPaths(&errs, prefix+"sparseCheckoutPaths", *req.Git.SparseCheckoutPaths)
+		}
+		if req.Git.SecretEnvKeys != nil {
+			checkEnvVarKeys(&errs, prefix+"secretEnvKeys", *req.Git.SecretEnvKeys)
+		}
+		if req.Git.BuildEnvKeys != nil {
+			checkEnvVarKeys(&errs, prefix+"buildEnvKeys", *req.Git.BuildEnvKeys)
+			if req.Git.SecretEnvKeys != nil {
+				checkBuildEnvKeys(&errs, prefix+"buildEnvKeys", *req.Git.BuildEnvKeys, *req.Git.SecretEnvKeys)
+			}
+		}
+	case req.Type == "managed" && req.Managed != nil:
+		base = req.Managed.BaseServiceUpdateRequest
+		if req.Managed.StorageSize != "" {
+			errs.CheckQuantity(prefix+"storageSize", req.Managed.StorageSize)
+		}
+		if req.Managed.PoolMode != "" && !IsValidPoolMode(req.Managed.PoolMode) {
+			errs.Add(prefix+"poolMode", "must be one of: session, transaction, statement")
+		}
+		if req.Managed.PoolSize != nil && *req.Managed.PoolSize < 0 {
+			errs.Add(prefix+"poolSize", "must not be negative")
+		}
+		if req.Managed.MaxClientConn != nil && *req.Managed.MaxClientConn < 0 {
+			errs.Add(prefix+"maxClientConn", "must not be negative")
+		}
+		if req.Managed.VPAMode != nil {
+			checkVPAMode(&errs, prefix+"vpaMode", *req.Managed.VPAMode)
+		}
+		if req.Managed.ExternalAllowedCIDRs != nil {
+			checkAllowedCIDRs(&errs, prefix+"externalAllowedCidrs", *req.Managed.ExternalAllowedCIDRs)
+		}
+		if req.Managed.AutoUpdate != nil {
+			checkAutoUpdate(&errs, prefix+"autoUpdate", *req.Managed.AutoUpdate)
+		}
+		if req.Managed.MaintenanceWindow != nil {
+			checkMaintenanceWindow(&errs, prefix+"maintenanceWindow", *req.Managed.MaintenanceWindow)
+		}
+	default:
+		// Structural problems are reported by dto.ValidateServiceUpdateRequest
+		return nil
+	}
+
+	if base.Name != "" {
+		errs.CheckDNSLabel(prefix+"name", base.Name)
+	}
+	if base.CPULimit != "" {
+		errs.CheckQuantity(prefix+"cpuLimit", base.CPULimit)
+	}
+	if base.MemoryLimit != "" {
+		errs.CheckQuantity(prefix+"memoryLimit", base.MemoryLimit)
+	}
+	if base.CustomDomain != "" {
+		errs.CheckHostname(prefix+"customDomain", base.CustomDomain)
+	}
+	checkSecretReferences(&errs, prefix+"envVars", base.EnvVars)
+	checkAnnotations(&errs, prefix+"serviceAccountAnnotations", base.ServiceAccountAnnotations)
+	checkPodLabels(&errs, prefix+"podLabels", base.PodLabels)
+	checkPodAnnotations(&errs, prefix+"podAnnotations", base.PodAnnotations)
+	errs.CheckReplicas(prefix, intValue(base.Replicas), intValue(base.MinReplicas), intValue(base.MaxReplicas))
+
+	return errs.Err()
+}
+
+// ValidateEnvironmentUpdateRequest validates the fields present in an environment update request
+func ValidateEnvironmentUpdateRequest(req dto.EnvironmentUpdateRequest) error {
+	var errs FieldErrors
+
+	if req.Name != "" && strings.TrimSpace(req.Name) == "" {
+		errs.Add("name", "must not be blank")
+	}
+	if req.DefaultCPULimit != nil && *req.DefaultCPULimit != "" {
+		errs.CheckQuantity("defaultCpuLimit", *req.DefaultCPULimit)
+	}
+	if req.DefaultMemoryLimit != nil && *req.DefaultMemoryLimit != "" {
+		errs.CheckQuantity("defaultMemoryLimit", *req.DefaultMemoryLimit)
+	}
+	if req.DefaultReplicas != nil && *req.DefaultReplicas < 0 {
+		errs.Add("defaultReplicas", "must not be negative")
+	}
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"defaultStorageSize", req.DefaultStorageSize},
+		{"minCpuLimit", req.MinCPULimit},
+		{"maxCpuLimit", req.MaxCPULimit},
+		{"minMemoryLimit", req.MinMemoryLimit},
+		{"maxMemoryLimit", req.MaxMemoryLimit},
+		{"maxStorageSize", req.MaxStorageSize},
+	} {
+		if field.value != nil && *field.value != "" {
+			errs.CheckQuantity(field.name, *field.value)
+		}
+	}
+	if req.DomainTemplate != nil {
+		errs.CheckDomainTemplate("domainTemplate", *req.DomainTemplate)
+	}
+
+	return errs.Err()
+}
+
+// ValidateEnvironmentResources checks that an environment's resource bounds are ordered
+// and that its defaults fall within them
+func ValidateEnvironmentResources(env models.Environment) error {
+	var errs FieldErrors
+
+	checkQuantityBounds(&errs, "minCpuLimit", env.MinCPULimit, "", env.MaxCPULimit)
+	checkQuantityBounds(&errs, "defaultCpuLimit", env.DefaultCPULimit, env.MinCPULimit, env.MaxCPULimit)
+	checkQuantityBounds(&errs, "minMemoryLimit", env.MinMemoryLimit, "", env.MaxMemoryLimit)
+	checkQuantityBounds(&errs, "defaultMemoryLimit", env.DefaultMemoryLimit, env.MinMemoryLimit, env.MaxMemoryLimit)
+	checkQuantityBounds(&errs, "defaultStorageSize", env.DefaultStorageSize, "", env.MaxStorageSize)
+
+	return errs.Err()
+}
+
+// CheckEnvironmentResourceRanges validates service resources against the ranges allowed in
+// its environment. Empty values are not checked.
+func CheckEnvironmentResourceRanges(env models.Environment, prefix, cpuLimit, memoryLimit, storageSize string) error {
+	var errs FieldErrors
+
+	checkQuantityBounds(&errs, prefix+"cpuLimit", cpuLimit, env.MinCPULimit, env.MaxCPULimit)
+	checkQuantityBounds(&errs, prefix+"memoryLimit", memoryLimit, env.MinMemoryLimit, env.MaxMemoryLimit)
+	checkQuantityBounds(&errs, prefix+"storageSize", storageSize, "", env.MaxStorageSize)
+
+	return errs.Err()
+}
+
+// checkQuantityBounds reports a quantity outside [min, max]; empty or unparsable values and
+// bounds are skipped, since quantity syntax is checked separately
+func checkQuantityBounds(errs *FieldErrors, field, value, min, max string) {
+	quantity, err := resource.ParseQuantity(value)
+	if value == "" || err != nil {
+		return
+	}
+	if lower, err := resource.ParseQuantity(min); min != "" && err == nil && quantity.Cmp(lower) < 0 {
+		errs.Add(field, "must be at least %s in this environment", min)
+	}
+	if upper, err := resource.ParseQuantity(max); max != "" && err == nil && quantity.Cmp(upper) > 0 {
+		errs.Add(field, "must be at most %s in this environment", max)
+	}
+}
+
+// ValidateManagedServiceFields validates the stored configuration of a managed service
+func ValidateManagedServiceFields(service models.Service) error {
+	var errs FieldErrors
+
+	if !IsValidManagedServiceType(service.ManagedType) {
+		errs.Add("managedType", "unsupported managed service type %q", service.ManagedType)
+	}
+	if service.StorageSize != "" {
+		errs.CheckQuantity("storageSize", service.StorageSize)
+	}
+	if service.CPULimit != "" {
+		errs.CheckQuantity("cpuLimit", service.CPULimit)
+	}
+	if service.MemoryLimit != "" {
+		errs.CheckQuantity("memoryLimit", service.MemoryLimit)
+	}
+	checkPooling(&errs, service.ManagedType, service.PoolingEnabled, service.PoolMode, service.PoolSize, service.MaxClientConn)
+	checkVPAMode(&errs, "vpaMode", service.VPAMode)
+	checkDatabaseTLS(&errs, "databaseTls", service.ManagedType, service.DatabaseTLS)
+	checkTopology(&errs, "topology", service.ManagedType, service.Topology)
+	checkAutoUpdate(&errs, "autoUpdate", service.AutoUpdate)
+	checkMaintenanceWindow(&errs, "maintenanceWindow", service.MaintenanceWindow)
+	if IsAutoUpdateEnabled(service.AutoUpdate) {
+		if service.MaintenanceWindow == "" {
+			errs.Add("maintenanceWindow", "is required for automatic updates")
+		}
+		if !CanAutoUpdateVersion(service.Version) {
+			errs.Add("version", "%q cannot follow the %s channel; pin a version such as 15.4 or 7.2.5", service.Version, service.AutoUpdate)
+		}
+	}
+
+	return errs.Err()
+}
+
+// checkTLSChallenge validates the ACME challenge type; dns01 needs a configured DNS provider
+func checkTLSChallenge(errs *FieldErrors, field, challenge string) {
+	switch challenge {
+	case "", TLSChallengeHTTP01:
+	case TLSChallengeDNS01:
+		if !IsDNS01Enabled() {
+			errs.Add(field, "dns01 requires a DNS provider to be configured (DNS01_PROVIDER)")
+		}
+	default:
+		errs.Add(field, "must be one of: http01, dns01")
+	}
+}
+
+// checkArtifactPath requires an absolute directory without traversal; the path is used
+// in the export job's shell script, so only plain path characters are allowed
+func checkArtifactPath(errs *FieldErrors, field, path string) {
+	if !artifactPathPattern.MatchString(path) || path == "/" {
+		errs.Add(field, "must be an absolute directory such as /app/dist")
+		return
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == ".." {
+			errs.Add(field, "must not contain ..")
+			return
+		}
+	}
+}
+
+// checkSecretReferences requires ${secret:...} references in env var values to name a
+// vault entry; whether the entry exists is checked at deploy time
+func checkSecretReferences(errs *FieldErrors, field string, envVars models.EnvVars) {
+	for key, value := range envVars {
+		if reference := InvalidSecretReference(value); reference != "" {
+			errs.Add(field+"."+key, "invalid secret reference %q, expected ${secret:name}", reference)
+		}
+	}
+}
+
+// checkEnvVarKeys requires secret or build-time env var flags to be valid variable names, each once
+func checkEnvVarKeys(errs *FieldErrors, field string, keys []string) {
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !envNamePattern.MatchString(key) {
+			errs.Add(field, "%q is not a valid environment variable name", key)
+			return
+		}
+		if seen[key] {
+			errs.Add(field, "lists %s more than once", key)
+			return
+		}
+		seen[key] = true
+	}
+}
+
+// checkBuildEnvKeys rejects build-time flags on secret env vars, which are never passed to builds
+func checkBuildEnvKeys(errs *FieldErrors, field string, buildKeys, secretKeys []string) {
+	for _, key := range buildKeys {
+		for _, secretKey := range secretKeys {
+			if key == secretKey {
+				errs.Add(field, "%s is a secret env var, which is never passed to builds", key)
+				return
+			}
+		}
+	}
+}
+
+// checkBuildPlatforms allows each supported target platform at most once
+func checkBuildPlatforms(errs *FieldErrors, field string, platforms []string) {
+	seen := map[string]bool{}
+	for _, platform := range platforms {
+		if !IsValidBuildPlatform(platform) {
+			errs.Add(field, "unsupported platform %q, must be one of: %s", platform, strings.Join(SupportedBuildPlatforms, ", "))
+			return
+		}
+		if seen[platform] {
+			errs.Add(field, "lists %s more than once", platform)
+			return
+		}
+		seen[platform] = true
+	}
+}
+
+// checkDockerfilePath requires a file relative to the repository root that a sparse checkout,
+// if any, includes. Root files are always checked out.
+func checkDockerfilePath(errs *FieldErrors, field, path string, sparsePaths []string) {
+	if !sparseCheckoutPathPattern.MatchString(path) || strings.HasSuffix(path, "/") {
+		errs.Add(field, "%q must be a file relative to the repository root, e.g. docker/api.Dockerfile", path)
+		return
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			errs.Add(field, "%q must not contain . or .. segments", path)
+			return
+		}
+	}
+	dir := ""
+	if slash := strings.LastIndex(path, "/"); slash >= 0 {
+		dir = path[:slash]
+	}
+	if dir == "" || len(sparsePaths) == 0 {
+		return
+	}
+	for _, sparsePath := range sparsePaths {
+		sparsePath = strings.TrimSuffix(sparsePath, "/")
+		if dir == sparsePath || strings.HasPrefix(dir, sparsePath+"/") {
+			return
+		}
+	}
+	errs.Add(field, "%q is outside the sparse checkout paths", path)
+}
+
+// checkBuildArgs requires build arg names to be valid variable names
+func checkBuildArgs(errs *FieldErrors, field string, args map[string]string) {
+	for key := range args {
+		if !envNamePattern.MatchString(key) {
+			errs.Add(field+"."+key, "must be a valid variable name (letters, digits and _, not starting with a digit)")
+		}
+	}
+}
+
+// checkTestCommand requires the image a test command runs in
+func checkTestCommand(errs *FieldErrors, command, image string) {
+	if command != "" && image == "" {
+		errs.Add("testImage", "is required with a test command")
+	}
+	if image != "" {
+		checkTestImage(errs, "testImage", image)
+	}
+}
+
+// checkTestImage requires an image reference such as node:20-alpine or ghcr.io/org/ci@sha256:...
+func checkTestImage(errs *FieldErrors, field, image string) {
+	if strings.ContainsAny(image, " \t\r\n") || strings.HasPrefix(image, "-") || strings.Contains(image, "://") {
+		errs.Add(field, "must be an image reference such as node:20-alpine")
+	}
+}
+
+// checkCloneDepth allows the default (0) or a depth up to MaxCloneDepth
+func checkCloneDepth(errs *FieldErrors, field string, depth int) {
+	if depth < 0 || depth > MaxCloneDepth {
+		errs.Add(field, "must be between 1 and %d, or 0 for the default depth of %d", MaxCloneDepth, DefaultCloneDepth)
+	}
+}
+
+// checkBuildTimeout allows the default (0) or a timeout up to the admin-set cap
+func checkBuildTimeout(errs *FieldErrors, field string, minutes int) {
+	if limit := GetMaxBuildTimeoutMinutes(); minutes < 0 || minutes > limit {
+		errs.Add(field, "must be between 1 and %d minutes, or 0 for the default of %d", limit, DefaultBuildTimeoutMinutes)
+	}
+}
+
+// checkGracefulTermination allows the default grace period (0) or one up to
+// MaxTerminationGracePeriodSeconds, and a preStop command of reasonable length
+func checkGracefulTermination(errs *FieldErrors, prefix string, gracePeriodSeconds int, preStopCommand string) {
+	if gracePeriodSeconds < 0 || gracePeriodSeconds > MaxTerminationGracePeriodSeconds {
+		errs.Add(prefix+"terminationGracePeriodSeconds", "must be between 1 and %d seconds, or 0 for the default of %d",
+			MaxTerminationGracePeriodSeconds, DefaultTerminationGracePeriodSeconds)
+	}
+	if len(preStopCommand) > maxContainerCommand {
+		errs.Add(prefix+"preStopCommand", "must be at most %d characters", maxContainerCommand)
+	}
+}
+
+// maxContainerArgs is the number of entries a command or args override may have
+const maxContainerArgs = 64
+
+// checkContainerArgs limits a command or args override to maxContainerArgs entries of at most
+// maxContainerCommand characters in total
+func checkContainerArgs(errs *FieldErrors, field string, args []string) {
+	if len(args) > maxContainerArgs {
+		errs.Add(field, "must have at most %d entries", maxContainerArgs)
+	}
+	total := 0
+	for _, arg := range args {
+		total += len(arg)
+	}
+	if total > maxContainerCommand {
+		errs.Add(field, "must be at most %d characters in total", maxContainerCommand)
+	}
+}
+
+// checkWorkingDir requires an absolute path in the container, e.g. /app/worker
+func checkWorkingDir(errs *FieldErrors, field string, workingDir string) {
+	if workingDir != "" && !strings.HasPrefix(workingDir, "/") {
+		errs.Add(field, "must be an absolute path such as /app")
+	}
+}
+
+// checkImageSizeBudget requires a positive size, e.g. 500Mi, and a known action
+func checkImageSizeBudget(errs *FieldErrors, prefix string, size string, action string) {
+	if size != "" {
+		if quantity, err := resource.ParseQuantity(size); err != nil || quantity.Sign() <= 0 {
+			errs.Add(prefix+"maxImageSize", "must be a positive size such as 500Mi")
+		}
+	}
+	if action != "" && action != ImageSizeBudgetWarn && action != ImageSizeBudgetFail {
+		errs.Add(prefix+"imageSizeBudgetAction", "must be %s or %s", ImageSizeBudgetWarn, ImageSizeBudgetFail)
+	}
+}
+
+// checkSparseCheckoutPaths requires repository-relative directories, each once. They are passed
+// to the clone script, so only plain path characters are allowed.
+func checkSparseCheckoutPaths(errs *FieldErrors, field string, paths []string) {
+	if len(paths) > MaxSparseCheckoutPaths {
+		errs.Add(field, "must list at most %d directories", MaxSparseCheckoutPaths)
+		return
+	}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if !sparseCheckoutPathPattern.MatchString(path) {
+			errs.Add(field, "%q must be a directory relative to the repository root, e.g. apps/web", path)
+			return
+		}
+		for _, segment := range strings.Split(strings.TrimSuffix(path, "/"), "/") {
+			if segment == "." || segment == ".." {
+				errs.Add(field, "%q must not contain . or .. segments", path)
+				return
+			}
+		}
+		if seen[path] {
+			errs.Add(field, "lists %s more than once", path)
+			return
+		}
+		seen[path] = true
+	}
+}
+
+// checkAllowedCIDRs validates a source allowlist of CIDRs or single IPs
+func checkAllowedCIDRs(errs *FieldErrors, field string, cidrs []string) {
+	if len(cidrs) > MaxAllowedCIDRs {
+		errs.Add(field, "must list at most %d entries", MaxAllowedCIDRs)
+		return
+	}
+	for _, cidr := range cidrs {
+		if !IsValidAllowedCIDR(cidr) {
+			errs.Add(field, "%q must be a CIDR such as 203.0.113.0/24 or an IP address", cidr)
+			return
+		}
+	}
+}
+
+// checkDatabaseTLS allows TLS only for the managed types that support it
+func checkDatabaseTLS(errs *FieldErrors, field, managedType string, enabled bool) {
+	if enabled && !SupportsDatabaseTLS(managedType) {
+		errs.Add(field, "is only available for postgresql, mysql and redis")
+	}
+}
+
+// checkManagedVersion rejects versions that cannot be an image tag; whether the image
+// exists is checked against the registry by VerifyManagedServiceVersion
+func checkManagedVersion(errs *FieldErrors, field, managedType, version string) {
+	if !IsValidManagedServiceVersion(managedType, version) && !imageTagPattern.MatchString(version) {
+		errs.Add(field, "%q is not a valid image tag", version)
+	}
+}
+
+// checkTopology allows only the topologies the managed service catalog offers for the type
+func checkTopology(errs *FieldErrors, field, managedType, topology string) {
+	if !IsValidTopology(managedType, topology) {
+		errs.Add(field, "%q is not available for %s", topology, managedType)
+	}
+}
+
+// checkAutoUpdate validates the version update channel of a managed service
+func checkAutoUpdate(errs *FieldErrors, field, autoUpdate string) {
+	if autoUpdate != "" && autoUpdate != AutoUpdatePinned && !IsAutoUpdateEnabled(autoUpdate) {
+		errs.Add(field, "must be one of: %s, %s, %s", AutoUpdatePinned, AutoUpdatePatch, AutoUpdateMinor)
+	}
+}
+
+// checkMaintenanceWindow validates the cron expression opening the maintenance window
+func checkMaintenanceWindow(errs *FieldErrors, field, window string) {
+	if window == "" {
+		return
+	}
+	if _, err := ParseCron(window); err != nil {
+		errs.Add(field, "%v", err)
+	}
+}
+
+// checkPooling validates PgBouncer settings, which only apply to PostgreSQL
+func checkPooling(errs *FieldErrors, managedType string, enabled bool, mode string, size, maxClientConn int) {
+	if enabled && managedType != "postgresql" {
+		errs.Add("poolingEnabled", "connection pooling is only supported for postgresql, not %s", managedType)
+	}
+	if mode != "" && !IsValidPoolMode(mode) {
+		errs.Add("poolMode", "must be one of: session, transaction, statement")
+	}
+	if size < 0 {
+		errs.Add("poolSize", "must not be negative")
+	}
+	if maxClientConn < 0 {
+		errs.Add("maxClientConn", "must not be negative")
+	}
+}
+
+// checkVPAMode validates the Vertical Pod Autoscaler mode; empty disables it
+func checkVPAMode(errs *FieldErrors, field, mode string) {
+	if mode != "" && !IsValidVPAMode(mode) {
+		errs.Add(field, "must be one of: %s, %s", VPAModeRecommend, VPAModeAuto)
+	}
+}
+
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// checkAnnotations validates annotation keys and the total size Kubernetes accepts
+func checkAnnotations(errs *FieldErrors, field string, annotations map[string]string) {
+	totalSize := 0
+	for key, value := range annotations {
+		for _, message := range validation.IsQualifiedName(key) {
+			errs.Add(field+"."+key, "%s", message)
+		}
+		totalSize += len(key) + len(value)
+	}
+	if totalSize > annotationsMaxBytes {
+		errs.Add(field, "must not exceed %d bytes in total", annotationsMaxBytes)
+	}
+}
+
+// checkPodLabels validates user-defined pod labels and rejects platform-reserved keys
+func checkPodLabels(errs *FieldErrors, field string, labels map[string]string) {
+	for key, value := range labels {
+		if IsReservedPodMetadataKey(key, true) {
+			errs.Add(field+"."+key, "is reserved by the platform")
+			continue
+		}
+		for _, message := range validation.IsQualifiedName(key) {
+			errs.Add(field+"."+key, "%s", message)
+		}
+		for _, message := range validation.IsValidLabelValue(value) {
+			errs.Add(field+"."+key, "%s", message)
+		}
+	}
+}
+
+// checkPodAnnotations validates user-defined pod annotations and rejects platform-reserved keys
+func checkPodAnnotations(errs *FieldErrors, field string, annotations map[string]string) {
+	for key := range annotations {
+		if IsReservedPodMetadataKey(key, false) {
+			errs.Add(field+"."+key, "is reserved by the platform")
+		}
+	}
+	checkAnnotations(errs, field, annotations)
+}
+
+// ValidatePullCredentialRequest validates a pull credential registration
+func ValidatePullCredentialRequest(req dto.PullCredentialRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("name", req.Name)
+	if len(GetPullSecretName(req.Name)) > validation.DNS1123LabelMaxLength {
+		errs.Add("name", "must be no more than %d characters", validation.DNS1123LabelMaxLength-len(GetPullSecretName("")))
+	}
+	checkRegistryServer(&errs, "server", req.Server)
+
+	return errs.Err()
+}
+
+// ValidateEnvImport validates the variables parsed from an imported .env file
+func ValidateEnvImport(envVars models.EnvVars) error {
+	var errs FieldErrors
+
+	if len(envVars) == 0 {
+		errs.Add("content", "defines no variables")
+	}
+	checkSecretReferences(&errs, "content", envVars)
+
+	return errs.Err()
+}
+
+// ValidateEnvVarChange validates a proposed change of a service's current env vars
+func ValidateEnvVarChange(req dto.EnvVarChangeRequest, current models.EnvVars) error {
+	var errs FieldErrors
+
+	if len(req.Set) == 0 && len(req.Unset) == 0 {
+		errs.Add("set", "set or unset at least one variable")
+	}
+	keys := make([]string, 0, len(req.Set))
+	for key := range req.Set {
+		keys = append(keys, key)
+	}
+	checkEnvVarKeys(&errs, "set", keys)
+	checkSecretReferences(&errs, "set", req.Set)
+	for _, key := range req.Unset {
+		if _, ok := current[key]; !ok {
+			errs.Add("unset", "service has no environment variable %s", key)
+		} else if _, ok := req.Set[key]; ok {
+			errs.Add("unset", "%s is also set", key)
+		}
+	}
+
+	return errs.Err()
+}
+
+// ValidateProjectSecretRequest validates a project secrets vault entry
+func ValidateProjectSecretRequest(req dto.ProjectSecretRequest) error {
+	var errs FieldErrors
+
+	if !ProjectSecretNamePattern.MatchString(req.Name) {
+		errs.Add("name", "must start with a letter or underscore and contain only letters, digits and underscores (at most 63 characters)")
+	}
+
+	return errs.Err()
+}
+
+// ValidateSecretStoreRequest validates the connection settings of an external secret manager
+func ValidateSecretStoreRequest(req dto.SecretStoreRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("name", req.Name)
+	switch req.Type {
+	case models.SecretStoreTypeVault:
+		if err := CheckSecretStoreAddress(req.Address); err != nil {
+			errs.Add("address", "%v", err)
+		}
+		if req.Token == "" {
+			errs.Add("token", "is required for Vault stores")
+		}
+	case models.SecretStoreTypeAWS:
+		if req.Region == "" {
+			errs.Add("region", "is required for AWS Secrets Manager stores")
+		}
+		if req.AccessKeyID == "" || req.SecretAccessKey == "" {
+			errs.Add("accessKeyId", "accessKeyId and secretAccessKey are required for AWS Secrets Manager stores")
+		}
+	}
+	checkRefreshMinutes(&errs, "refreshMinutes", req.RefreshMinutes)
+
+	return errs.Err()
+}
+
+// ValidateSecretStoreUpdateRequest validates the fields present in a secret store update
+func ValidateSecretStoreUpdateRequest(req dto.SecretStoreUpdateRequest) error {
+	var errs FieldErrors
+
+	if req.Address != "" {
+		if err := CheckSecretStoreAddress(req.Address); err != nil {
+			errs.Add("address", "%v", err)
+		}
+	}
+	checkRefreshMinutes(&errs, "refreshMinutes", req.RefreshMinutes)
+
+	return errs.Err()
+}
+
+// ValidateGitOpsConfigRequest validates the config repository of a project
+func ValidateGitOpsConfigRequest(req dto.GitOpsConfigRequest) error {
+	var errs FieldErrors
+
+	if err := CheckGitOpsRepoURL(req.RepoURL); err != nil {
+		errs.Add("repoUrl", "%v", err)
+	}
+	if req.Path != "" {
+		if !sparseCheckoutPathPattern.MatchString(req.Path) || strings.HasSuffix(req.Path, "/") {
+			errs.Add("path", "%q must be a file relative to the repository root, e.g. deploy/pendeploy.yaml", req.Path)
+		} else {
+			for _, segment := range strings.Split(req.Path, "/") {
+				if segment == "." || segment == ".." {
+					errs.Add("path", "%q must not contain . or .. segments", req.Path)
+					break
+				}
+			}
+		}
+	}
+	checkRefreshMinutes(&errs, "pollMinutes", req.PollMinutes)
+
+	return errs.Err()
+}
+
+// checkRefreshMinutes allows 0 (sync on deploy only) up to one refresh a week
+func checkRefreshMinutes(errs *FieldErrors, field string, minutes *int) {
+	if minutes != nil && (*minutes < 0 || *minutes > 7*24*60) {
+		errs.Add(field, "must be between 0 and %d", 7*24*60)
+	}
+}
+
+// ValidateMaintenanceTaskRequest validates a new maintenance task of an environment
+func ValidateMaintenanceTaskRequest(req dto.MaintenanceTaskRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("name", req.Name)
+	checkMaintenanceTaskFields(&errs, req.Schedule, req.Command, req.EnvVars, req.CPULimit, req.MemoryLimit, req.TimeoutMinutes)
+
+	return errs.Err()
+}
+
+// ValidateMaintenanceTaskUpdateRequest validates the changed fields of a maintenance task
+func ValidateMaintenanceTaskUpdateRequest(req dto.MaintenanceTaskUpdateRequest) error {
+	var errs FieldErrors
+
+	if req.Schedule != nil && *req.Schedule == "" {
+		errs.Add("schedule", "must not be empty")
+	}
+	if req.Command != nil && strings.TrimSpace(*req.Command) == "" {
+		errs.Add("command", "must not be empty")
+	}
+	var envVars models.EnvVars
+	if req.EnvVars != nil {
+		envVars = *req.EnvVars
+	}
+	checkMaintenanceTaskFields(&errs, stringValue(req.Schedule), stringValue(req.Command), envVars,
+		stringValue(req.CPULimit), stringValue(req.MemoryLimit), intValue(req.TimeoutMinutes))
+
+	return errs.Err()
+}
+
+// checkMaintenanceTaskFields validates the fields shared by creating and updating a
+// maintenance task; empty values are left to the defaults or the stored task
+func checkMaintenanceTaskFields(errs *FieldErrors, schedule, command string, envVars models.EnvVars, cpuLimit, memoryLimit string, timeoutMinutes int) {
+	if schedule != "" {
+		if _, err := ParseCron(schedule); err != nil {
+			errs.Add("schedule", "%v", err)
+		}
+	}
+	if command != "" && strings.TrimSpace(command) == "" {
+		errs.Add("command", "must not be empty")
+	}
+	for key, value := range envVars {
+		if !envNamePattern.MatchString(key) {
+			errs.Add("envVars."+key, "is not a valid environment variable name")
+		}
+		if HasSecretReferences(value) {
+			errs.Add("envVars."+key, "secret references are not resolved in maintenance tasks; set inheritEnv to pass the service's secrets")
+		}
+	}
+	if cpuLimit != "" {
+		errs.CheckQuantity("cpuLimit", cpuLimit)
+	}
+	if memoryLimit != "" {
+		errs.CheckQuantity("memoryLimit", memoryLimit)
+	}
+	if timeoutMinutes < 0 || timeoutMinutes > MaxMaintenanceTaskTimeoutMinutes {
+		errs.Add("timeoutMinutes", "must be between 1 and %d minutes, or 0 for the default", MaxMaintenanceTaskTimeoutMinutes)
+	}
+}
+
+// ValidateStorageExpansionRequest validates the new size of a service's volumes
+func ValidateStorageExpansionRequest(req dto.StorageExpansionRequest) error {
+	var errs FieldErrors
+
+	errs.CheckQuantity("size", req.Size)
+
+	return errs.Err()
+}
+
+// ValidateLogDrainRequest validates a log drain registration
+func ValidateLogDrainRequest(req dto.LogDrainRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("name", req.Name)
+	checkLogDrainEndpoint(&errs, "endpoint", req.Type, req.Endpoint)
+	if req.Type == models.LogDrainTypeDatadog && req.Token == "" {
+		errs.Add("token", "is required for Datadog drains")
+	}
+
+	return errs.Err()
+}
+
+// ValidateLogDrainEndpoint validates a new endpoint for a drain of the given type
+func ValidateLogDrainEndpoint(drainType, endpoint string) error {
+	var errs FieldErrors
+	checkLogDrainEndpoint(&errs, "endpoint", drainType, endpoint)
+	return errs.Err()
+}
+
+// checkLogDrainEndpoint validates a sink URL; only Loki, often run in-cluster, may use plain HTTP
+func checkLogDrainEndpoint(errs *FieldErrors, field, drainType, endpoint string) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		errs.Add(field, "must be an absolute URL")
+		return
+	}
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && drainType == models.LogDrainTypeLoki) {
+		errs.Add(field, "must use https")
+	}
+	if parsed.User != nil {
+		errs.Add(field, "must not contain credentials; use username and token")
+	}
+}
+
+// ValidateUptimeMonitorRequest validates the health path probed by the uptime checker
+func ValidateUptimeMonitorRequest(req dto.UptimeMonitorRequest) error {
+	var errs FieldErrors
+
+	checkURLPath(&errs, "path", req.Path)
+
+	return errs.Err()
+}
+
+// ValidateLoadTestRequest validates the target path of a load test
+func ValidateLoadTestRequest(req dto.LoadTestRequest) error {
+	var errs FieldErrors
+
+	checkURLPath(&errs, "path", req.Path)
+
+	return errs.Err()
+}
+
+// checkURLPath validates an optional request path probed inside the cluster
+func checkURLPath(errs *FieldErrors, field, path string) {
+	if path == "" {
+		return
+	}
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n#") {
+		errs.Add(field, "must be a URL path starting with /")
+	} else if len(path) > 2048 {
+		errs.Add(field, "must be no more than 2048 characters")
+	}
+}
+
+// ValidateStatusPageRequest validates a status page configuration
+func ValidateStatusPageRequest(req dto.StatusPageRequest) error {
+	var errs FieldErrors
+
+	errs.CheckDNSLabel("slug", req.Slug)
+	if len(req.Title) > 200 {
+		errs.Add("title", "must be no more than 200 characters")
+	}
+
+	return errs.Err()
+}
+
+// ValidateRegistryCopyRequest validates the repositories and references of an image copy
+func ValidateRegistryCopyRequest(req dto.RegistryCopyRequest) error {
+	var errs FieldErrors
+
+	checkRepositoryName(&errs, "repository", req.Repository)
+	checkImageReference(&errs, "tag", req.Tag)
+	if req.TargetRepository != "" {
+		checkRepositoryName(&errs, "targetRepository", req.TargetRepository)
+	}
+	if req.TargetTag != "" {
+		checkImageReference(&errs, "targetTag", req.TargetTag)
+	}
+
+	return errs.Err()
+}
+
+// ValidateCreateRegistryRequest validates the mode and upstream of a new registry
+func ValidateCreateRegistryRequest(req dto.CreateRegistryRequest) error {
+	var errs FieldErrors
+
+	switch req.Mode {
+	case "", models.RegistryModeStandard:
+		if req.ProxyRemoteURL != "" || req.ProxyUsername != "" || req.ProxyPassword != "" {
+			errs.Add("mode", "must be proxy when proxy settings are given")
+		}
+	case models.RegistryModeProxy:
+		checkRegistryProxy(&errs, req.ProxyRemoteURL, req.ProxyUsername, req.ProxyPassword)
+	default:
+		errs.Add("mode", "must be standard or proxy")
+	}
+	if req.StorageSize != "" {
+		errs.CheckQuantity("storageSize", req.StorageSize)
+	}
+
+	return errs.Err()
+}
+
+// ValidateUpdateRegistryRequest validates changed proxy, replica and volume settings of a registry
+func ValidateUpdateRegistryRequest(req dto.UpdateRegistryRequest) error {
+	var errs FieldErrors
+	checkRegistryProxy(&errs, req.ProxyRemoteURL, req.ProxyUsername, req.ProxyPassword)
+	if req.Replicas < 0 || req.Replicas > 10 {
+		errs.Add("replicas", "must be between 1 and 10")
+	}
+	if req.StorageSize != "" {
+		errs.CheckQuantity("storageSize", req.StorageSize)
+	}
+	return errs.Err()
+}
+
+// checkRegistryProxy validates the upstream of a pull-through cache; credentials come as a pair
+func checkRegistryProxy(errs *FieldErrors, remoteURL, username, password string) {
+	if remoteURL != "" {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil || parsed.Host == "" {
+			errs.Add("proxyRemoteUrl", "must be an absolute URL")
+		} else {
+			if parsed.Scheme != "https" {
+				errs.Add("proxyRemoteUrl", "must use https")
+			}
+			if parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") {
+				errs.Add("proxyRemoteUrl", "must be a registry base URL without credentials or path")
+			}
+		}
+	}
+	if (username == "") != (password == "") {
+		errs.Add("proxyPassword", "must be given together with proxyUsername")
+	}
+}
+
+// ValidateRegistryStorageRequest validates a storage backend switch; an S3 backend uses
+// either a managed MinIO service or a bucket with credentials (AWS when no endpoint is given)
+func ValidateRegistryStorageRequest(req dto.RegistryStorageRequest) error {
+	var errs FieldErrors
+
+	switch req.Backend {
+	case models.RegistryStorageFilesystem:
+		if req.ServiceID != "" || req.Endpoint != "" || req.Bucket != "" || req.AccessKey != "" || req.SecretKey != "" {
+			errs.Add("backend", "must be s3 when bucket settings are given")
+		}
+	case models.RegistryStorageS3:
+		if req.Bucket != "" && !IsValidBucketName(req.Bucket) {
+			errs.Add("bucket", "must be a valid S3 bucket name")
+		}
+		if req.ServiceID != "" {
+			if req.Endpoint != "" || req.AccessKey != "" || req.SecretKey != "" {
+				errs.Add("serviceId", "cannot be combined with endpoint or credentials")
+			}
+			break
+		}
+		if req.Bucket == "" {
+			errs.Add("bucket", "is required for external S3")
+		}
+		if req.AccessKey == "" || req.SecretKey == "" {
+			errs.Add("accessKey", "accessKey and secretKey are required for external S3")
+		}
+		if req.Endpoint != "" {
+			parsed, err := url.Parse(req.Endpoint)
+			if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+				errs.Add("endpoint", "must be an absolute http(s) URL")
+			} else if parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") {
+				errs.Add("endpoint", "must be a base URL without credentials or path")
+			}
+		}
+	default:
+		errs.Add("backend", "must be filesystem or s3")
+	}
+	if len(req.Region) > 64 || strings.ContainsAny(req.Region, " :\n") {
+		errs.Add("region", "must be a region name such as us-east-1")
+	}
+
+	return errs.Err()
+}
+
+// checkRepositoryName validates a repository name of the distribution spec
+func checkRepositoryName(errs *FieldErrors, field, name string) {
+	if !repositoryNamePattern.MatchString(name) || len(name) > 255 {
+		errs.Add(field, "must be lowercase path components separated by / (e.g. team/app)")
+	}
+}
+
+// checkImageReference validates a tag or a sha256 digest
+func checkImageReference(errs *FieldErrors, field, reference string) {
+	if !imageTagPattern.MatchString(reference) && !imageDigestPattern.MatchString(reference) {
+		errs.Add(field, "must be a tag or a sha256 digest")
+	}
+}
+
+// checkRegistryServer validates a registry host with optional port; schemes and paths
+// are rejected because the kubelet matches credentials by host
+func checkRegistryServer(errs *FieldErrors, field, server string) {
+	host := server
+	if colon := strings.LastIndex(server, ":"); colon >= 0 {
+		host = server[:colon]
+		port, err := strconv.Atoi(server[colon+1:])
+		if err != nil {
+			errs.Add(field, "must be a registry host such as ghcr.io or registry.example.com:5000")
+			return
+		}
+		errs.CheckPort(field, port)
+	}
+	errs.CheckHostname(field, host)
+}
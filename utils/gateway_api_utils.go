@@ -0,0 +1,408 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LabelTCPRoute marks the TCPRoutes publishing managed services' TCP ports
+const LabelTCPRoute = "pendeploy.io/tcp-route"
+
+// maxGatewayListeners is the number of listeners the Gateway API allows on a Gateway
+const maxGatewayListeners = 64
+
+var (
+	gatewayClassGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gatewayclasses"}
+	gatewayGVR      = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+	httpRouteGVR    = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	// TCPRoute is only in the Gateway API's experimental channel
+	tcpRouteGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "tcproutes"}
+)
+
+// GatewayConfig describes the Gateways routes are attached to
+type GatewayConfig struct {
+	ClassName string
+	HTTPPort  int
+	HTTPSPort int
+	// SharedNamespace and SharedName name an existing Gateway HTTPRoutes attach to instead
+	// of one Gateway per route; its listeners and certificates are managed outside the platform
+	SharedNamespace string
+	SharedName      string
+}
+
+// GetGatewayConfig reads the Gateway settings: GATEWAY_CLASS (default traefik), the listener
+// ports GATEWAY_HTTP_PORT and GATEWAY_HTTPS_PORT (default 80 and 443; Traefik needs the
+// ports of its entrypoints, 8000 and 8443) and GATEWAY_REF, a namespace/name shared Gateway
+func GetGatewayConfig() GatewayConfig {
+	config := GatewayConfig{
+		ClassName: getEnvString("GATEWAY_CLASS", "traefik"),
+		HTTPPort:  getEnvInt("GATEWAY_HTTP_PORT", 80),
+		HTTPSPort: getEnvInt("GATEWAY_HTTPS_PORT", 443),
+	}
+	if ref := getEnvString("GATEWAY_REF", ""); ref != "" {
+		namespace, name, ok := strings.Cut(ref, "/")
+		if !ok {
+			namespace, name = "default", ref
+		}
+		config.SharedNamespace, config.SharedName = namespace, name
+	}
+	return config
+}
+
+// gatewayProvider renders Gateway API resources instead of Ingresses, for clusters where
+// Ingress is deprecated. Ingress objects are still built and converted, so every builder
+// works unchanged.
+type gatewayProvider struct{}
+
+func (gatewayProvider) Name() string { return IngressProviderGateway }
+
+// IngressClassName is unused: the Gateway's class selects the controller
+func (gatewayProvider) IngressClassName() *string { return nil }
+
+func (gatewayProvider) TLSAnnotations() map[string]string { return map[string]string{} }
+
+func (gatewayProvider) SupportsMiddlewares() bool { return false }
+
+// ExposeTCP publishes plain TCP services with TCPRoutes on a Gateway next to the TCP proxy.
+// Services terminating TLS at the proxy or restricted to source CIDRs, and those beyond the
+// Gateway's listener limit, stay on the platform's TCP proxy.
+func (gatewayProvider) ExposeTCP(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) ([]models.Service, error) {
+	if err := applyNginxTCPServices(ctx, client, cfg, nil); err != nil {
+		log.Printf("Warning - failed to remove the TCP ports published through NGINX: %v", err)
+	}
+
+	var published, proxied []models.Service
+	for _, service := range services {
+		switch {
+		case !isTCPProxyService(service):
+		case terminatesTLSAtProxy(service) || len(GetExternalAllowedCIDRs(service)) > 0 || len(published) >= maxGatewayListeners:
+			proxied = append(proxied, service)
+		default:
+			published = append(published, service)
+		}
+	}
+	if err := applyGatewayTCPRoutes(ctx, client, cfg, published); err != nil {
+		return nil, err
+	}
+	return proxied, nil
+}
+
+// ApplyIngressRouting applies an Ingress, or its Gateway API rendering under the gateway
+// provider, for callers without a platform client
+func ApplyIngressRouting(ctx context.Context, ingress *networkingv1.Ingress) error {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	return applyIngress(ctx, client, ingress)
+}
+
+// DeleteGatewayRouting deletes the Gateway API rendering of an Ingress, if any
+func DeleteGatewayRouting(ctx context.Context, namespace string, name string) error {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	return deleteGatewayRouting(ctx, client, namespace, name)
+}
+
+// applyGatewayRouting applies the Gateway API rendering of an Ingress and deletes the
+// Ingress itself, left from before a switch to the gateway provider
+func applyGatewayRouting(ctx context.Context, client *kubernetes.Client, ingress *networkingv1.Ingress) error {
+	config := GetGatewayConfig()
+	gateway, routes := buildGatewayRouting(ingress, config)
+	if gateway != nil {
+		if err := applyGatewayResource(ctx, client, gatewayGVR, gateway); err != nil {
+			return err
+		}
+	}
+	for _, route := range routes {
+		if err := applyGatewayResource(ctx, client, httpRouteGVR, route); err != nil {
+			return err
+		}
+	}
+
+	err := client.Clientset.NetworkingV1().Ingresses(ingress.Namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Printf("Warning - failed to delete Ingress %s replaced by an HTTPRoute: %v", ingress.Name, err)
+	}
+	return nil
+}
+
+// deleteGatewayRouting deletes the Gateway and HTTPRoutes rendered for an Ingress
+func deleteGatewayRouting(ctx context.Context, client *kubernetes.Client, namespace string, name string) error {
+	deletions := []struct {
+		gvr  schema.GroupVersionResource
+		name string
+	}{
+		{httpRouteGVR, name},
+		{httpRouteGVR, name + "-http"},
+		{gatewayGVR, name},
+	}
+	for _, deletion := range deletions {
+		err := client.DynamicClient.Resource(deletion.gvr).Namespace(namespace).Delete(ctx, deletion.name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s: %v", deletion.gvr.Resource, deletion.name, err)
+		}
+	}
+	return nil
+}
+
+// buildGatewayRouting converts an Ingress into a Gateway with an HTTPS listener per TLS host,
+// an HTTPRoute to its backends and one redirecting plain HTTP to HTTPS. With a shared
+// Gateway only the HTTPRoute is rendered and the Gateway is nil. The platform's Ingresses
+// route every host the same way, so the route lists the paths of all rules once.
+func buildGatewayRouting(ingress *networkingv1.Ingress, config GatewayConfig) (*unstructured.Unstructured, []*unstructured.Unstructured) {
+	metadata := func(name string) map[string]interface{} {
+		labels := map[string]interface{}{}
+		for key, value := range ingress.Labels {
+			labels[key] = value
+		}
+		meta := map[string]interface{}{
+			"name":      name,
+			"namespace": ingress.Namespace,
+			"labels":    labels,
+		}
+		if len(ingress.OwnerReferences) > 0 {
+			owners := make([]interface{}, 0, len(ingress.OwnerReferences))
+			for _, owner := range ingress.OwnerReferences {
+				owners = append(owners, map[string]interface{}{
+					"apiVersion": owner.APIVersion,
+					"kind":       owner.Kind,
+					"name":       owner.Name,
+					"uid":        string(owner.UID),
+				})
+			}
+			meta["ownerReferences"] = owners
+		}
+		return meta
+	}
+
+	var hostnames []interface{}
+	seenHosts := map[string]bool{}
+	var rules []interface{}
+	seenPaths := map[string]bool{}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" && !seenHosts[rule.Host] {
+			seenHosts[rule.Host] = true
+			hostnames = append(hostnames, rule.Host)
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil || seenPaths[path.Path] {
+				continue
+			}
+			seenPaths[path.Path] = true
+			rules = append(rules, map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": path.Path}},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": path.Backend.Service.Name, "port": int64(path.Backend.Service.Port.Number)},
+				},
+			})
+		}
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   metadata(ingress.Name),
+		"spec": map[string]interface{}{
+			"hostnames": hostnames,
+			"rules":     rules,
+		},
+	}}
+	if config.SharedName != "" {
+		route.Object["spec"].(map[string]interface{})["parentRefs"] = []interface{}{
+			map[string]interface{}{"name": config.SharedName, "namespace": config.SharedNamespace},
+		}
+		return nil, []*unstructured.Unstructured{route}
+	}
+
+	listeners := []interface{}{
+		map[string]interface{}{"name": "http", "protocol": "HTTP", "port": int64(config.HTTPPort)},
+	}
+	var httpsRefs []interface{}
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			name := fmt.Sprintf("https-%d", len(httpsRefs))
+			listeners = append(listeners, map[string]interface{}{
+				"name":     name,
+				"hostname": host,
+				"protocol": "HTTPS",
+				"port":     int64(config.HTTPSPort),
+				"tls": map[string]interface{}{
+					"mode":            "Terminate",
+					"certificateRefs": []interface{}{map[string]interface{}{"kind": "Secret", "name": tls.SecretName}},
+				},
+			})
+			httpsRefs = append(httpsRefs, map[string]interface{}{"name": ingress.Name, "sectionName": name})
+		}
+	}
+	route.Object["spec"].(map[string]interface{})["parentRefs"] = httpsRefs
+
+	gatewayMetadata := metadata(ingress.Name)
+	// cert-manager's Gateway support issues the listeners' certificates like it does for Ingresses
+	if issuer := ingress.Annotations["cert-manager.io/cluster-issuer"]; issuer != "" {
+		gatewayMetadata["annotations"] = map[string]interface{}{"cert-manager.io/cluster-issuer": issuer}
+	}
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   gatewayMetadata,
+		"spec": map[string]interface{}{
+			"gatewayClassName": config.ClassName,
+			"listeners":        listeners,
+		},
+	}}
+
+	redirect := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   metadata(ingress.Name + "-http"),
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": ingress.Name, "sectionName": "http"}},
+			"hostnames":  hostnames,
+			"rules": []interface{}{
+				map[string]interface{}{
+					"filters": []interface{}{
+						map[string]interface{}{
+							"type":            "RequestRedirect",
+							"requestRedirect": map[string]interface{}{"scheme": "https", "statusCode": int64(301)},
+						},
+					},
+				},
+			},
+		},
+	}}
+	return gateway, []*unstructured.Unstructured{route, redirect}
+}
+
+// getTCPGatewayName returns the name of the Gateway TCP ports are published on
+func getTCPGatewayName(cfg TCPProxyConfig) string {
+	return cfg.Name + "-gateway"
+}
+
+// applyGatewayTCPRoutes makes the TCP ports of the services the only listeners of the TCP
+// Gateway, with a TCPRoute per service, and removes the Gateway when there are none
+func applyGatewayTCPRoutes(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) error {
+	gatewayName := getTCPGatewayName(cfg)
+	desired := map[string]bool{}
+
+	if len(services) > 0 {
+		sorted := append([]models.Service(nil), services...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ExternalPort < sorted[j].ExternalPort })
+
+		listeners := make([]interface{}, 0, len(sorted))
+		for _, service := range sorted {
+			listeners = append(listeners, map[string]interface{}{
+				"name":     fmt.Sprintf("tcp-%d", service.ExternalPort),
+				"protocol": "TCP",
+				"port":     int64(service.ExternalPort),
+				"allowedRoutes": map[string]interface{}{
+					"namespaces": map[string]interface{}{"from": "All"},
+					"kinds":      []interface{}{map[string]interface{}{"kind": "TCPRoute"}},
+				},
+			})
+		}
+		gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "Gateway",
+			"metadata":   map[string]interface{}{"name": gatewayName, "namespace": cfg.Namespace},
+			"spec": map[string]interface{}{
+				"gatewayClassName": GetGatewayConfig().ClassName,
+				"listeners":        listeners,
+			},
+		}}
+		if err := applyGatewayResource(ctx, client, gatewayGVR, gateway); err != nil {
+			return err
+		}
+
+		for _, service := range sorted {
+			labels := map[string]interface{}{LabelTCPRoute: "true"}
+			for key, value := range GetResourceLabels(service) {
+				labels[key] = value
+			}
+			route := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "gateway.networking.k8s.io/v1alpha2",
+				"kind":       "TCPRoute",
+				"metadata": map[string]interface{}{
+					"name":      GetResourceName(service) + "-tcp",
+					"namespace": service.EnvironmentID,
+					"labels":    labels,
+				},
+				"spec": map[string]interface{}{
+					"parentRefs": []interface{}{map[string]interface{}{
+						"name":        gatewayName,
+						"namespace":   cfg.Namespace,
+						"sectionName": fmt.Sprintf("tcp-%d", service.ExternalPort),
+					}},
+					"rules": []interface{}{map[string]interface{}{
+						"backendRefs": []interface{}{map[string]interface{}{"name": GetResourceName(service), "port": int64(service.Port)}},
+					}},
+				},
+			}}
+			if err := applyGatewayResource(ctx, client, tcpRouteGVR, route); err != nil {
+				return err
+			}
+			desired[service.EnvironmentID+"/"+route.GetName()] = true
+		}
+	}
+
+	routes, err := client.DynamicClient.Resource(tcpRouteGVR).List(ctx, metav1.ListOptions{LabelSelector: LabelTCPRoute + "=true"})
+	if apierrors.IsNotFound(err) && len(services) == 0 {
+		// The Gateway API's TCPRoute is not installed, so nothing was ever published
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list TCPRoutes: %v", err)
+	}
+	for _, route := range routes.Items {
+		if desired[route.GetNamespace()+"/"+route.GetName()] {
+			continue
+		}
+		err := client.DynamicClient.Resource(tcpRouteGVR).Namespace(route.GetNamespace()).Delete(ctx, route.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete TCPRoute %s: %v", route.GetName(), err)
+		}
+	}
+	if len(services) == 0 {
+		err := client.DynamicClient.Resource(gatewayGVR).Namespace(cfg.Namespace).Delete(ctx, gatewayName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Gateway %s: %v", gatewayName, err)
+		}
+	}
+	return nil
+}
+
+// applyGatewayResource creates or updates a Gateway API resource
+func applyGatewayResource(ctx context.Context, client *kubernetes.Client, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	resources := client.DynamicClient.Resource(gvr).Namespace(obj.GetNamespace())
+	existing, err := resources.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = resources.Create(ctx, obj, metav1.CreateOptions{})
+	case err == nil:
+		obj.SetResourceVersion(existing.GetResourceVersion())
+		_, err = resources.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply %s %s (are the Gateway API CRDs installed?): %v", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
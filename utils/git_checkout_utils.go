@@ -1,10 +1,84 @@
 package utils
 
-import "fmt"
-
-func getCheckoutCommand(commitSHA string) string {
-    if commitSHA == "" {
-        return ""
-    }
-    return fmt.Sprintf("&& cd /workspace && echo 'Checking out commit %s...' && git fetch origin %s && git checkout %s && echo 'Commit checkout completed'", commitSHA, commitSHA, commitSHA)
-}
\ No newline at end of file
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+)
+
+const (
+	// DefaultCloneDepth is the history depth cloned when a service does not set one
+	DefaultCloneDepth = 1
+	// MaxCloneDepth bounds the configurable depth; deeper histories are fetched by the full-clone fallback
+	MaxCloneDepth = 10000
+	// MaxSparseCheckoutPaths is the number of directories a sparse checkout may list
+	MaxSparseCheckoutPaths = 20
+)
+
+// sparseCheckoutPathPattern matches repository-relative directories such as apps/web
+var sparseCheckoutPathPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*/?$`)
+
+// GetCloneDepth returns the history depth cloned for the service's builds
+func GetCloneDepth(service models.Service) int {
+	if service.CloneDepth <= 0 {
+		return DefaultCloneDepth
+	}
+	return service.CloneDepth
+}
+
+// GetSparseCheckoutPaths returns the directories the service's builds check out; empty checks
+// out the whole repository
+func GetSparseCheckoutPaths(service models.Service) []string {
+	var paths []string
+	for _, path := range strings.Split(service.SparseCheckoutPaths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// getCloneScript returns the shell commands that clone the branch into /workspace and check
+// out commitSHA when set. Sparse checkouts are partial clones in cone mode: only the files at
+// the repository root (e.g. the Dockerfile) and the listed directories are downloaded. When
+// commitSHA cannot be fetched at the configured depth, the full history is fetched instead.
+func getCloneScript(service models.Service, repoURL string, branch string, commitSHA string) string {
+	depth := GetCloneDepth(service)
+	paths := GetSparseCheckoutPaths(service)
+
+	cloneFlags := fmt.Sprintf("--branch %s --single-branch --depth %d", branch, depth)
+	if len(paths) > 0 {
+		cloneFlags += " --filter=blob:none --no-checkout"
+	}
+
+	var script strings.Builder
+	script.WriteString(fmt.Sprintf(`
+                                echo "=== Starting git clone (depth %d) ==="
+                                git clone %s %s /workspace || exit 1
+                                cd /workspace`, depth, cloneFlags, repoURL))
+
+	if len(paths) > 0 {
+		script.WriteString(fmt.Sprintf(`
+                                echo "Sparse checkout of: %s"
+                                git sparse-checkout set --cone %s || exit 1`, strings.Join(paths, " "), strings.Join(paths, " ")))
+		if commitSHA == "" {
+			script.WriteString(fmt.Sprintf(`
+                                git checkout %s || exit 1`, branch))
+		}
+	}
+
+	if commitSHA != "" {
+		script.WriteString(fmt.Sprintf(`
+                                echo "Checking out commit %s..."
+                                if ! git fetch --depth %d origin %s; then
+                                    echo "Commit %s is not reachable at depth %d, falling back to a full clone"
+                                    git fetch --unshallow origin '+refs/heads/*:refs/remotes/origin/*' || exit 1
+                                fi
+                                git checkout %s || exit 1
+                                echo "Commit checkout completed"`, commitSHA, depth, commitSHA, commitSHA, depth, commitSHA))
+	}
+
+	return script.String()
+}
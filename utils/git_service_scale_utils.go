@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleGitService scales a git service's Deployment to zero or back to its configured
+// replicas. The HPA is removed while scaled down (it would scale the service back up)
+// and recreated when an autoscaled service is scaled up again.
+func ScaleGitService(service models.Service, scaleDown bool) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx := context.Background()
+	resourceName := GetResourceName(service)
+	namespace := service.EnvironmentID
+
+	replicas := int32(service.Replicas)
+	if !service.IsStaticReplica {
+		replicas = int32(service.MinReplicas)
+	}
+	if scaleDown {
+		replicas = 0
+		if err := deleteHPA(ctx, k8sClient, namespace, resourceName); err != nil {
+			return fmt.Errorf("failed to remove HPA: %v", err)
+		}
+	}
+
+	scale, err := k8sClient.Clientset.AppsV1().Deployments(namespace).GetScale(ctx, resourceName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// Never deployed, nothing to scale
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Deployment scale: %v", err)
+	}
+	scale.Spec.Replicas = replicas
+	if _, err := k8sClient.Clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, resourceName, scale, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale Deployment: %v", err)
+	}
+
+	if !scaleDown && !service.IsStaticReplica {
+		owner, err := ensureServiceOwner(ctx, k8sClient, service)
+		if err != nil {
+			return err
+		}
+		if err := handleHPA(ctx, k8sClient, service, owner); err != nil {
+			return fmt.Errorf("failed to restore HPA: %v", err)
+		}
+	}
+
+	log.Printf("Scaled git service %s to %d replica(s)", service.Name, replicas)
+	return nil
+}
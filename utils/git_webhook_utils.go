@@ -3,10 +3,13 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/pendeploy-simple/models"
 )
 
 // SendWebhookNotification sends a notification to a webhook URL with deployment status and optional error message
@@ -16,19 +19,35 @@ func SendWebhookNotification(webhookUrl string, deploymentID string, status stri
 		return
 	}
 	
-	// Sanitize the webhook URL - remove any whitespace or newlines
-	webhookUrl = strings.TrimSpace(webhookUrl)
+	jsonPayload, err := BuildWebhookPayload(deploymentID, status, errorMessage)
+	if err != nil {
+		log.Printf("Error marshaling webhook payload: %v", err)
+		return
+	}
 	
+	if err := PostWebhook(webhookUrl, jsonPayload); err != nil {
+		log.Printf("Error calling webhook: %v", err)
+		return
+	}
+	
+	log.Printf("Webhook notification sent to %s, status: %s, deployment: %s", 
+		strings.TrimSpace(webhookUrl), status, deploymentID)
+}
+
+// BuildWebhookPayload builds the JSON body of a deployment status notification
+func BuildWebhookPayload(deploymentID string, status string, errorMessage string) ([]byte, error) {
+	return BuildDeploymentWebhookPayload(deploymentID, status, errorMessage, nil, nil)
+}
+
+// BuildDeploymentWebhookPayload builds the JSON body of a deployment status notification,
+// including the classified cause of a failed build and the post-deployment health check
+// when there is one
+func BuildDeploymentWebhookPayload(deploymentID string, status string, errorMessage string, buildFailure *BuildFailure, healthCheck *models.DeploymentHealthCheck) ([]byte, error) {
 	// Safety check for deploymentID
 	if deploymentID == "" {
 		log.Printf("Warning: Empty deploymentID in webhook notification")
 	}
 	
-	// Clean error message if present to prevent JSON parsing errors
-	if errorMessage != "" {
-		errorMessage = strings.ReplaceAll(errorMessage, "\n", " ")
-	}
-	
 	// Prepare webhook payload
 	payload := map[string]interface{}{
 		"deploymentId": deploymentID,
@@ -36,29 +55,37 @@ func SendWebhookNotification(webhookUrl string, deploymentID string, status stri
 		"timestamp":   time.Now().Format(time.RFC3339),
 	}
 	
-	// Add error message if provided
+	// Add error message if provided, cleaned to prevent JSON parsing errors
 	if errorMessage != "" {
-		payload["error"] = errorMessage
+		payload["error"] = strings.ReplaceAll(errorMessage, "\n", " ")
 	}
-	
-	// Convert payload to JSON
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("Error marshaling webhook payload: %v", err)
-		return
+	if buildFailure != nil {
+		payload["errorCode"] = buildFailure.Code
+		payload["errorHint"] = buildFailure.Hint
+	}
+	if healthCheck != nil {
+		payload["healthCheck"] = healthCheck
 	}
 	
-	// Send webhook request
+	return json.Marshal(payload)
+}
+
+// PostWebhook delivers a JSON payload, treating any non-2xx response as a failure
+func PostWebhook(webhookUrl string, payload []byte) error {
+	// Sanitize the webhook URL - remove any whitespace or newlines
+	webhookUrl = strings.TrimSpace(webhookUrl)
+	
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Post(webhookUrl, "application/json", bytes.NewBuffer(jsonPayload))
+	resp, err := client.Post(webhookUrl, "application/json", bytes.NewBuffer(payload))
 	if err != nil {
-		log.Printf("Error calling webhook: %v", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 	
-	log.Printf("Webhook notification sent to %s, status: %s, deployment: %s", 
-		webhookUrl, status, deploymentID)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
 }
 
 // SendErrorWebhook sends an error notification to a webhook URL (no deployment ID)
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// gitopsRequestTimeout bounds a single request to the Git host
+	gitopsRequestTimeout = 15 * time.Second
+	// maxGitOpsSpecBytes bounds the size of a spec file
+	maxGitOpsSpecBytes = 1 << 20
+)
+
+var gitopsClient = &http.Client{Timeout: gitopsRequestTimeout}
+
+// gitopsRepository is a config repository on a supported Git host
+type gitopsRepository struct {
+	gitlab  bool
+	apiBase string // https://api.github.com or https://<host>/api/v4
+	project string // owner/repo, or the GitLab namespace path
+}
+
+// parseGitOpsRepoURL resolves the API of the host of a config repository. GitHub and GitLab
+// (gitlab.com and self-managed hosts named gitlab) are supported.
+func parseGitOpsRepoURL(repoURL string) (gitopsRepository, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return gitopsRepository{}, fmt.Errorf("must be an HTTPS URL (e.g. https://github.com/owner/config.git)")
+	}
+	project := strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git")
+	if strings.Count(project, "/") < 1 {
+		return gitopsRepository{}, fmt.Errorf("must name a repository (e.g. https://github.com/owner/config.git)")
+	}
+
+	host := strings.ToLower(parsed.Host)
+	switch {
+	case host == "github.com":
+		if strings.Count(project, "/") != 1 {
+			return gitopsRepository{}, fmt.Errorf("must be a GitHub repository URL (https://github.com/owner/repo)")
+		}
+		return gitopsRepository{apiBase: "https://api.github.com", project: project}, nil
+	case strings.Contains(host, "gitlab"):
+		return gitopsRepository{gitlab: true, apiBase: "https://" + parsed.Host + "/api/v4", project: project}, nil
+	default:
+		return gitopsRepository{}, fmt.Errorf("host %s is not supported; GitOps sync reads from GitHub and GitLab repositories", parsed.Host)
+	}
+}
+
+// CheckGitOpsRepoURL reports why a config repository URL cannot be synced from
+func CheckGitOpsRepoURL(repoURL string) error {
+	_, err := parseGitOpsRepoURL(repoURL)
+	return err
+}
+
+// ResolveGitOpsCommit returns the commit at the head of the configured branch
+func ResolveGitOpsCommit(config models.GitOpsConfig) (string, error) {
+	repo, err := parseGitOpsRepoURL(config.RepoURL)
+	if err != nil {
+		return "", err
+	}
+
+	if repo.gitlab {
+		body, err := doGitOpsRequest(config, repo, fmt.Sprintf("%s/projects/%s/repository/branches/%s",
+			repo.apiBase, url.PathEscape(repo.project), url.PathEscape(config.Branch)), "")
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve branch %s: %v", config.Branch, err)
+		}
+		var branch struct {
+			Commit struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+		if err := json.Unmarshal(body, &branch); err != nil || branch.Commit.ID == "" {
+			return "", fmt.Errorf("failed to resolve branch %s: invalid GitLab response", config.Branch)
+		}
+		return branch.Commit.ID, nil
+	}
+
+	body, err := doGitOpsRequest(config, repo, fmt.Sprintf("%s/repos/%s/commits/%s",
+		repo.apiBase, repo.project, url.PathEscape(config.Branch)), "application/vnd.github.sha")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve branch %s: %v", config.Branch, err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// FetchGitOpsSpec reads the spec file at a commit
+func FetchGitOpsSpec(config models.GitOpsConfig, commit string) ([]byte, error) {
+	repo, err := parseGitOpsRepoURL(config.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoint, accept string
+	if repo.gitlab {
+		endpoint = fmt.Sprintf("%s/projects/%s/repository/files/%s/raw?ref=%s",
+			repo.apiBase, url.PathEscape(repo.project), url.PathEscape(config.Path), url.QueryEscape(commit))
+	} else {
+		segments := strings.Split(config.Path, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		endpoint = fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s",
+			repo.apiBase, repo.project, strings.Join(segments, "/"), url.QueryEscape(commit))
+		accept = "application/vnd.github.raw"
+	}
+
+	body, err := doGitOpsRequest(config, repo, endpoint, accept)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %v", config.Path, shortCommit(commit), err)
+	}
+	return body, nil
+}
+
+// ParseGitOpsSpec decodes a spec file. Unknown fields are rejected, so a typo does not
+// silently leave a setting unmanaged, and Git credentials must not be committed: git
+// services created by a sync use the credentials of the config repository.
+func ParseGitOpsSpec(content []byte) (dto.GitOpsSpec, error) {
+	var spec dto.GitOpsSpec
+	if err := yaml.UnmarshalStrict(content, &spec); err != nil {
+		return spec, fmt.Errorf("invalid spec file: %v", err)
+	}
+
+	var errs FieldErrors
+	for envName, env := range spec.Environments {
+		if strings.TrimSpace(envName) == "" {
+			errs.Add("environments", "names must not be blank")
+		}
+		for serviceName, service := range env.Services {
+			field := fmt.Sprintf("environments.%s.services.%s", envName, serviceName)
+			if strings.TrimSpace(serviceName) == "" {
+				errs.Add(fmt.Sprintf("environments.%s.services", envName), "names must not be blank")
+			}
+			if service.Type != models.ServiceTypeGit && service.Type != models.ServiceTypeManaged {
+				errs.Add(field+".type", "must be git or managed")
+			}
+			if service.GitToken != "" {
+				errs.Add(field+".gitToken", "must not be committed; services use the credentials of the GitOps repository")
+			}
+		}
+	}
+	return spec, errs.Err()
+}
+
+// VerifyGitOpsWebhook authenticates a push webhook with the configuration's secret: the
+// GitHub X-Hub-Signature-256 HMAC of the body, or the GitLab X-Gitlab-Token
+func VerifyGitOpsWebhook(secret string, header http.Header, body []byte) bool {
+	if signature := header.Get("X-Hub-Signature-256"); signature != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(signature))
+	}
+	if token := header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+	}
+	return false
+}
+
+// GitOpsPushBranch returns the branch a push webhook is for, and false for other events
+// (tags, GitHub pings)
+func GitOpsPushBranch(body []byte) (string, bool) {
+	var event struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || !strings.HasPrefix(event.Ref, "refs/heads/") {
+		return "", false
+	}
+	return strings.TrimPrefix(event.Ref, "refs/heads/"), true
+}
+
+func doGitOpsRequest(config models.GitOpsConfig, repo gitopsRepository, endpoint string, accept string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	if config.GitToken != "" {
+		if repo.gitlab {
+			request.Header.Set("PRIVATE-TOKEN", config.GitToken)
+		} else {
+			request.Header.Set("Authorization", "Bearer "+config.GitToken)
+		}
+	}
+
+	response, err := gitopsClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxGitOpsSpecBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("not found (or the token cannot read the repository)")
+	case response.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s returned %d: %s", request.URL.Host, response.StatusCode, lastLine(string(body)))
+	case len(body) > maxGitOpsSpecBytes:
+		return nil, fmt.Errorf("larger than %d bytes", maxGitOpsSpecBytes)
+	}
+	return body, nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Grace period a pod gets between SIGTERM and being killed
+const (
+	DefaultTerminationGracePeriodSeconds = 30 // the Kubernetes default, used when a service sets none
+	MaxTerminationGracePeriodSeconds     = 3600
+)
+
+// applyGracefulTermination sets the termination grace period of a git service's pods and the
+// preStop hook of its app container, so in-flight requests and consumers can drain during
+// rollouts. Companion containers get SIGTERM at once and drain within the same period.
+func applyGracefulTermination(spec *corev1.PodSpec, service models.Service) {
+	if service.TerminationGracePeriodSeconds > 0 {
+		spec.TerminationGracePeriodSeconds = int64Ptr(int64(service.TerminationGracePeriodSeconds))
+	}
+	if service.PreStopCommand == "" {
+		return
+	}
+	for i := range spec.Containers {
+		if spec.Containers[i].Name != getMainContainerName() {
+			continue
+		}
+		spec.Containers[i].Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"sh", "-c", service.PreStopCommand}},
+			},
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MinHighAvailabilityReplicas is the replica count (and schedulable node count) a high
+// availability service needs for its replicas to land on different nodes
+const MinHighAvailabilityReplicas = 2
+
+// topologySpreadConstraints spreads the replicas of a high availability service evenly over
+// nodes, and over zones where nodes carry zone labels. The node spread is strict so that
+// losing one node never takes every replica down; the zone spread is best effort.
+func topologySpreadConstraints(service models.Service, selector map[string]string) []corev1.TopologySpreadConstraint {
+	if !service.HighAvailability {
+		return nil
+	}
+
+	labelSelector := &metav1.LabelSelector{MatchLabels: selector}
+	return []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelHostname,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     labelSelector,
+		},
+		{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     labelSelector,
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// authChallengeParamPattern matches the key="value" parameters of a WWW-Authenticate header
+var authChallengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// IsValidImageDigest reports whether digest is a sha256 manifest digest
+func IsValidImageDigest(digest string) bool {
+	return imageDigestPattern.MatchString(digest)
+}
+
+// PinnedImageReference adds a digest to an image reference, e.g. alpine/git:2.43.0@sha256:...
+// Pulling the reference fails unless the registry serves exactly that manifest. Without a
+// digest the reference is returned as is.
+func PinnedImageReference(image string, digest string) string {
+	if digest == "" {
+		return image
+	}
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	return image + "@" + digest
+}
+
+// ImageReferenceTag returns the tag of an image reference, latest when it has none
+func ImageReferenceTag(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		return image[colon+1:]
+	}
+	return "latest"
+}
+
+// ResolveImageDigest returns the manifest digest an image reference such as alpine/git:2.43.0
+// or gcr.io/kaniko-project/executor:v1.23.2 currently resolves to. Images without a registry
+// host are looked up on Docker Hub; registries that ask for a token get an anonymous one.
+func ResolveImageDigest(image string) (string, error) {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	baseURL, repository, tag := splitRegistryImageReference(image)
+	if !imageTagPattern.MatchString(tag) {
+		return "", fmt.Errorf("%q is not a valid image tag", tag)
+	}
+	client := &http.Client{Timeout: imageTagLookupTimeout}
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, repository, tag)
+
+	resp, err := headManifest(client, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := getAnonymousPullToken(client, resp.Header.Get("WWW-Authenticate"), repository)
+		if err != nil {
+			return "", err
+		}
+		if resp, err = headManifest(client, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("image %s does not exist", image)
+	default:
+		return "", fmt.Errorf("registry lookup of %s returned HTTP %d", image, resp.StatusCode)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !IsValidImageDigest(digest) {
+		return "", fmt.Errorf("registry returned no digest for %s", image)
+	}
+	return digest, nil
+}
+
+// headManifest asks for the headers of a manifest, lists and indexes included
+func headManifest(client *http.Client, manifestURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+	}, ", "))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry lookup failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// getAnonymousPullToken answers a Bearer challenge of a registry with an anonymous pull token
+func getAnonymousPullToken(client *http.Client, challenge string, repository string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("registry requires unsupported authentication %q", challenge)
+	}
+	params := map[string]string{}
+	for _, match := range authChallengeParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry authentication challenge has no realm")
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	resp, err := client.Get(params["realm"] + "?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("registry authentication failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry authentication returned HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid registry token response: %v", err)
+	}
+	if body.Token == "" {
+		return body.AccessToken, nil
+	}
+	return body.Token, nil
+}
+
+// splitRegistryImageReference splits an image reference into the base URL of its registry,
+// its repository path and its tag. References without a registry host are on Docker Hub.
+func splitRegistryImageReference(image string) (string, string, string) {
+	if slash := strings.Index(image, "/"); slash > 0 {
+		host := image[:slash]
+		if (strings.ContainsAny(host, ".:") || host == "localhost") && host != "docker.io" {
+			tag := ImageReferenceTag(image)
+			repository := strings.TrimSuffix(image[slash+1:], ":"+tag)
+			return "https://" + host, repository, tag
+		}
+		image = strings.TrimPrefix(image, "docker.io/")
+	}
+	repository, tag := splitImageReference(image)
+	return DockerHubRemoteURL, repository, tag
+}
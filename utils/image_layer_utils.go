@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// Layer statuses of an image comparison
+const (
+	LayerChanged = "changed"
+	LayerAdded   = "added"
+	LayerRemoved = "removed"
+)
+
+// InspectImageLayers reads the layers of an image from the registry and returns them with the
+// image size (config plus compressed layers). A multi-platform image is measured by its first
+// platform, which is the same for every build of the service.
+func InspectImageLayers(ctx context.Context, api *dto.RegistryAPI, repository, reference string) (models.ImageLayers, int64, error) {
+	manifest, err := fetchImageManifest(ctx, api, repository, reference)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(manifest.Manifests) > 0 {
+		if manifest, err = fetchImageManifest(ctx, api, repository, manifest.Manifests[0].Digest); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var size int64
+	if manifest.Config != nil {
+		size += manifest.Config.Size
+	}
+	layers := make(models.ImageLayers, 0, len(manifest.Layers))
+	for _, layer := range manifest.Layers {
+		layers = append(layers, models.ImageLayer{Digest: layer.Digest, Size: layer.Size})
+		size += layer.Size
+	}
+	return layers, size, nil
+}
+
+// fetchImageManifest downloads and parses a manifest or index
+func fetchImageManifest(ctx context.Context, api *dto.RegistryAPI, repository, reference string) (registryManifest, error) {
+	var manifest registryManifest
+
+	resp, err := registryProxyDo(ctx, api, http.MethodGet, fmt.Sprintf("v2/%s/manifests/%s", repository, reference), nil,
+		map[string]string{"Accept": strings.Join(registryManifestMediaTypes, ", ")})
+	if err != nil {
+		return manifest, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return manifest, fmt.Errorf("manifest %s:%s not found (status %d)", repository, reference, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse manifest %s: %v", reference, err)
+	}
+	return manifest, nil
+}
+
+// CompareImageLayers compares two images layer by layer in manifest order and returns the
+// positions that differ along with the number of identical ones. Since each Dockerfile
+// instruction adds a layer, a change points at the instruction that grew or shrank the image.
+func CompareImageLayers(a, b models.ImageLayers) ([]dto.ImageLayerChange, int) {
+	changes := []dto.ImageLayerChange{}
+	unchanged := 0
+
+	count := len(a)
+	if len(b) > count {
+		count = len(b)
+	}
+	for i := 0; i < count; i++ {
+		change := dto.ImageLayerChange{Index: i}
+		if i < len(a) {
+			change.DigestA, change.SizeA = a[i].Digest, a[i].Size
+		}
+		if i < len(b) {
+			change.DigestB, change.SizeB = b[i].Digest, b[i].Size
+		}
+
+		switch {
+		case change.DigestA == change.DigestB:
+			unchanged++
+			continue
+		case change.DigestA == "":
+			change.Status = LayerAdded
+		case change.DigestB == "":
+			change.Status = LayerRemoved
+		default:
+			change.Status = LayerChanged
+		}
+		change.SizeDelta = change.SizeB - change.SizeA
+		changes = append(changes, change)
+	}
+	return changes, unchanged
+}
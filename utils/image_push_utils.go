@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// PushedImageRepository returns the repository of the registry an externally built image of
+// the service must be pushed to: the one platform builds push to, see GenerateImage
+func PushedImageRepository(registryURL string, service models.Service) string {
+	return strings.TrimSuffix(CleanRegistryURL(registryURL), "/") + "/" + service.ID
+}
+
+// ResolvePushedImage checks that an image reported by external CI belongs to the service's
+// repository and returns the digest it is pinned to. The digest is taken from the reference
+// (repository[:tag]@digest) or given separately; when both are set they must agree.
+func ResolvePushedImage(image string, digest string, registryURL string, service models.Service) (string, error) {
+	if at := strings.Index(image, "@"); at >= 0 {
+		if digest != "" && digest != image[at+1:] {
+			return "", fmt.Errorf("digest %s does not match the digest of image %s", digest, image)
+		}
+		digest = image[at+1:]
+	}
+	if !imageDigestPattern.MatchString(digest) {
+		return "", fmt.Errorf("a sha256 digest of the image is required, e.g. sha256:<64 hex characters>")
+	}
+
+	expected := PushedImageRepository(registryURL, service)
+	if repository := imageRepository(image); repository != expected {
+		return "", fmt.Errorf("image must be pushed to %s, not %s", expected, repository)
+	}
+	return digest, nil
+}
+
+// ImageDigestExists reports whether the registry holds a manifest with the digest in the
+// repository (the path of the repository within the registry)
+func ImageDigestExists(ctx context.Context, api *dto.RegistryAPI, repository, digest string) (bool, error) {
+	resp, err := registryProxyDo(ctx, api, http.MethodGet, fmt.Sprintf("v2/%s/manifests/%s", repository, digest), nil,
+		map[string]string{"Accept": strings.Join(registryManifestMediaTypes, ", ")})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if served := resp.Header.Get("Docker-Content-Digest"); served != "" && served != digest {
+			return false, nil
+		}
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("registry answered %d for manifest %s@%s", resp.StatusCode, repository, digest)
+}
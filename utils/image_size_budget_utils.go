@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pendeploy-simple/models"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// What happens when a build's image is larger than the service's MaxImageSize
+const (
+	ImageSizeBudgetWarn = "warn" // the deployment is rolled out and the overrun recorded on it
+	ImageSizeBudgetFail = "fail" // the deployment fails before the rollout
+)
+
+// CheckImageSizeBudget compares the size of a pushed image (compressed layers and config,
+// as read from the registry) with the service's budget. It describes an overrun, and returns
+// a BuildFailure as well when the service fails builds over budget. An unknown size passes.
+func CheckImageSizeBudget(service models.Service, size int64) (string, error) {
+	if service.MaxImageSize == "" || size <= 0 {
+		return "", nil
+	}
+	budget, err := resource.ParseQuantity(service.MaxImageSize)
+	if err != nil || size <= budget.Value() {
+		return "", nil
+	}
+
+	message := fmt.Sprintf("the image is %s, over the size budget of %s", FormatBytesToHumanReadable(size), service.MaxImageSize)
+	if service.ImageSizeBudgetAction != ImageSizeBudgetFail {
+		return message, nil
+	}
+	return message, &BuildFailure{
+		Code: BuildErrorImageTooLarge,
+		Hint: "The image is larger than the service allows. Use a smaller base image (e.g. alpine or distroless), a multi-stage build that copies only the build output, and a .dockerignore; or raise maxImageSize.",
+		Err:  errors.New(message),
+	}
+}
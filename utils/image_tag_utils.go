@@ -7,6 +7,15 @@ import (
 	"github.com/pendeploy-simple/models"
 )
 
+// ImageByDigest pins an image to its digest (repository@sha256:...), so a re-pushed tag
+// cannot change what runs; without a digest the image is returned as is
+func ImageByDigest(image string, digest string) string {
+	if digest == "" {
+		return image
+	}
+	return imageRepository(image) + "@" + digest
+}
+
 func GenerateImage(registryURL string, service models.Service, deployment models.Deployment) string {
     log.Println("Generating image tag for service: " + service.Name + ", deployment: " + deployment.ID)
 	log.Printf("Image tag: %s", fmt.Sprintf("%s/%s:%s", CleanRegistryURL(registryURL), service.ID, deployment.ID))
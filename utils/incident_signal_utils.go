@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// probeFailureGrace keeps pods that are still booting from being reported as failing probes
+const probeFailureGrace = 2 * time.Minute
+
+// IncidentSignal is a failure currently observed on a service
+type IncidentSignal struct {
+	Kind    string
+	Key     string // stable identity of the source, e.g. pod/container
+	Message string
+	Since   time.Time
+}
+
+// CollectPodIncidentSignals reports the crash-looping containers and the running pods that
+// have been failing their readiness probe for longer than the boot grace period
+func CollectPodIncidentSignals(service models.Service) ([]IncidentSignal, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", GetResourceName(service)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	var signals []IncidentSignal
+	for i := range pods.Items {
+		signals = append(signals, podIncidentSignals(&pods.Items[i])...)
+	}
+	return signals, nil
+}
+
+func podIncidentSignals(pod *corev1.Pod) []IncidentSignal {
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	var signals []IncidentSignal
+	crashing := false
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil || status.State.Waiting.Reason != "CrashLoopBackOff" {
+			continue
+		}
+		crashing = true
+
+		message := fmt.Sprintf("Container %s in pod %s is crash-looping (%d restarts)", status.Name, pod.Name, status.RestartCount)
+		since := pod.CreationTimestamp.Time
+		if terminated := status.LastTerminationState.Terminated; terminated != nil {
+			message += fmt.Sprintf(": last exit %s, code %d", terminated.Reason, terminated.ExitCode)
+			since = terminated.FinishedAt.Time
+		}
+		signals = append(signals, IncidentSignal{
+			Kind:    models.IncidentSignalCrashLoop,
+			Key:     fmt.Sprintf("crash_loop:%s/%s", pod.Name, status.Name),
+			Message: message,
+			Since:   since,
+		})
+	}
+	if crashing || pod.Status.Phase != corev1.PodRunning {
+		return signals
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodReady || condition.Status == corev1.ConditionTrue {
+			continue
+		}
+		if time.Since(condition.LastTransitionTime.Time) < probeFailureGrace {
+			break
+		}
+		message := fmt.Sprintf("Pod %s is running but failing its readiness probe", pod.Name)
+		if condition.Message != "" {
+			message += ": " + condition.Message
+		}
+		signals = append(signals, IncidentSignal{
+			Kind:    models.IncidentSignalProbeFailure,
+			Key:     "probe_failure:" + pod.Name,
+			Message: message,
+			Since:   condition.LastTransitionTime.Time,
+		})
+	}
+	return signals
+}
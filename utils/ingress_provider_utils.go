@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ingress providers
+const (
+	IngressProviderTraefik = "traefik"
+	IngressProviderNginx   = "nginx"
+	IngressProviderGateway = "gateway"
+)
+
+// ErrMiddlewaresUnsupported is returned for features built on Traefik Middlewares while
+// another ingress provider is selected
+var ErrMiddlewaresUnsupported = errors.New("cache policies and error pages require the traefik ingress provider")
+
+// nginxTCPPortPrefix names the ports the platform adds to the NGINX controller's Service
+const nginxTCPPortPrefix = "pd-tcp-"
+
+// IngressProvider renders what differs between the ingress controllers the platform can
+// run on: the class and annotations of Ingresses (or their Gateway API rendering), whether
+// Traefik Middlewares (cache policies, error pages) apply, and how managed services' TCP
+// ports are published
+type IngressProvider interface {
+	Name() string
+	// IngressClassName is set on every Ingress; nil leaves it to the default IngressClass
+	IngressClassName() *string
+	// TLSAnnotations are the controller annotations of an Ingress serving HTTPS
+	TLSAnnotations() map[string]string
+	// SupportsMiddlewares reports whether Traefik Middlewares can be attached to Ingresses
+	SupportsMiddlewares() bool
+	// ExposeTCP publishes the TCP ports of managed services through the controller and
+	// returns the services it cannot publish, which stay on the platform's TCP proxy
+	ExposeTCP(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) ([]models.Service, error)
+}
+
+var ingressProvider = struct {
+	mu       sync.RWMutex
+	provider IngressProvider
+}{}
+
+// IsValidIngressProvider reports whether name is a supported ingress provider
+func IsValidIngressProvider(name string) bool {
+	return newIngressProvider(name) != nil
+}
+
+// newIngressProvider returns the provider named name, or nil for an unknown name
+func newIngressProvider(name string) IngressProvider {
+	switch name {
+	case IngressProviderTraefik:
+		return traefikProvider{}
+	case IngressProviderNginx:
+		return nginxProvider{}
+	case IngressProviderGateway:
+		return gatewayProvider{}
+	}
+	return nil
+}
+
+// GetDefaultIngressProvider returns the provider used until one is saved in the platform
+// settings (INGRESS_PROVIDER, default traefik)
+func GetDefaultIngressProvider() string {
+	if name := getEnvString("INGRESS_PROVIDER", IngressProviderTraefik); IsValidIngressProvider(name) {
+		return name
+	}
+	return IngressProviderTraefik
+}
+
+// SetIngressProvider switches the provider Ingresses and TCP exposure are rendered for
+func SetIngressProvider(name string) error {
+	provider := newIngressProvider(name)
+	if provider == nil {
+		return fmt.Errorf("unknown ingress provider %q", name)
+	}
+
+	ingressProvider.mu.Lock()
+	defer ingressProvider.mu.Unlock()
+	if ingressProvider.provider == nil || ingressProvider.provider.Name() != name {
+		log.Printf("Ingress provider set to %s", name)
+	}
+	ingressProvider.provider = provider
+	return nil
+}
+
+// GetIngressProvider returns the current ingress provider
+func GetIngressProvider() IngressProvider {
+	ingressProvider.mu.RLock()
+	provider := ingressProvider.provider
+	ingressProvider.mu.RUnlock()
+	if provider != nil {
+		return provider
+	}
+	return newIngressProvider(GetDefaultIngressProvider())
+}
+
+// ingressAnnotations returns the annotations of an Ingress serving HTTPS with a certificate
+// from clusterIssuer; empty for a certificate managed outside cert-manager
+func ingressAnnotations(clusterIssuer string) map[string]string {
+	annotations := GetIngressProvider().TLSAnnotations()
+	if clusterIssuer != "" {
+		annotations["cert-manager.io/cluster-issuer"] = clusterIssuer
+	}
+	return annotations
+}
+
+// traefikProvider renders for Traefik, k3s's bundled ingress controller
+type traefikProvider struct{}
+
+func (traefikProvider) Name() string { return IngressProviderTraefik }
+
+// IngressClassName leaves Traefik's Ingresses without a class unless TRAEFIK_INGRESS_CLASS
+// is set; k3s makes Traefik's IngressClass the default
+func (traefikProvider) IngressClassName() *string {
+	if class := getEnvString("TRAEFIK_INGRESS_CLASS", ""); class != "" {
+		return &class
+	}
+	return nil
+}
+
+func (traefikProvider) TLSAnnotations() map[string]string {
+	return map[string]string{
+		"traefik.ingress.kubernetes.io/router.entrypoints": "websecure",
+		"traefik.ingress.kubernetes.io/router.tls":         "true",
+	}
+}
+
+func (traefikProvider) SupportsMiddlewares() bool { return true }
+
+// ExposeTCP leaves every service on the platform's TCP proxy, and drops the ports published
+// through NGINX or the Gateway API before a switch back to Traefik
+func (traefikProvider) ExposeTCP(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) ([]models.Service, error) {
+	if err := applyNginxTCPServices(ctx, client, cfg, nil); err != nil {
+		log.Printf("Warning - failed to remove the TCP ports published through NGINX: %v", err)
+	}
+	if err := applyGatewayTCPRoutes(ctx, client, cfg, nil); err != nil {
+		log.Printf("Warning - failed to remove the TCP ports published through the Gateway API: %v", err)
+	}
+	return services, nil
+}
+
+// nginxProvider renders for ingress-nginx
+type nginxProvider struct{}
+
+func (nginxProvider) Name() string { return IngressProviderNginx }
+
+// IngressClassName returns NGINX_INGRESS_CLASS, default nginx
+func (nginxProvider) IngressClassName() *string {
+	class := getEnvString("NGINX_INGRESS_CLASS", "nginx")
+	return &class
+}
+
+func (nginxProvider) TLSAnnotations() map[string]string {
+	return map[string]string{
+		"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+		// Traefik does not limit request bodies; image pushes and uploads rely on that
+		"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+	}
+}
+
+func (nginxProvider) SupportsMiddlewares() bool { return false }
+
+// ExposeTCP publishes plain TCP services through ingress-nginx's tcp-services ConfigMap and
+// the controller's Service. Services terminating TLS at the proxy or restricted to source
+// CIDRs need features tcp-services lacks, so they stay on the platform's TCP proxy.
+func (nginxProvider) ExposeTCP(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) ([]models.Service, error) {
+	if err := applyGatewayTCPRoutes(ctx, client, cfg, nil); err != nil {
+		log.Printf("Warning - failed to remove the TCP ports published through the Gateway API: %v", err)
+	}
+
+	var published, proxied []models.Service
+	for _, service := range services {
+		switch {
+		case !isTCPProxyService(service):
+		case terminatesTLSAtProxy(service) || len(GetExternalAllowedCIDRs(service)) > 0:
+			proxied = append(proxied, service)
+		default:
+			published = append(published, service)
+		}
+	}
+	if err := applyNginxTCPServices(ctx, client, cfg, published); err != nil {
+		return nil, err
+	}
+	return proxied, nil
+}
+
+// getNginxObjectRef splits a namespace/name setting
+func getNginxObjectRef(key string, fallback string) (string, string) {
+	value := getEnvString(key, fallback)
+	if namespace, name, ok := strings.Cut(value, "/"); ok {
+		return namespace, name
+	}
+	return "ingress-nginx", value
+}
+
+// applyNginxTCPServices makes the TCP ports of the services the only ports of the platform's
+// range in ingress-nginx's tcp-services ConfigMap (NGINX_TCP_SERVICES_CONFIGMAP, default
+// ingress-nginx/tcp-services) and on its controller Service (NGINX_CONTROLLER_SERVICE,
+// default ingress-nginx/ingress-nginx-controller). Entries outside the range are left alone.
+// The controller must run with --tcp-services-configmap pointing at the ConfigMap.
+func applyNginxTCPServices(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig, services []models.Service) error {
+	inRange := func(port int) bool { return port >= cfg.PortStart && port <= cfg.PortEnd }
+
+	desired := map[string]string{}
+	for _, service := range services {
+		desired[strconv.Itoa(service.ExternalPort)] = fmt.Sprintf("%s/%s:%d", service.EnvironmentID, GetResourceName(service), service.Port)
+	}
+
+	namespace, name := getNginxObjectRef("NGINX_TCP_SERVICES_CONFIGMAP", "ingress-nginx/tcp-services")
+	configMaps := client.Clientset.CoreV1().ConfigMaps(namespace)
+	configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err) && len(desired) == 0:
+		// Nothing was ever published through NGINX
+	case apierrors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Data: desired}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s/%s: %v", namespace, name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get ConfigMap %s/%s: %v", namespace, name, err)
+	default:
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		for key := range configMap.Data {
+			if port, err := strconv.Atoi(key); err == nil && inRange(port) {
+				delete(configMap.Data, key)
+			}
+		}
+		for key, value := range desired {
+			configMap.Data[key] = value
+		}
+		if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update ConfigMap %s/%s: %v", namespace, name, err)
+		}
+	}
+
+	namespace, name = getNginxObjectRef("NGINX_CONTROLLER_SERVICE", "ingress-nginx/ingress-nginx-controller")
+	controller, err := client.Clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) && len(desired) == 0 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the NGINX controller Service %s/%s: %v", namespace, name, err)
+	}
+
+	// NodePorts of published ports are kept so they do not change on every update
+	nodePorts := map[string]int32{}
+	ports := make([]corev1.ServicePort, 0, len(controller.Spec.Ports)+len(desired))
+	for _, port := range controller.Spec.Ports {
+		if strings.HasPrefix(port.Name, nginxTCPPortPrefix) {
+			nodePorts[port.Name] = port.NodePort
+			continue
+		}
+		ports = append(ports, port)
+	}
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		port, _ := strconv.Atoi(key)
+		ports = append(ports, corev1.ServicePort{
+			Name:       nginxTCPPortPrefix + key,
+			Port:       int32(port),
+			TargetPort: intstr.FromInt(port),
+			Protocol:   corev1.ProtocolTCP,
+			NodePort:   nodePorts[nginxTCPPortPrefix+key],
+		})
+	}
+	controller.Spec.Ports = ports
+	if _, err := client.Clientset.CoreV1().Services(namespace).Update(ctx, controller, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update the NGINX controller Service %s/%s: %v", namespace, name, err)
+	}
+	return nil
+}
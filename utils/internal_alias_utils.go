@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelInternalAlias marks the ExternalName Services that carry a service's internal alias
+const LabelInternalAlias = "pendeploy.io/internal-alias"
+
+// GetInternalAliasTarget returns the cluster DNS name a service's alias resolves to
+func GetInternalAliasTarget(service models.Service) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", GetResourceName(service), service.EnvironmentID)
+}
+
+// aliasInvalidChars matches runs of characters a DNS label cannot contain
+var aliasInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DefaultInternalAlias derives an alias from the service name, e.g. "Orders DB" -> "orders-db".
+// It returns "" when the name yields no usable DNS label.
+func DefaultInternalAlias(name string) string {
+	alias := strings.Trim(aliasInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(alias) > 63 {
+		alias = strings.TrimRight(alias[:63], "-")
+	}
+	var errs FieldErrors
+	if errs.CheckDNS1035Label("internalAlias", alias); errs.Err() != nil || IsReservedInternalAlias(alias) {
+		return ""
+	}
+	return alias
+}
+
+// IsReservedInternalAlias reports whether an alias could collide with the platform's own
+// resource names (s-<service-id>...) or the default kubernetes Service
+func IsReservedInternalAlias(alias string) bool {
+	return alias == "" || alias == "kubernetes" || strings.HasPrefix(alias, "s-")
+}
+
+// createInternalAliasSpec builds the ExternalName Service that makes the alias resolve, within
+// the environment's namespace, to the service's own Service
+func createInternalAliasSpec(service models.Service) *corev1.Service {
+	labels := GetResourceLabels(service)
+	labels[LabelInternalAlias] = "true"
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service.InternalAlias,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: GetInternalAliasTarget(service),
+		},
+	}
+}
+
+// deployInternalAlias applies the service's alias and removes aliases it no longer uses
+func deployInternalAlias(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	existing, err := client.Clientset.CoreV1().Services(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s,%s=true", ServiceOwnerSelector(service.ID), LabelInternalAlias),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list alias Services: %v", err)
+	}
+	for _, svc := range existing.Items {
+		if svc.Name == service.InternalAlias {
+			continue
+		}
+		err := client.Clientset.CoreV1().Services(service.EnvironmentID).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete alias Service %s: %v", svc.Name, err)
+		}
+	}
+
+	if service.InternalAlias == "" {
+		return nil
+	}
+	alias := createInternalAliasSpec(service)
+	setServiceOwner(alias, owner)
+	return applyService(ctx, client, alias)
+}
+
+// ApplyInternalAlias updates the alias of a service whose alias changed. Services that were
+// never deployed are skipped; their alias is created with the first deployment.
+func ApplyInternalAlias(service models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = k8sClient.Clientset.CoreV1().ConfigMaps(service.EnvironmentID).Get(ctx, GetServiceOwnerName(service), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get owner ConfigMap: %v", err)
+	}
+
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return err
+	}
+	if err := deployInternalAlias(ctx, k8sClient, service, owner); err != nil {
+		return err
+	}
+	log.Printf("Internal alias of service %s set to %q", service.ID, service.InternalAlias)
+	return nil
+}
@@ -2,11 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/models"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"log"
 	"net/url"
 	"strings"
@@ -18,8 +18,21 @@ const (
 	KanikoVersion = "v1.23.2"
 	// KanikoExecutorImage is the upstream Kaniko executor image reference.
 	KanikoExecutorImage = "gcr.io/kaniko-project/executor:" + KanikoVersion
+	// KanikoContainerName is the build job container running the Kaniko executor
+	KanikoContainerName = "kaniko-executor"
 )
 
+// DefaultDockerfilePath is the Dockerfile built when a service does not set one
+const DefaultDockerfilePath = "Dockerfile"
+
+// GetDockerfilePath returns the service's Dockerfile relative to the repository root
+func GetDockerfilePath(service models.Service) string {
+	if service.DockerfilePath == "" {
+		return DefaultDockerfilePath
+	}
+	return service.DockerfilePath
+}
+
 // buildGitCloneURL returns the repository URL used by the git-clone step.
 // It appends ".git" and, for private services, injects HTTPS basic-auth
 // credentials (username + PAT). Public services clone over plain HTTPS.
@@ -52,181 +65,140 @@ func buildGitCloneURL(service models.Service) string {
 	return parsed.String()
 }
 
-// createKanikoBuildJob creates a job definition using Kaniko with auto Dockerfile fixing
-func createKanikoBuildJob(registryURL string, deployment models.Deployment, service models.Service, image string) (*batchv1.Job, error) {
+// kanikoBuilder builds images with Kaniko in a build job, one job per target platform
+type kanikoBuilder struct{}
+
+func (kanikoBuilder) Name() string {
+	return BuildBackendKaniko
+}
+
+// Capabilities of Kaniko: a registry layer cache, and multi-arch images from one job per
+// platform on nodes of that architecture. It has no build secrets.
+func (kanikoBuilder) Capabilities() BuildCapabilities {
+	return BuildCapabilities{Cache: true, MultiArch: true}
+}
+
+// Build creates a Kubernetes job with Kaniko and waits for completion
+func (kanikoBuilder) Build(deployment models.Deployment, service models.Service, registry models.Registry) (string, error) {
+	cleanRegistryURL := CleanRegistryURL(registry.URL)
+
+	registryURL := GetRegistryURLForKaniko(registry.URL)
+	if IsInsecureRegistry(registry.URL) {
+		log.Printf("Using HTTP protocol for local/cluster registry: %s", cleanRegistryURL)
+	} else {
+		log.Printf("Using HTTPS protocol for external registry: %s", cleanRegistryURL)
+	}
+
+	image := GenerateImage(cleanRegistryURL, service, deployment)
+	log.Printf("Target image (for K8s): %s", image)
+	log.Printf("Registry URL (for kaniko): %s", registryURL)
+
+	k8sClient, err := prepareBuildJobs()
+	if err != nil {
+		return "", err
+	}
+
+	platforms := GetBuildPlatforms(service)
+	if len(platforms) > 1 {
+		log.Printf("Building %s for platforms: %s", image, strings.Join(platforms, ", "))
+		if err := buildMultiPlatform(k8sClient, registryURL, deployment, service, image, platforms); err != nil {
+			return "", fmt.Errorf("build job failed: %v", err)
+		}
+		log.Printf("BUILD SUCCESS: Multi-platform image ready: %s", image)
+		return image, nil
+	}
+
+	platform := ""
+	if len(platforms) == 1 {
+		platform = platforms[0]
+	}
 	jobName := GetJobName(service.ID, deployment.ID)
-	log.Println("Creating Kaniko job with Dockerfile auto-fixing")
+	if err := runKanikoBuildJob(k8sClient, jobName, registryURL, deployment, service, image, platform); err != nil {
+		return "", fmt.Errorf("build job failed: %v", err)
+	}
+
+	log.Printf("BUILD SUCCESS: Job %s completed successfully! Image ready: %s", jobName, image)
+	return image, nil
+}
 
-	branch := service.Branch
-	if branch == "" {
-		branch = "main"
+// runKanikoBuildJob submits the Kaniko build pushing to destination and waits for it to
+// complete
+func runKanikoBuildJob(k8sClient *kubernetes.Client, jobName, registryURL string, deployment models.Deployment, service models.Service, destination, platform string) error {
+	log.Printf("Creating Kaniko job: %s", jobName)
+	// Create the job - pass all necessary parameters
+	job, err := createKanikoBuildJob(jobName, registryURL, deployment, service, destination, platform)
+	if err != nil {
+		log.Printf("FATAL: Failed to create job definition: %v", err)
+		return fmt.Errorf("job definition creation failed: %v", err)
 	}
-	log.Printf("Using branch: %s", branch)
-
-	// Authenticated URL for private repos; logged without credentials.
-	repoURL := buildGitCloneURL(service)
-	log.Printf("Repository URL: %s", service.RepoURL)
-
-	sharedVolumeName := "build-workspace"
-	log.Println("Preparing Kaniko job configuration with Dockerfile auto-fixing")
-
-	// Generate Dockerfile fix script
-	dockerfileFixScript := generateDockerfileFixScript(service.EnvVars)
-
-	job := &batchv1.Job{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      jobName,
-			Namespace: GetJobNamespace(),
-			Labels: map[string]string{
-				"app":           "pendeploy",
-				"service-id":    service.ID,
-				"deployment-id": deployment.ID,
-				"builder":       "kaniko",
-			},
-		},
-		Spec: batchv1.JobSpec{
-			BackoffLimit:            int32Ptr(0),
-			TTLSecondsAfterFinished: int32Ptr(600),
-			ActiveDeadlineSeconds:   int64Ptr(1200),
-
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app":           "pendeploy",
-						"service-id":    service.ID,
-						"deployment-id": deployment.ID,
-						"builder":       "kaniko",
-						"job-name":      jobName, // For log compatibility
-					},
-				},
-				Spec: corev1.PodSpec{
-					RestartPolicy: corev1.RestartPolicyNever,
-
-					InitContainers: []corev1.Container{
-						{
-							Name:    "git-clone",
-							Image:   "alpine/git:2.43.0",
-							Command: []string{"sh", "-c"},
-							Args: []string{fmt.Sprintf(`
-                                echo "=== Starting git clone ==="
-                                git clone --branch %s --single-branch --depth 1 %s /workspace %s
-                                cd /workspace
-                                echo "Git clone completed successfully"
-                                ls -la
-                                
-                                echo "=== Checking Dockerfile ==="
-                                if [ ! -f "Dockerfile" ]; then
-                                    echo "ERROR: Dockerfile not found!"
-                                    exit 1
-                                fi
-                                
-                                echo "Original Dockerfile:"
-                                cat Dockerfile
-                                echo "========================="
-                                
-                                echo "=== Auto-fixing Dockerfile ==="
-                                %s
-                                
-                                echo "Final Dockerfile:"
-                                cat Dockerfile
-                                echo "================"
-                                echo "Dockerfile auto-fixing completed!"
-                            `,
-								branch,
-								repoURL,
-								getCheckoutCommand(deployment.CommitSHA),
-								dockerfileFixScript,
-							)},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      sharedVolumeName,
-									MountPath: "/workspace",
-								},
-							},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:              resource.MustParse("100m"),
-									corev1.ResourceMemory:           resource.MustParse("128Mi"),
-									corev1.ResourceEphemeralStorage: resource.MustParse("512Mi"),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:              resource.MustParse("200m"),
-									corev1.ResourceMemory:           resource.MustParse("256Mi"),
-									corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
-								},
-							},
-						},
-					},
+	log.Println("Kaniko job definition created successfully")
 
-					Containers: []corev1.Container{
-						{
-							Name:  "kaniko-executor",
-							Image: KanikoExecutorImage,
-							Args: append(append([]string{
-								"--context=/workspace",
-								"--dockerfile=/workspace/Dockerfile",
-								fmt.Sprintf("--destination=%s", image),
-								"--cache=true",
-								fmt.Sprintf("--cache-repo=%s/cache", CleanRegistryURL(registryURL)),
-								"--cache-ttl=168h",
-								"--cleanup",
-								"--verbosity=info",
-								"--log-format=color",
-								"--log-timestamp",
-								"--compressed-caching=false",
-								"--single-snapshot",
-							}, KanikoRegistryArgs(registryURL)...), generateKanikoBuildArgs(service.EnvVars)...),
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      sharedVolumeName,
-									MountPath: "/workspace",
-								},
-							},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:              resource.MustParse("500m"),
-									corev1.ResourceMemory:           resource.MustParse("1Gi"),
-									corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:              resource.MustParse("2000m"),
-									corev1.ResourceMemory:           resource.MustParse("6Gi"),
-									corev1.ResourceEphemeralStorage: resource.MustParse("12Gi"),
-								},
-							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "GOOGLE_APPLICATION_CREDENTIALS",
-									Value: "/kaniko/.docker/config.json",
-								},
-								{
-									Name:  "KANIKO_DIR",
-									Value: "/kaniko",
-								},
-								{
-									Name:  "NODE_OPTIONS",
-									Value: "--max-old-space-size=4096",
-								},
-							},
-						},
-					},
+	return runBuildJob(k8sClient, job, service)
+}
 
-					Volumes: []corev1.Volume{
-						{
-							Name: sharedVolumeName,
-							VolumeSource: corev1.VolumeSource{
-								EmptyDir: &corev1.EmptyDirVolumeSource{
-									SizeLimit: resource.NewQuantity(8*1024*1024*1024, resource.BinarySI),
-								},
-							},
-						},
-					},
-				},
+// createKanikoBuildJob creates a job definition using Kaniko with auto Dockerfile fixing.
+// A non-empty platform builds for that os/arch on a node of the same architecture.
+func createKanikoBuildJob(jobName string, registryURL string, deployment models.Deployment, service models.Service, image string, platform string) (*batchv1.Job, error) {
+	log.Println("Creating Kaniko job with Dockerfile auto-fixing")
+
+	executor := corev1.Container{
+		Name:  KanikoContainerName,
+		Image: KanikoExecutorImage,
+		Args: append(append([]string{
+			"--context=/workspace",
+			"--dockerfile=/workspace/" + GetDockerfilePath(service),
+			fmt.Sprintf("--destination=%s", image),
+			"--cache=true",
+			fmt.Sprintf("--cache-repo=%s/cache", CleanRegistryURL(registryURL)),
+			"--cache-ttl=168h",
+			"--cleanup",
+			"--verbosity=info",
+			"--log-format=color",
+			"--log-timestamp",
+			"--compressed-caching=false",
+			"--single-snapshot",
+			// The pushed digest becomes the termination message, see captureBuildEnvironment
+			"--digest-file=/dev/termination-log",
+		}, append(KanikoRegistryArgs(registryURL), KanikoMirrorArgs()...)...), generateKanikoBuildArgs(buildArgs(service))...),
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      buildWorkspaceVolume,
+				MountPath: "/workspace",
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("500m"),
+				corev1.ResourceMemory:           resource.MustParse("1Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("2000m"),
+				corev1.ResourceMemory:           resource.MustParse("6Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("12Gi"),
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "GOOGLE_APPLICATION_CREDENTIALS",
+				Value: "/kaniko/.docker/config.json",
+			},
+			{
+				Name:  "KANIKO_DIR",
+				Value: "/kaniko",
+			},
+			{
+				Name:  "NODE_OPTIONS",
+				Value: "--max-old-space-size=4096",
 			},
 		},
 	}
+	if platform != "" {
+		executor.Args = append(executor.Args, fmt.Sprintf("--custom-platform=%s", platform))
+	}
 
-	SecurePodSpec(&job.Spec.Template.Spec)
+	job := newBuildJob(jobName, deployment, service, BuildBackendKaniko, executor)
+	applyBuildPlatform(&job.Spec.Template.Spec, platform)
 
 	// Kaniko builds arbitrary user Dockerfiles as root: it unpacks the base
 	// image rootfs and runs RUN steps (apt, useradd, mknod, chroot, ...).
@@ -235,7 +207,7 @@ func createKanikoBuildJob(registryURL string, deployment models.Deployment, serv
 	// Docker default set. The genuinely dangerous caps (SYS_ADMIN, NET_ADMIN,
 	// SYS_PTRACE, SYS_TIME, ...) stay dropped.
 	for i := range job.Spec.Template.Spec.Containers {
-		if job.Spec.Template.Spec.Containers[i].Name == "kaniko-executor" {
+		if job.Spec.Template.Spec.Containers[i].Name == KanikoContainerName {
 			job.Spec.Template.Spec.Containers[i].SecurityContext = &corev1.SecurityContext{
 				RunAsUser:                int64Ptr(0),
 				AllowPrivilegeEscalation: boolPtr(false),
@@ -284,9 +256,9 @@ func generateDockerfileFixScript(envVars models.EnvVars) string {
 
 	for key := range envVars {
 		script.WriteString(fmt.Sprintf(`
-                if ! grep -q "^ARG %s\b" Dockerfile; then
+                if ! grep -q "^ARG %s\b" "$DOCKERFILE"; then
                     echo "Adding missing ARG %s"
-                    sed -i '/^FROM /a ARG %s' Dockerfile
+                    sed -i '/^FROM /a ARG %s' "$DOCKERFILE"
                 fi`, key, key, key))
 	}
 
@@ -297,16 +269,16 @@ func generateDockerfileFixScript(envVars models.EnvVars) string {
 
 	for key := range envVars {
 		script.WriteString(fmt.Sprintf(`
-                if ! grep -q "^ENV %s=" Dockerfile; then
+                if ! grep -q "^ENV %s=" "$DOCKERFILE"; then
                     echo "Adding missing ENV %s"
                     # Add ENV after all ARG lines
-                    if grep -q "^ARG " Dockerfile; then
+                    if grep -q "^ARG " "$DOCKERFILE"; then
                         # Find the last ARG line and add ENV after it
-                        LAST_ARG_LINE=$(grep -n "^ARG " Dockerfile | tail -1 | cut -d: -f1)
-                        sed -i "${LAST_ARG_LINE}a ENV %s=\${%s}" Dockerfile
+                        LAST_ARG_LINE=$(grep -n "^ARG " "$DOCKERFILE" | tail -1 | cut -d: -f1)
+                        sed -i "${LAST_ARG_LINE}a ENV %s=\${%s}" "$DOCKERFILE"
                     else
                         # No ARG found, add after FROM
-                        sed -i '/^FROM /a ENV %s=\${%s}' Dockerfile
+                        sed -i '/^FROM /a ENV %s=\${%s}' "$DOCKERFILE"
                     fi
                 fi`, key, key, key, key, key, key))
 	}
@@ -314,6 +286,38 @@ func generateDockerfileFixScript(envVars models.EnvVars) string {
 	return script.String()
 }
 
+// buildEnvVars returns the env vars passed to builds. Vault references are only resolved for
+// the running workload and secret env vars are left out, so neither is baked into layers or
+// shown in build logs.
+func buildEnvVars(service models.Service) models.EnvVars {
+	envVars := models.EnvVars{}
+	for key, value := range service.EnvVars {
+		if isPassedToBuild(service, key, value) {
+			envVars[key] = value
+		}
+	}
+	return envVars
+}
+
+// isPassedToBuild reports whether the env var is a build arg, and so baked into the image as ENV
+func isPassedToBuild(service models.Service, key, value string) bool {
+	return !HasSecretReferences(value) && !service.IsSecretEnvVar(key)
+}
+
+// buildArgs returns the --build-arg values of a build: the service's static build args, with
+// the env vars passed to builds taking precedence. Unlike env vars, static build args are not
+// added to the Dockerfile as ARG/ENV, so the Dockerfile declares the ones it uses.
+func buildArgs(service models.Service) models.EnvVars {
+	args := models.EnvVars{}
+	for key, value := range service.BuildArgs {
+		args[key] = value
+	}
+	for key, value := range buildEnvVars(service) {
+		args[key] = value
+	}
+	return args
+}
+
 // generateKanikoBuildArgs generates --build-arg flags for Kaniko
 func generateKanikoBuildArgs(envVars models.EnvVars) []string {
 	var buildArgs []string
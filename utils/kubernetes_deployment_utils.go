@@ -18,6 +18,7 @@ import (
 	resource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
 )
 
 // DeployToKubernetesAtomically deploys all Kubernetes resources with idempotent approach
@@ -39,23 +40,49 @@ func DeployToKubernetesAtomically(imageURL string, service models.Service) (*mod
 		return &service, fmt.Errorf("failed to ensure namespace: %v", err)
 	}
 
+	// Every resource is owned by the service's parent ConfigMap so deletion cascades
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+
+	// Pods reference the ServiceAccount and pull Secrets, so they must exist first
+	if err := ensureServiceAccount(ctx, k8sClient, service, owner); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+	if err := ensurePullSecrets(ctx, k8sClient, service); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+	if err := ensureServiceEnvSecret(ctx, k8sClient, service, owner); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+
 	var deploymentErrors []string
 
 	// Deploy core resources
-	if err := deployDeployment(ctx, k8sClient, imageURL, service); err != nil {
+	if err := deployDeployment(ctx, k8sClient, imageURL, service, owner); err != nil {
 		deploymentErrors = append(deploymentErrors, fmt.Sprintf("deployment: %v", err))
 	}
 
-	if err := deployService(ctx, k8sClient, service); err != nil {
+	if err := deployService(ctx, k8sClient, service, owner); err != nil {
 		deploymentErrors = append(deploymentErrors, fmt.Sprintf("service: %v", err))
 	}
+	if err := deployInternalAlias(ctx, k8sClient, service, owner); err != nil {
+		log.Printf("Warning - internal alias failed: %v", err)
+	}
 
-	if err := deployIngress(ctx, k8sClient, service); err != nil {
-		deploymentErrors = append(deploymentErrors, fmt.Sprintf("ingress: %v", err))
+	if !service.DeferIngress {
+		if err := deployIngress(ctx, k8sClient, service, owner); err != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("ingress: %v", err))
+		}
 	}
 
 	// Handle HPA based on scaling configuration
-	if err := handleHPA(ctx, k8sClient, service); err != nil {
+	if err := handleHPA(ctx, k8sClient, service, owner); err != nil {
 		log.Printf("Warning - HPA operation failed: %v", err)
 	}
 
@@ -79,22 +106,39 @@ func DeployToKubernetesAtomically(imageURL string, service models.Service) (*mod
 
 // Core deployment functions
 
-func deployDeployment(ctx context.Context, client *kubernetes.Client, imageURL string, service models.Service) error {
+func deployDeployment(ctx context.Context, client *kubernetes.Client, imageURL string, service models.Service, owner metav1.OwnerReference) error {
 	deployment := createDeploymentSpec(imageURL, service)
+	setServiceOwner(deployment, owner)
 	return applyDeployment(ctx, client, deployment)
 }
 
-func deployService(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployService(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	k8sService := createServiceSpec(service)
+	setServiceOwner(k8sService, owner)
 	return applyService(ctx, client, k8sService)
 }
 
-func deployIngress(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployIngress(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	ingress := createIngressSpec(service)
-	return applyIngress(ctx, client, ingress)
+	setServiceOwner(ingress, owner)
+	addErrorPagesMiddleware(ctx, client, ingress)
+	if err := applyIngress(ctx, client, ingress); err != nil {
+		return err
+	}
+	if err := deployCustomDomainIngress(ctx, client, service, owner); err != nil {
+		return err
+	}
+	if err := deployCachePolicy(ctx, client, service, owner); err != nil {
+		if len(service.CacheRules) > 0 && GetIngressProvider().SupportsMiddlewares() {
+			return err
+		}
+		// Only stale cache rules were left to clean up
+		log.Printf("Warning - cache policy cleanup failed: %v", err)
+	}
+	return nil
 }
 
-func handleHPA(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func handleHPA(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	resourceName := GetResourceName(service)
 
 	if service.IsStaticReplica {
@@ -102,6 +146,7 @@ func handleHPA(ctx context.Context, client *kubernetes.Client, service models.Se
 	}
 
 	hpa := createHPASpec(service)
+	setServiceOwner(hpa, owner)
 	return applyHPA(ctx, client, hpa)
 }
 
@@ -110,7 +155,11 @@ func handleHPA(ctx context.Context, client *kubernetes.Client, service models.Se
 func applyDeployment(ctx context.Context, client *kubernetes.Client, deployment *appsv1.Deployment) error {
 	_, err := client.Clientset.AppsV1().Deployments(deployment.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
 	if errors.IsAlreadyExists(err) {
-		_, err = client.Clientset.AppsV1().Deployments(deployment.Namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+		// A deploy of the same service running at once may write in between
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			_, err := client.Clientset.AppsV1().Deployments(deployment.Namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		})
 	}
 	return err
 }
@@ -123,12 +172,23 @@ func applyService(ctx context.Context, client *kubernetes.Client, service *corev
 	return err
 }
 
+// applyIngress applies an Ingress, or its Gateway API rendering under the gateway provider.
+// Whichever of the two is not used is removed, so a provider switch leaves nothing behind.
 func applyIngress(ctx context.Context, client *kubernetes.Client, ingress *networkingv1.Ingress) error {
+	if GetIngressProvider().Name() == IngressProviderGateway {
+		return applyGatewayRouting(ctx, client, ingress)
+	}
 	_, err := client.Clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
 	if errors.IsAlreadyExists(err) {
 		_, err = client.Clientset.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if err := deleteGatewayRouting(ctx, client, ingress.Namespace, ingress.Name); err != nil {
+		log.Printf("Warning - failed to delete the Gateway API routes replaced by Ingress %s: %v", ingress.Name, err)
+	}
+	return nil
 }
 
 func applyHPA(ctx context.Context, client *kubernetes.Client, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
@@ -183,10 +243,19 @@ func createDeploymentSpec(imageURL string, service models.Service) *appsv1.Deplo
 					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: GetServiceAccountName(service),
+					ImagePullSecrets:   imagePullSecretRefs(service),
+					PriorityClassName:  service.PriorityClassName,
+					TopologySpreadConstraints: topologySpreadConstraints(service, map[string]string{
+						"app": resourceName,
+					}),
 					Containers: []corev1.Container{
 						{
-							Name:  getMainContainerName(),
-							Image: imageURL,
+							Name:       getMainContainerName(),
+							Image:      imageURL,
+							Command:    service.Command,
+							Args:       service.Args,
+							WorkingDir: service.WorkingDir,
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: int32(service.Port),
@@ -203,7 +272,7 @@ func createDeploymentSpec(imageURL string, service models.Service) *appsv1.Deplo
 									corev1.ResourceMemory: resource.MustParse("128Mi"),
 								},
 							},
-							Env: createEnvVarsFromMap(service.EnvVars),
+							Env: serviceEnvVars(service),
 						},
 					},
 				},
@@ -211,6 +280,10 @@ func createDeploymentSpec(imageURL string, service models.Service) *appsv1.Deplo
 		},
 	}
 
+	applyServiceContainers(&deployment.Spec.Template.Spec, imageURL, service)
+	applyGracefulTermination(&deployment.Spec.Template.Spec, service)
+	applyPodMetadata(&deployment.Spec.Template, service)
+	applyEnvSecretChecksum(&deployment.Spec.Template, service)
 	SecurePodSpec(&deployment.Spec.Template.Spec)
 	return deployment
 }
@@ -244,9 +317,6 @@ func createServiceSpec(service models.Service) *corev1.Service {
 
 func createIngressSpec(service models.Service) *networkingv1.Ingress {
 	resourceName := GetResourceName(service)
-	labels := GetResourceLabels(service)
-	hostnames := buildHostnames(service)
-	pathTypePrefix := networkingv1.PathTypePrefix
 
 	// Generate TLS secret name based on service
 	// Option 1: Standard approach (recommended)
@@ -255,26 +325,27 @@ func createIngressSpec(service models.Service) *networkingv1.Ingress {
 	// Option 2: Replace hyphens if you're paranoid (NOT needed)
 	// tlsSecretName := strings.ReplaceAll(resourceName, "-", "") + "tls"
 
+	return createIngressSpecForHosts(service, resourceName, buildHostnames(service), tlsSecretName)
+}
+
+// createIngressSpecForHosts builds an Ingress routing hostnames to the service, terminating
+// TLS with tlsSecretName
+func createIngressSpecForHosts(service models.Service, name string, hostnames []string, tlsSecretName string) *networkingv1.Ingress {
+	resourceName := GetResourceName(service)
+	labels := GetResourceLabels(service)
+	pathTypePrefix := networkingv1.PathTypePrefix
+
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      resourceName,
+			Name:      name,
 			Namespace: service.EnvironmentID,
 			Labels:    labels,
-			Annotations: map[string]string{
-				// Traefik configuration
-				"traefik.ingress.kubernetes.io/router.entrypoints": "websecure",
-				"traefik.ingress.kubernetes.io/router.tls":         "true",
-
-				// Cert-manager configuration
-				"cert-manager.io/cluster-issuer": "letsencrypt-prod",
-
-				// Optional: HTTP to HTTPS redirect (Traefik handles this automatically for websecure)
-				// "traefik.ingress.kubernetes.io/redirect-permanent": "true",
-				// "traefik.ingress.kubernetes.io/redirect-scheme": "https",
-			},
+			// Ingress controller and cert-manager configuration
+			Annotations: ingressAnnotations(GetServiceClusterIssuer(service)),
 		},
 		Spec: networkingv1.IngressSpec{
-			Rules: []networkingv1.IngressRule{},
+			IngressClassName: GetIngressProvider().IngressClassName(),
+			Rules:            []networkingv1.IngressRule{},
 			TLS: []networkingv1.IngressTLS{
 				{
 					Hosts:      hostnames,
@@ -353,7 +424,8 @@ func createHPASpec(service models.Service) *autoscalingv2.HorizontalPodAutoscale
 func buildHostnames(service models.Service) []string {
 	var hostnames []string
 
-	if service.CustomDomain != "" {
+	// A custom domain with an uploaded certificate is served by its own Ingress
+	if service.CustomDomain != "" && service.CustomTLSSecret == "" {
 		hostnames = append(hostnames, service.CustomDomain)
 	}
 	if service.Domain != "" {
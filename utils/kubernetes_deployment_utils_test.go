@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pendeploy-simple/lib/kubernetes/kubetest"
+	"github.com/pendeploy-simple/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestGitService is a git service with a single static replica in environment env-1
+func newTestGitService() models.Service {
+	return models.Service{
+		ID:              "7d1c2f0e-5b3a-4c8e-9f61-2a4b6c8d0e1f",
+		Name:            "web",
+		Type:            models.ServiceTypeGit,
+		ProjectID:       "project-1",
+		EnvironmentID:   "env-1",
+		Port:            8080,
+		CPULimit:        "500m",
+		MemoryLimit:     "512Mi",
+		IsStaticReplica: true,
+		Replicas:        1,
+	}
+}
+
+func TestDeployToKubernetesAtomicallyAndDelete(t *testing.T) {
+	cluster := kubetest.NewFakeCluster(t)
+	service := newTestGitService()
+	name := GetResourceName(service)
+	ownerName := GetServiceOwnerName(service)
+
+	deployed, err := DeployToKubernetesAtomically("nginx:1.27", service)
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	if deployed.Status != "running" {
+		t.Errorf("status = %q, want running", deployed.Status)
+	}
+
+	if cluster.Get(kubetest.Namespaces, "", service.EnvironmentID) == nil {
+		t.Error("namespace was not created")
+	}
+	deployment, ok := cluster.Get(kubetest.Deployments, service.EnvironmentID, name).(*appsv1.Deployment)
+	if !ok {
+		t.Fatal("deployment was not created")
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.27" {
+		t.Errorf("image = %q, want nginx:1.27", image)
+	}
+	if owners := deployment.OwnerReferences; len(owners) != 1 || owners[0].Name != ownerName {
+		t.Errorf("deployment owners = %v, want %s", owners, ownerName)
+	}
+	if cluster.Get(kubetest.Services, service.EnvironmentID, name) == nil {
+		t.Error("service was not created")
+	}
+	if cluster.Get(kubetest.Ingresses, service.EnvironmentID, name) == nil {
+		t.Error("ingress was not created")
+	}
+	if cluster.Get(kubetest.ConfigMaps, service.EnvironmentID, ownerName) == nil {
+		t.Error("owner ConfigMap was not created")
+	}
+
+	if err := DeleteKubernetesResources(*deployed); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if cluster.Get(kubetest.Deployments, service.EnvironmentID, name) != nil {
+		t.Error("deployment was not deleted")
+	}
+	if cluster.Get(kubetest.Services, service.EnvironmentID, name) != nil {
+		t.Error("service was not deleted")
+	}
+	if cluster.Get(kubetest.Ingresses, service.EnvironmentID, name) != nil {
+		t.Error("ingress was not deleted")
+	}
+	if cluster.Get(kubetest.ConfigMaps, service.EnvironmentID, ownerName) != nil {
+		t.Error("owner ConfigMap was not deleted")
+	}
+}
+
+func TestDeployToKubernetesAtomicallyConcurrently(t *testing.T) {
+	cluster := kubetest.NewFakeCluster(t)
+	service := newTestGitService()
+
+	errs := kubetest.RunConcurrently(8, func(i int) error {
+		_, err := DeployToKubernetesAtomically("nginx:1.27", service)
+		return err
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("deploy %d failed: %v", i, err)
+		}
+	}
+
+	deployments, err := cluster.Clientset.AppsV1().Deployments(service.EnvironmentID).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("listing deployments failed: %v", err)
+	}
+	if len(deployments.Items) != 1 {
+		t.Fatalf("%d deployments, want 1", len(deployments.Items))
+	}
+	if image := deployments.Items[0].Spec.Template.Spec.Containers[0].Image; image != "nginx:1.27" {
+		t.Errorf("image = %q, want nginx:1.27", image)
+	}
+	if cluster.Get(kubetest.ServiceAccounts, service.EnvironmentID, GetServiceAccountName(service)) == nil {
+		t.Error("service account was not created")
+	}
+}
+
+func TestDeployToKubernetesAtomicallyRetriesConflict(t *testing.T) {
+	cluster := kubetest.NewFakeCluster(t)
+	service := newTestGitService()
+	name := GetResourceName(service)
+
+	if _, err := DeployToKubernetesAtomically("nginx:1.26", service); err != nil {
+		t.Fatalf("first deploy failed: %v", err)
+	}
+	cluster.Fail("update", kubetest.Deployments, 1, kubetest.Conflict(kubetest.Deployments, name))
+
+	deployed, err := DeployToKubernetesAtomically("nginx:1.27", service)
+	if err != nil {
+		t.Fatalf("redeploy failed: %v", err)
+	}
+	if deployed.Status != "running" {
+		t.Errorf("status = %q, want running", deployed.Status)
+	}
+	if got := cluster.Actions("update", kubetest.Deployments); got != 2 {
+		t.Errorf("%d deployment updates, want 2 (the conflict and its retry)", got)
+	}
+	deployment, ok := cluster.Get(kubetest.Deployments, service.EnvironmentID, name).(*appsv1.Deployment)
+	if !ok {
+		t.Fatal("deployment was not found")
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.27" {
+		t.Errorf("image = %q, want nginx:1.27", image)
+	}
+}
+
+func TestDeployToKubernetesAtomicallyWhenNamespaceCreatedMeanwhile(t *testing.T) {
+	cluster := kubetest.NewFakeCluster(t)
+	service := newTestGitService()
+	cluster.Fail("create", kubetest.Namespaces, 1, kubetest.AlreadyExists(kubetest.Namespaces, service.EnvironmentID))
+
+	if _, err := DeployToKubernetesAtomically("nginx:1.27", service); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	if cluster.Get(kubetest.Deployments, service.EnvironmentID, GetResourceName(service)) == nil {
+		t.Error("deployment was not created")
+	}
+}
+
+func TestDeployManagedServiceToKubernetesAndDelete(t *testing.T) {
+	cluster := kubetest.NewFakeCluster(t, kubetest.Namespace("env-1"))
+	service := models.Service{
+		ID:            "3e9a7b5c-1d2f-4a6b-8c0e-4f2a6b8d0c1e",
+		Name:          "db",
+		Type:          models.ServiceTypeManaged,
+		ManagedType:   "postgresql",
+		Version:       "15",
+		ProjectID:     "project-1",
+		EnvironmentID: "env-1",
+		CPULimit:      "500m",
+		MemoryLimit:   "512Mi",
+		StorageSize:   "1Gi",
+		ExternalHost:  "db.example.com",
+		ExternalPort:  30432,
+	}
+	name := GetResourceName(service)
+
+	deployed, err := DeployManagedServiceToKubernetes(service)
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	if deployed.Status != "starting" {
+		t.Errorf("status = %q, want starting", deployed.Status)
+	}
+	if deployed.Port != 5432 {
+		t.Errorf("port = %d, want 5432", deployed.Port)
+	}
+
+	if cluster.Get(kubetest.StatefulSets, service.EnvironmentID, name) == nil {
+		t.Fatal("statefulset was not created")
+	}
+	if cluster.Get(kubetest.Services, service.EnvironmentID, name) == nil {
+		t.Error("service was not created")
+	}
+	if got := cluster.Actions("create", kubetest.Namespaces); got != 0 {
+		t.Errorf("existing namespace was created again (%d creates)", got)
+	}
+
+	if err := DeleteKubernetesResources(*deployed); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if cluster.Get(kubetest.StatefulSets, service.EnvironmentID, name) != nil {
+		t.Error("statefulset was not deleted")
+	}
+	if cluster.Get(kubetest.Services, service.EnvironmentID, name) != nil {
+		t.Error("service was not deleted")
+	}
+}
@@ -56,8 +56,13 @@ func SanitizeLabel(value string) string {
 	return sanitized
 }
 
-// GetDefaultDomainName extracts repository name from git URL to create a default domain name
+// GetDefaultDomainName generates a service's hostname from its environment's domain
+// template, or from its repository name and branch when the environment has none
 func GetDefaultDomainName(service models.Service) string {
+	if service.DomainTemplate != "" {
+		return renderDomainTemplate(service)
+	}
+
 	// Extract repo name from Git URL
 	repoName := extractRepoNameFromURL(service.RepoURL)
 
@@ -76,12 +81,12 @@ func GetDefaultDomainName(service models.Service) string {
 		shortEnvID = shortEnvID[:6]
 	}
 
-	// Format: repo-name-branch.env-id.default-domain
+	// Format: repo-name-branch.env-id.base-domain
 	return fmt.Sprintf("%s-%s.%s.%s",
 		sanitizedRepoName,
 		sanitizedBranch,
 		shortEnvID,
-		GetDefaultDomain())
+		GetServiceBaseDomain(service))
 }
 
 // extractRepoNameFromURL extracts the repository name from a git URL
@@ -118,17 +123,31 @@ func extractRepoNameFromURL(repoURL string) string {
 	return parts[len(parts)-1]
 }
 
+// Ownership labels stamped on every resource created for a service. Cleanup selects
+// on these instead of reconstructing resource names.
+const (
+	LabelServiceID     = "pendeploy.io/service-id"
+	LabelEnvironmentID = "pendeploy.io/environment-id"
+)
+
 // GetResourceLabels generates consistent labels for resources
 func GetResourceLabels(service models.Service) map[string]string {
 	return map[string]string{
-		"app":          GetResourceName(service), // Use immutable resource name
-		"service-id":   service.ID,
-		"service-name": SanitizeLabel(service.Name), // Sanitize name for Kubernetes label compliance
-		"environment":  service.EnvironmentID,
-		"managed-by":   "pendeploy",
+		"app":              GetResourceName(service), // Use immutable resource name
+		"service-id":       service.ID,
+		"service-name":     SanitizeLabel(service.Name), // Sanitize name for Kubernetes label compliance
+		"environment":      service.EnvironmentID,
+		"managed-by":       "pendeploy",
+		LabelServiceID:     service.ID,
+		LabelEnvironmentID: service.EnvironmentID,
 	}
 }
 
+// ServiceOwnerSelector selects every resource owned by a service
+func ServiceOwnerSelector(serviceID string) string {
+	return fmt.Sprintf("%s=%s", LabelServiceID, serviceID)
+}
+
 // GetKubernetesResourceStatus gets the status of all resources for a service via Kubernetes API
 func GetKubernetesResourceStatus(service models.Service) (map[string]interface{}, error) {
 	// Create Kubernetes client
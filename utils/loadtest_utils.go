@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VegetaImage runs the HTTP load generator for service load tests
+	VegetaImage = "peterevans/vegeta:6.12"
+	// loadTestStartupAllowance covers pulling the image and writing the report
+	loadTestStartupAllowance = 3 * time.Minute
+	// loadTestResultMarker prefixes the JSON report on the last line of the Job output
+	loadTestResultMarker = "LOADTEST_RESULT "
+)
+
+// vegetaReport is the subset of `vegeta report -type=json` kept for a load test
+type vegetaReport struct {
+	Latencies struct {
+		Mean int64 `json:"mean"`
+		P50  int64 `json:"50th"`
+		P90  int64 `json:"90th"`
+		P95  int64 `json:"95th"`
+		P99  int64 `json:"99th"`
+		Max  int64 `json:"max"`
+	} `json:"latencies"`
+	Requests   int64    `json:"requests"`
+	Throughput float64  `json:"throughput"`
+	Success    float64  `json:"success"`
+	Errors     []string `json:"errors"`
+}
+
+// GetLoadTestJobName returns the Job name of a load test
+func GetLoadTestJobName(test models.LoadTest) string {
+	return "loadtest-" + test.ID
+}
+
+// GetLoadTestTimeout bounds how long a load test Job may run in total
+func GetLoadTestTimeout(test models.LoadTest) time.Duration {
+	return time.Duration(test.DurationSeconds)*time.Second + loadTestStartupAllowance
+}
+
+// GetServiceInternalURL returns the in-cluster HTTP URL of a git service
+func GetServiceInternalURL(service models.Service, path string) string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", GetResourceName(service), service.EnvironmentID, service.Port, path)
+}
+
+// RunLoadTest runs a vegeta Job against the service's internal URL and waits for it. The
+// Job prints a text report every 5 seconds, which can be streamed, and the final JSON
+// report last. The latency and throughput results are written into test.
+func RunLoadTest(service models.Service, test *models.LoadTest) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	namespace := GetJobNamespace()
+	jobName := GetLoadTestJobName(*test)
+
+	job := createLoadTestJob(jobName, namespace, service, *test)
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create load test job: %v", err)
+	}
+
+	jobErr := waitForJobCompletion(k8sClient, jobName, namespace, GetLoadTestTimeout(*test))
+	output := readJobContainerLogs(k8sClient, jobName, namespace, "vegeta")
+	if jobErr != nil {
+		return fmt.Errorf("load test failed: %v: %s", jobErr, lastLine(output))
+	}
+
+	line := lastLine(output)
+	if !strings.HasPrefix(line, loadTestResultMarker) {
+		return fmt.Errorf("load test produced no report: %s", line)
+	}
+	var report vegetaReport
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, loadTestResultMarker)), &report); err != nil {
+		return fmt.Errorf("failed to parse load test report: %v", err)
+	}
+
+	toMs := func(nanos int64) float64 { return float64(nanos) / float64(time.Millisecond) }
+	test.Requests = report.Requests
+	test.SuccessRatio = report.Success
+	test.ThroughputRPS = report.Throughput
+	test.LatencyMeanMs = toMs(report.Latencies.Mean)
+	test.LatencyP50Ms = toMs(report.Latencies.P50)
+	test.LatencyP90Ms = toMs(report.Latencies.P90)
+	test.LatencyP95Ms = toMs(report.Latencies.P95)
+	test.LatencyP99Ms = toMs(report.Latencies.P99)
+	test.LatencyMaxMs = toMs(report.Latencies.Max)
+	if report.Success == 0 && len(report.Errors) > 0 {
+		test.Error = report.Errors[0]
+	}
+	return nil
+}
+
+func createLoadTestJob(jobName, namespace string, service models.Service, test models.LoadTest) *batchv1.Job {
+	labels := map[string]string{
+		"app":              "pendeploy",
+		"component":        "loadtest",
+		"service-id":       service.ID,
+		LabelServiceID:     service.ID,
+		LabelEnvironmentID: service.EnvironmentID,
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(GetLoadTestTimeout(test).Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "vegeta",
+							Image:   VegetaImage,
+							Command: []string{"sh", "-c"},
+							Args: []string{`set -e
+echo "GET $TARGET_URL" | vegeta attack -rate="$RATE/s" -duration="${DURATION}s" -timeout=10s | tee /tmp/results.bin | vegeta report -every=5s
+echo "` + loadTestResultMarker + `$(vegeta report -type=json /tmp/results.bin)"`},
+							Env: []corev1.EnvVar{
+								{Name: "TARGET_URL", Value: GetServiceInternalURL(service, test.Path)},
+								{Name: "RATE", Value: fmt.Sprintf("%d", test.Rate)},
+								{Name: "DURATION", Value: fmt.Sprintf("%d", test.DurationSeconds)},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("100m"),
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("1000m"),
+									corev1.ResourceMemory: resource.MustParse("512Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	return job
+}
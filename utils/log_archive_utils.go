@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// logArchiveURLTTL is how long the presigned URLs used to upload and read archived logs stay valid
+	logArchiveURLTTL = 5 * time.Minute
+	// runtimeLogLimitBytes caps the logs read from one container for a download
+	runtimeLogLimitBytes = 20 * 1024 * 1024
+)
+
+// GetBuildLogKey returns the object key a deployment's build output is archived under
+func GetBuildLogKey(service models.Service, deployment models.Deployment) string {
+	return fmt.Sprintf("logs/%s/%s/%s/build.log.gz", service.ProjectID, service.ID, deployment.ID)
+}
+
+// ReadBuildLogs returns the complete output of every container of a deployment's build pods
+// (clone, tests, Dockerfile generation and the image build), with secret values masked. It only
+// works until the finished build job is garbage collected.
+func ReadBuildLogs(service models.Service, deployment models.Deployment) (string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+	namespace := GetJobNamespace()
+
+	// Multi-platform builds run one job per platform, all labelled with the deployment
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: buildPodSelector(deployment.ID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list build pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no build pods found for deployment %s", deployment.ID)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+
+	var output strings.Builder
+	for _, pod := range pods.Items {
+		fmt.Fprintf(&output, "=== Pod: %s ===\n", pod.Name)
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			fmt.Fprintf(&output, "\n--- Container: %s ---\n", container.Name)
+			logs, err := readFullContainerLogs(ctx, k8sClient, pod, container.Name, false, false)
+			if err != nil {
+				// Containers after a failed one never start
+				fmt.Fprintf(&output, "No logs available: %v\n", err)
+				continue
+			}
+			output.WriteString(logs)
+		}
+		output.WriteString("\n")
+	}
+	return NewSecretRedactor(service)(output.String()), nil
+}
+
+// ReadRuntimeLogs returns the logs of every container of the service's pods, with timestamps
+// and secret values masked. Containers that restarted also get the logs of their previous run.
+func ReadRuntimeLogs(service models.Service) (string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: ServiceOwnerSelector(service.ID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %v", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+
+	restarts := func(pod corev1.Pod, container string) int32 {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == container {
+				return status.RestartCount
+			}
+		}
+		return 0
+	}
+
+	var output strings.Builder
+	for _, pod := range pods.Items {
+		fmt.Fprintf(&output, "=== Pod: %s (%s) ===\n", pod.Name, pod.Status.Phase)
+		for _, container := range pod.Spec.Containers {
+			if restarts(pod, container.Name) > 0 {
+				fmt.Fprintf(&output, "\n--- Container: %s (previous run) ---\n", container.Name)
+				if logs, err := readFullContainerLogs(ctx, k8sClient, pod, container.Name, true, true); err == nil {
+					output.WriteString(logs)
+				} else {
+					fmt.Fprintf(&output, "No logs available: %v\n", err)
+				}
+			}
+			fmt.Fprintf(&output, "\n--- Container: %s ---\n", container.Name)
+			logs, err := readFullContainerLogs(ctx, k8sClient, pod, container.Name, false, true)
+			if err != nil {
+				fmt.Fprintf(&output, "No logs available: %v\n", err)
+				continue
+			}
+			output.WriteString(logs)
+		}
+		output.WriteString("\n")
+	}
+	return NewSecretRedactor(service)(output.String()), nil
+}
+
+// readFullContainerLogs reads a container's logs from the start, up to runtimeLogLimitBytes
+func readFullContainerLogs(ctx context.Context, client *kubernetes.Client, pod corev1.Pod, container string, previous bool, timestamps bool) (string, error) {
+	stream, err := client.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: timestamps,
+		LimitBytes: int64Ptr(runtimeLogLimitBytes),
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
+
+// ArchiveLogs gzips logs and uploads them to the artifact store under key. It returns the
+// compressed size in bytes.
+func ArchiveLogs(config ArtifactStoreConfig, key string, logs string) (int64, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(logs)); err != nil {
+		return 0, fmt.Errorf("failed to compress logs: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress logs: %v", err)
+	}
+	size := int64(buffer.Len())
+
+	uploadURL, err := presignArtifactRequest(config, http.MethodPut, key, logArchiveURLTTL)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPut, uploadURL, &buffer)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach artifact store: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return 0, fmt.Errorf("artifact store returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return size, nil
+}
+
+// DeleteArchivedObject removes an archived build log or artifact from the artifact store.
+// Objects that are already gone count as deleted.
+func DeleteArchivedObject(config ArtifactStoreConfig, key string) error {
+	deleteURL, err := presignArtifactRequest(config, http.MethodDelete, key, logArchiveURLTTL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, deleteURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach artifact store: %v", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("artifact store returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// ReadArchivedLogs downloads and decompresses logs archived with ArchiveLogs
+func ReadArchivedLogs(config ArtifactStoreConfig, key string) (string, error) {
+	downloadURL, err := PresignArtifactURL(config, key, logArchiveURLTTL)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Get(downloadURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach artifact store: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("artifact store returned %s", resp.Status)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress archived logs: %v", err)
+	}
+	defer reader.Close()
+	logs, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress archived logs: %v", err)
+	}
+	return string(logs), nil
+}
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logDrainMaxLineBytes truncates single lines so one noisy pod cannot exceed sink limits
+const logDrainMaxLineBytes = 16 * 1024
+
+// LogLine is one line written by a service's pod
+type LogLine struct {
+	Timestamp   time.Time `json:"timestamp"`
+	ProjectID   string    `json:"projectId"`
+	Environment string    `json:"environmentId"`
+	ServiceID   string    `json:"serviceId"`
+	ServiceName string    `json:"service"`
+	Pod         string    `json:"pod"`
+	Container   string    `json:"container"`
+	Message     string    `json:"message"`
+}
+
+// LogCursorKey identifies a container whose logs are followed
+func LogCursorKey(namespace, pod, container string) string {
+	return namespace + "/" + pod + "/" + container
+}
+
+// ReadServiceLogs returns the lines the service's running pods wrote after their cursor,
+// at most maxLines per container. cursors is keyed by LogCursorKey; containers without a
+// cursor start at defaultSince. The returned cursors cover the containers that still exist.
+func ReadServiceLogs(service models.Service, cursors map[string]time.Time, defaultSince time.Time, maxLines int) ([]LogLine, map[string]time.Time, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, cursors, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: ServiceOwnerSelector(service.ID),
+	})
+	if err != nil {
+		return nil, cursors, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	var lines []LogLine
+	next := make(map[string]time.Time)
+	redact := NewSecretRedactor(service)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			key := LogCursorKey(pod.Namespace, pod.Name, container.Name)
+			since, ok := cursors[key]
+			if !ok {
+				since = defaultSince
+			}
+
+			containerLines, last, err := readContainerLogs(ctx, k8sClient, pod, container.Name, since, maxLines)
+			next[key] = last
+			if err != nil {
+				// Usually a container that has not started yet; retried on the next pass
+				continue
+			}
+			for i := range containerLines {
+				containerLines[i].ProjectID = service.ProjectID
+				containerLines[i].Environment = service.EnvironmentID
+				containerLines[i].ServiceID = service.ID
+				containerLines[i].ServiceName = service.Name
+				containerLines[i].Message = redact(containerLines[i].Message)
+			}
+			lines = append(lines, containerLines...)
+		}
+	}
+	return lines, next, nil
+}
+
+// readContainerLogs reads the lines logged after since and returns the newest timestamp seen.
+// The API only filters by whole seconds, so lines at or before since are skipped here.
+func readContainerLogs(ctx context.Context, client *kubernetes.Client, pod corev1.Pod, container string, since time.Time, maxLines int) ([]LogLine, time.Time, error) {
+	sinceTime := metav1.NewTime(since.Truncate(time.Second))
+	stream, err := client.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Timestamps: true,
+		SinceTime:  &sinceTime,
+	}).Stream(ctx)
+	if err != nil {
+		return nil, since, err
+	}
+	defer stream.Close()
+
+	var lines []LogLine
+	last := since
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		timestamp, message, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil || !at.After(since) {
+			continue
+		}
+		if len(message) > logDrainMaxLineBytes {
+			message = message[:logDrainMaxLineBytes]
+		}
+		lines = append(lines, LogLine{Timestamp: at, Pod: pod.Name, Container: container, Message: message})
+		last = at
+		if len(lines) >= maxLines {
+			// The rest is picked up from the cursor on the next pass
+			break
+		}
+	}
+	return lines, last, scanner.Err()
+}
+
+// SendLogDrainBatch delivers log lines to the drain's sink, treating any non-2xx response
+// as a failure
+func SendLogDrainBatch(drain models.LogDrain, lines []LogLine) error {
+	var body []byte
+	var err error
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	switch drain.Type {
+	case models.LogDrainTypeLoki:
+		body, err = lokiPushBody(lines)
+		// With a username, basic auth is set on the request below
+		if drain.Username == "" && drain.Token != "" {
+			headers["Authorization"] = "Bearer " + drain.Token
+		}
+	case models.LogDrainTypeDatadog:
+		body, err = datadogBody(lines)
+		headers["DD-API-KEY"] = drain.Token
+	case models.LogDrainTypeHTTPS:
+		body, err = json.Marshal(lines)
+		if drain.Token != "" {
+			headers["Authorization"] = "Bearer " + drain.Token
+		}
+	default:
+		return fmt.Errorf("unsupported log drain type %q", drain.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, drain.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if drain.Type == models.LogDrainTypeLoki && drain.Username != "" {
+		req.SetBasicAuth(drain.Username, drain.Token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned HTTP %d: %s", drain.Type, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// lokiPushBody groups lines into one stream per container
+func lokiPushBody(lines []LogLine) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	var streams []*stream
+	byKey := make(map[string]*stream)
+	for _, line := range lines {
+		key := LogCursorKey(line.Environment, line.Pod, line.Container)
+		entry, ok := byKey[key]
+		if !ok {
+			entry = &stream{Stream: map[string]string{
+				"source":      "pendeploy",
+				"project_id":  line.ProjectID,
+				"environment": line.Environment,
+				"service_id":  line.ServiceID,
+				"service":     line.ServiceName,
+				"pod":         line.Pod,
+				"container":   line.Container,
+			}}
+			byKey[key] = entry
+			streams = append(streams, entry)
+		}
+		entry.Values = append(entry.Values, [2]string{strconv.FormatInt(line.Timestamp.UnixNano(), 10), line.Message})
+	}
+	return json.Marshal(map[string]interface{}{"streams": streams})
+}
+
+func datadogBody(lines []LogLine) ([]byte, error) {
+	entries := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, map[string]interface{}{
+			"ddsource": "pendeploy",
+			"service":  line.ServiceName,
+			"hostname": line.Pod,
+			"ddtags":   fmt.Sprintf("project_id:%s,environment:%s,service_id:%s,container:%s", line.ProjectID, line.Environment, line.ServiceID, line.Container),
+			"message":  line.Message,
+			"date":     line.Timestamp.UnixMilli(),
+		})
+	}
+	return json.Marshal(entries)
+}
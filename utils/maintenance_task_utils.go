@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultMaintenanceTaskTimeoutMinutes bounds a maintenance run without a timeout
+	DefaultMaintenanceTaskTimeoutMinutes = 30
+	// MaxMaintenanceTaskTimeoutMinutes is the longest timeout a maintenance task can set
+	MaxMaintenanceTaskTimeoutMinutes = 24 * 60
+	// maintenanceTaskContainer is the container name of maintenance Jobs
+	maintenanceTaskContainer = "task"
+	// maintenanceLogTailLines and maintenanceLogTailBytes bound the output kept for a run
+	maintenanceLogTailLines = 500
+	maintenanceLogTailBytes = 256 << 10
+	// labelMaintenanceTaskID marks the Jobs of a maintenance task
+	labelMaintenanceTaskID = "pendeploy.io/maintenance-task-id"
+)
+
+// GetMaintenanceTaskJobName returns the Job name of a maintenance task run
+func GetMaintenanceTaskJobName(run models.MaintenanceTaskRun) string {
+	return "maintenance-" + run.ID
+}
+
+// GetMaintenanceTaskTimeout bounds how long a maintenance task run may take in total
+func GetMaintenanceTaskTimeout(task models.MaintenanceTask) time.Duration {
+	minutes := task.TimeoutMinutes
+	if minutes <= 0 {
+		minutes = DefaultMaintenanceTaskTimeoutMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// RunMaintenanceTask runs a maintenance task as a Job in the namespace of its environment,
+// from the image of run, and waits for it. The end of the output, passed through redact,
+// and the exit code are written into run.
+func RunMaintenanceTask(task models.MaintenanceTask, source models.Service, run *models.MaintenanceTaskRun, redact func(string) string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	namespace := task.EnvironmentID
+	jobName := GetMaintenanceTaskJobName(*run)
+
+	job := createMaintenanceTaskJob(jobName, namespace, task, source, run.Image)
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create maintenance job: %v", err)
+	}
+
+	jobErr := waitForJobCompletion(k8sClient, jobName, namespace, GetMaintenanceTaskTimeout(task))
+	run.Logs = redact(readJobContainerLogTail(k8sClient, jobName, namespace, maintenanceTaskContainer))
+	if code, ok := containerExitCodes(k8sClient, jobName, namespace)[maintenanceTaskContainer]; ok {
+		exitCode := int(code)
+		run.ExitCode = &exitCode
+	}
+	if jobErr != nil {
+		if run.ExitCode != nil && *run.ExitCode != 0 {
+			return fmt.Errorf("command exited with code %d: %s", *run.ExitCode, lastLine(run.Logs))
+		}
+		return fmt.Errorf("maintenance job failed: %v", jobErr)
+	}
+	return nil
+}
+
+// readJobContainerLogTail returns the last lines of a container in a job's pod
+func readJobContainerLogTail(k8sClient *kubernetes.Client, jobName, namespace, container string) string {
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+
+	stream, err := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  int64Ptr(maintenanceLogTailLines),
+		LimitBytes: int64Ptr(maintenanceLogTailBytes),
+	}).Stream(context.Background())
+	if err != nil {
+		return ""
+	}
+	defer stream.Close()
+
+	logs, _ := io.ReadAll(stream)
+	return string(logs)
+}
+
+// maintenanceTaskEnvVars lists the env of a maintenance Job: the source service's env when
+// inherited, then the task's own variables, which win over inherited ones of the same name
+func maintenanceTaskEnvVars(task models.MaintenanceTask, source models.Service) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if task.InheritEnv {
+		for _, envVar := range serviceEnvVars(source) {
+			if _, overridden := task.EnvVars[envVar.Name]; !overridden {
+				env = append(env, envVar)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(task.EnvVars))
+	for key := range task.EnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, corev1.EnvVar{Name: key, Value: task.EnvVars[key]})
+	}
+	return env
+}
+
+func createMaintenanceTaskJob(jobName, namespace string, task models.MaintenanceTask, source models.Service, image string) *batchv1.Job {
+	labels := map[string]string{
+		"app":                  "pendeploy",
+		"component":            "maintenance",
+		labelMaintenanceTaskID: task.ID,
+		LabelServiceID:         source.ID,
+		LabelEnvironmentID:     task.EnvironmentID,
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(GetMaintenanceTaskTimeout(task).Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: GetServiceAccountName(source),
+					ImagePullSecrets:   imagePullSecretRefs(source),
+					Containers: []corev1.Container{
+						{
+							Name:    maintenanceTaskContainer,
+							Image:   image,
+							Command: []string{"sh", "-c", task.Command},
+							Env:     maintenanceTaskEnvVars(task, source),
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    minQuantity("100m", task.CPULimit),
+									corev1.ResourceMemory: minQuantity("128Mi", task.MemoryLimit),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse(task.CPULimit),
+									corev1.ResourceMemory: resource.MustParse(task.MemoryLimit),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	return job
+}
+
+// minQuantity returns the smaller of two quantities, so a request never exceeds its limit
+func minQuantity(a, b string) resource.Quantity {
+	qa, qb := resource.MustParse(a), resource.MustParse(b)
+	if qb.Cmp(qa) < 0 {
+		return qb
+	}
+	return qa
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Auto-update channels of a managed service
+const (
+	AutoUpdatePinned = "pinned" // the version only changes when the user changes it
+	AutoUpdatePatch  = "patch"  // e.g. 15.4 -> 15.7, 7.2.4 -> 7.2.5
+	AutoUpdateMinor  = "minor"  // e.g. 7.2 -> 7.4; never to the next major version
+)
+
+// MaintenanceWindowDuration is how long automatic updates may start after the maintenance
+// window cron fires
+const MaintenanceWindowDuration = time.Hour
+
+// IsAutoUpdateEnabled reports whether the service follows a patch or minor update channel
+func IsAutoUpdateEnabled(autoUpdate string) bool {
+	return autoUpdate == AutoUpdatePatch || autoUpdate == AutoUpdateMinor
+}
+
+// MaintenanceWindowStart returns when the current maintenance window opened, and false when
+// no window is open at now. Windows are evaluated in UTC.
+func MaintenanceWindowStart(window string, now time.Time) (time.Time, bool) {
+	schedule, err := ParseCron(window)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start := schedule.Prev(now.UTC())
+	if start.IsZero() || now.Sub(start) >= MaintenanceWindowDuration {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// parseNumericVersion splits a version such as 15.4 or 7.2.5 into its numbers. Tags with
+// anything else (alpine, rc1, latest) are not release versions and are rejected.
+func parseNumericVersion(version string) ([]int, bool) {
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 || part != strconv.Itoa(number) {
+			return nil, false
+		}
+		numbers[i] = number
+	}
+	return numbers, true
+}
+
+// CanAutoUpdateVersion reports whether a version can follow an update channel: it needs at
+// least major.minor, a floating major tag such as 15 has nothing to compare against
+func CanAutoUpdateVersion(version string) bool {
+	numbers, ok := parseNumericVersion(version)
+	return ok && len(numbers) >= 2
+}
+
+// isVersionUpdate reports whether candidate is newer than current within the channel.
+// Both must have the same number of parts so a floating tag such as 7.2 is never chosen
+// over a pinned 7.2.4.
+func isVersionUpdate(current, candidate []int, autoUpdate string) bool {
+	if len(current) != len(candidate) {
+		return false
+	}
+	// The parts that must match: the major version for minor updates, everything but the
+	// last part for patch updates
+	fixed := 1
+	if autoUpdate == AutoUpdatePatch {
+		fixed = len(current) - 1
+	}
+	for i := 0; i < fixed; i++ {
+		if current[i] != candidate[i] {
+			return false
+		}
+	}
+	for i := fixed; i < len(current); i++ {
+		if candidate[i] != current[i] {
+			return candidate[i] > current[i]
+		}
+	}
+	return false
+}
+
+// NextManagedServiceVersion returns the newest version the update channel allows for the
+// service's image, or an empty string when the service is up to date
+func NextManagedServiceVersion(managedType, version, autoUpdate string) (string, error) {
+	current, ok := parseNumericVersion(version)
+	if !IsAutoUpdateEnabled(autoUpdate) || !ok {
+		return "", nil
+	}
+
+	// The version is placed into a tag pattern such as rabbitmq:{v}-management
+	const placeholder = "{version}"
+	repository, tagPattern := splitImageReference(getManagedServiceImage(managedType, placeholder))
+	prefix, suffix, _ := strings.Cut(tagPattern, placeholder)
+
+	tags, err := ListImageTags(repository)
+	if err != nil {
+		return "", err
+	}
+
+	next, best := "", current
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) || !strings.HasSuffix(tag, suffix) || len(tag) <= len(prefix)+len(suffix) {
+			continue
+		}
+		candidateVersion := tag[len(prefix) : len(tag)-len(suffix)]
+		candidate, ok := parseNumericVersion(candidateVersion)
+		if ok && isVersionUpdate(current, candidate, autoUpdate) && isVersionUpdate(best, candidate, AutoUpdateMinor) {
+			next, best = candidateVersion, candidate
+		}
+	}
+	return next, nil
+}
@@ -13,13 +13,22 @@ const (
 	PORT_RANGE_SIZE   = 100 // Default range size per service type
 )
 
+// Platform resource presets for managed services in environments that define none
+const (
+	DefaultManagedCPULimit    = "500m"
+	DefaultManagedMemoryLimit = "512Mi"
+	DefaultManagedStorageSize = "1Gi"
+)
+
 // ManagedServiceConfig holds configuration for a managed service type
 type ManagedServiceConfig struct {
 	Port            int
 	RequiresStorage bool
 	DefaultVersion  string
-	ServiceType     string // "StatefulSet" or "Deployment"
-	ExposureType    string // "TCPProxy" or "Ingress"
+	Versions        []string // image tags that can be selected; DefaultVersion is one of them
+	ServiceType     string   // "StatefulSet" or "Deployment"
+	ExposureType    string   // "TCPProxy" or "Ingress"
+	Topologies      []string // selectable at creation besides standalone, e.g. "sentinel"
 }
 
 // ServiceExposureConfig defines how a service should be exposed
@@ -39,6 +48,7 @@ func GetManagedServiceConfigs() map[string]ManagedServiceConfig {
 			Port:            5432,
 			RequiresStorage: true,
 			DefaultVersion:  "15",
+			Versions:        []string{"13", "14", "15", "16", "17"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
 		},
@@ -46,6 +56,7 @@ func GetManagedServiceConfigs() map[string]ManagedServiceConfig {
 			Port:            3306,
 			RequiresStorage: true,
 			DefaultVersion:  "8.0",
+			Versions:        []string{"8.0", "8.4"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
 		},
@@ -53,13 +64,16 @@ func GetManagedServiceConfigs() map[string]ManagedServiceConfig {
 			Port:            6379,
 			RequiresStorage: true,
 			DefaultVersion:  "7",
+			Versions:        []string{"6.2", "7", "7.2", "7.4"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
+			Topologies:      []string{TopologySentinel},
 		},
 		"mongodb": {
 			Port:            27017,
 			RequiresStorage: true,
 			DefaultVersion:  "7.0",
+			Versions:        []string{"5.0", "6.0", "7.0"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
 		},
@@ -67,6 +81,7 @@ func GetManagedServiceConfigs() map[string]ManagedServiceConfig {
 			Port:            9000,
 			RequiresStorage: true,
 			DefaultVersion:  "latest",
+			Versions:        []string{"latest"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
 		},
@@ -74,6 +89,7 @@ func GetManagedServiceConfigs() map[string]ManagedServiceConfig {
 			Port:            5672,
 			RequiresStorage: true,
 			DefaultVersion:  "3.12",
+			Versions:        []string{"3.11", "3.12", "3.13"},
 			ServiceType:     "StatefulSet",
 			ExposureType:    "TCPProxy",
 		},
@@ -204,6 +220,20 @@ func GetManagedServiceDefaultVersion(managedType string) string {
 	return "latest"
 }
 
+// IsValidManagedServiceVersion reports whether the version is offered for the managed service
+// type; empty selects the default version
+func IsValidManagedServiceVersion(managedType, version string) bool {
+	if version == "" {
+		return true
+	}
+	for _, supported := range GetManagedServiceConfigs()[managedType].Versions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
 // RequiresPersistentStorage checks if managed service needs storage
 func RequiresPersistentStorage(managedType string) bool {
 	configs := GetManagedServiceConfigs()
@@ -222,6 +252,20 @@ func GetManagedServiceType(managedType string) string {
 	return "Deployment"
 }
 
+// IsValidTopology reports whether the managed service type can be created with the topology;
+// empty is the standalone topology every type supports
+func IsValidTopology(managedType, topology string) bool {
+	if topology == "" {
+		return true
+	}
+	for _, supported := range GetManagedServiceConfigs()[managedType].Topologies {
+		if supported == topology {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateManagedServiceEnvVars creates comprehensive environment variables for managed services.
 func GenerateManagedServiceEnvVars(service models.Service, externalHost string, externalPort int) models.EnvVars {
 	envVars := make(models.EnvVars)
@@ -281,7 +325,16 @@ func GenerateManagedServiceEnvVars(service models.Service, externalHost string,
 
 		// Connection strings - use internal DNS and the shared TCP proxy for external access.
 		envVars["DATABASE_URL"] = fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", dbUser, dbPassword, internalHost, service.Port, dbName)
-		envVars["DATABASE_EXTERNAL_URL"] = fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=disable", dbUser, dbPassword, externalHost, externalPort, dbName)
+		sslMode := "disable"
+		if IsDatabaseTLSEnabled(service) {
+			sslMode = "require"
+		}
+		envVars["DATABASE_EXTERNAL_URL"] = fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?sslmode=%s", dbUser, dbPassword, externalHost, externalPort, dbName, sslMode)
+
+		// Pooled connection string through PgBouncer (in-cluster only)
+		if IsPoolingEnabled(service) {
+			envVars["DATABASE_POOLED_URL"] = fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", dbUser, dbPassword, GetPgBouncerHost(service), PgBouncerPort, dbName)
+		}
 
 	case "mysql":
 		dbName := GenerateSecureID("db")
@@ -296,6 +349,9 @@ func GenerateManagedServiceEnvVars(service models.Service, externalHost string,
 		// Connection strings - use internal DNS and the shared TCP proxy for external access.
 		envVars["DATABASE_URL"] = fmt.Sprintf("mysql://%s:%s@%s:%d/%s", dbUser, dbPassword, internalHost, service.Port, dbName)
 		envVars["DATABASE_EXTERNAL_URL"] = fmt.Sprintf("mysql://%s:%s@%s:%d/%s", dbUser, dbPassword, externalHost, externalPort, dbName)
+		if IsDatabaseTLSEnabled(service) {
+			envVars["DATABASE_EXTERNAL_URL"] += "?ssl-mode=REQUIRED"
+		}
 
 	case "redis":
 		redisPassword := GenerateSecurePassword(16)
@@ -304,7 +360,20 @@ func GenerateManagedServiceEnvVars(service models.Service, externalHost string,
 
 		// Connection strings - use internal DNS and the shared TCP proxy for external access.
 		envVars["REDIS_URL"] = fmt.Sprintf("redis://:%s@%s:%d", redisPassword, internalHost, service.Port)
-		envVars["REDIS_EXTERNAL_URL"] = fmt.Sprintf("redis://:%s@%s:%d", redisPassword, externalHost, externalPort)
+		externalScheme := "redis"
+		if IsDatabaseTLSEnabled(service) {
+			externalScheme = "rediss"
+		}
+		envVars["REDIS_EXTERNAL_URL"] = fmt.Sprintf("%s://:%s@%s:%d", externalScheme, redisPassword, externalHost, externalPort)
+
+		// Sentinel-aware clients discover the current primary themselves and follow failovers
+		if IsRedisSentinel(service) {
+			sentinelHost := GetRedisSentinelHost(service)
+			envVars["REDIS_SENTINEL_HOST"] = sentinelHost
+			envVars["REDIS_SENTINEL_PORT"] = fmt.Sprintf("%d", RedisSentinelPort)
+			envVars["REDIS_SENTINEL_MASTER"] = RedisSentinelMasterName
+			envVars["REDIS_SENTINEL_URL"] = fmt.Sprintf("redis+sentinel://:%s@%s:%d/%s", redisPassword, sentinelHost, RedisSentinelPort, RedisSentinelMasterName)
+		}
 
 	case "mongodb":
 		dbName := GenerateSecureID("db")
@@ -383,10 +452,10 @@ func GetManagedServiceExternalDomain(service models.Service, endpointName ...str
 
 	if endpoint == "primary" {
 		// Primary endpoint gets simple domain
-		return fmt.Sprintf("%s-%s.managed.%s", serviceName, shortEnvID, GetDefaultDomain())
+		return fmt.Sprintf("%s-%s.managed.%s", serviceName, shortEnvID, GetServiceBaseDomain(service))
 	} else {
 		// Secondary endpoints get prefixed domain
-		return fmt.Sprintf("%s-%s-%s.managed.%s", serviceName, endpoint, shortEnvID, GetDefaultDomain())
+		return fmt.Sprintf("%s-%s-%s.managed.%s", serviceName, endpoint, shortEnvID, GetServiceBaseDomain(service))
 	}
 }
 
@@ -424,6 +493,9 @@ func GetManagedServiceConnectionInfo(service models.Service, externalHost string
 
 		if config.ExposureType == "TCPProxy" {
 			endpoint["protocol"] = "TCP"
+			if config.Name == "primary" && IsDatabaseTLSEnabled(service) {
+				endpoint["protocol"] = "TLS"
+			}
 			endpoint["external_host"] = externalHost
 			if config.Name == "primary" {
 				endpoint["external_port"] = fmt.Sprintf("%d", externalPort)
@@ -504,6 +576,9 @@ func extractCredentialsFromEnvVars(envVars models.EnvVars, managedType string) m
 		if externalUrl, exists := envVars["REDIS_EXTERNAL_URL"]; exists {
 			credentials["external_connection_string"] = externalUrl
 		}
+		if sentinelUrl, exists := envVars["REDIS_SENTINEL_URL"]; exists {
+			credentials["sentinel_connection_string"] = sentinelUrl
+		}
 
 	case "mongodb":
 		if user, exists := envVars["MONGO_INITDB_ROOT_USERNAME"]; exists {
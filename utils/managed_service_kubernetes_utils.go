@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	"strings"
-	"time"
 
 	"github.com/pendeploy-simple/lib/kubernetes"
 	"github.com/pendeploy-simple/models"
@@ -41,25 +40,78 @@ func DeployManagedServiceToKubernetes(service models.Service) (*models.Service,
 	service.Port = GetManagedServicePort(service.ManagedType)
 	service.EnvVars = GenerateManagedServiceEnvVars(service, service.ExternalHost, service.ExternalPort)
 
+	// Every resource is owned by the service's parent ConfigMap so deletion cascades
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+
+	// Pods reference the ServiceAccount and pull Secrets, so they must exist first
+	if err := ensureServiceAccount(ctx, k8sClient, service, owner); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+	if err := ensurePullSecrets(ctx, k8sClient, service); err != nil {
+		service.Status = "failed"
+		return &service, err
+	}
+
 	var deploymentErrors []string
 
+	// The server mounts its certificate, so it is requested before the workload
+	if IsDatabaseTLSEnabled(service) && !terminatesTLSAtProxy(service) {
+		if err := deployDatabaseCertificate(ctx, k8sClient, service, owner); err != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("certificate: %v", err))
+		}
+	} else if err := deleteDatabaseCertificate(ctx, k8sClient, service); err != nil {
+		log.Printf("Warning: Failed to remove database certificate for %s: %v", service.Name, err)
+	}
+
 	// Deploy workload (StatefulSet/Deployment)
 	serviceType := GetManagedServiceType(service.ManagedType)
 	if serviceType == "StatefulSet" {
-		if err := deployStatefulSet(ctx, k8sClient, service); err != nil {
+		if err := deployStatefulSet(ctx, k8sClient, service, owner); err != nil {
 			deploymentErrors = append(deploymentErrors, fmt.Sprintf("statefulset: %v", err))
 		}
 	} else {
-		if err := deployManagedDeployment(ctx, k8sClient, service); err != nil {
+		if err := deployManagedDeployment(ctx, k8sClient, service, owner); err != nil {
 			deploymentErrors = append(deploymentErrors, fmt.Sprintf("deployment: %v", err))
 		}
 		if RequiresPersistentStorage(service.ManagedType) {
-			if err := createManagedServicePVC(ctx, k8sClient, service); err != nil {
+			if err := createManagedServicePVC(ctx, k8sClient, service, owner); err != nil {
 				deploymentErrors = append(deploymentErrors, fmt.Sprintf("pvc: %v", err))
 			}
 		}
 	}
 
+	// Sentinel and the router find the Redis pods through the headless Service
+	if IsRedisSentinel(service) {
+		if err := deployRedisSentinel(ctx, k8sClient, service, owner); err != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("sentinel: %v", err))
+		}
+	}
+
+	// PgBouncer lives and dies with the parent database
+	if IsPoolingEnabled(service) {
+		if err := deployPgBouncer(ctx, k8sClient, service, owner); err != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("pgbouncer: %v", err))
+		}
+	} else if service.ManagedType == "postgresql" {
+		if err := deletePgBouncer(ctx, k8sClient, service); err != nil {
+			log.Printf("Warning: Failed to remove PgBouncer for %s: %v", service.Name, err)
+		}
+	}
+
+	// The VerticalPodAutoscaler targets the workload deployed above
+	if service.VPAMode != "" {
+		if err := deployVPA(ctx, k8sClient, service, owner); err != nil {
+			deploymentErrors = append(deploymentErrors, fmt.Sprintf("vpa: %v", err))
+		}
+	} else if err := deleteVPA(ctx, k8sClient, service); err != nil {
+		log.Printf("Warning: Failed to remove VerticalPodAutoscaler for %s: %v", service.Name, err)
+	}
+
 	// Check if services already exist - if yes, skip service/ingress deployment.
 	resourceName := GetResourceName(service)
 	_, serviceErr := k8sClient.Clientset.CoreV1().Services(service.EnvironmentID).Get(ctx, resourceName, metav1.GetOptions{})
@@ -68,31 +120,39 @@ func DeployManagedServiceToKubernetes(service models.Service) (*models.Service,
 		log.Printf("Deploying new services and ingresses for %s", service.Name)
 
 		// Deploy all internal services. TCP exposure is handled by the shared HAProxy gateway.
-		if err := deployAllManagedServices(ctx, k8sClient, service); err != nil {
+		if err := deployAllManagedServices(ctx, k8sClient, service, owner); err != nil {
 			deploymentErrors = append(deploymentErrors, fmt.Sprintf("services: %v", err))
 		}
 
 		// Deploy ingresses only for HTTP services
-		if err := deployManagedIngresses(ctx, k8sClient, service); err != nil {
-			deploymentErrors = append(deploymentErrors, fmt.Sprintf("ingresses: %v", err))
+		if !service.DeferIngress {
+			if err := deployManagedIngresses(ctx, k8sClient, service, owner); err != nil {
+				deploymentErrors = append(deploymentErrors, fmt.Sprintf("ingresses: %v", err))
+			}
 		}
 	} else {
 		log.Printf("Skipping service/ingress deployment - resources already exist for %s", service.Name)
 	}
 
+	// The alias is reconciled on every deploy so renamed aliases are picked up
+	if err := deployInternalAlias(ctx, k8sClient, service, owner); err != nil {
+		log.Printf("Warning: Failed to apply internal alias for %s: %v", service.Name, err)
+	}
+
 	if len(deploymentErrors) > 0 {
 		service.Status = "failed"
 		return &service, fmt.Errorf("deployment failed: %s", strings.Join(deploymentErrors, "; "))
 	}
 
-	service.Status = "running"
+	// The pod still has to pass its readiness probe before it can take connections
+	service.Status = "starting"
 
 	log.Printf("Successfully deployed managed service: %s (%s) with TCP proxy %s:%d", service.Name, service.ManagedType, service.ExternalHost, service.ExternalPort)
 	return &service, nil
 }
 
 // deployAllManagedServices creates all required services with appropriate exposure
-func deployAllManagedServices(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployAllManagedServices(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	serviceConfigs := GetManagedServiceExposureConfig(service.ManagedType)
 
 	for _, config := range serviceConfigs {
@@ -101,6 +161,7 @@ func deployAllManagedServices(ctx context.Context, client *kubernetes.Client, se
 		// All managed service ports stay private as ClusterIP. External TCP access is
 		// routed through the shared tcp-proxy service.
 		k8sService = createClusterIPServiceSpec(service, config)
+		setServiceOwner(k8sService, owner)
 
 		if err := applyManagedService(ctx, client, k8sService); err != nil {
 			return fmt.Errorf("service %s: %v", config.Name, err)
@@ -110,14 +171,15 @@ func deployAllManagedServices(ctx context.Context, client *kubernetes.Client, se
 }
 
 // deployManagedIngresses creates ingresses only for HTTP services
-func deployManagedIngresses(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployManagedIngresses(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	serviceConfigs := GetManagedServiceExposureConfig(service.ManagedType)
 
 	for _, config := range serviceConfigs {
 		if config.IsHTTP && config.ExposureType == "Ingress" {
 			// Create HTTP Ingress for web services (MinIO console, RabbitMQ management)
 			ingress := createManagedIngressSpec(service, config)
-			if err := applyManagedIngress(ctx, client, ingress); err != nil {
+			setServiceOwner(ingress, owner)
+			if err := applyIngress(ctx, client, ingress); err != nil {
 				return fmt.Errorf("http ingress %s: %v", config.Name, err)
 			}
 			log.Printf("Created HTTP Ingress for %s (%s)", service.Name, config.Name)
@@ -126,17 +188,47 @@ func deployManagedIngresses(ctx context.Context, client *kubernetes.Client, serv
 	return nil
 }
 
+// ApplyManagedServiceIngresses re-applies the HTTP ingresses of a deployed managed service,
+// e.g. after the ingress provider changed. Services that were never deployed are left alone.
+func ApplyManagedServiceIngresses(service models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = k8sClient.Clientset.CoreV1().ConfigMaps(service.EnvironmentID).Get(ctx, GetServiceOwnerName(service), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return err
+	}
+	return deployManagedIngresses(ctx, k8sClient, service, owner)
+}
+
 // createClusterIPServiceSpec creates ClusterIP Service for internal/HTTP services
 func createClusterIPServiceSpec(service models.Service, config ServiceExposureConfig) *corev1.Service {
 	resourceName := GetResourceName(service)
 	labels := GetResourceLabels(service)
 	serviceName := resourceName
+	selector := resourceName
 
 	// Add suffix for secondary services
 	if config.Name != "primary" {
 		serviceName = fmt.Sprintf("%s-%s", resourceName, config.Name)
 	}
 
+	// With Sentinel the primary address goes through the router, which follows failovers
+	if IsRedisSentinel(service) && config.Name == "primary" {
+		selector = getRedisRouterName(service)
+	}
+
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serviceName,
@@ -145,7 +237,7 @@ func createClusterIPServiceSpec(service models.Service, config ServiceExposureCo
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     corev1.ServiceTypeClusterIP,
-			Selector: map[string]string{"app": resourceName},
+			Selector: map[string]string{"app": selector},
 			Ports: []corev1.ServicePort{
 				{
 					Port:       int32(config.Port),
@@ -163,7 +255,9 @@ func createStatefulSetSpec(service models.Service) *appsv1.StatefulSet {
 	resourceName := GetResourceName(service)
 	labels := GetResourceLabels(service)
 	replicas := int32(1)
-	containerImage := getManagedServiceImage(service.ManagedType, service.Version)
+	containerImage := MirrorImage(getManagedServiceImage(service.ManagedType, service.Version))
+
+	readinessProbe, livenessProbe := getManagedServiceProbes(service.ManagedType)
 
 	// Get all ports for this service type
 	exposureConfigs := GetManagedServiceExposureConfig(service.ManagedType)
@@ -191,6 +285,9 @@ func createStatefulSetSpec(service models.Service) *appsv1.StatefulSet {
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: labels},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: GetServiceAccountName(service),
+					ImagePullSecrets:   imagePullSecretRefs(service),
+					PriorityClassName:  service.PriorityClassName,
 					Containers: []corev1.Container{
 						{
 							Name:    "managed-service",
@@ -208,7 +305,9 @@ func createStatefulSetSpec(service models.Service) *appsv1.StatefulSet {
 									corev1.ResourceMemory: resource.MustParse("128Mi"),
 								},
 							},
-							Env: createEnvVarsFromMap(service.EnvVars),
+							Env:            createEnvVarsFromMap(service.EnvVars),
+							ReadinessProbe: readinessProbe,
+							LivenessProbe:  livenessProbe,
 						},
 					},
 				},
@@ -225,6 +324,11 @@ func createStatefulSetSpec(service models.Service) *appsv1.StatefulSet {
 			},
 		}
 
+		// Let the StatefulSet own its claims so they are garbage-collected with it
+		statefulSet.Spec.PersistentVolumeClaimRetentionPolicy = &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+			WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			WhenScaled:  appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+		}
 		statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
 			{
 				ObjectMeta: metav1.ObjectMeta{
@@ -243,6 +347,9 @@ func createStatefulSetSpec(service models.Service) *appsv1.StatefulSet {
 		}
 	}
 
+	applyPodMetadata(&statefulSet.Spec.Template, service)
+	applyRedisSentinel(statefulSet, service)
+	applyDatabaseTLS(&statefulSet.Spec.Template.Spec, service)
 	SecurePodSpec(&statefulSet.Spec.Template.Spec)
 	return statefulSet
 }
@@ -257,7 +364,9 @@ func createManagedDeploymentSpec(service models.Service) *appsv1.Deployment {
 		replicas = int32(service.MinReplicas)
 	}
 
-	containerImage := getManagedServiceImage(service.ManagedType, service.Version)
+	containerImage := MirrorImage(getManagedServiceImage(service.ManagedType, service.Version))
+
+	readinessProbe, livenessProbe := getManagedServiceProbes(service.ManagedType)
 
 	// Get all ports for this service type
 	exposureConfigs := GetManagedServiceExposureConfig(service.ManagedType)
@@ -285,6 +394,9 @@ func createManagedDeploymentSpec(service models.Service) *appsv1.Deployment {
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{Labels: labels},
 				Spec: corev1.PodSpec{
+					ServiceAccountName: GetServiceAccountName(service),
+					ImagePullSecrets:   imagePullSecretRefs(service),
+					PriorityClassName:  service.PriorityClassName,
 					Containers: []corev1.Container{
 						{
 							Name:    "managed-service",
@@ -302,7 +414,9 @@ func createManagedDeploymentSpec(service models.Service) *appsv1.Deployment {
 									corev1.ResourceMemory: resource.MustParse("128Mi"),
 								},
 							},
-							Env: createEnvVarsFromMap(service.EnvVars),
+							Env:            createEnvVarsFromMap(service.EnvVars),
+							ReadinessProbe: readinessProbe,
+							LivenessProbe:  livenessProbe,
 						},
 					},
 				},
@@ -331,6 +445,8 @@ func createManagedDeploymentSpec(service models.Service) *appsv1.Deployment {
 		}
 	}
 
+	applyPodMetadata(&deployment.Spec.Template, service)
+	applyDatabaseTLS(&deployment.Spec.Template.Spec, service)
 	SecurePodSpec(&deployment.Spec.Template.Spec)
 	return deployment
 }
@@ -353,11 +469,7 @@ func createManagedIngressSpec(service models.Service, config ServiceExposureConf
 	tlsSecretName := fmt.Sprintf("%s-tls", ingressName)
 
 	// HTTP Ingress annotations
-	annotations := map[string]string{
-		"traefik.ingress.kubernetes.io/router.entrypoints": "websecure",
-		"traefik.ingress.kubernetes.io/router.tls":         "true",
-		"cert-manager.io/cluster-issuer":                   "letsencrypt-prod",
-	}
+	annotations := ingressAnnotations(GetClusterIssuer())
 
 	return &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
@@ -367,6 +479,7 @@ func createManagedIngressSpec(service models.Service, config ServiceExposureConf
 			Annotations: annotations,
 		},
 		Spec: networkingv1.IngressSpec{
+			IngressClassName: GetIngressProvider().IngressClassName(),
 			Rules: []networkingv1.IngressRule{
 				{
 					Host: hostname,
@@ -401,19 +514,23 @@ func createManagedIngressSpec(service models.Service, config ServiceExposureConf
 }
 
 // createManagedServicePVC creates PVC for Deployment-based services
-func createManagedServicePVC(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func createManagedServicePVC(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	if !RequiresPersistentStorage(service.ManagedType) {
 		return nil
 	}
 
-	resourceName := GetResourceName(service)
-	labels := GetResourceLabels(service)
+	pvc := createManagedServicePVCSpec(service)
+	setServiceOwner(pvc, owner)
+	return applyPVC(ctx, client, pvc)
+}
 
-	pvc := &corev1.PersistentVolumeClaim{
+// createManagedServicePVCSpec builds the data volume of a Deployment-based managed service
+func createManagedServicePVCSpec(service models.Service) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-data", resourceName),
+			Name:      fmt.Sprintf("%s-data", GetResourceName(service)),
 			Namespace: service.EnvironmentID,
-			Labels:    labels,
+			Labels:    GetResourceLabels(service),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
@@ -424,18 +541,18 @@ func createManagedServicePVC(ctx context.Context, client *kubernetes.Client, ser
 			},
 		},
 	}
-
-	return applyPVC(ctx, client, pvc)
 }
 
 // Helper functions for StatefulSet and Deployment deployment
-func deployStatefulSet(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployStatefulSet(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	statefulSet := createStatefulSetSpec(service)
+	setServiceOwner(statefulSet, owner)
 	return applyStatefulSet(ctx, client, statefulSet)
 }
 
-func deployManagedDeployment(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+func deployManagedDeployment(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
 	deployment := createManagedDeploymentSpec(service)
+	setServiceOwner(deployment, owner)
 	return applyManagedDeployment(ctx, client, deployment)
 }
 
@@ -443,12 +560,12 @@ func deployManagedDeployment(ctx context.Context, client *kubernetes.Client, ser
 func applyStatefulSet(ctx context.Context, client *kubernetes.Client, statefulSet *appsv1.StatefulSet) error {
 	_, err := client.Clientset.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
 	if errors.IsAlreadyExists(err) {
-		// For StatefulSet, resource changes require scale-down-update-scale-up
-		if err := updateStatefulSetWithScaling(ctx, client, statefulSet); err != nil {
-			return err
+		// Several data pods (a Sentinel primary and replica) keep serving through a rolling
+		// update; a single pod is replaced by scale-down-update-scale-up
+		if statefulSetReplicas(statefulSet) > 1 {
+			return updateStatefulSetRolling(ctx, client, statefulSet)
 		}
-		// Update successful, return nil
-		return nil
+		return updateStatefulSetWithScaling(ctx, client, statefulSet)
 	}
 	return err
 }
@@ -469,14 +586,6 @@ func applyManagedService(ctx context.Context, client *kubernetes.Client, service
 	return err
 }
 
-func applyManagedIngress(ctx context.Context, client *kubernetes.Client, ingress *networkingv1.Ingress) error {
-	_, err := client.Clientset.NetworkingV1().Ingresses(ingress.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
-	if errors.IsAlreadyExists(err) {
-		_, err = client.Clientset.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
-	}
-	return err
-}
-
 func applyPVC(ctx context.Context, client *kubernetes.Client, pvc *corev1.PersistentVolumeClaim) error {
 	_, err := client.Clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
 	if errors.IsAlreadyExists(err) {
@@ -501,49 +610,152 @@ func applyPVC(ctx context.Context, client *kubernetes.Client, pvc *corev1.Persis
 	return err
 }
 
-// updateStatefulSetWithScaling updates StatefulSet by scaling down, updating spec, then scaling up
+// updateStatefulSetWithScaling updates StatefulSet by scaling down, updating spec, then scaling up.
+// It waits for the old pod to terminate and the new pod to pass readiness, and restores the
+// previous template if the new pod never becomes ready.
 func updateStatefulSetWithScaling(ctx context.Context, client *kubernetes.Client, newStatefulSet *appsv1.StatefulSet) error {
 	log.Printf("Updating StatefulSet %s via scale-down-update-scale-up", newStatefulSet.Name)
+	statefulSets := client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace)
 
 	// Step 1: Scale down to 0 (get fresh object first)
-	existingStatefulSet, err := client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace).Get(ctx, newStatefulSet.Name, metav1.GetOptions{})
+	existingStatefulSet, err := statefulSets.Get(ctx, newStatefulSet.Name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get existing StatefulSet: %v", err)
 	}
 
+	// Keep the running template and replica count around so we can roll back
+	previousTemplate := *existingStatefulSet.Spec.Template.DeepCopy()
+	previousReplicas := statefulSetReplicas(existingStatefulSet)
+
 	zeroReplicas := int32(0)
 	existingStatefulSet.Spec.Replicas = &zeroReplicas
-	_, err = client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace).Update(ctx, existingStatefulSet, metav1.UpdateOptions{})
+	existingStatefulSet, err = statefulSets.Update(ctx, existingStatefulSet, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to scale down StatefulSet: %v", err)
 	}
-	log.Printf("Scaled down StatefulSet %s to 0 replicas", newStatefulSet.Name)
+	emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeNormal, "ScalingDown", "Scaled down to 0 replicas to apply configuration changes")
 
 	// Step 2: Wait for pods to terminate
-	time.Sleep(5 * time.Second)
+	if err := waitForStatefulSetPodsTerminated(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, statefulSetTerminationTimeout); err != nil {
+		emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeWarning, "TerminationTimeout", err.Error())
+		if rollbackErr := rollbackStatefulSet(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, previousTemplate, previousReplicas); rollbackErr != nil {
+			return fmt.Errorf("%v; rollback failed: %v", err, rollbackErr)
+		}
+		return err
+	}
 
 	// Step 3: Get fresh object again and update template spec + scale up
-	existingStatefulSet, err = client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace).Get(ctx, newStatefulSet.Name, metav1.GetOptions{})
+	existingStatefulSet, err = statefulSets.Get(ctx, newStatefulSet.Name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get StatefulSet for template update: %v", err)
 	}
 
 	// Update template spec with new resource limits
 	existingStatefulSet.Spec.Template = newStatefulSet.Spec.Template
-	existingStatefulSet.Spec.VolumeClaimTemplates = newStatefulSet.Spec.VolumeClaimTemplates
-
-	// Scale back up to 1
-	oneReplica := int32(1)
-	existingStatefulSet.Spec.Replicas = &oneReplica
-	_, err = client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace).Update(ctx, existingStatefulSet, metav1.UpdateOptions{})
+	// Claim templates are immutable; existing claims are grown through the storage expansion API
+	existingStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy = newStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy
+	existingStatefulSet.Labels = newStatefulSet.Labels
+	existingStatefulSet.OwnerReferences = newStatefulSet.OwnerReferences
+
+	// Scale back up to the replicas of the new spec
+	replicas := statefulSetReplicas(newStatefulSet)
+	existingStatefulSet.Spec.Replicas = &replicas
+	existingStatefulSet, err = statefulSets.Update(ctx, existingStatefulSet, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to scale up StatefulSet: %v", err)
 	}
+	emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeNormal, "ScalingUp", fmt.Sprintf("Applied new template and scaled up to %d replica(s)", replicas))
+
+	// Step 4: Wait for the new pod to pass readiness, roll back otherwise
+	if err := waitForStatefulSetReady(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, statefulSetReadyTimeout); err != nil {
+		emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeWarning, "RollingBack", fmt.Sprintf("New pod did not become ready: %v", err))
+		if rollbackErr := rollbackStatefulSet(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, previousTemplate, previousReplicas); rollbackErr != nil {
+			return fmt.Errorf("new pod did not become ready: %v; rollback failed: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("new pod did not become ready, rolled back to previous configuration: %v", err)
+	}
 
+	emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeNormal, "Updated", "New pod is ready")
 	log.Printf("Successfully updated StatefulSet %s via scaling", newStatefulSet.Name)
 	return nil
 }
 
+// updateStatefulSetRolling applies a new template through the StatefulSet's rolling update,
+// which replaces one pod at a time while the others keep serving. It restores the previous
+// template if the new pods never become ready.
+func updateStatefulSetRolling(ctx context.Context, client *kubernetes.Client, newStatefulSet *appsv1.StatefulSet) error {
+	log.Printf("Updating StatefulSet %s via rolling update", newStatefulSet.Name)
+	statefulSets := client.Clientset.AppsV1().StatefulSets(newStatefulSet.Namespace)
+
+	existingStatefulSet, err := statefulSets.Get(ctx, newStatefulSet.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get existing StatefulSet: %v", err)
+	}
+
+	previousTemplate := *existingStatefulSet.Spec.Template.DeepCopy()
+	previousReplicas := statefulSetReplicas(existingStatefulSet)
+
+	existingStatefulSet.Spec.Template = newStatefulSet.Spec.Template
+	existingStatefulSet.Spec.Replicas = newStatefulSet.Spec.Replicas
+	existingStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy = newStatefulSet.Spec.PersistentVolumeClaimRetentionPolicy
+	existingStatefulSet.Labels = newStatefulSet.Labels
+	existingStatefulSet.OwnerReferences = newStatefulSet.OwnerReferences
+	existingStatefulSet, err = statefulSets.Update(ctx, existingStatefulSet, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update StatefulSet: %v", err)
+	}
+	emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeNormal, "RollingUpdate", "Replacing pods one at a time to apply configuration changes")
+
+	if err := waitForStatefulSetReady(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, statefulSetReadyTimeout); err != nil {
+		emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeWarning, "RollingBack", fmt.Sprintf("New pods did not become ready: %v", err))
+		if rollbackErr := rollbackStatefulSet(ctx, client, newStatefulSet.Namespace, newStatefulSet.Name, previousTemplate, previousReplicas); rollbackErr != nil {
+			return fmt.Errorf("new pods did not become ready: %v; rollback failed: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("new pods did not become ready, rolled back to previous configuration: %v", err)
+	}
+
+	emitStatefulSetEvent(ctx, client, existingStatefulSet, corev1.EventTypeNormal, "Updated", "New pods are ready")
+	log.Printf("Successfully updated StatefulSet %s via rolling update", newStatefulSet.Name)
+	return nil
+}
+
+// rollbackStatefulSet restores a previous pod template and replica count
+func rollbackStatefulSet(ctx context.Context, client *kubernetes.Client, namespace, name string, previousTemplate corev1.PodTemplateSpec, previousReplicas int32) error {
+	statefulSets := client.Clientset.AppsV1().StatefulSets(namespace)
+
+	statefulSet, err := statefulSets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get StatefulSet for rollback: %v", err)
+	}
+
+	statefulSet.Spec.Template = previousTemplate
+	statefulSet.Spec.Replicas = &previousReplicas
+	statefulSet, err = statefulSets.Update(ctx, statefulSet, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to restore previous StatefulSet template: %v", err)
+	}
+	emitStatefulSetEvent(ctx, client, statefulSet, corev1.EventTypeNormal, "RolledBack", fmt.Sprintf("Restored previous template and scaled to %d replica(s)", previousReplicas))
+
+	// A StatefulSet won't replace a pod that never became ready on its own
+	// (forced rollback), so delete it and let the restored template take over
+	err = client.Clientset.CoreV1().Pods(namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete pods created from the new template: %v", err)
+	}
+
+	return nil
+}
+
+// statefulSetReplicas returns the replica count of a StatefulSet, which defaults to 1
+func statefulSetReplicas(statefulSet *appsv1.StatefulSet) int32 {
+	if statefulSet.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulSet.Spec.Replicas
+}
+
 // Service helper functions
 func getManagedServiceImage(managedType, version string) string {
 	images := map[string]string{
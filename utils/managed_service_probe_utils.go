@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ManagedServiceReadyTimeout bounds how long a freshly deployed managed service may take to boot
+const ManagedServiceReadyTimeout = 5 * time.Minute
+
+// getManagedServiceProbeHandler returns the type-specific health check.
+// Exec probes run through sh so the container's credentials env vars are expanded.
+func getManagedServiceProbeHandler(managedType string, liveness bool) *corev1.ProbeHandler {
+	shellProbe := func(script string) *corev1.ProbeHandler {
+		return &corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{"sh", "-c", script}},
+		}
+	}
+
+	switch managedType {
+	case "postgresql":
+		return shellProbe(`pg_isready -h 127.0.0.1 -U "$POSTGRES_USER" -d "$POSTGRES_DB"`)
+	case "mysql":
+		return shellProbe(`mysqladmin ping -h 127.0.0.1 -uroot -p"$MYSQL_ROOT_PASSWORD" --silent`)
+	case "redis":
+		return shellProbe(`redis-cli -h 127.0.0.1 ping | grep -q PONG`)
+	case "mongodb":
+		// mongosh ships with 6.0+, older images only have the legacy shell
+		return shellProbe(`mongosh --quiet --eval "db.adminCommand('ping').ok" || mongo --quiet --eval "db.adminCommand('ping').ok"`)
+	case "minio":
+		path := "/minio/health/ready"
+		if liveness {
+			path = "/minio/health/live"
+		}
+		return &corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt(9000)},
+		}
+	case "rabbitmq":
+		if liveness {
+			return shellProbe(`rabbitmq-diagnostics -q ping`)
+		}
+		return shellProbe(`rabbitmq-diagnostics -q check_port_connectivity`)
+	default:
+		return &corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(GetManagedServicePort(managedType))},
+		}
+	}
+}
+
+// getManagedServiceProbes returns readiness and liveness probes for a managed service type.
+// Liveness is deliberately lenient so slow crash recovery (WAL replay etc.) isn't killed.
+func getManagedServiceProbes(managedType string) (readiness *corev1.Probe, liveness *corev1.Probe) {
+	livenessDelay := int32(30)
+	if managedType == "rabbitmq" {
+		livenessDelay = 60 // Erlang VM boot is slow
+	}
+
+	readiness = &corev1.Probe{
+		ProbeHandler:        *getManagedServiceProbeHandler(managedType, false),
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      5,
+		FailureThreshold:    6,
+	}
+	liveness = &corev1.Probe{
+		ProbeHandler:        *getManagedServiceProbeHandler(managedType, true),
+		InitialDelaySeconds: livenessDelay,
+		PeriodSeconds:       20,
+		TimeoutSeconds:      5,
+		FailureThreshold:    6,
+	}
+	return readiness, liveness
+}
+
+// GetManagedServiceHealth derives a managed service's health from its pods' readiness
+func GetManagedServiceHealth(service models.Service) (*models.ServiceHealth, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", GetResourceName(service)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	podPtrs := make([]*corev1.Pod, len(pods.Items))
+	for i := range pods.Items {
+		podPtrs[i] = &pods.Items[i]
+	}
+	return summarizePodHealth(podPtrs), nil
+}
+
+// summarizePodHealth derives a service's health from its pods
+func summarizePodHealth(pods []*corev1.Pod) *models.ServiceHealth {
+	health := &models.ServiceHealth{
+		Status:    models.ServiceHealthUnavailable,
+		Replicas:  len(pods),
+		CheckedAt: time.Now(),
+	}
+	if len(pods) == 0 {
+		health.Message = "No pods are running"
+		return health
+	}
+
+	var problem string
+	for _, pod := range pods {
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			health.Restarts += containerStatus.RestartCount
+		}
+		if isPodReady(pod) {
+			health.ReadyReplicas++
+			continue
+		}
+		if podErr := checkPodForErrors(pod); podErr != nil && problem == "" {
+			problem = podErr.Error()
+		}
+	}
+
+	switch {
+	case health.ReadyReplicas > 0:
+		health.Status = models.ServiceHealthHealthy
+	case problem != "":
+		health.Status = models.ServiceHealthUnhealthy
+		health.Message = problem
+	default:
+		health.Status = models.ServiceHealthStarting
+		health.Message = "Waiting for readiness probe to pass"
+	}
+
+	return health
+}
+
+// WaitForManagedServiceReady blocks until the managed service's pod passes its readiness probe
+func WaitForManagedServiceReady(service models.Service, timeout time.Duration) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	if GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+		return waitForStatefulSetReady(context.Background(), k8sClient, service.EnvironmentID, GetResourceName(service), timeout)
+	}
+
+	// Deployment-backed services (minio etc.): poll pod health until ready or broken
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		health, err := GetManagedServiceHealth(service)
+		if err != nil {
+			return err
+		}
+		switch health.Status {
+		case models.ServiceHealthHealthy:
+			return nil
+		case models.ServiceHealthUnhealthy:
+			return fmt.Errorf("%s", health.Message)
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return fmt.Errorf("timeout waiting for %s to become ready (waited %v)", service.Name, timeout)
+}
+
+// isPodReady reports whether the pod's Ready condition is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleManagedService sets the replica count of a managed service's workload.
+// Scaling to zero keeps the PVC so the data survives a pause.
+func ScaleManagedService(service models.Service, replicas int32) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx := context.Background()
+	resourceName := GetResourceName(service)
+	namespace := service.EnvironmentID
+
+	if GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+		scale, err := k8sClient.Clientset.AppsV1().StatefulSets(namespace).GetScale(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get StatefulSet scale: %v", err)
+		}
+		scale.Spec.Replicas = replicas
+		if _, err := k8sClient.Clientset.AppsV1().StatefulSets(namespace).UpdateScale(ctx, resourceName, scale, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to scale StatefulSet: %v", err)
+		}
+	} else {
+		scale, err := k8sClient.Clientset.AppsV1().Deployments(namespace).GetScale(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment scale: %v", err)
+		}
+		scale.Spec.Replicas = replicas
+		if _, err := k8sClient.Clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, resourceName, scale, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to scale Deployment: %v", err)
+		}
+	}
+
+	// The pooler is useless without its database
+	if IsPoolingEnabled(service) {
+		scale, err := k8sClient.Clientset.AppsV1().Deployments(namespace).GetScale(ctx, GetPgBouncerResourceName(service), metav1.GetOptions{})
+		if err == nil {
+			scale.Spec.Replicas = replicas
+			_, err = k8sClient.Clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, GetPgBouncerResourceName(service), scale, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			log.Printf("Warning: failed to scale PgBouncer for %s: %v", service.Name, err)
+		}
+	}
+
+	log.Printf("Scaled managed service %s to %d replica(s)", service.Name, replicas)
+	return nil
+}
+
+// GetManagedServiceDataReplicas returns the replica count of a running managed service's
+// workload: the primary and the replica of a Sentinel topology, one pod otherwise
+func GetManagedServiceDataReplicas(service models.Service) int32 {
+	if IsRedisSentinel(service) {
+		return RedisSentinelDataReplicas
+	}
+	return 1
+}
+
+// GetManagedServiceReplicas reads the replica count of a managed service's workload, e.g.
+// to restore it after the service was scaled to zero
+func GetManagedServiceReplicas(service models.Service) (int32, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx := context.Background()
+	resourceName := GetResourceName(service)
+	if GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+		scale, err := k8sClient.Clientset.AppsV1().StatefulSets(service.EnvironmentID).GetScale(ctx, resourceName, metav1.GetOptions{})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get StatefulSet scale: %v", err)
+		}
+		return scale.Spec.Replicas, nil
+	}
+	scale, err := k8sClient.Clientset.AppsV1().Deployments(service.EnvironmentID).GetScale(ctx, resourceName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get Deployment scale: %v", err)
+	}
+	return scale.Spec.Replicas, nil
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SelfSignedClusterIssuerName signs database certificates when no DNS-01 issuer is configured;
+// the TCP proxy host is usually not reachable for HTTP-01 challenges
+const SelfSignedClusterIssuerName = "pendeploy-selfsigned"
+
+const (
+	// databaseTLSSourcePath is where the certificate Secret is mounted
+	databaseTLSSourcePath = "/etc/pendeploy/tls"
+	// tcpProxyCertPath is where the TCP proxy reads the certificate it terminates TLS with
+	tcpProxyCertPath = "/usr/local/etc/haproxy-certs/proxy.pem"
+)
+
+var certificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// SupportsDatabaseTLS reports whether TLS can be enabled for the managed service type.
+// PostgreSQL and MySQL negotiate TLS in their own protocol and serve it natively; Redis
+// connections are terminated by the TCP proxy.
+func SupportsDatabaseTLS(managedType string) bool {
+	switch managedType {
+	case "postgresql", "mysql", "redis":
+		return true
+	}
+	return false
+}
+
+// IsDatabaseTLSEnabled reports whether external connections to the service use TLS
+func IsDatabaseTLSEnabled(service models.Service) bool {
+	return service.DatabaseTLS && SupportsDatabaseTLS(service.ManagedType)
+}
+
+// terminatesTLSAtProxy reports whether the TCP proxy, rather than the database, serves TLS
+func terminatesTLSAtProxy(service models.Service) bool {
+	return IsDatabaseTLSEnabled(service) && service.ManagedType == "redis"
+}
+
+// GetDatabaseTLSSecretName returns the Secret cert-manager stores the database certificate in
+func GetDatabaseTLSSecretName(service models.Service) string {
+	return fmt.Sprintf("%s-db-tls", GetResourceName(service))
+}
+
+// getCertificateIssuer returns the ClusterIssuer for certificates of the TCP proxy host
+func getCertificateIssuer() string {
+	if IsDNS01Enabled() {
+		return DNS01ClusterIssuerName
+	}
+	return SelfSignedClusterIssuerName
+}
+
+// buildCertificate renders a cert-manager Certificate for the TCP proxy host
+func buildCertificate(name, namespace, secretName string, labels map[string]string, dnsNames []string) *unstructured.Unstructured {
+	metadataLabels := map[string]interface{}{}
+	for key, value := range labels {
+		metadataLabels[key] = value
+	}
+	names := make([]interface{}, 0, len(dnsNames))
+	for _, dnsName := range dnsNames {
+		names = append(names, dnsName)
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    metadataLabels,
+		},
+		"spec": map[string]interface{}{
+			"secretName": secretName,
+			"dnsNames":   names,
+			"issuerRef": map[string]interface{}{
+				"name": getCertificateIssuer(),
+				"kind": "ClusterIssuer",
+			},
+		},
+	}}
+}
+
+// applyCertificate creates or updates a cert-manager Certificate, making sure its issuer exists
+func applyCertificate(ctx context.Context, client *kubernetes.Client, certificate *unstructured.Unstructured) error {
+	if getCertificateIssuer() == SelfSignedClusterIssuerName {
+		if err := ensureSelfSignedIssuer(ctx, client); err != nil {
+			return err
+		}
+	}
+
+	certificates := client.DynamicClient.Resource(certificateGVR).Namespace(certificate.GetNamespace())
+	existing, err := certificates.Get(ctx, certificate.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = certificates.Create(ctx, certificate, metav1.CreateOptions{})
+	case err == nil:
+		certificate.SetResourceVersion(existing.GetResourceVersion())
+		_, err = certificates.Update(ctx, certificate, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply Certificate %s (is cert-manager installed?): %v", certificate.GetName(), err)
+	}
+	return nil
+}
+
+// ensureSelfSignedIssuer creates the self-signed ClusterIssuer if it does not exist
+func ensureSelfSignedIssuer(ctx context.Context, client *kubernetes.Client) error {
+	issuers := client.DynamicClient.Resource(clusterIssuerGVR)
+	if _, err := issuers.Get(ctx, SelfSignedClusterIssuerName, metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get ClusterIssuer %s: %v", SelfSignedClusterIssuerName, err)
+	}
+
+	issuer := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "ClusterIssuer",
+		"metadata": map[string]interface{}{
+			"name":   SelfSignedClusterIssuerName,
+			"labels": map[string]interface{}{"app.kubernetes.io/managed-by": "pendeploy"},
+		},
+		"spec": map[string]interface{}{
+			"selfSigned": map[string]interface{}{},
+		},
+	}}
+	if _, err := issuers.Create(ctx, issuer, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ClusterIssuer %s: %v", SelfSignedClusterIssuerName, err)
+	}
+	log.Printf("ClusterIssuer %s created", SelfSignedClusterIssuerName)
+	return nil
+}
+
+// deployDatabaseCertificate issues the certificate a PostgreSQL or MySQL server presents to
+// clients connecting through the TCP proxy host
+func deployDatabaseCertificate(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	certificate := buildCertificate(
+		GetDatabaseTLSSecretName(service),
+		service.EnvironmentID,
+		GetDatabaseTLSSecretName(service),
+		GetResourceLabels(service),
+		[]string{GetTCPProxyConfig().Host},
+	)
+	setServiceOwner(certificate, owner)
+	return applyCertificate(ctx, client, certificate)
+}
+
+// deleteDatabaseCertificate removes the database certificate of a service, if any
+func deleteDatabaseCertificate(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	name := GetDatabaseTLSSecretName(service)
+	err := client.DynamicClient.Resource(certificateGVR).Namespace(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Certificate %s: %v", name, err)
+	}
+	return nil
+}
+
+// ensureTCPProxyCertificate issues the certificate the TCP proxy terminates TLS with
+func ensureTCPProxyCertificate(ctx context.Context, client *kubernetes.Client, cfg TCPProxyConfig) error {
+	name := getTCPProxyTLSSecretName(cfg)
+	certificate := buildCertificate(name, cfg.Namespace, name, map[string]string{"app": cfg.Name}, []string{cfg.Host})
+	return applyCertificate(ctx, client, certificate)
+}
+
+// getTCPProxyTLSSecretName returns the Secret holding the TCP proxy's certificate
+func getTCPProxyTLSSecretName(cfg TCPProxyConfig) string {
+	return fmt.Sprintf("%s-tls", cfg.Name)
+}
+
+// applyDatabaseTLS mounts the certificate into a PostgreSQL or MySQL pod and starts the
+// server with TLS. The key is copied with the ownership and mode the server insists on
+// before the image's entrypoint runs.
+func applyDatabaseTLS(spec *corev1.PodSpec, service models.Service) {
+	if !IsDatabaseTLSEnabled(service) || terminatesTLSAtProxy(service) {
+		return
+	}
+
+	var user, targetDir, entrypoint string
+	switch service.ManagedType {
+	case "postgresql":
+		user, targetDir = "postgres", "/var/run/postgresql/tls"
+		entrypoint = fmt.Sprintf("docker-entrypoint.sh postgres -c ssl=on -c ssl_cert_file=%[1]s/tls.crt -c ssl_key_file=%[1]s/tls.key", targetDir)
+	case "mysql":
+		user, targetDir = "mysql", "/var/run/mysqld/tls"
+		entrypoint = fmt.Sprintf("docker-entrypoint.sh mysqld --ssl-cert=%[1]s/tls.crt --ssl-key=%[1]s/tls.key", targetDir)
+	default:
+		return
+	}
+
+	script := strings.Join([]string{
+		fmt.Sprintf("install -d -m 0700 -o %[1]s -g %[1]s %[2]s", user, targetDir),
+		fmt.Sprintf("install -m 0600 -o %[1]s -g %[1]s %[2]s/tls.crt %[2]s/tls.key %[3]s/", user, databaseTLSSourcePath, targetDir),
+		"exec " + entrypoint,
+	}, " && ")
+
+	container := &spec.Containers[0]
+	container.Command = []string{"sh", "-c"}
+	container.Args = []string{script}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "db-tls",
+		MountPath: databaseTLSSourcePath,
+		ReadOnly:  true,
+	})
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: "db-tls",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: GetDatabaseTLSSecretName(service)},
+		},
+	})
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dockerHubAuthURL = "https://auth.docker.io/token"
+	// imageTagLookupTimeout bounds the token request and the manifest lookup together
+	imageTagLookupTimeout = 10 * time.Second
+)
+
+// existingImageTags caches images found in the registry; tags are not expected to disappear
+var existingImageTags sync.Map
+
+// VerifyManagedServiceVersion checks that the image of a managed service version exists.
+// Versions from the catalog are accepted as is; any other version is looked up in the
+// registry so a typo is reported now instead of as ImagePullBackOff after the deploy.
+func VerifyManagedServiceVersion(managedType, version string) error {
+	var errs FieldErrors
+	if version == "" || IsValidManagedServiceVersion(managedType, version) {
+		return nil
+	}
+	if !imageTagPattern.MatchString(version) {
+		errs.Add("version", "%q is not a valid image tag", version)
+		return errs.Err()
+	}
+
+	image := getManagedServiceImage(managedType, version)
+	exists, err := ImageTagExists(image)
+	switch {
+	case err != nil:
+		errs.Add("version", "%q is not in the catalog and image %s could not be verified (%v); use one of: %s",
+			version, image, err, strings.Join(GetManagedServiceConfigs()[managedType].Versions, ", "))
+	case !exists:
+		errs.Add("version", "image %s does not exist; use one of: %s",
+			image, strings.Join(GetManagedServiceConfigs()[managedType].Versions, ", "))
+	}
+	return errs.Err()
+}
+
+// ImageTagExists reports whether a Docker Hub image reference such as redis:7.2 or
+// minio/minio:latest resolves to a manifest
+func ImageTagExists(image string) (bool, error) {
+	if _, ok := existingImageTags.Load(image); ok {
+		return true, nil
+	}
+
+	repository, tag := splitImageReference(image)
+	client := &http.Client{Timeout: imageTagLookupTimeout}
+
+	token, err := getDockerHubPullToken(client, repository)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("%s/v2/%s/manifests/%s", DockerHubRemoteURL, repository, tag), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", strings.Join([]string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+	}, ", "))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("registry lookup failed: %v", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		existingImageTags.Store(image, struct{}{})
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("registry lookup returned HTTP %d", resp.StatusCode)
+	}
+}
+
+// getDockerHubPullToken requests the anonymous token Docker Hub requires for manifest reads
+func getDockerHubPullToken(client *http.Client, repository string) (string, error) {
+	url := fmt.Sprintf("%s?service=registry.docker.io&scope=repository:%s:pull", dockerHubAuthURL, repository)
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("registry authentication failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry authentication returned HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid registry token response: %v", err)
+	}
+	return body.Token, nil
+}
+
+// splitImageReference splits a Docker Hub reference into its repository path and tag,
+// adding the library/ namespace of official images
+func splitImageReference(image string) (string, string) {
+	repository, tag := image, "latest"
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		repository, tag = image[:colon], image[colon+1:]
+	}
+	if !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	return repository, tag
+}
+
+// ListImageTags returns every tag of a Docker Hub repository such as library/postgres,
+// following the registry's pagination
+func ListImageTags(repository string) ([]string, error) {
+	client := &http.Client{Timeout: imageTagLookupTimeout}
+	token, err := getDockerHubPullToken(client, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	next := fmt.Sprintf("/v2/%s/tags/list?n=1000", repository)
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, DockerHubRemoteURL+next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("registry tag listing failed: %v", err)
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("registry tag listing returned HTTP %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry tag listing: %v", err)
+		}
+		tags = append(tags, page.Tags...)
+
+		// The next page is announced as Link: </v2/...>; rel="next"
+		next = ""
+		if link := resp.Header.Get("Link"); strings.HasPrefix(link, "<") {
+			if end := strings.Index(link, ">"); end > 0 {
+				next = link[1:end]
+			}
+		}
+	}
+	return tags, nil
+}
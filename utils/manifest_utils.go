@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// manifest is a generated object with the type information the typed specs leave empty
+type manifest struct {
+	apiVersion string
+	kind       string
+	object     interface{}
+}
+
+// RenderServiceManifests renders every resource PenDeploy applies for a service as a
+// multi-document YAML stream, in apply order. Git services are rendered with imageURL.
+// Secret and sensitive values are masked, owner references are left out as they point at
+// the live owner ConfigMap, and the environment's shared pull Secrets are not included.
+func RenderServiceManifests(imageURL string, service models.Service) (string, error) {
+	var manifests []manifest
+	if service.Type == models.ServiceTypeManaged {
+		manifests = managedServiceManifests(service)
+	} else {
+		manifests = gitServiceManifests(imageURL, service)
+	}
+
+	documents := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		document, err := encodeManifest(m)
+		if err != nil {
+			return "", fmt.Errorf("failed to render %s: %v", m.kind, err)
+		}
+		documents = append(documents, document)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+func gitServiceManifests(imageURL string, service models.Service) []manifest {
+	// The env Secret is rendered with masked values, so its checksum would be meaningless
+	service.SecretEnvVars = maskedSecretEnvVars(service)
+
+	manifests := []manifest{
+		{"v1", "ConfigMap", createServiceOwnerSpec(service)},
+		{"v1", "ServiceAccount", createServiceAccountSpec(service)},
+	}
+	if len(service.SecretEnvVars) > 0 {
+		manifests = append(manifests, manifest{"v1", "Secret", createServiceEnvSecretSpec(service)})
+	}
+
+	deployment := createDeploymentSpec(imageURL, service)
+	delete(deployment.Spec.Template.Annotations, envSecretChecksumAnnotation)
+	maskPodSpecEnv(&deployment.Spec.Template.Spec, service)
+	manifests = append(manifests,
+		manifest{"apps/v1", "Deployment", deployment},
+		manifest{"v1", "Service", createServiceSpec(service)},
+		manifest{"networking.k8s.io/v1", "Ingress", createIngressSpec(service)},
+	)
+	if service.CustomDomain != "" && service.CustomTLSSecret != "" {
+		manifests = append(manifests, manifest{"networking.k8s.io/v1", "Ingress", createCustomDomainIngressSpec(service)})
+	}
+	if !service.IsStaticReplica {
+		manifests = append(manifests, manifest{"autoscaling/v2", "HorizontalPodAutoscaler", createHPASpec(service)})
+	}
+	return manifests
+}
+
+func managedServiceManifests(service models.Service) []manifest {
+	service.Port = GetManagedServicePort(service.ManagedType)
+	if len(service.EnvVars) == 0 {
+		service.EnvVars = GenerateManagedServiceEnvVars(service, service.ExternalHost, service.ExternalPort)
+	}
+
+	manifests := []manifest{
+		{"v1", "ConfigMap", createServiceOwnerSpec(service)},
+		{"v1", "ServiceAccount", createServiceAccountSpec(service)},
+	}
+
+	if GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+		statefulSet := createStatefulSetSpec(service)
+		maskPodSpecEnv(&statefulSet.Spec.Template.Spec, service)
+		manifests = append(manifests, manifest{"apps/v1", "StatefulSet", statefulSet})
+	} else {
+		deployment := createManagedDeploymentSpec(service)
+		maskPodSpecEnv(&deployment.Spec.Template.Spec, service)
+		manifests = append(manifests, manifest{"apps/v1", "Deployment", deployment})
+		if RequiresPersistentStorage(service.ManagedType) {
+			manifests = append(manifests, manifest{"v1", "PersistentVolumeClaim", createManagedServicePVCSpec(service)})
+		}
+	}
+
+	if IsPoolingEnabled(service) {
+		pooler := createPgBouncerDeploymentSpec(service)
+		maskPodSpecEnv(&pooler.Spec.Template.Spec, service)
+		manifests = append(manifests,
+			manifest{"apps/v1", "Deployment", pooler},
+			manifest{"v1", "Service", createPgBouncerServiceSpec(service)},
+		)
+	}
+
+	if service.VPAMode != "" {
+		manifests = append(manifests, manifest{"autoscaling.k8s.io/v1", "VerticalPodAutoscaler", buildVPA(service)})
+	}
+
+	for _, config := range GetManagedServiceExposureConfig(service.ManagedType) {
+		manifests = append(manifests, manifest{"v1", "Service", createClusterIPServiceSpec(service, config)})
+	}
+	for _, config := range GetManagedServiceExposureConfig(service.ManagedType) {
+		if config.IsHTTP && config.ExposureType == "Ingress" {
+			manifests = append(manifests, manifest{"networking.k8s.io/v1", "Ingress", createManagedIngressSpec(service, config)})
+		}
+	}
+	return manifests
+}
+
+// maskedSecretEnvVars lists the env vars read from the env Secret with masked values
+func maskedSecretEnvVars(service models.Service) models.EnvVars {
+	masked := models.EnvVars{}
+	for key, value := range service.EnvVars {
+		if HasSecretReferences(value) || service.IsSecretEnvVar(key) {
+			masked[key] = MaskedEnvValue
+		}
+	}
+	return masked
+}
+
+// maskPodSpecEnv masks secret and sensitive literal env values of every container
+func maskPodSpecEnv(spec *corev1.PodSpec, service models.Service) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				env.Value = MaskEnvValue(service, env.Name, env.Value)
+			}
+		}
+	}
+}
+
+// encodeManifest renders one object as YAML with its apiVersion and kind, leaving out the
+// empty status and creation timestamps of an object that was never applied
+func encodeManifest(m manifest) (string, error) {
+	data, err := json.Marshal(m.object)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	fields["apiVersion"] = m.apiVersion
+	fields["kind"] = m.kind
+	delete(fields, "status")
+	dropNullTimestamps(fields)
+
+	document, err := yaml.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(document), nil
+}
+
+// dropNullTimestamps removes the null creationTimestamp of the object and its pod and
+// volume claim templates
+func dropNullTimestamps(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if timestamp, ok := typed["creationTimestamp"]; ok && timestamp == nil {
+			delete(typed, "creationTimestamp")
+		}
+		for _, nested := range typed {
+			dropNullTimestamps(nested)
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			dropNullTimestamps(nested)
+		}
+	}
+}
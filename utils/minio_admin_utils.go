@@ -0,0 +1,415 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/minio/madmin-go/v3"
+	"github.com/minio/minio-go/v7"
+	miniocredentials "github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MinIOClientImage runs the mc CLI in Jobs that copy objects between buckets
+const MinIOClientImage = "minio/mc:latest"
+
+// bucketNamePattern follows the S3 bucket naming rules
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// anonymousBucketActions are the actions `mc anonymous set` grants on the bucket and on its
+// objects for each policy
+var anonymousBucketActions = map[string]struct{ bucket, object []string }{
+	"download": {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucket"},
+		object: []string{"s3:GetObject"},
+	},
+	"upload": {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucketMultipartUploads"},
+		object: []string{"s3:AbortMultipartUpload", "s3:DeleteObject", "s3:ListMultipartUploadParts", "s3:PutObject"},
+	},
+	"public": {
+		bucket: []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"},
+		object: []string{"s3:AbortMultipartUpload", "s3:DeleteObject", "s3:GetObject", "s3:ListMultipartUploadParts", "s3:PutObject"},
+	},
+}
+
+// IsValidBucketName checks S3 bucket naming rules
+func IsValidBucketName(name string) bool {
+	return bucketNamePattern.MatchString(name) && !strings.Contains(name, "..")
+}
+
+// IsValidBucketPolicy checks the anonymous access policy accepted by `mc anonymous set`
+func IsValidBucketPolicy(policy string) bool {
+	switch policy {
+	case "none", "download", "upload", "public":
+		return true
+	}
+	return false
+}
+
+// GetMinIOBucketSecretName returns the Secret holding a bucket's scoped credentials
+func GetMinIOBucketSecretName(service models.Service, bucket string) string {
+	return fmt.Sprintf("%s-bucket-%s", GetResourceName(service), bucket)
+}
+
+// getMinIOBucketPolicyName returns the MinIO IAM policy name for a bucket
+func getMinIOBucketPolicyName(bucket string) string {
+	return fmt.Sprintf("bucket-%s", bucket)
+}
+
+// ListMinIOBuckets lists the buckets on a managed MinIO instance
+func ListMinIOBuckets(service models.Service) ([]dto.MinIOBucketResponse, error) {
+	client, err := newMinIOClient(service)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketInfos, err := client.ListBuckets(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %v", err)
+	}
+
+	buckets := []dto.MinIOBucketResponse{}
+	for _, bucket := range bucketInfos {
+		buckets = append(buckets, dto.MinIOBucketResponse{Name: bucket.Name})
+	}
+	return buckets, nil
+}
+
+// CreateMinIOBucket creates a bucket with its access policy, versioning and lifecycle rules
+func CreateMinIOBucket(service models.Service, req dto.MinIOBucketRequest) error {
+	client, err := newMinIOClient(service)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check bucket %s: %v", req.Name, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, req.Name, minio.MakeBucketOptions{}); err != nil {
+			return fmt.Errorf("failed to create bucket %s: %v", req.Name, err)
+		}
+	}
+
+	if err := client.SetBucketPolicy(ctx, req.Name, anonymousBucketPolicy(req.Name, req.Policy)); err != nil {
+		return fmt.Errorf("failed to set policy of bucket %s: %v", req.Name, err)
+	}
+	if req.Versioning {
+		if err := client.EnableVersioning(ctx, req.Name); err != nil {
+			return fmt.Errorf("failed to enable versioning of bucket %s: %v", req.Name, err)
+		}
+	}
+	if len(req.LifecycleRules) > 0 {
+		if err := client.SetBucketLifecycle(ctx, req.Name, lifecycleConfiguration(req.LifecycleRules)); err != nil {
+			return fmt.Errorf("failed to set lifecycle rules of bucket %s: %v", req.Name, err)
+		}
+	}
+	return nil
+}
+
+// SetMinIOBucketLifecycle replaces all lifecycle rules on a bucket
+func SetMinIOBucketLifecycle(service models.Service, bucket string, rules []dto.MinIOLifecycleRule) error {
+	client, err := newMinIOClient(service)
+	if err != nil {
+		return err
+	}
+
+	// An empty configuration removes every rule
+	if err := client.SetBucketLifecycle(context.Background(), bucket, lifecycleConfiguration(rules)); err != nil {
+		return fmt.Errorf("failed to set lifecycle rules of bucket %s: %v", bucket, err)
+	}
+	return nil
+}
+
+// CreateMinIOBucketCredentials creates a user whose policy only allows access to one bucket.
+// The user it replaces, if any, is removed once the new credentials are saved.
+func CreateMinIOBucketCredentials(service models.Service, bucket string) (dto.MinIOBucketCredentials, error) {
+	credentials := dto.MinIOBucketCredentials{
+		AccessKey:        GenerateSecureID("bucket"),
+		SecretKey:        GenerateSecurePassword(32),
+		Bucket:           bucket,
+		Endpoint:         service.EnvVars["MINIO_ENDPOINT"],
+		ExternalEndpoint: fmt.Sprintf("%s:%d", service.ExternalHost, service.ExternalPort),
+		SecretName:       GetMinIOBucketSecretName(service, bucket),
+	}
+
+	previous, err := GetMinIOBucketCredentials(service, bucket)
+	if err != nil {
+		return credentials, err
+	}
+
+	admin, err := newMinIOAdminClient(service)
+	if err != nil {
+		return credentials, err
+	}
+
+	policy, _ := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   []string{"s3:*"},
+				"Resource": []string{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/*"},
+			},
+		},
+	})
+
+	ctx := context.Background()
+	policyName := getMinIOBucketPolicyName(bucket)
+	if err := admin.AddCannedPolicy(ctx, policyName, policy); err != nil {
+		return credentials, fmt.Errorf("failed to create policy %s: %v", policyName, err)
+	}
+	if err := admin.AddUser(ctx, credentials.AccessKey, credentials.SecretKey); err != nil {
+		return credentials, fmt.Errorf("failed to create user: %v", err)
+	}
+	if _, err := admin.AttachPolicy(ctx, madmin.PolicyAssociationReq{
+		Policies: []string{policyName},
+		User:     credentials.AccessKey,
+	}); err != nil {
+		removeMinIOUser(admin, credentials.AccessKey)
+		return credentials, fmt.Errorf("failed to attach policy %s: %v", policyName, err)
+	}
+
+	if err := saveMinIOBucketSecret(service, credentials); err != nil {
+		removeMinIOUser(admin, credentials.AccessKey)
+		return credentials, err
+	}
+
+	if previous != nil && previous.AccessKey != credentials.AccessKey {
+		if err := admin.RemoveUser(ctx, previous.AccessKey); err != nil && !isMinIOUserNotFound(err) {
+			return credentials, fmt.Errorf("new credentials saved but failed to remove previous user %s: %v", previous.AccessKey, err)
+		}
+	}
+	return credentials, nil
+}
+
+// DeleteMinIOBucket removes a bucket with its contents, scoped user, policy and Secret
+func DeleteMinIOBucket(service models.Service, bucket string) error {
+	credentials, err := GetMinIOBucketCredentials(service, bucket)
+	if err != nil {
+		return err
+	}
+
+	client, err := newMinIOClient(service)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := client.RemoveBucketWithOptions(ctx, bucket, minio.RemoveBucketOptions{ForceDelete: true}); err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %v", bucket, err)
+	}
+
+	if credentials == nil {
+		return nil
+	}
+
+	admin, err := newMinIOAdminClient(service)
+	if err != nil {
+		return err
+	}
+	removeMinIOUser(admin, credentials.AccessKey)
+	if err := admin.RemoveCannedPolicy(ctx, getMinIOBucketPolicyName(bucket)); err != nil {
+		log.Printf("Warning: failed to remove MinIO policy of bucket %s: %v", bucket, err)
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Delete(ctx, credentials.SecretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete credentials secret: %v", err)
+	}
+	return nil
+}
+
+// GetMinIOBucketCredentials returns the stored bucket credentials, or nil if none were created
+func GetMinIOBucketCredentials(service models.Service, bucket string) (*dto.MinIOBucketCredentials, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	secret, err := k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Get(context.Background(), GetMinIOBucketSecretName(service, bucket), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret: %v", err)
+	}
+
+	return &dto.MinIOBucketCredentials{
+		AccessKey:        string(secret.Data["access-key"]),
+		SecretKey:        string(secret.Data["secret-key"]),
+		Bucket:           string(secret.Data["bucket"]),
+		Endpoint:         string(secret.Data["endpoint"]),
+		ExternalEndpoint: string(secret.Data["external-endpoint"]),
+		SecretName:       secret.Name,
+	}, nil
+}
+
+// saveMinIOBucketSecret stores bucket credentials next to the MinIO service
+func saveMinIOBucketSecret(service models.Service, credentials dto.MinIOBucketCredentials) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	labels := GetResourceLabels(service)
+	labels["component"] = "minio-bucket"
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentials.SecretName,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"access-key":        credentials.AccessKey,
+			"secret-key":        credentials.SecretKey,
+			"bucket":            credentials.Bucket,
+			"endpoint":          credentials.Endpoint,
+			"external-endpoint": credentials.ExternalEndpoint,
+		},
+	}
+
+	ctx := context.Background()
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return err
+	}
+	setServiceOwner(secret, owner)
+
+	_, err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save credentials secret: %v", err)
+	}
+	return nil
+}
+
+// deleteMinIOBucketSecrets removes every bucket credentials Secret when the service is deleted
+func deleteMinIOBucketSecrets(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	return client.Clientset.CoreV1().Secrets(service.EnvironmentID).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("service-id=%s,component=minio-bucket", service.ID),
+	})
+}
+
+// anonymousBucketPolicy builds the bucket policy `mc anonymous set` would apply; "none"
+// returns an empty policy, which removes anonymous access
+func anonymousBucketPolicy(bucket string, policy string) string {
+	actions, ok := anonymousBucketActions[policy]
+	if !ok {
+		return ""
+	}
+
+	document, _ := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":    "Allow",
+				"Principal": map[string][]string{"AWS": {"*"}},
+				"Action":    actions.bucket,
+				"Resource":  []string{"arn:aws:s3:::" + bucket},
+			},
+			{
+				"Effect":    "Allow",
+				"Principal": map[string][]string{"AWS": {"*"}},
+				"Action":    actions.object,
+				"Resource":  []string{"arn:aws:s3:::" + bucket + "/*"},
+			},
+		},
+	})
+	return string(document)
+}
+
+// lifecycleConfiguration converts expiry rules to a bucket lifecycle configuration
+func lifecycleConfiguration(rules []dto.MinIOLifecycleRule) *lifecycle.Configuration {
+	config := lifecycle.NewConfiguration()
+	for i, rule := range rules {
+		config.Rules = append(config.Rules, lifecycle.Rule{
+			ID:         fmt.Sprintf("expire-%d", i+1),
+			Status:     "Enabled",
+			RuleFilter: lifecycle.Filter{Prefix: rule.Prefix},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(rule.ExpireDays)},
+		})
+	}
+	return config
+}
+
+// newMinIOClient connects to the S3 API of a managed MinIO service with its root credentials
+func newMinIOClient(service models.Service) (*minio.Client, error) {
+	client, err := minio.New(service.EnvVars["MINIO_ENDPOINT"], &minio.Options{
+		Creds: miniocredentials.NewStaticV4(service.EnvVars["MINIO_ROOT_USER"], service.EnvVars["MINIO_ROOT_PASSWORD"], ""),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client: %v", err)
+	}
+	return client, nil
+}
+
+// newMinIOAdminClient connects to the admin API of a managed MinIO service with its root credentials
+func newMinIOAdminClient(service models.Service) (*madmin.AdminClient, error) {
+	admin, err := madmin.New(service.EnvVars["MINIO_ENDPOINT"], service.EnvVars["MINIO_ROOT_USER"], service.EnvVars["MINIO_ROOT_PASSWORD"], false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO admin client: %v", err)
+	}
+	return admin, nil
+}
+
+// removeMinIOUser deletes a bucket user, logging instead of failing when it can't
+func removeMinIOUser(admin *madmin.AdminClient, accessKey string) {
+	if err := admin.RemoveUser(context.Background(), accessKey); err != nil && !isMinIOUserNotFound(err) {
+		log.Printf("Warning: failed to remove MinIO user %s: %v", accessKey, err)
+	}
+}
+
+// isMinIOUserNotFound reports whether an admin API error means the user no longer exists
+func isMinIOUserNotFound(err error) bool {
+	return madmin.ToErrorResponse(err).Code == "XMinioAdminNoSuchUser"
+}
+
+// readJobContainerLogs returns the full (size-capped) logs of a container in a job's pod
+func readJobContainerLogs(k8sClient *kubernetes.Client, jobName, namespace, container string) string {
+	return readJobContainerLogsLimited(k8sClient, jobName, namespace, container, 1<<20)
+}
+
+// readJobContainerLogsLimited is readJobContainerLogs with a caller-chosen size cap
+func readJobContainerLogsLimited(k8sClient *kubernetes.Client, jobName, namespace, container string, limit int64) string {
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+
+	stream, err := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{
+		Container: container,
+	}).Stream(context.Background())
+	if err != nil {
+		return ""
+	}
+	defer stream.Close()
+
+	logs, _ := io.ReadAll(io.LimitReader(stream, limit))
+	return string(logs)
+}
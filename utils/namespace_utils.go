@@ -53,6 +53,11 @@ func EnsureNamespaceExists(namespaceName string) error {
 		metav1.CreateOptions{},
 	)
 	
+	// Another deploy to the same environment may have created it meanwhile
+	if errors.IsAlreadyExists(err) {
+		log.Println("Namespace already exists:", namespaceName)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("error creating namespace: %v", err)
 	}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Node maintenance actions
+const (
+	NodeActionCordon   = "cordon"
+	NodeActionUncordon = "uncordon"
+	NodeActionDrain    = "drain"
+)
+
+const (
+	// defaultDrainTimeout bounds a drain when the request doesn't set a timeout
+	defaultDrainTimeout = 10 * time.Minute
+
+	// DrainRetryInterval is how often a drain retries evictions refused by a
+	// PodDisruptionBudget and checks which evicted pods are gone
+	DrainRetryInterval = 5 * time.Second
+)
+
+// mirrorPodAnnotation marks static pods the kubelet runs from a manifest on the node
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// GetDrainTimeout returns the requested drain timeout, or the default
+func GetDrainTimeout(timeoutSeconds int) time.Duration {
+	if timeoutSeconds > 0 {
+		return time.Duration(timeoutSeconds) * time.Second
+	}
+	return defaultDrainTimeout
+}
+
+// SetNodeUnschedulable cordons or uncordons a node
+func SetNodeUnschedulable(ctx context.Context, k8sClient *kubernetes.Client, nodeName string, unschedulable bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	if _, err := k8sClient.Clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to update node %s: %v", nodeName, err)
+	}
+	return nil
+}
+
+// DrainPods lists the pods a drain has to evict from a node. DaemonSet and mirror pods
+// are skipped since evicting them is pointless, and finished pods hold no resources.
+// Like kubectl drain, pods no controller would recreate and pods with emptyDir data are
+// refused unless force or deleteEmptyDirData allow them.
+func DrainPods(ctx context.Context, k8sClient *kubernetes.Client, nodeName string, force, deleteEmptyDirData bool) (evict []corev1.Pod, skipped []string, err error) {
+	pods, err := k8sClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods on node %s: %v", nodeName, err)
+	}
+
+	var unmanaged, emptyDir []string
+	for _, pod := range pods.Items {
+		key := pod.Namespace + "/" + pod.Name
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			skipped = append(skipped, key)
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			skipped = append(skipped, key)
+			continue
+		}
+		if controller == nil && !force {
+			unmanaged = append(unmanaged, key)
+		}
+		if !deleteEmptyDirData && podUsesEmptyDir(pod) {
+			emptyDir = append(emptyDir, key)
+		}
+		evict = append(evict, pod)
+	}
+
+	var problems []string
+	if len(unmanaged) > 0 {
+		problems = append(problems, fmt.Sprintf("pods not managed by a controller (set force to evict them): %s", strings.Join(unmanaged, ", ")))
+	}
+	if len(emptyDir) > 0 {
+		problems = append(problems, fmt.Sprintf("pods with emptyDir data (set deleteEmptyDirData to evict them): %s", strings.Join(emptyDir, ", ")))
+	}
+	if len(problems) > 0 {
+		return nil, nil, fmt.Errorf("cannot drain node %s: %s", nodeName, strings.Join(problems, "; "))
+	}
+	return evict, skipped, nil
+}
+
+func podUsesEmptyDir(pod corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// EvictPod asks the API server to evict a pod. Evictions that would violate a
+// PodDisruptionBudget fail with 429 Too Many Requests and can be retried.
+func EvictPod(ctx context.Context, k8sClient *kubernetes.Client, pod corev1.Pod, gracePeriodSeconds *int64) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+			// Don't evict a pod that was replaced under the same name meanwhile
+			Preconditions: &metav1.Preconditions{UID: &pod.UID},
+		},
+	}
+	return k8sClient.Clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Teardown states of a namespace of a deleted project
+const (
+	NamespaceGone        = "gone"
+	NamespaceTerminating = "terminating"
+	NamespaceSkipped     = "skipped"
+	NamespaceFailed      = "failed"
+)
+
+// GetAuditRetention returns how long audit records are kept (AUDIT_RETENTION_DAYS, default
+// 365). Offboarding deletes a user's older records and anonymizes the rest.
+func GetAuditRetention() time.Duration {
+	days := getEnvInt("AUDIT_RETENTION_DAYS", 365)
+	if days <= 0 {
+		days = 365
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// isSystemNamespace reports whether a namespace belongs to the platform or to Kubernetes
+// itself; those are never torn down on behalf of a project
+func isSystemNamespace(namespace string) bool {
+	return namespace == "default" || strings.HasPrefix(namespace, "kube-") ||
+		GetTenantIsolationConfig().IsProtectedNamespace(namespace)
+}
+
+// TeardownNamespace makes sure the namespace of a deleted environment goes away: it deletes
+// the namespace unless it is gone or terminating already. System namespaces are skipped.
+func TeardownNamespace(namespace string) (string, error) {
+	if isSystemNamespace(namespace) {
+		return NamespaceSkipped, nil
+	}
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return NamespaceFailed, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	existing, err := k8sClient.Clientset.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return NamespaceGone, nil
+	}
+	if err != nil {
+		return NamespaceFailed, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+	}
+	if existing.DeletionTimestamp != nil {
+		return NamespaceTerminating, nil
+	}
+
+	if err := k8sClient.DeleteNamespace(namespace); err != nil && !errors.IsNotFound(err) {
+		return NamespaceFailed, err
+	}
+	return NamespaceTerminating, nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetServiceOwnerName returns the name of the per-service parent ConfigMap
+func GetServiceOwnerName(service models.Service) string {
+	return GetResourceName(service) + "-owner"
+}
+
+// createServiceOwnerSpec builds the service's parent ConfigMap
+func createServiceOwnerSpec(service models.Service) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceOwnerName(service),
+			Namespace: service.EnvironmentID,
+			Labels:    GetResourceLabels(service),
+		},
+		Data: map[string]string{
+			"service-id":   service.ID,
+			"service-name": service.Name,
+			"service-type": string(service.Type),
+		},
+	}
+}
+
+// ensureServiceOwner gets or creates the parent ConfigMap that every resource of the
+// service references, so deleting it lets Kubernetes garbage-collect the rest
+func ensureServiceOwner(ctx context.Context, client *kubernetes.Client, service models.Service) (metav1.OwnerReference, error) {
+	configMaps := client.Clientset.CoreV1().ConfigMaps(service.EnvironmentID)
+	name := GetServiceOwnerName(service)
+
+	owner, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		owner, err = configMaps.Create(ctx, createServiceOwnerSpec(service), metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			owner, err = configMaps.Get(ctx, name, metav1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return metav1.OwnerReference{}, fmt.Errorf("failed to ensure owner ConfigMap %s: %v", name, err)
+	}
+
+	return metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}, nil
+}
+
+// setServiceOwner makes the service's parent ConfigMap the owner of obj
+func setServiceOwner(obj metav1.Object, owner metav1.OwnerReference) {
+	obj.SetOwnerReferences([]metav1.OwnerReference{owner})
+}
+
+// deleteServiceOwner deletes the parent ConfigMap; the garbage collector then removes
+// every resource that references it
+func deleteServiceOwner(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	name := GetServiceOwnerName(service)
+	background := metav1.DeletePropagationBackground
+
+	err := client.Clientset.CoreV1().ConfigMaps(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &background})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		log.Printf("Owner ConfigMap %s deleted, dependents will be garbage-collected", name)
+	}
+	return nil
+}
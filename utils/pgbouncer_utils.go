@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	PgBouncerImage = "edoburu/pgbouncer:v1.23.1-p2"
+	PgBouncerPort  = 6432
+
+	DefaultPoolMode      = "transaction"
+	DefaultPoolSize      = 20
+	DefaultMaxClientConn = 200
+)
+
+// IsValidPoolMode checks if the PgBouncer pool mode is supported
+func IsValidPoolMode(mode string) bool {
+	switch mode {
+	case "session", "transaction", "statement":
+		return true
+	}
+	return false
+}
+
+// GetPgBouncerResourceName returns the name of the pooler Deployment/Service for a service
+func GetPgBouncerResourceName(service models.Service) string {
+	return fmt.Sprintf("%s-pooler", GetResourceName(service))
+}
+
+// GetPgBouncerHost returns the in-cluster hostname of the pooler
+func GetPgBouncerHost(service models.Service) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", GetPgBouncerResourceName(service), service.EnvironmentID)
+}
+
+// IsPoolingEnabled reports whether a PgBouncer should run in front of the service
+func IsPoolingEnabled(service models.Service) bool {
+	return service.Type == models.ServiceTypeManaged && service.ManagedType == "postgresql" && service.PoolingEnabled
+}
+
+// getPgBouncerLabels uses its own app label so the pooler pods are not
+// picked up by the database Service selector or health checks
+func getPgBouncerLabels(service models.Service) map[string]string {
+	labels := GetResourceLabels(service)
+	labels["app"] = GetPgBouncerResourceName(service)
+	labels["component"] = "pooler"
+	return labels
+}
+
+// deployPgBouncer creates or updates the PgBouncer Deployment and Service for a managed PostgreSQL
+func deployPgBouncer(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	deployment := createPgBouncerDeploymentSpec(service)
+	setServiceOwner(deployment, owner)
+	if err := applyManagedDeployment(ctx, client, deployment); err != nil {
+		return fmt.Errorf("deployment: %v", err)
+	}
+	k8sService := createPgBouncerServiceSpec(service)
+	setServiceOwner(k8sService, owner)
+	if err := applyManagedService(ctx, client, k8sService); err != nil {
+		return fmt.Errorf("service: %v", err)
+	}
+
+	log.Printf("Deployed PgBouncer for %s (mode=%s)", service.Name, service.PoolMode)
+	return nil
+}
+
+// deletePgBouncer removes the pooler Deployment and Service, ignoring ones that don't exist
+func deletePgBouncer(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	name := GetPgBouncerResourceName(service)
+
+	err := client.Clientset.AppsV1().Deployments(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PgBouncer Deployment %s: %v", name, err)
+	}
+	if err == nil {
+		log.Printf("PgBouncer Deployment %s deleted successfully", name)
+	}
+
+	err = client.Clientset.CoreV1().Services(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PgBouncer Service %s: %v", name, err)
+	}
+	if err == nil {
+		log.Printf("PgBouncer Service %s deleted successfully", name)
+	}
+	return nil
+}
+
+// createPgBouncerDeploymentSpec builds the pooler Deployment. Credentials are taken
+// from the parent service's generated env vars so both always match.
+func createPgBouncerDeploymentSpec(service models.Service) *appsv1.Deployment {
+	name := GetPgBouncerResourceName(service)
+	labels := getPgBouncerLabels(service)
+	replicas := int32(1)
+
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(PgBouncerPort)},
+		},
+		InitialDelaySeconds: 5,
+		PeriodSeconds:       10,
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			RevisionHistoryLimit: int32Ptr(1),
+			Replicas:             &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "pgbouncer",
+							Image: PgBouncerImage,
+							Ports: []corev1.ContainerPort{
+								{
+									ContainerPort: PgBouncerPort,
+									Protocol:      corev1.ProtocolTCP,
+									Name:          "pooler",
+								},
+							},
+							Env: []corev1.EnvVar{
+								{Name: "DB_HOST", Value: fmt.Sprintf("%s.%s.svc.cluster.local", GetResourceName(service), service.EnvironmentID)},
+								{Name: "DB_PORT", Value: strconv.Itoa(service.Port)},
+								{Name: "DB_NAME", Value: service.EnvVars["POSTGRES_DB"]},
+								{Name: "DB_USER", Value: service.EnvVars["POSTGRES_USER"]},
+								{Name: "DB_PASSWORD", Value: service.EnvVars["POSTGRES_PASSWORD"]},
+								{Name: "AUTH_TYPE", Value: "scram-sha-256"},
+								{Name: "LISTEN_PORT", Value: strconv.Itoa(PgBouncerPort)},
+								{Name: "POOL_MODE", Value: service.PoolMode},
+								{Name: "DEFAULT_POOL_SIZE", Value: strconv.Itoa(service.PoolSize)},
+								{Name: "MAX_CLIENT_CONN", Value: strconv.Itoa(service.MaxClientConn)},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("200m"),
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+							},
+							ReadinessProbe: probe,
+							LivenessProbe:  probe,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	SecurePodSpec(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+// createPgBouncerServiceSpec creates the ClusterIP Service in front of the pooler
+func createPgBouncerServiceSpec(service models.Service) *corev1.Service {
+	name := GetPgBouncerResourceName(service)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    getPgBouncerLabels(service),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": name},
+			Ports: []corev1.ServicePort{
+				{
+					Port:       PgBouncerPort,
+					TargetPort: intstr.FromInt(PgBouncerPort),
+					Protocol:   corev1.ProtocolTCP,
+					Name:       "pooler",
+				},
+			},
+		},
+	}
+}
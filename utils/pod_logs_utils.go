@@ -31,8 +31,11 @@ func getDetailedJobLogs(k8sClient *kubernetes.Client, jobName, namespace string)
     for _, pod := range pods.Items {
         allLogs.WriteString(fmt.Sprintf("\n=== Pod: %s ===\n", pod.Name))
         
-        // Get logs from all containers
-        containers := []string{"git-clone", "dockerfile-generator", "kaniko-executor"}
+        // Get logs from all containers, whichever build backend the job runs
+        var containers []string
+        for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+            containers = append(containers, container.Name)
+        }
         
         for _, container := range containers {
             allLogs.WriteString(fmt.Sprintf("\n--- Container: %s ---\n", container))
@@ -81,9 +84,13 @@ func getJobPodLogs(k8sClient *kubernetes.Client, jobName, namespace string) stri
     // Get logs from the main container of the first pod
     podName := pods.Items[0].Name
     
-    // Try to get logs from kaniko-executor container
+    // Try to get logs from the executor container of the pod's build backend
+    container := KanikoContainerName
+    if pods.Items[0].Labels["builder"] == BuildBackendBuildKit {
+        container = BuildKitContainerName
+    }
     req := k8sClient.Clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
-        Container: "kaniko-executor",
+        Container: container,
         TailLines: int64Ptr(10), // Last 10 lines
     })
     
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// reservedPodMetadataDomains are key prefixes owned by the platform or Kubernetes itself.
+// Subdomains are reserved too (e.g. node.kubernetes.io/).
+var reservedPodMetadataDomains = []string{
+	"pendeploy.io",
+	"pendeploy.com",
+	"kubernetes.io",
+	"k8s.io",
+}
+
+// IsReservedPodMetadataKey reports whether a pod label or annotation key is reserved.
+// Labels additionally may not reuse the unprefixed keys PenDeploy selects pods by.
+func IsReservedPodMetadataKey(key string, label bool) bool {
+	if label {
+		if _, ok := GetResourceLabels(models.Service{})[key]; ok {
+			return true
+		}
+	}
+
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
+	}
+	for _, domain := range reservedPodMetadataDomains {
+		if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPodMetadata adds the service's user-defined labels and annotations (e.g.
+// prometheus.io/scrape, Datadog tags) to a pod template. Platform labels always win, so
+// selectors keep matching even for keys stored before they were reserved.
+func applyPodMetadata(template *corev1.PodTemplateSpec, service models.Service) {
+	if len(service.PodLabels) > 0 {
+		labels := make(map[string]string, len(template.Labels)+len(service.PodLabels))
+		for key, value := range service.PodLabels {
+			labels[key] = value
+		}
+		for key, value := range template.Labels {
+			labels[key] = value
+		}
+		template.Labels = labels
+	}
+
+	if len(service.PodAnnotations) > 0 {
+		annotations := make(map[string]string, len(template.Annotations)+len(service.PodAnnotations))
+		for key, value := range service.PodAnnotations {
+			annotations[key] = value
+		}
+		for key, value := range template.Annotations {
+			annotations[key] = value
+		}
+		template.Annotations = annotations
+	}
+}
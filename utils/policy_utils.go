@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WorkloadSpec is the generated workload of a service that policies are evaluated against
+type WorkloadSpec struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Template  corev1.PodTemplateSpec
+}
+
+// Resource identifies the workload in violation messages
+func (w WorkloadSpec) Resource() string {
+	return fmt.Sprintf("%s/%s/%s", w.Kind, w.Namespace, w.Name)
+}
+
+// RenderWorkloadSpec returns the workload PenDeploy would apply for the service, without
+// touching the cluster. imageURL is ignored for managed services.
+func RenderWorkloadSpec(imageURL string, service models.Service) WorkloadSpec {
+	if service.Type == models.ServiceTypeManaged {
+		if GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+			statefulSet := createStatefulSetSpec(service)
+			return WorkloadSpec{Kind: "StatefulSet", Namespace: statefulSet.Namespace, Name: statefulSet.Name, Template: statefulSet.Spec.Template}
+		}
+		deployment := createManagedDeploymentSpec(service)
+		return WorkloadSpec{Kind: "Deployment", Namespace: deployment.Namespace, Name: deployment.Name, Template: deployment.Spec.Template}
+	}
+
+	deployment := createDeploymentSpec(imageURL, service)
+	return WorkloadSpec{Kind: "Deployment", Namespace: deployment.Namespace, Name: deployment.Name, Template: deployment.Spec.Template}
+}
+
+// CheckImageTags reports containers whose image uses the mutable latest tag, or no tag
+func CheckImageTags(spec WorkloadSpec) []string {
+	var problems []string
+	for _, container := range podContainers(spec.Template.Spec) {
+		if strings.Contains(container.Image, "@") {
+			continue
+		}
+		if repository := imageRepository(container.Image); repository == container.Image || strings.HasSuffix(container.Image, ":latest") {
+			problems = append(problems, fmt.Sprintf("container %s uses image %s; pin a version tag instead of latest", container.Name, container.Image))
+		}
+	}
+	return problems
+}
+
+// CheckProbes reports long-running containers without readiness or liveness probes
+func CheckProbes(spec WorkloadSpec) []string {
+	var problems []string
+	for _, container := range spec.Template.Spec.Containers {
+		var missing []string
+		if container.ReadinessProbe == nil {
+			missing = append(missing, "readiness")
+		}
+		if container.LivenessProbe == nil {
+			missing = append(missing, "liveness")
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("container %s has no %s probe", container.Name, strings.Join(missing, " or ")))
+		}
+	}
+	return problems
+}
+
+func podContainers(spec corev1.PodSpec) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
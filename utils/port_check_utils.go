@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PortEnvVar is injected into git service containers with the port traffic is routed to
+const PortEnvVar = "PORT"
+
+const (
+	// portCheckTimeout bounds how long a rolled out pod gets to start listening on its port
+	portCheckTimeout = 2 * time.Minute
+	// portCheckInterval is the pause between connection attempts
+	portCheckInterval = 5 * time.Second
+	// portDialTimeout bounds a single connection attempt
+	portDialTimeout = 3 * time.Second
+)
+
+// portEnvVar returns the PORT env var of a git service's container. A PORT the user set
+// is kept, PortMismatchWarning reports when it differs from the service port.
+func portEnvVar(service models.Service) (corev1.EnvVar, bool) {
+	if service.Port <= 0 {
+		return corev1.EnvVar{}, false
+	}
+	if _, declared := service.EnvVars[PortEnvVar]; declared {
+		return corev1.EnvVar{}, false
+	}
+	return corev1.EnvVar{Name: PortEnvVar, Value: strconv.Itoa(service.Port)}, true
+}
+
+// PortMismatchWarning describes a PORT env var that differs from the port the Service and
+// ingress route to, the most common reason for a deployed service answering 502
+func PortMismatchWarning(service models.Service) string {
+	declared, ok := service.EnvVars[PortEnvVar]
+	if !ok || HasSecretReferences(declared) || service.IsSecretEnvVar(PortEnvVar) {
+		return ""
+	}
+	if port, err := strconv.Atoi(declared); err == nil && port == service.Port {
+		return ""
+	}
+	return fmt.Sprintf("env var PORT is %q but traffic is routed to port %d; remove PORT or set the service port to match", declared, service.Port)
+}
+
+// ExposedPortMismatchWarning describes a service port that is not one of the TCP ports the
+// image's Dockerfile EXPOSEs. Images that expose no port are not checked: EXPOSE is only
+// documentation, and many apps listen on PORT without declaring it.
+func ExposedPortMismatchWarning(service models.Service, exposedPorts []int) string {
+	if len(exposedPorts) == 0 {
+		return ""
+	}
+	ports := make([]string, 0, len(exposedPorts))
+	for _, port := range exposedPorts {
+		if port == service.Port {
+			return ""
+		}
+		ports = append(ports, strconv.Itoa(port))
+	}
+	return fmt.Sprintf("the Dockerfile exposes port %s but traffic is routed to port %d; unless the app listens on PORT, set the service port to the exposed port",
+		strings.Join(ports, ", "), service.Port)
+}
+
+// CheckServicePort verifies that the pods rolled out since the given time accept TCP
+// connections on the service port. It waits for a ready pod and retries until
+// portCheckTimeout, failing early when a pod crash-loops or cannot start.
+func CheckServicePort(service models.Service, since time.Time) error {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), portCheckTimeout)
+	defer cancel()
+
+	resourceName := GetResourceName(service)
+	selector := fmt.Sprintf("app=%s", resourceName)
+	for {
+		var lastErr error
+		pods, err := client.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			lastErr = fmt.Errorf("failed to list pods of %s: %v", resourceName, err)
+		} else {
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				if pod.DeletionTimestamp != nil || pod.CreationTimestamp.Time.Before(since.Add(-time.Second)) {
+					continue
+				}
+				if podErr := checkPodForErrors(pod); podErr != nil {
+					return portCheckError(service, podErr)
+				}
+				if !isPodReady(pod) || pod.Status.PodIP == "" {
+					lastErr = fmt.Errorf("no rolled out pod of %s is ready yet", resourceName)
+					continue
+				}
+				address := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(service.Port))
+				conn, dialErr := net.DialTimeout("tcp", address, portDialTimeout)
+				if dialErr == nil {
+					conn.Close()
+					return nil
+				}
+				lastErr = fmt.Errorf("pod %s does not accept connections on port %d: %v", pod.Name, service.Port, dialErr)
+			}
+			if lastErr == nil {
+				lastErr = fmt.Errorf("no rolled out pod of %s found", resourceName)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return portCheckError(service, fmt.Errorf("%v after %v", lastErr, portCheckTimeout))
+		case <-time.After(portCheckInterval):
+		}
+	}
+}
+
+// portCheckError adds the PORT mismatch, if any, as the likely cause of a failed check
+func portCheckError(service models.Service, err error) error {
+	if warning := PortMismatchWarning(service); warning != "" {
+		return fmt.Errorf("%v (%s)", err, warning)
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodePortRangePattern reads the API server's range from its rejection of a NodePort outside it
+var nodePortRangePattern = regexp.MustCompile(`range of valid ports is (\d+)-(\d+)`)
+
+// platformPortRanges holds the admin-set NodePort range and managed service port ranges
+var platformPortRanges struct {
+	mu            sync.RWMutex
+	nodePortRange string
+	managed       models.PortRanges
+}
+
+// SetPlatformPortRanges applies the admin-set port ranges; an empty NodePort range restores
+// SERVICE_NODE_PORT_RANGE
+func SetPlatformPortRanges(nodePortRange string, managed models.PortRanges) {
+	platformPortRanges.mu.Lock()
+	defer platformPortRanges.mu.Unlock()
+	platformPortRanges.nodePortRange = nodePortRange
+	platformPortRanges.managed = managed
+}
+
+// GetManagedServicePortRange returns the range external ports of a managed service type are
+// allocated from: the admin-set range of the type, or the TCP proxy's whole range
+func GetManagedServicePortRange(managedType string) (int, int) {
+	platformPortRanges.mu.RLock()
+	portRange, ok := platformPortRanges.managed[managedType]
+	platformPortRanges.mu.RUnlock()
+	if ok {
+		return portRange.Start, portRange.End
+	}
+	cfg := GetTCPProxyConfig()
+	return cfg.PortStart, cfg.PortEnd
+}
+
+// ParsePortRange parses an inclusive port range such as 30000-32767
+func ParsePortRange(value string) (models.PortRange, error) {
+	bounds := strings.SplitN(value, "-", 2)
+	if len(bounds) != 2 {
+		return models.PortRange{}, fmt.Errorf("%q is not a port range, e.g. 30000-32767", value)
+	}
+	start, startErr := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	end, endErr := strconv.Atoi(strings.TrimSpace(bounds[1]))
+	if startErr != nil || endErr != nil {
+		return models.PortRange{}, fmt.Errorf("%q is not a port range, e.g. 30000-32767", value)
+	}
+	portRange := models.PortRange{Start: start, End: end}
+	if err := checkPortRange(portRange); err != nil {
+		return models.PortRange{}, err
+	}
+	return portRange, nil
+}
+
+// ValidatePortRanges checks the admin-set port ranges: a NodePort range, known managed
+// service types, and managed ranges that overlap neither each other nor the NodePort range,
+// whose ports kube-proxy holds on every node
+func ValidatePortRanges(nodePortRange string, managed models.PortRanges) error {
+	var errs FieldErrors
+
+	nodePorts, err := ParsePortRange(nodePortRange)
+	if err != nil {
+		errs.Add("nodePortRange", "%v", err)
+	}
+
+	types := make([]string, 0, len(managed))
+	for managedType := range managed {
+		types = append(types, managedType)
+	}
+	sort.Strings(types)
+	for i, managedType := range types {
+		field := "managedPortRanges." + managedType
+		portRange := managed[managedType]
+		if !IsValidManagedServiceType(managedType) {
+			errs.Add(field, "%q is not a managed service type", managedType)
+			continue
+		}
+		if err := checkPortRange(portRange); err != nil {
+			errs.Add(field, "%v", err)
+			continue
+		}
+		if nodePorts.End > 0 && portRangesOverlap(portRange, nodePorts) {
+			errs.Add(field, "overlaps the NodePort range %d-%d", nodePorts.Start, nodePorts.End)
+		}
+		for _, other := range types[:i] {
+			if portRangesOverlap(portRange, managed[other]) {
+				errs.Add(field, "overlaps the range of %s", other)
+			}
+		}
+	}
+	return errs.Err()
+}
+
+// CheckNodePortRangeWithCluster compares a NodePort range with the API server's
+// --service-node-port-range. The API does not expose the flag, so NodePorts outside the
+// range are allocated in dry runs and the range is read from the rejection. It returns the
+// API server's range when it was found.
+func CheckNodePortRangeWithCluster(ctx context.Context, client *kubernetes.Client, portRange models.PortRange) (models.PortRange, error) {
+	probes := []int{1, 65535, portRange.Start - 1, portRange.End + 1}
+	for _, probe := range probes {
+		if probe < 1 || probe > 65535 {
+			continue
+		}
+		actual, found, err := detectNodePortRange(ctx, client, probe)
+		if err != nil {
+			return models.PortRange{}, err
+		}
+		if !found {
+			continue
+		}
+		if actual != portRange {
+			return actual, fmt.Errorf("the API server's --service-node-port-range is %d-%d, not %d-%d",
+				actual.Start, actual.End, portRange.Start, portRange.End)
+		}
+		return actual, nil
+	}
+	// Every probe was accepted: the API server's range is wider than the one checked
+	return models.PortRange{}, fmt.Errorf("the API server accepts NodePorts outside %d-%d", portRange.Start, portRange.End)
+}
+
+// detectNodePortRange allocates a NodePort in a dry run and reports the API server's range
+// when the port is rejected as outside it
+func detectNodePortRange(ctx context.Context, client *kubernetes.Client, nodePort int) (models.PortRange, bool, error) {
+	probe := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "pendeploy-nodeport-probe", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: map[string]string{"app": "pendeploy-nodeport-probe"},
+			Ports:    []corev1.ServicePort{{Port: 80, NodePort: int32(nodePort), Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	_, err := client.Clientset.CoreV1().Services("default").Create(ctx, probe, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err == nil || apierrors.IsAlreadyExists(err) {
+		return models.PortRange{}, false, nil
+	}
+	if !apierrors.IsInvalid(err) {
+		return models.PortRange{}, false, fmt.Errorf("failed to probe the NodePort range: %v", err)
+	}
+
+	// Ports inside the range but already allocated are rejected too, without a range
+	match := nodePortRangePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return models.PortRange{}, false, nil
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	return models.PortRange{Start: start, End: end}, true, nil
+}
+
+func checkPortRange(portRange models.PortRange) error {
+	if portRange.Start < 1 || portRange.End > 65535 || portRange.Start > portRange.End {
+		return fmt.Errorf("%d-%d is not a port range within 1-65535", portRange.Start, portRange.End)
+	}
+	return nil
+}
+
+func portRangesOverlap(a, b models.PortRange) bool {
+	return a.Start <= b.End && b.Start <= a.End
+}
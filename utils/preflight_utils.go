@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultNodePortRange = "30000-32767"
+	// nodePortHeadroom is the share of the NodePort range below which free ports are reported
+	nodePortHeadroom = 0.1
+)
+
+// preflightAPIGroup is an API group the platform creates or reads resources of, with the
+// resource that must be served, the status when it is not and how to get it. Groups with a
+// provider are only checked while that ingress provider is selected.
+type preflightAPIGroup struct {
+	name         string
+	groupVersion string
+	resource     string
+	status       string
+	remedy       string
+	provider     string
+}
+
+var preflightAPIGroups = []preflightAPIGroup{
+	{
+		name:         "traefik-crds",
+		groupVersion: "traefik.io/v1alpha1",
+		resource:     "middlewares",
+		status:       dto.PreflightWarning,
+		remedy:       "Install Traefik v2.10 or later with its CRDs (k3s bundles it; the Helm chart installs the CRDs). Cache policies and error pages are Traefik Middlewares.",
+		provider:     IngressProviderTraefik,
+	},
+	{
+		name:         "gateway-api",
+		groupVersion: "gateway.networking.k8s.io/v1",
+		resource:     "httproutes",
+		status:       dto.PreflightFailed,
+		remedy:       "Install the Gateway API CRDs (standard channel) and a controller implementing them; the gateway ingress provider renders HTTPRoutes instead of Ingresses.",
+		provider:     IngressProviderGateway,
+	},
+	{
+		name:         "gateway-api-tcproutes",
+		groupVersion: "gateway.networking.k8s.io/v1alpha2",
+		resource:     "tcproutes",
+		status:       dto.PreflightWarning,
+		remedy:       "Install the Gateway API's experimental channel CRDs to publish managed services' TCP ports with TCPRoutes; until then they fail to publish.",
+		provider:     IngressProviderGateway,
+	},
+	{
+		name:         "cert-manager",
+		groupVersion: "cert-manager.io/v1",
+		resource:     "clusterissuers",
+		status:       dto.PreflightFailed,
+		remedy:       "Install cert-manager (kubectl apply -f https://github.com/cert-manager/cert-manager/releases/latest/download/cert-manager.yaml); every Ingress requests its certificate from it.",
+	},
+	{
+		name:         "metrics-server",
+		groupVersion: "metrics.k8s.io/v1beta1",
+		resource:     "pods",
+		status:       dto.PreflightWarning,
+		remedy:       "Install metrics-server (k3s bundles it). Without it autoscaling, usage stats and right-sizing have no data.",
+	},
+}
+
+// GetNodePortRange returns the NodePort range of the API server: the one set in the platform
+// settings, else SERVICE_NODE_PORT_RANGE (default 30000-32767). The API does not expose it,
+// so it must match --service-node-port-range.
+func GetNodePortRange() (int, int, error) {
+	platformPortRanges.mu.RLock()
+	value := platformPortRanges.nodePortRange
+	platformPortRanges.mu.RUnlock()
+	if value == "" {
+		value = GetDefaultNodePortRange()
+	}
+	portRange, err := ParsePortRange(value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid NodePort range: %v", err)
+	}
+	return portRange.Start, portRange.End, nil
+}
+
+// GetDefaultNodePortRange returns the NodePort range in effect until an admin sets one
+func GetDefaultNodePortRange() string {
+	return getEnvString("SERVICE_NODE_PORT_RANGE", defaultNodePortRange)
+}
+
+// RunPreflightChecks verifies the cluster prerequisites of the platform without changing
+// anything: API access, the ingress provider's CRDs and IngressClass or GatewayClass, cert-manager and its ClusterIssuer, metrics-server,
+// a default StorageClass and free NodePorts, which the TCP proxy's LoadBalancer Service
+// allocates one of per exposed port
+func RunPreflightChecks() dto.PreflightReport {
+	report := dto.PreflightReport{Ready: true, CheckedAt: time.Now()}
+	add := func(check dto.PreflightCheck) {
+		if check.Status == dto.PreflightOK {
+			check.Remedy = ""
+		}
+		if check.Status == dto.PreflightFailed {
+			report.Ready = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	client, err := kubernetes.NewClient()
+	if err == nil {
+		_, err = client.Clientset.Discovery().ServerVersion()
+	}
+	if err != nil {
+		add(dto.PreflightCheck{
+			Name:    "kubernetes-api",
+			Status:  dto.PreflightFailed,
+			Message: fmt.Sprintf("the Kubernetes API is not reachable: %v", err),
+			Remedy:  "Run the API in the cluster with a ServiceAccount, or set K8S_PROXY_URL to a kubectl proxy for local development.",
+		})
+		return report
+	}
+	add(dto.PreflightCheck{Name: "kubernetes-api", Status: dto.PreflightOK, Message: "the Kubernetes API is reachable"})
+
+	provider := GetIngressProvider()
+	for _, group := range preflightAPIGroups {
+		if group.provider != "" && group.provider != provider.Name() {
+			continue
+		}
+		add(checkAPIGroup(client, group))
+	}
+
+	ctx := context.Background()
+	if provider.IngressClassName() != nil {
+		add(checkIngressClass(ctx, client, provider))
+	}
+	if provider.Name() == IngressProviderGateway {
+		add(checkGatewayClass(ctx, client))
+	}
+	add(checkClusterIssuer())
+	add(checkDefaultStorageClass(ctx, client))
+	add(checkNodePorts(ctx, client))
+	return report
+}
+
+// checkAPIGroup checks that the API server serves a resource of an API group
+func checkAPIGroup(client *kubernetes.Client, group preflightAPIGroup) dto.PreflightCheck {
+	check := dto.PreflightCheck{Name: group.name, Status: group.status, Remedy: group.remedy}
+	groupVersion, resource := group.groupVersion, group.resource
+	resources, err := client.Clientset.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		check.Message = fmt.Sprintf("%s is not served: %v", groupVersion, err)
+		return check
+	}
+	for _, served := range resources.APIResources {
+		if served.Name == resource {
+			check.Status = dto.PreflightOK
+			check.Message = fmt.Sprintf("%s/%s is served", groupVersion, resource)
+			return check
+		}
+	}
+	check.Message = fmt.Sprintf("%s does not serve %s", groupVersion, resource)
+	return check
+}
+
+// checkIngressClass checks that the IngressClass the provider sets on Ingresses exists
+func checkIngressClass(ctx context.Context, client *kubernetes.Client, provider IngressProvider) dto.PreflightCheck {
+	class := *provider.IngressClassName()
+	check := dto.PreflightCheck{
+		Name:    "ingress-class",
+		Status:  dto.PreflightOK,
+		Message: fmt.Sprintf("IngressClass %s exists", class),
+	}
+	_, err := client.Clientset.NetworkingV1().IngressClasses().Get(ctx, class, metav1.GetOptions{})
+	if err != nil {
+		check.Status = dto.PreflightFailed
+		check.Message = fmt.Sprintf("IngressClass %s of the %s ingress provider: %v", class, provider.Name(), err)
+		check.Remedy = fmt.Sprintf("Install the %s ingress controller, or set the class it serves in %s_INGRESS_CLASS; no Ingress is served without it.", provider.Name(), strings.ToUpper(provider.Name()))
+	}
+	return check
+}
+
+// checkGatewayClass checks that the GatewayClass of the rendered Gateways exists and was
+// accepted by its controller
+func checkGatewayClass(ctx context.Context, client *kubernetes.Client) dto.PreflightCheck {
+	class := GetGatewayConfig().ClassName
+	check := dto.PreflightCheck{
+		Name:   "gateway-class",
+		Status: dto.PreflightFailed,
+		Remedy: "Install a Gateway API controller (Traefik with its kubernetesGateway provider, Envoy Gateway, ...) and set GATEWAY_CLASS to its GatewayClass.",
+	}
+	gatewayClass, err := client.DynamicClient.Resource(gatewayClassGVR).Get(ctx, class, metav1.GetOptions{})
+	if err != nil {
+		check.Message = fmt.Sprintf("GatewayClass %s: %v", class, err)
+		return check
+	}
+	conditions, _, _ := unstructured.NestedSlice(gatewayClass.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if ok && condition["type"] == "Accepted" && condition["status"] == "True" {
+			check.Status = dto.PreflightOK
+			check.Message = fmt.Sprintf("GatewayClass %s is accepted", class)
+			return check
+		}
+	}
+	check.Status = dto.PreflightWarning
+	check.Message = fmt.Sprintf("GatewayClass %s is not accepted by its controller yet", class)
+	return check
+}
+
+// checkClusterIssuer checks that the ACME ClusterIssuer Ingresses name exists and is ready
+func checkClusterIssuer() dto.PreflightCheck {
+	issuer := GetClusterIssuer()
+	check := dto.PreflightCheck{
+		Name:    "cluster-issuer",
+		Status:  dto.PreflightOK,
+		Message: fmt.Sprintf("ClusterIssuer %s is ready", issuer),
+	}
+	if err := CheckClusterIssuerReady(issuer); err != nil {
+		check.Status = dto.PreflightWarning
+		check.Message = err.Error()
+		check.Remedy = fmt.Sprintf("Create an ACME ClusterIssuer named %s with an HTTP-01 solver, or check its status with kubectl describe clusterissuer %s; until it is ready no hostname gets a certificate.", issuer, issuer)
+	}
+	return check
+}
+
+// checkDefaultStorageClass checks that PVCs without a class, like the registry's and the
+// managed services', get provisioned
+func checkDefaultStorageClass(ctx context.Context, client *kubernetes.Client) dto.PreflightCheck {
+	check := dto.PreflightCheck{
+		Name:   "default-storage-class",
+		Status: dto.PreflightFailed,
+		Remedy: "Install a provisioner (k3s bundles local-path) and mark its StorageClass with the annotation storageclass.kubernetes.io/is-default-class=true; the registry and managed services stay Pending without it.",
+	}
+	classes, err := client.Clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to list StorageClasses: %v", err)
+		return check
+	}
+
+	var defaults []string
+	for _, class := range classes.Items {
+		if class.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" ||
+			class.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			defaults = append(defaults, class.Name)
+		}
+	}
+	switch len(defaults) {
+	case 0:
+		check.Message = fmt.Sprintf("none of the %d StorageClasses is the default", len(classes.Items))
+	case 1:
+		check.Status = dto.PreflightOK
+		check.Message = fmt.Sprintf("%s is the default StorageClass", defaults[0])
+	default:
+		check.Status = dto.PreflightWarning
+		check.Message = fmt.Sprintf("%d StorageClasses are marked default (%s)", len(defaults), strings.Join(defaults, ", "))
+		check.Remedy = "Keep the default annotation on one StorageClass so volumes land where expected."
+	}
+	return check
+}
+
+// checkNodePorts counts the NodePorts in use and lets the API server allocate one in a
+// dry run, which fails when the range is exhausted
+func checkNodePorts(ctx context.Context, client *kubernetes.Client) dto.PreflightCheck {
+	check := dto.PreflightCheck{
+		Name:   "nodeport-range",
+		Status: dto.PreflightFailed,
+		Remedy: "Free NodePorts by deleting unused NodePort/LoadBalancer Services, or widen --service-node-port-range (and SERVICE_NODE_PORT_RANGE to match).",
+	}
+	start, end, err := GetNodePortRange()
+	if err != nil {
+		check.Message = err.Error()
+		check.Remedy = "Set the NodePort range in the platform settings (or SERVICE_NODE_PORT_RANGE) to the API server's --service-node-port-range, e.g. 30000-32767."
+		return check
+	}
+	if actual, err := CheckNodePortRangeWithCluster(ctx, client, models.PortRange{Start: start, End: end}); err != nil && actual.End > 0 {
+		check.Status = dto.PreflightWarning
+		check.Message = err.Error()
+		check.Remedy = fmt.Sprintf("Set the NodePort range in the platform settings to %d-%d; free ports are counted in the wrong range.", actual.Start, actual.End)
+		return check
+	}
+
+	services, err := client.Clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		check.Message = fmt.Sprintf("failed to list Services: %v", err)
+		return check
+	}
+	used := 0
+	for _, service := range services.Items {
+		for _, port := range service.Spec.Ports {
+			if port.NodePort >= int32(start) && port.NodePort <= int32(end) {
+				used++
+			}
+		}
+	}
+	size := end - start + 1
+
+	probe := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "pendeploy-preflight", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: map[string]string{"app": "pendeploy-preflight"},
+			Ports:    []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	_, err = client.Clientset.CoreV1().Services("default").Create(ctx, probe, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		check.Message = fmt.Sprintf("no NodePort could be allocated (%d of %d in use): %v", used, size, err)
+		return check
+	}
+
+	free := size - used
+	check.Status = dto.PreflightOK
+	check.Message = fmt.Sprintf("%d of %d NodePorts (%d-%d) are free", free, size, start, end)
+	if float64(free) < float64(size)*nodePortHeadroom {
+		check.Status = dto.PreflightWarning
+	}
+	return check
+}
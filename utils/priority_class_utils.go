@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxPriorityTierValue is the highest value Kubernetes allows for user-defined PriorityClasses
+const MaxPriorityTierValue = 1000000000
+
+// GetPriorityClassName returns the PriorityClass backing a priority tier; empty for no tier
+func GetPriorityClassName(tier string) string {
+	if tier == "" {
+		return ""
+	}
+	return "pendeploy-" + tier
+}
+
+// ApplyPriorityClass creates or updates the PriorityClass of a tier. Value and preemption
+// policy are immutable, so changing them replaces the class; running pods keep the
+// priority they were admitted with until they are recreated.
+func ApplyPriorityClass(tier models.PriorityTier) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+	classes := k8sClient.Clientset.SchedulingV1().PriorityClasses()
+
+	preemption := corev1.PreemptLowerPriority
+	if !tier.PreemptLowerPriority {
+		preemption = corev1.PreemptNever
+	}
+	class := &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: GetPriorityClassName(tier.Name),
+			Labels: map[string]string{
+				"app":       "pendeploy",
+				"component": "priority-tier",
+			},
+		},
+		Value:            tier.Value,
+		PreemptionPolicy: &preemption,
+		Description:      tier.Description,
+	}
+
+	existing, err := classes.Get(ctx, class.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = classes.Create(ctx, class, metav1.CreateOptions{})
+	case err != nil:
+	case existing.Value != class.Value || existing.PreemptionPolicy == nil || *existing.PreemptionPolicy != preemption:
+		if err = classes.Delete(ctx, class.Name, metav1.DeleteOptions{}); err == nil {
+			_, err = classes.Create(ctx, class, metav1.CreateOptions{})
+		}
+	default:
+		class.ResourceVersion = existing.ResourceVersion
+		_, err = classes.Update(ctx, class, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply PriorityClass %s: %v", class.Name, err)
+	}
+	return nil
+}
+
+// DeletePriorityClass removes the PriorityClass of a tier
+func DeletePriorityClass(tier string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	err = k8sClient.Clientset.SchedulingV1().PriorityClasses().Delete(context.Background(), GetPriorityClassName(tier), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PriorityClass: %v", err)
+	}
+	return nil
+}
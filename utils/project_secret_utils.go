@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// envSecretChecksumAnnotation rolls the pods when a referenced vault value changes
+const envSecretChecksumAnnotation = "pendeploy.io/env-secret-checksum"
+
+var (
+	// ProjectSecretNamePattern is the name of a vault entry, usable in a ${secret:name} reference
+	ProjectSecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+	secretReferencePattern = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// GetProjectVaultSecretName returns the Secret in the build namespace holding a project's vault
+func GetProjectVaultSecretName(projectID string) string {
+	return "vault-" + projectID
+}
+
+// GetServiceEnvSecretName returns the Secret holding a service's interpolated env vars
+func GetServiceEnvSecretName(service models.Service) string {
+	return GetResourceName(service) + "-env"
+}
+
+// HasSecretReferences reports whether an env var value is a template that references the
+// vault or an external secret store
+func HasSecretReferences(value string) bool {
+	return strings.Contains(value, "${secret:") || strings.Contains(value, "${external:")
+}
+
+// InvalidSecretReference returns the first ${secret:...} or ${external:...} reference of a
+// value that is not well-formed, empty when all are
+func InvalidSecretReference(value string) string {
+	rest := secretReferencePattern.ReplaceAllString(value, "")
+	rest = externalReferencePattern.ReplaceAllString(rest, "")
+	start := strings.Index(rest, "${secret:")
+	if external := strings.Index(rest, "${external:"); external >= 0 && (start < 0 || external < start) {
+		start = external
+	}
+	if start < 0 {
+		return ""
+	}
+	if end := strings.Index(rest[start:], "}"); end >= 0 {
+		return rest[start : start+end+1]
+	}
+	return rest[start:]
+}
+
+// InterpolateSecretEnvVars resolves the ${secret:name} references of the templated env vars
+// against the vault values and ${external:...} references against the fetched values, keyed
+// by ExternalSecretReference.String(). Env vars without references are not returned.
+func InterpolateSecretEnvVars(envVars models.EnvVars, secrets map[string]string, external map[string]string) (models.EnvVars, error) {
+	resolved := models.EnvVars{}
+	missing := map[string]bool{}
+	for key, value := range envVars {
+		if !HasSecretReferences(value) {
+			continue
+		}
+		value = secretReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+			name := secretReferencePattern.FindStringSubmatch(reference)[1]
+			secret, ok := secrets[name]
+			if !ok {
+				missing[name] = true
+			}
+			return secret
+		})
+		resolved[key] = externalReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+			match := externalReferencePattern.FindStringSubmatch(reference)
+			name := ExternalSecretReference{Store: match[1], Path: match[3], Key: match[5]}.String()
+			secret, ok := external[name]
+			if !ok {
+				missing["external:"+name] = true
+			}
+			return secret
+		})
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("environment variables have unresolved secret references: %s", strings.Join(names, ", "))
+	}
+	return resolved, nil
+}
+
+func projectVaultLabels(projectID string) map[string]string {
+	return map[string]string{
+		"app":        "pendeploy",
+		"component":  "project-secrets",
+		"project-id": projectID,
+	}
+}
+
+// ApplyProjectSecretValue stores or replaces one value of a project's vault
+func ApplyProjectSecretValue(projectID, name, value string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	namespace := GetJobNamespace()
+	if err := EnsureNamespaceExists(namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %v", err)
+	}
+	secrets := k8sClient.Clientset.CoreV1().Secrets(namespace)
+	vault, err := secrets.Get(ctx, GetProjectVaultSecretName(projectID), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      GetProjectVaultSecretName(projectID),
+				Namespace: namespace,
+				Labels:    projectVaultLabels(projectID),
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{name: []byte(value)},
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		if vault.Data == nil {
+			vault.Data = map[string][]byte{}
+		}
+		vault.Data[name] = []byte(value)
+		_, err = secrets.Update(ctx, vault, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store project secret: %v", err)
+	}
+	return nil
+}
+
+// DeleteProjectSecretValue removes one value from a project's vault
+func DeleteProjectSecretValue(projectID, name string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	secrets := k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace())
+	vault, err := secrets.Get(ctx, GetProjectVaultSecretName(projectID), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read project secrets: %v", err)
+	}
+	if _, ok := vault.Data[name]; !ok {
+		return nil
+	}
+	delete(vault.Data, name)
+	if _, err := secrets.Update(ctx, vault, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to delete project secret: %v", err)
+	}
+	return nil
+}
+
+// ReadProjectSecrets returns the values of a project's vault
+func ReadProjectSecrets(projectID string) (map[string]string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	values := map[string]string{}
+	vault, err := k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace()).Get(context.Background(), GetProjectVaultSecretName(projectID), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project secrets: %v", err)
+	}
+	for name, value := range vault.Data {
+		values[name] = string(value)
+	}
+	return values, nil
+}
+
+// DeleteProjectVault removes a project's vault Secret; the per-service copies go away with
+// the environment namespaces
+func DeleteProjectVault(projectID string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	err = k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace()).Delete(context.Background(), GetProjectVaultSecretName(projectID), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete project secrets: %v", err)
+	}
+	return nil
+}
+
+// ensureServiceEnvSecret writes the interpolated env vars of the service into its env Secret,
+// or removes the Secret when no env var references the vault
+func ensureServiceEnvSecret(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	secrets := client.Clientset.CoreV1().Secrets(service.EnvironmentID)
+	name := GetServiceEnvSecretName(service)
+	if len(service.SecretEnvVars) == 0 {
+		err := secrets.Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete env secret: %v", err)
+		}
+		return nil
+	}
+
+	secret := createServiceEnvSecretSpec(service)
+	setServiceOwner(secret, owner)
+
+	_, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply env secret: %v", err)
+	}
+	return nil
+}
+
+// createServiceEnvSecretSpec builds the env Secret holding the service's SecretEnvVars
+func createServiceEnvSecretSpec(service models.Service) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceEnvSecretName(service),
+			Namespace: service.EnvironmentID,
+			Labels:    GetResourceLabels(service),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: service.SecretEnvVars,
+	}
+}
+
+// serviceEnvVars lists the container env of a git service; templated and secret values are
+// read from the service's env Secret so no secret appears in the Deployment
+func serviceEnvVars(service models.Service) []corev1.EnvVar {
+	env := createEnvVarsFromMap(service.EnvVars)
+	for i := range env {
+		if !HasSecretReferences(env[i].Value) && !service.IsSecretEnvVar(env[i].Name) {
+			continue
+		}
+		env[i].Value = ""
+		env[i].ValueFrom = &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: GetServiceEnvSecretName(service)},
+				Key:                  env[i].Name,
+			},
+		}
+	}
+	if port, ok := portEnvVar(service); ok {
+		env = append(env, port)
+	}
+	return env
+}
+
+// applyEnvSecretChecksum annotates the pod template with a digest of the interpolated values
+func applyEnvSecretChecksum(template *corev1.PodTemplateSpec, service models.Service) {
+	if len(service.SecretEnvVars) == 0 {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[envSecretChecksumAnnotation] = envSecretChecksum(service)
+}
+
+// envSecretChecksum digests the interpolated env vars of a service
+func envSecretChecksum(service models.Service) string {
+	keys := make([]string, 0, len(service.SecretEnvVars))
+	for key := range service.SecretEnvVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", key, service.SecretEnvVars[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SyftImage generates SBOMs straight from the registry, without running the image
+	SyftImage = "anchore/syft:v1.18.1"
+	// CosignImage signs pushed images with the platform key
+	CosignImage = "gcr.io/projectsigstore/cosign:v2.4.1"
+	// SBOMFormat is the syft output format stored for every deployment
+	SBOMFormat = "cyclonedx-json"
+
+	provenanceTimeout = 10 * time.Minute
+	// SBOMs of large images run to a few megabytes
+	sbomMaxBytes = 32 << 20
+)
+
+// ProvenanceConfig selects the post-build provenance steps
+type ProvenanceConfig struct {
+	SBOMEnabled bool   // BUILD_SBOM_ENABLED
+	CosignKey   string // COSIGN_KEY_SECRET: Secret in the build namespace with cosign.key and cosign.password
+}
+
+// LoadProvenanceConfig reads the provenance configuration from the environment
+func LoadProvenanceConfig() ProvenanceConfig {
+	return ProvenanceConfig{
+		SBOMEnabled: strings.EqualFold(strings.TrimSpace(os.Getenv("BUILD_SBOM_ENABLED")), "true"),
+		CosignKey:   strings.TrimSpace(os.Getenv("COSIGN_KEY_SECRET")),
+	}
+}
+
+// Enabled reports whether any provenance step runs after builds
+func (c ProvenanceConfig) Enabled() bool {
+	return c.SBOMEnabled || c.CosignKey != ""
+}
+
+// ProvenanceResult is the outcome of the provenance steps of one build
+type ProvenanceResult struct {
+	SBOM      string // empty when not generated
+	Signed    bool
+	SBOMError string
+	SignError string
+}
+
+// RunProvenanceJob generates the SBOM of a pushed image with syft and signs it with cosign,
+// as two independent containers of one job so a failure of one keeps the other's result
+func RunProvenanceJob(deployment models.Deployment, service models.Service, image string, insecureRegistry bool) (ProvenanceResult, error) {
+	var result ProvenanceResult
+	config := LoadProvenanceConfig()
+	if !config.Enabled() {
+		return result, nil
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return result, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	namespace := GetJobNamespace()
+	jobName := GetJobName(service.ID, deployment.ID) + "-provenance"
+
+	// Sign the immutable digest when the build recorded it, so a re-pushed tag is not covered
+	ref := ImageByDigest(image, deployment.ImageDigest)
+
+	job := createProvenanceJob(jobName, namespace, deployment, service, ref, insecureRegistry, config)
+	_ = cleanupExistingJob(k8sClient, jobName, namespace)
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(namespace).Create(context.Background(), job, metav1.CreateOptions{}); err != nil {
+		return result, fmt.Errorf("failed to create provenance job: %v", err)
+	}
+
+	if jobErr := waitForJobCompletion(k8sClient, jobName, namespace, provenanceTimeout); jobErr != nil {
+		log.Printf("Provenance job %s failed: %v", jobName, jobErr)
+	}
+
+	exitCodes := containerExitCodes(k8sClient, jobName, namespace)
+	if config.SBOMEnabled {
+		output := readJobContainerLogsLimited(k8sClient, jobName, namespace, "sbom", sbomMaxBytes)
+		if code, ok := exitCodes["sbom"]; ok && code == 0 && strings.HasPrefix(strings.TrimSpace(output), "{") {
+			result.SBOM = output
+		} else {
+			result.SBOMError = "SBOM generation failed: " + lastLine(output)
+		}
+	}
+	if config.CosignKey != "" {
+		if code, ok := exitCodes["sign"]; ok && code == 0 {
+			result.Signed = true
+		} else {
+			result.SignError = "image signing failed: " + lastLine(readJobContainerLogs(k8sClient, jobName, namespace, "sign"))
+		}
+	}
+	return result, nil
+}
+
+// createProvenanceJob builds the job running syft and/or cosign against the pushed image
+func createProvenanceJob(jobName, namespace string, deployment models.Deployment, service models.Service, ref string, insecureRegistry bool, config ProvenanceConfig) *batchv1.Job {
+	labels := map[string]string{
+		"app":              "pendeploy",
+		"component":        "provenance",
+		"service-id":       service.ID,
+		"deployment-id":    deployment.ID,
+		LabelServiceID:     service.ID,
+		LabelEnvironmentID: service.EnvironmentID,
+	}
+
+	var containers []corev1.Container
+	if config.SBOMEnabled {
+		// -q keeps progress output off stdout, which carries only the document
+		containers = append(containers, corev1.Container{
+			Name:  "sbom",
+			Image: SyftImage,
+			Args:  []string{"scan", "registry:" + ref, "-o", SBOMFormat, "-q"},
+			Env: []corev1.EnvVar{
+				{Name: "SYFT_REGISTRY_INSECURE_USE_HTTP", Value: fmt.Sprint(insecureRegistry)},
+				{Name: "SYFT_REGISTRY_INSECURE_SKIP_TLS_VERIFY", Value: fmt.Sprint(insecureRegistry)},
+				{Name: "SYFT_CHECK_FOR_APP_UPDATE", Value: "false"},
+			},
+		})
+	}
+	if config.CosignKey != "" {
+		args := []string{"sign", "--yes", "--tlog-upload=false", "--key", "env://COSIGN_PRIVATE_KEY"}
+		if insecureRegistry {
+			args = append(args, "--allow-insecure-registry", "--allow-http-registry")
+		}
+		keyEnv := func(name, key string) corev1.EnvVar {
+			return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: config.CosignKey},
+					Key:                  key,
+				},
+			}}
+		}
+		containers = append(containers, corev1.Container{
+			Name:  "sign",
+			Image: CosignImage,
+			Args:  append(args, ref),
+			Env: []corev1.EnvVar{
+				keyEnv("COSIGN_PRIVATE_KEY", "cosign.key"),
+				keyEnv("COSIGN_PASSWORD", "cosign.password"),
+			},
+		})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(600),
+			ActiveDeadlineSeconds:   int64Ptr(int64(provenanceTimeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    containers,
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	return job
+}
+
+// containerExitCodes returns the exit code of each terminated container of a job's pod
+func containerExitCodes(k8sClient *kubernetes.Client, jobName, namespace string) map[string]int32 {
+	codes := map[string]int32{}
+	pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return codes
+	}
+	for _, status := range pods.Items[0].Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			codes[status.Name] = status.State.Terminated.ExitCode
+		}
+	}
+	return codes
+}
+
+// imageRepository strips the tag from an image reference (registry ports are kept)
+func imageRepository(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		return image[:colon]
+	}
+	return image
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetPullSecretName returns the name of a pull credential's Secret in environment namespaces
+func GetPullSecretName(credentialName string) string {
+	return "pull-" + credentialName
+}
+
+// pullSourceSecretName returns the Secret in the build namespace that environment copies
+// are made from; the project prefix keeps equally named credentials of projects apart
+func pullSourceSecretName(projectID, secretName string) string {
+	return projectID + "-" + secretName
+}
+
+func pullSecretLabels(projectID string) map[string]string {
+	return map[string]string{
+		"app":        "pendeploy",
+		"component":  "pull-credential",
+		"project-id": projectID,
+	}
+}
+
+// dockerConfigJSON renders the .dockerconfigjson document for a single registry login
+func dockerConfigJSON(server, username, password string) ([]byte, error) {
+	type authEntry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	return json.Marshal(map[string]map[string]authEntry{
+		"auths": {
+			server: {
+				Username: username,
+				Password: password,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	})
+}
+
+// ApplyPullCredentialSecret stores the credential as a dockerconfigjson Secret in the build
+// namespace and refreshes the copies already present in the given environment namespaces.
+// Namespaces without a copy get one on the next deploy of a service there.
+func ApplyPullCredentialSecret(credential models.PullCredential, password string, namespaces []string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	data, err := dockerConfigJSON(credential.Server, credential.Username, password)
+	if err != nil {
+		return fmt.Errorf("failed to encode registry credentials: %v", err)
+	}
+
+	namespace := GetJobNamespace()
+	if err := EnsureNamespaceExists(namespace); err != nil {
+		return fmt.Errorf("failed to ensure namespace: %v", err)
+	}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pullSourceSecretName(credential.ProjectID, credential.SecretName),
+			Namespace: namespace,
+			Labels:    pullSecretLabels(credential.ProjectID),
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: data},
+	}
+	secrets := k8sClient.Clientset.CoreV1().Secrets(namespace)
+	_, err = secrets.Create(ctx, source, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(ctx, source, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store pull secret: %v", err)
+	}
+
+	for _, target := range namespaces {
+		copied := pullSecretCopy(source, credential.SecretName, target)
+		_, err := k8sClient.Clientset.CoreV1().Secrets(target).Update(ctx, copied, metav1.UpdateOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to update pull secret in namespace %s: %v", target, err)
+		}
+	}
+	return nil
+}
+
+// DeletePullCredentialSecrets removes a credential's Secret from the build namespace and
+// from the given environment namespaces
+func DeletePullCredentialSecrets(credential models.PullCredential, namespaces []string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	targets := map[string]string{GetJobNamespace(): pullSourceSecretName(credential.ProjectID, credential.SecretName)}
+	for _, namespace := range namespaces {
+		targets[namespace] = credential.SecretName
+	}
+	for namespace, name := range targets {
+		err := k8sClient.Clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pull secret in namespace %s: %v", namespace, err)
+		}
+	}
+	return nil
+}
+
+// DeleteProjectPullSecrets removes the build namespace Secrets of all credentials of a
+// project; the copies go away with the environment namespaces
+func DeleteProjectPullSecrets(projectID string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	err = k8sClient.Clientset.CoreV1().Secrets(GetJobNamespace()).DeleteCollection(
+		context.Background(),
+		metav1.DeleteOptions{},
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("component=pull-credential,project-id=%s", projectID)},
+	)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pull secrets: %v", err)
+	}
+	return nil
+}
+
+// ensurePullSecrets copies the service's pull Secrets from the build namespace into its
+// namespace. The copies are shared by all services of the environment, so they carry no owner.
+func ensurePullSecrets(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	for _, name := range service.ImagePullSecrets {
+		source, err := client.Clientset.CoreV1().Secrets(GetJobNamespace()).Get(ctx, pullSourceSecretName(service.ProjectID, name), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to read pull secret %s: %v", name, err)
+		}
+
+		copied := pullSecretCopy(source, name, service.EnvironmentID)
+		secrets := client.Clientset.CoreV1().Secrets(service.EnvironmentID)
+		_, err = secrets.Create(ctx, copied, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			_, err = secrets.Update(ctx, copied, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply pull secret %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func pullSecretCopy(source *corev1.Secret, name, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    source.Labels,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: source.Data,
+	}
+}
+
+// imagePullSecretRefs lists the service's pull Secrets for a pod spec
+func imagePullSecretRefs(service models.Service) []corev1.LocalObjectReference {
+	if len(service.ImagePullSecrets) == 0 {
+		return nil
+	}
+	refs := make([]corev1.LocalObjectReference, 0, len(service.ImagePullSecrets))
+	for _, name := range service.ImagePullSecrets {
+		refs = append(refs, corev1.LocalObjectReference{Name: name})
+	}
+	return refs
+}
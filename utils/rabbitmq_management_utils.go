@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rabbitMQNamePattern restricts vhost and user names to values that are safe in
+// URLs, Secret names and AMQP connection strings
+var rabbitMQNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]{0,48}[a-z0-9])?$`)
+
+// IsValidRabbitMQName checks vhost/user names
+func IsValidRabbitMQName(name string) bool {
+	return rabbitMQNamePattern.MatchString(name)
+}
+
+// RabbitMQManagementClient talks to the management HTTP API of a managed RabbitMQ
+// using the root credentials generated at deploy time
+type RabbitMQManagementClient struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewRabbitMQManagementClient creates a client for the service's in-cluster management endpoint
+func NewRabbitMQManagementClient(service models.Service) *RabbitMQManagementClient {
+	return &RabbitMQManagementClient{
+		baseURL:  fmt.Sprintf("http://%s-management.%s.svc.cluster.local:15672/api", GetResourceName(service), service.EnvironmentID),
+		username: service.EnvVars["RABBITMQ_DEFAULT_USER"],
+		password: service.EnvVars["RABBITMQ_DEFAULT_PASS"],
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ListVhosts returns all vhosts on the broker
+func (c *RabbitMQManagementClient) ListVhosts() ([]dto.RabbitMQVhostResponse, error) {
+	var vhosts []dto.RabbitMQVhostResponse
+	if err := c.do(http.MethodGet, "/vhosts", nil, &vhosts); err != nil {
+		return nil, err
+	}
+	return vhosts, nil
+}
+
+// CreateVhost creates a vhost (idempotent)
+func (c *RabbitMQManagementClient) CreateVhost(name string) error {
+	return c.do(http.MethodPut, "/vhosts/"+url.PathEscape(name), map[string]interface{}{}, nil)
+}
+
+// DeleteVhost deletes a vhost together with its queues and exchanges
+func (c *RabbitMQManagementClient) DeleteVhost(name string) error {
+	return c.do(http.MethodDelete, "/vhosts/"+url.PathEscape(name), nil, nil)
+}
+
+// PutUser creates or updates a user with no management tags
+func (c *RabbitMQManagementClient) PutUser(username, password string) error {
+	return c.do(http.MethodPut, "/users/"+url.PathEscape(username), map[string]interface{}{
+		"password": password,
+		"tags":     "",
+	}, nil)
+}
+
+// DeleteUser deletes a user
+func (c *RabbitMQManagementClient) DeleteUser(username string) error {
+	return c.do(http.MethodDelete, "/users/"+url.PathEscape(username), nil, nil)
+}
+
+// SetPermissions grants the user configure/write/read permissions on a vhost
+func (c *RabbitMQManagementClient) SetPermissions(vhost, username, configure, write, read string) error {
+	path := fmt.Sprintf("/permissions/%s/%s", url.PathEscape(vhost), url.PathEscape(username))
+	return c.do(http.MethodPut, path, map[string]interface{}{
+		"configure": configure,
+		"write":     write,
+		"read":      read,
+	}, nil)
+}
+
+// do sends a request to the management API and decodes the JSON response into out
+func (c *RabbitMQManagementClient) do(method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach RabbitMQ management API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("RabbitMQ management API returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetRabbitMQUserSecretName returns the Secret holding a provisioned user's credentials
+func GetRabbitMQUserSecretName(service models.Service, username string) string {
+	return fmt.Sprintf("%s-rmq-%s", GetResourceName(service), username)
+}
+
+// BuildRabbitMQUserResponse derives per-vhost connection strings for a user
+func BuildRabbitMQUserResponse(service models.Service, username, password, vhost string) dto.RabbitMQUserResponse {
+	internalHost := fmt.Sprintf("%s.%s.svc.cluster.local", GetResourceName(service), service.EnvironmentID)
+
+	// Generated passwords may contain '/' and '+', so let net/url do the escaping
+	buildURL := func(host string, port int) string {
+		amqpURL := url.URL{
+			Scheme:  "amqp",
+			User:    url.UserPassword(username, password),
+			Host:    fmt.Sprintf("%s:%d", host, port),
+			Path:    "/" + vhost,
+			RawPath: "/" + url.PathEscape(vhost),
+		}
+		return amqpURL.String()
+	}
+
+	return dto.RabbitMQUserResponse{
+		Username:    username,
+		Password:    password,
+		Vhost:       vhost,
+		SecretName:  GetRabbitMQUserSecretName(service, username),
+		URL:         buildURL(internalHost, service.Port),
+		ExternalURL: buildURL(service.ExternalHost, service.ExternalPort),
+	}
+}
+
+// SaveRabbitMQUserSecret stores a provisioned user's credentials and connection strings
+func SaveRabbitMQUserSecret(service models.Service, user dto.RabbitMQUserResponse) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	labels := GetResourceLabels(service)
+	labels["component"] = "rabbitmq-user"
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.SecretName,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"username":     user.Username,
+			"password":     user.Password,
+			"vhost":        user.Vhost,
+			"url":          user.URL,
+			"external-url": user.ExternalURL,
+		},
+	}
+
+	ctx := context.Background()
+	owner, err := ensureServiceOwner(ctx, k8sClient, service)
+	if err != nil {
+		return err
+	}
+	setServiceOwner(secret, owner)
+
+	_, err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save credentials secret: %v", err)
+	}
+	return nil
+}
+
+// ListRabbitMQUserSecrets returns all users provisioned through the API for a service
+func ListRabbitMQUserSecrets(service models.Service) ([]dto.RabbitMQUserResponse, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	secrets, err := k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("service-id=%s,component=rabbitmq-user", service.ID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials secrets: %v", err)
+	}
+
+	users := make([]dto.RabbitMQUserResponse, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		users = append(users, dto.RabbitMQUserResponse{
+			Username:    string(secret.Data["username"]),
+			Password:    string(secret.Data["password"]),
+			Vhost:       string(secret.Data["vhost"]),
+			SecretName:  secret.Name,
+			URL:         string(secret.Data["url"]),
+			ExternalURL: string(secret.Data["external-url"]),
+		})
+	}
+	return users, nil
+}
+
+// DeleteRabbitMQUserSecret removes a provisioned user's credentials secret
+func DeleteRabbitMQUserSecret(service models.Service, username string) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	name := GetRabbitMQUserSecretName(service, username)
+	err = k8sClient.Clientset.CoreV1().Secrets(service.EnvironmentID).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete credentials secret: %v", err)
+	}
+	if err == nil {
+		log.Printf("Secret %s deleted successfully", name)
+	}
+	return nil
+}
+
+// deleteRabbitMQUserSecrets removes every provisioned user's Secret when the service is deleted
+func deleteRabbitMQUserSecrets(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	return client.Clientset.CoreV1().Secrets(service.EnvironmentID).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("service-id=%s,component=rabbitmq-user", service.ID),
+	})
+}
@@ -0,0 +1,389 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// TopologySentinel runs Redis as a primary and a replica monitored by Sentinel, which
+// promotes the replica when the primary fails
+const TopologySentinel = "sentinel"
+
+const (
+	// RedisSentinelMasterName is the name Sentinel monitors the primary under
+	RedisSentinelMasterName = "mymaster"
+	// RedisSentinelPort is the port Sentinel listens on
+	RedisSentinelPort = 26379
+	// RedisSentinelDataReplicas is the number of Redis servers: the primary and a replica
+	RedisSentinelDataReplicas = 2
+
+	redisSentinelReplicas = 3
+	redisSentinelQuorum   = 2
+	redisRouterReplicas   = 2
+)
+
+// IsRedisSentinel reports whether the service runs Redis with Sentinel
+func IsRedisSentinel(service models.Service) bool {
+	return service.ManagedType == "redis" && service.Topology == TopologySentinel
+}
+
+// GetRedisHeadlessServiceName returns the headless Service giving each Redis pod a stable name
+func GetRedisHeadlessServiceName(service models.Service) string {
+	return fmt.Sprintf("%s-headless", GetResourceName(service))
+}
+
+// GetRedisSentinelName returns the name of the Sentinel Deployment and Service
+func GetRedisSentinelName(service models.Service) string {
+	return fmt.Sprintf("%s-sentinel", GetResourceName(service))
+}
+
+// GetRedisSentinelHost returns the in-cluster address of the Sentinel Service
+func GetRedisSentinelHost(service models.Service) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", GetRedisSentinelName(service), service.EnvironmentID)
+}
+
+// getRedisRouterName returns the name of the proxy that routes the service's primary
+// address to whichever pod is currently the primary
+func getRedisRouterName(service models.Service) string {
+	return fmt.Sprintf("%s-router", GetResourceName(service))
+}
+
+// getRedisPodHost returns the stable address of the Redis pod with the given ordinal
+func getRedisPodHost(service models.Service, ordinal int) string {
+	return fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local", GetResourceName(service), ordinal, GetRedisHeadlessServiceName(service), service.EnvironmentID)
+}
+
+// getRedisComponentLabels returns the service's labels for a Sentinel or router pod; app
+// differs so the pods are not selected as Redis servers
+func getRedisComponentLabels(service models.Service, name string) map[string]string {
+	labels := GetResourceLabels(service)
+	labels["app"] = name
+	return labels
+}
+
+// getRedisMasterLookup returns shell that sets MASTER to the primary Sentinel currently
+// reports, falling back to the first pod when no Sentinel answers yet
+func getRedisMasterLookup(service models.Service) string {
+	return fmt.Sprintf(`MASTER=$(timeout 5 redis-cli -h %s -p %d sentinel get-master-addr-by-name %s 2>/dev/null | head -n 1)
+[ -z "$MASTER" ] && MASTER=%s`, GetRedisSentinelHost(service), RedisSentinelPort, RedisSentinelMasterName, getRedisPodHost(service, 0))
+}
+
+// applyRedisSentinel turns the Redis StatefulSet into a primary and a replica. Each pod
+// asks Sentinel for the current primary on start, so a restarted former primary rejoins
+// as a replica instead of splitting the data.
+func applyRedisSentinel(statefulSet *appsv1.StatefulSet, service models.Service) {
+	if !IsRedisSentinel(service) {
+		return
+	}
+
+	replicas := int32(RedisSentinelDataReplicas)
+	statefulSet.Spec.Replicas = &replicas
+	statefulSet.Spec.ServiceName = GetRedisHeadlessServiceName(service)
+
+	self := fmt.Sprintf("$(hostname).%s.%s.svc.cluster.local", GetRedisHeadlessServiceName(service), service.EnvironmentID)
+	script := fmt.Sprintf(`SELF=%s
+%s
+if [ "$MASTER" = "$SELF" ]; then
+  exec docker-entrypoint.sh redis-server --appendonly yes --replica-announce-ip "$SELF"
+fi
+exec docker-entrypoint.sh redis-server --appendonly yes --replica-announce-ip "$SELF" --replicaof "$MASTER" 6379`, self, getRedisMasterLookup(service))
+
+	container := &statefulSet.Spec.Template.Spec.Containers[0]
+	container.Command = []string{"sh", "-c"}
+	container.Args = []string{script}
+	statefulSet.Spec.Template.Spec.Affinity = preferSpreadAcrossNodes(GetResourceName(service))
+}
+
+// preferSpreadAcrossNodes asks the scheduler to keep pods with the given app label apart
+func preferSpreadAcrossNodes(app string) *corev1.Affinity {
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+						TopologyKey:   "kubernetes.io/hostname",
+					},
+				},
+			},
+		},
+	}
+}
+
+// deployRedisSentinel deploys what a Sentinel topology needs next to the Redis StatefulSet:
+// the headless Service, the Sentinels and the router behind the service's primary address
+func deployRedisSentinel(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	headless := createRedisHeadlessServiceSpec(service)
+	setServiceOwner(headless, owner)
+	if err := applyManagedService(ctx, client, headless); err != nil {
+		return fmt.Errorf("headless service: %v", err)
+	}
+
+	sentinelService := createRedisComponentServiceSpec(service, GetRedisSentinelName(service), RedisSentinelPort)
+	setServiceOwner(sentinelService, owner)
+	if err := applyManagedService(ctx, client, sentinelService); err != nil {
+		return fmt.Errorf("sentinel service: %v", err)
+	}
+	sentinel := createRedisSentinelDeploymentSpec(service)
+	setServiceOwner(sentinel, owner)
+	if err := applyManagedDeployment(ctx, client, sentinel); err != nil {
+		return fmt.Errorf("sentinel: %v", err)
+	}
+
+	routerConfig := createRedisRouterConfigMap(service)
+	setServiceOwner(routerConfig, owner)
+	if err := applyRedisRouterConfigMap(ctx, client, routerConfig); err != nil {
+		return fmt.Errorf("router config: %v", err)
+	}
+	router := createRedisRouterDeploymentSpec(service)
+	setServiceOwner(router, owner)
+	if err := applyManagedDeployment(ctx, client, router); err != nil {
+		return fmt.Errorf("router: %v", err)
+	}
+	return nil
+}
+
+// createRedisHeadlessServiceSpec gives the Redis pods the stable names replication and
+// Sentinel refer to them by
+func createRedisHeadlessServiceSpec(service models.Service) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetRedisHeadlessServiceName(service),
+			Namespace: service.EnvironmentID,
+			Labels:    GetResourceLabels(service),
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:                corev1.ClusterIPNone,
+			Selector:                 map[string]string{"app": GetResourceName(service)},
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{Name: "redis", Port: 6379, TargetPort: intstr.FromInt(6379), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+}
+
+// createRedisComponentServiceSpec exposes the Sentinel or router pods in the cluster
+func createRedisComponentServiceSpec(service models.Service, name string, port int) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    getRedisComponentLabels(service, name),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": name},
+			Ports: []corev1.ServicePort{
+				{Port: int32(port), TargetPort: intstr.FromInt(port), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+}
+
+// createRedisSentinelDeploymentSpec runs the Sentinels that monitor the primary and promote
+// the replica once a quorum agrees the primary is down
+func createRedisSentinelDeploymentSpec(service models.Service) *appsv1.Deployment {
+	name := GetRedisSentinelName(service)
+	labels := getRedisComponentLabels(service, name)
+	replicas := int32(redisSentinelReplicas)
+
+	// Sentinel rewrites its config file, so it is generated into a writable location
+	script := fmt.Sprintf(`%s
+cat > /tmp/sentinel.conf <<EOF
+port %d
+sentinel resolve-hostnames yes
+sentinel announce-hostnames yes
+sentinel monitor %s $MASTER 6379 %d
+sentinel down-after-milliseconds %s 5000
+sentinel failover-timeout %s 60000
+sentinel parallel-syncs %s 1
+EOF
+exec redis-server /tmp/sentinel.conf --sentinel`,
+		getRedisMasterLookup(service), RedisSentinelPort,
+		RedisSentinelMasterName, redisSentinelQuorum,
+		RedisSentinelMasterName, RedisSentinelMasterName, RedisSentinelMasterName)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			RevisionHistoryLimit: int32Ptr(1),
+			Replicas:             &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: GetServiceAccountName(service),
+					ImagePullSecrets:   imagePullSecretRefs(service),
+					PriorityClassName:  service.PriorityClassName,
+					Affinity:           preferSpreadAcrossNodes(name),
+					Containers: []corev1.Container{
+						{
+							Name:    "sentinel",
+							Image:   MirrorImage(getManagedServiceImage(service.ManagedType, service.Version)),
+							Command: []string{"sh", "-c"},
+							Args:    []string{script},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: RedisSentinelPort, Protocol: corev1.ProtocolTCP, Name: "sentinel"},
+							},
+							Resources: redisComponentResources(),
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: []string{"sh", "-c", fmt.Sprintf("redis-cli -p %d ping | grep -q PONG", RedisSentinelPort)}},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyPodMetadata(&deployment.Spec.Template, service)
+	SecurePodSpec(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+// createRedisRouterConfigMap configures HAProxy to forward to the pod that reports itself
+// as the primary, so the service's address and the TCP proxy always reach a writable Redis
+func createRedisRouterConfigMap(service models.Service) *corev1.ConfigMap {
+	var b strings.Builder
+	b.WriteString(`global
+  log stdout format raw local0
+
+defaults
+  log global
+  mode tcp
+  timeout connect 5s
+  timeout client 1h
+  timeout server 1h
+
+resolvers cluster
+  parse-resolv-conf
+  hold valid 5s
+
+listen redis
+  bind *:6379
+  option tcp-check
+  tcp-check connect
+  tcp-check send PING\r\n
+  tcp-check expect string +PONG
+  tcp-check send info\ replication\r\n
+  tcp-check expect string role:master
+  tcp-check send QUIT\r\n
+  tcp-check expect string +OK
+`)
+	for ordinal := 0; ordinal < RedisSentinelDataReplicas; ordinal++ {
+		fmt.Fprintf(&b, "  server redis-%d %s:6379 check inter 1s resolvers cluster init-addr none\n", ordinal, getRedisPodHost(service, ordinal))
+	}
+
+	name := getRedisRouterName(service)
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    getRedisComponentLabels(service, name),
+		},
+		Data: map[string]string{"haproxy.cfg": b.String()},
+	}
+}
+
+// createRedisRouterDeploymentSpec runs the HAProxy instances behind the service's address
+func createRedisRouterDeploymentSpec(service models.Service) *appsv1.Deployment {
+	name := getRedisRouterName(service)
+	labels := getRedisComponentLabels(service, name)
+	replicas := int32(redisRouterReplicas)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: service.EnvironmentID,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			RevisionHistoryLimit: int32Ptr(1),
+			Replicas:             &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: GetServiceAccountName(service),
+					ImagePullSecrets:   imagePullSecretRefs(service),
+					PriorityClassName:  service.PriorityClassName,
+					Affinity:           preferSpreadAcrossNodes(name),
+					Containers: []corev1.Container{
+						{
+							Name:  "haproxy",
+							Image: MirrorImage("haproxy:2.9-alpine"),
+							Args:  []string{"-f", "/usr/local/etc/haproxy/haproxy.cfg"},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 6379, Protocol: corev1.ProtocolTCP, Name: "redis"},
+							},
+							Resources: redisComponentResources(),
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: "/usr/local/etc/haproxy", ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: name},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyPodMetadata(&deployment.Spec.Template, service)
+	SecurePodSpec(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+// redisComponentResources sizes the Sentinel and router containers, which need little
+func redisComponentResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("25m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+	}
+}
+
+func applyRedisRouterConfigMap(ctx context.Context, client *kubernetes.Client, configMap *corev1.ConfigMap) error {
+	_, err := client.Clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(ctx, configMap, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = client.Clientset.CoreV1().ConfigMaps(configMap.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	return err
+}
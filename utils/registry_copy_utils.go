@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	repositoryNamePattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTagPattern       = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	imageDigestPattern    = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Manifest media types accepted when copying; lists and indexes are copied with every
+// platform manifest they reference
+var registryManifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// registryManifest is the subset of an image manifest or index needed to walk its content
+type registryManifest struct {
+	MediaType string `json:"mediaType"`
+	Config    *struct {
+		Digest string `json:"digest"`
+		Size   int64  `json:"size"`
+	} `json:"config"`
+	Layers []struct {
+		Digest string `json:"digest"`
+		Size   int64  `json:"size"`
+	} `json:"layers"`
+	Manifests []struct {
+		Digest string `json:"digest"`
+	} `json:"manifests"`
+}
+
+// registryBlob is a blob of an image to copy
+type registryBlob struct {
+	digest string
+	size   int64
+}
+
+// registryCopier copies images between two registries through the Kubernetes service proxy
+type registryCopier struct {
+	source     *dto.RegistryAPI
+	target     *dto.RegistryAPI
+	progress   func(dto.RegistryCopyProgress)
+	result     dto.RegistryCopyResult
+	totalBlobs int
+}
+
+// CopyRegistryImage copies an image with all its manifests and blobs from one registry to
+// another over the registry HTTP API. Every blob and manifest is verified against its
+// digest while being transferred, and blobs already present in the target are skipped.
+func CopyRegistryImage(ctx context.Context, source, target *dto.RegistryAPI, req dto.RegistryCopyRequest, progress func(dto.RegistryCopyProgress)) (dto.RegistryCopyResult, error) {
+	copier := &registryCopier{
+		source:   source,
+		target:   target,
+		progress: progress,
+		result: dto.RegistryCopyResult{
+			Source: req.Repository + registryReferenceSeparator(req.Tag) + req.Tag,
+			Target: req.TargetRepository + registryReferenceSeparator(req.TargetTag) + req.TargetTag,
+		},
+	}
+
+	digest, err := copier.copyManifest(ctx, req.Repository, req.Tag, req.TargetRepository, req.TargetTag)
+	if err != nil {
+		return copier.result, err
+	}
+	copier.result.Digest = digest
+	return copier.result, nil
+}
+
+// copyManifest copies the blobs or child manifests a manifest references, then the
+// manifest itself, and returns its verified digest
+func (c *registryCopier) copyManifest(ctx context.Context, repository, reference, targetRepository, targetReference string) (string, error) {
+	body, mediaType, digest, err := c.fetchManifest(ctx, repository, reference)
+	if err != nil {
+		return "", err
+	}
+
+	var manifest registryManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse manifest %s: %v", reference, err)
+	}
+
+	if len(manifest.Manifests) > 0 {
+		for _, child := range manifest.Manifests {
+			if _, err := c.copyManifest(ctx, repository, child.Digest, targetRepository, child.Digest); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		var blobs []registryBlob
+		if manifest.Config != nil && manifest.Config.Digest != "" {
+			blobs = append(blobs, registryBlob{manifest.Config.Digest, manifest.Config.Size})
+		}
+		for _, layer := range manifest.Layers {
+			blobs = append(blobs, registryBlob{layer.Digest, layer.Size})
+		}
+		c.totalBlobs += len(blobs)
+		for _, blob := range blobs {
+			if err := c.copyBlob(ctx, repository, targetRepository, blob); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := c.pushManifest(ctx, targetRepository, targetReference, mediaType, body, digest); err != nil {
+		return "", err
+	}
+	c.report(dto.RegistryCopyProgress{Stage: "manifest", Digest: digest, Size: int64(len(body))})
+	return digest, nil
+}
+
+// fetchManifest downloads a manifest and verifies it against its digest
+func (c *registryCopier) fetchManifest(ctx context.Context, repository, reference string) ([]byte, string, string, error) {
+	resp, err := registryProxyDo(ctx, c.source, http.MethodGet, fmt.Sprintf("v2/%s/manifests/%s", repository, reference), nil,
+		map[string]string{"Accept": strings.Join(registryManifestMediaTypes, ", ")})
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", fmt.Errorf("manifest %s:%s not found in source registry (status %d)", repository, reference, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read manifest: %v", err)
+	}
+	digest := sha256Digest(body)
+	if expected := resp.Header.Get("Docker-Content-Digest"); expected != "" && expected != digest {
+		return nil, "", "", fmt.Errorf("manifest checksum mismatch: registry reported %s, content is %s", expected, digest)
+	}
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", "", fmt.Errorf("manifest checksum mismatch: requested %s, content is %s", reference, digest)
+	}
+
+	mediaType := resp.Header.Get("Content-Type")
+	if idx := strings.Index(mediaType, ";"); idx >= 0 {
+		mediaType = mediaType[:idx]
+	}
+	return body, mediaType, digest, nil
+}
+
+// copyBlob streams a blob from the source to the target, hashing it on the way. The
+// upload is completed with the expected digest, which the target registry verifies too.
+func (c *registryCopier) copyBlob(ctx context.Context, repository, targetRepository string, blob registryBlob) error {
+	exists, err := c.blobExists(ctx, targetRepository, blob.digest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		c.result.BlobsSkipped++
+		c.report(dto.RegistryCopyProgress{Stage: "blob", Digest: blob.digest, Size: blob.size, Skipped: true})
+		return nil
+	}
+
+	source, err := registryProxyDo(ctx, c.source, http.MethodGet, fmt.Sprintf("v2/%s/blobs/%s", repository, blob.digest), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer source.Body.Close()
+	if source.StatusCode != http.StatusOK {
+		return fmt.Errorf("blob %s not found in source registry (status %d)", blob.digest, source.StatusCode)
+	}
+
+	start, err := registryProxyDo(ctx, c.target, http.MethodPost, fmt.Sprintf("v2/%s/blobs/uploads/", targetRepository), nil, nil)
+	if err != nil {
+		return err
+	}
+	start.Body.Close()
+	if start.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("target registry refused upload of %s (status %d)", blob.digest, start.StatusCode)
+	}
+	uploadPath, uploadQuery, err := registryUploadLocation(start.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	uploadQuery.Set("digest", blob.digest)
+
+	hasher := sha256.New()
+	body := io.TeeReader(source.Body, hasher)
+	resp, err := registryProxyDoQuery(ctx, c.target, http.MethodPut, uploadPath, uploadQuery, body,
+		map[string]string{"Content-Type": "application/octet-stream"}, source.ContentLength)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	actual := "sha256:" + hex.EncodeToString(hasher.Sum(nil))
+	if actual != blob.digest {
+		return fmt.Errorf("blob checksum mismatch: expected %s, received %s", blob.digest, actual)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("target registry rejected blob %s (status %d)", blob.digest, resp.StatusCode)
+	}
+
+	c.result.BlobsCopied++
+	c.result.BytesCopied += blob.size
+	c.report(dto.RegistryCopyProgress{Stage: "blob", Digest: blob.digest, Size: blob.size})
+	return nil
+}
+
+// pushManifest uploads a manifest and checks the digest the target registry computed
+func (c *registryCopier) pushManifest(ctx context.Context, repository, reference, mediaType string, body []byte, digest string) error {
+	resp, err := registryProxyDo(ctx, c.target, http.MethodPut, fmt.Sprintf("v2/%s/manifests/%s", repository, reference),
+		strings.NewReader(string(body)), map[string]string{"Content-Type": mediaType})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("target registry rejected manifest %s (status %d): %s", reference, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if stored := resp.Header.Get("Docker-Content-Digest"); stored != "" && stored != digest {
+		return fmt.Errorf("manifest checksum mismatch: target stored %s, expected %s", stored, digest)
+	}
+	return nil
+}
+
+func (c *registryCopier) blobExists(ctx context.Context, repository, digest string) (bool, error) {
+	resp, err := registryProxyDo(ctx, c.target, http.MethodHead, fmt.Sprintf("v2/%s/blobs/%s", repository, digest), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK && resp.Header.Get("Docker-Content-Digest") == digest, nil
+}
+
+func (c *registryCopier) report(event dto.RegistryCopyProgress) {
+	if c.progress == nil {
+		return
+	}
+	event.Completed = c.result.BlobsCopied + c.result.BlobsSkipped
+	event.Total = c.totalBlobs
+	c.progress(event)
+}
+
+// registryProxyDo sends a request to a registry through the Kubernetes service proxy.
+// Unlike proxyRequest it supports every verb and exposes the response headers.
+func registryProxyDo(ctx context.Context, api *dto.RegistryAPI, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	return registryProxyDoQuery(ctx, api, method, path, nil, body, headers, -1)
+}
+
+func registryProxyDoQuery(ctx context.Context, api *dto.RegistryAPI, method, path string, query url.Values, body io.Reader, headers map[string]string, contentLength int64) (*http.Response, error) {
+	if api.K8sClient == nil {
+		return nil, fmt.Errorf("kubernetes client not available")
+	}
+	restClient, ok := api.K8sClient.Clientset.CoreV1().RESTClient().(*rest.RESTClient)
+	if !ok {
+		return nil, fmt.Errorf("kubernetes REST client not available")
+	}
+
+	target := restClient.Get().
+		Namespace(api.Namespace).
+		Resource("services").
+		Name(api.ServiceName + ":5000").
+		SubResource("proxy").
+		Suffix(strings.TrimPrefix(path, "/")).
+		URL()
+	if query != nil {
+		target.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if contentLength >= 0 {
+		req.ContentLength = contentLength
+	}
+
+	resp, err := restClient.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request %s %s failed: %v", method, path, err)
+	}
+	return resp, nil
+}
+
+// registryUploadLocation extracts the upload path and state from the Location of an
+// upload session; the host is dropped because requests go through the service proxy
+func registryUploadLocation(location string) (string, url.Values, error) {
+	parsed, err := url.Parse(location)
+	if err != nil || parsed.Path == "" {
+		return "", nil, fmt.Errorf("target registry returned an invalid upload location %q", location)
+	}
+	path := parsed.Path
+	if idx := strings.Index(path, "/v2/"); idx >= 0 {
+		path = path[idx:]
+	}
+	return path, parsed.Query(), nil
+}
+
+func registryReferenceSeparator(reference string) string {
+	if strings.HasPrefix(reference, "sha256:") {
+		return "@"
+	}
+	return ":"
+}
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
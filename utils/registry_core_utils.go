@@ -16,7 +16,7 @@ import (
 	"k8s.io/client-go/util/retry"
 )
 
-func CreateRegistryService(ctx context.Context, registryNamespace string, registry models.Registry, clientset *kubernetes.Clientset) error {
+func CreateRegistryService(ctx context.Context, registryNamespace string, registry models.Registry, clientset kubernetes.Interface) error {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetRegistryResourceName(registry.ID),
@@ -50,10 +50,20 @@ func CreateRegistryService(ctx context.Context, registryNamespace string, regist
 	return err
 }
 
-func CreateRegistryDeployment(ctx context.Context, registryNamespace string, registry models.Registry, clientset *kubernetes.Clientset) error {
-	var replicas int32 = 1
+func CreateRegistryDeployment(ctx context.Context, registryNamespace string, registry models.Registry, clientset kubernetes.Interface) error {
+	replicas := GetRegistryReplicas(registry)
 	resourceName := GetRegistryResourceName(registry.ID)
 
+	// The volume is ReadWriteOnce, so S3-backed registries leave it unmounted and can scale out
+	dataVolume := corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: resourceName,
+		},
+	}
+	if registry.StorageBackend == models.RegistryStorageS3 {
+		dataVolume = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	}
+
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      resourceName,
@@ -88,17 +98,23 @@ func CreateRegistryDeployment(ctx context.Context, registryNamespace string, reg
 									ContainerPort: 5000,
 								},
 							},
-							Env: []corev1.EnvVar{
+							Env: append([]corev1.EnvVar{
 								{
 									Name:  "REGISTRY_STORAGE_DELETE_ENABLED",
 									Value: "true",
 								},
-							},
+							}, append(registryProxyEnv(registry), registryStorageEnv(registry)...)...),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "data",
 									MountPath: "/var/lib/registry",
 								},
+								{
+									Name:      "config",
+									MountPath: registryConfigPath,
+									SubPath:   "config.yml",
+									ReadOnly:  true,
+								},
 							},
 							Resources: corev1.ResourceRequirements{
 								Limits: corev1.ResourceList{
@@ -136,10 +152,16 @@ func CreateRegistryDeployment(ctx context.Context, registryNamespace string, reg
 					},
 					Volumes: []corev1.Volume{
 						{
-							Name: "data",
+							Name:         "data",
+							VolumeSource: dataVolume,
+						},
+						{
+							Name: "config",
 							VolumeSource: corev1.VolumeSource{
-								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: resourceName,
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: GetRegistryConfigMapName(registry.ID),
+									},
 								},
 							},
 						},
@@ -149,6 +171,28 @@ func CreateRegistryDeployment(ctx context.Context, registryNamespace string, reg
 		},
 	}
 
+	if replicas > 1 {
+		// Spread replicas across nodes so one node failure keeps the registry serving
+		deployment.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"app":         "registry",
+									"registry-id": registry.ID,
+								},
+							},
+							TopologyKey: "kubernetes.io/hostname",
+						},
+					},
+				},
+			},
+		}
+	}
+
 	SecurePodSpec(&deployment.Spec.Template.Spec)
 
 	_, err := clientset.AppsV1().Deployments(registryNamespace).Create(ctx, deployment, metav1.CreateOptions{})
@@ -158,7 +202,7 @@ func CreateRegistryDeployment(ctx context.Context, registryNamespace string, reg
 	return err
 }
 
-func CreateRegistryIngress(ctx context.Context, registryNamespace string, registry models.Registry, clientset *kubernetes.Clientset) error {
+func CreateRegistryIngress(ctx context.Context, registryNamespace string, registry models.Registry) error {
 	resourceName := GetRegistryResourceName(registry.ID)
 	hostname := GetRegistryHostname(registry.ID)
 	pathTypePrefix := networkingv1.PathTypePrefix
@@ -171,13 +215,10 @@ func CreateRegistryIngress(ctx context.Context, registryNamespace string, regist
 				"app":         "registry",
 				"registry-id": registry.ID,
 			},
-			Annotations: map[string]string{
-				"traefik.ingress.kubernetes.io/router.entrypoints": "websecure",
-				"traefik.ingress.kubernetes.io/router.tls":         "true",
-				"cert-manager.io/cluster-issuer":                   "letsencrypt-prod",
-			},
+			Annotations: ingressAnnotations(GetClusterIssuer()),
 		},
 		Spec: networkingv1.IngressSpec{
+			IngressClassName: GetIngressProvider().IngressClassName(),
 			Rules: []networkingv1.IngressRule{
 				{
 					Host: hostname,
@@ -210,15 +251,12 @@ func CreateRegistryIngress(ctx context.Context, registryNamespace string, regist
 		},
 	}
 
-	_, err := clientset.NetworkingV1().Ingresses(registryNamespace).Create(ctx, ingress, metav1.CreateOptions{})
-	if apierrors.IsAlreadyExists(err) {
-		_, err = clientset.NetworkingV1().Ingresses(registryNamespace).Update(ctx, ingress, metav1.UpdateOptions{})
-	}
-	return err
+	return ApplyIngressRouting(ctx, ingress)
 }
 
-// CreatePVC creates a persistent volume claim for registry data with 5Gi storage
-func CreatePVC(ctx context.Context, registry models.Registry, registryNamespace string, clientset *kubernetes.Clientset) error {
+// CreatePVC creates the persistent volume claim for registry data, or expands an existing
+// one when the requested size grew (see CheckRegistryVolumeExpansion)
+func CreatePVC(ctx context.Context, registry models.Registry, registryNamespace string, clientset kubernetes.Interface) error {
 	// Log the PVC creation
 	fmt.Printf("Creating PVC with name %s in namespace %s\n", GetRegistryResourceName(registry.ID), registryNamespace)
 
@@ -238,18 +276,36 @@ func CreatePVC(ctx context.Context, registry models.Registry, registryNamespace
 	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
 	pvc.Spec.AccessModes = accessModes
 
-	// Add storage request - 5Gi
+	// Add storage request
+	requested, err := resource.ParseQuantity(GetRegistryStorageSize(registry))
+	if err != nil {
+		return fmt.Errorf("invalid storage size: %v", err)
+	}
 	pvc.Spec.Resources.Requests = make(corev1.ResourceList)
-	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("20Gi")
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = requested
 
-	_, err := clientset.CoreV1().PersistentVolumeClaims(registryNamespace).Create(ctx, pvc, metav1.CreateOptions{})
+	_, err = clientset.CoreV1().PersistentVolumeClaims(registryNamespace).Create(ctx, pvc, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
-		return nil
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existingPVC, err := clientset.CoreV1().PersistentVolumeClaims(registryNamespace).Get(ctx, pvc.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			existing := existingPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+			if requested.Cmp(existing) <= 0 {
+				return nil
+			}
+
+			fmt.Printf("Expanding PVC %s from %s to %s\n", pvc.Name, existing.String(), requested.String())
+			existingPVC.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+			_, err = clientset.CoreV1().PersistentVolumeClaims(registryNamespace).Update(ctx, existingPVC, metav1.UpdateOptions{})
+			return err
+		})
 	}
 	return err
 }
 
-func UpdateDeployment(ctx context.Context, registry models.Registry, clientset *kubernetes.Clientset, registryNamespace string) error {
+func UpdateDeployment(ctx context.Context, registry models.Registry, clientset kubernetes.Interface, registryNamespace string) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Get current deployment
 		deployment, err := clientset.AppsV1().Deployments(registryNamespace).Get(ctx, GetRegistryResourceName(registry.ID), metav1.GetOptions{})
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes"
+)
+
+// DockerHubRemoteURL is the upstream a proxy registry mirrors when none is configured
+const DockerHubRemoteURL = "https://registry-1.docker.io"
+
+// registryConfigPath is where registry:2 reads its configuration
+const registryConfigPath = "/etc/docker/registry/config.yml"
+
+var (
+	registryMirrorHost string
+	registryMirrorMu   sync.RWMutex
+)
+
+// SetRegistryMirror records the hostname of the ready Docker Hub pull-through cache.
+// An empty host disables mirroring.
+func SetRegistryMirror(host string) {
+	registryMirrorMu.Lock()
+	defer registryMirrorMu.Unlock()
+	registryMirrorHost = CleanRegistryURL(host)
+}
+
+// GetRegistryMirror returns the Docker Hub mirror hostname, empty when none is ready
+func GetRegistryMirror() string {
+	registryMirrorMu.RLock()
+	defer registryMirrorMu.RUnlock()
+	return registryMirrorHost
+}
+
+// GetRegistryProxyRemoteURL returns the upstream mirrored by a proxy registry
+func GetRegistryProxyRemoteURL(registry models.Registry) string {
+	if strings.TrimSpace(registry.ProxyRemoteURL) == "" {
+		return DockerHubRemoteURL
+	}
+	return strings.TrimRight(strings.TrimSpace(registry.ProxyRemoteURL), "/")
+}
+
+// IsDockerHubRemote reports whether a proxy registry mirrors Docker Hub
+func IsDockerHubRemote(registry models.Registry) bool {
+	parsed, err := url.Parse(GetRegistryProxyRemoteURL(registry))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "registry-1.docker.io" || host == "docker.io" || host == "index.docker.io"
+}
+
+// KanikoMirrorArgs points Kaniko base image pulls at the Docker Hub mirror.
+// Kaniko falls back to Docker Hub itself when the mirror cannot serve an image.
+func KanikoMirrorArgs() []string {
+	mirror := GetRegistryMirror()
+	if mirror == "" {
+		return nil
+	}
+
+	args := []string{fmt.Sprintf("--registry-mirror=%s", mirror)}
+	if IsInsecureRegistry(mirror) {
+		args = append(args, fmt.Sprintf("--insecure-registry=%s", mirror), fmt.Sprintf("--skip-tls-verify-registry=%s", mirror))
+	}
+	return args
+}
+
+// MirrorImage rewrites a Docker Hub image reference to pull through the mirror.
+// Images from other registries and all images when no mirror is ready are unchanged.
+func MirrorImage(image string) string {
+	mirror := GetRegistryMirror()
+	if mirror == "" {
+		return image
+	}
+
+	name := image
+	if slash := strings.Index(image, "/"); slash >= 0 {
+		domain := image[:slash]
+		if domain == "docker.io" || domain == "index.docker.io" {
+			name = image[slash+1:]
+		} else if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+			return image
+		}
+	}
+	if !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return fmt.Sprintf("%s/%s", mirror, name)
+}
+
+// GetRegistryConfigMapName returns the name of the ConfigMap holding config.yml
+func GetRegistryConfigMapName(registryID string) string {
+	return fmt.Sprintf("%s-config", GetRegistryResourceName(registryID))
+}
+
+// GetRegistryProxySecretName returns the name of the Secret holding upstream credentials
+func GetRegistryProxySecretName(registryID string) string {
+	return fmt.Sprintf("%s-proxy", GetRegistryResourceName(registryID))
+}
+
+// generateRegistryConfig renders config.yml; proxy mode adds the pull-through section.
+// The storage section depends on the backend, see registryStorageConfig.
+// Upstream credentials are injected as REGISTRY_PROXY_* env vars so they stay in a Secret.
+func generateRegistryConfig(registry models.Registry) string {
+	var config strings.Builder
+	config.WriteString("version: 0.1\n")
+	config.WriteString("log:\n  fields:\n    service: registry\n")
+	config.WriteString(registryStorageConfig(registry))
+	config.WriteString("http:\n  addr: :5000\n  headers:\n    X-Content-Type-Options: [nosniff]\n")
+	config.WriteString("health:\n  storagedriver:\n    enabled: true\n    interval: 10s\n    threshold: 3\n")
+	if registry.Mode == models.RegistryModeProxy {
+		config.WriteString(fmt.Sprintf("proxy:\n  remoteurl: %s\n", GetRegistryProxyRemoteURL(registry)))
+	}
+	return config.String()
+}
+
+// CreateRegistryConfigMap creates or updates the registry configuration
+func CreateRegistryConfigMap(ctx context.Context, registryNamespace string, registry models.Registry, clientset kubernetes.Interface) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetRegistryConfigMapName(registry.ID),
+			Namespace: registryNamespace,
+			Labels: map[string]string{
+				"app":         "registry",
+				"registry-id": registry.ID,
+			},
+		},
+		Data: map[string]string{
+			"config.yml": generateRegistryConfig(registry),
+		},
+	}
+
+	_, err := clientset.CoreV1().ConfigMaps(registryNamespace).Create(ctx, configMap, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().ConfigMaps(registryNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// CreateRegistryProxySecret stores the upstream credentials of a proxy registry.
+// Anonymous proxies have their Secret removed.
+func CreateRegistryProxySecret(ctx context.Context, registryNamespace string, registry models.Registry, clientset kubernetes.Interface) error {
+	secretName := GetRegistryProxySecretName(registry.ID)
+	if registry.Mode != models.RegistryModeProxy || registry.ProxyUsername == "" {
+		err := clientset.CoreV1().Secrets(registryNamespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: registryNamespace,
+			Labels: map[string]string{
+				"app":         "registry",
+				"registry-id": registry.ID,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"username": registry.ProxyUsername,
+			"password": registry.ProxyPassword,
+		},
+	}
+
+	_, err := clientset.CoreV1().Secrets(registryNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(registryNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// registryProxyEnv maps the upstream credentials Secret onto registry:2 config overrides
+func registryProxyEnv(registry models.Registry) []corev1.EnvVar {
+	if registry.Mode != models.RegistryModeProxy || registry.ProxyUsername == "" {
+		return nil
+	}
+
+	secretName := GetRegistryProxySecretName(registry.ID)
+	return []corev1.EnvVar{
+		registrySecretEnv("REGISTRY_PROXY_USERNAME", secretName, "username"),
+		registrySecretEnv("REGISTRY_PROXY_PASSWORD", secretName, "password"),
+	}
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// registryStorageMigrationTimeout bounds copying a registry volume into a bucket
+const registryStorageMigrationTimeout = 60 * time.Minute
+
+// MigrateRegistryStorage copies the blobs on a registry's volume into its S3 bucket with
+// `mc mirror`. The Job is scheduled next to the registry pod because the volume is
+// ReadWriteOnce; it must run while the registry still uses the filesystem backend.
+func MigrateRegistryStorage(registry models.Registry) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	resourceName := GetRegistryResourceName(registry.ID)
+	secretName := GetRegistryStorageSecretName(registry.ID)
+	jobName := fmt.Sprintf("%s-migrate-%d", resourceName, time.Now().Unix())
+	labels := map[string]string{
+		"app":         jobName,
+		"registry-id": registry.ID,
+		"component":   "registry-storage-migration",
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: RegistryNamespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(3600),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Affinity: &corev1.Affinity{
+						PodAffinity: &corev1.PodAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+								{
+									LabelSelector: &metav1.LabelSelector{
+										MatchLabels: map[string]string{
+											"app":         "registry",
+											"registry-id": registry.ID,
+										},
+									},
+									TopologyKey: "kubernetes.io/hostname",
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "mc",
+							Image:   MinIOClientImage,
+							Command: []string{"/bin/sh", "-c"},
+							Args: []string{strings.Join([]string{
+								"set -e",
+								`mc alias set target "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY" >/dev/null`,
+								`mc mb --ignore-existing "target/$S3_BUCKET"`,
+								`if [ -d /var/lib/registry/docker ]; then`,
+								`  mc mirror --overwrite --preserve /var/lib/registry/docker "target/$S3_BUCKET/docker"`,
+								`fi`,
+								`echo "MIGRATION_COMPLETE"`,
+							}, "\n")},
+							Env: []corev1.EnvVar{
+								{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+								registrySecretEnv("S3_ENDPOINT", secretName, "endpoint"),
+								registrySecretEnv("S3_BUCKET", secretName, "bucket"),
+								registrySecretEnv("S3_ACCESS_KEY", secretName, "accessKey"),
+								registrySecretEnv("S3_SECRET_KEY", secretName, "secretKey"),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/var/lib/registry",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: resourceName,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+
+	ctx := context.Background()
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(RegistryNamespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create storage migration job: %v", err)
+	}
+
+	jobErr := waitForJobCompletion(k8sClient, jobName, RegistryNamespace, registryStorageMigrationTimeout)
+	output := readJobContainerLogs(k8sClient, jobName, RegistryNamespace, "mc")
+	if jobErr != nil {
+		return fmt.Errorf("storage migration failed: %v: %s", jobErr, lastLine(output))
+	}
+
+	log.Printf("Registry %s blobs copied to bucket %s", registry.ID, registry.S3Bucket)
+	return nil
+}
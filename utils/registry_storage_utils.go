@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes"
+)
+
+// DefaultRegistryStorageSize is the registry volume size when none is requested
+const DefaultRegistryStorageSize = "20Gi"
+
+// GetRegistryStorageSize returns the requested registry volume size
+func GetRegistryStorageSize(registry models.Registry) string {
+	if registry.StorageSize == "" {
+		return DefaultRegistryStorageSize
+	}
+	return registry.StorageSize
+}
+
+// GetRegistryReplicas returns the replica count of a registry, at least one
+func GetRegistryReplicas(registry models.Registry) int32 {
+	if registry.Replicas < 1 {
+		return 1
+	}
+	return int32(registry.Replicas)
+}
+
+// CheckRegistryVolumeExpansion verifies the registry volume can grow to size online:
+// volumes never shrink and the storage class must allow expansion
+func CheckRegistryVolumeExpansion(ctx context.Context, registryNamespace string, registry models.Registry, size string, clientset kubernetes.Interface) error {
+	requested, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid storage size %q: %v", size, err)
+	}
+
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(registryNamespace).Get(ctx, GetRegistryResourceName(registry.ID), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get registry volume: %v", err)
+	}
+	return checkClaimExpansion(ctx, clientset, *pvc, requested)
+}
+
+// checkClaimExpansion verifies a claim can grow to requested online: volumes never shrink
+// and the storage class must allow expansion
+func checkClaimExpansion(ctx context.Context, clientset kubernetes.Interface, pvc corev1.PersistentVolumeClaim, requested resource.Quantity) error {
+	existing := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch requested.Cmp(existing) {
+	case 0:
+		return nil
+	case -1:
+		return fmt.Errorf("volumes cannot shrink from %s to %s", existing.String(), requested.String())
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return fmt.Errorf("volume %s has no storage class and cannot be expanded", pvc.Name)
+	}
+	storageClass, err := clientset.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get storage class %s: %v", *pvc.Spec.StorageClassName, err)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return fmt.Errorf("storage class %s does not allow volume expansion", storageClass.Name)
+	}
+	return nil
+}
+
+// GetRegistryStorageSecretName returns the name of the Secret holding S3 credentials
+func GetRegistryStorageSecretName(registryID string) string {
+	return fmt.Sprintf("%s-storage", GetRegistryResourceName(registryID))
+}
+
+// GetRegistryBucketName returns the bucket created for a registry on a managed MinIO
+func GetRegistryBucketName(registryID string) string {
+	return fmt.Sprintf("registry-%s", strings.ReplaceAll(registryID, "-", ""))
+}
+
+// GetRegistryS3Region returns the configured region, us-east-1 for MinIO and when unset
+func GetRegistryS3Region(registry models.Registry) string {
+	if registry.S3Region == "" {
+		return "us-east-1"
+	}
+	return registry.S3Region
+}
+
+// registryStorageConfig renders the storage section of config.yml. With S3 the registry
+// serves blobs itself instead of redirecting clients to an endpoint they may not reach;
+// credentials are injected as REGISTRY_STORAGE_S3_* env vars from a Secret.
+func registryStorageConfig(registry models.Registry) string {
+	var config strings.Builder
+	config.WriteString("storage:\n  cache:\n    blobdescriptor: inmemory\n")
+	if registry.StorageBackend == models.RegistryStorageS3 {
+		config.WriteString("  s3:\n")
+		config.WriteString(fmt.Sprintf("    region: %s\n", GetRegistryS3Region(registry)))
+		config.WriteString(fmt.Sprintf("    bucket: %s\n", registry.S3Bucket))
+		if registry.S3Endpoint != "" {
+			config.WriteString(fmt.Sprintf("    regionendpoint: %s\n", registry.S3Endpoint))
+		}
+		config.WriteString(fmt.Sprintf("    secure: %t\n", !strings.HasPrefix(registry.S3Endpoint, "http://")))
+		config.WriteString("    v4auth: true\n")
+		config.WriteString("  redirect:\n    disable: true\n")
+	} else {
+		config.WriteString("  filesystem:\n    rootdirectory: /var/lib/registry\n")
+	}
+	config.WriteString("  delete:\n    enabled: true\n")
+	return config.String()
+}
+
+// CreateRegistryStorageSecret stores the S3 credentials of a registry; filesystem
+// registries have their Secret removed
+func CreateRegistryStorageSecret(ctx context.Context, registryNamespace string, registry models.Registry, clientset kubernetes.Interface) error {
+	secretName := GetRegistryStorageSecretName(registry.ID)
+	if registry.StorageBackend != models.RegistryStorageS3 {
+		err := clientset.CoreV1().Secrets(registryNamespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: registryNamespace,
+			Labels: map[string]string{
+				"app":         "registry",
+				"registry-id": registry.ID,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"endpoint":  registryS3ClientEndpoint(registry),
+			"bucket":    registry.S3Bucket,
+			"accessKey": registry.S3AccessKey,
+			"secretKey": registry.S3SecretKey,
+		},
+	}
+
+	_, err := clientset.CoreV1().Secrets(registryNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(registryNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// registryStorageEnv maps the S3 credentials Secret onto registry:2 config overrides
+func registryStorageEnv(registry models.Registry) []corev1.EnvVar {
+	if registry.StorageBackend != models.RegistryStorageS3 {
+		return nil
+	}
+
+	secretName := GetRegistryStorageSecretName(registry.ID)
+	return []corev1.EnvVar{
+		registrySecretEnv("REGISTRY_STORAGE_S3_ACCESSKEY", secretName, "accessKey"),
+		registrySecretEnv("REGISTRY_STORAGE_S3_SECRETKEY", secretName, "secretKey"),
+	}
+}
+
+// registrySecretEnv reads an env var from a key of a Secret in the registry namespace
+func registrySecretEnv(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// registryS3ClientEndpoint returns the endpoint mc talks to, AWS S3 when none is set
+func registryS3ClientEndpoint(registry models.Registry) string {
+	if registry.S3Endpoint != "" {
+		return registry.S3Endpoint
+	}
+	return fmt.Sprintf("https://s3.%s.amazonaws.com", GetRegistryS3Region(registry))
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// previousLogLimitBytes caps the logs read from one previous container instance
+const previousLogLimitBytes = 1024 * 1024
+
+// GetRestartHistory returns the restart counts and last terminations of the containers of a
+// service's pods, with the last tailLines lines of each restarted container's previous
+// instance (secret values masked). Pods are listed newest first.
+func GetRestartHistory(service models.Service, tailLines int64) (dto.ServiceRestartHistory, error) {
+	history := dto.ServiceRestartHistory{
+		ServiceID: service.ID,
+		Pods:      []dto.PodRestartHistory{},
+		CheckedAt: time.Now(),
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return history, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: ServiceOwnerSelector(service.ID),
+	})
+	if err != nil {
+		return history, fmt.Errorf("failed to list pods: %v", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+
+	redact := NewSecretRedactor(service)
+	for _, pod := range pods.Items {
+		podHistory := dto.PodRestartHistory{
+			Name:       pod.Name,
+			Phase:      string(pod.Status.Phase),
+			NodeName:   pod.Spec.NodeName,
+			CreatedAt:  pod.CreationTimestamp.Time,
+			Containers: []dto.ContainerRestartInfo{},
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for i, status := range statuses {
+			info := containerRestartInfo(status)
+			info.Init = i < len(pod.Status.InitContainerStatuses)
+			if status.LastTerminationState.Terminated != nil {
+				logs, err := readPreviousContainerLogs(ctx, k8sClient, pod, status.Name, tailLines)
+				if err != nil {
+					// The kubelet only keeps the previous instance until the next restart
+					info.PreviousLogsError = err.Error()
+				} else {
+					info.PreviousLogs = redact(logs)
+				}
+			}
+			podHistory.Restarts += status.RestartCount
+			podHistory.Containers = append(podHistory.Containers, info)
+		}
+		history.Pods = append(history.Pods, podHistory)
+	}
+	return history, nil
+}
+
+// containerRestartInfo describes a container's current state and last termination
+func containerRestartInfo(status corev1.ContainerStatus) dto.ContainerRestartInfo {
+	info := dto.ContainerRestartInfo{
+		Name:         status.Name,
+		RestartCount: status.RestartCount,
+		Ready:        status.Ready,
+	}
+	switch {
+	case status.State.Running != nil:
+		info.State = "running"
+	case status.State.Waiting != nil:
+		info.State = "waiting"
+		info.StateReason = status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		info.State = "terminated"
+		info.StateReason = status.State.Terminated.Reason
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil {
+		info.LastTermination = &dto.ContainerTermination{
+			Reason:     terminated.Reason,
+			ExitCode:   terminated.ExitCode,
+			Signal:     terminated.Signal,
+			Message:    terminated.Message,
+			StartedAt:  terminated.StartedAt.Time,
+			FinishedAt: terminated.FinishedAt.Time,
+		}
+	}
+	return info
+}
+
+// readPreviousContainerLogs reads the last lines of the container instance before the
+// current one
+func readPreviousContainerLogs(ctx context.Context, client *kubernetes.Client, pod corev1.Pod, container string, tailLines int64) (string, error) {
+	stream, err := client.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   true,
+		Timestamps: true,
+		TailLines:  &tailLines,
+		LimitBytes: int64Ptr(previousLogLimitBytes),
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// annotationsMaxBytes is the total annotation size the API server accepts per object
+const annotationsMaxBytes = 256 * 1024
+
+// GetServiceAccountName returns the name of the service's dedicated ServiceAccount
+func GetServiceAccountName(service models.Service) string {
+	return GetResourceName(service)
+}
+
+// createServiceAccountSpec builds the service's ServiceAccount. No Role is ever bound to
+// it, so its pods have no Kubernetes API permissions; the annotations only carry cloud
+// workload identity (e.g. eks.amazonaws.com/role-arn, iam.gke.io/gcp-service-account).
+func createServiceAccountSpec(service models.Service) *corev1.ServiceAccount {
+	annotations := make(map[string]string, len(service.ServiceAccountAnnotations))
+	for key, value := range service.ServiceAccountAnnotations {
+		annotations[key] = value
+	}
+
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetServiceAccountName(service),
+			Namespace:   service.EnvironmentID,
+			Labels:      GetResourceLabels(service),
+			Annotations: annotations,
+		},
+		AutomountServiceAccountToken: boolPtr(false),
+	}
+}
+
+// ensureServiceAccount creates or updates the service's ServiceAccount before its pods
+// reference it. Deletion cascades from the owner ConfigMap.
+func ensureServiceAccount(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	serviceAccount := createServiceAccountSpec(service)
+	setServiceOwner(serviceAccount, owner)
+
+	serviceAccounts := client.Clientset.CoreV1().ServiceAccounts(service.EnvironmentID)
+	_, err := serviceAccounts.Create(ctx, serviceAccount, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// A deploy of the same service running at once may update it in between
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existing, err := serviceAccounts.Get(ctx, serviceAccount.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			// Replace labels and annotations, keeping fields managed by Kubernetes
+			existing.Labels = serviceAccount.Labels
+			existing.Annotations = serviceAccount.Annotations
+			existing.OwnerReferences = serviceAccount.OwnerReferences
+			existing.AutomountServiceAccountToken = serviceAccount.AutomountServiceAccountToken
+			_, err = serviceAccounts.Update(ctx, existing, metav1.UpdateOptions{})
+			return err
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply ServiceAccount %s: %v", serviceAccount.Name, err)
+	}
+	return nil
+}
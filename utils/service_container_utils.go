@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Limits of the companion containers and shared volumes of a service
+const (
+	maxServiceContainers = 5
+	maxSharedVolumes     = 5
+	maxContainerCommand  = 4096
+)
+
+// sharedVolumePrefix keeps the pod volumes of shared volumes apart from the platform's own
+const sharedVolumePrefix = "shared-"
+
+// ValidateServiceContainers checks the companion containers and shared volumes of a service:
+// DNS label names, each used once, a command for every container, valid resource limits, and
+// absolute mount paths that do not overlap
+func ValidateServiceContainers(containers models.ServiceContainers, volumes models.SharedVolumes) error {
+	var errs FieldErrors
+
+	if len(containers) > maxServiceContainers {
+		errs.Add("containers", "must not contain more than %d containers", maxServiceContainers)
+	}
+	names := map[string]bool{getMainContainerName(): true}
+	for i, container := range containers {
+		field := fmt.Sprintf("containers[%d]", i)
+		errs.CheckDNSLabel(field+".name", container.Name)
+		if names[container.Name] {
+			errs.Add(field+".name", "%q is already used by another container", container.Name)
+		}
+		names[container.Name] = true
+		if container.Command == "" {
+			errs.Add(field+".command", "is required")
+		} else if len(container.Command) > maxContainerCommand {
+			errs.Add(field+".command", "must be at most %d characters", maxContainerCommand)
+		}
+		if container.CPULimit != "" {
+			errs.CheckQuantity(field+".cpuLimit", container.CPULimit)
+		}
+		if container.MemoryLimit != "" {
+			errs.CheckQuantity(field+".memoryLimit", container.MemoryLimit)
+		}
+	}
+
+	if len(volumes) > maxSharedVolumes {
+		errs.Add("sharedVolumes", "must not contain more than %d volumes", maxSharedVolumes)
+	}
+	volumeNames := map[string]bool{}
+	var mountPaths []string
+	for i, volume := range volumes {
+		field := fmt.Sprintf("sharedVolumes[%d]", i)
+		errs.CheckDNSLabel(field+".name", volume.Name)
+		if volumeNames[volume.Name] {
+			errs.Add(field+".name", "%q is listed more than once", volume.Name)
+		}
+		volumeNames[volume.Name] = true
+		if volume.SizeLimit != "" {
+			errs.CheckQuantity(field+".sizeLimit", volume.SizeLimit)
+		}
+
+		switch {
+		case !path.IsAbs(volume.MountPath) || path.Clean(volume.MountPath) != volume.MountPath:
+			errs.Add(field+".mountPath", "must be a clean absolute path, e.g. /shared")
+		case volume.MountPath == "/":
+			errs.Add(field+".mountPath", "must not be the root directory")
+		default:
+			for _, other := range mountPaths {
+				if isSubPath(volume.MountPath, other) || isSubPath(other, volume.MountPath) {
+					errs.Add(field+".mountPath", "overlaps the mount path %s", other)
+					break
+				}
+			}
+			mountPaths = append(mountPaths, volume.MountPath)
+		}
+	}
+	return errs.Err()
+}
+
+// CheckServiceContainerResources rejects companion container limits outside the resource
+// ranges of the service's environment
+func CheckServiceContainerResources(env models.Environment, containers models.ServiceContainers) error {
+	var errs FieldErrors
+	for i, container := range containers {
+		field := fmt.Sprintf("containers[%d].", i)
+		checkQuantityBounds(&errs, field+"cpuLimit", container.CPULimit, env.MinCPULimit, env.MaxCPULimit)
+		checkQuantityBounds(&errs, field+"memoryLimit", container.MemoryLimit, env.MinMemoryLimit, env.MaxMemoryLimit)
+	}
+	return errs.Err()
+}
+
+// isSubPath reports whether dir is p or one of its parents
+func isSubPath(p, dir string) bool {
+	return p == dir || len(p) > len(dir) && p[:len(dir)] == dir && p[len(dir)] == '/'
+}
+
+// applyServiceContainers adds the companion containers of a git service to its pod template,
+// run from the app's image with the app's env vars, and mounts the shared volumes into the
+// app and every companion. Companions get no ports or probes: traffic goes to the app.
+func applyServiceContainers(spec *corev1.PodSpec, imageURL string, service models.Service) {
+	var mounts []corev1.VolumeMount
+	for _, volume := range service.SharedVolumes {
+		emptyDir := &corev1.EmptyDirVolumeSource{}
+		if volume.SizeLimit != "" {
+			sizeLimit := resource.MustParse(volume.SizeLimit)
+			emptyDir.SizeLimit = &sizeLimit
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name:         sharedVolumePrefix + volume.Name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      sharedVolumePrefix + volume.Name,
+			MountPath: volume.MountPath,
+		})
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, mounts...)
+	}
+
+	for _, companion := range service.Containers {
+		cpuLimit, memoryLimit := companion.CPULimit, companion.MemoryLimit
+		if cpuLimit == "" {
+			cpuLimit = service.CPULimit
+		}
+		if memoryLimit == "" {
+			memoryLimit = service.MemoryLimit
+		}
+		spec.Containers = append(spec.Containers, corev1.Container{
+			Name:    companion.Name,
+			Image:   imageURL,
+			Command: []string{"sh", "-c", companion.Command},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpuLimit),
+					corev1.ResourceMemory: resource.MustParse(memoryLimit),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    minQuantity(cpuLimit, "100m"),
+					corev1.ResourceMemory: minQuantity(memoryLimit, "128Mi"),
+				},
+			},
+			Env:          serviceEnvVars(service),
+			VolumeMounts: append([]corev1.VolumeMount(nil), mounts...),
+		})
+	}
+}
+
+// LogContainerName returns the container a pod's logs are read from: the app container of
+// pods that also run companion containers, which the logs API cannot choose between, and
+// the only container (empty) otherwise
+func LogContainerName(pod corev1.Pod) string {
+	if len(pod.Spec.Containers) < 2 {
+		return ""
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == getMainContainerName() {
+			return container.Name
+		}
+	}
+	return pod.Spec.Containers[0].Name
+}
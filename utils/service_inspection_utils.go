@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaxInspectionLogLines bounds the lines read per container for an admin inspection
+const MaxInspectionLogLines = 2000
+
+// ListServiceEvents returns the Kubernetes events of a service's workload, its ReplicaSets
+// and its pods, newest first. Events are kept by the API server for about an hour.
+func ListServiceEvents(ctx context.Context, service models.Service) ([]dto.ServiceEvent, error) {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	namespace := service.EnvironmentID
+	selector := metav1.ListOptions{LabelSelector: ServiceOwnerSelector(service.ID)}
+
+	// Objects are matched by name: events carry no labels
+	names := map[string]bool{GetResourceName(service): true}
+	pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, pod := range pods.Items {
+		names[pod.Name] = true
+	}
+	replicaSets, err := client.Clientset.AppsV1().ReplicaSets(namespace).List(ctx, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ReplicaSets: %v", err)
+	}
+	for _, replicaSet := range replicaSets.Items {
+		names[replicaSet.Name] = true
+	}
+
+	events, err := client.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %v", err)
+	}
+	result := make([]dto.ServiceEvent, 0)
+	for _, event := range events.Items {
+		if !names[event.InvolvedObject.Name] {
+			continue
+		}
+		result = append(result, toServiceEvent(event))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result, nil
+}
+
+func toServiceEvent(event corev1.Event) dto.ServiceEvent {
+	firstSeen, lastSeen := event.FirstTimestamp.Time, event.LastTimestamp.Time
+	if lastSeen.IsZero() {
+		// Events recorded through events.k8s.io only set the event time
+		lastSeen = event.EventTime.Time
+	}
+	if firstSeen.IsZero() {
+		firstSeen = lastSeen
+	}
+	source := event.Source.Component
+	if source == "" {
+		source = event.ReportingController
+	}
+	count := event.Count
+	if count == 0 {
+		count = 1
+	}
+	return dto.ServiceEvent{
+		Type:      event.Type,
+		Reason:    event.Reason,
+		Message:   event.Message,
+		Kind:      event.InvolvedObject.Kind,
+		Name:      event.InvolvedObject.Name,
+		Count:     count,
+		Source:    source,
+		FirstSeen: firstSeen,
+		LastSeen:  lastSeen,
+	}
+}
+
+// ReadRecentServiceLogs returns the last lines of every container of a service's pods, with
+// the previous run of restarted containers, and secret values redacted
+func ReadRecentServiceLogs(ctx context.Context, service models.Service, tailLines int64) (string, error) {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	pods, err := client.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: ServiceOwnerSelector(service.ID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %v", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+
+	var output strings.Builder
+	for _, pod := range pods.Items {
+		fmt.Fprintf(&output, "=== Pod: %s (%s) ===\n", pod.Name, pod.Status.Phase)
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.RestartCount > 0 {
+				fmt.Fprintf(&output, "\n--- Container: %s (previous run, %d restarts) ---\n", status.Name, status.RestartCount)
+				output.WriteString(readTailLogs(ctx, client, pod, status.Name, true, tailLines))
+			}
+			fmt.Fprintf(&output, "\n--- Container: %s ---\n", status.Name)
+			output.WriteString(readTailLogs(ctx, client, pod, status.Name, false, tailLines))
+		}
+		output.WriteString("\n")
+	}
+	return NewSecretRedactor(service)(output.String()), nil
+}
+
+// readTailLogs reads the last lines of a container, or why they are not available
+func readTailLogs(ctx context.Context, client *kubernetes.Client, pod corev1.Pod, container string, previous bool, tailLines int64) string {
+	stream, err := client.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: true,
+		TailLines:  &tailLines,
+		LimitBytes: int64Ptr(runtimeLogLimitBytes),
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Sprintf("No logs available: %v\n", err)
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Sprintf("No logs available: %v\n", err)
+	}
+	return string(logs)
+}
@@ -0,0 +1,354 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// DataMoverImage streams a volume from one namespace into another with tar and nc
+	DataMoverImage = "busybox:1.36"
+	// ServiceMoveReadyTimeout bounds how long a moved git service may take to roll out
+	ServiceMoveReadyTimeout = 10 * time.Minute
+
+	dataMoverPort = 7070
+	// dataMoverCopyTimeout bounds copying one volume into the target namespace
+	dataMoverCopyTimeout = 60 * time.Minute
+	// dataMoverStartTimeout bounds how long the sending pod may take to start listening
+	dataMoverStartTimeout = 5 * time.Minute
+)
+
+// MoveServiceVolumes copies the data volumes of a managed service into the namespace of
+// target, which must be the same service in another environment. Every claim is recreated
+// under its own name, so the StatefulSet or Deployment deployed there adopts it, and filled
+// by a data-mover: a Job in the source namespace serves the volume as a tar stream that a
+// Job in the target namespace unpacks into the new claim. The source workload must be
+// scaled to zero first; its pods are waited for so the volumes are detached and consistent.
+func MoveServiceVolumes(source models.Service, target models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	claims, _, err := ListServiceClaims(ctx, k8sClient, source)
+	if err != nil {
+		return err
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+
+	// Pods of Deployment-based services carry the same app label, so the wait covers both
+	if err := waitForStatefulSetPodsTerminated(ctx, k8sClient, source.EnvironmentID, GetResourceName(source), statefulSetTerminationTimeout); err != nil {
+		return fmt.Errorf("the service did not stop in the source environment: %v", err)
+	}
+
+	for _, claim := range claims {
+		if err := createMovedClaim(ctx, k8sClient, claim, target); err != nil {
+			return err
+		}
+		if err := copyClaimData(ctx, k8sClient, source, target, claim.Name); err != nil {
+			return fmt.Errorf("failed to copy volume %s: %v", claim.Name, err)
+		}
+		log.Printf("Copied volume %s of service %s from %s to %s", claim.Name, source.ID, source.EnvironmentID, target.EnvironmentID)
+	}
+	return nil
+}
+
+// createMovedClaim creates a claim like the source claim in the target's namespace. It keeps
+// the storage class and the current request, which may have been expanded since creation.
+func createMovedClaim(ctx context.Context, k8sClient *kubernetes.Client, claim corev1.PersistentVolumeClaim, target models.Service) error {
+	labels := make(map[string]string, len(claim.Labels))
+	for key, value := range claim.Labels {
+		labels[key] = value
+	}
+	for key, value := range GetResourceLabels(target) {
+		labels[key] = value
+	}
+
+	moved := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claim.Name,
+			Namespace: target.EnvironmentID,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      claim.Spec.AccessModes,
+			Resources:        claim.Spec.Resources,
+			StorageClassName: claim.Spec.StorageClassName,
+			VolumeMode:       claim.Spec.VolumeMode,
+		},
+	}
+	_, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(target.EnvironmentID).Create(ctx, moved, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return fmt.Errorf("volume %s already exists in the target environment", claim.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create volume %s in the target environment: %v", claim.Name, err)
+	}
+	return nil
+}
+
+// copyClaimData streams one claim from the source namespace into the claim of the same name
+// in the target namespace. The sender is reached through a Service; workload namespaces may
+// talk to each other under tenant isolation. Both Jobs are removed afterwards so the
+// ReadWriteOnce volumes are free for the workload.
+func copyClaimData(ctx context.Context, k8sClient *kubernetes.Client, source models.Service, target models.Service, claimName string) error {
+	name := fmt.Sprintf("data-mover-%d", time.Now().UnixNano())
+	sourceNamespace := source.EnvironmentID
+	targetNamespace := target.EnvironmentID
+	background := metav1.DeletePropagationBackground
+	deleteOptions := metav1.DeleteOptions{PropagationPolicy: &background}
+
+	sender := createDataMoverJobSpec(name+"-send", sourceNamespace, source, claimName, true, []string{
+		"set -eo pipefail",
+		fmt.Sprintf("tar -cf - -C /data . | nc -l -p %d", dataMoverPort),
+		`echo "SEND_COMPLETE"`,
+	})
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(sourceNamespace).Create(ctx, sender, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create sending job: %v", err)
+	}
+	defer k8sClient.Clientset.BatchV1().Jobs(sourceNamespace).Delete(context.Background(), sender.Name, deleteOptions)
+
+	endpoint := createDataMoverServiceSpec(name, sourceNamespace, source, sender.Name)
+	if _, err := k8sClient.Clientset.CoreV1().Services(sourceNamespace).Create(ctx, endpoint, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create data mover service: %v", err)
+	}
+	defer k8sClient.Clientset.CoreV1().Services(sourceNamespace).Delete(context.Background(), endpoint.Name, metav1.DeleteOptions{})
+
+	if err := waitForJobPodRunning(ctx, k8sClient, sender.Name, sourceNamespace, dataMoverStartTimeout); err != nil {
+		return err
+	}
+
+	// A connection refused before the Service has endpoints is retried; tar rejects the
+	// empty stream, so a failed attempt never looks like a copied volume
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", endpoint.Name, sourceNamespace)
+	receiver := createDataMoverJobSpec(name+"-receive", targetNamespace, target, claimName, false, []string{
+		"set -o pipefail",
+		"for attempt in 1 2 3 4 5 6 7 8 9 10; do",
+		fmt.Sprintf("  if nc -w 30 %s %d | tar -xf - -C /data; then", host, dataMoverPort),
+		`    echo "RECEIVE_COMPLETE"`,
+		"    exit 0",
+		"  fi",
+		"  sleep 3",
+		"done",
+		`echo "could not receive the volume" >&2`,
+		"exit 1",
+	})
+	if _, err := k8sClient.Clientset.BatchV1().Jobs(targetNamespace).Create(ctx, receiver, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create receiving job: %v", err)
+	}
+	defer k8sClient.Clientset.BatchV1().Jobs(targetNamespace).Delete(context.Background(), receiver.Name, deleteOptions)
+
+	if err := WaitForJobCompletion(k8sClient, receiver.Name, targetNamespace, "mover", dataMoverCopyTimeout); err != nil {
+		return fmt.Errorf("receiving job failed: %v", err)
+	}
+	if err := WaitForJobCompletion(k8sClient, sender.Name, sourceNamespace, "mover", dataMoverStartTimeout); err != nil {
+		return fmt.Errorf("sending job failed: %v", err)
+	}
+	return nil
+}
+
+// createDataMoverJobSpec builds a data-mover Job mounting the claim at /data. The mover
+// runs as root with the capabilities tar needs to read files of any owner and restore their
+// ownership and modes; they are all allowed by the baseline Pod Security Standard.
+func createDataMoverJobSpec(name, namespace string, service models.Service, claimName string, readOnly bool, script []string) *batchv1.Job {
+	labels := map[string]string{
+		"app":          name,
+		"component":    "data-mover",
+		"managed-by":   "pendeploy",
+		LabelServiceID: service.ID,
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: int32Ptr(3600),
+			Template: corev1.PodTemplateSpec{
+				// Without the service label the pod stays out of the service's selectors
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					"app":       name,
+					"component": "data-mover",
+				}},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "mover",
+							Image:   DataMoverImage,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{strings.Join(script, "\n")},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: dataMoverPort, Protocol: corev1.ProtocolTCP},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/data",
+									ReadOnly:  readOnly,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: claimName,
+									ReadOnly:  readOnly,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SecurePodSpec(&job.Spec.Template.Spec)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.SecurityContext.Capabilities.Add = []corev1.Capability{"CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID"}
+	return job
+}
+
+// createDataMoverServiceSpec exposes the sending pod of a data-mover to the receiving one
+func createDataMoverServiceSpec(name, namespace string, service models.Service, senderName string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"component":    "data-mover",
+				"managed-by":   "pendeploy",
+				LabelServiceID: service.ID,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": senderName},
+			Ports: []corev1.ServicePort{
+				{
+					Port:       dataMoverPort,
+					TargetPort: intstr.FromInt(dataMoverPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// waitForJobPodRunning blocks until a pod of the Job runs, failing early when it ends
+func waitForJobPodRunning(ctx context.Context, k8sClient *kubernetes.Client, jobName, namespace string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		pods, err := k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+		})
+		if err == nil {
+			for _, pod := range pods.Items {
+				switch pod.Status.Phase {
+				case corev1.PodRunning:
+					return nil
+				case corev1.PodFailed, corev1.PodSucceeded:
+					return fmt.Errorf("job %s ended before it started serving", jobName)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for job %s to start (waited %v)", jobName, timeout)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// FlipServiceIngresses hands the Ingresses of a service over from one namespace to
+// another: the ones in from's namespace are removed first, since most ingress controllers
+// reject a host that another Ingress already serves, and to's are applied right after.
+func FlipServiceIngresses(from models.Service, to models.Service) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	background := metav1.DeletePropagationBackground
+	if err := k8sClient.Clientset.NetworkingV1().Ingresses(from.EnvironmentID).DeleteCollection(ctx,
+		metav1.DeleteOptions{PropagationPolicy: &background},
+		metav1.ListOptions{LabelSelector: ServiceOwnerSelector(from.ID)}); err != nil {
+		return fmt.Errorf("failed to remove ingresses from %s: %v", from.EnvironmentID, err)
+	}
+	// Gateway API routes and Ingresses older than the ownership labels are found by name
+	if err := deleteAllIngresses(ctx, k8sClient, from); err != nil {
+		return fmt.Errorf("failed to remove ingresses from %s: %v", from.EnvironmentID, err)
+	}
+
+	owner, err := ensureServiceOwner(ctx, k8sClient, to)
+	if err != nil {
+		return err
+	}
+	if to.Type == models.ServiceTypeManaged {
+		err = deployManagedIngresses(ctx, k8sClient, to, owner)
+	} else {
+		err = deployIngress(ctx, k8sClient, to, owner)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply ingresses in %s: %v", to.EnvironmentID, err)
+	}
+
+	log.Printf("Moved ingresses of service %s from %s to %s", from.ID, from.EnvironmentID, to.EnvironmentID)
+	return nil
+}
+
+// WaitForGitServiceReady blocks until every replica of a git service's Deployment runs the
+// latest template and is available
+func WaitForGitServiceReady(service models.Service, timeout time.Duration) error {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	name := GetResourceName(service)
+	for {
+		deployment, err := k8sClient.Clientset.AppsV1().Deployments(service.EnvironmentID).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			if deployment.Status.ObservedGeneration >= deployment.Generation &&
+				deployment.Status.UpdatedReplicas >= desired &&
+				deployment.Status.AvailableReplicas >= desired {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for %s to become ready (waited %v)", service.Name, timeout)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"encoding/json"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// Fields of dto.ServicePatchDocument a merge patch may set, per service type
+var (
+	commonPatchFields = []string{
+		"name", "cpuLimit", "memoryLimit", "isStaticReplica", "replicas", "minReplicas", "maxReplicas",
+		"customDomain", "serviceAccountAnnotations", "podLabels", "podAnnotations",
+	}
+	gitPatchFields = []string{
+		"envVars", "branch", "port", "buildCommand", "startCommand", "tlsChallenge", "artifactPath",
+		"buildPlatforms", "secretEnvKeys", "buildEnvKeys", "highAvailability",
+	}
+	managedPatchFields = []string{
+		"version", "storageSize", "poolingEnabled", "poolMode", "poolSize", "maxClientConn", "vpaMode",
+	}
+	mapPatchFields = map[string]bool{
+		"envVars": true, "serviceAccountAnnotations": true, "podLabels": true, "podAnnotations": true,
+	}
+)
+
+// ApplyServiceMergePatch applies a JSON merge patch (RFC 7386) to the updatable fields of
+// the service and returns the patched copy. Fields of the other service type, read-only
+// fields and null for anything but a map key are rejected.
+func ApplyServiceMergePatch(service models.Service, patch []byte) (models.Service, error) {
+	var errs FieldErrors
+
+	var changes map[string]interface{}
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		errs.Add("body", "must be a JSON object")
+		return service, errs.Err()
+	}
+
+	allowed := map[string]bool{}
+	for _, field := range commonPatchFields {
+		allowed[field] = true
+	}
+	typeFields := managedPatchFields
+	if service.Type == models.ServiceTypeGit {
+		typeFields = gitPatchFields
+	}
+	for _, field := range typeFields {
+		allowed[field] = true
+	}
+
+	for field, value := range changes {
+		switch {
+		case !allowed[field]:
+			errs.Add(field, "cannot be changed for %s services", service.Type)
+		case value == nil && mapPatchFields[field]:
+			errs.Add(field, "cannot be null; set individual keys to null to remove them")
+		case value == nil:
+			errs.Add(field, "cannot be null")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return service, err
+	}
+
+	current, err := json.Marshal(servicePatchDocument(service))
+	if err != nil {
+		return service, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return service, err
+	}
+
+	merged, err := json.Marshal(mergePatch(document, changes))
+	if err != nil {
+		return service, err
+	}
+	var patched dto.ServicePatchDocument
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		errs.Add("body", "%v", err)
+		return service, errs.Err()
+	}
+	return applyServicePatchDocument(service, patched), nil
+}
+
+// mergePatch applies an RFC 7386 merge patch to target: objects are merged recursively,
+// null removes a key and any other value replaces the target
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// servicePatchDocument returns the patchable fields of the service
+func servicePatchDocument(service models.Service) dto.ServicePatchDocument {
+	return dto.ServicePatchDocument{
+		Name:                          service.Name,
+		CPULimit:                      service.CPULimit,
+		MemoryLimit:                   service.MemoryLimit,
+		IsStaticReplica:               service.IsStaticReplica,
+		Replicas:                      service.Replicas,
+		MinReplicas:                   service.MinReplicas,
+		MaxReplicas:                   service.MaxReplicas,
+		CustomDomain:                  service.CustomDomain,
+		ServiceAccountAnnotations:     service.ServiceAccountAnnotations,
+		PodLabels:                     service.PodLabels,
+		PodAnnotations:                service.PodAnnotations,
+		EnvVars:                       service.EnvVars,
+		Branch:                        service.Branch,
+		Port:                          service.Port,
+		BuildCommand:                  service.BuildCommand,
+		StartCommand:                  service.StartCommand,
+		DockerfilePath:                service.DockerfilePath,
+		BuildArgs:                     service.BuildArgs,
+		TestCommand:                   service.TestCommand,
+		TestImage:                     service.TestImage,
+		TLSChallenge:                  service.TLSChallenge,
+		ArtifactPath:                  service.ArtifactPath,
+		BuildPlatforms:                service.BuildPlatforms,
+		BuildBackend:                  service.BuildBackend,
+		BuildSecrets:                  service.BuildSecrets,
+		SecretEnvKeys:                 service.SecretEnvKeys,
+		BuildEnvKeys:                  service.BuildEnvKeys,
+		HighAvailability:              service.HighAvailability,
+		TerminationGracePeriodSeconds: service.TerminationGracePeriodSeconds,
+		PreStopCommand:                service.PreStopCommand,
+		Command:                       service.Command,
+		Args:                          service.Args,
+		WorkingDir:                    service.WorkingDir,
+		AutoCorrectPort:               service.AutoCorrectPort,
+		CloneDepth:                    service.CloneDepth,
+		BuildTimeoutMinutes:           service.BuildTimeoutMinutes,
+		MaxImageSize:                  service.MaxImageSize,
+		ImageSizeBudgetAction:         service.ImageSizeBudgetAction,
+		SparseCheckoutPaths:           service.SparseCheckoutPaths,
+		Version:                       service.Version,
+		StorageSize:                   service.StorageSize,
+		PoolingEnabled:                service.PoolingEnabled,
+		PoolMode:                      service.PoolMode,
+		PoolSize:                      service.PoolSize,
+		MaxClientConn:                 service.MaxClientConn,
+		VPAMode:                       service.VPAMode,
+		ExternalAllowedCIDRs:          service.ExternalAllowedCIDRs,
+		DatabaseTLS:                   service.DatabaseTLS,
+		AutoUpdate:                    service.AutoUpdate,
+		MaintenanceWindow:             service.MaintenanceWindow,
+	}
+}
+
+// applyServicePatchDocument copies the patched fields of the service's type onto it
+func applyServicePatchDocument(service models.Service, document dto.ServicePatchDocument) models.Service {
+	service.Name = document.Name
+	service.CPULimit = document.CPULimit
+	service.MemoryLimit = document.MemoryLimit
+	service.IsStaticReplica = document.IsStaticReplica
+	service.Replicas = document.Replicas
+	service.MinReplicas = document.MinReplicas
+	service.MaxReplicas = document.MaxReplicas
+	service.CustomDomain = document.CustomDomain
+	service.ServiceAccountAnnotations = document.ServiceAccountAnnotations
+	service.PodLabels = document.PodLabels
+	service.PodAnnotations = document.PodAnnotations
+
+	if service.Type == models.ServiceTypeGit {
+		service.EnvVars = document.EnvVars
+		service.Branch = document.Branch
+		service.Port = document.Port
+		service.BuildCommand = document.BuildCommand
+		service.StartCommand = document.StartCommand
+		service.DockerfilePath = document.DockerfilePath
+		service.BuildArgs = document.BuildArgs
+		service.TestCommand = document.TestCommand
+		service.TestImage = document.TestImage
+		service.TLSChallenge = document.TLSChallenge
+		service.ArtifactPath = document.ArtifactPath
+		service.BuildPlatforms = document.BuildPlatforms
+		service.BuildBackend = document.BuildBackend
+		service.BuildSecrets = document.BuildSecrets
+		service.SecretEnvKeys = document.SecretEnvKeys
+		service.BuildEnvKeys = document.BuildEnvKeys
+		service.HighAvailability = document.HighAvailability
+		service.TerminationGracePeriodSeconds = document.TerminationGracePeriodSeconds
+		service.PreStopCommand = document.PreStopCommand
+		service.Command = document.Command
+		service.Args = document.Args
+		service.WorkingDir = document.WorkingDir
+		service.AutoCorrectPort = document.AutoCorrectPort
+		service.CloneDepth = document.CloneDepth
+		service.BuildTimeoutMinutes = document.BuildTimeoutMinutes
+		service.MaxImageSize = document.MaxImageSize
+		service.ImageSizeBudgetAction = document.ImageSizeBudgetAction
+		service.SparseCheckoutPaths = document.SparseCheckoutPaths
+		return service
+	}
+
+	service.Version = document.Version
+	service.StorageSize = document.StorageSize
+	service.PoolingEnabled = document.PoolingEnabled
+	service.PoolMode = document.PoolMode
+	service.PoolSize = document.PoolSize
+	service.MaxClientConn = document.MaxClientConn
+	service.VPAMode = document.VPAMode
+	service.ExternalAllowedCIDRs = document.ExternalAllowedCIDRs
+	service.DatabaseTLS = document.DatabaseTLS
+	service.AutoUpdate = document.AutoUpdate
+	service.MaintenanceWindow = document.MaintenanceWindow
+	return service
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// statusCacheResync is how often the informers re-list everything from the API server
+const statusCacheResync = 10 * time.Minute
+
+// statusCacheSyncTimeout bounds the initial cache fill on first use
+const statusCacheSyncTimeout = 30 * time.Second
+
+// serviceStatusCache holds informer listers for platform-managed workloads and pods
+type serviceStatusCache struct {
+	deployments  appslisters.DeploymentLister
+	statefulSets appslisters.StatefulSetLister
+	pods         corelisters.PodLister
+}
+
+var (
+	statusCache   *serviceStatusCache
+	statusCacheMu sync.Mutex
+)
+
+// getServiceStatusCache starts the shared informers on first use and waits for them
+// to sync. A failed start is retried on the next call.
+func getServiceStatusCache() (*serviceStatusCache, error) {
+	statusCacheMu.Lock()
+	defer statusCacheMu.Unlock()
+
+	if statusCache != nil {
+		return statusCache, nil
+	}
+
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	// Only watch objects the platform created
+	factory := informers.NewSharedInformerFactoryWithOptions(k8sClient.Clientset, statusCacheResync,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = "managed-by=pendeploy"
+		}),
+	)
+
+	c := &serviceStatusCache{
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+	}
+
+	stopCh := make(chan struct{})
+	factory.Start(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), statusCacheSyncTimeout)
+	defer cancel()
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			close(stopCh)
+			return nil, fmt.Errorf("timed out syncing %v informer", informerType)
+		}
+	}
+
+	log.Println("Service status informer cache synced")
+	statusCache = c
+	return statusCache, nil
+}
+
+// GetBatchServiceStatus returns ready-replica counts and health for many services
+// from the informer cache, without per-service API calls
+func GetBatchServiceStatus(services []models.Service) ([]dto.ServiceStatusSummary, error) {
+	statusCache, err := getServiceStatusCache()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]dto.ServiceStatusSummary, 0, len(services))
+	for _, service := range services {
+		summaries = append(summaries, statusCache.summarize(service))
+	}
+	return summaries, nil
+}
+
+// summarize builds the status of a single service from cached objects
+func (c *serviceStatusCache) summarize(service models.Service) dto.ServiceStatusSummary {
+	resourceName := GetResourceName(service)
+	summary := dto.ServiceStatusSummary{
+		ServiceID: service.ID,
+		Status:    service.Status,
+		Kind:      "Deployment",
+	}
+
+	if service.Type == models.ServiceTypeManaged && GetManagedServiceType(service.ManagedType) == "StatefulSet" {
+		summary.Kind = "StatefulSet"
+		if statefulSet, err := c.statefulSets.StatefulSets(service.EnvironmentID).Get(resourceName); err == nil {
+			if statefulSet.Spec.Replicas != nil {
+				summary.Replicas = *statefulSet.Spec.Replicas
+			}
+			summary.ReadyReplicas = statefulSet.Status.ReadyReplicas
+			summary.AvailableReplicas = statefulSet.Status.AvailableReplicas
+			summary.Found = true
+		}
+	} else if deployment, err := c.deployments.Deployments(service.EnvironmentID).Get(resourceName); err == nil {
+		if deployment.Spec.Replicas != nil {
+			summary.Replicas = *deployment.Spec.Replicas
+		}
+		summary.ReadyReplicas = deployment.Status.ReadyReplicas
+		summary.AvailableReplicas = deployment.Status.AvailableReplicas
+		summary.Found = true
+	}
+
+	pods, err := c.pods.Pods(service.EnvironmentID).List(labels.SelectorFromSet(labels.Set{"app": resourceName}))
+	if err != nil {
+		pods = []*corev1.Pod{}
+	}
+	summary.Health = summarizePodHealth(pods)
+
+	return summary
+}
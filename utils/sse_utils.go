@@ -4,6 +4,18 @@ import (
 	"fmt"
 	"io"
 	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SSEHeartbeatInterval is how often a comment is sent on open streams, so proxies and
+	// load balancers do not close them as idle
+	SSEHeartbeatInterval = 15 * time.Second
+	// sseRetryMillis is how long EventSource clients wait before reconnecting
+	sseRetryMillis = 3000
 )
 
 // Helper functions for SSE formatting
@@ -11,6 +23,21 @@ func WriteSSEData(w io.Writer, data string) {
 	fmt.Fprintf(w, "data: %s\n\n", data)
 }
 
+// WriteSSEEvent writes data as an event of the given type, so clients can tell streams apart
+func WriteSSEEvent(w io.Writer, event string, data string) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// WriteSSEEventWithID writes data with an event ID, which the client sends back in the
+// Last-Event-ID header when it reconnects. An empty event writes an unnamed event.
+func WriteSSEEventWithID(w io.Writer, event string, id string, data string) {
+	if event == "" {
+		fmt.Fprintf(w, "id: %s\ndata: %s\n\n", id, data)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", event, id, data)
+}
+
 func WriteSSEMessage(w io.Writer, message string) {
 	data := map[string]string{"message": message}
 	jsonData, err := json.Marshal(data)
@@ -19,4 +46,85 @@ func WriteSSEMessage(w io.Writer, message string) {
 		return
 	}
 	fmt.Fprintf(w, "data: %s\n\n", jsonData)
-}
\ No newline at end of file
+}
+
+// GetLastEventID returns the ID of the last event a reconnecting client received: the
+// Last-Event-ID header EventSource sends, or the lastEventId query parameter for clients
+// that cannot set headers
+func GetLastEventID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get("Last-Event-ID")); id != "" {
+		return id
+	}
+	return strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+}
+
+// SSEWriter is a ResponseWriter for Server-Sent Events that can be written from several
+// goroutines: each Write (one event) and Flush is serialized. It sends heartbeat comments
+// until Close is called.
+type SSEWriter struct {
+	http.ResponseWriter
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewSSEWriter wraps w, tells clients how soon to reconnect and starts the heartbeat
+func NewSSEWriter(w http.ResponseWriter) *SSEWriter {
+	sse := &SSEWriter{ResponseWriter: w, done: make(chan struct{})}
+	fmt.Fprintf(sse, "retry: %d\n\n", sseRetryMillis)
+	sse.Flush()
+
+	go func() {
+		ticker := time.NewTicker(SSEHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sse.done:
+				return
+			case <-ticker.C:
+				// A comment line, ignored by EventSource
+				if _, err := sse.Write([]byte(": heartbeat\n\n")); err != nil {
+					return
+				}
+				sse.Flush()
+			}
+		}
+	}()
+	return sse
+}
+
+func (w *SSEWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *SSEWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok && !w.closed {
+		flusher.Flush()
+	}
+}
+
+// CloseNotify reports the client going away, as the wrapped writer does
+func (w *SSEWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Close stops the heartbeat and drops writes of goroutines still streaming; the handler must
+// call it before returning
+func (w *SSEWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildJobStatus is the state of a deployment's build job in the cluster
+type BuildJobStatus string
+
+const (
+	BuildJobMissing   BuildJobStatus = "missing" // never created, or removed by its TTL or the janitor
+	BuildJobActive    BuildJobStatus = "active"
+	BuildJobSucceeded BuildJobStatus = "succeeded"
+	BuildJobFailed    BuildJobStatus = "failed"
+)
+
+// GetBuildJobStatus reports the state of a deployment's build job, with the reason the
+// Job controller gave when it failed
+func GetBuildJobStatus(ctx context.Context, serviceID string, deploymentID string) (BuildJobStatus, string, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	job, err := k8sClient.Clientset.BatchV1().Jobs(GetJobNamespace()).Get(ctx, GetJobName(serviceID, deploymentID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return BuildJobMissing, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get build job: %v", err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return BuildJobSucceeded, "", nil
+		case batchv1.JobFailed:
+			reason := condition.Reason
+			if condition.Message != "" {
+				reason = fmt.Sprintf("%s: %s", reason, condition.Message)
+			}
+			return BuildJobFailed, reason, nil
+		}
+	}
+	return BuildJobActive, "", nil
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// statefulSetTerminationTimeout bounds how long we wait for old pods to go away
+	statefulSetTerminationTimeout = 2 * time.Minute
+	// statefulSetReadyTimeout bounds how long the new pod gets to pass readiness
+	statefulSetReadyTimeout = 5 * time.Minute
+)
+
+// waitForStatefulSetPodsTerminated blocks until no pods of the StatefulSet remain
+func waitForStatefulSetPodsTerminated(ctx context.Context, client *kubernetes.Client, namespace, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	selector := fmt.Sprintf("app=%s", name)
+	for {
+		pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods for StatefulSet %s: %v", name, err)
+		}
+		if len(pods.Items) == 0 {
+			log.Printf("All pods of StatefulSet %s terminated", name)
+			return nil
+		}
+		log.Printf("Waiting for %d pod(s) of StatefulSet %s to terminate", len(pods.Items), name)
+
+		// Resume watching from the list we just made so no deletion is missed
+		watcher, err := client.Clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+			LabelSelector:   selector,
+			ResourceVersion: pods.ResourceVersion,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch pods for StatefulSet %s: %v", name, err)
+		}
+
+		err = waitForWatchEvent(ctx, watcher, func(event watch.Event) bool {
+			return event.Type == watch.Deleted
+		})
+		watcher.Stop()
+		if err != nil {
+			return fmt.Errorf("timeout waiting for pods of StatefulSet %s to terminate: %v", name, err)
+		}
+	}
+}
+
+// waitForStatefulSetReady blocks until every replica of the StatefulSet runs the
+// latest template and passes readiness. It fails fast when a pod crash-loops or
+// cannot pull its image instead of waiting for the full timeout.
+func waitForStatefulSetReady(ctx context.Context, client *kubernetes.Client, namespace, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	selector := fmt.Sprintf("app=%s", name)
+	for {
+		statefulSet, err := client.Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get StatefulSet %s: %v", name, err)
+		}
+		if isStatefulSetRolledOut(statefulSet) {
+			return nil
+		}
+
+		pods, err := client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods for StatefulSet %s: %v", name, err)
+		}
+		for i := range pods.Items {
+			if podErr := checkPodForErrors(&pods.Items[i]); podErr != nil {
+				return podErr
+			}
+		}
+
+		// Any change to the StatefulSet or its pods is a reason to re-evaluate
+		setWatcher, err := client.Clientset.AppsV1().StatefulSets(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fmt.Sprintf("metadata.name=%s", name),
+			ResourceVersion: statefulSet.ResourceVersion,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch StatefulSet %s: %v", name, err)
+		}
+		podWatcher, err := client.Clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+			LabelSelector:   selector,
+			ResourceVersion: pods.ResourceVersion,
+		})
+		if err != nil {
+			setWatcher.Stop()
+			return fmt.Errorf("failed to watch pods for StatefulSet %s: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("timeout waiting for StatefulSet %s to become ready (waited %v)", name, timeout)
+		case <-setWatcher.ResultChan():
+		case <-podWatcher.ResultChan():
+		}
+		setWatcher.Stop()
+		podWatcher.Stop()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isStatefulSetRolledOut reports whether all desired replicas are updated and ready
+func isStatefulSetRolledOut(statefulSet *appsv1.StatefulSet) bool {
+	desired := statefulSetReplicas(statefulSet)
+
+	return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.UpdatedReplicas >= desired &&
+		statefulSet.Status.ReadyReplicas >= desired
+}
+
+// waitForWatchEvent reads events until match returns true, the watch closes or ctx ends.
+// A closed watch is not an error: callers re-list and watch again.
+func waitForWatchEvent(ctx context.Context, watcher watch.Interface, match func(watch.Event) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			if match(event) {
+				return nil
+			}
+		}
+	}
+}
+
+// emitStatefulSetEvent records a progress event on the StatefulSet so rollouts
+// are visible via `kubectl describe` and the cluster event stream
+func emitStatefulSetEvent(ctx context.Context, client *kubernetes.Client, statefulSet *appsv1.StatefulSet, eventType, reason, message string) {
+	log.Printf("StatefulSet %s: %s - %s", statefulSet.Name, reason, message)
+
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: statefulSet.Name + ".",
+			Namespace:    statefulSet.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:            "StatefulSet",
+			APIVersion:      "apps/v1",
+			Namespace:       statefulSet.Namespace,
+			Name:            statefulSet.Name,
+			UID:             statefulSet.UID,
+			ResourceVersion: statefulSet.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source: corev1.EventSource{
+			Component: "pendeploy",
+		},
+	}
+
+	if _, err := client.Clientset.CoreV1().Events(statefulSet.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		log.Printf("Warning: failed to record event %s for StatefulSet %s: %v", reason, statefulSet.Name, err)
+	}
+}
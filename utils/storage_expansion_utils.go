@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListServiceClaims lists the PersistentVolumeClaims of a service in name order: the claim
+// of a Deployment-based managed service, or one claim per StatefulSet replica. The resource
+// version of the list is returned to watch for changes from.
+func ListServiceClaims(ctx context.Context, k8sClient *kubernetes.Client, service models.Service) ([]corev1.PersistentVolumeClaim, string, error) {
+	claims, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: ServiceOwnerSelector(service.ID),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list volumes: %v", err)
+	}
+	sort.Slice(claims.Items, func(i, j int) bool { return claims.Items[i].Name < claims.Items[j].Name })
+	return claims.Items, claims.ResourceVersion, nil
+}
+
+// CheckServiceStorageExpansion verifies every claim of a service can grow to size online
+// and that the ResourceQuotas of its namespace leave room for the extra storage. It returns
+// the storage the expansion adds over all claims.
+func CheckServiceStorageExpansion(ctx context.Context, k8sClient *kubernetes.Client, claims []corev1.PersistentVolumeClaim, size string) (resource.Quantity, error) {
+	requested, err := resource.ParseQuantity(size)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid storage size %q: %v", size, err)
+	}
+
+	increase := resource.Quantity{}
+	byClass := map[string]resource.Quantity{}
+	for _, claim := range claims {
+		if err := checkClaimExpansion(ctx, k8sClient.Clientset, claim, requested); err != nil {
+			return resource.Quantity{}, err
+		}
+		growth := requested.DeepCopy()
+		growth.Sub(claim.Spec.Resources.Requests[corev1.ResourceStorage])
+		if growth.Sign() <= 0 {
+			continue
+		}
+		increase.Add(growth)
+
+		// Claims that grow have a storage class, checked above
+		className := *claim.Spec.StorageClassName
+		classIncrease := byClass[className]
+		classIncrease.Add(growth)
+		byClass[className] = classIncrease
+	}
+
+	if len(claims) > 0 {
+		if err := checkStorageQuota(ctx, k8sClient, claims[0].Namespace, increase, byClass); err != nil {
+			return resource.Quantity{}, err
+		}
+	}
+	return increase, nil
+}
+
+// checkStorageQuota rejects an increase of storage requests that a ResourceQuota of the
+// namespace would refuse, in total or for a storage class
+func checkStorageQuota(ctx context.Context, k8sClient *kubernetes.Client, namespace string, increase resource.Quantity, byClass map[string]resource.Quantity) error {
+	quotas, err := k8sClient.Clientset.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list resource quotas: %v", err)
+	}
+
+	limits := map[corev1.ResourceName]resource.Quantity{corev1.ResourceRequestsStorage: increase}
+	for className, classIncrease := range byClass {
+		limits[corev1.ResourceName(className+".storageclass.storage.k8s.io/requests.storage")] = classIncrease
+	}
+	for _, quota := range quotas.Items {
+		for name, needed := range limits {
+			hard, ok := quota.Status.Hard[name]
+			if !ok {
+				continue
+			}
+			used := quota.Status.Used[name]
+			after := used.DeepCopy()
+			after.Add(needed)
+			if after.Cmp(hard) > 0 {
+				return fmt.Errorf("resource quota %s allows %s of %s; %s is used and the expansion needs %s more",
+					quota.Name, hard.String(), name, used.String(), needed.String())
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandServiceClaims raises the storage request of each claim to size; claims already at
+// or above it are left alone
+func ExpandServiceClaims(ctx context.Context, k8sClient *kubernetes.Client, claims []corev1.PersistentVolumeClaim, size string) error {
+	requested, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid storage size %q: %v", size, err)
+	}
+
+	for _, claim := range claims {
+		existing := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		if requested.Cmp(existing) <= 0 {
+			continue
+		}
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = requested
+		if _, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Update(ctx, &claim, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to expand volume %s: %v", claim.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetClaimExpansionStatus reports how far a claim has grown to its requested size. The
+// volume is resized by the storage driver first; drivers that grow the filesystem on the
+// node then set FileSystemResizePending until a pod mounts the volume.
+func GetClaimExpansionStatus(claim corev1.PersistentVolumeClaim) models.StorageClaimStatus {
+	requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := claim.Status.Capacity[corev1.ResourceStorage]
+	status := models.StorageClaimStatus{
+		Name:     claim.Name,
+		Capacity: capacity.String(),
+		State:    models.StorageExpansionResizing,
+	}
+
+	for _, condition := range claim.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case corev1.PersistentVolumeClaimControllerResizeError, corev1.PersistentVolumeClaimNodeResizeError:
+			status.State = models.StorageExpansionFailed
+			status.Message = condition.Message
+			return status
+		case corev1.PersistentVolumeClaimFileSystemResizePending:
+			status.State = models.StorageExpansionFileSystemResizePending
+			status.Message = condition.Message
+			return status
+		case corev1.PersistentVolumeClaimResizing:
+			status.Message = condition.Message
+		}
+	}
+
+	if capacity.Cmp(requested) >= 0 {
+		status.State = models.StorageExpansionCompleted
+		status.Message = ""
+	}
+	return status
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -26,6 +28,9 @@ const (
 	defaultTCPProxyPortEnd   = 24999
 )
 
+// MaxAllowedCIDRs is the number of sources a service's TCP proxy allowlist may list
+const MaxAllowedCIDRs = 50
+
 type TCPProxyConfig struct {
 	Host      string
 	Namespace string
@@ -65,12 +70,31 @@ func EnsureTCPProxyExists(services []models.Service) error {
 		return fmt.Errorf("failed to ensure TCP proxy namespace: %w", err)
 	}
 
+	// The ingress controller may publish some ports itself; the proxy serves the rest
+	services, err = GetIngressProvider().ExposeTCP(ctx, client, cfg, services)
+	if err != nil {
+		return fmt.Errorf("failed to publish TCP ports through %s: %w", GetIngressProvider().Name(), err)
+	}
+
+	terminatesTLS := false
+	for _, service := range services {
+		if isTCPProxyService(service) && terminatesTLSAtProxy(service) {
+			terminatesTLS = true
+			break
+		}
+	}
+	if terminatesTLS {
+		if err := ensureTCPProxyCertificate(ctx, client, cfg); err != nil {
+			return err
+		}
+	}
+
 	configMap := createTCPProxyConfigMap(cfg, services)
 	if err := applyTCPProxyConfigMap(ctx, client, configMap); err != nil {
 		return err
 	}
 
-	deployment := createTCPProxyDeployment(cfg)
+	deployment := createTCPProxyDeployment(cfg, terminatesTLS)
 	if err := applyTCPProxyDeployment(ctx, client, deployment); err != nil {
 		return err
 	}
@@ -127,7 +151,14 @@ defaults
 		targetHost := fmt.Sprintf("%s.%s.svc.cluster.local", resourceName, service.EnvironmentID)
 
 		b.WriteString(fmt.Sprintf("frontend %s\n", frontendName))
-		b.WriteString(fmt.Sprintf("  bind *:%d\n", service.ExternalPort))
+		if terminatesTLSAtProxy(service) {
+			b.WriteString(fmt.Sprintf("  bind *:%d ssl crt %s\n", service.ExternalPort, tcpProxyCertPath))
+		} else {
+			b.WriteString(fmt.Sprintf("  bind *:%d\n", service.ExternalPort))
+		}
+		if allowed := GetExternalAllowedCIDRs(service); len(allowed) > 0 {
+			b.WriteString(fmt.Sprintf("  tcp-request connection reject unless { src %s }\n", strings.Join(allowed, " ")))
+		}
 		b.WriteString(fmt.Sprintf("  default_backend %s\n\n", backendName))
 		b.WriteString(fmt.Sprintf("backend %s\n", backendName))
 		b.WriteString(fmt.Sprintf("  server primary %s:%d check\n\n", targetHost, service.Port))
@@ -136,7 +167,7 @@ defaults
 	return b.String()
 }
 
-func createTCPProxyDeployment(cfg TCPProxyConfig) *appsv1.Deployment {
+func createTCPProxyDeployment(cfg TCPProxyConfig, terminatesTLS bool) *appsv1.Deployment {
 	replicas := int32(1)
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -186,6 +217,28 @@ func createTCPProxyDeployment(cfg TCPProxyConfig) *appsv1.Deployment {
 		},
 	}
 
+	if terminatesTLS {
+		// HAProxy loads the key from <crt>.key next to the certificate
+		spec := &deployment.Spec.Template.Spec
+		spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "tls",
+			MountPath: path.Dir(tcpProxyCertPath),
+			ReadOnly:  true,
+		})
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: "tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: getTCPProxyTLSSecretName(cfg),
+					Items: []corev1.KeyToPath{
+						{Key: "tls.crt", Path: path.Base(tcpProxyCertPath)},
+						{Key: "tls.key", Path: path.Base(tcpProxyCertPath) + ".key"},
+					},
+				},
+			},
+		})
+	}
+
 	SecurePodSpec(&deployment.Spec.Template.Spec)
 	return deployment
 }
@@ -215,7 +268,7 @@ func createTCPProxyService(cfg TCPProxyConfig, services []models.Service) *corev
 		return ports[i].Port < ports[j].Port
 	})
 
-	return &corev1.Service{
+	proxyService := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cfg.Name,
 			Namespace: cfg.Namespace,
@@ -227,6 +280,36 @@ func createTCPProxyService(cfg TCPProxyConfig, services []models.Service) *corev
 			Ports:    ports,
 		},
 	}
+
+	// Allowlists match the client address, which the load balancer only preserves when it
+	// sends traffic straight to the node running the proxy
+	for _, service := range services {
+		if isTCPProxyService(service) && len(GetExternalAllowedCIDRs(service)) > 0 {
+			proxyService.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+			break
+		}
+	}
+	return proxyService
+}
+
+// GetExternalAllowedCIDRs returns the sources allowed to reach the service through the TCP
+// proxy; empty allows any source
+func GetExternalAllowedCIDRs(service models.Service) []string {
+	var cidrs []string
+	for _, cidr := range strings.Split(service.ExternalAllowedCIDRs, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+// IsValidAllowedCIDR reports whether value is a CIDR or a single IP address
+func IsValidAllowedCIDR(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
 }
 
 func isTCPProxyService(service models.Service) bool {
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TenantIsolationPolicyName names the NetworkPolicy in each workload namespace
+	TenantIsolationPolicyName = "pendeploy-tenant-isolation"
+	// LabelTenantIsolation marks the NetworkPolicies of the tenant isolation
+	LabelTenantIsolation = "pendeploy.io/tenant-isolation"
+
+	namespaceNameLabel       = "kubernetes.io/metadata.name"
+	defaultPlatformNamespace = "kubesa-system"
+	// k3s defaults: pod network 10.42.0.0/16, Service network 10.43.0.0/16
+	defaultClusterCIDRs = "10.42.0.0/16,10.43.0.0/16"
+)
+
+// TenantIsolationConfig is what the isolation policy of workload namespaces keeps apart
+type TenantIsolationConfig struct {
+	// ProtectedNamespaces are the platform's namespaces workloads must not reach directly:
+	// the registry, the build namespace and the platform API
+	ProtectedNamespaces []string
+	// ClusterCIDRs are the pod and Service ranges. Workloads reach pods by namespace only, so
+	// the ranges are excluded from the address-based rule that allows the internet.
+	ClusterCIDRs []string
+}
+
+// GetPlatformNamespace returns the namespace the platform API runs in (PLATFORM_NAMESPACE,
+// default kubesa-system)
+func GetPlatformNamespace() string {
+	return getEnvString("PLATFORM_NAMESPACE", defaultPlatformNamespace)
+}
+
+// GetTenantIsolationConfig reads the protected namespaces and cluster ranges.
+// ISOLATION_PROTECTED_NAMESPACES adds namespaces to the platform's own and
+// ISOLATION_CLUSTER_CIDRS sets the ranges (default k3s's).
+func GetTenantIsolationConfig() TenantIsolationConfig {
+	protected := map[string]bool{
+		RegistryNamespace:             true,
+		GetJobNamespace():             true,
+		GetPlatformNamespace():        true,
+		GetTCPProxyConfig().Namespace: true,
+	}
+	for _, namespace := range strings.Split(os.Getenv("ISOLATION_PROTECTED_NAMESPACES"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			protected[namespace] = true
+		}
+	}
+
+	config := TenantIsolationConfig{}
+	for namespace := range protected {
+		config.ProtectedNamespaces = append(config.ProtectedNamespaces, namespace)
+	}
+	sort.Strings(config.ProtectedNamespaces)
+	for _, cidr := range strings.Split(getEnvString("ISOLATION_CLUSTER_CIDRS", defaultClusterCIDRs), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			config.ClusterCIDRs = append(config.ClusterCIDRs, cidr)
+		}
+	}
+	return config
+}
+
+// GetTenantIsolationInterval returns the minutes between isolation reconcile passes
+// (ISOLATION_RECONCILE_INTERVAL_MINUTES, default 5)
+func GetTenantIsolationInterval() int {
+	if minutes := getEnvInt("ISOLATION_RECONCILE_INTERVAL_MINUTES", 5); minutes > 0 {
+		return minutes
+	}
+	return 5
+}
+
+// IsProtectedNamespace reports whether a namespace belongs to the platform; workloads are
+// never isolated in them
+func (c TenantIsolationConfig) IsProtectedNamespace(namespace string) bool {
+	for _, protected := range c.ProtectedNamespaces {
+		if protected == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// createTenantIsolationPolicySpec builds the egress NetworkPolicy of a workload namespace:
+// pods may reach any namespace but the protected ones, and any address outside the cluster
+// ranges. Public endpoints of the platform stay reachable through the ingress controller,
+// whose namespace is not protected, as does cluster DNS.
+func createTenantIsolationPolicySpec(namespace string, config TenantIsolationConfig) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TenantIsolationPolicyName,
+			Namespace: namespace,
+			Labels:    map[string]string{LabelTenantIsolation: "true"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchExpressions: []metav1.LabelSelectorRequirement{{
+								Key:      namespaceNameLabel,
+								Operator: metav1.LabelSelectorOpNotIn,
+								Values:   config.ProtectedNamespaces,
+							}},
+						},
+					}},
+				},
+				{
+					To: []networkingv1.NetworkPolicyPeer{{
+						IPBlock: &networkingv1.IPBlock{
+							CIDR:   "0.0.0.0/0",
+							Except: config.ClusterCIDRs,
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// tenantIsolationChecksum fingerprints a policy spec, so outdated policies can be reported
+func tenantIsolationChecksum(spec networkingv1.NetworkPolicySpec) string {
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ApplyTenantIsolation creates or updates the isolation policy of a workload namespace. A
+// namespace that does not exist yet is left alone and reported as not applied.
+func ApplyTenantIsolation(namespace string, config TenantIsolationConfig) (bool, error) {
+	if config.IsProtectedNamespace(namespace) {
+		return false, fmt.Errorf("namespace %s belongs to the platform", namespace)
+	}
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return false, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = k8sClient.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+	}
+
+	policy := createTenantIsolationPolicySpec(namespace, config)
+	policies := k8sClient.Clientset.NetworkingV1().NetworkPolicies(namespace)
+	_, err = policies.Create(ctx, policy, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = policies.Update(ctx, policy, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to apply NetworkPolicy in %s: %v", namespace, err)
+	}
+	return true, nil
+}
+
+// RemoveTenantIsolation deletes the isolation policy of a namespace; it reports whether
+// there was one
+func RemoveTenantIsolation(namespace string) (bool, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return false, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	err = k8sClient.Clientset.NetworkingV1().NetworkPolicies(namespace).Delete(context.Background(), TenantIsolationPolicyName, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete NetworkPolicy in %s: %v", namespace, err)
+	}
+	log.Printf("Tenant isolation removed from namespace %s", namespace)
+	return true, nil
+}
+
+// TenantIsolationState is what the cluster holds for a workload namespace
+type TenantIsolationState struct {
+	Exists        bool
+	PolicyPresent bool
+	UpToDate      bool
+}
+
+// CheckTenantIsolation reads the isolation state of a workload namespace
+func CheckTenantIsolation(namespace string, config TenantIsolationConfig) (TenantIsolationState, error) {
+	var state TenantIsolationState
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return state, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = k8sClient.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+	}
+	state.Exists = true
+
+	live, err := k8sClient.Clientset.NetworkingV1().NetworkPolicies(namespace).Get(ctx, TenantIsolationPolicyName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to get NetworkPolicy in %s: %v", namespace, err)
+	}
+	state.PolicyPresent = true
+	expected := createTenantIsolationPolicySpec(namespace, config)
+	state.UpToDate = tenantIsolationChecksum(live.Spec) == tenantIsolationChecksum(expected.Spec)
+	return state, nil
+}
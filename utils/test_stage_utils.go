@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+	corev1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// TestContainerName is the init container of a build job that runs the service's TestCommand
+const TestContainerName = "run-tests"
+
+// TestLogEvent labels the test stage output in the build log stream
+const TestLogEvent = "test"
+
+// createTestContainer returns the init container that runs the service's TestCommand in
+// TestImage on the repository checkout, after the clone and before the build. A non-zero
+// exit fails the pod and with it the deployment. It gets the env vars passed to builds.
+func createTestContainer(service models.Service, workspaceVolume string) corev1.Container {
+	envVars := buildEnvVars(service)
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := []corev1.EnvVar{{Name: "CI", Value: "true"}}
+	for _, key := range keys {
+		env = append(env, corev1.EnvVar{Name: key, Value: envVars[key]})
+	}
+
+	return corev1.Container{
+		Name:       TestContainerName,
+		Image:      service.TestImage,
+		Command:    []string{"sh", "-c"},
+		Args:       []string{"echo '=== Running tests ==='\n" + service.TestCommand},
+		WorkingDir: "/workspace",
+		Env:        env,
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      workspaceVolume,
+				MountPath: "/workspace",
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("250m"),
+				corev1.ResourceMemory:           resource.MustParse("512Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("1000m"),
+				corev1.ResourceMemory:           resource.MustParse("2Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("4Gi"),
+			},
+		},
+	}
+}
+
+// TestContainerState returns the state of the test stage in a build pod, nil when the pod
+// has no test stage or it has not been reported yet
+func TestContainerState(pod corev1.Pod) *corev1.ContainerState {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == TestContainerName {
+			return &status.State
+		}
+	}
+	return nil
+}
+
+// testStageDuration returns how long the finished test stage of a build pod ran
+func testStageDuration(pod corev1.Pod) time.Duration {
+	state := TestContainerState(pod)
+	if state == nil || state.Terminated == nil {
+		return 0
+	}
+	return state.Terminated.FinishedAt.Sub(state.Terminated.StartedAt.Time)
+}
+
+// checkTestStage reports a failed test run, so the build error says the tests failed
+// rather than only that the pod did
+func checkTestStage(pod *corev1.Pod) error {
+	state := TestContainerState(*pod)
+	if state == nil || state.Terminated == nil || state.Terminated.ExitCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("tests failed with exit code %d", state.Terminated.ExitCode)
+}
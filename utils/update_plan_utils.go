@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"reflect"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/models"
+)
+
+// How the change of a service's config is rolled out
+const (
+	UpdateActionNone    = "none"    // only stored, e.g. a rename or a setting used by the next build
+	UpdateActionRestart = "restart" // the running image is rolled out again with the new config
+	UpdateActionRebuild = "rebuild" // a new image is built from the repository
+)
+
+// serviceFieldChange is one updatable field of a service and the action a change needs
+type serviceFieldChange struct {
+	name           string
+	action         string
+	current, value interface{}
+}
+
+// PlanServiceUpdate lists the fields that differ between the existing and the updated
+// service and the strongest action one of them needs
+func PlanServiceUpdate(existing, updated models.Service) dto.ServiceUpdatePlan {
+	plan := dto.ServiceUpdatePlan{Action: UpdateActionNone, ChangedFields: []string{}}
+
+	for _, field := range changedServiceFields(existing, updated) {
+		plan.ChangedFields = append(plan.ChangedFields, field.name)
+		if field.action == UpdateActionRebuild {
+			plan.RebuildFields = append(plan.RebuildFields, field.name)
+		}
+		if updateActionRank(field.action) > updateActionRank(plan.Action) {
+			plan.Action = field.action
+		}
+	}
+	return plan
+}
+
+// DiffRuntimeConfig lists the settings applied to the running workload, rather than baked
+// into the image, that differ between two services, e.g. the same service in two
+// environments. Env vars are compared separately with DiffEnvVars.
+func DiffRuntimeConfig(a, b models.Service) []dto.ConfigFieldDiff {
+	diffs := []dto.ConfigFieldDiff{}
+	for _, field := range changedServiceFields(a, b) {
+		switch {
+		case field.action != UpdateActionRestart,
+			field.name == "environmentId", field.name == "envVars", field.name == "secretEnvKeys":
+			continue
+		}
+		diffs = append(diffs, dto.ConfigFieldDiff{Field: field.name, A: field.current, B: field.value})
+	}
+	return diffs
+}
+
+// changedServiceFields returns the updatable fields whose value differs between the
+// existing and the updated service
+func changedServiceFields(existing, updated models.Service) []serviceFieldChange {
+	fields := []serviceFieldChange{
+		{"name", UpdateActionNone, existing.Name, updated.Name},
+		{"environmentId", UpdateActionRestart, existing.EnvironmentID, updated.EnvironmentID},
+		{"cpuLimit", UpdateActionRestart, existing.CPULimit, updated.CPULimit},
+		{"memoryLimit", UpdateActionRestart, existing.MemoryLimit, updated.MemoryLimit},
+		{"customDomain", UpdateActionRestart, existing.CustomDomain, updated.CustomDomain},
+		{"serviceAccountAnnotations", UpdateActionRestart, existing.ServiceAccountAnnotations, updated.ServiceAccountAnnotations},
+		{"podLabels", UpdateActionRestart, existing.PodLabels, updated.PodLabels},
+		{"podAnnotations", UpdateActionRestart, existing.PodAnnotations, updated.PodAnnotations},
+	}
+	if existing.Type == models.ServiceTypeGit {
+		envAction := UpdateActionRestart
+		if len(EnvVarsRequiringRebuild(existing, updated)) > 0 {
+			envAction = UpdateActionRebuild
+		}
+		fields = append(fields,
+			serviceFieldChange{"isStaticReplica", UpdateActionRestart, existing.IsStaticReplica, updated.IsStaticReplica},
+			serviceFieldChange{"replicas", UpdateActionRestart, existing.Replicas, updated.Replicas},
+			serviceFieldChange{"minReplicas", UpdateActionRestart, existing.MinReplicas, updated.MinReplicas},
+			serviceFieldChange{"maxReplicas", UpdateActionRestart, existing.MaxReplicas, updated.MaxReplicas},
+			serviceFieldChange{"highAvailability", UpdateActionRestart, existing.HighAvailability, updated.HighAvailability},
+			serviceFieldChange{"terminationGracePeriodSeconds", UpdateActionRestart, existing.TerminationGracePeriodSeconds, updated.TerminationGracePeriodSeconds},
+			serviceFieldChange{"preStopCommand", UpdateActionRestart, existing.PreStopCommand, updated.PreStopCommand},
+			serviceFieldChange{"command", UpdateActionRestart, existing.Command, updated.Command},
+			serviceFieldChange{"args", UpdateActionRestart, existing.Args, updated.Args},
+			serviceFieldChange{"workingDir", UpdateActionRestart, existing.WorkingDir, updated.WorkingDir},
+			serviceFieldChange{"port", UpdateActionRestart, existing.Port, updated.Port},
+			serviceFieldChange{"autoCorrectPort", UpdateActionNone, existing.AutoCorrectPort, updated.AutoCorrectPort},
+			serviceFieldChange{"tlsChallenge", UpdateActionRestart, existing.TLSChallenge, updated.TLSChallenge},
+			serviceFieldChange{"envVars", envAction, existing.EnvVars, updated.EnvVars},
+			serviceFieldChange{"secretEnvKeys", envAction, existing.SecretEnvKeys, updated.SecretEnvKeys},
+			serviceFieldChange{"buildEnvKeys", UpdateActionNone, existing.BuildEnvKeys, updated.BuildEnvKeys},
+			serviceFieldChange{"artifactPath", UpdateActionNone, existing.ArtifactPath, updated.ArtifactPath},
+			serviceFieldChange{"cloneDepth", UpdateActionNone, existing.CloneDepth, updated.CloneDepth},
+			serviceFieldChange{"buildTimeoutMinutes", UpdateActionNone, existing.BuildTimeoutMinutes, updated.BuildTimeoutMinutes},
+			serviceFieldChange{"maxImageSize", UpdateActionNone, existing.MaxImageSize, updated.MaxImageSize},
+			serviceFieldChange{"imageSizeBudgetAction", UpdateActionNone, existing.ImageSizeBudgetAction, updated.ImageSizeBudgetAction},
+			serviceFieldChange{"testCommand", UpdateActionNone, existing.TestCommand, updated.TestCommand},
+			serviceFieldChange{"testImage", UpdateActionNone, existing.TestImage, updated.TestImage},
+			serviceFieldChange{"buildBackend", UpdateActionNone, existing.BuildBackend, updated.BuildBackend},
+			serviceFieldChange{"branch", UpdateActionRebuild, existing.Branch, updated.Branch},
+			serviceFieldChange{"buildCommand", UpdateActionRebuild, existing.BuildCommand, updated.BuildCommand},
+			serviceFieldChange{"startCommand", UpdateActionRebuild, existing.StartCommand, updated.StartCommand},
+			serviceFieldChange{"buildPlatforms", UpdateActionRebuild, existing.BuildPlatforms, updated.BuildPlatforms},
+			serviceFieldChange{"buildSecrets", UpdateActionRebuild, existing.BuildSecrets, updated.BuildSecrets},
+			serviceFieldChange{"sparseCheckoutPaths", UpdateActionRebuild, existing.SparseCheckoutPaths, updated.SparseCheckoutPaths},
+			serviceFieldChange{"dockerfilePath", UpdateActionRebuild, existing.DockerfilePath, updated.DockerfilePath},
+			serviceFieldChange{"buildArgs", UpdateActionRebuild, existing.BuildArgs, updated.BuildArgs},
+		)
+	} else {
+		fields = append(fields,
+			serviceFieldChange{"version", UpdateActionRestart, existing.Version, updated.Version},
+			serviceFieldChange{"storageSize", UpdateActionRestart, existing.StorageSize, updated.StorageSize},
+			serviceFieldChange{"poolingEnabled", UpdateActionRestart, existing.PoolingEnabled, updated.PoolingEnabled},
+			serviceFieldChange{"poolMode", UpdateActionRestart, existing.PoolMode, updated.PoolMode},
+			serviceFieldChange{"poolSize", UpdateActionRestart, existing.PoolSize, updated.PoolSize},
+			serviceFieldChange{"maxClientConn", UpdateActionRestart, existing.MaxClientConn, updated.MaxClientConn},
+			serviceFieldChange{"vpaMode", UpdateActionRestart, existing.VPAMode, updated.VPAMode},
+			serviceFieldChange{"databaseTls", UpdateActionRestart, existing.DatabaseTLS, updated.DatabaseTLS},
+			// Enforced by the shared TCP proxy, which is reconfigured without restarting the service
+			serviceFieldChange{"externalAllowedCidrs", UpdateActionNone, existing.ExternalAllowedCIDRs, updated.ExternalAllowedCIDRs},
+			// Read by the version updater on its next run
+			serviceFieldChange{"autoUpdate", UpdateActionNone, existing.AutoUpdate, updated.AutoUpdate},
+			serviceFieldChange{"maintenanceWindow", UpdateActionNone, existing.MaintenanceWindow, updated.MaintenanceWindow},
+		)
+	}
+
+	var changed []serviceFieldChange
+	for _, field := range fields {
+		if isEmptyCollection(field.current) && isEmptyCollection(field.value) || reflect.DeepEqual(field.current, field.value) {
+			continue
+		}
+		changed = append(changed, field)
+	}
+	return changed
+}
+
+// isEmptyCollection treats nil and empty maps and lists alike, as both are stored as {} or []
+func isEmptyCollection(value interface{}) bool {
+	switch value := value.(type) {
+	case models.EnvVars:
+		return len(value) == 0
+	case models.ContainerArgs:
+		return len(value) == 0
+	}
+	return false
+}
+
+func updateActionRank(action string) int {
+	switch action {
+	case UpdateActionRestart:
+		return 1
+	case UpdateActionRebuild:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// uptimeProbeTimeout bounds a single uptime check
+const uptimeProbeTimeout = 10 * time.Second
+
+const (
+	// deploymentHealthCheckTimeout bounds how long the health check after a deployment
+	// waits for the ingress to route to the new pods
+	deploymentHealthCheckTimeout = time.Minute
+	// deploymentHealthCheckInterval is the pause between health check attempts
+	deploymentHealthCheckInterval = 5 * time.Second
+)
+
+// GetUptimeCheckURL returns the URL an uptime monitor requests: the path on the service's
+// generated hostname, which is always routed and has a certificate
+func GetUptimeCheckURL(service models.Service, path string) string {
+	host := service.Domain
+	if host == "" {
+		host = GetDefaultDomainName(service)
+	}
+	return fmt.Sprintf("https://%s%s", host, path)
+}
+
+// ProbeUptime requests the URL once. Responses below 400 count as up; redirects are not
+// followed so a login redirect still counts as the service responding.
+func ProbeUptime(url string) models.UptimeCheck {
+	client := &http.Client{
+		Timeout: uptimeProbeTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	check := models.UptimeCheck{CheckedAt: time.Now()}
+	start := time.Now()
+	resp, err := client.Get(url)
+	check.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	check.StatusCode = resp.StatusCode
+	check.Up = resp.StatusCode < http.StatusBadRequest
+	if !check.Up {
+		check.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return check
+}
+
+// CheckDeploymentHealth requests the root of the service's domain after a deployment. It
+// retries while the request fails or the ingress answers 502-504, which is expected until
+// the new pods are routed to, and returns the last attempt.
+func CheckDeploymentHealth(service models.Service) models.DeploymentHealthCheck {
+	url := GetUptimeCheckURL(service, "/")
+	deadline := time.Now().Add(deploymentHealthCheckTimeout)
+	for {
+		check := ProbeUptime(url)
+		healthCheck := models.DeploymentHealthCheck{
+			URL:        url,
+			StatusCode: check.StatusCode,
+			LatencyMs:  check.LatencyMs,
+			Error:      check.Error,
+			CheckedAt:  check.CheckedAt,
+		}
+		retry := check.StatusCode == 0 || (check.StatusCode >= http.StatusBadGateway && check.StatusCode <= http.StatusGatewayTimeout)
+		if !retry || time.Now().Add(deploymentHealthCheckInterval).After(deadline) {
+			return healthCheck
+		}
+		time.Sleep(deploymentHealthCheckInterval)
+	}
+}
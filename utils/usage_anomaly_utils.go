@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/models"
+)
+
+// Thresholds of usage anomaly detection
+const (
+	// UsageAnomalyRecentWindow is how much of the latest usage history is compared with the
+	// service's baseline, the history before it
+	UsageAnomalyRecentWindow = 30 * time.Minute
+	// usageAnomalyMinBaselineSamples is the history a service needs before its memory and CPU
+	// are judged, so new services are not flagged while they warm up
+	usageAnomalyMinBaselineSamples = 12
+	memoryGrowthFactor             = 1.5
+	memoryGrowthMinBytes           = 64 * 1024 * 1024 // ignore growth too small to matter
+	cpuSaturationRatio             = 0.9              // of the CPU limit
+	restartStormMinRestarts        = 5
+	restartStormFactor             = 3
+)
+
+// UsageAnomalyFinding is a departure of a service's recent usage from its baseline
+type UsageAnomalyFinding struct {
+	Kind     string
+	Message  string
+	Observed int64
+	Baseline int64
+}
+
+// GetUsageAnomalyBaseline returns how much usage history a service's baseline covers
+func GetUsageAnomalyBaseline() time.Duration {
+	days := getEnvInt("USAGE_ANOMALY_BASELINE_DAYS", 7)
+	if days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DetectUsageAnomalies compares a service's samples of the recent window with the ones before
+// it and reports sudden memory growth, CPU saturation and restart storms. It returns false when
+// there are no recent samples, e.g. the metrics API was unavailable, as nothing can be judged.
+func DetectUsageAnomalies(service models.Service, samples []models.ServiceUsageSample, now time.Time) ([]UsageAnomalyFinding, bool) {
+	recentSince := now.Add(-UsageAnomalyRecentWindow)
+	var baseline, recent []models.ServiceUsageSample
+	for _, sample := range samples {
+		if sample.SampledAt.Before(recentSince) {
+			baseline = append(baseline, sample)
+		} else {
+			recent = append(recent, sample)
+		}
+	}
+	if len(recent) == 0 {
+		return nil, false
+	}
+
+	var findings []UsageAnomalyFinding
+	for _, detect := range []func(models.Service, []models.ServiceUsageSample, []models.ServiceUsageSample) (UsageAnomalyFinding, bool){
+		detectMemoryGrowth, detectCPUSaturation, detectRestartStorm,
+	} {
+		if finding, found := detect(service, baseline, recent); found {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, true
+}
+
+// detectMemoryGrowth reports a recent per-pod memory average well above the baseline's 95th percentile
+func detectMemoryGrowth(service models.Service, baseline, recent []models.ServiceUsageSample) (UsageAnomalyFinding, bool) {
+	if len(baseline) < usageAnomalyMinBaselineSamples {
+		return UsageAnomalyFinding{}, false
+	}
+	memory := func(sample models.ServiceUsageSample) int64 { return sample.MaxPodMemoryUsed }
+	usual := samplePercentile(baseline, memory, 0.95)
+	current := sampleAverage(recent, memory)
+	if float64(current) < float64(usual)*memoryGrowthFactor || current-usual < memoryGrowthMinBytes {
+		return UsageAnomalyFinding{}, false
+	}
+
+	message := fmt.Sprintf("Memory per pod grew to %s in the last %d minutes, up from a usual %s",
+		FormatBytesToHumanReadable(current), int(UsageAnomalyRecentWindow.Minutes()), FormatBytesToHumanReadable(usual))
+	if limit, err := ParseResourceLimit("memory", service.MemoryLimit); err == nil && limit > 0 {
+		message += fmt.Sprintf(" (%d%% of the %s limit)", current*100/limit, service.MemoryLimit)
+	}
+	return UsageAnomalyFinding{
+		Kind:     models.UsageAnomalyMemoryGrowth,
+		Message:  message,
+		Observed: current,
+		Baseline: usual,
+	}, true
+}
+
+// detectCPUSaturation reports pods running at their CPU limit when they usually do not
+func detectCPUSaturation(service models.Service, baseline, recent []models.ServiceUsageSample) (UsageAnomalyFinding, bool) {
+	limit, err := ParseResourceLimit("cpu", service.CPULimit)
+	if err != nil || limit <= 0 || len(baseline) < usageAnomalyMinBaselineSamples {
+		return UsageAnomalyFinding{}, false
+	}
+	cpu := func(sample models.ServiceUsageSample) int64 { return sample.MaxPodCPUUsed }
+	usual := samplePercentile(baseline, cpu, 0.95)
+	current := sampleAverage(recent, cpu)
+	threshold := int64(float64(limit) * cpuSaturationRatio)
+	if current < threshold || usual >= threshold {
+		return UsageAnomalyFinding{}, false
+	}
+
+	return UsageAnomalyFinding{
+		Kind: models.UsageAnomalyCPUSaturation,
+		Message: fmt.Sprintf("CPU per pod averaged %dm of the %s limit in the last %d minutes, up from a usual %dm; the pods are likely being throttled",
+			current, service.CPULimit, int(UsageAnomalyRecentWindow.Minutes()), usual),
+		Observed: current,
+		Baseline: usual,
+	}, true
+}
+
+// detectRestartStorm reports container restarts in the recent window far above the
+// baseline's rate over a window of the same length
+func detectRestartStorm(service models.Service, baseline, recent []models.ServiceUsageSample) (UsageAnomalyFinding, bool) {
+	series := recent
+	if len(baseline) > 0 {
+		// Count the restarts since the last sample before the window too
+		series = append([]models.ServiceUsageSample{baseline[len(baseline)-1]}, recent...)
+	}
+	current := countRestarts(series)
+	if current < restartStormMinRestarts {
+		return UsageAnomalyFinding{}, false
+	}
+
+	var usual int64
+	if len(baseline) > 1 {
+		span := baseline[len(baseline)-1].SampledAt.Sub(baseline[0].SampledAt)
+		if span > 0 {
+			usual = int64(float64(countRestarts(baseline)) * float64(UsageAnomalyRecentWindow) / float64(span))
+		}
+	}
+	if current <= usual*restartStormFactor {
+		return UsageAnomalyFinding{}, false
+	}
+
+	return UsageAnomalyFinding{
+		Kind: models.UsageAnomalyRestartStorm,
+		Message: fmt.Sprintf("Containers restarted %d times in the last %d minutes, usually %d",
+			current, int(UsageAnomalyRecentWindow.Minutes()), usual),
+		Observed: current,
+		Baseline: usual,
+	}, true
+}
+
+// countRestarts adds up the increases of the restart count between consecutive samples.
+// The count drops when pods are replaced, which is not a restart.
+func countRestarts(samples []models.ServiceUsageSample) int64 {
+	var restarts int64
+	for i := 1; i < len(samples); i++ {
+		previous, current := samples[i-1].Restarts, samples[i].Restarts
+		if previous == nil || current == nil {
+			continue
+		}
+		if delta := *current - *previous; delta > 0 {
+			restarts += int64(delta)
+		}
+	}
+	return restarts
+}
+
+func sampleAverage(samples []models.ServiceUsageSample, value func(models.ServiceUsageSample) int64) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var total int64
+	for _, sample := range samples {
+		total += value(sample)
+	}
+	return total / int64(len(samples))
+}
+
+// samplePercentile returns the nearest-rank percentile of the values, e.g. 0.95
+func samplePercentile(samples []models.ServiceUsageSample, value func(models.ServiceUsageSample) int64, percentile float64) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]int64, len(samples))
+	for i, sample := range samples {
+		values[i] = value(sample)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := int(math.Ceil(float64(len(values))*percentile)) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetUsageSampleInterval returns how often cluster and service usage is recorded
+func GetUsageSampleInterval() time.Duration {
+	minutes := getEnvInt("USAGE_SAMPLE_INTERVAL_MINUTES", 5)
+	if minutes <= 0 {
+		minutes = 5
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetUsageSampleRetention returns how long usage history is kept
+func GetUsageSampleRetention() time.Duration {
+	days := getEnvInt("USAGE_SAMPLE_RETENTION_DAYS", 30)
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// CollectClusterUsage snapshots the allocatable, requested and used resources of the Ready,
+// schedulable nodes. Usage is left at zero when the metrics API or kubelet stats are
+// unavailable, so forecasts based on it are skipped rather than wrong.
+func CollectClusterUsage(ctx context.Context, k8sClient *kubernetes.Client) (models.ClusterUsageSample, error) {
+	sample := models.ClusterUsageSample{SampledAt: time.Now()}
+
+	nodes, err := k8sClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return sample, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := k8sClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return sample, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	schedulable := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if GetNodeStatus(node) != "Ready" || node.Spec.Unschedulable {
+			continue
+		}
+		schedulable[node.Name] = true
+		sample.Nodes++
+		sample.CPUAllocatable += node.Status.Allocatable.Cpu().MilliValue()
+		sample.MemoryAllocatable += node.Status.Allocatable.Memory().Value()
+	}
+	for i := range pods.Items {
+		if !schedulable[pods.Items[i].Spec.NodeName] {
+			continue
+		}
+		cpuRequest, _, memoryRequest, _ := GetContainerResourceTotals(&pods.Items[i])
+		sample.CPURequested += cpuRequest
+		sample.MemoryRequested += memoryRequest
+	}
+
+	if k8sClient.MetricsClient != nil {
+		if metrics, err := k8sClient.MetricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{}); err == nil {
+			for _, metric := range metrics.Items {
+				if schedulable[metric.Name] {
+					sample.CPUUsed += metric.Usage.Cpu().MilliValue()
+					sample.MemoryUsed += metric.Usage.Memory().Value()
+				}
+			}
+		}
+	}
+
+	for name := range schedulable {
+		used, capacity, err := getNodeFilesystemUsage(ctx, k8sClient, name)
+		if err != nil {
+			// A partial total would show up as a sudden drop in the trend
+			sample.StorageUsed, sample.StorageCapacity = 0, 0
+			break
+		}
+		sample.StorageUsed += used
+		sample.StorageCapacity += capacity
+	}
+
+	return sample, nil
+}
+
+// getNodeFilesystemUsage reads the node filesystem usage from the kubelet stats summary
+func getNodeFilesystemUsage(ctx context.Context, k8sClient *kubernetes.Client, nodeName string) (used, capacity int64, err error) {
+	raw, err := k8sClient.Clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(nodeName).Suffix("proxy/stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read kubelet stats of node %s: %v", nodeName, err)
+	}
+	var summary struct {
+		Node struct {
+			Fs *struct {
+				UsedBytes     int64 `json:"usedBytes"`
+				CapacityBytes int64 `json:"capacityBytes"`
+			} `json:"fs"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse kubelet stats of node %s: %v", nodeName, err)
+	}
+	if summary.Node.Fs == nil || summary.Node.Fs.CapacityBytes == 0 {
+		return 0, 0, fmt.Errorf("kubelet reported no filesystem stats for node %s", nodeName)
+	}
+	return summary.Node.Fs.UsedBytes, summary.Node.Fs.CapacityBytes, nil
+}
+
+// CollectServiceUsage snapshots the metrics-server usage and the container restarts of every
+// service's running pods, found through the service ownership label
+func CollectServiceUsage(ctx context.Context, k8sClient *kubernetes.Client) ([]models.ServiceUsageSample, error) {
+	if k8sClient.MetricsClient == nil {
+		return nil, fmt.Errorf("metrics API is not available")
+	}
+
+	pods, err := k8sClient.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: LabelServiceID,
+		FieldSelector: "status.phase=" + string(corev1.PodRunning),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	serviceByPod := make(map[string]string, len(pods.Items))
+	restartsByPod := make(map[string]int, len(pods.Items))
+	for _, pod := range pods.Items {
+		key := pod.Namespace + "/" + pod.Name
+		serviceByPod[key] = pod.Labels[LabelServiceID]
+		for _, status := range pod.Status.ContainerStatuses {
+			restartsByPod[key] += int(status.RestartCount)
+		}
+	}
+
+	podMetrics, err := k8sClient.MetricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{
+		LabelSelector: LabelServiceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %v", err)
+	}
+
+	now := time.Now()
+	byService := make(map[string]*models.ServiceUsageSample)
+	for _, metrics := range podMetrics.Items {
+		serviceID, ok := serviceByPod[metrics.Namespace+"/"+metrics.Name]
+		if !ok {
+			continue
+		}
+		sample := byService[serviceID]
+		if sample == nil {
+			sample = &models.ServiceUsageSample{ServiceID: serviceID, SampledAt: now, Restarts: new(int)}
+			byService[serviceID] = sample
+		}
+
+		// Sidecars are included, as in the pod stats
+		var cpu, memory int64
+		for _, container := range metrics.Containers {
+			cpu += container.Usage.Cpu().MilliValue()
+			memory += container.Usage.Memory().Value()
+		}
+		sample.Pods++
+		*sample.Restarts += restartsByPod[metrics.Namespace+"/"+metrics.Name]
+		sample.CPUUsed += cpu
+		sample.MemoryUsed += memory
+		if cpu > sample.MaxPodCPUUsed {
+			sample.MaxPodCPUUsed = cpu
+		}
+		if memory > sample.MaxPodMemoryUsed {
+			sample.MaxPodMemoryUsed = memory
+		}
+	}
+
+	samples := make([]models.ServiceUsageSample, 0, len(byService))
+	for _, sample := range byService {
+		samples = append(samples, *sample)
+	}
+	return samples, nil
+}
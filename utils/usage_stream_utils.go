@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/dto"
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetUsageStreamInterval returns how often the live usage stream polls the metrics API
+// (USAGE_STREAM_INTERVAL_SECONDS, default 5). metrics-server scrapes less often, so a sample
+// is only sent when it changed.
+func GetUsageStreamInterval() time.Duration {
+	seconds := getEnvInt("USAGE_STREAM_INTERVAL_SECONDS", 5)
+	if seconds <= 0 {
+		seconds = 5
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// SampleServiceUsage returns the current CPU and memory use of each running pod of a
+// service. Timestamp is the newest scrape among the pods.
+func SampleServiceUsage(ctx context.Context, k8sClient *kubernetes.Client, service models.Service) (dto.ServiceUsageSnapshot, error) {
+	snapshot := dto.ServiceUsageSnapshot{ServiceID: service.ID, Pods: []dto.PodUsageSample{}}
+	if k8sClient.MetricsClient == nil {
+		return snapshot, fmt.Errorf("metrics API is not available")
+	}
+
+	selector := ServiceOwnerSelector(service.ID)
+	pods, err := k8sClient.Clientset.CoreV1().Pods(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+		FieldSelector: "status.phase=" + string(corev1.PodRunning),
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to list pods: %v", err)
+	}
+	limits := make(map[string]dto.PodUsageSample, len(pods.Items))
+	for _, pod := range pods.Items {
+		var sample dto.PodUsageSample
+		for _, container := range pod.Spec.Containers {
+			sample.CPULimit += container.Resources.Limits.Cpu().MilliValue()
+			sample.MemoryLimit += container.Resources.Limits.Memory().Value()
+		}
+		limits[pod.Name] = sample
+	}
+
+	podMetrics, err := k8sClient.MetricsClient.MetricsV1beta1().PodMetricses(service.EnvironmentID).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to get pod metrics: %v", err)
+	}
+
+	for _, metrics := range podMetrics.Items {
+		sample, running := limits[metrics.Name]
+		if !running {
+			continue
+		}
+		sample.Pod = metrics.Name
+		// Sidecars are included, as in the pod stats
+		for _, container := range metrics.Containers {
+			sample.CPUMillicores += container.Usage.Cpu().MilliValue()
+			sample.MemoryBytes += container.Usage.Memory().Value()
+		}
+		snapshot.CPUMillicores += sample.CPUMillicores
+		snapshot.MemoryBytes += sample.MemoryBytes
+		snapshot.Pods = append(snapshot.Pods, sample)
+		if metrics.Timestamp.Time.After(snapshot.Timestamp) {
+			snapshot.Timestamp = metrics.Timestamp.Time
+		}
+	}
+	sort.Slice(snapshot.Pods, func(i, j int) bool { return snapshot.Pods[i].Pod < snapshot.Pods[j].Pod })
+	return snapshot, nil
+}
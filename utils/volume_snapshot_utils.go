@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// labelSnapshotReason records why a VolumeSnapshot was taken, e.g. pre-update
+	labelSnapshotReason = "pendeploy.io/snapshot-reason"
+	// volumeSnapshotReadyTimeout bounds how long a snapshot may take to become usable
+	volumeSnapshotReadyTimeout = 10 * time.Minute
+	// volumeSnapshotsKept is how many snapshots per service and reason are retained
+	volumeSnapshotsKept = 3
+)
+
+var volumeSnapshotGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+
+// SnapshotManagedServiceVolumes takes a CSI VolumeSnapshot of every data volume of a managed
+// service and waits until they are ready to use. The snapshot class comes from
+// VOLUME_SNAPSHOT_CLASS, or the cluster default when unset. Older snapshots taken for the
+// same reason are pruned. It returns the names of the new snapshots.
+func SnapshotManagedServiceVolumes(service models.Service, reason string) ([]string, error) {
+	client, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), volumeSnapshotReadyTimeout)
+	defer cancel()
+
+	owner, err := ensureServiceOwner(ctx, client, service)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fmt.Sprintf("app=%s", GetResourceName(service))
+	claims, err := client.Clientset.CoreV1().PersistentVolumeClaims(service.EnvironmentID).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes of %s: %v", service.Name, err)
+	}
+	if len(claims.Items) == 0 {
+		return nil, fmt.Errorf("%s has no data volumes to snapshot", service.Name)
+	}
+
+	snapshots := client.DynamicClient.Resource(volumeSnapshotGVR).Namespace(service.EnvironmentID)
+	stamp := time.Now().UTC().Format("20060102-150405")
+	var names []string
+	for _, claim := range claims.Items {
+		snapshot := buildVolumeSnapshot(service, claim.Name, fmt.Sprintf("%s-%s-%s", claim.Name, reason, stamp), reason)
+		setServiceOwner(snapshot, owner)
+		if _, err := snapshots.Create(ctx, snapshot, metav1.CreateOptions{}); err != nil {
+			return names, fmt.Errorf("failed to create VolumeSnapshot of %s (are CSI snapshots available?): %v", claim.Name, err)
+		}
+		names = append(names, snapshot.GetName())
+	}
+
+	for _, name := range names {
+		if err := waitForVolumeSnapshotReady(ctx, client, service.EnvironmentID, name); err != nil {
+			return names, err
+		}
+	}
+
+	pruneVolumeSnapshots(ctx, client, service, reason)
+	return names, nil
+}
+
+// buildVolumeSnapshot renders a VolumeSnapshot of a PersistentVolumeClaim
+func buildVolumeSnapshot(service models.Service, claimName, name, reason string) *unstructured.Unstructured {
+	labels := map[string]interface{}{labelSnapshotReason: reason}
+	for key, value := range GetResourceLabels(service) {
+		labels[key] = value
+	}
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": claimName},
+	}
+	if class := getEnvString("VOLUME_SNAPSHOT_CLASS", ""); class != "" {
+		spec["volumeSnapshotClassName"] = class
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": service.EnvironmentID,
+			"labels":    labels,
+		},
+		"spec": spec,
+	}}
+}
+
+// waitForVolumeSnapshotReady polls a VolumeSnapshot until the CSI driver reports it usable
+func waitForVolumeSnapshotReady(ctx context.Context, client *kubernetes.Client, namespace, name string) error {
+	snapshots := client.DynamicClient.Resource(volumeSnapshotGVR).Namespace(namespace)
+	for {
+		snapshot, err := snapshots.Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			if ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse"); ready {
+				return nil
+			}
+			if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && message != "" {
+				return fmt.Errorf("VolumeSnapshot %s failed: %s", name, message)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("VolumeSnapshot %s was not ready after %v", name, volumeSnapshotReadyTimeout)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// pruneVolumeSnapshots deletes all but the newest snapshots of each volume of a service
+// taken for the reason
+func pruneVolumeSnapshots(ctx context.Context, client *kubernetes.Client, service models.Service, reason string) {
+	snapshots := client.DynamicClient.Resource(volumeSnapshotGVR).Namespace(service.EnvironmentID)
+	selector := fmt.Sprintf("app=%s,%s=%s", GetResourceName(service), labelSnapshotReason, reason)
+	list, err := snapshots.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return
+	}
+
+	byClaim := map[string][]unstructured.Unstructured{}
+	for _, snapshot := range list.Items {
+		claim, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		byClaim[claim] = append(byClaim[claim], snapshot)
+	}
+	for _, items := range byClaim {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].GetCreationTimestamp().After(items[j].GetCreationTimestamp().Time)
+		})
+		// Pruning is best effort; leftovers are retried after the next snapshot
+		for i := volumeSnapshotsKept; i < len(items); i++ {
+			snapshots.Delete(ctx, items[i].GetName(), metav1.DeleteOptions{})
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pendeploy-simple/lib/kubernetes"
+	"github.com/pendeploy-simple/models"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Vertical Pod Autoscaler modes a managed service can request
+const (
+	VPAModeRecommend = "recommend" // updateMode Off: recommendations only, pods are left alone
+	VPAModeAuto      = "auto"      // updateMode Auto: pods are recreated with the recommended resources
+)
+
+const (
+	vpaMinAllowedCPU    = "25m"
+	vpaMinAllowedMemory = "64Mi"
+)
+
+var vpaGVR = schema.GroupVersionResource{Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers"}
+
+// IsValidVPAMode checks if the Vertical Pod Autoscaler mode is supported
+func IsValidVPAMode(mode string) bool {
+	return mode == VPAModeRecommend || mode == VPAModeAuto
+}
+
+// GetVPAResourceName returns the name of the service's VerticalPodAutoscaler
+func GetVPAResourceName(service models.Service) string {
+	return GetResourceName(service)
+}
+
+// buildVPA builds the VerticalPodAutoscaler targeting the managed service's workload
+func buildVPA(service models.Service) *unstructured.Unstructured {
+	updateMode := "Off"
+	if service.VPAMode == VPAModeAuto {
+		updateMode = "Auto"
+	}
+
+	labels := map[string]interface{}{}
+	for key, value := range GetResourceLabels(service) {
+		labels[key] = value
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata": map[string]interface{}{
+			"name":      GetVPAResourceName(service),
+			"namespace": service.EnvironmentID,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       GetManagedServiceType(service.ManagedType),
+				"name":       GetResourceName(service),
+			},
+			"updatePolicy": map[string]interface{}{
+				"updateMode": updateMode,
+				// Managed services run a single replica, which the updater skips by default
+				"minReplicas": int64(1),
+			},
+			"resourcePolicy": map[string]interface{}{
+				"containerPolicies": []interface{}{
+					map[string]interface{}{
+						"containerName":       "*",
+						"controlledResources": []interface{}{"cpu", "memory"},
+						"minAllowed": map[string]interface{}{
+							"cpu":    vpaMinAllowedCPU,
+							"memory": vpaMinAllowedMemory,
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// deployVPA creates or updates the VerticalPodAutoscaler of a managed service
+func deployVPA(ctx context.Context, client *kubernetes.Client, service models.Service, owner metav1.OwnerReference) error {
+	vpa := buildVPA(service)
+	setServiceOwner(vpa, owner)
+
+	vpas := client.DynamicClient.Resource(vpaGVR).Namespace(service.EnvironmentID)
+	existing, err := vpas.Get(ctx, vpa.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = vpas.Create(ctx, vpa, metav1.CreateOptions{})
+	case err == nil:
+		vpa.SetResourceVersion(existing.GetResourceVersion())
+		_, err = vpas.Update(ctx, vpa, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply VerticalPodAutoscaler %s (is the VPA installed in the cluster?): %v", vpa.GetName(), err)
+	}
+
+	log.Printf("VerticalPodAutoscaler %s applied in %s mode", vpa.GetName(), service.VPAMode)
+	return nil
+}
+
+// deleteVPA removes the VerticalPodAutoscaler of a managed service, if any
+func deleteVPA(ctx context.Context, client *kubernetes.Client, service models.Service) error {
+	name := GetVPAResourceName(service)
+
+	err := client.DynamicClient.Resource(vpaGVR).Namespace(service.EnvironmentID).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete VerticalPodAutoscaler %s: %v", name, err)
+	}
+	if err == nil {
+		log.Printf("VerticalPodAutoscaler %s deleted successfully", name)
+	}
+	return nil
+}
+
+// GetVPARecommendation reads the current recommendation from the service's VerticalPodAutoscaler
+func GetVPARecommendation(service models.Service) (*models.VPARecommendation, error) {
+	k8sClient, err := kubernetes.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+
+	recommendation := &models.VPARecommendation{
+		Mode:       service.VPAMode,
+		Containers: []models.VPAContainerRecommendation{},
+		CheckedAt:  time.Now(),
+	}
+
+	name := GetVPAResourceName(service)
+	vpa, err := k8sClient.DynamicClient.Resource(vpaGVR).Namespace(service.EnvironmentID).Get(context.Background(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		recommendation.Message = "the VerticalPodAutoscaler has not been created yet; redeploy the service"
+		return recommendation, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VerticalPodAutoscaler %s: %v", name, err)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+	for _, item := range containers {
+		container, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		containerName, _, _ := unstructured.NestedString(container, "containerName")
+		recommendation.Containers = append(recommendation.Containers, models.VPAContainerRecommendation{
+			ContainerName: containerName,
+			Target:        vpaResources(container, "target"),
+			LowerBound:    vpaResources(container, "lowerBound"),
+			UpperBound:    vpaResources(container, "upperBound"),
+		})
+	}
+	if len(recommendation.Containers) == 0 {
+		recommendation.Message = "the recommender is still collecting usage samples"
+	}
+	return recommendation, nil
+}
+
+// vpaResources reads one of the CPU/memory maps of a container recommendation
+func vpaResources(container map[string]interface{}, field string) models.VPAResources {
+	cpu, _, _ := unstructured.NestedString(container, field, "cpu")
+	memory, _, _ := unstructured.NestedString(container, field, "memory")
+	return models.VPAResources{CPU: cpu, Memory: memory}
+}
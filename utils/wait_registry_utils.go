@@ -11,7 +11,7 @@ import (
 )
 
 // waitForRegistryPod waits for a pod to be created and returns its name
-func WaitForRegistryPod(ctx context.Context, registry models.Registry, namespace string, clientset *kubernetes.Clientset) (string, error) {
+func WaitForRegistryPod(ctx context.Context, registry models.Registry, namespace string, clientset kubernetes.Interface) (string, error) {
 	labelSelector := fmt.Sprintf("app=registry,registry-id=%s", registry.ID)
 	
 	// Poll until a pod is found or timeout